@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/MRQ67/stackmatch-cli/internal/utils"
+	"github.com/MRQ67/stackmatch-cli/pkg/stackmatch"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+	"github.com/spf13/cobra"
+)
+
+var (
+	annotateRequired     []string
+	annotateOptional     []string
+	annotateUnclassified []string
+)
+
+var annotateCmd = &cobra.Command{
+	Use:   "annotate <env.json>",
+	Short: "Mark entries of an environment file as required or optional",
+	Long: `Classifies the entries of an environment file so a shared environment can
+tell must-haves from personal preference:
+
+  stackmatch annotate env.json --required git,go,docker --optional neovim
+
+Required entries fail 'check' when missing and are the only ones installed by
+'import --required-only'. Optional entries that are missing are reported as
+warnings. Entries never annotated are unclassified and behave as before.
+
+Names match an entry's name ignoring case or its tool ID (e.g. vscode for
+VS Code). Without flags, the current classification is listed.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		env, err := readEnvironmentFile(args[0])
+		if err != nil {
+			utils.ExitWithError(err)
+		}
+
+		if len(annotateRequired)+len(annotateOptional)+len(annotateUnclassified) == 0 {
+			printRequirements(env)
+			return
+		}
+		if err := checkAnnotateNames(); err != nil {
+			utils.ExitWithError(err)
+		}
+
+		changes := []struct {
+			names       []string
+			requirement types.Requirement
+		}{
+			{annotateUnclassified, types.Unclassified},
+			{annotateOptional, types.Optional},
+			{annotateRequired, types.Required},
+		}
+		for _, change := range changes {
+			if err := stackmatch.Annotate(env, change.names, change.requirement); err != nil {
+				utils.ExitWithError(fmt.Errorf("%s: %w", args[0], err))
+			}
+		}
+
+		if err := writeEnvironmentFile(env, args[0]); err != nil {
+			utils.ExitWithError(fmt.Errorf("could not write %s: %w", args[0], err))
+		}
+		fmt.Printf("Updated %s\n", args[0])
+		printRequirements(env)
+	},
+}
+
+// checkAnnotateNames rejects names given to more than one flag
+func checkAnnotateNames() error {
+	seen := make(map[string]string)
+	flags := map[string][]string{"required": annotateRequired, "optional": annotateOptional, "unclassified": annotateUnclassified}
+	for _, flag := range []string{"required", "optional", "unclassified"} {
+		for _, name := range flags[flag] {
+			id := types.CanonicalID(name)
+			if other, ok := seen[id]; ok && other != flag {
+				return fmt.Errorf("%s is listed in both --%s and --%s", name, other, flag)
+			}
+			seen[id] = flag
+		}
+	}
+	return nil
+}
+
+// printRequirements lists the classified entries of env
+func printRequirements(env *types.EnvironmentData) {
+	if len(env.Requirements) == 0 {
+		fmt.Println("No entries are classified; every entry is unclassified.")
+		return
+	}
+	names := make([]string, 0, len(env.Requirements))
+	for name := range env.Requirements {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tREQUIREMENT")
+	for _, name := range names {
+		fmt.Fprintf(w, "%s\t%s\n", name, env.Requirements[name])
+	}
+	w.Flush()
+}
+
+// requirementSuffix returns " (required)" or " (optional)" for classified
+// entries, to mark them in human-readable listings
+func requirementSuffix(r types.Requirement) string {
+	if r == types.Unclassified {
+		return ""
+	}
+	return " (" + r.String() + ")"
+}
+
+func init() {
+	annotateCmd.Flags().StringSliceVar(&annotateRequired, "required", nil, "Entries to mark as required (comma-separated)")
+	annotateCmd.Flags().StringSliceVar(&annotateOptional, "optional", nil, "Entries to mark as optional (comma-separated)")
+	annotateCmd.Flags().StringSliceVar(&annotateUnclassified, "unclassified", nil, "Entries whose classification to remove (comma-separated)")
+	rootCmd.AddCommand(annotateCmd)
+}
@@ -0,0 +1,184 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/MRQ67/stackmatch-cli/internal/utils"
+	"github.com/MRQ67/stackmatch-cli/pkg/audit"
+	"github.com/MRQ67/stackmatch-cli/pkg/cleanup"
+	"github.com/MRQ67/stackmatch-cli/pkg/config"
+	"github.com/MRQ67/stackmatch-cli/pkg/runner"
+	"github.com/spf13/cobra"
+)
+
+var (
+	auditSince string
+	auditArgv  bool
+)
+
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Show and verify the log of changes StackMatch made to this machine",
+	Long: `Every change StackMatch makes to this machine is appended to
+~/.stackmatch/audit.jsonl: packages installed, reinstalled, uninstalled,
+pinned and released, init lines added to shell rc files, crontab entries,
+git and language settings, services enabled and corepack. Each line holds
+the time, the user, the StackMatch command, the package and package manager,
+the exact arguments of every command run for the change and its result.
+
+Each line also holds the hash of the line before it, so a line edited or
+removed afterwards is found by 'stackmatch audit verify'.
+
+Writing the log never stops a change: when it can't be written, a warning
+says so and the change goes on unaudited.`,
+}
+
+var auditShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "List the changes StackMatch made, oldest first",
+	Long: `Lists the changes StackMatch made to this machine, oldest first.
+
+Use --since 7d (or 12h, 2w, 2026-10-01) to only list the recent ones, and
+--argv to print the commands run for each change.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		since, err := parseSince(auditSince, time.Now())
+		if err != nil {
+			utils.ExitWithError(err)
+		}
+		log := audit.NewLog(config.AuditLogFile())
+		entries, err := log.Read()
+		if err != nil {
+			utils.ExitWithError(fmt.Errorf("failed to read %s: %w; check it with 'stackmatch audit verify'", log.Path(), err))
+		}
+		var shown []audit.Entry
+		for _, entry := range entries {
+			if !entry.Time.Before(since) {
+				shown = append(shown, entry)
+			}
+		}
+		if len(shown) == 0 {
+			if len(entries) == 0 {
+				fmt.Println("No changes recorded yet.")
+			} else {
+				fmt.Printf("No changes recorded since %s; %d in total.\n", since.Local().Format("2006-01-02 15:04"), len(entries))
+			}
+			return
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "TIME\tUSER\tCOMMAND\tACTION\tPACKAGE\tMANAGER\tRESULT")
+		for _, entry := range shown {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", entry.Time.Local().Format("2006-01-02 15:04:05"), entry.User, entry.Command,
+				entry.Action, auditSubject(entry), orDash(entry.Manager), entry.Result)
+			if auditArgv {
+				for _, argv := range entry.Argv {
+					fmt.Fprintf(w, "\t\t\t\t  $ %s\t\t\n", strings.Join(argv, " "))
+				}
+			}
+		}
+		w.Flush()
+		for _, entry := range shown {
+			if entry.Error != "" {
+				fmt.Printf("\n%s %s %s failed: %s\n", entry.Time.Local().Format("2006-01-02 15:04:05"), entry.Action, auditSubject(entry), firstLine(entry.Error))
+			}
+		}
+	},
+}
+
+var auditVerifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Check that no entry of the log was edited or removed",
+	Long: `Checks the hash chain of ~/.stackmatch/audit.jsonl: every line must hold
+the hash of the line before it and match its own hash. Exits with status 1
+and names the first line that doesn't, which was edited, removed or
+reordered. Lines removed from the end of the log can't be detected.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		log := audit.NewLog(config.AuditLogFile())
+		count, err := log.Verify()
+		var chainErr *audit.ChainError
+		if errors.As(err, &chainErr) {
+			utils.ExitWithError(fmt.Errorf("%s was tampered with at %v; the %d entries before it are intact", log.Path(), err, count))
+		}
+		if err != nil {
+			utils.ExitWithError(err)
+		}
+		fmt.Printf("%s is intact: %d entries\n", log.Path(), count)
+	},
+}
+
+// parseSince returns the time --since names: an age before now such as
+// "7d" or "12h", or a date such as "2026-10-01". Empty means the beginning.
+func parseSince(since string, now time.Time) (time.Time, error) {
+	if since == "" {
+		return time.Time{}, nil
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, since, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	age, err := cleanup.ParseAge(since)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid --since %q: use an age like 7d or 12h, or a date like 2026-10-01", since)
+	}
+	return now.Add(-age), nil
+}
+
+// auditSubject returns what entry changed, for a column of 'audit show'
+func auditSubject(entry audit.Entry) string {
+	subject := entry.Package
+	if entry.Version != "" {
+		subject += " " + entry.Version
+	}
+	if entry.File != "" {
+		subject = strings.TrimSpace(subject + " " + entry.File)
+	}
+	return orDash(subject)
+}
+
+// orDash returns s, or "-" when it is empty
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// firstLine returns the first line of s
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return line
+}
+
+// startAudit has the changes cmd makes recorded in the audit log, and the
+// commands run for them. An entry that can't be written is reported once
+// on stderr and doesn't stop the command.
+func startAudit(cmd *cobra.Command) {
+	if _, ok := runner.Default.(audit.Runner); !ok {
+		runner.Default = audit.Runner{Runner: runner.Default}
+	}
+	logger := audit.NewLogger(audit.NewLog(config.AuditLogFile()), invocation.command)
+	var once sync.Once
+	logger.Failed = func(err error) {
+		once.Do(func() {
+			fmt.Fprintf(os.Stderr, "Warning: could not write the audit log, so changes are not recorded in it: %v\n", err)
+		})
+	}
+	cmd.SetContext(audit.WithLogger(cmd.Context(), logger))
+}
+
+func init() {
+	auditShowCmd.Flags().StringVar(&auditSince, "since", "", "Only list changes since an age like 7d or 12h, or a date like 2026-10-01")
+	auditShowCmd.Flags().BoolVar(&auditArgv, "argv", false, "Print the commands run for each change")
+	auditCmd.AddCommand(auditShowCmd)
+	auditCmd.AddCommand(auditVerifyCmd)
+	rootCmd.AddCommand(auditCmd)
+}
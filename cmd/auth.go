@@ -6,14 +6,13 @@ import (
 	"os"
 	"regexp"
 	"strings"
-	"syscall"
 	"time"
 
 	"github.com/spf13/cobra"
-	"golang.org/x/term"
 
 	"github.com/MRQ67/stackmatch-cli/pkg/auth"
 	"github.com/MRQ67/stackmatch-cli/pkg/supabase"
+	"github.com/MRQ67/stackmatch-cli/pkg/ui"
 )
 
 var (
@@ -50,24 +49,18 @@ var loginCmd = &cobra.Command{
 		}
 
 		// Prompt for email
-		fmt.Print("Email: ")
-		var email string
-		_, err := fmt.Scanln(&email)
+		email, err := ui.Ask("Email: ", "", nil)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error reading email: %v\n", err)
 			os.Exit(1)
 		}
 
 		// Prompt for password (hidden)
-		fmt.Print("Password: ")
-		bytePassword, err := term.ReadPassword(int(syscall.Stdin))
+		password, err := ui.AskPassword("Password: ", nil)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error reading password: %v\n", err)
 			os.Exit(1)
 		}
-		fmt.Println() // Print newline after password input
-
-		password := string(bytePassword)
 
 		// Use the global supabase client that was already initialized
 		if supabaseClient == nil {
@@ -148,58 +141,44 @@ var registerCmd = &cobra.Command{
 		}
 
 		// Prompt for email
-		var email string
-		for {
-			fmt.Print("Email: ")
-			_, err := fmt.Scanln(&email)
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "Error reading email: %v\n", err)
-				continue
-			}
+		email, err := ui.Ask("Email: ", "", func(answer string) error {
 			// Basic email validation
-			if !strings.Contains(email, "@") || !strings.Contains(email, ".") {
-				fmt.Fprintln(os.Stderr, "Please enter a valid email address")
-				continue
+			if !strings.Contains(answer, "@") || !strings.Contains(answer, ".") {
+				return fmt.Errorf("Please enter a valid email address")
 			}
-			break
+			return nil
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading email: %v\n", err)
+			os.Exit(1)
 		}
 
 		// Prompt for password (hidden)
-		var password string
-		for {
-			fmt.Print("Password (min 6 characters): ")
-			bytePassword, err := term.ReadPassword(int(syscall.Stdin))
-			fmt.Println() // Print newline after password input
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "Error reading password: %v\n", err)
-				continue
-			}
-			password = string(bytePassword)
-			if len(password) < 6 {
-				fmt.Fprintln(os.Stderr, "Password must be at least 6 characters long")
-				continue
+		password, err := ui.AskPassword("Password (min 6 characters): ", func(answer string) error {
+			if len(answer) < 6 {
+				return fmt.Errorf("Password must be at least 6 characters long")
 			}
-			break
+			return nil
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading password: %v\n", err)
+			os.Exit(1)
 		}
 
 		// Compile the username validation regex once
 		usernameRegex := regexp.MustCompile(`^[a-zA-Z0-9_]+$`)
 
 		// Prompt for username
-		var username string
-		for {
-			fmt.Print("Username (letters, numbers, and underscores only): ")
-			_, err := fmt.Scanln(&username)
-			if err != nil && err.Error() != "unexpected newline" {
-				fmt.Fprintf(os.Stderr, "Error reading username: %v\n", err)
-				continue
-			}
+		username, err := ui.Ask("Username (letters, numbers, and underscores only): ", "", func(answer string) error {
 			// Basic username validation
-			if !usernameRegex.MatchString(username) || username == "" {
-				fmt.Fprintln(os.Stderr, "Username can only contain letters, numbers, and underscores")
-				continue
+			if !usernameRegex.MatchString(answer) {
+				return fmt.Errorf("Username can only contain letters, numbers, and underscores")
 			}
-			break
+			return nil
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading username: %v\n", err)
+			os.Exit(1)
 		}
 
 		// Initialize auth service
@@ -241,11 +220,10 @@ var whoamiCmd = &cobra.Command{
 		// Show session info
 		if !user.ExpiresAt.IsZero() {
 			fmt.Printf("Session expires: %s\n", user.ExpiresAt.Format("2006-01-02 15:04:05"))
-			remaining := time.Until(user.ExpiresAt).Round(time.Minute)
-			if remaining > 0 {
-				fmt.Printf("Time remaining: %s\n", remaining)
+			if remaining := time.Until(user.ExpiresAt); remaining > 0 {
+				fmt.Printf("Time remaining: %s\n", ui.HumanDuration(remaining))
 			} else {
-				fmt.Println("Session has expired")
+				fmt.Printf("Session expired %s\n", ui.RelativeTime(user.ExpiresAt))
 			}
 		}
 
@@ -259,7 +237,7 @@ var whoamiCmd = &cobra.Command{
 					fmt.Printf("Email confirmed: %v\n", !supabaseUser.EmailConfirmedAt.IsZero())
 				}
 				if !supabaseUser.LastSignInAt.IsZero() {
-					fmt.Printf("Last sign in: %s\n", supabaseUser.LastSignInAt.Format("2006-01-02 15:04:05"))
+					fmt.Printf("Last sign in: %s (%s)\n", supabaseUser.LastSignInAt.Format("2006-01-02 15:04:05"), ui.RelativeTime(*supabaseUser.LastSignInAt))
 				}
 			}
 		}
@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/MRQ67/stackmatch-cli/internal/utils"
+	"github.com/MRQ67/stackmatch-cli/pkg/auth"
+	"github.com/MRQ67/stackmatch-cli/pkg/diff"
+	"github.com/MRQ67/stackmatch-cli/pkg/stackmatch"
+	"github.com/MRQ67/stackmatch-cli/pkg/supabase"
+)
+
+var (
+	logChangelog string
+	logFrom      int
+	logTo        int
+	logJSON      bool
+)
+
+// runChangelog prints the changelog of a stored environment between the
+// versions chosen with --from and --to, which default to the two latest
+func runChangelog(ctx context.Context, name string) {
+	user := auth.GetCurrentUser()
+	ref, err := parseEnvironmentRef(name, "", user)
+	if err != nil {
+		utils.ExitWithError(err)
+	}
+	var accessToken string
+	if user != nil {
+		accessToken = user.AccessToken
+	}
+	client, err := supabase.NewClient(cfg.SupabaseURL, cfg.SupabaseAPIKey, accessToken)
+	if err != nil {
+		utils.ExitWithError(fmt.Errorf("failed to initialize Supabase client: %w", err))
+	}
+
+	details, err := client.ShowEnvironment(ctx, ref, false)
+	if err != nil {
+		utils.ExitWithError(err)
+	}
+	versions, err := client.EnvironmentVersions(ctx, details.ID)
+	if err != nil {
+		utils.ExitWithError(err)
+	}
+	from, to, err := changelogVersions(versions, logFrom, logTo)
+	if err != nil {
+		utils.ExitWithError(fmt.Errorf("%s: %w", ref, err))
+	}
+	if from == 0 {
+		fmt.Printf("%s has only version %d; push it again to get a changelog.\n", ref, to)
+		return
+	}
+
+	fromSnapshot := fetchSnapshot(ctx, client, details.ID, ref, from)
+	toSnapshot := fetchSnapshot(ctx, client, details.ID, ref, to)
+	result := stackmatch.Diff(*fromSnapshot.Data, *toSnapshot.Data)
+	loadDiffRules().ApplyDiff(&result)
+	changelog := diff.NewChangelog(details.Name,
+		diff.ChangelogVersion{Version: from, PushedAt: fromSnapshot.CreatedAt},
+		diff.ChangelogVersion{Version: to, PushedAt: toSnapshot.CreatedAt},
+		&result)
+
+	if logJSON {
+		jsonData, err := json.MarshalIndent(changelog, "", "  ")
+		if err != nil {
+			utils.ExitWithError(fmt.Errorf("could not encode changelog: %w", err))
+		}
+		fmt.Println(string(jsonData))
+		return
+	}
+	changelog.WriteMarkdown(os.Stdout)
+}
+
+// changelogVersions picks the versions to compare among those recorded,
+// oldest first. to defaults to the latest and from to the one before to;
+// from is 0 when to is the only version.
+func changelogVersions(versions []int, from, to int) (int, int, error) {
+	if len(versions) == 0 {
+		return 0, 0, fmt.Errorf("no versions are recorded yet; history starts with the next push")
+	}
+	if to == 0 {
+		to = versions[len(versions)-1]
+	}
+	toIndex := slices.Index(versions, to)
+	if toIndex < 0 {
+		return 0, 0, fmt.Errorf("version %d not found; recorded versions are %s", to, formatVersions(versions))
+	}
+	if from == 0 {
+		if toIndex == 0 {
+			return 0, to, nil
+		}
+		return versions[toIndex-1], to, nil
+	}
+	if !slices.Contains(versions, from) {
+		return 0, 0, fmt.Errorf("version %d not found; recorded versions are %s", from, formatVersions(versions))
+	}
+	if from >= to {
+		return 0, 0, fmt.Errorf("--from %d must be older than --to %d", from, to)
+	}
+	return from, to, nil
+}
+
+// formatVersions lists versions, collapsing runs such as "1-4, 7"
+func formatVersions(versions []int) string {
+	var parts []string
+	for i := 0; i < len(versions); {
+		j := i
+		for j+1 < len(versions) && versions[j+1] == versions[j]+1 {
+			j++
+		}
+		if j > i {
+			parts = append(parts, fmt.Sprintf("%d-%d", versions[i], versions[j]))
+		} else {
+			parts = append(parts, strconv.Itoa(versions[i]))
+		}
+		i = j + 1
+	}
+	return strings.Join(parts, ", ")
+}
+
+// fetchSnapshot fetches a version listed as recorded, or exits
+func fetchSnapshot(ctx context.Context, client *supabase.Client, envID string, ref supabase.EnvironmentRef, version int) *supabase.Snapshot {
+	snapshot, err := client.EnvironmentSnapshot(ctx, envID, version)
+	if err != nil {
+		utils.ExitWithError(err)
+	}
+	if snapshot == nil {
+		utils.ExitWithError(fmt.Errorf("%s: version %d not found", ref, version))
+	}
+	return snapshot
+}
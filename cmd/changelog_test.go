@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestChangelogVersions(t *testing.T) {
+	testCases := []struct {
+		name     string
+		versions []int
+		from, to int
+		expected [2]int
+		errText  string
+	}{
+		{name: "two latest", versions: []int{1, 2, 4}, expected: [2]int{2, 4}},
+		{name: "before to", versions: []int{1, 2, 4}, to: 2, expected: [2]int{1, 2}},
+		{name: "both given", versions: []int{1, 2, 4}, from: 1, to: 4, expected: [2]int{1, 4}},
+		{name: "from only", versions: []int{1, 2, 4}, from: 1, expected: [2]int{1, 4}},
+		{name: "single version", versions: []int{1}, expected: [2]int{0, 1}},
+		{name: "no history", errText: "no versions are recorded yet"},
+		{name: "missing to", versions: []int{1, 2, 3, 7}, to: 5, errText: "version 5 not found; recorded versions are 1-3, 7"},
+		{name: "missing from", versions: []int{1, 3}, from: 2, errText: "version 2 not found; recorded versions are 1, 3"},
+		{name: "from after to", versions: []int{1, 2, 3}, from: 3, to: 2, errText: "--from 3 must be older than --to 2"},
+		{name: "same version", versions: []int{1, 2, 3}, from: 2, to: 2, errText: "must be older"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			from, to, err := changelogVersions(tc.versions, tc.from, tc.to)
+			if tc.errText != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.errText) {
+					t.Fatalf("expected an error containing %q but got %v", tc.errText, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if [2]int{from, to} != tc.expected {
+				t.Errorf("expected versions %v but got [%d %d]", tc.expected, from, to)
+			}
+		})
+	}
+}
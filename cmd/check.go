@@ -0,0 +1,250 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/MRQ67/stackmatch-cli/internal/utils"
+	"github.com/MRQ67/stackmatch-cli/pkg/diff"
+	"github.com/MRQ67/stackmatch-cli/pkg/stackmatch"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+	"github.com/MRQ67/stackmatch-cli/pkg/ui"
+	"github.com/MRQ67/stackmatch-cli/pkg/version"
+	"github.com/spf13/cobra"
+)
+
+var (
+	checkJSON      bool
+	checkPorcelain bool
+	checkExplain   []string
+)
+
+var checkCmd = &cobra.Command{
+	Use:   "check <env.json>",
+	Short: "Check this machine against an environment file",
+	Long: `Scans the local environment and reports whether every language, tool,
+package manager and editor recorded in the environment file is installed with
+an acceptable version. Exits with status 1 when anything is missing or mismatched,
+except for entries marked optional with 'annotate', which are only warned about.
+
+When --path points at a project, versions pinned by the project's build tool
+wrappers (Gradle, Maven) are compared instead of the globally installed ones.
+
+The environment may also be given as a .tool-versions, .nvmrc or similar
+version file, or a project directory containing them.
+
+Use --explain <tool> to see how a version was compared: the installed version
+as recorded, how it was normalized and parsed, and the result of each clause
+of the wanted constraint. JSON output always explains mismatched entries.
+
+The diff rules used by 'diff' (see 'stackmatch diff --help') also apply:
+unsatisfied entries they suppress don't fail the check.
+
+The SOURCE column shows how each installed entry got on this machine: the
+package manager owning it, a 'stackmatch import' that installed it, or
+"manual" when no package owns it. Entries whose sources disagree, such as an
+import recorded for a file no package owns, are flagged as conflicts.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		wanted, err := readEnvironmentSource(args[0])
+		if err != nil {
+			utils.ExitWithError(err)
+		}
+
+		installed, err := stackmatch.Scan(cmd.Context(), stackmatch.ScanOptions{ProjectPath: projectPath, LoginShellProbe: loginShellProbe})
+		if err != nil {
+			utils.ExitWithError(fmt.Errorf("scan failed: %w", err))
+		}
+
+		result := stackmatch.Check(installed, *wanted)
+		loadDiffRules().ApplyCheck(&result)
+		explained, err := explainItems(result, checkExplain)
+		if err != nil {
+			utils.ExitWithError(fmt.Errorf("%w in %s", err, args[0]))
+		}
+
+		switch {
+		case checkJSON:
+			jsonData, err := json.MarshalIndent(result, "", "  ")
+			if err != nil {
+				utils.ExitWithError(fmt.Errorf("could not encode check result: %w", err))
+			}
+			fmt.Println(string(jsonData))
+		case checkPorcelain:
+			writeCheckPorcelain(os.Stdout, result)
+		default:
+			writeCheckTable(os.Stdout, result)
+			printBrokenItems(result, installed)
+			printProvenanceConflicts(result)
+			printOptionalItems(result)
+			printNotes(result)
+			printSuppressed(os.Stdout, result.Suppressed, "unsatisfied entries")
+			for _, i := range explained {
+				writeExplanation(os.Stdout, result.Items[i])
+			}
+		}
+
+		recordCount("checked", len(result.Items))
+		if !result.Passed() {
+			recordInvocation(errCheckFailed)
+			os.Exit(1)
+		}
+	},
+}
+
+// printBrokenItems explains the broken entries of a check result on stderr
+func printBrokenItems(result stackmatch.CheckResult, installed types.EnvironmentData) {
+	for _, item := range result.Items {
+		if item.Status != diff.StatusBroken {
+			continue
+		}
+		failure := installed.BrokenTools[item.Name]
+		message := fmt.Sprintf("%s is on PATH but its version command exited with status %d", item.Name, failure.ExitStatus)
+		if failure.Output != "" {
+			message += ": " + failure.Output
+		}
+		fmt.Fprintln(os.Stderr, ui.Warning("Broken:")+" "+message)
+	}
+}
+
+// printProvenanceConflicts explains on stderr the entries whose sources
+// disagree on how they were installed
+func printProvenanceConflicts(result stackmatch.CheckResult) {
+	for _, item := range result.Items {
+		if item.Provenance != nil && item.Provenance.Conflict != "" {
+			fmt.Fprintln(os.Stderr, ui.Warning("Conflict:")+fmt.Sprintf(" %s: %s", item.Name, item.Provenance.Conflict))
+		}
+	}
+}
+
+// printOptionalItems explains on stderr that unsatisfied optional entries did
+// not fail the check
+func printOptionalItems(result stackmatch.CheckResult) {
+	for _, item := range result.Warnings() {
+		fmt.Fprintln(os.Stderr, ui.Warning("Optional:")+fmt.Sprintf(" %s is %s; optional entries do not fail the check", item.Name, item.Status))
+	}
+}
+
+// printNotes prints on stderr the notes about installed entries that may not
+// be the ones used
+func printNotes(result stackmatch.CheckResult) {
+	for _, note := range result.Notes {
+		fmt.Fprintln(os.Stderr, ui.Warning("Note:")+" "+note)
+	}
+}
+
+// explainItems attaches an explanation to the items named by names, matched
+// ignoring case, and returns their indexes
+func explainItems(result stackmatch.CheckResult, names []string) ([]int, error) {
+	var indexes []int
+	for _, name := range names {
+		found := false
+		for i, item := range result.Items {
+			if !strings.EqualFold(item.Name, name) {
+				continue
+			}
+			found = true
+			if item.Installed != "" && item.Explanation == nil && item.Category != types.CategoryLanguageConfig {
+				result.Items[i].Explanation = version.Explain(item.Installed, item.Wanted)
+			}
+			indexes = append(indexes, i)
+		}
+		if !found {
+			return nil, fmt.Errorf("%s is not a wanted entry", name)
+		}
+	}
+	return indexes, nil
+}
+
+// writeExplanation prints how the version of item was compared
+func writeExplanation(w io.Writer, item diff.CheckItem) {
+	fmt.Fprintf(w, "\n%s (wanted %s):\n", item.Name, orAny(item.Wanted))
+	e := item.Explanation
+	switch {
+	case item.Category == types.CategoryLanguageConfig && item.Status == diff.StatusMissing:
+		fmt.Fprintln(w, "  not set on this machine")
+		return
+	case item.Category == types.CategoryLanguageConfig:
+		fmt.Fprintf(w, "  set to %s; settings are compared exactly, not as versions\n", item.Installed)
+		return
+	case item.Status == diff.StatusMissing:
+		fmt.Fprintln(w, "  not installed, so no version was compared")
+		return
+	case item.Status == diff.StatusBroken:
+		fmt.Fprintf(w, "  installed %s, but its version command fails, so it is broken whatever version is wanted\n", item.Installed)
+		return
+	case e == nil:
+		return
+	}
+
+	fmt.Fprintf(w, "  installed:  %s\n", e.Raw)
+	if len(e.Normalizations) > 0 {
+		fmt.Fprintf(w, "  normalized: %s (%s)\n", e.Normalized, strings.Join(e.Normalizations, "; "))
+	}
+	if e.Parsed != nil {
+		parsed := fmt.Sprintf("major %d, minor %d, patch %d", e.Parsed.Major, e.Parsed.Minor, e.Parsed.Patch)
+		if e.Parsed.PreRelease != "" {
+			parsed += ", pre-release " + e.Parsed.PreRelease
+		}
+		if e.Parsed.Build != "" {
+			parsed += ", build " + e.Parsed.Build + " (ignored)"
+		}
+		fmt.Fprintf(w, "  parsed:     %s\n", parsed)
+	}
+	if e.ParseError != "" {
+		fmt.Fprintf(w, "  not a version: %s\n", e.ParseError)
+	}
+	for _, clause := range e.Clauses {
+		fmt.Fprintf(w, "  clause %s: %s\n", clause.Text, passFail(clause.Satisfied))
+		for _, c := range clause.Comparisons {
+			fmt.Fprintf(w, "    %s %s %s: %s\n", e.Parsed, c.Op, c.Target, passFail(c.Satisfied))
+		}
+		if clause.Pattern != "" {
+			fmt.Fprintf(w, "    %s matched against pattern %s\n", e.Parsed, clause.Pattern)
+		}
+		if clause.Error != "" {
+			fmt.Fprintf(w, "    %s\n", clause.Error)
+		}
+	}
+	if e.Note != "" {
+		fmt.Fprintf(w, "  %s\n", e.Note)
+	}
+	if e.Satisfied {
+		fmt.Fprintln(w, "  result: satisfied")
+	} else {
+		fmt.Fprintln(w, "  result: not satisfied")
+	}
+}
+
+// orAny names an empty wanted version
+func orAny(wanted string) string {
+	if wanted == "" {
+		return "any version"
+	}
+	return wanted
+}
+
+// passFail describes the outcome of a clause or comparison
+func passFail(ok bool) string {
+	if ok {
+		return "holds"
+	}
+	return "fails"
+}
+
+// errCheckFailed marks a check that ran but found problems
+var errCheckFailed = errors.New("check failed")
+
+func init() {
+	checkCmd.Flags().BoolVar(&checkJSON, "json", false, "Output the check result as JSON")
+	addPorcelainFlag(checkCmd, &checkPorcelain)
+	addDiffRulesFlags(checkCmd)
+	checkCmd.Flags().StringSliceVar(&checkExplain, "explain", nil, "Explain how the versions of these tools were compared")
+	checkCmd.Flags().StringVar(&projectPath, "path", "", "Project directory whose pinned tool versions should be checked")
+	checkCmd.Flags().BoolVar(&loginShellProbe, "login-shell-probe", false, "Retry tools missing from PATH through your login shell (for nvm, sdkman, rbenv...)")
+	rootCmd.AddCommand(checkCmd)
+}
@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/MRQ67/stackmatch-cli/internal/utils"
+	"github.com/MRQ67/stackmatch-cli/pkg/cleanup"
+	"github.com/MRQ67/stackmatch-cli/pkg/config"
+	"github.com/MRQ67/stackmatch-cli/pkg/ui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	cleanupCache     bool
+	cleanupSnapshots bool
+	cleanupReports   bool
+	cleanupAll       bool
+	cleanupOlderThan string
+	cleanupDryRun    bool
+)
+
+var cleanupCmd = &cobra.Command{
+	Use:   "cleanup",
+	Short: "Show and reclaim the space StackMatch uses under ~/.stackmatch",
+	Long: `Without flags, lists what StackMatch keeps in ~/.stackmatch with the size and
+number of items of each entry.
+
+With flags, deletes the selected artifacts and prints the space reclaimed:
+  --cache      cached data, fetched again when needed
+  --snapshots  environment snapshots
+  --reports    installation records of finished imports (see 'stackmatch history')
+  --all        all of the above, and the local usage statistics
+
+--older-than keeps anything more recent, such as --snapshots --older-than 30d.
+The session, configuration files and records of imports still in progress are
+always kept. Use --dry-run to see what would be removed.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		dir := config.StateDir()
+		opts := cleanup.Options{
+			Cache:     cleanupCache || cleanupAll,
+			Snapshots: cleanupSnapshots || cleanupAll,
+			Reports:   cleanupReports || cleanupAll,
+			Stats:     cleanupAll,
+			DryRun:    cleanupDryRun,
+		}
+		if cleanupOlderThan != "" {
+			age, err := cleanup.ParseAge(cleanupOlderThan)
+			if err != nil {
+				utils.ExitWithError(err)
+			}
+			opts.OlderThan = age
+		}
+
+		if !opts.Cache && !opts.Snapshots && !opts.Reports {
+			if cleanupOlderThan != "" || cleanupDryRun {
+				utils.ExitWithError(fmt.Errorf("select what to delete with --cache, --snapshots, --reports or --all"))
+			}
+			printCleanupReport(dir)
+			return
+		}
+
+		removals, err := cleanup.Clean(dir, opts)
+		printRemovals(removals, opts.DryRun)
+		if err != nil {
+			utils.ExitWithError(err)
+		}
+	},
+}
+
+// printCleanupReport lists the entries of the state directory dir
+func printCleanupReport(dir string) {
+	entries, err := cleanup.Report(dir)
+	if err != nil {
+		utils.ExitWithError(err)
+	}
+	if len(entries) == 0 {
+		fmt.Printf("%s is empty.\n", dir)
+		return
+	}
+
+	fmt.Printf("%s:\n\n", dir)
+	var total int64
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tKIND\tITEMS\tSIZE")
+	for _, entry := range entries {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\n", entry.Name, entry.Kind, entry.Items, ui.HumanSize(entry.Size))
+		total += entry.Size
+	}
+	w.Flush()
+	fmt.Printf("\nTotal: %s. Delete artifacts with --cache, --snapshots, --reports or --all.\n", ui.HumanSize(total))
+}
+
+// printRemovals lists what cleanup removed, grouped by kind, and the space
+// reclaimed
+func printRemovals(removals []cleanup.Removal, dryRun bool) {
+	if len(removals) == 0 {
+		fmt.Println("Nothing to remove.")
+		return
+	}
+
+	verb, total := "Removed", "Reclaimed"
+	if dryRun {
+		verb, total = "Would remove", "Would reclaim"
+	}
+	sizes := make(map[cleanup.Kind]int64)
+	items := make(map[cleanup.Kind]int)
+	var kinds []cleanup.Kind
+	var reclaimed int64
+	for _, removal := range removals {
+		if _, seen := items[removal.Kind]; !seen {
+			kinds = append(kinds, removal.Kind)
+		}
+		sizes[removal.Kind] += removal.Size
+		items[removal.Kind] += removal.Items
+		reclaimed += removal.Size
+	}
+	for _, kind := range kinds {
+		fmt.Printf("%s %s: %d item(s), %s\n", verb, kind, items[kind], ui.HumanSize(sizes[kind]))
+	}
+	fmt.Printf("%s %s.\n", total, ui.HumanSize(reclaimed))
+}
+
+func init() {
+	cleanupCmd.Flags().BoolVar(&cleanupCache, "cache", false, "Delete cached data")
+	cleanupCmd.Flags().BoolVar(&cleanupSnapshots, "snapshots", false, "Delete environment snapshots")
+	cleanupCmd.Flags().BoolVar(&cleanupReports, "reports", false, "Delete the records of finished installations")
+	cleanupCmd.Flags().BoolVar(&cleanupAll, "all", false, "Delete caches, snapshots, reports and usage statistics")
+	cleanupCmd.Flags().StringVar(&cleanupOlderThan, "older-than", "", "Only delete artifacts older than this, such as 30d, 2w or 12h")
+	cleanupCmd.Flags().BoolVar(&cleanupDryRun, "dry-run", false, "Show what would be removed without removing it")
+	rootCmd.AddCommand(cleanupCmd)
+}
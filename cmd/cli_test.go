@@ -1,9 +1,12 @@
 package cmd
 
 import (
-	"bytes"
+	"bufio"
 	"encoding/json"
 	"fmt"
+	"io"
+	"net/http"
+	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -11,6 +14,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/MRQ67/stackmatch-cli/pkg/exporter"
 	"github.com/MRQ67/stackmatch-cli/pkg/types"
 )
 
@@ -34,7 +38,9 @@ func TestMain(m *testing.M) {
 	}
 
 	// Build the CLI binary.
-	buildCmd := exec.Command("go", "build", "-o", cliBinaryPath, projectRoot)
+	// The testmocks tag lets tests swap in scripted commands and a fake
+	// Supabase backend (see harness_test.go); other tests run as before.
+	buildCmd := exec.Command("go", "build", "-tags", "testmocks", "-o", cliBinaryPath, projectRoot)
 	buildOutput, err := buildCmd.CombinedOutput()
 	if err != nil {
 		fmt.Printf("Failed to build CLI binary: %v\nOutput: %s\n", err, string(buildOutput))
@@ -50,28 +56,23 @@ func TestMain(m *testing.M) {
 	os.Exit(exitCode)
 }
 
-func extractJSONOutput(output []byte) ([]byte, error) {
-	// Find the start of JSON (first '{' character)
-	start := bytes.IndexByte(output, '{')
-	if start == -1 {
-		return nil, fmt.Errorf("no JSON data found in output")
+// runScan runs scan with args and returns its stdout, which holds the JSON
+// document and nothing else
+func runScan(t *testing.T, args ...string) []byte {
+	t.Helper()
+	output, err := exec.Command(cliBinaryPath, append([]string{"scan"}, args...)...).Output()
+	if err != nil {
+		var stderr []byte
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			stderr = exitErr.Stderr
+		}
+		t.Fatalf("failed to run scan command: %v\nOutput: %s%s", err, output, stderr)
 	}
-	// Return everything from the first '{' to the end
-	return output[start:], nil
+	return output
 }
 
 func TestScanCommand(t *testing.T) {
-	cmd := exec.Command(cliBinaryPath, "scan")
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		t.Fatalf("failed to run scan command: %v\nOutput: %s", err, string(output))
-	}
-
-	// Extract just the JSON part of the output
-	jsonOutput, err := extractJSONOutput(output)
-	if err != nil {
-		t.Fatalf("failed to extract JSON from output: %v\nOutput: %s", err, string(output))
-	}
+	jsonOutput := runScan(t)
 
 	var envData types.EnvironmentData
 	if err := json.Unmarshal(jsonOutput, &envData); err != nil {
@@ -91,16 +92,7 @@ func TestScanCommand(t *testing.T) {
 
 func TestImportCommand_DryRun(t *testing.T) {
 	// First, create a test environment file by running scan
-	scanCmd := exec.Command(cliBinaryPath, "scan")
-	scanOutput, err := scanCmd.CombinedOutput()
-	if err != nil {
-		t.Fatalf("failed to run scan command: %v\nOutput: %s", err, string(scanOutput))
-	}
-
-	// Extract just the JSON part of the output
-	if _, err := extractJSONOutput(scanOutput); err != nil {
-		t.Fatalf("failed to extract JSON from scan output: %v\nOutput: %s", err, string(scanOutput))
-	}
+	jsonOutput := runScan(t)
 
 	// Create a temporary file for the environment data
 	tempFile, err := os.CreateTemp("", "stackmatch-test-*.json")
@@ -109,12 +101,6 @@ func TestImportCommand_DryRun(t *testing.T) {
 	}
 	defer os.Remove(tempFile.Name())
 
-	// Write the JSON part of the output to the temp file
-	jsonOutput, err := extractJSONOutput(scanOutput)
-	if err != nil {
-		t.Fatalf("failed to extract JSON from scan output: %v\nOutput: %s", err, string(scanOutput))
-	}
-	
 	if _, err := tempFile.Write(jsonOutput); err != nil {
 		t.Fatalf("failed to write to temp file: %v", err)
 	}
@@ -220,3 +206,385 @@ func getProjectRoot() (string, error) {
 		wd = filepath.Dir(wd)
 	}
 }
+
+// TestExportMatchesScan verifies that export and scan go through the same scan
+// path and produce the same environment.
+func TestExportMatchesScan(t *testing.T) {
+	jsonOutput := runScan(t)
+	var scanned types.EnvironmentData
+	if err := json.Unmarshal(jsonOutput, &scanned); err != nil {
+		t.Fatalf("failed to unmarshal scan output: %v", err)
+	}
+
+	exportFile := filepath.Join(t.TempDir(), "env.json")
+	if output, err := exec.Command(cliBinaryPath, "export", exportFile).CombinedOutput(); err != nil {
+		t.Fatalf("failed to run export command: %v\nOutput: %s", err, string(output))
+	}
+	data, err := os.ReadFile(exportFile)
+	if err != nil {
+		t.Fatalf("failed to read exported file: %v", err)
+	}
+	var exported types.EnvironmentData
+	if err := json.Unmarshal(data, &exported); err != nil {
+		t.Fatalf("failed to unmarshal exported file: %v", err)
+	}
+
+	if scanned.System != exported.System {
+		t.Errorf("expected matching system info, scan=%+v export=%+v", scanned.System, exported.System)
+	}
+	if scanned.StackmatchVersion != exported.StackmatchVersion {
+		t.Errorf("expected matching version, scan=%q export=%q", scanned.StackmatchVersion, exported.StackmatchVersion)
+	}
+	if len(scanned.Tools) != len(exported.Tools) {
+		t.Errorf("expected %d tools in export, got %d", len(scanned.Tools), len(exported.Tools))
+	}
+	if scanned.Summary == nil || exported.Summary == nil {
+		t.Fatal("expected scan and export to include a summary")
+	}
+	if scanned.Summary.Fingerprint != exported.Summary.Fingerprint {
+		t.Errorf("expected matching fingerprints, scan=%s export=%s", scanned.Summary.Fingerprint, exported.Summary.Fingerprint)
+	}
+}
+
+// TestStatsNeverRecordValues verifies that usage statistics only hold command
+// names and counts, never arguments or flag values.
+func TestStatsNeverRecordValues(t *testing.T) {
+	const sentinel = "SENTINEL7f3a"
+	home := t.TempDir()
+
+	envFile := filepath.Join(home, "env-"+sentinel+".json")
+	if err := os.WriteFile(envFile, []byte(`{"stackmatch_version":"0.3.0","tools":{"Git":"2.40.0"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	runs := [][]string{
+		{"diff", "--json", envFile, envFile},
+		{"import", "--list-only", "--brew-prefix", "/opt/" + sentinel, envFile},
+	}
+	for _, args := range runs {
+		cmd := exec.Command(cliBinaryPath, args...)
+		cmd.Env = append(os.Environ(), "HOME="+home, "XDG_CONFIG_HOME="+home, "STACKMATCH_STATS=1")
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("%v failed: %v\nOutput: %s", args, err, output)
+		}
+	}
+
+	data, err := os.ReadFile(filepath.Join(home, ".stackmatch", "stats.jsonl"))
+	if err != nil {
+		t.Fatalf("expected a stats file: %v", err)
+	}
+	if strings.Contains(string(data), sentinel) {
+		t.Errorf("stats file contains an argument value:\n%s", data)
+	}
+
+	var commands []string
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var rec struct{ Command string }
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			t.Fatalf("invalid stats line %q: %v", line, err)
+		}
+		commands = append(commands, rec.Command)
+	}
+	if strings.Join(commands, ",") != "diff,import" {
+		t.Errorf("expected diff and import to be recorded, got %v", commands)
+	}
+}
+
+func TestImportRepair(t *testing.T) {
+	envFile := filepath.Join(t.TempDir(), "env.json")
+	content := "2026/10/16 09:12:44 Found Git version 2.43.0\n" +
+		`{"stackmatch_version": "0.3.0", "system": {"os": "linux", "arch": "amd64"}, "tools": {"Git": "2.43.0"}}`
+	if err := os.WriteFile(envFile, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := exec.Command(cliBinaryPath, "import", envFile).CombinedOutput()
+	if err == nil {
+		t.Fatalf("expected import to fail on a file with a log line preamble\nOutput: %s", output)
+	}
+	for _, s := range []string{"line 1, column 1", "Found Git version", "--repair"} {
+		if !strings.Contains(string(output), s) {
+			t.Errorf("expected error output to contain %q, got: %s", s, output)
+		}
+	}
+
+	output, err = exec.Command(cliBinaryPath, "import", "--repair", envFile).CombinedOutput()
+	if err != nil {
+		t.Fatalf("expected import --repair to succeed: %v\nOutput: %s", err, output)
+	}
+	if !strings.Contains(string(output), "Git: 2.43.0") {
+		t.Errorf("expected the repaired environment to be listed, got: %s", output)
+	}
+}
+
+func TestImportUnknownCategory(t *testing.T) {
+	envFile := filepath.Join("..", "pkg", "types", "testdata", "env_extensions.json")
+
+	output, err := exec.Command(cliBinaryPath, "import", envFile).CombinedOutput()
+	if err != nil {
+		t.Fatalf("expected import to accept an unknown category: %v\nOutput: %s", err, output)
+	}
+	for _, s := range []string{"databases (not installable by this version):", "Redis: 7.2.4", "Git: 2.45.0"} {
+		if !strings.Contains(string(output), s) {
+			t.Errorf("expected output to contain %q, got: %s", s, output)
+		}
+	}
+
+	output, err = exec.Command(cliBinaryPath, "diff", "--json", envFile, envFile).CombinedOutput()
+	if err != nil {
+		t.Fatalf("expected diff to accept an unknown category: %v\nOutput: %s", err, output)
+	}
+}
+
+func TestValidateExitCodes(t *testing.T) {
+	validFile := filepath.Join(t.TempDir(), "env.json")
+	content := `{"schema_version": 2, "stackmatch_version": "0.3.0", "scan_date": "2026-10-16T09:12:44Z",
+		"system": {"os": "linux", "arch": "amd64"}, "tools": {"Git": "2.43.0"}}`
+	if err := os.WriteFile(validFile, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name     string
+		args     []string
+		exitCode int
+		contains string
+	}{
+		{name: "Valid file", args: []string{validFile}, exitCode: 0, contains: "is a valid environment file"},
+		{name: "Semantic warning", args: []string{filepath.Join("..", "pkg", "types", "testdata", "env_v2.json")}, exitCode: 2, contains: "warning: /summary:"},
+		{name: "Schema error", args: []string{filepath.Join("..", "pkg", "types", "testdata", "env_extensions.json")}, exitCode: 1, contains: "error: /build_id: expected object but got string"},
+		{name: "Print schema", args: []string{"--print-schema"}, exitCode: 0, contains: `"$schema"`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			output, err := exec.Command(cliBinaryPath, append([]string{"validate"}, tc.args...)...).CombinedOutput()
+			exitCode := 0
+			if exitErr, ok := err.(*exec.ExitError); ok {
+				exitCode = exitErr.ExitCode()
+			} else if err != nil {
+				t.Fatalf("failed to run validate: %v", err)
+			}
+			if exitCode != tc.exitCode {
+				t.Errorf("expected exit code %d but got %d\nOutput: %s", tc.exitCode, exitCode, output)
+			}
+			if !strings.Contains(string(output), tc.contains) {
+				t.Errorf("expected output to contain %q, got: %s", tc.contains, output)
+			}
+		})
+	}
+}
+
+func TestImportVersionCompatibility(t *testing.T) {
+	dir := t.TempDir()
+	writeEnv := func(version string) string {
+		path := filepath.Join(dir, "env-"+version+".json")
+		content := fmt.Sprintf(`{"schema_version": 2, "stackmatch_version": %q, "system": {"os": "linux", "arch": "amd64"}, "tools": {"Git": "2.43.0"}}`, version)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	testCases := []struct {
+		name    string
+		version string
+		force   bool
+		wantErr bool
+		warning string
+	}{
+		{name: "Older release", version: "0.1.0"},
+		{name: "Newer minor", version: "0.9.0", warning: "written by stackmatch 0.9.0, newer than this release"},
+		{name: "Newer major", version: "1.0.0", wantErr: true, warning: "pass --force"},
+		{name: "Newer major with force", version: "1.0.0", force: true, warning: "newer major release"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			args := []string{"import", writeEnv(tc.version)}
+			if tc.force {
+				args = append(args, "--force")
+			}
+			output, err := exec.Command(cliBinaryPath, args...).CombinedOutput()
+			if tc.wantErr != (err != nil) {
+				t.Fatalf("expected failure to be %v but got %v\nOutput: %s", tc.wantErr, err, output)
+			}
+			if tc.warning == "" && strings.Contains(string(output), "Warning:") {
+				t.Errorf("expected no warning, got: %s", output)
+			}
+			if !strings.Contains(string(output), tc.warning) {
+				t.Errorf("expected output to contain %q, got: %s", tc.warning, output)
+			}
+		})
+	}
+}
+
+func TestServe(t *testing.T) {
+	home := t.TempDir()
+	envFile := filepath.Join(home, "env.json")
+	if err := os.WriteFile(envFile, []byte(`{"stackmatch_version": "0.3.0", "tools": {"StackMatchTestTool": "1.0.0"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command(cliBinaryPath, "serve", "--listen", "127.0.0.1:0")
+	cmd.Env = append(os.Environ(), "HOME="+home, "XDG_CONFIG_HOME="+home)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	})
+
+	var baseURL string
+	lines := bufio.NewScanner(stdout)
+	for lines.Scan() {
+		if addr, ok := strings.CutPrefix(lines.Text(), "Listening on "); ok {
+			baseURL = addr
+			break
+		}
+	}
+	if baseURL == "" {
+		t.Fatal("expected serve to print the address it listens on")
+	}
+	go io.Copy(io.Discard, stdout)
+
+	token, err := os.ReadFile(filepath.Join(home, ".stackmatch", "serve-token"))
+	if err != nil {
+		t.Fatalf("expected a token to be generated: %v", err)
+	}
+
+	get := func(path string, withToken bool) (int, []byte) {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodGet, baseURL+path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if withToken {
+			req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.StatusCode, body
+	}
+
+	if status, body := get("/healthz", false); status != http.StatusOK {
+		t.Errorf("expected /healthz to answer 200 but got %d: %s", status, body)
+	}
+	if status, _ := get("/scan", false); status != http.StatusUnauthorized {
+		t.Errorf("expected /scan without token to answer 401 but got %d", status)
+	}
+
+	status, body := get("/scan", true)
+	if status != http.StatusOK {
+		t.Fatalf("expected /scan to answer 200 but got %d: %s", status, body)
+	}
+	var env types.EnvironmentData
+	if err := json.Unmarshal(body, &env); err != nil {
+		t.Fatalf("expected an environment from /scan: %v\n%s", err, body)
+	}
+	if env.System.OS != runtime.GOOS {
+		t.Errorf("expected the scanned OS %s but got %q", runtime.GOOS, env.System.OS)
+	}
+
+	status, body = get("/diff?against="+url.QueryEscape(envFile), true)
+	if status != http.StatusOK || !strings.Contains(string(body), "StackMatchTestTool") {
+		t.Errorf("expected /diff to list the test tool but got %d: %s", status, body)
+	}
+}
+
+func TestAnnotateRequirements(t *testing.T) {
+	envFile := filepath.Join(t.TempDir(), "env.json")
+	content := `{"schema_version": 2, "stackmatch_version": "0.3.0", "scan_date": "2026-10-16T09:12:44Z",
+		"system": {"os": "linux", "arch": "amd64"}, "tools": {"Git": "Installed", "StackMatchTestTool": "1.0.0"}}`
+	if err := os.WriteFile(envFile, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	run := func(args ...string) (string, int) {
+		t.Helper()
+		output, err := exec.Command(cliBinaryPath, args...).CombinedOutput()
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return string(output), exitErr.ExitCode()
+		} else if err != nil {
+			t.Fatalf("failed to run %v: %v", args, err)
+		}
+		return string(output), 0
+	}
+
+	steps := []struct {
+		name     string
+		args     []string
+		exitCode int
+		contains string
+		excludes string
+	}{
+		{name: "Unclassified miss fails", args: []string{"check", envFile}, exitCode: 1},
+		{name: "Annotate", args: []string{"annotate", envFile, "--required", "git", "--optional", "stackmatchtesttool"}, contains: "StackMatchTestTool  optional"},
+		{name: "Optional miss warns", args: []string{"check", envFile}, contains: "StackMatchTestTool is missing; optional entries do not fail the check"},
+		{name: "Dry run marks entries", args: []string{"import", envFile}, contains: "StackMatchTestTool: 1.0.0 (optional)"},
+		{name: "Import required only", args: []string{"import", "--required-only", envFile}, contains: "Git: Installed (required)", excludes: "StackMatchTestTool"},
+		{name: "Unknown name", args: []string{"annotate", envFile, "--required", "emacs"}, exitCode: 1, contains: "no entry is named emacs"},
+		{name: "Conflicting flags", args: []string{"annotate", envFile, "--required", "git", "--optional", "Git"}, exitCode: 1, contains: "listed in both --required and --optional"},
+		{name: "Require the missing tool", args: []string{"annotate", envFile, "--required", "StackMatchTestTool"}},
+		{name: "Required miss fails", args: []string{"check", envFile}, exitCode: 1},
+		{name: "File stays valid", args: []string{"validate", envFile}, contains: "is a valid environment file"},
+	}
+
+	for _, step := range steps {
+		output, exitCode := run(step.args...)
+		if exitCode != step.exitCode {
+			t.Errorf("%s: expected exit code %d but got %d\nOutput: %s", step.name, step.exitCode, exitCode, output)
+		}
+		if !strings.Contains(output, step.contains) {
+			t.Errorf("%s: expected output to contain %q, got: %s", step.name, step.contains, output)
+		}
+		if step.excludes != "" && strings.Contains(output, step.excludes) {
+			t.Errorf("%s: expected output not to contain %q, got: %s", step.name, step.excludes, output)
+		}
+	}
+}
+
+// TestAnnotateKeepsFormat makes sure annotate writes a YAML file back as
+// YAML
+func TestAnnotateKeepsFormat(t *testing.T) {
+	envFile := filepath.Join(t.TempDir(), "env.yaml")
+	original := types.EnvironmentData{SchemaVersion: 2, StackmatchVersion: "0.3.0", System: types.SystemInfo{OS: "linux", Arch: "amd64"}, Tools: map[string]string{"Git": "2.43.0"}}
+	if err := exporter.Write(original, envFile, exporter.FormatYAML); err != nil {
+		t.Fatal(err)
+	}
+
+	if output, err := exec.Command(cliBinaryPath, "annotate", envFile, "--required", "git").CombinedOutput(); err != nil {
+		t.Fatalf("annotate failed: %v\nOutput: %s", err, output)
+	}
+
+	content, err := os.ReadFile(envFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if format := exporter.DetectFormat("", content); format != exporter.FormatYAML {
+		t.Fatalf("expected the file to stay YAML but got %s:\n%s", format, content)
+	}
+	data, err := exporter.ToJSON(content, exporter.FormatYAML)
+	if err != nil {
+		t.Fatalf("could not read the file back: %v", err)
+	}
+	var env types.EnvironmentData
+	if err := json.Unmarshal(data, &env); err != nil {
+		t.Fatal(err)
+	}
+	if env.Requirements["Git"] != types.Required || env.Tools["Git"] != "2.43.0" {
+		t.Errorf("expected Git to be kept and marked required but got %+v", env)
+	}
+}
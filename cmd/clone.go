@@ -9,6 +9,7 @@ import (
 
 	"github.com/MRQ67/stackmatch-cli/pkg/auth"
 	"github.com/MRQ67/stackmatch-cli/pkg/supabase"
+	"github.com/MRQ67/stackmatch-cli/pkg/ui"
 	"github.com/spf13/cobra"
 )
 
@@ -44,6 +45,7 @@ Format should be 'username/env-name'.`,
 		if err != nil {
 			log.Fatalf("Failed to find environment: %v", err)
 		}
+		checkCompatibility(sourceEnv)
 
 		// Get the current user from the session
 		user := auth.GetCurrentUser()
@@ -64,7 +66,7 @@ Format should be 'username/env-name'.`,
 		if cloneListOnly {
 			fmt.Printf("Environment: %s\n", envName)
 			fmt.Printf("Owner: %s\n", username)
-			fmt.Printf("Size: %d bytes\n", len(envJSON))
+			fmt.Printf("Size: %s\n", ui.HumanSize(int64(len(envJSON))))
 			return
 		}
 
@@ -75,5 +77,6 @@ Format should be 'username/env-name'.`,
 
 func init() {
 	cloneCmd.Flags().BoolVarP(&cloneListOnly, "list-only", "l", false, "Only list environment details without cloning")
+	cloneCmd.Flags().BoolVar(&forceNewer, "force", false, "Use environments written by a newer major release of stackmatch")
 	rootCmd.AddCommand(cloneCmd)
 }
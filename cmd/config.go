@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/MRQ67/stackmatch-cli/internal/utils"
+	"github.com/MRQ67/stackmatch-cli/pkg/config"
+	"github.com/MRQ67/stackmatch-cli/pkg/stackmatch"
+	"github.com/spf13/cobra"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Show StackMatch configuration",
+	Long:  `Shows configuration StackMatch reads from ~/.stackmatch.`,
+}
+
+var configProfilesCmd = &cobra.Command{
+	Use:   "profiles",
+	Short: "List the profiles 'export --profile' can filter with",
+	Long: `Lists the built-in export profiles and those defined in
+~/.stackmatch/profiles.yaml, which look like:
+
+  profiles:
+    bootstrap:
+      description: What every new hire needs on day one
+      categories: [languages, editors]
+      tools: [git, docker, kubectl]
+
+A profile includes its categories whole, plus the listed tools from whichever
+category holds them. A profile in the file replaces a built-in one of the
+same name.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		profiles, err := stackmatch.LoadProfiles(config.ProfilesFile())
+		if err != nil {
+			utils.ExitWithError(err)
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tCATEGORIES\tTOOLS\tDESCRIPTION")
+		for _, profile := range profiles {
+			description := profile.Description
+			if profile.BuiltIn {
+				description += " (built in)"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", profile.Name, orNone(profile.Categories), orNone(profile.Tools), strings.TrimSpace(description))
+		}
+		w.Flush()
+	},
+}
+
+// orNone joins names with commas, or returns "-" when there are none
+func orNone(names []string) string {
+	if len(names) == 0 {
+		return "-"
+	}
+	return strings.Join(names, ",")
+}
+
+func init() {
+	configCmd.AddCommand(configProfilesCmd)
+	rootCmd.AddCommand(configCmd)
+}
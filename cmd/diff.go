@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/MRQ67/stackmatch-cli/internal/utils"
+	"github.com/MRQ67/stackmatch-cli/pkg/stackmatch"
+	"github.com/spf13/cobra"
+)
+
+var (
+	diffJSON      bool
+	diffPorcelain bool
+)
+
+var diffCmd = &cobra.Command{
+	Use:   "diff <from.json> <to.json>",
+	Short: "Show the differences between two environment files",
+	Long: `Compares two environment files produced by 'export' or 'scan' and lists
+every entry that was added, removed or changed between them.
+
+Differences you don't care about, such as patch bumps of an editor or the
+hostname, can be suppressed with rules in ~/.stackmatch/diffrules.yaml (or
+the file given with --rules), or with --ignore and --ignore-category:
+
+  rules:
+    - name: VS Code
+      ignore: patch      # all (default), minor or patch
+    - category: editors
+    - category: system
+      name: hostname
+
+The same rules apply to 'check'.
+
+When only one of the files is a fast scan ('scan --fast'), the tools it can't
+see and the versions its package databases record in another form are left
+out, and counted at the end. So are the entries a scan limited with
+'scan --manifest' didn't look for because its manifest doesn't list them.`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		from, err := readEnvironmentFile(args[0])
+		if err != nil {
+			utils.ExitWithError(err)
+		}
+		to, err := readEnvironmentFile(args[1])
+		if err != nil {
+			utils.ExitWithError(err)
+		}
+
+		result := stackmatch.Diff(*from, *to)
+		loadDiffRules().ApplyDiff(&result)
+
+		if diffJSON {
+			jsonData, err := json.MarshalIndent(result, "", "  ")
+			if err != nil {
+				utils.ExitWithError(fmt.Errorf("could not encode diff: %w", err))
+			}
+			fmt.Println(string(jsonData))
+			return
+		}
+		if diffPorcelain {
+			writeDiffPorcelain(os.Stdout, result.Changes)
+			return
+		}
+
+		if result.Empty() {
+			fmt.Println("No differences found.")
+			printSuppressed(os.Stdout, result.Suppressed, "differences")
+			printFastScanOmitted(os.Stdout, result.FastScan)
+			printManifestScanOmitted(os.Stdout, result.ManifestScan)
+			return
+		}
+
+		writeChanges(os.Stdout, result.Changes)
+		printSuppressed(os.Stdout, result.Suppressed, "differences")
+		printFastScanOmitted(os.Stdout, result.FastScan)
+		printManifestScanOmitted(os.Stdout, result.ManifestScan)
+	},
+}
+
+// printFastScanOmitted notes how many differences were left out because one
+// of the environments is a fast scan
+func printFastScanOmitted(w io.Writer, omitted int) {
+	if omitted > 0 {
+		fmt.Fprintf(w, "\n%d differences left out: only one environment is a fast scan, which reads versions from package databases and misses tools installed without one\n", omitted)
+	}
+}
+
+// printManifestScanOmitted notes how many differences were left out because
+// an environment is a scan limited to a manifest
+func printManifestScanOmitted(w io.Writer, omitted int) {
+	if omitted > 0 {
+		fmt.Fprintf(w, "\n%d differences left out: the entries were not looked for by a scan limited with --manifest\n", omitted)
+	}
+}
+
+func init() {
+	diffCmd.Flags().BoolVar(&diffJSON, "json", false, "Output the differences as JSON")
+	addPorcelainFlag(diffCmd, &diffPorcelain)
+	addDiffRulesFlags(diffCmd)
+	rootCmd.AddCommand(diffCmd)
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/MRQ67/stackmatch-cli/internal/utils"
+	"github.com/MRQ67/stackmatch-cli/pkg/config"
+	"github.com/MRQ67/stackmatch-cli/pkg/diff"
+	"github.com/spf13/cobra"
+)
+
+var (
+	diffRulesFile    string
+	noDiffRules      bool
+	ignoreRules      []string
+	ignoreCategories []string
+)
+
+// addDiffRulesFlags registers the flags selecting the diff rules of cmd
+func addDiffRulesFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&diffRulesFile, "rules", "", "Diff rules file (default ~/.stackmatch/diffrules.yaml)")
+	cmd.Flags().BoolVar(&noDiffRules, "no-rules", false, "Ignore the diff rules file")
+	cmd.Flags().StringSliceVar(&ignoreRules, "ignore", nil, "Suppress differences of [category/]name[=patch|minor], e.g. \"VS Code=patch\" or hostname")
+	cmd.Flags().StringSliceVar(&ignoreCategories, "ignore-category", nil, "Suppress every difference in these categories")
+	cmd.MarkFlagsMutuallyExclusive("rules", "no-rules")
+}
+
+// loadDiffRules returns the rules of the rules file extended by those given
+// with --ignore and --ignore-category. Invalid rules are fatal: silently
+// dropping one would show or hide differences the user didn't expect.
+func loadDiffRules() *diff.Rules {
+	rules := &diff.Rules{}
+	if !noDiffRules {
+		path, required := diffRulesFile, true
+		if path == "" {
+			path, required = config.DiffRulesFile(), false
+		}
+		loaded, err := diff.LoadRules(path, required)
+		if err != nil {
+			utils.ExitWithError(err)
+		}
+		rules = loaded
+	}
+
+	for _, value := range ignoreRules {
+		rule, err := diff.ParseRuleFlag(value)
+		if err != nil {
+			utils.ExitWithError(fmt.Errorf("--ignore: %w", err))
+		}
+		rules.Add(rule)
+	}
+	for _, category := range ignoreCategories {
+		rules.Add(diff.Rule{Category: category, Ignore: diff.IgnoreAll})
+	}
+	return rules
+}
+
+// printSuppressed notes how many differences the diff rules left out
+func printSuppressed(w io.Writer, suppressed int, what string) {
+	if suppressed > 0 {
+		fmt.Fprintf(w, "\n%d %s suppressed by diff rules (--no-rules to show them)\n", suppressed, what)
+	}
+}
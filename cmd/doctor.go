@@ -0,0 +1,193 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/MRQ67/stackmatch-cli/internal/utils"
+	"github.com/MRQ67/stackmatch-cli/pkg/config"
+	"github.com/MRQ67/stackmatch-cli/pkg/installer"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+	"github.com/spf13/cobra"
+)
+
+var (
+	doctorDryRun bool
+	doctorJSON   bool
+)
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check the package managers StackMatch can use and where it keeps its files",
+	Long: `Prints the state directory StackMatch uses and every package manager import
+considers on this OS: whether it was found on PATH and, when it was found
+but can't be used, why and how to fix it (a locked dpkg database, a
+PowerShell execution policy that blocks Scoop, snapd not running, no
+package sources). Use --json to print that report for scripts.
+
+It also moves files StackMatch no longer reads where they are into the state
+directory:
+  config-dir  the config.json older releases kept in your OS configuration
+              directory (~/.config/stackmatch on Linux)
+  temp-state  state kept in the temp directory while the home directory
+              couldn't be written
+
+Every command moves these files the first time it runs and says what it
+moved; each file is copied and checked before the original is removed, and
+a file the state directory already has is left where it is. Use --dry-run
+to list what would be moved without moving anything.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		managers := installer.ProbePackageManagers(cmd.Context())
+		if doctorJSON {
+			jsonData, err := json.MarshalIndent(managers, "", "  ")
+			if err != nil {
+				utils.ExitWithError(fmt.Errorf("could not encode the package manager report: %w", err))
+			}
+			fmt.Println(string(jsonData))
+			return
+		}
+
+		location := config.ResolveState()
+		fmt.Printf("State directory: %s\n", location.Dir)
+		if location.Warning != "" {
+			fmt.Printf("  %s\n", location.Warning)
+		}
+		fmt.Println()
+		writeProbeReport(os.Stdout, managers)
+		fmt.Println()
+
+		if doctorDryRun || !location.Writable {
+			pending, err := config.PendingMigrations()
+			if err != nil {
+				utils.ExitWithError(err)
+			}
+			printPendingMigrations(pending, location.Writable)
+			return
+		}
+		report, err := config.Migrate()
+		if report.Empty() && err == nil {
+			fmt.Println("No files left by older releases.")
+		}
+		printMigrationReport(os.Stdout, report)
+		if err != nil {
+			utils.ExitWithError(err)
+		}
+	},
+}
+
+// printPendingMigrations lists the files Migrate would move. writable is
+// false when the state directory can't be written, so nothing would be.
+func printPendingMigrations(pending []config.Migration, writable bool) {
+	if len(pending) == 0 {
+		fmt.Println("No files left by older releases.")
+		return
+	}
+	if writable {
+		fmt.Printf("%d file(s) left by older releases would be moved:\n", len(pending))
+	} else {
+		fmt.Printf("%d file(s) left by older releases can't be moved until the state directory can be written:\n", len(pending))
+	}
+	for _, m := range pending {
+		if m.Conflict {
+			fmt.Printf("  %s (%s): kept, %s already exists\n", m.From, m.Layout, m.To)
+			continue
+		}
+		fmt.Printf("  %s -> %s (%s)\n", m.From, m.To, m.Layout)
+	}
+}
+
+// writeProbeReport prints which package managers were considered, as a
+// table, followed by a hint for each that was found but can't be used
+func writeProbeReport(w io.Writer, report types.ProbeReport) {
+	fmt.Fprintf(w, "Package managers considered on %s:\n", report.OS)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "  MANAGER\tEXECUTABLE\tSTATUS")
+	found := false
+	for _, probe := range report.Managers {
+		executable, status := "-", "not found"
+		if probe.Found {
+			found = true
+			if probe.Executable != "" {
+				executable = probe.Executable
+			}
+			switch {
+			case probe.Selected:
+				status = "selected"
+			case probe.Problem != "":
+				status = "unusable"
+			default:
+				status = "usable"
+			}
+		}
+		fmt.Fprintf(tw, "  %s\t%s\t%s\n", probe.Name, executable, status)
+	}
+	tw.Flush()
+
+	if !found {
+		fmt.Fprintln(w, "Hint: none of them is on PATH. Install one, or add the directory it is in to PATH.")
+		return
+	}
+	for _, probe := range report.Managers {
+		if !probe.Found || probe.Problem == "" {
+			continue
+		}
+		fmt.Fprintf(w, "Hint: %s found but %s.", probe.Name, probe.Problem)
+		if probe.Fix != "" {
+			fmt.Fprintf(w, " %s.", probe.Fix)
+		}
+		fmt.Fprintln(w)
+	}
+	if _, ok := report.Selected(); ok {
+		return
+	}
+	for _, probe := range report.Managers {
+		if probe.Usable() {
+			fmt.Fprintf(w, "Hint: %s can be used instead; run again with --fallback-package-manager to fall back to it.\n", probe.Name)
+			return
+		}
+	}
+}
+
+// printProbeReport prints the report of a *types.NoPackageManagerError
+// within err to w, so a failed detection says what was found
+func printProbeReport(w io.Writer, err error) {
+	var noManager *types.NoPackageManagerError
+	if errors.As(err, &noManager) {
+		writeProbeReport(w, noManager.Report)
+	}
+}
+
+// migrateLegacyFiles moves the files of older releases into the state
+// directory before a command runs, and says what it moved
+func migrateLegacyFiles() {
+	report, err := config.Migrate()
+	printMigrationReport(os.Stderr, report)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v; run 'stackmatch doctor' to try again\n", err)
+	}
+}
+
+// printMigrationReport prints the files Migrate moved and those it left in
+// place to w
+func printMigrationReport(w io.Writer, report config.MigrationReport) {
+	if len(report.Moved) > 0 {
+		fmt.Fprintf(w, "Moved %d file(s) left by older releases into %s:\n", len(report.Moved), config.StateDir())
+		for _, m := range report.Moved {
+			fmt.Fprintf(w, "  %s -> %s\n", m.From, m.To)
+		}
+	}
+	for _, m := range report.Kept {
+		fmt.Fprintf(w, "Note: left %s in place: %s already exists and is the one used\n", m.From, m.To)
+	}
+}
+
+func init() {
+	doctorCmd.Flags().BoolVar(&doctorDryRun, "dry-run", false, "List the files that would be moved without moving them")
+	doctorCmd.Flags().BoolVar(&doctorJSON, "json", false, "Print the package manager report as JSON")
+	rootCmd.AddCommand(doctorCmd)
+}
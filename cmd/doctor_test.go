@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+func TestProbeReportGolden(t *testing.T) {
+	report := types.ProbeReport{OS: "linux", Managers: []types.ManagerProbe{
+		{Name: "APT", Type: types.TypeApt, Found: true, Executable: "/usr/bin/apt",
+			Problem: "/var/lib/dpkg is locked: unattended-upgrade (pid 812) is using it",
+			Fix:     "Wait for it to finish, or see what holds the lock with 'sudo lsof /var/lib/dpkg/lock-frontend'"},
+		{Name: "DNF", Type: types.TypeDnf},
+		{Name: "Snap", Type: types.TypeSnap, Found: true, Executable: "/usr/bin/snap", Problem: "snapd is not running",
+			Fix: "Start it with 'sudo systemctl enable --now snapd.socket'"},
+	}}
+
+	var buf bytes.Buffer
+	writeProbeReport(&buf, report)
+	// A failed detection prints the same report
+	printProbeReport(&buf, fmt.Errorf("could not detect a supported package manager: %w", &types.NoPackageManagerError{Report: types.ProbeReport{
+		OS: "darwin", Managers: []types.ManagerProbe{{Name: "Homebrew", Type: types.TypeHomebrew}},
+	}}))
+	// Detection doesn't fall back to Snap unless told to
+	printProbeReport(&buf, &types.NoPackageManagerError{Report: types.ProbeReport{OS: "linux", Managers: []types.ManagerProbe{
+		report.Managers[0],
+		{Name: "Snap", Type: types.TypeSnap, Found: true, Executable: "/usr/bin/snap"},
+	}}})
+
+	golden := filepath.Join("testdata", "doctor", "probe-report.golden")
+	if *updateGolden {
+		if err := os.MkdirAll(filepath.Dir(golden), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(golden, buf.Bytes(), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expected, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != string(expected) {
+		t.Errorf("expected:\n%s\nbut got:\n%s", expected, buf.String())
+	}
+}
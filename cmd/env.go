@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/MRQ67/stackmatch-cli/internal/utils"
+	"github.com/MRQ67/stackmatch-cli/pkg/auth"
+	"github.com/MRQ67/stackmatch-cli/pkg/supabase"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+	"github.com/MRQ67/stackmatch-cli/pkg/ui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	envShowID   string
+	envShowFull bool
+	envShowJSON bool
+)
+
+var envCmd = &cobra.Command{
+	Use:   "env",
+	Short: "Inspect environments stored in Supabase",
+}
+
+var envShowCmd = &cobra.Command{
+	Use:   "show <name | username/name | --id ID>",
+	Short: "Show what a stored environment contains without downloading it",
+	Long: `Shows a stored environment's metadata and how many entries each category
+holds, using the stored summary so the full data is not downloaded.
+
+A bare name refers to one of your own environments and requires login.
+username/name and --id work anonymously for public environments; your own
+private environments are visible when you are logged in.
+
+Use --full to list every entry and --json for machine-readable output.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if envShowID != "" && len(args) > 0 {
+			return fmt.Errorf("pass either an environment name or --id, not both")
+		}
+		if envShowID == "" && len(args) != 1 {
+			return fmt.Errorf("requires an environment name, username/name or --id")
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		user := auth.GetCurrentUser()
+
+		var arg string
+		if len(args) > 0 {
+			arg = args[0]
+		}
+		ref, err := parseEnvironmentRef(arg, envShowID, user)
+		if err != nil {
+			utils.ExitWithError(err)
+		}
+
+		var accessToken string
+		if user != nil {
+			accessToken = user.AccessToken
+		}
+		client, err := supabase.NewClient(cfg.SupabaseURL, cfg.SupabaseAPIKey, accessToken)
+		if err != nil {
+			utils.ExitWithError(fmt.Errorf("failed to initialize Supabase client: %w", err))
+		}
+
+		details, err := client.ShowEnvironment(cmd.Context(), ref, envShowFull)
+		if err != nil {
+			utils.ExitWithError(err)
+		}
+
+		if envShowJSON {
+			printEnvironmentJSON(details, envShowFull)
+			return
+		}
+		printEnvironmentShow(details, envShowFull)
+	},
+}
+
+// parseEnvironmentRef turns the 'env show' argument or --id into a reference.
+// A bare name refers to one of user's environments.
+func parseEnvironmentRef(arg, id string, user *auth.User) (supabase.EnvironmentRef, error) {
+	switch {
+	case id != "":
+		return supabase.EnvironmentRef{ID: id}, nil
+	case strings.Contains(arg, "/"):
+		username, name, _ := strings.Cut(arg, "/")
+		if username == "" || name == "" || strings.Contains(name, "/") {
+			return supabase.EnvironmentRef{}, fmt.Errorf("invalid format. Use: username/env-name")
+		}
+		return supabase.EnvironmentRef{Username: username, Name: name}, nil
+	case user == nil:
+		return supabase.EnvironmentRef{}, fmt.Errorf("log in to show your own environments by name, or use username/name")
+	default:
+		return supabase.EnvironmentRef{UserID: user.ID, Name: arg}, nil
+	}
+}
+
+// environmentView is the JSON form of 'env show'
+type environmentView struct {
+	supabase.EnvironmentInfo
+	Username string                 `json:"username,omitempty"`
+	Data     *types.EnvironmentData `json:"data,omitempty"`
+}
+
+func printEnvironmentJSON(details *supabase.EnvironmentDetails, full bool) {
+	view := environmentView{EnvironmentInfo: details.EnvironmentInfo, Username: details.Username}
+	if full {
+		view.Data = details.Data
+	}
+	out, err := json.MarshalIndent(view, "", "  ")
+	if err != nil {
+		utils.ExitWithError(fmt.Errorf("failed to format environment: %w", err))
+	}
+	fmt.Println(string(out))
+}
+
+func printEnvironmentShow(details *supabase.EnvironmentDetails, full bool) {
+	visibility := "private"
+	if details.IsPublic {
+		visibility = "public"
+	}
+	fmt.Printf("Environment: %s\n", details.Name)
+	fmt.Printf("Owner: %s\n", details.Username)
+	fmt.Printf("ID: %s\n", details.ID)
+	fmt.Printf("Visibility: %s\n", visibility)
+	if !details.CreatedAt.IsZero() {
+		fmt.Printf("Created: %s (%s)\n", details.CreatedAt.Local().Format("2006-01-02 15:04"), ui.RelativeTime(details.CreatedAt))
+	}
+	if details.Size > 0 {
+		fmt.Printf("Size: %s\n", ui.HumanSize(int64(details.Size)))
+	}
+	if details.Summary != nil {
+		fmt.Printf("OS: %s\n", details.Summary.OS)
+		fmt.Printf("Fingerprint: %s\n", details.Summary.Fingerprint)
+		if details.Summary.ScanDurationMS > 0 {
+			fmt.Printf("Scan took: %s\n", ui.HumanDuration(time.Duration(details.Summary.ScanDurationMS)*time.Millisecond))
+		}
+		fmt.Println()
+		printCategoryCounts(os.Stdout, details.Summary)
+	}
+
+	if full && details.Data != nil {
+		fmt.Println()
+		printEnvironmentDetails(os.Stdout, details.Data)
+	}
+}
+
+func init() {
+	envShowCmd.Flags().StringVar(&envShowID, "id", "", "Environment ID to show")
+	envShowCmd.Flags().BoolVar(&envShowFull, "full", false, "List every entry (downloads the environment)")
+	envShowCmd.Flags().BoolVar(&envShowJSON, "json", false, "Print the environment as JSON")
+	envCmd.AddCommand(envShowCmd)
+	rootCmd.AddCommand(envCmd)
+}
@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/auth"
+	"github.com/MRQ67/stackmatch-cli/pkg/supabase"
+)
+
+func TestParseEnvironmentRef(t *testing.T) {
+	me := &auth.User{ID: "user-1"}
+
+	testCases := []struct {
+		name     string
+		arg      string
+		id       string
+		user     *auth.User
+		expected supabase.EnvironmentRef
+		errText  string
+	}{
+		{name: "own by name", arg: "laptop", user: me, expected: supabase.EnvironmentRef{UserID: "user-1", Name: "laptop"}},
+		{name: "own by name anonymously", arg: "laptop", errText: "log in"},
+		{name: "user/name anonymously", arg: "ada/laptop", expected: supabase.EnvironmentRef{Username: "ada", Name: "laptop"}},
+		{name: "user/name logged in", arg: "ada/laptop", user: me, expected: supabase.EnvironmentRef{Username: "ada", Name: "laptop"}},
+		{name: "ID", id: "0b7c", expected: supabase.EnvironmentRef{ID: "0b7c"}},
+		{name: "missing name", arg: "ada/", errText: "invalid format"},
+		{name: "too many parts", arg: "ada/laptop/old", errText: "invalid format"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ref, err := parseEnvironmentRef(tc.arg, tc.id, tc.user)
+			if tc.errText != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.errText) {
+					t.Fatalf("expected an error containing %q but got %v", tc.errText, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ref != tc.expected {
+				t.Errorf("expected %+v but got %+v", tc.expected, ref)
+			}
+		})
+	}
+}
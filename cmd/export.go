@@ -2,61 +2,141 @@ package cmd
 
 import (
 	"fmt"
-	"time"
+	"os"
+	"slices"
+	"strings"
 
 	"github.com/MRQ67/stackmatch-cli/internal/utils"
+	"github.com/MRQ67/stackmatch-cli/pkg/config"
 	"github.com/MRQ67/stackmatch-cli/pkg/exporter"
 	"github.com/MRQ67/stackmatch-cli/pkg/scanner"
-	"github.com/MRQ67/stackmatch-cli/pkg/types"
+	"github.com/MRQ67/stackmatch-cli/pkg/stackmatch"
+	"github.com/MRQ67/stackmatch-cli/pkg/ui"
 	"github.com/spf13/cobra"
 )
 
+// exportProfile names the profile to filter the export with
+var exportProfile string
+
+// exportFormat is the format of the exported file
+var exportFormat string
+
 var exportCmd = &cobra.Command{
 	Use:   "export [filename]",
-	Short: "Scan the environment and export it to a JSON file",
-	Long:  `Scans the local development environment and saves the complete configuration to a specified JSON file.
-This file can be used for sharing, analysis, or later with the 'import' command.`,
+	Short: "Scan the environment and export it to a JSON, YAML or TOML file",
+	Long:  `Scans the local development environment and saves the complete configuration to a specified JSON, YAML or TOML file.
+This file can be used for sharing, analysis, or later with the 'import' command.
+
+Use --only or --skip to scan some categories and not others (see 'stackmatch
+scan --help').
+
+Credential files such as .git-credentials, .netrc and .env files are left out
+of the config files, and tokens recognized anywhere in the environment are
+replaced with [REDACTED]. --no-redact exports the environment as it is.
+
+--format yaml and --format toml write the environment as YAML or TOML, with
+the keys of the JSON file; import reads them back.
+
+--format winget writes a file for 'winget import' and --format chocolatey a
+packages.config file for 'choco install' instead, with the languages, tools,
+package managers and editors that have a package there. Entries without one
+are listed in a report next to the winget file, named <filename>.skipped.txt,
+and in comments at the end of the packages.config file.`,
 	Args:  cobra.ExactArgs(1), // Ensures exactly one argument (the filename) is provided
 	Run: func(cmd *cobra.Command, args []string) {
 		outputFile := args[0]
-		fmt.Printf("Scanning environment to export to %s...\n", outputFile)
-
-		envData := types.EnvironmentData{
-			StackmatchVersion: cliVersion,
-			ScanDate:          time.Now().UTC(),
-			Tools:             make(map[string]string),
-			PackageManagers:   make(map[string]string),
-			CodeEditors:       make(map[string]string),
-			ConfiguredLanguages: make(map[string]string),
-			ConfigFiles:       []string{},
+		if !slices.Contains(exporter.Formats, exportFormat) {
+			utils.ExitWithError(fmt.Errorf("unknown format %q; use one of %s", exportFormat, strings.Join(exporter.Formats, ", ")))
+		}
+		detectors, err := scanDetectors()
+		if err != nil {
+			utils.ExitWithError(err)
 		}
+		// Look the profile up first so a typo doesn't cost a full scan
+		var profile *stackmatch.Profile
+		if exportProfile != "" {
+			profiles, err := stackmatch.LoadProfiles(config.ProfilesFile())
+			if err != nil {
+				utils.ExitWithError(err)
+			}
+			found, err := stackmatch.FindProfile(profiles, exportProfile)
+			if err != nil {
+				utils.ExitWithError(fmt.Errorf("%w; see 'stackmatch config profiles'", err))
+			}
+			profile = &found
+		}
+		fmt.Printf("Scanning environment to export to %s...\n", outputFile)
 
 		// Run all our detection logic
-		fmt.Println("• Detecting system info...")
-		scanner.DetectSystemInfo(&envData.System)
-		fmt.Println("• Detecting programming languages...")
-		scanner.DetectProgrammingLanguages(&envData)
-		fmt.Println("• Detecting development tools...")
-		scanner.DetectTools(&envData)
-		fmt.Println("• Detecting package managers...")
-		scanner.DetectPackageManagers(&envData)
-		fmt.Println("• Detecting code editors...")
-		scanner.DetectEditors(&envData)
-		fmt.Println("• Detecting config files...")
-		scanner.DetectConfigFiles(&envData)
+		envData, err := scanWithCache(cmd.Context(), stackmatch.ScanOptions{
+			Progress: stackmatch.ProgressFunc(func(msg string) {
+				fmt.Printf("%s %s...\n", ui.Symbols().Bullet, msg)
+			}),
+			ProjectPath:     projectPath,
+			LoginShellProbe: loginShellProbe,
+			ScheduledJobs:   scanScheduledJobs,
+			ProbeServices:   probeServices,
+			Deep:            scanDeep,
+			Concurrency:     scanConcurrency,
+			Detectors:       detectors,
+		})
+		if err != nil {
+			utils.ExitWithError(fmt.Errorf("scan failed: %w", err))
+		}
 
+		printScanWarnings(envData)
+		recordScanCounts(envData)
 		fmt.Println("\nScan complete.")
 
+		if profile != nil {
+			var missing []string
+			envData, missing = stackmatch.ApplyProfile(envData, *profile)
+			for _, name := range missing {
+				fmt.Fprintf(os.Stderr, "Warning: profile %s includes %s, which the scan did not find\n", profile.Name, name)
+			}
+			fmt.Printf("Filtered with profile %s\n", profile.Name)
+		}
+
+		if !noRedact {
+			scanner.RedactSensitive(&envData)
+		}
+
 		// Export the data
-		err := exporter.WriteJSON(envData, outputFile)
+		var skipped []exporter.Skipped
+		switch exportFormat {
+		case exporter.FormatWinget:
+			skipped, err = exporter.WriteWinget(envData, outputFile)
+		case exporter.FormatChocolatey:
+			skipped, err = exporter.WriteChocolatey(envData, outputFile)
+		default:
+			err = exporter.Write(envData, outputFile, exportFormat)
+		}
 		if err != nil {
 			utils.ExitWithError(fmt.Errorf("could not export data: %w", err))
 		}
+		if len(skipped) > 0 {
+			where := "comments at the end of " + outputFile
+			if exportFormat == exporter.FormatWinget {
+				where = outputFile + exporter.SkippedSuffix
+			}
+			fmt.Fprintf(os.Stderr, "Warning: %d entries have no %s package and were left out; see %s\n", len(skipped), exportFormat, where)
+		}
 
 		fmt.Printf("Environment successfully exported to %s\n", outputFile)
 	},
 }
 
 func init() {
+	exportCmd.Flags().StringVar(&projectPath, "path", "", "Scan a project directory's manifests and pinned tool versions instead of the home directory's config files")
+	exportCmd.Flags().BoolVar(&loginShellProbe, "login-shell-probe", false, "Retry tools missing from PATH through your login shell (for nvm, sdkman, rbenv...)")
+	exportCmd.Flags().BoolVar(&scanScheduledJobs, "scheduled-jobs", false, "Also capture your crontab or scheduled tasks, with secrets in their commands redacted")
+	exportCmd.Flags().BoolVar(&probeServices, "services", false, "Also probe well-known local ports for running development services such as PostgreSQL and Redis")
+	exportCmd.Flags().BoolVar(&scanDeep, "deep", false, "Also list the key packages of every conda environment (slow)")
+	exportCmd.Flags().IntVar(&scanConcurrency, "concurrency", scanner.DefaultConcurrency, "Number of version commands to run at once")
+	exportCmd.Flags().StringSliceVar(&scanOnly, "only", nil, "Scan only these categories or detectors (repeatable)")
+	exportCmd.Flags().StringSliceVar(&scanSkip, "skip", nil, "Do not scan these categories or detectors (repeatable)")
+	exportCmd.Flags().BoolVar(&noRedact, "no-redact", false, "Export credential files and tokens instead of leaving them out")
+	exportCmd.Flags().StringVar(&exportProfile, "profile", "", "Export only what the named profile includes (see 'stackmatch config profiles')")
+	exportCmd.Flags().StringVar(&exportFormat, "format", exporter.FormatJSON, "Format of the exported file: json, yaml, toml, winget or chocolatey")
 	rootCmd.AddCommand(exportCmd)
 }
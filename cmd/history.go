@@ -0,0 +1,228 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/MRQ67/stackmatch-cli/internal/utils"
+	"github.com/MRQ67/stackmatch-cli/pkg/config"
+	"github.com/MRQ67/stackmatch-cli/pkg/installer"
+	"github.com/MRQ67/stackmatch-cli/pkg/stackmatch"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+	"github.com/spf13/cobra"
+)
+
+var (
+	historyStepDone  int
+	historyPages     pageFlags
+	historyPorcelain bool
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "List local installation reports",
+	Long:  `Lists the installations performed by 'stackmatch import' on this machine, newest first.`,
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		pages := historyPages
+		if historyPorcelain {
+			pages = pages.unlimited(cmd)
+		}
+		page, err := pages.toPage()
+		if err != nil {
+			utils.ExitWithError(err)
+		}
+		tracker := openTracker()
+
+		records := tracker.ListInstallations()
+		if len(records) == 0 && !historyPorcelain {
+			fmt.Println("No installations recorded yet. Install an environment with 'stackmatch import --dry-run=false'")
+			return
+		}
+		sort.Slice(records, func(i, j int) bool {
+			return records[i].Timestamp.After(records[j].Timestamp)
+		})
+		total := len(records)
+		start, end := paginate(total, page)
+		records = records[start:end]
+		if historyPorcelain {
+			writeHistoryPorcelain(os.Stdout, records)
+			return
+		}
+		if len(records) == 0 {
+			fmt.Printf("No installations on this page; %d recorded in total.\n", total)
+			return
+		}
+
+		writeHistoryTable(os.Stdout, records)
+		historyPages.printPageFooter(page, len(records), total)
+	},
+}
+
+var historyStepsCmd = &cobra.Command{
+	Use:   "steps <id>",
+	Short: "Show or tick off the manual steps of an installation",
+	Long: `Shows the manual follow-up steps recorded for an installation.
+
+Use --done N to mark step N as done once you have performed it.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		tracker := openTracker()
+		id := args[0]
+
+		if historyStepDone != 0 {
+			if err := tracker.MarkStepDone(id, historyStepDone); err != nil {
+				utils.ExitWithError(err)
+			}
+		}
+
+		record, ok := tracker.GetInstallation(id)
+		if !ok {
+			utils.ExitWithError(fmt.Errorf("installation record not found: %s", id))
+		}
+		if len(record.ManualSteps) == 0 {
+			fmt.Println("This installation has no manual steps.")
+			return
+		}
+		printChecklist(record.ManualSteps)
+	},
+}
+
+// openTracker opens the local installation records or exits
+func openTracker() *installer.InstallationTracker {
+	tracker, err := installer.NewInstallationTracker(config.TrackerFile())
+	if err != nil {
+		utils.ExitWithError(fmt.Errorf("failed to open installation records: %w", err))
+	}
+	return tracker
+}
+
+// recordInstallation writes the installation report for an import and returns
+// its ID, or "" if the report could not be written
+func recordInstallation(env *types.EnvironmentData, plan *stackmatch.InstallPlan, result *stackmatch.InstallResult, installErr error) string {
+	tracker, err := installer.NewInstallationTracker(config.TrackerFile())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not record installation: %v\n", err)
+		return ""
+	}
+
+	record, err := tracker.StartInstallation(env)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not record installation: %v\n", err)
+		return ""
+	}
+
+	var manager types.PackageManagerType
+	if plan.Manager != nil {
+		manager = plan.Manager.Type()
+	}
+	for _, item := range plan.Items {
+		if err := tracker.AddPackage(record.ID, types.PackageInfo{Name: item.Package, Version: item.Version, Tool: item.Name, Manager: manager}); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not record package %s: %v\n", item.Package, err)
+		}
+	}
+	if len(plan.Conflicts) > 0 {
+		if err := tracker.SetConflicts(record.ID, plan.Conflicts); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not record conflicts: %v\n", err)
+		}
+	}
+	if result != nil {
+		if err := tracker.SetManualSteps(record.ID, result.ManualSteps); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not record manual steps: %v\n", err)
+		}
+		for _, pkg := range result.Pinned {
+			if err := tracker.AddPin(record.ID, installer.Pin{Package: pkg, ManagerType: plan.Manager.Type()}); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: could not record pin of %s: %v\n", pkg, err)
+			}
+		}
+	}
+
+	if installErr != nil {
+		err = tracker.FailInstallation(record.ID, installErr.Error())
+	} else {
+		err = tracker.CompleteInstallation(record.ID)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not update installation status: %v\n", err)
+	}
+
+	return record.ID
+}
+
+// printManualSteps prints the checklist shown at the end of an import
+func printManualSteps(recordID string, steps []types.ManualStep) {
+	if len(steps) == 0 {
+		return
+	}
+
+	fmt.Println("\nManual steps remaining:")
+	printChecklist(steps)
+	if recordID != "" {
+		fmt.Printf("\nMark steps as done with 'stackmatch history steps %s --done N'\n", recordID)
+	}
+}
+
+// printChecklist prints steps as a numbered checklist
+func printChecklist(steps []types.ManualStep) {
+	for i, step := range steps {
+		mark := " "
+		if step.Done {
+			mark = "x"
+		}
+		fmt.Printf("%3d. [%s] (%s) %s\n", i+1, mark, step.Category, step.Description)
+		for _, line := range strings.Split(step.Snippet, "\n") {
+			if line != "" {
+				fmt.Printf("          %s\n", line)
+			}
+		}
+		if step.DocURL != "" {
+			fmt.Printf("          See %s\n", step.DocURL)
+		}
+	}
+}
+
+// pendingSteps counts the steps not yet marked as done
+func pendingSteps(steps []types.ManualStep) int {
+	pending := 0
+	for _, step := range steps {
+		if !step.Done {
+			pending++
+		}
+	}
+	return pending
+}
+
+func init() {
+	addPageFlags(historyCmd, &historyPages)
+	addPorcelainFlag(historyCmd, &historyPorcelain)
+	historyStepsCmd.Flags().IntVar(&historyStepDone, "done", 0, "Mark step `N` as done")
+	historyCmd.AddCommand(historyStepsCmd)
+	rootCmd.AddCommand(historyCmd)
+}
+
+// rollbackInstallation undoes the installation with the given ID through the
+// package manager detected here, or exits
+func rollbackInstallation(ctx context.Context, id string) {
+	tracker := openTracker()
+	if _, ok := tracker.GetInstallation(id); !ok {
+		utils.ExitWithError(fmt.Errorf("installation record not found: %s; see 'stackmatch history'", id))
+	}
+	manager, err := installer.DetectPackageManagerContext(ctx)
+	if err != nil {
+		printProbeReport(os.Stderr, err)
+		utils.ExitWithError(err)
+	}
+	fmt.Printf("Rolling back installation %s with %s\n", id, manager.Name())
+	err = tracker.Rollback(ctx, id, manager, types.UninstallOptions{RemoveDependencies: removeDependencies})
+	record, _ := tracker.GetInstallation(id)
+	if len(record.RemovedPackages) > 0 {
+		fmt.Printf("Removed %s\n", strings.Join(record.RemovedPackages, ", "))
+	}
+	if err != nil {
+		utils.ExitWithError(err)
+	}
+	fmt.Println("Rollback completed")
+}
@@ -1,16 +1,36 @@
 package cmd
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"os"
-	"time"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
 
 	"github.com/MRQ67/stackmatch-cli/internal/utils"
+	"github.com/MRQ67/stackmatch-cli/pkg/audit"
+	"github.com/MRQ67/stackmatch-cli/pkg/config"
+	"github.com/MRQ67/stackmatch-cli/pkg/cron"
+	"github.com/MRQ67/stackmatch-cli/pkg/envfile"
+	"github.com/MRQ67/stackmatch-cli/pkg/exporter"
+	"github.com/MRQ67/stackmatch-cli/pkg/gitconfig"
+	"github.com/MRQ67/stackmatch-cli/pkg/langconfig"
 	"github.com/MRQ67/stackmatch-cli/pkg/installer"
+	"github.com/MRQ67/stackmatch-cli/pkg/installer/package_managers"
+	"github.com/MRQ67/stackmatch-cli/pkg/redact"
+	"github.com/MRQ67/stackmatch-cli/pkg/runner"
+	"github.com/MRQ67/stackmatch-cli/pkg/shellinit"
+	"github.com/MRQ67/stackmatch-cli/pkg/scanner"
+	"github.com/MRQ67/stackmatch-cli/pkg/services"
+	"github.com/MRQ67/stackmatch-cli/pkg/stackmatch"
 	"github.com/MRQ67/stackmatch-cli/pkg/supabase"
+	"github.com/MRQ67/stackmatch-cli/pkg/toolversions"
 	"github.com/MRQ67/stackmatch-cli/pkg/types"
+	"github.com/MRQ67/stackmatch-cli/pkg/ui"
 	"github.com/spf13/cobra"
 )
 
@@ -19,6 +39,21 @@ var (
 	sourceSupabase bool
 	supabaseID     string
 	importListOnly bool
+	brewPrefix     string
+	importScope    string
+	repairInput    bool
+	requiredOnly   bool
+	importPin      bool
+	applyCron      bool
+	applyShellInit bool
+	skipPreflight  bool
+	systemServices bool
+	noVerify       bool
+	failFast       bool
+	minCoverage    int
+	// importRollback is the installation to undo instead of importing
+	importRollback     string
+	removeDependencies bool
 )
 
 var importCmd = &cobra.Command{
@@ -29,26 +64,170 @@ var importCmd = &cobra.Command{
 By default, this command runs in dry-run mode, showing what would be installed
 without making any changes. Use the --no-dry-run flag to perform the actual installation.
 
+The dry run asks the package manager what installing each package would do
+without installing anything (apt-get install --dry-run, dnf install
+--assumeno, brew install --dry-run or choco install --noop), and lists each
+as install, upgrade, downgrade, held or none (already installed), with the
+dependencies it pulls in. dnf only answers as root, so run import with sudo
+for its answer. Other package managers, and packages a dry run doesn't
+mention, are listed as installed and marked estimated.
+
 When using --source=supabase, authentication is required.
 
-You can specify either a local file or use --from-supabase with --id to import from Supabase.`,
+You can specify either a local file or use --from-supabase with --id to import from Supabase.
+The file may be JSON, YAML or TOML, as written by 'scan --format' or 'export
+--format', told apart by its extension or its first line.
+The file may also be a .tool-versions, .nvmrc, .node-version, .python-version,
+.ruby-version, .go-version or .java-version file, or a project directory
+containing them; languages are then installed through mise or asdf when
+available.
+
+Python is installed through pyenv or uv when the environment got its Python
+from that manager and it is installed here, rather than through mise, asdf
+or the package manager. Python from a conda environment gets a manual step
+to recreate the environment.
+
+Packages installed globally with npm are reinstalled at their recorded
+versions with 'npm install --global' after the languages, or listed as manual
+steps when npm is not available.
+
+When the file declares platform targets (see 'stackmatch targets'), the one
+matching this machine is merged into the environment before anything else.
+
+Use --scope user on shared machines to install into your own prefix rather
+than system-wide: Homebrew into ~/homebrew (which must be installed there),
+global npm packages with --prefix ~/.local, pip packages with --user and
+winget packages with --scope user. Scoop and go install already install into
+your home, and version managers always do. apt, dnf and the other system
+package managers only install system-wide, so a plan with packages for them
+stops with an error; install those entries through language-level backends
+instead. The planned changes show the scope of each package, and
+verification looks for them where they were installed.
+
+Use --required-only to install just the entries marked as required with
+'stackmatch annotate'.
+
+Use --pin to hold packages installed for versioned entries at their version
+(apt-mark hold, dnf versionlock, brew pin or choco pin), so the next system
+upgrade does not move them. Manage pins later with 'stackmatch pins'.
+
+Crontab entries and scheduled tasks captured with 'scan --scheduled-jobs' are
+listed as manual steps. Use --apply-cron to add the crontab entries missing
+here to your own crontab, confirming each one. System crontabs and scheduled
+tasks are never changed.
+
+Version managers such as nvm, pyenv and sdkman only work in new shells once
+their init lines are in your shell's rc file (~/.bashrc, ~/.zshrc or
+~/.config/fish/config.fish). The lines for your shell are listed as manual
+steps; use --apply-shell-init to append them, between marker comments, to
+the rc file after backing it up. Lines already there are never added twice.
+
+Developer services the environment starts on their own, such as postgresql
+through brew services or redis through a systemd user unit, are offered one
+by one after installation, through this machine's service manager ('brew
+services start', 'systemctl --user enable --now'). Services whose package is
+not installed here are listed as manual steps. System services, such as
+systemd system units and Windows services, are only changed with
+--system-services.
+
+When the environment had corepack enabled, so that yarn and pnpm run the
+version each project's packageManager field asks for, import offers to run
+'corepack enable' after installation.
+
+Once everything is installed, import asks the package manager for the
+versions of all the packages at once (dpkg-query, rpm, pacman -Q, brew list
+or choco list) and reports each as satisfied, unsatisfied or unknown against
+the version the plan installs it at. Use --no-verify to skip this. With
+--fail-fast, packages are installed one at a time, each verified right after
+it is installed, and the import stops at the first that fails.
+
+Before installing, import prints how much of the environment the plan
+covers: the share of its languages, tools, package managers, editors and
+global packages the plan installs, and how many are left for you. The
+breakdown, repeated at the end, counts the entries installed at a version
+that can't be verified, those with no package for this package manager and
+those left as manual steps. Use --min-coverage 90 to stop before installing
+anything when the plan covers less, such as when provisioning machines
+unattended.
+
+Packages that can't be installed together, such as docker.io and docker-ce
+or several JDKs, are caught while planning. Each conflict keeps the package
+its rule prefers or asks which one to install, failing when input ends
+without an answer, such as in unattended runs; the others are left as manual
+steps and the decision is recorded in the installation report. Add rules to
+the conflicts list of ~/.stackmatch/mappings.yaml; they take precedence over
+the built-in ones.
+
+Before installing, import checks that there is enough disk space for a rough
+estimate of the installation, that the package manager can reach its
+repositories and that it is in a healthy state (dpkg --audit, Chocolatey and
+winget sources). Use --skip-preflight to install anyway.
+
+Git URL rewrites (url.<base>.insteadOf) missing from your global git config
+are offered one by one after installation. Credential helpers given by a path
+that does not exist here, and git settings such as user.name, pull.rebase and
+aliases that differ here, are listed as manual steps.
+
+Use --simulate <dir> to run the installation against the command outputs
+recorded in <dir> instead of this machine: the plan, the package manager
+commands, the report and the exit status are those of a real import, but no
+command runs. Commands missing from the recording fail and are listed at the
+end. Preflight checks, crontab entries, git and language settings, services
+and the installation history are left out. Record outputs on a real import with
+--record <dir>; secrets in them are redacted, and recording into the same
+directory again adds the commands not yet recorded.
+
+Use --rollback <id> --dry-run=false to undo an installation listed by
+'stackmatch history': its pins are released and its packages uninstalled,
+newest first. Packages an earlier rollback already removed are left alone.
+Add --remove-dependencies to also remove the dependencies Chocolatey
+installed with them. Chocolatey itself and PowerShell are never removed, so a
+package depending on them keeps all its dependencies.`,
 	PreRunE: func(cmd *cobra.Command, args []string) error {
 		// Only require auth if using Supabase source
 		if sourceSupabase {
+			if err := requireSync("stackmatch import --from-supabase"); err != nil {
+				return err
+			}
 			return requireAuth(cmd, args)
 		}
 		return nil
 	},
 	Args: func(cmd *cobra.Command, args []string) error {
+		if removeDependencies && importRollback == "" {
+			return fmt.Errorf("--remove-dependencies only applies to --rollback")
+		}
+		if importRollback != "" {
+			if sourceSupabase || len(args) != 0 {
+				return fmt.Errorf("--rollback takes no filename or --from-supabase")
+			}
+			if dryRun {
+				return fmt.Errorf("--rollback needs --dry-run=false, since it uninstalls packages")
+			}
+			return nil
+		}
 		if !sourceSupabase && len(args) != 1 {
 			return fmt.Errorf("requires a filename argument when not using --from-supabase")
 		}
 		if sourceSupabase && supabaseID == "" {
 			return fmt.Errorf("--id is required when using --from-supabase")
 		}
+		if recordDir != "" && dryRun {
+			return fmt.Errorf("--record needs --dry-run=false, since a dry run runs no commands")
+		}
+		if minCoverage < 0 || minCoverage > 100 {
+			return fmt.Errorf("--min-coverage must be a percentage between 0 and 100")
+		}
+		if minCoverage > 0 && dryRun && simulateDir == "" {
+			return fmt.Errorf("--min-coverage needs --dry-run=false, since it stops an installation")
+		}
 		return nil
 	},
 	Run: func(cmd *cobra.Command, args []string) {
+		if importRollback != "" {
+			rollbackInstallation(cmd.Context(), importRollback)
+			return
+		}
 		var envData types.EnvironmentData
 		var err error
 
@@ -70,39 +249,62 @@ You can specify either a local file or use --from-supabase with --id to import f
 			}
 			envData = *env
 		} else {
-			// Read from local file
-			inputFile := args[0]
-			fileContent, err := os.ReadFile(inputFile)
-			if err != nil {
-				utils.ExitWithError(fmt.Errorf("could not read file %s: %w", inputFile, err))
-			}
-
-			err = json.Unmarshal(fileContent, &envData)
+			// Read from a local environment or version file
+			env, err := readEnvironmentSource(args[0])
 			if err != nil {
-				utils.ExitWithError(fmt.Errorf("could not parse JSON from %s: %w", inputFile, err))
+				utils.ExitWithError(err)
 			}
+			envData = *env
 		}
 
+		checkCompatibility(&envData)
+
+		// Merge the platform target matching this machine before filtering
+		// and planning
+		var local types.SystemInfo
+		scanner.DetectSystemInfo(cmd.Context(), &local)
+		installer.Libc = local.Libc
+		var target string
+		envData, target = types.ForPlatform(envData, local.OS, local.Arch)
+
 		var source string
 		if sourceSupabase {
 			source = fmt.Sprintf("Supabase (ID: %s)", supabaseID)
 		} else {
 			source = args[0]
 		}
+
+		if requiredOnly {
+			required := stackmatch.RequiredOnly(envData)
+			if len(required.ConfiguredLanguages)+len(required.Tools)+len(required.PackageManagers)+len(required.CodeEditors) == 0 {
+				utils.ExitWithError(fmt.Errorf("%s marks no entries as required; mark them with 'stackmatch annotate <file> --required <names>'", source))
+			}
+			envData = required
+		}
 		fmt.Printf("--- Environment Summary from %s ---\n", source)
 		fmt.Printf("Generated by StackMatch Version: %s\n", envData.StackmatchVersion)
-		fmt.Printf("Scan Date: %s\n\n", envData.ScanDate.Format("2006-01-02 15:04:05 MST"))
+		fmt.Printf("Scan Date: %s (%s)\n\n", envData.ScanDate.Format("2006-01-02 15:04:05 MST"), ui.RelativeTime(envData.ScanDate))
+		if target != "" {
+			fmt.Printf("Applied the %s target\n\n", target)
+		}
+		if note := releaseMismatch(envData.System, local); note != "" {
+			fmt.Printf("%s\n\n", note)
+		}
+		if warning := wslMismatch(envData.System, local); warning != "" {
+			fmt.Fprintf(os.Stderr, "Warning: %s\n\n", warning)
+		}
+		if warning := libcMismatch(envData.System, local); warning != "" {
+			fmt.Fprintf(os.Stderr, "Warning: %s\n\n", warning)
+		}
 
 		// If list-only, just show the summary and exit
 		if importListOnly {
 			fmt.Println("=== Environment Summary ===")
 			fmt.Printf("Source: %s\n", source)
 			fmt.Printf("StackMatch Version: %s\n", envData.StackmatchVersion)
-			fmt.Printf("Scan Date: %s\n", envData.ScanDate.Format("2006-01-02 15:04:05 MST"))
+			fmt.Printf("Scan Date: %s (%s)\n", envData.ScanDate.Format("2006-01-02 15:04:05 MST"), ui.RelativeTime(envData.ScanDate))
 			fmt.Println("\nSystem Information:")
-			fmt.Printf("  OS: %s\n", envData.System.OS)
-			fmt.Printf("  Architecture: %s\n", envData.System.Arch)
-			fmt.Printf("  Shell: %s\n", envData.System.Shell)
+			printSystemInfo(os.Stdout, envData.System)
 			
 			// Count tools by category
 			totalTools := 0
@@ -113,121 +315,868 @@ You can specify either a local file or use --from-supabase with --id to import f
 				totalTools += len(envData.PackageManagers)
 			}
 			fmt.Printf("\nTotal tools to install: %d\n", totalTools)
+			if n := extensionEntries(&envData); n > 0 {
+				fmt.Printf("Not installable by this version: %d (%s)\n", n, strings.Join(types.ExtensionCategories(&envData), ", "))
+			}
 			return
 		}
 
 		// Show detailed information if not in list-only mode
-		fmt.Println("System Information:")
-		fmt.Printf("  OS: %s\n", envData.System.OS)
-		fmt.Printf("  Architecture: %s\n", envData.System.Arch)
-		fmt.Printf("  Shell: %s\n\n", envData.System.Shell)
+		printEnvironmentDetails(os.Stdout, &envData)
 
-		if len(envData.ConfiguredLanguages) > 0 {
-			fmt.Println("Programming Languages:")
-			for lang, version := range envData.ConfiguredLanguages {
-				fmt.Printf("  - %s: %s\n", lang, version)
+		fmt.Println("--- End of Summary ---")
+
+		if dryRun && simulateDir == "" {
+			if envData.GitConfig != nil {
+				printGitSettingChanges(cmd.Context(), envData.GitConfig.Settings)
 			}
-			fmt.Println()
+			printPlannedChanges(cmd.Context(), envData, local.Shell)
+			fmt.Println("Note: This is a dry run. No changes have been made to your system.")
+			return
 		}
 
-		if len(envData.Tools) > 0 {
-			fmt.Println("Development Tools:")
-			for tool, version := range envData.Tools {
-				fmt.Printf("  - %s: %s\n", tool, version)
+		// Replay recorded commands instead of running them, or record them
+		simulating := simulateDir != ""
+		if simulating {
+			finish, err := startSimulation(simulateDir)
+			if err != nil {
+				utils.ExitWithError(err)
 			}
-			fmt.Println()
+			defer finish()
+			// Nothing changes on this machine, so there is nothing to audit
+			cmd.SetContext(audit.WithLogger(cmd.Context(), nil))
+			fmt.Printf("\nSimulating installation with the commands recorded in %s...\n", simulateDir)
+		} else {
+			if recordDir != "" {
+				save, err := startRecording(recordDir)
+				if err != nil {
+					utils.ExitWithError(err)
+				}
+				defer save()
+			}
+			fmt.Println("\nStarting installation...")
 		}
 
-		if len(envData.PackageManagers) > 0 {
-			fmt.Println("Package Managers:")
-			for pm, version := range envData.PackageManagers {
-				fmt.Printf("  - %s: %s\n", pm, version)
+		// Build the installation plan using the best available package manager,
+		// or the Homebrew installation the user picked
+		planOpts := stackmatch.PlanOptions{Shell: local.Shell, ConflictRules: loadConflictRules(), ResolveConflict: askConflict, Scope: installScope()}
+		if brewPrefix != "" {
+			planOpts.Manager, err = package_managers.NewHomebrewWithPrefix(brewPrefix)
+			if err != nil {
+				utils.ExitWithError(err)
 			}
-			fmt.Println()
 		}
+		// Scan first so tools that are installed but broken get reinstalled
+		fmt.Println("Checking installed tools...")
+		installed, err := stackmatch.Scan(cmd.Context(), stackmatch.ScanOptions{})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not scan installed tools, broken tools will not be reinstalled: %v\n", err)
+		} else {
+			planOpts.Installed = &installed
+		}
+		plan, err := stackmatch.Plan(cmd.Context(), envData, planOpts)
+		if err != nil {
+			printProbeReport(os.Stderr, err)
+			utils.ExitWithError(conflictHint(scopeHint(err)))
+		}
+		printConflicts(plan.Conflicts)
+		fmt.Printf("Coverage: %s\n", plan.Coverage.Summary())
+		if plan.Coverage.Percent() < minCoverage {
+			utils.ExitWithError(fmt.Errorf("the plan covers %d%% of the environment, below --min-coverage %d%% (%s); nothing was installed",
+				plan.Coverage.Percent(), minCoverage, plan.Coverage.Breakdown(plan.Manager.Name())))
+		}
+		if !skipPreflight && !simulating {
+			runPreflight(cmd.Context(), plan)
+		}
+		confirmReinstalls(plan)
 
-		if len(envData.CodeEditors) > 0 {
-			fmt.Println("Code Editors:")
-			for editor, version := range envData.CodeEditors {
-				fmt.Printf("  - %s: %s\n", editor, version)
+		// The cache describes the detected package manager, not one picked
+		// with --brew-prefix
+		managerName := plan.Manager.Name()
+		if installer.Cache != nil && brewPrefix == "" {
+			if version := installer.Metadata(cmd.Context(), plan.Manager).Version; version != "" {
+				managerName += " " + version
 			}
-			fmt.Println()
+		}
+		if planOpts.Scope != "" {
+			managerName += fmt.Sprintf(" (%s scope)", planOpts.Scope)
+		}
+		fmt.Printf("Using package manager: %s\n", managerName)
+		if !simulating {
+			recordManager(plan.Manager.Name())
 		}
 
-		if len(envData.ConfigFiles) > 0 {
-			fmt.Println("Configuration Files:")
-			for _, file := range envData.ConfigFiles {
-				fmt.Printf("  - %s\n", file)
+		// Install packages
+		result, err := stackmatch.Install(cmd.Context(), plan, stackmatch.InstallOptions{
+			Progress: stackmatch.ProgressFunc(func(msg string) {
+				fmt.Printf("%s...\n", msg)
+			}),
+			Pin:      importPin,
+			Verify:   !noVerify,
+			FailFast: failFast,
+		})
+		// A simulation leaves this machine's settings and history alone
+		recordID := ""
+		if !simulating {
+			// Whatever was installed, even in part, makes the cached scan
+			// out of date
+			invalidateScanCache()
+			if err == nil && applyCron {
+				applyCronJobs(cmd.Context(), envData.ScheduledJobs, result)
+			}
+			if err == nil && len(plan.ShellInits) > 0 {
+				applyShellInits(cmd.Context(), plan.ShellInits, result)
+			}
+			if err == nil && envData.GitConfig != nil {
+				applyURLRewrites(cmd.Context(), envData.GitConfig.URLRewrites, result)
+			}
+			if err == nil && len(envData.LanguageConfig) > 0 {
+				applyLanguageConfig(cmd.Context(), envData.LanguageConfig, result)
 			}
-			fmt.Println()
+			if err == nil && len(envData.Services) > 0 {
+				applyServices(cmd.Context(), envData.Services, result)
+			}
+			if err == nil && plan.EnableCorepack {
+				applyCorepack(cmd.Context(), result)
+			}
+			recordID = recordInstallation(&envData, plan, result, err)
+		}
+		if err != nil {
+			if result != nil {
+				printVerification(result.Verification)
+				printManualSteps(recordID, result.ManualSteps)
+			}
+			utils.ExitWithError(conflictHint(err))
 		}
 
-		fmt.Println("--- End of Summary ---")
+		if simulating {
+			fmt.Println("\nSimulated installation completed")
+		} else {
+			recordCount("packages_installed", len(result.Packages))
+			recordCount("manual_steps", len(result.ManualSteps))
+			fmt.Printf("\nInstallation completed in %s\n", ui.HumanDuration(result.Duration))
+		}
+		if len(result.Pinned) > 0 {
+			fmt.Printf("Pinned %s; release them with 'stackmatch pins remove'\n", strings.Join(result.Pinned, ", "))
+		}
+		for _, warning := range result.Warnings {
+			fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+		}
+		printVerification(result.Verification)
+		fmt.Printf("\nCoverage: %s\n", result.Coverage.Breakdown(plan.Manager.Name()))
+		printManualSteps(recordID, result.ManualSteps)
+	},
+}
 
-		if dryRun {
-			fmt.Println("Note: This is a dry run. No changes have been made to your system.")
+// printVerification prints the state of every package checked after
+// installation, followed by a count of each state
+func printVerification(results []installer.PackageVerification) {
+	if len(results) == 0 {
+		return
+	}
+
+	fmt.Println("\nVerification:")
+	counts := make(map[string]int)
+	for _, result := range results {
+		counts[result.State]++
+		line := fmt.Sprintf("  %-12s %s", result.State, result.Package)
+		if result.Installed != "" {
+			line += " " + result.Installed
+		}
+		if result.Constraint != "" {
+			line += fmt.Sprintf(" (wanted %s)", result.Constraint)
+		}
+		if result.Reason != "" {
+			line += ": " + result.Reason
+		}
+		fmt.Println(line)
+	}
+	fmt.Printf("%d satisfied, %d unsatisfied, %d unknown\n",
+		counts[installer.VerificationSatisfied], counts[installer.VerificationUnsatisfied], counts[installer.VerificationUnknown])
+}
+
+// printPlannedChanges plans the import, without scanning what is installed,
+// and prints what installing each package would do: as the package manager
+// reports it when it can tell without installing anything, and estimated
+// otherwise
+func printPlannedChanges(ctx context.Context, env types.EnvironmentData, shell string) {
+	planOpts := stackmatch.PlanOptions{Shell: shell, ConflictRules: loadConflictRules(), Scope: installScope()}
+	var err error
+	if brewPrefix != "" {
+		if planOpts.Manager, err = package_managers.NewHomebrewWithPrefix(brewPrefix); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
 			return
 		}
+	}
+	plan, err := stackmatch.Plan(ctx, env, planOpts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not plan the installation: %v\n", scopeHint(err))
+		printProbeReport(os.Stderr, err)
+		return
+	}
+	if len(plan.Items) == 0 && (planOpts.Scope == "" || len(plan.Runtimes)+len(plan.GlobalPackages) == 0) {
+		return
+	}
+	if err := stackmatch.DryRun(ctx, plan); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+
+	fmt.Printf("\nPlanned changes (%s):\n", plan.Manager.Name())
+	estimated := 0
+	for _, item := range plan.Items {
+		line := "  " + describeChange(*item.Change)
+		if item.Estimated {
+			line += " (estimated)"
+			estimated++
+		}
+		fmt.Println(line + describeScope(item))
+	}
+	// Where runtimes and global packages go only matters once a scope is
+	// asked for
+	if planOpts.Scope != "" {
+		for _, item := range plan.Runtimes {
+			manager := item.Manager
+			if manager == "" {
+				manager = plan.VersionManager.Name()
+			}
+			fmt.Printf("  %-10s %s with %s%s\n", "runtime", strings.TrimSpace(item.Name+" "+item.Version), manager, describeScope(item))
+		}
+		for _, item := range plan.GlobalPackages {
+			fmt.Printf("  %-10s %s with %s%s\n", "global", item.Package, item.Manager, describeScope(item))
+		}
+	}
+	if len(plan.Dependencies) > 0 {
+		names := make([]string, len(plan.Dependencies))
+		for i, dependency := range plan.Dependencies {
+			names[i] = dependency.Package
+		}
+		fmt.Printf("  and %d dependencies: %s\n", len(names), strings.Join(names, ", "))
+	}
+	if estimated > 0 {
+		fmt.Printf("Estimated changes assume the package is installed; %s can't tell what an install would do without installing.\n", plan.Manager.Name())
+	}
+	printConflicts(plan.Conflicts)
+	fmt.Println()
+}
+
+// installScope returns the scope given with --scope, or "" when none was
+// given, leaving each package manager's default
+func installScope() types.InstallScope {
+	if importScope == "" {
+		return ""
+	}
+	scope, err := types.ParseInstallScope(importScope)
+	if err != nil {
+		utils.ExitWithError(err)
+	}
+	return scope
+}
+
+// describeScope returns where item is installed for a planned change, such
+// as " [user]", or "" when no scope was asked for
+func describeScope(item stackmatch.PlanItem) string {
+	if importScope == "" || item.Scope == "" {
+		return ""
+	}
+	return " [" + string(item.Scope) + "]"
+}
+
+// scopeHint adds what to do instead to err when the package manager can't
+// install into the scope asked for
+func scopeHint(err error) error {
+	var unsupported *types.UnsupportedScopeError
+	if !errors.As(err, &unsupported) {
+		return err
+	}
+	return fmt.Errorf("%w\nUse --scope system, or install these entries through language-level backends that install into your home instead, such as mise or asdf for languages and npm or pip global packages for tools", err)
+}
+
+// conflictHint points to the mappings file when err is about packages that
+// can't be installed together: a conflict the package manager reported, or
+// one of the plan nobody picked a package for
+func conflictHint(err error) error {
+	var unresolved *types.UnresolvedConflictError
+	if errors.As(err, &unresolved) {
+		return fmt.Errorf("%w\nAdd a rule with prefer: set to one of them under conflicts in %s so import installs it without asking", err, config.MappingsFile())
+	}
+	var conflict *types.PackageConflictError
+	if !errors.As(err, &conflict) {
+		return err
+	}
+	return fmt.Errorf("%w\nList the packages that can't be installed together under conflicts in %s so import keeps only one of them", err, config.MappingsFile())
+}
+
+// loadConflictRules returns the conflict rules of the mappings file. Invalid
+// rules are fatal: silently dropping one would install packages the user
+// said can't go together.
+func loadConflictRules() []installer.ConflictRule {
+	rules, err := installer.LoadConflictRules(config.MappingsFile())
+	if err != nil {
+		utils.ExitWithError(err)
+	}
+	return rules
+}
+
+// askConflict asks which package of conflict to install, by number or
+// name, an empty answer picking the first. It fails when input ends, such
+// as in unattended runs, rather than picking one nobody chose.
+func askConflict(conflict installer.Conflict) (string, error) {
+	fmt.Printf("\nThese packages can't be installed together: %s\n", strings.Join(conflict.Packages, ", "))
+	if conflict.Rule.Reason != "" {
+		fmt.Printf("(%s)\n", conflict.Rule.Reason)
+	}
+	for i, pkg := range conflict.Packages {
+		fmt.Printf("  %d. %s\n", i+1, pkg)
+	}
+	pick := func(answer string) (string, bool) {
+		if answer == "" {
+			return conflict.Packages[0], true
+		}
+		if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= len(conflict.Packages) {
+			return conflict.Packages[n-1], true
+		}
+		if slices.Contains(conflict.Packages, answer) {
+			return answer, true
+		}
+		return "", false
+	}
+	answer, err := ui.Ask("Install which one? [1]: ", "", func(answer string) error {
+		if _, ok := pick(answer); !ok {
+			return fmt.Errorf("Please answer a number from 1 to %d or a package name.", len(conflict.Packages))
+		}
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("nobody picked which one to install: %w", err)
+	}
+	kept, _ := pick(answer)
+	return kept, nil
+}
 
-		// Start the installation process
-		fmt.Println("\nStarting installation...")
+// printConflicts lists the packages of the plan that can't be installed
+// together, and which of each is installed
+func printConflicts(conflicts []installer.ConflictDecision) {
+	if len(conflicts) == 0 {
+		return
+	}
+	fmt.Println("\nConflicting packages:")
+	for _, conflict := range conflicts {
+		line := fmt.Sprintf("  %s instead of %s (%s)", conflict.Kept, strings.Join(conflict.Dropped(), ", "), conflict.Decision)
+		if conflict.Decision == installer.DecisionUnresolved {
+			line = fmt.Sprintf("  %s (import asks which one to install)", strings.Join(conflict.Packages, " or "))
+		}
+		if conflict.Reason != "" {
+			line += ": " + conflict.Reason
+		}
+		fmt.Println(line)
+	}
+}
 
-		// Detect the best available package manager
-		pm, err := installer.DetectPackageManager()
+// describeChange returns the action of change followed by its package and
+// versions, such as "upgrade    nodejs 18.19.0 -> 20.11.1"
+func describeChange(change types.PackageChange) string {
+	line := fmt.Sprintf("%-10s %s", change.Action, change.Package)
+	switch {
+	case change.Action == types.ActionNone:
+		if change.From != "" {
+			line += " " + change.From
+		}
+		line += " (already installed)"
+	case change.Action == types.ActionHeld && change.From != "":
+		line += fmt.Sprintf(" (stays at %s)", change.From)
+	case change.From != "" && change.To != "":
+		line += fmt.Sprintf(" %s -> %s", change.From, change.To)
+	case change.To != "":
+		line += " " + change.To
+	}
+	return line
+}
+
+// runPreflight checks that plan is not bound to fail halfway, and exits with
+// what to fix when it is
+func runPreflight(ctx context.Context, plan *stackmatch.InstallPlan) {
+	fmt.Println("Running preflight checks...")
+	failed := 0
+	for _, result := range stackmatch.Preflight(ctx, plan) {
+		if result.Passed() {
+			continue
+		}
+		failed++
+		fmt.Fprintf(os.Stderr, "Preflight check failed (%s): %s\n", result.Check, result.Problem)
+		if result.Fix != "" {
+			fmt.Fprintf(os.Stderr, "  %s\n", result.Fix)
+		}
+	}
+	if failed > 0 {
+		utils.ExitWithError(fmt.Errorf("%d preflight checks failed; fix them or rerun with --skip-preflight", failed))
+	}
+}
+
+// confirmReinstalls lists the broken tools the plan would reinstall and asks
+// whether to go ahead, leaving them alone otherwise
+func confirmReinstalls(plan *stackmatch.InstallPlan) {
+	reinstalls := plan.Reinstalls()
+	if len(reinstalls) == 0 {
+		return
+	}
+	fmt.Println("These tools are installed but broken:")
+	for _, item := range reinstalls {
+		fmt.Printf("  - %s\n", item.Name)
+	}
+	ok, err := ui.Confirm("Reinstall them?", true)
+	if err != nil {
+		utils.ExitWithError(err)
+	}
+	if !ok {
+		plan.DeclineReinstalls()
+	}
+}
+
+// applyCronJobs asks, entry by entry, whether to add the crontab entries of
+// jobs missing from the user's crontab, adds those accepted and marks the
+// manual steps of every entry now in the crontab as done. Entries with
+// redacted secrets are left for the user to add.
+func applyCronJobs(ctx context.Context, jobs []types.ScheduledJob, result *stackmatch.InstallResult) {
+	missing, err := cron.Missing(ctx, runner.Default, jobs)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: not adding crontab entries: %v\n", err)
+		return
+	}
+
+	pending := make(map[string]bool)
+	var accepted []types.ScheduledJob
+	for _, job := range missing {
+		pending[job.Line()] = true
+		if redact.Redacted(job.Command) {
+			fmt.Printf("Skipping '%s': secrets in it were redacted, so add it by hand\n", job.Line())
+			continue
+		}
+		ok, err := ui.Confirm(fmt.Sprintf("Add '%s' to your crontab?", job.Line()), false)
+		if err != nil {
+			utils.ExitWithError(err)
+		}
+		if ok {
+			accepted = append(accepted, job)
+		}
+	}
+	if len(accepted) > 0 {
+		lines := make([]string, len(accepted))
+		for i, job := range accepted {
+			lines[i] = job.Line()
+		}
+		err := audit.Do(ctx, audit.Operation{Action: audit.ActionCrontab, Package: strings.Join(lines, "; ")}, func(ctx context.Context) error {
+			return cron.Append(ctx, runner.Default, accepted)
+		})
 		if err != nil {
-			utils.ExitWithError(fmt.Errorf("could not detect a supported package manager: %w", err))
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			return
 		}
+	}
+	for _, job := range accepted {
+		delete(pending, job.Line())
+	}
+	if len(accepted) > 0 {
+		fmt.Printf("Added %d entries to your crontab\n", len(accepted))
+	}
 
-		fmt.Printf("Using package manager: %s\n", pm.Name())
+	done := make(map[string]bool)
+	for _, job := range jobs {
+		if job.Source == types.JobSourceCrontab && !pending[job.Line()] {
+			done[stackmatch.ScheduledJobStep(job).Description] = true
+		}
+	}
+	for i, step := range result.ManualSteps {
+		if step.Category == types.CategoryScheduledJobs && done[step.Description] {
+			result.ManualSteps[i].Done = true
+		}
+	}
+}
 
-		// Collect all packages to install
-		var packagesToInstall []string
+// applyShellInits marks the shell init steps of the rc files that already
+// load their version manager as done and, with --apply-shell-init, adds the
+// missing init lines to them first
+func applyShellInits(ctx context.Context, inits []shellinit.Init, result *stackmatch.InstallResult) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: not checking shell init: %v\n", err)
+		return
+	}
 
-		// Add tools
-		for tool := range envData.Tools {
-			packagesToInstall = append(packagesToInstall, tool)
+	done := make(map[string]bool)
+	for _, init := range inits {
+		if init.Snippet == "" {
+			continue
 		}
+		if applyShellInit {
+			var added bool
+			op := audit.Operation{Action: audit.ActionShellInit, Package: init.Manager, File: init.Path(home)}
+			err := audit.Do(ctx, op, func(context.Context) error {
+				var err error
+				added, err = shellinit.Apply(home, init)
+				return err
+			})
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+				continue
+			}
+			if added {
+				fmt.Printf("Added the %s init to %s (backed up to %s%s)\n", init.Manager, init.File, init.File, shellinit.BackupSuffix)
+			}
+			done[stackmatch.ShellInitStep(init).Description] = true
+			continue
+		}
+		if content, err := os.ReadFile(init.Path(home)); err == nil && init.Configured(string(content)) {
+			done[stackmatch.ShellInitStep(init).Description] = true
+		}
+	}
+	for i, step := range result.ManualSteps {
+		if step.Category == types.CategoryVersionManagers && done[step.Description] {
+			result.ManualSteps[i].Done = true
+		}
+	}
+}
 
-		// Add package managers
-		for pm := range envData.PackageManagers {
-			packagesToInstall = append(packagesToInstall, pm)
+// applyURLRewrites asks, rule by rule, whether to add the git URL rewrites
+// missing from the user's global git config, adds those accepted and marks
+// the manual steps of every rule now in the config as done. Rules with
+// redacted secrets, and rules for a prefix the config already rewrites
+// elsewhere, are left for the user.
+func applyURLRewrites(ctx context.Context, rewrites []types.URLRewrite, result *stackmatch.InstallResult) {
+	if len(rewrites) == 0 {
+		return
+	}
+	missing, conflicting, err := gitconfig.Missing(ctx, runner.Default, rewrites)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: not adding git URL rewrites: %v\n", err)
+		return
+	}
+
+	pending := make(map[types.URLRewrite]bool)
+	for _, rule := range conflicting {
+		pending[rule] = true
+		fmt.Printf("Skipping %s = %s: your git config already rewrites %s elsewhere\n", rule.Key(), rule.InsteadOf, rule.InsteadOf)
+	}
+	added := 0
+	for _, rule := range missing {
+		pending[rule] = true
+		if redact.Redacted(rule.Base) || redact.Redacted(rule.InsteadOf) {
+			fmt.Printf("Skipping %s: secrets in it were redacted, so add it by hand\n", rule.Key())
+			continue
 		}
+		ok, err := ui.Confirm(fmt.Sprintf("Rewrite git URLs starting with %s to %s?", rule.InsteadOf, rule.Base), false)
+		if err != nil {
+			utils.ExitWithError(err)
+		}
+		if !ok {
+			continue
+		}
+		err = audit.Do(ctx, audit.Operation{Action: audit.ActionGitConfig, Package: rule.Key() + " " + rule.InsteadOf}, func(ctx context.Context) error {
+			return gitconfig.Add(ctx, runner.Default, rule)
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			continue
+		}
+		delete(pending, rule)
+		added++
+	}
+	if added > 0 {
+		fmt.Printf("Added %d URL rewrites to your global git config\n", added)
+	}
 
-		// Add code editors
-		for editor := range envData.CodeEditors {
-			packagesToInstall = append(packagesToInstall, editor)
+	done := make(map[string]bool)
+	for _, rule := range rewrites {
+		if !pending[rule] {
+			done[stackmatch.URLRewriteStep(rule).Description] = true
+		}
+	}
+	for i, step := range result.ManualSteps {
+		if step.Category == types.CategoryGitConfig && done[step.Description] {
+			result.ManualSteps[i].Done = true
 		}
+	}
+}
+
+// printGitSettingChanges lists the git settings that differ from this
+// machine's global git config, with the command that would set each
+func printGitSettingChanges(ctx context.Context, settings map[string]string) {
+	list, err := gitconfig.ReadGlobal(ctx, runner.Default)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: not comparing git settings: %v\n", err)
+		return
+	}
+	var current map[string]string
+	if config := gitconfig.Parse(list); config != nil {
+		current = config.Settings
+	}
+	changes := gitconfig.Compare(settings, current)
+	if len(changes) == 0 {
+		return
+	}
 
-		// Remove duplicates and sort
-		packagesToInstall = uniqueStrings(packagesToInstall)
+	fmt.Println("These git settings differ from your global git config:")
+	for _, change := range changes {
+		now := change.Current
+		if now == "" {
+			now = "not set"
+		}
+		fmt.Printf("  %s (now %s)\n", gitconfig.SetCommand(change.Key, change.Value), now)
+	}
+	fmt.Println()
+}
 
-		// Install packages
-		fmt.Printf("Installing %d packages...\n", len(packagesToInstall))
-		startTime := time.Now()
+// applyLanguageConfig lists the language settings that differ on this
+// machine, with the exact command and file each would write, and applies
+// the Go and npm ones if the user agrees. pip settings and settings with
+// redacted secrets are left for the user. Shell init files are never changed.
+func applyLanguageConfig(ctx context.Context, config types.LanguageConfig, result *stackmatch.InstallResult) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: not applying language settings: %v\n", err)
+		return
+	}
+	current, warnings := langconfig.Read(ctx, runner.Default, runner.DefaultPath, home)
+	for _, warning := range warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+	}
+
+	var changes []langconfig.Change
+	for _, change := range langconfig.Compare(config, current) {
+		if langconfig.Applicable(change.LanguageSetting) {
+			changes = append(changes, change)
+		}
+	}
+	if len(changes) == 0 {
+		return
+	}
 
-		err = pm.InstallMultiple(cmd.Context(), packagesToInstall)
+	fmt.Println("\nThese language settings differ from the imported environment:")
+	for _, change := range changes {
+		now := change.Current
+		if now == "" {
+			now = "not set"
+		}
+		fmt.Printf("  %s (now %s; writes %s)\n", strings.Join(langconfig.Command(change.LanguageSetting, home), " "), now, langconfig.Target(change.LanguageSetting))
+	}
+	ok, err := ui.Confirm("Run these commands?", false)
+	if err != nil {
+		utils.ExitWithError(err)
+	}
+	if !ok {
+		return
+	}
+
+	done := make(map[string]bool)
+	for _, change := range changes {
+		op := audit.Operation{Action: audit.ActionLanguageConfig, Package: change.Name(), File: langconfig.Target(change.LanguageSetting)}
+		err := audit.Do(ctx, op, func(ctx context.Context) error {
+			return langconfig.Apply(ctx, runner.Default, change.LanguageSetting, home)
+		})
 		if err != nil {
-			utils.ExitWithError(fmt.Errorf("failed to install packages: %w", err))
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			continue
+		}
+		done[stackmatch.LanguageSettingStep(change.LanguageSetting).Description] = true
+	}
+	if len(done) > 0 {
+		fmt.Printf("Wrote %d language settings\n", len(done))
+	}
+	for i, step := range result.ManualSteps {
+		if step.Category == types.CategoryLanguageConfig && done[step.Description] {
+			result.ManualSteps[i].Done = true
 		}
+	}
+}
 
-		elapsed := time.Since(startTime)
-		fmt.Printf("\nInstallation completed in %s\n", elapsed.Round(time.Second))
-	},
+// applyServices asks, service by service, whether to enable the equivalents
+// on this machine of the developer services in wanted, enables those
+// accepted and marks the manual steps of every service now enabled as done.
+// Services whose package is not installed here are left for the user, and
+// system services are only changed with --system-services.
+func applyServices(ctx context.Context, wanted []types.Service, result *stackmatch.InstallResult) {
+	installed, warnings := services.List(ctx, runner.Default, runner.DefaultPath, runtime.GOOS)
+	for _, warning := range warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+	}
+	matches := services.Match(wanted, installed)
+
+	enabled := make(map[string]bool)
+	asked := make(map[string]bool)
+	count := 0
+	for _, service := range wanted {
+		local, found := matches[service.Name]
+		if !found || asked[service.Name] {
+			continue
+		}
+		asked[service.Name] = true
+		if local.Enabled {
+			enabled[service.Name] = true
+			continue
+		}
+		if local.Scope == types.ServiceScopeSystem && !systemServices {
+			fmt.Printf("Skipping the %s service: %s is a system service, so enable it by hand or import with --system-services\n", service.Name, local.Unit)
+			continue
+		}
+		command := strings.Join(services.EnableCommand(local.Service), " ")
+		ok, err := ui.Confirm(fmt.Sprintf("Enable the %s service with '%s'?", service.Name, command), false)
+		if err != nil {
+			utils.ExitWithError(err)
+		}
+		if !ok {
+			continue
+		}
+		err = audit.Do(ctx, audit.Operation{Action: audit.ActionService, Package: local.Unit, Manager: local.Manager}, func(ctx context.Context) error {
+			return services.Enable(ctx, runner.Default, local.Service)
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			continue
+		}
+		enabled[service.Name] = true
+		count++
+	}
+	if count > 0 {
+		fmt.Printf("Enabled %d services\n", count)
+	}
+
+	done := make(map[string]bool)
+	for _, service := range wanted {
+		if enabled[service.Name] {
+			done[stackmatch.ServiceStep(service).Description] = true
+		}
+	}
+	for i, step := range result.ManualSteps {
+		if step.Category == types.CategoryServices && done[step.Description] {
+			result.ManualSteps[i].Done = true
+		}
+	}
 }
 
-// uniqueStrings returns a new slice containing unique strings from the input slice
-func uniqueStrings(input []string) []string {
-	unique := make(map[string]bool)
-	var result []string
+// applyCorepack asks whether to run 'corepack enable', which the source
+// environment had done, and marks its manual step as done if it succeeds
+func applyCorepack(ctx context.Context, result *stackmatch.InstallResult) {
+	if _, err := runner.DefaultPath.LookPath("corepack"); err != nil {
+		fmt.Println("Skipping 'corepack enable': corepack is not on PATH; it ships with Node.js 16.9 to 24")
+		return
+	}
+	ok, err := ui.Confirm("Enable corepack with 'corepack enable'?", false)
+	if err != nil {
+		utils.ExitWithError(err)
+	}
+	if !ok {
+		return
+	}
+	var stderr string
+	err = audit.Do(ctx, audit.Operation{Action: audit.ActionCorepack, Package: "corepack"}, func(ctx context.Context) error {
+		var err error
+		_, stderr, err = runner.Default.Output(ctx, "corepack", "enable")
+		return err
+	})
+	if err != nil {
+		if message := strings.TrimSpace(stderr); message != "" {
+			err = fmt.Errorf("%w: %s", err, message)
+		}
+		fmt.Fprintf(os.Stderr, "Warning: could not enable corepack: %v\n", err)
+		return
+	}
+	fmt.Println("Enabled corepack")
 
-	for _, item := range input {
-		if !unique[item] {
-			unique[item] = true
-			result = append(result, item)
+	done := stackmatch.CorepackStep().Description
+	for i, step := range result.ManualSteps {
+		if step.Category == types.CategoryLanguageConfig && step.Description == done {
+			result.ManualSteps[i].Done = true
 		}
 	}
+}
+
+// readEnvironmentSource loads an environment from a StackMatch JSON file, a
+// version file such as .tool-versions or .nvmrc, or a project directory
+// containing version files. Conflicting versions are reported on stderr.
+func readEnvironmentSource(path string) (*types.EnvironmentData, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read %s: %w", path, err)
+	}
+
+	var result *toolversions.Result
+	switch {
+	case info.IsDir():
+		result, err = toolversions.LoadDir(path)
+	case toolversions.IsVersionFile(path):
+		result, err = toolversions.Load(path)
+	default:
+		return readEnvironmentFile(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, conflict := range result.Conflicts {
+		fmt.Fprintf(os.Stderr, "Warning: conflicting versions for %s\n", conflict)
+	}
+
+	env := result.Environment()
+	env.StackmatchVersion = cliVersion
+	env.Summary = types.BuildSummary(&env)
+	return &env, nil
+}
+
+// readEnvironmentFile loads an environment previously written by export or scan,
+// as JSON, YAML or TOML. Text around the JSON is rejected unless --repair was
+// passed.
+func readEnvironmentFile(path string) (*types.EnvironmentData, error) {
+	fileContent, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read file %s: %w", path, err)
+	}
+	// YAML and TOML files are read as the JSON file they were written from
+	if format := exporter.DetectFormat(path, fileContent); format != exporter.FormatJSON {
+		if fileContent, err = exporter.ToJSON(fileContent, format); err != nil {
+			return nil, fmt.Errorf("could not parse %s: %w", path, err)
+		}
+	}
+
+	envData, err := envfile.Parse(fileContent, envfile.Options{Repair: repairInput, Warn: printReadWarning})
+	if err != nil {
+		return nil, fmt.Errorf("could not parse %s: %w", path, err)
+	}
+
+	return envData, nil
+}
+
+// writeEnvironmentFile writes env back to the file at path in the format
+// the file is in, such as YAML, so commands that update a file in place
+// don't turn it into JSON
+func writeEnvironmentFile(env *types.EnvironmentData, path string) error {
+	original, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return exporter.Write(*env, path, exporter.DetectFormat(path, original))
+}
+
+// forceNewer lets import, pull and clone use environments written by a newer
+// major release
+var forceNewer bool
+
+// checkCompatibility warns about environments written by a newer release and
+// stops on a newer major release unless --force was passed
+func checkCompatibility(env *types.EnvironmentData) {
+	warning, err := stackmatch.CheckCompatibility(env)
+	if err != nil {
+		if !forceNewer {
+			utils.ExitWithError(fmt.Errorf("%w, or pass --force to continue anyway", err))
+		}
+		warning = err.Error()
+	}
+	if warning != "" {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+	}
+}
 
-	return result
+// printReadWarning reports text skipped while repairing an environment
+func printReadWarning(message string) {
+	fmt.Fprintf(os.Stderr, "Warning: %s\n", message)
 }
 
 func init() {
@@ -235,5 +1184,23 @@ func init() {
 	importCmd.Flags().BoolVar(&sourceSupabase, "from-supabase", false, "Import from Supabase instead of a local file")
 	importCmd.Flags().StringVar(&supabaseID, "id", "", "Environment ID to import from Supabase")
 	importCmd.Flags().BoolVarP(&importListOnly, "list-only", "l", false, "Only list environment details without importing")
+	importCmd.Flags().BoolVar(&repairInput, "repair", false, "Skip non-JSON text (such as log lines) around the environment in the file")
+	importCmd.Flags().StringVar(&importScope, "scope", "", "Install system-wide (system) or into your own prefix (user), such as ~/homebrew and ~/.local")
+	importCmd.Flags().StringVar(&brewPrefix, "brew-prefix", "", "Install with the Homebrew at this prefix (e.g. /opt/homebrew) instead of the one first on PATH")
+	importCmd.Flags().BoolVar(&forceNewer, "force", false, "Import environments written by a newer major release of stackmatch")
+	importCmd.Flags().BoolVar(&requiredOnly, "required-only", false, "Only install the entries the environment marks as required")
+	importCmd.Flags().BoolVar(&importPin, "pin", false, "Hold packages installed for versioned entries at their version so system upgrades leave them alone")
+	importCmd.Flags().BoolVar(&noVerify, "no-verify", false, "Don't check the installed packages against their versions after installation")
+	importCmd.Flags().BoolVar(&failFast, "fail-fast", false, "Install packages one at a time, verifying each, and stop at the first that fails")
+	importCmd.Flags().IntVar(&minCoverage, "min-coverage", 0, "Stop before installing when the plan covers less than this percentage of the environment")
+	importCmd.Flags().BoolVar(&skipPreflight, "skip-preflight", false, "Install without checking disk space, repository reachability and package manager health first")
+	importCmd.Flags().BoolVar(&applyShellInit, "apply-shell-init", false, "Add the lines version managers such as nvm and pyenv need to your shell's rc file, backing it up first")
+	importCmd.Flags().BoolVar(&applyCron, "apply-cron", false, "Add the environment's crontab entries missing from your crontab, confirming each one")
+	importCmd.Flags().BoolVar(&systemServices, "system-services", false, "Also offer to enable system services, such as systemd system units and Windows services")
+	importCmd.Flags().StringVar(&simulateDir, "simulate", "", "Run the installation against the command outputs recorded in this directory instead of this machine")
+	importCmd.Flags().StringVar(&importRollback, "rollback", "", "Uninstall the packages of this installation from 'stackmatch history' and release its pins, instead of importing")
+	importCmd.Flags().BoolVar(&removeDependencies, "remove-dependencies", false, "With --rollback, also remove the dependencies installed with each package (Chocolatey)")
+	importCmd.Flags().StringVar(&recordDir, "record", "", "Record the outputs of the commands run to this directory, for use with --simulate")
+	importCmd.MarkFlagsMutuallyExclusive("simulate", "record")
 	rootCmd.AddCommand(importCmd)
 }
@@ -4,18 +4,33 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"os"
 
 	"github.com/MRQ67/stackmatch-cli/pkg/auth"
 	"github.com/MRQ67/stackmatch-cli/pkg/supabase"
 	"github.com/spf13/cobra"
 )
 
+var (
+	listPages     pageFlags
+	listPorcelain bool
+)
+
 var listCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List your environments stored in Supabase",
 	Long:  `Lists all of the environments that you have pushed to Supabase.`,
 	PreRunE: requireAuth,
 	Run: func(cmd *cobra.Command, args []string) {
+		pages := listPages
+		if listPorcelain {
+			pages = pages.unlimited(cmd)
+		}
+		page, err := pages.toPage()
+		if err != nil {
+			log.Fatal(err)
+		}
+
 		// Get the current user
 		user := auth.GetCurrentUser()
 		if user == nil {
@@ -29,24 +44,32 @@ var listCmd = &cobra.Command{
 		}
 
 		// List the environments
-		environments, err := supabaseClient.ListEnvironments(context.Background(), user.ID)
+		environments, total, err := supabaseClient.ListEnvironmentInfo(context.Background(), user.ID, page)
 		if err != nil {
 			log.Fatalf("Failed to list environments: %v", err)
 		}
 
 		// Print the environments
+		if listPorcelain {
+			writeListPorcelain(os.Stdout, environments)
+			return
+		}
 		if len(environments) == 0 {
+			if total > 0 {
+				fmt.Printf("No environments on this page; you have %d in total.\n", total)
+				return
+			}
 			fmt.Println("You don't have any environments stored in Supabase.")
 			return
 		}
 
-		fmt.Println("Your environments:")
-		for _, env := range environments {
-			fmt.Printf("- %s\n", env.Name)
-		}
+		writeEnvironmentList(os.Stdout, environments)
+		listPages.printPageFooter(page, len(environments), total)
 	},
 }
 
 func init() {
+	addPageFlags(listCmd, &listPages)
+	addPorcelainFlag(listCmd, &listPorcelain)
 	rootCmd.AddCommand(listCmd)
 }
@@ -1,24 +1,47 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
 	"text/tabwriter"
 
-	"github.com/MRQ67/stackmatch-cli/pkg/supabase"
+	"github.com/MRQ67/stackmatch-cli/internal/utils"
 	"github.com/MRQ67/stackmatch-cli/pkg/auth"
+	"github.com/MRQ67/stackmatch-cli/pkg/supabase"
+	"github.com/MRQ67/stackmatch-cli/pkg/ui"
 	"github.com/spf13/cobra"
 )
 
-// envRow is defined in pull.go
+var logPages pageFlags
 
 var logCmd = &cobra.Command{
 	Use:   "log",
 	Short: "List all your environments",
-	Long:  `Lists all environments for the currently authenticated user.`,
+	Long:  `Lists all environments for the currently authenticated user, newest first.
+
+With --changelog <name>, prints what changed in one environment between two
+of its pushed versions instead, like release notes: which languages and tools
+were added, removed or moved to another version. --to defaults to the latest
+version and --from to the one before it. The changelog is Markdown, ready to
+paste into a team channel, or JSON with --json. Diff rules apply as they do
+to 'diff', so changes you don't care about are left out.`,
 	PreRunE: requireAuth,
 	Run: func(cmd *cobra.Command, args []string) {
+		if logChangelog != "" {
+			runChangelog(cmd.Context(), logChangelog)
+			return
+		}
+		if logFrom != 0 || logTo != 0 || logJSON {
+			utils.ExitWithError(fmt.Errorf("--from, --to and --json require --changelog"))
+		}
+
+		page, err := logPages.toPage()
+		if err != nil {
+			log.Fatal(err)
+		}
+
 		// Get current user
 		currentUser := auth.GetCurrentUser()
 		if currentUser == nil {
@@ -31,40 +54,50 @@ var logCmd = &cobra.Command{
 			log.Fatalf("Failed to initialize Supabase client: %v", err)
 		}
 
-		// Get all environments for the current user
-		var envs []envRow
-		_, err = supabaseClient.From("environments").
-			Select("*", "exact", false).
-			Eq("user_id", currentUser.ID).
-			ExecuteTo(&envs)
-
+		// Only metadata is fetched; the data blobs can be large
+		envs, total, err := supabaseClient.ListEnvironmentInfo(context.Background(), currentUser.ID, page)
 		if err != nil {
 			log.Fatalf("Failed to get environments: %v", err)
 		}
 
 		if len(envs) == 0 {
+			if total > 0 {
+				fmt.Printf("No environments on this page; you have %d in total.\n", total)
+				return
+			}
 			fmt.Println("No environments found. Push your first environment with 'stackmatch push'")
 			return
 		}
 
 		// Display environments in a table
 		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-		fmt.Fprintln(w, "NAME\tCREATED\tSIZE (bytes)")
+		fmt.Fprintln(w, "NAME\tCREATED\tSIZE")
 		for _, env := range envs {
 			createdAt := ""
 			if !env.CreatedAt.IsZero() {
-				createdAt = env.CreatedAt.Format("2006-01-02 15:04")
+				createdAt = ui.RelativeTime(env.CreatedAt)
+			}
+			size := "-"
+			if env.Size > 0 {
+				size = ui.HumanSize(int64(env.Size))
 			}
-			fmt.Fprintf(w, "%s\t%s\t%d\n",
+			fmt.Fprintf(w, "%s\t%s\t%s\n",
 				env.Name,
 				createdAt,
-				len(env.Data),
+				size,
 			)
 		}
 		w.Flush()
+		logPages.printPageFooter(page, len(envs), total)
 	},
 }
 
 func init() {
+	addPageFlags(logCmd, &logPages)
+	logCmd.Flags().StringVar(&logChangelog, "changelog", "", "Print the changelog of the environment `name` between two pushed versions")
+	logCmd.Flags().IntVar(&logFrom, "from", 0, "Version the changelog starts from (default: the one before --to)")
+	logCmd.Flags().IntVar(&logTo, "to", 0, "Version the changelog ends with (default: the latest)")
+	logCmd.Flags().BoolVar(&logJSON, "json", false, "Print the changelog as JSON")
+	addDiffRulesFlags(logCmd)
 	rootCmd.AddCommand(logCmd)
 }
@@ -0,0 +1,37 @@
+//go:build testmocks
+
+package cmd
+
+import (
+	"os"
+
+	"github.com/MRQ67/stackmatch-cli/internal/testmocks"
+	"github.com/MRQ67/stackmatch-cli/pkg/config"
+)
+
+func init() {
+	activateMocks = useTestMocks
+}
+
+// useTestMocks replays the fixture in the directory named by
+// STACKMATCH_TEST_MOCKS, when it is set. The configuration is detached so that
+// the fake Supabase URL is never saved.
+func useTestMocks(cfg *config.Config) error {
+	dir := os.Getenv(testmocks.EnvVar)
+	if dir == "" {
+		return nil
+	}
+	fixture, err := testmocks.Load(dir)
+	if err != nil {
+		return err
+	}
+	if err := fixture.Install(dir); err != nil {
+		return err
+	}
+	cfg.Detach()
+	if fixture.SupabaseURL != "" {
+		cfg.SupabaseURL = fixture.SupabaseURL
+		cfg.SupabaseAPIKey = "test-key"
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/supabase"
+	"github.com/spf13/cobra"
+)
+
+// pageFlags holds the pagination flags shared by listing commands
+type pageFlags struct {
+	limit  int
+	page   int
+	offset int
+}
+
+// addPageFlags registers --limit, --page and --offset on cmd
+func addPageFlags(cmd *cobra.Command, p *pageFlags) {
+	cmd.Flags().IntVar(&p.limit, "limit", supabase.DefaultPageLimit, "Maximum number of entries to show (0 for all)")
+	cmd.Flags().IntVar(&p.page, "page", 1, "Page of entries to show, starting at 1")
+	cmd.Flags().IntVar(&p.offset, "offset", 0, "Number of entries to skip (overrides --page)")
+	cmd.MarkFlagsMutuallyExclusive("page", "offset")
+}
+
+// unlimited returns p without the default --limit, for output read by
+// scripts, which expect every entry unless --limit is given
+func (p pageFlags) unlimited(cmd *cobra.Command) pageFlags {
+	if !cmd.Flags().Changed("limit") {
+		p.limit = 0
+	}
+	return p
+}
+
+// toPage validates the flags and converts them to a row window
+func (p pageFlags) toPage() (supabase.Page, error) {
+	switch {
+	case p.limit < 0:
+		return supabase.Page{}, fmt.Errorf("--limit must not be negative")
+	case p.page < 1:
+		return supabase.Page{}, fmt.Errorf("--page must be 1 or more")
+	case p.offset < 0:
+		return supabase.Page{}, fmt.Errorf("--offset must not be negative")
+	}
+	offset := p.offset
+	if offset == 0 && p.limit > 0 {
+		offset = (p.page - 1) * p.limit
+	}
+	return supabase.Page{Limit: p.limit, Offset: offset}, nil
+}
+
+// paginate returns the part of n entries selected by page, as slice bounds
+func paginate(n int, page supabase.Page) (int, int) {
+	start := min(page.Offset, n)
+	end := n
+	if page.Limit > 0 {
+		end = min(start+page.Limit, n)
+	}
+	return start, end
+}
+
+// printPageFooter tells the user how to see more when a page does not hold
+// every entry
+func (p pageFlags) printPageFooter(page supabase.Page, shown, total int) {
+	if page.Offset+shown >= total {
+		return
+	}
+	next := fmt.Sprintf("--page %d", page.Offset/max(page.Limit, 1)+2)
+	if p.offset > 0 || page.Limit == 0 || page.Offset%page.Limit != 0 {
+		next = fmt.Sprintf("--offset %d", page.Offset+shown)
+	}
+	fmt.Printf("\nShowing %d of %d, use %s to see more.\n", shown, total, next)
+}
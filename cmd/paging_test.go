@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/supabase"
+	"github.com/spf13/cobra"
+)
+
+func TestPageFlags(t *testing.T) {
+	testCases := []struct {
+		name     string
+		flags    pageFlags
+		expected supabase.Page
+		bounds   [2]int
+		wantErr  bool
+	}{
+		{name: "defaults", flags: pageFlags{limit: 50, page: 1}, expected: supabase.Page{Limit: 50}, bounds: [2]int{0, 50}},
+		{name: "second page", flags: pageFlags{limit: 50, page: 2}, expected: supabase.Page{Limit: 50, Offset: 50}, bounds: [2]int{50, 100}},
+		{name: "last page is partial", flags: pageFlags{limit: 50, page: 7}, expected: supabase.Page{Limit: 50, Offset: 300}, bounds: [2]int{300, 320}},
+		{name: "offset", flags: pageFlags{limit: 10, page: 1, offset: 15}, expected: supabase.Page{Limit: 10, Offset: 15}, bounds: [2]int{15, 25}},
+		{name: "no limit", flags: pageFlags{limit: 0, page: 1}, expected: supabase.Page{}, bounds: [2]int{0, 320}},
+		{name: "past the end", flags: pageFlags{limit: 50, page: 9}, expected: supabase.Page{Limit: 50, Offset: 400}, bounds: [2]int{320, 320}},
+		{name: "negative limit", flags: pageFlags{limit: -1, page: 1}, wantErr: true},
+		{name: "page zero", flags: pageFlags{limit: 50, page: 0}, wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			page, err := tc.flags.toPage()
+			if tc.wantErr {
+				if err == nil {
+					t.Fatal("expected an error but got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if page != tc.expected {
+				t.Errorf("expected %+v but got %+v", tc.expected, page)
+			}
+			start, end := paginate(320, page)
+			if [2]int{start, end} != tc.bounds {
+				t.Errorf("expected bounds %v but got [%d %d]", tc.bounds, start, end)
+			}
+		})
+	}
+}
+
+func TestPageFlagsUnlimited(t *testing.T) {
+	testCases := []struct {
+		args     []string
+		expected int
+	}{
+		{args: nil, expected: 0},
+		{args: []string{"--limit", "10"}, expected: 10},
+		{args: []string{"--limit", "50"}, expected: 50},
+	}
+
+	for _, tc := range testCases {
+		var flags pageFlags
+		cmd := &cobra.Command{Use: "list"}
+		addPageFlags(cmd, &flags)
+		if err := cmd.ParseFlags(tc.args); err != nil {
+			t.Fatal(err)
+		}
+		if got := flags.unlimited(cmd).limit; got != tc.expected {
+			t.Errorf("expected a limit of %d with %q but got %d", tc.expected, tc.args, got)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+//go:build !windows
+
+package cmd
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Files that older releases left readable by others are restricted on
+// startup, and everything the command writes is private
+func TestStateFilesArePrivate(t *testing.T) {
+	home := t.TempDir()
+	stateDir := filepath.Join(home, ".stackmatch")
+	if err := os.Mkdir(stateDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	tracker := filepath.Join(stateDir, "installations.json")
+	if err := os.WriteFile(tracker, []byte("{}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(stateDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command(cliBinaryPath, "history")
+	cmd.Env = append(os.Environ(), "HOME="+home, "XDG_CONFIG_HOME="+filepath.Join(home, ".config"), "STACKMATCH_STATS=1")
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("failed to run history command: %v\nOutput: %s", err, output)
+	}
+	if !strings.Contains(string(output), "readable only by you") || !strings.Contains(string(output), tracker) {
+		t.Errorf("expected a warning listing the corrected files but got:\n%s", output)
+	}
+
+	expected := map[string]os.FileMode{
+		stateDir:                               0o700,
+		tracker:                                0o600,
+		filepath.Join(stateDir, "stats.jsonl"): 0o600,
+		filepath.Join(stateDir, "config.json"): 0o600,
+	}
+	for path, mode := range expected {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Errorf("expected %s to exist: %v", path, err)
+			continue
+		}
+		if info.Mode().Perm() != mode {
+			t.Errorf("expected %s to have mode %o but got %o", path, mode, info.Mode().Perm())
+		}
+	}
+}
+
+// A ~/.stackmatch that is a link owned by another user is refused
+func TestForeignStateDirIsRefused(t *testing.T) {
+	if os.Getuid() != 0 {
+		t.Skip("changing the owner of a link needs root")
+	}
+	home := t.TempDir()
+	target := filepath.Join(home, "elsewhere")
+	if err := os.Mkdir(target, 0o777); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(home, ".stackmatch")
+	if err := os.Symlink(target, link); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Lchown(link, 65534, 65534); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command(cliBinaryPath, "history")
+	cmd.Env = append(os.Environ(), "HOME="+home, "XDG_CONFIG_HOME="+filepath.Join(home, ".config"))
+	output, err := cmd.CombinedOutput()
+	if err == nil || !strings.Contains(string(output), "symlink owned by another user") {
+		t.Errorf("expected the command to refuse the link but got %v:\n%s", err, output)
+	}
+}
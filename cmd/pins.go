@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/MRQ67/stackmatch-cli/internal/utils"
+	"github.com/MRQ67/stackmatch-cli/pkg/audit"
+	"github.com/MRQ67/stackmatch-cli/pkg/installer"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+	"github.com/spf13/cobra"
+)
+
+var pinsCmd = &cobra.Command{
+	Use:   "pins",
+	Short: "Manage packages pinned by 'import --pin'",
+	Long: `Lists and releases the packages 'stackmatch import --pin' held at their
+installed version, so system upgrades such as 'apt upgrade' or 'brew upgrade'
+leave them alone.`,
+}
+
+var pinsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List pinned packages",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		pins := recordedPins(openTracker())
+		if len(pins) == 0 {
+			fmt.Println("No pins recorded. Pin versioned packages with 'stackmatch import --pin'")
+			return
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "PACKAGE\tMANAGER\tINSTALLATION\tPINNED")
+		for _, pin := range pins {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", pin.Package, pin.ManagerType, pin.record.ID, pin.record.Timestamp.Format("2006-01-02 15:04"))
+		}
+		w.Flush()
+	},
+}
+
+var pinsRemoveCmd = &cobra.Command{
+	Use:   "remove <package>...",
+	Short: "Release pinned packages so upgrades can move them again",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		tracker := openTracker()
+		pinned := make(map[string]types.PackageManagerType)
+		for _, pin := range recordedPins(tracker) {
+			pinned[pin.Package] = pin.ManagerType
+		}
+		for _, pkg := range args {
+			if _, ok := pinned[pkg]; !ok {
+				utils.ExitWithError(fmt.Errorf("no pin recorded for package %s; see 'stackmatch pins list'", pkg))
+			}
+		}
+
+		manager, err := installer.DetectPackageManagerContext(cmd.Context())
+		if err != nil {
+			printProbeReport(os.Stderr, err)
+			utils.ExitWithError(err)
+		}
+		pinner, ok := manager.(types.Pinner)
+		if !ok {
+			utils.ExitWithError(fmt.Errorf("%s cannot release pins", manager.Name()))
+		}
+		for _, pkg := range args {
+			if pinned[pkg] != manager.Type() {
+				utils.ExitWithError(fmt.Errorf("%s was pinned with %s, but the package manager here is %s", pkg, pinned[pkg], manager.Name()))
+			}
+			err := audit.Do(cmd.Context(), audit.Operation{Action: audit.ActionUnpin, Package: pkg, Manager: manager.Name()}, func(ctx context.Context) error {
+				return pinner.UnpinPackage(ctx, pkg)
+			})
+			if err != nil {
+				utils.ExitWithError(err)
+			}
+			if err := tracker.RemovePin(pkg); err != nil {
+				utils.ExitWithError(err)
+			}
+			fmt.Printf("Released %s\n", pkg)
+		}
+	},
+}
+
+// recordedPin is a pin together with the installation that created it
+type recordedPin struct {
+	installer.Pin
+	record installer.InstallationRecord
+}
+
+// recordedPins returns the pins of every installation, sorted by package
+func recordedPins(tracker *installer.InstallationTracker) []recordedPin {
+	var pins []recordedPin
+	for _, record := range tracker.ListInstallations() {
+		for _, pin := range record.Pins {
+			pins = append(pins, recordedPin{Pin: pin, record: record})
+		}
+	}
+	sort.Slice(pins, func(i, j int) bool {
+		if pins[i].Package != pins[j].Package {
+			return pins[i].Package < pins[j].Package
+		}
+		return pins[i].record.Timestamp.Before(pins[j].record.Timestamp)
+	})
+	return pins
+}
+
+func init() {
+	pinsCmd.AddCommand(pinsListCmd)
+	pinsCmd.AddCommand(pinsRemoveCmd)
+	rootCmd.AddCommand(pinsCmd)
+}
@@ -0,0 +1,212 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/diff"
+	"github.com/MRQ67/stackmatch-cli/pkg/installer"
+	"github.com/MRQ67/stackmatch-cli/pkg/stackmatch"
+	"github.com/MRQ67/stackmatch-cli/pkg/supabase"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+	"github.com/MRQ67/stackmatch-cli/pkg/ui"
+	"github.com/spf13/cobra"
+)
+
+// Porcelain output (--porcelain on check, diff, list and history) is meant
+// for scripts and is a compatibility contract: each record is one line of
+// tab-separated fields, with no header, colors, progress output or page
+// footer. Fields are never added in the middle or reordered; new fields are
+// only appended, so scripts should ignore fields they do not know. Empty
+// fields are written as "-", tabs and newlines inside a field are replaced
+// by spaces, and times are RFC 3339 in UTC. Requirements are "required",
+// "optional" or "unclassified".
+//
+//	check    status  category  name  installed  wanted  requirement
+//	diff     kind    category  name  from       to      requirement
+//	list     id      name      visibility  created  size
+//	history  id      date      status  packages  pending
+
+// porcelainEscaper keeps each field on one line and within its column
+var porcelainEscaper = strings.NewReplacer("\t", " ", "\r", " ", "\n", " ")
+
+// addPorcelainFlag registers --porcelain on cmd
+func addPorcelainFlag(cmd *cobra.Command, porcelain *bool) {
+	cmd.Flags().BoolVar(porcelain, "porcelain", false, "Print one tab-separated record per line for scripts")
+	if cmd.Flags().Lookup("json") != nil {
+		cmd.MarkFlagsMutuallyExclusive("json", "porcelain")
+	}
+}
+
+// writePorcelainRecord writes fields as one porcelain line
+func writePorcelainRecord(w io.Writer, fields ...string) {
+	for i, field := range fields {
+		if field == "" {
+			field = "-"
+		}
+		fields[i] = porcelainEscaper.Replace(field)
+	}
+	fmt.Fprintln(w, strings.Join(fields, "\t"))
+}
+
+// porcelainTime formats t for porcelain output
+func porcelainTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// writeCheckTable prints a check result as an aligned table
+func writeCheckTable(w io.Writer, result stackmatch.CheckResult) {
+	// The requirement column is only shown for annotated environments
+	classified := false
+	// and the source column for scans that recorded provenance
+	traced := false
+	for _, item := range result.Items {
+		classified = classified || item.Requirement != types.Unclassified
+		traced = traced || item.Provenance != nil
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	header := "STATUS\tCATEGORY\tNAME\tINSTALLED\tWANTED"
+	if classified {
+		header += "\tREQUIREMENT"
+	}
+	if traced {
+		header += "\tSOURCE"
+	}
+	fmt.Fprintln(tw, header)
+	for _, item := range result.Items {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s", item.Status, item.Category, item.Name, item.Installed, item.Wanted)
+		if classified {
+			fmt.Fprintf(tw, "\t%s", item.Requirement)
+		}
+		if traced && item.Provenance != nil {
+			source := item.Provenance.String()
+			if item.Provenance.Conflict != "" {
+				source += " (conflict)"
+			}
+			fmt.Fprintf(tw, "\t%s", source)
+		} else if traced {
+			fmt.Fprint(tw, "\t")
+		}
+		fmt.Fprintln(tw)
+	}
+	tw.Flush()
+}
+
+// writeCheckPorcelain prints a check result in porcelain format
+func writeCheckPorcelain(w io.Writer, result stackmatch.CheckResult) {
+	for _, item := range result.Items {
+		writePorcelainRecord(w, string(item.Status), item.Category, item.Name, item.Installed, item.Wanted, item.Requirement.String())
+	}
+}
+
+// writeChanges prints one line per change using +, - and ~ markers
+func writeChanges(w io.Writer, changes []diff.Change) {
+	for _, c := range changes {
+		suffix := requirementSuffix(c.Requirement) + changeProvenanceSuffix(c)
+		switch c.Kind {
+		case diff.Added:
+			fmt.Fprintf(w, "+ %s/%s: %s%s\n", c.Category, c.Name, c.To, suffix)
+		case diff.Removed:
+			fmt.Fprintf(w, "- %s/%s: %s%s\n", c.Category, c.Name, c.From, suffix)
+		case diff.Changed:
+			fmt.Fprintf(w, "~ %s/%s: %s -> %s%s\n", c.Category, c.Name, c.From, c.To, suffix)
+		}
+	}
+}
+
+// changeProvenanceSuffix describes how a changed entry was installed in
+// each environment, when either recorded it
+func changeProvenanceSuffix(c diff.Change) string {
+	describe := func(p *types.Provenance) string {
+		if p == nil {
+			return "unknown"
+		}
+		return p.String()
+	}
+	switch {
+	case c.FromProvenance == nil && c.ToProvenance == nil:
+		return ""
+	case c.Kind == diff.Added:
+		return provenanceSuffix(*c.ToProvenance)
+	case c.Kind == diff.Removed:
+		return provenanceSuffix(*c.FromProvenance)
+	case c.FromProvenance != nil && c.ToProvenance != nil && *c.FromProvenance == *c.ToProvenance:
+		return provenanceSuffix(*c.ToProvenance)
+	default:
+		return fmt.Sprintf(" [%s -> %s]", describe(c.FromProvenance), describe(c.ToProvenance))
+	}
+}
+
+// writeDiffPorcelain prints changes in porcelain format
+func writeDiffPorcelain(w io.Writer, changes []diff.Change) {
+	for _, c := range changes {
+		writePorcelainRecord(w, string(c.Kind), c.Category, c.Name, c.From, c.To, c.Requirement.String())
+	}
+}
+
+// writeEnvironmentList prints stored environments as a bulleted list
+func writeEnvironmentList(w io.Writer, environments []supabase.EnvironmentInfo) {
+	fmt.Fprintln(w, "Your environments:")
+	for _, env := range environments {
+		var details []string
+		if env.Size > 0 {
+			details = append(details, ui.HumanSize(int64(env.Size)))
+		}
+		if !env.CreatedAt.IsZero() {
+			details = append(details, "pushed "+ui.RelativeTime(env.CreatedAt))
+		}
+		if len(details) > 0 {
+			fmt.Fprintf(w, "- %s (%s)\n", env.Name, strings.Join(details, ", "))
+		} else {
+			fmt.Fprintf(w, "- %s\n", env.Name)
+		}
+	}
+}
+
+// writeListPorcelain prints stored environments in porcelain format
+func writeListPorcelain(w io.Writer, environments []supabase.EnvironmentInfo) {
+	for _, env := range environments {
+		visibility := "private"
+		if env.IsPublic {
+			visibility = "public"
+		}
+		writePorcelainRecord(w, env.ID, env.Name, visibility, porcelainTime(env.CreatedAt), fmt.Sprint(env.Size))
+	}
+}
+
+// writeHistoryTable prints installation records as an aligned table
+func writeHistoryTable(w io.Writer, records []installer.InstallationRecord) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tDATE\tSTATUS\tPACKAGES\tSTEPS PENDING")
+	for _, record := range records {
+		fmt.Fprintf(tw, "%s\t%s (%s)\t%s\t%d\t%d\n",
+			record.ID,
+			record.Timestamp.Local().Format("2006-01-02 15:04"),
+			ui.RelativeTime(record.Timestamp),
+			record.Status,
+			len(record.Packages),
+			pendingSteps(record.ManualSteps),
+		)
+	}
+	tw.Flush()
+}
+
+// writeHistoryPorcelain prints installation records in porcelain format
+func writeHistoryPorcelain(w io.Writer, records []installer.InstallationRecord) {
+	for _, record := range records {
+		writePorcelainRecord(w,
+			record.ID,
+			porcelainTime(record.Timestamp),
+			string(record.Status),
+			fmt.Sprint(len(record.Packages)),
+			fmt.Sprint(pendingSteps(record.ManualSteps)),
+		)
+	}
+}
@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/diff"
+	"github.com/MRQ67/stackmatch-cli/pkg/installer"
+	"github.com/MRQ67/stackmatch-cli/pkg/stackmatch"
+	"github.com/MRQ67/stackmatch-cli/pkg/supabase"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+var updateGolden = flag.Bool("update", false, "Rewrite the golden files in testdata")
+
+// Porcelain output is a compatibility contract, so any change to these files
+// must be deliberate: run 'go test ./cmd -run TestPorcelainGolden -update'
+// and review the diff.
+func TestPorcelainGolden(t *testing.T) {
+	created := time.Date(2026, 10, 16, 9, 12, 44, 0, time.FixedZone("EAT", 3*60*60))
+
+	testCases := []struct {
+		name   string
+		render func(w io.Writer)
+	}{
+		{
+			name: "check",
+			render: func(w io.Writer) {
+				writeCheckPorcelain(w, stackmatch.CheckResult{Items: []diff.CheckItem{
+					{Category: "languages", Name: "Go", Wanted: ">=1.22", Installed: "1.22.3", Status: diff.StatusOK, Requirement: types.Required},
+					{Category: "tools", Name: "Docker", Wanted: "26.1.0", Status: diff.StatusMissing, Requirement: types.Optional},
+					{Category: "tools", Name: "Git", Wanted: "2.45.0", Installed: "2.39.5", Status: diff.StatusMismatch},
+					{Category: "tools", Name: "kubectl", Wanted: "1.30.0", Status: diff.StatusBroken},
+				}})
+			},
+		},
+		{
+			name: "diff",
+			render: func(w io.Writer) {
+				writeDiffPorcelain(w, []diff.Change{
+					{Category: "languages", Name: "Node.js", Kind: diff.Added, To: "20.11.0", Requirement: types.Required},
+					{Category: "tools", Name: "Docker", Kind: diff.Removed, From: "26.1.0"},
+					{Category: "tools", Name: "Git", Kind: diff.Changed, From: "2.39.5", To: "2.45.0"},
+					{Category: "system", Name: "shell", Kind: diff.Changed, From: "/bin/bash", To: "/usr/bin/fish\tlogin"},
+				})
+			},
+		},
+		{
+			name: "list",
+			render: func(w io.Writer) {
+				writeListPorcelain(w, []supabase.EnvironmentInfo{
+					{ID: "4f6c1a2e", Name: "backend", IsPublic: true, CreatedAt: created, Size: 2048},
+					{ID: "9b0d7e31", Name: "laptop\nsetup", CreatedAt: created.AddDate(0, 1, 0)},
+				})
+			},
+		},
+		{
+			name: "history",
+			render: func(w io.Writer) {
+				writeHistoryPorcelain(w, []installer.InstallationRecord{
+					{
+						ID:        "install-1760595164",
+						Timestamp: created,
+						Status:    installer.StatusCompleted,
+						Packages:  map[string]installer.PackageInfo{"git": {Name: "git"}, "nodejs": {Name: "nodejs"}},
+						ManualSteps: []types.ManualStep{
+							{Category: "shell", Description: "Restart your shell", Done: true},
+							{Category: "editor", Description: "Install the Go extension"},
+						},
+					},
+					{ID: "install-1760508764", Timestamp: created.AddDate(0, 0, -1), Status: installer.StatusFailed},
+				})
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			tc.render(&buf)
+
+			golden := filepath.Join("testdata", "porcelain", tc.name+".golden")
+			if *updateGolden {
+				if err := os.WriteFile(golden, buf.Bytes(), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			expected, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != string(expected) {
+				t.Errorf("expected:\n%s\nbut got:\n%s", expected, buf.String())
+			}
+		})
+	}
+}
+
+// TestPorcelainAwk pipes the output of the real binary through awk, as a
+// script would, to prove the records split into the documented fields. Like
+// any script, it must tolerate fields appended by later releases.
+func TestPorcelainAwk(t *testing.T) {
+	awk, err := exec.LookPath("awk")
+	if err != nil {
+		t.Skip("awk is not installed")
+	}
+	envFile := filepath.Join(t.TempDir(), "env.json")
+	content := `{"stackmatch_version": "0.3.0", "tools": {"StackMatchTestTool": "1.0.0", "StackMatchOtherTool": "2.0.0"}}`
+	if err := os.WriteFile(envFile, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := exec.Command(cliBinaryPath, "check", "--porcelain", envFile).Output()
+	if exitErr, ok := err.(*exec.ExitError); !ok || exitErr.ExitCode() != 1 {
+		t.Fatalf("expected check to fail with status 1 but got %v\nOutput: %s", err, output)
+	}
+
+	script := `NF < 5 { print "bad record: " $0; exit 1 } $1 == "missing" { print $2 "/" $3 "=" $5 }`
+	awkCmd := exec.Command(awk, "-F", "\t", script)
+	awkCmd.Stdin = bytes.NewReader(output)
+	parsed, err := awkCmd.Output()
+	if err != nil {
+		t.Fatalf("awk failed: %v\nOutput: %s\nInput: %s", err, parsed, output)
+	}
+	lines := strings.Fields(string(parsed))
+	expected := []string{"tools/StackMatchOtherTool=2.0.0", "tools/StackMatchTestTool=1.0.0"}
+	if strings.Join(lines, " ") != strings.Join(expected, " ") {
+		t.Errorf("expected %q but got %q", expected, lines)
+	}
+}
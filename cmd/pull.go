@@ -10,6 +10,8 @@ import (
 
 	"github.com/MRQ67/stackmatch-cli/pkg/supabase"
 	"github.com/MRQ67/stackmatch-cli/pkg/auth"
+	"github.com/MRQ67/stackmatch-cli/pkg/envfile"
+	"github.com/MRQ67/stackmatch-cli/pkg/ui"
 	"github.com/spf13/cobra"
 )
 
@@ -23,6 +25,8 @@ type envRow struct {
 	ID        string          `json:"id"`
 	Name      string          `json:"name"`
 	Data      json.RawMessage `json:"data"`
+	// DataSHA256 is the checksum of Data recorded on push
+	DataSHA256 string         `json:"data_sha256"`
 	UserID    string          `json:"user_id"`
 	IsPublic  bool            `json:"is_public"`
 	CreatedAt time.Time       `json:"created_at"`
@@ -69,16 +73,24 @@ func runPullCommand(cmd *cobra.Command, args []string) {
 		log.Fatal(err)
 	}
 
-	// Extract environment data
-	envData = env.Data
+	// Verify and extract environment data before anything is written
+	parsed, err := supabase.ParseStored(env.Data, env.DataSHA256, fmt.Sprintf("environment '%s'", env.Name), envfile.Options{Repair: true, Warn: printReadWarning})
+	if err != nil {
+		log.Fatalf("Failed to read environment '%s': %v", env.Name, err)
+	}
+	checkCompatibility(parsed)
+	envData, err = json.MarshalIndent(parsed, "", "  ")
+	if err != nil {
+		log.Fatalf("Failed to encode environment: %v", err)
+	}
 
 	// Output based on flags
 	if listOnly {
 		// Display environment details
 		fmt.Printf("Environment: %s\n", env.Name)
 		fmt.Printf("ID: %s\n", env.ID)
-		fmt.Printf("Created: %s\n", env.CreatedAt.Format(time.RFC1123))
-		fmt.Printf("Size: %d bytes\n", len(envData))
+		fmt.Printf("Created: %s (%s)\n", env.CreatedAt.Format(time.RFC1123), ui.RelativeTime(env.CreatedAt))
+		fmt.Printf("Size: %s\n", ui.HumanSize(int64(len(envData))))
 		return
 	}
 
@@ -124,5 +136,6 @@ func getEnvironment(ctx context.Context, client *supabase.Client, userID, envNam
 func init() {
 	pullCmd.Flags().StringVarP(&pullOutput, "output", "o", "", "Save output to a file instead of stdout")
 	pullCmd.Flags().BoolVarP(&listOnly, "list-only", "l", false, "Only list environment details without downloading")
+	pullCmd.Flags().BoolVar(&forceNewer, "force", false, "Use environments written by a newer major release of stackmatch")
 	rootCmd.AddCommand(pullCmd)
 }
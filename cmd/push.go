@@ -1,73 +1,40 @@
 package cmd
 
 import (
-	"bufio"
 	"context"
 	"fmt"
 	"log"
-	"os"
-	"strings"
 	"time"
 
 	"github.com/MRQ67/stackmatch-cli/pkg/auth"
 	"github.com/MRQ67/stackmatch-cli/pkg/scanner"
+	"github.com/MRQ67/stackmatch-cli/pkg/stackmatch"
 	"github.com/MRQ67/stackmatch-cli/pkg/types"
+	"github.com/MRQ67/stackmatch-cli/pkg/ui"
 	"github.com/spf13/cobra"
 )
 
-// promptForVisibility asks the user if the environment should be public
+// promptForVisibility asks the user if the environment should be public.
+// Environments stay private unless the user says yes.
 func promptForVisibility() (bool, error) {
-	reader := bufio.NewReader(os.Stdin)
-	for {
-		fmt.Print("Make this environment public? (y/n): ")
-		input, _ := reader.ReadString('\n')
-		input = strings.TrimSpace(strings.ToLower(input))
-		switch input {
-		case "y", "yes":
-			return true, nil
-		case "n", "no":
-			return false, nil
-		default:
-			fmt.Println("Please enter 'y' for yes or 'n' for no")
-		}
-	}
+	return ui.Confirm("Make this environment public?", false)
 }
 
-// scanEnvironment scans the current development environment
-func scanEnvironment() *types.EnvironmentData {
-	envData := &types.EnvironmentData{
-		Tools:              make(map[string]string),
-		PackageManagers:    make(map[string]string),
-		CodeEditors:        make(map[string]string),
-		ConfiguredLanguages: make(map[string]string),
-		ConfigFiles:        []string{},
-		System:             types.SystemInfo{},
+// scanEnvironment scans the current development environment, limited to
+// the named detectors unless detectors is nil, or reuses a fresh cached scan
+func scanEnvironment(ctx context.Context, detectors []string) *types.EnvironmentData {
+	envData, err := scanWithCache(ctx, stackmatch.ScanOptions{Detectors: detectors})
+	if err != nil {
+		log.Fatalf("Failed to scan environment: %v", err)
 	}
-
-	// Set scan timestamp
-	envData.ScanDate = time.Now()
-
-	// Detect system information
-	scanner.DetectSystemInfo(&envData.System)
-
-	// Detect package managers
-	scanner.DetectPackageManagers(envData)
-
-	// Detect programming languages
-	scanner.DetectProgrammingLanguages(envData)
-
-	// Detect development tools
-	scanner.DetectTools(envData)
-	// Detect code editors and IDEs
-	scanner.DetectEditors(envData)
-	// Scan for configuration files
-	scanner.DetectConfigFiles(envData)
-
-	return envData
+	return &envData
 }
 
 var (
 	isPublic bool
+	pushFile string
+	// noRedact pushes or exports the environment without RedactSensitive
+	noRedact bool
 )
 
 var pushCmd = &cobra.Command{
@@ -76,7 +43,17 @@ var pushCmd = &cobra.Command{
 	Long: `Scans the current development environment and uploads the configuration to Supabase.
 This requires authentication and Supabase URL/API key to be set.
 
-If a name is not provided as an argument, you will be prompted to enter one.`,
+If a name is not provided as an argument, you will be prompted to enter one.
+
+Use --file to push an environment file instead, for example one annotated with
+'stackmatch annotate' so the team knows which entries are required.
+
+Use --only or --skip to scan some categories and not others (see 'stackmatch
+scan --help').
+
+Credential files such as .git-credentials, .netrc and .env files are left out
+of the config files, and tokens recognized anywhere in the environment are
+replaced with [REDACTED]. --no-redact pushes the environment as it is.`,
 	Args:  cobra.MaximumNArgs(1),
 	PreRunE: requireAuth,
 	Run: func(cmd *cobra.Command, args []string) {
@@ -90,8 +67,26 @@ If a name is not provided as an argument, you will be prompted to enter one.`,
 			log.Fatalf("Configuration error: %v", err)
 		}
 
-		// Scan the environment
-		envData := scanEnvironment()
+		// Scan the environment, or push a file such as one annotated with
+		// required and optional entries
+		var envData *types.EnvironmentData
+		if pushFile != "" {
+			var err error
+			if envData, err = readEnvironmentFile(pushFile); err != nil {
+				log.Fatal(err)
+			}
+		} else {
+			detectors, err := scanDetectors()
+			if err != nil {
+				log.Fatal(err)
+			}
+			envData = scanEnvironment(cmd.Context(), detectors)
+		}
+		// A file may have been edited since it was scanned, so it is
+		// redacted too
+		if !noRedact {
+			scanner.RedactSensitive(envData)
+		}
 
 		// Get the current user from the session
 		user := auth.GetCurrentUser()
@@ -107,13 +102,11 @@ If a name is not provided as an argument, you will be prompted to enter one.`,
 
 		// If no name provided, prompt for one
 		if envName == "" {
-			reader := bufio.NewReader(os.Stdin)
-			fmt.Print("Enter a name for this environment: ")
-			input, _ := reader.ReadString('\n')
-			envName = strings.TrimSpace(input)
-
-			if envName == "" {
-				envName = fmt.Sprintf("Environment %s", time.Now().Format("2006-01-02 15:04"))
+			defaultName := fmt.Sprintf("Environment %s", time.Now().Format("2006-01-02 15:04"))
+			var err error
+			envName, err = ui.Ask("Enter a name for this environment: ", defaultName, nil)
+			if err != nil {
+				log.Fatalf("Failed to read environment name: %v", err)
 			}
 		}
 
@@ -147,5 +140,11 @@ If a name is not provided as an argument, you will be prompted to enter one.`,
 
 func init() {
 	pushCmd.Flags().BoolVarP(&isPublic, "public", "p", false, "Make the environment publicly accessible")
+	pushCmd.Flags().StringVar(&pushFile, "file", "", "Push this environment file instead of scanning")
+	pushCmd.Flags().BoolVar(&noRedact, "no-redact", false, "Push credential files and tokens instead of leaving them out")
+	pushCmd.Flags().StringSliceVar(&scanOnly, "only", nil, "Scan only these categories or detectors (repeatable)")
+	pushCmd.Flags().StringSliceVar(&scanSkip, "skip", nil, "Do not scan these categories or detectors (repeatable)")
+	pushCmd.MarkFlagsMutuallyExclusive("file", "only")
+	pushCmd.MarkFlagsMutuallyExclusive("file", "skip")
 	rootCmd.AddCommand(pushCmd)
 }
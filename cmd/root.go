@@ -1,13 +1,17 @@
 package cmd
 
 import (
+	"cmp"
 	"fmt"
 	"log"
 	"os"
 
+	"github.com/MRQ67/stackmatch-cli/internal/utils"
 	"github.com/MRQ67/stackmatch-cli/pkg/auth"
 	"github.com/MRQ67/stackmatch-cli/pkg/config"
+	"github.com/MRQ67/stackmatch-cli/pkg/installer"
 	"github.com/MRQ67/stackmatch-cli/pkg/supabase"
+	"github.com/MRQ67/stackmatch-cli/pkg/ui"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 )
@@ -19,17 +23,47 @@ var (
 	// Supabase client
 	supabaseClient *supabase.Client
 
+	// activateMocks substitutes scripted commands and a fake Supabase backend
+	// for the real ones. It is only set in binaries built with the testmocks
+	// tag (see mocks.go).
+	activateMocks func(*config.Config) error
+
+	// asciiOutput prints ASCII symbols instead of Unicode glyphs
+	asciiOutput bool
+
+	// noCache scans and probes the package manager again instead of reusing
+	// what an earlier command cached
+	noCache bool
+	// fallBackManager lets package manager detection pass over one that
+	// was found but can't be used
+	fallBackManager bool
+
+	// quiet never starts setup or asks to, for scripts
+	quiet bool
+
 	rootCmd = &cobra.Command{
 		Use:   "stackmatch",
 		Short: "StackMatch: Clone environments, not just code.",
-		Long:  `StackMatch is a CLI tool that helps developers scan, export, and import their development environment configurations.
+		Long: `StackMatch is a CLI tool that helps developers scan, export, and import their development environment configurations.
 It aims to eliminate "works on my machine" problems by providing a consistent way to manage development setups.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			// The first run walks new users through setup instead of listing
+			// every command
+			if offerOnboarding() {
+				if err := newOnboarding().run(cmd.Context()); err != nil {
+					utils.ExitWithError(err)
+				}
+				return
+			}
+			_ = cmd.Help()
+		},
 	}
 )
 
 func init() {
 	// Initialize config
 	cfg = config.New()
+	supabase.Notice = printNote
 
 	// Add commands directly to root
 	rootCmd.AddCommand(versionCmd)
@@ -47,8 +81,39 @@ func init() {
 	rootCmd.AddCommand(deleteCmd)
 	rootCmd.AddCommand(searchCmd)
 
+	rootCmd.PersistentFlags().BoolVar(&asciiOutput, "ascii", false, "Print ASCII symbols such as [OK] instead of Unicode glyphs (also STACKMATCH_ASCII=1)")
+	rootCmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false, "Never start the first-run setup, and leave out the progress of scans")
+	rootCmd.PersistentFlags().BoolVar(&noCache, "no-cache", false, "Scan and probe the package manager again instead of using cached results")
+	rootCmd.PersistentFlags().BoolVar(&fallBackManager, "fallback-package-manager", false, "Use the next package manager found when the preferred one can't be used, such as APT with the dpkg lock held")
+
 	// Persistent pre-run to validate config and handle flags
 	rootCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		if asciiOutput {
+			ui.SetASCII(true)
+		}
+		if warning := config.StateWarning(); warning != "" {
+			fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+		}
+		if err := secureStateDir(); err != nil {
+			return err
+		}
+		// doctor moves them itself, or only lists them with --dry-run
+		if cmd != doctorCmd {
+			migrateLegacyFiles()
+		}
+		startInvocation(cmd)
+		installer.Cache = installer.NewMetadataCache(config.PackageManagerCacheFile())
+		installer.Cache.Refresh = noCache
+		installer.FallBack = fallBackManager
+		supabase.Writes = supabase.NewRateLimiter(cmp.Or(cfg.WritesPerMinute, supabase.DefaultWritesPerMinute), cmp.Or(cfg.WriteBurst, supabase.DefaultWriteBurst), config.RateLimitFile())
+
+		if activateMocks != nil {
+			if err := activateMocks(cfg); err != nil {
+				return fmt.Errorf("failed to activate test mocks: %w", err)
+			}
+		}
+		startAudit(cmd)
+
 		// Update config from flags if provided
 		if err := cfg.BindFlags(pflag.CommandLine); err != nil {
 			return fmt.Errorf("failed to bind flags: %w", err)
@@ -61,6 +126,12 @@ func init() {
 			return fmt.Errorf("failed to initialize Supabase client: %w", err)
 		}
 
+		if remoteCommands[cmd.CommandPath()] {
+			if err := requireSync(cmd.CommandPath()); err != nil {
+				return err
+			}
+		}
+
 		// Validate config for all commands except auth commands
 		switch cmd.Name() {
 		case "login", "logout", "whoami", "register":
@@ -76,6 +147,8 @@ func init() {
 
 	// Save config on successful command execution (but not for auth commands)
 	rootCmd.PersistentPostRunE = func(cmd *cobra.Command, args []string) error {
+		recordInvocation(nil)
+
 		switch cmd.Name() {
 		case "login", "logout", "whoami":
 			return nil // Skip saving for auth commands
@@ -88,6 +161,22 @@ func init() {
 	}
 }
 
+// secureStateDir refuses a ~/.stackmatch that another user could control and
+// restricts files that older releases wrote readable by others
+func secureStateDir() error {
+	corrected, err := config.SecureStateDir()
+	if err != nil {
+		return err
+	}
+	if len(corrected) > 0 {
+		fmt.Fprintf(os.Stderr, "Warning: made %d path(s) under %s readable only by you:\n", len(corrected), config.StateDir())
+		for _, path := range corrected {
+			fmt.Fprintf(os.Stderr, "  %s\n", path)
+		}
+	}
+	return nil
+}
+
 // initSupabase initializes the Supabase client with the current configuration
 func initSupabase(supabaseURL, supabaseAPIKey string) (*supabase.Client, error) {
 	if supabaseURL == "" || supabaseAPIKey == "" {
@@ -108,6 +197,11 @@ func initSupabase(supabaseURL, supabaseAPIKey string) (*supabase.Client, error)
 	return client, nil
 }
 
+// printNote reports something worth knowing that isn't a problem
+func printNote(message string) {
+	fmt.Fprintf(os.Stderr, "Note: %s\n", message)
+}
+
 // requireAuth is a middleware that ensures the user is authenticated
 func requireAuth(cmd *cobra.Command, args []string) error {
 	if !auth.IsAuthenticated() {
@@ -120,6 +214,7 @@ func requireAuth(cmd *cobra.Command, args []string) error {
 func Execute() {
 	// Execute the command
 	if err := rootCmd.Execute(); err != nil {
+		recordInvocation(err)
 		log.Printf("Error: %v", err)
 		os.Exit(1)
 	}
@@ -0,0 +1,315 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/MRQ67/stackmatch-cli/internal/utils"
+	"github.com/MRQ67/stackmatch-cli/pkg/config"
+	"github.com/MRQ67/stackmatch-cli/pkg/exporter"
+	"github.com/MRQ67/stackmatch-cli/pkg/scanner"
+	"github.com/MRQ67/stackmatch-cli/pkg/stackmatch"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+	"github.com/MRQ67/stackmatch-cli/pkg/ui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	projectPath       string
+	loginShellProbe   bool
+	scanScheduledJobs bool
+	probeServices     bool
+	scanDeep          bool
+	scanConcurrency   int
+	scanOnly          []string
+	scanSkip          []string
+	scanFast          bool
+	scanFormat        string
+	scanOutput        string
+	scanManifest      string
+)
+
+var scanCmd = &cobra.Command{
+	Use:   "scan",
+	Short: "Scan the environment and summarize it, print it as JSON or write it to a file",
+	Long: `Scans the local development environment and prints the result as JSON.
+In a terminal, a summary is printed instead: the system, the number of
+entries found per category and the versions of the languages and key tools.
+Use --format json to print the JSON anyway, or --output <file> to write it to
+a file, in the format its extension names. When stdout is piped or
+redirected, the JSON is printed as before.
+
+Use --format yaml or --format toml to print it as YAML or TOML instead, with
+the keys of the JSON document, sorted. import, diff and the other commands
+reading environment files read these too, telling them apart by extension
+(.yaml, .yml, .toml) or by their first line.
+
+Use --only or --skip to scan some categories and not others, for example
+--only languages for a CI check. The categories are system, languages, tools,
+package-managers, editors, config-files, git-config, language-config, env-vars
+(toolchain variables such as JAVA_HOME, with secrets masked),
+version-managers, global-packages, services, containers (Docker images and
+running containers) and provenance (how the tools found were installed).
+Sections of categories not scanned are left out of the JSON. Both flags also
+take the name of a single detector, such as --skip docker-plugins; an
+unknown name lists the categories and detectors there are.
+
+The last scan is reused for 10 minutes (scan_cache_ttl in config.json) by
+scan, export and push when run with the same options, PATH and detectors
+file; --no-cache scans again.
+
+Use --path <dir> to describe a project rather than this machine's home: the
+config-files category then records the manifests found up to three
+directories deep in dir (go.mod, package.json, .nvmrc, Dockerfile, ...),
+skipping dependency and build directories such as node_modules, and the
+project section lists them with the versions they ask for, such as Node.js
+from .nvmrc or package.json engines and Go from go.mod.
+
+Use --services to also probe well-known local ports (5432, 6379, 3306, 27017
+and 9200) for running development services such as PostgreSQL and Redis and
+record their versions under running_services. Change the ports with
+service_ports in the detectors file.
+
+When conda, mamba or micromamba is found, its environments are recorded
+under conda. Use --deep to also record the versions of key packages, such as
+python and numpy, in each environment; it runs one 'conda list' per
+environment, which can take a while.
+
+Use --fast for a quick inventory that runs no version commands: languages,
+tools, package managers and editors are read from the package databases
+instead (dpkg's status file, 'brew info --installed', Scoop's apps directory
+and 'winget export') and matched to StackMatch's names through the package
+mappings. Their sources are recorded as package-db:<database>:<package>, and
+the fast_scan section lists the databases read and how the result can differ
+from a full scan: tools not installed through a package manager are missing,
+and versions are those of the packages. 'diff' leaves out the differences
+that come from comparing a fast scan with a full one.
+
+Use --manifest <env.json> to only look for the languages, tools, package
+managers and editors of an environment file, such as your team's, when
+checking for drift: only their version commands run, which makes repeat
+scans much faster than probing every tool StackMatch knows. Entries are
+matched by canonical ID, and those StackMatch doesn't know are probed by
+running their ID with --version. The other categories are scanned as usual.
+The result records the manifest under manifest_scan, and 'diff' leaves out
+the entries it didn't look for rather than reporting them as removed.
+
+Only the document is printed to stdout, so 'stackmatch scan > env.json' and
+'stackmatch scan | jq' work; progress, notes and warnings go to stderr, where
+each category is listed with what was found as soon as it is scanned.
+Use --quiet to leave out the progress and what each detector finds.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if !slices.Contains(exporter.DocumentFormats, scanFormat) {
+			utils.ExitWithError(fmt.Errorf("unknown format %q; use one of %s", scanFormat, strings.Join(exporter.DocumentFormats, ", ")))
+		}
+		detectors, err := scanDetectors()
+		if err != nil {
+			utils.ExitWithError(err)
+		}
+		manifest, err := loadScanManifest()
+		if err != nil {
+			utils.ExitWithError(err)
+		}
+		sections, waitSections := scanSections()
+		envData, err := scanWithCache(cmd.Context(), stackmatch.ScanOptions{
+			Progress:        scanProgress(),
+			Sections:        sections,
+			ProjectPath:     projectPath,
+			LoginShellProbe: loginShellProbe,
+			ScheduledJobs:   scanScheduledJobs,
+			ProbeServices:   probeServices,
+			Deep:            scanDeep,
+			Concurrency:     scanConcurrency,
+			Detectors:       detectors,
+			Fast:            scanFast,
+			Manifest:        manifest,
+		})
+		waitSections()
+		if err != nil {
+			utils.ExitWithError(fmt.Errorf("scan failed: %w", err))
+		}
+		printScanWarnings(envData)
+		recordScanCounts(envData)
+
+		if scanOutput != "" {
+			format := scanFormat
+			if !cmd.Flags().Changed("format") {
+				format = exporter.DetectFormat(scanOutput, nil)
+			}
+			if err := exporter.Write(envData, scanOutput, format); err != nil {
+				utils.ExitWithError(fmt.Errorf("could not write scan result: %w", err))
+			}
+			if !quiet {
+				fmt.Fprintf(os.Stderr, "Environment written to %s\n", scanOutput)
+			}
+			return
+		}
+		if !cmd.Flags().Changed("format") && ui.StdoutTerminal() {
+			writeScanSummary(os.Stdout, envData)
+			return
+		}
+
+		encoded, err := exporter.Encode(envData, scanFormat)
+		if err != nil {
+			utils.ExitWithError(fmt.Errorf("could not encode scan result: %w", err))
+		}
+
+		fmt.Println(strings.TrimSuffix(string(encoded), "\n"))
+	},
+}
+
+// scanProgress returns the progress of a scan, printed to stderr so stdout
+// holds nothing but the result. With --quiet there is none, and what the
+// detectors log as they go is dropped too; warnings are still printed.
+func scanProgress() stackmatch.Progress {
+	if quiet {
+		log.SetOutput(io.Discard)
+		return nil
+	}
+	return stackmatch.ProgressFunc(func(msg string) {
+		fmt.Fprintf(os.Stderr, "%s %s...\n", ui.Symbols().Bullet, msg)
+	})
+}
+
+// scanSections returns the channel a scan sends its sections on, which
+// prints each category to stderr as it completes with what was found so
+// far, and a function waiting for the last one to be printed once the scan
+// returns. With --quiet nothing is printed and the channel is nil.
+func scanSections() (chan<- stackmatch.ScanSection, func()) {
+	if quiet {
+		return nil, func() {}
+	}
+	sections := make(chan stackmatch.ScanSection)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for section := range sections {
+			fmt.Fprintf(os.Stderr, "%s %s\n", ui.Symbols().Success, describeSection(section))
+		}
+	}()
+	return sections, func() {
+		close(sections)
+		<-done
+	}
+}
+
+// describeSection says what a completed category of a scan found, such as
+// "languages: 6 found" or "system: linux/amd64 (Ubuntu 24.04)"
+func describeSection(section stackmatch.ScanSection) string {
+	env := section.Environment
+	if section.Category == types.CategorySystem {
+		description := fmt.Sprintf("system: %s/%s", env.System.OS, env.System.Arch)
+		if release := env.System.Release(); release != "" {
+			description += " (" + release + ")"
+		}
+		return description
+	}
+	if env.Summary != nil {
+		if n, ok := env.Summary.Counts[section.Category]; ok {
+			return fmt.Sprintf("%s: %d found", section.Category, n)
+		}
+	}
+	return section.Category + ": done"
+}
+
+// scanDetectors returns the names of the detectors selected with --only,
+// less those given to --skip, or nil to run every detector when neither is
+// used
+func scanDetectors() ([]string, error) {
+	return stackmatch.SelectDetectors(scanOnly, scanSkip)
+}
+
+// loadScanManifest returns the manifest read from the file given to
+// --manifest, or nil to look for everything
+func loadScanManifest() (*scanner.Manifest, error) {
+	if scanManifest == "" {
+		return nil, nil
+	}
+	env, err := readEnvironmentFile(scanManifest)
+	if err != nil {
+		return nil, err
+	}
+	return scanner.NewManifest(scanManifest, env), nil
+}
+
+// scanWithCache scans with opts, reusing the last scan while it is fresh
+// unless --no-cache is given or scan_cache_ttl is "0", and says so when it
+// does
+func scanWithCache(ctx context.Context, opts stackmatch.ScanOptions) (types.EnvironmentData, error) {
+	ttl, err := cfg.ScanCacheDuration(stackmatch.DefaultScanCacheTTL)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	var cache *stackmatch.ScanCache
+	if ttl > 0 {
+		cache = stackmatch.NewScanCache(config.ScanCacheFile(), ttl)
+		cache.Refresh = noCache
+	}
+	env, scannedAt, err := cache.Scan(ctx, opts)
+	if err == nil && !scannedAt.IsZero() {
+		printNote(fmt.Sprintf("using the scan from %s ago; run with --no-cache to scan again", ui.HumanDuration(time.Since(scannedAt))))
+	}
+	return env, err
+}
+
+// invalidateScanCache drops the cached scan after this machine changed,
+// such as after an import installed packages
+func invalidateScanCache() {
+	if err := stackmatch.NewScanCache(config.ScanCacheFile(), 0).Invalidate(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+}
+
+// printScanWarnings prints the warnings collected during a scan to stderr,
+// ending with the broken tools so they are not lost among other warnings
+func printScanWarnings(env types.EnvironmentData) {
+	for _, warning := range env.Warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+	}
+	if len(env.BrokenTools) > 0 {
+		names := make([]string, 0, len(env.BrokenTools))
+		for name := range env.BrokenTools {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		fmt.Fprintln(os.Stderr, ui.Warning("%d broken tool(s) on PATH: %s", len(names), strings.Join(names, ", ")))
+	}
+}
+
+// recordScanCounts adds the per-category counts of a scan to usage statistics
+func recordScanCounts(env types.EnvironmentData) {
+	if env.Summary == nil {
+		return
+	}
+	for category, n := range env.Summary.Counts {
+		recordCount(category, n)
+	}
+}
+
+func init() {
+	scanCmd.Flags().StringVar(&projectPath, "path", "", "Scan a project directory's manifests and pinned tool versions instead of the home directory's config files")
+	scanCmd.Flags().BoolVar(&loginShellProbe, "login-shell-probe", false, "Retry tools missing from PATH through your login shell (for nvm, sdkman, rbenv...)")
+	scanCmd.Flags().BoolVar(&scanScheduledJobs, "scheduled-jobs", false, "Also capture your crontab or scheduled tasks, with secrets in their commands redacted")
+	scanCmd.Flags().BoolVar(&probeServices, "services", false, "Also probe well-known local ports for running development services such as PostgreSQL and Redis")
+	scanCmd.Flags().BoolVar(&scanDeep, "deep", false, "Also list the key packages of every conda environment (slow)")
+	scanCmd.Flags().IntVar(&scanConcurrency, "concurrency", scanner.DefaultConcurrency, "Number of version commands to run at once")
+	scanCmd.Flags().StringSliceVar(&scanOnly, "only", nil, "Scan only these categories or detectors (repeatable)")
+	scanCmd.Flags().StringSliceVar(&scanSkip, "skip", nil, "Do not scan these categories or detectors (repeatable)")
+	scanCmd.Flags().StringVar(&scanFormat, "format", exporter.FormatJSON, "Format of the result: json, yaml or toml")
+	scanCmd.Flags().StringVarP(&scanOutput, "output", "o", "", "Write the result to this file instead of stdout")
+	scanCmd.Flags().BoolVar(&scanFast, "fast", false, "Read versions from package databases instead of running each tool")
+	scanCmd.Flags().StringVar(&scanManifest, "manifest", "", "Only look for the languages, tools, package managers and editors of this environment file")
+	scanCmd.MarkFlagsMutuallyExclusive("fast", "only")
+	scanCmd.MarkFlagsMutuallyExclusive("fast", "skip")
+	scanCmd.MarkFlagsMutuallyExclusive("fast", "login-shell-probe")
+	scanCmd.MarkFlagsMutuallyExclusive("fast", "manifest")
+	rootCmd.AddCommand(scanCmd)
+}
@@ -9,12 +9,19 @@ import (
 	"github.com/spf13/cobra"
 )
 
+var searchPages pageFlags
+
 var searchCmd = &cobra.Command{
 	Use:   "search [query]",
 	Short: "Search for public environments in Supabase",
 	Long:  `Searches for public environments in Supabase that you can clone.`,
 	Args:  cobra.MinimumNArgs(0),
 	Run: func(cmd *cobra.Command, args []string) {
+		page, err := searchPages.toPage()
+		if err != nil {
+			log.Fatal(err)
+		}
+
 		// Get the search query
 		query := ""
 		if len(args) > 0 {
@@ -28,13 +35,17 @@ var searchCmd = &cobra.Command{
 		}
 
 		// Search for environments
-		environments, err := supabaseClient.SearchEnvironments(context.Background(), query)
+		environments, total, err := supabaseClient.SearchEnvironmentInfo(context.Background(), query, page)
 		if err != nil {
 			log.Fatalf("Failed to search for environments: %v", err)
 		}
 
 		// Print the environments
 		if len(environments) == 0 {
+			if total > 0 {
+				fmt.Printf("No environments on this page; %d matched in total.\n", total)
+				return
+			}
 			fmt.Println("No public environments found.")
 			return
 		}
@@ -43,9 +54,11 @@ var searchCmd = &cobra.Command{
 		for _, env := range environments {
 			fmt.Printf("- %s by %s\n", env.Name, env.Username)
 		}
+		searchPages.printPageFooter(page, len(environments), total)
 	},
 }
 
 func init() {
+	addPageFlags(searchCmd, &searchPages)
 	rootCmd.AddCommand(searchCmd)
 }
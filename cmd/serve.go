@@ -0,0 +1,153 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/MRQ67/stackmatch-cli/internal/utils"
+	"github.com/MRQ67/stackmatch-cli/pkg/auth"
+	"github.com/MRQ67/stackmatch-cli/pkg/config"
+	"github.com/MRQ67/stackmatch-cli/pkg/server"
+	"github.com/MRQ67/stackmatch-cli/pkg/supabase"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+	"github.com/spf13/cobra"
+)
+
+var (
+	serveListen      string
+	serveAllowRemote bool
+	serveCacheTTL    time.Duration
+	serveScanTimeout time.Duration
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve scan, check and diff results over a local HTTP API",
+	Long: `Starts an HTTP server answering JSON for tools such as dashboards:
+
+  GET  /healthz             liveness, no token needed
+  GET  /scan                this machine's environment (?refresh=1 to rescan,
+                            ?stream=1 for a JSON line per category scanned)
+  POST /check               check this machine against the posted environment
+  GET  /diff?against=<ref>  changes from this machine to another environment
+
+<ref> is an environment file, a version file or project directory, or a stored
+environment as in 'env show' (name or username/name).
+
+With ?stream=1, /scan answers a JSON line each time a category is scanned,
+holding what was found so far, so the system shows before the languages are
+done. The last line holds the whole environment with "complete": true, or
+what was found before the scan failed or timed out with the error.
+
+Requests must send "Authorization: Bearer <token>" with the token stored in
+~/.stackmatch/serve-token, which is generated on first run. Scans are cached
+for --cache-ttl and abandoned after --scan-timeout.
+
+The server only listens on loopback addresses unless --allow-remote is passed.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := checkLoopback(serveListen); err != nil && !serveAllowRemote {
+			utils.ExitWithError(fmt.Errorf("%w; pass --allow-remote to listen on it anyway", err))
+		}
+
+		token, err := server.LoadOrCreateToken(config.ServeTokenFile())
+		if err != nil {
+			utils.ExitWithError(err)
+		}
+		srv, err := server.New(server.Options{
+			Token:       token,
+			CacheTTL:    serveCacheTTL,
+			ScanTimeout: serveScanTimeout,
+			Resolve:     resolveEnvironmentRef,
+			Rules:       loadDiffRules(),
+		})
+		if err != nil {
+			utils.ExitWithError(err)
+		}
+
+		listener, err := net.Listen("tcp", serveListen)
+		if err != nil {
+			utils.ExitWithError(fmt.Errorf("could not listen on %s: %w", serveListen, err))
+		}
+		fmt.Printf("Listening on http://%s\n", listener.Addr())
+		fmt.Printf("Bearer token: %s\n", config.ServeTokenFile())
+
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		httpServer := &http.Server{Handler: srv, ReadHeaderTimeout: 10 * time.Second}
+		go func() {
+			<-ctx.Done()
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			_ = httpServer.Shutdown(shutdownCtx)
+		}()
+		if err := httpServer.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			utils.ExitWithError(fmt.Errorf("server failed: %w", err))
+		}
+	},
+}
+
+// checkLoopback returns an error unless addr only accepts local connections
+func checkLoopback(addr string) error {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("invalid listen address %q: %w", addr, err)
+	}
+	if host == "localhost" {
+		return nil
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		return nil
+	}
+	return fmt.Errorf("%s is not a loopback address", addr)
+}
+
+// resolveEnvironmentRef loads the environment /diff compares against: a local
+// file or directory when one exists at ref, otherwise a stored environment
+func resolveEnvironmentRef(ctx context.Context, ref string) (*types.EnvironmentData, error) {
+	if _, err := os.Stat(ref); err == nil {
+		return readEnvironmentSource(ref)
+	}
+	if err := requireSync("comparing against a stored environment"); err != nil {
+		return nil, err
+	}
+
+	user := auth.GetCurrentUser()
+	envRef, err := parseEnvironmentRef(ref, "", user)
+	if err != nil {
+		return nil, err
+	}
+	var accessToken string
+	if user != nil {
+		accessToken = user.AccessToken
+	}
+	client, err := supabase.NewClient(cfg.SupabaseURL, cfg.SupabaseAPIKey, accessToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize Supabase client: %w", err)
+	}
+	details, err := client.ShowEnvironment(ctx, envRef, true)
+	if err != nil {
+		return nil, err
+	}
+	if details.Data == nil {
+		return nil, fmt.Errorf("environment %s has no data", ref)
+	}
+	return details.Data, nil
+}
+
+func init() {
+	serveCmd.Flags().StringVar(&serveListen, "listen", "127.0.0.1:7345", "Address to listen on")
+	serveCmd.Flags().BoolVar(&serveAllowRemote, "allow-remote", false, "Allow listening on addresses other than loopback")
+	serveCmd.Flags().DurationVar(&serveCacheTTL, "cache-ttl", server.DefaultCacheTTL, "How long a scan is reused before scanning again")
+	addDiffRulesFlags(serveCmd)
+	serveCmd.Flags().DurationVar(&serveScanTimeout, "scan-timeout", server.DefaultScanTimeout, "Give up on scans taking longer than this")
+	rootCmd.AddCommand(serveCmd)
+}
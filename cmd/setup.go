@@ -0,0 +1,321 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/MRQ67/stackmatch-cli/internal/utils"
+	"github.com/MRQ67/stackmatch-cli/pkg/auth"
+	"github.com/MRQ67/stackmatch-cli/pkg/config"
+	"github.com/MRQ67/stackmatch-cli/pkg/scanner"
+	"github.com/MRQ67/stackmatch-cli/pkg/stackmatch"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+	"github.com/MRQ67/stackmatch-cli/pkg/ui"
+	"github.com/spf13/cobra"
+)
+
+var setupCmd = &cobra.Command{
+	Use:   "setup",
+	Short: "Choose how to sync, take a first scan and log in, step by step",
+	Long: `Walks through setting StackMatch up: syncing environments through the hosted
+backend, your own Supabase project or not at all (local-only mode), taking a
+first scan of this machine and saving it, and logging in or registering.
+Every step can be skipped by pressing Enter.
+
+Running 'stackmatch' without a command does the same the first time, before
+anything is configured or logged in. Setup asks questions, so it needs a
+terminal and is never started with --quiet.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if quiet || !ui.Interactive() {
+			utils.ExitWithError(fmt.Errorf("setup asks questions, so it needs a terminal and can't run with --quiet"))
+		}
+		if err := newOnboarding().run(cmd.Context()); err != nil {
+			utils.ExitWithError(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(setupCmd)
+}
+
+// isFirstRun reports whether StackMatch runs for the first time on this
+// machine: no configuration was saved and nobody logged in
+func isFirstRun(cfg *config.Config, sessionFile string) bool {
+	if cfg.Found() {
+		return false
+	}
+	_, err := os.Stat(sessionFile)
+	return errors.Is(err, os.ErrNotExist)
+}
+
+// offerOnboarding reports whether running without a command should start
+// setup rather than print the help
+func offerOnboarding() bool {
+	return !quiet && ui.Interactive() && isFirstRun(cfg, config.SessionFile())
+}
+
+// Sync choices of the first onboarding step
+const (
+	syncHosted = "hosted"
+	syncOwn    = "own"
+	syncLocal  = "local"
+)
+
+// onboarding walks a user through setting StackMatch up. The steps that act
+// beyond the configuration are functions, so tests can script them.
+type onboarding struct {
+	prompter *ui.Prompter
+	out      io.Writer
+	cfg      *config.Config
+	// scan scans this machine
+	scan func(ctx context.Context) (types.EnvironmentData, error)
+	// snapshotDir is where the first scan is saved
+	snapshotDir string
+	// connect reconnects to Supabase after the sync settings changed
+	connect func() error
+	// user returns the logged-in user, if any
+	user func() *auth.User
+	// login and register run 'stackmatch login' and 'stackmatch register'
+	login    func() error
+	register func() error
+	now      func() time.Time
+}
+
+// newOnboarding returns the onboarding of this machine, asking on standard
+// input and acting through the login and register commands
+func newOnboarding() *onboarding {
+	return &onboarding{
+		prompter: ui.Stdin(),
+		out:      os.Stdout,
+		cfg:      cfg,
+		scan: func(ctx context.Context) (types.EnvironmentData, error) {
+			return stackmatch.Scan(ctx, stackmatch.ScanOptions{
+				Progress: stackmatch.ProgressFunc(func(msg string) {
+					fmt.Printf("%s %s...\n", ui.Symbols().Bullet, msg)
+				}),
+			})
+		},
+		snapshotDir: config.SnapshotsDir(),
+		connect: func() error {
+			var err error
+			supabaseClient, err = initSupabase(cfg.SupabaseURL, cfg.SupabaseAPIKey)
+			return err
+		},
+		user: auth.GetCurrentUser,
+		login: func() error {
+			loginCmd.Run(loginCmd, nil)
+			return nil
+		},
+		register: func() error {
+			registerCmd.Run(registerCmd, nil)
+			return nil
+		},
+		now: time.Now,
+	}
+}
+
+// run goes through the steps in order. The configuration is saved as soon
+// as the sync step is answered, so later steps can be abandoned.
+func (o *onboarding) run(ctx context.Context) error {
+	fmt.Fprintln(o.out, "Welcome to StackMatch! A few questions to get you set up; press Enter to take the suggestion in brackets.")
+	fmt.Fprintln(o.out)
+
+	if err := o.chooseSync(); err != nil {
+		return err
+	}
+	fmt.Fprintln(o.out)
+	snapshot, err := o.firstScan(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(o.out)
+	if err := o.account(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(o.out)
+	fmt.Fprintln(o.out, ui.Success("%s Setup complete.", ui.Symbols().Success))
+	if snapshot != "" {
+		fmt.Fprintf(o.out, "Compare another machine with 'stackmatch check %s'", snapshot)
+		if !o.cfg.LocalOnly {
+			fmt.Fprintf(o.out, ", or share it with 'stackmatch push --file %s'", snapshot)
+		}
+		fmt.Fprintln(o.out, ".")
+	}
+	fmt.Fprintln(o.out, "Run 'stackmatch setup' to change these choices, or 'stackmatch --help' for every command.")
+	return nil
+}
+
+// chooseSync asks where environments are synced and saves the answer
+func (o *onboarding) chooseSync() error {
+	current := syncHosted
+	switch {
+	case o.cfg.LocalOnly:
+		current = syncLocal
+	case !o.cfg.Hosted():
+		current = syncOwn
+	}
+	fmt.Fprintln(o.out, "Step 1: syncing environments")
+	fmt.Fprintln(o.out, "  hosted  push and pull through the StackMatch backend")
+	fmt.Fprintln(o.out, "  own     use your own Supabase project")
+	fmt.Fprintln(o.out, "  local   keep everything on this machine; you can turn sync on later")
+	choice, err := o.prompter.Ask(fmt.Sprintf("Sync [%s]: ", current), current, func(answer string) error {
+		switch strings.ToLower(answer) {
+		case syncHosted, syncOwn, syncLocal:
+			return nil
+		}
+		return fmt.Errorf("Please answer hosted, own or local.")
+	})
+	if err != nil {
+		return err
+	}
+
+	switch strings.ToLower(choice) {
+	case syncHosted:
+		o.cfg.UseHostedSync()
+	case syncOwn:
+		if err := o.askProject(); err != nil {
+			return err
+		}
+	case syncLocal:
+		o.cfg.LocalOnly = true
+	}
+	if err := o.cfg.Save(); err != nil {
+		return err
+	}
+	if o.cfg.LocalOnly {
+		fmt.Fprintln(o.out, "Local-only mode: commands that need sync will say so. Run 'stackmatch setup' to turn it on.")
+		return nil
+	}
+	return o.connect()
+}
+
+// askProject asks for the URL and API key of the user's Supabase project
+func (o *onboarding) askProject() error {
+	projectURL, err := o.prompter.Ask("Supabase project URL (https://<ref>.supabase.co): ", "", func(answer string) error {
+		u, err := url.Parse(answer)
+		if err != nil || u.Scheme != "https" || u.Host == "" {
+			return fmt.Errorf("Please enter an https:// URL.")
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	key, err := o.prompter.AskPassword("Anon API key: ", func(answer string) error {
+		if answer == "" {
+			return fmt.Errorf("Please enter the project's anon key (Project Settings > API).")
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	o.cfg.SupabaseURL = strings.TrimSuffix(projectURL, "/")
+	o.cfg.SupabaseAPIKey = key
+	o.cfg.LocalOnly = false
+	return nil
+}
+
+// firstScan offers to scan this machine and save the result, returning the
+// file it was saved to, or "" when skipped
+func (o *onboarding) firstScan(ctx context.Context) (string, error) {
+	fmt.Fprintln(o.out, "Step 2: a first scan")
+	scan, err := o.prompter.Confirm("Scan this machine for languages, tools and editors now?", true)
+	if err != nil || !scan {
+		return "", err
+	}
+	env, err := o.scan(ctx)
+	if err != nil {
+		fmt.Fprintln(o.out, ui.Warning("The scan failed: %v", err))
+		fmt.Fprintln(o.out, "Try again later with 'stackmatch scan'.")
+		return "", nil
+	}
+	summary := types.BuildSummary(&env)
+	var found []string
+	for _, category := range summaryCategories {
+		found = append(found, fmt.Sprintf("%d %s", summary.Counts[category.key], strings.ToLower(category.title)))
+	}
+	fmt.Fprintf(o.out, "Found %s.\n", strings.Join(found, ", "))
+
+	path := filepath.Join(o.snapshotDir, "scan-"+o.now().Format("2006-01-02")+".json")
+	save, err := o.prompter.Confirm(fmt.Sprintf("Save it to %s?", path), true)
+	if err != nil || !save {
+		return "", err
+	}
+	scanner.RedactSensitive(&env)
+	data, err := json.MarshalIndent(env, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("could not encode scan result: %w", err)
+	}
+	if err := config.WritePrivateFile(path, append(data, '\n')); err != nil {
+		return "", fmt.Errorf("could not save the scan: %w", err)
+	}
+	fmt.Fprintf(o.out, "Saved to %s\n", path)
+	return path, nil
+}
+
+// account offers to log in or register, unless sync is off or someone is
+// already logged in
+func (o *onboarding) account() error {
+	fmt.Fprintln(o.out, "Step 3: your account")
+	if o.cfg.LocalOnly {
+		fmt.Fprintln(o.out, "Skipped in local-only mode.")
+		return nil
+	}
+	if user := o.user(); user != nil {
+		fmt.Fprintf(o.out, "Logged in as %s.\n", user.Email)
+		return nil
+	}
+	choice, err := o.prompter.Ask("Log in to push and pull environments? [login/register/skip] (skip): ", "skip", func(answer string) error {
+		switch strings.ToLower(answer) {
+		case "login", "register", "skip":
+			return nil
+		}
+		return fmt.Errorf("Please answer login, register or skip.")
+	})
+	if err != nil {
+		return err
+	}
+	switch strings.ToLower(choice) {
+	case "login":
+		return o.login()
+	case "register":
+		return o.register()
+	}
+	fmt.Fprintln(o.out, "Log in later with 'stackmatch login'.")
+	return nil
+}
+
+// remoteCommands are the commands that need sync, by command path
+var remoteCommands = map[string]bool{
+	"stackmatch login":    true,
+	"stackmatch register": true,
+	"stackmatch push":     true,
+	"stackmatch pull":     true,
+	"stackmatch clone":    true,
+	"stackmatch list":     true,
+	"stackmatch delete":   true,
+	"stackmatch search":   true,
+	"stackmatch log":      true,
+	"stackmatch env show": true,
+}
+
+// requireSync returns an error explaining how to turn sync on when it is off
+// in local-only mode, for what, such as "stackmatch push"
+func requireSync(what string) error {
+	if !cfg.LocalOnly {
+		return nil
+	}
+	return fmt.Errorf("%s needs sync, which is off in local-only mode; run 'stackmatch setup' and choose hosted or own to turn it on", what)
+}
@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"runtime"
+	"sync"
+
+	"github.com/MRQ67/stackmatch-cli/internal/utils"
+	"github.com/MRQ67/stackmatch-cli/pkg/installer"
+	"github.com/MRQ67/stackmatch-cli/pkg/runner"
+	"github.com/MRQ67/stackmatch-cli/pkg/runner/replay"
+)
+
+var (
+	simulateDir string
+	recordDir   string
+)
+
+// startSimulation makes every command replay the recording in dir instead
+// of running, with PATH replaced by stubs for the recorded commands. The
+// returned function lists the commands that had no recorded result and
+// removes the stubs; it also runs if the import fails.
+func startSimulation(dir string) (func(), error) {
+	recording, err := replay.Load(dir)
+	if err != nil {
+		return nil, err
+	}
+	if recording.OS != "" && recording.OS != runtime.GOOS {
+		return nil, fmt.Errorf("the commands in %s were recorded on %s, so they can only be replayed there", dir, recording.OS)
+	}
+	bin, err := os.MkdirTemp("", "stackmatch-simulate-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stub PATH: %w", err)
+	}
+	if err := replay.WriteStubs(bin, recording.Path); err != nil {
+		os.RemoveAll(bin)
+		return nil, err
+	}
+	if err := os.Setenv("PATH", bin); err != nil {
+		os.RemoveAll(bin)
+		return nil, err
+	}
+	replayer := replay.NewRunner(recording.Commands, "")
+	runner.Default = replayer
+	// The simulated machine's package manager must not be cached as this one's
+	installer.Cache = nil
+
+	var once sync.Once
+	finish := func() {
+		once.Do(func() {
+			os.RemoveAll(bin)
+			if missing := replayer.Missing(); len(missing) > 0 {
+				fmt.Fprintf(os.Stderr, "\nThese commands have no recorded result in %s and failed with exit status 127:\n", dir)
+				for _, line := range missing {
+					fmt.Fprintf(os.Stderr, "  - %s\n", line)
+				}
+			}
+		})
+	}
+	utils.OnExit(func(error) { finish() })
+	return finish, nil
+}
+
+// startRecording records every command run from now on, adding to the
+// recording already in dir, if any. The returned function saves the
+// recording; it also runs if the import fails.
+func startRecording(dir string) (func(), error) {
+	base, err := replay.Load(dir)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	recorder := replay.NewRecorder(runner.Default, runner.DefaultPath, base)
+	runner.Default = recorder
+	runner.DefaultPath = recorder
+	// Probe the package manager so that the recording can be replayed
+	// without a cache
+	installer.Cache = nil
+
+	var once sync.Once
+	save := func() {
+		once.Do(func() {
+			recording := recorder.Recording()
+			if err := recording.Save(dir); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+				return
+			}
+			fmt.Fprintf(os.Stderr, "Recorded %d commands to %s\n", len(recording.Commands), dir)
+		})
+	}
+	utils.OnExit(func(error) { save() })
+	return save, nil
+}
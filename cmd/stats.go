@@ -0,0 +1,193 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/MRQ67/stackmatch-cli/internal/utils"
+	"github.com/MRQ67/stackmatch-cli/pkg/config"
+	"github.com/MRQ67/stackmatch-cli/pkg/stats"
+	"github.com/MRQ67/stackmatch-cli/pkg/ui"
+	"github.com/spf13/cobra"
+)
+
+// invocation tracks the running command for local usage statistics. Only the
+// command name, timing, outcome, package manager and counts are kept.
+var invocation struct {
+	command  string
+	start    time.Time
+	manager  string
+	counts   map[string]int
+	recorded bool
+}
+
+// statsEnabled reports whether usage statistics are on. STACKMATCH_STATS=1
+// or =0 overrides the config file.
+func statsEnabled() bool {
+	switch strings.ToLower(os.Getenv("STACKMATCH_STATS")) {
+	case "1", "true", "yes":
+		return true
+	case "0", "false", "no":
+		return false
+	}
+	return cfg != nil && cfg.Stats
+}
+
+// startInvocation starts timing cmd
+func startInvocation(cmd *cobra.Command) {
+	invocation.command = strings.TrimPrefix(cmd.CommandPath(), rootCmd.Name()+" ")
+	invocation.start = time.Now()
+	invocation.counts = make(map[string]int)
+}
+
+// recordManager notes the package manager used by the running command
+func recordManager(name string) {
+	invocation.manager = name
+}
+
+// recordCount adds n to a named count for the running command
+func recordCount(name string, n int) {
+	if invocation.counts != nil {
+		invocation.counts[name] += n
+	}
+}
+
+// recordInvocation appends the running command to the stats file once
+func recordInvocation(err error) {
+	if invocation.recorded || invocation.command == "" || !statsEnabled() {
+		return
+	}
+	// Don't recreate the file right after 'stats clear'
+	if invocation.command == "stats" || strings.HasPrefix(invocation.command, "stats ") {
+		return
+	}
+	invocation.recorded = true
+
+	rec := stats.Record{
+		Time:       invocation.start.UTC(),
+		Command:    invocation.command,
+		DurationMS: time.Since(invocation.start).Milliseconds(),
+		Result:     stats.Classify(err),
+		Manager:    invocation.manager,
+	}
+	if len(invocation.counts) > 0 {
+		rec.Counts = invocation.counts
+	}
+	if err := stats.NewStore(config.StatsFile()).Append(rec); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not record usage statistics: %v\n", err)
+	}
+}
+
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show local usage statistics",
+	Long: `Shows which commands you use most, how long imports take and how often
+installs fail per package manager.
+
+Statistics are opt-in and never leave this machine. Each command run appends
+one line to ~/.stackmatch/stats.jsonl with the command name, duration,
+outcome and counts such as packages installed; arguments and flag values are
+never recorded. Turn collection on with 'stackmatch stats enable' or by setting
+STACKMATCH_STATS=1.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		records, err := stats.NewStore(config.StatsFile()).Read()
+		if err != nil {
+			utils.ExitWithError(err)
+		}
+		if len(records) == 0 {
+			if statsEnabled() {
+				fmt.Println("No usage statistics recorded yet.")
+			} else {
+				fmt.Println("Usage statistics are off. Turn them on with 'stackmatch stats enable'.")
+			}
+			return
+		}
+
+		agg := stats.Aggregate(records)
+		fmt.Printf("%d commands recorded since %s\n\n", agg.Total, records[0].Time.Local().Format("2006-01-02"))
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "COMMAND\tRUNS\tFAILURES")
+		for _, usage := range agg.Commands {
+			fmt.Fprintf(w, "%s\t%d\t%d\n", usage.Command, usage.Count, usage.Failures)
+		}
+		w.Flush()
+
+		if agg.AverageImport > 0 {
+			fmt.Printf("\nAverage import duration: %s\n", ui.HumanDuration(agg.AverageImport))
+		}
+
+		if len(agg.Managers) > 0 {
+			fmt.Println()
+			w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "PACKAGE MANAGER\tRUNS\tFAILURE RATE")
+			for _, m := range agg.Managers {
+				fmt.Fprintf(w, "%s\t%d\t%.0f%%\n", m.Manager, m.Runs, m.Rate()*100)
+			}
+			w.Flush()
+		}
+
+		if len(agg.Counts) > 0 {
+			names := make([]string, 0, len(agg.Counts))
+			for name := range agg.Counts {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			fmt.Println("\nTotals:")
+			for _, name := range names {
+				fmt.Printf("  %s: %d\n", name, agg.Counts[name])
+			}
+		}
+	},
+}
+
+var statsClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Delete all local usage statistics",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := stats.NewStore(config.StatsFile()).Clear(); err != nil {
+			utils.ExitWithError(err)
+		}
+		fmt.Println("Usage statistics cleared.")
+	},
+}
+
+var statsEnableCmd = &cobra.Command{
+	Use:   "enable",
+	Short: "Start recording local usage statistics",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		setStats(true)
+		fmt.Printf("Usage statistics will be recorded to %s\n", config.StatsFile())
+	},
+}
+
+var statsDisableCmd = &cobra.Command{
+	Use:   "disable",
+	Short: "Stop recording local usage statistics",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		setStats(false)
+		fmt.Println("Usage statistics disabled. Existing data is kept until 'stackmatch stats clear'.")
+	},
+}
+
+// setStats turns collection on or off in the config file
+func setStats(enabled bool) {
+	cfg.Stats = enabled
+	if err := cfg.Save(); err != nil {
+		utils.ExitWithError(err)
+	}
+}
+
+func init() {
+	utils.OnExit(recordInvocation)
+	statsCmd.AddCommand(statsClearCmd, statsEnableCmd, statsDisableCmd)
+	rootCmd.AddCommand(statsCmd)
+}
@@ -0,0 +1,636 @@
+package cmd
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+	"github.com/MRQ67/stackmatch-cli/pkg/ui"
+)
+
+// summaryCategories lists the categories shown in environment summaries, in
+// display order
+var summaryCategories = []struct {
+	key   string
+	title string
+	get   func(env *types.EnvironmentData) map[string]string
+}{
+	{types.CategoryLanguages, "Programming Languages", func(env *types.EnvironmentData) map[string]string { return env.ConfiguredLanguages }},
+	{types.CategoryTools, "Development Tools", func(env *types.EnvironmentData) map[string]string { return env.Tools }},
+	{types.CategoryPackageManagers, "Package Managers", func(env *types.EnvironmentData) map[string]string { return env.PackageManagers }},
+	{types.CategoryEditors, "Code Editors", func(env *types.EnvironmentData) map[string]string { return env.CodeEditors }},
+}
+
+// printSystemInfo prints the OS, its release, kernel and C library when
+// known, the architecture and the shell
+func printSystemInfo(w io.Writer, system types.SystemInfo) {
+	fmt.Fprintf(w, "  OS: %s\n", system.OS)
+	if release := system.Release(); release != "" {
+		fmt.Fprintf(w, "  Release: %s\n", release)
+	}
+	if system.KernelVersion != "" {
+		fmt.Fprintf(w, "  Kernel: %s\n", system.KernelVersion)
+	}
+	if system.IsWSL {
+		fmt.Fprintf(w, "  WSL: %s\n", orUnknown(system.WSLDistro))
+	}
+	if system.Libc != "" {
+		fmt.Fprintf(w, "  C library: %s\n", system.Libc)
+	}
+	fmt.Fprintf(w, "  Architecture: %s\n", system.Arch)
+	fmt.Fprintf(w, "  Shell: %s\n", system.Shell)
+}
+
+// releaseMismatch returns a note when the environment was scanned on another
+// OS release than local runs, since package names and versions differ
+// between distributions, or "" when they match or either is unknown
+func releaseMismatch(scanned, local types.SystemInfo) string {
+	from, to := scanned.Release(), local.Release()
+	if from == "" || to == "" || from == to {
+		return ""
+	}
+	return fmt.Sprintf("Note: this environment was scanned on %s, but this machine runs %s; package names and versions may differ.", from, to)
+}
+
+// wslMismatch returns a warning when an environment scanned inside WSL is
+// applied outside it or the other way around, since tools such as Docker and
+// editors may run on the Windows host of one and not the other. Files that
+// recorded no kernel version predate WSL detection, so they get none.
+func wslMismatch(scanned, local types.SystemInfo) string {
+	if scanned.OS != "linux" || local.OS != "linux" || scanned.KernelVersion == "" || scanned.IsWSL == local.IsWSL {
+		return ""
+	}
+	if scanned.IsWSL {
+		return fmt.Sprintf("this environment was scanned inside WSL (%s), but this machine is not WSL; tools it used from the Windows host, such as Docker Desktop or an editor, must be installed here", orUnknown(scanned.WSLDistro))
+	}
+	return fmt.Sprintf("this environment was scanned outside WSL, but this machine runs inside WSL (%s); tools such as Docker or an editor may be better installed on the Windows host", orUnknown(local.WSLDistro))
+}
+
+// libcMismatch returns a warning when the environment was scanned on a
+// Linux system with another C library than local, since binaries built for
+// glibc don't run on musl and Alpine packages some tools under other names.
+// Files that recorded no C library predate its detection, so they get none.
+func libcMismatch(scanned, local types.SystemInfo) string {
+	if scanned.Libc == "" || local.Libc == "" || scanned.Libc == local.Libc {
+		return ""
+	}
+	return fmt.Sprintf("this environment was scanned on a %s system, but this machine uses %s; prebuilt binaries and runtimes installed outside the package manager may not run here, and some tools are packaged under other names", scanned.Libc, local.Libc)
+}
+
+// printEnvironmentDetails prints the system information and every entry of
+// env, as shown by import's dry run and 'env show --full'
+func printEnvironmentDetails(w io.Writer, env *types.EnvironmentData) {
+	fmt.Fprintln(w, "System Information:")
+	printSystemInfo(w, env.System)
+	if env.Profile != "" {
+		fmt.Fprintf(w, "  Profile: %s\n", env.Profile)
+	}
+	switch {
+	case env.FastScan == nil:
+	case len(env.FastScan.Databases) == 0:
+		fmt.Fprintln(w, "  Fast scan: no package database found")
+	default:
+		fmt.Fprintf(w, "  Fast scan: versions read from %s\n", strings.Join(env.FastScan.Databases, ", "))
+	}
+	if env.ManifestScan != nil {
+		fmt.Fprintf(w, "  Manifest scan: limited to the %d entries of %s\n", len(env.ManifestScan.IDs), env.ManifestScan.Manifest)
+	}
+	fmt.Fprintln(w)
+
+	for _, category := range summaryCategories {
+		entries := category.get(env)
+		if len(entries) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "%s:\n", category.title)
+		for _, name := range sortedNames(entries) {
+			suffix := requirementSuffix(env.Requirements[name]) + provenanceSuffix(env.Provenance[name])
+			if category.key == types.CategoryLanguages {
+				suffix += sideBySideSuffix(entries[name], env.SideBySideVersions(name))
+			}
+			fmt.Fprintf(w, "  - %s: %s%s\n", name, entries[name], suffix)
+		}
+		fmt.Fprintln(w)
+	}
+
+	if len(env.ConfigFiles) > 0 {
+		fmt.Fprintln(w, "Configuration Files:")
+		for _, file := range env.ConfigFiles {
+			fmt.Fprintf(w, "  - %s%s\n", file, configFileSuffix(env, file))
+		}
+		fmt.Fprintln(w)
+	}
+
+	if len(env.ScheduledJobs) > 0 {
+		fmt.Fprintln(w, "Scheduled Jobs:")
+		for _, job := range env.ScheduledJobs {
+			if job.Name != "" {
+				fmt.Fprintf(w, "  - %s (%s): %s\n", job.Name, job.Schedule, job.Command)
+			} else {
+				fmt.Fprintf(w, "  - %s\n", job.Line())
+			}
+		}
+		fmt.Fprintln(w)
+	}
+
+	if env.Node != nil {
+		fmt.Fprintln(w, "Node.js:")
+		if env.Node.Corepack != nil {
+			fmt.Fprintf(w, "  - corepack %s: %s", orUnknown(env.Node.Corepack.Version), env.Node.Corepack.State())
+			if len(env.Node.Corepack.Shims) > 0 {
+				fmt.Fprintf(w, " (%s)", strings.Join(env.Node.Corepack.Shims, ", "))
+			}
+			fmt.Fprintln(w)
+		}
+		if env.Node.PackageManager != "" {
+			fmt.Fprintf(w, "  - packageManager: %s\n", env.Node.PackageManager)
+		}
+		fmt.Fprintln(w)
+	}
+
+	if env.Conda != nil {
+		fmt.Fprintf(w, "Conda Environments (%s):\n", condaLabel(env.Conda))
+		for _, condaEnv := range env.Conda.Environments {
+			fmt.Fprintf(w, "  - %s\n", cmp.Or(condaEnv.Name, condaEnv.Prefix))
+			for _, name := range sortedNames(condaEnv.Packages) {
+				fmt.Fprintf(w, "      %s: %s\n", name, condaEnv.Packages[name])
+			}
+		}
+		fmt.Fprintln(w)
+	}
+
+	if len(env.ShellSetup) > 0 {
+		fmt.Fprintln(w, "Shell Setup:")
+		for _, name := range sortedNames(env.ShellSetup) {
+			fmt.Fprintf(w, "  - %s: %s\n", name, env.ShellSetup[name])
+		}
+		fmt.Fprintln(w)
+	}
+
+	if env.GitConfig != nil {
+		fmt.Fprintln(w, "Git Config:")
+		for _, rule := range env.GitConfig.URLRewrites {
+			fmt.Fprintf(w, "  - %s = %s\n", rule.Key(), rule.InsteadOf)
+		}
+		for _, helper := range env.GitConfig.CredentialHelpers {
+			fmt.Fprintf(w, "  - %s = %s\n", helper.Key(), helper.Helper)
+		}
+		for _, key := range sortedNames(env.GitConfig.Settings) {
+			fmt.Fprintf(w, "  - %s = %s\n", key, env.GitConfig.Settings[key])
+		}
+		fmt.Fprintln(w)
+	}
+
+	if len(env.LanguageConfig) > 0 {
+		fmt.Fprintln(w, "Language Config:")
+		for _, setting := range env.LanguageConfig.Settings() {
+			fmt.Fprintf(w, "  - %s = %s\n", setting.Name(), setting.Value)
+		}
+		fmt.Fprintln(w)
+	}
+
+	if len(env.EnvVars) > 0 {
+		fmt.Fprintln(w, "Environment Variables:")
+		for _, name := range sortedNames(env.EnvVars) {
+			fmt.Fprintf(w, "  - %s = %s\n", name, env.EnvVars[name])
+		}
+		fmt.Fprintln(w)
+	}
+
+	if len(env.VersionManagers) > 0 {
+		fmt.Fprintln(w, "Version Managers:")
+		for _, manager := range sortedNames(env.VersionManagers) {
+			versions := strings.Join(env.VersionManagers[manager], ", ")
+			if versions == "" {
+				versions = "no versions installed"
+			}
+			fmt.Fprintf(w, "  - %s: %s\n", manager, versions)
+		}
+		fmt.Fprintln(w)
+	}
+
+	if env.Python != nil {
+		printPythonEnvironment(w, env.Python)
+	}
+
+	for _, manager := range sortedNames(env.GlobalPackages) {
+		fmt.Fprintf(w, "Global Packages (%s):\n", manager)
+		for _, name := range sortedNames(env.GlobalPackages[manager]) {
+			fmt.Fprintf(w, "  - %s\n", withVersionSuffix(name, env.GlobalPackages[manager][name]))
+		}
+		fmt.Fprintln(w)
+	}
+
+	if len(env.Services) > 0 {
+		fmt.Fprintln(w, "Services:")
+		for _, service := range env.Services {
+			fmt.Fprintf(w, "  - %s: %s through %s as %s (%s)\n", service.Name, service.State, service.Manager, service.Unit, service.Scope)
+		}
+		fmt.Fprintln(w)
+	}
+
+	if len(env.RunningServices) > 0 {
+		fmt.Fprintln(w, "Running Services:")
+		for _, name := range sortedNames(env.RunningServices) {
+			fmt.Fprintf(w, "  - %s: %s\n", name, env.RunningServices[name])
+		}
+		fmt.Fprintln(w)
+	}
+
+	if env.Containers != nil {
+		printContainers(w, env.Containers)
+	}
+
+	if env.GPU != nil {
+		printGPU(w, env.GPU)
+	}
+
+	if env.Kubernetes != nil {
+		printKubernetes(w, env.Kubernetes)
+	}
+
+	if len(env.CloudProfiles) > 0 {
+		printCloudProfiles(w, env.CloudProfiles)
+	}
+
+	if len(env.SSHKeys) > 0 || env.SSHAgent {
+		printSSHKeys(w, env.SSHKeys, env.SSHAgent)
+	}
+
+	if env.Mobile != nil {
+		printMobile(w, env.Mobile)
+	}
+
+	// Categories from newer releases or custom detectors are shown but
+	// left alone
+	for _, category := range types.ExtensionCategories(env) {
+		fmt.Fprintf(w, "%s (not installable by this version):\n", category)
+		for _, name := range sortedNames(env.Extensions[category]) {
+			fmt.Fprintf(w, "  - %s\n", withVersionSuffix(name, env.Extensions[category][name]))
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+// printContainers prints the Docker images and running containers found, or
+// why they could not be listed
+func printContainers(w io.Writer, containers *types.Containers) {
+	fmt.Fprintln(w, "Containers:")
+	if containers.Error != "" {
+		fmt.Fprintf(w, "  - not listed: %s\n", containers.Error)
+	}
+	for _, image := range containers.Running {
+		fmt.Fprintf(w, "  - running: %s\n", image)
+	}
+	for _, image := range containers.Images {
+		fmt.Fprintf(w, "  - image: %s\n", image)
+	}
+	fmt.Fprintln(w)
+}
+
+// printGPU prints the GPU compute stack found and the GPUs
+func printGPU(w io.Writer, gpu *types.GPU) {
+	fmt.Fprintln(w, "GPU:")
+	fields := []struct{ name, value string }{
+		{"NVIDIA driver", gpu.DriverVersion},
+		{"CUDA (driver)", gpu.CUDAVersion},
+		{"CUDA toolkit", gpu.CUDAToolkit},
+		{"cuDNN", gpu.CuDNN},
+		{"ROCm", gpu.ROCmVersion},
+	}
+	for _, field := range fields {
+		if field.value != "" {
+			fmt.Fprintf(w, "  - %s: %s\n", field.name, field.value)
+		}
+	}
+	for _, device := range gpu.Devices {
+		fmt.Fprintf(w, "  - device: %s\n", device)
+	}
+	fmt.Fprintln(w)
+}
+
+// printKubernetes prints the kubectl contexts and the servers of their
+// clusters, marking the current one
+func printKubernetes(w io.Writer, kubernetes *types.Kubernetes) {
+	fmt.Fprintln(w, "Kubernetes contexts:")
+	for _, kubeContext := range kubernetes.Contexts {
+		line := "  - " + kubeContext.Name
+		if kubeContext.Server != "" {
+			line += " (" + kubeContext.Server + ")"
+		}
+		if kubeContext.Namespace != "" {
+			line += ", namespace " + kubeContext.Namespace
+		}
+		if kubeContext.Name == kubernetes.CurrentContext {
+			line += " [current]"
+		}
+		fmt.Fprintln(w, line)
+	}
+	fmt.Fprintln(w)
+}
+
+// printCloudProfiles prints the profile names of each cloud CLI
+func printCloudProfiles(w io.Writer, profiles map[string][]string) {
+	fmt.Fprintln(w, "Cloud profiles:")
+	for _, cli := range sortedNames(profiles) {
+		fmt.Fprintf(w, "  - %s: %s\n", cli, strings.Join(profiles[cli], ", "))
+	}
+	fmt.Fprintln(w)
+}
+
+// printSSHKeys prints the SSH keys by fingerprint and whether an ssh-agent
+// was running
+func printSSHKeys(w io.Writer, keys []types.SSHKeyInfo, agent bool) {
+	fmt.Fprintln(w, "SSH Keys:")
+	for _, key := range keys {
+		fmt.Fprintf(w, "  - %s: %s %d %s", key.File, key.Type, key.Bits, key.Fingerprint)
+		if !key.PrivateKey {
+			fmt.Fprint(w, " (no private key)")
+		}
+		fmt.Fprintln(w)
+	}
+	if agent {
+		fmt.Fprintln(w, "  - ssh-agent: running")
+	}
+	fmt.Fprintln(w)
+}
+
+// printMobile prints the Android SDK and Flutter, with the checks of
+// flutter doctor that did not pass
+func printMobile(w io.Writer, mobile *types.Mobile) {
+	fmt.Fprintln(w, "Mobile:")
+	if sdk := mobile.AndroidSDK; sdk != nil {
+		fmt.Fprintf(w, "  - Android SDK: %s\n", sdk.Path)
+		if len(sdk.Platforms) > 0 {
+			fmt.Fprintf(w, "    platforms: %s\n", strings.Join(sdk.Platforms, ", "))
+		}
+		if len(sdk.BuildTools) > 0 {
+			fmt.Fprintf(w, "    build tools: %s\n", strings.Join(sdk.BuildTools, ", "))
+		}
+	}
+	if flutter := mobile.Flutter; flutter != nil {
+		fmt.Fprintf(w, "  - Flutter: %s", orUnknown(flutter.Version))
+		if flutter.Channel != "" {
+			fmt.Fprintf(w, " (%s channel)", flutter.Channel)
+		}
+		if flutter.Dart != "" {
+			fmt.Fprintf(w, ", Dart %s", flutter.Dart)
+		}
+		fmt.Fprintln(w)
+		for _, check := range flutter.Doctor {
+			if check.Status != types.FlutterInstalled {
+				fmt.Fprintf(w, "    %s: %s\n", check.Name, check.Status)
+			}
+		}
+	}
+	fmt.Fprintln(w)
+}
+
+// printPythonEnvironment prints the Python environment managers found and
+// the interpreter python3 resolves to
+func printPythonEnvironment(w io.Writer, python *types.PythonEnvironment) {
+	fmt.Fprintln(w, "Python Environment:")
+	if python.Pyenv != nil {
+		fmt.Fprintf(w, "  - pyenv: global %s", orUnknown(python.Pyenv.Global))
+		if python.Pyenv.Local != "" {
+			fmt.Fprintf(w, ", local %s", python.Pyenv.Local)
+		}
+		fmt.Fprintln(w)
+	}
+	if python.Conda != nil {
+		fmt.Fprintf(w, "  - conda: %d environment(s), base %s", len(python.Conda.Envs), orUnknown(python.Conda.Base))
+		if python.Conda.Active != "" {
+			fmt.Fprintf(w, ", active %s", python.Conda.Active)
+		}
+		fmt.Fprintln(w)
+	}
+	if python.Uv != "" {
+		fmt.Fprintf(w, "  - uv: %s\n", python.Uv)
+	}
+	if python.Virtualenvwrapper {
+		fmt.Fprintln(w, "  - virtualenvwrapper")
+	}
+	if in := python.Interpreter; in != nil {
+		if in.Error != "" {
+			fmt.Fprintf(w, "  - python3: %s fails: %s\n", in.Path, in.Error)
+		} else {
+			fmt.Fprintf(w, "  - python3: %s %s (prefix %s)\n", in.Path, in.Version, in.Prefix)
+		}
+	}
+	if python.Mismatch != "" {
+		fmt.Fprintf(w, "  ! %s\n", python.Mismatch)
+	}
+	fmt.Fprintln(w)
+}
+
+// printCategoryCounts prints the per-category counts of a summary
+func printCategoryCounts(w io.Writer, summary *types.Summary) {
+	fmt.Fprintln(w, "Contents:")
+	shown := make(map[string]bool)
+	for _, category := range summaryCategories {
+		fmt.Fprintf(w, "  %s: %d\n", category.title, summary.Counts[category.key])
+		shown[category.key] = true
+	}
+	fmt.Fprintf(w, "  Configuration Files: %d\n", summary.Counts[types.CategoryConfigFiles])
+	shown[types.CategoryConfigFiles] = true
+
+	var others []string
+	for key := range summary.Counts {
+		if !shown[key] {
+			others = append(others, key)
+		}
+	}
+	sort.Strings(others)
+	for _, key := range others {
+		fmt.Fprintf(w, "  %s: %d\n", key, summary.Counts[key])
+	}
+}
+
+// extensionEntries counts the entries in env's extension categories
+func extensionEntries(env *types.EnvironmentData) int {
+	n := 0
+	for _, category := range types.ExtensionCategories(env) {
+		n += len(env.Extensions[category])
+	}
+	return n
+}
+
+// sortedNames returns the keys of entries in order
+func sortedNames[V any](entries map[string]V) []string {
+	names := make([]string, 0, len(entries))
+	for name := range entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// withVersionSuffix formats an entry as "name: version", or just the name
+// when it has no version
+func withVersionSuffix(name, version string) string {
+	if version == "" {
+		return name
+	}
+	return name + ": " + version
+}
+
+// provenanceSuffix describes how an entry was installed, such as
+// " [apt (git)]", or returns "" when that isn't known
+func provenanceSuffix(p types.Provenance) string {
+	switch {
+	case p.Source == "":
+		return ""
+	case p.Conflict != "":
+		return " [" + p.String() + "; conflict: " + p.Conflict + "]"
+	default:
+		return " [" + p.String() + "]"
+	}
+}
+
+// configFileSuffix describes how the config file at path is kept, such as
+// " (managed by chezmoi)" or " (symlink to /home/me/dotfiles/zsh/.zshrc)", or
+// returns "" for a plain file
+func configFileSuffix(env *types.EnvironmentData, path string) string {
+	info, ok := env.ConfigFile(path)
+	switch {
+	case !ok:
+		return ""
+	case info.ManagedBy != "":
+		return " (managed by " + info.ManagedBy + ")"
+	case info.Symlink && info.Target != "":
+		return " (symlink to " + info.Target + ")"
+	}
+	return ""
+}
+
+// sideBySideSuffix lists the versions installed next to primary, the one
+// that runs first
+func sideBySideSuffix(primary string, versions []string) string {
+	var others []string
+	for _, v := range versions {
+		if v != primary {
+			others = append(others, v)
+		}
+	}
+	if len(others) == 0 {
+		return ""
+	}
+	return " (also installed: " + strings.Join(others, ", ") + ")"
+}
+
+// condaLabel describes the front end of a conda installation, such as
+// "mamba 1.5.8 from miniforge3"
+func condaLabel(conda *types.CondaInstallation) string {
+	label := strings.TrimSpace(conda.Executable + " " + conda.Version)
+	if conda.Distribution != "" {
+		label += " from " + conda.Distribution
+	}
+	return label
+}
+
+// orUnknown names a value that could not be read
+func orUnknown(value string) string {
+	if value == "" {
+		return "unknown"
+	}
+	return value
+}
+
+// keyTools are the tools whose versions the scan summary shows, when found,
+// in this order
+var keyTools = []string{"Git", "Docker", "Kubernetes", "Helm", "Terraform", "AWS CLI", "Azure CLI", "Google Cloud SDK", "Make", "CMake", "Gradle", "Maven"}
+
+// maxNotable bounds the versions shown per category in the scan summary
+const maxNotable = 5
+
+// writeScanSummary prints a scan as read in a terminal: the system, a table
+// of the entries found per category with the versions of the languages and
+// key tools, and how to get the document itself
+func writeScanSummary(w io.Writer, env types.EnvironmentData) {
+	system := fmt.Sprintf("%s/%s", env.System.OS, env.System.Arch)
+	if release := env.System.Release(); release != "" {
+		system += ", " + release
+	}
+	if env.System.Shell != "" {
+		system += ", " + env.System.Shell
+	}
+	fmt.Fprintf(w, "System: %s\n", system)
+
+	summary := env.Summary
+	if summary == nil {
+		summary = types.BuildSummary(&env)
+	}
+	total := 0
+	for _, n := range summary.Counts {
+		total += n
+	}
+	scanned := fmt.Sprintf("Scanned: %d entries", total)
+	if summary.ScanDurationMS > 0 {
+		scanned += " in " + ui.HumanDuration(time.Duration(summary.ScanDurationMS)*time.Millisecond)
+	}
+	if len(env.BrokenTools) > 0 {
+		scanned += fmt.Sprintf(", %d broken", len(env.BrokenTools))
+	}
+	fmt.Fprintln(w, scanned)
+	fmt.Fprintln(w)
+
+	categories := []string{types.CategoryLanguages, types.CategoryTools, types.CategoryPackageManagers, types.CategoryEditors, types.CategoryConfigFiles}
+	for _, category := range sortedNames(summary.Counts) {
+		if !slices.Contains(categories, category) {
+			categories = append(categories, category)
+		}
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "CATEGORY\tFOUND\tNOTABLE")
+	for _, category := range categories {
+		n, ok := summary.Counts[category]
+		if !ok {
+			continue
+		}
+		row := fmt.Sprintf("%s\t%d", category, n)
+		if notable := notableVersions(env, category); notable != "" {
+			row += "\t" + notable
+		}
+		fmt.Fprintln(tw, row)
+	}
+	tw.Flush()
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Use --format json, or --output <file>, for the machine-readable document.")
+}
+
+// notableVersions returns the versions the scan summary shows for
+// category: every language, and the key tools found, up to maxNotable
+func notableVersions(env types.EnvironmentData, category string) string {
+	var names []string
+	var entries map[string]string
+	switch category {
+	case types.CategoryLanguages:
+		entries = env.ConfiguredLanguages
+		names = sortedNames(entries)
+	case types.CategoryTools:
+		entries = env.Tools
+		for _, name := range keyTools {
+			if _, ok := entries[name]; ok {
+				names = append(names, name)
+			}
+		}
+	default:
+		return ""
+	}
+
+	var notable []string
+	for _, name := range names[:min(len(names), maxNotable)] {
+		notable = append(notable, name+" "+entries[name])
+	}
+	if more := len(names) - maxNotable; more > 0 {
+		notable = append(notable, fmt.Sprintf("+%d more", more))
+	}
+	return strings.Join(notable, ", ")
+}
@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+func TestWSLMismatch(t *testing.T) {
+	wsl := types.SystemInfo{OS: "linux", KernelVersion: "5.15.146.1-microsoft-standard-WSL2", IsWSL: true, WSLDistro: "Ubuntu-22.04"}
+	native := types.SystemInfo{OS: "linux", KernelVersion: "6.8.0-31-generic"}
+	testCases := []struct {
+		name     string
+		scanned  types.SystemInfo
+		local    types.SystemInfo
+		expected string
+	}{
+		{name: "WSL onto native Linux", scanned: wsl, local: native, expected: "scanned inside WSL (Ubuntu-22.04), but this machine is not WSL"},
+		{name: "native Linux onto WSL", scanned: native, local: wsl, expected: "scanned outside WSL, but this machine runs inside WSL (Ubuntu-22.04)"},
+		{name: "both WSL", scanned: wsl, local: wsl},
+		{name: "both native", scanned: native, local: native},
+		{name: "file from before WSL detection", scanned: types.SystemInfo{OS: "linux"}, local: wsl},
+		{name: "macOS onto WSL", scanned: types.SystemInfo{OS: "darwin", KernelVersion: "23.4.0"}, local: wsl},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := wslMismatch(tc.scanned, tc.local)
+			if tc.expected == "" && actual != "" {
+				t.Errorf("expected no warning but got %q", actual)
+			}
+			if !strings.Contains(actual, tc.expected) {
+				t.Errorf("expected a warning containing %q but got %q", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestLibcMismatch(t *testing.T) {
+	glibc := types.SystemInfo{OS: "linux", Libc: types.LibcGlibc}
+	alpine := types.SystemInfo{OS: "linux", Libc: types.LibcMusl}
+	testCases := []struct {
+		name     string
+		scanned  types.SystemInfo
+		local    types.SystemInfo
+		expected string
+	}{
+		{name: "glibc onto Alpine", scanned: glibc, local: alpine, expected: "scanned on a glibc system, but this machine uses musl"},
+		{name: "Alpine onto glibc", scanned: alpine, local: glibc, expected: "scanned on a musl system, but this machine uses glibc"},
+		{name: "both glibc", scanned: glibc, local: glibc},
+		{name: "file from before libc detection", scanned: types.SystemInfo{OS: "linux"}, local: alpine},
+		{name: "macOS onto Alpine", scanned: types.SystemInfo{OS: "darwin"}, local: alpine},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := libcMismatch(tc.scanned, tc.local)
+			if tc.expected == "" && actual != "" {
+				t.Errorf("expected no warning but got %q", actual)
+			}
+			if !strings.Contains(actual, tc.expected) {
+				t.Errorf("expected a warning containing %q but got %q", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestPrintEnvironmentDetailsCorepack(t *testing.T) {
+	var out bytes.Buffer
+	printEnvironmentDetails(&out, &types.EnvironmentData{Node: &types.NodeEnvironment{Corepack: &types.Corepack{Shims: []string{"yarn"}}}})
+	if !strings.Contains(out.String(), "  - corepack unknown: enabled (yarn)\n") {
+		t.Errorf("expected corepack without a version listed as unknown but got %q", out.String())
+	}
+}
+
+// TestScanSummaryGolden checks the summary scan prints in a terminal; run
+// 'go test ./cmd -run TestScanSummaryGolden -update' after changing it and
+// review the diff
+func TestScanSummaryGolden(t *testing.T) {
+	env := types.EnvironmentData{
+		System: types.SystemInfo{OS: "linux", Arch: "amd64", Shell: "/bin/zsh", OSName: "Ubuntu", OSVersion: "24.04"},
+		ConfiguredLanguages: map[string]string{
+			"Go": "1.22.3", "Java": "21.0.2", "Node.js": "20.11.0", "Python": "3.12.1", "Ruby": "3.3.0", "Rust": "1.77.0", "Zig": "0.11.0",
+		},
+		Tools:           map[string]string{"Docker": "26.1.0", "Git": "2.45.0", "jq": "1.7.1", "Terraform": "1.8.2"},
+		PackageManagers: map[string]string{"npm": "10.2.4", "pip": "24.0"},
+		CodeEditors:     map[string]string{"Visual Studio Code": "1.89.0"},
+		ConfigFiles:     []string{".gitconfig", ".zshrc"},
+		BrokenTools:     map[string]types.ToolFailure{"kubectl": {}},
+		Extensions:      map[string]map[string]string{"mobile-sdks": {"Android SDK": "34"}},
+	}
+	env.Summary = types.BuildSummary(&env)
+	env.Summary.ScanDurationMS = 4200
+
+	var buf bytes.Buffer
+	writeScanSummary(&buf, env)
+
+	golden := filepath.Join("testdata", "scan", "summary.golden")
+	if *updateGolden {
+		if err := os.MkdirAll(filepath.Dir(golden), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(golden, buf.Bytes(), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expected, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != string(expected) {
+		t.Errorf("expected:\n%s\nbut got:\n%s", expected, buf.String())
+	}
+}
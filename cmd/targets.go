@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/MRQ67/stackmatch-cli/internal/utils"
+	"github.com/MRQ67/stackmatch-cli/pkg/stackmatch"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+	"github.com/spf13/cobra"
+)
+
+var targetsCmd = &cobra.Command{
+	Use:   "targets",
+	Short: "Manage the platform targets of an environment file",
+	Long: `An environment file can describe one environment meant for several platforms,
+such as macOS and Linux. Its entries are the base, and the "targets" section
+adds, removes or renames entries for each platform, keyed by os/arch such as
+"darwin/arm64", or by os alone such as "linux":
+
+  "targets": {
+    "linux/amd64": {
+      "add": {"package_managers": {"APT": "2.4.11"}},
+      "remove": ["Homebrew"],
+      "rename": {"Docker Desktop": "Docker"}
+    }
+  }
+
+'stackmatch import' merges the target matching this machine into the base
+before planning, removals first, then renames, then additions. A target keyed
+by os/arch wins over one keyed by os. 'stackmatch validate' reports targets
+that remove or rename entries the base does not have.`,
+}
+
+var targetsAddCurrentCmd = &cobra.Command{
+	Use:   "add-current <env.json>",
+	Short: "Record this machine's platform as a target of an environment file",
+	Long: `Scans the languages, tools, package managers and editors of this machine and
+records how they differ from the entries of the file as the target for this
+platform: entries found here that the file lacks are added, and entries of the
+file not found here are removed. Versions of entries found in both stay those
+of the file. Renames are not detected; add them to the target by hand.
+
+A target already recorded for this platform is replaced.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		env, err := readEnvironmentFile(args[0])
+		if err != nil {
+			utils.ExitWithError(err)
+		}
+
+		detectors, err := stackmatch.SelectDetectors(stackmatch.TargetCategories, nil)
+		if err != nil {
+			utils.ExitWithError(err)
+		}
+		fmt.Println("Scanning this machine...")
+		scanned, err := stackmatch.Scan(cmd.Context(), stackmatch.ScanOptions{Detectors: detectors})
+		if err != nil {
+			utils.ExitWithError(fmt.Errorf("scan failed: %w", err))
+		}
+		key, replaced := stackmatch.AddTarget(env, scanned)
+
+		if err := writeEnvironmentFile(env, args[0]); err != nil {
+			utils.ExitWithError(fmt.Errorf("could not write %s: %w", args[0], err))
+		}
+		if replaced {
+			fmt.Printf("Replaced the %s target of %s\n", key, args[0])
+		} else {
+			fmt.Printf("Added the %s target to %s\n", key, args[0])
+		}
+		printTarget(env.Targets[key])
+	},
+}
+
+// printTarget lists the changes a target makes to the base entries
+func printTarget(target *types.PlatformTarget) {
+	var added []string
+	for _, category := range types.TargetCategories {
+		for name, version := range target.Add[category] {
+			added = append(added, fmt.Sprintf("%s %s", name, version))
+		}
+	}
+	sort.Strings(added)
+	if len(added)+len(target.Remove)+len(target.Rename) == 0 {
+		fmt.Println("This machine has the same entries as the base environment.")
+		return
+	}
+	if len(added) > 0 {
+		fmt.Printf("  Adds: %s\n", strings.Join(added, ", "))
+	}
+	if len(target.Remove) > 0 {
+		fmt.Printf("  Removes: %s\n", strings.Join(target.Remove, ", "))
+	}
+	if len(target.Rename) > 0 {
+		var renamed []string
+		for from, to := range target.Rename {
+			renamed = append(renamed, from+" as "+to)
+		}
+		sort.Strings(renamed)
+		fmt.Printf("  Renames: %s\n", strings.Join(renamed, ", "))
+	}
+}
+
+func init() {
+	targetsCmd.AddCommand(targetsAddCurrentCmd)
+	rootCmd.AddCommand(targetsCmd)
+}
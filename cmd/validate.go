@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/MRQ67/stackmatch-cli/internal/utils"
+	"github.com/MRQ67/stackmatch-cli/pkg/envfile"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+	"github.com/spf13/cobra"
+)
+
+// Exit codes of validate besides 0 for a valid file
+const (
+	exitSchemaErrors = 1
+	exitWarnings     = 2
+)
+
+var (
+	validateJSON        bool
+	validatePrintSchema bool
+)
+
+var validateCmd = &cobra.Command{
+	Use:   "validate <file>",
+	Short: "Check an environment file against the environment schema",
+	Long: `Checks an environment file against the JSON Schema environment files follow,
+then against rules the schema cannot express, such as a scan date in the future
+or a stale summary. Every problem is reported with a JSON pointer to the value.
+
+Exit status is 0 for a valid file, 1 when the file is not JSON or violates the
+schema, and 2 when it only has warnings. Files with warnings can still be imported.
+
+Use --print-schema to print the schema, for example to validate files generated
+by other tools.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if validatePrintSchema {
+			return cobra.NoArgs(cmd, args)
+		}
+		return cobra.ExactArgs(1)(cmd, args)
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		if validatePrintSchema {
+			os.Stdout.Write(envfile.Schema())
+			return
+		}
+
+		data, err := os.ReadFile(args[0])
+		if err != nil {
+			utils.ExitWithError(fmt.Errorf("could not read file %s: %w", args[0], err))
+		}
+		result, err := validateEnvironment(data)
+		if err != nil {
+			utils.ExitWithError(fmt.Errorf("%s: %w", args[0], err))
+		}
+
+		if validateJSON {
+			jsonData, err := json.MarshalIndent(result, "", "  ")
+			if err != nil {
+				utils.ExitWithError(fmt.Errorf("could not encode validation result: %w", err))
+			}
+			fmt.Println(string(jsonData))
+		} else {
+			for _, issue := range result.Errors {
+				fmt.Printf("error: %s\n", issue)
+			}
+			for _, issue := range result.Warnings {
+				fmt.Printf("warning: %s\n", issue)
+			}
+			if result.Valid && len(result.Warnings) == 0 {
+				fmt.Printf("%s is a valid environment file.\n", args[0])
+			}
+		}
+
+		recordCount("errors", len(result.Errors))
+		recordCount("warnings", len(result.Warnings))
+		switch {
+		case !result.Valid:
+			recordInvocation(errValidationFailed)
+			os.Exit(exitSchemaErrors)
+		case len(result.Warnings) > 0:
+			recordInvocation(nil)
+			os.Exit(exitWarnings)
+		}
+	},
+}
+
+// errValidationFailed marks a validation that ran but found schema errors
+var errValidationFailed = errors.New("validation failed")
+
+// validationResult is what validate reports
+type validationResult struct {
+	Valid    bool                    `json:"valid"`
+	Errors   []types.ValidationIssue `json:"errors"`
+	Warnings []types.ValidationIssue `json:"warnings"`
+}
+
+// validateEnvironment checks data against the schema and the semantic rules.
+// An error means data is not JSON.
+func validateEnvironment(data []byte) (*validationResult, error) {
+	errs, warnings, err := envfile.Validate(data)
+	if err != nil {
+		return nil, err
+	}
+	result := &validationResult{
+		Valid:    len(errs) == 0,
+		Errors:   append([]types.ValidationIssue{}, errs...),
+		Warnings: append([]types.ValidationIssue{}, warnings...),
+	}
+	return result, nil
+}
+
+func init() {
+	validateCmd.Flags().BoolVar(&validateJSON, "json", false, "Output the validation result as JSON")
+	validateCmd.Flags().BoolVar(&validatePrintSchema, "print-schema", false, "Print the environment JSON Schema and exit")
+	rootCmd.AddCommand(validateCmd)
+}
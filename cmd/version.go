@@ -3,10 +3,11 @@ package cmd
 import (
 	"fmt"
 
+	"github.com/MRQ67/stackmatch-cli/pkg/stackmatch"
 	"github.com/spf13/cobra"
 )
 
-const cliVersion = "0.3.0" // Define the current version
+const cliVersion = stackmatch.Version // Define the current version
 
 var versionCmd = &cobra.Command{
 	Use:   "version",
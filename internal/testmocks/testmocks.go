@@ -0,0 +1,74 @@
+// Package testmocks replays scripted commands and points the CLI at a fake
+// Supabase backend, so the binary can be tested end to end without real
+// package managers or network access. Only binaries built with the testmocks
+// tag use it, and only when STACKMATCH_TEST_MOCKS names a fixture directory.
+package testmocks
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/runner"
+	"github.com/MRQ67/stackmatch-cli/pkg/runner/replay"
+)
+
+// EnvVar names the fixture directory of a test run
+const EnvVar = "STACKMATCH_TEST_MOCKS"
+
+// Files of a fixture directory
+const (
+	// FixtureFile holds the Fixture
+	FixtureFile = replay.File
+	// CallsFile receives every command line run, one per line
+	CallsFile = "calls.log"
+	// binDir holds the stub executables that make up PATH
+	binDir = "bin"
+)
+
+// Fixture scripts the outside world of a test run. Path and Commands are
+// read like those of a replay.Recording, so recordings made with
+// 'import --record' can be used as fixtures.
+type Fixture struct {
+	// SupabaseURL replaces the configured Supabase URL, typically with an
+	// httptest server
+	SupabaseURL string `json:"supabase_url,omitempty"`
+	// Path lists the commands that exist. PATH is replaced by a directory
+	// of empty stubs for them, so nothing from the real machine is found.
+	Path []string `json:"path,omitempty"`
+	// Commands maps full command lines (name and arguments joined by
+	// spaces) to their results. Other commands fail with exit status 127.
+	Commands map[string]Command `json:"commands,omitempty"`
+}
+
+// Command is the scripted result of a command
+type Command = replay.Command
+
+// Load reads the fixture of dir
+func Load(dir string) (*Fixture, error) {
+	data, err := os.ReadFile(filepath.Join(dir, FixtureFile))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read test mocks: %w", err)
+	}
+	var fixture Fixture
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		return nil, fmt.Errorf("invalid test mocks in %s: %w", dir, err)
+	}
+	return &fixture, nil
+}
+
+// Install replaces PATH with stubs for the fixture's commands and makes
+// runner.Default replay the scripted results, logging every call to
+// CallsFile in dir
+func (f *Fixture) Install(dir string) error {
+	bin := filepath.Join(dir, binDir)
+	if err := replay.WriteStubs(bin, f.Path); err != nil {
+		return err
+	}
+	if err := os.Setenv("PATH", bin); err != nil {
+		return err
+	}
+	runner.Default = replay.NewRunner(f.Commands, filepath.Join(dir, CallsFile))
+	return nil
+}
@@ -5,8 +5,20 @@ import (
 	"os"
 )
 
+// exitHooks run before ExitWithError terminates the program
+var exitHooks []func(err error)
+
+// OnExit registers fn to run with the error passed to ExitWithError before
+// the program exits
+func OnExit(fn func(err error)) {
+	exitHooks = append(exitHooks, fn)
+}
+
 // ExitWithError prints a formatted error message to stderr and exits the program.
 func ExitWithError(err error) {
 	fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	for _, fn := range exitHooks {
+		fn(err)
+	}
 	os.Exit(1)
 }
@@ -0,0 +1,370 @@
+// Package audit keeps a tamper-evident record of every change StackMatch
+// makes to the machine: packages installed, uninstalled and pinned, shell
+// init files edited and settings written. Each entry is one JSON line
+// holding the hash of the entry before it, so editing or deleting a line
+// breaks the chain from there on (see Verify).
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/config"
+	"github.com/MRQ67/stackmatch-cli/pkg/runner"
+)
+
+// Actions recorded in Entry.Action
+const (
+	ActionInstall        = "install"
+	ActionReinstall      = "reinstall"
+	ActionUninstall      = "uninstall"
+	ActionPin            = "pin"
+	ActionUnpin          = "unpin"
+	ActionShellInit      = "shell-init"
+	ActionCrontab        = "crontab"
+	ActionGitConfig      = "git-config"
+	ActionLanguageConfig = "language-config"
+	ActionService        = "service"
+	ActionCorepack       = "corepack"
+)
+
+// Results recorded in Entry.Result
+const (
+	ResultOK     = "ok"
+	ResultFailed = "failed"
+)
+
+// Entry is one change made to the machine
+type Entry struct {
+	Time time.Time `json:"time"`
+	// User is the account StackMatch ran as
+	User string `json:"user"`
+	// Command is the StackMatch command that made the change, such as "import"
+	Command string `json:"command"`
+	Action  string `json:"action"`
+	// Package is what the change is about: the packages installed, the
+	// version manager whose init lines were added, the setting written
+	Package string `json:"package,omitempty"`
+	Version string `json:"version,omitempty"`
+	// Manager is the package, version or service manager making the change
+	Manager string `json:"manager,omitempty"`
+	// File is the file written directly, for changes that run no command
+	File string `json:"file,omitempty"`
+	// Argv holds the exact arguments of every command run for the change,
+	// in the order they ran
+	Argv   [][]string `json:"argv,omitempty"`
+	Result string     `json:"result"`
+	Error  string     `json:"error,omitempty"`
+	// Prev is the Hash of the entry before, empty for the first
+	Prev string `json:"prev"`
+	// Hash covers Prev and every other field of the entry
+	Hash string `json:"hash"`
+}
+
+// hash returns the hash of e chained to prev
+func (e Entry) hash(prev string) (string, error) {
+	e.Prev = prev
+	e.Hash = ""
+	data, err := json.Marshal(e)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Log is the JSON lines file entries are appended to
+type Log struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewLog returns the log kept in path
+func NewLog(path string) *Log {
+	return &Log{path: path}
+}
+
+// Path returns the file of the log
+func (l *Log) Path() string {
+	return l.path
+}
+
+// Append chains e to the last entry of the log and writes it as one line.
+// The log is locked from reading the last entry until e is written, so
+// commands run at the same time don't chain two entries to the same one.
+func (l *Log) Append(e Entry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	f, err := config.AppendPrivateFile(l.path)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", l.path, err)
+	}
+	defer f.Close()
+	if err := lockFile(f); err != nil {
+		return fmt.Errorf("failed to lock %s: %w", l.path, err)
+	}
+	defer unlockFile(f)
+
+	prev, err := lastHash(f)
+	if err != nil {
+		return err
+	}
+	e.Prev = prev
+	if e.Hash, err = e.hash(prev); err != nil {
+		return fmt.Errorf("failed to encode audit entry: %w", err)
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit entry: %w", err)
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write %s: %w", l.path, err)
+	}
+	return nil
+}
+
+// tailSize is how much of the end of the log lastHash reads at a time,
+// enough for most entries
+const tailSize = 64 << 10
+
+// lastHash returns the Hash of the last entry of the log open in f, or ""
+// when the log is empty. The log is read backwards from its end until the
+// whole last line has been read.
+func lastHash(f *os.File) (string, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", f.Name(), err)
+	}
+	var tail []byte
+	for offset := info.Size(); ; {
+		start := max(offset-tailSize, 0)
+		chunk := make([]byte, offset-start)
+		if _, err := f.ReadAt(chunk, start); err != nil && err != io.EOF {
+			return "", fmt.Errorf("failed to read %s: %w", f.Name(), err)
+		}
+		tail, offset = append(chunk, tail...), start
+		if offset == 0 || bytes.IndexByte(bytes.TrimRight(tail, "\n"), '\n') >= 0 {
+			break
+		}
+	}
+	lines := bytes.Split(bytes.TrimRight(tail, "\n"), []byte("\n"))
+	last := lines[len(lines)-1]
+	if len(last) == 0 {
+		return "", nil
+	}
+	var e Entry
+	if err := json.Unmarshal(last, &e); err != nil || e.Hash == "" {
+		return "", fmt.Errorf("the last line of %s is not an audit entry; check it with 'stackmatch audit verify'", f.Name())
+	}
+	return e.Hash, nil
+}
+
+// Read returns every entry of the log, oldest first. A line that is not an
+// entry is an error, since the log is only ever appended to.
+func (l *Log) Read() ([]Entry, error) {
+	data, err := os.ReadFile(l.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", l.path, err)
+	}
+
+	var entries []Entry
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, len(data)+1)
+	for line := 1; scanner.Scan(); line++ {
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return entries, &ChainError{Line: line, Reason: fmt.Sprintf("not an audit entry: %v", err)}
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// ChainError is the first line of the log that breaks the hash chain
+type ChainError struct {
+	Line   int
+	Reason string
+}
+
+func (e *ChainError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Reason)
+}
+
+// Verify checks that every entry of the log holds the hash of the one
+// before it and its own hash, and returns the number of entries. An edited
+// line fails its own hash, and a removed or reordered one fails the Prev of
+// the line after it; either is reported as a *ChainError. Removing entries
+// from the end of the log can't be told from a log that ends there.
+func (l *Log) Verify() (int, error) {
+	entries, err := l.Read()
+	if err != nil {
+		return len(entries), err
+	}
+	prev := ""
+	for i, e := range entries {
+		if e.Prev != prev {
+			return i, &ChainError{Line: i + 1, Reason: "does not follow the entry before it; an entry was removed, reordered or edited"}
+		}
+		hash, err := e.hash(prev)
+		if err != nil {
+			return i, err
+		}
+		if hash != e.Hash {
+			return i, &ChainError{Line: i + 1, Reason: "its content does not match its hash; the entry was edited"}
+		}
+		prev = e.Hash
+	}
+	return len(entries), nil
+}
+
+// Logger writes the entries of one StackMatch command. Writing is best
+// effort: an entry that can't be written is passed to Failed and the change
+// goes on.
+type Logger struct {
+	log     *Log
+	command string
+	user    string
+	// Failed is called with the error of every entry that could not be
+	// written. May be nil.
+	Failed func(error)
+}
+
+// NewLogger returns a logger appending the changes command makes to log
+func NewLogger(log *Log, command string) *Logger {
+	return &Logger{log: log, command: command, user: currentUser()}
+}
+
+// currentUser returns the name of the account running StackMatch
+func currentUser() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	for _, name := range []string{"USER", "USERNAME"} {
+		if value := os.Getenv(name); value != "" {
+			return value
+		}
+	}
+	return "unknown"
+}
+
+type loggerKey struct{}
+
+type operationKey struct{}
+
+// WithLogger returns ctx with the logger Do writes to. A nil logger turns
+// logging off, such as for simulations that change nothing.
+func WithLogger(ctx context.Context, logger *Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// Operation describes a change about to be made; it becomes an Entry once
+// the change is done
+type Operation struct {
+	Action  string
+	Package string
+	Version string
+	Manager string
+	File    string
+}
+
+// operation collects the commands run for an Operation
+type operation struct {
+	mu   sync.Mutex
+	argv [][]string
+}
+
+// Do runs fn, which makes the change op describes, and records it with the
+// commands fn ran through Runner and its outcome in the logger of ctx, if
+// any. The error of fn is returned as is.
+func Do(ctx context.Context, op Operation, fn func(ctx context.Context) error) error {
+	logger, _ := ctx.Value(loggerKey{}).(*Logger)
+	if logger == nil {
+		return fn(ctx)
+	}
+
+	running := &operation{}
+	err := fn(context.WithValue(ctx, operationKey{}, running))
+
+	entry := Entry{
+		Time:    time.Now().UTC(),
+		User:    logger.user,
+		Command: logger.command,
+		Action:  op.Action,
+		Package: op.Package,
+		Version: op.Version,
+		Manager: op.Manager,
+		File:    op.File,
+		Argv:    running.argv,
+		Result:  ResultOK,
+	}
+	if err != nil {
+		entry.Result = ResultFailed
+		entry.Error = truncateError(err.Error())
+	}
+	if werr := logger.log.Append(entry); werr != nil && logger.Failed != nil {
+		logger.Failed(werr)
+	}
+	return err
+}
+
+// maxErrorSize bounds the Error of entries, since errors of package manager
+// commands hold their whole output
+const maxErrorSize = 4 << 10
+
+// truncateError cuts message to maxErrorSize bytes, on a character boundary
+func truncateError(message string) string {
+	if len(message) <= maxErrorSize {
+		return message
+	}
+	cut := maxErrorSize
+	for cut > 0 && !utf8.RuneStart(message[cut]) {
+		cut--
+	}
+	return message[:cut] + "... (truncated)"
+}
+
+// Runner wraps a runner.Runner so that the commands run for an operation
+// of Do are recorded in its entry. Other commands, such as those of scans,
+// are not recorded.
+type Runner struct {
+	runner.Runner
+}
+
+// record adds the command to the operation of ctx, if any
+func record(ctx context.Context, name string, args []string) {
+	running, _ := ctx.Value(operationKey{}).(*operation)
+	if running == nil {
+		return
+	}
+	running.mu.Lock()
+	defer running.mu.Unlock()
+	running.argv = append(running.argv, append([]string{name}, args...))
+}
+
+// CombinedOutput implements runner.Runner
+func (r Runner) CombinedOutput(ctx context.Context, name string, args ...string) (string, error) {
+	record(ctx, name, args)
+	return r.Runner.CombinedOutput(ctx, name, args...)
+}
+
+// Output implements runner.Runner
+func (r Runner) Output(ctx context.Context, name string, args ...string) (string, string, error) {
+	record(ctx, name, args)
+	return r.Runner.Output(ctx, name, args...)
+}
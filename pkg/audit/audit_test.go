@@ -0,0 +1,251 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/runner/runnertest"
+)
+
+// writeLog returns a log holding an entry per package
+func writeLog(t *testing.T, packages ...string) *Log {
+	t.Helper()
+	log := NewLog(filepath.Join(t.TempDir(), "audit.jsonl"))
+	for _, pkg := range packages {
+		if err := log.Append(Entry{User: "dev", Command: "import", Action: ActionInstall, Package: pkg, Manager: "APT", Result: ResultOK}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	return log
+}
+
+func TestVerify(t *testing.T) {
+	log := writeLog(t, "git", "make", "curl")
+	count, err := log.Verify()
+	if err != nil || count != 3 {
+		t.Fatalf("expected 3 intact entries but got %d, %v", count, err)
+	}
+	entries, _ := log.Read()
+	if entries[0].Prev != "" || entries[1].Prev != entries[0].Hash || entries[2].Prev != entries[1].Hash {
+		t.Errorf("expected each entry to hold the hash of the one before but got %+v", entries)
+	}
+
+	empty := NewLog(filepath.Join(t.TempDir(), "audit.jsonl"))
+	if count, err := empty.Verify(); err != nil || count != 0 {
+		t.Errorf("expected a missing log to be intact but got %d, %v", count, err)
+	}
+}
+
+// TestAppendConcurrently appends through a log of the same file per
+// goroutine, as commands running at once would, and checks the chain
+// stays intact
+func TestAppendConcurrently(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	var wg sync.WaitGroup
+	for i := range 100 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := NewLog(path).Append(Entry{User: "dev", Command: "import", Action: ActionInstall, Package: fmt.Sprint("pkg-", i), Result: ResultOK}); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if count, err := NewLog(path).Verify(); err != nil || count != 100 {
+		t.Errorf("expected 100 intact entries but got %d, %v", count, err)
+	}
+}
+
+// TestAppendAfterLongEntry appends after an entry longer than tailSize, as
+// written by releases that didn't bound errors
+func TestAppendAfterLongEntry(t *testing.T) {
+	log := writeLog(t, "git")
+	long := Entry{User: "dev", Command: "import", Action: ActionInstall, Package: "nodejs", Result: ResultFailed, Error: strings.Repeat("E: Sub-process /usr/bin/dpkg returned an error code (1)\n", 3000)}
+	if err := log.Append(long); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := log.Append(Entry{User: "dev", Command: "import", Action: ActionInstall, Package: "make", Result: ResultOK}); err != nil {
+		t.Fatalf("expected to append after a long entry but got %v", err)
+	}
+	if count, err := log.Verify(); err != nil || count != 3 {
+		t.Errorf("expected 3 intact entries but got %d, %v", count, err)
+	}
+}
+
+func TestTruncateError(t *testing.T) {
+	if message := "exit status 1"; truncateError(message) != message {
+		t.Errorf("expected a short error to be kept but got %q", truncateError(message))
+	}
+	truncated := truncateError(strings.Repeat("é", maxErrorSize))
+	if len(truncated) > maxErrorSize+len("... (truncated)") || !utf8.ValidString(truncated) || !strings.HasSuffix(truncated, "... (truncated)") {
+		t.Errorf("expected the error cut to %d bytes on a character boundary but got %d bytes", maxErrorSize, len(truncated))
+	}
+}
+
+func TestVerifyDetectsTampering(t *testing.T) {
+	testCases := []struct {
+		name   string
+		tamper func(lines []string) []string
+		line   int
+	}{
+		{
+			name: "Edited field",
+			tamper: func(lines []string) []string {
+				lines[1] = strings.Replace(lines[1], `"package":"make"`, `"package":"nmap"`, 1)
+				return lines
+			},
+			line: 2,
+		},
+		{
+			name: "Edited field and hash",
+			tamper: func(lines []string) []string {
+				// Rehashing the edited line still breaks the line after it
+				var entry Entry
+				if err := json.Unmarshal([]byte(lines[1]), &entry); err != nil {
+					t.Fatal(err)
+				}
+				entry.Package = "nmap"
+				entry.Hash, _ = entry.hash(entry.Prev)
+				forged, _ := json.Marshal(entry)
+				lines[1] = string(forged)
+				return lines
+			},
+			line: 3,
+		},
+		{
+			name: "Removed line",
+			tamper: func(lines []string) []string {
+				return append(lines[:1], lines[2:]...)
+			},
+			line: 2,
+		},
+		{
+			name: "Swapped lines",
+			tamper: func(lines []string) []string {
+				lines[1], lines[2] = lines[2], lines[1]
+				return lines
+			},
+			line: 2,
+		},
+		{
+			name: "Garbage line",
+			tamper: func(lines []string) []string {
+				lines[0] = "not json"
+				return lines
+			},
+			line: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			log := writeLog(t, "git", "make", "curl")
+			data, err := os.ReadFile(log.Path())
+			if err != nil {
+				t.Fatal(err)
+			}
+			lines := tc.tamper(strings.Split(strings.TrimSpace(string(data)), "\n"))
+			if err := os.WriteFile(log.Path(), []byte(strings.Join(lines, "\n")+"\n"), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			count, err := log.Verify()
+			var chainErr *ChainError
+			if !errors.As(err, &chainErr) {
+				t.Fatalf("expected a chain error but got %v", err)
+			}
+			if chainErr.Line != tc.line || count != tc.line-1 {
+				t.Errorf("expected line %d to break the chain after %d entries but got line %d after %d", tc.line, tc.line-1, chainErr.Line, count)
+			}
+		})
+	}
+}
+
+func TestDo(t *testing.T) {
+	r := &runnertest.Runner{Responses: map[string]runnertest.Response{
+		"apt-get install --assume-yes git": {Output: "Setting up git"},
+		"dpkg -s nmap":                     {Err: errors.New("exit status 1")},
+	}}
+	wrapped := Runner{Runner: r}
+	log := NewLog(filepath.Join(t.TempDir(), "audit.jsonl"))
+	logger := NewLogger(log, "import")
+	ctx := WithLogger(context.Background(), logger)
+
+	// Commands outside an operation, such as scans, are not recorded
+	wrapped.CombinedOutput(ctx, "git", "--version")
+	err := Do(ctx, Operation{Action: ActionInstall, Package: "git", Manager: "APT"}, func(ctx context.Context) error {
+		_, err := wrapped.CombinedOutput(ctx, "apt-get", "install", "--assume-yes", "git")
+		return err
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	failure := errors.New("exit status 1")
+	err = Do(ctx, Operation{Action: ActionPin, Package: "nmap", Manager: "APT"}, func(ctx context.Context) error {
+		wrapped.Output(ctx, "dpkg", "-s", "nmap")
+		return failure
+	})
+	if err != failure {
+		t.Errorf("expected the error of the change back but got %v", err)
+	}
+
+	entries, err := log.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries but got %+v", entries)
+	}
+	expected := [][][]string{{{"apt-get", "install", "--assume-yes", "git"}}, {{"dpkg", "-s", "nmap"}}}
+	for i, entry := range entries {
+		if !reflect.DeepEqual(entry.Argv, expected[i]) {
+			t.Errorf("expected the commands %v but got %v", expected[i], entry.Argv)
+		}
+		if entry.Command != "import" || entry.User == "" || entry.Time.IsZero() {
+			t.Errorf("expected the command, user and time to be recorded but got %+v", entry)
+		}
+	}
+	if entries[0].Result != ResultOK || entries[1].Result != ResultFailed || entries[1].Error != "exit status 1" {
+		t.Errorf("expected ok then failed but got %+v", entries)
+	}
+
+	// Without a logger nothing is recorded
+	Do(WithLogger(ctx, nil), Operation{Action: ActionInstall, Package: "make"}, func(context.Context) error { return nil })
+	if entries, _ := log.Read(); len(entries) != 2 {
+		t.Errorf("expected nothing recorded without a logger but got %+v", entries)
+	}
+}
+
+func TestDoWhenLogCannotBeWritten(t *testing.T) {
+	// The parent of the log is a file, so the log can't be created
+	parent := filepath.Join(t.TempDir(), "state")
+	if err := os.WriteFile(parent, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	logger := NewLogger(NewLog(filepath.Join(parent, "audit.jsonl")), "import")
+	var failures []error
+	logger.Failed = func(err error) { failures = append(failures, err) }
+
+	ran := false
+	err := Do(WithLogger(context.Background(), logger), Operation{Action: ActionInstall, Package: "git"}, func(context.Context) error {
+		ran = true
+		return nil
+	})
+	if err != nil || !ran {
+		t.Errorf("expected the change to go on but got ran %v, %v", ran, err)
+	}
+	if len(failures) != 1 {
+		t.Errorf("expected the failure to be reported once but got %v", failures)
+	}
+}
@@ -0,0 +1,29 @@
+//go:build windows
+
+package audit
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// lockOffsetHigh is the high half of the offset of the byte lockFile
+// locks, 1<<62, far past the end of any log: Windows locks are mandatory,
+// and locking the entries would keep other commands from reading them
+const lockOffsetHigh = 1 << 30
+
+// lockFile blocks until it holds an exclusive lock on f
+func lockFile(f *os.File) error {
+	return windows.LockFileEx(windows.Handle(f.Fd()), windows.LOCKFILE_EXCLUSIVE_LOCK, 0, 1, 0, lockRange())
+}
+
+// unlockFile releases the lock taken by lockFile
+func unlockFile(f *os.File) error {
+	return windows.UnlockFileEx(windows.Handle(f.Fd()), 0, 1, 0, lockRange())
+}
+
+// lockRange returns the position of the locked byte
+func lockRange() *windows.Overlapped {
+	return &windows.Overlapped{OffsetHigh: lockOffsetHigh}
+}
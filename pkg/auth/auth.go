@@ -5,10 +5,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"path/filepath"
 	"sync"
 	"time"
 
+	"github.com/MRQ67/stackmatch-cli/pkg/config"
 	"github.com/google/uuid"
 	"github.com/supabase-community/gotrue-go/types"
 )
@@ -84,12 +84,7 @@ var (
 )
 
 func init() {
-	// Set up session file path with proper permissions
-	home, err := os.UserHomeDir()
-	if err != nil {
-		home = "."
-	}
-	sessionFile = filepath.Join(home, ".stackmatch", "session.json")
+	sessionFile = config.SessionFile()
 	initialized = true
 }
 
@@ -110,21 +105,7 @@ func SaveSession(user *User) error {
 		return fmt.Errorf("failed to marshal session: %w", err)
 	}
 
-	// Ensure the directory exists
-	dir := filepath.Dir(sessionFile)
-	if err := os.MkdirAll(dir, 0700); err != nil {
-		return fmt.Errorf("failed to create config directory: %w", err)
-	}
-
-	// Write to a temporary file first
-	tempFile := sessionFile + ".tmp"
-	if err := os.WriteFile(tempFile, data, 0600); err != nil {
-		return fmt.Errorf("failed to write session file: %w", err)
-	}
-
-	// Atomically rename the temp file
-	if err := os.Rename(tempFile, sessionFile); err != nil {
-		_ = os.Remove(tempFile)
+	if err := config.WritePrivateFile(sessionFile, data); err != nil {
 		return fmt.Errorf("failed to save session: %w", err)
 	}
 
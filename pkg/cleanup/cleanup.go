@@ -0,0 +1,290 @@
+// Package cleanup reports and removes what StackMatch accumulates in its
+// state directory. The session, configuration files and installations still
+// in progress are never removed.
+package cleanup
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/installer"
+)
+
+// Kind is the kind of artifact a state directory entry holds
+type Kind string
+
+const (
+	// KindCache is cached data that is fetched again when missing: the cache
+	// directory and *-cache.json files
+	KindCache Kind = "cache"
+	// KindSnapshots are environment snapshots kept in the snapshots directory
+	KindSnapshots Kind = "snapshots"
+	// KindReports are the installation records import keeps for history and
+	// rollback
+	KindReports Kind = "reports"
+	// KindStats is the local usage statistics file and its rotated copy
+	KindStats Kind = "stats"
+	// KindPreserved is everything else: the session, configuration files such
+	// as detectors.yaml, and files cleanup doesn't know
+	KindPreserved Kind = "preserved"
+)
+
+const (
+	cacheDir     = "cache"
+	snapshotsDir = "snapshots"
+	trackerFile  = "installations.json"
+	statsFile    = "stats.jsonl"
+)
+
+// Entry is a top-level file or directory of the state directory
+type Entry struct {
+	Name string
+	Kind Kind
+	// Size is the total size of the files, in bytes
+	Size int64
+	// Items counts the files in a directory, or the records in
+	// installations.json
+	Items int
+}
+
+// Report lists the entries of the state directory dir, sorted by name. A
+// missing directory has no entries.
+func Report(dir string) ([]Entry, error) {
+	dirEntries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state directory: %w", err)
+	}
+
+	var entries []Entry
+	for _, dirEntry := range dirEntries {
+		entry := Entry{Name: dirEntry.Name(), Kind: kindOf(dirEntry.Name(), dirEntry.IsDir())}
+		entry.Size, entry.Items, err = measure(filepath.Join(dir, entry.Name))
+		if err != nil {
+			return nil, err
+		}
+		if entry.Name == trackerFile {
+			tracker, err := installer.NewInstallationTracker(filepath.Join(dir, trackerFile))
+			if err != nil {
+				return nil, err
+			}
+			entry.Items = len(tracker.ListInstallations())
+		}
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+	return entries, nil
+}
+
+// kindOf classifies the top-level entry name
+func kindOf(name string, isDir bool) Kind {
+	switch {
+	case isDir && name == cacheDir, !isDir && strings.HasSuffix(name, "-cache.json"):
+		return KindCache
+	case isDir && name == snapshotsDir:
+		return KindSnapshots
+	case !isDir && name == trackerFile:
+		return KindReports
+	case !isDir && (name == statsFile || strings.HasPrefix(name, statsFile+".")):
+		return KindStats
+	default:
+		return KindPreserved
+	}
+}
+
+// measure returns the total size and the number of files under path
+func measure(path string) (int64, int, error) {
+	var size int64
+	items := 0
+	err := filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		size += info.Size()
+		items++
+		return nil
+	})
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to measure %s: %w", path, err)
+	}
+	return size, items, nil
+}
+
+// Options selects what Clean removes
+type Options struct {
+	Cache     bool
+	Snapshots bool
+	Reports   bool
+	Stats     bool
+	// OlderThan keeps anything more recent, going by modification time or,
+	// for reports, by when the installation last changed. Zero removes
+	// everything selected.
+	OlderThan time.Duration
+	// DryRun reports what would be removed without removing it
+	DryRun bool
+	// Now is the time OlderThan counts back from; zero means time.Now()
+	Now time.Time
+}
+
+// Removal is something Clean removed, or would remove in a dry run
+type Removal struct {
+	Kind Kind
+	// Path is the file or directory removed, relative to the state directory.
+	// For reports it is the installation ID.
+	Path  string
+	Size  int64
+	Items int
+}
+
+// Clean removes the artifacts opts selects from the state directory dir and
+// returns what it removed. Installations still in progress are kept, since
+// they are needed to roll back an interrupted import.
+func Clean(dir string, opts Options) ([]Removal, error) {
+	now := opts.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	var cutoff time.Time
+	if opts.OlderThan > 0 {
+		cutoff = now.Add(-opts.OlderThan)
+	}
+	old := func(t time.Time) bool {
+		return cutoff.IsZero() || t.Before(cutoff)
+	}
+
+	entries, err := Report(dir)
+	if err != nil {
+		return nil, err
+	}
+	var removals []Removal
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name)
+		switch {
+		case entry.Kind == KindCache && opts.Cache, entry.Kind == KindSnapshots && opts.Snapshots:
+			var removed []Removal
+			if entry.Name == cacheDir || entry.Name == snapshotsDir {
+				removed, err = cleanDir(dir, entry.Name, entry.Kind, old, opts.DryRun)
+			} else {
+				removed, err = cleanFile(dir, entry.Name, entry.Kind, old, opts.DryRun)
+			}
+			if err != nil {
+				return removals, err
+			}
+			removals = append(removals, removed...)
+		case entry.Kind == KindStats && opts.Stats:
+			removed, err := cleanFile(dir, entry.Name, entry.Kind, old, opts.DryRun)
+			if err != nil {
+				return removals, err
+			}
+			removals = append(removals, removed...)
+		case entry.Kind == KindReports && opts.Reports:
+			removed, err := cleanReports(path, old, opts.DryRun)
+			if err != nil {
+				return removals, err
+			}
+			removals = append(removals, removed...)
+		}
+	}
+	return removals, nil
+}
+
+// cleanDir removes the entries of the directory name that old selects
+func cleanDir(dir, name string, kind Kind, old func(time.Time) bool, dryRun bool) ([]Removal, error) {
+	children, err := os.ReadDir(filepath.Join(dir, name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	var removals []Removal
+	for _, child := range children {
+		removed, err := cleanFile(dir, filepath.Join(name, child.Name()), kind, old, dryRun)
+		if err != nil {
+			return removals, err
+		}
+		removals = append(removals, removed...)
+	}
+	return removals, nil
+}
+
+// cleanFile removes the file or directory name if old selects it
+func cleanFile(dir, name string, kind Kind, old func(time.Time) bool, dryRun bool) ([]Removal, error) {
+	path := filepath.Join(dir, name)
+	info, err := os.Lstat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	if !old(info.ModTime()) {
+		return nil, nil
+	}
+	size, items, err := measure(path)
+	if err != nil {
+		return nil, err
+	}
+	if !dryRun {
+		if err := os.RemoveAll(path); err != nil {
+			return nil, fmt.Errorf("failed to remove %s: %w", name, err)
+		}
+	}
+	return []Removal{{Kind: kind, Path: name, Size: size, Items: items}}, nil
+}
+
+// cleanReports removes the installation records in path that old selects
+func cleanReports(path string, old func(time.Time) bool, dryRun bool) ([]Removal, error) {
+	tracker, err := installer.NewInstallationTracker(path)
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	var removals []Removal
+	for _, record := range tracker.ListInstallations() {
+		if record.Status == installer.StatusInProgress || !old(record.Timestamp) {
+			continue
+		}
+		data, _ := json.MarshalIndent(record, "  ", "  ")
+		ids = append(ids, record.ID)
+		removals = append(removals, Removal{Kind: KindReports, Path: record.ID, Size: int64(len(data)), Items: 1})
+	}
+	sort.Slice(removals, func(i, j int) bool { return removals[i].Path < removals[j].Path })
+	if dryRun || len(ids) == 0 {
+		return removals, nil
+	}
+	if err := tracker.RemoveInstallations(ids); err != nil {
+		return nil, err
+	}
+	return removals, nil
+}
+
+// ParseAge parses an age such as "30d", "2w" or any time.ParseDuration
+// duration like "12h"
+func ParseAge(s string) (time.Duration, error) {
+	units := map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour}
+	for suffix, unit := range units {
+		if n, ok := strings.CutSuffix(s, suffix); ok {
+			count, err := strconv.Atoi(n)
+			if err != nil || count < 0 {
+				return 0, fmt.Errorf("invalid age %q: use a number of days or weeks like 30d or 2w, or a duration like 12h", s)
+			}
+			return time.Duration(count) * unit, nil
+		}
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid age %q: use a number of days or weeks like 30d or 2w, or a duration like 12h", s)
+	}
+	return d, nil
+}
@@ -0,0 +1,224 @@
+package cleanup
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/installer"
+)
+
+var now = time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+// populate fills a state directory with every kind of artifact, half of
+// them 60 days old
+func populate(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	old := now.AddDate(0, 0, -60)
+	files := []struct {
+		name    string
+		content string
+		modTime time.Time
+	}{
+		{"session.json", `{"access_token":"x"}`, old},
+		{"detectors.yaml", "detectors: []\n", old},
+		{"stats.jsonl", "{}\n", now},
+		{"scan-cache.json", "{}", old},
+		{"cache/brew.json", "0123456789", old},
+		{"cache/apt.json", "01234", now},
+		{"snapshots/laptop.json", "0123456789", old},
+		{"snapshots/desktop.json", "012", now},
+	}
+	for _, file := range files {
+		path := filepath.Join(dir, file.name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(file.content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, file.modTime, file.modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	records := map[string]installer.InstallationRecord{
+		"inst_old":      {ID: "inst_old", Timestamp: old, Status: installer.StatusCompleted},
+		"inst_new":      {ID: "inst_new", Timestamp: now, Status: installer.StatusFailed},
+		"inst_running":  {ID: "inst_running", Timestamp: old, Status: installer.StatusInProgress},
+		"inst_reverted": {ID: "inst_reverted", Timestamp: old, Status: installer.StatusRolledBack},
+	}
+	data, err := json.Marshal(records)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, trackerFile), data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestReport(t *testing.T) {
+	dir := populate(t)
+
+	entries, err := Report(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var actual []Entry
+	for _, entry := range entries {
+		// The tracker file's size depends on the JSON encoding
+		if entry.Name == trackerFile {
+			entry.Size = 0
+		}
+		actual = append(actual, entry)
+	}
+	expected := []Entry{
+		{Name: "cache", Kind: KindCache, Size: 15, Items: 2},
+		{Name: "detectors.yaml", Kind: KindPreserved, Size: 14, Items: 1},
+		{Name: "installations.json", Kind: KindReports, Items: 4},
+		{Name: "scan-cache.json", Kind: KindCache, Size: 2, Items: 1},
+		{Name: "session.json", Kind: KindPreserved, Size: 20, Items: 1},
+		{Name: "snapshots", Kind: KindSnapshots, Size: 13, Items: 2},
+		{Name: "stats.jsonl", Kind: KindStats, Size: 3, Items: 1},
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected entries %+v but got %+v", expected, actual)
+	}
+}
+
+func TestReportWithoutStateDir(t *testing.T) {
+	entries, err := Report(filepath.Join(t.TempDir(), "missing"))
+	if err != nil || entries != nil {
+		t.Errorf("expected no entries but got %v, %v", entries, err)
+	}
+}
+
+func TestClean(t *testing.T) {
+	testCases := []struct {
+		name      string
+		opts      Options
+		removed   []string
+		remaining []string
+		records   []string
+	}{
+		{
+			name:      "cache",
+			opts:      Options{Cache: true},
+			removed:   []string{"cache/apt.json", "cache/brew.json", "scan-cache.json"},
+			remaining: []string{"cache", "detectors.yaml", "installations.json", "session.json", "snapshots", "stats.jsonl"},
+			records:   []string{"inst_new", "inst_old", "inst_reverted", "inst_running"},
+		},
+		{
+			name:      "old snapshots",
+			opts:      Options{Snapshots: true, OlderThan: 30 * 24 * time.Hour},
+			removed:   []string{"snapshots/laptop.json"},
+			remaining: []string{"cache", "detectors.yaml", "installations.json", "scan-cache.json", "session.json", "snapshots", "stats.jsonl"},
+			records:   []string{"inst_new", "inst_old", "inst_reverted", "inst_running"},
+		},
+		{
+			name:      "reports",
+			opts:      Options{Reports: true},
+			removed:   []string{"inst_new", "inst_old", "inst_reverted"},
+			remaining: []string{"cache", "detectors.yaml", "installations.json", "scan-cache.json", "session.json", "snapshots", "stats.jsonl"},
+			records:   []string{"inst_running"},
+		},
+		{
+			name:      "old reports",
+			opts:      Options{Reports: true, OlderThan: 30 * 24 * time.Hour},
+			removed:   []string{"inst_old", "inst_reverted"},
+			remaining: []string{"cache", "detectors.yaml", "installations.json", "scan-cache.json", "session.json", "snapshots", "stats.jsonl"},
+			records:   []string{"inst_new", "inst_running"},
+		},
+		{
+			name:      "everything",
+			opts:      Options{Cache: true, Snapshots: true, Reports: true, Stats: true},
+			removed:   []string{"cache/apt.json", "cache/brew.json", "inst_new", "inst_old", "inst_reverted", "scan-cache.json", "snapshots/desktop.json", "snapshots/laptop.json", "stats.jsonl"},
+			remaining: []string{"cache", "detectors.yaml", "installations.json", "session.json", "snapshots"},
+			records:   []string{"inst_running"},
+		},
+		{
+			name:      "dry run",
+			opts:      Options{Cache: true, Snapshots: true, Reports: true, Stats: true, DryRun: true},
+			removed:   []string{"cache/apt.json", "cache/brew.json", "inst_new", "inst_old", "inst_reverted", "scan-cache.json", "snapshots/desktop.json", "snapshots/laptop.json", "stats.jsonl"},
+			remaining: []string{"cache", "detectors.yaml", "installations.json", "scan-cache.json", "session.json", "snapshots", "stats.jsonl"},
+			records:   []string{"inst_new", "inst_old", "inst_reverted", "inst_running"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := populate(t)
+			tc.opts.Now = now
+
+			removals, err := Clean(dir, tc.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var removed []string
+			for _, removal := range removals {
+				removed = append(removed, filepath.ToSlash(removal.Path))
+			}
+			if !reflect.DeepEqual(removed, tc.removed) {
+				t.Errorf("expected %v to be removed but got %v", tc.removed, removed)
+			}
+
+			entries, err := Report(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var remaining []string
+			for _, entry := range entries {
+				remaining = append(remaining, entry.Name)
+			}
+			if !reflect.DeepEqual(remaining, tc.remaining) {
+				t.Errorf("expected %v to remain but got %v", tc.remaining, remaining)
+			}
+
+			tracker, err := installer.NewInstallationTracker(filepath.Join(dir, trackerFile))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var records []string
+			for _, record := range tracker.ListInstallations() {
+				records = append(records, record.ID)
+			}
+			sort.Strings(records)
+			if !reflect.DeepEqual(records, tc.records) {
+				t.Errorf("expected records %v to remain but got %v", tc.records, records)
+			}
+		})
+	}
+}
+
+func TestParseAge(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected time.Duration
+		err      bool
+	}{
+		{input: "30d", expected: 30 * 24 * time.Hour},
+		{input: "2w", expected: 14 * 24 * time.Hour},
+		{input: "12h", expected: 12 * time.Hour},
+		{input: "d", err: true},
+		{input: "-3d", err: true},
+		{input: "soon", err: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			actual, err := ParseAge(tc.input)
+			if (err != nil) != tc.err {
+				t.Fatalf("expected error %v but got %v", tc.err, err)
+			}
+			if actual != tc.expected {
+				t.Errorf("expected %v but got %v", tc.expected, actual)
+			}
+		})
+	}
+}
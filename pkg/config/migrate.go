@@ -0,0 +1,322 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Migration moves one file StackMatch no longer reads where it is into the
+// state directory
+type Migration struct {
+	// Layout names the legacy layout the file belongs to (see
+	// legacyLayouts)
+	Layout string
+	From   string
+	To     string
+	// Conflict is set when To already exists. The file at To is the one
+	// read, so From is left where it is.
+	Conflict bool
+}
+
+// legacyLayout is a place older releases, or earlier runs, kept files that
+// are no longer read from there
+type legacyLayout struct {
+	Name string
+	// dir returns where the layout keeps its files, or "" when it doesn't
+	// apply
+	dir func(key migrationKey) string
+	// Files are the names of the files it holds, relative to dir. Nil means
+	// every file under dir.
+	Files []string
+}
+
+// migrationKey is what the legacy layouts and the state directory depend on
+type migrationKey struct {
+	stateDir, configDir, temp string
+}
+
+// legacyLayouts are the layouts files are moved out of, in order
+var legacyLayouts = []legacyLayout{
+	{
+		// Releases before the state directory was resolved kept the
+		// configuration in the OS configuration directory
+		Name:  "config-dir",
+		dir:   func(key migrationKey) string { return nonEmptyJoin(key.configDir, "stackmatch") },
+		Files: []string{"config.json"},
+	},
+	{
+		// Runs that couldn't write the home directory kept their state in
+		// the temp directory (see ResolveState), which is no longer read
+		// once the home directory can be written again
+		Name: "temp-state",
+		dir: func(key migrationKey) string {
+			fallback := nonEmptyJoin(key.temp, tempStateName())
+			if fallback == key.stateDir || ownedByOther(fallback) {
+				return ""
+			}
+			return fallback
+		},
+	},
+}
+
+// migrationsFile names the marker in the state directory recording the
+// layouts already migrated
+const migrationsFile = "migrations.json"
+
+// migrationMarker is the content of migrationsFile
+type migrationMarker struct {
+	// Migrated maps a layout to when its files were moved
+	Migrated map[string]time.Time `json:"migrated"`
+}
+
+// MigrationReport is what Migrate did
+type MigrationReport struct {
+	// Moved are the files moved into the state directory
+	Moved []Migration
+	// Kept are the files left in place because the state directory already
+	// has a file of the same name
+	Kept []Migration
+}
+
+// Empty reports whether Migrate found nothing to move
+func (r MigrationReport) Empty() bool {
+	return len(r.Moved) == 0 && len(r.Kept) == 0
+}
+
+// PendingMigrations returns the files of legacy layouts that Migrate would
+// move into the state directory, without moving them. Layouts already
+// migrated are not looked at again.
+func PendingMigrations() ([]Migration, error) {
+	return pendingMigrations(currentMigrationKey())
+}
+
+// Migrate moves the files of legacy layouts into the state directory, once
+// per layout: each file is copied, the copy is read back and compared, and
+// only then is the original removed. Files the state directory already has
+// are kept where they are. Layouts whose files were all handled are recorded
+// in migrations.json, so they are skipped from then on. Nothing is moved
+// when the state directory can't be written.
+func Migrate() (MigrationReport, error) {
+	if !ResolveState().Writable {
+		return MigrationReport{}, nil
+	}
+	return migrate(currentMigrationKey(), time.Now())
+}
+
+// currentMigrationKey returns the directories of this run
+func currentMigrationKey() migrationKey {
+	// Without a configuration directory there is no layout there
+	configDir, _ := os.UserConfigDir()
+	return migrationKey{stateDir: StateDir(), configDir: configDir, temp: os.TempDir()}
+}
+
+func pendingMigrations(key migrationKey) ([]Migration, error) {
+	marker, err := readMigrationMarker(key.stateDir)
+	if err != nil {
+		return nil, err
+	}
+	var pending []Migration
+	for _, layout := range legacyLayouts {
+		if _, done := marker.Migrated[layout.Name]; done {
+			continue
+		}
+		files, err := layout.files(key)
+		if err != nil {
+			return pending, err
+		}
+		pending = append(pending, files...)
+	}
+	return pending, nil
+}
+
+func migrate(key migrationKey, now time.Time) (MigrationReport, error) {
+	var report MigrationReport
+	pending, err := pendingMigrations(key)
+	if err != nil {
+		return report, err
+	}
+	if len(pending) == 0 {
+		return report, nil
+	}
+
+	marker, err := readMigrationMarker(key.stateDir)
+	if err != nil {
+		return report, err
+	}
+	var errs []error
+	for _, layout := range legacyLayouts {
+		failed := false
+		found := false
+		for _, m := range pending {
+			if m.Layout != layout.Name {
+				continue
+			}
+			found = true
+			if m.Conflict {
+				report.Kept = append(report.Kept, m)
+				continue
+			}
+			if err := moveFile(m.From, m.To); err != nil {
+				errs = append(errs, err)
+				failed = true
+				continue
+			}
+			report.Moved = append(report.Moved, m)
+		}
+		if found && !failed {
+			removeEmptyDirs(layout.dir(key))
+			marker.Migrated[layout.Name] = now.UTC()
+		}
+	}
+
+	data, err := json.MarshalIndent(marker, "", "  ")
+	if err == nil {
+		err = WritePrivateFile(filepath.Join(key.stateDir, migrationsFile), data)
+	}
+	if err != nil {
+		errs = append(errs, fmt.Errorf("failed to record the migrations: %w", err))
+	}
+	return report, errors.Join(errs...)
+}
+
+// files returns the files of the layout found under key, each with where it
+// goes in the state directory
+func (l legacyLayout) files(key migrationKey) ([]Migration, error) {
+	dir := l.dir(key)
+	if dir == "" || dir == key.stateDir {
+		return nil, nil
+	}
+	names := l.Files
+	if names == nil {
+		var err error
+		if names, err = listStateFiles(dir); err != nil {
+			return nil, err
+		}
+	}
+
+	var migrations []Migration
+	for _, name := range names {
+		from := filepath.Join(dir, name)
+		if info, err := os.Lstat(from); err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		m := Migration{Layout: l.Name, From: from, To: filepath.Join(key.stateDir, name)}
+		if _, err := os.Lstat(m.To); err == nil {
+			m.Conflict = true
+		}
+		migrations = append(migrations, m)
+	}
+	return migrations, nil
+}
+
+// listStateFiles returns the files under dir relative to it, less those
+// that only matter to the directory they are in: the marker of the warning
+// about it and files half written by WritePrivateFile
+func listStateFiles(dir string) ([]string, error) {
+	var names []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if errors.Is(err, fs.ErrNotExist) && path == dir {
+			return fs.SkipAll
+		}
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		name, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if base := d.Name(); base == ".fallback-warned" || (strings.HasPrefix(base, ".") && strings.HasSuffix(base, ".tmp")) {
+			return nil
+		}
+		names = append(names, name)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", dir, err)
+	}
+	slices.Sort(names)
+	return names, nil
+}
+
+// moveFile copies from to to, readable only by the current user, checks the
+// copy reads back the same and then removes from. A failed copy leaves from
+// untouched.
+func moveFile(from, to string) error {
+	data, err := os.ReadFile(from)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", from, err)
+	}
+	if err := WritePrivateFile(to, data); err != nil {
+		return err
+	}
+	copied, err := os.ReadFile(to)
+	if err != nil || !bytes.Equal(copied, data) {
+		os.Remove(to)
+		return fmt.Errorf("failed to copy %s to %s: the copy doesn't match", from, to)
+	}
+	if err := os.Remove(from); err != nil {
+		return fmt.Errorf("copied %s to %s but failed to remove it: %w", from, to, err)
+	}
+	return nil
+}
+
+// removeEmptyDirs removes dir and the directories under it once they are
+// empty, deepest first
+func removeEmptyDirs(dir string) {
+	if dir == "" {
+		return
+	}
+	var dirs []string
+	_ = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && d.IsDir() {
+			dirs = append(dirs, path)
+		}
+		return nil
+	})
+	for _, d := range slices.Backward(dirs) {
+		// Directories that still hold files fail to be removed
+		os.Remove(d)
+	}
+	// The marker of the fallback warning is all a migrated fallback has left
+	if os.Remove(filepath.Join(dir, ".fallback-warned")) == nil {
+		os.Remove(dir)
+	}
+}
+
+// readMigrationMarker returns the layouts already migrated into stateDir
+func readMigrationMarker(stateDir string) (migrationMarker, error) {
+	marker := migrationMarker{Migrated: make(map[string]time.Time)}
+	data, err := os.ReadFile(filepath.Join(stateDir, migrationsFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return marker, nil
+	}
+	if err != nil {
+		return marker, err
+	}
+	if err := json.Unmarshal(data, &marker); err != nil {
+		return marker, fmt.Errorf("invalid %s: %w", filepath.Join(stateDir, migrationsFile), err)
+	}
+	if marker.Migrated == nil {
+		marker.Migrated = make(map[string]time.Time)
+	}
+	return marker, nil
+}
+
+// nonEmptyJoin joins dir and name, or returns "" when dir is unknown
+func nonEmptyJoin(dir, name string) string {
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, name)
+}
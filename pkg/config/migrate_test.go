@@ -0,0 +1,176 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// writeFiles creates the files under dir, keyed by their path relative to
+// it
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// expectFile fails the test unless path holds content
+func expectFile(t *testing.T, path, content string) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Errorf("expected %s to exist but got %v", path, err)
+		return
+	}
+	if string(data) != content {
+		t.Errorf("expected %s to hold %q but got %q", path, content, data)
+	}
+}
+
+// expectMissing fails the test if path exists
+func expectMissing(t *testing.T, path string) {
+	t.Helper()
+	if _, err := os.Lstat(path); err == nil {
+		t.Errorf("expected %s to be gone", path)
+	}
+}
+
+func TestMigrateConfigDir(t *testing.T) {
+	root := t.TempDir()
+	key := migrationKey{stateDir: filepath.Join(root, "home", ".stackmatch"), configDir: filepath.Join(root, "config"), temp: filepath.Join(root, "tmp")}
+	legacy := filepath.Join(key.configDir, "stackmatch")
+	writeFiles(t, legacy, map[string]string{"config.json": `{"local_only": true}`})
+
+	// Listing them moves nothing
+	pending, err := pendingMigrations(key)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []Migration{{Layout: "config-dir", From: filepath.Join(legacy, "config.json"), To: filepath.Join(key.stateDir, "config.json")}}
+	if !reflect.DeepEqual(pending, expected) {
+		t.Fatalf("expected %+v but got %+v", expected, pending)
+	}
+	expectFile(t, filepath.Join(legacy, "config.json"), `{"local_only": true}`)
+
+	now := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	report, err := migrate(key, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(report.Moved, expected) || len(report.Kept) != 0 {
+		t.Errorf("expected %+v to be moved but got %+v", expected, report)
+	}
+	expectFile(t, filepath.Join(key.stateDir, "config.json"), `{"local_only": true}`)
+	expectMissing(t, legacy)
+	if runtime.GOOS != "windows" {
+		if info, err := os.Stat(filepath.Join(key.stateDir, "config.json")); err != nil || info.Mode().Perm() != PrivateFileMode {
+			t.Errorf("expected the moved file to be readable only by its owner but got %v, %v", info, err)
+		}
+	}
+
+	marker, err := readMigrationMarker(key.stateDir)
+	if err != nil || !marker.Migrated["config-dir"].Equal(now) {
+		t.Errorf("expected the migration to be recorded but got %+v, %v", marker, err)
+	}
+
+	// An older release writing the file again doesn't bring it back
+	writeFiles(t, legacy, map[string]string{"config.json": `{}`})
+	report, err = migrate(key, now)
+	if err != nil || !report.Empty() {
+		t.Errorf("expected the migration to run once but got %+v, %v", report, err)
+	}
+	expectFile(t, filepath.Join(key.stateDir, "config.json"), `{"local_only": true}`)
+}
+
+func TestMigrateTempState(t *testing.T) {
+	root := t.TempDir()
+	key := migrationKey{stateDir: filepath.Join(root, "home", ".stackmatch"), configDir: filepath.Join(root, "config"), temp: filepath.Join(root, "tmp")}
+	fallback := filepath.Join(key.temp, tempStateName())
+	writeFiles(t, fallback, map[string]string{
+		"session.json":                           `{"access_token": "fallback"}`,
+		"installations.json":                     `[]`,
+		filepath.Join("snapshots", "first.json"): `{"tools": {}}`,
+		".fallback-warned":                       "",
+		".session.json.123.tmp":                  "half written",
+	})
+	writeFiles(t, key.stateDir, map[string]string{"installations.json": `[{"id": "kept"}]`})
+
+	report, err := migrate(key, time.Now())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedMoved := []Migration{
+		{Layout: "temp-state", From: filepath.Join(fallback, "session.json"), To: filepath.Join(key.stateDir, "session.json")},
+		{Layout: "temp-state", From: filepath.Join(fallback, "snapshots", "first.json"), To: filepath.Join(key.stateDir, "snapshots", "first.json")},
+	}
+	expectedKept := []Migration{
+		{Layout: "temp-state", From: filepath.Join(fallback, "installations.json"), To: filepath.Join(key.stateDir, "installations.json"), Conflict: true},
+	}
+	if !reflect.DeepEqual(report.Moved, expectedMoved) || !reflect.DeepEqual(report.Kept, expectedKept) {
+		t.Errorf("expected %+v to be moved and %+v kept but got %+v", expectedMoved, expectedKept, report)
+	}
+
+	expectFile(t, filepath.Join(key.stateDir, "session.json"), `{"access_token": "fallback"}`)
+	expectFile(t, filepath.Join(key.stateDir, "snapshots", "first.json"), `{"tools": {}}`)
+	expectFile(t, filepath.Join(key.stateDir, "installations.json"), `[{"id": "kept"}]`)
+	expectFile(t, filepath.Join(fallback, "installations.json"), `[]`)
+	expectMissing(t, filepath.Join(fallback, "session.json"))
+	expectMissing(t, filepath.Join(fallback, "snapshots"))
+	expectMissing(t, filepath.Join(key.stateDir, ".fallback-warned"))
+
+	if pending, err := pendingMigrations(key); err != nil || len(pending) != 0 {
+		t.Errorf("expected nothing left to migrate but got %+v, %v", pending, err)
+	}
+}
+
+func TestMigrateEmptiesFallback(t *testing.T) {
+	root := t.TempDir()
+	key := migrationKey{stateDir: filepath.Join(root, "state"), temp: filepath.Join(root, "tmp")}
+	fallback := filepath.Join(key.temp, tempStateName())
+	writeFiles(t, fallback, map[string]string{"stats.jsonl": "{}\n", ".fallback-warned": ""})
+
+	if _, err := migrate(key, time.Now()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectFile(t, filepath.Join(key.stateDir, "stats.jsonl"), "{}\n")
+	expectMissing(t, fallback)
+}
+
+func TestMigrateNothing(t *testing.T) {
+	root := t.TempDir()
+	fallback := filepath.Join(root, "tmp", tempStateName())
+	writeFiles(t, fallback, map[string]string{"session.json": `{}`})
+
+	// The fallback in use is where state is, not a layout to move
+	key := migrationKey{stateDir: fallback, configDir: filepath.Join(root, "config"), temp: filepath.Join(root, "tmp")}
+	report, err := migrate(key, time.Now())
+	if err != nil || !report.Empty() {
+		t.Errorf("expected nothing to migrate but got %+v, %v", report, err)
+	}
+	expectFile(t, filepath.Join(fallback, "session.json"), `{}`)
+	expectMissing(t, filepath.Join(fallback, migrationsFile))
+}
+
+func TestMigrateFailureKeepsFiles(t *testing.T) {
+	root := t.TempDir()
+	blocked := filepath.Join(root, "blocked")
+	writeFiles(t, root, map[string]string{"blocked": ""})
+	key := migrationKey{stateDir: filepath.Join(blocked, "state"), configDir: filepath.Join(root, "config")}
+	legacy := filepath.Join(key.configDir, "stackmatch", "config.json")
+	writeFiles(t, filepath.Dir(legacy), map[string]string{"config.json": `{}`})
+
+	if _, err := migrate(key, time.Now()); err == nil {
+		t.Error("expected an error when the state directory can't be created")
+	}
+	expectFile(t, legacy, `{}`)
+}
@@ -0,0 +1,274 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+)
+
+// StateDirEnv names the environment variable that sets where StackMatch
+// keeps its state instead of ~/.stackmatch
+const StateDirEnv = "STACKMATCH_STATE_DIR"
+
+// StateLocation is where StackMatch keeps its state for this run
+type StateLocation struct {
+	Dir string
+	// Writable is false when neither the preferred directory nor the
+	// fallback can be written. Nothing is saved then, but commands that only
+	// read, such as scan and diff, work as usual.
+	Writable bool
+	// Warning explains why Dir is not the preferred directory, or that
+	// nothing can be saved. Empty when the preferred directory is used.
+	Warning string
+}
+
+// stateKey is what the state location depends on
+type stateKey struct {
+	setting, home, temp string
+}
+
+var (
+	stateMu        sync.Mutex
+	stateLocations = make(map[stateKey]StateLocation)
+	stateWarned    = make(map[string]bool)
+)
+
+// ResolveState returns where state is kept: $STACKMATCH_STATE_DIR when set,
+// otherwise ~/.stackmatch, as long as it can be written. In containers and
+// CI, where the home directory may be read-only or missing, state falls back
+// to a directory of the current user under the OS temp directory. The
+// directory is checked once and the answer reused.
+func ResolveState() StateLocation {
+	home, _ := os.UserHomeDir()
+	key := stateKey{setting: os.Getenv(StateDirEnv), home: home, temp: os.TempDir()}
+
+	stateMu.Lock()
+	defer stateMu.Unlock()
+	if location, ok := stateLocations[key]; ok {
+		return location
+	}
+	location := resolveState(key)
+	stateLocations[key] = location
+	return location
+}
+
+func resolveState(key stateKey) StateLocation {
+	preferred := key.setting
+	if preferred == "" {
+		if key.home == "" {
+			preferred = ".stackmatch"
+		} else {
+			preferred = filepath.Join(key.home, ".stackmatch")
+		}
+	}
+	reason := checkWritable(preferred)
+	if reason == nil {
+		return StateLocation{Dir: preferred, Writable: true}
+	}
+
+	// Anyone can create the fallback in the shared temp directory first, so
+	// one that isn't ours is not used
+	fallback := filepath.Join(key.temp, tempStateName())
+	err := checkWritable(fallback)
+	if err == nil && ownedByOther(fallback) {
+		err = errors.New("it belongs to another user")
+	}
+	if err != nil {
+		return StateLocation{
+			Dir:     preferred,
+			Warning: fmt.Sprintf("%s is not writable (%v) and neither is %s (%v); nothing will be saved this run", preferred, reason, fallback, err),
+		}
+	}
+	return StateLocation{
+		Dir:      fallback,
+		Writable: true,
+		Warning: fmt.Sprintf("%s is not writable (%v); keeping state in %s, which may not last. Set %s to a writable directory to keep it",
+			preferred, reason, fallback, StateDirEnv),
+	}
+}
+
+// checkWritable returns why dir can't hold state, or nil. A missing dir is
+// writable when the closest directory above it that exists is.
+func checkWritable(dir string) error {
+	existing := dir
+	for {
+		info, err := os.Stat(existing)
+		if err == nil {
+			if !info.IsDir() && existing == dir {
+				return errors.New("not a directory")
+			}
+			if !info.IsDir() {
+				return fmt.Errorf("%s is not a directory", existing)
+			}
+			break
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		parent := filepath.Dir(existing)
+		if parent == existing {
+			return err
+		}
+		existing = parent
+	}
+	f, err := os.CreateTemp(existing, ".stackmatch-write-test-*")
+	if err != nil {
+		var pathErr *fs.PathError
+		if errors.As(err, &pathErr) {
+			return pathErr.Err
+		}
+		return err
+	}
+	f.Close()
+	os.Remove(f.Name())
+	return nil
+}
+
+// tempStateName names the fallback state directory, per user on systems
+// where users share the temp directory
+func tempStateName() string {
+	if uid := os.Getuid(); uid >= 0 {
+		return "stackmatch-" + strconv.Itoa(uid)
+	}
+	return "stackmatch"
+}
+
+// StateWarning returns the warning of ResolveState the first time it is
+// called for a fallback directory, and "" afterwards. A fallback that can
+// be written remembers the warning was shown, so it is shown once rather
+// than on every command.
+func StateWarning() string {
+	location := ResolveState()
+	if location.Warning == "" {
+		return ""
+	}
+	stateMu.Lock()
+	defer stateMu.Unlock()
+	if stateWarned[location.Dir] {
+		return ""
+	}
+	stateWarned[location.Dir] = true
+	if location.Writable {
+		marker := filepath.Join(location.Dir, ".fallback-warned")
+		if _, err := os.Stat(marker); err == nil {
+			return ""
+		}
+		_ = WritePrivateFile(marker, nil)
+	}
+	return location.Warning
+}
+
+// StateDir returns the directory where StackMatch keeps local state such as
+// the session, installation records and caches (see ResolveState)
+func StateDir() string {
+	return ResolveState().Dir
+}
+
+// userFile returns the path of a file the user writes by hand, such as the
+// detectors file: the one in StateDir, or the one in ~/.stackmatch when state
+// has moved elsewhere and only the home directory has it
+func userFile(name string) string {
+	path := filepath.Join(StateDir(), name)
+	if _, err := os.Stat(path); err == nil {
+		return path
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		if inHome := filepath.Join(home, ".stackmatch", name); inHome != path {
+			if _, err := os.Stat(inHome); err == nil {
+				return inHome
+			}
+		}
+	}
+	return path
+}
+
+// ConfigFile returns the path of the configuration file
+func ConfigFile() string {
+	return filepath.Join(StateDir(), "config.json")
+}
+
+// legacyConfigFile returns where releases before the state directory was
+// resolved kept the configuration file
+func legacyConfigFile() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "stackmatch", "config.json")
+}
+
+// SessionFile returns the path of the login session
+func SessionFile() string {
+	return filepath.Join(StateDir(), "session.json")
+}
+
+// TrackerFile returns the path of the installation records file
+func TrackerFile() string {
+	return filepath.Join(StateDir(), "installations.json")
+}
+
+// PackageManagerCacheFile returns the path of the cached metadata of the
+// detected package manager
+func PackageManagerCacheFile() string {
+	return filepath.Join(StateDir(), "package-manager-cache.json")
+}
+
+// ScanCacheFile returns the path of the last scan, reused by scan, export
+// and push while it is fresh
+func ScanCacheFile() string {
+	return filepath.Join(StateDir(), "scan-cache.json")
+}
+
+// RateLimitFile returns the path of the state of the limit on writes to
+// Supabase, shared by every run
+func RateLimitFile() string {
+	return filepath.Join(StateDir(), "rate-limit.json")
+}
+
+// SnapshotsDir returns the directory of saved environment snapshots, such
+// as the first scan 'stackmatch setup' takes
+func SnapshotsDir() string {
+	return filepath.Join(StateDir(), "snapshots")
+}
+
+// StatsFile returns the path of the local usage statistics file
+func StatsFile() string {
+	return filepath.Join(StateDir(), "stats.jsonl")
+}
+
+// DetectorsFile returns the path of the user's scanner detector overrides
+func DetectorsFile() string {
+	return userFile("detectors.yaml")
+}
+
+// DiffRulesFile returns the path of the rules diff and check use to
+// suppress differences the user doesn't care about
+func DiffRulesFile() string {
+	return userFile("diffrules.yaml")
+}
+
+// MappingsFile returns the path of the user's additions to the package
+// mappings, such as packages that conflict with each other
+func MappingsFile() string {
+	return userFile("mappings.yaml")
+}
+
+// ProfilesFile returns the path of the user's export profiles
+func ProfilesFile() string {
+	return userFile("profiles.yaml")
+}
+
+// ServeTokenFile returns the path of the bearer token 'stackmatch serve' requires
+func ServeTokenFile() string {
+	return filepath.Join(StateDir(), "serve-token")
+}
+
+// AuditLogFile returns the path of the log of every change StackMatch made
+// to the machine
+func AuditLogFile() string {
+	return filepath.Join(StateDir(), "audit.jsonl")
+}
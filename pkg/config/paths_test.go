@@ -0,0 +1,129 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResolveState(t *testing.T) {
+	root := t.TempDir()
+	home := filepath.Join(root, "home")
+	temp := filepath.Join(root, "tmp")
+	blocked := filepath.Join(root, "blocked")
+	for _, dir := range []string{home, temp} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(blocked, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	fallback := filepath.Join(temp, tempStateName())
+
+	tests := []struct {
+		name     string
+		key      stateKey
+		dir      string
+		writable bool
+		warning  string
+	}{
+		{
+			name:     "Home directory",
+			key:      stateKey{home: home, temp: temp},
+			dir:      filepath.Join(home, ".stackmatch"),
+			writable: true,
+		},
+		{
+			name:     "Setting wins over the home directory",
+			key:      stateKey{setting: filepath.Join(root, "state", "nested"), home: home, temp: temp},
+			dir:      filepath.Join(root, "state", "nested"),
+			writable: true,
+		},
+		{
+			name:     "Unusable home falls back to the temp directory",
+			key:      stateKey{home: blocked, temp: temp},
+			dir:      fallback,
+			writable: true,
+			warning:  "keeping state in " + fallback,
+		},
+		{
+			name:     "Unusable setting falls back too",
+			key:      stateKey{setting: blocked, home: home, temp: temp},
+			dir:      fallback,
+			writable: true,
+			warning:  blocked + " is not writable (not a directory)",
+		},
+		{
+			name:    "Nothing writable",
+			key:     stateKey{home: blocked, temp: blocked},
+			dir:     filepath.Join(blocked, ".stackmatch"),
+			warning: "nothing will be saved this run",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			location := resolveState(tt.key)
+			if location.Dir != tt.dir {
+				t.Errorf("expected state in %s but got %s", tt.dir, location.Dir)
+			}
+			if location.Writable != tt.writable {
+				t.Errorf("expected writable %v but got %v", tt.writable, location.Writable)
+			}
+			if tt.warning == "" && location.Warning != "" {
+				t.Errorf("expected no warning but got %q", location.Warning)
+			}
+			if !strings.Contains(location.Warning, tt.warning) {
+				t.Errorf("expected the warning to mention %q but got %q", tt.warning, location.Warning)
+			}
+		})
+	}
+}
+
+func TestStateWarningOnce(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, ".stackmatch"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("HOME", root)
+	t.Setenv("USERPROFILE", root)
+	t.Setenv("TMPDIR", filepath.Join(root, "tmp"))
+	t.Setenv("TMP", filepath.Join(root, "tmp"))
+	t.Setenv(StateDirEnv, "")
+
+	if warning := StateWarning(); warning == "" {
+		t.Fatal("expected a warning for the fallback state directory")
+	}
+	if warning := StateWarning(); warning != "" {
+		t.Errorf("expected the warning once but got it again: %q", warning)
+	}
+
+	// A later run finds the marker the first one left
+	stateMu.Lock()
+	clear(stateWarned)
+	stateMu.Unlock()
+	if warning := StateWarning(); warning != "" {
+		t.Errorf("expected later runs not to warn but got %q", warning)
+	}
+}
+
+func TestUserFileFromHome(t *testing.T) {
+	root := t.TempDir()
+	home := filepath.Join(root, "home")
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	t.Setenv(StateDirEnv, filepath.Join(root, "state"))
+
+	if got, expected := DetectorsFile(), filepath.Join(root, "state", "detectors.yaml"); got != expected {
+		t.Errorf("expected %s without a detectors file but got %s", expected, got)
+	}
+	inHome := filepath.Join(home, ".stackmatch", "detectors.yaml")
+	if err := WritePrivateFile(inHome, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := DetectorsFile(); got != inHome {
+		t.Errorf("expected the detectors file of the home directory %s but got %s", inHome, got)
+	}
+}
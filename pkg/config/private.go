@@ -0,0 +1,134 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Permissions of everything StackMatch writes under StateDir. The files can
+// hold session tokens and details of the machine, so only the owner may read
+// them.
+const (
+	PrivateDirMode  fs.FileMode = 0o700
+	PrivateFileMode fs.FileMode = 0o600
+)
+
+// MkdirPrivate creates dir and its missing parents readable only by the
+// current user, and restricts dir itself if it already exists. It refuses a
+// dir that is a symlink owned by another user.
+func MkdirPrivate(dir string) error {
+	if err := checkOwnership(dir); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, PrivateDirMode); err != nil {
+		return fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	if err := restrict(dir, true); err != nil {
+		return fmt.Errorf("failed to restrict permissions of %s: %w", dir, err)
+	}
+	return nil
+}
+
+// WritePrivateFile replaces the file at path with data, readable only by the
+// current user whatever the umask. The file is written to a temporary file
+// first and renamed into place, so readers never see it half written.
+func WritePrivateFile(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := MkdirPrivate(dir); err != nil {
+		return err
+	}
+
+	// CreateTemp creates the file with PrivateFileMode
+	f, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	tmp := f.Name()
+	defer os.Remove(tmp)
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := restrict(tmp, false); err != nil {
+		return fmt.Errorf("failed to restrict permissions of %s: %w", path, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// AppendPrivateFile opens the file at path for reading and appending,
+// creating it readable only by the current user when it does not exist
+func AppendPrivateFile(path string) (*os.File, error) {
+	if err := MkdirPrivate(filepath.Dir(path)); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_RDWR, PrivateFileMode)
+	if err != nil {
+		return nil, err
+	}
+	if err := restrict(path, false); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to restrict permissions of %s: %w", path, err)
+	}
+	return f, nil
+}
+
+// SecureStateDir checks StateDir before a command uses it and restricts the
+// permissions of everything in it, returning the paths it corrected. It
+// refuses a StateDir that is a symlink owned by another user, which would let
+// that user read or replace StackMatch's files. A missing StateDir is left for
+// the first write to create, and one that can't be written is only checked.
+func SecureStateDir() ([]string, error) {
+	location := ResolveState()
+	if !location.Writable {
+		return nil, checkOwnership(location.Dir)
+	}
+	return securePrivateTree(location.Dir)
+}
+
+// securePrivateTree checks root and restricts everything under it
+func securePrivateTree(root string) ([]string, error) {
+	if _, err := os.Lstat(root); errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err := checkOwnership(root); err != nil {
+		return nil, err
+	}
+
+	var corrected []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		// Links inside the tree are not followed; what they point to is not
+		// StackMatch's to change
+		if d.Type()&fs.ModeSymlink != 0 && path != root {
+			return nil
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		if !tooOpen(path, info) {
+			return nil
+		}
+		if err := restrict(path, info.IsDir()); err != nil {
+			return fmt.Errorf("failed to restrict permissions of %s: %w", path, err)
+		}
+		corrected = append(corrected, path)
+		return nil
+	})
+	if err != nil {
+		return corrected, err
+	}
+	return corrected, nil
+}
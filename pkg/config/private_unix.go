@@ -0,0 +1,74 @@
+//go:build !windows
+
+package config
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"syscall"
+)
+
+// currentUID returns the user ID files must belong to; tests replace it
+var currentUID = os.Getuid
+
+// checkOwnership refuses path when it is a symlink owned by another user, or
+// one pointing at a directory owned by another user
+func checkOwnership(path string) error {
+	link, err := os.Lstat(path)
+	if err != nil || link.Mode()&fs.ModeSymlink == 0 {
+		return nil
+	}
+	uid := currentUID()
+	if owner, ok := ownerOf(link); ok && owner != uid {
+		return fmt.Errorf("refusing to use %s: it is a symlink owned by another user (uid %d)", path, owner)
+	}
+	if target, err := os.Stat(path); err == nil {
+		if owner, ok := ownerOf(target); ok && owner != uid {
+			return fmt.Errorf("refusing to use %s: it links to a directory owned by another user (uid %d)", path, owner)
+		}
+	}
+	return nil
+}
+
+// ownedByOther reports whether path belongs to another user, such as a
+// directory someone else created in the shared temp directory
+func ownedByOther(path string) bool {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return false
+	}
+	owner, ok := ownerOf(info)
+	return ok && owner != currentUID()
+}
+
+// ownerOf returns the user ID owning the file described by info
+func ownerOf(info fs.FileInfo) (int, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, false
+	}
+	return int(stat.Uid), true
+}
+
+// tooOpen reports whether anyone but the owner may access the file
+func tooOpen(path string, info fs.FileInfo) bool {
+	return info.Mode().Perm()&0o077 != 0
+}
+
+// restrict removes the group and other permissions of path, and makes sure
+// the owner can use a directory
+func restrict(path string, dir bool) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	mode := info.Mode().Perm() &^ 0o077
+	if dir {
+		mode |= PrivateDirMode
+	}
+	if mode == info.Mode().Perm() {
+		return nil
+	}
+	return os.Chmod(path, mode)
+}
@@ -0,0 +1,144 @@
+//go:build !windows
+
+package config
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+// expectMode fails the test unless path has exactly mode
+func expectMode(t *testing.T, path string, mode fs.FileMode) {
+	t.Helper()
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != mode {
+		t.Errorf("expected %s to have mode %o but got %o", path, mode, info.Mode().Perm())
+	}
+}
+
+func TestWritePrivateFileIgnoresUmask(t *testing.T) {
+	defer syscall.Umask(syscall.Umask(0))
+	dir := filepath.Join(t.TempDir(), ".stackmatch")
+	path := filepath.Join(dir, "installations.json")
+
+	if err := WritePrivateFile(path, []byte("{}\n")); err != nil {
+		t.Fatalf("expected the file to be written but got %v", err)
+	}
+	expectMode(t, dir, PrivateDirMode)
+	expectMode(t, path, PrivateFileMode)
+
+	// Files written by older releases are replaced with private ones
+	if err := os.Chmod(path, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := WritePrivateFile(path, []byte("[]\n")); err != nil {
+		t.Fatalf("expected the file to be rewritten but got %v", err)
+	}
+	expectMode(t, path, PrivateFileMode)
+
+	f, err := AppendPrivateFile(filepath.Join(dir, "stats.jsonl"))
+	if err != nil {
+		t.Fatalf("expected the file to be opened but got %v", err)
+	}
+	f.Close()
+	expectMode(t, f.Name(), PrivateFileMode)
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("expected no temporary files to be left behind but got %v", entries)
+	}
+}
+
+func TestSecurePrivateTree(t *testing.T) {
+	root := filepath.Join(t.TempDir(), ".stackmatch")
+	files := map[string]fs.FileMode{
+		"installations.json": 0o644,
+		"session.json":       0o600,
+		"cache/scan.json":    0o664,
+		"hooks/post-import":  0o755,
+	}
+	for name, mode := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, mode); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chmod(path, mode); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Chmod(root, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	corrected, err := securePrivateTree(root)
+	if err != nil {
+		t.Fatalf("expected the tree to be secured but got %v", err)
+	}
+	expected := []string{
+		root,
+		filepath.Join(root, "cache"),
+		filepath.Join(root, "cache", "scan.json"),
+		filepath.Join(root, "hooks"),
+		filepath.Join(root, "hooks", "post-import"),
+		filepath.Join(root, "installations.json"),
+	}
+	if !reflect.DeepEqual(corrected, expected) {
+		t.Errorf("expected corrected paths %v but got %v", expected, corrected)
+	}
+	expectMode(t, root, PrivateDirMode)
+	expectMode(t, filepath.Join(root, "installations.json"), PrivateFileMode)
+	expectMode(t, filepath.Join(root, "hooks", "post-import"), 0o700)
+
+	if corrected, err := securePrivateTree(root); err != nil || len(corrected) != 0 {
+		t.Errorf("expected nothing left to correct but got %v, %v", corrected, err)
+	}
+	if corrected, err := securePrivateTree(filepath.Join(root, "missing")); err != nil || corrected != nil {
+		t.Errorf("expected a missing directory to be left alone but got %v, %v", corrected, err)
+	}
+}
+
+func TestForeignSymlinkIsRefused(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	target := filepath.Join(home, "elsewhere")
+	if err := os.Mkdir(target, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(target, StateDir()); err != nil {
+		t.Fatal(err)
+	}
+
+	// A link the user owns is fine
+	if _, err := SecureStateDir(); err != nil {
+		t.Fatalf("expected the user's own link to be accepted but got %v", err)
+	}
+
+	// Pretend the link belongs to someone else
+	uid := os.Getuid()
+	currentUID = func() int { return uid + 1 }
+	defer func() { currentUID = os.Getuid }()
+
+	if _, err := SecureStateDir(); err == nil || !strings.Contains(err.Error(), "symlink owned by another user") {
+		t.Errorf("expected the link to be refused but got %v", err)
+	}
+	if err := WritePrivateFile(TrackerFile(), []byte("{}")); err == nil {
+		t.Errorf("expected writing through the link to be refused")
+	}
+	if _, err := os.Stat(filepath.Join(target, "installations.json")); err == nil {
+		t.Errorf("expected nothing to be written through the link")
+	}
+}
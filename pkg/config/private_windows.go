@@ -0,0 +1,69 @@
+//go:build windows
+
+package config
+
+import (
+	"io/fs"
+
+	"golang.org/x/sys/windows"
+)
+
+// checkOwnership is a no-op on Windows, where creating symlinks needs
+// privileges other users of the machine normally lack
+func checkOwnership(path string) error {
+	return nil
+}
+
+// ownedByOther is false on Windows, where every user has their own temp
+// directory
+func ownedByOther(path string) bool {
+	return false
+}
+
+// tooOpen reports directories whose ACL is not the one restrict sets.
+// Files are not inspected: restricting a directory propagates its
+// inheritable entry to the files in it.
+func tooOpen(path string, info fs.FileInfo) bool {
+	if !info.IsDir() {
+		return false
+	}
+	sd, err := windows.GetNamedSecurityInfo(path, windows.SE_FILE_OBJECT, windows.DACL_SECURITY_INFORMATION)
+	if err != nil {
+		return true
+	}
+	control, _, err := sd.Control()
+	if err != nil || control&windows.SE_DACL_PROTECTED == 0 {
+		return true
+	}
+	dacl, _, err := sd.DACL()
+	return err != nil || dacl == nil || dacl.AceCount != 1
+}
+
+// restrict replaces the ACL of path with one granting the current user full
+// control and nobody else anything. Entries inherited from the parent are
+// dropped; a directory's entry is inherited by everything created in it.
+func restrict(path string, dir bool) error {
+	user, err := windows.GetCurrentProcessToken().GetTokenUser()
+	if err != nil {
+		return err
+	}
+	inheritance := uint32(windows.NO_INHERITANCE)
+	if dir {
+		inheritance = windows.SUB_CONTAINERS_AND_OBJECTS_INHERIT
+	}
+	acl, err := windows.ACLFromEntries([]windows.EXPLICIT_ACCESS{{
+		AccessPermissions: windows.GENERIC_ALL,
+		AccessMode:        windows.SET_ACCESS,
+		Inheritance:       inheritance,
+		Trustee: windows.TRUSTEE{
+			TrusteeForm:  windows.TRUSTEE_IS_SID,
+			TrusteeType:  windows.TRUSTEE_IS_USER,
+			TrusteeValue: windows.TrusteeValueFromSID(user.User.Sid),
+		},
+	}}, nil)
+	if err != nil {
+		return err
+	}
+	return windows.SetNamedSecurityInfo(path, windows.SE_FILE_OBJECT,
+		windows.DACL_SECURITY_INFORMATION|windows.PROTECTED_DACL_SECURITY_INFORMATION, nil, nil, acl, nil)
+}
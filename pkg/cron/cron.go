@@ -0,0 +1,157 @@
+// Package cron reads and extends the current user's crontab, and reads the
+// current user's Windows scheduled tasks. It never touches system crontabs
+// such as /etc/crontab or tasks owned by other accounts.
+package cron
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/runner"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+// assignment matches crontab lines that set a variable, such as MAILTO=""
+var assignment = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*\s*=`)
+
+// ParseCrontab returns the jobs of a crontab. Comments, blank lines and
+// variable assignments are skipped.
+func ParseCrontab(content string) []types.ScheduledJob {
+	var jobs []types.ScheduledJob
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") || assignment.MatchString(line) {
+			continue
+		}
+		schedule, command, ok := splitCrontabLine(line)
+		if !ok {
+			continue
+		}
+		jobs = append(jobs, types.ScheduledJob{
+			Source:   types.JobSourceCrontab,
+			Schedule: schedule,
+			Command:  command,
+		})
+	}
+	return jobs
+}
+
+// splitCrontabLine splits a crontab line into its schedule, either a macro
+// like @daily or five time fields, and the command, whose spacing is kept
+func splitCrontabLine(line string) (schedule, command string, ok bool) {
+	fieldCount := 5
+	if strings.HasPrefix(line, "@") {
+		fieldCount = 1
+	}
+	fields := make([]string, 0, fieldCount)
+	rest := line
+	for len(fields) < fieldCount {
+		rest = strings.TrimLeft(rest, " \t")
+		end := strings.IndexAny(rest, " \t")
+		if end < 0 {
+			return "", "", false
+		}
+		fields = append(fields, rest[:end])
+		rest = rest[end:]
+	}
+	command = strings.TrimSpace(rest)
+	if command == "" {
+		return "", "", false
+	}
+	return strings.Join(fields, " "), command, true
+}
+
+// ReadCrontab returns the current user's crontab, which is empty when the
+// user has none
+func ReadCrontab(ctx context.Context, r runner.Runner) (string, error) {
+	stdout, stderr, err := r.Output(ctx, "crontab", "-l")
+	if err != nil {
+		if strings.Contains(stderr, "no crontab for") {
+			return "", nil
+		}
+		message := strings.TrimSpace(stderr)
+		if i := strings.IndexByte(message, '\n'); i >= 0 {
+			message = message[:i]
+		}
+		if message == "" {
+			message = err.Error()
+		}
+		return "", fmt.Errorf("could not read your crontab: %s", message)
+	}
+	return stdout, nil
+}
+
+// Missing returns the crontab jobs of jobs that the current user's crontab
+// does not have yet. Jobs from other sources are left out.
+func Missing(ctx context.Context, r runner.Runner, jobs []types.ScheduledJob) ([]types.ScheduledJob, error) {
+	current, err := ReadCrontab(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	present := make(map[string]bool)
+	for _, job := range ParseCrontab(current) {
+		present[normalize(job.Line())] = true
+	}
+
+	var missing []types.ScheduledJob
+	for _, job := range jobs {
+		if job.Source == types.JobSourceCrontab && !present[normalize(job.Line())] {
+			missing = append(missing, job)
+			present[normalize(job.Line())] = true
+		}
+	}
+	return missing, nil
+}
+
+// Append adds jobs to the end of the current user's crontab, keeping every
+// existing entry
+func Append(ctx context.Context, r runner.Runner, jobs []types.ScheduledJob) error {
+	if len(jobs) == 0 {
+		return nil
+	}
+	current, err := ReadCrontab(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp("", "stackmatch-crontab-*")
+	if err != nil {
+		return fmt.Errorf("failed to write the new crontab: %w", err)
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.WriteString(appendJobs(current, jobs)); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write the new crontab: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write the new crontab: %w", err)
+	}
+
+	if output, err := r.CombinedOutput(ctx, "crontab", f.Name()); err != nil {
+		return fmt.Errorf("failed to install the new crontab: %w\n%s", err, output)
+	}
+	return nil
+}
+
+// appendJobs returns crontab content with jobs added as lines at the end
+func appendJobs(current string, jobs []types.ScheduledJob) string {
+	var b strings.Builder
+	b.WriteString(current)
+	if current != "" && !strings.HasSuffix(current, "\n") {
+		b.WriteString("\n")
+	}
+	b.WriteString("# Added by stackmatch import\n")
+	for _, job := range jobs {
+		b.WriteString(job.Line())
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// normalize collapses whitespace so lines differing only in spacing match
+func normalize(line string) string {
+	return strings.Join(strings.Fields(line), " ")
+}
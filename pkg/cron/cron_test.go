@@ -0,0 +1,132 @@
+package cron
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/runner/runnertest"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+func TestParseCrontab(t *testing.T) {
+	testCases := []struct {
+		name     string
+		content  string
+		expected []types.ScheduledJob
+	}{
+		{
+			name: "Entries",
+			content: `# m h  dom mon dow   command
+SHELL=/bin/bash
+MAILTO=""
+
+0 3 * * *	docker system prune -af --filter "until=168h"
+*/15 9-18 * * 1-5  cd ~/src/app && git fetch --all  >/dev/null 2>&1
+@reboot ~/bin/start-ssh-agent.sh
+`,
+			expected: []types.ScheduledJob{
+				{Source: types.JobSourceCrontab, Schedule: "0 3 * * *", Command: `docker system prune -af --filter "until=168h"`},
+				{Source: types.JobSourceCrontab, Schedule: "*/15 9-18 * * 1-5", Command: "cd ~/src/app && git fetch --all  >/dev/null 2>&1"},
+				{Source: types.JobSourceCrontab, Schedule: "@reboot", Command: "~/bin/start-ssh-agent.sh"},
+			},
+		},
+		{
+			name:    "Incomplete lines",
+			content: "0 3 * *\n@daily\n",
+		},
+		{
+			name:    "Empty",
+			content: "",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			jobs := ParseCrontab(tc.content)
+			if !reflect.DeepEqual(jobs, tc.expected) {
+				t.Errorf("expected %+v but got %+v", tc.expected, jobs)
+			}
+		})
+	}
+}
+
+func TestReadCrontab(t *testing.T) {
+	testCases := []struct {
+		name          string
+		response      runnertest.Response
+		expected      string
+		expectedError string
+	}{
+		{
+			name:     "Crontab",
+			response: runnertest.Response{Output: "@daily ~/bin/backup.sh\n"},
+			expected: "@daily ~/bin/backup.sh\n",
+		},
+		{
+			name:     "No crontab",
+			response: runnertest.Response{Stderr: "no crontab for dev\n", Err: errors.New("exit status 1")},
+		},
+		{
+			name:          "Permission denied",
+			response:      runnertest.Response{Stderr: "You (dev) are not allowed to use this program (crontab)\nSee crontab(1) for more information\n", Err: errors.New("exit status 1")},
+			expectedError: "could not read your crontab: You (dev) are not allowed to use this program (crontab)",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &runnertest.Runner{Responses: map[string]runnertest.Response{"crontab -l": tc.response}}
+
+			content, err := ReadCrontab(context.Background(), r)
+			if tc.expectedError != "" {
+				if err == nil || err.Error() != tc.expectedError {
+					t.Errorf("expected error %q but got %v", tc.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected the crontab to be read but got %v", err)
+			}
+			if content != tc.expected {
+				t.Errorf("expected %q but got %q", tc.expected, content)
+			}
+		})
+	}
+}
+
+func TestMissing(t *testing.T) {
+	r := &runnertest.Runner{Responses: map[string]runnertest.Response{
+		"crontab -l": {Output: "0 3 * * *  docker system prune -f\n"},
+	}}
+	jobs := []types.ScheduledJob{
+		{Source: types.JobSourceCrontab, Schedule: "0 3 * * *", Command: "docker system prune -f"},
+		{Source: types.JobSourceCrontab, Schedule: "@reboot", Command: "~/bin/start-ssh-agent.sh"},
+		{Source: types.JobSourceCrontab, Schedule: "@reboot", Command: "~/bin/start-ssh-agent.sh"},
+		{Source: types.JobSourceSchtasks, Name: `\Backup`, Schedule: "Daily at 3:00:00 AM", Command: "backup.cmd"},
+	}
+
+	missing, err := Missing(context.Background(), r, jobs)
+	if err != nil {
+		t.Fatalf("expected the crontab to be read but got %v", err)
+	}
+	expected := []types.ScheduledJob{jobs[1]}
+	if !reflect.DeepEqual(missing, expected) {
+		t.Errorf("expected %+v but got %+v", expected, missing)
+	}
+}
+
+func TestAppendJobs(t *testing.T) {
+	jobs := []types.ScheduledJob{{Source: types.JobSourceCrontab, Schedule: "@reboot", Command: "~/bin/start-ssh-agent.sh"}}
+
+	got := appendJobs("MAILTO=\"\"\n0 3 * * * docker system prune -f", jobs)
+	expected := "MAILTO=\"\"\n0 3 * * * docker system prune -f\n# Added by stackmatch import\n@reboot ~/bin/start-ssh-agent.sh\n"
+	if got != expected {
+		t.Errorf("expected %q but got %q", expected, got)
+	}
+	if !strings.HasPrefix(appendJobs("", jobs), "# Added by stackmatch import\n") {
+		t.Errorf("expected an empty crontab to start with the added entries")
+	}
+}
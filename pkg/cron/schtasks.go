@@ -0,0 +1,122 @@
+package cron
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"strings"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/runner"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+// QueryTasks returns the output of schtasks listing every task verbosely as CSV
+func QueryTasks(ctx context.Context, r runner.Runner) (string, error) {
+	output, err := r.CombinedOutput(ctx, "schtasks", "/query", "/fo", "CSV", "/v")
+	if err != nil {
+		message := strings.TrimSpace(output)
+		if message == "" {
+			message = err.Error()
+		}
+		return "", fmt.Errorf("could not list your scheduled tasks: %s", message)
+	}
+	return output, nil
+}
+
+// ParseSchtasks returns the tasks in the output of QueryTasks that run as
+// user, which may be given with or without its domain. Tasks under
+// \Microsoft\ are skipped, as are tasks of other accounts such as SYSTEM.
+func ParseSchtasks(output, user string) ([]types.ScheduledJob, error) {
+	reader := csv.NewReader(strings.NewReader(output))
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("could not parse the scheduled task list: %w", err)
+	}
+
+	var (
+		jobs    []types.ScheduledJob
+		columns map[string]int
+		seen    = make(map[string]bool)
+	)
+	for _, record := range records {
+		// The verbose listing repeats its header for each folder
+		if len(record) > 1 && record[1] == "TaskName" {
+			columns = make(map[string]int)
+			for i, name := range record {
+				columns[name] = i
+			}
+			continue
+		}
+		if columns == nil {
+			continue
+		}
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				if v := strings.TrimSpace(record[i]); v != "N/A" {
+					return v
+				}
+			}
+			return ""
+		}
+
+		name := field("TaskName")
+		if name == "" || strings.HasPrefix(name, `\Microsoft\`) || !sameUser(field("Run As User"), user) {
+			continue
+		}
+		// Tasks with several triggers are listed once per trigger; the
+		// first one stands for the task
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		command := field("Task To Run")
+		if command == "" {
+			continue
+		}
+		jobs = append(jobs, types.ScheduledJob{
+			Source:   types.JobSourceSchtasks,
+			Name:     name,
+			Schedule: describeTrigger(field("Schedule Type"), field("Start Time"), field("Days")),
+			Command:  command,
+		})
+	}
+	return jobs, nil
+}
+
+// sameUser reports whether the account runAs is user, comparing only the
+// part after the domain when either omits it
+func sameUser(runAs, user string) bool {
+	if runAs == "" || user == "" {
+		return false
+	}
+	if strings.EqualFold(runAs, user) {
+		return true
+	}
+	return strings.EqualFold(accountName(runAs), accountName(user))
+}
+
+// accountName strips the domain from DOMAIN\user
+func accountName(account string) string {
+	if i := strings.LastIndexByte(account, '\\'); i >= 0 {
+		return account[i+1:]
+	}
+	return account
+}
+
+// describeTrigger builds a schedule such as "Weekly at 9:00:00 AM on MON, FRI"
+func describeTrigger(scheduleType, startTime, days string) string {
+	schedule := scheduleType
+	if schedule == "" {
+		schedule = "Unknown schedule"
+	}
+	if startTime != "" {
+		schedule += " at " + startTime
+	}
+	if days != "" && !strings.EqualFold(days, "Every 1 day(s)") {
+		schedule += " on " + days
+	}
+	return schedule
+}
@@ -0,0 +1,61 @@
+package cron
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/runner/runnertest"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+func TestParseSchtasks(t *testing.T) {
+	listing, err := os.ReadFile(filepath.Join("testdata", "schtasks.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []types.ScheduledJob{
+		{Source: types.JobSourceSchtasks, Name: `\Backup repos`, Schedule: "Daily at 3:00:00 AM", Command: `C:\Users\dev\bin\backup.cmd --token abc123`},
+		{Source: types.JobSourceSchtasks, Name: `\Prune docker`, Schedule: "Weekly at 9:00:00 AM on MON, FRI", Command: "docker system prune -f"},
+	}
+
+	testCases := []struct {
+		name     string
+		output   string
+		user     string
+		expected []types.ScheduledJob
+	}{
+		{name: "User with domain", output: string(listing), user: `DEVBOX\dev`, expected: expected},
+		{name: "User without domain", output: string(listing), user: "dev", expected: expected},
+		{name: "Other user", output: string(listing), user: "alice"},
+		{name: "Empty", output: "", user: "dev"},
+		{name: "No tasks", output: "INFO: There are no scheduled tasks presently available at your access level.\r\n", user: "dev"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			jobs, err := ParseSchtasks(tc.output, tc.user)
+			if err != nil {
+				t.Fatalf("expected the listing to parse but got %v", err)
+			}
+			if !reflect.DeepEqual(jobs, tc.expected) {
+				t.Errorf("expected %+v but got %+v", tc.expected, jobs)
+			}
+		})
+	}
+}
+
+func TestQueryTasksAccessDenied(t *testing.T) {
+	r := &runnertest.Runner{Responses: map[string]runnertest.Response{
+		"schtasks /query /fo CSV /v": {Output: "ERROR: Access is denied.\r\n", Err: errors.New("exit status 1")},
+	}}
+
+	_, err := QueryTasks(context.Background(), r)
+	if err == nil || !strings.Contains(err.Error(), "Access is denied") {
+		t.Errorf("expected an access denied error but got %v", err)
+	}
+}
@@ -0,0 +1,112 @@
+package diff
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/ui"
+)
+
+// ChangelogVersion identifies one side of a Changelog
+type ChangelogVersion struct {
+	Version  int       `json:"version"`
+	PushedAt time.Time `json:"pushed_at"`
+}
+
+// Changelog summarizes how a stored environment changed between two pushed
+// versions, in the style of release notes
+type Changelog struct {
+	Environment string           `json:"environment"`
+	From        ChangelogVersion `json:"from"`
+	To          ChangelogVersion `json:"to"`
+	Changes     []Change         `json:"changes"`
+	// Suppressed counts the changes left out by diff rules
+	Suppressed int `json:"suppressed,omitempty"`
+}
+
+// NewChangelog returns the changelog of environment between from and to,
+// made of the changes of result
+func NewChangelog(environment string, from, to ChangelogVersion, result *Result) *Changelog {
+	changes := result.Changes
+	if changes == nil {
+		changes = []Change{}
+	}
+	return &Changelog{Environment: environment, From: from, To: to, Changes: changes, Suppressed: result.Suppressed}
+}
+
+// WriteMarkdown writes the changelog as Markdown, compact enough to paste
+// into a team channel: a heading naming the versions, then the changed,
+// added and removed entries
+func (c *Changelog) WriteMarkdown(w io.Writer) {
+	fmt.Fprintf(w, "## %s: version %d %s %d\n\n", c.Environment, c.From.Version, ui.Symbols().Arrow, c.To.Version)
+	if !c.From.PushedAt.IsZero() && !c.To.PushedAt.IsZero() {
+		fmt.Fprintf(w, "Pushed %s and %s.\n\n", c.From.PushedAt.UTC().Format(time.DateOnly), c.To.PushedAt.UTC().Format(time.DateOnly))
+	}
+
+	if len(c.Changes) == 0 {
+		fmt.Fprintf(w, "No changes since version %d.\n", c.From.Version)
+	}
+	sections := []struct {
+		title string
+		kind  ChangeKind
+	}{
+		{"Changed", Changed},
+		{"Added", Added},
+		{"Removed", Removed},
+	}
+	first := true
+	for _, section := range sections {
+		var lines []string
+		for _, change := range c.Changes {
+			if change.Kind == section.kind {
+				lines = append(lines, changelogLine(change))
+			}
+		}
+		if len(lines) == 0 {
+			continue
+		}
+		if !first {
+			fmt.Fprintln(w)
+		}
+		first = false
+		fmt.Fprintf(w, "### %s\n\n", section.title)
+		for _, line := range lines {
+			fmt.Fprintf(w, "- %s\n", line)
+		}
+	}
+
+	switch c.Suppressed {
+	case 0:
+	case 1:
+		fmt.Fprintln(w, "\n_1 change suppressed by diff rules._")
+	default:
+		fmt.Fprintf(w, "\n_%d changes suppressed by diff rules._\n", c.Suppressed)
+	}
+}
+
+// changelogLine describes one change, such as "**Go** 1.21.5 → 1.22.0
+// (languages)", with an ASCII arrow under --ascii. Entries without a
+// version, such as config files, are named alone.
+func changelogLine(c Change) string {
+	var line string
+	switch {
+	case c.Kind == Changed:
+		line = fmt.Sprintf("**%s** %s %s %s", c.Name, orNone(c.From), ui.Symbols().Arrow, orNone(c.To))
+	case c.Kind == Added && c.To != "":
+		line = fmt.Sprintf("**%s** %s", c.Name, c.To)
+	case c.Kind == Removed && c.From != "":
+		line = fmt.Sprintf("**%s** %s", c.Name, c.From)
+	default:
+		line = fmt.Sprintf("**%s**", c.Name)
+	}
+	return line + " (" + c.Category + ")"
+}
+
+// orNone shows an empty value, such as a setting that was unset
+func orNone(value string) string {
+	if value == "" {
+		return "(none)"
+	}
+	return value
+}
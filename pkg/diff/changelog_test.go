@@ -0,0 +1,97 @@
+package diff
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+	"github.com/MRQ67/stackmatch-cli/pkg/ui"
+)
+
+var updateGolden = flag.Bool("update", false, "Rewrite the golden files in testdata")
+
+// readSnapshot reads an environment file of testdata/changelog
+func readSnapshot(t *testing.T, path string) *types.EnvironmentData {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var env types.EnvironmentData
+	if err := json.Unmarshal(data, &env); err != nil {
+		t.Fatalf("invalid snapshot %s: %v", path, err)
+	}
+	return &env
+}
+
+// expectGolden compares got with the golden file at path, rewriting it
+// with -update
+func expectGolden(t *testing.T, path string, got []byte) {
+	t.Helper()
+	if *updateGolden {
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expected, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(expected) {
+		t.Errorf("expected %s:\n%s\nbut got:\n%s", path, expected, got)
+	}
+}
+
+// Each directory of testdata/changelog holds a pair of pushed snapshots,
+// optional diff rules and the expected changelogs. Run
+// 'go test ./pkg/diff -run TestChangelogGolden -update' after a deliberate
+// change to the output and review the diff.
+func TestChangelogGolden(t *testing.T) {
+	defer ui.SetASCII(ui.DetectASCII(os.Getenv))
+	ui.SetASCII(false)
+	from := ChangelogVersion{Version: 3, PushedAt: time.Date(2026, 9, 1, 10, 0, 0, 0, time.UTC)}
+	to := ChangelogVersion{Version: 5, PushedAt: time.Date(2026, 10, 15, 10, 0, 0, 0, time.UTC)}
+
+	for _, name := range []string{"upgrade", "identical", "suppressed"} {
+		t.Run(name, func(t *testing.T) {
+			dir := filepath.Join("testdata", "changelog", name)
+			result := Compare(readSnapshot(t, filepath.Join(dir, "from.json")), readSnapshot(t, filepath.Join(dir, "to.json")))
+			rules, err := LoadRules(filepath.Join(dir, "rules.yaml"), false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rules.ApplyDiff(result)
+
+			changelog := NewChangelog("laptop", from, to, result)
+			var markdown bytes.Buffer
+			changelog.WriteMarkdown(&markdown)
+			expectGolden(t, filepath.Join(dir, "changelog.md"), markdown.Bytes())
+
+			jsonData, err := json.MarshalIndent(changelog, "", "  ")
+			if err != nil {
+				t.Fatal(err)
+			}
+			expectGolden(t, filepath.Join(dir, "changelog.json"), append(jsonData, '\n'))
+		})
+	}
+}
+
+func TestChangelogASCII(t *testing.T) {
+	defer ui.SetASCII(ui.DetectASCII(os.Getenv))
+	ui.SetASCII(true)
+
+	result := &Result{Changes: []Change{{Kind: Changed, Category: "languages", Name: "Go", From: "1.21.5", To: "1.22.0"}}}
+	var markdown bytes.Buffer
+	NewChangelog("laptop", ChangelogVersion{Version: 3}, ChangelogVersion{Version: 5}, result).WriteMarkdown(&markdown)
+	for _, expected := range []string{"## laptop: version 3 -> 5\n", "- **Go** 1.21.5 -> 1.22.0 (languages)\n"} {
+		if !strings.Contains(markdown.String(), expected) {
+			t.Errorf("expected %q in:\n%s", expected, markdown.String())
+		}
+	}
+}
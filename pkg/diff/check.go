@@ -0,0 +1,217 @@
+package diff
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+	"github.com/MRQ67/stackmatch-cli/pkg/version"
+)
+
+// CheckStatus is the outcome of checking a single wanted entry
+type CheckStatus string
+
+const (
+	// StatusOK indicates the entry is installed with an acceptable version
+	StatusOK CheckStatus = "ok"
+	// StatusMissing indicates the entry is not installed
+	StatusMissing CheckStatus = "missing"
+	// StatusMismatch indicates the entry is installed with a different version
+	StatusMismatch CheckStatus = "mismatch"
+	// StatusBroken indicates the entry is on PATH but its version command
+	// fails, whatever version is wanted
+	StatusBroken CheckStatus = "broken"
+)
+
+// CheckItem is the check result for one wanted entry
+type CheckItem struct {
+	Category  string      `json:"category"`
+	Name      string      `json:"name"`
+	Wanted    string      `json:"wanted,omitempty"`
+	Installed string      `json:"installed,omitempty"`
+	Status    CheckStatus `json:"status"`
+	// Requirement is how the wanted environment classifies the entry
+	Requirement types.Requirement `json:"requirement,omitempty"`
+	// Explanation traces the version comparison of mismatched entries
+	Explanation *version.Explanation `json:"explanation,omitempty"`
+	// Provenance is how the installed entry got on the machine, when the
+	// scan could tell
+	Provenance *types.Provenance `json:"provenance,omitempty"`
+}
+
+// Failed reports whether the item fails the check. Optional entries that are
+// not satisfied are only warnings.
+func (i CheckItem) Failed() bool {
+	return i.Status != StatusOK && i.Requirement != types.Optional
+}
+
+// CheckResult holds the outcome of checking an environment against the machine
+type CheckResult struct {
+	Items []CheckItem `json:"items"`
+	// Suppressed counts the unsatisfied items left out by diff rules (see Rules)
+	Suppressed int `json:"suppressed,omitempty"`
+	// Notes explain installed entries that may not be what gets used, such
+	// as a python3 on PATH other than the one pyenv selects. They don't fail
+	// the check.
+	Notes []string `json:"notes,omitempty"`
+}
+
+// Passed reports whether every wanted entry that is not optional was satisfied
+func (r *CheckResult) Passed() bool {
+	for _, item := range r.Items {
+		if item.Failed() {
+			return false
+		}
+	}
+	return true
+}
+
+// Warnings returns the optional entries that were not satisfied
+func (r *CheckResult) Warnings() []CheckItem {
+	var items []CheckItem
+	for _, item := range r.Items {
+		if item.Status != StatusOK && !item.Failed() {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// Check reports whether installed satisfies every entry recorded in wanted.
+// Entries that only exist in installed are ignored.
+func Check(installed, wanted *types.EnvironmentData) *CheckResult {
+	result := &CheckResult{Items: []CheckItem{}}
+
+	checkMaps(result, types.CategoryLanguages, installed.ConfiguredLanguages, wanted.ConfiguredLanguages, installed.BrokenTools)
+	checkSideBySide(result, installed)
+	checkMaps(result, types.CategoryTools, effectiveTools(installed), effectiveTools(wanted), effectiveBroken(installed))
+	checkMaps(result, types.CategoryPackageManagers, installed.PackageManagers, wanted.PackageManagers, installed.BrokenTools)
+	checkMaps(result, types.CategoryEditors, installed.CodeEditors, wanted.CodeEditors, installed.BrokenTools)
+	checkSettings(result, installed.LanguageConfig, wanted.LanguageConfig)
+	checkCorepack(result, installed, wanted)
+	if _, ok := wanted.ConfiguredLanguages["Python"]; ok && installed.Python != nil && installed.Python.Mismatch != "" {
+		result.Notes = append(result.Notes, "Python: "+installed.Python.Mismatch)
+	}
+
+	for i, item := range result.Items {
+		result.Items[i].Requirement = wanted.Requirements[item.Name]
+		if item.Status != StatusMissing && tracedCategory(item.Category) {
+			result.Items[i].Provenance = provenanceOf(installed, item.Name)
+		}
+	}
+	return result
+}
+
+// checkMaps checks every wanted entry of one category. Broken entries fail
+// even when their recorded version would satisfy the wanted one.
+func checkMaps(result *CheckResult, category string, installed, wanted map[string]string, broken map[string]types.ToolFailure) {
+	names := make([]string, 0, len(wanted))
+	for name := range wanted {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		item := CheckItem{Category: category, Name: name, Wanted: wanted[name]}
+		have, ok := installed[name]
+		switch {
+		case !ok:
+			item.Status = StatusMissing
+		case isBroken(broken, name):
+			item.Installed = have
+			item.Status = StatusBroken
+		default:
+			item.Installed = have
+			if explanation := version.Explain(have, item.Wanted); explanation.Satisfied {
+				item.Status = StatusOK
+			} else {
+				item.Status = StatusMismatch
+				item.Explanation = explanation
+			}
+		}
+		result.Items = append(result.Items, item)
+	}
+}
+
+// checkSideBySide checks the languages whose first version on PATH doesn't
+// satisfy the wanted one against the versions installed next to it, such as
+// python3.12 shadowed by python3.11. The newest that satisfies counts as
+// installed, with a note saying it isn't the one that runs by default.
+func checkSideBySide(result *CheckResult, installed *types.EnvironmentData) {
+	for i, item := range result.Items {
+		if item.Category != types.CategoryLanguages || item.Status != StatusMismatch {
+			continue
+		}
+		versions := installed.SideBySideVersions(item.Name)
+		for j := len(versions) - 1; j >= 0; j-- {
+			if versions[j] == item.Installed || !version.Explain(versions[j], item.Wanted).Satisfied {
+				continue
+			}
+			result.Items[i].Installed = versions[j]
+			result.Items[i].Status = StatusOK
+			result.Items[i].Explanation = nil
+			result.Notes = append(result.Notes, fmt.Sprintf("%s: %s runs first on PATH; %s, installed side by side, satisfies %s", item.Name, item.Installed, versions[j], item.Wanted))
+			break
+		}
+	}
+}
+
+// checkSettings checks every wanted language setting. Settings are not
+// versions, so only the exact value satisfies them.
+func checkSettings(result *CheckResult, installed, wanted types.LanguageConfig) {
+	for _, setting := range wanted.Settings() {
+		item := CheckItem{Category: types.CategoryLanguageConfig, Name: setting.Name(), Wanted: setting.Value}
+		have, ok := installed.Get(setting.Toolchain, setting.Key)
+		switch {
+		case !ok:
+			item.Status = StatusMissing
+		case have == setting.Value:
+			item.Installed = have
+			item.Status = StatusOK
+		default:
+			item.Installed = have
+			item.Status = StatusMismatch
+		}
+		result.Items = append(result.Items, item)
+	}
+}
+
+// checkCorepack checks that corepack is enabled when wanted had it enabled.
+// A wanted environment with corepack disabled asks for nothing.
+func checkCorepack(result *CheckResult, installed, wanted *types.EnvironmentData) {
+	if wanted.Node == nil || !wanted.Node.Corepack.Enabled() {
+		return
+	}
+	item := CheckItem{Category: types.CategoryLanguageConfig, Name: "node.corepack", Wanted: wanted.Node.Corepack.State()}
+	switch {
+	case installed.Node == nil || installed.Node.Corepack == nil:
+		item.Status = StatusMissing
+	case installed.Node.Corepack.Enabled():
+		item.Installed = installed.Node.Corepack.State()
+		item.Status = StatusOK
+	default:
+		item.Installed = installed.Node.Corepack.State()
+		item.Status = StatusMismatch
+	}
+	result.Items = append(result.Items, item)
+}
+
+// effectiveBroken returns the broken tools of env, leaving out those pinned by
+// a build tool wrapper, which runs instead of the global install
+func effectiveBroken(env *types.EnvironmentData) map[string]types.ToolFailure {
+	if env.Project == nil || len(env.Project.BuildWrappers) == 0 {
+		return env.BrokenTools
+	}
+	broken := make(map[string]types.ToolFailure, len(env.BrokenTools))
+	for name, failure := range env.BrokenTools {
+		if _, pinned := env.Project.BuildWrappers[name]; !pinned {
+			broken[name] = failure
+		}
+	}
+	return broken
+}
+
+func isBroken(broken map[string]types.ToolFailure, name string) bool {
+	_, ok := broken[name]
+	return ok
+}
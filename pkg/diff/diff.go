@@ -0,0 +1,324 @@
+package diff
+
+import (
+	"maps"
+	"sort"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+// ChangeKind describes how an entry differs between two environments
+type ChangeKind string
+
+const (
+	// Added indicates the entry only exists in the second environment
+	Added ChangeKind = "added"
+	// Removed indicates the entry only exists in the first environment
+	Removed ChangeKind = "removed"
+	// Changed indicates the entry exists in both environments with different values
+	Changed ChangeKind = "changed"
+)
+
+// Change is a single difference between two environments
+type Change struct {
+	Category string     `json:"category"`
+	Name     string     `json:"name"`
+	Kind     ChangeKind `json:"kind"`
+	From     string     `json:"from,omitempty"`
+	To       string     `json:"to,omitempty"`
+	// Requirement is how the entry is classified, taken from the second
+	// environment or from the first when the entry was removed
+	Requirement types.Requirement `json:"requirement,omitempty"`
+	// FromProvenance and ToProvenance are how the entry was installed in
+	// each environment, when the scan could tell
+	FromProvenance *types.Provenance `json:"from_provenance,omitempty"`
+	ToProvenance   *types.Provenance `json:"to_provenance,omitempty"`
+}
+
+// Result holds every difference found between two environments
+type Result struct {
+	Changes []Change `json:"changes"`
+	// Suppressed counts the changes left out by diff rules (see Rules)
+	Suppressed int `json:"suppressed,omitempty"`
+	// FastScan counts the changes left out because one environment is a
+	// fast scan and the other is not (see fastScanArtifact)
+	FastScan int `json:"fast_scan,omitempty"`
+	// ManifestScan counts the entries left out because the environment
+	// missing them is a scan limited to a manifest that doesn't list them
+	// (see manifestScanArtifact)
+	ManifestScan int `json:"manifest_scan,omitempty"`
+}
+
+// Empty reports whether the two environments were identical
+func (r *Result) Empty() bool {
+	return len(r.Changes) == 0
+}
+
+// Compare returns the changes needed to go from environment a to environment b.
+// Changes are ordered by category and then by name so output is deterministic.
+func Compare(a, b *types.EnvironmentData) *Result {
+	result := &Result{Changes: []Change{}}
+
+	compareSystem(result, a.System, b.System)
+	compareMaps(result, types.CategoryLanguages, a.ConfiguredLanguages, b.ConfiguredLanguages)
+	compareMaps(result, types.CategoryTools, effectiveTools(a), effectiveTools(b))
+	compareMaps(result, types.CategoryPackageManagers, a.PackageManagers, b.PackageManagers)
+	compareMaps(result, types.CategoryEditors, a.CodeEditors, b.CodeEditors)
+	compareMaps(result, types.CategoryConfigFiles, toSet(a.ConfigFiles), toSet(b.ConfigFiles))
+	compareMaps(result, types.CategoryGitConfig, gitConfigEntries(a.GitConfig), gitConfigEntries(b.GitConfig))
+	compareMaps(result, types.CategoryLanguageConfig, languageConfigEntries(a), languageConfigEntries(b))
+	compareMaps(result, types.CategoryRequirements, requirementNames(a), requirementNames(b))
+
+	// Categories this release doesn't know are compared like any other
+	for _, category := range extensionCategories(a, b) {
+		compareMaps(result, category, a.Extensions[category], b.Extensions[category])
+	}
+
+	kept := result.Changes[:0]
+	for _, c := range result.Changes {
+		if fastScanArtifact(c, a, b) {
+			result.FastScan++
+			continue
+		}
+		if manifestScanArtifact(c, a, b) {
+			result.ManifestScan++
+			continue
+		}
+		kept = append(kept, c)
+	}
+	result.Changes = kept
+
+	for i, c := range result.Changes {
+		switch c.Category {
+		case types.CategorySystem, types.CategoryConfigFiles, types.CategoryGitConfig, types.CategoryLanguageConfig, types.CategoryRequirements:
+			continue
+		}
+		requirement := b.Requirements[c.Name]
+		if c.Kind == Removed {
+			requirement = a.Requirements[c.Name]
+		}
+		result.Changes[i].Requirement = requirement
+		if tracedCategory(c.Category) {
+			result.Changes[i].FromProvenance = provenanceOf(a, c.Name)
+			result.Changes[i].ToProvenance = provenanceOf(b, c.Name)
+		}
+	}
+
+	return result
+}
+
+// fastScanArtifact reports whether c can come from comparing a fast scan
+// with a full scan rather than from the environments differing: an entry
+// only the full scan has, since the fast scan only sees packaged tools, one
+// only the fast scan read from a package database, or a version the package
+// database records in another form, such as "18.19.1+dfsg" for "18.19.1".
+// Versions that differ are kept.
+func fastScanArtifact(c Change, a, b *types.EnvironmentData) bool {
+	if !tracedCategory(c.Category) || (a.FastScan == nil) == (b.FastScan == nil) {
+		return false
+	}
+	fast, fastSide := a, Removed
+	if b.FastScan != nil {
+		fast, fastSide = b, Added
+	}
+	switch c.Kind {
+	case Added, Removed:
+		return c.Kind != fastSide || fast.PackageDBVersion(c.Name)
+	}
+	if !fast.PackageDBVersion(c.Name) {
+		return false
+	}
+	if c.From == "Installed" || c.To == "Installed" {
+		return true
+	}
+	from, errFrom := parseNormalized(c.From)
+	to, errTo := parseNormalized(c.To)
+	return errFrom == nil && errTo == nil && from.Compare(to) == 0
+}
+
+// manifestScanArtifact reports whether c is an entry one environment is
+// missing only because it is a scan limited to a manifest that doesn't
+// list it, so the entry was never looked for
+func manifestScanArtifact(c Change, a, b *types.EnvironmentData) bool {
+	if !tracedCategory(c.Category) {
+		return false
+	}
+	switch c.Kind {
+	case Added:
+		return !a.ManifestScan.Covers(b.ToolID(c.Name))
+	case Removed:
+		return !b.ManifestScan.Covers(a.ToolID(c.Name))
+	}
+	return false
+}
+
+// tracedCategory reports whether the entries of category have a provenance
+// (see types.Provenance)
+func tracedCategory(category string) bool {
+	switch category {
+	case types.CategoryLanguages, types.CategoryTools, types.CategoryPackageManagers, types.CategoryEditors:
+		return true
+	}
+	return false
+}
+
+// provenanceOf returns how env's entry called name was installed, or nil
+// when env doesn't record it
+func provenanceOf(env *types.EnvironmentData, name string) *types.Provenance {
+	if p, ok := env.Provenance[name]; ok {
+		return &p
+	}
+	return nil
+}
+
+// requirementNames returns env's classifications as a map that can be
+// compared like the other categories
+func requirementNames(env *types.EnvironmentData) map[string]string {
+	names := make(map[string]string, len(env.Requirements))
+	for name, r := range env.Requirements {
+		names[name] = string(r)
+	}
+	return names
+}
+
+// gitConfigEntries returns config as a map that can be compared like the
+// other categories. URL rewrites are keyed by the prefix they rewrite, so a
+// prefix sent somewhere else shows as changed. Credential helpers are keyed
+// by their config key, with every helper set for it as the value, and
+// settings by their key.
+func gitConfigEntries(config *types.GitConfig) map[string]string {
+	entries := make(map[string]string)
+	if config == nil {
+		return entries
+	}
+	for _, rule := range config.URLRewrites {
+		kind := "insteadOf"
+		if rule.Push {
+			kind = "pushInsteadOf"
+		}
+		entries[kind+" "+rule.InsteadOf] = rule.Base
+	}
+	for _, helper := range config.CredentialHelpers {
+		if current, ok := entries[helper.Key()]; ok {
+			entries[helper.Key()] = current + ", " + helper.Helper
+		} else {
+			entries[helper.Key()] = helper.Helper
+		}
+	}
+	maps.Copy(entries, config.Settings)
+	return entries
+}
+
+// languageConfigEntries returns the language config of env as a map that can
+// be compared like the other categories, keyed by setting name such as
+// "go.GOPATH". Whether corepack is enabled and the package manager the
+// project pins are compared as the node.corepack and node.packageManager
+// settings.
+func languageConfigEntries(env *types.EnvironmentData) map[string]string {
+	entries := make(map[string]string)
+	for _, setting := range env.LanguageConfig.Settings() {
+		entries[setting.Name()] = setting.Value
+	}
+	if env.Node != nil {
+		if env.Node.Corepack != nil {
+			entries["node.corepack"] = env.Node.Corepack.State()
+		}
+		if env.Node.PackageManager != "" {
+			entries["node.packageManager"] = env.Node.PackageManager
+		}
+	}
+	return entries
+}
+
+// compareSystem records differences in the system information block
+func compareSystem(result *Result, a, b types.SystemInfo) {
+	fields := []struct {
+		name string
+		a, b string
+	}{
+		{"os", a.OS, b.OS},
+		{"arch", a.Arch, b.Arch},
+		{"release", a.Release(), b.Release()},
+		{"shell", a.Shell, b.Shell},
+		{"hostname", a.Hostname, b.Hostname},
+	}
+	for _, f := range fields {
+		if f.a != f.b {
+			result.Changes = append(result.Changes, Change{
+				Category: types.CategorySystem,
+				Name:     f.name,
+				Kind:     Changed,
+				From:     f.a,
+				To:       f.b,
+			})
+		}
+	}
+}
+
+// compareMaps records added, removed and changed entries between two name → version maps
+func compareMaps(result *Result, category string, a, b map[string]string) {
+	names := make(map[string]struct{}, len(a)+len(b))
+	for name := range a {
+		names[name] = struct{}{}
+	}
+	for name := range b {
+		names[name] = struct{}{}
+	}
+
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	for _, name := range sorted {
+		from, inA := a[name]
+		to, inB := b[name]
+		switch {
+		case inA && !inB:
+			result.Changes = append(result.Changes, Change{Category: category, Name: name, Kind: Removed, From: from})
+		case !inA && inB:
+			result.Changes = append(result.Changes, Change{Category: category, Name: name, Kind: Added, To: to})
+		case from != to:
+			result.Changes = append(result.Changes, Change{Category: category, Name: name, Kind: Changed, From: from, To: to})
+		}
+	}
+}
+
+// effectiveTools returns env.Tools with build tool versions replaced by the
+// versions pinned in the project's wrappers, since those are what builds use
+func effectiveTools(env *types.EnvironmentData) map[string]string {
+	if env.Project == nil || len(env.Project.BuildWrappers) == 0 {
+		return env.Tools
+	}
+
+	tools := make(map[string]string, len(env.Tools)+len(env.Project.BuildWrappers))
+	for name, version := range env.Tools {
+		tools[name] = version
+	}
+	for name, version := range env.Project.BuildWrappers {
+		tools[name] = version
+	}
+	return tools
+}
+
+// extensionCategories returns the extension categories of either environment, in order
+func extensionCategories(a, b *types.EnvironmentData) []string {
+	categories := types.ExtensionCategories(a)
+	for _, category := range types.ExtensionCategories(b) {
+		if _, ok := a.Extensions[category]; !ok {
+			categories = append(categories, category)
+		}
+	}
+	sort.Strings(categories)
+	return categories
+}
+
+// toSet converts a list of names into a map so it can be compared like the other categories
+func toSet(items []string) map[string]string {
+	set := make(map[string]string, len(items))
+	for _, item := range items {
+		set[item] = ""
+	}
+	return set
+}
@@ -0,0 +1,490 @@
+package diff
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+func TestCheckPrefersWrapperPins(t *testing.T) {
+	wanted := &types.EnvironmentData{
+		Tools: map[string]string{"Gradle": "8.5", "Git": "2.40.1"},
+	}
+	installed := &types.EnvironmentData{
+		Tools: map[string]string{"Gradle": "7.4", "Git": "2.40.1"},
+		Project: &types.ProjectInfo{
+			BuildWrappers: map[string]string{"Gradle": "8.5"},
+		},
+	}
+
+	result := Check(installed, wanted)
+	if !result.Passed() {
+		t.Fatalf("expected wrapper-pinned Gradle to satisfy the check, got %+v", result.Items)
+	}
+
+	installed.Project = nil
+	result = Check(installed, wanted)
+	if result.Passed() {
+		t.Fatal("expected global Gradle 7.4 to fail the check without a wrapper")
+	}
+}
+
+func TestCompareUsesWrapperPins(t *testing.T) {
+	a := &types.EnvironmentData{
+		Tools:   map[string]string{"Maven": "3.8.1"},
+		Project: &types.ProjectInfo{BuildWrappers: map[string]string{"Maven": "3.9.6"}},
+	}
+	b := &types.EnvironmentData{
+		Tools: map[string]string{"Maven": "3.9.6"},
+	}
+
+	if result := Compare(a, b); !result.Empty() {
+		t.Errorf("expected no differences, got %+v", result.Changes)
+	}
+}
+
+func TestCompareExtensionCategories(t *testing.T) {
+	a := &types.EnvironmentData{
+		Extensions: map[string]map[string]string{
+			"databases": {"Redis": "7.0.0", "SQLite server": "3.45"},
+		},
+	}
+	b := &types.EnvironmentData{
+		Extensions: map[string]map[string]string{
+			"databases": {"Redis": "7.2.4"},
+			"browsers":  {"Firefox": "131.0"},
+		},
+	}
+
+	expected := []Change{
+		{Category: "browsers", Name: "Firefox", Kind: Added, To: "131.0"},
+		{Category: "databases", Name: "Redis", Kind: Changed, From: "7.0.0", To: "7.2.4"},
+		{Category: "databases", Name: "SQLite server", Kind: Removed, From: "3.45"},
+	}
+	result := Compare(a, b)
+	if len(result.Changes) != len(expected) {
+		t.Fatalf("expected %d changes but got %+v", len(expected), result.Changes)
+	}
+	for i, change := range expected {
+		if result.Changes[i] != change {
+			t.Errorf("change %d: expected %+v but got %+v", i, change, result.Changes[i])
+		}
+	}
+}
+
+func TestCheckReportsBrokenTools(t *testing.T) {
+	wanted := &types.EnvironmentData{
+		ConfiguredLanguages: map[string]string{"Node.js": "Installed"},
+		Tools:               map[string]string{"Git": "2.40.1", "Gradle": "8.5"},
+	}
+	installed := &types.EnvironmentData{
+		ConfiguredLanguages: map[string]string{"Node.js": "Installed"},
+		Tools:               map[string]string{"Git": "2.40.1", "Gradle": "Installed"},
+		Project:             &types.ProjectInfo{BuildWrappers: map[string]string{"Gradle": "8.5"}},
+		BrokenTools: map[string]types.ToolFailure{
+			"Node.js": {ExitStatus: 127, Output: "node: error while loading shared libraries: libicui18n.so.72"},
+			"Gradle":  {ExitStatus: 1},
+		},
+	}
+
+	result := Check(installed, wanted)
+	if result.Passed() {
+		t.Fatal("expected a broken Node.js to fail the check")
+	}
+	statuses := make(map[string]CheckStatus)
+	for _, item := range result.Items {
+		statuses[item.Name] = item.Status
+	}
+	expected := map[string]CheckStatus{"Node.js": StatusBroken, "Git": StatusOK, "Gradle": StatusOK}
+	for name, status := range expected {
+		if statuses[name] != status {
+			t.Errorf("expected %s to be %s but got %s", name, status, statuses[name])
+		}
+	}
+}
+
+func TestCheckRequirements(t *testing.T) {
+	installed := &types.EnvironmentData{Tools: map[string]string{"Git": "2.45.0"}}
+
+	testCases := []struct {
+		name         string
+		requirements map[string]types.Requirement
+		passed       bool
+		warnings     int
+	}{
+		{name: "Unclassified misses fail", passed: false},
+		{name: "Optional misses warn", requirements: map[string]types.Requirement{"Docker": types.Optional, "Neovim": types.Optional}, passed: true, warnings: 2},
+		{name: "Required misses fail", requirements: map[string]types.Requirement{"Git": types.Required, "Docker": types.Required, "Neovim": types.Optional}, passed: false, warnings: 1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			wanted := &types.EnvironmentData{
+				Tools:        map[string]string{"Git": "2.45.0", "Docker": "26.1.0"},
+				CodeEditors:  map[string]string{"Neovim": "0.10.0"},
+				Requirements: tc.requirements,
+			}
+			result := Check(installed, wanted)
+			if result.Passed() != tc.passed {
+				t.Errorf("expected passed %v but got %+v", tc.passed, result.Items)
+			}
+			if warnings := result.Warnings(); len(warnings) != tc.warnings {
+				t.Errorf("expected %d warnings but got %+v", tc.warnings, warnings)
+			}
+			for _, item := range result.Items {
+				if item.Requirement != tc.requirements[item.Name] {
+					t.Errorf("expected %s to be %s but got %s", item.Name, tc.requirements[item.Name], item.Requirement)
+				}
+			}
+		})
+	}
+}
+
+func TestCompareRequirements(t *testing.T) {
+	a := &types.EnvironmentData{
+		Tools:        map[string]string{"Docker": "26.1.0", "Git": "2.40.1"},
+		Requirements: map[string]types.Requirement{"Docker": types.Optional, "Git": types.Optional},
+	}
+	b := &types.EnvironmentData{
+		Tools:        map[string]string{"Git": "2.45.0", "Make": "4.4"},
+		Requirements: map[string]types.Requirement{"Git": types.Required},
+	}
+
+	expected := []Change{
+		{Category: types.CategoryTools, Name: "Docker", Kind: Removed, From: "26.1.0", Requirement: types.Optional},
+		{Category: types.CategoryTools, Name: "Git", Kind: Changed, From: "2.40.1", To: "2.45.0", Requirement: types.Required},
+		{Category: types.CategoryTools, Name: "Make", Kind: Added, To: "4.4"},
+		{Category: types.CategoryRequirements, Name: "Docker", Kind: Removed, From: "optional"},
+		{Category: types.CategoryRequirements, Name: "Git", Kind: Changed, From: "optional", To: "required"},
+	}
+	result := Compare(a, b)
+	if len(result.Changes) != len(expected) {
+		t.Fatalf("expected %d changes but got %+v", len(expected), result.Changes)
+	}
+	for i, change := range expected {
+		if result.Changes[i] != change {
+			t.Errorf("change %d: expected %+v but got %+v", i, change, result.Changes[i])
+		}
+	}
+}
+
+func TestCompareGitConfig(t *testing.T) {
+	a := &types.EnvironmentData{
+		GitConfig: &types.GitConfig{
+			URLRewrites: []types.URLRewrite{
+				{Base: "git@github.com:", InsteadOf: "https://github.com/"},
+				{Base: "https://old-mirror.example/", InsteadOf: "https://proxy.golang.org/"},
+			},
+			CredentialHelpers: []types.CredentialHelper{{Helper: "cache"}},
+		},
+	}
+	b := &types.EnvironmentData{
+		GitConfig: &types.GitConfig{
+			URLRewrites: []types.URLRewrite{
+				{Base: "git@github.com:", InsteadOf: "https://github.com/"},
+				{Base: "git@github.com:", InsteadOf: "https://github.com/", Push: true},
+				{Base: "https://mirror.example/", InsteadOf: "https://proxy.golang.org/"},
+			},
+			CredentialHelpers: []types.CredentialHelper{{Helper: "cache"}, {Helper: "osxkeychain"}},
+		},
+	}
+
+	expected := []Change{
+		{Category: types.CategoryGitConfig, Name: "credential.helper", Kind: Changed, From: "cache", To: "cache, osxkeychain"},
+		{Category: types.CategoryGitConfig, Name: "insteadOf https://proxy.golang.org/", Kind: Changed, From: "https://old-mirror.example/", To: "https://mirror.example/"},
+		{Category: types.CategoryGitConfig, Name: "pushInsteadOf https://github.com/", Kind: Added, To: "git@github.com:"},
+	}
+	result := Compare(a, b)
+	if len(result.Changes) != len(expected) {
+		t.Fatalf("expected %d changes but got %+v", len(expected), result.Changes)
+	}
+	for i, change := range expected {
+		if result.Changes[i] != change {
+			t.Errorf("change %d: expected %+v but got %+v", i, change, result.Changes[i])
+		}
+	}
+}
+
+func TestLanguageConfig(t *testing.T) {
+	installed := &types.EnvironmentData{
+		LanguageConfig: types.LanguageConfig{
+			"go":  {"GOPATH": "~/go", "GOPROXY": "https://proxy.golang.org,direct"},
+			"npm": {"prefix": "/usr/local"},
+		},
+	}
+	wanted := &types.EnvironmentData{
+		LanguageConfig: types.LanguageConfig{
+			"go":  {"GOPATH": "~/go", "GOPROXY": "https://goproxy.corp.example,direct", "GOPRIVATE": "corp.example/*"},
+			"npm": {"prefix": "/usr/local"},
+		},
+	}
+
+	expectedChanges := []Change{
+		{Category: types.CategoryLanguageConfig, Name: "go.GOPRIVATE", Kind: Added, To: "corp.example/*"},
+		{Category: types.CategoryLanguageConfig, Name: "go.GOPROXY", Kind: Changed, From: "https://proxy.golang.org,direct", To: "https://goproxy.corp.example,direct"},
+	}
+	result := Compare(installed, wanted)
+	if len(result.Changes) != len(expectedChanges) {
+		t.Fatalf("expected %d changes but got %+v", len(expectedChanges), result.Changes)
+	}
+	for i, change := range expectedChanges {
+		if result.Changes[i] != change {
+			t.Errorf("change %d: expected %+v but got %+v", i, change, result.Changes[i])
+		}
+	}
+
+	expectedStatus := map[string]CheckStatus{
+		"go.GOPATH":    StatusOK,
+		"go.GOPRIVATE": StatusMissing,
+		"go.GOPROXY":   StatusMismatch,
+		"npm.prefix":   StatusOK,
+	}
+	check := Check(installed, wanted)
+	if len(check.Items) != len(expectedStatus) {
+		t.Fatalf("expected %d check items but got %+v", len(expectedStatus), check.Items)
+	}
+	for _, item := range check.Items {
+		if item.Status != expectedStatus[item.Name] {
+			t.Errorf("expected %s to be %s but got %s", item.Name, expectedStatus[item.Name], item.Status)
+		}
+		if item.Explanation != nil {
+			t.Errorf("expected no version explanation for %s", item.Name)
+		}
+	}
+	if check.Passed() {
+		t.Errorf("expected the check to fail on the differing settings")
+	}
+}
+
+func TestProvenance(t *testing.T) {
+	apt := types.Provenance{Source: "apt", Manager: types.TypeApt, Package: "git"}
+	imported := types.Provenance{Source: types.ProvenanceImport, Manager: types.TypeApt, Package: "git", Installation: "inst_1"}
+	a := &types.EnvironmentData{
+		Tools:      map[string]string{"Git": "2.39.2", "Make": "4.3"},
+		Provenance: map[string]types.Provenance{"Git": apt},
+	}
+	b := &types.EnvironmentData{
+		Tools:      map[string]string{"Git": "2.43.0", "jq": "1.7"},
+		Provenance: map[string]types.Provenance{"Git": imported},
+	}
+
+	result := Check(a, b)
+	for _, item := range result.Items {
+		switch item.Name {
+		case "Git":
+			if item.Provenance == nil || *item.Provenance != apt {
+				t.Errorf("expected the installed provenance of Git but got %v", item.Provenance)
+			}
+		case "jq":
+			if item.Provenance != nil {
+				t.Errorf("expected no provenance for a missing entry but got %v", item.Provenance)
+			}
+		}
+	}
+
+	diff := Compare(a, b)
+	for _, c := range diff.Changes {
+		if c.Name != "Git" {
+			continue
+		}
+		if c.FromProvenance == nil || *c.FromProvenance != apt || c.ToProvenance == nil || *c.ToProvenance != imported {
+			t.Errorf("expected Git to change from %v to %v but got %v to %v", apt, imported, c.FromProvenance, c.ToProvenance)
+		}
+	}
+}
+
+func TestCheckNotesPythonMismatch(t *testing.T) {
+	installed := &types.EnvironmentData{
+		ConfiguredLanguages: map[string]string{"Python": "3.10.12"},
+		Python: &types.PythonEnvironment{
+			Pyenv:    &types.PyenvSelection{Global: "3.12.1"},
+			Mismatch: "pyenv says 3.12.1 but PATH resolves to /usr/bin/python3 3.10.12",
+		},
+	}
+
+	result := Check(installed, &types.EnvironmentData{ConfiguredLanguages: map[string]string{"Python": "3.10.12"}})
+	expected := []string{"Python: pyenv says 3.12.1 but PATH resolves to /usr/bin/python3 3.10.12"}
+	if !reflect.DeepEqual(result.Notes, expected) {
+		t.Errorf("expected notes %q but got %q", expected, result.Notes)
+	}
+	if !result.Passed() {
+		t.Error("expected a note not to fail the check")
+	}
+
+	if result := Check(installed, &types.EnvironmentData{Tools: map[string]string{}}); len(result.Notes) > 0 {
+		t.Errorf("expected no notes when Python is not wanted but got %q", result.Notes)
+	}
+}
+
+func TestCheckSideBySideVersions(t *testing.T) {
+	installed := &types.EnvironmentData{
+		ConfiguredLanguages: map[string]string{"Python 3": "3.9.18", "Ruby": "3.1.4"},
+		LanguageVersions: map[string][]string{
+			"Python": {"2.7.18", "3.9.18", "3.11.7", "3.12.1"},
+			"Ruby":   {"3.1.4", "3.2.2"},
+		},
+	}
+
+	testCases := []struct {
+		name      string
+		wanted    map[string]string
+		installed string
+		status    CheckStatus
+		notes     int
+	}{
+		{name: "First on PATH satisfies", wanted: map[string]string{"Python 3": "3.9.18"}, installed: "3.9.18", status: StatusOK},
+		{name: "Newest that satisfies", wanted: map[string]string{"Python 3": ">=3.11"}, installed: "3.12.1", status: StatusOK, notes: 1},
+		{name: "Exact side-by-side version", wanted: map[string]string{"Python 3": "3.11.7"}, installed: "3.11.7", status: StatusOK, notes: 1},
+		{name: "Python 2 doesn't count for Python 3", wanted: map[string]string{"Python 3": "2.7"}, installed: "3.9.18", status: StatusMismatch},
+		{name: "None satisfies", wanted: map[string]string{"Ruby": "3.3"}, installed: "3.1.4", status: StatusMismatch},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := Check(installed, &types.EnvironmentData{ConfiguredLanguages: tc.wanted})
+			item := result.Items[0]
+			if item.Installed != tc.installed || item.Status != tc.status {
+				t.Errorf("expected %s %s but got %s %s", tc.installed, tc.status, item.Installed, item.Status)
+			}
+			if len(result.Notes) != tc.notes {
+				t.Errorf("expected %d notes but got %q", tc.notes, result.Notes)
+			}
+		})
+	}
+}
+
+func TestCorepack(t *testing.T) {
+	enabled := &types.NodeEnvironment{Corepack: &types.Corepack{Version: "0.29.4", Shims: []string{"pnpm", "yarn"}}, PackageManager: "pnpm@9.1.0"}
+	testCases := []struct {
+		name      string
+		installed *types.NodeEnvironment
+		changes   []Change
+		status    CheckStatus
+	}{
+		{
+			name:      "enabled on both",
+			installed: &types.NodeEnvironment{Corepack: &types.Corepack{Version: "0.28.0", Shims: []string{"yarn"}}, PackageManager: "pnpm@9.1.0"},
+			status:    StatusOK,
+		},
+		{
+			name:      "installed but not enabled",
+			installed: &types.NodeEnvironment{Corepack: &types.Corepack{Version: "0.29.4"}},
+			changes: []Change{
+				{Category: types.CategoryLanguageConfig, Name: "node.corepack", Kind: Changed, From: "disabled", To: "enabled"},
+				{Category: types.CategoryLanguageConfig, Name: "node.packageManager", Kind: Added, To: "pnpm@9.1.0"},
+			},
+			status: StatusMismatch,
+		},
+		{
+			name: "not installed",
+			changes: []Change{
+				{Category: types.CategoryLanguageConfig, Name: "node.corepack", Kind: Added, To: "enabled"},
+				{Category: types.CategoryLanguageConfig, Name: "node.packageManager", Kind: Added, To: "pnpm@9.1.0"},
+			},
+			status: StatusMissing,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			installed := &types.EnvironmentData{Node: tc.installed}
+			wanted := &types.EnvironmentData{Node: enabled}
+			result := Compare(installed, wanted)
+			if len(result.Changes) != len(tc.changes) {
+				t.Fatalf("expected %d changes but got %+v", len(tc.changes), result.Changes)
+			}
+			for i, change := range tc.changes {
+				if result.Changes[i] != change {
+					t.Errorf("change %d: expected %+v but got %+v", i, change, result.Changes[i])
+				}
+			}
+
+			check := Check(installed, wanted)
+			if len(check.Items) != 1 || check.Items[0].Name != "node.corepack" || check.Items[0].Status != tc.status {
+				t.Errorf("expected node.corepack to be %s but got %+v", tc.status, check.Items)
+			}
+		})
+	}
+
+	// Corepack left disabled asks for nothing
+	check := Check(&types.EnvironmentData{}, &types.EnvironmentData{Node: &types.NodeEnvironment{Corepack: &types.Corepack{Version: "0.29.4"}}})
+	if len(check.Items) != 0 {
+		t.Errorf("expected no check items but got %+v", check.Items)
+	}
+}
+
+func TestCompareFastScan(t *testing.T) {
+	full := &types.EnvironmentData{
+		ConfiguredLanguages: map[string]string{"Node.js": "18.19.1", "Python": "3.12.3"},
+		Tools:               map[string]string{"Git": "2.43.0", "Terraform": "1.9.2"},
+		CodeEditors:         map[string]string{"VS Code": "1.90.2"},
+	}
+	fast := &types.EnvironmentData{
+		ConfiguredLanguages: map[string]string{"Node.js": "18.19.1+dfsg", "Python": "3.11.9"},
+		Tools:               map[string]string{"Git": "2.43.0", "Docker": "Installed"},
+		CodeEditors:         map[string]string{"VS Code": "Installed"},
+		ToolSources: map[string]string{
+			"Node.js": "package-db:dpkg:nodejs",
+			"Python":  "package-db:dpkg:python3.11",
+			"Git":     "package-db:dpkg:git",
+			"Docker":  "package-db:dpkg:docker.io",
+			"VS Code": "package-db:dpkg:code",
+		},
+		FastScan: &types.FastScan{Databases: []string{"dpkg"}},
+	}
+
+	// Terraform is missing from the fast scan, Docker was read from dpkg and
+	// the versions of Node.js and VS Code are those of their packages; only
+	// Python really differs
+	expected := []Change{
+		{Category: types.CategoryLanguages, Name: "Python", Kind: Changed, From: "3.12.3", To: "3.11.9"},
+	}
+	result := Compare(full, fast)
+	if !reflect.DeepEqual(result.Changes, expected) {
+		t.Errorf("expected %+v but got %+v", expected, result.Changes)
+	}
+	if result.FastScan != 4 {
+		t.Errorf("expected 4 differences left out but got %d", result.FastScan)
+	}
+
+	reversed := Compare(fast, full)
+	if len(reversed.Changes) != 1 || reversed.FastScan != 4 {
+		t.Errorf("expected the same differences in reverse but got %+v and %d left out", reversed.Changes, reversed.FastScan)
+	}
+
+	// Two fast scans are compared like any others
+	if result := Compare(fast, &types.EnvironmentData{FastScan: &types.FastScan{}}); result.FastScan != 0 {
+		t.Errorf("expected nothing left out between two fast scans but got %d", result.FastScan)
+	}
+}
+
+func TestCompareManifestScan(t *testing.T) {
+	full := &types.EnvironmentData{
+		ConfiguredLanguages: map[string]string{"Go": "1.22.4", "Python": "3.12.3"},
+		Tools:               map[string]string{"Git": "2.43.0", "Terraform": "1.9.2", "tmux": "3.4"},
+	}
+	limited := &types.EnvironmentData{
+		ConfiguredLanguages: map[string]string{"Go": "1.23.0"},
+		Tools:               map[string]string{"Git": "2.43.0"},
+		ManifestScan:        &types.ManifestScan{Manifest: "team.json", IDs: []string{"git", "go", "terraform"}},
+	}
+
+	// Python and tmux were not looked for; Terraform was, and is gone
+	expected := []Change{
+		{Category: types.CategoryLanguages, Name: "Go", Kind: Changed, From: "1.22.4", To: "1.23.0"},
+		{Category: types.CategoryTools, Name: "Terraform", Kind: Removed, From: "1.9.2"},
+	}
+	result := Compare(full, limited)
+	if !reflect.DeepEqual(result.Changes, expected) {
+		t.Errorf("expected %+v but got %+v", expected, result.Changes)
+	}
+	if result.ManifestScan != 2 {
+		t.Errorf("expected 2 differences left out but got %d", result.ManifestScan)
+	}
+
+	reversed := Compare(limited, full)
+	if len(reversed.Changes) != 2 || reversed.ManifestScan != 2 {
+		t.Errorf("expected the same differences in reverse but got %+v and %d left out", reversed.Changes, reversed.ManifestScan)
+	}
+}
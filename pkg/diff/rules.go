@@ -0,0 +1,244 @@
+package diff
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"syscall"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/version"
+	"gopkg.in/yaml.v3"
+)
+
+// Ignore levels of a rule
+const (
+	// IgnoreAll suppresses every difference of the matched entries
+	IgnoreAll = "all"
+	// IgnoreMinor suppresses version changes within the same major version
+	IgnoreMinor = "minor"
+	// IgnorePatch suppresses version changes within the same minor version
+	IgnorePatch = "patch"
+)
+
+// Rule suppresses differences that don't matter to the user, such as patch
+// bumps of an editor or the hostname
+type Rule struct {
+	// Category limits the rule to one category (e.g. "editors"); empty
+	// matches every category
+	Category string `yaml:"category,omitempty" json:"category,omitempty"`
+	// Name limits the rule to one entry (e.g. "VS Code" or "hostname");
+	// empty matches every entry of Category
+	Name string `yaml:"name,omitempty" json:"name,omitempty"`
+	// Ignore is IgnoreAll (the default), IgnoreMinor or IgnorePatch
+	Ignore string `yaml:"ignore,omitempty" json:"ignore,omitempty"`
+}
+
+// Rules are the user's diff rules, applied by diff and check. The zero value
+// suppresses nothing.
+type Rules struct {
+	Rules []Rule `yaml:"rules" json:"rules"`
+}
+
+// LoadRules reads a diff rules file. A missing file yields no rules unless
+// required is set, for files the user named explicitly.
+func LoadRules(path string, required bool) (*Rules, error) {
+	data, err := os.ReadFile(path)
+	// A directory that is a file holds no rules either
+	if (errors.Is(err, os.ErrNotExist) || errors.Is(err, syscall.ENOTDIR)) && !required {
+		return &Rules{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read diff rules: %w", err)
+	}
+	rules, err := ParseRules(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return rules, nil
+}
+
+// ParseRules parses diff rules in YAML or JSON and validates every rule.
+// Errors give the line of the offending rule.
+func ParseRules(data []byte) (*Rules, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid diff rules: %w", err)
+	}
+	rules := &Rules{}
+	if len(doc.Content) == 0 {
+		return rules, nil
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("line %d: diff rules must be a mapping with a rules list", root.Line)
+	}
+
+	var errs []error
+	for i := 0; i < len(root.Content); i += 2 {
+		key, value := root.Content[i], root.Content[i+1]
+		if key.Value != "rules" {
+			errs = append(errs, fmt.Errorf("line %d: unknown key %q (want rules)", key.Line, key.Value))
+			continue
+		}
+		if value.Kind != yaml.SequenceNode {
+			errs = append(errs, fmt.Errorf("line %d: rules must be a list", value.Line))
+			continue
+		}
+		for n, node := range value.Content {
+			var rule Rule
+			err := decodeStrict(node, &rule)
+			if err == nil {
+				err = rule.validate()
+			}
+			if err != nil {
+				errs = append(errs, fmt.Errorf("rule %d (line %d): %w", n+1, node.Line, err))
+				continue
+			}
+			rules.Rules = append(rules.Rules, rule)
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// decodeStrict decodes node into v, rejecting keys v doesn't have
+func decodeStrict(node *yaml.Node, v any) error {
+	if node.Kind != yaml.MappingNode {
+		return errors.New("must be a mapping with category, name and ignore keys")
+	}
+	for i := 0; i < len(node.Content); i += 2 {
+		switch key := node.Content[i].Value; key {
+		case "category", "name", "ignore":
+		default:
+			return fmt.Errorf("unknown key %q (want category, name or ignore)", key)
+		}
+	}
+	return node.Decode(v)
+}
+
+// validate checks the rule and fills in its default ignore level
+func (r *Rule) validate() error {
+	if r.Category == "" && r.Name == "" {
+		return errors.New("needs a category, a name or both")
+	}
+	switch r.Ignore {
+	case "":
+		r.Ignore = IgnoreAll
+	case IgnoreAll, IgnoreMinor, IgnorePatch:
+	default:
+		return fmt.Errorf("ignore %q is not one of all, minor or patch", r.Ignore)
+	}
+	return nil
+}
+
+// ParseRuleFlag parses a rule given on the command line as
+// [category/]name[=level], for example "editors/VS Code=patch" or "hostname"
+func ParseRuleFlag(value string) (Rule, error) {
+	var rule Rule
+	target, level, _ := strings.Cut(value, "=")
+	if category, name, ok := strings.Cut(target, "/"); ok {
+		rule.Category, rule.Name = category, name
+	} else {
+		rule.Name = target
+	}
+	rule.Category = strings.TrimSpace(rule.Category)
+	rule.Name = strings.TrimSpace(rule.Name)
+	rule.Ignore = strings.TrimSpace(level)
+	if err := rule.validate(); err != nil {
+		return Rule{}, fmt.Errorf("invalid rule %q: %w", value, err)
+	}
+	return rule, nil
+}
+
+// matches reports whether the rule applies to the entry called name in
+// category. Names and categories are matched ignoring case.
+func (r Rule) matches(category, name string) bool {
+	return (r.Category == "" || strings.EqualFold(r.Category, category)) &&
+		(r.Name == "" || strings.EqualFold(r.Name, name))
+}
+
+// tolerates reports whether the rule suppresses a difference between
+// versions from and to of a matched entry. Entries present on one side only
+// are only suppressed by IgnoreAll, as are versions that don't parse.
+func (r Rule) tolerates(from, to string, both bool) bool {
+	if r.Ignore == IgnoreAll {
+		return true
+	}
+	if !both {
+		return false
+	}
+	a, errA := parseNormalized(from)
+	b, errB := parseNormalized(to)
+	if errA != nil || errB != nil {
+		return false
+	}
+	switch r.Ignore {
+	case IgnoreMinor:
+		return a.Major == b.Major
+	case IgnorePatch:
+		return a.Major == b.Major && a.Minor == b.Minor
+	}
+	return false
+}
+
+// parseNormalized parses a recorded version after normalizing it like
+// version constraints do (epochs, "go" prefixes and distro revisions)
+func parseNormalized(raw string) (*version.Version, error) {
+	normalized, _ := version.Normalize(raw)
+	return version.Parse(normalized)
+}
+
+// suppresses reports whether any rule suppresses the difference
+func (rs *Rules) suppresses(category, name, from, to string, both bool) bool {
+	if rs == nil {
+		return false
+	}
+	for _, rule := range rs.Rules {
+		if rule.matches(category, name) && rule.tolerates(from, to, both) {
+			return true
+		}
+	}
+	return false
+}
+
+// Empty reports whether there are no rules
+func (rs *Rules) Empty() bool {
+	return rs == nil || len(rs.Rules) == 0
+}
+
+// Add appends rules, such as those given on the command line
+func (rs *Rules) Add(rules ...Rule) {
+	rs.Rules = append(rs.Rules, rules...)
+}
+
+// ApplyDiff removes the changes the rules suppress from result and counts
+// them in result.Suppressed
+func (rs *Rules) ApplyDiff(result *Result) {
+	kept := result.Changes[:0]
+	for _, c := range result.Changes {
+		if rs.suppresses(c.Category, c.Name, c.From, c.To, c.Kind == Changed) {
+			result.Suppressed++
+			continue
+		}
+		kept = append(kept, c)
+	}
+	result.Changes = kept
+}
+
+// ApplyCheck removes the items the rules suppress from result and counts
+// them in result.Suppressed. Satisfied items are kept. A tolerance applies
+// when the wanted version is a plain version rather than a constraint.
+func (rs *Rules) ApplyCheck(result *CheckResult) {
+	kept := result.Items[:0]
+	for _, item := range result.Items {
+		if item.Status != StatusOK && rs.suppresses(item.Category, item.Name, item.Wanted, item.Installed, item.Status == StatusMismatch) {
+			result.Suppressed++
+			continue
+		}
+		kept = append(kept, item)
+	}
+	result.Items = kept
+}
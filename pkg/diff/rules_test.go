@@ -0,0 +1,218 @@
+package diff
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+func TestRuleTolerates(t *testing.T) {
+	testCases := []struct {
+		name     string
+		ignore   string
+		from     string
+		to       string
+		both     bool
+		expected bool
+	}{
+		{"Patch bump within patch tolerance", IgnorePatch, "1.89.0", "1.89.1", true, true},
+		{"Minor bump beyond patch tolerance", IgnorePatch, "1.89.1", "1.90.0", true, false},
+		{"Minor bump within minor tolerance", IgnoreMinor, "1.89.1", "1.90.0", true, true},
+		{"Major bump beyond minor tolerance", IgnoreMinor, "1.89.1", "2.0.0", true, false},
+		{"Downgrade within patch tolerance", IgnorePatch, "2.43.2", "2.43.0", true, true},
+		{"Partial versions", IgnorePatch, "3.12", "3.12.4", true, true},
+		{"Debian revision and epoch", IgnorePatch, "1:2.39.2-1ubuntu1", "2.39.5", true, true},
+		{"Go prefix", IgnorePatch, "go1.22.1", "1.22.5", true, true},
+		{"Pre-release within patch tolerance", IgnorePatch, "1.90.0-insider", "1.90.0", true, true},
+		{"Unparseable versions are not tolerated", IgnorePatch, "Installed", "1.2.3", true, false},
+		{"Added entry is not a version change", IgnorePatch, "", "1.2.3", false, false},
+		{"Ignore all suppresses added entries", IgnoreAll, "", "1.2.3", false, true},
+		{"Ignore all suppresses any change", IgnoreAll, "1.0.0", "9.0.0", true, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rule := Rule{Name: "VS Code", Ignore: tc.ignore}
+			if got := rule.tolerates(tc.from, tc.to, tc.both); got != tc.expected {
+				t.Errorf("expected %s tolerance of %q → %q to be %t but got %t", tc.ignore, tc.from, tc.to, tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestParseRules(t *testing.T) {
+	testCases := []struct {
+		name          string
+		data          string
+		expected      []Rule
+		expectedError []string
+	}{
+		{
+			name: "Rules",
+			data: `rules:
+  - name: VS Code
+    ignore: patch
+  - category: editors
+  - category: system
+    name: hostname
+`,
+			expected: []Rule{
+				{Name: "VS Code", Ignore: IgnorePatch},
+				{Category: "editors", Ignore: IgnoreAll},
+				{Category: "system", Name: "hostname", Ignore: IgnoreAll},
+			},
+		},
+		{
+			name:     "JSON",
+			data:     `{"rules": [{"name": "Node.js", "ignore": "minor"}]}`,
+			expected: []Rule{{Name: "Node.js", Ignore: IgnoreMinor}},
+		},
+		{
+			name: "Empty file",
+			data: "",
+		},
+		{
+			name: "Every invalid rule is reported with its line",
+			data: `rules:
+  - name: VS Code
+    ignore: pach
+  - ignore: patch
+  - nmae: Git
+`,
+			expectedError: []string{
+				`rule 1 (line 2): ignore "pach" is not one of all, minor or patch`,
+				"rule 2 (line 4): needs a category, a name or both",
+				`rule 3 (line 5): unknown key "nmae" (want category, name or ignore)`,
+			},
+		},
+		{
+			name:          "Unknown top-level key",
+			data:          "ignore:\n  - hostname\n",
+			expectedError: []string{`line 1: unknown key "ignore" (want rules)`},
+		},
+		{
+			name:          "Rules not a list",
+			data:          "rules:\n  name: Git\n",
+			expectedError: []string{"line 2: rules must be a list"},
+		},
+		{
+			name:          "Rule not a mapping",
+			data:          "rules:\n  - hostname\n",
+			expectedError: []string{"rule 1 (line 2): must be a mapping"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rules, err := ParseRules([]byte(tc.data))
+			if len(tc.expectedError) > 0 {
+				if err == nil {
+					t.Fatalf("expected errors %q but got rules %+v", tc.expectedError, rules)
+				}
+				for _, expected := range tc.expectedError {
+					if !strings.Contains(err.Error(), expected) {
+						t.Errorf("expected the error to mention %q but got %q", expected, err)
+					}
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(rules.Rules, tc.expected) {
+				t.Errorf("expected rules %+v but got %+v", tc.expected, rules.Rules)
+			}
+		})
+	}
+}
+
+func TestParseRuleFlag(t *testing.T) {
+	testCases := []struct {
+		value         string
+		expected      Rule
+		expectedError bool
+	}{
+		{value: "hostname", expected: Rule{Name: "hostname", Ignore: IgnoreAll}},
+		{value: "VS Code=patch", expected: Rule{Name: "VS Code", Ignore: IgnorePatch}},
+		{value: "editors/VS Code=minor", expected: Rule{Category: "editors", Name: "VS Code", Ignore: IgnoreMinor}},
+		{value: "editors/", expected: Rule{Category: "editors", Ignore: IgnoreAll}},
+		{value: "Git=major", expectedError: true},
+		{value: "=patch", expectedError: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.value, func(t *testing.T) {
+			rule, err := ParseRuleFlag(tc.value)
+			if tc.expectedError {
+				if err == nil {
+					t.Errorf("expected an error but got %+v", rule)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if rule != tc.expected {
+				t.Errorf("expected %+v but got %+v", tc.expected, rule)
+			}
+		})
+	}
+}
+
+func TestApplyRules(t *testing.T) {
+	rules := &Rules{Rules: []Rule{
+		{Name: "vs code", Ignore: IgnorePatch},
+		{Category: types.CategorySystem, Name: "hostname", Ignore: IgnoreAll},
+		{Category: types.CategoryPackageManagers, Ignore: IgnoreAll},
+	}}
+
+	a := &types.EnvironmentData{
+		System:          types.SystemInfo{OS: "linux", Hostname: "laptop"},
+		CodeEditors:     map[string]string{"VS Code": "1.89.0", "Vim": "9.0"},
+		PackageManagers: map[string]string{"apt": "2.6.1"},
+		Tools:           map[string]string{"Git": "2.39.5"},
+	}
+	b := &types.EnvironmentData{
+		System:          types.SystemInfo{OS: "linux", Hostname: "desktop"},
+		CodeEditors:     map[string]string{"VS Code": "1.89.1", "Vim": "9.1"},
+		PackageManagers: map[string]string{"apt": "2.7.14", "snap": "2.61"},
+		Tools:           map[string]string{"Git": "2.43.0"},
+	}
+
+	result := Compare(a, b)
+	rules.ApplyDiff(result)
+	expected := []Change{
+		{Category: types.CategoryTools, Name: "Git", Kind: Changed, From: "2.39.5", To: "2.43.0"},
+		{Category: types.CategoryEditors, Name: "Vim", Kind: Changed, From: "9.0", To: "9.1"},
+	}
+	if !reflect.DeepEqual(result.Changes, expected) {
+		t.Errorf("expected changes %+v but got %+v", expected, result.Changes)
+	}
+	if result.Suppressed != 4 {
+		t.Errorf("expected 4 suppressed changes but got %d", result.Suppressed)
+	}
+
+	// b is installed and a is wanted: VS Code is a patch ahead, apt is
+	// mismatched but its category is ignored, Git fails
+	check := Check(b, a)
+	rules.ApplyCheck(check)
+	var names []string
+	for _, item := range check.Items {
+		names = append(names, item.Name)
+	}
+	if expected := []string{"Git", "Vim"}; !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected check items %v but got %v", expected, names)
+	}
+	if check.Suppressed != 2 {
+		t.Errorf("expected 2 suppressed check items but got %d", check.Suppressed)
+	}
+
+	var none *Rules
+	result = Compare(a, b)
+	none.ApplyDiff(result)
+	if result.Suppressed != 0 || len(result.Changes) != 6 {
+		t.Errorf("expected nil rules to keep every change but got %+v", result)
+	}
+}
@@ -0,0 +1,71 @@
+package envfile
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrCorrupted reports data that doesn't match the checksum recorded when it
+// was written, such as a payload truncated by a proxy
+var ErrCorrupted = errors.New("payload corrupted in transit or storage")
+
+// Canonical re-encodes the JSON in data in a form that doesn't depend on
+// how it was serialized: object keys sorted, no insignificant whitespace and
+// numbers in their shortest form. A database such as PostgreSQL's jsonb
+// reorders keys and reformats the data it stores, so checksums are taken
+// over this form rather than the bytes sent. A JSON string wrapping the
+// data, as older rows hold it, is unwrapped first.
+func Canonical(data []byte) ([]byte, error) {
+	data = bytes.TrimPrefix(data, utf8BOM)
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	if inner, ok := value.(string); ok {
+		return Canonical([]byte(inner))
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(value); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// Checksum returns the hex-encoded SHA-256 of the canonical form of data
+func Checksum(data []byte) (string, error) {
+	canonical, err := Canonical(data)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(canonical)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Verify checks data against checksum, as returned by Checksum when the
+// data was written. Data that differs, or no longer parses, is reported
+// with ErrCorrupted.
+func Verify(data []byte, checksum string) error {
+	got, err := Checksum(data)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrCorrupted, err)
+	}
+	if got != checksum {
+		return fmt.Errorf("%w: SHA-256 %s does not match %s recorded when it was written", ErrCorrupted, shortChecksum(got), shortChecksum(checksum))
+	}
+	return nil
+}
+
+// shortChecksum abbreviates a checksum for messages
+func shortChecksum(checksum string) string {
+	if len(checksum) > 12 {
+		return checksum[:12]
+	}
+	return checksum
+}
@@ -0,0 +1,78 @@
+package envfile
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+)
+
+func TestChecksumIsCanonical(t *testing.T) {
+	pushed := `{"stackmatch_version":"0.3.0","system":{"os":"linux","arch":"amd64"},"tools":{"Make":"4.3","Git":"2.43.0"},"summary":{"scan_duration_ms":1500}}`
+	checksum, err := Checksum([]byte(pushed))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name string
+		data string
+	}{
+		// jsonb sorts keys by length, then bytewise, and adds spaces
+		{name: "Reordered by the database", data: `{"tools": {"Git": "2.43.0", "Make": "4.3"}, "system": {"os": "linux", "arch": "amd64"}, "summary": {"scan_duration_ms": 1500}, "stackmatch_version": "0.3.0"}`},
+		{name: "Indented", data: "{\n  \"stackmatch_version\": \"0.3.0\",\n  \"system\": {\"arch\": \"amd64\", \"os\": \"linux\"},\n  \"tools\": {\"Git\": \"2.43.0\", \"Make\": \"4.3\"},\n  \"summary\": {\"scan_duration_ms\": 1.5e3}\n}\n"},
+		{name: "Stored as a JSON string", data: strconv.Quote(pushed)},
+		{name: "Byte order mark", data: "\xEF\xBB\xBF" + pushed},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := Checksum([]byte(tc.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != checksum {
+				t.Errorf("expected checksum %s but got %s", checksum, got)
+			}
+		})
+	}
+}
+
+func TestParseVerifiesChecksum(t *testing.T) {
+	pushed := `{"stackmatch_version":"0.3.0","system":{"os":"linux","arch":"amd64"},"tools":{"Git":"2.43.0"}}`
+	checksum, err := Checksum([]byte(pushed))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name      string
+		data      string
+		checksum  string
+		corrupted bool
+	}{
+		{name: "Matching", data: pushed, checksum: checksum},
+		{name: "Truncated", data: pushed[:len(pushed)/2], checksum: checksum, corrupted: true},
+		{name: "Altered", data: `{"stackmatch_version":"0.3.0","system":{"os":"linux","arch":"amd64"},"tools":{"Git":"2.34.0"}}`, checksum: checksum, corrupted: true},
+		// Repair would skip the trailing text, but corrupted data isn't repaired
+		{name: "Trailing bytes", data: pushed + "}garbage", checksum: checksum, corrupted: true},
+		{name: "No checksum", data: pushed},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			env, err := Parse([]byte(tc.data), Options{Repair: true, Checksum: tc.checksum})
+			if tc.corrupted {
+				if !errors.Is(err, ErrCorrupted) {
+					t.Fatalf("expected the data to be reported corrupted but got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected the data to parse but got %v", err)
+			}
+			if env.Tools["Git"] != "2.43.0" {
+				t.Errorf("expected Git 2.43.0 but got %q", env.Tools["Git"])
+			}
+		})
+	}
+}
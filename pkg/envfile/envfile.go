@@ -0,0 +1,209 @@
+// Package envfile reads environment files written by export, scan or pull.
+// Besides plain JSON it recognizes files with text in front of the JSON, such
+// as log lines captured together with the output, and can skip it on request.
+package envfile
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+// utf8BOM is written at the start of files by some Windows editors. It is not
+// garbage and is always skipped.
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// maxPrefixLen bounds how much of the offending text errors quote
+const maxPrefixLen = 60
+
+// Options controls how tolerant Parse is
+type Options struct {
+	// Repair skips any text before the first '{' and after the JSON object
+	// instead of failing
+	Repair bool
+	// Warn, when set, is told about text that Repair skipped
+	Warn func(message string)
+	// Checksum, when set, is the checksum recorded when the data was
+	// written (see Checksum). Data that doesn't match fails with
+	// ErrCorrupted before anything is repaired.
+	Checksum string
+}
+
+// GarbageError reports non-JSON text before or after the environment object
+type GarbageError struct {
+	// Text is the first line of the offending text, truncated
+	Text string
+	// Line and Column locate the first offending character, starting at 1
+	Line   int
+	Column int
+	// Trailing is set when the text follows the JSON rather than preceding it
+	Trailing bool
+}
+
+func (e *GarbageError) Error() string {
+	where := "before"
+	if e.Trailing {
+		where = "after"
+	}
+	return fmt.Sprintf("found non-JSON text %s the environment at line %d, column %d: %q; remove it or pass --repair to skip it",
+		where, e.Line, e.Column, e.Text)
+}
+
+// Parse decodes an environment, refreshing its summary. Data fetched
+// remotely may be a JSON string wrapping the environment; it is unwrapped.
+func Parse(data []byte, opts Options) (*types.EnvironmentData, error) {
+	data = bytes.TrimPrefix(data, utf8BOM)
+	if opts.Checksum != "" {
+		if err := Verify(data, opts.Checksum); err != nil {
+			return nil, err
+		}
+		opts.Checksum = ""
+	}
+
+	// Environments stored as text come back as a JSON string
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '"' {
+		var inner string
+		if err := json.Unmarshal(trimmed, &inner); err == nil {
+			return Parse([]byte(inner), opts)
+		}
+	}
+
+	start := bytes.IndexByte(data, '{')
+	if start < 0 {
+		return nil, fmt.Errorf("no JSON object found")
+	}
+	if garbage := firstNonSpace(data[:start]); garbage >= 0 && !opts.Repair {
+		return nil, newGarbageError(data, garbage, start, false)
+	}
+
+	env, end, err := decodeFrom(data, start)
+	// Log lines may contain braces themselves, so when repairing keep
+	// looking for an object that decodes as an environment
+	firstErr := err
+	for err != nil && opts.Repair {
+		next := bytes.IndexByte(data[start+1:], '{')
+		if next < 0 {
+			break
+		}
+		start += next + 1
+		env, end, err = decodeFrom(data, start)
+	}
+	if err != nil {
+		return nil, firstErr
+	}
+	if garbage := firstNonSpace(data[:start]); garbage >= 0 {
+		warn(opts, fmt.Sprintf("skipped non-JSON text before the environment: %q", newGarbageError(data, garbage, start, false).Text))
+	}
+
+	if garbage := firstNonSpace(data[end:]); garbage >= 0 {
+		gerr := newGarbageError(data, end+garbage, len(data), true)
+		if !opts.Repair {
+			return nil, gerr
+		}
+		warn(opts, fmt.Sprintf("skipped non-JSON text after the environment: %q", gerr.Text))
+	}
+
+	types.RefreshSummary(env)
+	return env, nil
+}
+
+// decodeFrom decodes the JSON object starting at data[start] and returns it
+// with the offset just past it
+func decodeFrom(data []byte, start int) (*types.EnvironmentData, int, error) {
+	dec := json.NewDecoder(bytes.NewReader(data[start:]))
+	var raw json.RawMessage
+	if err := dec.Decode(&raw); err != nil {
+		return nil, 0, fmt.Errorf("invalid JSON: %w", err)
+	}
+	if err := validate(raw); err != nil {
+		return nil, 0, err
+	}
+	var env types.EnvironmentData
+	if err := json.Unmarshal(raw, &env); err != nil {
+		return nil, 0, fmt.Errorf("invalid JSON: %w", err)
+	}
+	return &env, start + int(dec.InputOffset()), nil
+}
+
+// Read parses everything read from r
+func Read(r io.Reader, opts Options) (*types.EnvironmentData, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data, opts)
+}
+
+// environmentKeys are top-level keys that mark a StackMatch environment; a
+// file needs only one of them. Files from before stackmatch_version was
+// recorded lack it but have some of the others.
+var environmentKeys = []string{
+	"stackmatch_version", "scan_date", "system", "tools", "package_managers",
+	"code_editors", "configured_languages", "config_files",
+}
+
+// validate checks that a JSON object is a StackMatch environment rather than
+// some other JSON document. A missing stackmatch_version is not enough to
+// reject it: environments written before it was recorded lack it.
+func validate(raw json.RawMessage) error {
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &object); err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+	for _, key := range environmentKeys {
+		if _, ok := object[key]; ok {
+			return nil
+		}
+	}
+	return fmt.Errorf("none of %s found; this does not look like a StackMatch environment file", strings.Join(environmentKeys, ", "))
+}
+
+func warn(opts Options, message string) {
+	if opts.Warn != nil {
+		opts.Warn(message)
+	}
+}
+
+// firstNonSpace returns the index of the first non-whitespace byte, or -1
+func firstNonSpace(b []byte) int {
+	for i, c := range b {
+		switch c {
+		case ' ', '\t', '\r', '\n':
+			continue
+		}
+		return i
+	}
+	return -1
+}
+
+// newGarbageError describes the text in data[from:to]
+func newGarbageError(data []byte, from, to int, trailing bool) *GarbageError {
+	line, col := 1, 1
+	for _, r := range string(data[:from]) {
+		if r == '\n' {
+			line++
+			col = 1
+		} else {
+			col++
+		}
+	}
+
+	// Quote the first offending line, which is usually enough to recognize it
+	text := data[from:to]
+	if i := bytes.IndexByte(text, '\n'); i >= 0 {
+		text = text[:i]
+	}
+	text = bytes.TrimSpace(text)
+	if len(text) > maxPrefixLen {
+		text = text[:maxPrefixLen]
+		for len(text) > 0 && !utf8.Valid(text) {
+			text = text[:len(text)-1]
+		}
+	}
+	return &GarbageError{Text: string(text), Line: line, Column: col, Trailing: trailing}
+}
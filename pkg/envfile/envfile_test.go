@@ -0,0 +1,147 @@
+package envfile
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		name     string
+		fixture  string
+		wantErr  *GarbageError
+		warnings int
+	}{
+		{name: "Clean file", fixture: "clean.json"},
+		{name: "Byte order mark only", fixture: "bom.json"},
+		// written before stackmatch_version was recorded
+		{name: "Baseline release", fixture: "baseline.json"},
+		{
+			name:     "Log lines before JSON",
+			fixture:  "log_lines.json",
+			wantErr:  &GarbageError{Text: "2026/10/16 09:12:44 Found Git version 2.43.0", Line: 1, Column: 1},
+			warnings: 1,
+		},
+		{
+			name:     "Byte order mark and preamble",
+			fixture:  "bom_preamble.json",
+			wantErr:  &GarbageError{Text: "Scanning environment...", Line: 1, Column: 1},
+			warnings: 1,
+		},
+		{
+			name:     "ANSI codes and trailing text",
+			fixture:  "ansi.json",
+			wantErr:  &GarbageError{Text: "\x1b[1;32m✓ Scan complete\x1b[0m", Line: 1, Column: 1},
+			warnings: 2,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("testdata", tc.fixture))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			env, err := Parse(data, Options{})
+			if tc.wantErr == nil {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			} else {
+				var gerr *GarbageError
+				if !errors.As(err, &gerr) {
+					t.Fatalf("expected a GarbageError but got %v", err)
+				}
+				if *gerr != *tc.wantErr {
+					t.Errorf("expected %+v but got %+v", *tc.wantErr, *gerr)
+				}
+				if !strings.Contains(err.Error(), "--repair") {
+					t.Errorf("expected the error to mention --repair: %v", err)
+				}
+
+				var warnings []string
+				env, err = Parse(data, Options{Repair: true, Warn: func(msg string) { warnings = append(warnings, msg) }})
+				if err != nil {
+					t.Fatalf("repair failed: %v", err)
+				}
+				if len(warnings) != tc.warnings {
+					t.Errorf("expected %d warnings but got %v", tc.warnings, warnings)
+				}
+			}
+
+			if env.Tools["Git"] != "2.43.0" {
+				t.Errorf("expected Git 2.43.0, got %v", env.Tools)
+			}
+			if env.Summary == nil {
+				t.Error("expected the summary to be computed")
+			}
+		})
+	}
+}
+
+func TestParseReportsLocation(t *testing.T) {
+	_, err := Parse([]byte("\n\n   oops {\"stackmatch_version\": \"0.3.0\"}"), Options{})
+	var gerr *GarbageError
+	if !errors.As(err, &gerr) {
+		t.Fatalf("expected a GarbageError but got %v", err)
+	}
+	if gerr.Line != 3 || gerr.Column != 4 {
+		t.Errorf("expected line 3, column 4 but got line %d, column %d", gerr.Line, gerr.Column)
+	}
+}
+
+func TestParseRepairValidates(t *testing.T) {
+	testCases := map[string]string{
+		"Not an environment": `Saved {"name": "something else"}`,
+		"Broken JSON":        `log line {"stackmatch_version": "0.3.0", "tools": {`,
+		"No object":          "just some text",
+	}
+	for name, input := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if env, err := Parse([]byte(input), Options{Repair: true}); err == nil {
+				t.Errorf("expected an error but got %+v", env)
+			}
+		})
+	}
+}
+
+func TestParseNamesEnvironmentKeys(t *testing.T) {
+	_, err := Parse([]byte(`{"name": "something else"}`), Options{})
+	if err == nil {
+		t.Fatal("expected an error for a document that is not an environment")
+	}
+	for _, key := range environmentKeys {
+		if !strings.Contains(err.Error(), key) {
+			t.Errorf("expected the error to name %s: %v", key, err)
+		}
+	}
+}
+
+func TestParseUnwrapsJSONString(t *testing.T) {
+	env, err := Parse([]byte(`"{\"stackmatch_version\": \"0.3.0\", \"tools\": {\"Git\": \"2.43.0\"}}"`), Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if env.Tools["Git"] != "2.43.0" {
+		t.Errorf("expected Git 2.43.0, got %v", env.Tools)
+	}
+}
+
+// Classifications must survive a push and pull, which stores the data as a
+// JSON string and parses it back
+func TestParseKeepsRequirements(t *testing.T) {
+	pushed := `"{\"stackmatch_version\": \"0.3.0\", \"tools\": {\"Git\": \"2.43.0\", \"Make\": \"4.4\"}, \"requirements\": {\"Git\": \"required\"}}"`
+	env, err := Parse([]byte(pushed), Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if env.Tool("Git").Requirement != types.Required || env.Tool("Make").Requirement != types.Unclassified {
+		t.Errorf("expected Git to be required and Make unclassified, got %v", env.Requirements)
+	}
+}
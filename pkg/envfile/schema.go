@@ -0,0 +1,224 @@
+package envfile
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+//go:embed schema.json
+var schemaJSON []byte
+
+// Schema returns the JSON Schema environment files follow. It is kept in
+// sync with types.EnvironmentData by the package tests.
+func Schema() []byte {
+	return schemaJSON
+}
+
+// schemaNode is the subset of JSON Schema the environment schema uses
+type schemaNode struct {
+	Ref                  string                 `json:"$ref"`
+	Type                 string                 `json:"type"`
+	Required             []string               `json:"required"`
+	Properties           map[string]*schemaNode `json:"properties"`
+	AdditionalProperties *additionalProperties  `json:"additionalProperties"`
+	Items                *schemaNode            `json:"items"`
+	MinLength            *int                   `json:"minLength"`
+	Minimum              *float64               `json:"minimum"`
+	Enum                 []string               `json:"enum"`
+	Format               string                 `json:"format"`
+	Defs                 map[string]*schemaNode `json:"$defs"`
+}
+
+// additionalProperties is either false or a schema for every property not
+// listed in properties
+type additionalProperties struct {
+	forbidden bool
+	schema    *schemaNode
+}
+
+func (a *additionalProperties) UnmarshalJSON(data []byte) error {
+	var allowed bool
+	if err := json.Unmarshal(data, &allowed); err == nil {
+		a.forbidden = !allowed
+		return nil
+	}
+	a.schema = &schemaNode{}
+	return json.Unmarshal(data, a.schema)
+}
+
+// environmentSchema is the parsed embedded schema
+var environmentSchema = func() *schemaNode {
+	var root schemaNode
+	if err := json.Unmarshal(schemaJSON, &root); err != nil {
+		panic("invalid embedded schema: " + err.Error())
+	}
+	return &root
+}()
+
+// CheckSchema validates a JSON document against the environment schema and
+// returns every violation found. An error is returned only when data is not
+// JSON at all.
+func CheckSchema(data []byte) ([]types.ValidationIssue, error) {
+	return checkAgainst(environmentSchema, data)
+}
+
+// CheckSchemaOf validates a JSON document against another JSON Schema, such
+// as that of a file exported for another tool. Only the keywords the
+// environment schema uses are checked; others are ignored. An error is
+// returned when schema or data is not JSON at all.
+func CheckSchemaOf(schema, data []byte) ([]types.ValidationIssue, error) {
+	var root schemaNode
+	if err := json.Unmarshal(schema, &root); err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	return checkAgainst(&root, data)
+}
+
+func checkAgainst(root *schemaNode, data []byte) ([]types.ValidationIssue, error) {
+	dec := json.NewDecoder(bytes.NewReader(bytes.TrimPrefix(data, utf8BOM)))
+	dec.UseNumber()
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	if dec.More() {
+		return nil, fmt.Errorf("invalid JSON: unexpected data after the top-level value at offset %d", dec.InputOffset())
+	}
+
+	c := &schemaChecker{root: root}
+	c.check(root, doc, "")
+	return c.issues, nil
+}
+
+// Validate checks data against the environment schema and, when it decodes
+// as an environment, against the rules of types.EnvironmentData.Validate.
+// The file is read as written: nothing is repaired and the summary is not
+// refreshed. An error is returned only when data is not JSON at all.
+func Validate(data []byte) (errs, warnings []types.ValidationIssue, err error) {
+	errs, err = CheckSchema(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	// A file violating the schema may not decode into typed data
+	var env types.EnvironmentData
+	if json.Unmarshal(bytes.TrimPrefix(data, utf8BOM), &env) == nil {
+		warnings = env.Validate()
+	}
+	return errs, warnings, nil
+}
+
+type schemaChecker struct {
+	root   *schemaNode
+	issues []types.ValidationIssue
+}
+
+func (c *schemaChecker) fail(path, format string, args ...interface{}) {
+	c.issues = append(c.issues, types.ValidationIssue{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+func (c *schemaChecker) check(node *schemaNode, value interface{}, path string) {
+	if node.Ref != "" {
+		name := strings.TrimPrefix(node.Ref, "#/$defs/")
+		node = c.root.Defs[name]
+	}
+
+	if node.Type != "" && !hasType(value, node.Type) {
+		c.fail(path, "expected %s but got %s", node.Type, typeName(value))
+		return
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for _, name := range node.Required {
+			if _, ok := v[name]; !ok {
+				c.fail(path+types.JSONPointer(name), "required property is missing")
+			}
+		}
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			child := path + types.JSONPointer(key)
+			if prop, ok := node.Properties[key]; ok {
+				c.check(prop, v[key], child)
+				continue
+			}
+			switch extra := node.AdditionalProperties; {
+			case extra == nil:
+			case extra.forbidden:
+				c.fail(child, "unknown property")
+			default:
+				c.check(extra.schema, v[key], child)
+			}
+		}
+	case []interface{}:
+		if node.Items != nil {
+			for i, item := range v {
+				c.check(node.Items, item, fmt.Sprintf("%s/%d", path, i))
+			}
+		}
+	case string:
+		if node.MinLength != nil && utf8.RuneCountInString(v) < *node.MinLength {
+			c.fail(path, "must not be empty")
+		}
+		if len(node.Enum) > 0 && !slices.Contains(node.Enum, v) {
+			c.fail(path, "%q is not one of %s", v, strings.Join(node.Enum, ", "))
+		}
+		if node.Format == "date-time" {
+			if _, err := time.Parse(time.RFC3339, v); err != nil {
+				c.fail(path, "%q is not an RFC 3339 date-time", v)
+			}
+		}
+	case json.Number:
+		if f, err := v.Float64(); err == nil && node.Minimum != nil && f < *node.Minimum {
+			c.fail(path, "must be at least %v", *node.Minimum)
+		}
+	}
+}
+
+// hasType reports whether value is of the JSON Schema type t
+func hasType(value interface{}, t string) bool {
+	switch t {
+	case "integer":
+		n, ok := value.(json.Number)
+		if !ok {
+			return false
+		}
+		_, err := n.Int64()
+		return err == nil
+	case "number":
+		_, ok := value.(json.Number)
+		return ok
+	default:
+		return typeName(value) == t
+	}
+}
+
+// typeName returns the JSON Schema type name of a decoded value
+func typeName(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case json.Number:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	default:
+		return "object"
+	}
+}
@@ -0,0 +1,180 @@
+package envfile
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+// TestSchemaMatchesTypes fails when a field is added to or removed from the
+// environment types without updating schema.json
+func TestSchemaMatchesTypes(t *testing.T) {
+	checkSchemaFields(t, environmentSchema, reflect.TypeOf(types.EnvironmentData{}), "")
+}
+
+func checkSchemaFields(t *testing.T, node *schemaNode, typ reflect.Type, path string) {
+	t.Helper()
+	for typ.Kind() == reflect.Ptr || typ.Kind() == reflect.Slice {
+		typ = typ.Elem()
+		if node.Items != nil {
+			node = node.Items
+		}
+	}
+	if typ.Kind() != reflect.Struct || typ == reflect.TypeOf(time.Time{}) {
+		return
+	}
+
+	fields := make(map[string]bool)
+	for i := 0; i < typ.NumField(); i++ {
+		name, _, _ := strings.Cut(typ.Field(i).Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		fields[name] = true
+		prop, ok := node.Properties[name]
+		if !ok {
+			t.Errorf("expected schema.json to describe %s/%s but it does not", path, name)
+			continue
+		}
+		checkSchemaFields(t, prop, typ.Field(i).Type, path+"/"+name)
+	}
+	for name := range node.Properties {
+		if !fields[name] {
+			t.Errorf("expected %s/%s in schema.json to be a field of %s but it is not", path, name, typ.Name())
+		}
+	}
+}
+
+func TestSchemaAcceptsExports(t *testing.T) {
+	env := types.EnvironmentData{
+		SchemaVersion:       types.CurrentSchemaVersion,
+		StackmatchVersion:   "0.3.0",
+		ScanDate:            time.Date(2026, 10, 16, 9, 12, 44, 0, time.UTC),
+		System:              types.SystemInfo{OS: "darwin", Arch: "arm64", Shell: "/bin/zsh", Hostname: "mbp"},
+		Tools:               map[string]string{"Git": "2.45.0"},
+		PackageManagers:     map[string]string{"Homebrew": "4.3.1"},
+		CodeEditors:         map[string]string{"VS Code": "1.89.1"},
+		ConfiguredLanguages: map[string]string{"Go": "1.22.3"},
+		ConfigFiles:         []string{"/Users/dev/.gitconfig"},
+		ToolIDs:             map[string]string{"VS Code": "vscode"},
+		ToolSources:         map[string]string{"Go": "dnf-module:go-toolset:rhel8"},
+		BrokenTools:         map[string]types.ToolFailure{"Homebrew": {ExitStatus: 1, Output: "Error: Homebrew must be run under Ruby 3.3!"}},
+		Requirements:        map[string]types.Requirement{"Git": types.Required, "VS Code": types.Optional},
+		Project:             &types.ProjectInfo{Path: "/src/app", BuildWrappers: map[string]string{"Gradle": "8.7"}},
+		Homebrew:            []types.HomebrewInstall{{Prefix: "/opt/homebrew", Arch: "arm64", Primary: true}},
+		Warnings:            []string{"skipped ~/.config: permission denied"},
+		Extensions:          map[string]map[string]string{"databases": {"Redis": "7.2.4"}},
+	}
+	types.RefreshSummary(&env)
+	exported, err := json.MarshalIndent(env, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	documents := map[string][]byte{"export": exported}
+	fixtures := []string{
+		filepath.Join("..", "types", "testdata", "env_v1.json"),
+		filepath.Join("..", "types", "testdata", "env_v2.json"),
+		filepath.Join("testdata", "clean.json"),
+		filepath.Join("testdata", "bom.json"),
+	}
+	for _, fixture := range fixtures {
+		data, err := os.ReadFile(fixture)
+		if err != nil {
+			t.Fatal(err)
+		}
+		documents[fixture] = data
+	}
+
+	for name, data := range documents {
+		t.Run(name, func(t *testing.T) {
+			issues, err := CheckSchema(data)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(issues) != 0 {
+				t.Errorf("expected no schema violations but got %v", issues)
+			}
+		})
+	}
+}
+
+func TestCheckSchema(t *testing.T) {
+	testCases := []struct {
+		name     string
+		doc      string
+		expected []types.ValidationIssue
+	}{
+		{
+			name:     "Missing version",
+			doc:      `{"tools": {"Git": "2.43.0"}}`,
+			expected: []types.ValidationIssue{{Path: "/stackmatch_version", Message: "required property is missing"}},
+		},
+		{
+			name:     "Version as a number",
+			doc:      `{"stackmatch_version": "0.3.0", "tools": {"Git": 2.43}}`,
+			expected: []types.ValidationIssue{{Path: "/tools/Git", Message: "expected string but got number"}},
+		},
+		{
+			name:     "Escaped pointer tokens",
+			doc:      `{"stackmatch_version": "0.3.0", "tool_ids": {"a/b~c": ""}}`,
+			expected: []types.ValidationIssue{{Path: "/tool_ids/a~1b~0c", Message: "must not be empty"}},
+		},
+		{
+			name: "Nested objects and arrays",
+			doc: `{"stackmatch_version": "0.3.0", "system": {"os": "linux", "kernel": "6.8"},
+				"homebrew": [{"prefix": "/usr/local"}, {"arch": "arm64"}]}`,
+			expected: []types.ValidationIssue{
+				{Path: "/homebrew/1/prefix", Message: "required property is missing"},
+				{Path: "/system/arch", Message: "required property is missing"},
+				{Path: "/system/kernel", Message: "unknown property"},
+			},
+		},
+		{
+			name: "Formats and ranges",
+			doc:  `{"stackmatch_version": "0.3.0", "schema_version": 1.5, "scan_date": "yesterday", "summary": {"counts": {"tools": -1}, "os": "linux", "fingerprint": ""}}`,
+			expected: []types.ValidationIssue{
+				{Path: "/scan_date", Message: `"yesterday" is not an RFC 3339 date-time`},
+				{Path: "/schema_version", Message: "expected integer but got number"},
+				{Path: "/summary/counts/tools", Message: "must be at least 0"},
+			},
+		},
+		{
+			name:     "Unknown requirement",
+			doc:      `{"stackmatch_version": "0.3.0", "requirements": {"Git": "required", "Vim": "nice to have"}}`,
+			expected: []types.ValidationIssue{{Path: "/requirements/Vim", Message: `"nice to have" is not one of required, optional`}},
+		},
+		{
+			name:     "Unknown category that is not a map of versions",
+			doc:      `{"stackmatch_version": "0.3.0", "databases": ["Redis"]}`,
+			expected: []types.ValidationIssue{{Path: "/databases", Message: "expected object but got array"}},
+		},
+		{
+			name:     "Not an object",
+			doc:      `[]`,
+			expected: []types.ValidationIssue{{Path: "", Message: "expected object but got array"}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			issues, err := CheckSchema([]byte(tc.doc))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(issues, tc.expected) {
+				t.Errorf("expected %v but got %v", tc.expected, issues)
+			}
+		})
+	}
+
+	if _, err := CheckSchema([]byte(`{"stackmatch_version": "0.3.0"} trailing`)); err == nil {
+		t.Error("expected an error for text after the JSON but got none")
+	}
+}
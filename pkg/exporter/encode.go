@@ -0,0 +1,184 @@
+package exporter
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Formats the environment document itself can be written in, besides
+// FormatJSON
+const (
+	FormatYAML = "yaml"
+	FormatTOML = "toml"
+)
+
+// DocumentFormats lists the formats that hold the whole environment, which
+// scan prints and import reads back
+var DocumentFormats = []string{FormatJSON, FormatYAML, FormatTOML}
+
+// Encode returns data in format, one of DocumentFormats. YAML and TOML
+// documents have the keys of the JSON one, so they convert back to it
+// without losing anything. Keys are sorted in both.
+func Encode(data types.EnvironmentData, format string) ([]byte, error) {
+	jsonData, err := json.MarshalIndent(data, "", "  ")
+	if err != nil || format == FormatJSON {
+		return jsonData, err
+	}
+
+	// Go through the JSON document so its keys, and the way each type
+	// writes itself, are kept
+	decoder := json.NewDecoder(bytes.NewReader(jsonData))
+	decoder.UseNumber()
+	var document any
+	if err := decoder.Decode(&document); err != nil {
+		return nil, err
+	}
+	document = plainNumbers(document)
+
+	switch format {
+	case FormatYAML:
+		var buf bytes.Buffer
+		encoder := yaml.NewEncoder(&buf)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(document); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), encoder.Close()
+	case FormatTOML:
+		// TOML has no null; a missing key decodes to the same zero value
+		return toml.Marshal(withoutNulls(document))
+	default:
+		return nil, fmt.Errorf("unknown format %q; use one of %s", format, strings.Join(DocumentFormats, ", "))
+	}
+}
+
+// Write encodes data in format, one of DocumentFormats, to filename
+func Write(data types.EnvironmentData, filename, format string) error {
+	encoded, err := Encode(data, format)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, encoded, 0644)
+}
+
+// plainNumbers replaces the json.Numbers in document with int64 or float64,
+// which YAML and TOML write as numbers
+func plainNumbers(document any) any {
+	switch v := document.(type) {
+	case map[string]any:
+		for key, value := range v {
+			v[key] = plainNumbers(value)
+		}
+	case []any:
+		for i, value := range v {
+			v[i] = plainNumbers(value)
+		}
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+		if f, err := v.Float64(); err == nil {
+			return f
+		}
+		return v.String()
+	}
+	return document
+}
+
+// withoutNulls drops the null values of the objects in document
+func withoutNulls(document any) any {
+	switch v := document.(type) {
+	case map[string]any:
+		for key, value := range v {
+			if value == nil {
+				delete(v, key)
+				continue
+			}
+			v[key] = withoutNulls(value)
+		}
+	case []any:
+		for i, value := range v {
+			v[i] = withoutNulls(value)
+		}
+	}
+	return document
+}
+
+var (
+	// tomlLine matches the first line of a TOML document: a table header
+	// or a key assignment
+	tomlLine = regexp.MustCompile(`^(\[[^\[\]]+\]|[A-Za-z0-9_."-]+\s*=)`)
+	// yamlLine matches the first line of a YAML document: a document start
+	// or a mapping key
+	yamlLine = regexp.MustCompile(`^(---|[A-Za-z0-9_."-]+:(\s|$))`)
+)
+
+// DetectFormat returns the format, one of DocumentFormats, of an
+// environment document read from path: the one its extension names, or
+// the one its first line looks like. Content with a line starting an
+// object is JSON, even with text in front of it such as captured log
+// lines, and so is anything else.
+func DetectFormat(path string, content []byte) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return FormatJSON
+	case ".yaml", ".yml":
+		return FormatYAML
+	case ".toml":
+		return FormatTOML
+	}
+
+	var first string
+	scanner := bufio.NewScanner(bytes.NewReader(bytes.TrimPrefix(content, []byte{0xEF, 0xBB, 0xBF})))
+	scanner.Buffer(nil, len(content)+1)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "{") {
+			return FormatJSON
+		}
+		if first == "" && line != "" && !strings.HasPrefix(line, "#") {
+			first = line
+		}
+	}
+	switch {
+	case tomlLine.MatchString(first):
+		return FormatTOML
+	case yamlLine.MatchString(first):
+		return FormatYAML
+	}
+	return FormatJSON
+}
+
+// ToJSON converts an environment document in format, one of
+// DocumentFormats, to the JSON document it was encoded from
+func ToJSON(content []byte, format string) ([]byte, error) {
+	var document any
+	switch format {
+	case FormatJSON:
+		return content, nil
+	case FormatYAML:
+		if err := yaml.Unmarshal(content, &document); err != nil {
+			return nil, fmt.Errorf("invalid YAML: %w", err)
+		}
+	case FormatTOML:
+		if err := toml.Unmarshal(content, &document); err != nil {
+			return nil, fmt.Errorf("invalid TOML: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unknown format %q; use one of %s", format, strings.Join(DocumentFormats, ", "))
+	}
+	if _, ok := document.(map[string]any); !ok {
+		return nil, fmt.Errorf("the %s document is not an environment", strings.ToUpper(format))
+	}
+	return json.MarshalIndent(document, "", "  ")
+}
@@ -0,0 +1,143 @@
+package exporter
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/envfile"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+// roundTripEnvironments returns environments whose values YAML and TOML
+// could mistake for other types, and files from other releases
+func roundTripEnvironments(t *testing.T) map[string]types.EnvironmentData {
+	t.Helper()
+	tricky := types.EnvironmentData{
+		StackmatchVersion: "1.20",
+		ScanDate:          time.Date(2026, 10, 16, 9, 30, 0, 0, time.UTC),
+		System:            types.SystemInfo{OS: "linux", Arch: "amd64", Shell: "/bin/zsh"},
+		Tools: map[string]string{
+			"Git":       "2.40",
+			"Yes":       "yes",
+			"Null":      "null",
+			"True":      "true",
+			"Tilde":     "~",
+			"Empty":     "",
+			"Date":      "2026-10-16",
+			"VS Code":   "1.90.2",
+			"node.js@x": "# not a comment",
+		},
+		ConfiguredLanguages: map[string]string{"Go": "1.22.0", "Python": "3.10"},
+		ConfigFiles:         []string{"/home/dev/.bashrc", "C:\\Users\\dev\\.gitconfig"},
+		ToolSources:         map[string]string{"Git": "package-db:dpkg:git"},
+	}
+	trickyJSON, err := Encode(tricky, FormatJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Read the way import reads them, which fills in the summary
+	envs := make(map[string]types.EnvironmentData)
+	for _, name := range []string{"Tricky values", "env_v2.json", "env_extensions.json"} {
+		data := trickyJSON
+		if name != "Tricky values" {
+			if data, err = os.ReadFile(filepath.Join("..", "types", "testdata", name)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		env, err := envfile.Parse(data, envfile.Options{})
+		if err != nil {
+			t.Fatalf("expected %s to be accepted but got %v", name, err)
+		}
+		envs[name] = *env
+	}
+	return envs
+}
+
+func TestEncodeRoundTrip(t *testing.T) {
+	for name, env := range roundTripEnvironments(t) {
+		// Every format converts back to the environment, and to the same
+		// document in every other format
+		expected, err := Encode(env, FormatJSON)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, format := range DocumentFormats {
+			t.Run(name+"/"+format, func(t *testing.T) {
+				encoded, err := Encode(env, format)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if detected := DetectFormat("env", encoded); detected != format {
+					t.Errorf("expected the document to be detected as %s but got %s:\n%s", format, detected, encoded)
+				}
+				jsonData, err := ToJSON(encoded, format)
+				if err != nil {
+					t.Fatalf("unexpected error: %v\n%s", err, encoded)
+				}
+				decoded, err := envfile.Parse(jsonData, envfile.Options{})
+				if err != nil {
+					t.Fatalf("unexpected error: %v\n%s", err, jsonData)
+				}
+				if !reflect.DeepEqual(*decoded, env) {
+					t.Errorf("expected %+v but got %+v\n%s", env, *decoded, encoded)
+				}
+
+				for _, other := range DocumentFormats {
+					again, err := Encode(*decoded, other)
+					if err != nil {
+						t.Fatal(err)
+					}
+					if first, _ := Encode(env, other); string(again) != string(first) {
+						t.Errorf("expected %s to convert to the same %s document but got:\n%s\ninstead of:\n%s", format, other, again, first)
+					}
+				}
+				if again, _ := Encode(*decoded, FormatJSON); string(again) != string(expected) {
+					t.Errorf("expected the JSON document back but got:\n%s", again)
+				}
+			})
+		}
+	}
+}
+
+func TestDetectFormat(t *testing.T) {
+	testCases := []struct {
+		name     string
+		path     string
+		content  string
+		expected string
+	}{
+		{name: "JSON extension", path: "env.json", content: "tools: {}", expected: FormatJSON},
+		{name: "YAML extension", path: "env.YML", content: "{}", expected: FormatYAML},
+		{name: "TOML extension", path: "env.toml", content: "{}", expected: FormatTOML},
+		{name: "JSON", path: "env", content: "\n  {\"tools\": {}}", expected: FormatJSON},
+		{name: "Log lines before JSON", path: "env.txt", content: "Warning: Rust is broken\n{\"tools\": {}}\n", expected: FormatJSON},
+		{name: "YAML", path: "env", content: "# exported\nschema_version: 2\ntools:\n  Git: 2.43.0\n", expected: FormatYAML},
+		{name: "YAML document start", path: "-", content: "---\ntools: {}\n", expected: FormatYAML},
+		{name: "TOML key", path: "env", content: "\xEF\xBB\xBFschema_version = 2\n", expected: FormatTOML},
+		{name: "TOML table", path: "env", content: "[tools]\nGit = '2.43.0'\n", expected: FormatTOML},
+		{name: "Empty", path: "env", content: "", expected: FormatJSON},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := DetectFormat(tc.path, []byte(tc.content)); got != tc.expected {
+				t.Errorf("expected %s but got %s", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestToJSONRejectsOtherDocuments(t *testing.T) {
+	for format, content := range map[string]string{
+		FormatYAML: "- git\n- make\n",
+		FormatTOML: "tools = [",
+	} {
+		if _, err := ToJSON([]byte(content), format); err == nil {
+			t.Errorf("expected an error for the %s document %q", format, content)
+		}
+	}
+}
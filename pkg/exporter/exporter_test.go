@@ -0,0 +1,84 @@
+package exporter
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/envfile"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+// TestWriteJSONKeepsUnknownCategories reads a file from a newer release the
+// way import does and writes it back, checking that no category is lost
+func TestWriteJSONKeepsUnknownCategories(t *testing.T) {
+	original, err := os.ReadFile(filepath.Join("..", "types", "testdata", "env_extensions.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	env, err := envfile.Parse(original, envfile.Options{})
+	if err != nil {
+		t.Fatalf("expected the file to be accepted but got %v", err)
+	}
+
+	out := filepath.Join(t.TempDir(), "env.json")
+	if err := WriteJSON(*env, out); err != nil {
+		t.Fatal(err)
+	}
+	written, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var before, after map[string]any
+	if err := json.Unmarshal(original, &before); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(written, &after); err != nil {
+		t.Fatal(err)
+	}
+	// Only categories round-trip; the summary is recomputed on read
+	delete(before, "build_id")
+	delete(after, "summary")
+	if !reflect.DeepEqual(before, after) {
+		t.Errorf("expected the environment to survive import and export unchanged\nbefore: %v\nafter:  %v", before, after)
+	}
+}
+
+// TestWriteJSONKeepsFlatpakAndNix checks that flatpak apps and Nix profile
+// packages are read back by import as they were exported
+func TestWriteJSONKeepsFlatpakAndNix(t *testing.T) {
+	env := types.EnvironmentData{
+		SchemaVersion:     types.CurrentSchemaVersion,
+		StackmatchVersion: "1.0.0",
+		ScanDate:          time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		System:            types.SystemInfo{OS: "linux", Arch: "amd64"},
+		PackageManagers:   map[string]string{"apt": "2.7.14", "flatpak": "1.14.6", "nix": "2.18.1", "nix-env": "2.18.1"},
+		GlobalPackages: map[string]map[string]string{
+			"flatpak": {"org.mozilla.firefox": "125.0.3", "com.spotify.Client": "Installed"},
+			"nix":     {"hello": "2.12.1", "ripgrep": "14.1.0"},
+		},
+	}
+
+	out := filepath.Join(t.TempDir(), "env.json")
+	if err := WriteJSON(env, out); err != nil {
+		t.Fatal(err)
+	}
+	written, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	read, err := envfile.Parse(written, envfile.Options{})
+	if err != nil {
+		t.Fatalf("expected the file to be accepted but got %v", err)
+	}
+	if !reflect.DeepEqual(read.PackageManagers, env.PackageManagers) {
+		t.Errorf("expected package managers %v but got %v", env.PackageManagers, read.PackageManagers)
+	}
+	if !reflect.DeepEqual(read.GlobalPackages, env.GlobalPackages) {
+		t.Errorf("expected global packages %v but got %v", env.GlobalPackages, read.GlobalPackages)
+	}
+}
@@ -0,0 +1,241 @@
+package exporter
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/installer"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+// Formats the environment can be exported in
+const (
+	// FormatJSON is the StackMatch environment file
+	FormatJSON = "json"
+	// FormatWinget is the file 'winget import' installs from
+	FormatWinget = "winget"
+	// FormatChocolatey is the packages.config file 'choco install' installs
+	// from
+	FormatChocolatey = "chocolatey"
+)
+
+// Formats lists the formats the environment can be exported in
+var Formats = []string{FormatJSON, FormatYAML, FormatTOML, FormatWinget, FormatChocolatey}
+
+// SkippedSuffix is added to the name of a winget file for the report of the
+// entries left out of it. JSON has no comments to list them in.
+const SkippedSuffix = ".skipped.txt"
+
+// wingetSchema is the schema of the files 'winget export' writes
+const wingetSchema = "https://aka.ms/winget-packages.schema.2.0.json"
+
+// exactVersion matches versions a native file can pin, unlike "Installed"
+// or a range from a version file
+var exactVersion = regexp.MustCompile(`^\d+(\.\d+)*$`)
+
+// Skipped is an entry a native format has no package for
+type Skipped struct {
+	Category string
+	Name     string
+	Version  string
+	// Reason is why it was left out
+	Reason string
+}
+
+// String describes the entry and why it was left out
+func (s Skipped) String() string {
+	name := s.Name
+	if s.Version != "" && s.Version != "Installed" {
+		name += " " + s.Version
+	}
+	return fmt.Sprintf("%s (%s): %s", name, s.Category, s.Reason)
+}
+
+// nativePackage is a package of a native format, at Version when the
+// environment pins one
+type nativePackage struct {
+	ID      string
+	Version string
+}
+
+// nativePackages translates the languages, tools, package managers and
+// editors of data to packages of pmType through the package mappings, in
+// category and then name order. Entries sharing a package are installed by
+// the first one. Entries with no mapping or no package for pmType are
+// returned as skipped.
+func nativePackages(data types.EnvironmentData, pmType types.PackageManagerType) ([]nativePackage, []Skipped) {
+	categories := []struct {
+		name    string
+		entries map[string]string
+	}{
+		{types.CategoryLanguages, data.ConfiguredLanguages},
+		{types.CategoryTools, data.Tools},
+		{types.CategoryPackageManagers, data.PackageManagers},
+		{types.CategoryEditors, data.CodeEditors},
+	}
+
+	var packages []nativePackage
+	var skipped []Skipped
+	seen := make(map[string]bool)
+	for _, category := range categories {
+		names := make([]string, 0, len(category.entries))
+		for name := range category.entries {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			recorded := category.entries[name]
+			id := data.ToolID(name)
+			if _, ok := installer.LookupMapping(id); !ok {
+				skipped = append(skipped, Skipped{Category: category.name, Name: name, Version: recorded, Reason: "no package mapping"})
+				continue
+			}
+			pkg, constraint, err := installer.ResolvePackage(id, pmType, recorded)
+			if err != nil {
+				reason := fmt.Sprintf("no %s package", installer.GetPackageManagerName(pmType))
+				if _, ok := err.(*installer.VersionResolutionError); ok {
+					reason = err.Error()
+				}
+				skipped = append(skipped, Skipped{Category: category.name, Name: name, Version: recorded, Reason: reason})
+				continue
+			}
+			if seen[pkg] {
+				continue
+			}
+			seen[pkg] = true
+
+			version := recorded
+			if installer.MapsVersions(id, pmType) {
+				version = constraint.Version
+			}
+			if !exactVersion.MatchString(version) {
+				version = ""
+			}
+			packages = append(packages, nativePackage{ID: pkg, Version: version})
+		}
+	}
+	return packages, skipped
+}
+
+// wingetFile is the file 'winget export' writes and 'winget import' reads
+type wingetFile struct {
+	Schema        string         `json:"$schema"`
+	CreationDate  string         `json:"CreationDate"`
+	Sources       []wingetSource `json:"Sources"`
+	WinGetVersion string         `json:"WinGetVersion"`
+}
+
+type wingetSource struct {
+	Packages      []wingetPackage     `json:"Packages"`
+	SourceDetails wingetSourceDetails `json:"SourceDetails"`
+}
+
+type wingetPackage struct {
+	PackageIdentifier string `json:"PackageIdentifier"`
+	Version           string `json:"Version,omitempty"`
+}
+
+type wingetSourceDetails struct {
+	Argument   string `json:"Argument"`
+	Identifier string `json:"Identifier"`
+	Name       string `json:"Name"`
+	Type       string `json:"Type"`
+}
+
+// WriteWinget writes the packages of data as a file for 'winget import',
+// from the winget community repository. The entries with no winget package
+// are listed in a report next to it, named with SkippedSuffix, and returned.
+func WriteWinget(data types.EnvironmentData, filename string) ([]Skipped, error) {
+	packages, skipped := nativePackages(data, types.TypeWinget)
+	source := wingetSource{
+		Packages: []wingetPackage{},
+		SourceDetails: wingetSourceDetails{
+			Argument:   "https://cdn.winget.microsoft.com/cache",
+			Identifier: "Microsoft.Winget.Source_8wekyb3d8bbwe",
+			Name:       "winget",
+			Type:       "Microsoft.PreIndexed.Package",
+		},
+	}
+	for _, pkg := range packages {
+		source.Packages = append(source.Packages, wingetPackage{PackageIdentifier: pkg.ID, Version: pkg.Version})
+	}
+	file := wingetFile{
+		Schema:        wingetSchema,
+		CreationDate:  data.ScanDate.UTC().Format(time.RFC3339),
+		Sources:       []wingetSource{source},
+		WinGetVersion: data.PackageManagers["Winget"],
+	}
+	content, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filename, append(content, '\n'), 0644); err != nil {
+		return nil, err
+	}
+
+	report := filename + SkippedSuffix
+	if len(skipped) == 0 {
+		// A report left by an earlier export would no longer be true
+		if err := os.Remove(report); err != nil && !os.IsNotExist(err) {
+			return skipped, err
+		}
+		return skipped, nil
+	}
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "Entries left out of %s, which winget has no package for:\n", filename)
+	for _, s := range skipped {
+		fmt.Fprintf(&buf, "  %s\n", s)
+	}
+	return skipped, os.WriteFile(report, buf.Bytes(), 0644)
+}
+
+// WriteChocolatey writes the packages of data as a packages.config file for
+// 'choco install'. The entries with no Chocolatey package are listed in
+// comments at the end of the file, and returned.
+func WriteChocolatey(data types.EnvironmentData, filename string) ([]Skipped, error) {
+	packages, skipped := nativePackages(data, types.TypeChocolatey)
+
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	buf.WriteString("<packages>\n")
+	for _, pkg := range packages {
+		fmt.Fprintf(&buf, `  <package id="%s"`, xmlAttr(pkg.ID))
+		if pkg.Version != "" {
+			fmt.Fprintf(&buf, ` version="%s"`, xmlAttr(pkg.Version))
+		}
+		buf.WriteString(" />\n")
+	}
+	if len(skipped) > 0 {
+		buf.WriteString("  <!-- Left out, with no Chocolatey package:\n")
+		for _, s := range skipped {
+			fmt.Fprintf(&buf, "       %s\n", xmlComment(s.String()))
+		}
+		buf.WriteString("  -->\n")
+	}
+	buf.WriteString("</packages>\n")
+	return skipped, os.WriteFile(filename, buf.Bytes(), 0644)
+}
+
+// xmlAttr escapes text for an XML attribute value
+func xmlAttr(text string) string {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(text))
+	return buf.String()
+}
+
+// xmlComment makes text safe inside an XML comment, which can't contain
+// "--"
+func xmlComment(text string) string {
+	for strings.Contains(text, "--") {
+		text = strings.ReplaceAll(text, "--", "- -")
+	}
+	return text
+}
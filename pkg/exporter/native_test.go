@@ -0,0 +1,193 @@
+package exporter
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/envfile"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+var updateGolden = flag.Bool("update", false, "Rewrite the golden files in testdata")
+
+// nativeEnvironment has entries with a package on both Windows package
+// managers, on only one, and on neither
+func nativeEnvironment() types.EnvironmentData {
+	return types.EnvironmentData{
+		SchemaVersion:     types.CurrentSchemaVersion,
+		StackmatchVersion: "1.0.0",
+		ScanDate:          time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		System:            types.SystemInfo{OS: "windows", Arch: "amd64"},
+		ConfiguredLanguages: map[string]string{
+			"Go":      "1.22.3",
+			"Node.js": "20.11.0",
+			"Python":  "3.12.1",
+			"Zig":     "0.12.0",
+		},
+		Tools: map[string]string{
+			"Git":    "2.43.0",
+			"Docker": "Installed",
+			"Frob":   "1.0",
+		},
+		PackageManagers: map[string]string{
+			"Winget": "1.7.10861",
+			"npm":    "10.2.4",
+		},
+		CodeEditors: map[string]string{"VS Code": "1.85.0"},
+	}
+}
+
+func TestWriteNative(t *testing.T) {
+	testCases := []struct {
+		name  string
+		write func(types.EnvironmentData, string) ([]Skipped, error)
+	}{
+		{name: "winget.json", write: WriteWinget},
+		{name: "packages.config", write: WriteChocolatey},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			out := filepath.Join(t.TempDir(), tc.name)
+			skipped, err := tc.write(nativeEnvironment(), out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(skipped) == 0 {
+				t.Error("expected the entries with no package to be skipped but got none")
+			}
+			written, err := os.ReadFile(out)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			golden := filepath.Join("testdata", "native", tc.name+".golden")
+			if *updateGolden {
+				if err := os.WriteFile(golden, written, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			expected, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("could not read %s (run with -update to create it): %v", golden, err)
+			}
+			if string(written) != string(expected) {
+				t.Errorf("expected:\n%s\nbut got:\n%s", expected, written)
+			}
+		})
+	}
+}
+
+// TestWriteWingetMatchesSchema validates the winget file against the schema
+// of the files 'winget import' reads
+func TestWriteWingetMatchesSchema(t *testing.T) {
+	schema, err := os.ReadFile(filepath.Join("testdata", "native", "winget-packages.schema.2.0.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	envs := map[string]types.EnvironmentData{
+		"full":  nativeEnvironment(),
+		"empty": {ScanDate: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)},
+	}
+	for name, env := range envs {
+		t.Run(name, func(t *testing.T) {
+			out := filepath.Join(t.TempDir(), "winget.json")
+			if _, err := WriteWinget(env, out); err != nil {
+				t.Fatal(err)
+			}
+			written, err := os.ReadFile(out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			issues, err := envfile.CheckSchemaOf(schema, written)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, issue := range issues {
+				t.Errorf("expected the file to match the winget schema but got %s: %s", issue.Path, issue.Message)
+			}
+		})
+	}
+}
+
+// TestWriteWingetReport checks that the skipped entries are reported next to
+// the winget file, and that a report left by an earlier export is removed
+// once nothing is skipped
+func TestWriteWingetReport(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "winget.json")
+	skipped, err := WriteWinget(nativeEnvironment(), out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err := os.ReadFile(out + SkippedSuffix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range skipped {
+		if !strings.Contains(string(report), s.String()) {
+			t.Errorf("expected the report to list %q but got:\n%s", s, report)
+		}
+	}
+
+	env := types.EnvironmentData{Tools: map[string]string{"Git": "2.43.0"}}
+	if _, err := WriteWinget(env, out); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(out + SkippedSuffix); !os.IsNotExist(err) {
+		t.Errorf("expected the report to be removed but got %v", err)
+	}
+}
+
+// TestWriteChocolateyIsWellFormed reads the packages.config file back with
+// an XML decoder, the way Chocolatey does
+func TestWriteChocolateyIsWellFormed(t *testing.T) {
+	env := nativeEnvironment()
+	// Comments can't contain "--"; the skipped entries are written in one
+	env.Tools["frob--legacy"] = "1.0--beta"
+	out := filepath.Join(t.TempDir(), "packages.config")
+	if _, err := WriteChocolatey(env, out); err != nil {
+		t.Fatal(err)
+	}
+	written, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dec := xml.NewDecoder(bytes.NewReader(written))
+	for {
+		_, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("expected well-formed XML but got %v:\n%s", err, written)
+		}
+	}
+
+	var config struct {
+		XMLName  xml.Name `xml:"packages"`
+		Packages []struct {
+			ID      string `xml:"id,attr"`
+			Version string `xml:"version,attr"`
+		} `xml:"package"`
+	}
+	if err := xml.Unmarshal(written, &config); err != nil {
+		t.Fatal(err)
+	}
+	for _, pkg := range config.Packages {
+		if pkg.ID == "" {
+			t.Errorf("expected every package to have an id but got %+v", pkg)
+		}
+	}
+	if len(config.Packages) == 0 {
+		t.Error("expected packages but got none")
+	}
+}
@@ -0,0 +1,222 @@
+// Package gitconfig reads the URL rewrites, credential helpers and a safe
+// subset of the other settings of the current user's global git config, and
+// adds missing URL rewrites to it. Stored credentials are never read.
+package gitconfig
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/runner"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+// ReadGlobal returns the output of 'git config --global --list', which is
+// empty when the user has no global git config
+func ReadGlobal(ctx context.Context, r runner.Runner) (string, error) {
+	stdout, stderr, err := r.Output(ctx, "git", "config", "--global", "--list")
+	if err != nil {
+		if strings.Contains(stderr, "No such file or directory") {
+			return "", nil
+		}
+		message := strings.TrimSpace(stderr)
+		if i := strings.IndexByte(message, '\n'); i >= 0 {
+			message = message[:i]
+		}
+		if message == "" {
+			message = err.Error()
+		}
+		return "", fmt.Errorf("could not read your global git config: %s", message)
+	}
+	return stdout, nil
+}
+
+// settingKeys are the settings recorded in GitConfig.Settings besides
+// aliases, keyed by the lower case name git lists them under
+var settingKeys = map[string]string{
+	"user.name":          "user.name",
+	"init.defaultbranch": "init.defaultBranch",
+	"core.editor":        "core.editor",
+	"pull.rebase":        "pull.rebase",
+}
+
+// Parse returns the URL rewrites, credential helpers and settings listed in
+// the output of 'git config --list', or nil when there are none. An empty
+// helper resets the helpers set before it for the same URL, as it does in
+// git, and the last value of a setting wins.
+func Parse(list string) *types.GitConfig {
+	config := &types.GitConfig{}
+	for _, line := range strings.Split(list, "\n") {
+		key, value, ok := strings.Cut(strings.TrimRight(line, "\r"), "=")
+		if !ok {
+			continue
+		}
+		// git lowercases section and variable names but not the URL between them
+		lower := strings.ToLower(key)
+		switch {
+		case strings.HasPrefix(lower, "url.") && strings.HasSuffix(lower, ".pushinsteadof"):
+			base := key[len("url.") : len(key)-len(".pushinsteadof")]
+			config.URLRewrites = append(config.URLRewrites, types.URLRewrite{Base: base, InsteadOf: value, Push: true})
+		case strings.HasPrefix(lower, "url.") && strings.HasSuffix(lower, ".insteadof"):
+			base := key[len("url.") : len(key)-len(".insteadof")]
+			config.URLRewrites = append(config.URLRewrites, types.URLRewrite{Base: base, InsteadOf: value})
+		case lower == "credential.helper" || strings.HasPrefix(lower, "credential.") && strings.HasSuffix(lower, ".helper"):
+			url := strings.TrimSuffix(strings.TrimPrefix(key[:len(key)-len("helper")], "credential."), ".")
+			if value == "" {
+				config.CredentialHelpers = resetHelpers(config.CredentialHelpers, url)
+				continue
+			}
+			config.CredentialHelpers = append(config.CredentialHelpers, types.CredentialHelper{URL: url, Helper: value})
+		case settingKeys[lower] != "" || strings.HasPrefix(lower, "alias."):
+			if config.Settings == nil {
+				config.Settings = make(map[string]string)
+			}
+			config.Settings[cmp.Or(settingKeys[lower], key)] = value
+		}
+	}
+	if len(config.URLRewrites) == 0 && len(config.CredentialHelpers) == 0 && len(config.Settings) == 0 {
+		return nil
+	}
+	return config
+}
+
+// SettingChange is a setting whose value differs on this machine
+type SettingChange struct {
+	Key   string
+	Value string
+	// Current is the value this machine has, or "" when it has none
+	Current string
+}
+
+// Compare returns the settings of want that have another value, or none, in
+// have, sorted by key. Settings only found in have are not changes: nothing
+// is unset.
+func Compare(want, have map[string]string) []SettingChange {
+	var changes []SettingChange
+	for _, key := range slices.Sorted(maps.Keys(want)) {
+		if current := have[key]; current != want[key] {
+			changes = append(changes, SettingChange{Key: key, Value: want[key], Current: current})
+		}
+	}
+	return changes
+}
+
+// SetCommand returns the command line that sets key to value in the global
+// git config, with value quoted for a POSIX shell when it needs to be
+func SetCommand(key, value string) string {
+	return "git config --global " + key + " " + shellQuote(value)
+}
+
+// shellQuote quotes s in single quotes unless it is a plain word
+func shellQuote(s string) string {
+	if s != "" && !strings.ContainsAny(s, " \t\n'\"\\$`!*?[]{}()<>|&;#~") {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// resetHelpers drops the helpers set for url
+func resetHelpers(helpers []types.CredentialHelper, url string) []types.CredentialHelper {
+	kept := helpers[:0]
+	for _, h := range helpers {
+		if h.URL != url {
+			kept = append(kept, h)
+		}
+	}
+	return kept
+}
+
+// Missing compares rewrites with the current user's global git config. It
+// returns the rules the config does not have yet, and those whose prefix the
+// config already rewrites to a different URL, which adding would make
+// ambiguous.
+func Missing(ctx context.Context, r runner.Runner, rewrites []types.URLRewrite) (missing, conflicting []types.URLRewrite, err error) {
+	list, err := ReadGlobal(ctx, r)
+	if err != nil {
+		return nil, nil, err
+	}
+	present := make(map[types.URLRewrite]bool)
+	prefixes := make(map[string]bool)
+	if current := Parse(list); current != nil {
+		for _, rule := range current.URLRewrites {
+			present[rule] = true
+			prefixes[prefixKey(rule)] = true
+		}
+	}
+
+	for _, rule := range rewrites {
+		switch {
+		case present[rule]:
+		case prefixes[prefixKey(rule)]:
+			conflicting = append(conflicting, rule)
+		default:
+			missing = append(missing, rule)
+			present[rule] = true
+		}
+	}
+	return missing, conflicting, nil
+}
+
+// prefixKey identifies the URLs a rule rewrites
+func prefixKey(rule types.URLRewrite) string {
+	return fmt.Sprintf("%t %s", rule.Push, rule.InsteadOf)
+}
+
+// Add adds rule to the current user's global git config, keeping every
+// existing rule
+func Add(ctx context.Context, r runner.Runner, rule types.URLRewrite) error {
+	if output, err := r.CombinedOutput(ctx, "git", "config", "--global", "--add", rule.Key(), rule.InsteadOf); err != nil {
+		return fmt.Errorf("failed to add %s: %w\n%s", rule.Key(), err, output)
+	}
+	return nil
+}
+
+// windowsAbs matches absolute Windows paths, which filepath.IsAbs only
+// recognizes on Windows
+var windowsAbs = regexp.MustCompile(`^[A-Za-z]:[\\/]`)
+
+// HelperPath returns the absolute path of the program a credential helper
+// runs, or "" when the helper is a name git resolves itself (such as
+// "osxkeychain" for git-credential-osxkeychain)
+func HelperPath(helper string) string {
+	command := firstWord(strings.TrimPrefix(strings.TrimSpace(helper), "!"))
+	if filepath.IsAbs(command) || strings.HasPrefix(command, "/") || windowsAbs.MatchString(command) {
+		return command
+	}
+	return ""
+}
+
+// firstWord returns the first shell word of s, removing quotes and
+// backslash-escaped spaces such as those in C:/Program\ Files
+func firstWord(s string) string {
+	s = strings.TrimLeft(s, " \t")
+	var b strings.Builder
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else {
+				b.WriteByte(c)
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '\\' && i+1 < len(s) && s[i+1] == ' ':
+			b.WriteByte(' ')
+			i++
+		case c == ' ' || c == '\t':
+			return b.String()
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,192 @@
+package gitconfig
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/runner/runnertest"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+const listCommand = "git config --global --list"
+
+// readFixture returns the 'git config --list' output of a global config with
+// several rewrite rules and credential helpers
+func readFixture(t *testing.T) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", "global.list"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestParse(t *testing.T) {
+	expected := &types.GitConfig{
+		URLRewrites: []types.URLRewrite{
+			{Base: "git@github.com:", InsteadOf: "https://github.com/"},
+			{Base: "git@github.com:", InsteadOf: "gh:"},
+			{Base: "ssh://git@gitlab.corp.example:2222/", InsteadOf: "https://gitlab.corp.example/"},
+			{Base: "ssh://git@gitlab.corp.example:2222/", InsteadOf: "https://gitlab.com/corp/", Push: true},
+			{Base: "https://goproxy.corp.example/mirror/", InsteadOf: "https://proxy.golang.org/"},
+		},
+		// The empty helper resets the cache helper set before it
+		CredentialHelpers: []types.CredentialHelper{
+			{Helper: "osxkeychain"},
+			{URL: "https://dev.azure.com", Helper: "/usr/local/share/gcm-core/git-credential-manager"},
+		},
+		Settings: map[string]string{
+			"user.name":          "Dev Example",
+			"core.editor":        "nvim",
+			"init.defaultBranch": "main",
+			"pull.rebase":        "true",
+			"alias.co":           "checkout",
+			"alias.lg":           "log --graph --oneline",
+		},
+	}
+
+	got := Parse(readFixture(t))
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %+v but got %+v", expected, got)
+	}
+	if got := Parse("user.email=dev@example.com\ncommit.gpgsign=true\n"); got != nil {
+		t.Errorf("expected no git config without rewrites, helpers or recorded settings but got %+v", got)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	want := map[string]string{"user.name": "Dev Example", "pull.rebase": "true", "alias.co": "checkout"}
+	have := map[string]string{"user.name": "Dev", "alias.co": "checkout", "alias.st": "status"}
+	expected := []SettingChange{
+		{Key: "pull.rebase", Value: "true"},
+		{Key: "user.name", Value: "Dev Example", Current: "Dev"},
+	}
+
+	if got := Compare(want, have); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %+v but got %+v", expected, got)
+	}
+}
+
+func TestSetCommand(t *testing.T) {
+	testCases := []struct {
+		key      string
+		value    string
+		expected string
+	}{
+		{"init.defaultBranch", "main", "git config --global init.defaultBranch main"},
+		{"user.name", "Dev Example", "git config --global user.name 'Dev Example'"},
+		{"alias.last", "log -1 HEAD", "git config --global alias.last 'log -1 HEAD'"},
+		{"alias.who", "!echo it's me", `git config --global alias.who '!echo it'\''s me'`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.key, func(t *testing.T) {
+			if got := SetCommand(tc.key, tc.value); got != tc.expected {
+				t.Errorf("expected %q but got %q", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestReadGlobal(t *testing.T) {
+	testCases := []struct {
+		name          string
+		response      runnertest.Response
+		expected      string
+		expectedError string
+	}{
+		{
+			name:     "Config",
+			response: runnertest.Response{Output: "core.editor=nvim\n"},
+			expected: "core.editor=nvim\n",
+		},
+		{
+			name:     "No global config",
+			response: runnertest.Response{Stderr: "fatal: unable to read config file '/home/dev/.gitconfig': No such file or directory\n", Err: errors.New("exit status 128")},
+		},
+		{
+			name:          "Invalid config",
+			response:      runnertest.Response{Stderr: "fatal: bad config line 3 in file /home/dev/.gitconfig\n", Err: errors.New("exit status 128")},
+			expectedError: "could not read your global git config: fatal: bad config line 3 in file /home/dev/.gitconfig",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &runnertest.Runner{Responses: map[string]runnertest.Response{listCommand: tc.response}}
+			got, err := ReadGlobal(context.Background(), r)
+			if tc.expectedError != "" {
+				if err == nil || err.Error() != tc.expectedError {
+					t.Errorf("expected error %q but got %v", tc.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tc.expected {
+				t.Errorf("expected %q but got %q", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestMissing(t *testing.T) {
+	wanted := Parse(readFixture(t)).URLRewrites
+	current := "url.git@github.com:.insteadof=https://github.com/\n" +
+		"url.https://gitlab.corp.example/.insteadof=https://gitlab.corp.example/\n"
+	r := &runnertest.Runner{Responses: map[string]runnertest.Response{listCommand: {Output: current}}}
+
+	missing, conflicting, err := Missing(context.Background(), r, wanted)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedMissing := []types.URLRewrite{wanted[1], wanted[3], wanted[4]}
+	if !reflect.DeepEqual(missing, expectedMissing) {
+		t.Errorf("expected missing rules %+v but got %+v", expectedMissing, missing)
+	}
+	expectedConflicting := []types.URLRewrite{wanted[2]}
+	if !reflect.DeepEqual(conflicting, expectedConflicting) {
+		t.Errorf("expected conflicting rules %+v but got %+v", expectedConflicting, conflicting)
+	}
+}
+
+func TestAdd(t *testing.T) {
+	rule := types.URLRewrite{Base: "ssh://git@gitlab.corp.example:2222/", InsteadOf: "https://gitlab.com/corp/", Push: true}
+	command := "git config --global --add url.ssh://git@gitlab.corp.example:2222/.pushInsteadOf https://gitlab.com/corp/"
+	r := &runnertest.Runner{Responses: map[string]runnertest.Response{command: {}}}
+
+	if err := Add(context.Background(), r, rule); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls := r.Calls(); len(calls) != 1 || calls[0] != command {
+		t.Errorf("expected %q to run but got %q", command, calls)
+	}
+}
+
+func TestHelperPath(t *testing.T) {
+	testCases := []struct {
+		helper   string
+		expected string
+	}{
+		{"osxkeychain", ""},
+		{"cache --timeout=3600", ""},
+		{"/usr/local/share/gcm-core/git-credential-manager", "/usr/local/share/gcm-core/git-credential-manager"},
+		{"!/opt/vault/bin/git-credential-vault --role dev", "/opt/vault/bin/git-credential-vault"},
+		{`!f() { echo "password=$(pass git)"; }; f`, ""},
+		{`C:/Program\ Files/Git/mingw64/bin/git-credential-manager.exe`, "C:/Program Files/Git/mingw64/bin/git-credential-manager.exe"},
+		{`"C:\Program Files\Git\mingw64\bin\git-credential-manager.exe" --no-ui`, `C:\Program Files\Git\mingw64\bin\git-credential-manager.exe`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.helper, func(t *testing.T) {
+			if got := HelperPath(tc.helper); got != tc.expected {
+				t.Errorf("expected %q but got %q", tc.expected, got)
+			}
+		})
+	}
+}
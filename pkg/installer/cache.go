@@ -0,0 +1,143 @@
+package installer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/config"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+// DefaultCacheTTL is how long the metadata of the detected package manager
+// is reused before it is probed again
+const DefaultCacheTTL = 10 * time.Minute
+
+// MetadataCache keeps the metadata of the detected package manager on disk,
+// so that the commands of a session don't each detect it again. Detection
+// only records the type; the version is probed and added the first time
+// Metadata is asked for it.
+type MetadataCache struct {
+	// Path is the cache file, normally config.PackageManagerCacheFile
+	Path string
+	// TTL is how long an entry is fresh (default DefaultCacheTTL)
+	TTL time.Duration
+	// Refresh ignores the cached entry, probing again and replacing it, as
+	// --no-cache does
+	Refresh bool
+
+	now func() time.Time
+}
+
+// Cache is the cache DetectPackageManager consults. It is nil, and nothing
+// is cached, unless the CLI sets it.
+var Cache *MetadataCache
+
+// NewMetadataCache returns a cache kept in path with the default TTL
+func NewMetadataCache(path string) *MetadataCache {
+	return &MetadataCache{Path: path, TTL: DefaultCacheTTL}
+}
+
+// Load returns the cached metadata while it is fresh, or nil when it is
+// missing, stale, unreadable or Refresh is set
+func (c *MetadataCache) Load() *types.PackageManagerMetadata {
+	if c.Refresh {
+		return nil
+	}
+	data, err := os.ReadFile(c.Path)
+	if err != nil {
+		return nil
+	}
+	var metadata types.PackageManagerMetadata
+	if err := json.Unmarshal(data, &metadata); err != nil || metadata.Type == "" {
+		return nil
+	}
+	ttl := c.TTL
+	if ttl <= 0 {
+		ttl = DefaultCacheTTL
+	}
+	if age := c.clock().Sub(metadata.VerifiedAt); age < 0 || age >= ttl {
+		return nil
+	}
+	return &metadata
+}
+
+// Store replaces the cached metadata
+func (c *MetadataCache) Store(metadata types.PackageManagerMetadata) error {
+	data, err := json.MarshalIndent(metadata, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := config.WritePrivateFile(c.Path, append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write package manager cache: %w", err)
+	}
+	return nil
+}
+
+// Invalidate deletes the cached metadata, so that the next command probes
+// the package manager again
+func (c *MetadataCache) Invalidate() error {
+	if err := os.Remove(c.Path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to invalidate package manager cache: %w", err)
+	}
+	return nil
+}
+
+// clock returns the current time, which tests replace
+func (c *MetadataCache) clock() time.Time {
+	if c.now != nil {
+		return c.now()
+	}
+	return time.Now()
+}
+
+// Metadata returns the metadata of manager: the cached entry when it is
+// fresh and describes manager, or else what manager reports, which is then
+// cached. Without a cache it only reports the type.
+func Metadata(ctx context.Context, manager Installer) types.PackageManagerMetadata {
+	var cached *types.PackageManagerMetadata
+	if Cache != nil {
+		if cached = Cache.Load(); cached != nil && cached.Type != manager.Type() {
+			cached = nil
+		}
+	}
+	// Entries stored by detection have no executable until described
+	if cached != nil && cached.Executable != "" {
+		return *cached
+	}
+	describer, ok := manager.(types.MetadataDescriber)
+	if !ok {
+		if cached != nil {
+			return *cached
+		}
+		return types.PackageManagerMetadata{Type: manager.Type(), VerifiedAt: time.Now().UTC()}
+	}
+	metadata := describer.Describe(ctx)
+	if Cache != nil {
+		// A cache that can't be written costs the next command a probe
+		_ = Cache.Store(metadata)
+	}
+	return metadata
+}
+
+// InvalidateMetadata drops the cached metadata, if there is a cache, after
+// something that may change which package manager is found or its version
+func InvalidateMetadata() error {
+	if Cache == nil {
+		return nil
+	}
+	return Cache.Invalidate()
+}
+
+// UpdatePackageManager updates manager itself and invalidates the cached
+// metadata, whose version is now out of date
+func UpdatePackageManager(ctx context.Context, manager Installer) error {
+	err := manager.UpdatePackageManager(ctx)
+	if invalidateErr := InvalidateMetadata(); invalidateErr != nil && err == nil {
+		err = invalidateErr
+	}
+	return err
+}
@@ -0,0 +1,184 @@
+package installer
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/installer/package_managers"
+	"github.com/MRQ67/stackmatch-cli/pkg/runner"
+	"github.com/MRQ67/stackmatch-cli/pkg/runner/runnertest"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+// useCache makes DetectPackageManager consult a cache in a temporary
+// directory, with APT and pacman on PATH, until the test ends
+func useCache(t *testing.T) *MetadataCache {
+	previousCache, previousPath := Cache, runner.DefaultPath
+	Cache = NewMetadataCache(filepath.Join(t.TempDir(), "package-manager-cache.json"))
+	runner.DefaultPath = runnertest.NewPath([]string{"/usr/bin"}, "/usr/bin/apt", "/usr/bin/pacman")
+	t.Cleanup(func() {
+		Cache, runner.DefaultPath = previousCache, previousPath
+	})
+	return Cache
+}
+
+// linuxManagers returns the candidates DetectPackageManager checks on Linux
+func linuxManagers() []Installer {
+	return []Installer{package_managers.NewDnf(), package_managers.NewApt(), package_managers.NewPacman()}
+}
+
+// aptProbes answers the commands that describe APT
+func aptProbes() *runnertest.Runner {
+	return &runnertest.Runner{Responses: map[string]runnertest.Response{
+		"apt --version":            {Output: "apt 2.6.1 (amd64)\n"},
+		"apt update":               {},
+		"apt upgrade --assume-yes": {},
+	}}
+}
+
+func TestDetectPackageManagerCaches(t *testing.T) {
+	cache := useCache(t)
+	r := aptProbes()
+	useRunner(t, r)
+	ctx := context.Background()
+
+	manager, err := detectPackageManager(ctx, linuxManagers())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if manager.Type() != types.TypeApt {
+		t.Fatalf("expected APT but got %s", manager.Name())
+	}
+	if calls := r.Calls(); len(calls) != 0 {
+		t.Errorf("expected detection not to describe APT but got %v", calls)
+	}
+	if metadata := cache.Load(); metadata == nil || metadata.Type != types.TypeApt {
+		t.Fatalf("expected APT to be cached but got %+v", metadata)
+	}
+
+	metadata := Metadata(ctx, manager)
+	if metadata.Executable != "/usr/bin/apt" || metadata.Version != "2.6.1" {
+		t.Errorf("expected /usr/bin/apt 2.6.1 but got %s %s", metadata.Executable, metadata.Version)
+	}
+	probes := len(r.Calls())
+	if probes == 0 {
+		t.Fatal("expected APT to be described when its metadata is asked for")
+	}
+	if cached := cache.Load(); cached == nil || cached.Version != "2.6.1" {
+		t.Errorf("expected the version to be cached but got %+v", cached)
+	}
+
+	if _, err := detectPackageManager(ctx, linuxManagers()); err != nil {
+		t.Fatal(err)
+	}
+	if got := Metadata(ctx, manager); got.Version != "2.6.1" {
+		t.Errorf("expected the cached version 2.6.1 but got %q", got.Version)
+	}
+	if calls := r.Calls(); len(calls) != probes {
+		t.Errorf("expected no probes with a fresh cache but got %v", calls[probes:])
+	}
+}
+
+func TestDetectPackageManagerRefreshes(t *testing.T) {
+	tests := []struct {
+		name    string
+		prepare func(t *testing.T, cache *MetadataCache, manager Installer)
+	}{
+		{
+			name: "Stale after the TTL",
+			prepare: func(t *testing.T, cache *MetadataCache, manager Installer) {
+				cache.now = func() time.Time { return time.Now().Add(DefaultCacheTTL + time.Second) }
+			},
+		},
+		{
+			name: "Refresh ignores the cache",
+			prepare: func(t *testing.T, cache *MetadataCache, manager Installer) {
+				cache.Refresh = true
+			},
+		},
+		{
+			name: "Updating the package manager invalidates the cache",
+			prepare: func(t *testing.T, cache *MetadataCache, manager Installer) {
+				if err := UpdatePackageManager(context.Background(), manager); err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+		{
+			name: "Cached package manager was removed",
+			prepare: func(t *testing.T, cache *MetadataCache, manager Installer) {
+				runner.DefaultPath = runnertest.NewPath([]string{"/usr/bin"}, "/usr/bin/pacman")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := useCache(t)
+			r := aptProbes()
+			r.Responses["pacman --version"] = runnertest.Response{Output: "Pacman v6.0.2 - libalpm v13.0.2\n"}
+			useRunner(t, r)
+			ctx := context.Background()
+
+			manager, err := detectPackageManager(ctx, linuxManagers())
+			if err != nil {
+				t.Fatal(err)
+			}
+			Metadata(ctx, manager)
+			tt.prepare(t, cache, manager)
+			probes := len(r.Calls())
+
+			manager, err = detectPackageManager(ctx, linuxManagers())
+			if err != nil {
+				t.Fatal(err)
+			}
+			Metadata(ctx, manager)
+			if calls := r.Calls()[probes:]; !slices.Contains(calls, string(manager.Type())+" --version") {
+				t.Errorf("expected %s to be probed again but got %v", manager.Name(), calls)
+			}
+			if metadata := NewMetadataCache(cache.Path).Load(); metadata == nil || metadata.Type != manager.Type() {
+				t.Errorf("expected the cache to be refreshed for %s but got %+v", manager.Name(), metadata)
+			}
+		})
+	}
+}
+
+func TestDetectPackageManagerFallsBack(t *testing.T) {
+	cache := useCache(t)
+	runner.DefaultPath = runnertest.NewPath([]string{"/usr/bin"}, "/usr/bin/apt", "/usr/bin/pgrep", "/usr/bin/pacman")
+	r := aptProbes()
+	r.Responses["pgrep -a -x dpkg|unattended-upgr"] = runnertest.Response{Output: "812 /usr/bin/python3 /usr/bin/unattended-upgrade\n"}
+	r.Responses["pacman --version"] = runnertest.Response{Output: "Pacman v6.0.2 - libalpm v13.0.2\n"}
+	useRunner(t, r)
+	ctx := context.Background()
+
+	// A package manager cached by an earlier fallback is not used while
+	// the preferred one is on PATH
+	if err := cache.Store(types.PackageManagerMetadata{Type: types.TypePacman, Executable: "/usr/bin/pacman"}); err != nil {
+		t.Fatal(err)
+	}
+	_, err := detectPackageManager(ctx, linuxManagers())
+	var noManager *types.NoPackageManagerError
+	if !errors.As(err, &noManager) {
+		t.Fatalf("expected the locked APT to fail detection but got %v", err)
+	}
+	if metadata := cache.Load(); metadata != nil {
+		t.Errorf("expected the cache to be invalidated but got %+v", metadata)
+	}
+
+	useFallBack(t, true)
+	manager, err := detectPackageManager(ctx, linuxManagers())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if manager.Type() != types.TypePacman {
+		t.Fatalf("expected to fall back to Pacman but got %s", manager.Name())
+	}
+	if metadata := cache.Load(); metadata != nil {
+		t.Errorf("expected a package manager chosen by falling back not to be cached but got %+v", metadata)
+	}
+}
@@ -0,0 +1,286 @@
+package installer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"syscall"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+	"gopkg.in/yaml.v3"
+)
+
+// ConflictRule names packages that can't be installed together, such as
+// docker.io and docker-ce on apt. Any two packages of a plan matching the
+// rule conflict, so only one of them is installed.
+type ConflictRule struct {
+	// Managers limits the rule to these package managers, such as "apt";
+	// empty matches every package manager
+	Managers []types.PackageManagerType `yaml:"managers,omitempty" json:"managers,omitempty"`
+	// Packages are the conflicting package names. A name may hold * and ?
+	// wildcards, such as "openjdk-*-jdk", matching several packages that
+	// conflict with each other as well.
+	Packages []string `yaml:"packages" json:"packages"`
+	// Prefer is the package kept when it is one of the conflicting ones,
+	// which may also hold wildcards. Without one, or when it matches none
+	// or several of them, the user is asked.
+	Prefer string `yaml:"prefer,omitempty" json:"prefer,omitempty"`
+	// Reason says why the packages conflict, for the plan and the report
+	Reason string `yaml:"reason,omitempty" json:"reason,omitempty"`
+}
+
+// builtinConflicts are the conflicts between packages known to break an
+// installation halfway. Rules of the mappings file are checked first.
+var builtinConflicts = []ConflictRule{
+	{
+		Managers: []types.PackageManagerType{types.TypeApt},
+		Packages: []string{"docker.io", "docker-ce", "podman-docker"},
+		Prefer:   "docker-ce",
+		Reason:   "they all provide the docker command and the Docker daemon; Docker's own docker-ce is kept",
+	},
+	{
+		Managers: []types.PackageManagerType{types.TypeApt},
+		Packages: []string{"python2", "python-is-python3"},
+		Reason:   "python-is-python3 makes python run Python 3, which scripts written for python2 break on",
+	},
+	{
+		Managers: []types.PackageManagerType{types.TypeApt},
+		Packages: []string{"python-is-python2", "python-is-python3"},
+		Prefer:   "python-is-python3",
+		Reason:   "both provide the python command; python-is-python3 is kept",
+	},
+	{
+		Managers: []types.PackageManagerType{types.TypeApt},
+		Packages: []string{"default-jdk", "openjdk-*-jdk"},
+		Prefer:   "default-jdk",
+		Reason:   "each JDK package makes its Java the default; the distribution's default-jdk is kept",
+	},
+	{
+		Managers: []types.PackageManagerType{types.TypeDnf, types.TypeYum},
+		Packages: []string{"docker", "moby-engine", "docker-ce", "podman-docker"},
+		Prefer:   "docker-ce",
+		Reason:   "they all provide the docker command and the Docker daemon; Docker's own docker-ce is kept",
+	},
+	{
+		Managers: []types.PackageManagerType{types.TypeDnf, types.TypeYum},
+		Packages: []string{"java-latest-openjdk-devel", "java-*-openjdk-devel"},
+		Prefer:   "java-latest-openjdk-devel",
+		Reason:   "each JDK package makes its Java the default; java-latest-openjdk-devel is kept",
+	},
+	{
+		Managers: []types.PackageManagerType{types.TypePacman},
+		Packages: []string{"jdk-openjdk", "jdk*-openjdk"},
+		Prefer:   "jdk-openjdk",
+		Reason:   "each JDK package makes its Java the default; jdk-openjdk is kept",
+	},
+	{
+		Managers: []types.PackageManagerType{types.TypeHomebrew},
+		Packages: []string{"mysql", "mysql@*", "mariadb", "mariadb@*", "percona-server"},
+		Reason:   "they install the same binaries, so Homebrew refuses to install more than one",
+	},
+}
+
+// Conflict is a set of packages of a plan that a rule says can't be
+// installed together
+type Conflict struct {
+	Rule ConflictRule
+	// Packages are the conflicting packages, in the order of the plan
+	Packages []string
+}
+
+// Preferred returns the package the rule of c keeps, or "" when the rule
+// prefers none of them, or several
+func (c Conflict) Preferred() string {
+	if c.Rule.Prefer == "" {
+		return ""
+	}
+	preferred := ""
+	for _, pkg := range c.Packages {
+		if matchPackage(c.Rule.Prefer, pkg) {
+			if preferred != "" {
+				return ""
+			}
+			preferred = pkg
+		}
+	}
+	return preferred
+}
+
+// Decisions recorded in ConflictDecision.Decision
+const (
+	// DecisionPreferred means the rule's preference picked the package kept
+	DecisionPreferred = "preferred"
+	// DecisionChosen means the user picked the package kept
+	DecisionChosen = "chosen"
+	// DecisionUnresolved means nobody picked, so every package is kept
+	// and the package manager may refuse to install them
+	DecisionUnresolved = "unresolved"
+)
+
+// ConflictDecision records how a conflict between packages of an
+// installation was settled
+type ConflictDecision struct {
+	Packages []string `json:"packages"`
+	Reason   string   `json:"reason,omitempty"`
+	// Kept is the package installed; the others were left out. Empty when
+	// the conflict is unresolved.
+	Kept     string `json:"kept,omitempty"`
+	Decision string `json:"decision"`
+}
+
+// Dropped returns the packages of the conflict left out of the installation
+func (d ConflictDecision) Dropped() []string {
+	if d.Kept == "" {
+		return nil
+	}
+	var dropped []string
+	for _, pkg := range d.Packages {
+		if pkg != d.Kept {
+			dropped = append(dropped, pkg)
+		}
+	}
+	return dropped
+}
+
+// FindConflicts returns the conflicts among packages, those of a plan for
+// pmType, found by rules and then by the built-in rules. A package is in
+// at most one conflict: the first rule matching two of them wins, so rules
+// given by the user override the built-in ones for the same packages.
+func FindConflicts(rules []ConflictRule, pmType types.PackageManagerType, packages []string) []Conflict {
+	var conflicts []Conflict
+	taken := make(map[string]bool)
+	for _, rule := range append(slices.Clone(rules), builtinConflicts...) {
+		if len(rule.Managers) > 0 && !slices.Contains(rule.Managers, pmType) {
+			continue
+		}
+		var matched []string
+		for _, pkg := range packages {
+			if !taken[pkg] && !slices.Contains(matched, pkg) && slices.ContainsFunc(rule.Packages, func(pattern string) bool { return matchPackage(pattern, pkg) }) {
+				matched = append(matched, pkg)
+			}
+		}
+		if len(matched) < 2 {
+			continue
+		}
+		for _, pkg := range matched {
+			taken[pkg] = true
+		}
+		conflicts = append(conflicts, Conflict{Rule: rule, Packages: matched})
+	}
+	return conflicts
+}
+
+// matchPackage reports whether pkg matches pattern, a package name that
+// may hold wildcards
+func matchPackage(pattern, pkg string) bool {
+	if pattern == pkg {
+		return true
+	}
+	matched, err := path.Match(pattern, pkg)
+	return err == nil && matched
+}
+
+// LoadConflictRules reads the conflict rules of the mappings file at path.
+// A missing file has none.
+func LoadConflictRules(path string) ([]ConflictRule, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) || errors.Is(err, syscall.ENOTDIR) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read mappings: %w", err)
+	}
+	rules, err := ParseConflictRules(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return rules, nil
+}
+
+// ParseConflictRules parses the conflicts list of a mappings file in YAML
+// or JSON and validates every rule. Errors give the line of the offending
+// rule.
+func ParseConflictRules(data []byte) ([]ConflictRule, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid mappings: %w", err)
+	}
+	if len(doc.Content) == 0 {
+		return nil, nil
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("line %d: mappings must be a mapping with a conflicts list", root.Line)
+	}
+
+	var rules []ConflictRule
+	var errs []error
+	for i := 0; i < len(root.Content); i += 2 {
+		key, value := root.Content[i], root.Content[i+1]
+		if key.Value != "conflicts" {
+			errs = append(errs, fmt.Errorf("line %d: unknown key %q (want conflicts)", key.Line, key.Value))
+			continue
+		}
+		if value.Kind != yaml.SequenceNode {
+			errs = append(errs, fmt.Errorf("line %d: conflicts must be a list", value.Line))
+			continue
+		}
+		for n, node := range value.Content {
+			rule, err := parseConflictRule(node)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("conflict %d (line %d): %w", n+1, node.Line, err))
+				continue
+			}
+			rules = append(rules, rule)
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// parseConflictRule decodes and validates one rule of the conflicts list
+func parseConflictRule(node *yaml.Node) (ConflictRule, error) {
+	var rule ConflictRule
+	if node.Kind != yaml.MappingNode {
+		return rule, errors.New("must be a mapping with managers, packages, prefer and reason keys")
+	}
+	for i := 0; i < len(node.Content); i += 2 {
+		switch key := node.Content[i].Value; key {
+		case "managers", "packages", "prefer", "reason":
+		default:
+			return rule, fmt.Errorf("unknown key %q (want managers, packages, prefer or reason)", key)
+		}
+	}
+	if err := node.Decode(&rule); err != nil {
+		return rule, err
+	}
+
+	if len(rule.Packages) < 2 && !(len(rule.Packages) == 1 && hasWildcard(rule.Packages[0])) {
+		return rule, errors.New("packages must list at least two packages, or one with a wildcard")
+	}
+	for _, pattern := range append(slices.Clone(rule.Packages), rule.Prefer) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return rule, fmt.Errorf("invalid package pattern %q", pattern)
+		}
+	}
+	for _, manager := range rule.Managers {
+		// Only the package managers StackMatch knows have a display name
+		if GetPackageManagerName(manager) == string(manager) {
+			return rule, fmt.Errorf("unknown package manager %q", manager)
+		}
+	}
+	if rule.Prefer != "" && !slices.ContainsFunc(rule.Packages, func(pattern string) bool { return matchPackage(pattern, rule.Prefer) }) {
+		return rule, fmt.Errorf("prefer %q is not one of the packages", rule.Prefer)
+	}
+	return rule, nil
+}
+
+// hasWildcard reports whether pattern may match more than one package
+func hasWildcard(pattern string) bool {
+	return strings.ContainsAny(pattern, "*?[")
+}
@@ -0,0 +1,115 @@
+package installer
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+func TestFindConflicts(t *testing.T) {
+	userRules := []ConflictRule{
+		{Packages: []string{"docker.io", "docker-ce"}, Prefer: "docker.io"},
+		{Managers: []types.PackageManagerType{types.TypeDnf}, Packages: []string{"emacs", "emacs-nox"}},
+	}
+
+	testCases := []struct {
+		name      string
+		rules     []ConflictRule
+		manager   types.PackageManagerType
+		packages  []string
+		expected  [][]string
+		preferred []string
+	}{
+		{
+			name:      "Built-in rule",
+			manager:   types.TypeApt,
+			packages:  []string{"git", "docker.io", "curl", "docker-ce"},
+			expected:  [][]string{{"docker.io", "docker-ce"}},
+			preferred: []string{"docker-ce"},
+		},
+		{
+			name:      "User rule overrides the built-in one",
+			rules:     userRules,
+			manager:   types.TypeApt,
+			packages:  []string{"docker-ce", "docker.io"},
+			expected:  [][]string{{"docker-ce", "docker.io"}},
+			preferred: []string{"docker.io"},
+		},
+		{
+			name:      "Wildcards match packages conflicting with each other",
+			manager:   types.TypeApt,
+			packages:  []string{"openjdk-17-jdk", "maven", "openjdk-21-jdk"},
+			expected:  [][]string{{"openjdk-17-jdk", "openjdk-21-jdk"}},
+			preferred: []string{""},
+		},
+		{
+			name:      "Without a preference",
+			manager:   types.TypeApt,
+			packages:  []string{"python2", "python-is-python3", "docker.io", "podman-docker"},
+			expected:  [][]string{{"docker.io", "podman-docker"}, {"python2", "python-is-python3"}},
+			preferred: []string{"", ""},
+		},
+		{
+			name:     "Rule of another package manager",
+			rules:    userRules,
+			manager:  types.TypeApt,
+			packages: []string{"emacs", "emacs-nox"},
+		},
+		{
+			name:     "A single package of a rule",
+			manager:  types.TypeApt,
+			packages: []string{"docker-ce", "git"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			conflicts := FindConflicts(tc.rules, tc.manager, tc.packages)
+			var packages [][]string
+			var preferred []string
+			for _, conflict := range conflicts {
+				packages = append(packages, conflict.Packages)
+				preferred = append(preferred, conflict.Preferred())
+			}
+			if !reflect.DeepEqual(packages, tc.expected) || !reflect.DeepEqual(preferred, tc.preferred) {
+				t.Errorf("expected conflicts %v preferring %q but got %v preferring %q", tc.expected, tc.preferred, packages, preferred)
+			}
+		})
+	}
+}
+
+func TestParseConflictRules(t *testing.T) {
+	rules, err := ParseConflictRules([]byte(`conflicts:
+  - managers: [apt]
+    packages: [docker.io, docker-ce]
+    prefer: docker.io
+    reason: the team uses Ubuntu's docker
+  - packages: ["openjdk-*-jdk"]
+`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []ConflictRule{
+		{Managers: []types.PackageManagerType{types.TypeApt}, Packages: []string{"docker.io", "docker-ce"}, Prefer: "docker.io", Reason: "the team uses Ubuntu's docker"},
+		{Packages: []string{"openjdk-*-jdk"}},
+	}
+	if !reflect.DeepEqual(rules, expected) {
+		t.Errorf("expected %+v but got %+v", expected, rules)
+	}
+
+	invalid := map[string]string{
+		"conflict:\n  - packages: [a, b]\n":                        `line 1: unknown key "conflict"`,
+		"conflicts:\n  - packages: [a]\n":                          "conflict 1 (line 2): packages must list at least two",
+		"conflicts:\n  - packages: [a, b]\n    prefer: c\n":        `prefer "c" is not one of the packages`,
+		"conflicts:\n  - packages: [a, b]\n    manager: apt\n":     `unknown key "manager"`,
+		"conflicts:\n  - packages: [a, b]\n    managers: [aptt]\n": `unknown package manager "aptt"`,
+		"conflicts:\n  - packages: [a, \"b[\"]\n":                  `invalid package pattern "b["`,
+	}
+	for content, message := range invalid {
+		if _, err := ParseConflictRules([]byte(content)); err == nil || !strings.Contains(err.Error(), message) {
+			t.Errorf("expected an error containing %q for:\n%s\nbut got %v", message, content, err)
+		}
+	}
+}
@@ -2,9 +2,11 @@ package installer
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"runtime"
 	"strings"
+	"time"
 
 	"github.com/MRQ67/stackmatch-cli/pkg/installer/package_managers"
 	"github.com/MRQ67/stackmatch-cli/pkg/types"
@@ -23,45 +25,211 @@ type (
 
 // DetectPackageManager detects the best available package manager for the current system
 func DetectPackageManager() (Installer, error) {
-	// Check package managers in order of preference based on OS
-	var managers []Installer
+	return DetectPackageManagerContext(context.Background())
+}
+
+// FallBack lets detection pass over a package manager that was found but
+// can't be used, such as APT while unattended-upgrades holds the dpkg
+// lock, and use the next one found. Package managers chosen that way are
+// not cached. It is off unless the CLI sets it, so a transient problem
+// fails detection rather than silently switching to another manager.
+var FallBack bool
+
+// DetectPackageManagerContext is DetectPackageManager with a context for
+// the probes. When Cache holds a fresh entry for the first package manager
+// on PATH, that one is used without checking it; otherwise the first
+// package manager found is checked and, when it can be used, cached. When
+// none can be used, the error is a *types.NoPackageManagerError saying what
+// was found of each.
+func DetectPackageManagerContext(ctx context.Context) (Installer, error) {
+	return detectPackageManager(ctx, packageManagers(runtime.GOOS))
+}
 
-	switch runtime.GOOS {
+// ProbePackageManagers reports on every package manager DetectPackageManager
+// considers on this OS: whether it was found and, when it was, whether it
+// can be used. The one detection would use is marked as selected. The cache
+// is not consulted.
+func ProbePackageManagers(ctx context.Context) types.ProbeReport {
+	report, _ := probePackageManagers(ctx, runtime.GOOS, packageManagers(runtime.GOOS), false)
+	return report
+}
+
+// packageManagers returns the package managers considered on goos, in
+// order of preference
+func packageManagers(goos string) []Installer {
+	switch goos {
 	case "windows":
-		managers = []Installer{
+		return []Installer{
 			package_managers.NewChocolatey(),
 			package_managers.NewScoop(),
 			package_managers.NewWinget(),
 		}
 	case "darwin":
-		managers = []Installer{
+		return []Installer{
 			package_managers.NewHomebrew(),
 		}
 	default: // Linux and others
-		managers = []Installer{
+		return []Installer{
 			package_managers.NewApt(),
 			package_managers.NewDnf(),
 			package_managers.NewYum(),
 			package_managers.NewPacman(),
+			package_managers.NewApk(),
 			package_managers.NewSnap(),
 		}
 	}
+}
 
-	// Return the first available package manager
+// detectPackageManager returns the first of managers found, or with
+// FallBack the first usable one
+func detectPackageManager(ctx context.Context, managers []Installer) (Installer, error) {
+	if Cache != nil {
+		if cached := Cache.Load(); cached != nil {
+			// Only the first manager found is cached, so an entry for any
+			// other is not used
+			for _, mgr := range managers {
+				if mgr.IsAvailable() {
+					if mgr.Type() == cached.Type {
+						return mgr, nil
+					}
+					break
+				}
+			}
+		}
+	}
+
+	report, selected := probePackageManagers(ctx, runtime.GOOS, managers, true)
+	if selected == nil {
+		if Cache != nil {
+			// Nothing is there to describe, so a stale entry must not linger
+			_ = Cache.Invalidate()
+		}
+		return nil, &types.NoPackageManagerError{Report: report}
+	}
+	if Cache != nil && report.PassedOver() == 0 {
+		// Only the type is needed to detect it again; Metadata adds the
+		// version when a command asks for it
+		_ = Cache.Store(types.PackageManagerMetadata{Type: selected.Type(), VerifiedAt: time.Now().UTC()})
+	}
+	return selected, nil
+}
+
+// probePackageManagers probes managers in order and returns the report with
+// the first found, which is selected when it can be used. Without FallBack
+// a manager found but unusable is not passed over: nothing is selected.
+// With stopAtSelected the rest are left out, so detection probes no more
+// than it needs to.
+func probePackageManagers(ctx context.Context, goos string, managers []Installer, stopAtSelected bool) (types.ProbeReport, Installer) {
+	report := types.ProbeReport{OS: goos, Managers: []types.ManagerProbe{}}
+	var selected Installer
+	blocked := false
 	for _, mgr := range managers {
-		if mgr.IsAvailable() {
-			return mgr, nil
+		probe := probeManager(ctx, mgr)
+		switch {
+		case selected != nil || blocked:
+		case probe.Usable():
+			probe.Selected = true
+			selected = mgr
+		case probe.Found && !FallBack:
+			blocked = true
+		}
+		report.Managers = append(report.Managers, probe)
+		if selected != nil && stopAtSelected {
+			break
+		}
+	}
+	return report, selected
+}
+
+// probeManager finds out whether mgr is on PATH and, for those that can
+// tell (see types.ManagerProber), whether it can be used
+func probeManager(ctx context.Context, mgr Installer) types.ManagerProbe {
+	probe := types.ManagerProbe{Name: mgr.Name(), Type: mgr.Type()}
+	if !mgr.IsAvailable() {
+		return probe
+	}
+	probe.Found = true
+	prober, ok := mgr.(types.ManagerProber)
+	if !ok {
+		return probe
+	}
+	probe.Executable = prober.Executable()
+	if ctx.Err() == nil {
+		if result := prober.CheckUsable(ctx); !result.Passed() {
+			probe.Problem, probe.Fix = result.Problem, result.Fix
+		}
+	}
+	return probe
+}
+
+// DetectVersionManager returns the first available language version manager,
+// or nil if none is installed
+func DetectVersionManager() types.VersionManager {
+	for _, vm := range []types.VersionManager{
+		package_managers.NewMise(),
+		package_managers.NewAsdf(),
+	} {
+		if vm.IsAvailable() {
+			return vm
 		}
 	}
+	return nil
+}
 
-	return nil, fmt.Errorf("no supported package manager found")
+// DnfModuleManager returns the version manager installing languages from DNF
+// module streams. Module installs are system-wide and need root, so it is
+// only used for languages the environment got from a module stream.
+func DnfModuleManager() types.VersionManager {
+	return package_managers.NewDnfModules()
 }
 
-// installWithMapping installs a package using the appropriate package name for the installer
+// PythonVersionManager returns the version manager installing Python through
+// manager, a PythonEnvironment manager such as "pyenv", or nil if this
+// release can't install Python through it
+func PythonVersionManager(manager string) types.VersionManager {
+	switch manager {
+	case types.PythonManagerPyenv:
+		return package_managers.NewPyenv()
+	case types.PythonManagerUv:
+		return package_managers.NewUv()
+	}
+	return nil
+}
+
+// GlobalPackageInstaller returns the installer for the global packages of
+// manager, a key of EnvironmentData.GlobalPackages such as "npm", or nil if
+// this release can't install them
+func GlobalPackageInstaller(manager string) types.GlobalPackageInstaller {
+	switch manager {
+	case "npm":
+		return package_managers.NewNpmGlobal()
+	case "go":
+		return package_managers.NewGoGlobal()
+	case "pip":
+		return package_managers.NewPipGlobal()
+	}
+	return nil
+}
+
+// installWithMapping installs a package using the appropriate package name for
+// the installer. A version constraint is first resolved to the package and
+// version format of the installer (see ResolvePackage).
 func installWithMapping(ctx context.Context, installerInst Installer, pkg string, version ...VersionConstraint) error {
-	// Get the package name for this specific package manager
+	constraint := VersionConstraint{}
+	if len(version) > 0 {
+		constraint = version[0]
+	}
+
+	// Get the package name and version for this specific package manager
 	pmType := installerInst.Type()
-	mappedPkg, err := GetPackageName(pkg, pmType)
+	mappedPkg, mappedVersion, err := ResolvePackage(pkg, pmType, constraint.Version)
+	var unresolvable *VersionResolutionError
+	if errors.As(err, &unresolvable) {
+		// Install the unversioned package rather than nothing
+		ui.PrintWarning("%v; installing the latest %s", err, pkg)
+		mappedVersion = VersionConstraint{}
+		mappedPkg, err = GetPackageName(pkg, pmType)
+	}
 	if err != nil {
 		return fmt.Errorf("package mapping error: %w", err)
 	}
@@ -72,40 +240,32 @@ func installWithMapping(ctx context.Context, installerInst Installer, pkg string
 		mappedPkg = pkg
 	}
 
-	// Check if we have a version constraint
-	if len(version) > 0 && version[0].Version != "" {
+	if constraint.Version != "" {
 		// First check if the installed version already satisfies the constraint
-		info, err := installerInst.CheckVersion(ctx, mappedPkg, version[0])
+		info, err := installerInst.CheckVersion(ctx, mappedPkg, constraint)
 		if err == nil && info != nil && info.Satisfies {
 			// Already installed with a compatible version
 			return nil
 		}
-
-		// Install specific version
-		err = installerInst.InstallVersion(ctx, mappedPkg, version[0])
-	} else {
-		// Install without version constraint
-		err = installerInst.InstallPackage(ctx, mappedPkg)
 	}
 
-	if err != nil {
-		// If we get a PackageNotFoundError, try with the original package name
-		if _, ok := err.(*types.PackageNotFoundError); ok && mappedPkg != pkg {
-			if len(version) > 0 && version[0].Version != "" {
-				err = installerInst.InstallVersion(ctx, pkg, version[0])
-			} else {
-				err = installerInst.InstallPackage(ctx, pkg)
-			}
+	install := func(name string, version VersionConstraint) error {
+		if version.Version != "" {
+			return installerInst.InstallVersion(ctx, name, version)
 		}
-		return err
+		return installerInst.InstallPackage(ctx, name)
 	}
-
-	return nil
+	err = install(mappedPkg, mappedVersion)
+	// If we get a PackageNotFoundError, try with the original package name
+	if _, ok := err.(*types.PackageNotFoundError); ok && mappedPkg != pkg {
+		err = install(pkg, constraint)
+	}
+	return err
 }
 
 // InstallPackage installs a package using the best available package manager
 func InstallPackage(ctx context.Context, pkg string, version ...VersionConstraint) error {
-	installerInst, err := DetectPackageManager()
+	installerInst, err := DetectPackageManagerContext(ctx)
 	if err != nil {
 		return err
 	}
@@ -162,7 +322,7 @@ func InstallPackages(ctx context.Context, packages []string, versions ...map[str
 		return fmt.Errorf("no packages to install")
 	}
 
-	installerInst, err := DetectPackageManager()
+	installerInst, err := DetectPackageManagerContext(ctx)
 	if err != nil {
 		return err
 	}
@@ -2,23 +2,159 @@ package installer
 
 import (
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/MRQ67/stackmatch-cli/pkg/types"
+	"github.com/MRQ67/stackmatch-cli/pkg/version"
 )
 
 // PackageMapping defines a mapping for a package across different package managers
 type PackageMapping struct {
-	Name        string
+	// ID is the canonical tool ID the scanner records (see types.ToolInfo)
+	ID string
+	// Aliases are other IDs that resolve to this mapping, such as the
+	// command name or an older spelling
+	Aliases     []string
 	Description string
-	Packages   map[types.PackageManagerType]string
+	// Packages maps each package manager to its package name. A manager
+	// missing from the map has no package for the tool. DNF and YUM names
+	// starting with "@" are package groups, such as "@development-tools".
+	Packages map[types.PackageManagerType]string
+	// Versions tells, for the managers that need it, how to install a
+	// given version of the tool (see ResolvePackage). Other managers
+	// install the package in Packages at the version asked for.
+	Versions map[types.PackageManagerType]VersionFormat
+	// Musl overrides Packages when installing on a musl-based system (see
+	// Libc), where tools are packaged under other names. An empty name
+	// means the manager has no package for the tool there. apk names are
+	// only given here: they are those of Alpine, which is musl-based,
+	// while glibc distributions using apk, such as Wolfi, name packages
+	// differently.
+	Musl map[types.PackageManagerType]string
 }
 
-// packageMappings contains the mapping of common packages across different package managers
+// VersionFormat describes how a package manager installs a given version of
+// a tool. Templates may use {major}, {minor} and {version}, which are taken
+// from the version constraint being installed.
+type VersionFormat struct {
+	// Package is the package for a version, such as "python@{major}.{minor}"
+	// for Homebrew. Empty keeps the package in Packages.
+	Package string
+	// Majors maps major versions to the package that provides them, such
+	// as 18 to "node@18", for managers that only ship some majors. It
+	// overrides Package, and majors missing from it can't be installed.
+	Majors map[int]string
+	// Minors maps major.minor versions to the package that provides them,
+	// such as "3.12" to "Python.Python.3.12", for managers that give each
+	// minor release a package of its own. Like Majors, it overrides Package.
+	Minors map[string]string
+	// Version is the version passed to the package manager, such as
+	// "{major}.*" for an apt version glob. Empty installs whatever version
+	// the package provides.
+	Version string
+}
+
+// packageMappings contains the mapping of every tool the scanner detects
+// across different package managers, keyed by canonical ID
 var packageMappings = []PackageMapping{
 	// Programming Languages
 	{
-		Name:        "nodejs",
+		ID:          "go",
+		Description: "Go toolchain",
+		Packages: map[types.PackageManagerType]string{
+			types.TypeApt:        "golang-go",
+			types.TypeDnf:        "golang",
+			types.TypeYum:        "golang",
+			types.TypePacman:     "go",
+			types.TypeSnap:       "go",
+			types.TypeHomebrew:   "go",
+			types.TypeChocolatey: "golang",
+			types.TypeScoop:      "go",
+			types.TypeWinget:     "GoLang.Go",
+		},
+		Musl: map[types.PackageManagerType]string{
+			types.TypeApk: "go",
+		},
+	},
+	{
+		ID:          "rust",
+		Aliases:     []string{"rustc"},
+		Description: "Rust compiler",
+		Packages: map[types.PackageManagerType]string{
+			types.TypeApt:        "rustc",
+			types.TypeDnf:        "rust",
+			types.TypeYum:        "rust",
+			types.TypePacman:     "rust",
+			types.TypeSnap:       "rustup",
+			types.TypeHomebrew:   "rust",
+			types.TypeChocolatey: "rust",
+			types.TypeScoop:      "rust",
+			types.TypeWinget:     "Rustlang.Rustup",
+		},
+		Musl: map[types.PackageManagerType]string{
+			types.TypeApk: "rust",
+		},
+	},
+	{
+		ID:          "java",
+		Aliases:     []string{"jdk"},
+		Description: "Java development kit",
+		Packages: map[types.PackageManagerType]string{
+			types.TypeApt:        "default-jdk",
+			types.TypeDnf:        "java-latest-openjdk-devel",
+			types.TypeYum:        "java-latest-openjdk-devel",
+			types.TypePacman:     "jdk-openjdk",
+			types.TypeHomebrew:   "openjdk",
+			types.TypeChocolatey: "openjdk",
+			types.TypeScoop:      "openjdk",
+			types.TypeWinget:     "Microsoft.OpenJDK.21",
+		},
+	},
+	{
+		ID:          "kotlin",
+		Description: "Kotlin compiler",
+		Packages: map[types.PackageManagerType]string{
+			types.TypePacman:     "kotlin",
+			types.TypeSnap:       "kotlin",
+			types.TypeHomebrew:   "kotlin",
+			types.TypeChocolatey: "kotlinc",
+			types.TypeScoop:      "kotlin",
+			types.TypeWinget:     "JetBrains.Kotlin.Compiler",
+		},
+	},
+	{
+		ID:          "dotnet",
+		Aliases:     []string{"c#", "csharp"},
+		Description: ".NET SDK (C#)",
+		Packages: map[types.PackageManagerType]string{
+			types.TypeApt:        "dotnet-sdk-8.0",
+			types.TypeDnf:        "dotnet-sdk-8.0",
+			types.TypeYum:        "dotnet-sdk-8.0",
+			types.TypePacman:     "dotnet-sdk",
+			types.TypeSnap:       "dotnet-sdk",
+			types.TypeHomebrew:   "dotnet",
+			types.TypeChocolatey: "dotnet-sdk",
+			types.TypeScoop:      "dotnet-sdk",
+			types.TypeWinget:     "Microsoft.DotNet.SDK.8",
+		},
+	},
+	{
+		ID:          "scala",
+		Description: "Scala compiler",
+		Packages: map[types.PackageManagerType]string{
+			types.TypeApt:        "scala",
+			types.TypeDnf:        "scala",
+			types.TypePacman:     "scala",
+			types.TypeHomebrew:   "scala",
+			types.TypeChocolatey: "scala",
+			types.TypeScoop:      "scala",
+		},
+	},
+	{
+		ID:          "nodejs",
+		Aliases:     []string{"node"},
 		Description: "Node.js JavaScript runtime",
 		Packages: map[types.PackageManagerType]string{
 			types.TypeApt:        "nodejs",
@@ -30,96 +166,1349 @@ var packageMappings = []PackageMapping{
 			types.TypeScoop:      "nodejs",
 			types.TypeWinget:     "OpenJS.NodeJS",
 		},
+		Musl: map[types.PackageManagerType]string{
+			types.TypeApk: "nodejs",
+		},
+		Versions: map[types.PackageManagerType]VersionFormat{
+			// node is the current release, and moves to the next major
+			// when it comes out
+			types.TypeHomebrew: {Majors: map[int]string{18: "node@18", 20: "node@20", 22: "node@22", 24: "node@24", 26: "node"}},
+			// NodeSource publishes one nodejs package per major
+			types.TypeApt:    {Version: "{major}.*"},
+			types.TypeWinget: {Version: "{version}"},
+		},
 	},
 	{
-		Name:        "python3",
+		ID:          "python3",
+		Aliases:     []string{"python", "python-3"},
 		Description: "Python 3 interpreter",
 		Packages: map[types.PackageManagerType]string{
 			types.TypeApt:        "python3",
 			types.TypeDnf:        "python3",
 			types.TypeYum:        "python3",
 			types.TypePacman:     "python",
-			types.TypeHomebrew:    "python",
+			types.TypeHomebrew:   "python",
 			types.TypeChocolatey: "python",
 			types.TypeScoop:      "python",
 			types.TypeWinget:     "Python.Python.3",
 		},
+		Musl: map[types.PackageManagerType]string{
+			types.TypeApk: "python3",
+		},
+		Versions: map[types.PackageManagerType]VersionFormat{
+			types.TypeHomebrew: {Package: "python@{major}.{minor}"},
+			// Minor releases other than the default come from the
+			// deadsnakes PPA on Ubuntu and are packaged side by side
+			types.TypeApt: {Package: "python{major}.{minor}"},
+			types.TypeDnf: {Package: "python{major}.{minor}"},
+			types.TypeWinget: {Minors: map[string]string{
+				"3.9": "Python.Python.3.9", "3.10": "Python.Python.3.10", "3.11": "Python.Python.3.11",
+				"3.12": "Python.Python.3.12", "3.13": "Python.Python.3.13",
+			}},
+		},
 	},
-
-	// Development Tools
 	{
-		Name:        "git",
-		Description: "Distributed version control system",
+		ID:          "ruby",
+		Description: "Ruby interpreter",
 		Packages: map[types.PackageManagerType]string{
-			types.TypeApt:        "git",
-			types.TypeDnf:        "git",
-			types.TypeYum:        "git",
-			types.TypePacman:     "git",
-			types.TypeHomebrew:    "git",
-			types.TypeChocolatey: "git",
-			types.TypeScoop:      "git",
-			types.TypeWinget:     "Git.Git",
+			types.TypeApt:        "ruby-full",
+			types.TypeDnf:        "ruby",
+			types.TypeYum:        "ruby",
+			types.TypePacman:     "ruby",
+			types.TypeSnap:       "ruby",
+			types.TypeHomebrew:   "ruby",
+			types.TypeChocolatey: "ruby",
+			types.TypeScoop:      "ruby",
+			types.TypeWinget:     "RubyInstallerTeam.Ruby.3.3",
+		},
+		Musl: map[types.PackageManagerType]string{
+			types.TypeApk: "ruby",
+		},
+	},
+	{
+		ID:          "php",
+		Description: "PHP interpreter",
+		Packages: map[types.PackageManagerType]string{
+			types.TypeApt:        "php-cli",
+			types.TypeDnf:        "php-cli",
+			types.TypeYum:        "php-cli",
+			types.TypePacman:     "php",
+			types.TypeHomebrew:   "php",
+			types.TypeChocolatey: "php",
+			types.TypeScoop:      "php",
+		},
+	},
+	{
+		ID:          "perl",
+		Description: "Perl interpreter",
+		Packages: map[types.PackageManagerType]string{
+			types.TypeApt:        "perl",
+			types.TypeDnf:        "perl",
+			types.TypeYum:        "perl",
+			types.TypePacman:     "perl",
+			types.TypeHomebrew:   "perl",
+			types.TypeChocolatey: "strawberryperl",
+			types.TypeScoop:      "perl",
+			types.TypeWinget:     "StrawberryPerl.StrawberryPerl",
+		},
+		Musl: map[types.PackageManagerType]string{
+			types.TypeApk: "perl",
+		},
+	},
+	{
+		ID:          "lua",
+		Description: "Lua interpreter",
+		Packages: map[types.PackageManagerType]string{
+			types.TypeApt:        "lua5.4",
+			types.TypeDnf:        "lua",
+			types.TypeYum:        "lua",
+			types.TypePacman:     "lua",
+			types.TypeHomebrew:   "lua",
+			types.TypeChocolatey: "lua",
+			types.TypeScoop:      "lua",
+		},
+		Musl: map[types.PackageManagerType]string{
+			types.TypeApk: "lua5.4",
+		},
+	},
+	{
+		ID:          "groovy",
+		Description: "Apache Groovy",
+		Packages: map[types.PackageManagerType]string{
+			types.TypeApt:        "groovy",
+			types.TypePacman:     "groovy",
+			types.TypeHomebrew:   "groovy",
+			types.TypeChocolatey: "groovy",
+			types.TypeScoop:      "groovy",
+		},
+	},
+	{
+		ID:          "haskell",
+		Aliases:     []string{"ghc"},
+		Description: "Glasgow Haskell Compiler",
+		Packages: map[types.PackageManagerType]string{
+			types.TypeApt:        "ghc",
+			types.TypeDnf:        "ghc",
+			types.TypeYum:        "ghc",
+			types.TypePacman:     "ghc",
+			types.TypeHomebrew:   "ghc",
+			types.TypeChocolatey: "ghc",
+			types.TypeScoop:      "ghc",
+		},
+	},
+	{
+		ID:          "elixir",
+		Description: "Elixir language",
+		Packages: map[types.PackageManagerType]string{
+			types.TypeApt:        "elixir",
+			types.TypeDnf:        "elixir",
+			types.TypePacman:     "elixir",
+			types.TypeHomebrew:   "elixir",
+			types.TypeChocolatey: "elixir",
+			types.TypeScoop:      "elixir",
+		},
+	},
+	{
+		ID:          "clojure",
+		Aliases:     []string{"clj"},
+		Description: "Clojure CLI",
+		Packages: map[types.PackageManagerType]string{
+			types.TypePacman:   "clojure",
+			types.TypeHomebrew: "clojure",
+			types.TypeScoop:    "clojure",
+		},
+	},
+	{
+		ID:          "typescript",
+		Aliases:     []string{"tsc"},
+		Description: "TypeScript compiler",
+		Packages: map[types.PackageManagerType]string{
+			types.TypeApt:        "node-typescript",
+			types.TypePacman:     "typescript",
+			types.TypeHomebrew:   "typescript",
+			types.TypeChocolatey: "typescript",
+			types.TypeScoop:      "typescript",
+		},
+	},
+	{
+		ID:          "dart",
+		Description: "Dart SDK",
+		Packages: map[types.PackageManagerType]string{
+			types.TypeSnap:       "dart",
+			types.TypeHomebrew:   "dart-sdk",
+			types.TypeChocolatey: "dart-sdk",
+			types.TypeScoop:      "dart",
+		},
+	},
+	{
+		ID:          "bash",
+		Description: "Bash shell",
+		Packages: map[types.PackageManagerType]string{
+			types.TypeApt:      "bash",
+			types.TypeDnf:      "bash",
+			types.TypeYum:      "bash",
+			types.TypePacman:   "bash",
+			types.TypeHomebrew: "bash",
+		},
+		Musl: map[types.PackageManagerType]string{
+			types.TypeApk: "bash",
+		},
+	},
+	{
+		ID:          "zsh",
+		Description: "Z shell",
+		Packages: map[types.PackageManagerType]string{
+			types.TypeApt:      "zsh",
+			types.TypeDnf:      "zsh",
+			types.TypeYum:      "zsh",
+			types.TypePacman:   "zsh",
+			types.TypeHomebrew: "zsh",
+		},
+		Musl: map[types.PackageManagerType]string{
+			types.TypeApk: "zsh",
+		},
+	},
+	{
+		ID:          "fish",
+		Description: "Fish shell",
+		Packages: map[types.PackageManagerType]string{
+			types.TypeApt:      "fish",
+			types.TypeDnf:      "fish",
+			types.TypeYum:      "fish",
+			types.TypePacman:   "fish",
+			types.TypeHomebrew: "fish",
+		},
+		Musl: map[types.PackageManagerType]string{
+			types.TypeApk: "fish",
 		},
 	},
 
 	// Databases
 	{
-		Name:        "postgresql",
+		ID:          "sqlite",
+		Aliases:     []string{"sqlite3"},
+		Description: "SQLite command-line shell",
+		Packages: map[types.PackageManagerType]string{
+			types.TypeApt:        "sqlite3",
+			types.TypeDnf:        "sqlite",
+			types.TypeYum:        "sqlite",
+			types.TypePacman:     "sqlite",
+			types.TypeHomebrew:   "sqlite",
+			types.TypeChocolatey: "sqlite",
+			types.TypeScoop:      "sqlite",
+			types.TypeWinget:     "SQLite.SQLite",
+		},
+		Musl: map[types.PackageManagerType]string{
+			types.TypeApk: "sqlite",
+		},
+	},
+	{
+		ID:          "postgresql",
+		Aliases:     []string{"postgres", "psql"},
 		Description: "PostgreSQL database server",
 		Packages: map[types.PackageManagerType]string{
 			types.TypeApt:        "postgresql",
 			types.TypeDnf:        "postgresql-server",
 			types.TypeYum:        "postgresql-server",
 			types.TypePacman:     "postgresql",
-			types.TypeHomebrew:    "postgresql@14",
+			types.TypeHomebrew:   "postgresql@14",
 			types.TypeChocolatey: "postgresql",
 			types.TypeScoop:      "postgresql",
 			types.TypeWinget:     "PostgreSQL.pgAdmin",
 		},
 	},
+	{
+		ID:          "mysql",
+		Description: "MySQL database server",
+		Packages: map[types.PackageManagerType]string{
+			types.TypeApt:        "mysql-server",
+			types.TypeDnf:        "mysql-server",
+			types.TypeYum:        "mysql-server",
+			types.TypePacman:     "mariadb",
+			types.TypeHomebrew:   "mysql",
+			types.TypeChocolatey: "mysql",
+			types.TypeScoop:      "mysql",
+			types.TypeWinget:     "Oracle.MySQL",
+		},
+	},
+
+	// Development Tools
+	{
+		ID:          "git",
+		Description: "Distributed version control system",
+		Packages: map[types.PackageManagerType]string{
+			types.TypeApt:        "git",
+			types.TypeDnf:        "git",
+			types.TypeYum:        "git",
+			types.TypePacman:     "git",
+			types.TypeHomebrew:   "git",
+			types.TypeChocolatey: "git",
+			types.TypeScoop:      "git",
+			types.TypeWinget:     "Git.Git",
+		},
+		Musl: map[types.PackageManagerType]string{
+			types.TypeApk: "git",
+		},
+	},
+	{
+		ID:          "mercurial",
+		Aliases:     []string{"hg"},
+		Description: "Mercurial version control system",
+		Packages: map[types.PackageManagerType]string{
+			types.TypeApt:        "mercurial",
+			types.TypeDnf:        "mercurial",
+			types.TypeYum:        "mercurial",
+			types.TypePacman:     "mercurial",
+			types.TypeHomebrew:   "mercurial",
+			types.TypeChocolatey: "hg",
+			types.TypeScoop:      "mercurial",
+			types.TypeWinget:     "Mercurial.Mercurial",
+		},
+		Musl: map[types.PackageManagerType]string{
+			types.TypeApk: "mercurial",
+		},
+	},
+	{
+		ID:          "subversion",
+		Aliases:     []string{"svn"},
+		Description: "Subversion version control system",
+		Packages: map[types.PackageManagerType]string{
+			types.TypeApt:        "subversion",
+			types.TypeDnf:        "subversion",
+			types.TypeYum:        "subversion",
+			types.TypePacman:     "subversion",
+			types.TypeHomebrew:   "subversion",
+			types.TypeChocolatey: "svn",
+			types.TypeScoop:      "sliksvn",
+		},
+		Musl: map[types.PackageManagerType]string{
+			types.TypeApk: "subversion",
+		},
+	},
+	{
+		ID:          "make",
+		Aliases:     []string{"gnu-make"},
+		Description: "GNU Make",
+		Packages: map[types.PackageManagerType]string{
+			types.TypeApt:        "make",
+			types.TypeDnf:        "make",
+			types.TypeYum:        "make",
+			types.TypePacman:     "make",
+			types.TypeHomebrew:   "make",
+			types.TypeChocolatey: "make",
+			types.TypeScoop:      "make",
+			types.TypeWinget:     "GnuWin32.Make",
+		},
+		Musl: map[types.PackageManagerType]string{
+			types.TypeApk: "make",
+		},
+	},
+	{
+		ID:          "build-essential",
+		Aliases:     []string{"development-tools", "base-devel"},
+		Description: "C and C++ compilers with the usual build tools",
+		Packages: map[types.PackageManagerType]string{
+			types.TypeApt:    "build-essential",
+			types.TypeDnf:    "@development-tools",
+			types.TypeYum:    "@development",
+			types.TypePacman: "base-devel",
+		},
+		Musl: map[types.PackageManagerType]string{
+			types.TypeApk: "build-base",
+		},
+	},
+	{
+		ID:          "cmake",
+		Description: "CMake build system",
+		Packages: map[types.PackageManagerType]string{
+			types.TypeApt:        "cmake",
+			types.TypeDnf:        "cmake",
+			types.TypeYum:        "cmake",
+			types.TypePacman:     "cmake",
+			types.TypeSnap:       "cmake",
+			types.TypeHomebrew:   "cmake",
+			types.TypeChocolatey: "cmake",
+			types.TypeScoop:      "cmake",
+			types.TypeWinget:     "Kitware.CMake",
+		},
+		Musl: map[types.PackageManagerType]string{
+			types.TypeApk: "cmake",
+		},
+	},
+	{
+		ID:          "gradle",
+		Description: "Gradle build tool",
+		Packages: map[types.PackageManagerType]string{
+			types.TypeApt:        "gradle",
+			types.TypePacman:     "gradle",
+			types.TypeSnap:       "gradle",
+			types.TypeHomebrew:   "gradle",
+			types.TypeChocolatey: "gradle",
+			types.TypeScoop:      "gradle",
+		},
+	},
+	{
+		ID:          "maven",
+		Aliases:     []string{"mvn"},
+		Description: "Apache Maven",
+		Packages: map[types.PackageManagerType]string{
+			types.TypeApt:        "maven",
+			types.TypeDnf:        "maven",
+			types.TypeYum:        "maven",
+			types.TypePacman:     "maven",
+			types.TypeHomebrew:   "maven",
+			types.TypeChocolatey: "maven",
+			types.TypeScoop:      "maven",
+		},
+	},
+	{
+		ID:          "jest",
+		Description: "Jest test runner, installed with npm",
+	},
+	{
+		ID:          "pytest",
+		Description: "pytest test framework",
+		Packages: map[types.PackageManagerType]string{
+			types.TypeApt:      "python3-pytest",
+			types.TypeDnf:      "python3-pytest",
+			types.TypeYum:      "python3-pytest",
+			types.TypePacman:   "python-pytest",
+			types.TypeHomebrew: "pytest",
+		},
+	},
+	{
+		ID:          "tmux",
+		Description: "tmux terminal multiplexer",
+		Packages: map[types.PackageManagerType]string{
+			types.TypeApt:      "tmux",
+			types.TypeDnf:      "tmux",
+			types.TypeYum:      "tmux",
+			types.TypePacman:   "tmux",
+			types.TypeApk:      "tmux",
+			types.TypeHomebrew: "tmux",
+		},
+	},
+	{
+		ID:          "gnu-screen",
+		Aliases:     []string{"screen"},
+		Description: "GNU Screen terminal multiplexer",
+		Packages: map[types.PackageManagerType]string{
+			types.TypeApt:      "screen",
+			types.TypeDnf:      "screen",
+			types.TypeYum:      "screen",
+			types.TypePacman:   "screen",
+			types.TypeApk:      "screen",
+			types.TypeHomebrew: "screen",
+		},
+	},
+	{
+		ID:          "zellij",
+		Description: "Zellij terminal workspace",
+		Packages: map[types.PackageManagerType]string{
+			types.TypePacman:   "zellij",
+			types.TypeApk:      "zellij",
+			types.TypeHomebrew: "zellij",
+		},
+	},
+	{
+		ID:          "openssl",
+		Description: "OpenSSL toolkit",
+		Packages: map[types.PackageManagerType]string{
+			types.TypeApt:        "openssl",
+			types.TypeDnf:        "openssl",
+			types.TypeYum:        "openssl",
+			types.TypePacman:     "openssl",
+			types.TypeHomebrew:   "openssl",
+			types.TypeChocolatey: "openssl",
+			types.TypeScoop:      "openssl",
+			types.TypeWinget:     "ShiningLight.OpenSSL",
+		},
+		Musl: map[types.PackageManagerType]string{
+			types.TypeApk: "openssl",
+		},
+	},
 
 	// Containerization
 	{
-		Name:        "docker",
+		ID:          "docker",
 		Description: "Docker container platform",
 		Packages: map[types.PackageManagerType]string{
 			types.TypeApt:        "docker.io",
 			types.TypeDnf:        "docker",
 			types.TypeYum:        "docker",
 			types.TypePacman:     "docker",
-			types.TypeHomebrew:    "docker",
+			types.TypeHomebrew:   "docker",
 			types.TypeChocolatey: "docker-desktop",
 			types.TypeScoop:      "docker",
 			types.TypeWinget:     "Docker.DockerDesktop",
 		},
+		Musl: map[types.PackageManagerType]string{
+			types.TypeApk: "docker",
+		},
+	},
+	{
+		ID:          "docker-compose",
+		Description: "Docker Compose",
+		Packages: map[types.PackageManagerType]string{
+			types.TypeApt:        "docker-compose",
+			types.TypeDnf:        "docker-compose",
+			types.TypeYum:        "docker-compose",
+			types.TypePacman:     "docker-compose",
+			types.TypeHomebrew:   "docker-compose",
+			types.TypeChocolatey: "docker-compose",
+			types.TypeScoop:      "docker-compose",
+		},
+		Musl: map[types.PackageManagerType]string{
+			types.TypeApk: "docker-cli-compose",
+		},
+	},
+	{
+		ID:          "docker-compose-plugin",
+		Description: "Docker Compose CLI plugin",
+		Packages: map[types.PackageManagerType]string{
+			types.TypeApt:      "docker-compose-plugin",
+			types.TypeDnf:      "docker-compose-plugin",
+			types.TypeYum:      "docker-compose-plugin",
+			types.TypePacman:   "docker-compose",
+			types.TypeHomebrew: "docker-compose",
+		},
+	},
+	{
+		ID:          "docker-buildx-plugin",
+		Description: "Docker Buildx CLI plugin",
+		Packages: map[types.PackageManagerType]string{
+			types.TypeApt:      "docker-buildx-plugin",
+			types.TypeDnf:      "docker-buildx-plugin",
+			types.TypeYum:      "docker-buildx-plugin",
+			types.TypePacman:   "docker-buildx",
+			types.TypeHomebrew: "docker-buildx",
+		},
+	},
+	{
+		ID:          "podman",
+		Description: "Podman container engine",
+		Packages: map[types.PackageManagerType]string{
+			types.TypeApt:        "podman",
+			types.TypeDnf:        "podman",
+			types.TypeYum:        "podman",
+			types.TypePacman:     "podman",
+			types.TypeHomebrew:   "podman",
+			types.TypeChocolatey: "podman-cli",
+			types.TypeScoop:      "podman",
+			types.TypeWinget:     "RedHat.Podman",
+		},
+	},
+	{
+		ID:          "kubectl",
+		Aliases:     []string{"kubernetes"},
+		Description: "Kubernetes command-line tool",
+		Packages: map[types.PackageManagerType]string{
+			types.TypePacman:     "kubectl",
+			types.TypeSnap:       "kubectl",
+			types.TypeHomebrew:   "kubernetes-cli",
+			types.TypeChocolatey: "kubernetes-cli",
+			types.TypeScoop:      "kubectl",
+			types.TypeWinget:     "Kubernetes.kubectl",
+		},
+		Musl: map[types.PackageManagerType]string{
+			types.TypeApk: "kubectl",
+		},
+	},
+	{
+		ID:          "helm",
+		Description: "Helm package manager for Kubernetes",
+		Packages: map[types.PackageManagerType]string{
+			types.TypePacman:     "helm",
+			types.TypeSnap:       "helm",
+			types.TypeHomebrew:   "helm",
+			types.TypeChocolatey: "kubernetes-helm",
+			types.TypeScoop:      "helm",
+			types.TypeWinget:     "Helm.Helm",
+		},
+		Musl: map[types.PackageManagerType]string{
+			types.TypeApk: "helm",
+		},
+	},
+
+	// Cloud and Infrastructure
+	{
+		ID:          "aws-cli",
+		Aliases:     []string{"aws", "awscli"},
+		Description: "AWS command-line interface",
+		Packages: map[types.PackageManagerType]string{
+			types.TypeApt:        "awscli",
+			types.TypePacman:     "aws-cli",
+			types.TypeSnap:       "aws-cli",
+			types.TypeHomebrew:   "awscli",
+			types.TypeChocolatey: "awscli",
+			types.TypeScoop:      "aws",
+			types.TypeWinget:     "Amazon.AWSCLI",
+		},
+	},
+	{
+		ID:          "azure-cli",
+		Aliases:     []string{"az"},
+		Description: "Azure command-line interface",
+		Packages: map[types.PackageManagerType]string{
+			types.TypePacman:     "azure-cli",
+			types.TypeHomebrew:   "azure-cli",
+			types.TypeChocolatey: "azure-cli",
+			types.TypeScoop:      "azure-cli",
+			types.TypeWinget:     "Microsoft.AzureCLI",
+		},
+	},
+	{
+		ID:          "google-cloud-sdk",
+		Aliases:     []string{"gcloud"},
+		Description: "Google Cloud SDK",
+		Packages: map[types.PackageManagerType]string{
+			types.TypeSnap:       "google-cloud-cli",
+			types.TypeHomebrew:   "google-cloud-sdk",
+			types.TypeChocolatey: "gcloudsdk",
+			types.TypeScoop:      "gcloud",
+			types.TypeWinget:     "Google.CloudSDK",
+		},
+	},
+	{
+		ID:          "terraform",
+		Description: "Terraform",
+		Packages: map[types.PackageManagerType]string{
+			types.TypeSnap:       "terraform",
+			types.TypeHomebrew:   "terraform",
+			types.TypeChocolatey: "terraform",
+			types.TypeScoop:      "terraform",
+			types.TypeWinget:     "Hashicorp.Terraform",
+		},
+	},
+	{
+		ID:          "ansible",
+		Description: "Ansible automation",
+		Packages: map[types.PackageManagerType]string{
+			types.TypeApt:      "ansible",
+			types.TypeDnf:      "ansible",
+			types.TypeYum:      "ansible",
+			types.TypePacman:   "ansible",
+			types.TypeHomebrew: "ansible",
+		},
+		Musl: map[types.PackageManagerType]string{
+			types.TypeApk: "ansible",
+		},
+	},
+	{
+		ID:          "packer",
+		Description: "Packer image builder",
+		Packages: map[types.PackageManagerType]string{
+			types.TypePacman:     "packer",
+			types.TypeHomebrew:   "packer",
+			types.TypeChocolatey: "packer",
+			types.TypeScoop:      "packer",
+			types.TypeWinget:     "Hashicorp.Packer",
+		},
+	},
+
+	// Package Managers
+	{
+		ID:          "npm",
+		Description: "Node.js package manager",
+		Packages: map[types.PackageManagerType]string{
+			types.TypeApt:        "npm",
+			types.TypeDnf:        "npm",
+			types.TypeYum:        "npm",
+			types.TypePacman:     "npm",
+			types.TypeHomebrew:   "node",
+			types.TypeChocolatey: "nodejs",
+			types.TypeScoop:      "nodejs",
+			types.TypeWinget:     "OpenJS.NodeJS",
+		},
+		Musl: map[types.PackageManagerType]string{
+			types.TypeApk: "npm",
+		},
+	},
+	{
+		ID:          "yarn",
+		Description: "Yarn package manager",
+		Packages: map[types.PackageManagerType]string{
+			types.TypeApt:        "yarnpkg",
+			types.TypeDnf:        "yarnpkg",
+			types.TypePacman:     "yarn",
+			types.TypeHomebrew:   "yarn",
+			types.TypeChocolatey: "yarn",
+			types.TypeScoop:      "yarn",
+			types.TypeWinget:     "Yarn.Yarn",
+		},
+		Musl: map[types.PackageManagerType]string{
+			types.TypeApk: "yarn",
+		},
+	},
+	{
+		ID:          "pnpm",
+		Description: "pnpm package manager",
+		Packages: map[types.PackageManagerType]string{
+			types.TypePacman:     "pnpm",
+			types.TypeHomebrew:   "pnpm",
+			types.TypeChocolatey: "pnpm",
+			types.TypeScoop:      "pnpm",
+			types.TypeWinget:     "pnpm.pnpm",
+		},
+		Musl: map[types.PackageManagerType]string{
+			types.TypeApk: "pnpm",
+		},
+	},
+	{
+		ID:          "pip",
+		Aliases:     []string{"pip3"},
+		Description: "Python package installer",
+		Packages: map[types.PackageManagerType]string{
+			types.TypeApt:        "python3-pip",
+			types.TypeDnf:        "python3-pip",
+			types.TypeYum:        "python3-pip",
+			types.TypePacman:     "python-pip",
+			types.TypeHomebrew:   "python",
+			types.TypeChocolatey: "python",
+			types.TypeScoop:      "python",
+			types.TypeWinget:     "Python.Python.3",
+		},
+		Musl: map[types.PackageManagerType]string{
+			types.TypeApk: "py3-pip",
+		},
+	},
+	{
+		ID:          "pipx",
+		Description: "Install Python applications in isolated environments",
+		Packages: map[types.PackageManagerType]string{
+			types.TypeApt:      "pipx",
+			types.TypeDnf:      "pipx",
+			types.TypePacman:   "python-pipx",
+			types.TypeHomebrew: "pipx",
+			types.TypeScoop:    "pipx",
+		},
+		Musl: map[types.PackageManagerType]string{
+			types.TypeApk: "pipx",
+		},
+	},
+	{
+		ID:          "poetry",
+		Description: "Python dependency manager",
+		Packages: map[types.PackageManagerType]string{
+			types.TypeApt:      "python3-poetry",
+			types.TypeDnf:      "poetry",
+			types.TypePacman:   "python-poetry",
+			types.TypeHomebrew: "poetry",
+			types.TypeScoop:    "poetry",
+		},
+		Musl: map[types.PackageManagerType]string{
+			types.TypeApk: "poetry",
+		},
+	},
+	// System package managers ship with the OS or have their own installers
+	{
+		ID:          "homebrew",
+		Aliases:     []string{"brew"},
+		Description: "Homebrew package manager",
+	},
+	{
+		ID:          "macports",
+		Aliases:     []string{"port"},
+		Description: "MacPorts package manager",
+	},
+	{
+		ID:          "apt",
+		Description: "APT package manager",
+	},
+	{
+		ID:          "apt-get",
+		Description: "APT package manager",
+	},
+	{
+		ID:          "yum",
+		Description: "YUM package manager",
+	},
+	{
+		ID:          "dnf",
+		Description: "DNF package manager",
+	},
+	{
+		ID:          "pacman",
+		Description: "Pacman package manager",
+	},
+	{
+		ID:          "apk",
+		Aliases:     []string{"apk-tools"},
+		Description: "Alpine package manager",
+	},
+	{
+		ID:          "zypper",
+		Description: "Zypper package manager",
+	},
+	{
+		ID:          "snap",
+		Aliases:     []string{"snapd"},
+		Description: "Snap package manager",
+		Packages: map[types.PackageManagerType]string{
+			types.TypeApt: "snapd",
+			types.TypeDnf: "snapd",
+			types.TypeYum: "snapd",
+		},
+	},
+	{
+		ID:          "flatpak",
+		Description: "Flatpak application manager",
+		Packages: map[types.PackageManagerType]string{
+			types.TypeApt:    "flatpak",
+			types.TypeDnf:    "flatpak",
+			types.TypeYum:    "flatpak",
+			types.TypePacman: "flatpak",
+			types.TypeApk:    "flatpak",
+		},
+	},
+	{
+		// nix-env comes with nix
+		ID:          "nix",
+		Aliases:     []string{"nix-env"},
+		Description: "Nix package manager",
+		Packages: map[types.PackageManagerType]string{
+			types.TypeApt:    "nix-bin",
+			types.TypeDnf:    "nix",
+			types.TypePacman: "nix",
+		},
+	},
+	{
+		ID:          "chocolatey",
+		Aliases:     []string{"choco"},
+		Description: "Chocolatey package manager",
+	},
+	{
+		ID:          "scoop",
+		Description: "Scoop package manager",
+	},
+	{
+		ID:          "winget",
+		Description: "Windows Package Manager",
+	},
+
+	// Editors and IDEs
+	{
+		ID:          "vscode",
+		Aliases:     []string{"vs-code", "code", "visual-studio-code"},
+		Description: "Visual Studio Code",
+		Packages: map[types.PackageManagerType]string{
+			types.TypeSnap:       "code",
+			types.TypeHomebrew:   "visual-studio-code",
+			types.TypeChocolatey: "vscode",
+			types.TypeScoop:      "vscode",
+			types.TypeWinget:     "Microsoft.VisualStudioCode",
+		},
+	},
+	{
+		ID:          "sublime-text",
+		Aliases:     []string{"subl"},
+		Description: "Sublime Text",
+		Packages: map[types.PackageManagerType]string{
+			types.TypeSnap:       "sublime-text",
+			types.TypeHomebrew:   "sublime-text",
+			types.TypeChocolatey: "sublimetext4",
+			types.TypeScoop:      "sublime-text",
+			types.TypeWinget:     "SublimeHQ.SublimeText.4",
+		},
+	},
+	{
+		ID:          "atom",
+		Description: "Atom editor (discontinued)",
+	},
+	{
+		ID:          "vim",
+		Description: "Vim editor",
+		Packages: map[types.PackageManagerType]string{
+			types.TypeApt:        "vim",
+			types.TypeDnf:        "vim-enhanced",
+			types.TypeYum:        "vim-enhanced",
+			types.TypePacman:     "vim",
+			types.TypeHomebrew:   "vim",
+			types.TypeChocolatey: "vim",
+			types.TypeScoop:      "vim",
+			types.TypeWinget:     "vim.vim",
+		},
+		Musl: map[types.PackageManagerType]string{
+			types.TypeApk: "vim",
+		},
+	},
+	{
+		ID:          "neovim",
+		Aliases:     []string{"nvim"},
+		Description: "Neovim editor",
+		Packages: map[types.PackageManagerType]string{
+			types.TypeApt:        "neovim",
+			types.TypeDnf:        "neovim",
+			types.TypeYum:        "neovim",
+			types.TypePacman:     "neovim",
+			types.TypeSnap:       "nvim",
+			types.TypeHomebrew:   "neovim",
+			types.TypeChocolatey: "neovim",
+			types.TypeScoop:      "neovim",
+			types.TypeWinget:     "Neovim.Neovim",
+		},
+		Musl: map[types.PackageManagerType]string{
+			types.TypeApk: "neovim",
+		},
+	},
+	{
+		ID:          "emacs",
+		Description: "GNU Emacs",
+		Packages: map[types.PackageManagerType]string{
+			types.TypeApt:        "emacs",
+			types.TypeDnf:        "emacs",
+			types.TypeYum:        "emacs",
+			types.TypePacman:     "emacs",
+			types.TypeSnap:       "emacs",
+			types.TypeHomebrew:   "emacs",
+			types.TypeChocolatey: "emacs",
+			types.TypeScoop:      "emacs",
+			types.TypeWinget:     "GNU.Emacs",
+		},
+		Musl: map[types.PackageManagerType]string{
+			types.TypeApk: "emacs",
+		},
+	},
+	{
+		ID:          "nano",
+		Description: "GNU nano editor",
+		Packages: map[types.PackageManagerType]string{
+			types.TypeApt:        "nano",
+			types.TypeDnf:        "nano",
+			types.TypeYum:        "nano",
+			types.TypePacman:     "nano",
+			types.TypeHomebrew:   "nano",
+			types.TypeChocolatey: "nano",
+			types.TypeScoop:      "nano",
+		},
+		Musl: map[types.PackageManagerType]string{
+			types.TypeApk: "nano",
+		},
+	},
+	{
+		ID:          "intellij-idea",
+		Aliases:     []string{"idea"},
+		Description: "IntelliJ IDEA",
+		Packages: map[types.PackageManagerType]string{
+			types.TypeSnap:       "intellij-idea-community",
+			types.TypeHomebrew:   "intellij-idea-ce",
+			types.TypeChocolatey: "intellijidea-community",
+			types.TypeScoop:      "idea",
+			types.TypeWinget:     "JetBrains.IntelliJIDEA.Community",
+		},
+	},
+	{
+		ID:          "pycharm",
+		Description: "PyCharm",
+		Packages: map[types.PackageManagerType]string{
+			types.TypeSnap:       "pycharm-community",
+			types.TypeHomebrew:   "pycharm-ce",
+			types.TypeChocolatey: "pycharm-community",
+			types.TypeScoop:      "pycharm",
+			types.TypeWinget:     "JetBrains.PyCharm.Community",
+		},
+	},
+	{
+		ID:          "webstorm",
+		Description: "WebStorm",
+		Packages: map[types.PackageManagerType]string{
+			types.TypeSnap:       "webstorm",
+			types.TypeHomebrew:   "webstorm",
+			types.TypeChocolatey: "webstorm",
+			types.TypeScoop:      "webstorm",
+			types.TypeWinget:     "JetBrains.WebStorm",
+		},
+	},
+	{
+		ID:          "goland",
+		Description: "GoLand",
+		Packages: map[types.PackageManagerType]string{
+			types.TypeSnap:       "goland",
+			types.TypeHomebrew:   "goland",
+			types.TypeChocolatey: "goland",
+			types.TypeScoop:      "goland",
+			types.TypeWinget:     "JetBrains.GoLand",
+		},
+	},
+	{
+		ID:          "android-studio",
+		Aliases:     []string{"studio"},
+		Description: "Android Studio",
+		Packages: map[types.PackageManagerType]string{
+			types.TypeSnap:       "android-studio",
+			types.TypeHomebrew:   "android-studio",
+			types.TypeChocolatey: "androidstudio",
+			types.TypeScoop:      "android-studio",
+			types.TypeWinget:     "Google.AndroidStudio",
+		},
+	},
+	{
+		ID:          "xcode",
+		Description: "Xcode, installed from the App Store",
+	},
+	{
+		ID:          "visual-studio",
+		Aliases:     []string{"devenv"},
+		Description: "Visual Studio",
+		Packages: map[types.PackageManagerType]string{
+			types.TypeChocolatey: "visualstudio2022community",
+			types.TypeWinget:     "Microsoft.VisualStudio.2022.Community",
+		},
+	},
+	{
+		ID:          "dbeaver",
+		Description: "DBeaver database tool",
+		Packages: map[types.PackageManagerType]string{
+			types.TypeSnap:       "dbeaver-ce",
+			types.TypeHomebrew:   "dbeaver-community",
+			types.TypeChocolatey: "dbeaver",
+			types.TypeScoop:      "dbeaver",
+			types.TypeWinget:     "dbeaver.dbeaver",
+		},
+	},
+	{
+		ID:          "tableplus",
+		Description: "TablePlus database tool",
+		Packages: map[types.PackageManagerType]string{
+			types.TypeHomebrew:   "tableplus",
+			types.TypeChocolatey: "tableplus",
+			types.TypeWinget:     "TablePlus.TablePlus",
+		},
+	},
+	{
+		ID:          "github-desktop",
+		Description: "GitHub Desktop",
+		Packages: map[types.PackageManagerType]string{
+			types.TypeHomebrew:   "github",
+			types.TypeChocolatey: "github-desktop",
+			types.TypeScoop:      "github",
+			types.TypeWinget:     "GitHub.GitHubDesktop",
+		},
+	},
+	{
+		ID:          "gitkraken",
+		Description: "GitKraken",
+		Packages: map[types.PackageManagerType]string{
+			types.TypeSnap:       "gitkraken",
+			types.TypeHomebrew:   "gitkraken",
+			types.TypeChocolatey: "gitkraken",
+			types.TypeScoop:      "gitkraken",
+			types.TypeWinget:     "Axosoft.GitKraken",
+		},
+	},
+	{
+		ID:          "sourcetree",
+		Description: "Sourcetree",
+		Packages: map[types.PackageManagerType]string{
+			types.TypeHomebrew:   "sourcetree",
+			types.TypeChocolatey: "sourcetree",
+			types.TypeWinget:     "Atlassian.Sourcetree",
+		},
+	},
+	{
+		ID:          "windsurf",
+		Description: "Windsurf editor",
+		Packages: map[types.PackageManagerType]string{
+			types.TypeHomebrew: "windsurf",
+			types.TypeWinget:   "Codeium.Windsurf",
+		},
+	},
+	{
+		ID:          "cursor",
+		Description: "Cursor editor",
+		Packages: map[types.PackageManagerType]string{
+			types.TypeHomebrew: "cursor",
+			types.TypeWinget:   "Anysphere.Cursor",
+		},
+	},
+
+	// Terminal emulators, recorded from the environment of the scan
+	{
+		ID:          "iterm2",
+		Description: "iTerm2 terminal",
+		Packages: map[types.PackageManagerType]string{
+			types.TypeHomebrew: "iterm2",
+		},
+	},
+	{
+		ID:          "windows-terminal",
+		Description: "Windows Terminal",
+		Packages: map[types.PackageManagerType]string{
+			types.TypeChocolatey: "microsoft-windows-terminal",
+			types.TypeScoop:      "windows-terminal",
+			types.TypeWinget:     "Microsoft.WindowsTerminal",
+		},
+	},
+	{
+		ID:          "wezterm",
+		Description: "WezTerm terminal",
+		Packages: map[types.PackageManagerType]string{
+			types.TypePacman:     "wezterm",
+			types.TypeHomebrew:   "wezterm",
+			types.TypeChocolatey: "wezterm",
+			types.TypeScoop:      "wezterm",
+			types.TypeWinget:     "wez.wezterm",
+		},
+	},
+	{
+		ID:          "kitty",
+		Description: "kitty terminal",
+		Packages: map[types.PackageManagerType]string{
+			types.TypeApt:      "kitty",
+			types.TypeDnf:      "kitty",
+			types.TypePacman:   "kitty",
+			types.TypeApk:      "kitty",
+			types.TypeHomebrew: "kitty",
+		},
+	},
+	{
+		ID:          "alacritty",
+		Description: "Alacritty terminal",
+		Packages: map[types.PackageManagerType]string{
+			types.TypeApt:        "alacritty",
+			types.TypeDnf:        "alacritty",
+			types.TypePacman:     "alacritty",
+			types.TypeApk:        "alacritty",
+			types.TypeHomebrew:   "alacritty",
+			types.TypeChocolatey: "alacritty",
+			types.TypeScoop:      "alacritty",
+			types.TypeWinget:     "Alacritty.Alacritty",
+		},
+	},
+	{
+		ID:          "ghostty",
+		Description: "Ghostty terminal",
+		Packages: map[types.PackageManagerType]string{
+			types.TypePacman:   "ghostty",
+			types.TypeHomebrew: "ghostty",
+		},
+	},
+	{
+		ID:          "warp",
+		Description: "Warp terminal",
+		Packages: map[types.PackageManagerType]string{
+			types.TypeHomebrew: "warp",
+			types.TypeWinget:   "Warp.Warp",
+		},
 	},
 }
 
-// packageNameCache caches package name lookups to avoid repeated searches
-var packageNameCache = make(map[string]map[types.PackageManagerType]string)
+// mappingIndex maps canonical IDs and aliases to their index in packageMappings
+var mappingIndex = make(map[string]int)
 
-// init initializes the package name cache
 func init() {
-	for _, mapping := range packageMappings {
-		packageNameCache[strings.ToLower(mapping.Name)] = mapping.Packages
+	for i, mapping := range packageMappings {
+		indexMapping(i, mapping)
+	}
+}
+
+// indexMapping adds a mapping's ID and aliases to mappingIndex
+func indexMapping(i int, mapping PackageMapping) {
+	mappingIndex[types.CanonicalID(mapping.ID)] = i
+	for _, alias := range mapping.Aliases {
+		mappingIndex[types.CanonicalID(alias)] = i
+	}
+}
+
+// LookupMapping returns the mapping for a canonical ID, alias or display
+// name, which is canonicalized first
+func LookupMapping(name string) (PackageMapping, bool) {
+	i, ok := mappingIndex[types.CanonicalID(name)]
+	if !ok {
+		return PackageMapping{}, false
 	}
+	return packageMappings[i], true
 }
 
-// GetPackageName returns the package name for a given package and package manager
+// Libc is the C library of the machine packages are installed on, as
+// recorded in types.SystemInfo.Libc. Package names for musl (see
+// PackageMapping.Musl) are used when it is types.LibcMusl. It is "", and
+// only Packages is used, unless the CLI sets it.
+var Libc string
+
+// GetPackageName returns the package name for a given package and package
+// manager. pkg may be a canonical ID, an alias or a display name. Unknown
+// packages are returned unchanged.
 func GetPackageName(pkg string, pmType types.PackageManagerType) (string, error) {
-	// Check if the package name exists in our mappings
-	for _, mapping := range packageMappings {
-		if strings.EqualFold(mapping.Name, pkg) {
-			// Check if we have a mapping for this package manager
-			if pkgName, ok := mapping.Packages[pmType]; ok {
-				return pkgName, nil
+	mapping, ok := LookupMapping(pkg)
+	if !ok {
+		// No mapping found, return the original package name
+		return pkg, nil
+	}
+	if pkgName, ok := mapping.Musl[pmType]; ok && Libc == types.LibcMusl {
+		if pkgName == "" {
+			return "", fmt.Errorf("no package for '%s' on package manager %s on musl-based systems", pkg, pmType)
+		}
+		return pkgName, nil
+	}
+	if pkgName, ok := mapping.Packages[pmType]; ok {
+		return pkgName, nil
+	}
+	// No mapping for this package manager
+	return "", fmt.Errorf("no mapping found for package '%s' on package manager %s", pkg, pmType)
+}
+
+// MapsVersions reports whether the mapping of pkg tells pmType how to
+// install a given version of it
+func MapsVersions(pkg string, pmType types.PackageManagerType) bool {
+	mapping, ok := LookupMapping(pkg)
+	if !ok {
+		return false
+	}
+	_, ok = mapping.Versions[pmType]
+	return ok
+}
+
+// VersionResolutionError reports a version constraint that a package
+// manager's mapping can't turn into a package to install
+type VersionResolutionError struct {
+	Package    string
+	Manager    types.PackageManagerType
+	Constraint string
+	Reason     string
+}
+
+func (e *VersionResolutionError) Error() string {
+	return fmt.Sprintf("%s can't install %s %q: %s", GetPackageManagerName(e.Manager), e.Package, e.Constraint, e.Reason)
+}
+
+// ResolvePackage returns the package and version that install pkg at
+// constraint with pmType. Mappings with a VersionFormat for pmType turn the
+// constraint into the manager's format, such as node@18 for ">=18 <19" on
+// Homebrew or nodejs=18.* on apt, and fail with a VersionResolutionError
+// when they can't. Otherwise the package is that of GetPackageName and the
+// constraint is returned unchanged.
+func ResolvePackage(pkg string, pmType types.PackageManagerType, constraint string) (string, VersionConstraint, error) {
+	name, err := GetPackageName(pkg, pmType)
+	if err != nil {
+		return "", VersionConstraint{}, err
+	}
+	mapping, _ := LookupMapping(pkg)
+	format, ok := mapping.Versions[pmType]
+	if !ok || constraint == "" || strings.EqualFold(constraint, "Installed") {
+		return name, VersionConstraint{Version: constraint}, nil
+	}
+
+	fail := func(format string, args ...any) (string, VersionConstraint, error) {
+		return "", VersionConstraint{}, &VersionResolutionError{Package: pkg, Manager: pmType, Constraint: constraint, Reason: fmt.Sprintf(format, args...)}
+	}
+	lower, upper, err := version.Bounds(constraint)
+	if err != nil {
+		return fail("%v", err)
+	}
+
+	// Majors pick the newest major the constraint allows
+	major := -1
+	if format.Majors != nil {
+		majors := make([]int, 0, len(format.Majors))
+		for m := range format.Majors {
+			majors = append(majors, m)
+		}
+		sort.Sort(sort.Reverse(sort.IntSlice(majors)))
+		for _, m := range majors {
+			if (upper == nil || upper.Compare(&version.Version{Major: m}) > 0) && (lower == nil || lower.Compare(&version.Version{Major: m + 1}) < 0) {
+				major = m
+				break
 			}
-			// No mapping for this package manager
-			return "", fmt.Errorf("no mapping found for package '%s' on package manager %s", pkg, pmType)
 		}
+		if major < 0 {
+			return fail("it only has packages for major versions %s", joinInts(majors))
+		}
+		name = format.Majors[major]
+	}
+
+	// Minors pick the newest minor release the constraint allows
+	if format.Minors != nil {
+		var minors []*version.Version
+		for m := range format.Minors {
+			v, err := version.Parse(m)
+			if err != nil {
+				return fail("invalid minor release %q in mapping", m)
+			}
+			minors = append(minors, v)
+		}
+		sort.Slice(minors, func(i, j int) bool { return minors[i].Compare(minors[j]) > 0 })
+		var chosen *version.Version
+		for _, m := range minors {
+			if (upper == nil || upper.Compare(m) > 0) && (lower == nil || lower.Compare(&version.Version{Major: m.Major, Minor: m.Minor + 1}) < 0) {
+				chosen = m
+				break
+			}
+		}
+		if chosen == nil {
+			releases := make([]string, len(minors))
+			for i, m := range minors {
+				releases[i] = fmt.Sprintf("%d.%d", m.Major, m.Minor)
+			}
+			return fail("it only has packages for %s", joinWords(releases))
+		}
+		name = format.Minors[fmt.Sprintf("%d.%d", chosen.Major, chosen.Minor)]
+	}
+
+	// The placeholders a constraint pins down, and why the others can't be
+	// used
+	values := make(map[string]string)
+	placeholders := []struct{ name, reason string }{
+		{"{major}", "the constraint spans several major versions"},
+		{"{minor}", "the constraint spans several minor versions"},
+		{"{version}", "it needs an exact version such as 1.2.3"},
+	}
+	if major < 0 && lower != nil && upper != nil && upper.Compare(&version.Version{Major: lower.Major + 1}) <= 0 {
+		major = lower.Major
+	}
+	if major >= 0 {
+		values["{major}"] = strconv.Itoa(major)
+	}
+	if lower != nil && upper != nil && upper.Compare(&version.Version{Major: lower.Major, Minor: lower.Minor + 1}) <= 0 {
+		values["{minor}"] = strconv.Itoa(lower.Minor)
+	}
+	if exact := strings.TrimPrefix(strings.TrimSpace(constraint), "="); version.IsValid(exact) && !strings.ContainsAny(exact, "xX*") {
+		values["{version}"] = exact
+	}
+	expand := func(template string) (string, error) {
+		for _, p := range placeholders {
+			if !strings.Contains(template, p.name) {
+				continue
+			}
+			value, ok := values[p.name]
+			if !ok {
+				_, _, err := fail("%s", p.reason)
+				return "", err
+			}
+			template = strings.ReplaceAll(template, p.name, value)
+		}
+		return template, nil
+	}
+
+	if format.Package != "" && format.Majors == nil && format.Minors == nil {
+		if name, err = expand(format.Package); err != nil {
+			return "", VersionConstraint{}, err
+		}
+	}
+	resolved := VersionConstraint{}
+	if format.Version != "" {
+		if resolved.Version, err = expand(format.Version); err != nil {
+			return "", VersionConstraint{}, err
+		}
+	}
+	return name, resolved, nil
+}
+
+// joinInts lists numbers for messages, such as "22, 20 and 18"
+func joinInts(numbers []int) string {
+	words := make([]string, len(numbers))
+	for i, n := range numbers {
+		words[i] = strconv.Itoa(n)
+	}
+	return joinWords(words)
+}
+
+// joinWords lists words for messages, such as "3.13, 3.12 and 3.11"
+func joinWords(words []string) string {
+	if len(words) < 2 {
+		return strings.Join(words, "")
 	}
-	// No mapping found, return the original package name
-	return pkg, nil
+	return strings.Join(words[:len(words)-1], ", ") + " and " + words[len(words)-1]
 }
 
 // GetPackageManagerType returns the PackageManagerType for a given installer
@@ -141,6 +1530,8 @@ func GetPackageManagerName(pmType types.PackageManagerType) string {
 		return "YUM"
 	case types.TypePacman:
 		return "Pacman"
+	case types.TypeApk:
+		return "APK"
 	case types.TypeSnap:
 		return "Snap"
 	case types.TypeHomebrew:
@@ -164,14 +1555,15 @@ func GetAllPackageMappings() []PackageMapping {
 // AddPackageMapping adds a new package mapping
 func AddPackageMapping(mapping PackageMapping) error {
 	// Validate the mapping
-	if mapping.Name == "" {
-		return fmt.Errorf("package name cannot be empty")
+	if mapping.ID == "" {
+		return fmt.Errorf("package ID cannot be empty")
 	}
-	if len(mapping.Packages) == 0 {
+	if len(mapping.Packages) == 0 && len(mapping.Musl) == 0 {
 		return fmt.Errorf("at least one package manager mapping is required")
 	}
 
 	// Add the new mapping
 	packageMappings = append(packageMappings, mapping)
+	indexMapping(len(packageMappings)-1, mapping)
 	return nil
 }
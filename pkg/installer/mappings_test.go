@@ -0,0 +1,179 @@
+package installer
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+func TestResolvePackage(t *testing.T) {
+	testCases := []struct {
+		name            string
+		pkg             string
+		manager         types.PackageManagerType
+		constraint      string
+		expectedPackage string
+		expectedVersion string
+		expectedError   string
+	}{
+		{name: "node range on brew", pkg: "nodejs", manager: types.TypeHomebrew, constraint: ">=18 <19", expectedPackage: "node@18"},
+		{name: "node exact on brew", pkg: "Node.js", manager: types.TypeHomebrew, constraint: "20.11.0", expectedPackage: "node@20"},
+		{name: "node open range on brew", pkg: "node", manager: types.TypeHomebrew, constraint: ">=18", expectedPackage: "node"},
+		{name: "node LTS on brew", pkg: "nodejs", manager: types.TypeHomebrew, constraint: "24.11.1", expectedPackage: "node@24"},
+		{name: "node current on brew", pkg: "nodejs", manager: types.TypeHomebrew, constraint: "26.1.0", expectedPackage: "node"},
+		{
+			name: "node major brew doesn't ship", pkg: "nodejs", manager: types.TypeHomebrew, constraint: "^16.20",
+			expectedError: `Homebrew can't install nodejs "^16.20": it only has packages for major versions 26, 24, 22, 20 and 18`,
+		},
+		{name: "node range on apt", pkg: "nodejs", manager: types.TypeApt, constraint: ">=18 <19", expectedPackage: "nodejs", expectedVersion: "18.*"},
+		{name: "node caret on apt", pkg: "nodejs", manager: types.TypeApt, constraint: "^20.11", expectedPackage: "nodejs", expectedVersion: "20.*"},
+		{
+			name: "node across majors on apt", pkg: "nodejs", manager: types.TypeApt, constraint: ">=18 <21",
+			expectedError: `APT can't install nodejs ">=18 <21": the constraint spans several major versions`,
+		},
+		{name: "node exact on winget", pkg: "nodejs", manager: types.TypeWinget, constraint: "20.11.0", expectedPackage: "OpenJS.NodeJS", expectedVersion: "20.11.0"},
+		{
+			name: "node range on winget", pkg: "nodejs", manager: types.TypeWinget, constraint: ">=18 <19",
+			expectedError: `Winget can't install nodejs ">=18 <19": it needs an exact version such as 1.2.3`,
+		},
+		{name: "python exact on brew", pkg: "python", manager: types.TypeHomebrew, constraint: "3.12.1", expectedPackage: "python@3.12"},
+		{name: "python tilde on apt", pkg: "python3", manager: types.TypeApt, constraint: "~3.11.4", expectedPackage: "python3.11"},
+		{name: "python minor on winget", pkg: "python3", manager: types.TypeWinget, constraint: "3.12", expectedPackage: "Python.Python.3.12"},
+		{name: "python open range on winget", pkg: "python3", manager: types.TypeWinget, constraint: ">=3.11", expectedPackage: "Python.Python.3.13"},
+		{
+			name: "python minor winget doesn't ship", pkg: "python3", manager: types.TypeWinget, constraint: "3.7.9",
+			expectedError: `Winget can't install python3 "3.7.9": it only has packages for 3.13, 3.12, 3.11, 3.10 and 3.9`,
+		},
+		{
+			name: "python across minors on brew", pkg: "python3", manager: types.TypeHomebrew, constraint: "^3.11",
+			expectedError: `Homebrew can't install python3 "^3.11": the constraint spans several minor versions`,
+		},
+		{
+			name: "python invalid constraint", pkg: "python3", manager: types.TypeWinget, constraint: ">=latest",
+			expectedError: `Winget can't install python3 ">=latest": invalid version "latest" in constraint: "latest" does not start with a number`,
+		},
+		{name: "no version format", pkg: "python3", manager: types.TypePacman, constraint: "3.12.1", expectedPackage: "python", expectedVersion: "3.12.1"},
+		{name: "no constraint", pkg: "nodejs", manager: types.TypeHomebrew, expectedPackage: "node"},
+		{name: "unmapped package", pkg: "leftpad", manager: types.TypeApt, constraint: "1.0.0", expectedPackage: "leftpad", expectedVersion: "1.0.0"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			pkg, version, err := ResolvePackage(tc.pkg, tc.manager, tc.constraint)
+			if tc.expectedError != "" {
+				var resolution *VersionResolutionError
+				if !errors.As(err, &resolution) || err.Error() != tc.expectedError {
+					t.Errorf("expected error %q but got %v", tc.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error but got %v", err)
+			}
+			if pkg != tc.expectedPackage || version.Version != tc.expectedVersion {
+				t.Errorf("expected %s at %q but got %s at %q", tc.expectedPackage, tc.expectedVersion, pkg, version.Version)
+			}
+		})
+	}
+}
+
+// recordingInstaller records the packages it is asked to install
+type recordingInstaller struct {
+	Installer
+	pmType types.PackageManagerType
+	calls  []string
+}
+
+func (i *recordingInstaller) Type() types.PackageManagerType { return i.pmType }
+
+func (i *recordingInstaller) CheckVersion(ctx context.Context, pkg string, constraint VersionConstraint) (*PackageVersionInfo, error) {
+	return nil, &types.PackageNotFoundError{Package: pkg}
+}
+
+func (i *recordingInstaller) InstallPackage(ctx context.Context, pkg string) error {
+	i.calls = append(i.calls, pkg)
+	return nil
+}
+
+func (i *recordingInstaller) InstallVersion(ctx context.Context, pkg string, version VersionConstraint) error {
+	i.calls = append(i.calls, pkg+"="+version.Version)
+	return nil
+}
+
+func TestInstallWithMapping(t *testing.T) {
+	testCases := []struct {
+		manager  types.PackageManagerType
+		pkg      string
+		version  string
+		expected []string
+	}{
+		{manager: types.TypeHomebrew, pkg: "nodejs", version: ">=18 <19", expected: []string{"node@18"}},
+		{manager: types.TypeApt, pkg: "nodejs", version: ">=18 <19", expected: []string{"nodejs=18.*"}},
+		{manager: types.TypeWinget, pkg: "python3", version: "3.12.1", expected: []string{"Python.Python.3.12"}},
+		{manager: types.TypeApt, pkg: "git", version: "2.43.0", expected: []string{"git=2.43.0"}},
+		{manager: types.TypeApt, pkg: "git", expected: []string{"git"}},
+		// Constraints the manager can't express install the latest version
+		{manager: types.TypeWinget, pkg: "nodejs", version: ">=18 <19", expected: []string{"OpenJS.NodeJS"}},
+	}
+
+	for _, tc := range testCases {
+		inst := &recordingInstaller{pmType: tc.manager}
+		var version []VersionConstraint
+		if tc.version != "" {
+			version = append(version, VersionConstraint{Version: tc.version})
+		}
+		if err := installWithMapping(context.Background(), inst, tc.pkg, version...); err != nil {
+			t.Errorf("%s %s on %s: expected no error but got %v", tc.pkg, tc.version, tc.manager, err)
+			continue
+		}
+		if !reflect.DeepEqual(inst.calls, tc.expected) {
+			t.Errorf("%s %s on %s: expected installs %v but got %v", tc.pkg, tc.version, tc.manager, tc.expected, inst.calls)
+		}
+	}
+}
+
+func TestInstallWithMappingOnMusl(t *testing.T) {
+	testCases := []struct {
+		libc     string
+		pkg      string
+		expected []string
+		err      bool
+	}{
+		{libc: types.LibcMusl, pkg: "node", expected: []string{"nodejs"}},
+		{libc: types.LibcMusl, pkg: "build-essential", expected: []string{"build-base"}},
+		{libc: types.LibcMusl, pkg: "pip", expected: []string{"py3-pip"}},
+		// apk on glibc, as on Wolfi, names packages differently
+		{libc: types.LibcGlibc, pkg: "node", err: true},
+		{libc: "", pkg: "build-essential", err: true},
+	}
+
+	defer func() { Libc = "" }()
+	for _, tc := range testCases {
+		Libc = tc.libc
+		inst := &recordingInstaller{pmType: types.TypeApk}
+		err := installWithMapping(context.Background(), inst, tc.pkg)
+		if tc.err {
+			if err == nil || len(inst.calls) != 0 {
+				t.Errorf("%s on %q apk: expected no package and an error but got %v after %v", tc.pkg, tc.libc, err, inst.calls)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s on %q apk: expected no error but got %v", tc.pkg, tc.libc, err)
+			continue
+		}
+		if !reflect.DeepEqual(inst.calls, tc.expected) {
+			t.Errorf("%s on %q apk: expected installs %v but got %v", tc.pkg, tc.libc, tc.expected, inst.calls)
+		}
+	}
+
+	// glibc systems keep using Packages
+	Libc = types.LibcMusl
+	inst := &recordingInstaller{pmType: types.TypeHomebrew}
+	if err := installWithMapping(context.Background(), inst, "nodejs"); err != nil || !reflect.DeepEqual(inst.calls, []string{"node"}) {
+		t.Errorf("expected node from Homebrew, which has no musl names, but got %v (%v)", inst.calls, err)
+	}
+}
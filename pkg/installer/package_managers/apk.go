@@ -0,0 +1,95 @@
+package package_managers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+type apk struct {
+	*basePackageManager
+}
+
+// NewApk creates a new APK package manager instance, as found on Alpine
+func NewApk() types.Installer {
+	return &apk{
+		basePackageManager: &basePackageManager{
+			name:           "APK",
+			pmType:         types.TypeApk,
+			executableName: "apk",
+		},
+	}
+}
+
+func (a *apk) InstallPackage(ctx context.Context, pkg string) error {
+	// First check if already installed
+	installed, err := a.checkIfInstalled(ctx, pkg)
+	if err != nil {
+		return fmt.Errorf("failed to check if package is installed: %w", err)
+	}
+
+	if installed {
+		return &types.PackageAlreadyInstalledError{Package: pkg}
+	}
+
+	// apk never prompts
+	_, err = a.runCommand(ctx, "add", pkg)
+	if err != nil {
+		return fmt.Errorf("failed to install package: %w", err)
+	}
+
+	return nil
+}
+
+func (a *apk) InstallMultiple(ctx context.Context, packages []string) error {
+	if len(packages) == 0 {
+		return nil
+	}
+
+	args := append([]string{"add"}, packages...)
+	_, err := a.runCommand(ctx, args...)
+	if err != nil {
+		return fmt.Errorf("failed to install packages: %w", err)
+	}
+
+	return nil
+}
+
+// UninstallPackage removes a package with 'apk del'; apk has no remove
+// subcommand
+func (a *apk) UninstallPackage(ctx context.Context, pkg string) error {
+	_, err := a.runCommand(ctx, "del", pkg)
+	if err != nil {
+		return fmt.Errorf("failed to uninstall package %s: %w", pkg, err)
+	}
+	return nil
+}
+
+func (a *apk) UpdatePackageManager(ctx context.Context) error {
+	// Update the package index
+	_, err := a.runCommand(ctx, "update")
+	if err != nil {
+		return fmt.Errorf("failed to update package index: %w", err)
+	}
+
+	// Upgrade all packages
+	_, err = a.runCommand(ctx, "upgrade")
+	if err != nil {
+		return fmt.Errorf("failed to upgrade packages: %w", err)
+	}
+
+	return nil
+}
+
+// checkIfInstalled overrides the base implementation with APK-specific logic
+func (a *apk) checkIfInstalled(ctx context.Context, pkg string) (bool, error) {
+	// apk info -e prints the package name and exits 0 only if it is installed
+	output, err := a.runCommand(ctx, "info", "-e", pkg)
+	if err != nil {
+		return false, nil
+	}
+
+	return strings.TrimSpace(output) != "", nil
+}
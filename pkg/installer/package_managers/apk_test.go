@@ -0,0 +1,28 @@
+package package_managers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/runner/runnertest"
+)
+
+func TestApkCommands(t *testing.T) {
+	r := &runnertest.Runner{Responses: map[string]runnertest.Response{
+		"apk add git jq": {},
+		"apk del jq":     {},
+	}}
+	a := &apk{basePackageManager: &basePackageManager{name: "APK", executableName: "apk", runner: r}}
+
+	if err := a.InstallMultiple(context.Background(), []string{"git", "jq"}); err != nil {
+		t.Fatalf("expected the install to succeed but got %v", err)
+	}
+	if err := a.UninstallPackage(context.Background(), "jq"); err != nil {
+		t.Fatalf("expected the uninstall to succeed but got %v", err)
+	}
+	expected := []string{"apk add git jq", "apk del jq"}
+	if !reflect.DeepEqual(r.Calls(), expected) {
+		t.Errorf("expected calls %q but got %q", expected, r.Calls())
+	}
+}
@@ -100,6 +100,19 @@ func (a *apt) InstallMultiple(ctx context.Context, packages []string) error {
 	return a.installMultiple(ctx, packages)
 }
 
+// ReinstallPackages implements the Reinstaller interface
+func (a *apt) ReinstallPackages(ctx context.Context, packages []string) error {
+	if len(packages) == 0 {
+		return nil
+	}
+
+	args := append([]string{"install", "--reinstall", "--assume-yes"}, packages...)
+	if _, err := a.runCommand(ctx, args...); err != nil {
+		return fmt.Errorf("failed to reinstall packages: %w", err)
+	}
+	return nil
+}
+
 // InstallMultipleVersions installs multiple packages with specific versions
 func (a *apt) InstallMultipleVersions(ctx context.Context, packages map[string]types.VersionConstraint) error {
 	if len(packages) == 0 {
@@ -3,10 +3,11 @@ package package_managers
 import (
 	"context"
 	"fmt"
-	"os/exec"
 	"regexp"
+	"slices"
 	"strings"
 
+	"github.com/MRQ67/stackmatch-cli/pkg/runner"
 	"github.com/MRQ67/stackmatch-cli/pkg/types"
 	"github.com/MRQ67/stackmatch-cli/pkg/version"
 )
@@ -28,6 +29,26 @@ type basePackageManager struct {
 	installMultipleFunc func(ctx context.Context, packages []string) error
 	// uninstallPackageFunc is a function to uninstall a package
 	uninstallPackageFunc func(ctx context.Context, pkg string) error
+	// runner runs commands; runner.Default when nil
+	runner runner.Runner
+	// path resolves executables; runner.DefaultPath when nil
+	path runner.PathIndex
+}
+
+// commandRunner returns the runner used for this package manager's commands
+func (b *basePackageManager) commandRunner() runner.Runner {
+	if b.runner != nil {
+		return b.runner
+	}
+	return runner.Default
+}
+
+// pathIndex returns the PATH index used to find executables
+func (b *basePackageManager) pathIndex() runner.PathIndex {
+	if b.path != nil {
+		return b.path
+	}
+	return runner.DefaultPath
 }
 
 // UninstallPackage uninstalls a package using the package manager's uninstall command
@@ -73,18 +94,35 @@ func (b *basePackageManager) Type() types.PackageManagerType {
 }
 
 func (b *basePackageManager) IsAvailable() bool {
-	_, err := exec.LookPath(b.executableName)
+	_, err := b.pathIndex().LookPath(b.executableName)
 	return err == nil
 }
 
-// runCommand is a helper method to run shell commands
+// runCommand is a helper method to run shell commands. Failures reporting
+// conflicting packages are returned as *types.PackageConflictError.
 func (b *basePackageManager) runCommand(ctx context.Context, args ...string) (string, error) {
-	cmd := exec.CommandContext(ctx, b.executableName, args...)
-	output, err := cmd.CombinedOutput()
+	output, err := b.commandRunner().CombinedOutput(ctx, b.executableName, args...)
 	if err != nil {
-		return "", fmt.Errorf("command failed: %v\nOutput: %s", err, string(output))
+		return "", conflictError(b.name, output, fmt.Errorf("command failed: %v\nOutput: %s", err, output))
 	}
-	return string(output), nil
+	return output, nil
+}
+
+// reinstallEach reinstalls packages one at a time, running the package
+// manager with reinstallArgs and then the package. Package managers that
+// reject reinstalling packages that are not installed, as when the broken
+// tool came from elsewhere, would fail a single command for all of them, so
+// a package that can't be reinstalled is installed with installArgs instead.
+func (b *basePackageManager) reinstallEach(ctx context.Context, packages, reinstallArgs, installArgs []string) error {
+	for _, pkg := range packages {
+		if _, err := b.runCommand(ctx, append(slices.Clone(reinstallArgs), pkg)...); err == nil {
+			continue
+		}
+		if _, err := b.runCommand(ctx, append(slices.Clone(installArgs), pkg)...); err != nil {
+			return fmt.Errorf("failed to reinstall %s: %w", pkg, err)
+		}
+	}
+	return nil
 }
 
 // GetInstalledVersion gets the installed version of a package
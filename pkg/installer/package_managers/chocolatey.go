@@ -1,8 +1,14 @@
 package package_managers
 
 import (
+	"cmp"
 	"context"
+	"encoding/xml"
+	"errors"
 	"fmt"
+	"log"
+	"os"
+	"path/filepath"
 	"regexp"
 	"strings"
 
@@ -12,6 +18,9 @@ import (
 
 type chocolatey struct {
 	*basePackageManager
+	// lib is the directory Chocolatey keeps installed packages in;
+	// chocolateyLib() when empty
+	lib string
 }
 
 // NewChocolatey creates a new Chocolatey package manager instance
@@ -45,33 +54,153 @@ func (c *chocolatey) installPackage(ctx context.Context, pkg string) error {
 	}
 
 	// Install the package with --yes to avoid prompts
-	_, err = c.runCommand(ctx, "install", "--yes", pkg)
+	output, err := c.runCommand(ctx, "install", "--yes", pkg)
 	if err != nil {
 		return fmt.Errorf("failed to install package: %w", err)
 	}
+	reportChocoReboots(ctx, output)
 
 	return nil
 }
 
+// chocoRebootRegex matches the entries choco lists under "Packages requiring reboot:"
+var chocoRebootRegex = regexp.MustCompile(`(?m)^\s*-\s*(\S+)\s+\(exit code 3010\)`)
+
+// parseChocoRebootPackages extracts the packages choco says need a reboot to finish installing
+func parseChocoRebootPackages(output string) []string {
+	var packages []string
+	for _, match := range chocoRebootRegex.FindAllStringSubmatch(output, -1) {
+		packages = append(packages, match[1])
+	}
+	return packages
+}
+
+// reportChocoReboots records a manual step for every package waiting on a reboot
+func reportChocoReboots(ctx context.Context, output string) {
+	for _, pkg := range parseChocoRebootPackages(output) {
+		types.AddManualStep(ctx, types.ManualStep{
+			Category:    types.CategoryTools,
+			Description: fmt.Sprintf("Restart Windows to finish installing %s", pkg),
+			DocURL:      "https://docs.chocolatey.org/en-us/choco/commands/install/#exit-codes",
+		})
+	}
+}
+
+// protectedChocolateyPackages are never uninstalled, even during rollback
+var protectedChocolateyPackages = map[string]bool{
+	"chocolatey":                true,
+	"chocolatey-core.extension": true,
+	"powershell":                true,
+	"powershell-core":           true,
+}
+
+// chocoUninstalledRegex matches the per-package success line of choco uninstall
+var chocoUninstalledRegex = regexp.MustCompile(`(?m)^\s*(\S+) has been successfully uninstalled\.?\s*$`)
+
 // uninstallPackage uninstalls a package using Chocolatey
 func (c *chocolatey) uninstallPackage(ctx context.Context, pkg string) error {
+	_, err := c.UninstallPackageWithReport(ctx, pkg, types.UninstallOptions{})
+	return err
+}
+
+// UninstallPackageWithReport uninstalls a package and reports every package
+// Chocolatey removed, including dependencies when RemoveDependencies is set
+func (c *chocolatey) UninstallPackageWithReport(ctx context.Context, pkg string, opts types.UninstallOptions) (*types.UninstallResult, error) {
+	if protectedChocolateyPackages[strings.ToLower(pkg)] {
+		return nil, fmt.Errorf("refusing to uninstall protected package %s", pkg)
+	}
+
 	// First check if installed
 	installed, err := c.checkIfInstalled(ctx, pkg)
 	if err != nil {
-		return fmt.Errorf("failed to check if package is installed: %w", err)
+		return nil, fmt.Errorf("failed to check if package is installed: %w", err)
 	}
 
 	if !installed {
-		return nil // Already uninstalled
+		return &types.UninstallResult{}, nil // Already uninstalled
 	}
 
-	// Uninstall the package with --yes to avoid prompts
-	_, err = c.runCommand(ctx, "uninstall", "--yes", pkg)
+	// Uninstall the package with --yes to avoid prompts. Dependencies are
+	// only removed when none of them is protected.
+	args := []string{"uninstall", "--yes", pkg}
+	if opts.RemoveDependencies {
+		protected, err := c.protectedDependency(pkg)
+		switch {
+		case err != nil:
+			log.Printf("Warning: leaving the dependencies of %s installed: %v", pkg, err)
+		case protected != "":
+			log.Printf("Warning: leaving the dependencies of %s installed: it depends on %s, which is never uninstalled", pkg, protected)
+		default:
+			args = append(args, "--remove-dependencies")
+		}
+	}
+	output, err := c.runCommand(ctx, args...)
 	if err != nil {
-		return fmt.Errorf("failed to uninstall package: %w", err)
+		return nil, fmt.Errorf("failed to uninstall package: %w", err)
 	}
 
-	return nil
+	return &types.UninstallResult{Removed: parseChocoUninstallOutput(output)}, nil
+}
+
+// chocolateyLib returns the directory Chocolatey keeps installed packages in
+func chocolateyLib() string {
+	return filepath.Join(cmp.Or(os.Getenv("ChocolateyInstall"), `C:\ProgramData\chocolatey`), "lib")
+}
+
+// chocoNuspec holds the dependencies of an installed package's .nuspec
+type chocoNuspec struct {
+	Dependencies []struct {
+		ID string `xml:"id,attr"`
+	} `xml:"metadata>dependencies>dependency"`
+	// Packages targeting several frameworks list them by group
+	GroupDependencies []struct {
+		ID string `xml:"id,attr"`
+	} `xml:"metadata>dependencies>group>dependency"`
+}
+
+// protectedDependency returns the first of protectedChocolateyPackages that
+// pkg depends on, directly or through its other dependencies, as the
+// .nuspec files of the installed packages list them, or "" when there is
+// none. Dependencies that aren't installed are passed over.
+func (c *chocolatey) protectedDependency(pkg string) (string, error) {
+	lib := cmp.Or(c.lib, chocolateyLib())
+	seen := map[string]bool{strings.ToLower(pkg): true}
+	queue := []string{pkg}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		data, err := os.ReadFile(filepath.Join(lib, id, id+".nuspec"))
+		if errors.Is(err, os.ErrNotExist) && id != pkg {
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("could not read its dependencies: %w", err)
+		}
+		var spec chocoNuspec
+		if err := xml.Unmarshal(data, &spec); err != nil {
+			return "", fmt.Errorf("could not read its dependencies: %w", err)
+		}
+		for _, dependency := range append(spec.Dependencies, spec.GroupDependencies...) {
+			name := strings.ToLower(dependency.ID)
+			if protectedChocolateyPackages[name] {
+				return dependency.ID, nil
+			}
+			if !seen[name] {
+				seen[name] = true
+				queue = append(queue, dependency.ID)
+			}
+		}
+	}
+	return "", nil
+}
+
+// parseChocoUninstallOutput extracts the names of the packages choco reported as uninstalled
+func parseChocoUninstallOutput(output string) []string {
+	var removed []string
+	for _, match := range chocoUninstalledRegex.FindAllStringSubmatch(output, -1) {
+		removed = append(removed, match[1])
+	}
+	return removed
 }
 
 // InstallPackage implements the Installer interface
@@ -133,10 +262,11 @@ func (c *chocolatey) installMultiple(ctx context.Context, packages []string) err
 	args := append([]string{"install"}, packages...)
 	args = append(args, "-y") // Assume yes to all prompts
 
-	_, err := c.runCommand(ctx, args...)
+	output, err := c.runCommand(ctx, args...)
 	if err != nil {
 		return fmt.Errorf("failed to install packages: %w", err)
 	}
+	reportChocoReboots(ctx, output)
 
 	return nil
 }
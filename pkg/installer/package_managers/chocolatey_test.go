@@ -0,0 +1,39 @@
+package package_managers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+func TestReportChocoReboots(t *testing.T) {
+	output := `Chocolatey v2.2.2
+Installing the following packages:
+docker-desktop;git
+docker-desktop v4.25.0
+ The install of docker-desktop was successful.
+git v2.43.0
+ The install of git was successful.
+
+Chocolatey installed 2/2 packages.
+
+Packages requiring reboot:
+ - docker-desktop (exit code 3010)
+
+The recent package changes indicate a reboot is necessary.
+`
+	collector := &types.StepCollector{}
+	reportChocoReboots(types.WithStepCollector(context.Background(), collector), output)
+
+	steps := collector.Steps()
+	if len(steps) != 1 {
+		t.Fatalf("expected 1 manual step but got %d: %+v", len(steps), steps)
+	}
+	if steps[0].Description != "Restart Windows to finish installing docker-desktop" {
+		t.Errorf("unexpected step description %q", steps[0].Description)
+	}
+
+	// Without a collector on the context reporting is a no-op
+	reportChocoReboots(context.Background(), output)
+}
@@ -0,0 +1,91 @@
+package package_managers
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+var (
+	// aptConflict matches the unmet dependency apt reports for a package
+	// that conflicts with another, such as " docker-ce : Conflicts: docker.io",
+	// and the further conflicts listed under it, such as
+	// "             Breaks: docker-engine"
+	aptConflict = regexp.MustCompile(`^\s*(?:(\S+)\s+:\s+)?(?:Conflicts|Breaks):\s+(\S+)`)
+	// rpmConflict matches the problem dnf and yum report for conflicting
+	// packages, such as "package moby-engine-24.0.5-1.fc39.x86_64 conflicts
+	// with docker-ce provided by docker-ce-3:24.0.7-1.fc39.x86_64"
+	rpmConflict = regexp.MustCompile(`package (\S+) conflicts with (\S+)`)
+	// pacmanConflict matches the question pacman answers no to with
+	// --noconfirm, such as ":: docker-ce and docker are in conflict"
+	pacmanConflict = regexp.MustCompile(`^:: (\S+) and (\S+) are in conflict`)
+	// brewConflict matches the error of Homebrew for formulae that install
+	// the same files, such as "Error: Cannot install mariadb because
+	// conflicting formulae are installed.", followed by a line per
+	// installed formula, such as "  mysql: because both install the same
+	// binaries"
+	brewConflict        = regexp.MustCompile(`Cannot install (\S+) because conflicting formulae are installed`)
+	brewConflictFormula = regexp.MustCompile(`^\s+(\S+): because`)
+)
+
+// ParseConflicts returns the packages the output of a failed install names
+// as conflicting, in order, and whether it reports a conflict at all
+func ParseConflicts(output string) ([]string, bool) {
+	var packages []string
+	found := false
+	seen := make(map[string]bool)
+	add := func(names ...string) {
+		found = true
+		for _, name := range names {
+			if name != "" && !seen[name] {
+				seen[name] = true
+				packages = append(packages, name)
+			}
+		}
+	}
+
+	inBrewConflict := false
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if inBrewConflict {
+			if m := brewConflictFormula.FindStringSubmatch(line); m != nil {
+				add(m[1])
+				continue
+			}
+			inBrewConflict = false
+		}
+		if m := brewConflict.FindStringSubmatch(line); m != nil {
+			add(m[1])
+			inBrewConflict = true
+			continue
+		}
+		if m := aptConflict.FindStringSubmatch(line); m != nil {
+			add(m[1], m[2])
+			continue
+		}
+		if m := rpmConflict.FindStringSubmatch(line); m != nil {
+			add(m[1], m[2])
+			continue
+		}
+		if m := pacmanConflict.FindStringSubmatch(line); m != nil {
+			add(m[1], m[2])
+			continue
+		}
+		if strings.Contains(line, "unresolvable package conflicts detected") {
+			add()
+		}
+	}
+	return packages, found
+}
+
+// conflictError returns err as a *types.PackageConflictError when output,
+// that of the command that failed, reports conflicting packages, and err as
+// is otherwise
+func conflictError(manager, output string, err error) error {
+	packages, ok := ParseConflicts(output)
+	if !ok {
+		return err
+	}
+	return &types.PackageConflictError{Manager: manager, Packages: packages, Err: err}
+}
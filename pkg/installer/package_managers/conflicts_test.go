@@ -0,0 +1,111 @@
+package package_managers
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/runner/runnertest"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+const aptConflictOutput = `Reading package lists...
+Building dependency tree...
+Reading state information...
+Some packages could not be installed. This may mean that you have
+requested an impossible situation or if you are using the unstable
+distribution that some required packages have not yet been created
+or been moved out of Incoming.
+The following information may help to resolve the situation:
+
+The following packages have unmet dependencies:
+ docker-ce : Conflicts: docker.io
+             Conflicts: docker-engine
+ containerd.io : Conflicts: containerd
+E: Unable to correct problems, you have held broken packages.
+`
+
+func TestParseConflicts(t *testing.T) {
+	testCases := []struct {
+		name     string
+		output   string
+		expected []string
+		conflict bool
+	}{
+		{
+			name:     "apt",
+			output:   aptConflictOutput,
+			expected: []string{"docker-ce", "docker.io", "docker-engine", "containerd.io", "containerd"},
+			conflict: true,
+		},
+		{
+			name: "dnf",
+			output: `Last metadata expiration check: 0:12:03 ago.
+Error:
+ Problem: problem with installed package moby-engine-24.0.5-1.fc39.x86_64
+  - package moby-engine-24.0.5-1.fc39.x86_64 conflicts with docker-ce provided by docker-ce-3:24.0.7-1.fc39.x86_64
+  - conflicting requests
+(try to add '--allowerasing' to command line to replace conflicting packages)
+`,
+			expected: []string{"moby-engine-24.0.5-1.fc39.x86_64", "docker-ce"},
+			conflict: true,
+		},
+		{
+			name: "pacman",
+			output: `resolving dependencies...
+looking for conflicting packages...
+:: docker-ce and docker are in conflict. Remove docker? [y/N]
+error: unresolvable package conflicts detected
+error: failed to prepare transaction (conflicting dependencies)
+`,
+			expected: []string{"docker-ce", "docker"},
+			conflict: true,
+		},
+		{
+			name: "Homebrew",
+			output: `Error: Cannot install mariadb because conflicting formulae are installed.
+  mysql: because both install the same binaries
+  percona-server: because both install the same binaries
+
+Please ` + "`brew unlink mysql percona-server`" + ` before continuing.
+`,
+			expected: []string{"mariadb", "mysql", "percona-server"},
+			conflict: true,
+		},
+		{
+			name:   "Other failure",
+			output: "E: Unable to locate package nosuchpackage\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			packages, conflict := ParseConflicts(tc.output)
+			if conflict != tc.conflict || !reflect.DeepEqual(packages, tc.expected) {
+				t.Errorf("expected %v (conflict %v) but got %v (conflict %v)", tc.expected, tc.conflict, packages, conflict)
+			}
+		})
+	}
+}
+
+func TestInstallReportsConflicts(t *testing.T) {
+	r := &runnertest.Runner{Responses: map[string]runnertest.Response{
+		"apt install --assume-yes docker-ce docker.io": {Output: aptConflictOutput, Err: errors.New("exit status 100")},
+		"apt install --assume-yes nosuchpackage":       {Output: "E: Unable to locate package nosuchpackage\n", Err: errors.New("exit status 100")},
+	}}
+	a := &apt{basePackageManager: &basePackageManager{name: "APT", executableName: "apt", runner: r}}
+
+	err := a.InstallMultiple(context.Background(), []string{"docker-ce", "docker.io"})
+	var conflict *types.PackageConflictError
+	if !errors.As(err, &conflict) {
+		t.Fatalf("expected a conflict error but got %v", err)
+	}
+	if conflict.Manager != "APT" || len(conflict.Packages) != 5 {
+		t.Errorf("expected the conflict APT reported but got %+v", conflict)
+	}
+
+	if err := a.InstallMultiple(context.Background(), []string{"nosuchpackage"}); err == nil || errors.As(err, &conflict) {
+		t.Errorf("expected a plain error but got %v", err)
+	}
+}
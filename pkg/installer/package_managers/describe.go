@@ -0,0 +1,26 @@
+package package_managers
+
+import (
+	"context"
+	"regexp"
+	"time"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+// managerVersion matches the version in the output of '<manager> --version',
+// such as "apt 2.4.11 (amd64)", "Homebrew 4.2.0" or "Pacman v6.0.2"
+var managerVersion = regexp.MustCompile(`\d+(?:\.\d+)+`)
+
+// Describe implements the MetadataDescriber interface with the executable
+// and the version the package manager reports
+func (b *basePackageManager) Describe(ctx context.Context) types.PackageManagerMetadata {
+	metadata := types.PackageManagerMetadata{Type: b.pmType, Executable: b.executableName, VerifiedAt: time.Now().UTC()}
+	if path, err := b.pathIndex().LookPath(b.executableName); err == nil {
+		metadata.Executable = path
+	}
+	if output, err := b.probeTool(ctx, b.executableName, "--version"); err == nil {
+		metadata.Version = managerVersion.FindString(firstLine(output))
+	}
+	return metadata
+}
@@ -0,0 +1,74 @@
+package package_managers
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/runner/runnertest"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+func TestDescribe(t *testing.T) {
+	testCases := []struct {
+		name       string
+		manager    func(r *runnertest.Runner) types.MetadataDescriber
+		responses  map[string]runnertest.Response
+		executable string
+		version    string
+	}{
+		{
+			name: "Homebrew",
+			manager: func(r *runnertest.Runner) types.MetadataDescriber {
+				return newHomebrew("/opt/homebrew/bin/brew", r, runnertest.NewPath(nil, "/opt/homebrew/bin/brew"))
+			},
+			responses: map[string]runnertest.Response{
+				"/opt/homebrew/bin/brew --version": {Output: "Homebrew 4.2.0\nHomebrew/homebrew-core (git revision 1a2b3c; last commit 2026-10-01)\n"},
+			},
+			executable: "/opt/homebrew/bin/brew",
+			version:    "4.2.0",
+		},
+		{
+			name: "Chocolatey",
+			manager: func(r *runnertest.Runner) types.MetadataDescriber {
+				c := NewChocolatey().(*chocolatey)
+				c.runner = r
+				c.path = runnertest.NewPath([]string{"/choco/bin"}, "/choco/bin/choco")
+				return c
+			},
+			responses: map[string]runnertest.Response{
+				"choco --version": {Output: "2.2.2\n"},
+			},
+			executable: "/choco/bin/choco",
+			version:    "2.2.2",
+		},
+		{
+			name: "Failing probe leaves the version empty",
+			manager: func(r *runnertest.Runner) types.MetadataDescriber {
+				a := NewApt().(*apt)
+				a.runner = r
+				a.path = runnertest.NewPath(nil)
+				return a
+			},
+			responses: map[string]runnertest.Response{
+				"apt --version": {Err: errors.New("exit status 1")},
+			},
+			executable: "apt",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			metadata := tc.manager(&runnertest.Runner{Responses: tc.responses}).Describe(context.Background())
+			if metadata.Executable != tc.executable {
+				t.Errorf("expected executable %s but got %s", tc.executable, metadata.Executable)
+			}
+			if metadata.Version != tc.version {
+				t.Errorf("expected version %q but got %q", tc.version, metadata.Version)
+			}
+			if metadata.VerifiedAt.IsZero() {
+				t.Error("expected the probe time to be recorded")
+			}
+		})
+	}
+}
@@ -8,6 +8,12 @@ import (
 	"github.com/MRQ67/stackmatch-cli/pkg/types"
 )
 
+// isPackageGroup reports whether pkg names a DNF or YUM package group, such
+// as "@development-tools"
+func isPackageGroup(pkg string) bool {
+	return strings.HasPrefix(pkg, "@")
+}
+
 type dnf struct {
 	*basePackageManager
 }
@@ -24,6 +30,15 @@ func NewDnf() types.Installer {
 }
 
 func (d *dnf) InstallPackage(ctx context.Context, pkg string) error {
+	// Groups are not listed as installed packages, and installing one
+	// again is a no-op
+	if isPackageGroup(pkg) {
+		if _, err := d.runCommand(ctx, "install", "-y", pkg); err != nil {
+			return fmt.Errorf("failed to install group: %w", err)
+		}
+		return nil
+	}
+
 	// First check if already installed
 	installed, err := d.checkIfInstalled(ctx, pkg)
 	if err != nil {
@@ -58,6 +73,11 @@ func (d *dnf) InstallMultiple(ctx context.Context, packages []string) error {
 	return nil
 }
 
+// ReinstallPackages implements the Reinstaller interface
+func (d *dnf) ReinstallPackages(ctx context.Context, packages []string) error {
+	return d.reinstallEach(ctx, packages, []string{"reinstall", "-y"}, []string{"install", "-y"})
+}
+
 func (d *dnf) UpdatePackageManager(ctx context.Context) error {
 	// Update all packages
 	_, err := d.runCommand(ctx, "upgrade", "-y")
@@ -0,0 +1,206 @@
+package package_managers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/runner"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+	"github.com/MRQ67/stackmatch-cli/pkg/version"
+)
+
+// dnfModuleLanguages maps scanner language names to the DNF modules whose
+// streams ship them, as on RHEL and its rebuilds
+var dnfModuleLanguages = map[string]string{
+	"Node.js": "nodejs",
+	"Ruby":    "ruby",
+	"PHP":     "php",
+	"Perl":    "perl",
+}
+
+// DnfModuleFor returns the DNF module that ships the language called name,
+// or "" when it is not packaged as a module
+func DnfModuleFor(name string) string {
+	return dnfModuleLanguages[name]
+}
+
+// DnfModule is one stream of a DNF module, such as nodejs:18
+type DnfModule struct {
+	Name   string
+	Stream string
+	// Default is set for the stream installed when none is named ([d])
+	Default bool
+	// Enabled is set for the stream packages are currently installed from ([e])
+	Enabled bool
+}
+
+func (m DnfModule) String() string {
+	return m.Name + ":" + m.Stream
+}
+
+// Source returns the provenance recorded for tools installed from the stream
+func (m DnfModule) Source() string {
+	return "dnf-module:" + m.String()
+}
+
+// ParseDnfModuleList parses the table printed by 'dnf module list'. Streams
+// are returned in the order listed, which groups them by repository.
+func ParseDnfModuleList(output string) []DnfModule {
+	var modules []DnfModule
+	inTable := false
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 0:
+			// A blank line ends the table of one repository
+			inTable = false
+		case fields[0] == "Hint:":
+			return modules
+		case len(fields) >= 2 && fields[0] == "Name" && fields[1] == "Stream":
+			inTable = true
+		case inTable && len(fields) >= 2:
+			module := DnfModule{Name: fields[0], Stream: fields[1]}
+			// Flags follow the stream, as in "18 [d][e]"
+			for _, flag := range fields[2:] {
+				if !strings.HasPrefix(flag, "[") {
+					break
+				}
+				module.Default = module.Default || strings.Contains(flag, "[d]")
+				module.Enabled = module.Enabled || strings.Contains(flag, "[e]")
+			}
+			modules = append(modules, module)
+		}
+	}
+	return modules
+}
+
+// ListEnabledDnfModules returns the enabled module streams. It reads the
+// metadata cache only, so it is quick enough to run during a scan but finds
+// nothing until dnf has refreshed its cache once.
+func ListEnabledDnfModules(ctx context.Context, r runner.Runner) ([]DnfModule, error) {
+	output, err := r.CombinedOutput(ctx, "dnf", "--cacheonly", "module", "list", "--enabled")
+	if err != nil {
+		// dnf exits non-zero when no module is enabled
+		if strings.Contains(output, "No matching Modules") {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to list DNF modules: %w", err)
+	}
+	return ParseDnfModuleList(output), nil
+}
+
+// selectDnfStream picks the stream of streams satisfying constraint. An empty
+// constraint picks the enabled stream, then the default one. A version, or a
+// constraint pinned to one such as "18.x" or "^3.1.2", picks the most specific
+// stream it falls in ("18" or "3.1"); a range such as ">=18" picks the newest
+// stream in range.
+func selectDnfStream(streams []DnfModule, constraint string) (DnfModule, bool) {
+	constraint = strings.TrimSpace(constraint)
+	if constraint == "" {
+		for _, want := range []func(DnfModule) bool{
+			func(m DnfModule) bool { return m.Enabled },
+			func(m DnfModule) bool { return m.Default },
+		} {
+			for _, m := range streams {
+				if want(m) {
+					return m, true
+				}
+			}
+		}
+		return DnfModule{}, false
+	}
+
+	pinned := versionPrefix(strings.TrimLeft(constraint, "^~="))
+	if _, err := version.Parse(pinned); err == nil {
+		pinned = strings.TrimPrefix(pinned, "v")
+		var best DnfModule
+		found := false
+		for _, m := range streams {
+			if (pinned == m.Stream || strings.HasPrefix(pinned, m.Stream+".")) && len(m.Stream) > len(best.Stream) {
+				best, found = m, true
+			}
+		}
+		return best, found
+	}
+
+	var best DnfModule
+	var bestVersion *version.Version
+	for _, m := range streams {
+		v, err := version.Parse(m.Stream)
+		if err != nil {
+			// Named streams such as "mainline" are not versions
+			continue
+		}
+		if ok, err := v.Satisfies(constraint); err != nil || !ok {
+			continue
+		}
+		if bestVersion == nil || v.Compare(bestVersion) > 0 {
+			best, bestVersion = m, v
+		}
+	}
+	return best, bestVersion != nil
+}
+
+type dnfModules struct {
+	*basePackageManager
+}
+
+// NewDnfModules creates a version manager installing languages from DNF
+// module streams, as in 'dnf module install nodejs:18'
+func NewDnfModules() types.VersionManager {
+	return &dnfModules{basePackageManager: &basePackageManager{name: "DNF modules", executableName: "dnf"}}
+}
+
+// Supports implements the VersionManager interface
+func (d *dnfModules) Supports(language string) bool {
+	_, ok := dnfModuleLanguages[language]
+	return ok
+}
+
+// InstallRuntime installs the module stream satisfying constraint, resetting
+// the module first when another stream is enabled. Distributions without
+// module streams, like Fedora 39 and later, get the plain package instead.
+func (d *dnfModules) InstallRuntime(ctx context.Context, language, constraint string) error {
+	module, ok := dnfModuleLanguages[language]
+	if !ok {
+		return fmt.Errorf("DNF has no module for %s", language)
+	}
+
+	output, err := d.runCommand(ctx, "module", "list", module)
+	streams := ParseDnfModuleList(output)
+	if err != nil || len(streams) == 0 {
+		if _, err := d.runCommand(ctx, "install", "-y", module); err != nil {
+			return fmt.Errorf("failed to install %s: %w", language, err)
+		}
+		if constraint != "" {
+			types.AddManualStep(ctx, types.ManualStep{
+				Category:    types.CategoryLanguages,
+				Description: fmt.Sprintf("DNF has no %s module streams; check that the installed %s matches %s", module, language, constraint),
+			})
+		}
+		return nil
+	}
+
+	stream, ok := selectDnfStream(streams, constraint)
+	if !ok {
+		available := make([]string, len(streams))
+		for i, m := range streams {
+			available[i] = m.Stream
+		}
+		return fmt.Errorf("no %s module stream satisfies %q (available: %s)", module, constraint, strings.Join(available, ", "))
+	}
+
+	for _, m := range streams {
+		if m.Enabled && m.Stream != stream.Stream {
+			if _, err := d.runCommand(ctx, "module", "reset", "-y", module); err != nil {
+				return fmt.Errorf("failed to reset module %s: %w", module, err)
+			}
+			break
+		}
+	}
+	if _, err := d.runCommand(ctx, "module", "install", "-y", stream.String()); err != nil {
+		return fmt.Errorf("failed to install module %s: %w", stream, err)
+	}
+	return nil
+}
@@ -0,0 +1,197 @@
+package package_managers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/runner/runnertest"
+)
+
+// rhel8NodejsModules is 'dnf module list nodejs' on RHEL 8 with nodejs:18 enabled
+const rhel8NodejsModules = `Updating Subscription Management repositories.
+Last metadata expiration check: 0:41:07 ago on Fri 16 Oct 2026 08:12:44 AM UTC.
+Red Hat Enterprise Linux 8 for x86_64 - AppStream (RPMs)
+Name      Stream    Profiles                                  Summary
+nodejs    10 [d]    common [d], development, minimal, s2i     Javascript runtime
+nodejs    16        common [d], development, minimal, s2i     Javascript runtime
+nodejs    18 [e]    common [d] [i], development, minimal, s2i Javascript runtime
+nodejs    20        common [d], development, minimal, s2i     Javascript runtime
+
+Hint: [d]efault, [e]nabled, [x]disabled, [i]nstalled
+`
+
+// rhel8EnabledModules is 'dnf module list --enabled' with modules enabled from
+// two repositories
+const rhel8EnabledModules = `Last metadata expiration check: 0:41:07 ago on Fri 16 Oct 2026 08:12:44 AM UTC.
+Red Hat Enterprise Linux 8 for x86_64 - AppStream (RPMs)
+Name       Stream      Profiles                      Summary
+nodejs     18 [e]      common [d] [i], development   Javascript runtime
+ruby       3.1 [d][e]  common [d] [i]                An interpreter of object-oriented scripting language
+
+Extra Packages for Enterprise Linux Modular 8 - x86_64
+Name       Stream      Profiles                      Summary
+php        8.1 [e]     common [d], devel, minimal    PHP scripting language
+
+Hint: [d]efault, [e]nabled, [x]disabled, [i]nstalled
+`
+
+func TestParseDnfModuleList(t *testing.T) {
+	testCases := []struct {
+		name     string
+		output   string
+		expected []DnfModule
+	}{
+		{
+			name:   "Streams of one module",
+			output: rhel8NodejsModules,
+			expected: []DnfModule{
+				{Name: "nodejs", Stream: "10", Default: true},
+				{Name: "nodejs", Stream: "16"},
+				{Name: "nodejs", Stream: "18", Enabled: true},
+				{Name: "nodejs", Stream: "20"},
+			},
+		},
+		{
+			name:   "Enabled streams across repositories",
+			output: rhel8EnabledModules,
+			expected: []DnfModule{
+				{Name: "nodejs", Stream: "18", Enabled: true},
+				{Name: "ruby", Stream: "3.1", Default: true, Enabled: true},
+				{Name: "php", Stream: "8.1", Enabled: true},
+			},
+		},
+		{
+			name:   "No modules",
+			output: "Last metadata expiration check: 0:01:02 ago on Fri 16 Oct 2026.\nError: No matching Modules to list\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := ParseDnfModuleList(tc.output)
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("expected %+v but got %+v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestSelectDnfStream(t *testing.T) {
+	nodejs := ParseDnfModuleList(rhel8NodejsModules)
+	php := []DnfModule{{Name: "php", Stream: "7.4", Default: true}, {Name: "php", Stream: "8.0"}, {Name: "php", Stream: "8.1"}}
+	nginx := []DnfModule{{Name: "nginx", Stream: "1.22"}, {Name: "nginx", Stream: "mainline"}}
+
+	testCases := []struct {
+		name       string
+		streams    []DnfModule
+		constraint string
+		expected   string
+	}{
+		{name: "Exact version", streams: nodejs, constraint: "18.19.0", expected: "18"},
+		{name: "Major version", streams: nodejs, constraint: "20", expected: "20"},
+		{name: "Wildcard", streams: nodejs, constraint: "16.x", expected: "16"},
+		{name: "Caret", streams: nodejs, constraint: "^20.11", expected: "20"},
+		{name: "Leading v", streams: nodejs, constraint: "v18.2.0", expected: "18"},
+		{name: "Range picks newest", streams: nodejs, constraint: ">=16", expected: "20"},
+		{name: "Upper bound", streams: nodejs, constraint: "<18", expected: "16"},
+		{name: "No constraint prefers enabled", streams: nodejs, expected: "18"},
+		{name: "No constraint falls back to default", streams: php, expected: "7.4"},
+		{name: "Minor streams", streams: php, constraint: "8.1.2", expected: "8.1"},
+		{name: "Minor streams do not match other minors", streams: php, constraint: "8.2.0"},
+		{name: "Named streams are skipped", streams: nginx, constraint: ">=1.20", expected: "1.22"},
+		{name: "No stream", streams: nodejs, constraint: "22.1.0"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := selectDnfStream(tc.streams, tc.constraint)
+			if tc.expected == "" {
+				if ok {
+					t.Errorf("expected no stream but got %s", got)
+				}
+				return
+			}
+			if !ok || got.Stream != tc.expected {
+				t.Errorf("expected stream %s but got %q (found: %v)", tc.expected, got.Stream, ok)
+			}
+		})
+	}
+}
+
+func TestDnfModulesInstallRuntime(t *testing.T) {
+	testCases := []struct {
+		name       string
+		constraint string
+		responses  map[string]runnertest.Response
+		expected   []string
+		wantErr    bool
+	}{
+		{
+			name:       "Enabled stream",
+			constraint: "18.19.0",
+			responses: map[string]runnertest.Response{
+				"dnf module list nodejs":          {Output: rhel8NodejsModules},
+				"dnf module install -y nodejs:18": {},
+			},
+			expected: []string{"dnf module list nodejs", "dnf module install -y nodejs:18"},
+		},
+		{
+			name:       "Switching streams resets the module",
+			constraint: "20.x",
+			responses: map[string]runnertest.Response{
+				"dnf module list nodejs":          {Output: rhel8NodejsModules},
+				"dnf module reset -y nodejs":      {},
+				"dnf module install -y nodejs:20": {},
+			},
+			expected: []string{"dnf module list nodejs", "dnf module reset -y nodejs", "dnf module install -y nodejs:20"},
+		},
+		{
+			name:       "No matching stream",
+			constraint: "22.1.0",
+			responses: map[string]runnertest.Response{
+				"dnf module list nodejs": {Output: rhel8NodejsModules},
+			},
+			expected: []string{"dnf module list nodejs"},
+			wantErr:  true,
+		},
+		{
+			name:       "No module streams",
+			constraint: "20.11.0",
+			responses: map[string]runnertest.Response{
+				"dnf install -y nodejs": {},
+			},
+			expected: []string{"dnf module list nodejs", "dnf install -y nodejs"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &runnertest.Runner{Responses: tc.responses}
+			vm := &dnfModules{basePackageManager: &basePackageManager{name: "DNF modules", executableName: "dnf", runner: r}}
+
+			err := vm.InstallRuntime(context.Background(), "Node.js", tc.constraint)
+			if tc.wantErr != (err != nil) {
+				t.Fatalf("expected error: %v but got %v", tc.wantErr, err)
+			}
+			if calls := r.Calls(); !reflect.DeepEqual(calls, tc.expected) {
+				t.Errorf("expected commands %q but got %q", tc.expected, calls)
+			}
+		})
+	}
+}
+
+// Groups skip the installed check, which only knows about packages
+func TestDnfInstallGroup(t *testing.T) {
+	r := &runnertest.Runner{Responses: map[string]runnertest.Response{
+		"dnf install -y @development-tools": {},
+	}}
+	d := &dnf{basePackageManager: &basePackageManager{name: "DNF", executableName: "dnf", runner: r}}
+
+	if err := d.InstallPackage(context.Background(), "@development-tools"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls := r.Calls(); !reflect.DeepEqual(calls, []string{"dnf install -y @development-tools"}) {
+		t.Errorf("expected only the group install but got %q", calls)
+	}
+}
@@ -0,0 +1,397 @@
+package package_managers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+// packageChanges collects the changes a dry run reports, in the order the
+// packages are first mentioned
+type packageChanges struct {
+	requested map[string]bool
+	order     []string
+	changes   map[string]*types.PackageChange
+}
+
+// newPackageChanges returns an empty collection in which packages other
+// than requested are dependencies
+func newPackageChanges(requested []string) *packageChanges {
+	c := &packageChanges{requested: make(map[string]bool), changes: make(map[string]*types.PackageChange)}
+	for _, name := range requested {
+		c.requested[name] = true
+	}
+	return c
+}
+
+// get returns the change of pkg, adding it without an action when the dry
+// run hasn't mentioned it before
+func (c *packageChanges) get(pkg string) *types.PackageChange {
+	if change, ok := c.changes[pkg]; ok {
+		return change
+	}
+	change := &types.PackageChange{Package: pkg, Dependency: !c.requested[pkg]}
+	c.changes[pkg] = change
+	c.order = append(c.order, pkg)
+	return change
+}
+
+// list returns the changes in order. Packages mentioned without an action
+// are installed, or upgraded when a version is installed.
+func (c *packageChanges) list() []types.PackageChange {
+	changes := make([]types.PackageChange, 0, len(c.order))
+	for _, pkg := range c.order {
+		change := *c.changes[pkg]
+		if change.Action == "" {
+			change.Action = types.ActionInstall
+			if change.From != "" {
+				change.Action = types.ActionUpgrade
+			}
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// packageNames returns the names of packages
+func packageNames(packages []types.PackageInfo) []string {
+	names := make([]string, len(packages))
+	for i, pkg := range packages {
+		names[i] = pkg.Name
+	}
+	return names
+}
+
+// DryRunInstall implements the DryRunner interface with 'apt-get install
+// --dry-run', which simulates the install without needing root
+func (a *apt) DryRunInstall(ctx context.Context, packages []types.PackageInfo) ([]types.PackageChange, error) {
+	args := []string{"install", "--dry-run", "--allow-downgrades"}
+	for _, pkg := range packages {
+		if pkg.Version != "" {
+			args = append(args, pkg.Name+"="+pkg.Version)
+		} else {
+			args = append(args, pkg.Name)
+		}
+	}
+	output, err := a.runTool(ctx, "apt-get", args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to simulate the install: %w", err)
+	}
+	return parseAptDryRun(output, packageNames(packages)), nil
+}
+
+// aptSections maps the headings of 'apt-get install' output to what
+// happens to the packages listed under them
+var aptSections = map[string]types.PackageAction{
+	"The following NEW packages will be installed:": types.ActionInstall,
+	"The following packages will be upgraded:":      types.ActionUpgrade,
+	"The following packages will be DOWNGRADED:":    types.ActionDowngrade,
+	"The following held packages will be changed:":  types.ActionHeld,
+	"The following packages have been kept back:":   types.ActionHeld,
+}
+
+var (
+	// aptInstLine matches the simulated installs, such as
+	// "Inst nodejs [18.19.0-1nodesource1] (20.11.1-1nodesource1 nodesource:nodistro [amd64])"
+	aptInstLine = regexp.MustCompile(`^Inst (\S+) (?:\[([^\]]+)\] )?\((\S+)`)
+	// aptNewestLine matches packages already installed as asked
+	aptNewestLine = regexp.MustCompile(`^(\S+) is already the newest version \(([^)]+)\)`)
+)
+
+// parseAptDryRun reads what 'apt-get install --dry-run' would do to the
+// requested packages and their dependencies
+func parseAptDryRun(output string, requested []string) []types.PackageChange {
+	changes := newPackageChanges(requested)
+	var section types.PackageAction
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.HasPrefix(line, " ") {
+			if section == "" {
+				continue
+			}
+			for _, name := range strings.Fields(line) {
+				// Held packages are also listed as upgraded or downgraded
+				if change := changes.get(name); change.Action != types.ActionHeld {
+					change.Action = section
+				}
+			}
+			continue
+		}
+		// Any other line ends the list of packages above it
+		section = aptSections[line]
+
+		if m := aptInstLine.FindStringSubmatch(line); m != nil {
+			change := changes.get(m[1])
+			change.From, change.To = m[2], m[3]
+		} else if m := aptNewestLine.FindStringSubmatch(line); m != nil {
+			change := changes.get(m[1])
+			change.Action = types.ActionNone
+			change.From, change.To = m[2], m[2]
+		}
+	}
+	return changes.list()
+}
+
+// isRoot reports whether stackmatch runs as root; a variable for tests
+var isRoot = func() bool { return os.Geteuid() == 0 }
+
+// errDnfNeedsRoot is returned by the dnf dry run when not run as root
+var errDnfNeedsRoot = errors.New("dnf only resolves an install as root; run import with sudo to see what it would change")
+
+// DryRunInstall implements the DryRunner interface with 'dnf install
+// --assumeno', which resolves the transaction and then declines it. dnf
+// refuses to resolve an install without root, with --assumeno and
+// --setopt=tsflags=test alike, so without root the dry run is reported as
+// unavailable and dnf isn't run.
+func (d *dnf) DryRunInstall(ctx context.Context, packages []types.PackageInfo) ([]types.PackageChange, error) {
+	if !isRoot() {
+		return nil, errDnfNeedsRoot
+	}
+	args := []string{"install", "--assumeno"}
+	for _, pkg := range packages {
+		if pkg.Version != "" {
+			args = append(args, pkg.Name+"-"+pkg.Version)
+		} else {
+			args = append(args, pkg.Name)
+		}
+	}
+	// Declining the transaction makes dnf exit with an error whenever there
+	// was something to do, so the output tells whether it answered
+	output, err := d.commandRunner().CombinedOutput(ctx, d.executableName, args...)
+	changes, ok := parseDnfDryRun(output, packageNames(packages))
+	if !ok {
+		return nil, fmt.Errorf("failed to simulate the install: %v\nOutput: %s", err, output)
+	}
+	return changes, nil
+}
+
+// dnfSections maps the headings of the transaction table of 'dnf install'
+// to what happens to the packages listed under them
+var dnfSections = map[string]types.PackageAction{
+	"Installing:":                       types.ActionInstall,
+	"Installing dependencies:":          types.ActionInstall,
+	"Installing weak dependencies:":     types.ActionInstall,
+	"Installing group/module packages:": types.ActionInstall,
+	"Upgrading:":                        types.ActionUpgrade,
+	"Downgrading:":                      types.ActionDowngrade,
+}
+
+var (
+	// dnfInstalledLine matches packages already installed, such as
+	// "Package git-2.43.0-1.fc39.x86_64 is already installed."
+	dnfInstalledLine = regexp.MustCompile(`^Package (\S+) is already installed`)
+	// dnfExcludedLine matches packages excluded in dnf.conf or locked by
+	// the versionlock plugin
+	dnfExcludedLine = regexp.MustCompile(`^All matches were filtered out by (?:exclude|modular) filtering for argument: (\S+)`)
+)
+
+// parseDnfDryRun reads what 'dnf install --assumeno' would do to the
+// requested packages and their dependencies. It reports false when dnf gave
+// no answer, such as when it needs root or a package doesn't exist.
+func parseDnfDryRun(output string, requested []string) ([]types.PackageChange, bool) {
+	changes := newPackageChanges(requested)
+	answered := false
+	var section types.PackageAction
+	// Names too long for their column are on a line of their own
+	wrapped := ""
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.HasPrefix(line, " ") {
+			if section == "" {
+				continue
+			}
+			fields := strings.Fields(wrapped + " " + line)
+			wrapped = ""
+			switch {
+			case len(fields) == 1:
+				wrapped = fields[0]
+			case len(fields) >= 3 && fields[0] != "replacing":
+				change := changes.get(fields[0])
+				change.Action, change.To = section, fields[2]
+			}
+			continue
+		}
+		section, wrapped = dnfSections[line], ""
+
+		if m := dnfInstalledLine.FindStringSubmatch(line); m != nil {
+			name, version := splitNEVRA(m[1])
+			change := changes.get(name)
+			change.Action = types.ActionNone
+			change.From, change.To = version, version
+			answered = true
+		} else if m := dnfExcludedLine.FindStringSubmatch(line); m != nil {
+			changes.get(m[1]).Action = types.ActionHeld
+			answered = true
+		} else if line == "Transaction Summary" || strings.HasPrefix(line, "Nothing to do") {
+			answered = true
+		}
+	}
+	if !answered || strings.Contains(output, "No match for argument") {
+		return nil, false
+	}
+	return changes.list(), true
+}
+
+// splitNEVRA splits a package as dnf names it, such as
+// git-2.43.0-1.fc39.x86_64, into its name and version-release
+func splitNEVRA(nevra string) (string, string) {
+	nevr := nevra
+	if i := strings.LastIndex(nevr, "."); i > 0 {
+		nevr = nevr[:i]
+	}
+	// Names may contain dashes, versions and releases don't
+	parts := strings.Split(nevr, "-")
+	if len(parts) < 3 {
+		return nevra, ""
+	}
+	return strings.Join(parts[:len(parts)-2], "-"), strings.Join(parts[len(parts)-2:], "-")
+}
+
+// DryRunInstall implements the DryRunner interface with 'brew install
+// --dry-run'
+func (h *homebrew) DryRunInstall(ctx context.Context, packages []types.PackageInfo) ([]types.PackageChange, error) {
+	args := []string{"install", "--dry-run"}
+	// Versions are installed as formulae of their own, such as node@18,
+	// which are reported under the package they were asked for
+	names := make(map[string]string)
+	for _, pkg := range packages {
+		formula := pkg.Name
+		if pkg.Version != "" {
+			formula = fmt.Sprintf("%s@%s", pkg.Name, pkg.Version)
+		}
+		names[formula] = pkg.Name
+		args = append(args, formula)
+	}
+	output, err := h.runCommand(ctx, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to simulate the install: %w", err)
+	}
+	changes := parseBrewDryRun(output, mapKeys(names))
+	for i := range changes {
+		if name, ok := names[changes[i].Package]; ok {
+			changes[i].Package = name
+		}
+	}
+	return changes, nil
+}
+
+var (
+	// brewInstalledLine matches formulae already installed, such as
+	// "Warning: git 2.45.2 is already installed and up-to-date."
+	brewInstalledLine = regexp.MustCompile(`^Warning: (\S+) (\S+) is already installed`)
+	// brewPinnedLine matches pinned formulae the install would upgrade
+	brewPinnedLine = regexp.MustCompile(`^(?:Warning|Error): (\S+) (?:\S+ )?is pinned`)
+)
+
+// parseBrewDryRun reads what 'brew install --dry-run' would do to the
+// requested formulae and casks and their dependencies
+func parseBrewDryRun(output string, requested []string) []types.PackageChange {
+	changes := newPackageChanges(requested)
+	var section types.PackageAction
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "==> Would install"):
+			section = types.ActionInstall
+		case strings.HasPrefix(line, "==> Would upgrade"):
+			section = types.ActionUpgrade
+		case strings.HasPrefix(line, "==>"), strings.HasPrefix(line, "Warning:"), strings.HasPrefix(line, "Error:"):
+			section = ""
+			if m := brewInstalledLine.FindStringSubmatch(line); m != nil {
+				change := changes.get(m[1])
+				change.Action = types.ActionNone
+				change.From, change.To = m[2], m[2]
+			} else if m := brewPinnedLine.FindStringSubmatch(line); m != nil {
+				changes.get(m[1]).Action = types.ActionHeld
+			}
+		case section == types.ActionInstall:
+			for _, name := range strings.Fields(line) {
+				changes.get(name).Action = types.ActionInstall
+			}
+		case section == types.ActionUpgrade:
+			// node 20.11.0 -> 20.11.1
+			if fields := strings.Fields(line); len(fields) == 4 && fields[2] == "->" {
+				change := changes.get(fields[0])
+				change.Action = types.ActionUpgrade
+				change.From, change.To = fields[1], fields[3]
+			}
+		}
+	}
+	return changes.list()
+}
+
+// mapKeys returns the keys of m
+func mapKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// DryRunInstall implements the DryRunner interface with 'choco install
+// --noop'. Chocolatey takes one version per command, so each package with
+// a version is simulated on its own.
+func (c *chocolatey) DryRunInstall(ctx context.Context, packages []types.PackageInfo) ([]types.PackageChange, error) {
+	var changes []types.PackageChange
+	var unversioned []string
+	for _, pkg := range packages {
+		if pkg.Version == "" {
+			unversioned = append(unversioned, pkg.Name)
+			continue
+		}
+		output, err := c.runCommand(ctx, "install", pkg.Name, "--version", pkg.Version, "--noop", "-y")
+		if err != nil {
+			return nil, fmt.Errorf("failed to simulate the install of %s: %w", pkg.Name, err)
+		}
+		changes = append(changes, parseChocoDryRun(output, []string{pkg.Name})...)
+	}
+	if len(unversioned) > 0 {
+		args := append(append([]string{"install"}, unversioned...), "--noop", "-y")
+		output, err := c.runCommand(ctx, args...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to simulate the install: %w", err)
+		}
+		changes = append(changes, parseChocoDryRun(output, unversioned)...)
+	}
+	return changes, nil
+}
+
+var (
+	// chocoPackageLine matches the packages Chocolatey would install, such
+	// as "git v2.45.2 [Approved]"
+	chocoPackageLine = regexp.MustCompile(`^(\S+) v(\d\S*)(?: \[Approved\])?$`)
+	// chocoInstalledLine matches packages already installed, which install
+	// leaves alone even when they are outdated
+	chocoInstalledLine = regexp.MustCompile(`^(\S+) v(\S+) already installed`)
+	// chocoNewerLine matches packages install refuses to downgrade
+	chocoNewerLine = regexp.MustCompile(`^A newer version of (\S+) \(v([^)]+)\) is already installed`)
+)
+
+// parseChocoDryRun reads what 'choco install --noop' would do to the
+// requested packages and their dependencies
+func parseChocoDryRun(output string, requested []string) []types.PackageChange {
+	changes := newPackageChanges(requested)
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if m := chocoInstalledLine.FindStringSubmatch(line); m != nil {
+			change := changes.get(m[1])
+			change.Action = types.ActionNone
+			change.From, change.To = m[2], m[2]
+		} else if m := chocoNewerLine.FindStringSubmatch(line); m != nil {
+			change := changes.get(m[1])
+			change.Action = types.ActionHeld
+			change.From = m[2]
+		} else if m := chocoPackageLine.FindStringSubmatch(line); m != nil && m[1] != "Chocolatey" {
+			change := changes.get(m[1])
+			change.Action = types.ActionInstall
+			change.To = m[2]
+		}
+	}
+	return changes.list()
+}
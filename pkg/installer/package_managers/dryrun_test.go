@@ -0,0 +1,326 @@
+package package_managers
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/runner/runnertest"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+const aptDryRunOutput = `NOTE: This is only a simulation!
+      apt-get needs root privileges for real execution.
+      Keep also in mind that locking is deactivated,
+      so don't depend on the relevance to the real current situation!
+Reading package lists...
+Building dependency tree...
+Reading state information...
+curl is already the newest version (8.5.0-2ubuntu10.1).
+The following additional packages will be installed:
+  git-man liberror-perl
+Suggested packages:
+  git-daemon-run git-doc git-email
+The following NEW packages will be installed:
+  git git-man liberror-perl
+The following held packages will be changed:
+  python3
+The following packages will be upgraded:
+  nodejs
+The following packages will be DOWNGRADED:
+  python3 terraform
+3 upgraded, 3 newly installed, 2 downgraded, 0 to remove and 12 not upgraded.
+Inst liberror-perl (0.17029-2 Ubuntu:24.04/noble [all])
+Inst git-man (1:2.43.0-1ubuntu7.1 Ubuntu:24.04/noble-updates [all])
+Inst git (1:2.43.0-1ubuntu7.1 Ubuntu:24.04/noble-updates [amd64])
+Inst nodejs [18.19.0-1nodesource1] (20.11.1-1nodesource1 nodesource:nodistro [amd64])
+Inst python3 [3.12.3-0ubuntu2] (3.12.3-0ubuntu1 Ubuntu:24.04/noble [amd64])
+Inst terraform [1.9.0-1] (1.8.5-1 hashicorp:noble [amd64])
+Conf liberror-perl (0.17029-2 Ubuntu:24.04/noble [all])
+Conf git-man (1:2.43.0-1ubuntu7.1 Ubuntu:24.04/noble-updates [all])
+Conf git (1:2.43.0-1ubuntu7.1 Ubuntu:24.04/noble-updates [amd64])
+`
+
+func TestParseAptDryRun(t *testing.T) {
+	expected := []types.PackageChange{
+		{Package: "curl", Action: types.ActionNone, From: "8.5.0-2ubuntu10.1", To: "8.5.0-2ubuntu10.1"},
+		{Package: "git", Action: types.ActionInstall, To: "1:2.43.0-1ubuntu7.1"},
+		{Package: "git-man", Action: types.ActionInstall, To: "1:2.43.0-1ubuntu7.1", Dependency: true},
+		{Package: "liberror-perl", Action: types.ActionInstall, To: "0.17029-2", Dependency: true},
+		{Package: "python3", Action: types.ActionHeld, From: "3.12.3-0ubuntu2", To: "3.12.3-0ubuntu1"},
+		{Package: "nodejs", Action: types.ActionUpgrade, From: "18.19.0-1nodesource1", To: "20.11.1-1nodesource1"},
+		{Package: "terraform", Action: types.ActionDowngrade, From: "1.9.0-1", To: "1.8.5-1"},
+	}
+
+	got := parseAptDryRun(aptDryRunOutput, []string{"curl", "git", "nodejs", "python3", "terraform"})
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %+v but got %+v", expected, got)
+	}
+
+	// Quiet output only has the simulated installs
+	got = parseAptDryRun("Inst git (1:2.43.0-1ubuntu7.1 Ubuntu:24.04/noble-updates [amd64])\nInst nodejs [18.19.0-1nodesource1] (20.11.1-1nodesource1 nodesource:nodistro [amd64])\n", []string{"git", "nodejs"})
+	expected = []types.PackageChange{
+		{Package: "git", Action: types.ActionInstall, To: "1:2.43.0-1ubuntu7.1"},
+		{Package: "nodejs", Action: types.ActionUpgrade, From: "18.19.0-1nodesource1", To: "20.11.1-1nodesource1"},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %+v but got %+v", expected, got)
+	}
+}
+
+const dnfDryRunOutput = `Last metadata expiration check: 0:12:03 ago on Wed 16 Oct 2026 09:00:00 AM UTC.
+Package curl-8.2.1-3.fc39.x86_64 is already installed.
+All matches were filtered out by exclude filtering for argument: kernel-devel
+Dependencies resolved.
+================================================================================
+ Package                    Arch       Version                Repository   Size
+================================================================================
+Installing:
+ git                        x86_64     2.43.0-1.fc39          updates      53 k
+Upgrading:
+ nodejs                     x86_64     1:20.11.1-1.fc39       updates     2.1 M
+Downgrading:
+ terraform                  x86_64     1.8.5-1                hashicorp    25 M
+Installing dependencies:
+ git-core                   x86_64     2.43.0-1.fc39          updates     4.5 M
+ python3-setuptools-wheel-extras-for-testing
+                            noarch     69.0.3-1.fc39          updates     1.4 M
+Installing weak dependencies:
+ git-core-doc               noarch     2.43.0-1.fc39          updates     2.9 M
+
+Transaction Summary
+================================================================================
+Install  4 Packages
+Upgrade  1 Package
+Downgrade  1 Package
+
+Total download size: 36 M
+Operation aborted.
+`
+
+func TestParseDnfDryRun(t *testing.T) {
+	testCases := []struct {
+		name     string
+		output   string
+		expected []types.PackageChange
+		ok       bool
+	}{
+		{
+			name:   "Transaction",
+			output: dnfDryRunOutput,
+			expected: []types.PackageChange{
+				{Package: "curl", Action: types.ActionNone, From: "8.2.1-3.fc39", To: "8.2.1-3.fc39"},
+				{Package: "kernel-devel", Action: types.ActionHeld},
+				{Package: "git", Action: types.ActionInstall, To: "2.43.0-1.fc39"},
+				{Package: "nodejs", Action: types.ActionUpgrade, To: "1:20.11.1-1.fc39"},
+				{Package: "terraform", Action: types.ActionDowngrade, To: "1.8.5-1"},
+				{Package: "git-core", Action: types.ActionInstall, To: "2.43.0-1.fc39", Dependency: true},
+				{Package: "python3-setuptools-wheel-extras-for-testing", Action: types.ActionInstall, To: "69.0.3-1.fc39", Dependency: true},
+				{Package: "git-core-doc", Action: types.ActionInstall, To: "2.43.0-1.fc39", Dependency: true},
+			},
+			ok: true,
+		},
+		{
+			name:     "Nothing to do",
+			output:   "Package git-2.43.0-1.fc39.x86_64 is already installed.\nDependencies resolved.\nNothing to do.\nComplete!\n",
+			expected: []types.PackageChange{{Package: "git", Action: types.ActionNone, From: "2.43.0-1.fc39", To: "2.43.0-1.fc39"}},
+			ok:       true,
+		},
+		{
+			name:   "Not root",
+			output: "Error: This command has to be run with superuser privileges (under the root user on most systems).\n",
+		},
+		{
+			name:   "Missing package",
+			output: "No match for argument: gti\nError: Unable to find a match: gti\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := parseDnfDryRun(tc.output, []string{"curl", "git", "kernel-devel", "nodejs", "terraform"})
+			if ok != tc.ok {
+				t.Fatalf("expected an answer %v but got %v", tc.ok, ok)
+			}
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("expected %+v but got %+v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestParseBrewDryRun(t *testing.T) {
+	output := `Warning: jq 1.7.1 is already installed and up-to-date.
+To reinstall 1.7.1, run:
+  brew reinstall jq
+Warning: terraform is pinned. You must unpin it to upgrade it.
+==> Would install 2 dependencies for git:
+gettext pcre2
+==> Would install 1 formula:
+git
+==> Would upgrade 1 outdated package:
+node@20 20.11.0 -> 20.11.1
+==> Would install 1 cask:
+visual-studio-code
+`
+	expected := []types.PackageChange{
+		{Package: "jq", Action: types.ActionNone, From: "1.7.1", To: "1.7.1"},
+		{Package: "terraform", Action: types.ActionHeld},
+		{Package: "gettext", Action: types.ActionInstall, Dependency: true},
+		{Package: "pcre2", Action: types.ActionInstall, Dependency: true},
+		{Package: "git", Action: types.ActionInstall},
+		{Package: "node@20", Action: types.ActionUpgrade, From: "20.11.0", To: "20.11.1"},
+		{Package: "visual-studio-code", Action: types.ActionInstall},
+	}
+
+	got := parseBrewDryRun(output, []string{"git", "jq", "node@20", "terraform", "visual-studio-code"})
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %+v but got %+v", expected, got)
+	}
+}
+
+func TestParseChocoDryRun(t *testing.T) {
+	output := `Chocolatey v2.2.2
+Installing the following packages:
+git;nodejs;python
+By installing, you accept licenses for the packages.
+git.install v2.45.2 [Approved]
+git.install package files install completed. Performing other installation steps.
+Would have run 'chocolateyinstall.ps1':
+git v2.45.2 [Approved]
+nodejs v20.11.0 already installed.
+ Use --force to reinstall, specify a version to install, or try upgrade.
+A newer version of python (v3.12.4) is already installed.
+ Use --allow-downgrade or --force to attempt to install older versions, or use side by side to allow multiple versions.
+Chocolatey would have installed 2/3 packages.
+`
+	expected := []types.PackageChange{
+		{Package: "git.install", Action: types.ActionInstall, To: "2.45.2", Dependency: true},
+		{Package: "git", Action: types.ActionInstall, To: "2.45.2"},
+		{Package: "nodejs", Action: types.ActionNone, From: "20.11.0", To: "20.11.0"},
+		{Package: "python", Action: types.ActionHeld, From: "3.12.4"},
+	}
+
+	got := parseChocoDryRun(output, []string{"git", "nodejs", "python"})
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %+v but got %+v", expected, got)
+	}
+}
+
+// asRoot makes the dry runs of the test run as root, or not
+func asRoot(t *testing.T, root bool) {
+	saved := isRoot
+	isRoot = func() bool { return root }
+	t.Cleanup(func() { isRoot = saved })
+}
+
+func TestDryRunInstallCommands(t *testing.T) {
+	asRoot(t, true)
+	packages := []types.PackageInfo{{Name: "git"}, {Name: "node", Version: "20"}}
+
+	testCases := []struct {
+		name      string
+		dryRunner func(r *runnertest.Runner) types.DryRunner
+		responses map[string]runnertest.Response
+		expected  []types.PackageChange
+	}{
+		{
+			name: "APT",
+			dryRunner: func(r *runnertest.Runner) types.DryRunner {
+				a := NewApt().(*apt)
+				a.runner = r
+				return a
+			},
+			responses: map[string]runnertest.Response{
+				"apt-get install --dry-run --allow-downgrades git node=20": {Output: "Inst git (1:2.43.0-1 Ubuntu:24.04/noble [amd64])\nnode is already the newest version (20).\n"},
+			},
+			expected: []types.PackageChange{
+				{Package: "git", Action: types.ActionInstall, To: "1:2.43.0-1"},
+				{Package: "node", Action: types.ActionNone, From: "20", To: "20"},
+			},
+		},
+		{
+			name: "DNF",
+			dryRunner: func(r *runnertest.Runner) types.DryRunner {
+				d := NewDnf().(*dnf)
+				d.runner = r
+				return d
+			},
+			responses: map[string]runnertest.Response{
+				"dnf install --assumeno git node-20": {
+					Output: "Installing:\n git   x86_64   2.43.0-1.fc39   updates   53 k\n\nTransaction Summary\nOperation aborted.\n",
+					Err:    errors.New("exit status 1"),
+				},
+			},
+			expected: []types.PackageChange{{Package: "git", Action: types.ActionInstall, To: "2.43.0-1.fc39"}},
+		},
+		{
+			name: "Homebrew",
+			dryRunner: func(r *runnertest.Runner) types.DryRunner {
+				return newHomebrew("/opt/homebrew/bin/brew", r, nil)
+			},
+			responses: map[string]runnertest.Response{
+				"/opt/homebrew/bin/brew install --dry-run git node@20": {Output: "==> Would install 2 formulae:\ngit node@20\n"},
+			},
+			expected: []types.PackageChange{
+				{Package: "git", Action: types.ActionInstall},
+				{Package: "node", Action: types.ActionInstall},
+			},
+		},
+		{
+			name: "Chocolatey",
+			dryRunner: func(r *runnertest.Runner) types.DryRunner {
+				c := NewChocolatey().(*chocolatey)
+				c.runner = r
+				return c
+			},
+			responses: map[string]runnertest.Response{
+				"choco install node --version 20 --noop -y": {Output: "node v20.0.0 [Approved]\n"},
+				"choco install git --noop -y":               {Output: "git v2.45.2 already installed.\n"},
+			},
+			expected: []types.PackageChange{
+				{Package: "node", Action: types.ActionInstall, To: "20.0.0"},
+				{Package: "git", Action: types.ActionNone, From: "2.45.2", To: "2.45.2"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &runnertest.Runner{Responses: tc.responses}
+			got, err := tc.dryRunner(r).DryRunInstall(context.Background(), packages)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("expected %+v but got %+v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestDryRunInstallFails(t *testing.T) {
+	asRoot(t, true)
+	r := &runnertest.Runner{Responses: map[string]runnertest.Response{
+		"dnf install --assumeno gti": {Output: "No match for argument: gti\nError: Unable to find a match: gti\n", Err: errors.New("exit status 1")},
+	}}
+	d := NewDnf().(*dnf)
+	d.runner = r
+	if _, err := d.DryRunInstall(context.Background(), []types.PackageInfo{{Name: "gti"}}); err == nil {
+		t.Error("expected an error when dnf finds no package")
+	}
+}
+
+func TestDnfDryRunNeedsRoot(t *testing.T) {
+	asRoot(t, false)
+	r := &runnertest.Runner{}
+	d := NewDnf().(*dnf)
+	d.runner = r
+	if _, err := d.DryRunInstall(context.Background(), []types.PackageInfo{{Name: "git"}}); !errors.Is(err, errDnfNeedsRoot) {
+		t.Errorf("expected the dry run to be unavailable without root but got %v", err)
+	}
+	if calls := r.Calls(); len(calls) != 0 {
+		t.Errorf("expected dnf not to run without root but ran %q", calls)
+	}
+}
@@ -0,0 +1,49 @@
+package package_managers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+type goGlobal struct {
+	*basePackageManager
+}
+
+// NewGoGlobal creates an installer for programs installed with 'go install'
+func NewGoGlobal() types.GlobalPackageInstaller {
+	return &goGlobal{basePackageManager: &basePackageManager{name: "go", executableName: "go"}}
+}
+
+// InstallGlobal implements the GlobalPackageInstaller interface. go install
+// only takes several packages of one module at one version, so each package
+// is installed on its own, at its latest version when none is given.
+func (g *goGlobal) InstallGlobal(ctx context.Context, packages []string) error {
+	for _, pkg := range packages {
+		if !strings.Contains(pkg, "@") {
+			pkg += "@latest"
+		}
+		if _, err := g.runCommand(ctx, "install", pkg); err != nil {
+			return fmt.Errorf("failed to install %s with go install: %w", pkg, err)
+		}
+	}
+	return nil
+}
+
+// Scope implements the Scoper interface. go install always installs into
+// the user's GOBIN, or GOPATH/bin.
+func (g *goGlobal) Scope() types.InstallScope {
+	return types.ScopeUser
+}
+
+// SetScope implements the Scoper interface. go install has no system-wide
+// install, so the system scope, the default of the other installers, leaves
+// it installing into GOBIN.
+func (g *goGlobal) SetScope(scope types.InstallScope) error {
+	if scope != types.ScopeUser && scope != types.ScopeSystem {
+		return &types.UnsupportedScopeError{Manager: g.name, Scope: scope, Reason: "go install only installs into GOBIN or GOPATH/bin"}
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+package package_managers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/runner/runnertest"
+)
+
+func TestGoGlobalInstallsEachPackage(t *testing.T) {
+	r := &runnertest.Runner{Responses: map[string]runnertest.Response{
+		"go install golang.org/x/tools/gopls@v0.15.2":         {},
+		"go install github.com/go-delve/delve/cmd/dlv@latest": {},
+	}}
+	g := NewGoGlobal().(*goGlobal)
+	g.runner = r
+
+	if err := g.InstallGlobal(context.Background(), []string{"golang.org/x/tools/gopls@v0.15.2", "github.com/go-delve/delve/cmd/dlv"}); err != nil {
+		t.Fatalf("expected the packages to install but got %v", err)
+	}
+	expected := []string{"go install golang.org/x/tools/gopls@v0.15.2", "go install github.com/go-delve/delve/cmd/dlv@latest"}
+	if !reflect.DeepEqual(r.Calls(), expected) {
+		t.Errorf("expected calls %q but got %q", expected, r.Calls())
+	}
+}
@@ -3,28 +3,61 @@ package package_managers
 import (
 	"context"
 	"fmt"
+	"os"
+	"path/filepath"
 	"regexp"
 	"strings"
 
+	"github.com/MRQ67/stackmatch-cli/pkg/runner"
 	"github.com/MRQ67/stackmatch-cli/pkg/types"
 	"github.com/MRQ67/stackmatch-cli/pkg/version"
 )
 
 type homebrew struct {
 	*basePackageManager
+	// userPrefix is the installation the user scope installs into
+	userPrefix string
+	// systemBinary is the brew the installer ran before switching to the
+	// user scope, which it runs again in the system scope
+	systemBinary string
 }
 
-// NewHomebrew creates a new Homebrew package manager instance
+// NewHomebrew creates a new Homebrew package manager instance. It operates
+// on the brew first on PATH, resolved to an absolute path so every command
+// targets the same installation.
 func NewHomebrew() types.Installer {
+	binary := "brew"
+	if path, err := runner.DefaultPath.LookPath("brew"); err == nil {
+		binary = path
+	}
+	return newHomebrew(binary, nil, nil)
+}
+
+// NewHomebrewWithPrefix creates a Homebrew package manager that operates on
+// the installation at prefix (e.g. /opt/homebrew or /usr/local)
+func NewHomebrewWithPrefix(prefix string) (types.Installer, error) {
+	binary := brewBinary(prefix)
+	if !runner.DefaultPath.IsExecutable(binary) {
+		return nil, fmt.Errorf("no Homebrew installation found at %s", prefix)
+	}
+	return newHomebrew(binary, nil, nil), nil
+}
+
+// newHomebrew creates a Homebrew instance running binary with r and path,
+// which default to the system ones when nil
+func newHomebrew(binary string, r runner.Runner, path runner.PathIndex) *homebrew {
 	hb := &homebrew{
 		basePackageManager: &basePackageManager{
-			name:            "Homebrew",
-			pmType:          types.TypeHomebrew,
-			executableName:   "brew",
+			name:             "Homebrew",
+			pmType:           types.TypeHomebrew,
+			executableName:   binary,
 			versionCommand:   "info --json=v2",
 			versionRegex:     `"version":"([^"]+)"`,
 			installWithFlags: true,
+			runner:           r,
+			path:             path,
 		},
+		userPrefix: userBrewPrefix(),
 	}
 	hb.installPackageFunc = hb.installPackage
 	hb.installMultipleFunc = hb.installMultiple
@@ -32,6 +65,40 @@ func NewHomebrew() types.Installer {
 	return hb
 }
 
+// userBrewPrefix returns the Homebrew installation in the user's home,
+// ~/homebrew
+func userBrewPrefix() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, "homebrew")
+}
+
+// Scope implements the Scoper interface
+func (h *homebrew) Scope() types.InstallScope {
+	if h.systemBinary != "" {
+		return types.ScopeUser
+	}
+	return types.ScopeSystem
+}
+
+// SetScope implements the Scoper interface. The user scope runs the brew of
+// the untarred installation in ~/homebrew, so packages, and their
+// verification, go there rather than to the system prefix.
+func (h *homebrew) SetScope(scope types.InstallScope) error {
+	switch {
+	case scope == h.Scope():
+		return nil
+	case scope == types.ScopeSystem:
+		h.executableName, h.systemBinary = h.systemBinary, ""
+		return nil
+	}
+	binary := brewBinary(h.userPrefix)
+	if !h.pathIndex().IsExecutable(binary) {
+		return &types.UnsupportedScopeError{Manager: h.name, Scope: scope, Reason: fmt.Sprintf("no Homebrew installation found at %s", h.userPrefix)}
+	}
+	h.systemBinary, h.executableName = h.executableName, binary
+	return nil
+}
+
 // installPackage installs a single package
 func (h *homebrew) installPackage(ctx context.Context, pkg string) error {
 	// First check if already installed
@@ -150,6 +217,11 @@ func (h *homebrew) InstallMultiple(ctx context.Context, packages []string) error
 	return h.installMultiple(ctx, packages)
 }
 
+// ReinstallPackages implements the Reinstaller interface
+func (h *homebrew) ReinstallPackages(ctx context.Context, packages []string) error {
+	return h.reinstallEach(ctx, packages, []string{"reinstall"}, []string{"install"})
+}
+
 // InstallMultipleVersions installs multiple packages with specific versions
 func (h *homebrew) InstallMultipleVersions(ctx context.Context, packages map[string]types.VersionConstraint) error {
 	if len(packages) == 0 {
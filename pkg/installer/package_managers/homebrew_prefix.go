@@ -0,0 +1,104 @@
+package package_managers
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/runner"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+// standardBrewPrefixes are the default Homebrew prefixes of each OS and the
+// architecture each implies. An empty arch means the host architecture:
+// /usr/local holds x86_64 bottles on every Mac, native or under Rosetta, but
+// whatever the host runs on Linux.
+var standardBrewPrefixes = map[string][]struct {
+	prefix string
+	arch   string
+}{
+	"darwin": {
+		{"/opt/homebrew", "arm64"},
+		{"/usr/local", "x86_64"},
+	},
+	"linux": {
+		{"/home/linuxbrew/.linuxbrew", ""},
+		{"/usr/local", ""},
+	},
+}
+
+// brewBinary returns the brew executable inside prefix
+func brewBinary(prefix string) string {
+	return filepath.Join(prefix, "bin", "brew")
+}
+
+// DetectHomebrewInstalls finds every Homebrew installation by asking each brew
+// on PATH for its prefix and probing the standard prefixes. The installation
+// first on PATH is returned first and marked primary.
+func DetectHomebrewInstalls(ctx context.Context, r runner.Runner, path runner.PathIndex) []types.HomebrewInstall {
+	return detectHomebrewInstalls(ctx, r, path, runtime.GOOS, runtime.GOARCH)
+}
+
+func detectHomebrewInstalls(ctx context.Context, r runner.Runner, path runner.PathIndex, goos, goarch string) []types.HomebrewInstall {
+	var installs []types.HomebrewInstall
+	seen := make(map[string]bool)
+	add := func(prefix string, onPath bool) {
+		prefix = filepath.Clean(prefix)
+		if seen[prefix] {
+			return
+		}
+		seen[prefix] = true
+		installs = append(installs, types.HomebrewInstall{
+			Prefix:  prefix,
+			Arch:    brewArch(prefix, goos, goarch),
+			Primary: onPath && len(installs) == 0,
+		})
+	}
+
+	for _, brew := range path.LookPathAll("brew") {
+		prefix := filepath.Dir(filepath.Dir(brew))
+		if output, err := r.CombinedOutput(ctx, brew, "--prefix"); err == nil && strings.TrimSpace(output) != "" {
+			prefix = strings.TrimSpace(output)
+		}
+		add(prefix, true)
+	}
+
+	for _, std := range standardBrewPrefixes[goos] {
+		if path.IsExecutable(brewBinary(std.prefix)) {
+			add(std.prefix, false)
+		}
+	}
+
+	return installs
+}
+
+// brewArch returns the architecture a Homebrew prefix targets on goos
+func brewArch(prefix, goos, goarch string) string {
+	for _, std := range standardBrewPrefixes[goos] {
+		if std.prefix == prefix && std.arch != "" {
+			return std.arch
+		}
+	}
+	switch goarch {
+	case "amd64":
+		return "x86_64"
+	default:
+		return goarch
+	}
+}
+
+// HomebrewWarning returns a warning when more than one Homebrew installation
+// exists, or "" otherwise
+func HomebrewWarning(installs []types.HomebrewInstall) string {
+	if len(installs) < 2 {
+		return ""
+	}
+	prefixes := make([]string, 0, len(installs))
+	for _, install := range installs {
+		prefixes = append(prefixes, fmt.Sprintf("%s (%s)", install.Prefix, install.Arch))
+	}
+	return fmt.Sprintf("found %d Homebrew installations: %s; %s is first on PATH and will be used, pass --brew-prefix to choose another",
+		len(installs), strings.Join(prefixes, ", "), installs[0].Prefix)
+}
@@ -0,0 +1,143 @@
+package package_managers
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/runner/runnertest"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+func TestDetectHomebrewInstalls(t *testing.T) {
+	testCases := []struct {
+		name      string
+		goos      string
+		goarch    string
+		path      *runnertest.Path
+		responses map[string]runnertest.Response
+		expected  []types.HomebrewInstall
+	}{
+		{
+			name:   "Apple Silicon with leftover Intel install",
+			goos:   "darwin",
+			goarch: "arm64",
+			path: runnertest.NewPath(
+				[]string{"/opt/homebrew/bin", "/usr/local/bin", "/usr/bin"},
+				"/opt/homebrew/bin/brew", "/usr/local/bin/brew",
+			),
+			responses: map[string]runnertest.Response{
+				"/opt/homebrew/bin/brew --prefix": {Output: "/opt/homebrew\n"},
+				"/usr/local/bin/brew --prefix":    {Output: "/usr/local\n"},
+			},
+			expected: []types.HomebrewInstall{
+				{Prefix: "/opt/homebrew", Arch: "arm64", Primary: true},
+				{Prefix: "/usr/local", Arch: "x86_64"},
+			},
+		},
+		{
+			name:   "Intel install first on PATH",
+			goos:   "darwin",
+			goarch: "arm64",
+			path: runnertest.NewPath(
+				[]string{"/usr/local/bin", "/opt/homebrew/bin"},
+				"/opt/homebrew/bin/brew", "/usr/local/bin/brew",
+			),
+			responses: map[string]runnertest.Response{
+				"/opt/homebrew/bin/brew --prefix": {Output: "/opt/homebrew\n"},
+				"/usr/local/bin/brew --prefix":    {Output: "/usr/local\n"},
+			},
+			expected: []types.HomebrewInstall{
+				{Prefix: "/usr/local", Arch: "x86_64", Primary: true},
+				{Prefix: "/opt/homebrew", Arch: "arm64"},
+			},
+		},
+		{
+			name:   "Second install not on PATH",
+			goos:   "darwin",
+			goarch: "arm64",
+			path: runnertest.NewPath(
+				[]string{"/opt/homebrew/bin", "/usr/bin"},
+				"/opt/homebrew/bin/brew", "/usr/local/bin/brew",
+			),
+			responses: map[string]runnertest.Response{
+				"/opt/homebrew/bin/brew --prefix": {Output: "/opt/homebrew\n"},
+			},
+			expected: []types.HomebrewInstall{
+				{Prefix: "/opt/homebrew", Arch: "arm64", Primary: true},
+				{Prefix: "/usr/local", Arch: "x86_64"},
+			},
+		},
+		{
+			name:   "Intel Mac",
+			goos:   "darwin",
+			goarch: "amd64",
+			path:   runnertest.NewPath([]string{"/usr/local/bin", "/usr/bin"}, "/usr/local/bin/brew"),
+			responses: map[string]runnertest.Response{
+				"/usr/local/bin/brew --prefix": {Output: "/usr/local\n"},
+			},
+			expected: []types.HomebrewInstall{{Prefix: "/usr/local", Arch: "x86_64", Primary: true}},
+		},
+		{
+			name:   "Linux on ARM",
+			goos:   "linux",
+			goarch: "arm64",
+			path: runnertest.NewPath(
+				[]string{"/home/linuxbrew/.linuxbrew/bin", "/usr/bin"},
+				"/home/linuxbrew/.linuxbrew/bin/brew", "/usr/local/bin/brew", "/opt/homebrew/bin/brew",
+			),
+			responses: map[string]runnertest.Response{
+				"/home/linuxbrew/.linuxbrew/bin/brew --prefix": {Output: "/home/linuxbrew/.linuxbrew\n"},
+			},
+			expected: []types.HomebrewInstall{
+				{Prefix: "/home/linuxbrew/.linuxbrew", Arch: "arm64", Primary: true},
+				{Prefix: "/usr/local", Arch: "arm64"},
+			},
+		},
+		{
+			name:     "No Homebrew",
+			goos:     "darwin",
+			goarch:   "arm64",
+			path:     runnertest.NewPath([]string{"/usr/bin"}),
+			expected: nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &runnertest.Runner{Responses: tc.responses}
+			installs := detectHomebrewInstalls(context.Background(), r, tc.path, tc.goos, tc.goarch)
+			if !reflect.DeepEqual(installs, tc.expected) {
+				t.Errorf("expected %+v but got %+v", tc.expected, installs)
+			}
+
+			warning := HomebrewWarning(installs)
+			if (len(tc.expected) > 1) != (warning != "") {
+				t.Errorf("unexpected warning %q for %d installations", warning, len(installs))
+			}
+		})
+	}
+}
+
+func TestHomebrewUsesSelectedPrefix(t *testing.T) {
+	r := &runnertest.Runner{Responses: map[string]runnertest.Response{
+		"/usr/local/bin/brew list --versions jq": {Output: ""},
+		"/usr/local/bin/brew install jq":         {Output: "==> Pouring jq--1.7.1.ventura.bottle.tar.gz\n"},
+	}}
+	path := runnertest.NewPath([]string{"/opt/homebrew/bin"}, "/opt/homebrew/bin/brew", "/usr/local/bin/brew")
+
+	hb := newHomebrew(brewBinary("/usr/local"), r, path)
+	if !hb.IsAvailable() {
+		t.Fatal("expected the selected installation to be available")
+	}
+	if err := hb.InstallPackage(context.Background(), "jq"); err != nil {
+		t.Fatalf("install failed: %v", err)
+	}
+
+	for _, call := range r.Calls() {
+		if !strings.HasPrefix(call, "/usr/local/bin/brew ") {
+			t.Errorf("expected every command to use /usr/local/bin/brew, got %q", call)
+		}
+	}
+}
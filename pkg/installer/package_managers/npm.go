@@ -0,0 +1,62 @@
+package package_managers
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+type npmGlobal struct {
+	*basePackageManager
+	// prefix overrides npm's global prefix in the user scope
+	prefix string
+}
+
+// userLocalPrefix returns the prefix of the user scope, ~/.local
+func userLocalPrefix() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".local")
+}
+
+// NewNpmGlobal creates an installer for global npm packages
+func NewNpmGlobal() types.GlobalPackageInstaller {
+	return &npmGlobal{basePackageManager: &basePackageManager{name: "npm", executableName: "npm"}}
+}
+
+// InstallGlobal implements the GlobalPackageInstaller interface
+func (n *npmGlobal) InstallGlobal(ctx context.Context, packages []string) error {
+	if len(packages) == 0 {
+		return nil
+	}
+	args := []string{"install", "--global"}
+	if n.prefix != "" {
+		args = append(args, "--prefix", n.prefix)
+	}
+	args = append(args, packages...)
+	if _, err := n.runCommand(ctx, args...); err != nil {
+		return fmt.Errorf("failed to install global npm packages: %w", err)
+	}
+	return nil
+}
+
+// Scope implements the Scoper interface
+func (n *npmGlobal) Scope() types.InstallScope {
+	if n.prefix != "" {
+		return types.ScopeUser
+	}
+	return types.ScopeSystem
+}
+
+// SetScope implements the Scoper interface. The user scope installs with
+// the prefix ~/.local, putting the commands in ~/.local/bin, rather than
+// in npm's global prefix, which is often /usr/local.
+func (n *npmGlobal) SetScope(scope types.InstallScope) error {
+	n.prefix = ""
+	if scope == types.ScopeUser {
+		n.prefix = userLocalPrefix()
+	}
+	return nil
+}
@@ -10,6 +10,8 @@ import (
 
 type pacman struct {
 	*basePackageManager
+	// lockFile exists while pacman changes its database
+	lockFile string
 }
 
 // NewPacman creates a new Pacman package manager instance
@@ -20,6 +22,7 @@ func NewPacman() types.Installer {
 			pmType:        types.TypePacman,
 			executableName: "pacman",
 		},
+		lockFile: pacmanLock,
 	}
 }
 
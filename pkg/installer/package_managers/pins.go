@@ -0,0 +1,92 @@
+package package_managers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// runTool runs a companion executable of the package manager, such as
+// apt-mark next to apt
+func (b *basePackageManager) runTool(ctx context.Context, name string, args ...string) (string, error) {
+	output, err := b.commandRunner().CombinedOutput(ctx, name, args...)
+	if err != nil {
+		return "", fmt.Errorf("command failed: %v\nOutput: %s", err, output)
+	}
+	return output, nil
+}
+
+// PinPackage implements the Pinner interface with 'apt-mark hold'
+func (a *apt) PinPackage(ctx context.Context, pkg string) error {
+	if _, err := a.runTool(ctx, "apt-mark", "hold", pkg); err != nil {
+		return fmt.Errorf("failed to hold %s: %w", pkg, err)
+	}
+	return nil
+}
+
+// UnpinPackage implements the Pinner interface
+func (a *apt) UnpinPackage(ctx context.Context, pkg string) error {
+	if _, err := a.runTool(ctx, "apt-mark", "unhold", pkg); err != nil {
+		return fmt.Errorf("failed to release the hold on %s: %w", pkg, err)
+	}
+	return nil
+}
+
+// PinPackage implements the Pinner interface with the versionlock plugin,
+// which is not installed everywhere
+func (d *dnf) PinPackage(ctx context.Context, pkg string) error {
+	if _, err := d.runCommand(ctx, "versionlock", "add", pkg); err != nil {
+		if isMissingVersionlock(err) {
+			return fmt.Errorf("cannot pin %s: the DNF versionlock plugin is not installed (install python3-dnf-plugin-versionlock)", pkg)
+		}
+		return fmt.Errorf("failed to lock the version of %s: %w", pkg, err)
+	}
+	return nil
+}
+
+// UnpinPackage implements the Pinner interface
+func (d *dnf) UnpinPackage(ctx context.Context, pkg string) error {
+	if _, err := d.runCommand(ctx, "versionlock", "delete", pkg); err != nil {
+		return fmt.Errorf("failed to remove the version lock on %s: %w", pkg, err)
+	}
+	return nil
+}
+
+// isMissingVersionlock reports whether a versionlock command failed because
+// the plugin providing it is not installed. The error carries the output.
+func isMissingVersionlock(err error) bool {
+	message := err.Error()
+	return strings.Contains(message, "No such command") || strings.Contains(message, `Unknown argument "versionlock"`)
+}
+
+// PinPackage implements the Pinner interface with 'brew pin'
+func (h *homebrew) PinPackage(ctx context.Context, pkg string) error {
+	if _, err := h.runCommand(ctx, "pin", pkg); err != nil {
+		return fmt.Errorf("failed to pin %s: %w", pkg, err)
+	}
+	return nil
+}
+
+// UnpinPackage implements the Pinner interface
+func (h *homebrew) UnpinPackage(ctx context.Context, pkg string) error {
+	if _, err := h.runCommand(ctx, "unpin", pkg); err != nil {
+		return fmt.Errorf("failed to unpin %s: %w", pkg, err)
+	}
+	return nil
+}
+
+// PinPackage implements the Pinner interface with 'choco pin'
+func (c *chocolatey) PinPackage(ctx context.Context, pkg string) error {
+	if _, err := c.runCommand(ctx, "pin", "add", "--name="+pkg); err != nil {
+		return fmt.Errorf("failed to pin %s: %w", pkg, err)
+	}
+	return nil
+}
+
+// UnpinPackage implements the Pinner interface
+func (c *chocolatey) UnpinPackage(ctx context.Context, pkg string) error {
+	if _, err := c.runCommand(ctx, "pin", "remove", "--name="+pkg); err != nil {
+		return fmt.Errorf("failed to unpin %s: %w", pkg, err)
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package package_managers
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/runner/runnertest"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+func TestPinCommands(t *testing.T) {
+	testCases := []struct {
+		name     string
+		pinner   func(r *runnertest.Runner) types.Pinner
+		expected []string
+	}{
+		{
+			name: "APT",
+			pinner: func(r *runnertest.Runner) types.Pinner {
+				a := NewApt().(*apt)
+				a.runner = r
+				return a
+			},
+			expected: []string{"apt-mark hold terraform", "apt-mark unhold terraform"},
+		},
+		{
+			name: "DNF",
+			pinner: func(r *runnertest.Runner) types.Pinner {
+				d := NewDnf().(*dnf)
+				d.runner = r
+				return d
+			},
+			expected: []string{"dnf versionlock add terraform", "dnf versionlock delete terraform"},
+		},
+		{
+			name: "Homebrew",
+			pinner: func(r *runnertest.Runner) types.Pinner {
+				return newHomebrew("/opt/homebrew/bin/brew", r, nil)
+			},
+			expected: []string{"/opt/homebrew/bin/brew pin terraform", "/opt/homebrew/bin/brew unpin terraform"},
+		},
+		{
+			name: "Chocolatey",
+			pinner: func(r *runnertest.Runner) types.Pinner {
+				c := NewChocolatey().(*chocolatey)
+				c.runner = r
+				return c
+			},
+			expected: []string{"choco pin add --name=terraform", "choco pin remove --name=terraform"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &runnertest.Runner{Responses: map[string]runnertest.Response{}}
+			for _, line := range tc.expected {
+				r.Responses[line] = runnertest.Response{}
+			}
+			pinner := tc.pinner(r)
+
+			if err := pinner.PinPackage(context.Background(), "terraform"); err != nil {
+				t.Fatalf("expected pinning to succeed but got %v", err)
+			}
+			if err := pinner.UnpinPackage(context.Background(), "terraform"); err != nil {
+				t.Fatalf("expected unpinning to succeed but got %v", err)
+			}
+			if !reflect.DeepEqual(r.Calls(), tc.expected) {
+				t.Errorf("expected commands %q but got %q", tc.expected, r.Calls())
+			}
+		})
+	}
+}
+
+func TestDnfPinWithoutVersionlock(t *testing.T) {
+	r := &runnertest.Runner{Responses: map[string]runnertest.Response{
+		"dnf versionlock add terraform": {
+			Output: "No such command: versionlock. Please use /usr/bin/dnf --help\n",
+			Err:    errors.New("exit status 1"),
+		},
+	}}
+	d := NewDnf().(*dnf)
+	d.runner = r
+
+	err := d.PinPackage(context.Background(), "terraform")
+	if err == nil || !strings.Contains(err.Error(), "install python3-dnf-plugin-versionlock") {
+		t.Errorf("expected an error naming the missing plugin but got %v", err)
+	}
+}
@@ -0,0 +1,58 @@
+package package_managers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+type pipGlobal struct {
+	*basePackageManager
+	scope types.InstallScope
+}
+
+// NewPipGlobal creates an installer for Python packages installed with pip
+// outside of any virtual environment. They are installed with --user unless
+// the system scope is asked for, since scans record them from there.
+func NewPipGlobal() types.GlobalPackageInstaller {
+	return &pipGlobal{basePackageManager: &basePackageManager{name: "pip", executableName: "pip3"}}
+}
+
+// InstallGlobal implements the GlobalPackageInstaller interface. Packages
+// given as name@version are installed as name==version.
+func (p *pipGlobal) InstallGlobal(ctx context.Context, packages []string) error {
+	if len(packages) == 0 {
+		return nil
+	}
+	args := []string{"install"}
+	if p.Scope() == types.ScopeUser {
+		args = append(args, "--user")
+	}
+	for _, pkg := range packages {
+		if name, version, ok := strings.Cut(pkg, "@"); ok {
+			pkg = name + "==" + version
+		}
+		args = append(args, pkg)
+	}
+	if _, err := p.runCommand(ctx, args...); err != nil {
+		return fmt.Errorf("failed to install pip packages: %w", err)
+	}
+	return nil
+}
+
+// Scope implements the Scoper interface
+func (p *pipGlobal) Scope() types.InstallScope {
+	if p.scope == "" {
+		return types.ScopeUser
+	}
+	return p.scope
+}
+
+// SetScope implements the Scoper interface. The user scope installs with
+// --user, into ~/.local on Linux and macOS and %APPDATA%\Python on Windows.
+func (p *pipGlobal) SetScope(scope types.InstallScope) error {
+	p.scope = scope
+	return nil
+}
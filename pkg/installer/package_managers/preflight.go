@@ -0,0 +1,291 @@
+package package_managers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+// preflightTimeout bounds each preflight probe, so an unreachable mirror
+// delays the import by seconds rather than minutes
+var preflightTimeout = 5 * time.Second
+
+// maxProbedHosts is the number of repository hosts probed for reachability
+const maxProbedHosts = 3
+
+const checkRepositories = "repositories reachable"
+
+// reach reports whether the server at rawURL answers within the preflight
+// timeout. Any response below 500 counts: the server is there even if it
+// wants credentials or the probed file is gone.
+func reach(ctx context.Context, rawURL string) error {
+	ctx, cancel := context.WithTimeout(ctx, preflightTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, rawURL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("no answer within %s", preflightTimeout)
+		}
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 500 {
+		return fmt.Errorf("server answered %s", resp.Status)
+	}
+	return nil
+}
+
+// reachHosts probes one URL per host of urls, up to maxProbedHosts hosts,
+// and describes the first host that could not be reached
+func reachHosts(ctx context.Context, urls []string, fix string) types.PreflightResult {
+	result := types.PreflightResult{Check: checkRepositories}
+	probed := make(map[string]bool)
+	for _, raw := range urls {
+		u, err := url.Parse(raw)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || probed[u.Host] {
+			continue
+		}
+		if len(probed) == maxProbedHosts {
+			break
+		}
+		probed[u.Host] = true
+		if err := reach(ctx, raw); err != nil {
+			result.Problem = fmt.Sprintf("could not reach %s: %v", u.Host, err)
+			result.Fix = fix
+			return result
+		}
+	}
+	return result
+}
+
+// probeTool runs a preflight command with the preflight timeout
+func (b *basePackageManager) probeTool(ctx context.Context, name string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, preflightTimeout)
+	defer cancel()
+	stdout, stderr, err := b.commandRunner().Output(ctx, name, args...)
+	if err != nil {
+		if ctx.Err() != nil {
+			return "", fmt.Errorf("%s did not finish within %s", name, preflightTimeout)
+		}
+		if message := firstLine(stderr); message != "" {
+			return "", fmt.Errorf("%s failed: %s", name, message)
+		}
+		return "", fmt.Errorf("%s failed: %w", name, err)
+	}
+	return stdout, nil
+}
+
+// firstLine returns the first non-empty line of s
+func firstLine(s string) string {
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return ""
+}
+
+// InstallVolume implements the PreflightChecker interface
+func (a *apt) InstallVolume() string {
+	return "/"
+}
+
+// PreflightChecks implements the PreflightChecker interface. It checks that
+// dpkg has no half-installed packages and that a sample of the index URLs
+// 'apt-get update' would fetch can be reached.
+func (a *apt) PreflightChecks(ctx context.Context) []types.PreflightResult {
+	health, err := a.dpkgAudit(ctx)
+	if err != nil {
+		health.Problem = err.Error()
+		health.Fix = "Run 'dpkg --audit' to see what is wrong"
+	}
+
+	repositories := types.PreflightResult{Check: checkRepositories}
+	output, err := a.probeTool(ctx, "apt-get", "update", "--print-uris")
+	if err != nil {
+		repositories.Problem = err.Error()
+		repositories.Fix = "Run 'apt-get update' to see what is wrong with your sources"
+	} else {
+		repositories = reachHosts(ctx, parseAptURIs(output),
+			"Check your network connection and proxy settings (http_proxy, /etc/apt/apt.conf.d), or the mirrors in /etc/apt/sources.list and /etc/apt/sources.list.d")
+	}
+	return []types.PreflightResult{health, repositories}
+}
+
+// dpkgAudit checks that dpkg has no half-installed packages. The error is
+// set when dpkg couldn't be asked.
+func (a *apt) dpkgAudit(ctx context.Context) (types.PreflightResult, error) {
+	health := types.PreflightResult{Check: "dpkg state"}
+	output, err := a.probeTool(ctx, "dpkg", "--audit")
+	if err != nil {
+		return health, err
+	}
+	if strings.TrimSpace(output) != "" {
+		health.Problem = "dpkg reports packages in a broken state: " + firstLine(output)
+		health.Fix = "Run 'sudo dpkg --configure -a' and 'sudo apt-get install -f', then import again"
+	}
+	return health, nil
+}
+
+// parseAptURIs returns the URIs listed by 'apt-get update --print-uris', each
+// line of which starts with the quoted URI
+func parseAptURIs(output string) []string {
+	var uris []string
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "'") {
+			continue
+		}
+		if end := strings.Index(line[1:], "'"); end > 0 {
+			uris = append(uris, line[1:end+1])
+		}
+	}
+	return uris
+}
+
+// defaultBrewAPI is where Homebrew downloads formula metadata from, unless
+// HOMEBREW_API_DOMAIN points elsewhere
+const defaultBrewAPI = "https://formulae.brew.sh/api"
+
+// InstallVolume implements the PreflightChecker interface
+func (h *homebrew) InstallVolume() string {
+	if filepath.IsAbs(h.executableName) {
+		return filepath.Dir(filepath.Dir(h.executableName))
+	}
+	return "/"
+}
+
+// PreflightChecks implements the PreflightChecker interface by reaching
+// Homebrew's formula API. Installations set to use taps instead
+// (HOMEBREW_NO_INSTALL_FROM_API) are not probed.
+func (h *homebrew) PreflightChecks(ctx context.Context) []types.PreflightResult {
+	if os.Getenv("HOMEBREW_NO_INSTALL_FROM_API") != "" {
+		return nil
+	}
+	api := defaultBrewAPI
+	if domain := os.Getenv("HOMEBREW_API_DOMAIN"); domain != "" {
+		api = strings.TrimSuffix(domain, "/")
+	}
+	return []types.PreflightResult{reachHosts(ctx, []string{api + "/formula.jws.json"},
+		"Check your network connection and proxy settings (HTTPS_PROXY), or set HOMEBREW_API_DOMAIN to a reachable mirror")}
+}
+
+// InstallVolume implements the PreflightChecker interface
+func (c *chocolatey) InstallVolume() string {
+	return windowsInstallVolume()
+}
+
+// PreflightChecks implements the PreflightChecker interface. It checks that
+// Chocolatey has an enabled source and that the first of them can be
+// reached.
+func (c *chocolatey) PreflightChecks(ctx context.Context) []types.PreflightResult {
+	sources := types.PreflightResult{Check: "package sources"}
+	enabled, err := c.enabledSources(ctx)
+	if err != nil {
+		sources.Problem = err.Error()
+		sources.Fix = "Run 'choco source list' to see what is wrong"
+		return []types.PreflightResult{sources}
+	}
+	if len(enabled) == 0 {
+		sources.Problem = "Chocolatey has no enabled package sources"
+		sources.Fix = chocoSourcesFix
+		return []types.PreflightResult{sources}
+	}
+	return []types.PreflightResult{sources, reachHosts(ctx, enabled[:1],
+		"Check your network connection and proxy settings ('choco config get proxy'), or the sources listed by 'choco source list'")}
+}
+
+const chocoSourcesFix = "Enable one with 'choco source enable --name=chocolatey', or add your internal feed with 'choco source add'"
+
+// enabledSources returns the URLs of Chocolatey's enabled sources
+func (c *chocolatey) enabledSources(ctx context.Context) ([]string, error) {
+	output, err := c.probeTool(ctx, c.executableName, "source", "list", "--limit-output")
+	if err != nil {
+		return nil, err
+	}
+	return parseChocoSources(output), nil
+}
+
+// parseChocoSources returns the URLs of the enabled sources in the output of
+// 'choco source list --limit-output', whose lines are name|url|disabled|...
+func parseChocoSources(output string) []string {
+	var urls []string
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Split(strings.TrimSpace(line), "|")
+		if len(fields) < 3 || strings.EqualFold(fields[2], "true") {
+			continue
+		}
+		urls = append(urls, fields[1])
+	}
+	return urls
+}
+
+// InstallVolume implements the PreflightChecker interface
+func (w *winget) InstallVolume() string {
+	return windowsInstallVolume()
+}
+
+// PreflightChecks implements the PreflightChecker interface. It checks that
+// winget has sources configured and that the first of them can be reached.
+func (w *winget) PreflightChecks(ctx context.Context) []types.PreflightResult {
+	sources := types.PreflightResult{Check: "package sources"}
+	urls, err := w.sourceURLs(ctx)
+	if err != nil {
+		sources.Problem = err.Error()
+		sources.Fix = wingetSourcesFix
+		return []types.PreflightResult{sources}
+	}
+	if len(urls) == 0 {
+		sources.Problem = "winget has no package sources"
+		sources.Fix = wingetSourcesFix
+		return []types.PreflightResult{sources}
+	}
+	return []types.PreflightResult{sources, reachHosts(ctx, urls[:1],
+		"Check your network connection and proxy settings, or run 'winget source update' to see what is wrong")}
+}
+
+const wingetSourcesFix = "Run 'winget source reset --force' as administrator to restore the default sources"
+
+// sourceURLs returns the URLs of winget's sources
+func (w *winget) sourceURLs(ctx context.Context) ([]string, error) {
+	output, err := w.probeTool(ctx, w.executableName, "source", "list")
+	if err != nil {
+		return nil, err
+	}
+	return parseWingetSources(output), nil
+}
+
+// parseWingetSources returns the source URLs in the table printed by
+// 'winget source list'
+func parseWingetSources(output string) []string {
+	var urls []string
+	for _, line := range strings.Split(output, "\n") {
+		for _, field := range strings.Fields(line) {
+			if strings.HasPrefix(field, "https://") || strings.HasPrefix(field, "http://") {
+				urls = append(urls, field)
+				break
+			}
+		}
+	}
+	return urls
+}
+
+// windowsInstallVolume returns the system drive, where Chocolatey and winget
+// install by default
+func windowsInstallVolume() string {
+	if drive := os.Getenv("SystemDrive"); drive != "" {
+		return drive + `\`
+	}
+	return `C:\`
+}
@@ -0,0 +1,246 @@
+package package_managers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/runner/runnertest"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+// repositoryServers starts a reachable repository, one answering 503 and
+// returns them with the URL of one that is down
+func repositoryServers(t *testing.T) (up, failing, down string) {
+	t.Helper()
+	ok := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	t.Cleanup(ok.Close)
+	unavailable := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(unavailable.Close)
+	closed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	closed.Close()
+	return ok.URL, unavailable.URL, closed.URL
+}
+
+// checkResults compares preflight results with the expected problems, given
+// as substrings, one per check; "" expects the check to pass
+func checkResults(t *testing.T, results []types.PreflightResult, expected []string) {
+	t.Helper()
+	if len(results) != len(expected) {
+		t.Fatalf("expected %d checks but got %+v", len(expected), results)
+	}
+	for i, problem := range expected {
+		got := results[i]
+		switch {
+		case problem == "" && !got.Passed():
+			t.Errorf("check %q: expected it to pass but got %q", got.Check, got.Problem)
+		case problem != "" && !strings.Contains(got.Problem, problem):
+			t.Errorf("check %q: expected a problem containing %q but got %q", got.Check, problem, got.Problem)
+		case problem != "" && got.Fix == "":
+			t.Errorf("check %q: expected a fix for %q", got.Check, got.Problem)
+		}
+	}
+}
+
+func TestAptPreflight(t *testing.T) {
+	up, failing, down := repositoryServers(t)
+	uris := func(urls ...string) string {
+		var b strings.Builder
+		for i, u := range urls {
+			b.WriteString("'" + u + "/dists/bookworm/InRelease' mirror_" + string(rune('a'+i)) + "_InRelease 0 \n")
+		}
+		return b.String()
+	}
+
+	testCases := []struct {
+		name      string
+		responses map[string]runnertest.Response
+		expected  []string
+	}{
+		{
+			name: "Healthy",
+			responses: map[string]runnertest.Response{
+				"dpkg --audit":                {},
+				"apt-get update --print-uris": {Output: uris(up, up) + "'cdrom://[Debian]/dists/bookworm/Release' cdrom 0 \n"},
+			},
+			expected: []string{"", ""},
+		},
+		{
+			name: "Broken dpkg",
+			responses: map[string]runnertest.Response{
+				"dpkg --audit":                {Output: "The following packages are only half configured, probably due to problems\nconfiguring them the first time.\n nginx-common\n"},
+				"apt-get update --print-uris": {Output: uris(up)},
+			},
+			expected: []string{"broken state: The following packages are only half configured", ""},
+		},
+		{
+			name: "Mirror down",
+			responses: map[string]runnertest.Response{
+				"dpkg --audit":                {},
+				"apt-get update --print-uris": {Output: uris(up, down)},
+			},
+			expected: []string{"", "could not reach " + strings.TrimPrefix(down, "http://")},
+		},
+		{
+			name: "Mirror failing",
+			responses: map[string]runnertest.Response{
+				"dpkg --audit":                {},
+				"apt-get update --print-uris": {Output: uris(failing)},
+			},
+			expected: []string{"", "503 Service Unavailable"},
+		},
+		{
+			name: "Invalid sources",
+			responses: map[string]runnertest.Response{
+				"dpkg --audit":                {},
+				"apt-get update --print-uris": {Stderr: "E: Malformed entry 3 in list file /etc/apt/sources.list.d/corp.list (Component)\n", Err: errors.New("exit status 100")},
+			},
+			expected: []string{"", "apt-get failed: E: Malformed entry 3"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			a := NewApt().(*apt)
+			a.runner = &runnertest.Runner{Responses: tc.responses}
+			checkResults(t, a.PreflightChecks(context.Background()), tc.expected)
+		})
+	}
+}
+
+func TestHomebrewPreflight(t *testing.T) {
+	up, _, down := repositoryServers(t)
+
+	testCases := []struct {
+		name     string
+		env      map[string]string
+		expected []string
+	}{
+		{
+			name:     "API reachable",
+			env:      map[string]string{"HOMEBREW_API_DOMAIN": up + "/api"},
+			expected: []string{""},
+		},
+		{
+			name:     "API down",
+			env:      map[string]string{"HOMEBREW_API_DOMAIN": down + "/api"},
+			expected: []string{"could not reach"},
+		},
+		{
+			name: "Installing from taps",
+			env:  map[string]string{"HOMEBREW_API_DOMAIN": down + "/api", "HOMEBREW_NO_INSTALL_FROM_API": "1"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("HOMEBREW_NO_INSTALL_FROM_API", "")
+			for key, value := range tc.env {
+				t.Setenv(key, value)
+			}
+			h := newHomebrew("/opt/homebrew/bin/brew", &runnertest.Runner{}, nil)
+			checkResults(t, h.PreflightChecks(context.Background()), tc.expected)
+			if volume := h.InstallVolume(); volume != "/opt/homebrew" {
+				t.Errorf("expected packages to go to /opt/homebrew but got %s", volume)
+			}
+		})
+	}
+}
+
+func TestChocolateyPreflight(t *testing.T) {
+	up, _, down := repositoryServers(t)
+
+	testCases := []struct {
+		name     string
+		response runnertest.Response
+		expected []string
+	}{
+		{
+			name:     "Enabled source",
+			response: runnertest.Response{Output: "chocolatey|" + down + "/api/v2/|True|||0|False|False|False\ncorp|" + up + "/nuget/|False|||1|False|False|False\n"},
+			expected: []string{"", ""},
+		},
+		{
+			name:     "Every source disabled",
+			response: runnertest.Response{Output: "chocolatey|" + up + "/api/v2/|True|||0|False|False|False\n"},
+			expected: []string{"no enabled package sources"},
+		},
+		{
+			name:     "Source down",
+			response: runnertest.Response{Output: "chocolatey|" + down + "/api/v2/|False|||0|False|False|False\n"},
+			expected: []string{"", "could not reach"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := NewChocolatey().(*chocolatey)
+			c.runner = &runnertest.Runner{Responses: map[string]runnertest.Response{"choco source list --limit-output": tc.response}}
+			checkResults(t, c.PreflightChecks(context.Background()), tc.expected)
+		})
+	}
+}
+
+func TestWingetPreflight(t *testing.T) {
+	up, _, _ := repositoryServers(t)
+	table := func(rows ...string) string {
+		return "Name    Argument                                      Explicit\r\n" +
+			"-----------------------------------------------------------------\r\n" + strings.Join(rows, "\r\n") + "\r\n"
+	}
+
+	testCases := []struct {
+		name     string
+		response runnertest.Response
+		expected []string
+	}{
+		{
+			name:     "Sources",
+			response: runnertest.Response{Output: table("winget  "+up+"/cache        false", "msstore https://storeedgefd.dsx.mp.microsoft.com/v9.0 false")},
+			expected: []string{"", ""},
+		},
+		{
+			name:     "No sources",
+			response: runnertest.Response{Output: "There are no sources configured.\r\n"},
+			expected: []string{"no package sources"},
+		},
+		{
+			name:     "Corrupt sources",
+			response: runnertest.Response{Stderr: "Failed when opening source(s); try the 'source reset' command if the problem persists.\r\n", Err: errors.New("exit status 1")},
+			expected: []string{"try the 'source reset' command"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := NewWinget().(*winget)
+			w.runner = &runnertest.Runner{Responses: map[string]runnertest.Response{"winget source list": tc.response}}
+			checkResults(t, w.PreflightChecks(context.Background()), tc.expected)
+		})
+	}
+}
+
+func TestPreflightTimeout(t *testing.T) {
+	defer func(timeout time.Duration) { preflightTimeout = timeout }(preflightTimeout)
+	preflightTimeout = 50 * time.Millisecond
+
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer slow.Close()
+	defer close(release)
+
+	result := reachHosts(context.Background(), []string{slow.URL + "/InRelease"}, "fix it")
+	if !strings.Contains(result.Problem, "no answer within 50ms") {
+		t.Errorf("expected the probe to give up after the timeout but got %q", result.Problem)
+	}
+}
@@ -10,6 +10,9 @@ import (
 
 type scoop struct {
 	*basePackageManager
+	// global is set in the system scope, where packages are installed for
+	// every user with --global
+	global bool
 }
 
 // NewScoop creates a new Scoop package manager instance
@@ -35,7 +38,7 @@ func (s *scoop) InstallPackage(ctx context.Context, pkg string) error {
 	}
 
 	// Install the package
-	_, err = s.runCommand(ctx, "install", pkg)
+	_, err = s.runCommand(ctx, append(s.installArgs(), pkg)...)
 	if err != nil {
 		return fmt.Errorf("failed to install package: %w", err)
 	}
@@ -49,7 +52,7 @@ func (s *scoop) InstallMultiple(ctx context.Context, packages []string) error {
 	}
 
 	// Scoop can install multiple packages in one command
-	args := append([]string{"install"}, packages...)
+	args := append(s.installArgs(), packages...)
 	_, err := s.runCommand(ctx, args...)
 	if err != nil {
 		return fmt.Errorf("failed to install packages: %w", err)
@@ -58,6 +61,29 @@ func (s *scoop) InstallMultiple(ctx context.Context, packages []string) error {
 	return nil
 }
 
+// installArgs returns the arguments installing packages in the scope
+func (s *scoop) installArgs() []string {
+	if s.global {
+		return []string{"install", "--global"}
+	}
+	return []string{"install"}
+}
+
+// Scope implements the Scoper interface. Scoop installs into the user's
+// home unless told to install globally.
+func (s *scoop) Scope() types.InstallScope {
+	if s.global {
+		return types.ScopeSystem
+	}
+	return types.ScopeUser
+}
+
+// SetScope implements the Scoper interface
+func (s *scoop) SetScope(scope types.InstallScope) error {
+	s.global = scope == types.ScopeSystem
+	return nil
+}
+
 func (s *scoop) UpdatePackageManager(ctx context.Context) error {
 	// Update scoop itself
 	_, err := s.runCommand(ctx, "update")
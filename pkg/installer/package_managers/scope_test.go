@@ -0,0 +1,228 @@
+package package_managers
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/runner/runnertest"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+func TestInstallScopes(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	local := filepath.Join(home, ".local")
+
+	testCases := []struct {
+		name     string
+		scope    types.InstallScope
+		install  func(r *runnertest.Runner, scope types.InstallScope) error
+		expected []string
+	}{
+		{
+			name:  "npm in the user scope",
+			scope: types.ScopeUser,
+			install: func(r *runnertest.Runner, scope types.InstallScope) error {
+				n := NewNpmGlobal().(*npmGlobal)
+				n.runner = r
+				if err := n.SetScope(scope); err != nil {
+					return err
+				}
+				return n.InstallGlobal(context.Background(), []string{"typescript@5.4.5"})
+			},
+			expected: []string{"npm install --global --prefix " + local + " typescript@5.4.5"},
+		},
+		{
+			name:  "npm in the system scope",
+			scope: types.ScopeSystem,
+			install: func(r *runnertest.Runner, scope types.InstallScope) error {
+				n := NewNpmGlobal().(*npmGlobal)
+				n.runner = r
+				if err := n.SetScope(scope); err != nil {
+					return err
+				}
+				return n.InstallGlobal(context.Background(), []string{"typescript@5.4.5"})
+			},
+			expected: []string{"npm install --global typescript@5.4.5"},
+		},
+		{
+			name:  "pip in the user scope",
+			scope: types.ScopeUser,
+			install: func(r *runnertest.Runner, scope types.InstallScope) error {
+				p := NewPipGlobal().(*pipGlobal)
+				p.runner = r
+				if err := p.SetScope(scope); err != nil {
+					return err
+				}
+				return p.InstallGlobal(context.Background(), []string{"black@24.4.0", "httpie"})
+			},
+			expected: []string{"pip3 install --user black==24.4.0 httpie"},
+		},
+		{
+			// Scans record pip packages from the user site-packages
+			name: "pip without a scope",
+			install: func(r *runnertest.Runner, scope types.InstallScope) error {
+				p := NewPipGlobal().(*pipGlobal)
+				p.runner = r
+				return p.InstallGlobal(context.Background(), []string{"httpie@3.2.2"})
+			},
+			expected: []string{"pip3 install --user httpie==3.2.2"},
+		},
+		{
+			name:  "pip in the system scope",
+			scope: types.ScopeSystem,
+			install: func(r *runnertest.Runner, scope types.InstallScope) error {
+				p := NewPipGlobal().(*pipGlobal)
+				p.runner = r
+				if err := p.SetScope(scope); err != nil {
+					return err
+				}
+				return p.InstallGlobal(context.Background(), []string{"black@24.4.0"})
+			},
+			expected: []string{"pip3 install black==24.4.0"},
+		},
+		{
+			name:  "Scoop in the system scope",
+			scope: types.ScopeSystem,
+			install: func(r *runnertest.Runner, scope types.InstallScope) error {
+				s := NewScoop().(*scoop)
+				s.runner = r
+				if err := s.SetScope(scope); err != nil {
+					return err
+				}
+				return s.InstallMultiple(context.Background(), []string{"git", "jq"})
+			},
+			expected: []string{"scoop install --global git jq"},
+		},
+		{
+			name:  "Scoop in the user scope",
+			scope: types.ScopeUser,
+			install: func(r *runnertest.Runner, scope types.InstallScope) error {
+				s := NewScoop().(*scoop)
+				s.runner = r
+				if err := s.SetScope(scope); err != nil {
+					return err
+				}
+				return s.InstallMultiple(context.Background(), []string{"git", "jq"})
+			},
+			expected: []string{"scoop install git jq"},
+		},
+		{
+			name:  "winget in the user scope",
+			scope: types.ScopeUser,
+			install: func(r *runnertest.Runner, scope types.InstallScope) error {
+				r.Responses["winget list --id Git.Git --exact"] = runnertest.Response{Output: "No installed package found matching input criteria.\n"}
+				w := NewWinget().(*winget)
+				w.runner = r
+				if err := w.SetScope(scope); err != nil {
+					return err
+				}
+				return w.InstallPackage(context.Background(), "Git.Git")
+			},
+			expected: []string{
+				"winget list --id Git.Git --exact",
+				"winget install --silent --accept-package-agreements --accept-source-agreements --scope user Git.Git",
+			},
+		},
+		{
+			name:  "go in the user scope",
+			scope: types.ScopeUser,
+			install: func(r *runnertest.Runner, scope types.InstallScope) error {
+				g := NewGoGlobal().(*goGlobal)
+				g.runner = r
+				if err := g.SetScope(scope); err != nil {
+					return err
+				}
+				return g.InstallGlobal(context.Background(), []string{"golang.org/x/tools/gopls@v0.15.2"})
+			},
+			expected: []string{"go install golang.org/x/tools/gopls@v0.15.2"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &runnertest.Runner{Responses: map[string]runnertest.Response{}}
+			for _, call := range tc.expected {
+				if _, ok := r.Responses[call]; !ok {
+					r.Responses[call] = runnertest.Response{}
+				}
+			}
+			if err := tc.install(r, tc.scope); err != nil {
+				t.Fatalf("expected the install to succeed but got %v", err)
+			}
+			if !reflect.DeepEqual(r.Calls(), tc.expected) {
+				t.Errorf("expected calls %q but got %q", tc.expected, r.Calls())
+			}
+		})
+	}
+}
+
+func TestHomebrewUserScope(t *testing.T) {
+	userPrefix := "/home/ci/homebrew"
+	r := &runnertest.Runner{Responses: map[string]runnertest.Response{
+		"/home/ci/homebrew/bin/brew install git jq":       {},
+		"/home/ci/homebrew/bin/brew list --versions git":  {Output: "git 2.45.0\n"},
+		"/home/linuxbrew/.linuxbrew/bin/brew install git": {},
+	}}
+	h := newHomebrew("/home/linuxbrew/.linuxbrew/bin/brew", r, runnertest.NewPath(nil, "/home/ci/homebrew/bin/brew"))
+	h.userPrefix = userPrefix
+
+	if err := h.SetScope(types.ScopeUser); err != nil {
+		t.Fatalf("expected the user scope to be set but got %v", err)
+	}
+	if h.Scope() != types.ScopeUser {
+		t.Errorf("expected the user scope but got %q", h.Scope())
+	}
+	if err := h.InstallMultiple(context.Background(), []string{"git", "jq"}); err != nil {
+		t.Fatalf("expected the install to succeed but got %v", err)
+	}
+	// Verification asks the same installation
+	info, err := h.GetInstalledVersion(context.Background(), "git")
+	if err != nil || info.Version != "2.45.0" {
+		t.Errorf("expected git 2.45.0 in the user prefix but got %+v, %v", info, err)
+	}
+
+	if err := h.SetScope(types.ScopeSystem); err != nil {
+		t.Fatalf("expected the system scope to be set but got %v", err)
+	}
+	if err := h.InstallMultiple(context.Background(), []string{"git"}); err != nil {
+		t.Fatalf("expected the install to succeed but got %v", err)
+	}
+
+	expected := []string{
+		"/home/ci/homebrew/bin/brew install git jq",
+		"/home/ci/homebrew/bin/brew list --versions git",
+		"/home/linuxbrew/.linuxbrew/bin/brew install git",
+	}
+	if !reflect.DeepEqual(r.Calls(), expected) {
+		t.Errorf("expected calls %q but got %q", expected, r.Calls())
+	}
+}
+
+func TestUnsupportedScopes(t *testing.T) {
+	h := newHomebrew("/opt/homebrew/bin/brew", &runnertest.Runner{}, runnertest.NewPath(nil))
+	h.userPrefix = "/home/ci/homebrew"
+	var unsupported *types.UnsupportedScopeError
+	if err := h.SetScope(types.ScopeUser); !errors.As(err, &unsupported) {
+		t.Errorf("expected no Homebrew in the user prefix to be an unsupported scope but got %v", err)
+	}
+	if h.Scope() != types.ScopeSystem || h.executableName != "/opt/homebrew/bin/brew" {
+		t.Errorf("expected the installer to stay in the system scope but got %q running %s", h.Scope(), h.executableName)
+	}
+
+	goGlobal := NewGoGlobal().(types.Scoper)
+	if err := goGlobal.SetScope(types.ScopeSystem); err != nil || goGlobal.Scope() != types.ScopeUser {
+		t.Errorf("expected go install to keep installing into GOBIN with the system scope but got %q, %v", goGlobal.Scope(), err)
+	}
+
+	// System package managers only install system-wide
+	for _, manager := range []types.Installer{NewApt(), NewDnf(), NewYum(), NewPacman(), NewApk(), NewSnap(), NewChocolatey()} {
+		if _, ok := manager.(types.Scoper); ok {
+			t.Errorf("expected %s to install system-wide only", manager.Name())
+		}
+	}
+}
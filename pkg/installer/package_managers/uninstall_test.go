@@ -0,0 +1,198 @@
+package package_managers
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/runner/runnertest"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+func TestParseChocoUninstallOutput(t *testing.T) {
+	testCases := []struct {
+		name     string
+		output   string
+		expected []string
+	}{
+		{
+			name: "Single package",
+			output: `Chocolatey v1.4.0
+Uninstalling the following packages:
+git
+
+git v2.40.0
+ Running auto uninstaller...
+ Auto uninstaller has successfully uninstalled git or detected previous uninstall.
+ git has been successfully uninstalled.
+
+Chocolatey uninstalled 1/1 packages.
+`,
+			expected: []string{"git"},
+		},
+		{
+			name: "With dependencies",
+			output: `Chocolatey v2.2.2
+Uninstalling the following packages:
+git
+git v2.43.0
+ git has been successfully uninstalled.
+git.install v2.43.0
+ Skipping auto uninstaller - No registry snapshot.
+ git.install has been successfully uninstalled.
+chocolatey-core.extension v1.4.0
+ chocolatey-core.extension has been successfully uninstalled.
+
+Chocolatey uninstalled 3/3 packages.
+`,
+			expected: []string{"git", "git.install", "chocolatey-core.extension"},
+		},
+		{
+			name:     "Nothing removed",
+			output:   "Chocolatey v2.2.2\nChocolatey uninstalled 0/1 packages.\n",
+			expected: nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := parseChocoUninstallOutput(tc.output)
+			if !reflect.DeepEqual(actual, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestParseWingetUninstallOutput(t *testing.T) {
+	testCases := []struct {
+		name     string
+		output   string
+		expected []string
+	}{
+		{
+			name:     "Found line",
+			output:   "Found Git [Git.Git]\r\nStarting package uninstall...\r\nSuccessfully uninstalled\r\n",
+			expected: []string{"Git.Git"},
+		},
+		{
+			name:     "No found line",
+			output:   "Starting package uninstall...\nSuccessfully uninstalled\n",
+			expected: []string{"Git.Git"},
+		},
+		{
+			name:     "Failed",
+			output:   "Found Git [Git.Git]\nUninstall failed with exit code: 1603\n",
+			expected: nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := parseWingetUninstallOutput(tc.output, "Git.Git")
+			if !reflect.DeepEqual(actual, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+// wingetGoList is 'winget list --id GoLang.Go --exact' output, whose Name
+// column doesn't hold the ID
+const wingetGoList = "\r-\r\\\r \r" + `Name           Id        Version Source
+------------------------------------------
+Go Programming GoLang.Go 1.22.5  winget
+`
+
+func TestParseWingetListIDs(t *testing.T) {
+	testCases := []struct {
+		name     string
+		output   string
+		expected []string
+	}{
+		{name: "Name differs from the ID", output: wingetGoList, expected: []string{"GoLang.Go"}},
+		{
+			name:     "Name with spaces and no source",
+			output:   "Name                         Id                         Version\r\n---------------------------------------------------------------\r\nMicrosoft Visual Studio Code Microsoft.VisualStudioCode 1.91.1\r\n",
+			expected: []string{"Microsoft.VisualStudioCode"},
+		},
+		{name: "Nothing installed", output: "No installed package found matching input criteria.\n"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := parseWingetListIDs(tc.output)
+			if !reflect.DeepEqual(actual, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestWingetUninstallLooksUpByID(t *testing.T) {
+	uninstall := "winget uninstall --id GoLang.Go --exact --silent --accept-source-agreements"
+	r := &runnertest.Runner{Responses: map[string]runnertest.Response{
+		"winget list --id GoLang.Go --exact": {Output: wingetGoList},
+		uninstall:                            {Output: "Found Go Programming [GoLang.Go]\nSuccessfully uninstalled\n"},
+	}}
+	w := NewWinget().(*winget)
+	w.runner = r
+	result, err := w.UninstallPackageWithReport(context.Background(), "GoLang.Go", types.UninstallOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(result.Removed, []string{"GoLang.Go"}) {
+		t.Errorf("expected GoLang.Go to be removed, got %v (calls %q)", result.Removed, r.Calls())
+	}
+}
+
+func TestChocoUninstallKeepsProtectedDependencies(t *testing.T) {
+	lib := t.TempDir()
+	nuspecs := map[string]string{
+		// git.install pulls in chocolatey-core.extension through its own
+		// dependency
+		"git":            `<?xml version="1.0"?><package xmlns="http://schemas.microsoft.com/packaging/2015/06/nuspec.xsd"><metadata><id>git</id><dependencies><dependency id="git.install" version="[2.45.2]" /></dependencies></metadata></package>`,
+		"git.install":    `<?xml version="1.0"?><package><metadata><id>git.install</id><dependencies><group targetFramework=".NETFramework4.0"><dependency id="chocolatey-core.extension" version="1.4.0" /></group></dependencies></metadata></package>`,
+		"nodejs":         `<?xml version="1.0"?><package><metadata><id>nodejs</id><dependencies><dependency id="nodejs.install" version="[20.11.1]" /><dependency id="vcredist140" /></dependencies></metadata></package>`,
+		"nodejs.install": `<?xml version="1.0"?><package><metadata><id>nodejs.install</id></metadata></package>`,
+	}
+	for id, nuspec := range nuspecs {
+		if err := os.MkdirAll(filepath.Join(lib, id), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(lib, id, id+".nuspec"), []byte(nuspec), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	testCases := []struct {
+		pkg      string
+		expected string
+	}{
+		{pkg: "git", expected: "choco uninstall --yes git"},
+		// vcredist140 isn't installed, so it is passed over
+		{pkg: "nodejs", expected: "choco uninstall --yes nodejs --remove-dependencies"},
+		// Without a nuspec the dependencies can't be checked
+		{pkg: "jq", expected: "choco uninstall --yes jq"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.pkg, func(t *testing.T) {
+			r := &runnertest.Runner{Responses: map[string]runnertest.Response{
+				"choco list --local-only " + tc.pkg: {Output: "Chocolatey v2.2.2\n" + tc.pkg + " 1.0.0\n1 packages installed.\n"},
+				tc.expected:                         {Output: tc.pkg + " has been successfully uninstalled.\n"},
+			}}
+			c := NewChocolatey().(*chocolatey)
+			c.runner = r
+			c.lib = lib
+			if _, err := c.UninstallPackageWithReport(context.Background(), tc.pkg, types.UninstallOptions{RemoveDependencies: true}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if calls := r.Calls(); calls[len(calls)-1] != tc.expected {
+				t.Errorf("expected %q to run but got %q", tc.expected, calls)
+			}
+		})
+	}
+}
@@ -0,0 +1,196 @@
+package package_managers
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+// checkUsable names the usability check of package managers that have
+// none of their own
+const checkUsable = "usable"
+
+// dpkgLockers are the processes that hold the dpkg lock while they run,
+// as pgrep names them ("unattended-upgr" is cut to 15 characters)
+const dpkgLockers = "dpkg|unattended-upgr"
+
+// unattendedShutdown is the daemon Ubuntu keeps running to finish
+// unattended upgrades at shutdown. pgrep names it "unattended-upgr" too, but
+// it doesn't hold the dpkg lock.
+const unattendedShutdown = "unattended-upgrade-shutdown"
+
+// aptFrontends are the APT front ends, which only hold the dpkg lock while
+// running one of aptChangeCommands; 'apt list' or 'apt show' in another
+// terminal doesn't
+const aptFrontends = "apt|apt-get|aptitude"
+
+// aptChangeCommands are the subcommands of aptFrontends that run dpkg
+var aptChangeCommands = []string{"install", "reinstall", "remove", "purge", "upgrade", "dist-upgrade", "full-upgrade", "autoremove", "safe-upgrade"}
+
+// pacmanLock is the lock file pacman leaves while it changes the database
+const pacmanLock = "/var/lib/pacman/db.lck"
+
+// blockingExecutionPolicies are the PowerShell execution policies under
+// which Scoop's unsigned scripts don't run
+var blockingExecutionPolicies = []string{"Restricted", "AllSigned"}
+
+// Executable implements the ManagerProber interface
+func (b *basePackageManager) Executable() string {
+	path, err := b.pathIndex().LookPath(b.executableName)
+	if err != nil {
+		return ""
+	}
+	return path
+}
+
+// CheckUsable implements the ManagerProber interface. Package managers
+// with no checks of their own can be used once they are found.
+func (b *basePackageManager) CheckUsable(ctx context.Context) types.PreflightResult {
+	return types.PreflightResult{Check: checkUsable}
+}
+
+// CheckUsable implements the ManagerProber interface. APT can't be used
+// while another APT or dpkg holds the dpkg lock, or with dpkg in a broken
+// state.
+func (a *apt) CheckUsable(ctx context.Context) types.PreflightResult {
+	lock := types.PreflightResult{Check: "dpkg lock"}
+	if name, pid := a.dpkgLockHolder(ctx); pid != "" {
+		lock.Problem = fmt.Sprintf("/var/lib/dpkg is locked: %s (pid %s) is using it", name, pid)
+		lock.Fix = "Wait for it to finish, or see what holds the lock with 'sudo lsof /var/lib/dpkg/lock-frontend'"
+		return lock
+	}
+	if _, err := a.pathIndex().LookPath("dpkg"); err == nil {
+		if health, err := a.dpkgAudit(ctx); err == nil && !health.Passed() {
+			return health
+		}
+	}
+	return lock
+}
+
+// dpkgLockHolder returns the name and pid of a process holding the dpkg
+// lock, as far as pgrep tells: dpkg, unattended-upgrades, or an APT front
+// end changing packages. The pid is "" when there is none.
+func (a *apt) dpkgLockHolder(ctx context.Context) (name, pid string) {
+	if _, err := a.pathIndex().LookPath("pgrep"); err != nil {
+		return "", ""
+	}
+	// pgrep exits with 1 when nothing matches. unattended-upgrades runs
+	// under python3, so it is told apart by the script it runs.
+	if output, err := a.probeTool(ctx, "pgrep", "-a", "-x", dpkgLockers); err == nil {
+		for _, line := range strings.Split(output, "\n") {
+			// "<pid> <command> <arguments>"
+			fields := strings.Fields(line)
+			if len(fields) < 2 {
+				continue
+			}
+			for _, arg := range fields[1:] {
+				name := filepath.Base(arg)
+				if name == unattendedShutdown {
+					break
+				}
+				if name == "dpkg" || strings.HasPrefix(name, "unattended-upgrade") {
+					return name, fields[0]
+				}
+			}
+		}
+	}
+	output, err := a.probeTool(ctx, "pgrep", "-a", "-x", aptFrontends)
+	if err != nil {
+		return "", ""
+	}
+	for _, line := range strings.Split(output, "\n") {
+		// "<pid> <command> <arguments>"
+		fields := strings.Fields(line)
+		if len(fields) < 3 {
+			continue
+		}
+		for _, arg := range fields[2:] {
+			if slices.Contains(aptChangeCommands, arg) {
+				return filepath.Base(fields[1]), fields[0]
+			}
+		}
+	}
+	return "", ""
+}
+
+// CheckUsable implements the ManagerProber interface. pacman can't be used
+// while its database lock exists, which a pacman that was killed leaves
+// behind.
+func (p *pacman) CheckUsable(ctx context.Context) types.PreflightResult {
+	lock := types.PreflightResult{Check: "database lock"}
+	if _, err := os.Stat(p.lockFile); err == nil {
+		lock.Problem = fmt.Sprintf("its database is locked (%s exists)", p.lockFile)
+		lock.Fix = fmt.Sprintf("If no other pacman is running, remove the stale lock with 'sudo rm %s'", p.lockFile)
+	}
+	return lock
+}
+
+// CheckUsable implements the ManagerProber interface. snap can't install
+// anything while snapd isn't running.
+func (s *snap) CheckUsable(ctx context.Context) types.PreflightResult {
+	daemon := types.PreflightResult{Check: "snapd"}
+	output, err := s.probeTool(ctx, s.executableName, "version")
+	if err != nil {
+		return daemon
+	}
+	for _, line := range strings.Split(output, "\n") {
+		if fields := strings.Fields(line); len(fields) == 2 && fields[0] == "snapd" && fields[1] == "unavailable" {
+			daemon.Problem = "snapd is not running"
+			daemon.Fix = "Start it with 'sudo systemctl enable --now snapd.socket'"
+		}
+	}
+	return daemon
+}
+
+// CheckUsable implements the ManagerProber interface. Scoop is a set of
+// PowerShell scripts, which an execution policy such as Restricted, the
+// default on Windows clients, keeps from running.
+func (s *scoop) CheckUsable(ctx context.Context) types.PreflightResult {
+	policy := types.PreflightResult{Check: "execution policy"}
+	for _, shell := range []string{"powershell", "pwsh"} {
+		if _, err := s.pathIndex().LookPath(shell); err != nil {
+			continue
+		}
+		output, err := s.probeTool(ctx, shell, "-NoProfile", "-NonInteractive", "-Command", "Get-ExecutionPolicy")
+		if err != nil {
+			return policy
+		}
+		current := strings.TrimSpace(output)
+		for _, blocking := range blockingExecutionPolicies {
+			if strings.EqualFold(current, blocking) {
+				policy.Problem = fmt.Sprintf("the PowerShell execution policy (%s) blocks it", current)
+				policy.Fix = "Run 'Set-ExecutionPolicy RemoteSigned -Scope CurrentUser' in PowerShell"
+			}
+		}
+		return policy
+	}
+	return policy
+}
+
+// CheckUsable implements the ManagerProber interface with the package
+// sources check: Chocolatey can't install anything with every source
+// disabled.
+func (c *chocolatey) CheckUsable(ctx context.Context) types.PreflightResult {
+	sources := types.PreflightResult{Check: "package sources"}
+	if enabled, err := c.enabledSources(ctx); err == nil && len(enabled) == 0 {
+		sources.Problem = "it has no enabled package sources"
+		sources.Fix = chocoSourcesFix
+	}
+	return sources
+}
+
+// CheckUsable implements the ManagerProber interface with the package
+// sources check: winget can't install anything without a source.
+func (w *winget) CheckUsable(ctx context.Context) types.PreflightResult {
+	sources := types.PreflightResult{Check: "package sources"}
+	if urls, err := w.sourceURLs(ctx); err == nil && len(urls) == 0 {
+		sources.Problem = "it has no package sources"
+		sources.Fix = wingetSourcesFix
+	}
+	return sources
+}
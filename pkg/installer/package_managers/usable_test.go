@@ -0,0 +1,167 @@
+package package_managers
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/runner/runnertest"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+func TestCheckUsable(t *testing.T) {
+	pgrep := "pgrep -a -x " + dpkgLockers
+	pgrepFrontends := "pgrep -a -x " + aptFrontends
+	executionPolicy := "powershell -NoProfile -NonInteractive -Command Get-ExecutionPolicy"
+
+	testCases := []struct {
+		name      string
+		manager   func(r *runnertest.Runner, path *runnertest.Path) types.ManagerProber
+		path      []string
+		responses map[string]runnertest.Response
+		problem   string
+	}{
+		{
+			name: "APT with unattended-upgrades holding the lock",
+			manager: func(r *runnertest.Runner, path *runnertest.Path) types.ManagerProber {
+				return &apt{basePackageManager: &basePackageManager{executableName: "apt", runner: r, path: path}}
+			},
+			path:      []string{"/usr/bin/apt", "/usr/bin/pgrep", "/usr/bin/dpkg"},
+			responses: map[string]runnertest.Response{pgrep: {Output: "731 /usr/bin/python3 /usr/share/unattended-upgrades/unattended-upgrade-shutdown --wait-for-signal\n812 /usr/bin/python3 /usr/bin/unattended-upgrade\n"}},
+			problem:   "/var/lib/dpkg is locked: unattended-upgrade (pid 812) is using it",
+		},
+		{
+			// Ubuntu runs unattended-upgrade-shutdown all the time
+			name: "APT with only the unattended-upgrades shutdown daemon",
+			manager: func(r *runnertest.Runner, path *runnertest.Path) types.ManagerProber {
+				return &apt{basePackageManager: &basePackageManager{executableName: "apt", runner: r, path: path}}
+			},
+			path: []string{"/usr/bin/apt", "/usr/bin/pgrep", "/usr/bin/dpkg"},
+			responses: map[string]runnertest.Response{
+				pgrep:          {Output: "731 /usr/bin/python3 /usr/share/unattended-upgrades/unattended-upgrade-shutdown --wait-for-signal\n"},
+				pgrepFrontends: {Err: errors.New("exit status 1")},
+				"dpkg --audit": {},
+			},
+		},
+		{
+			name: "APT with apt-get installing in another terminal",
+			manager: func(r *runnertest.Runner, path *runnertest.Path) types.ManagerProber {
+				return &apt{basePackageManager: &basePackageManager{executableName: "apt", runner: r, path: path}}
+			},
+			path: []string{"/usr/bin/apt", "/usr/bin/pgrep", "/usr/bin/dpkg"},
+			responses: map[string]runnertest.Response{
+				pgrep:          {Err: errors.New("exit status 1")},
+				pgrepFrontends: {Output: "3977 apt list --installed\n4211 /usr/bin/apt-get install -y nginx\n"},
+			},
+			problem: "/var/lib/dpkg is locked: apt-get (pid 4211) is using it",
+		},
+		{
+			// 'apt list' doesn't take the dpkg lock
+			name: "APT with apt list in another terminal",
+			manager: func(r *runnertest.Runner, path *runnertest.Path) types.ManagerProber {
+				return &apt{basePackageManager: &basePackageManager{executableName: "apt", runner: r, path: path}}
+			},
+			path: []string{"/usr/bin/apt", "/usr/bin/pgrep", "/usr/bin/dpkg"},
+			responses: map[string]runnertest.Response{
+				pgrep:          {Err: errors.New("exit status 1")},
+				pgrepFrontends: {Output: "3977 apt list --installed\n"},
+				"dpkg --audit": {},
+			},
+		},
+		{
+			name: "APT with dpkg interrupted",
+			manager: func(r *runnertest.Runner, path *runnertest.Path) types.ManagerProber {
+				return &apt{basePackageManager: &basePackageManager{executableName: "apt", runner: r, path: path}}
+			},
+			path: []string{"/usr/bin/apt", "/usr/bin/pgrep", "/usr/bin/dpkg"},
+			responses: map[string]runnertest.Response{
+				pgrep:          {Err: errors.New("exit status 1")},
+				"dpkg --audit": {Output: "The following packages are only half configured:\n nginx\n"},
+			},
+			problem: "dpkg reports packages in a broken state: The following packages are only half configured:",
+		},
+		{
+			name: "APT with nothing to tell",
+			manager: func(r *runnertest.Runner, path *runnertest.Path) types.ManagerProber {
+				return &apt{basePackageManager: &basePackageManager{executableName: "apt", runner: r, path: path}}
+			},
+			path: []string{"/usr/bin/apt", "/usr/bin/dpkg"},
+			// dpkg that can't be asked is left to the preflight checks
+			responses: map[string]runnertest.Response{"dpkg --audit": {Err: errors.New("exit status 2")}},
+		},
+		{
+			name: "Scoop with a Restricted execution policy",
+			manager: func(r *runnertest.Runner, path *runnertest.Path) types.ManagerProber {
+				return &scoop{basePackageManager: &basePackageManager{executableName: "scoop", runner: r, path: path}}
+			},
+			path:      []string{"/usr/bin/scoop", "/usr/bin/powershell"},
+			responses: map[string]runnertest.Response{executionPolicy: {Output: "Restricted\r\n"}},
+			problem:   "the PowerShell execution policy (Restricted) blocks it",
+		},
+		{
+			name: "Scoop with a RemoteSigned execution policy",
+			manager: func(r *runnertest.Runner, path *runnertest.Path) types.ManagerProber {
+				return &scoop{basePackageManager: &basePackageManager{executableName: "scoop", runner: r, path: path}}
+			},
+			path:      []string{"/usr/bin/scoop", "/usr/bin/powershell"},
+			responses: map[string]runnertest.Response{executionPolicy: {Output: "RemoteSigned\r\n"}},
+		},
+		{
+			name: "snap without snapd",
+			manager: func(r *runnertest.Runner, path *runnertest.Path) types.ManagerProber {
+				return &snap{basePackageManager: &basePackageManager{executableName: "snap", runner: r, path: path}}
+			},
+			path:      []string{"/usr/bin/snap"},
+			responses: map[string]runnertest.Response{"snap version": {Output: "snap    2.61.3\nsnapd   unavailable\nseries  -\n"}},
+			problem:   "snapd is not running",
+		},
+		{
+			name: "Chocolatey with every source disabled",
+			manager: func(r *runnertest.Runner, path *runnertest.Path) types.ManagerProber {
+				return &chocolatey{basePackageManager: &basePackageManager{executableName: "choco", runner: r, path: path}}
+			},
+			path:      []string{"/usr/bin/choco"},
+			responses: map[string]runnertest.Response{"choco source list --limit-output": {Output: "chocolatey|https://community.chocolatey.org/api/v2/|True|||0|False|False|False\n"}},
+			problem:   "it has no enabled package sources",
+		},
+		{
+			name: "winget without sources",
+			manager: func(r *runnertest.Runner, path *runnertest.Path) types.ManagerProber {
+				return &winget{basePackageManager: &basePackageManager{executableName: "winget", runner: r, path: path}}
+			},
+			path:      []string{"/usr/bin/winget"},
+			responses: map[string]runnertest.Response{"winget source list": {Output: "There are no sources configured.\n"}},
+			problem:   "it has no package sources",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &runnertest.Runner{Responses: tc.responses}
+			result := tc.manager(r, runnertest.NewPath([]string{"/usr/bin"}, tc.path...)).CheckUsable(context.Background())
+			if result.Problem != tc.problem {
+				t.Errorf("expected problem %q but got %q", tc.problem, result.Problem)
+			}
+			if result.Problem != "" && result.Fix == "" {
+				t.Errorf("expected a fix for %q", result.Problem)
+			}
+		})
+	}
+}
+
+func TestPacmanLock(t *testing.T) {
+	p := NewPacman().(*pacman)
+	p.lockFile = filepath.Join(t.TempDir(), "db.lck")
+	if result := p.CheckUsable(context.Background()); !result.Passed() {
+		t.Errorf("expected pacman to be usable without a lock but got %q", result.Problem)
+	}
+
+	if err := os.WriteFile(p.lockFile, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if result := p.CheckUsable(context.Background()); result.Passed() || result.Fix == "" {
+		t.Errorf("expected the lock to make pacman unusable but got %+v", result)
+	}
+}
@@ -0,0 +1,253 @@
+package package_managers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+	"github.com/MRQ67/stackmatch-cli/pkg/version"
+)
+
+// miseTools maps scanner language names to mise tool names
+var miseTools = map[string]string{
+	"Node.js": "node",
+	"Python":  "python",
+	"Ruby":    "ruby",
+	"Go":      "go",
+	"Java":    "java",
+	"Rust":    "rust",
+	"Kotlin":  "kotlin",
+	"Scala":   "scala",
+	"Elixir":  "elixir",
+	"Dart":    "dart",
+	"Groovy":  "groovy",
+	"Lua":     "lua",
+	"PHP":     "php",
+	"Perl":    "perl",
+	"Haskell": "ghc",
+	"C#":      "dotnet",
+}
+
+// asdfPlugins maps scanner language names to asdf plugin names
+var asdfPlugins = map[string]string{
+	"Node.js": "nodejs",
+	"Python":  "python",
+	"Ruby":    "ruby",
+	"Go":      "golang",
+	"Java":    "java",
+	"Rust":    "rust",
+	"Kotlin":  "kotlin",
+	"Scala":   "scala",
+	"Elixir":  "elixir",
+	"Dart":    "dart",
+	"Groovy":  "groovy",
+	"Lua":     "lua",
+	"PHP":     "php",
+	"Perl":    "perl",
+	"Haskell": "haskell",
+	"C#":      "dotnet",
+}
+
+// versionPrefix turns a constraint such as "18.x" into the version prefix
+// version managers resolve to the newest match ("18"), or "" for any version
+func versionPrefix(constraint string) string {
+	return strings.TrimSuffix(strings.TrimSuffix(constraint, ".x"), ".X")
+}
+
+type mise struct {
+	*basePackageManager
+}
+
+// NewMise creates a mise version manager instance
+func NewMise() types.VersionManager {
+	return &mise{basePackageManager: &basePackageManager{name: "mise", executableName: "mise"}}
+}
+
+// Supports implements the VersionManager interface
+func (m *mise) Supports(language string) bool {
+	_, ok := miseTools[language]
+	return ok
+}
+
+// InstallRuntime installs the runtime and makes it the global default
+func (m *mise) InstallRuntime(ctx context.Context, language, constraint string) error {
+	tool, ok := miseTools[language]
+	if !ok {
+		return fmt.Errorf("mise does not support %s", language)
+	}
+
+	v := versionPrefix(constraint)
+	if v == "" {
+		v = "latest"
+	}
+	if _, err := m.runCommand(ctx, "use", "--global", tool+"@"+v); err != nil {
+		return fmt.Errorf("failed to install %s %s: %w", language, v, err)
+	}
+	return nil
+}
+
+type asdf struct {
+	*basePackageManager
+}
+
+// NewAsdf creates an asdf version manager instance
+func NewAsdf() types.VersionManager {
+	return &asdf{basePackageManager: &basePackageManager{name: "asdf", executableName: "asdf"}}
+}
+
+// Supports implements the VersionManager interface
+func (a *asdf) Supports(language string) bool {
+	_, ok := asdfPlugins[language]
+	return ok
+}
+
+// InstallRuntime adds the plugin if needed and installs the runtime. Selecting
+// it as the default differs between asdf releases, so that is left to the user.
+func (a *asdf) InstallRuntime(ctx context.Context, language, constraint string) error {
+	plugin, ok := asdfPlugins[language]
+	if !ok {
+		return fmt.Errorf("asdf does not support %s", language)
+	}
+
+	// Fails when the plugin is already added, which is fine
+	_, _ = a.runCommand(ctx, "plugin", "add", plugin)
+
+	v := "latest"
+	if prefix := versionPrefix(constraint); prefix != constraint {
+		v = "latest:" + prefix
+	} else if strings.ContainsAny(constraint, "<>=^~* ") {
+		// asdf only installs versions, so ranges are resolved here
+		resolved, err := a.resolveRange(ctx, plugin, constraint)
+		if err != nil {
+			return fmt.Errorf("failed to install %s %s: %w", language, constraint, err)
+		}
+		v = resolved
+	} else if constraint != "" {
+		v = constraint
+	}
+	if _, err := a.runCommand(ctx, "install", plugin, v); err != nil {
+		return fmt.Errorf("failed to install %s %s: %w", language, v, err)
+	}
+
+	types.AddManualStep(ctx, types.ManualStep{
+		Category:    types.CategoryLanguages,
+		Description: fmt.Sprintf("Select the installed %s version with 'asdf set -u %s %s' (or 'asdf global' on asdf < 0.16)", language, plugin, v),
+		DocURL:      "https://asdf-vm.com/manage/versions.html",
+	})
+	return nil
+}
+
+// resolveRange returns the newest release 'asdf list all' lists for plugin
+// that satisfies constraint, such as ">=1.22". Pre-releases and versions
+// named otherwise, such as "temurin-21.0.2", are left out.
+func (a *asdf) resolveRange(ctx context.Context, plugin, constraint string) (string, error) {
+	output, err := a.runCommand(ctx, "list", "all", plugin)
+	if err != nil {
+		return "", fmt.Errorf("failed to list the %s versions: %w", plugin, err)
+	}
+	var best string
+	var bestVersion *version.Version
+	for _, name := range strings.Fields(output) {
+		v, err := version.Parse(name)
+		if err != nil || v.PreRelease != "" {
+			continue
+		}
+		ok, err := v.Satisfies(constraint)
+		if err != nil {
+			return "", err
+		}
+		if ok && (bestVersion == nil || v.Compare(bestVersion) > 0) {
+			best, bestVersion = name, v
+		}
+	}
+	if bestVersion == nil {
+		return "", fmt.Errorf("no %s version satisfies %q", plugin, constraint)
+	}
+	return best, nil
+}
+
+type pyenv struct {
+	*basePackageManager
+}
+
+// NewPyenv creates a version manager installing Python through pyenv
+func NewPyenv() types.VersionManager {
+	return &pyenv{basePackageManager: &basePackageManager{name: "pyenv", executableName: "pyenv"}}
+}
+
+// Supports implements the VersionManager interface
+func (p *pyenv) Supports(language string) bool {
+	return language == "Python"
+}
+
+// InstallRuntime installs the newest Python matching the constraint's
+// version prefix and makes it the global default
+func (p *pyenv) InstallRuntime(ctx context.Context, language, constraint string) error {
+	if !p.Supports(language) {
+		return fmt.Errorf("pyenv does not support %s", language)
+	}
+
+	prefix := versionPrefix(constraint)
+	if prefix == "" {
+		prefix = "3"
+	}
+	if _, err := p.runCommand(ctx, "install", "--skip-existing", prefix); err != nil {
+		return fmt.Errorf("failed to install %s %s: %w", language, prefix, err)
+	}
+	// 'pyenv global' wants the installed version's full name
+	output, err := p.runCommand(ctx, "latest", prefix)
+	if err != nil {
+		return fmt.Errorf("failed to find the installed %s %s: %w", language, prefix, err)
+	}
+	lines := strings.Fields(output)
+	if len(lines) == 0 {
+		return fmt.Errorf("pyenv found no installed %s %s", language, prefix)
+	}
+	installed := lines[len(lines)-1]
+	if _, err := p.runCommand(ctx, "global", installed); err != nil {
+		return fmt.Errorf("failed to select %s %s: %w", language, installed, err)
+	}
+	return nil
+}
+
+type uv struct {
+	*basePackageManager
+}
+
+// NewUv creates a version manager installing Python through uv
+func NewUv() types.VersionManager {
+	return &uv{basePackageManager: &basePackageManager{name: "uv", executableName: "uv"}}
+}
+
+// Supports implements the VersionManager interface
+func (u *uv) Supports(language string) bool {
+	return language == "Python"
+}
+
+// InstallRuntime installs the newest Python matching the constraint's
+// version prefix. uv selects interpreters per project, so pinning one is
+// left to the user.
+func (u *uv) InstallRuntime(ctx context.Context, language, constraint string) error {
+	if !u.Supports(language) {
+		return fmt.Errorf("uv does not support %s", language)
+	}
+
+	args := []string{"python", "install"}
+	prefix := versionPrefix(constraint)
+	if prefix != "" {
+		args = append(args, prefix)
+	}
+	if _, err := u.runCommand(ctx, args...); err != nil {
+		return fmt.Errorf("failed to install %s %s: %w", language, prefix, err)
+	}
+
+	if prefix != "" {
+		types.AddManualStep(ctx, types.ManualStep{
+			Category:    types.CategoryLanguages,
+			Description: fmt.Sprintf("Select the installed Python %s in your projects with 'uv python pin %s'", prefix, prefix),
+			DocURL:      "https://docs.astral.sh/uv/concepts/python-versions/",
+		})
+	}
+	return nil
+}
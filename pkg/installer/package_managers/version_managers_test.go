@@ -0,0 +1,140 @@
+package package_managers
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/runner/runnertest"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+func TestPyenvInstallRuntime(t *testing.T) {
+	testCases := []struct {
+		name       string
+		constraint string
+		responses  map[string]runnertest.Response
+		expected   []string
+		wantErr    bool
+	}{
+		{
+			name:       "Exact version",
+			constraint: "3.12.1",
+			responses: map[string]runnertest.Response{
+				"pyenv install --skip-existing 3.12.1": {},
+				"pyenv latest 3.12.1":                  {Output: "3.12.1\n"},
+				"pyenv global 3.12.1":                  {},
+			},
+			expected: []string{"pyenv install --skip-existing 3.12.1", "pyenv latest 3.12.1", "pyenv global 3.12.1"},
+		},
+		{
+			name:       "Version prefix",
+			constraint: "3.11.x",
+			responses: map[string]runnertest.Response{
+				"pyenv install --skip-existing 3.11": {},
+				"pyenv latest 3.11":                  {Output: "3.11.9\n"},
+				"pyenv global 3.11.9":                {},
+			},
+			expected: []string{"pyenv install --skip-existing 3.11", "pyenv latest 3.11", "pyenv global 3.11.9"},
+		},
+		{
+			name: "Failed build",
+			responses: map[string]runnertest.Response{
+				"pyenv install --skip-existing 3": {Output: "BUILD FAILED (Ubuntu 22.04 using python-build 2.4.0)\n", Err: errors.New("exit status 1")},
+			},
+			expected: []string{"pyenv install --skip-existing 3"},
+			wantErr:  true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &runnertest.Runner{Responses: tc.responses}
+			vm := &pyenv{basePackageManager: &basePackageManager{name: "pyenv", executableName: "pyenv", runner: r}}
+
+			err := vm.InstallRuntime(context.Background(), "Python", tc.constraint)
+			if tc.wantErr != (err != nil) {
+				t.Fatalf("expected error: %v but got %v", tc.wantErr, err)
+			}
+			if calls := r.Calls(); !reflect.DeepEqual(calls, tc.expected) {
+				t.Errorf("expected commands %q but got %q", tc.expected, calls)
+			}
+		})
+	}
+}
+
+func TestAsdfInstallRuntime(t *testing.T) {
+	const versions = "1.21.13\n1.22.0\n1.22.5\n1.23rc1\n1.23.0-rc.2\n"
+	testCases := []struct {
+		name       string
+		constraint string
+		responses  map[string]runnertest.Response
+		expected   []string
+		wantErr    bool
+	}{
+		{
+			name:       "Exact version",
+			constraint: "1.22.5",
+			responses:  map[string]runnertest.Response{"asdf install golang 1.22.5": {}},
+			expected:   []string{"asdf plugin add golang", "asdf install golang 1.22.5"},
+		},
+		{
+			name:       "Version prefix",
+			constraint: "1.22.x",
+			responses:  map[string]runnertest.Response{"asdf install golang latest:1.22": {}},
+			expected:   []string{"asdf plugin add golang", "asdf install golang latest:1.22"},
+		},
+		{
+			name:       "Range",
+			constraint: ">=1.22",
+			responses: map[string]runnertest.Response{
+				"asdf list all golang":       {Output: versions},
+				"asdf install golang 1.22.5": {},
+			},
+			expected: []string{"asdf plugin add golang", "asdf list all golang", "asdf install golang 1.22.5"},
+		},
+		{
+			name:       "Range nothing satisfies",
+			constraint: ">=1.24 <2",
+			responses:  map[string]runnertest.Response{"asdf list all golang": {Output: versions}},
+			expected:   []string{"asdf plugin add golang", "asdf list all golang"},
+			wantErr:    true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &runnertest.Runner{Responses: tc.responses}
+			vm := &asdf{basePackageManager: &basePackageManager{name: "asdf", executableName: "asdf", runner: r}}
+
+			err := vm.InstallRuntime(context.Background(), "Go", tc.constraint)
+			if tc.wantErr != (err != nil) {
+				t.Fatalf("expected error: %v but got %v", tc.wantErr, err)
+			}
+			if calls := r.Calls(); !reflect.DeepEqual(calls, tc.expected) {
+				t.Errorf("expected commands %q but got %q", tc.expected, calls)
+			}
+		})
+	}
+}
+
+func TestUvInstallRuntime(t *testing.T) {
+	r := &runnertest.Runner{Responses: map[string]runnertest.Response{"uv python install 3.12": {}}}
+	vm := &uv{basePackageManager: &basePackageManager{name: "uv", executableName: "uv", runner: r}}
+
+	collector := &types.StepCollector{}
+	ctx := types.WithStepCollector(context.Background(), collector)
+	if err := vm.InstallRuntime(ctx, "Python", "3.12.x"); err != nil {
+		t.Fatal(err)
+	}
+	if calls := r.Calls(); !reflect.DeepEqual(calls, []string{"uv python install 3.12"}) {
+		t.Errorf("expected uv python install 3.12 but got %q", calls)
+	}
+	if steps := collector.Steps(); len(steps) != 1 || steps[0].Description != "Select the installed Python 3.12 in your projects with 'uv python pin 3.12'" {
+		t.Errorf("expected a step to pin the version but got %+v", collector.Steps())
+	}
+	if vm.Supports("Node.js") {
+		t.Error("expected uv to only support Python")
+	}
+}
@@ -0,0 +1,223 @@
+package package_managers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/version"
+)
+
+// queryVersions runs a companion executable that lists the installed
+// versions of packages and parses its output with parse. Listing commands
+// exit non-zero when some of the packages are not installed, which is not a
+// failure as long as every line of stderr is about such a package, i.e.
+// contains missing.
+func (b *basePackageManager) queryVersions(ctx context.Context, name string, args []string, missing string, parse func(output string) map[string]string) (map[string]string, error) {
+	stdout, stderr, err := b.commandRunner().Output(ctx, name, args...)
+	if err != nil && !onlyMissing(stdout, stderr, missing) {
+		message := strings.TrimSpace(stderr)
+		if message == "" {
+			message = err.Error()
+		}
+		return nil, fmt.Errorf("failed to list installed versions: %s", message)
+	}
+	return parse(stdout), nil
+}
+
+// onlyMissing reports whether a listing command that failed only
+// complained about packages that are not installed
+func onlyMissing(stdout, stderr, missing string) bool {
+	if strings.TrimSpace(stdout+stderr) == "" {
+		return false
+	}
+	for _, line := range strings.Split(stderr, "\n") {
+		if strings.TrimSpace(line) != "" && (missing == "" || !strings.Contains(line, missing)) {
+			return false
+		}
+	}
+	return true
+}
+
+// requested keeps the entries of versions for packages, matching names
+// without regard to case as package managers such as Chocolatey do
+func requested(versions map[string]string, packages []string) map[string]string {
+	byName := make(map[string]string, len(versions))
+	for name, v := range versions {
+		byName[strings.ToLower(name)] = v
+	}
+	result := make(map[string]string)
+	for _, pkg := range packages {
+		if v, ok := byName[strings.ToLower(pkg)]; ok {
+			result[pkg] = v
+		}
+	}
+	return result
+}
+
+// InstalledVersions implements the BulkVersionChecker interface with a
+// single dpkg-query
+func (a *apt) InstalledVersions(ctx context.Context, packages []string) (map[string]string, error) {
+	if len(packages) == 0 {
+		return map[string]string{}, nil
+	}
+	args := append([]string{"-W", "-f=${Package}\\t${Version}\\t${Status}\\n"}, packages...)
+	versions, err := a.queryVersions(ctx, "dpkg-query", args, "no packages found matching", ParseDpkgQuery)
+	if err != nil {
+		return nil, err
+	}
+	return requested(versions, packages), nil
+}
+
+// ParseDpkgQuery returns the versions of the installed packages in the
+// output of 'dpkg-query -W -f=${Package}\t${Version}\t${Status}\n'.
+// Packages removed with their configuration kept are not installed.
+func ParseDpkgQuery(output string) map[string]string {
+	versions := make(map[string]string)
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Split(line, "\t")
+		if len(fields) < 3 || !strings.HasSuffix(strings.TrimSpace(fields[2]), " installed") {
+			continue
+		}
+		versions[fields[0]] = fields[1]
+	}
+	return versions
+}
+
+// InstalledVersions implements the BulkVersionChecker interface with a
+// single rpm query. Package groups have no version and are reported as
+// installed at an unknown one.
+func (d *dnf) InstalledVersions(ctx context.Context, packages []string) (map[string]string, error) {
+	return d.rpmVersions(ctx, packages)
+}
+
+// InstalledVersions implements the BulkVersionChecker interface
+func (y *yum) InstalledVersions(ctx context.Context, packages []string) (map[string]string, error) {
+	return y.rpmVersions(ctx, packages)
+}
+
+// rpmVersions asks rpm for the installed versions of packages at once
+func (b *basePackageManager) rpmVersions(ctx context.Context, packages []string) (map[string]string, error) {
+	result := make(map[string]string)
+	var query []string
+	for _, pkg := range packages {
+		if isPackageGroup(pkg) {
+			result[pkg] = ""
+			continue
+		}
+		query = append(query, pkg)
+	}
+	if len(query) == 0 {
+		return result, nil
+	}
+	// rpm prints "package x is not installed" to stdout and exits with the
+	// number of such packages
+	args := append([]string{"-q", "--queryformat", "%{NAME}\\t%{VERSION}\\n"}, query...)
+	versions, err := b.queryVersions(ctx, "rpm", args, "is not installed", parseTabbed)
+	if err != nil {
+		return nil, err
+	}
+	for pkg, v := range requested(versions, query) {
+		result[pkg] = v
+	}
+	return result, nil
+}
+
+// parseTabbed returns the name and version on each line of output, separated
+// by a tab
+func parseTabbed(output string) map[string]string {
+	versions := make(map[string]string)
+	for _, line := range strings.Split(output, "\n") {
+		if name, v, found := strings.Cut(strings.TrimSpace(line), "\t"); found {
+			versions[name] = v
+		}
+	}
+	return versions
+}
+
+// InstalledVersions implements the BulkVersionChecker interface with a
+// single 'pacman -Q'
+func (p *pacman) InstalledVersions(ctx context.Context, packages []string) (map[string]string, error) {
+	if len(packages) == 0 {
+		return map[string]string{}, nil
+	}
+	versions, err := p.queryVersions(ctx, p.executableName, append([]string{"-Q"}, packages...), "was not found", ParseNameVersions)
+	if err != nil {
+		return nil, err
+	}
+	return requested(versions, packages), nil
+}
+
+// InstalledVersions implements the BulkVersionChecker interface with a
+// single 'brew list --versions'
+func (h *homebrew) InstalledVersions(ctx context.Context, packages []string) (map[string]string, error) {
+	if len(packages) == 0 {
+		return map[string]string{}, nil
+	}
+	args := append([]string{"list", "--versions"}, packages...)
+	versions, err := h.queryVersions(ctx, h.executableName, args, "No such keg", ParseNameVersions)
+	if err != nil {
+		return nil, err
+	}
+	return requested(versions, packages), nil
+}
+
+// ParseNameVersions returns the versions in output listing a package per
+// line followed by its versions, as 'pacman -Q' and 'brew list --versions'
+// do. Of several versions of a package, the newest is kept.
+func ParseNameVersions(output string) map[string]string {
+	versions := make(map[string]string)
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		versions[fields[0]] = newestVersion(fields[1:])
+	}
+	return versions
+}
+
+// newestVersion returns the newest of versions, or the first when they
+// can't all be compared
+func newestVersion(versions []string) string {
+	var (
+		newest       string
+		newestParsed *version.Version
+	)
+	for _, v := range versions {
+		normalized, _ := version.Normalize(v)
+		parsed, err := version.Parse(normalized)
+		if err != nil {
+			return versions[0]
+		}
+		if newestParsed == nil || parsed.Compare(newestParsed) > 0 {
+			newest, newestParsed = v, parsed
+		}
+	}
+	return newest
+}
+
+// InstalledVersions implements the BulkVersionChecker interface with a
+// single listing of the local packages
+func (c *chocolatey) InstalledVersions(ctx context.Context, packages []string) (map[string]string, error) {
+	if len(packages) == 0 {
+		return map[string]string{}, nil
+	}
+	versions, err := c.queryVersions(ctx, c.executableName, []string{"list", "--local-only", "--limit-output"}, "", ParseChocoList)
+	if err != nil {
+		return nil, err
+	}
+	return requested(versions, packages), nil
+}
+
+// ParseChocoList returns the versions in the output of 'choco list
+// --limit-output', which lists packages as name|version
+func ParseChocoList(output string) map[string]string {
+	versions := make(map[string]string)
+	for _, line := range strings.Split(output, "\n") {
+		if name, v, found := strings.Cut(strings.TrimSpace(line), "|"); found {
+			versions[name] = v
+		}
+	}
+	return versions
+}
@@ -0,0 +1,100 @@
+package package_managers
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/runner/runnertest"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+func TestInstalledVersions(t *testing.T) {
+	missing := errors.New("exit status 1")
+	testCases := []struct {
+		name     string
+		checker  func(r *runnertest.Runner) types.BulkVersionChecker
+		packages []string
+		response map[string]runnertest.Response
+		expected map[string]string
+	}{
+		{
+			name: "DNF",
+			checker: func(r *runnertest.Runner) types.BulkVersionChecker {
+				d := NewDnf().(*dnf)
+				d.runner = r
+				return d
+			},
+			packages: []string{"git", "@development-tools", "jq"},
+			response: map[string]runnertest.Response{
+				"rpm -q --queryformat %{NAME}\\t%{VERSION}\\n git jq": {Output: "git\t2.43.0\npackage jq is not installed\n", Err: missing},
+			},
+			expected: map[string]string{"git": "2.43.0", "@development-tools": ""},
+		},
+		{
+			name: "Pacman",
+			checker: func(r *runnertest.Runner) types.BulkVersionChecker {
+				p := NewPacman().(*pacman)
+				p.runner = r
+				return p
+			},
+			packages: []string{"git", "jq"},
+			response: map[string]runnertest.Response{
+				"pacman -Q git jq": {Output: "git 2.43.0-1\n", Stderr: "error: package 'jq' was not found\n", Err: missing},
+			},
+			expected: map[string]string{"git": "2.43.0-1"},
+		},
+		{
+			name: "Homebrew",
+			checker: func(r *runnertest.Runner) types.BulkVersionChecker {
+				return newHomebrew("/opt/homebrew/bin/brew", r, nil)
+			},
+			packages: []string{"git", "node@18"},
+			response: map[string]runnertest.Response{
+				"/opt/homebrew/bin/brew list --versions git node@18": {Output: "git 2.42.0 2.43.0_1\nnode@18 18.19.0\n"},
+			},
+			expected: map[string]string{"git": "2.43.0_1", "node@18": "18.19.0"},
+		},
+		{
+			name: "Chocolatey",
+			checker: func(r *runnertest.Runner) types.BulkVersionChecker {
+				c := NewChocolatey().(*chocolatey)
+				c.runner = r
+				return c
+			},
+			packages: []string{"Git", "nodejs-lts", "jq"},
+			response: map[string]runnertest.Response{
+				"choco list --local-only --limit-output": {Output: "chocolatey|2.2.2\ngit|2.43.0\nnodejs-lts|20.11.0\n"},
+			},
+			expected: map[string]string{"Git": "2.43.0", "nodejs-lts": "20.11.0"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &runnertest.Runner{Responses: tc.response}
+			actual, err := tc.checker(r).InstalledVersions(context.Background(), tc.packages)
+			if err != nil {
+				t.Fatalf("expected no error but got %v", err)
+			}
+			if !reflect.DeepEqual(actual, tc.expected) {
+				t.Errorf("expected %v but got %v", tc.expected, actual)
+			}
+			if calls := r.Calls(); len(calls) != 1 {
+				t.Errorf("expected a single query but got %v", calls)
+			}
+		})
+	}
+}
+
+func TestInstalledVersionsFailure(t *testing.T) {
+	p := NewPacman().(*pacman)
+	p.runner = &runnertest.Runner{Responses: map[string]runnertest.Response{
+		"pacman -Q git": {Stderr: "error: could not lock database\n", Err: errors.New("exit status 1")},
+	}}
+	_, err := p.InstalledVersions(context.Background(), []string{"git"})
+	if err == nil || err.Error() != "failed to list installed versions: error: could not lock database" {
+		t.Errorf("expected the lock error but got %v", err)
+	}
+}
@@ -3,24 +3,29 @@ package package_managers
 import (
 	"context"
 	"fmt"
+	"regexp"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/MRQ67/stackmatch-cli/pkg/types"
 )
 
 type winget struct {
 	*basePackageManager
+	scope types.InstallScope
 }
 
 // NewWinget creates a new Winget package manager instance
 func NewWinget() types.Installer {
-	return &winget{
+	w := &winget{
 		basePackageManager: &basePackageManager{
 			name:           "Winget",
 			pmType:        types.TypeWinget,
 			executableName: "winget",
 		},
 	}
+	w.uninstallPackageFunc = w.uninstallPackage
+	return w
 }
 
 func (w *winget) InstallPackage(ctx context.Context, pkg string) error {
@@ -35,7 +40,11 @@ func (w *winget) InstallPackage(ctx context.Context, pkg string) error {
 	}
 
 	// Install the package with --silent for non-interactive installation
-	_, err = w.runCommand(ctx, "install", "--silent", "--accept-package-agreements", "--accept-source-agreements", pkg)
+	args := []string{"install", "--silent", "--accept-package-agreements", "--accept-source-agreements"}
+	if w.scope == types.ScopeUser {
+		args = append(args, "--scope", "user")
+	}
+	_, err = w.runCommand(ctx, append(args, pkg)...)
 	if err != nil {
 		return fmt.Errorf("failed to install package: %w", err)
 	}
@@ -63,6 +72,22 @@ func (w *winget) InstallMultiple(ctx context.Context, packages []string) error {
 	return nil
 }
 
+// Scope implements the Scoper interface
+func (w *winget) Scope() types.InstallScope {
+	if w.scope == "" {
+		return types.ScopeSystem
+	}
+	return w.scope
+}
+
+// SetScope implements the Scoper interface. In the user scope, packages
+// are installed with --scope user, which installers that only install
+// machine-wide reject.
+func (w *winget) SetScope(scope types.InstallScope) error {
+	w.scope = scope
+	return nil
+}
+
 func (w *winget) UpdatePackageManager(ctx context.Context) error {
 	// Update winget itself
 	_, err := w.runCommand(ctx, "--version")
@@ -79,23 +104,111 @@ func (w *winget) UpdatePackageManager(ctx context.Context) error {
 	return nil
 }
 
-// checkIfInstalled overrides the base implementation with Winget-specific logic
+// wingetFoundRegex matches the "Found <name> [<id>]" line winget prints before acting on a package
+var wingetFoundRegex = regexp.MustCompile(`(?m)^Found (.+?) \[([^\]]+)\]`)
+
+// uninstallPackage uninstalls a package using Winget
+func (w *winget) uninstallPackage(ctx context.Context, pkg string) error {
+	_, err := w.UninstallPackageWithReport(ctx, pkg, types.UninstallOptions{})
+	return err
+}
+
+// UninstallPackageWithReport uninstalls a package and reports what Winget removed.
+// Winget never removes dependencies, so RemoveDependencies is ignored.
+func (w *winget) UninstallPackageWithReport(ctx context.Context, pkg string, opts types.UninstallOptions) (*types.UninstallResult, error) {
+	installed, err := w.checkIfInstalled(ctx, pkg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check if package is installed: %w", err)
+	}
+
+	if !installed {
+		return &types.UninstallResult{}, nil // Already uninstalled
+	}
+
+	output, err := w.runCommand(ctx, "uninstall", "--id", pkg, "--exact", "--silent", "--accept-source-agreements")
+	if err != nil {
+		return nil, fmt.Errorf("failed to uninstall package: %w", err)
+	}
+
+	return &types.UninstallResult{Removed: parseWingetUninstallOutput(output, pkg)}, nil
+}
+
+// parseWingetUninstallOutput returns the package ID winget reported as uninstalled.
+// The ID comes from the "Found" line when present, falling back to the requested name.
+func parseWingetUninstallOutput(output, pkg string) []string {
+	if !strings.Contains(output, "Successfully uninstalled") {
+		return nil
+	}
+	if match := wingetFoundRegex.FindStringSubmatch(output); match != nil {
+		return []string{match[2]}
+	}
+	return []string{pkg}
+}
+
+// checkIfInstalled overrides the base implementation with Winget-specific
+// logic. Packages are winget IDs, which rarely match the display name, so
+// they are looked up by ID the way uninstall does.
 func (w *winget) checkIfInstalled(ctx context.Context, pkg string) (bool, error) {
-	output, err := w.runCommand(ctx, "list", "--name", pkg)
+	output, err := w.runCommand(ctx, "list", "--id", pkg, "--exact")
 	if err != nil {
+		// winget list fails when nothing matches
 		return false, nil
 	}
+	for _, id := range parseWingetListIDs(output) {
+		if strings.EqualFold(id, pkg) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
 
-	// Check if the package appears in the list of installed packages
-	lines := strings.Split(output, "\n")
-	if len(lines) > 2 { // Header + separator + at least one package
-		// Check if any line (after header and separator) contains the package
-		for _, line := range lines[2:] {
-			if strings.Contains(strings.ToLower(line), strings.ToLower(pkg)) {
-				return true, nil
-			}
+// parseWingetListIDs returns the Id column of a 'winget list' table. The
+// columns are found from the header, since names may hold spaces.
+func parseWingetListIDs(output string) []string {
+	var lines []string
+	for _, line := range strings.Split(strings.ReplaceAll(output, "\r\n", "\n"), "\n") {
+		// Progress spinners are overwritten with carriage returns
+		if i := strings.LastIndex(line, "\r"); i >= 0 {
+			line = line[i+1:]
 		}
+		lines = append(lines, line)
 	}
 
-	return false, nil
+	for i := 0; i+1 < len(lines); i++ {
+		separator := strings.TrimSpace(lines[i+1])
+		if separator == "" || strings.Trim(separator, "-") != "" {
+			continue
+		}
+		// Columns are measured in characters, not bytes
+		at := strings.Index(lines[i], " Id ")
+		if at < 0 {
+			return nil
+		}
+		header := []rune(lines[i])
+		start := utf8.RuneCountInString(lines[i][:at+1])
+		end := start + len("Id")
+		for end < len(header) && header[end] == ' ' {
+			end++
+		}
+		if end == len(header) {
+			end = -1
+		}
+
+		var ids []string
+		for _, line := range lines[i+2:] {
+			row := []rune(line)
+			if len(row) <= start {
+				continue
+			}
+			cell := row[start:]
+			if end >= 0 && len(row) > end {
+				cell = row[start:end]
+			}
+			if id := strings.TrimSpace(string(cell)); id != "" {
+				ids = append(ids, id)
+			}
+		}
+		return ids
+	}
+	return nil
 }
@@ -24,6 +24,15 @@ func NewYum() types.Installer {
 }
 
 func (y *yum) InstallPackage(ctx context.Context, pkg string) error {
+	// Groups are not listed as installed packages, and installing one
+	// again is a no-op
+	if isPackageGroup(pkg) {
+		if _, err := y.runCommand(ctx, "install", "-y", pkg); err != nil {
+			return fmt.Errorf("failed to install group: %w", err)
+		}
+		return nil
+	}
+
 	// First check if already installed
 	installed, err := y.checkIfInstalled(ctx, pkg)
 	if err != nil {
@@ -58,6 +67,11 @@ func (y *yum) InstallMultiple(ctx context.Context, packages []string) error {
 	return nil
 }
 
+// ReinstallPackages implements the Reinstaller interface
+func (y *yum) ReinstallPackages(ctx context.Context, packages []string) error {
+	return y.reinstallEach(ctx, packages, []string{"reinstall", "-y"}, []string{"install", "-y"})
+}
+
 func (y *yum) UpdatePackageManager(ctx context.Context) error {
 	// Update all packages
 	_, err := y.runCommand(ctx, "update", "-y")
@@ -0,0 +1,137 @@
+package installer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/runner"
+	"github.com/MRQ67/stackmatch-cli/pkg/runner/runnertest"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+var updateGolden = flag.Bool("update", false, "Rewrite the golden files in testdata")
+
+// useMachine simulates a machine with executables on PATH answering
+// commands with responses, until the test ends
+func useMachine(t *testing.T, executables []string, responses map[string]runnertest.Response) {
+	previous := runner.DefaultPath
+	runner.DefaultPath = runnertest.NewPath([]string{"/usr/bin"}, executables...)
+	t.Cleanup(func() { runner.DefaultPath = previous })
+	useRunner(t, &runnertest.Runner{Responses: responses})
+}
+
+func TestProbePackageManagers(t *testing.T) {
+	testCases := []struct {
+		name        string
+		goos        string
+		executables []string
+		responses   map[string]runnertest.Response
+		fallBack    bool
+	}{
+		{
+			name:        "ubuntu-dpkg-locked",
+			goos:        "linux",
+			executables: []string{"/usr/bin/apt", "/usr/bin/dpkg", "/usr/bin/pgrep", "/usr/bin/snap"},
+			responses: map[string]runnertest.Response{
+				"pgrep -a -x dpkg|unattended-upgr": {Output: "812 /usr/bin/python3 /usr/bin/unattended-upgrade\n"},
+				"snap version":                     {Output: "snap    2.61.3\nsnapd   2.61.3\nseries  16\n"},
+			},
+		},
+		{
+			name:        "ubuntu-dpkg-locked-fallback",
+			goos:        "linux",
+			executables: []string{"/usr/bin/apt", "/usr/bin/dpkg", "/usr/bin/pgrep", "/usr/bin/snap"},
+			responses: map[string]runnertest.Response{
+				"pgrep -a -x dpkg|unattended-upgr": {Output: "812 /usr/bin/python3 /usr/bin/unattended-upgrade\n"},
+				"snap version":                     {Output: "snap    2.61.3\nsnapd   2.61.3\nseries  16\n"},
+			},
+			fallBack: true,
+		},
+		{
+			name:        "fedora",
+			goos:        "linux",
+			executables: []string{"/usr/bin/dnf", "/usr/bin/yum"},
+		},
+		{
+			name: "alpine-without-apk",
+			goos: "linux",
+		},
+		{
+			name:        "windows-scoop-blocked",
+			goos:        "windows",
+			executables: []string{"/usr/bin/scoop", "/usr/bin/powershell", "/usr/bin/winget"},
+			responses: map[string]runnertest.Response{
+				"powershell -NoProfile -NonInteractive -Command Get-ExecutionPolicy": {Output: "Restricted\r\n"},
+				"winget source list": {Output: "Name    Argument\n--------------------------------------------------------------\nwinget  https://cdn.winget.microsoft.com/cache\n"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			useMachine(t, tc.executables, tc.responses)
+			useFallBack(t, tc.fallBack)
+			report, _ := probePackageManagers(context.Background(), tc.goos, packageManagers(tc.goos), false)
+			data, err := json.MarshalIndent(report, "", "  ")
+			if err != nil {
+				t.Fatal(err)
+			}
+			data = append(data, '\n')
+
+			golden := filepath.Join("testdata", "probe", tc.name+".json")
+			if *updateGolden {
+				if err := os.MkdirAll(filepath.Dir(golden), 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(golden, data, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			expected, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("failed to read %s (run with -update to create it): %v", golden, err)
+			}
+			if string(data) != string(expected) {
+				t.Errorf("report differs from %s:\n%s", golden, data)
+			}
+		})
+	}
+}
+
+// useFallBack sets FallBack until the test ends
+func useFallBack(t *testing.T, fallBack bool) {
+	previous := FallBack
+	FallBack = fallBack
+	t.Cleanup(func() { FallBack = previous })
+}
+
+func TestDetectPackageManagerReport(t *testing.T) {
+	useMachine(t, []string{"/usr/bin/apt", "/usr/bin/pgrep"}, map[string]runnertest.Response{
+		"pgrep -a -x apt|apt-get|aptitude": {Output: "4211 apt-get install -y nginx\n"},
+	})
+
+	_, err := detectPackageManager(context.Background(), packageManagers("linux"))
+	var noManager *types.NoPackageManagerError
+	if !errors.As(err, &noManager) {
+		t.Fatalf("expected a NoPackageManagerError but got %v", err)
+	}
+	if len(noManager.Report.Managers) != len(packageManagers("linux")) {
+		t.Errorf("expected every package manager considered to be reported but got %+v", noManager.Report.Managers)
+	}
+	expected := "no supported package manager found: APT found but /var/lib/dpkg is locked: apt-get (pid 4211) is using it"
+	if err.Error() != expected {
+		t.Errorf("expected %q but got %q", expected, err.Error())
+	}
+
+	useMachine(t, nil, nil)
+	_, err = detectPackageManager(context.Background(), packageManagers("darwin"))
+	if err == nil || !strings.Contains(err.Error(), "looked for Homebrew") {
+		t.Errorf("expected the error to name the package managers looked for but got %v", err)
+	}
+}
@@ -1,14 +1,18 @@
 package installer
 
 import (
+	"cmp"
 	"context"
 	"encoding/json"
 	"fmt"
 	"os"
-	"path/filepath"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/MRQ67/stackmatch-cli/pkg/audit"
+	"github.com/MRQ67/stackmatch-cli/pkg/config"
 	"github.com/MRQ67/stackmatch-cli/pkg/types"
 )
 
@@ -27,6 +31,23 @@ type InstallationRecord struct {
 	Environment *types.EnvironmentData     `json:"environment,omitempty"`
 	Metadata    map[string]string         `json:"metadata,omitempty"`
 	Status      InstallationStatus         `json:"status"`
+	// RemovedPackages lists packages the package manager reported as removed
+	// during rollback, including dependencies removed along with them
+	RemovedPackages []string `json:"removed_packages,omitempty"`
+	// ManualSteps are follow-up actions the user has to perform by hand
+	ManualSteps []types.ManualStep `json:"manual_steps,omitempty"`
+	// Pins lists the packages held at their installed version after the
+	// installation, which rollback releases
+	Pins []Pin `json:"pins,omitempty"`
+	// Conflicts records how conflicts between the packages of the
+	// environment were settled before installing
+	Conflicts []ConflictDecision `json:"conflicts,omitempty"`
+}
+
+// Pin is a package held at its installed version by its package manager
+type Pin struct {
+	Package     string                   `json:"package"`
+	ManagerType types.PackageManagerType `json:"manager_type"`
 }
 
 // PackageInfo contains information about an installed package
@@ -34,6 +55,12 @@ type PackageInfo struct {
 	Name        string `json:"name"`
 	Version     string `json:"version,omitempty"`
 	ManagerType string `json:"manager_type"`
+	// Tool is the environment entry the package installs, such as "Git".
+	// Records written by older releases don't have it.
+	Tool string `json:"tool,omitempty"`
+	// Order counts the packages of the installation in the order they were
+	// installed, from 1. Records written by older releases don't have it.
+	Order int `json:"order,omitempty"`
 }
 
 // InstallationStatus represents the status of an installation
@@ -104,11 +131,18 @@ func (t *InstallationTracker) AddPackage(installationID string, pkg types.Packag
 		return fmt.Errorf("installation record not found: %s", installationID)
 	}
 
-	// Convert types.PackageInfo to installer.PackageInfo
+	// Convert types.PackageInfo to installer.PackageInfo, keeping the
+	// order of a package recorded again
+	order := len(record.Packages) + 1
+	if existing, ok := record.Packages[pkg.Name]; ok {
+		order = existing.Order
+	}
 	record.Packages[pkg.Name] = PackageInfo{
 		Name:        pkg.Name,
 		Version:     pkg.Version,
-		ManagerType: "", // Manager type is not available in types.PackageInfo
+		ManagerType: string(pkg.Manager),
+		Tool:        pkg.Tool,
+		Order:       order,
 	}
 
 	return t.save()
@@ -147,8 +181,96 @@ func (t *InstallationTracker) FailInstallation(installationID string, reason str
 	return t.save()
 }
 
-// Rollback rolls back an installation by uninstalling all installed packages
-func (t *InstallationTracker) Rollback(ctx context.Context, installationID string, manager types.Installer) error {
+// SetManualSteps records the manual follow-up steps of an installation
+func (t *InstallationTracker) SetManualSteps(installationID string, steps []types.ManualStep) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	record, exists := t.installations[installationID]
+	if !exists {
+		return fmt.Errorf("installation record not found: %s", installationID)
+	}
+
+	record.ManualSteps = steps
+	return t.save()
+}
+
+// SetConflicts records how the conflicts between packages of an
+// installation were settled
+func (t *InstallationTracker) SetConflicts(installationID string, conflicts []ConflictDecision) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	record, exists := t.installations[installationID]
+	if !exists {
+		return fmt.Errorf("installation record not found: %s", installationID)
+	}
+
+	record.Conflicts = conflicts
+	return t.save()
+}
+
+// MarkStepDone marks the manual step with the given 1-based number as done
+func (t *InstallationTracker) MarkStepDone(installationID string, number int) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	record, exists := t.installations[installationID]
+	if !exists {
+		return fmt.Errorf("installation record not found: %s", installationID)
+	}
+	if number < 1 || number > len(record.ManualSteps) {
+		return fmt.Errorf("step %d does not exist; installation %s has %d manual steps", number, installationID, len(record.ManualSteps))
+	}
+
+	record.ManualSteps[number-1].Done = true
+	return t.save()
+}
+
+// AddPin records a package pinned after an installation
+func (t *InstallationTracker) AddPin(installationID string, pin Pin) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	record, exists := t.installations[installationID]
+	if !exists {
+		return fmt.Errorf("installation record not found: %s", installationID)
+	}
+
+	record.Pins = append(record.Pins, pin)
+	return t.save()
+}
+
+// RemovePin forgets every recorded pin of pkg, once its hold was released
+func (t *InstallationTracker) RemovePin(pkg string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	found := false
+	for _, record := range t.installations {
+		kept := record.Pins[:0]
+		for _, pin := range record.Pins {
+			if pin.Package == pkg {
+				found = true
+				continue
+			}
+			kept = append(kept, pin)
+		}
+		record.Pins = kept
+	}
+	if !found {
+		return fmt.Errorf("no pin recorded for package: %s", pkg)
+	}
+	return t.save()
+}
+
+// Rollback rolls back an installation by uninstalling all installed packages,
+// last installed first, so packages go before those they were installed on.
+// Packages already reported as removed (for example as a dependency of another
+// package, or by an earlier partial rollback) are skipped. Pins the
+// installation created are released first, since held packages cannot be
+// removed.
+func (t *InstallationTracker) Rollback(ctx context.Context, installationID string, manager types.Installer, opts types.UninstallOptions) error {
 	t.mu.Lock()
 	record, exists := t.installations[installationID]
 	if !exists {
@@ -162,12 +284,73 @@ func (t *InstallationTracker) Rollback(ctx context.Context, installationID strin
 		t.mu.Unlock()
 		return fmt.Errorf("failed to update installation status: %w", err)
 	}
+	removed := make(map[string]bool, len(record.RemovedPackages))
+	for _, name := range record.RemovedPackages {
+		removed[strings.ToLower(name)] = true
+	}
+	pins := append([]Pin(nil), record.Pins...)
+	packages := make([]PackageInfo, 0, len(record.Packages))
+	for _, pkg := range record.Packages {
+		packages = append(packages, pkg)
+	}
 	t.mu.Unlock()
 
-	// Rollback packages in reverse order
 	var rollbackErr error
-	for _, pkg := range record.Packages {
-		if err := manager.UninstallPackage(ctx, pkg.Name); err != nil {
+	var kept []Pin
+	pinner, canPin := manager.(types.Pinner)
+	for _, pin := range pins {
+		var err error
+		if canPin {
+			err = audit.Do(ctx, audit.Operation{Action: audit.ActionUnpin, Package: pin.Package, Manager: string(pin.ManagerType)}, func(ctx context.Context) error {
+				return pinner.UnpinPackage(ctx, pin.Package)
+			})
+		} else {
+			err = fmt.Errorf("%s cannot release pins", manager.Name())
+		}
+		if err != nil {
+			kept = append(kept, pin)
+			if rollbackErr == nil {
+				rollbackErr = fmt.Errorf("failed to unpin package %s: %w", pin.Package, err)
+			} else {
+				rollbackErr = fmt.Errorf("%w; failed to unpin package %s: %v", rollbackErr, pin.Package, err)
+			}
+		}
+	}
+
+	reporter, canReport := manager.(types.UninstallReporter)
+
+	// Rollback packages in reverse order. Those of older records, which
+	// don't know the order, go last, by name.
+	slices.SortFunc(packages, func(a, b PackageInfo) int {
+		return cmp.Or(cmp.Compare(b.Order, a.Order), strings.Compare(a.Name, b.Name))
+	})
+	var newlyRemoved []string
+	for _, pkg := range packages {
+		if removed[strings.ToLower(pkg.Name)] {
+			continue // Already gone
+		}
+
+		op := audit.Operation{Action: audit.ActionUninstall, Package: pkg.Name, Manager: pkg.ManagerType}
+		err := audit.Do(ctx, op, func(ctx context.Context) error {
+			if !canReport {
+				err := manager.UninstallPackage(ctx, pkg.Name)
+				if err == nil {
+					removed[strings.ToLower(pkg.Name)] = true
+					newlyRemoved = append(newlyRemoved, pkg.Name)
+				}
+				return err
+			}
+			result, err := reporter.UninstallPackageWithReport(ctx, pkg.Name, opts)
+			if err == nil {
+				for _, name := range result.Removed {
+					removed[strings.ToLower(name)] = true
+					newlyRemoved = append(newlyRemoved, name)
+				}
+			}
+			return err
+		})
+
+		if err != nil {
 			// Log the error but continue with other packages
 			if rollbackErr == nil {
 				rollbackErr = fmt.Errorf("failed to uninstall package %s: %w", pkg.Name, err)
@@ -180,6 +363,9 @@ func (t *InstallationTracker) Rollback(ctx context.Context, installationID strin
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
+	record.RemovedPackages = append(record.RemovedPackages, newlyRemoved...)
+	record.Pins = kept
+
 	if rollbackErr != nil {
 		record.Status = "rollback_failed"
 		record.Metadata["rollback_error"] = rollbackErr.Error()
@@ -227,27 +413,30 @@ func (t *InstallationTracker) ListInstallations() []InstallationRecord {
 	return records
 }
 
-// save saves the installation records to disk
-func (t *InstallationTracker) save() error {
+// RemoveInstallations forgets the installation records with the given IDs.
+// Installations still in progress are kept, since rolling back an
+// interrupted import needs them.
+func (t *InstallationTracker) RemoveInstallations(ids []string) error {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
-	// Ensure the directory exists
-	if err := os.MkdirAll(filepath.Dir(t.trackerFile), 0755); err != nil {
-		return fmt.Errorf("failed to create tracker directory: %w", err)
+	for _, id := range ids {
+		if record, exists := t.installations[id]; exists && record.Status != StatusInProgress {
+			delete(t.installations, id)
+		}
 	}
+	return t.save()
+}
 
-	file, err := os.Create(t.trackerFile)
+// save saves the installation records to disk. Callers must hold t.mu.
+func (t *InstallationTracker) save() error {
+	data, err := json.MarshalIndent(t.installations, "", "  ")
 	if err != nil {
-		return fmt.Errorf("failed to create tracker file: %w", err)
+		return fmt.Errorf("failed to encode installation records: %w", err)
 	}
-	defer file.Close()
 
-	encoder := json.NewEncoder(file)
-	encoder.SetIndent("", "  ")
-
-	if err := encoder.Encode(t.installations); err != nil {
-		return fmt.Errorf("failed to encode installation records: %w", err)
+	if err := config.WritePrivateFile(t.trackerFile, append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to save installation records: %w", err)
 	}
 
 	return nil
@@ -0,0 +1,184 @@
+package installer
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+// reportingManager is a fake installer that removes a fixed set of packages per uninstall
+type reportingManager struct {
+	types.Installer
+	removes    map[string][]string
+	uninstalls []string
+}
+
+func (m *reportingManager) UninstallPackageWithReport(ctx context.Context, pkg string, opts types.UninstallOptions) (*types.UninstallResult, error) {
+	m.uninstalls = append(m.uninstalls, pkg)
+	return &types.UninstallResult{Removed: m.removes[pkg]}, nil
+}
+
+func TestRollbackSkipsPackagesAlreadyRemoved(t *testing.T) {
+	tracker, err := NewInstallationTracker(filepath.Join(t.TempDir(), "installations.json"))
+	if err != nil {
+		t.Fatalf("failed to create tracker: %v", err)
+	}
+
+	record, err := tracker.StartInstallation(nil)
+	if err != nil {
+		t.Fatalf("failed to start installation: %v", err)
+	}
+	for _, name := range []string{"git", "git.install"} {
+		if err := tracker.AddPackage(record.ID, types.PackageInfo{Name: name}); err != nil {
+			t.Fatalf("failed to add package: %v", err)
+		}
+	}
+
+	// Removing either package also removes the other as a dependency
+	manager := &reportingManager{removes: map[string][]string{
+		"git":         {"git", "git.install"},
+		"git.install": {"git.install", "git"},
+	}}
+	if err := tracker.Rollback(context.Background(), record.ID, manager, types.UninstallOptions{RemoveDependencies: true}); err != nil {
+		t.Fatalf("rollback failed: %v", err)
+	}
+
+	if len(manager.uninstalls) != 1 {
+		t.Errorf("expected a single uninstall call, got %v", manager.uninstalls)
+	}
+
+	got, _ := tracker.GetInstallation(record.ID)
+	if got.Status != StatusRolledBack {
+		t.Errorf("expected status %s, got %s", StatusRolledBack, got.Status)
+	}
+	if len(got.RemovedPackages) != 2 {
+		t.Errorf("expected both packages recorded as removed, got %v", got.RemovedPackages)
+	}
+}
+
+func TestRollbackRemovesPackagesInReverseOrder(t *testing.T) {
+	tracker, err := NewInstallationTracker(filepath.Join(t.TempDir(), "installations.json"))
+	if err != nil {
+		t.Fatalf("failed to create tracker: %v", err)
+	}
+
+	record, err := tracker.StartInstallation(nil)
+	if err != nil {
+		t.Fatalf("failed to start installation: %v", err)
+	}
+	for _, name := range []string{"python3", "python3-pip", "pipx"} {
+		if err := tracker.AddPackage(record.ID, types.PackageInfo{Name: name}); err != nil {
+			t.Fatalf("failed to add package: %v", err)
+		}
+	}
+
+	manager := &reportingManager{}
+	if err := tracker.Rollback(context.Background(), record.ID, manager, types.UninstallOptions{}); err != nil {
+		t.Fatalf("rollback failed: %v", err)
+	}
+
+	expected := []string{"pipx", "python3-pip", "python3"}
+	if strings.Join(manager.uninstalls, ", ") != strings.Join(expected, ", ") {
+		t.Errorf("expected uninstalls %v but got %v", expected, manager.uninstalls)
+	}
+}
+
+func TestMarkStepDone(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "installations.json")
+	tracker, err := NewInstallationTracker(file)
+	if err != nil {
+		t.Fatalf("failed to create tracker: %v", err)
+	}
+
+	record, err := tracker.StartInstallation(nil)
+	if err != nil {
+		t.Fatalf("failed to start installation: %v", err)
+	}
+	steps := []types.ManualStep{
+		{Category: types.CategoryConfigFiles, Description: "Copy .gitconfig from the source machine"},
+		{Category: types.CategoryTools, Description: "Restart Windows to finish installing docker-desktop"},
+	}
+	if err := tracker.SetManualSteps(record.ID, steps); err != nil {
+		t.Fatalf("failed to set manual steps: %v", err)
+	}
+
+	if err := tracker.MarkStepDone(record.ID, 2); err != nil {
+		t.Fatalf("failed to mark step done: %v", err)
+	}
+	if err := tracker.MarkStepDone(record.ID, 3); err == nil {
+		t.Error("expected an error for a step that does not exist")
+	}
+
+	// Reload from disk to check the status was persisted
+	reloaded, err := NewInstallationTracker(file)
+	if err != nil {
+		t.Fatalf("failed to reload tracker: %v", err)
+	}
+	got, ok := reloaded.GetInstallation(record.ID)
+	if !ok {
+		t.Fatalf("installation %s not found after reload", record.ID)
+	}
+	if got.ManualSteps[0].Done || !got.ManualSteps[1].Done {
+		t.Errorf("expected only step 2 to be done, got %+v", got.ManualSteps)
+	}
+}
+
+// pinningManager is a fake installer that records unpins and uninstalls in order
+type pinningManager struct {
+	types.Installer
+	calls []string
+}
+
+func (m *pinningManager) PinPackage(ctx context.Context, pkg string) error {
+	m.calls = append(m.calls, "pin "+pkg)
+	return nil
+}
+
+func (m *pinningManager) UnpinPackage(ctx context.Context, pkg string) error {
+	m.calls = append(m.calls, "unpin "+pkg)
+	return nil
+}
+
+func (m *pinningManager) UninstallPackage(ctx context.Context, pkg string) error {
+	m.calls = append(m.calls, "uninstall "+pkg)
+	return nil
+}
+
+func TestRollbackReleasesPins(t *testing.T) {
+	tracker, err := NewInstallationTracker(filepath.Join(t.TempDir(), "installations.json"))
+	if err != nil {
+		t.Fatalf("failed to create tracker: %v", err)
+	}
+
+	record, err := tracker.StartInstallation(nil)
+	if err != nil {
+		t.Fatalf("failed to start installation: %v", err)
+	}
+	if err := tracker.AddPackage(record.ID, types.PackageInfo{Name: "terraform", Version: "1.8.5"}); err != nil {
+		t.Fatalf("failed to add package: %v", err)
+	}
+	if err := tracker.AddPin(record.ID, Pin{Package: "terraform", ManagerType: types.TypeApt}); err != nil {
+		t.Fatalf("failed to add pin: %v", err)
+	}
+
+	manager := &pinningManager{}
+	if err := tracker.Rollback(context.Background(), record.ID, manager, types.UninstallOptions{}); err != nil {
+		t.Fatalf("rollback failed: %v", err)
+	}
+
+	// Held packages cannot be removed, so the pin goes first
+	expected := []string{"unpin terraform", "uninstall terraform"}
+	if strings.Join(manager.calls, ", ") != strings.Join(expected, ", ") {
+		t.Errorf("expected calls %v but got %v", expected, manager.calls)
+	}
+	got, _ := tracker.GetInstallation(record.ID)
+	if len(got.Pins) != 0 {
+		t.Errorf("expected the released pin to be forgotten but got %v", got.Pins)
+	}
+	if err := tracker.RemovePin("terraform"); err == nil {
+		t.Error("expected an error removing a pin that is no longer recorded")
+	}
+}
@@ -3,11 +3,114 @@ package installer
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/MRQ67/stackmatch-cli/pkg/types"
 	"github.com/MRQ67/stackmatch-cli/pkg/ui"
+	"github.com/MRQ67/stackmatch-cli/pkg/version"
 )
 
+// Verification states of a package
+const (
+	// VerificationSatisfied means the package is installed at a version
+	// satisfying its constraint
+	VerificationSatisfied = "satisfied"
+	// VerificationUnsatisfied means the package is missing or installed at
+	// a version outside its constraint
+	VerificationUnsatisfied = "unsatisfied"
+	// VerificationUnknown means the package manager could not tell
+	VerificationUnknown = "unknown"
+)
+
+// PackageVerification is the outcome of checking one installed package
+type PackageVerification struct {
+	Package string `json:"package"`
+	// Constraint is the version the package had to be installed at; any
+	// version will do when empty
+	Constraint string `json:"constraint,omitempty"`
+	// Installed is the version found, if any
+	Installed string `json:"installed,omitempty"`
+	State     string `json:"state"`
+	// Reason explains an unsatisfied or unknown state
+	Reason string `json:"reason,omitempty"`
+}
+
+// VerifyPackages checks that each of packages is installed at a version
+// satisfying its entry in constraints, if any. Package managers that
+// implement types.BulkVersionChecker are asked about every package at once;
+// others are asked about each package in turn. A failed query leaves the
+// packages it was about unknown rather than failing the verification.
+func VerifyPackages(ctx context.Context, pkgMgr Installer, packages []string, constraints map[string]string) []PackageVerification {
+	results := make([]PackageVerification, len(packages))
+	for i, pkg := range packages {
+		results[i] = PackageVerification{Package: pkg, Constraint: constraints[pkg]}
+	}
+
+	if checker, ok := pkgMgr.(types.BulkVersionChecker); ok {
+		installed, err := checker.InstalledVersions(ctx, packages)
+		for i := range results {
+			v, found := installed[results[i].Package]
+			results[i].judge(v, found, err)
+		}
+		return results
+	}
+
+	for i := range results {
+		info, err := pkgMgr.GetInstalledVersion(ctx, results[i].Package)
+		if err == nil && info.Version == "" {
+			// Package managers without a version query report nothing,
+			// which doesn't tell a missing package from an installed one
+			results[i].State = VerificationUnknown
+			results[i].Reason = fmt.Sprintf("%s did not report an installed version", pkgMgr.Name())
+			continue
+		}
+		var v string
+		if info != nil {
+			v = info.Version
+		}
+		results[i].judge(v, true, err)
+	}
+	return results
+}
+
+// judge sets the state of p from the version the package manager reported,
+// whether the package was found at all and the error querying it
+func (p *PackageVerification) judge(installed string, found bool, err error) {
+	p.Installed = installed
+	switch {
+	case err != nil:
+		p.State, p.Reason = VerificationUnknown, err.Error()
+	case !found:
+		p.State, p.Reason = VerificationUnsatisfied, "not installed"
+	case installed == "" && p.Constraint == "":
+		p.State = VerificationSatisfied
+	case installed == "":
+		p.State, p.Reason = VerificationUnknown, "installed, but at a version the package manager did not report"
+	default:
+		explanation := version.Explain(installed, p.Constraint)
+		switch {
+		case explanation.Satisfied:
+			p.State = VerificationSatisfied
+		case explanation.ParseError != "":
+			p.State, p.Reason = VerificationUnknown, explanation.ParseError
+		default:
+			p.State, p.Reason = VerificationUnsatisfied, fmt.Sprintf("%s does not satisfy %s", installed, p.Constraint)
+		}
+	}
+}
+
+// unsatisfied describes the packages of results that did not verify, or
+// returns "" when every package did or could not be told
+func unsatisfied(results []PackageVerification) string {
+	var problems []string
+	for _, result := range results {
+		if result.State == VerificationUnsatisfied {
+			problems = append(problems, fmt.Sprintf("%s (%s)", result.Package, result.Reason))
+		}
+	}
+	return strings.Join(problems, ", ")
+}
+
 // VerifyInstallation verifies that a package was installed correctly
 func VerifyInstallation(ctx context.Context, pkgMgr Installer, pkg string, versionConstraint *types.VersionConstraint) error {
 	ui.PrintInfo("Verifying installation of %s...", pkg)
@@ -42,7 +145,8 @@ func VerifyInstallation(ctx context.Context, pkgMgr Installer, pkg string, versi
 	return nil
 }
 
-// BatchInstall performs batch installation with progress reporting
+// BatchInstall performs batch installation with progress reporting, then
+// verifies the installed packages in one pass
 func BatchInstall(ctx context.Context, pkgMgr Installer, packages []string, versionedPackages map[string]types.VersionConstraint) error {
 	total := len(packages) + len(versionedPackages)
 	if total == 0 {
@@ -64,11 +168,6 @@ func BatchInstall(ctx context.Context, pkgMgr Installer, packages []string, vers
 		if err := pkgMgr.InstallPackage(ctx, pkg); err != nil {
 			return fmt.Errorf("failed to install %s: %w", pkg, err)
 		}
-
-		// Verify installation
-		if err := VerifyInstallation(ctx, pkgMgr, pkg, nil); err != nil {
-			return fmt.Errorf("verification failed for %s: %w", pkg, err)
-		}
 	}
 
 	// Install versioned packages
@@ -81,12 +180,18 @@ func BatchInstall(ctx context.Context, pkgMgr Installer, packages []string, vers
 		if err := pkgMgr.InstallVersion(ctx, pkg, constraint); err != nil {
 			return fmt.Errorf("failed to install %s@%s: %w", pkg, constraint.Version, err)
 		}
-
-		// Verify installation with version constraint
-		if err := VerifyInstallation(ctx, pkgMgr, pkg, &constraint); err != nil {
-			return fmt.Errorf("verification failed for %s@%s: %w", pkg, constraint.Version, err)
-		}
 	}
 
+	// Verify everything at once, rather than querying the package manager
+	// between installs
+	verify := append([]string(nil), packages...)
+	constraints := make(map[string]string, len(versionedPackages))
+	for pkg, constraint := range versionedPackages {
+		verify = append(verify, pkg)
+		constraints[pkg] = constraint.Version
+	}
+	if problems := unsatisfied(VerifyPackages(ctx, pkgMgr, verify, constraints)); problems != "" {
+		return fmt.Errorf("verification failed for %s", problems)
+	}
 	return nil
 }
@@ -0,0 +1,96 @@
+package installer
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/installer/package_managers"
+	"github.com/MRQ67/stackmatch-cli/pkg/runner"
+	"github.com/MRQ67/stackmatch-cli/pkg/runner/runnertest"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+// useRunner makes the package managers run commands with r until the test
+// ends
+func useRunner(t *testing.T, r runner.Runner) {
+	previous := runner.Default
+	runner.Default = r
+	t.Cleanup(func() { runner.Default = previous })
+}
+
+func TestVerifyPackagesQueriesOnce(t *testing.T) {
+	packages := []string{"git", "curl", "nodejs"}
+	constraints := map[string]string{"nodejs": "18.*"}
+	r := &runnertest.Runner{Responses: map[string]runnertest.Response{
+		// The per-package queries of VerifyInstallation
+		"apt dpkg-query -W -f=${Version}\\n${Status}\\n git":    {Output: "1:2.39.2-1ubuntu1\ninstall ok installed\n"},
+		"apt dpkg-query -W -f=${Version}\\n${Status}\\n curl":   {Output: "7.88.1-10\ninstall ok installed\n"},
+		"apt dpkg-query -W -f=${Version}\\n${Status}\\n nodejs": {Output: "18.19.0-1nodesource1\ninstall ok installed\n"},
+		// The single query of VerifyPackages
+		"dpkg-query -W -f=${Package}\\t${Version}\\t${Status}\\n git curl nodejs": {
+			Output: "git\t1:2.39.2-1ubuntu1\tinstall ok installed\ncurl\t7.88.1-10\tinstall ok installed\nnodejs\t18.19.0-1nodesource1\tinstall ok installed\n",
+		},
+	}}
+	useRunner(t, r)
+	apt := package_managers.NewApt()
+
+	for _, pkg := range packages {
+		var constraint *types.VersionConstraint
+		if c, ok := constraints[pkg]; ok {
+			constraint = &types.VersionConstraint{Version: c}
+		}
+		if err := VerifyInstallation(context.Background(), apt, pkg, constraint); err != nil {
+			t.Fatalf("expected %s to verify but got %v", pkg, err)
+		}
+	}
+	perPackage := len(r.Calls())
+
+	results := VerifyPackages(context.Background(), apt, packages, constraints)
+	batched := len(r.Calls()) - perPackage
+
+	if perPackage != 4 || batched != 1 {
+		t.Errorf("expected 4 queries verifying each package and 1 verifying them together but got %d and %d", perPackage, batched)
+	}
+	expected := []PackageVerification{
+		{Package: "git", Installed: "1:2.39.2-1ubuntu1", State: VerificationSatisfied},
+		{Package: "curl", Installed: "7.88.1-10", State: VerificationSatisfied},
+		{Package: "nodejs", Constraint: "18.*", Installed: "18.19.0-1nodesource1", State: VerificationSatisfied},
+	}
+	if !reflect.DeepEqual(results, expected) {
+		t.Errorf("expected %+v but got %+v", expected, results)
+	}
+}
+
+func TestVerifyPackagesStates(t *testing.T) {
+	r := &runnertest.Runner{Responses: map[string]runnertest.Response{
+		"dpkg-query -W -f=${Package}\\t${Version}\\t${Status}\\n git nodejs jq vim": {
+			Output: "git\t2.39.2-1\tinstall ok installed\nnodejs\t20.11.0-1\tinstall ok installed\nvim\t2:9.0.1378-2\tdeinstall ok config-files\n",
+			Stderr: "dpkg-query: no packages found matching jq\n",
+			Err:    errors.New("exit status 1"),
+		},
+		"dpkg-query -W -f=${Package}\\t${Version}\\t${Status}\\n git": {
+			Stderr: "dpkg-query: error: database is locked\n",
+			Err:    errors.New("exit status 2"),
+		},
+	}}
+	useRunner(t, r)
+	apt := package_managers.NewApt()
+
+	results := VerifyPackages(context.Background(), apt, []string{"git", "nodejs", "jq", "vim"}, map[string]string{"nodejs": "18.*"})
+	expected := []PackageVerification{
+		{Package: "git", Installed: "2.39.2-1", State: VerificationSatisfied},
+		{Package: "nodejs", Constraint: "18.*", Installed: "20.11.0-1", State: VerificationUnsatisfied, Reason: "20.11.0-1 does not satisfy 18.*"},
+		{Package: "jq", State: VerificationUnsatisfied, Reason: "not installed"},
+		{Package: "vim", State: VerificationUnsatisfied, Reason: "not installed"},
+	}
+	if !reflect.DeepEqual(results, expected) {
+		t.Errorf("expected %+v but got %+v", expected, results)
+	}
+
+	results = VerifyPackages(context.Background(), apt, []string{"git"}, nil)
+	if len(results) != 1 || results[0].State != VerificationUnknown {
+		t.Errorf("expected a failed query to leave git unknown but got %+v", results)
+	}
+}
@@ -0,0 +1,244 @@
+// Package langconfig reads the settings of language toolchains that decide
+// where packages are installed and fetched from, such as GOPATH and the npm
+// prefix, and writes them through the toolchains' own config commands. Shell
+// init files are never read or changed.
+package langconfig
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/redact"
+	"github.com/MRQ67/stackmatch-cli/pkg/runner"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+// Toolchains whose settings are recorded
+const (
+	Go  = "go"
+	Npm = "npm"
+	Pip = "pip"
+)
+
+// goVars are the go env variables recorded. Unset variables are left out.
+var goVars = []string{"GOPATH", "GOBIN", "GOPROXY", "GOPRIVATE"}
+
+// Read returns the settings of every toolchain found on path, or nil when
+// there are none, with paths inside home abbreviated to "~". Toolchains whose
+// config command fails are reported as warnings and left out.
+func Read(ctx context.Context, r runner.Runner, path runner.PathIndex, home string) (types.LanguageConfig, []string) {
+	config := make(types.LanguageConfig)
+	var warnings []string
+	record := func(toolchain string, values map[string]string, err error) {
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("could not read the %s config: %v", toolchain, err))
+			return
+		}
+		for key, value := range values {
+			values[key] = Abbreviate(value, home)
+		}
+		if len(values) > 0 {
+			config[toolchain] = values
+		}
+	}
+
+	if _, err := path.LookPath("go"); err == nil {
+		values, err := readGo(ctx, r)
+		record(Go, values, err)
+	}
+	if _, err := path.LookPath("npm"); err == nil {
+		values, err := readNpm(ctx, r)
+		record(Npm, values, err)
+	}
+	if pip := pipCommand(path); pip != "" {
+		values, err := readPip(ctx, r, pip)
+		record(Pip, values, err)
+	}
+
+	if len(config) == 0 {
+		return nil, warnings
+	}
+	return config, warnings
+}
+
+// readGo runs 'go env' for goVars, which prints one value per line in order
+func readGo(ctx context.Context, r runner.Runner) (map[string]string, error) {
+	stdout, stderr, err := r.Output(ctx, "go", append([]string{"env"}, goVars...)...)
+	if err != nil {
+		return nil, commandError(err, stderr)
+	}
+	values := make(map[string]string)
+	lines := strings.Split(strings.ReplaceAll(stdout, "\r\n", "\n"), "\n")
+	for i, name := range goVars {
+		if i < len(lines) && strings.TrimSpace(lines[i]) != "" {
+			values[name] = strings.TrimSpace(lines[i])
+		}
+	}
+	return values, nil
+}
+
+// readNpm runs 'npm config get prefix'
+func readNpm(ctx context.Context, r runner.Runner) (map[string]string, error) {
+	stdout, stderr, err := r.Output(ctx, "npm", "config", "get", "prefix")
+	if err != nil {
+		return nil, commandError(err, stderr)
+	}
+	values := make(map[string]string)
+	if prefix := strings.TrimSpace(stdout); prefix != "" {
+		values["prefix"] = prefix
+	}
+	return values, nil
+}
+
+// readPip runs 'pip config list'
+func readPip(ctx context.Context, r runner.Runner, pip string) (map[string]string, error) {
+	stdout, stderr, err := r.Output(ctx, pip, "config", "list")
+	if err != nil {
+		return nil, commandError(err, stderr)
+	}
+	return ParsePip(stdout), nil
+}
+
+// pipCommand returns the pip found on path, preferring pip3
+func pipCommand(path runner.PathIndex) string {
+	for _, name := range []string{"pip3", "pip"} {
+		if _, err := path.LookPath(name); err == nil {
+			return name
+		}
+	}
+	return ""
+}
+
+// ParsePip returns the settings listed by 'pip config list', whose lines look
+// like global.index-url='https://pypi.example/simple'
+func ParsePip(list string) map[string]string {
+	values := make(map[string]string)
+	for _, line := range strings.Split(list, "\n") {
+		key, value, ok := strings.Cut(strings.TrimRight(line, "\r"), "=")
+		if !ok || !strings.Contains(key, ".") {
+			continue
+		}
+		if len(value) >= 2 && (value[0] == '\'' || value[0] == '"') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		values[key] = value
+	}
+	return values
+}
+
+// commandError returns the first line of stderr as the error, or err when
+// stderr is empty
+func commandError(err error, stderr string) error {
+	message := strings.TrimSpace(stderr)
+	if i := strings.IndexByte(message, '\n'); i >= 0 {
+		message = message[:i]
+	}
+	if message == "" {
+		return err
+	}
+	return fmt.Errorf("%s", message)
+}
+
+// Abbreviate replaces home at the start of value with "~", so that settings
+// recorded on machines with different user names compare equal
+func Abbreviate(value, home string) string {
+	if home == "" {
+		return value
+	}
+	if value == home {
+		return "~"
+	}
+	for _, sep := range []string{"/", `\`} {
+		if strings.HasPrefix(value, home+sep) {
+			return "~" + value[len(home):]
+		}
+	}
+	return value
+}
+
+// Expand replaces a leading "~" in value with home
+func Expand(value, home string) string {
+	if value == "~" {
+		return home
+	}
+	if strings.HasPrefix(value, "~/") || strings.HasPrefix(value, `~\`) {
+		return filepath.Join(home, value[2:])
+	}
+	return value
+}
+
+// Change is a recorded setting whose value on this machine differs
+type Change struct {
+	types.LanguageSetting
+	// Current is the value on this machine, empty when it is not set
+	Current string
+}
+
+// Compare returns the settings of want that have another value, or none, in
+// have. Settings only found in have are not changes: nothing is unset.
+func Compare(want, have types.LanguageConfig) []Change {
+	var changes []Change
+	for _, setting := range want.Settings() {
+		current, _ := have.Get(setting.Toolchain, setting.Key)
+		if current != setting.Value {
+			changes = append(changes, Change{LanguageSetting: setting, Current: current})
+		}
+	}
+	return changes
+}
+
+// Applicable reports whether Apply may write setting: Go and npm settings
+// without redacted secrets. pip settings are left to the user, because pip
+// picks a config file by scope and the recorded key alone does not say
+// which one the user wants.
+func Applicable(setting types.LanguageSetting) bool {
+	if redact.Redacted(setting.Value) {
+		return false
+	}
+	return setting.Toolchain == Go || setting.Toolchain == Npm
+}
+
+// Command returns the command that writes setting, with a leading "~" in its
+// value expanded to home. An empty home leaves the value as recorded.
+func Command(setting types.LanguageSetting, home string) []string {
+	value := setting.Value
+	if home != "" {
+		value = Expand(value, home)
+	}
+	switch setting.Toolchain {
+	case Go:
+		return []string{"go", "env", "-w", setting.Key + "=" + value}
+	case Npm:
+		return []string{"npm", "config", "set", setting.Key, value}
+	case Pip:
+		return []string{"pip", "config", "set", setting.Key, value}
+	}
+	return nil
+}
+
+// Target describes the file the command of setting writes to
+func Target(setting types.LanguageSetting) string {
+	switch setting.Toolchain {
+	case Go:
+		return "the go env file ('go env GOENV')"
+	case Npm:
+		return "your user .npmrc"
+	case Pip:
+		return "your pip config file"
+	}
+	return ""
+}
+
+// Apply writes setting through its toolchain's config command
+func Apply(ctx context.Context, r runner.Runner, setting types.LanguageSetting, home string) error {
+	if !Applicable(setting) {
+		return fmt.Errorf("%s must be set by hand", setting.Name())
+	}
+	command := Command(setting, home)
+	if output, err := r.CombinedOutput(ctx, command[0], command[1:]...); err != nil {
+		return fmt.Errorf("failed to set %s: %w\n%s", setting.Name(), err, output)
+	}
+	return nil
+}
@@ -0,0 +1,203 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// DefaultListBudget bounds how long listing a single PATH directory may take
+// before it is skipped. Local directories list in well under a millisecond;
+// NFS or SMB mounts and macOS network homes can stall for seconds.
+const DefaultListBudget = 2 * time.Second
+
+// IndexOptions configures IndexPath
+type IndexOptions struct {
+	// Dirs is the PATH to index, in order (default: the PATH environment variable)
+	Dirs []string
+	// Exclude lists directory prefixes that are never listed or probed
+	Exclude []string
+	// Budget is how long listing one directory may take (default DefaultListBudget)
+	Budget time.Duration
+	// ReadDir lists the file names in a directory (default: os.ReadDir)
+	ReadDir func(dir string) ([]string, error)
+}
+
+// IndexedPath is a PathIndex answering lookups from one listing of every PATH
+// directory, so slow directories are waited on at most once and excluded ones
+// never touched
+type IndexedPath struct {
+	dirs    []string
+	entries map[string]map[string]bool
+	exclude []string
+	// Slow lists the PATH directories skipped because listing them took
+	// longer than the budget
+	Slow []string
+	// Excluded lists the PATH directories skipped because they match an
+	// exclude prefix
+	Excluded []string
+}
+
+// IndexPath lists every PATH directory concurrently. Directories that do not
+// finish within the budget, or before ctx is done, are skipped and reported
+// in Slow; their listing is abandoned rather than waited for.
+func IndexPath(ctx context.Context, opts IndexOptions) *IndexedPath {
+	dirs := opts.Dirs
+	if dirs == nil {
+		dirs = filepath.SplitList(os.Getenv("PATH"))
+	}
+	budget := opts.Budget
+	if budget <= 0 {
+		budget = DefaultListBudget
+	}
+	readDir := opts.ReadDir
+	if readDir == nil {
+		readDir = readDirNames
+	}
+
+	p := &IndexedPath{entries: make(map[string]map[string]bool)}
+	for _, prefix := range opts.Exclude {
+		p.exclude = append(p.exclude, filepath.Clean(prefix))
+	}
+
+	type listing struct {
+		names []string
+		err   error
+	}
+	seen := make(map[string]bool)
+	var pending []string
+	results := make(map[string]chan listing)
+	for _, dir := range dirs {
+		if dir == "" || seen[dir] {
+			continue
+		}
+		seen[dir] = true
+		if p.excluded(dir) {
+			p.Excluded = append(p.Excluded, dir)
+			continue
+		}
+		// Buffered so an abandoned listing can still finish and exit
+		ch := make(chan listing, 1)
+		go func(dir string) {
+			names, err := readDir(dir)
+			ch <- listing{names, err}
+		}(dir)
+		results[dir] = ch
+		pending = append(pending, dir)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, budget)
+	defer cancel()
+	for _, dir := range pending {
+		var l listing
+		select {
+		case l = <-results[dir]:
+		case <-ctx.Done():
+			select {
+			case l = <-results[dir]:
+			default:
+				p.Slow = append(p.Slow, dir)
+				continue
+			}
+		}
+		if l.err != nil {
+			continue // Missing or unreadable directories hold no executables
+		}
+		names := make(map[string]bool, len(l.names))
+		for _, name := range l.names {
+			names[nameKey(name)] = true
+		}
+		p.dirs = append(p.dirs, dir)
+		p.entries[dir] = names
+	}
+	return p
+}
+
+// readDirNames returns the names of the entries in dir
+func readDirNames(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	return names, err
+}
+
+// nameKey normalizes a file name for lookups; Windows file names are case
+// insensitive
+func nameKey(name string) string {
+	if runtime.GOOS == "windows" {
+		return strings.ToLower(name)
+	}
+	return name
+}
+
+// excluded reports whether path is inside one of the exclude prefixes
+func (p *IndexedPath) excluded(path string) bool {
+	path = filepath.Clean(path)
+	for _, prefix := range p.exclude {
+		if path == prefix || strings.HasPrefix(path, prefix+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// skipped reports whether path lies in an excluded or slow directory
+func (p *IndexedPath) skipped(path string) bool {
+	if p.excluded(path) {
+		return true
+	}
+	dir := filepath.Dir(path)
+	for _, slow := range p.Slow {
+		if filepath.Clean(slow) == dir {
+			return true
+		}
+	}
+	return false
+}
+
+// LookPath implements PathIndex
+func (p *IndexedPath) LookPath(file string) (string, error) {
+	if strings.ContainsAny(file, `/\`) {
+		if p.IsExecutable(file) {
+			return file, nil
+		}
+		return "", fmt.Errorf("%s: %w", file, ErrNotFound)
+	}
+	if all := p.LookPathAll(file); len(all) > 0 {
+		return all[0], nil
+	}
+	return "", fmt.Errorf("%s: %w", file, ErrNotFound)
+}
+
+// LookPathAll implements PathIndex
+func (p *IndexedPath) LookPathAll(file string) []string {
+	var found []string
+	for _, dir := range p.dirs {
+		for _, candidate := range executableNames(file) {
+			if !p.entries[dir][nameKey(candidate)] {
+				continue
+			}
+			path := filepath.Join(dir, candidate)
+			if (SystemPath{}).IsExecutable(path) {
+				found = append(found, path)
+				break
+			}
+		}
+	}
+	return found
+}
+
+// IsExecutable implements PathIndex. Paths in skipped directories are never
+// probed and reported as not executable.
+func (p *IndexedPath) IsExecutable(path string) bool {
+	if p.skipped(path) {
+		return false
+	}
+	return (SystemPath{}).IsExecutable(path)
+}
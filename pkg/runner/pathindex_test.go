@@ -0,0 +1,129 @@
+//go:build !windows
+
+package runner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// writeTool creates an executable named name in dir
+func writeTool(t *testing.T, dir, name string) string {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// delayedLister lists directories with os.ReadDir, stalling on the ones in
+// delays the way a network mount does, and records which directories it listed
+type delayedLister struct {
+	delays map[string]time.Duration
+
+	mu     sync.Mutex
+	listed []string
+}
+
+func (l *delayedLister) readDir(dir string) ([]string, error) {
+	l.mu.Lock()
+	l.listed = append(l.listed, dir)
+	l.mu.Unlock()
+	time.Sleep(l.delays[dir])
+	return readDirNames(dir)
+}
+
+func TestIndexPath(t *testing.T) {
+	root := t.TempDir()
+	local := filepath.Join(root, "usr", "bin")
+	nfs := filepath.Join(root, "net", "tools", "bin")
+	smb := filepath.Join(root, "mnt", "share", "bin")
+	home := filepath.Join(root, "home", "bin")
+	missing := filepath.Join(root, "missing")
+
+	git := writeTool(t, local, "git")
+	writeTool(t, nfs, "terraform")
+	writeTool(t, smb, "git")
+	homeGit := writeTool(t, home, "git")
+	if err := os.WriteFile(filepath.Join(local, "README"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	lister := &delayedLister{delays: map[string]time.Duration{nfs: time.Second}}
+	start := time.Now()
+	p := IndexPath(context.Background(), IndexOptions{
+		Dirs:    []string{local, nfs, smb, missing, home, local},
+		Exclude: []string{filepath.Join(root, "mnt")},
+		Budget:  50 * time.Millisecond,
+		ReadDir: lister.readDir,
+	})
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("expected the slow directory to be abandoned but indexing took %s", elapsed)
+	}
+
+	if !reflect.DeepEqual(p.Slow, []string{nfs}) {
+		t.Errorf("expected %s to be reported as slow but got %v", nfs, p.Slow)
+	}
+	if !reflect.DeepEqual(p.Excluded, []string{smb}) {
+		t.Errorf("expected %s to be excluded but got %v", smb, p.Excluded)
+	}
+	lister.mu.Lock()
+	defer lister.mu.Unlock()
+	for _, dir := range lister.listed {
+		if dir == smb {
+			t.Errorf("expected the excluded directory not to be listed")
+		}
+	}
+
+	testCases := []struct {
+		name     string
+		file     string
+		expected []string
+	}{
+		{name: "Found in PATH order", file: "git", expected: []string{git, homeGit}},
+		{name: "Only in a slow directory", file: "terraform"},
+		{name: "Not executable", file: "README"},
+		{name: "Not installed", file: "node"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := p.LookPathAll(tc.file); !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("expected %v but got %v", tc.expected, got)
+			}
+			_, err := p.LookPath(tc.file)
+			if (err == nil) != (len(tc.expected) > 0) {
+				t.Errorf("expected LookPath to agree with LookPathAll but got %v", err)
+			}
+		})
+	}
+
+	// Absolute paths into skipped directories are not probed either
+	if p.IsExecutable(filepath.Join(nfs, "terraform")) || p.IsExecutable(filepath.Join(smb, "git")) {
+		t.Errorf("expected paths in skipped directories not to be executable")
+	}
+	if !p.IsExecutable(git) {
+		t.Errorf("expected %s to be executable", git)
+	}
+}
+
+func TestIndexPathCancelled(t *testing.T) {
+	dir := t.TempDir()
+	writeTool(t, dir, "git")
+	lister := &delayedLister{delays: map[string]time.Duration{dir: time.Second}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	p := IndexPath(ctx, IndexOptions{Dirs: []string{dir}, ReadDir: lister.readDir})
+	if !reflect.DeepEqual(p.Slow, []string{dir}) {
+		t.Errorf("expected the unfinished listing to be skipped but got %v", p.Slow)
+	}
+}
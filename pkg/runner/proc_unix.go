@@ -0,0 +1,24 @@
+//go:build !windows
+
+package runner
+
+import (
+	"context"
+	"os/exec"
+	"syscall"
+)
+
+// configureProcess starts cmd in its own process group when ctx has a
+// deadline, so a timeout can kill the whole tree, not just the direct child.
+// Other commands, such as installs, stay in the foreground group: Ctrl-C then
+// reaches them too, and they can still read the terminal for a sudo prompt.
+func configureProcess(ctx context.Context, cmd *exec.Cmd) {
+	if _, ok := ctx.Deadline(); !ok {
+		return
+	}
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		// A negative pid signals every process in the group
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
@@ -0,0 +1,190 @@
+//go:build !windows
+
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// treeScript starts a shell that spawns nested and background children, each
+// recording its pid in the file named by $1, then waits on them forever
+const treeScript = `
+echo $$ >> "$1"
+sh -c 'echo $$ >> "$1"; sleep 300 & echo $! >> "$1"; sleep 300 & echo $! >> "$1"; wait' child "$1" &
+sleep 300 &
+echo $! >> "$1"
+wait
+`
+
+// treeSize is the number of pids treeScript records
+const treeSize = 5
+
+func TestExecCancelKillsProcessTree(t *testing.T) {
+	const trees = 20
+	dir := t.TempDir()
+
+	var wg sync.WaitGroup
+	pidFiles := make([]string, trees)
+	errs := make([]error, trees)
+	for i := range pidFiles {
+		pidFiles[i] = filepath.Join(dir, fmt.Sprintf("tree%d.pids", i))
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			// Only commands with a deadline get a process group to kill
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			defer cancel()
+			go func() {
+				waitForPids(pidFiles[i], treeSize, 10*time.Second)
+				cancel()
+			}()
+			_, errs[i] = Exec{}.CombinedOutput(ctx, "sh", "-c", treeScript, "tree", pidFiles[i])
+		}(i)
+	}
+
+	done := make(chan struct{})
+	go func() { wg.Wait(); close(done) }()
+	select {
+	case <-done:
+	case <-time.After(30 * time.Second):
+		t.Fatal("expected cancelled commands to return, but they are still running")
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for i, file := range pidFiles {
+		if errs[i] == nil {
+			t.Errorf("tree %d: expected an error from a cancelled command but got nil", i)
+		}
+		pids := waitForPids(file, treeSize, 0)
+		if len(pids) != treeSize {
+			t.Fatalf("tree %d: expected %d pids but got %v", i, treeSize, pids)
+		}
+		for _, pid := range pids {
+			if !waitExited(pid, time.Until(deadline)) {
+				t.Errorf("tree %d: expected process %d to be killed, but it survived cancellation", i, pid)
+				syscall.Kill(pid, syscall.SIGKILL)
+			}
+		}
+	}
+}
+
+func TestExecProcessGroup(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("reads the process group from /proc")
+	}
+	// The process group is the fifth field of /proc/<pid>/stat
+	const script = `set -- $(cat /proc/$$/stat); echo "$5"`
+
+	stdout, _, err := Exec{}.Output(context.Background(), "sh", "-c", script)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := strings.TrimSpace(stdout), strconv.Itoa(syscall.Getpgrp()); got != want {
+		t.Errorf("expected a command without a deadline to stay in process group %s but it ran in %s", want, got)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	stdout, _, err = Exec{}.Output(ctx, "sh", "-c", script)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(stdout); got == strconv.Itoa(syscall.Getpgrp()) {
+		t.Errorf("expected a command with a deadline to get its own process group but it ran in %s", got)
+	}
+}
+
+func TestExecInjectsEnvironment(t *testing.T) {
+	t.Setenv("CI", "")
+	os.Unsetenv("CI")
+	t.Setenv("LC_ALL", "fr_FR.UTF-8")
+
+	stdout, _, err := Exec{}.Output(context.Background(), "sh", "-c", `echo "$LC_ALL $DEBIAN_FRONTEND $CI"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(stdout); got != "C noninteractive 1" {
+		t.Errorf("expected injected environment %q but got %q", "C noninteractive 1", got)
+	}
+
+	t.Setenv("CI", "true")
+	stdout, _, err = Exec{}.Output(context.Background(), "sh", "-c", `echo "$CI"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(stdout); got != "true" {
+		t.Errorf("expected the user's CI value to be kept but got %q", got)
+	}
+}
+
+func TestExecSeparatesStreams(t *testing.T) {
+	stdout, stderr, err := Exec{}.Output(context.Background(), "sh", "-c", "echo out; echo err >&2; exit 3")
+	var exitErr interface{ ExitCode() int }
+	if !errors.As(err, &exitErr) || exitErr.ExitCode() != 3 {
+		t.Errorf("expected exit code 3 but got %v", err)
+	}
+	if stdout != "out\n" || stderr != "err\n" {
+		t.Errorf("expected stdout %q and stderr %q but got %q and %q", "out\n", "err\n", stdout, stderr)
+	}
+}
+
+// waitForPids reads pid lines from file until it holds n of them or timeout passes
+func waitForPids(file string, n int, timeout time.Duration) []int {
+	deadline := time.Now().Add(timeout)
+	for {
+		var pids []int
+		if data, err := os.ReadFile(file); err == nil {
+			for _, field := range strings.Fields(string(data)) {
+				if pid, err := strconv.Atoi(field); err == nil {
+					pids = append(pids, pid)
+				}
+			}
+		}
+		if len(pids) >= n || time.Now().After(deadline) {
+			return pids
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// waitExited reports whether pid exits within timeout. Zombies count as
+// exited since orphans may not be reaped promptly inside containers.
+func waitExited(pid int, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for {
+		if !alive(pid) {
+			return true
+		}
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func alive(pid int) bool {
+	if err := syscall.Kill(pid, 0); err != nil {
+		return false
+	}
+	if runtime.GOOS != "linux" {
+		return true
+	}
+	stat, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return false
+	}
+	// The state follows the parenthesized command name
+	fields := strings.Fields(string(stat[strings.LastIndexByte(string(stat), ')')+1:]))
+	return len(fields) == 0 || fields[0] != "Z"
+}
@@ -0,0 +1,35 @@
+//go:build windows
+
+package runner
+
+import (
+	"context"
+	"os/exec"
+	"strconv"
+	"syscall"
+)
+
+// createNoWindow keeps console programs from flashing a window when
+// StackMatch runs them in the background
+const createNoWindow = 0x08000000
+
+// configureProcess hides the console window and makes cancellation kill the
+// whole process tree with taskkill /T. Only commands with a deadline get a new
+// process group, which stops Ctrl-C from reaching them; installs keep
+// receiving it along with StackMatch.
+func configureProcess(ctx context.Context, cmd *exec.Cmd) {
+	flags := uint32(createNoWindow)
+	if _, ok := ctx.Deadline(); ok {
+		flags |= syscall.CREATE_NEW_PROCESS_GROUP
+	}
+	cmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true, CreationFlags: flags}
+	cmd.Cancel = func() error {
+		kill := exec.Command("taskkill", "/T", "/F", "/PID", strconv.Itoa(cmd.Process.Pid))
+		kill.SysProcAttr = &syscall.SysProcAttr{HideWindow: true, CreationFlags: createNoWindow}
+		if err := kill.Run(); err != nil {
+			// taskkill is missing or the tree already exited; kill the child at least
+			return cmd.Process.Kill()
+		}
+		return nil
+	}
+}
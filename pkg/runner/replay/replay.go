@@ -0,0 +1,302 @@
+// Package replay records the results of the commands a run executes and
+// replays them later, so an import can be simulated against the outputs of
+// another machine without running anything. The integration tests script
+// their commands in the same format.
+package replay
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/redact"
+	"github.com/MRQ67/stackmatch-cli/pkg/runner"
+)
+
+// File is the name of the recording in a fixtures directory
+const File = "mocks.json"
+
+// Recording holds the commands of a machine and their results
+type Recording struct {
+	// OS is the operating system the recording was made on, as in GOOS
+	OS string `json:"os,omitempty"`
+	// Path lists the commands that exist
+	Path []string `json:"path,omitempty"`
+	// Commands maps command lines (see Line) to their results
+	Commands map[string]Command `json:"commands,omitempty"`
+}
+
+// Command is the recorded result of a command
+type Command struct {
+	Stdout   string `json:"stdout,omitempty"`
+	Stderr   string `json:"stderr,omitempty"`
+	ExitCode int    `json:"exit_code,omitempty"`
+}
+
+// Line returns the key of a command in Recording.Commands: the base name of
+// the executable and the arguments, joined by spaces
+func Line(name string, args ...string) string {
+	return strings.Join(append([]string{filepath.Base(name)}, args...), " ")
+}
+
+// Load reads the recording in dir
+func Load(dir string) (*Recording, error) {
+	data, err := os.ReadFile(filepath.Join(dir, File))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read recorded commands: %w", err)
+	}
+	var recording Recording
+	if err := json.Unmarshal(data, &recording); err != nil {
+		return nil, fmt.Errorf("invalid recorded commands in %s: %w", dir, err)
+	}
+	return &recording, nil
+}
+
+// Save writes the recording to dir, creating it if needed
+func (rec *Recording) Save(dir string) error {
+	data, err := json.MarshalIndent(rec, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to save recorded commands: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, File), append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to save recorded commands: %w", err)
+	}
+	return nil
+}
+
+// WriteStubs creates an empty executable in dir for each of names, so that
+// PATH lookups find exactly the recorded commands when PATH is set to dir
+func WriteStubs(dir string, names []string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create stub PATH: %w", err)
+	}
+	for _, name := range names {
+		if runtime.GOOS == "windows" {
+			name += ".exe"
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o755); err != nil {
+			return fmt.Errorf("failed to create stub PATH: %w", err)
+		}
+	}
+	return nil
+}
+
+// ExitError is the error of a replayed command that failed
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
+// ExitCode returns the recorded exit code, like (*exec.ExitError).ExitCode
+func (e *ExitError) ExitCode() int {
+	return e.Code
+}
+
+// notRecordedCode is the exit code of commands missing from a recording,
+// the shell's code for a command that doesn't exist
+const notRecordedCode = 127
+
+// Runner is a runner.Runner that replays recorded commands. Commands that
+// weren't recorded fail with exit status 127.
+type Runner struct {
+	commands map[string]Command
+	log      string
+
+	mu      sync.Mutex
+	missing map[string]bool
+}
+
+// NewRunner returns a Runner replaying commands. Unless log is empty, every
+// command line run is appended to the file log.
+func NewRunner(commands map[string]Command, log string) *Runner {
+	return &Runner{commands: commands, log: log, missing: make(map[string]bool)}
+}
+
+// CombinedOutput implements runner.Runner
+func (r *Runner) CombinedOutput(ctx context.Context, name string, args ...string) (string, error) {
+	stdout, stderr, err := r.Output(ctx, name, args...)
+	return stdout + stderr, err
+}
+
+// Output implements runner.Runner
+func (r *Runner) Output(ctx context.Context, name string, args ...string) (string, string, error) {
+	line := Line(name, args...)
+	r.record(line)
+	if err := ctx.Err(); err != nil {
+		return "", "", err
+	}
+	command, ok := r.commands[line]
+	if !ok {
+		r.mu.Lock()
+		r.missing[line] = true
+		r.mu.Unlock()
+		return "", fmt.Sprintf("no recorded result for %q\n", line), &ExitError{Code: notRecordedCode}
+	}
+	if command.ExitCode != 0 {
+		return command.Stdout, command.Stderr, &ExitError{Code: command.ExitCode}
+	}
+	return command.Stdout, command.Stderr, nil
+}
+
+// Missing returns the command lines run that had no recorded result, in order
+func (r *Runner) Missing() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	lines := make([]string, 0, len(r.missing))
+	for line := range r.missing {
+		lines = append(lines, line)
+	}
+	sort.Strings(lines)
+	return lines
+}
+
+// record appends line to the log
+func (r *Runner) record(line string) {
+	if r.log == "" {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	file, err := os.OpenFile(r.log, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+	fmt.Fprintln(file, line)
+}
+
+// Recorder runs commands and looks up executables through another runner
+// and path index, and records what it sees. Secrets in the command lines and
+// outputs are redacted (see redact.String). When a command runs more than
+// once, its first result is kept, since that is the one a replay of the same
+// run needs first.
+type Recorder struct {
+	runner runner.Runner
+	path   runner.PathIndex
+
+	mu        sync.Mutex
+	recording Recording
+}
+
+// NewRecorder returns a Recorder adding to base, whose commands are kept
+// as they are. base may be nil.
+func NewRecorder(r runner.Runner, path runner.PathIndex, base *Recording) *Recorder {
+	recorder := &Recorder{runner: r, path: path, recording: Recording{Commands: make(map[string]Command)}}
+	if base != nil {
+		recorder.recording.Path = append(recorder.recording.Path, base.Path...)
+		for line, command := range base.Commands {
+			recorder.recording.Commands[line] = command
+		}
+	}
+	return recorder
+}
+
+// CombinedOutput implements runner.Runner. The combined output is recorded
+// as stdout.
+func (r *Recorder) CombinedOutput(ctx context.Context, name string, args ...string) (string, error) {
+	output, err := r.runner.CombinedOutput(ctx, name, args...)
+	r.recordCommand(ctx, name, args, output, "", err)
+	return output, err
+}
+
+// Output implements runner.Runner
+func (r *Recorder) Output(ctx context.Context, name string, args ...string) (string, string, error) {
+	stdout, stderr, err := r.runner.Output(ctx, name, args...)
+	r.recordCommand(ctx, name, args, stdout, stderr, err)
+	return stdout, stderr, err
+}
+
+// LookPath implements runner.PathIndex
+func (r *Recorder) LookPath(file string) (string, error) {
+	found, err := r.path.LookPath(file)
+	if err == nil {
+		r.recordPath(file)
+	}
+	return found, err
+}
+
+// LookPathAll implements runner.PathIndex
+func (r *Recorder) LookPathAll(file string) []string {
+	found := r.path.LookPathAll(file)
+	if len(found) > 0 {
+		r.recordPath(file)
+	}
+	return found
+}
+
+// IsExecutable implements runner.PathIndex
+func (r *Recorder) IsExecutable(path string) bool {
+	return r.path.IsExecutable(path)
+}
+
+// Recording returns what was recorded so far, with its OS set to this one
+func (r *Recorder) Recording() *Recording {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	recording := Recording{OS: runtime.GOOS, Path: append([]string(nil), r.recording.Path...), Commands: make(map[string]Command)}
+	for line, command := range r.recording.Commands {
+		recording.Commands[line] = command
+	}
+	sort.Strings(recording.Path)
+	return &recording
+}
+
+// recordCommand records the result of a command. Commands interrupted by
+// ctx are not recorded, and those that don't exist are recorded as failing
+// with exit status 127.
+func (r *Recorder) recordCommand(ctx context.Context, name string, args []string, stdout, stderr string, err error) {
+	if ctx.Err() != nil {
+		return
+	}
+	command := Command{Stdout: redact.String(stdout), Stderr: redact.String(stderr)}
+	var exitErr interface{ ExitCode() int }
+	switch {
+	case err == nil:
+	case errors.Is(err, exec.ErrNotFound):
+		command.ExitCode = notRecordedCode
+	case errors.As(err, &exitErr) && exitErr.ExitCode() > 0:
+		command.ExitCode = exitErr.ExitCode()
+	default:
+		command.ExitCode = 1
+	}
+
+	line := redact.String(Line(name, args...))
+	r.mu.Lock()
+	if _, ok := r.recording.Commands[line]; !ok {
+		r.recording.Commands[line] = command
+	}
+	r.mu.Unlock()
+	if command.ExitCode != notRecordedCode {
+		r.recordPath(name)
+	}
+}
+
+// recordPath adds the command file to the recorded PATH
+func (r *Recorder) recordPath(file string) {
+	name := filepath.Base(file)
+	if runtime.GOOS == "windows" {
+		name = strings.TrimSuffix(name, filepath.Ext(name))
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.recording.Path {
+		if existing == name {
+			return
+		}
+	}
+	r.recording.Path = append(r.recording.Path, name)
+}
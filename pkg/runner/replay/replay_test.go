@@ -0,0 +1,83 @@
+package replay
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"reflect"
+	"testing"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/runner/runnertest"
+)
+
+func TestRecorder(t *testing.T) {
+	r := &runnertest.Runner{Responses: map[string]runnertest.Response{
+		"/usr/bin/apt --version":      {Output: "apt 2.7.14 (amd64)\n"},
+		"dpkg --audit":                {Stderr: "broken\n", Err: &ExitError{Code: 2}},
+		"nope --version":              {Err: &exec.Error{Name: "nope", Err: exec.ErrNotFound}},
+		"curl --token abc123 example": {Output: "PGPASSWORD=secret\n"},
+	}}
+	path := runnertest.NewPath([]string{"/usr/bin"}, "/usr/bin/apt", "/usr/bin/dpkg")
+	base := &Recording{Path: []string{"git"}, Commands: map[string]Command{"git --version": {Stdout: "git version 2.43.0\n"}}}
+	recorder := NewRecorder(r, path, base)
+
+	ctx := context.Background()
+	recorder.Output(ctx, "/usr/bin/apt", "--version")
+	recorder.Output(ctx, "dpkg", "--audit")
+	recorder.Output(ctx, "nope", "--version")
+	recorder.CombinedOutput(ctx, "curl", "--token", "abc123", "example")
+	recorder.LookPath("dpkg")
+	// Only the first result of a command is kept
+	r.Responses["/usr/bin/apt --version"] = runnertest.Response{Output: "apt 2.8.0 (amd64)\n"}
+	recorder.Output(ctx, "/usr/bin/apt", "--version")
+
+	recording := recorder.Recording()
+	expectedCommands := map[string]Command{
+		"git --version":                   {Stdout: "git version 2.43.0\n"},
+		"apt --version":                   {Stdout: "apt 2.7.14 (amd64)\n"},
+		"dpkg --audit":                    {Stderr: "broken\n", ExitCode: 2},
+		"nope --version":                  {ExitCode: 127},
+		"curl --token [REDACTED] example": {Stdout: "PGPASSWORD=[REDACTED]\n"},
+	}
+	if !reflect.DeepEqual(recording.Commands, expectedCommands) {
+		t.Errorf("expected commands %v but got %v", expectedCommands, recording.Commands)
+	}
+	expectedPath := []string{"apt", "curl", "dpkg", "git"}
+	if !reflect.DeepEqual(recording.Path, expectedPath) {
+		t.Errorf("expected path %v but got %v", expectedPath, recording.Path)
+	}
+}
+
+func TestRunner(t *testing.T) {
+	dir := t.TempDir()
+	recording := &Recording{OS: "linux", Commands: map[string]Command{
+		"apt --version": {Stdout: "apt 2.7.14 (amd64)\n"},
+		"dpkg --audit":  {Stderr: "broken\n", ExitCode: 2},
+	}}
+	if err := recording.Save(dir); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(loaded, recording) {
+		t.Fatalf("expected %v to be loaded but got %v", recording, loaded)
+	}
+
+	r := NewRunner(loaded.Commands, "")
+	ctx := context.Background()
+	if stdout, _, err := r.Output(ctx, "/usr/bin/apt", "--version"); err != nil || stdout != "apt 2.7.14 (amd64)\n" {
+		t.Errorf("expected the recorded output but got %q, %v", stdout, err)
+	}
+	var exitErr *ExitError
+	if _, stderr, err := r.Output(ctx, "dpkg", "--audit"); !errors.As(err, &exitErr) || exitErr.Code != 2 || stderr != "broken\n" {
+		t.Errorf("expected exit status 2 but got %v with %q", err, stderr)
+	}
+	if _, err := r.CombinedOutput(ctx, "apt", "install", "--assume-yes", "jq"); !errors.As(err, &exitErr) || exitErr.Code != 127 {
+		t.Errorf("expected exit status 127 for a command not recorded but got %v", err)
+	}
+	if missing := r.Missing(); !reflect.DeepEqual(missing, []string{"apt install --assume-yes jq"}) {
+		t.Errorf("expected the command not recorded to be listed but got %v", missing)
+	}
+}
@@ -0,0 +1,177 @@
+// Package runner abstracts spawning external commands and looking up
+// executables on PATH so detection and installation code can be tested
+// without the real tools installed.
+package runner
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Runner runs external commands
+type Runner interface {
+	// CombinedOutput runs name with args and returns its combined stdout and
+	// stderr. The output is returned even when the command fails.
+	CombinedOutput(ctx context.Context, name string, args ...string) (string, error)
+	// Output runs name with args and returns stdout and stderr separately.
+	// Both are returned even when the command fails.
+	Output(ctx context.Context, name string, args ...string) (stdout, stderr string, err error)
+}
+
+// PathIndex resolves executables and probes the filesystem
+type PathIndex interface {
+	// LookPath returns the first executable named file on PATH, like exec.LookPath
+	LookPath(file string) (string, error)
+	// LookPathAll returns every executable named file on PATH, in PATH order
+	LookPathAll(file string) []string
+	// IsExecutable reports whether path is an existing executable file
+	IsExecutable(path string) bool
+}
+
+// Exec runs commands with os/exec. Every command gets the same setup: the
+// executable is resolved with DefaultPath, the environment is made
+// non-interactive and locale-neutral (see Environment), no console window is
+// shown on Windows, and cancelling ctx kills the command together with every
+// process it started.
+type Exec struct{}
+
+// CombinedOutput implements Runner
+func (Exec) CombinedOutput(ctx context.Context, name string, args ...string) (string, error) {
+	cmd := command(ctx, name, args...)
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	err := cmd.Run()
+	return output.String(), err
+}
+
+// Output implements Runner
+func (Exec) Output(ctx context.Context, name string, args ...string) (string, string, error) {
+	cmd := command(ctx, name, args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	return stdout.String(), stderr.String(), err
+}
+
+// waitDelay bounds how long Wait blocks on output pipes after the command
+// exits or is killed, in case a detached grandchild still holds them open
+const waitDelay = 2 * time.Second
+
+// command builds an exec.Cmd with the setup shared by every spawned process
+func command(ctx context.Context, name string, args ...string) *exec.Cmd {
+	if !strings.ContainsAny(name, `/\`) {
+		// Resolve the same way detection does, including PATHEXT on Windows
+		// and skipping PATH entries a scan found too slow to list
+		if found := DefaultPath.LookPathAll(name); len(found) > 0 {
+			name = found[0]
+		}
+	}
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Env = Environment(os.Environ())
+	cmd.WaitDelay = waitDelay
+	configureProcess(ctx, cmd)
+	return cmd
+}
+
+// injectedEnv is added to the environment of every spawned command. Output is
+// parsed, so it must not be localized, and nothing can answer a prompt.
+var injectedEnv = []string{
+	"LC_ALL=C",
+	"DEBIAN_FRONTEND=noninteractive",
+}
+
+// Environment returns base with the variables every spawned command needs.
+// CI=1 is added unless the user already set CI, so tools skip prompts,
+// spinners and colors.
+func Environment(base []string) []string {
+	env := append([]string{}, base...)
+	env = append(env, injectedEnv...)
+	hasCI := false
+	for _, kv := range base {
+		if strings.HasPrefix(kv, "CI=") {
+			hasCI = true
+			break
+		}
+	}
+	if !hasCI {
+		env = append(env, "CI=1")
+	}
+	return env
+}
+
+// SystemPath resolves executables against the PATH environment variable
+type SystemPath struct{}
+
+// LookPath implements PathIndex
+func (SystemPath) LookPath(file string) (string, error) {
+	return exec.LookPath(file)
+}
+
+// LookPathAll implements PathIndex
+func (p SystemPath) LookPathAll(file string) []string {
+	var found []string
+	seen := make(map[string]bool)
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		if dir == "" {
+			continue
+		}
+		for _, candidate := range executableNames(file) {
+			path := filepath.Join(dir, candidate)
+			if !seen[path] && p.IsExecutable(path) {
+				seen[path] = true
+				found = append(found, path)
+				break
+			}
+		}
+	}
+	return found
+}
+
+// IsExecutable implements PathIndex
+func (SystemPath) IsExecutable(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() {
+		return false
+	}
+	if runtime.GOOS == "windows" {
+		return true
+	}
+	return info.Mode()&0111 != 0
+}
+
+// executableNames returns the file names file may have on disk. On Windows
+// names without an extension are tried with every PATHEXT extension.
+func executableNames(file string) []string {
+	if runtime.GOOS != "windows" || filepath.Ext(file) != "" {
+		return []string{file}
+	}
+	exts := os.Getenv("PATHEXT")
+	if exts == "" {
+		exts = ".com;.exe;.bat;.cmd"
+	}
+	var names []string
+	for _, ext := range strings.Split(exts, ";") {
+		if ext != "" {
+			names = append(names, file+strings.ToLower(ext))
+		}
+	}
+	return names
+}
+
+// Default is the runner used when none is configured
+var Default Runner = Exec{}
+
+// DefaultPath is the PATH index used when none is configured
+var DefaultPath PathIndex = SystemPath{}
+
+// ErrNotFound is returned by fake path indexes when an executable is missing
+var ErrNotFound = errors.New("executable file not found in $PATH")
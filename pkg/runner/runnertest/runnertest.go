@@ -0,0 +1,119 @@
+// Package runnertest provides fake runners and PATH indexes for tests.
+package runnertest
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/runner"
+)
+
+// Response is the canned result of a command
+type Response struct {
+	// Output is written to stdout
+	Output string
+	// Stderr is written to stderr. CombinedOutput appends it to Output.
+	Stderr string
+	Err    error
+}
+
+// Runner is a runner.Runner that returns canned responses keyed by the full
+// command line (name and arguments joined by spaces) and records every call
+type Runner struct {
+	Responses map[string]Response
+
+	mu    sync.Mutex
+	calls []string
+}
+
+// CombinedOutput implements runner.Runner. Commands without a canned
+// response fail.
+func (r *Runner) CombinedOutput(ctx context.Context, name string, args ...string) (string, error) {
+	resp, err := r.respond(ctx, name, args)
+	if err != nil {
+		return "", err
+	}
+	return resp.Output + resp.Stderr, resp.Err
+}
+
+// Output implements runner.Runner
+func (r *Runner) Output(ctx context.Context, name string, args ...string) (string, string, error) {
+	resp, err := r.respond(ctx, name, args)
+	if err != nil {
+		return "", "", err
+	}
+	return resp.Output, resp.Stderr, resp.Err
+}
+
+// respond records the call and looks up its canned response
+func (r *Runner) respond(ctx context.Context, name string, args []string) (Response, error) {
+	line := strings.Join(append([]string{name}, args...), " ")
+
+	r.mu.Lock()
+	r.calls = append(r.calls, line)
+	r.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return Response{}, err
+	}
+	resp, ok := r.Responses[line]
+	if !ok {
+		return Response{}, fmt.Errorf("runnertest: unexpected command %q", line)
+	}
+	return resp, nil
+}
+
+// Calls returns the command lines run so far
+func (r *Runner) Calls() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.calls...)
+}
+
+// Path is a runner.PathIndex over a fixed PATH and set of executables
+type Path struct {
+	// Dirs is the simulated PATH, in order
+	Dirs []string
+	// Executables holds the full paths of every executable that exists,
+	// whether or not its directory is on PATH
+	Executables map[string]bool
+}
+
+// NewPath returns a Path with dirs on PATH and the given executables present
+func NewPath(dirs []string, executables ...string) *Path {
+	p := &Path{Dirs: dirs, Executables: make(map[string]bool)}
+	for _, exe := range executables {
+		p.Executables[exe] = true
+	}
+	return p
+}
+
+// LookPath implements runner.PathIndex
+func (p *Path) LookPath(file string) (string, error) {
+	if all := p.LookPathAll(file); len(all) > 0 {
+		return all[0], nil
+	}
+	if strings.ContainsRune(file, '/') && p.Executables[file] {
+		return file, nil
+	}
+	return "", fmt.Errorf("%s: %w", file, runner.ErrNotFound)
+}
+
+// LookPathAll implements runner.PathIndex
+func (p *Path) LookPathAll(file string) []string {
+	var found []string
+	for _, dir := range p.Dirs {
+		if path := filepath.Join(dir, file); p.Executables[path] {
+			found = append(found, path)
+		}
+	}
+	return found
+}
+
+// IsExecutable implements runner.PathIndex
+func (p *Path) IsExecutable(path string) bool {
+	return p.Executables[path]
+}
@@ -0,0 +1,317 @@
+package scanner
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/runner"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+// guiApp is an editor or tool detected from its install record rather than
+// a command on PATH: its application bundle on macOS and its uninstall
+// entry in the registry on Windows
+type guiApp struct {
+	// Name is the display name, shared with editorExecutables for apps that
+	// also have a command
+	Name string
+	ID   string
+	// BundleIDs are the CFBundleIdentifier values of the macOS app
+	BundleIDs []string
+	// DisplayNames are prefixes of the DisplayName of its Windows uninstall
+	// entry
+	DisplayNames []string
+}
+
+// guiApps are the apps looked for in application bundles and the registry.
+// The first five have a command only looked for on other OSes (see
+// appCommands). The last four have a command that is detected first; their install
+// record is only read when the command is not on PATH, which on macOS is
+// until it is installed from the app.
+var guiApps = []guiApp{
+	{Name: "DBeaver", ID: "dbeaver", BundleIDs: []string{"org.jkiss.dbeaver.core.product"}, DisplayNames: []string{"DBeaver"}},
+	{Name: "TablePlus", ID: "tableplus", BundleIDs: []string{"com.tinyapp.TablePlus"}, DisplayNames: []string{"TablePlus"}},
+	{Name: "GitHub Desktop", ID: "github-desktop", BundleIDs: []string{"com.github.GitHubClient"}, DisplayNames: []string{"GitHub Desktop"}},
+	{Name: "GitKraken", ID: "gitkraken", BundleIDs: []string{"com.axosoft.gitkraken"}, DisplayNames: []string{"GitKraken"}},
+	{Name: "Sourcetree", ID: "sourcetree", BundleIDs: []string{"com.torusknot.SourceTreeNotMAS"}, DisplayNames: []string{"Sourcetree"}},
+
+	{Name: "VS Code", ID: "vscode", BundleIDs: []string{"com.microsoft.VSCode"}, DisplayNames: []string{"Microsoft Visual Studio Code"}},
+	{Name: "Sublime Text", ID: "sublime-text", BundleIDs: []string{"com.sublimetext.4", "com.sublimetext.3"}, DisplayNames: []string{"Sublime Text"}},
+	{Name: "Cursor", ID: "cursor", BundleIDs: []string{"com.todesktop.230313mzl4w4u92"}, DisplayNames: []string{"Cursor"}},
+	{Name: "Windsurf", ID: "windsurf", BundleIDs: []string{"com.exafunction.windsurf"}, DisplayNames: []string{"Windsurf"}},
+}
+
+// uninstallKeys are the registry keys listing installed programs: machine
+// wide, 32-bit programs on 64-bit Windows, and per user
+var uninstallKeys = []string{
+	`HKLM\SOFTWARE\Microsoft\Windows\CurrentVersion\Uninstall`,
+	`HKLM\SOFTWARE\WOW6432Node\Microsoft\Windows\CurrentVersion\Uninstall`,
+	`HKCU\SOFTWARE\Microsoft\Windows\CurrentVersion\Uninstall`,
+}
+
+// installedApp is an install record of a guiApp
+type installedApp struct {
+	Version string
+	// Source is where it was found: "app-bundle:<bundle>", named without
+	// its directory so home paths stay out of shared files, or
+	// "registry:<key>"
+	Source string
+}
+
+// DetectApps records the apps of guiApps found installed under CodeEditors,
+// from the CFBundleShortVersionString of their bundle in /Applications or
+// ~/Applications on macOS and the DisplayVersion of their uninstall entry
+// on Windows. Where they were found is recorded in ToolSources. Apps whose
+// command was already found are left as they are.
+func DetectApps(ctx context.Context, envData *types.EnvironmentData) {
+	home, _ := os.UserHomeDir()
+	dirs := []string{"/Applications", filepath.Join(home, "Applications")}
+	detectApps(ctx, envData, runner.Default, runtime.GOOS, dirs)
+}
+
+// readsInstallRecords reports whether DetectApps finds apps from their
+// install records on goos
+func readsInstallRecords(goos string) bool {
+	return goos == "darwin" || goos == "windows"
+}
+
+func detectApps(ctx context.Context, envData *types.EnvironmentData, r runner.Runner, goos string, appDirs []string) {
+	var found map[string]installedApp
+	switch goos {
+	case "darwin":
+		found = findAppBundles(ctx, envData, r, appDirs)
+	case "windows":
+		found = findUninstallEntries(ctx, envData, r)
+	default:
+		return
+	}
+
+	for _, app := range guiApps {
+		installed, ok := found[app.Name]
+		if !ok {
+			continue
+		}
+		if _, detected := envData.CodeEditors[app.Name]; detected {
+			continue
+		}
+		log.Printf("Found %s version %s (%s)", app.Name, installed.Version, installed.Source)
+		if envData.CodeEditors == nil {
+			envData.CodeEditors = make(map[string]string)
+		}
+		envData.CodeEditors[app.Name] = installed.Version
+		if envData.ToolIDs == nil {
+			envData.ToolIDs = make(map[string]string)
+		}
+		envData.ToolIDs[app.Name] = app.ID
+		if envData.ToolSources == nil {
+			envData.ToolSources = make(map[string]string)
+		}
+		envData.ToolSources[app.Name] = installed.Source
+	}
+}
+
+// findAppBundles reads the Info.plist of every bundle in appDirs and
+// returns the guiApps among them, keyed by name. The first directory
+// listing an app wins.
+func findAppBundles(ctx context.Context, envData *types.EnvironmentData, r runner.Runner, appDirs []string) map[string]installedApp {
+	byBundleID := make(map[string]string)
+	for _, app := range guiApps {
+		for _, id := range app.BundleIDs {
+			byBundleID[id] = app.Name
+		}
+	}
+
+	found := make(map[string]installedApp)
+	for _, dir := range appDirs {
+		bundles, _ := filepath.Glob(filepath.Join(dir, "*.app"))
+		for _, bundle := range bundles {
+			if ctx.Err() != nil {
+				return found
+			}
+			info, err := readInfoPlist(ctx, r, filepath.Join(bundle, "Contents", "Info.plist"))
+			if err != nil {
+				if !errors.Is(err, os.ErrNotExist) {
+					envData.Warnings = append(envData.Warnings, fmt.Sprintf("could not read the Info.plist of %s: %v", bundle, err))
+				}
+				continue
+			}
+			name, ok := byBundleID[info["CFBundleIdentifier"]]
+			if _, seen := found[name]; !ok || seen {
+				continue
+			}
+			found[name] = installedApp{Version: bundleVersion(info), Source: "app-bundle:" + filepath.Base(bundle)}
+		}
+	}
+	return found
+}
+
+// bundleVersion returns the version an app shows, falling back to its build
+// number and then "Installed"
+func bundleVersion(info map[string]string) string {
+	for _, key := range []string{"CFBundleShortVersionString", "CFBundleVersion"} {
+		if v := strings.TrimSpace(info[key]); v != "" {
+			return v
+		}
+	}
+	return "Installed"
+}
+
+// readInfoPlist returns the string values at the top of an Info.plist.
+// Binary property lists are converted to XML with plutil first.
+func readInfoPlist(ctx context.Context, r runner.Runner, file string) (map[string]string, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.HasPrefix(data, []byte("bplist")) {
+		stdout, stderr, err := r.Output(ctx, "plutil", "-convert", "xml1", "-o", "-", file)
+		if err != nil {
+			if message := firstLine(stderr); message != "" {
+				return nil, errors.New(message)
+			}
+			return nil, err
+		}
+		data = []byte(stdout)
+	}
+	return parsePlist(data)
+}
+
+// parsePlist returns the string values of the top-level dictionary of an
+// XML property list, keyed by their key. Nested dictionaries and arrays are
+// skipped.
+func parsePlist(data []byte) (map[string]string, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	values := make(map[string]string)
+	depth := 0
+	key := ""
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid property list: %w", err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			depth++
+			// plist > dict > key, string
+			if depth != 3 {
+				continue
+			}
+			var text string
+			if err := dec.DecodeElement(&text, &t); err != nil {
+				return nil, fmt.Errorf("invalid property list: %w", err)
+			}
+			depth--
+			switch t.Name.Local {
+			case "key":
+				key = text
+			case "string":
+				if key != "" {
+					values[key] = text
+				}
+				key = ""
+			default:
+				key = ""
+			}
+		case xml.EndElement:
+			depth--
+		}
+	}
+	if len(values) == 0 {
+		return nil, errors.New("invalid property list: no values found")
+	}
+	return values, nil
+}
+
+// findUninstallEntries lists the uninstall entries of every uninstallKeys
+// and returns the guiApps among them, keyed by name. The first key listing
+// an app wins.
+func findUninstallEntries(ctx context.Context, envData *types.EnvironmentData, r runner.Runner) map[string]installedApp {
+	found := make(map[string]installedApp)
+	var failures []string
+	for _, root := range uninstallKeys {
+		stdout, stderr, err := r.Output(ctx, "reg", "query", root, "/s")
+		if err != nil {
+			// 32-bit Windows has no WOW6432Node key, so one failure is not
+			// worth a warning
+			failures = append(failures, cmp.Or(firstLine(stderr), err.Error()))
+			continue
+		}
+		for _, entry := range parseUninstallEntries(stdout) {
+			app, ok := matchUninstallEntry(entry.DisplayName)
+			if _, seen := found[app]; !ok || seen {
+				continue
+			}
+			version := entry.DisplayVersion
+			if version == "" {
+				version = "Installed"
+			}
+			found[app] = installedApp{Version: version, Source: "registry:" + entry.Key}
+		}
+	}
+	if len(failures) == len(uninstallKeys) {
+		envData.Warnings = append(envData.Warnings, "could not list the installed programs in the registry: "+failures[0])
+	}
+	return found
+}
+
+// uninstallEntry is a program listed under an uninstall key
+type uninstallEntry struct {
+	Key            string
+	DisplayName    string
+	DisplayVersion string
+}
+
+// parseUninstallEntries returns the entries in the output of 'reg query
+// <key> /s': a line with each subkey's path, followed by its values
+// indented, one "name type data" per line
+func parseUninstallEntries(output string) []uninstallEntry {
+	var entries []uninstallEntry
+	var current *uninstallEntry
+	for _, line := range strings.Split(strings.ReplaceAll(output, "\r\n", "\n"), "\n") {
+		if strings.HasPrefix(line, "HKEY_") {
+			entries = append(entries, uninstallEntry{Key: strings.TrimSpace(line)})
+			current = &entries[len(entries)-1]
+			continue
+		}
+		fields := strings.Fields(line)
+		if current == nil || len(fields) < 3 || fields[1] != "REG_SZ" {
+			continue
+		}
+		switch fields[0] {
+		case "DisplayName":
+			current.DisplayName = strings.Join(fields[2:], " ")
+		case "DisplayVersion":
+			current.DisplayVersion = strings.Join(fields[2:], " ")
+		}
+	}
+	// Keys without a DisplayName, such as the uninstall key itself, are not
+	// programs
+	return slices.DeleteFunc(entries, func(e uninstallEntry) bool { return e.DisplayName == "" })
+}
+
+// matchUninstallEntry returns the guiApp a DisplayName belongs to, such as
+// GitHub Desktop for "GitHub Desktop" or DBeaver for "DBeaver 24.0.0"
+func matchUninstallEntry(displayName string) (string, bool) {
+	for _, app := range guiApps {
+		for _, prefix := range app.DisplayNames {
+			if displayName == prefix || strings.HasPrefix(displayName, prefix+" ") {
+				return app.Name, true
+			}
+		}
+	}
+	return "", false
+}
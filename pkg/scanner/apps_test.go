@@ -0,0 +1,154 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/runner/runnertest"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+// installBundle copies a fixture Info.plist into dir/name.app
+func installBundle(t *testing.T, dir, name string, plist []byte) string {
+	t.Helper()
+	bundle := filepath.Join(dir, name+".app")
+	contents := filepath.Join(bundle, "Contents")
+	if err := os.MkdirAll(contents, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(contents, "Info.plist"), plist, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return bundle
+}
+
+func TestDetectAppsMacOS(t *testing.T) {
+	system := t.TempDir()
+	user := t.TempDir()
+	installBundle(t, system, "GitKraken", []byte(readFixture(t, "apps", "GitKraken-Info.plist")))
+	installBundle(t, system, "Visual Studio Code", []byte(readFixture(t, "apps", "VSCode-Info.plist")))
+	// A copy in ~/Applications doesn't replace the one in /Applications
+	installBundle(t, user, "GitKraken", []byte(`<plist><dict><key>CFBundleIdentifier</key><string>com.axosoft.gitkraken</string><key>CFBundleShortVersionString</key><string>9.0.0</string></dict></plist>`))
+	// Binary property lists are read through plutil
+	sourcetree := installBundle(t, user, "Sourcetree", []byte("bplist00\x00\x01"))
+	installBundle(t, user, "Notes", []byte(`<plist><dict><key>CFBundleIdentifier</key><string>com.apple.Notes</string></dict></plist>`))
+	broken := installBundle(t, user, "Broken", []byte("bplist00"))
+
+	plutil := func(file string) string { return "plutil -convert xml1 -o - " + file }
+	r := &runnertest.Runner{Responses: map[string]runnertest.Response{
+		plutil(filepath.Join(sourcetree, "Contents", "Info.plist")): {Output: readFixture(t, "apps", "Sourcetree-Info.plist")},
+		plutil(filepath.Join(broken, "Contents", "Info.plist")):     {Stderr: "Broken.app/Contents/Info.plist: Property List error\n", Err: errors.New("exit status 1")},
+	}}
+	env := types.EnvironmentData{
+		// code is on PATH, so the scan already found VS Code
+		CodeEditors: map[string]string{"VS Code": "1.86.0"},
+		ToolIDs:     map[string]string{"VS Code": "vscode"},
+	}
+	detectApps(context.Background(), &env, r, "darwin", []string{system, user})
+
+	expectedEditors := map[string]string{"VS Code": "1.86.0", "GitKraken": "10.0.2", "Sourcetree": "4.2.6"}
+	if !reflect.DeepEqual(env.CodeEditors, expectedEditors) {
+		t.Errorf("expected editors %v but got %v", expectedEditors, env.CodeEditors)
+	}
+	expectedSources := map[string]string{"GitKraken": "app-bundle:GitKraken.app", "Sourcetree": "app-bundle:Sourcetree.app"}
+	if !reflect.DeepEqual(env.ToolSources, expectedSources) {
+		t.Errorf("expected sources %v but got %v", expectedSources, env.ToolSources)
+	}
+	if env.ToolIDs["GitKraken"] != "gitkraken" || env.ToolIDs["Sourcetree"] != "sourcetree" {
+		t.Errorf("expected the apps' tool IDs to be recorded but got %v", env.ToolIDs)
+	}
+	if len(env.Warnings) != 1 {
+		t.Errorf("expected a warning for the unreadable Info.plist but got %q", env.Warnings)
+	}
+}
+
+func TestDetectAppsWindows(t *testing.T) {
+	query := func(key string) string { return "reg query " + key + " /s" }
+	r := &runnertest.Runner{Responses: map[string]runnertest.Response{
+		query(uninstallKeys[0]): {Output: readFixture(t, "apps", "reg-uninstall-machine.txt")},
+		query(uninstallKeys[1]): {Stderr: "ERROR: The system was unable to find the specified registry key or value.\r\n", Err: errors.New("exit status 1")},
+		query(uninstallKeys[2]): {Output: readFixture(t, "apps", "reg-uninstall-user.txt")},
+	}}
+	var env types.EnvironmentData
+	detectApps(context.Background(), &env, r, "windows", nil)
+
+	expected := map[string]string{
+		"DBeaver":        "24.0.0",
+		"VS Code":        "1.85.1",
+		"GitHub Desktop": "3.3.8",
+		"TablePlus":      "Installed",
+	}
+	if !reflect.DeepEqual(env.CodeEditors, expected) {
+		t.Errorf("expected editors %v but got %v", expected, env.CodeEditors)
+	}
+	if source := env.ToolSources["GitHub Desktop"]; source != `registry:HKEY_CURRENT_USER\SOFTWARE\Microsoft\Windows\CurrentVersion\Uninstall\GitHubDesktop` {
+		t.Errorf("expected GitHub Desktop's uninstall key as its source but got %q", source)
+	}
+	if len(env.Warnings) != 0 {
+		t.Errorf("expected no warnings when only one key is missing but got %q", env.Warnings)
+	}
+}
+
+func TestDetectAppsOtherPlatforms(t *testing.T) {
+	var env types.EnvironmentData
+	r := &runnertest.Runner{}
+	detectApps(context.Background(), &env, r, "linux", []string{t.TempDir()})
+	if env.CodeEditors != nil || len(r.Calls()) != 0 {
+		t.Errorf("expected nothing to be looked up but got %v after %v", env.CodeEditors, r.Calls())
+	}
+}
+
+func TestParsePlist(t *testing.T) {
+	values, err := parsePlist([]byte(readFixture(t, "apps", "GitKraken-Info.plist")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{
+		"CFBundleDisplayName":        "GitKraken",
+		"CFBundleExecutable":         "GitKraken",
+		"CFBundleIdentifier":         "com.axosoft.gitkraken",
+		"CFBundleShortVersionString": "10.0.2",
+		"CFBundleVersion":            "10.0.2",
+		"LSMinimumSystemVersion":     "10.15",
+	}
+	if !reflect.DeepEqual(values, expected) {
+		t.Errorf("expected %v but got %v", expected, values)
+	}
+
+	if _, err := parsePlist([]byte("<plist><dict><key>a</key>")); err == nil {
+		t.Error("expected a truncated property list to be rejected but got no error")
+	}
+}
+
+// TestGuiAppsShareEditorIDs checks that apps also detected by their command
+// are recorded under the same tool ID either way
+func TestGuiAppsShareEditorIDs(t *testing.T) {
+	for _, app := range guiApps {
+		for _, exe := range append(slices.Clip(editorExecutables), appCommands...) {
+			if exe.Name == app.Name && exe.Info().ID != app.ID {
+				t.Errorf("expected %s to have the ID %s of its command but got %s", app.Name, exe.Info().ID, app.ID)
+			}
+		}
+	}
+}
+
+func TestEditorCommandsWithoutInstallRecords(t *testing.T) {
+	for _, goos := range []string{"darwin", "linux", "windows", "freebsd"} {
+		var names []string
+		for _, exe := range editorCommands(goos) {
+			names = append(names, exe.Name)
+		}
+		probed := slices.Contains(names, "DBeaver") && slices.Contains(names, "GitKraken")
+		if expected := !readsInstallRecords(goos); probed != expected {
+			t.Errorf("expected the commands of database tools and git GUIs to be looked for on %s: %v, but got %v", goos, expected, probed)
+		}
+		if !slices.Contains(names, "VS Code") {
+			t.Errorf("expected VS Code to be looked for by command on %s", goos)
+		}
+	}
+}
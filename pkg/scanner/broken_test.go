@@ -0,0 +1,102 @@
+package scanner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/runner"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+// writeStub writes an executable shell script called name to dir
+func writeStub(t *testing.T, dir, name, script string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"+script+"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDetectBrokenTools(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("stub tools are shell scripts")
+	}
+	dir := t.TempDir()
+	writeStub(t, dir, "node", `echo "node: error while loading shared libraries: libicui18n.so.72: cannot open shared object file" >&2; exit 127`)
+	writeStub(t, dir, "python", `exit 1`)
+	writeStub(t, dir, "git", `echo "git version 2.45.0"`)
+	writeStub(t, dir, "java", `echo 'openjdk version "21.0.3" 2024-04-16' >&2`)
+	// screen -v exits with status 1 after printing its version
+	writeStub(t, dir, "screen", `echo "Screen version 4.09.01 (GNU) 20-Aug-23"; exit 1`)
+	t.Setenv("PATH", dir)
+
+	exes := []Executable{
+		{Name: "Node.js", Command: "node", VersionArg: "--version", VersionRegex: regexp.MustCompile(`v?([\d\.]+)`)},
+		{Name: "Python", Command: "python", VersionArg: "--version", VersionRegex: regexp.MustCompile(`Python ([\d\.]+)`)},
+		{Name: "Git", Command: "git", VersionArg: "--version", VersionRegex: regexp.MustCompile(`git version ([\d\.]+)`)},
+		{Name: "Java", Command: "java", VersionArg: "-version", VersionRegex: regexp.MustCompile(`version "([\d\._]+)"`)},
+		{Name: "GNU Screen", Command: "screen", VersionArg: "-v", VersionRegex: regexp.MustCompile(`Screen version ([\d\.]+)`), ExitsNonZero: true},
+	}
+	env := &types.EnvironmentData{}
+	found := make(map[string]string)
+	detectExecutablesWith(context.Background(), runner.Default, runner.DefaultPath, nil, nil, DefaultConcurrency, env, exes, found)
+
+	expectedVersions := map[string]string{"Node.js": "Installed", "Python": "Installed", "Git": "2.45.0", "Java": "21.0.3", "GNU Screen": "4.09.01"}
+	for name, version := range expectedVersions {
+		if found[name] != version {
+			t.Errorf("expected %s to be recorded as %q but got %q", name, version, found[name])
+		}
+	}
+
+	expectedBroken := map[string]types.ToolFailure{
+		"Node.js": {ExitStatus: 127, Output: "node: error while loading shared libraries: libicui18n.so.72: cannot open shared object file"},
+		"Python":  {ExitStatus: 1},
+	}
+	if len(env.BrokenTools) != len(expectedBroken) {
+		t.Errorf("expected broken tools %v but got %v", expectedBroken, env.BrokenTools)
+	}
+	for name, failure := range expectedBroken {
+		if got := env.BrokenTools[name]; got != failure {
+			t.Errorf("expected %s to fail with %+v but got %+v", name, failure, got)
+		}
+		if info := env.Tool(name); !info.Broken || info.ExitStatus != failure.ExitStatus {
+			t.Errorf("expected %s to be reported broken by Tool but got %+v", name, info)
+		}
+	}
+
+	if len(env.Warnings) != 2 || !strings.Contains(env.Warnings[0], "Node.js is installed but broken: 'node --version' exited with status 127") {
+		t.Errorf("expected a warning per broken tool but got %q", env.Warnings)
+	}
+}
+
+func TestDetectToolsStopsWhenCancelled(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("stub tools are shell scripts")
+	}
+	dir := t.TempDir()
+	writeStub(t, dir, "git", `/bin/sleep 10; echo "git version 2.45.0"`)
+	t.Setenv("PATH", dir)
+	tools, ok := LookupDetector("tools")
+	if !ok {
+		t.Fatal("expected the tools detector to be registered")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	env := &types.EnvironmentData{Tools: make(map[string]string)}
+	start := time.Now()
+	if err := tools.Detect(ctx, env); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("expected the scan's context to stop git --version, but it took %s", elapsed)
+	}
+	if env.Tools["Git"] == "2.45.0" || len(env.BrokenTools) != 0 {
+		t.Errorf("expected git to be left without a version or failure, got %q and %v", env.Tools["Git"], env.BrokenTools)
+	}
+}
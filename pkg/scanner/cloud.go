@@ -0,0 +1,164 @@
+package scanner
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/runner"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+// cloudTimeout bounds each command listing the profiles of a cloud CLI,
+// since gcloud and az take a while to start
+const cloudTimeout = 10 * time.Second
+
+// accountIDPattern matches AWS account IDs and the GUIDs of Azure
+// subscriptions and tenants. Profile names holding one are left out, so
+// that no account ID is recorded even when a profile is named after it.
+var accountIDPattern = regexp.MustCompile(`(?i)(^|[^0-9])[0-9]{12}($|[^0-9])|[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}`)
+
+// DetectCloudProfiles records the names of the profiles of the cloud CLIs:
+// the sections of ~/.aws/config (or $AWS_CONFIG_FILE), the gcloud
+// configurations and the Azure subscriptions az is logged in to. Only the
+// names are read, never the keys, tokens, accounts or IDs next to them.
+func DetectCloudProfiles(ctx context.Context, envData *types.EnvironmentData) {
+	detectCloudProfiles(ctx, envData, runner.Default, runner.DefaultPath, awsConfigPath())
+}
+
+// awsConfigPath returns the AWS CLI config file: $AWS_CONFIG_FILE, or
+// ~/.aws/config
+func awsConfigPath() string {
+	if path := os.Getenv("AWS_CONFIG_FILE"); path != "" {
+		return path
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".aws", "config")
+}
+
+func detectCloudProfiles(ctx context.Context, envData *types.EnvironmentData, r runner.Runner, path runner.PathIndex, awsConfig string) {
+	profiles := make(map[string][]string)
+	add := func(cli string, names []string) {
+		var kept []string
+		for _, name := range names {
+			if accountIDPattern.MatchString(name) {
+				log.Printf("Leaving out a %s profile named after an account ID", cli)
+				continue
+			}
+			if !slices.Contains(kept, name) {
+				kept = append(kept, name)
+			}
+		}
+		if len(kept) > 0 {
+			slices.Sort(kept)
+			profiles[cli] = kept
+		}
+	}
+
+	data, err := os.ReadFile(awsConfig)
+	switch {
+	case err == nil:
+		add(types.CloudAWS, ParseAWSConfigProfiles(string(data)))
+	case !errors.Is(err, os.ErrNotExist):
+		envData.Warnings = append(envData.Warnings, fmt.Sprintf("could not read AWS config: %v", err))
+	}
+
+	if _, err := path.LookPath("gcloud"); err == nil {
+		if stdout, err := cloudCommand(ctx, r, "gcloud", "config", "configurations", "list", "--format=json"); err != nil {
+			log.Printf("Warning: %v", err)
+		} else if names, err := ParseCloudNames(stdout); err != nil {
+			log.Printf("Warning: could not parse the gcloud configurations: %v", err)
+		} else {
+			add(types.CloudGcloud, names)
+		}
+	}
+
+	if _, err := path.LookPath("az"); err == nil {
+		if stdout, err := cloudCommand(ctx, r, "az", "account", "list", "--output", "json"); err != nil {
+			log.Printf("Warning: %v", err)
+		} else if names, err := ParseCloudNames(stdout); err != nil {
+			log.Printf("Warning: could not parse the Azure subscriptions: %v", err)
+		} else {
+			add(types.CloudAzure, names)
+		}
+	}
+
+	if len(profiles) == 0 {
+		return
+	}
+	for cli, names := range profiles {
+		log.Printf("Found %d %s profiles", len(names), cli)
+	}
+	envData.CloudProfiles = profiles
+}
+
+// ParseAWSConfigProfiles returns the profiles of an AWS CLI config file,
+// in file order: "default" for [default] and "dev" for [profile dev].
+// Other sections, such as [sso-session ...] and [services ...], and every
+// setting, including any keys in the file, are skipped.
+func ParseAWSConfigProfiles(content string) []string {
+	var profiles []string
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "[") || !strings.HasSuffix(line, "]") {
+			continue
+		}
+		fields := strings.Fields(strings.TrimSuffix(strings.TrimPrefix(line, "["), "]"))
+		switch {
+		case len(fields) == 1 && fields[0] == "default":
+			profiles = append(profiles, "default")
+		case len(fields) == 2 && fields[0] == "profile":
+			profiles = append(profiles, fields[1])
+		}
+	}
+	return profiles
+}
+
+// ParseCloudNames returns the names of the entries of a JSON array, as
+// printed by 'gcloud config configurations list --format=json' and 'az
+// account list --output json'. Nothing but the names is decoded, so the
+// accounts, projects and IDs next to them are never read into the scan.
+func ParseCloudNames(output string) ([]string, error) {
+	var entries []struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal([]byte(output), &entries); err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, entry := range entries {
+		if name := strings.TrimSpace(entry.Name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// cloudCommand runs a cloud CLI command with cloudTimeout and returns its
+// stdout, or an error saying why it failed
+func cloudCommand(ctx context.Context, r runner.Runner, name string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, cloudTimeout)
+	defer cancel()
+	stdout, stderr, err := r.Output(ctx, name, args...)
+	switch {
+	case err == nil:
+		return stdout, nil
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return "", fmt.Errorf("%s did not answer within %s", name, cloudTimeout)
+	}
+	message := firstLine(stderr, stdout)
+	if message == "" {
+		message = err.Error()
+	}
+	return "", fmt.Errorf("%s failed: %s", name, message)
+}
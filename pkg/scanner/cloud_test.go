@@ -0,0 +1,109 @@
+package scanner
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/runner/runnertest"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+func TestParseAWSConfigProfiles(t *testing.T) {
+	expected := []string{"default", "dev", "prod", "123456789012-admin"}
+	if actual := ParseAWSConfigProfiles(readFixture(t, "cloud", "aws-config")); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %q but got %q", expected, actual)
+	}
+}
+
+func TestParseCloudNames(t *testing.T) {
+	names, err := ParseCloudNames(readFixture(t, "cloud", "gcloud-configurations.json"))
+	if err != nil || !reflect.DeepEqual(names, []string{"work", "default"}) {
+		t.Errorf("expected the gcloud configurations but got %q, %v", names, err)
+	}
+	if _, err := ParseCloudNames("ERROR: (gcloud) not logged in\n"); err == nil {
+		t.Error("expected output that isn't JSON to be rejected")
+	}
+}
+
+func TestDetectCloudProfiles(t *testing.T) {
+	awsConfig := filepath.Join("testdata", "cloud", "aws-config")
+	responses := map[string]runnertest.Response{
+		"gcloud config configurations list --format=json": {Output: readFixture(t, "cloud", "gcloud-configurations.json")},
+		"az account list --output json":                   {Output: readFixture(t, "cloud", "az-accounts.json")},
+	}
+
+	testCases := []struct {
+		name      string
+		awsConfig string
+		path      []string
+		responses map[string]runnertest.Response
+		expected  map[string][]string
+	}{
+		{
+			name:      "Every CLI",
+			awsConfig: awsConfig,
+			path:      []string{"/usr/bin/gcloud", "/usr/bin/az"},
+			responses: responses,
+			expected: map[string][]string{
+				types.CloudAWS:    {"default", "dev", "prod"},
+				types.CloudGcloud: {"default", "work"},
+				types.CloudAzure:  {"Corp Production", "Dev Sandbox"},
+			},
+		},
+		{
+			name:      "az not logged in",
+			awsConfig: filepath.Join(t.TempDir(), "missing"),
+			path:      []string{"/usr/bin/az"},
+			responses: map[string]runnertest.Response{
+				"az account list --output json": {Output: "Please run 'az login' to setup account.\n", Err: errors.New("exit status 1")},
+			},
+		},
+		{
+			name:      "No cloud CLI",
+			awsConfig: filepath.Join(t.TempDir(), "missing"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &runnertest.Runner{Responses: tc.responses}
+			env := &types.EnvironmentData{}
+			detectCloudProfiles(context.Background(), env, r, runnertest.NewPath([]string{"/usr/bin"}, tc.path...), tc.awsConfig)
+			if !reflect.DeepEqual(env.CloudProfiles, tc.expected) {
+				t.Errorf("expected %q but got %q", tc.expected, env.CloudProfiles)
+			}
+			if len(env.Warnings) != 0 {
+				t.Errorf("expected no warnings but got %q", env.Warnings)
+			}
+		})
+	}
+}
+
+// TestDetectCloudProfilesLeavesOutCredentials makes sure that nothing but
+// profile names gets into the scan: no keys, SSO settings, accounts,
+// projects, or AWS and Azure IDs, even a profile named after one
+func TestDetectCloudProfilesLeavesOutCredentials(t *testing.T) {
+	r := &runnertest.Runner{Responses: map[string]runnertest.Response{
+		"gcloud config configurations list --format=json": {Output: readFixture(t, "cloud", "gcloud-configurations.json")},
+		"az account list --output json":                   {Output: readFixture(t, "cloud", "az-accounts.json")},
+	}}
+	env := &types.EnvironmentData{}
+	detectCloudProfiles(context.Background(), env, r, runnertest.NewPath([]string{"/usr/bin"}, "/usr/bin/gcloud", "/usr/bin/az"), filepath.Join("testdata", "cloud", "aws-config"))
+	data, err := json.Marshal(env)
+	if err != nil {
+		t.Fatalf("failed to marshal: %v", err)
+	}
+	for _, secret := range []string{
+		"AKIA", "wJalrXUtnFEMI", "123456789012", "210987654321", "corp.awsapps.com", "localhost",
+		"jane", "corp-prod-314159", "72f988bf", "0b1f6471", "5c8e9a2b", "9d3c1e7f",
+	} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("expected %q to be left out but got %s", secret, data)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package scanner
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/runner"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+// condaExecutables are the conda front ends, in the order they are looked
+// for. Miniforge and Mambaforge ship conda next to mamba; micromamba is a
+// standalone binary that works without either.
+var condaExecutables = []string{"conda", "mamba", "micromamba"}
+
+// condaDistributions are the installers recognized by the directory of the
+// base environment they create
+var condaDistributions = []string{"miniforge3", "miniforge", "mambaforge", "miniconda3", "miniconda", "anaconda3", "anaconda", "micromamba"}
+
+// keyCondaPackages are the packages whose versions deep scans record. They
+// define a data-science stack; recording every package would bloat the
+// environment file with each environment's dependencies.
+var keyCondaPackages = []string{
+	"python", "r-base", "numpy", "pandas", "scipy", "scikit-learn",
+	"matplotlib", "jupyterlab", "notebook", "pytorch", "tensorflow",
+	"cudatoolkit", "cuda-version",
+}
+
+// DetectConda records the conda installation whose front end PATH finds
+// first, with its version, the distribution it came from and the name and
+// prefix of each environment. Their packages are only listed by
+// DetectCondaPackages.
+func DetectConda(ctx context.Context, envData *types.EnvironmentData) {
+	detectConda(ctx, envData, runner.Default, runner.DefaultPath)
+}
+
+func detectConda(ctx context.Context, envData *types.EnvironmentData, r runner.Runner, path runner.PathIndex) {
+	exe := ""
+	for _, name := range condaExecutables {
+		if _, err := path.LookPath(name); err == nil {
+			exe = name
+			break
+		}
+	}
+	if exe == "" {
+		return
+	}
+
+	var envs *types.CondaEnvironments
+	if exe == "conda" && envData.Python != nil && envData.Python.Conda != nil {
+		// The Python step already listed them
+		envs = envData.Python.Conda
+	} else {
+		stdout, stderr, err := r.Output(ctx, exe, "env", "list", "--json")
+		if err == nil {
+			envs, err = ParseCondaEnvList(stdout)
+		} else if message := firstLine(stderr); message != "" {
+			err = errors.New(message)
+		}
+		if err != nil {
+			envData.Warnings = append(envData.Warnings, fmt.Sprintf("could not list the %s environments: %v", exe, err))
+			return
+		}
+	}
+
+	conda := &types.CondaInstallation{Executable: exe, Distribution: condaDistribution(envs.Base)}
+	// conda 24.1.2, or for mamba a line per package with mamba's first;
+	// micromamba prints the bare version
+	if stdout, _, err := r.Output(ctx, exe, "--version"); err == nil {
+		if fields := strings.Fields(firstLine(stdout)); len(fields) > 0 {
+			conda.Version = fields[len(fields)-1]
+		}
+	}
+	for _, prefix := range envs.Envs {
+		env := types.CondaEnvironment{Name: condaEnvName(prefix, envs.Base), Prefix: prefix}
+		if prefix == envs.Base {
+			conda.Environments = slices.Insert(conda.Environments, 0, env)
+		} else {
+			conda.Environments = append(conda.Environments, env)
+		}
+	}
+	log.Printf("Found %s %s with %d environments", exe, conda.Version, len(conda.Environments))
+	envData.Conda = conda
+}
+
+// DetectCondaPackages records the versions of the key packages of every
+// environment DetectConda found, with one 'conda list' per environment. An
+// environment that can't be listed gets an error and a warning; the others
+// are still listed.
+func DetectCondaPackages(ctx context.Context, envData *types.EnvironmentData) {
+	detectCondaPackages(ctx, envData, runner.Default)
+}
+
+func detectCondaPackages(ctx context.Context, envData *types.EnvironmentData, r runner.Runner) {
+	if envData.Conda == nil {
+		return
+	}
+	exe := envData.Conda.Executable
+	for i := range envData.Conda.Environments {
+		if ctx.Err() != nil {
+			return
+		}
+		env := &envData.Conda.Environments[i]
+		// Environments made with --prefix outside envs have no name
+		args := []string{"list", "--json", "-p", env.Prefix}
+		if env.Name != "" {
+			args = []string{"list", "--json", "-n", env.Name}
+		}
+		stdout, stderr, err := r.Output(ctx, exe, args...)
+		if err == nil {
+			env.Packages, err = parseCondaList(stdout)
+		} else if message := firstLine(stderr); message != "" {
+			err = errors.New(message)
+		}
+		if err != nil {
+			env.Error = err.Error()
+			envData.Warnings = append(envData.Warnings, fmt.Sprintf("could not list the packages of the conda environment %s: %v", env.Prefix, err))
+		}
+	}
+}
+
+// condaPackage is an entry of the output of 'conda list --json'
+type condaPackage struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// parseCondaList returns the versions of the key packages in the output of
+// 'conda list --json', or nil when the environment has none of them
+func parseCondaList(output string) (map[string]string, error) {
+	var list []condaPackage
+	if err := json.Unmarshal([]byte(output), &list); err != nil {
+		return nil, fmt.Errorf("could not parse the conda package list: %w", err)
+	}
+	var packages map[string]string
+	for _, pkg := range list {
+		if !slices.Contains(keyCondaPackages, pkg.Name) {
+			continue
+		}
+		if packages == nil {
+			packages = make(map[string]string)
+		}
+		packages[pkg.Name] = pkg.Version
+	}
+	return packages, nil
+}
+
+// condaEnvName returns the name conda knows the environment at prefix by:
+// "base" for the base environment, the directory name of one under an envs
+// directory and "" for one elsewhere
+func condaEnvName(prefix, base string) string {
+	if prefix == base {
+		return "base"
+	}
+	parts := strings.Split(strings.TrimRight(strings.ReplaceAll(prefix, `\`, "/"), "/"), "/")
+	if len(parts) >= 2 && parts[len(parts)-2] == "envs" {
+		return parts[len(parts)-1]
+	}
+	return ""
+}
+
+// condaDistribution returns the installer named by the directory of the
+// base environment, such as "miniforge3", or "" when it names none
+func condaDistribution(base string) string {
+	if base == "" {
+		return ""
+	}
+	name := strings.ToLower(filepath.Base(strings.ReplaceAll(base, `\`, "/")))
+	name = strings.TrimPrefix(name, ".")
+	if slices.Contains(condaDistributions, name) {
+		return name
+	}
+	return ""
+}
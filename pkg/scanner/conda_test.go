@@ -0,0 +1,142 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/runner/runnertest"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+func TestDetectConda(t *testing.T) {
+	testCases := []struct {
+		name      string
+		dirs      []string
+		exes      []string
+		responses map[string]runnertest.Response
+		python    *types.PythonEnvironment
+		expected  *types.CondaInstallation
+		warnings  int
+	}{
+		{
+			name: "nothing installed",
+		},
+		{
+			name: "miniconda",
+			dirs: []string{"/home/ada/miniconda3/bin"},
+			exes: []string{"/home/ada/miniconda3/bin/conda"},
+			responses: map[string]runnertest.Response{
+				"conda env list --json": {Output: readFixture(t, "python", "conda-env-list.json")},
+				"conda --version":       {Output: "conda 24.1.2\n"},
+			},
+			expected: &types.CondaInstallation{
+				Executable:   "conda",
+				Version:      "24.1.2",
+				Distribution: "miniconda3",
+				Environments: []types.CondaEnvironment{
+					{Name: "base", Prefix: "/home/ada/miniconda3"},
+					{Name: "ml", Prefix: "/home/ada/miniconda3/envs/ml"},
+					{Name: "py311", Prefix: "/home/ada/miniconda3/envs/py311"},
+				},
+			},
+		},
+		{
+			// The Python step already ran 'conda env list'
+			name: "environments listed by the Python step",
+			dirs: []string{"/home/ada/miniconda3/bin"},
+			exes: []string{"/home/ada/miniconda3/bin/conda"},
+			responses: map[string]runnertest.Response{
+				"conda --version": {Output: "conda 24.1.2\n"},
+			},
+			python: &types.PythonEnvironment{Conda: &types.CondaEnvironments{
+				Base: "/home/ada/miniconda3",
+				Envs: []string{"/home/ada/miniconda3", "/srv/envs-shared/tools"},
+			}},
+			expected: &types.CondaInstallation{
+				Executable:   "conda",
+				Version:      "24.1.2",
+				Distribution: "miniconda3",
+				Environments: []types.CondaEnvironment{
+					{Name: "base", Prefix: "/home/ada/miniconda3"},
+					{Prefix: "/srv/envs-shared/tools"},
+				},
+			},
+		},
+		{
+			name: "micromamba from miniforge without conda",
+			dirs: []string{"/home/ada/.local/bin"},
+			exes: []string{"/home/ada/.local/bin/micromamba"},
+			responses: map[string]runnertest.Response{
+				"micromamba env list --json": {Output: `{"envs": ["/home/ada/miniforge3", "/home/ada/miniforge3/envs/geo"]}`},
+				"micromamba --version":       {Output: "1.5.8\n"},
+			},
+			expected: &types.CondaInstallation{
+				Executable:   "micromamba",
+				Version:      "1.5.8",
+				Distribution: "miniforge3",
+				Environments: []types.CondaEnvironment{
+					{Name: "base", Prefix: "/home/ada/miniforge3"},
+					{Name: "geo", Prefix: "/home/ada/miniforge3/envs/geo"},
+				},
+			},
+		},
+		{
+			name: "listing fails",
+			dirs: []string{"/opt/conda/bin"},
+			exes: []string{"/opt/conda/bin/conda"},
+			responses: map[string]runnertest.Response{
+				"conda env list --json": {Stderr: "CondaError: could not read ~/.condarc\n", Err: errors.New("exit status 1")},
+			},
+			warnings: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			env := types.EnvironmentData{Python: tc.python}
+			r := &runnertest.Runner{Responses: tc.responses}
+			detectConda(context.Background(), &env, r, runnertest.NewPath(tc.dirs, tc.exes...))
+			if !reflect.DeepEqual(env.Conda, tc.expected) {
+				t.Errorf("expected %+v but got %+v", tc.expected, env.Conda)
+			}
+			if len(env.Warnings) != tc.warnings {
+				t.Errorf("expected %d warnings but got %v", tc.warnings, env.Warnings)
+			}
+		})
+	}
+}
+
+func TestDetectCondaPackages(t *testing.T) {
+	env := types.EnvironmentData{Conda: &types.CondaInstallation{
+		Executable: "mamba",
+		Environments: []types.CondaEnvironment{
+			{Name: "base", Prefix: "/home/ada/mambaforge"},
+			{Name: "broken", Prefix: "/home/ada/mambaforge/envs/broken"},
+			{Prefix: "/srv/envs-shared/tools"},
+		},
+	}}
+	r := &runnertest.Runner{Responses: map[string]runnertest.Response{
+		"mamba list --json -n base": {Output: `[
+  {"name": "python", "version": "3.11.8", "channel": "conda-forge"},
+  {"name": "numpy", "version": "1.26.4", "channel": "conda-forge"},
+  {"name": "libzlib", "version": "1.2.13", "channel": "conda-forge"}
+]`},
+		"mamba list --json -n broken":                 {Stderr: "EnvironmentLocationNotFound: Not a conda environment\n", Err: errors.New("exit status 1")},
+		"mamba list --json -p /srv/envs-shared/tools": {Output: `[{"name": "libzlib", "version": "1.2.13"}]`},
+	}}
+
+	detectCondaPackages(context.Background(), &env, r)
+	expected := []types.CondaEnvironment{
+		{Name: "base", Prefix: "/home/ada/mambaforge", Packages: map[string]string{"python": "3.11.8", "numpy": "1.26.4"}},
+		{Name: "broken", Prefix: "/home/ada/mambaforge/envs/broken", Error: "EnvironmentLocationNotFound: Not a conda environment"},
+		{Prefix: "/srv/envs-shared/tools"},
+	}
+	if !reflect.DeepEqual(env.Conda.Environments, expected) {
+		t.Errorf("expected %+v but got %+v", expected, env.Conda.Environments)
+	}
+	if len(env.Warnings) != 1 {
+		t.Errorf("expected a warning for the broken environment but got %v", env.Warnings)
+	}
+}
@@ -0,0 +1,283 @@
+package scanner
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+// GlobBudget bounds the work done for a single config file pattern
+type GlobBudget struct {
+	// MaxFiles is the number of directory entries a pattern may visit
+	MaxFiles int
+	// MaxDuration is how long a pattern may take
+	MaxDuration time.Duration
+}
+
+// DefaultGlobBudget keeps a pattern over a large home directory from
+// stalling the scan
+var DefaultGlobBudget = GlobBudget{MaxFiles: 10000, MaxDuration: 2 * time.Second}
+
+// errBudget stops a walk once its budget is used up
+type errBudget struct {
+	reason string
+}
+
+func (e *errBudget) Error() string { return e.reason }
+
+// maxBufferedHash is the size above which config files are hashed as they
+// are read rather than read whole
+const maxBufferedHash = 1 << 20
+
+// addConfigFile records a config file found at path in envData
+func addConfigFile(envData *types.EnvironmentData, path string) {
+	log.Printf("Found config file: %s", path)
+	envData.ConfigFiles = append(envData.ConfigFiles, path)
+	envData.ConfigFileInfo = append(envData.ConfigFileInfo, describeConfigFile(path))
+}
+
+// describeConfigFile returns the size, modification time and SHA-256 of the
+// file at path. A symlink is described by the file it resolves to, with its
+// target; a file that can't be read, or a dangling symlink, is described
+// with the reason instead of a hash.
+func describeConfigFile(path string) types.ConfigFileInfo {
+	info := types.ConfigFileInfo{Path: path}
+	if link, err := os.Lstat(path); err == nil && link.Mode()&os.ModeSymlink != 0 {
+		info.Symlink = true
+		if info.Target, err = filepath.EvalSymlinks(path); err != nil {
+			// Dangling: record where it points anyway
+			info.Target, _ = os.Readlink(path)
+		}
+	}
+	stat, err := os.Stat(path)
+	if err != nil {
+		info.Error = errorReason(err)
+		return info
+	}
+	info.ModTime = stat.ModTime().UTC()
+	if stat.IsDir() {
+		info.Dir = true
+		return info
+	}
+	info.Size = stat.Size()
+
+	hash := sha256.New()
+	if stat.Size() <= maxBufferedHash {
+		var data []byte
+		if data, err = os.ReadFile(path); err == nil {
+			hash.Write(data)
+		}
+	} else {
+		err = hashFile(hash, path)
+	}
+	if err != nil {
+		info.Error = errorReason(err)
+		return info
+	}
+	info.SHA256 = hex.EncodeToString(hash.Sum(nil))
+	return info
+}
+
+// hashFile streams the file at path into w
+func hashFile(w io.Writer, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(w, f)
+	return err
+}
+
+// errorReason returns err without the path a PathError repeats
+func errorReason(err error) string {
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		return pathErr.Err.Error()
+	}
+	return err.Error()
+}
+
+// isGlob reports whether pattern contains glob syntax rather than naming a
+// single file
+func isGlob(pattern string) bool {
+	return strings.ContainsAny(pattern, "*?[")
+}
+
+// globFiles returns the files under root matching pattern, a slash-separated
+// path relative to root in which "**" matches any number of directories.
+// Symlinks are only followed when they resolve inside root, and each real
+// directory is visited once, so links out of root and link cycles are skipped.
+// When the budget runs out the files found so far are returned together with
+// a warning describing the truncation.
+func globFiles(ctx context.Context, root, pattern string, budget GlobBudget) ([]string, string, error) {
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Start from the literal directories before the first glob segment
+	segments := strings.Split(pattern, "/")
+	base := 0
+	for base < len(segments)-1 && !isGlob(segments[base]) {
+		base++
+	}
+	start := filepath.Join(append([]string{root}, segments[:base]...)...)
+
+	w := &globWalker{
+		ctx:      ctx,
+		realRoot: realRoot,
+		pattern:  pattern,
+		budget:   budget,
+		deadline: time.Now().Add(budget.MaxDuration),
+		visited:  make(map[string]bool),
+	}
+	err = w.walk(start, path.Join(segments[:base]...))
+
+	var stopped *errBudget
+	switch {
+	case err == nil:
+		return w.found, "", nil
+	case errors.As(err, &stopped):
+		return w.found, fmt.Sprintf("config file pattern %q %s; results are incomplete", pattern, stopped.reason), nil
+	default:
+		return w.found, "", err
+	}
+}
+
+// globWalker holds the state of one globFiles call
+type globWalker struct {
+	ctx      context.Context
+	realRoot string
+	pattern  string
+	budget   GlobBudget
+	deadline time.Time
+	visited  map[string]bool
+	entries  int
+	found    []string
+}
+
+// walk visits dir, whose path relative to the root is rel
+func (w *globWalker) walk(dir, rel string) error {
+	real, ok := w.inside(dir)
+	if !ok || w.visited[real] {
+		return nil
+	}
+	w.visited[real] = true
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		// Unreadable directories are skipped like missing ones
+		return nil
+	}
+	for _, entry := range entries {
+		if err := w.ctx.Err(); err != nil {
+			return err
+		}
+		w.entries++
+		if w.budget.MaxFiles > 0 && w.entries > w.budget.MaxFiles {
+			return &errBudget{fmt.Sprintf("stopped after %d files", w.budget.MaxFiles)}
+		}
+		if w.budget.MaxDuration > 0 && time.Now().After(w.deadline) {
+			return &errBudget{fmt.Sprintf("stopped after %s", w.budget.MaxDuration)}
+		}
+
+		full := filepath.Join(dir, entry.Name())
+		entryRel := path.Join(rel, entry.Name())
+
+		isDir := entry.IsDir()
+		if entry.Type()&os.ModeSymlink != 0 {
+			if _, ok := w.inside(full); !ok {
+				continue
+			}
+			info, err := os.Stat(full)
+			if err != nil {
+				continue
+			}
+			isDir = info.IsDir()
+		}
+
+		if isDir {
+			if couldMatchBelow(w.pattern, entryRel) {
+				if err := w.walk(full, entryRel); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if matchGlob(w.pattern, entryRel) {
+			w.found = append(w.found, full)
+		}
+	}
+	return nil
+}
+
+// inside resolves p and reports whether it stays within the root
+func (w *globWalker) inside(p string) (string, bool) {
+	real, err := filepath.EvalSymlinks(p)
+	if err != nil {
+		return "", false
+	}
+	rel, err := filepath.Rel(w.realRoot, real)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return real, true
+}
+
+// matchGlob reports whether the slash-separated name matches pattern, where
+// "**" matches zero or more path segments
+func matchGlob(pattern, name string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// couldMatchBelow reports whether some path inside directory dir could match
+// pattern, so walks skip subtrees that cannot contain results
+func couldMatchBelow(pattern, dir string) bool {
+	segments := strings.Split(pattern, "/")
+	for i, part := range strings.Split(dir, "/") {
+		if i >= len(segments)-1 {
+			return false
+		}
+		if segments[i] == "**" {
+			return true
+		}
+		if ok, _ := path.Match(segments[i], part); !ok {
+			return false
+		}
+	}
+	return true
+}
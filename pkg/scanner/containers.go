@@ -0,0 +1,111 @@
+package scanner
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/runner"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+// containersTimeout bounds each docker command listing images or
+// containers, so a daemon that hangs doesn't stall the scan
+const containersTimeout = 5 * time.Second
+
+// DetectContainers records the Docker images present and the images of the
+// running containers when docker is installed. A daemon that can't be
+// reached is recorded in Containers.Error rather than failing the scan.
+func DetectContainers(ctx context.Context, envData *types.EnvironmentData) {
+	detectContainers(ctx, envData, runner.Default, runner.DefaultPath, containersTimeout)
+}
+
+func detectContainers(ctx context.Context, envData *types.EnvironmentData, r runner.Runner, path runner.PathIndex, timeout time.Duration) {
+	if _, err := path.LookPath("docker"); err != nil {
+		return
+	}
+	containers := &types.Containers{}
+	envData.Containers = containers
+
+	// 'docker ps' needs the daemon, so it tells first whether it is up
+	running, err := listDocker(ctx, r, timeout, "ps")
+	if err != nil {
+		containers.Error = err.Error()
+		return
+	}
+	containers.Running = ParseDockerPs(running)
+	images, err := listDocker(ctx, r, timeout, "images")
+	if err != nil {
+		containers.Error = err.Error()
+		return
+	}
+	containers.Images = ParseDockerImages(images)
+}
+
+// listDocker runs 'docker <command> --format json' within timeout
+func listDocker(ctx context.Context, r runner.Runner, timeout time.Duration, command string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	stdout, stderr, err := r.Output(ctx, "docker", command, "--format", "json")
+	switch {
+	case err == nil:
+		return stdout, nil
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return "", fmt.Errorf("docker %s did not answer within %s", command, timeout)
+	case isDaemonDown(stderr):
+		return "", errors.New(types.ContainersDaemonNotRunning)
+	}
+	message, _, _ := strings.Cut(strings.TrimSpace(stderr), "\n")
+	if message == "" {
+		message = err.Error()
+	}
+	return "", fmt.Errorf("docker %s failed: %s", command, message)
+}
+
+// isDaemonDown reports whether a docker command failed because the CLI
+// could not connect to the daemon
+func isDaemonDown(stderr string) bool {
+	return strings.Contains(stderr, "Cannot connect to the Docker daemon") ||
+		strings.Contains(stderr, "Is the docker daemon running") ||
+		strings.Contains(stderr, "error during connect")
+}
+
+// ParseDockerImages returns the named images in the output of 'docker images
+// --format json', one JSON object per line, in name order. Dangling images,
+// whose repository is "<none>", are skipped.
+func ParseDockerImages(output string) []types.ContainerImage {
+	var images []types.ContainerImage
+	for _, line := range strings.Split(output, "\n") {
+		var image struct{ Repository, Tag string }
+		if json.Unmarshal([]byte(line), &image) != nil || image.Repository == "" || image.Repository == "<none>" {
+			continue
+		}
+		if image.Tag == "<none>" {
+			image.Tag = ""
+		}
+		images = append(images, types.ContainerImage{Name: image.Repository, Tag: image.Tag})
+	}
+	sort.Slice(images, func(i, j int) bool { return images[i].String() < images[j].String() })
+	return slices.Compact(images)
+}
+
+// ParseDockerPs returns the images of the containers in the output of
+// 'docker ps --format json', one JSON object per line, in order and without
+// repeats
+func ParseDockerPs(output string) []string {
+	var running []string
+	for _, line := range strings.Split(output, "\n") {
+		var container struct{ Image string }
+		if json.Unmarshal([]byte(line), &container) != nil || container.Image == "" {
+			continue
+		}
+		running = append(running, container.Image)
+	}
+	sort.Strings(running)
+	return slices.Compact(running)
+}
@@ -0,0 +1,98 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/runner/runnertest"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+func TestParseDockerImages(t *testing.T) {
+	expected := []types.ContainerImage{
+		{Name: "ghcr.io/acme/api"},
+		{Name: "postgres", Tag: "16"},
+		{Name: "redis", Tag: "7-alpine"},
+	}
+	if actual := ParseDockerImages(readFixture(t, "docker", "images.json")); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %+v but got %+v", expected, actual)
+	}
+	// Releases before 'json' was a format print it literally
+	if actual := ParseDockerImages("json\njson\n"); actual != nil {
+		t.Errorf("expected no images but got %+v", actual)
+	}
+}
+
+func TestParseDockerPs(t *testing.T) {
+	expected := []string{"postgres:16", "redis:7-alpine"}
+	if actual := ParseDockerPs(readFixture(t, "docker", "ps.json")); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v but got %v", expected, actual)
+	}
+}
+
+func TestDetectContainers(t *testing.T) {
+	exitStatus := errors.New("exit status 1")
+	testCases := []struct {
+		name      string
+		responses map[string]runnertest.Response
+		path      []string
+		timeout   time.Duration
+		expected  *types.Containers
+	}{
+		{
+			name: "daemon running",
+			responses: map[string]runnertest.Response{
+				"docker ps --format json":     {Output: readFixture(t, "docker", "ps.json")},
+				"docker images --format json": {Output: readFixture(t, "docker", "images.json")},
+			},
+			path: []string{"/usr/bin/docker"},
+			expected: &types.Containers{
+				Images:  []types.ContainerImage{{Name: "ghcr.io/acme/api"}, {Name: "postgres", Tag: "16"}, {Name: "redis", Tag: "7-alpine"}},
+				Running: []string{"postgres:16", "redis:7-alpine"},
+			},
+		},
+		{
+			name: "daemon not running",
+			responses: map[string]runnertest.Response{
+				"docker ps --format json": {Stderr: "Cannot connect to the Docker daemon at unix:///var/run/docker.sock. Is the docker daemon running?\n", Err: exitStatus},
+			},
+			path:     []string{"/usr/bin/docker"},
+			expected: &types.Containers{Error: types.ContainersDaemonNotRunning},
+		},
+		{
+			name: "no access to the daemon",
+			responses: map[string]runnertest.Response{
+				"docker ps --format json": {Stderr: "permission denied while trying to connect to the Docker daemon socket at unix:///var/run/docker.sock\n", Err: exitStatus},
+			},
+			path:     []string{"/usr/bin/docker"},
+			expected: &types.Containers{Error: "docker ps failed: permission denied while trying to connect to the Docker daemon socket at unix:///var/run/docker.sock"},
+		},
+		{
+			name:     "daemon hangs",
+			path:     []string{"/usr/bin/docker"},
+			timeout:  -time.Second,
+			expected: &types.Containers{Error: "docker ps did not answer within -1s"},
+		},
+		{
+			name: "docker not installed",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &runnertest.Runner{Responses: tc.responses}
+			timeout := tc.timeout
+			if timeout == 0 {
+				timeout = containersTimeout
+			}
+			env := &types.EnvironmentData{}
+			detectContainers(context.Background(), env, r, runnertest.NewPath([]string{"/usr/bin"}, tc.path...), timeout)
+			if !reflect.DeepEqual(env.Containers, tc.expected) {
+				t.Errorf("expected %+v but got %+v", tc.expected, env.Containers)
+			}
+		})
+	}
+}
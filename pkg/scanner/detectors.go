@@ -0,0 +1,290 @@
+package scanner
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"syscall"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+	"gopkg.in/yaml.v3"
+)
+
+// Output streams a version override can read
+const (
+	StreamStdout = "stdout"
+	StreamStderr = "stderr"
+	StreamBoth   = "both"
+)
+
+// VersionOverride replaces how the version of one tool is detected
+type VersionOverride struct {
+	// Regex extracts the version from the output; its first capture group is the version
+	Regex string `yaml:"regex" json:"regex"`
+	// Args, when set, replace the built-in version arguments (e.g. ["version", "--short"])
+	Args []string `yaml:"args,omitempty" json:"args,omitempty"`
+	// Stream selects the output to match: stdout, stderr or both (the default)
+	Stream string `yaml:"stream,omitempty" json:"stream,omitempty"`
+
+	regex *regexp.Regexp
+}
+
+// DetectorConfig holds user customizations of the built-in detectors
+type DetectorConfig struct {
+	// Overrides maps a tool name (e.g. "Terraform") or command (e.g.
+	// "terraform") to its version override
+	Overrides map[string]VersionOverride `yaml:"overrides" json:"overrides"`
+	// ExcludePath lists PATH directory prefixes that are never searched for
+	// tools, such as network mounts that make lookups stall
+	ExcludePath []string `yaml:"exclude_path,omitempty" json:"exclude_path,omitempty"`
+	// LoginShellTools lists the commands the login shell probe retries when
+	// they are missing from PATH (default DefaultLoginShellTools)
+	LoginShellTools []string `yaml:"login_shell_tools,omitempty" json:"login_shell_tools,omitempty"`
+	// Aliases maps a canonical name to other names scans report the same
+	// tool under, extending types.DefaultAliasGroups
+	Aliases map[string][]string `yaml:"aliases,omitempty" json:"aliases,omitempty"`
+	// Detectors adds tools the built-in lists don't know, such as company CLIs
+	Detectors []CustomDetector `yaml:"detectors,omitempty" json:"detectors,omitempty"`
+	// ServicePorts changes the ports the service probe tries, keyed by
+	// service (see DefaultServicePorts). A port of 0 skips the service.
+	ServicePorts map[string]int `yaml:"service_ports,omitempty" json:"service_ports,omitempty"`
+	// EnvVars lists environment variables to record besides DefaultEnvVars.
+	// Secrets among them are masked like any other.
+	EnvVars []string `yaml:"env_vars,omitempty" json:"env_vars,omitempty"`
+
+	custom []customExecutable
+}
+
+// CustomDetector detects a tool the built-in lists don't know
+type CustomDetector struct {
+	Name    string `yaml:"name" json:"name"`
+	Command string `yaml:"command" json:"command"`
+	// VersionArg holds the arguments that print the version, separated by
+	// spaces (default "--version")
+	VersionArg string `yaml:"version_arg,omitempty" json:"version_arg,omitempty"`
+	// Regex extracts the version from the output; its first capture group is
+	// the version (default the first dotted number)
+	Regex string `yaml:"regex,omitempty" json:"regex,omitempty"`
+	// Category is the category the tool is recorded under: tools (the
+	// default), languages, package-managers or editors
+	Category string `yaml:"category,omitempty" json:"category,omitempty"`
+}
+
+// defaultCustomRegex is the version regex of custom detectors without one
+const defaultCustomRegex = `(\d+(?:\.\d+)+)`
+
+// customCategories are the categories custom detectors can add to
+var customCategories = []string{types.CategoryTools, types.CategoryLanguages, types.CategoryPackageManagers, types.CategoryEditors}
+
+// LoadDetectorConfig reads a detectors file. A missing file yields an empty
+// config, and so does one whose directory is a file, as when ~/.stackmatch
+// can't hold state. Invalid overrides are dropped and reported in the returned error
+// together with the config holding the valid ones.
+func LoadDetectorConfig(path string) (*DetectorConfig, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) || errors.Is(err, syscall.ENOTDIR) {
+		return &DetectorConfig{}, nil
+	}
+	if err != nil {
+		return &DetectorConfig{}, fmt.Errorf("failed to read detectors file: %w", err)
+	}
+	cfg, err := ParseDetectorConfig(data)
+	if err != nil {
+		return cfg, fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// envVarName matches the names of environment variables
+var envVarName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ParseDetectorConfig parses a detectors file in YAML or JSON and validates
+// every override
+func ParseDetectorConfig(data []byte) (*DetectorConfig, error) {
+	var raw DetectorConfig
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return &DetectorConfig{}, fmt.Errorf("invalid detectors file: %w", err)
+	}
+
+	cfg := &DetectorConfig{Overrides: make(map[string]VersionOverride)}
+	names := make([]string, 0, len(raw.Overrides))
+	for name := range raw.Overrides {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var errs []error
+	for _, name := range names {
+		override := raw.Overrides[name]
+		if err := override.compile(); err != nil {
+			errs = append(errs, fmt.Errorf("override %q: %w", name, err))
+			continue
+		}
+		cfg.Overrides[strings.ToLower(name)] = override
+	}
+	for _, prefix := range raw.ExcludePath {
+		// A relative prefix would depend on the directory scan runs in
+		if !filepath.IsAbs(prefix) {
+			errs = append(errs, fmt.Errorf("exclude_path %q: must be an absolute path", prefix))
+			continue
+		}
+		cfg.ExcludePath = append(cfg.ExcludePath, prefix)
+	}
+	known := make(map[string]bool)
+	for _, tool := range KnownTools() {
+		known[tool.ID] = true
+	}
+	for i, detector := range raw.Detectors {
+		exe, err := detector.compile()
+		if err == nil && known[exe.Info().ID] {
+			err = errors.New("is detected already; use overrides to change how")
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("detector %d (%s): %w", i+1, detector.Name, err))
+			continue
+		}
+		known[exe.Info().ID] = true
+		cfg.Detectors = append(cfg.Detectors, detector)
+		cfg.custom = append(cfg.custom, customExecutable{category: detector.Category, exe: exe})
+	}
+	for _, name := range slices.Sorted(maps.Keys(raw.ServicePorts)) {
+		port := raw.ServicePorts[name]
+		if port < 0 || port > 65535 {
+			errs = append(errs, fmt.Errorf("service_ports %q: %d is not a port", name, port))
+			continue
+		}
+		if cfg.ServicePorts == nil {
+			cfg.ServicePorts = make(map[string]int)
+		}
+		cfg.ServicePorts[name] = port
+	}
+	for _, name := range raw.EnvVars {
+		if !envVarName.MatchString(name) {
+			errs = append(errs, fmt.Errorf("env_vars %q: not a variable name", name))
+			continue
+		}
+		cfg.EnvVars = append(cfg.EnvVars, name)
+	}
+	cfg.LoginShellTools = raw.LoginShellTools
+	cfg.Aliases = raw.Aliases
+	return cfg, errors.Join(errs...)
+}
+
+// customExecutable is a validated custom detector
+type customExecutable struct {
+	category string
+	exe      Executable
+}
+
+// compile validates the detector, fills in its defaults and returns the
+// executable that detects it
+func (d *CustomDetector) compile() (Executable, error) {
+	if d.Name == "" || d.Command == "" {
+		return Executable{}, errors.New("name and command are required")
+	}
+	if d.VersionArg == "" {
+		d.VersionArg = "--version"
+	}
+	if d.Regex == "" {
+		d.Regex = defaultCustomRegex
+	}
+	if d.Category == "" {
+		d.Category = types.CategoryTools
+	}
+	if !slices.Contains(customCategories, d.Category) {
+		return Executable{}, fmt.Errorf("unknown category %q (want %s)", d.Category, strings.Join(customCategories, ", "))
+	}
+	re, err := regexp.Compile(d.Regex)
+	if err != nil {
+		return Executable{}, fmt.Errorf("invalid regex: %w", err)
+	}
+	if re.NumSubexp() < 1 {
+		return Executable{}, errors.New("regex needs a capture group for the version")
+	}
+	return Executable{Name: d.Name, Command: d.Command, VersionArg: d.VersionArg, VersionRegex: re}, nil
+}
+
+// executables returns the custom detectors of category
+func (c *DetectorConfig) executables(category string) []Executable {
+	if c == nil {
+		return nil
+	}
+	var executables []Executable
+	for _, custom := range c.custom {
+		if custom.category == category {
+			executables = append(executables, custom.exe)
+		}
+	}
+	return executables
+}
+
+// withCustom returns builtin followed by the user's detectors of category
+func withCustom(cfg *DetectorConfig, builtin []Executable, category string) []Executable {
+	custom := cfg.executables(category)
+	if len(custom) == 0 {
+		return builtin
+	}
+	return append(slices.Clip(builtin), custom...)
+}
+
+// compile validates the override and prepares its regex
+func (o *VersionOverride) compile() error {
+	if o.Regex == "" {
+		return errors.New("regex is required")
+	}
+	re, err := regexp.Compile(o.Regex)
+	if err != nil {
+		return fmt.Errorf("invalid regex: %w", err)
+	}
+	if re.NumSubexp() < 1 {
+		return errors.New("regex needs a capture group for the version")
+	}
+	switch o.Stream {
+	case "":
+		o.Stream = StreamBoth
+	case StreamStdout, StreamStderr, StreamBoth:
+	default:
+		return fmt.Errorf("unknown stream %q (want stdout, stderr or both)", o.Stream)
+	}
+	o.regex = re
+	return nil
+}
+
+// AliasGroups returns the default alias groups extended by the configured ones
+func (c *DetectorConfig) AliasGroups() []types.AliasGroup {
+	if c == nil {
+		return types.DefaultAliasGroups
+	}
+	return types.MergeAliasGroups(types.DefaultAliasGroups, c.Aliases)
+}
+
+// override returns the override for exe, matched by name or command
+func (c *DetectorConfig) override(exe Executable) *VersionOverride {
+	if c == nil {
+		return nil
+	}
+	for _, key := range []string{exe.Name, exe.Command} {
+		if o, ok := c.Overrides[strings.ToLower(key)]; ok {
+			return &o
+		}
+	}
+	return nil
+}
+
+// pick returns the output stream the override reads
+func (o *VersionOverride) pick(stdout, stderr string) string {
+	switch o.Stream {
+	case StreamStdout:
+		return stdout
+	case StreamStderr:
+		return stderr
+	default:
+		return stdout + stderr
+	}
+}
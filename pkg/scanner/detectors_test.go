@@ -0,0 +1,265 @@
+package scanner
+
+import (
+	"context"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/runner/runnertest"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+// widget is a fake tool whose banner the built-in regex cannot parse
+var widget = Executable{
+	Name:         "Widget",
+	Command:      "widget",
+	VersionArg:   "--version",
+	VersionRegex: regexp.MustCompile(`widget version ([\d\.]+)`),
+}
+
+func TestParseDetectorConfig(t *testing.T) {
+	testCases := []struct {
+		name        string
+		data        string
+		overrides   []string
+		excludePath []string
+		loginShell  []string
+		aliases     map[string][]string
+		ports       map[string]int
+		envVars     []string
+		errors      []string
+	}{
+		{
+			name: "YAML",
+			data: `
+overrides:
+  Widget:
+    regex: 'build ([\d.]+)'
+    args: [about]
+    stream: stderr
+`,
+			overrides: []string{"widget"},
+		},
+		{
+			name:      "JSON",
+			data:      `{"overrides": {"terraform": {"regex": "v([0-9.]+)"}}}`,
+			overrides: []string{"terraform"},
+		},
+		{
+			name: "invalid entries are dropped",
+			data: `
+overrides:
+  good: {regex: 'v(\d+)'}
+  badregex: {regex: 'v(\d+'}
+  nogroup: {regex: 'v\d+'}
+  nostream: {regex: 'v(\d+)', stream: stdin}
+  empty: {args: [version]}
+`,
+			overrides: []string{"good"},
+			errors:    []string{`"badregex": invalid regex`, `"nogroup": regex needs a capture group`, `"nostream": unknown stream "stdin"`, `"empty": regex is required`},
+		},
+		{
+			name: "PATH exclusions",
+			data: `
+exclude_path:
+  - /net/tools/bin
+  - shared/bin
+`,
+			excludePath: []string{"/net/tools/bin"},
+			errors:      []string{`exclude_path "shared/bin": must be an absolute path`},
+		},
+		{
+			name:       "Login shell tools",
+			data:       "login_shell_tools: [node, gradle]\n",
+			loginShell: []string{"node", "gradle"},
+		},
+		{
+			name:    "Aliases",
+			data:    "aliases:\n  Python: [python3.12]\n  kubectl: [k]\n",
+			aliases: map[string][]string{"Python": {"python3.12"}, "kubectl": {"k"}},
+		},
+		{
+			name:   "Service ports",
+			data:   "service_ports:\n  postgresql: 5433\n  mongodb: 0\n  rabbitmq: 99999\n",
+			ports:  map[string]int{"postgresql": 5433, "mongodb": 0},
+			errors: []string{`service_ports "rabbitmq": 99999 is not a port`},
+		},
+		{
+			name:    "Environment variables",
+			data:    "env_vars: [FLUTTER_HOME, 'MY SDK']\n",
+			envVars: []string{"FLUTTER_HOME"},
+			errors:  []string{`env_vars "MY SDK": not a variable name`},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg, err := ParseDetectorConfig([]byte(tc.data))
+			if len(tc.errors) == 0 && err != nil {
+				t.Fatalf("expected no error but got %v", err)
+			}
+			for _, e := range tc.errors {
+				if err == nil || !strings.Contains(err.Error(), e) {
+					t.Errorf("expected error to contain %q but got %v", e, err)
+				}
+			}
+			if len(cfg.Overrides) != len(tc.overrides) {
+				t.Errorf("expected %d overrides but got %v", len(tc.overrides), cfg.Overrides)
+			}
+			for _, name := range tc.overrides {
+				if _, ok := cfg.Overrides[name]; !ok {
+					t.Errorf("expected override %q but got %v", name, cfg.Overrides)
+				}
+			}
+			if strings.Join(cfg.ExcludePath, ",") != strings.Join(tc.excludePath, ",") {
+				t.Errorf("expected excluded PATH entries %v but got %v", tc.excludePath, cfg.ExcludePath)
+			}
+			if strings.Join(cfg.LoginShellTools, ",") != strings.Join(tc.loginShell, ",") {
+				t.Errorf("expected login shell tools %v but got %v", tc.loginShell, cfg.LoginShellTools)
+			}
+			if len(cfg.Aliases)+len(tc.aliases) > 0 && !reflect.DeepEqual(cfg.Aliases, tc.aliases) {
+				t.Errorf("expected aliases %v but got %v", tc.aliases, cfg.Aliases)
+			}
+			if len(cfg.ServicePorts)+len(tc.ports) > 0 && !reflect.DeepEqual(cfg.ServicePorts, tc.ports) {
+				t.Errorf("expected service ports %v but got %v", tc.ports, cfg.ServicePorts)
+			}
+			if strings.Join(cfg.EnvVars, ",") != strings.Join(tc.envVars, ",") {
+				t.Errorf("expected environment variables %v but got %v", tc.envVars, cfg.EnvVars)
+			}
+		})
+	}
+}
+
+func TestDetectExecutablesWithOverride(t *testing.T) {
+	path := runnertest.NewPath([]string{"/usr/bin"}, "/usr/bin/widget")
+
+	testCases := []struct {
+		name       string
+		config     string
+		responses  map[string]runnertest.Response
+		expected   string
+		overridden bool
+	}{
+		{
+			name:   "built-in regex cannot parse the banner",
+			config: `overrides: {}`,
+			responses: map[string]runnertest.Response{
+				"widget --version": {Output: "Widget(TM) build 4.2.1 (c) Acme\n"},
+			},
+			expected: "",
+		},
+		{
+			name:   "override regex parses the banner",
+			config: `overrides: {Widget: {regex: 'build ([\d.]+)'}}`,
+			responses: map[string]runnertest.Response{
+				"widget --version": {Output: "Widget(TM) build 4.2.1 (c) Acme\n"},
+			},
+			expected:   "4.2.1",
+			overridden: true,
+		},
+		{
+			name:   "override with alternate subcommand and stream",
+			config: `overrides: {widget: {regex: 'release=([\d.]+)', args: [about, --plain], stream: stderr}}`,
+			responses: map[string]runnertest.Response{
+				"widget about --plain": {Output: "release=0.0.1\n", Stderr: "release=5.0.3\n"},
+			},
+			expected:   "5.0.3",
+			overridden: true,
+		},
+		{
+			name:   "unmatched override falls back to built-in",
+			config: `overrides: {Widget: {regex: 'build ([\d.]+)', args: [about]}}`,
+			responses: map[string]runnertest.Response{
+				"widget about":     {Output: "nothing useful\n"},
+				"widget --version": {Output: "widget version 3.1.0\n"},
+			},
+			expected: "3.1.0",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg, err := ParseDetectorConfig([]byte(tc.config))
+			if err != nil {
+				t.Fatal(err)
+			}
+			r := &runnertest.Runner{Responses: tc.responses}
+
+			version, overridden, _ := getCommandVersion(context.Background(), r, widget, cfg.override(widget))
+			if version != tc.expected {
+				t.Errorf("expected version %q but got %q", tc.expected, version)
+			}
+			if overridden != tc.overridden {
+				t.Errorf("expected overridden=%v but got %v", tc.overridden, overridden)
+			}
+
+			found := make(map[string]string)
+			detectExecutablesWith(context.Background(), r, path, cfg, nil, DefaultConcurrency, &types.EnvironmentData{}, []Executable{widget}, found)
+			want := tc.expected
+			if want == "" {
+				want = "Installed"
+			}
+			if found["Widget"] != want {
+				t.Errorf("expected Widget to be recorded as %q but got %q", want, found["Widget"])
+			}
+		})
+	}
+}
+
+func TestCustomDetectors(t *testing.T) {
+	cfg, err := ParseDetectorConfig([]byte(`
+detectors:
+  - name: Acme Deploy
+    command: acme-deploy
+    version_arg: version --short
+    regex: 'acme-deploy v([\d.]+)'
+  - name: Acme Lang
+    command: acmec
+    category: languages
+  - name: Broken
+    command: broken
+    regex: 'v([\d.]+'
+  - name: Git
+    command: git
+  - name: Misfiled
+    command: misfiled
+    category: databases
+`))
+	for _, e := range []string{`detector 3 (Broken): invalid regex`, `detector 4 (Git): is detected already`, `detector 5 (Misfiled): unknown category "databases"`} {
+		if err == nil || !strings.Contains(err.Error(), e) {
+			t.Errorf("expected error to contain %q but got %v", e, err)
+		}
+	}
+	if len(cfg.Detectors) != 2 {
+		t.Fatalf("expected the 2 valid detectors to be kept but got %+v", cfg.Detectors)
+	}
+
+	builtin := []Executable{widget}
+	tools := withCustom(cfg, builtin, types.CategoryTools)
+	if len(tools) != 2 || tools[0].Name != "Widget" || tools[1].Name != "Acme Deploy" {
+		t.Errorf("expected the custom tool after the built-in ones but got %+v", tools)
+	}
+	if len(builtin) != 1 || cap(builtin) != 1 {
+		t.Errorf("expected the built-in list to be left unchanged")
+	}
+	if languages := withCustom(cfg, nil, types.CategoryLanguages); len(languages) != 1 || languages[0].Name != "Acme Lang" {
+		t.Errorf("expected the custom language but got %+v", languages)
+	}
+
+	r := &runnertest.Runner{Responses: map[string]runnertest.Response{
+		"acme-deploy version --short": {Output: "acme-deploy v2.14.0 (linux/amd64)\n"},
+	}}
+	path := runnertest.NewPath([]string{"/opt/acme/bin"}, "/opt/acme/bin/acme-deploy")
+	env := &types.EnvironmentData{ToolIDs: make(map[string]string)}
+	found := make(map[string]string)
+	detectExecutablesWith(context.Background(), r, path, cfg, nil, DefaultConcurrency, env, tools, found)
+
+	if !reflect.DeepEqual(found, map[string]string{"Acme Deploy": "2.14.0"}) {
+		t.Errorf("expected the custom tool to be detected but got %v", found)
+	}
+	if env.ToolIDs["Acme Deploy"] != "acme-deploy" {
+		t.Errorf("expected the custom tool's ID to be recorded but got %v", env.ToolIDs)
+	}
+}
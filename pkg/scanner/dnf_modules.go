@@ -0,0 +1,45 @@
+package scanner
+
+import (
+	"context"
+	"log"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/installer/package_managers"
+	"github.com/MRQ67/stackmatch-cli/pkg/runner"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+// DetectDnfModules records which detected languages come from an enabled DNF
+// module stream, so imports can install the same stream rather than whatever
+// version the distribution ships by default
+func DetectDnfModules(ctx context.Context, envData *types.EnvironmentData) {
+	detectDnfModules(ctx, envData, runner.Default, runner.DefaultPath)
+}
+
+func detectDnfModules(ctx context.Context, envData *types.EnvironmentData, r runner.Runner, path runner.PathIndex) {
+	if _, err := path.LookPath("dnf"); err != nil {
+		return
+	}
+	modules, err := package_managers.ListEnabledDnfModules(ctx, r)
+	if err != nil {
+		log.Printf("Warning: %v", err)
+		return
+	}
+
+	enabled := make(map[string]package_managers.DnfModule)
+	for _, m := range modules {
+		if m.Enabled {
+			enabled[m.Name] = m
+		}
+	}
+	for name := range envData.ConfiguredLanguages {
+		m, ok := enabled[package_managers.DnfModuleFor(name)]
+		if !ok {
+			continue
+		}
+		if envData.ToolSources == nil {
+			envData.ToolSources = make(map[string]string)
+		}
+		envData.ToolSources[name] = m.Source()
+	}
+}
@@ -0,0 +1,31 @@
+package scanner
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/runner/runnertest"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+func TestDetectDnfModules(t *testing.T) {
+	r := &runnertest.Runner{Responses: map[string]runnertest.Response{
+		"dnf --cacheonly module list --enabled": {Output: `Red Hat Enterprise Linux 8 for x86_64 - AppStream (RPMs)
+Name       Stream      Profiles                      Summary
+nodejs     18 [e]      common [d] [i], development   Javascript runtime
+postgresql 15 [e]      client, server [d] [i]        PostgreSQL server and client module
+
+Hint: [d]efault, [e]nabled, [x]disabled, [i]nstalled
+`},
+	}}
+	path := runnertest.NewPath([]string{"/usr/bin"}, "/usr/bin/dnf")
+	env := &types.EnvironmentData{ConfiguredLanguages: map[string]string{"Node.js": "18.19.0", "Go": "1.22.3"}}
+
+	detectDnfModules(context.Background(), env, r, path)
+
+	expected := map[string]string{"Node.js": "dnf-module:nodejs:18"}
+	if !reflect.DeepEqual(env.ToolSources, expected) {
+		t.Errorf("expected sources %v but got %v", expected, env.ToolSources)
+	}
+}
@@ -0,0 +1,150 @@
+package scanner
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/runner"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+// dockerPlugin is a docker CLI plugin detected through the docker subcommand
+// it provides, so plugins installed system-wide are found too
+type dockerPlugin struct {
+	Executable
+	// Subcommand is the docker subcommand the plugin adds
+	Subcommand string
+}
+
+// dockerPlugins are the docker CLI plugins detected by name. They are
+// recorded apart from standalone binaries such as docker-compose v1.
+var dockerPlugins = []dockerPlugin{
+	{
+		Executable: Executable{Name: "Docker Compose Plugin", Command: "docker", VersionArg: "compose version", VersionRegex: regexp.MustCompile(`Docker Compose version v?(\d+(?:\.\d+)+)`)},
+		Subcommand: "compose",
+	},
+	{
+		Executable: Executable{Name: "Docker Buildx Plugin", Command: "docker", VersionArg: "buildx version", VersionRegex: regexp.MustCompile(`github\.com/docker/buildx v?(\d+(?:\.\d+)+)`)},
+		Subcommand: "buildx",
+	},
+}
+
+// pluginMetadataVersion matches the version in the metadata every docker CLI
+// plugin prints for 'docker-cli-plugin-metadata', such as "v0.23.0"
+var pluginMetadataVersion = regexp.MustCompile(`^v?(\d+(?:\.\d+)+)`)
+
+// DetectDockerPlugins records the docker CLI plugins, such as the compose and
+// buildx plugins, and the other plugins in the user's cli-plugins directory
+// (~/.docker/cli-plugins, or under DOCKER_CONFIG when it is set)
+func DetectDockerPlugins(ctx context.Context, envData *types.EnvironmentData) {
+	configDir := os.Getenv("DOCKER_CONFIG")
+	if configDir == "" {
+		home, _ := os.UserHomeDir()
+		configDir = filepath.Join(home, ".docker")
+	}
+	detectDockerPlugins(ctx, envData, runner.Default, runner.DefaultPath, filepath.Join(configDir, "cli-plugins"))
+}
+
+func detectDockerPlugins(ctx context.Context, envData *types.EnvironmentData, r runner.Runner, path runner.PathIndex, pluginDir string) {
+	if _, err := path.LookPath("docker"); err != nil {
+		return
+	}
+	if envData.Tools == nil {
+		envData.Tools = make(map[string]string)
+	}
+	if envData.ToolIDs == nil {
+		envData.ToolIDs = make(map[string]string)
+	}
+	record := func(exe Executable, version string) {
+		log.Printf("Found %s version %s", exe.Name, version)
+		envData.Tools[exe.Name] = version
+		envData.ToolIDs[exe.Name] = exe.Info().ID
+	}
+
+	detected := make(map[string]bool)
+	for _, plugin := range dockerPlugins {
+		// docker fails for subcommands it doesn't have, which just means
+		// the plugin isn't installed
+		stdout, stderr, err := r.Output(ctx, plugin.Command, strings.Fields(plugin.VersionArg)...)
+		if err != nil {
+			continue
+		}
+		version := parseVersion(stdout, plugin.VersionRegex)
+		if version == "" {
+			version = parseVersion(stderr, plugin.VersionRegex)
+		}
+		if version == "" {
+			version = "Installed"
+		}
+		detected[plugin.Subcommand] = true
+		record(plugin.Executable, version)
+	}
+
+	for _, name := range listPlugins(path, pluginDir) {
+		if detected[name] || ctx.Err() != nil {
+			continue
+		}
+		file := filepath.Join(pluginDir, "docker-"+name)
+		if runtime.GOOS == "windows" {
+			file += ".exe"
+		}
+		// Plugins describe themselves when run with this argument; docker
+		// ignores those that don't
+		stdout, _, err := r.Output(ctx, file, "docker-cli-plugin-metadata")
+		if err != nil {
+			continue
+		}
+		if version, ok := pluginVersion(stdout); ok {
+			record(pluginExecutable(name), version)
+		}
+	}
+}
+
+// pluginVersion returns the version in the metadata a plugin prints for
+// 'docker-cli-plugin-metadata', or false when the output isn't metadata
+func pluginVersion(metadata string) (string, bool) {
+	var fields struct{ Version string }
+	if err := json.Unmarshal([]byte(metadata), &fields); err != nil {
+		return "", false
+	}
+	if version := parseVersion(fields.Version, pluginMetadataVersion); version != "" {
+		return version, true
+	}
+	return "Installed", true
+}
+
+// listPlugins returns the names of the plugins in dir, the executables named
+// docker-<name>, in order
+func listPlugins(path runner.PathIndex, dir string) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, entry := range entries {
+		name, ok := strings.CutPrefix(entry.Name(), "docker-")
+		if ok && runtime.GOOS == "windows" {
+			name, ok = strings.CutSuffix(name, ".exe")
+		}
+		if !ok || name == "" || !path.IsExecutable(filepath.Join(dir, entry.Name())) {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// pluginExecutable describes a plugin found in the cli-plugins directory,
+// named like the known plugins: "scan" becomes "Docker Scan Plugin"
+func pluginExecutable(name string) Executable {
+	display := strings.ToUpper(name[:1]) + name[1:]
+	return Executable{Name: "Docker " + display + " Plugin", Command: "docker-" + name, VersionArg: "docker-cli-plugin-metadata"}
+}
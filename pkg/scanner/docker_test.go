@@ -0,0 +1,135 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"slices"
+	"testing"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/runner/runnertest"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+func TestParseDockerPluginVersion(t *testing.T) {
+	compose := dockerPlugins[0].VersionRegex
+	buildx := dockerPlugins[1].VersionRegex
+
+	testCases := []struct {
+		fixture  string
+		plugin   string
+		expected string
+	}{
+		{fixture: "compose.txt", plugin: "compose", expected: "2.24.5"},
+		{fixture: "compose-desktop.txt", plugin: "compose", expected: "2.24.6"},
+		{fixture: "compose-distro.txt", plugin: "compose", expected: "2.21.0"},
+		{fixture: "buildx.txt", plugin: "buildx", expected: "0.12.1"},
+		{fixture: "buildx-desktop.txt", plugin: "buildx", expected: "0.12.1"},
+		{fixture: "buildx-distro.txt", plugin: "buildx", expected: "0.11.2"},
+		{fixture: "scan-metadata.json", plugin: "metadata", expected: "0.23.0"},
+		{fixture: "sbom-metadata.json", plugin: "metadata", expected: "0.6.0"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.fixture, func(t *testing.T) {
+			output, err := os.ReadFile(filepath.Join("testdata", "docker", tc.fixture))
+			if err != nil {
+				t.Fatalf("failed to read fixture: %v", err)
+			}
+
+			var actual string
+			switch tc.plugin {
+			case "compose":
+				actual = parseVersion(string(output), compose)
+			case "buildx":
+				actual = parseVersion(string(output), buildx)
+			default:
+				var ok bool
+				if actual, ok = pluginVersion(string(output)); !ok {
+					t.Fatal("expected the fixture to be read as plugin metadata")
+				}
+			}
+			if actual != tc.expected {
+				t.Errorf("expected version '%s', but got '%s'", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestDetectDockerPlugins(t *testing.T) {
+	pluginDir := t.TempDir()
+	exe := ""
+	if runtime.GOOS == "windows" {
+		exe = ".exe"
+	}
+	var executables []string
+	for _, name := range []string{"docker-compose", "docker-scan", "docker-sbom", "docker-broken", "README"} {
+		file := filepath.Join(pluginDir, name)
+		if name != "README" {
+			file += exe
+			executables = append(executables, file)
+		}
+		if err := os.WriteFile(file, nil, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	metadata := func(name string) string {
+		data, err := os.ReadFile(filepath.Join("testdata", "docker", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+
+	r := &runnertest.Runner{Responses: map[string]runnertest.Response{
+		"docker compose version": {Output: "Docker Compose version v2.24.5\n"},
+		"docker buildx version": {
+			Stderr: "docker: 'buildx' is not a docker command.\nSee 'docker --help'\n",
+			Err:    errors.New("exit status 1"),
+		},
+		filepath.Join(pluginDir, "docker-scan"+exe) + " docker-cli-plugin-metadata":   {Output: metadata("scan-metadata.json")},
+		filepath.Join(pluginDir, "docker-sbom"+exe) + " docker-cli-plugin-metadata":   {Output: metadata("sbom-metadata.json")},
+		filepath.Join(pluginDir, "docker-broken"+exe) + " docker-cli-plugin-metadata": {Output: "Segmentation fault\n"},
+	}}
+	path := runnertest.NewPath([]string{"/usr/bin"}, append(executables, "/usr/bin/docker")...)
+	env := &types.EnvironmentData{Tools: map[string]string{"Docker": "25.0.3"}}
+
+	detectDockerPlugins(context.Background(), env, r, path, pluginDir)
+
+	expected := map[string]string{
+		"Docker":                "25.0.3",
+		"Docker Compose Plugin": "2.24.5",
+		"Docker Sbom Plugin":    "0.6.0",
+		"Docker Scan Plugin":    "0.23.0",
+	}
+	if !reflect.DeepEqual(env.Tools, expected) {
+		t.Errorf("expected tools %v but got %v", expected, env.Tools)
+	}
+	if id := env.ToolIDs["Docker Compose Plugin"]; id != "docker-compose-plugin" {
+		t.Errorf("expected tool ID docker-compose-plugin but got %q", id)
+	}
+	// The compose plugin answered through docker, so its file isn't queried
+	if calls := r.Calls(); slices.Contains(calls, filepath.Join(pluginDir, "docker-compose"+exe)+" docker-cli-plugin-metadata") {
+		t.Errorf("expected the compose plugin to be queried once but got %v", calls)
+	}
+	if len(env.BrokenTools) != 0 || len(env.Warnings) != 0 {
+		t.Errorf("expected missing plugins not to be reported but got %v and %q", env.BrokenTools, env.Warnings)
+	}
+}
+
+func TestDetectDockerPluginsWithoutDocker(t *testing.T) {
+	r := &runnertest.Runner{}
+	env := &types.EnvironmentData{}
+
+	detectDockerPlugins(context.Background(), env, r, runnertest.NewPath(nil), t.TempDir())
+
+	if calls := r.Calls(); len(calls) != 0 {
+		t.Errorf("expected no commands without docker but got %v", calls)
+	}
+	if len(env.Tools) != 0 {
+		t.Errorf("expected no tools but got %v", env.Tools)
+	}
+}
@@ -0,0 +1,367 @@
+package scanner
+
+import (
+	"bufio"
+	"context"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/runner"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+// dotfileTimeout bounds the commands asking a dotfile manager what it manages
+const dotfileTimeout = 10 * time.Second
+
+// dotfileDirs are where the dotfile managers keep their state, after the
+// environment variables that move them
+type dotfileDirs struct {
+	Home string
+	// Chezmoi is chezmoi's default source directory,
+	// $XDG_DATA_HOME/chezmoi or ~/.local/share/chezmoi
+	Chezmoi string
+	// Yadm lists where yadm's bare repository may be, newest layout first
+	Yadm []string
+	// Stowrc is ~/.stowrc, whose --dir option names the stow directory
+	Stowrc string
+	// Dotfiles lists the directories stow and dotbot link files from:
+	// $STOW_DIR, ~/dotfiles and ~/.dotfiles
+	Dotfiles []string
+}
+
+// chezmoiAttributes are the prefixes chezmoi adds to source state names,
+// stripped to find the target name. dot_ and literal_ end the attributes.
+var chezmoiAttributes = []string{
+	"after_", "before_", "create_", "empty_", "encrypted_", "exact_", "executable_",
+	"external_", "modify_", "once_", "onchange_", "private_", "readonly_", "symlink_",
+}
+
+// chezmoiSuffixes are the suffixes chezmoi adds to source file names
+var chezmoiSuffixes = []string{".tmpl", ".age", ".asc"}
+
+// dotbotConfigs are the file names of a dotbot configuration, at the root
+// of the dotfiles repository
+var dotbotConfigs = []string{"install.conf.yaml", "install.conf.yml", "install.conf.json"}
+
+// DetectDotfileManagers sets ManagedBy on the config files a dotfile
+// manager keeps: chezmoi from its source directory, yadm from the files its
+// repository tracks, and dotbot and GNU stow from the dotfiles repository
+// their symlinks point into. Each is detected by its binary or by where it
+// keeps its state. It runs after the config files are found.
+func DetectDotfileManagers(ctx context.Context, envData *types.EnvironmentData) {
+	home, _ := os.UserHomeDir()
+	detectDotfileManagers(ctx, envData, runner.Default, runner.DefaultPath, newDotfileDirs(home, os.Getenv))
+}
+
+// newDotfileDirs returns the dotfile manager directories of home, moved by
+// the variables getenv returns
+func newDotfileDirs(home string, getenv func(string) string) dotfileDirs {
+	or := func(name, fallback string) string {
+		if value := getenv(name); value != "" {
+			return value
+		}
+		return fallback
+	}
+	data := or("XDG_DATA_HOME", filepath.Join(home, ".local", "share"))
+	config := or("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	dirs := dotfileDirs{
+		Home:    home,
+		Chezmoi: filepath.Join(data, "chezmoi"),
+		Yadm: []string{
+			filepath.Join(data, "yadm", "repo.git"),
+			filepath.Join(config, "yadm", "repo.git"),
+			filepath.Join(home, ".yadm", "repo.git"),
+		},
+		Stowrc: filepath.Join(home, ".stowrc"),
+	}
+	if stowDir := getenv("STOW_DIR"); stowDir != "" {
+		dirs.Dotfiles = append(dirs.Dotfiles, stowDir)
+	}
+	dirs.Dotfiles = append(dirs.Dotfiles, filepath.Join(home, "dotfiles"), filepath.Join(home, ".dotfiles"))
+	return dirs
+}
+
+func detectDotfileManagers(ctx context.Context, envData *types.EnvironmentData, r runner.Runner, path runner.PathIndex, dirs dotfileDirs) {
+	if len(envData.ConfigFileInfo) == 0 {
+		return
+	}
+
+	// Each manager claims the files it knows of; the first one wins
+	managers := []struct {
+		name   string
+		claims func(info types.ConfigFileInfo) bool
+	}{
+		{types.DotfileManagerChezmoi, chezmoiClaims(ctx, r, path, dirs)},
+		{types.DotfileManagerYadm, yadmClaims(ctx, r, path, dirs)},
+		{types.DotfileManagerDotbot, dotbotClaims(dirs)},
+		{types.DotfileManagerStow, stowClaims(path, dirs)},
+	}
+	for _, manager := range managers {
+		if manager.claims == nil {
+			continue
+		}
+		count := 0
+		for i, info := range envData.ConfigFileInfo {
+			if info.ManagedBy == "" && manager.claims(info) {
+				envData.ConfigFileInfo[i].ManagedBy = manager.name
+				count++
+			}
+		}
+		if count > 0 {
+			log.Printf("Found %s managing %d config files", manager.name, count)
+		}
+	}
+}
+
+// chezmoiClaims returns whether chezmoi manages a config file: its target
+// is in chezmoi's source state, or it is a symlink into the source
+// directory. The source directory is asked of chezmoi when it is on PATH.
+// It returns nil when chezmoi is not set up.
+func chezmoiClaims(ctx context.Context, r runner.Runner, path runner.PathIndex, dirs dotfileDirs) func(types.ConfigFileInfo) bool {
+	source := dirs.Chezmoi
+	if _, err := path.LookPath("chezmoi"); err == nil {
+		if out, err := dotfileCommand(ctx, r, "chezmoi", "source-path"); err == nil && strings.TrimSpace(out) != "" {
+			source = strings.TrimSpace(out)
+		}
+	}
+	if !dirExists(source) {
+		return nil
+	}
+
+	targets := chezmoiTargets(source)
+	return func(info types.ConfigFileInfo) bool {
+		if info.Symlink && within(source, info.Target) {
+			return true
+		}
+		rel, ok := homeRelative(dirs.Home, info.Path)
+		return ok && targets[rel]
+	}
+}
+
+// chezmoiTargets returns the slash-separated paths, relative to the home
+// directory, of the files and directories in the chezmoi source directory.
+// A .chezmoiroot file moves the source state to a subdirectory.
+func chezmoiTargets(source string) map[string]bool {
+	if data, err := os.ReadFile(filepath.Join(source, ".chezmoiroot")); err == nil {
+		if root := strings.TrimSpace(string(data)); root != "" {
+			source = filepath.Join(source, root)
+		}
+	}
+
+	targets := make(map[string]bool)
+	entries := 0
+	_ = filepath.WalkDir(source, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || p == source {
+			return nil
+		}
+		if entries++; entries > DefaultGlobBudget.MaxFiles {
+			return filepath.SkipAll
+		}
+		rel, _ := filepath.Rel(source, p)
+		var parts []string
+		for _, name := range strings.Split(filepath.ToSlash(rel), "/") {
+			target, ok := chezmoiTargetName(name)
+			if !ok {
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			parts = append(parts, target)
+		}
+		targets[strings.Join(parts, "/")] = true
+		return nil
+	})
+	return targets
+}
+
+// chezmoiTargetName returns the target name of a chezmoi source state
+// name, such as ".zshrc" for "private_dot_zshrc.tmpl". Names that have no
+// target, chezmoi's own files and scripts, are reported as not ok.
+func chezmoiTargetName(name string) (string, bool) {
+	if strings.HasPrefix(name, ".") || strings.HasPrefix(name, "run_") {
+		return "", false
+	}
+	if literal, ok := strings.CutSuffix(name, ".literal"); ok {
+		name = literal
+	} else {
+		for trimmed := true; trimmed; {
+			trimmed = false
+			for _, suffix := range chezmoiSuffixes {
+				if before, ok := strings.CutSuffix(name, suffix); ok {
+					name, trimmed = before, true
+				}
+			}
+		}
+	}
+	for {
+		if after, ok := strings.CutPrefix(name, "literal_"); ok {
+			return after, after != ""
+		}
+		if after, ok := strings.CutPrefix(name, "dot_"); ok {
+			return "." + after, true
+		}
+		stripped := false
+		for _, attribute := range chezmoiAttributes {
+			if after, ok := strings.CutPrefix(name, attribute); ok {
+				name, stripped = after, true
+				break
+			}
+		}
+		if !stripped {
+			return name, name != ""
+		}
+	}
+}
+
+// yadmClaims returns whether a config file is tracked by yadm's
+// repository, listed with git. It returns nil when there is no yadm
+// repository or no git to read it.
+func yadmClaims(ctx context.Context, r runner.Runner, path runner.PathIndex, dirs dotfileDirs) func(types.ConfigFileInfo) bool {
+	repo := ""
+	for _, dir := range dirs.Yadm {
+		if dirExists(dir) {
+			repo = dir
+			break
+		}
+	}
+	if repo == "" {
+		return nil
+	}
+	if _, err := path.LookPath("git"); err != nil {
+		return nil
+	}
+	out, err := dotfileCommand(ctx, r, "git", "--git-dir="+repo, "--work-tree="+dirs.Home, "ls-files", "--full-name")
+	if err != nil {
+		log.Printf("Warning: Could not list the files yadm tracks: %v", err)
+		return nil
+	}
+
+	tracked := make(map[string]bool)
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		// Directories such as ~/.vim are managed when a file in them is
+		for p := line; p != "."; p = filepath.ToSlash(filepath.Dir(p)) {
+			tracked[p] = true
+		}
+	}
+	return func(info types.ConfigFileInfo) bool {
+		rel, ok := homeRelative(dirs.Home, info.Path)
+		return ok && tracked[rel]
+	}
+}
+
+// dotbotClaims returns whether a config file is a symlink into a
+// repository with a dotbot configuration at its root
+func dotbotClaims(dirs dotfileDirs) func(types.ConfigFileInfo) bool {
+	return func(info types.ConfigFileInfo) bool {
+		if !info.Symlink || info.Target == "" {
+			return false
+		}
+		// Walk up from the target to the home directory or the root
+		for dir := filepath.Dir(info.Target); ; dir = filepath.Dir(dir) {
+			for _, name := range dotbotConfigs {
+				if fileExists(filepath.Join(dir, name)) {
+					return true
+				}
+			}
+			if dir == dirs.Home || dir == filepath.Dir(dir) {
+				return false
+			}
+		}
+	}
+}
+
+// stowClaims returns whether a config file is a symlink into a stow
+// directory: $STOW_DIR, the --dir of ~/.stowrc, ~/dotfiles or ~/.dotfiles.
+// It returns nil unless stow is on PATH or configured.
+func stowClaims(path runner.PathIndex, dirs dotfileDirs) func(types.ConfigFileInfo) bool {
+	stowDirs := dirs.Dotfiles
+	stowrcDir, configured := parseStowrc(dirs.Stowrc, dirs.Home)
+	if stowrcDir != "" {
+		stowDirs = append([]string{stowrcDir}, stowDirs...)
+	}
+	if _, err := path.LookPath("stow"); err != nil && !configured {
+		return nil
+	}
+	return func(info types.ConfigFileInfo) bool {
+		if !info.Symlink {
+			return false
+		}
+		for _, dir := range stowDirs {
+			if within(dir, info.Target) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// parseStowrc returns the stow directory set by --dir or -d in the stowrc
+// file, relative paths and ~ resolved against home, and whether the file
+// exists at all
+func parseStowrc(file, home string) (string, bool) {
+	f, err := os.Open(file)
+	if err != nil {
+		return "", false
+	}
+	defer f.Close()
+
+	dir := ""
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		for i, field := range fields {
+			switch {
+			case strings.HasPrefix(field, "--dir="):
+				dir = strings.TrimPrefix(field, "--dir=")
+			case (field == "--dir" || field == "-d") && i+1 < len(fields):
+				dir = fields[i+1]
+			}
+		}
+	}
+	if rest, ok := strings.CutPrefix(dir, "~"); ok {
+		dir = filepath.Join(home, rest)
+	} else if dir != "" && !filepath.IsAbs(dir) {
+		dir = filepath.Join(home, dir)
+	}
+	return dir, true
+}
+
+// dotfileCommand runs a dotfile manager command with dotfileTimeout and
+// returns its stdout
+func dotfileCommand(ctx context.Context, r runner.Runner, name string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, dotfileTimeout)
+	defer cancel()
+	stdout, _, err := r.Output(ctx, name, args...)
+	return stdout, err
+}
+
+// homeRelative returns p relative to home, slash-separated, if it is inside
+func homeRelative(home, p string) (string, bool) {
+	rel, err := filepath.Rel(home, p)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return filepath.ToSlash(rel), true
+}
+
+// within reports whether p is dir or inside it. Both are resolved, so a
+// dotfiles directory reached through a symlink still matches.
+func within(dir, p string) bool {
+	if p == "" {
+		return false
+	}
+	if real, err := filepath.EvalSymlinks(dir); err == nil {
+		dir = real
+	}
+	rel, err := filepath.Rel(dir, p)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
@@ -0,0 +1,178 @@
+package scanner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/runner/runnertest"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+func TestChezmoiTargetName(t *testing.T) {
+	testCases := []struct {
+		name     string
+		expected string
+		ok       bool
+	}{
+		{"dot_zshrc", ".zshrc", true},
+		{"private_dot_zshrc.tmpl", ".zshrc", true},
+		{"private_executable_dot_local", ".local", true},
+		{"exact_dot_vim", ".vim", true},
+		{"encrypted_private_dot_netrc.age", ".netrc", true},
+		{"literal_dot_file", "dot_file", true},
+		{"dot_notes.tmpl.literal", ".notes.tmpl", true},
+		{"config", "config", true},
+		{".chezmoiignore", "", false},
+		{".git", "", false},
+		{"run_once_install.sh", "", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			name, ok := chezmoiTargetName(tc.name)
+			if name != tc.expected || ok != tc.ok {
+				t.Errorf("expected %q, %v but got %q, %v", tc.expected, tc.ok, name, ok)
+			}
+		})
+	}
+}
+
+// describeHome describes the slash-separated config files of home as the
+// config-files detector does
+func describeHome(home string, files ...string) *types.EnvironmentData {
+	env := &types.EnvironmentData{}
+	for _, file := range files {
+		p := filepath.Join(home, filepath.FromSlash(file))
+		env.ConfigFiles = append(env.ConfigFiles, p)
+		env.ConfigFileInfo = append(env.ConfigFileInfo, describeConfigFile(p))
+	}
+	return env
+}
+
+// managedBy returns the manager of each config file that has one, keyed by
+// its path relative to home
+func managedBy(t *testing.T, home string, env *types.EnvironmentData) map[string]string {
+	t.Helper()
+	managed := make(map[string]string)
+	for _, info := range env.ConfigFileInfo {
+		if info.ManagedBy != "" {
+			managed[relative(t, home, []string{info.Path})[0]] = info.ManagedBy
+		}
+	}
+	return managed
+}
+
+func TestDetectDotfileManagers(t *testing.T) {
+	testCases := []struct {
+		name      string
+		setup     func(t *testing.T, home string)
+		path      []string
+		responses func(home string) map[string]runnertest.Response
+		files     []string
+		expected  map[string]string
+	}{
+		{
+			name: "chezmoi source directory",
+			setup: func(t *testing.T, home string) {
+				writeFiles(t, home, ".zshrc", ".config/git/config", ".npmrc",
+					".local/share/chezmoi/private_dot_zshrc.tmpl",
+					".local/share/chezmoi/dot_config/git/private_config",
+					".local/share/chezmoi/.chezmoiignore",
+					".local/share/chezmoi/run_once_install.sh")
+			},
+			files:    []string{".zshrc", ".config/git/config", ".npmrc"},
+			expected: map[string]string{".zshrc": "chezmoi", ".config/git/config": "chezmoi"},
+		},
+		{
+			name: "chezmoi source directory asked of chezmoi",
+			setup: func(t *testing.T, home string) {
+				writeFiles(t, home, ".gitconfig", "src/dots/home/dot_gitconfig")
+				if err := os.WriteFile(filepath.Join(home, "src", "dots", ".chezmoiroot"), []byte("home\n"), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			},
+			path: []string{"/usr/bin/chezmoi"},
+			responses: func(home string) map[string]runnertest.Response {
+				return map[string]runnertest.Response{"chezmoi source-path": {Output: filepath.Join(home, "src", "dots") + "\n"}}
+			},
+			files:    []string{".gitconfig"},
+			expected: map[string]string{".gitconfig": "chezmoi"},
+		},
+		{
+			name: "yadm repository",
+			setup: func(t *testing.T, home string) {
+				writeFiles(t, home, ".gitconfig", ".bashrc", ".vim/vimrc", ".local/share/yadm/repo.git/HEAD")
+			},
+			path: []string{"/usr/bin/git"},
+			responses: func(home string) map[string]runnertest.Response {
+				repo := filepath.Join(home, ".local", "share", "yadm", "repo.git")
+				return map[string]runnertest.Response{
+					"git --git-dir=" + repo + " --work-tree=" + home + " ls-files --full-name": {Output: ".gitconfig\n.vim/vimrc\n"},
+				}
+			},
+			files:    []string{".gitconfig", ".bashrc", ".vim"},
+			expected: map[string]string{".gitconfig": "yadm", ".vim": "yadm"},
+		},
+		{
+			name: "dotbot repository",
+			setup: func(t *testing.T, home string) {
+				writeFiles(t, home, ".dotfiles/install.conf.yaml", ".dotfiles/tmux/tmux.conf")
+				symlink(t, filepath.Join(home, ".dotfiles", "tmux", "tmux.conf"), filepath.Join(home, ".tmux.conf"))
+			},
+			path:     []string{"/usr/bin/stow"},
+			files:    []string{".tmux.conf"},
+			expected: map[string]string{".tmux.conf": "dotbot"},
+		},
+		{
+			name: "stow package",
+			setup: func(t *testing.T, home string) {
+				writeFiles(t, home, "dotfiles/zsh/.zshrc", ".npmrc")
+				symlink(t, filepath.Join("dotfiles", "zsh", ".zshrc"), filepath.Join(home, ".zshrc"))
+			},
+			path:     []string{"/usr/bin/stow"},
+			files:    []string{".zshrc", ".npmrc"},
+			expected: map[string]string{".zshrc": "stow"},
+		},
+		{
+			name: "stow directory from .stowrc",
+			setup: func(t *testing.T, home string) {
+				writeFiles(t, home, "src/dots/git/.gitconfig")
+				if err := os.WriteFile(filepath.Join(home, ".stowrc"), []byte("--dir=~/src/dots\n--target=~\n"), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				symlink(t, filepath.Join(home, "src", "dots", "git", ".gitconfig"), filepath.Join(home, ".gitconfig"))
+			},
+			files:    []string{".gitconfig"},
+			expected: map[string]string{".gitconfig": "stow"},
+		},
+		{
+			name: "Symlink with no manager",
+			setup: func(t *testing.T, home string) {
+				writeFiles(t, home, "dotfiles/zsh/.zshrc")
+				symlink(t, filepath.Join(home, "dotfiles", "zsh", ".zshrc"), filepath.Join(home, ".zshrc"))
+			},
+			files:    []string{".zshrc"},
+			expected: map[string]string{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			home := t.TempDir()
+			tc.setup(t, home)
+			r := &runnertest.Runner{Responses: map[string]runnertest.Response{}}
+			if tc.responses != nil {
+				r.Responses = tc.responses(home)
+			}
+			env := describeHome(home, tc.files...)
+			dirs := newDotfileDirs(home, func(string) string { return "" })
+			detectDotfileManagers(context.Background(), env, r, runnertest.NewPath([]string{"/usr/bin"}, tc.path...), dirs)
+			if actual := managedBy(t, home, env); !reflect.DeepEqual(actual, tc.expected) {
+				t.Errorf("expected %v but got %v", tc.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package scanner
+
+import (
+	"context"
+	"os"
+	"slices"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/langconfig"
+	"github.com/MRQ67/stackmatch-cli/pkg/redact"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+// DefaultEnvVars are the environment variables a scan records: those that
+// decide where toolchains, SDKs and version managers are found. The env_vars
+// entry of the detectors file adds to them.
+var DefaultEnvVars = []string{
+	"GOPATH", "GOROOT", "GOBIN",
+	"JAVA_HOME", "GRADLE_HOME", "MAVEN_HOME", "M2_HOME",
+	"ANDROID_HOME", "ANDROID_SDK_ROOT", "ANDROID_NDK_HOME",
+	"NVM_DIR", "VOLTA_HOME", "PNPM_HOME", "BUN_INSTALL", "DENO_INSTALL",
+	"PYENV_ROOT", "PIPX_HOME", "POETRY_HOME",
+	"RBENV_ROOT", "GEM_HOME",
+	"CARGO_HOME", "RUSTUP_HOME",
+	"SDKMAN_DIR", "ASDF_DIR", "ASDF_DATA_DIR", "MISE_DATA_DIR",
+	"DOTNET_ROOT", "FLUTTER_ROOT",
+	"HOMEBREW_PREFIX", "DOCKER_HOST", "KUBECONFIG", "EDITOR",
+}
+
+// DetectEnvVars records the variables of DefaultEnvVars and of the detectors
+// file that are set, in EnvVars. Values are passed through redact.Variable,
+// so secrets are masked before they are stored, and paths inside the home
+// directory start with ~.
+func DetectEnvVars(_ context.Context, envData *types.EnvironmentData) {
+	var extra []string
+	if detectorConfig != nil {
+		extra = detectorConfig.EnvVars
+	}
+	home, _ := os.UserHomeDir()
+	detectEnvVars(envData, os.LookupEnv, home, extra)
+}
+
+func detectEnvVars(envData *types.EnvironmentData, lookup func(string) (string, bool), home string, extra []string) {
+	for _, name := range slices.Concat(DefaultEnvVars, extra) {
+		value, ok := lookup(name)
+		if !ok {
+			continue
+		}
+		if envData.EnvVars == nil {
+			envData.EnvVars = make(map[string]string)
+		}
+		envData.EnvVars[name] = langconfig.Abbreviate(redact.Variable(name, value), home)
+	}
+}
@@ -0,0 +1,63 @@
+package scanner
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/runner"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+// DetectFlatpakApps records the flatpak applications installed, user and
+// system wide, under GlobalPackages["flatpak"], keyed by application ID
+// such as "org.mozilla.firefox". Runtimes are left out: installing an app
+// brings the runtimes it needs.
+func DetectFlatpakApps(ctx context.Context, envData *types.EnvironmentData) {
+	detectFlatpakApps(ctx, envData, runner.Default, runner.DefaultPath)
+}
+
+func detectFlatpakApps(ctx context.Context, envData *types.EnvironmentData, r runner.Runner, path runner.PathIndex) {
+	if _, err := path.LookPath("flatpak"); err != nil {
+		return
+	}
+	stdout, stderr, err := r.Output(ctx, "flatpak", "list", "--app", "--columns=application,version")
+	if err != nil {
+		message := firstLine(stderr)
+		if message == "" {
+			message = err.Error()
+		}
+		envData.Warnings = append(envData.Warnings, "could not list the flatpak apps: "+message)
+		return
+	}
+	apps := parseFlatpakList(stdout)
+	if len(apps) == 0 {
+		return
+	}
+	log.Printf("Found %d flatpak apps", len(apps))
+	if envData.GlobalPackages == nil {
+		envData.GlobalPackages = make(map[string]map[string]string)
+	}
+	envData.GlobalPackages["flatpak"] = apps
+}
+
+// parseFlatpakList returns the apps in the output of 'flatpak list --app
+// --columns=application,version', one tab-separated app per line. Apps that
+// don't report a version are recorded as "Installed".
+func parseFlatpakList(output string) map[string]string {
+	apps := make(map[string]string)
+	for _, line := range strings.Split(strings.ReplaceAll(output, "\r\n", "\n"), "\n") {
+		fields := strings.SplitN(line, "\t", 2)
+		id := strings.TrimSpace(fields[0])
+		// flatpak prints a header when its output is a terminal
+		if id == "" || id == "Application ID" {
+			continue
+		}
+		version := "Installed"
+		if len(fields) == 2 && strings.TrimSpace(fields[1]) != "" {
+			version = strings.TrimSpace(fields[1])
+		}
+		apps[id] = version
+	}
+	return apps
+}
@@ -0,0 +1,61 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/runner/runnertest"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+func TestDetectFlatpakApps(t *testing.T) {
+	const list = "flatpak list --app --columns=application,version"
+	testCases := []struct {
+		name     string
+		response runnertest.Response
+		expected map[string]map[string]string
+		warnings int
+	}{
+		{
+			name: "apps with and without a version",
+			response: runnertest.Response{Output: "org.mozilla.firefox\t125.0.3\n" +
+				"com.spotify.Client\t\n" +
+				"org.gimp.GIMP\t2.10.38\n"},
+			expected: map[string]map[string]string{"flatpak": {
+				"org.mozilla.firefox": "125.0.3",
+				"com.spotify.Client":  "Installed",
+				"org.gimp.GIMP":       "2.10.38",
+			}},
+		},
+		{
+			name:     "header printed",
+			response: runnertest.Response{Output: "Application ID\tVersion\nmd.obsidian.Obsidian\t1.5.12\n"},
+			expected: map[string]map[string]string{"flatpak": {"md.obsidian.Obsidian": "1.5.12"}},
+		},
+		{
+			name:     "no apps",
+			response: runnertest.Response{Output: "\n"},
+		},
+		{
+			name:     "listing fails",
+			response: runnertest.Response{Stderr: "error: No remote refs found\n", Err: errors.New("exit status 1")},
+			warnings: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &runnertest.Runner{Responses: map[string]runnertest.Response{list: tc.response}}
+			env := &types.EnvironmentData{}
+			detectFlatpakApps(context.Background(), env, r, runnertest.NewPath([]string{"/usr/bin"}, "/usr/bin/flatpak"))
+			if !reflect.DeepEqual(env.GlobalPackages, tc.expected) {
+				t.Errorf("expected global packages %v but got %v", tc.expected, env.GlobalPackages)
+			}
+			if len(env.Warnings) != tc.warnings {
+				t.Errorf("expected %d warnings but got %q", tc.warnings, env.Warnings)
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package scanner
+
+import (
+	"context"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/gitconfig"
+	"github.com/MRQ67/stackmatch-cli/pkg/redact"
+	"github.com/MRQ67/stackmatch-cli/pkg/runner"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+// DetectGitConfig records the URL rewrites, credential helpers and settings
+// such as init.defaultBranch and aliases of the user's global git config.
+// Credentials embedded in URLs, helper commands or settings are redacted;
+// stored credentials are never read.
+func DetectGitConfig(ctx context.Context, envData *types.EnvironmentData) {
+	detectGitConfig(ctx, envData, runner.Default, runner.DefaultPath)
+}
+
+func detectGitConfig(ctx context.Context, envData *types.EnvironmentData, r runner.Runner, path runner.PathIndex) {
+	if _, err := path.LookPath("git"); err != nil {
+		return
+	}
+	list, err := gitconfig.ReadGlobal(ctx, r)
+	if err != nil {
+		envData.Warnings = append(envData.Warnings, err.Error())
+		return
+	}
+	config := gitconfig.Parse(list)
+	if config == nil {
+		return
+	}
+	for i, rule := range config.URLRewrites {
+		config.URLRewrites[i].Base = redact.String(rule.Base)
+		config.URLRewrites[i].InsteadOf = redact.String(rule.InsteadOf)
+	}
+	for i, helper := range config.CredentialHelpers {
+		config.CredentialHelpers[i].URL = redact.String(helper.URL)
+		config.CredentialHelpers[i].Helper = redact.String(helper.Helper)
+	}
+	for key, value := range config.Settings {
+		config.Settings[key] = redact.String(value)
+	}
+	envData.GitConfig = config
+}
@@ -0,0 +1,105 @@
+package scanner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/runner"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+// npmBundled are the global packages that come with Node.js itself rather
+// than from 'npm install -g'
+var npmBundled = map[string]bool{
+	"npm":      true,
+	"corepack": true,
+}
+
+// DetectGlobalPackages records the packages installed globally with npm
+// under GlobalPackages["npm"]
+func DetectGlobalPackages(ctx context.Context, envData *types.EnvironmentData) {
+	detectGlobalPackages(ctx, envData, runner.Default, runner.DefaultPath)
+}
+
+func detectGlobalPackages(ctx context.Context, envData *types.EnvironmentData, r runner.Runner, path runner.PathIndex) {
+	if _, err := path.LookPath("npm"); err != nil {
+		return
+	}
+	// npm exits with 1 when the tree has problems such as a missing peer
+	// dependency, but still lists the packages
+	stdout, stderr, err := r.Output(ctx, "npm", "ls", "-g", "--depth=0", "--json")
+	if strings.TrimSpace(stdout) == "" {
+		if err != nil {
+			message := firstLine(stderr)
+			if message == "" {
+				message = err.Error()
+			}
+			envData.Warnings = append(envData.Warnings, "could not list the global npm packages: "+message)
+		}
+		return
+	}
+	packages, unpublished, err := parseNpmGlobals(stdout)
+	if err != nil {
+		envData.Warnings = append(envData.Warnings, fmt.Sprintf("could not read the global npm packages: %v", err))
+		return
+	}
+	if len(unpublished) > 0 {
+		envData.Warnings = append(envData.Warnings, "left out global npm packages not installed from the registry, which import would install from there: "+strings.Join(unpublished, ", "))
+	}
+	if len(packages) == 0 {
+		return
+	}
+	log.Printf("Found %d global npm packages", len(packages))
+	if envData.GlobalPackages == nil {
+		envData.GlobalPackages = make(map[string]map[string]string)
+	}
+	envData.GlobalPackages["npm"] = packages
+}
+
+// parseNpmGlobals returns the packages and versions in the output of
+// 'npm ls -g --json', leaving out those that come with Node.js and those
+// npm reports as missing. Packages linked or installed from a directory,
+// tarball or git repository are returned apart, with where they came from,
+// since the registry package of the same name may be someone else's.
+func parseNpmGlobals(output string) (map[string]string, []string, error) {
+	var tree struct {
+		Dependencies map[string]struct {
+			Version  string `json:"version"`
+			Resolved string `json:"resolved"`
+		} `json:"dependencies"`
+	}
+	if err := json.Unmarshal([]byte(output), &tree); err != nil {
+		return nil, nil, err
+	}
+	packages := make(map[string]string)
+	var unpublished []string
+	for name, dependency := range tree.Dependencies {
+		if npmBundled[name] || dependency.Version == "" {
+			continue
+		}
+		if source := npmSource(dependency.Resolved); source != "" {
+			unpublished = append(unpublished, fmt.Sprintf("%s (%s)", name, source))
+			continue
+		}
+		packages[name] = dependency.Version
+	}
+	sort.Strings(unpublished)
+	return packages, unpublished, nil
+}
+
+// npmSource describes where a package resolved from outside a registry came
+// from, or returns "" for registry packages
+func npmSource(resolved string) string {
+	switch {
+	case strings.HasPrefix(resolved, "file:"), strings.HasPrefix(resolved, "link:"):
+		return "local"
+	case strings.HasPrefix(resolved, "git"):
+		// git+ssh:, git+https:, git: and github: specs
+		return "git"
+	}
+	return ""
+}
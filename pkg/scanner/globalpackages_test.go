@@ -0,0 +1,111 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/runner/runnertest"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+func TestDetectGlobalPackages(t *testing.T) {
+	fixture := func(name string) string {
+		data, err := os.ReadFile(filepath.Join("testdata", "npm", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+
+	testCases := []struct {
+		name     string
+		response runnertest.Response
+		expected map[string]map[string]string
+		warning  string
+	}{
+		{
+			name:     "Packages",
+			response: runnertest.Response{Output: fixture("ls-global.json")},
+			expected: map[string]map[string]string{"npm": {
+				"eslint":      "8.57.0",
+				"serve":       "14.2.1",
+				"typescript":  "5.4.5",
+				"@vercel/ncc": "0.38.1",
+			}},
+		},
+		{
+			name:     "Local and git packages",
+			response: runnertest.Response{Output: fixture("ls-global-sources.json")},
+			expected: map[string]map[string]string{"npm": {"eslint": "8.57.0"}},
+			warning:  "left out global npm packages not installed from the registry, which import would install from there: internal-tool (git), my-cli (local), vendored (local)",
+		},
+		{
+			name: "Problems",
+			response: runnertest.Response{
+				Output: fixture("ls-global-problems.json"),
+				Stderr: "npm ERR! code ELSPROBLEMS\n",
+				Err:    errors.New("exit status 1"),
+			},
+			expected: map[string]map[string]string{"npm": {"@typescript-eslint/parser": "7.7.0"}},
+		},
+		{
+			name:     "No packages",
+			response: runnertest.Response{Output: `{"name": "lib"}`},
+		},
+		{
+			name:     "Empty output",
+			response: runnertest.Response{},
+		},
+		{
+			name: "Failed",
+			response: runnertest.Response{
+				Stderr: "npm ERR! code EACCES\nnpm ERR! syscall scandir\n",
+				Err:    errors.New("exit status 243"),
+			},
+			warning: "could not list the global npm packages: npm ERR! code EACCES",
+		},
+		{
+			name:     "Invalid JSON",
+			response: runnertest.Response{Output: "npm WARN config global `--global`, `--local` are deprecated\n"},
+			warning:  "could not read the global npm packages:",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &runnertest.Runner{Responses: map[string]runnertest.Response{"npm ls -g --depth=0 --json": tc.response}}
+			env := &types.EnvironmentData{}
+
+			detectGlobalPackages(context.Background(), env, r, runnertest.NewPath([]string{"/usr/bin"}, "/usr/bin/npm"))
+
+			if !reflect.DeepEqual(env.GlobalPackages, tc.expected) {
+				t.Errorf("expected global packages %v but got %v", tc.expected, env.GlobalPackages)
+			}
+			switch {
+			case tc.warning == "" && len(env.Warnings) != 0:
+				t.Errorf("expected no warnings but got %q", env.Warnings)
+			case tc.warning != "" && (len(env.Warnings) != 1 || !strings.HasPrefix(env.Warnings[0], tc.warning)):
+				t.Errorf("expected a warning starting with %q but got %q", tc.warning, env.Warnings)
+			}
+		})
+	}
+}
+
+func TestDetectGlobalPackagesWithoutNpm(t *testing.T) {
+	r := &runnertest.Runner{}
+	env := &types.EnvironmentData{}
+
+	detectGlobalPackages(context.Background(), env, r, runnertest.NewPath(nil))
+
+	if calls := r.Calls(); len(calls) != 0 {
+		t.Errorf("expected no commands without npm but got %v", calls)
+	}
+	if env.GlobalPackages != nil || len(env.Warnings) != 0 {
+		t.Errorf("expected nothing recorded without npm but got %v and %q", env.GlobalPackages, env.Warnings)
+	}
+}
@@ -0,0 +1,132 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/runner"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+// maxGoBinaries caps the executables of GOBIN read in one scan, so a
+// directory with hundreds of entries doesn't slow the scan down or bloat the
+// environment file
+var maxGoBinaries = 100
+
+// DetectGoBinaries records the programs installed with 'go install' under
+// GlobalPackages["go"], keyed by package path such as
+// "golang.org/x/tools/gopls" with the version of their module. Every
+// executable in GOBIN, or else the bin directory of the first GOPATH entry,
+// is read with 'go version -m'; those that aren't Go programs and those built
+// from a local checkout, which can't be installed by version, are left out.
+func DetectGoBinaries(ctx context.Context, envData *types.EnvironmentData) {
+	detectGoBinaries(ctx, envData, runner.Default, runner.DefaultPath)
+}
+
+func detectGoBinaries(ctx context.Context, envData *types.EnvironmentData, r runner.Runner, path runner.PathIndex) {
+	if _, err := path.LookPath("go"); err != nil {
+		return
+	}
+	dir, err := goBinDir(ctx, r)
+	if err != nil {
+		envData.Warnings = append(envData.Warnings, "could not find the Go bin directory: "+err.Error())
+		return
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		// Nothing was ever installed with 'go install'
+		return
+	}
+
+	var files []string
+	for _, entry := range entries {
+		file := filepath.Join(dir, entry.Name())
+		if !entry.IsDir() && path.IsExecutable(file) {
+			files = append(files, file)
+		}
+	}
+	if len(files) == 0 {
+		return
+	}
+	if len(files) > maxGoBinaries {
+		envData.Warnings = append(envData.Warnings, fmt.Sprintf("read only the first %d of the %d executables in %s", maxGoBinaries, len(files), dir))
+		files = files[:maxGoBinaries]
+	}
+
+	// go version exits with 1 when some files aren't Go programs, but still
+	// reports the others
+	stdout, _, _ := r.Output(ctx, "go", append([]string{"version", "-m"}, files...)...)
+	packages := parseGoVersionM(stdout)
+	if len(packages) == 0 {
+		return
+	}
+	log.Printf("Found %d programs installed with go install", len(packages))
+	if envData.GlobalPackages == nil {
+		envData.GlobalPackages = make(map[string]map[string]string)
+	}
+	envData.GlobalPackages["go"] = packages
+}
+
+// goBinDir returns the directory 'go install' puts programs in: GOBIN, or
+// else the bin directory of the first GOPATH entry
+func goBinDir(ctx context.Context, r runner.Runner) (string, error) {
+	stdout, stderr, err := r.Output(ctx, "go", "env", "GOBIN", "GOPATH")
+	if err != nil {
+		if message := firstLine(stderr); message != "" {
+			return "", errors.New(message)
+		}
+		return "", err
+	}
+	lines := strings.Split(strings.ReplaceAll(stdout, "\r\n", "\n"), "\n")
+	if gobin := strings.TrimSpace(lines[0]); gobin != "" {
+		return gobin, nil
+	}
+	if len(lines) > 1 {
+		if gopath := filepath.SplitList(strings.TrimSpace(lines[1])); len(gopath) > 0 && gopath[0] != "" {
+			return filepath.Join(gopath[0], "bin"), nil
+		}
+	}
+	return "", fmt.Errorf("go env reports neither GOBIN nor GOPATH")
+}
+
+// parseGoVersionM returns the package path and module version of every
+// program in the output of 'go version -m', such as
+//
+//	/home/me/go/bin/gopls: go1.22.1
+//		path	golang.org/x/tools/gopls
+//		mod	golang.org/x/tools/gopls	v0.15.2	h1:...
+//
+// Programs built from a local checkout, whose version is "(devel)" or marks
+// uncommitted changes, are left out.
+func parseGoVersionM(output string) map[string]string {
+	packages := make(map[string]string)
+	var pkg string
+	for _, line := range strings.Split(strings.ReplaceAll(output, "\r\n", "\n"), "\n") {
+		if !strings.HasPrefix(line, "\t") {
+			// A new program starts
+			pkg = ""
+			continue
+		}
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) >= 2 && fields[0] == "path":
+			pkg = fields[1]
+		case len(fields) >= 3 && fields[0] == "mod" && pkg != "":
+			version := fields[2]
+			if version == "(devel)" || strings.HasSuffix(version, "+dirty") {
+				log.Printf("Skipping %s, built from a local checkout", pkg)
+				continue
+			}
+			packages[pkg] = version
+		}
+	}
+	if len(packages) == 0 {
+		return nil
+	}
+	return packages
+}
@@ -0,0 +1,123 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/runner/runnertest"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+func TestDetectGoBinaries(t *testing.T) {
+	gopath := t.TempDir()
+	bin := filepath.Join(gopath, "bin")
+	if err := os.MkdirAll(filepath.Join(bin, "cache"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"air", "dlv", "golangci-lint", "gopls", "mytool", "notes.txt", "sync.sh"} {
+		if err := os.WriteFile(filepath.Join(bin, name), nil, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	executables := []string{"/usr/local/go/bin/go"}
+	for _, name := range []string{"air", "dlv", "golangci-lint", "gopls", "mytool", "sync.sh"} {
+		executables = append(executables, filepath.Join(bin, name))
+	}
+	path := runnertest.NewPath([]string{"/usr/local/go/bin"}, executables...)
+
+	// sync.sh isn't a Go program and mytool was built from a checkout
+	versionM := strings.Join([]string{
+		filepath.Join(bin, "air") + ": go1.22.1",
+		"\tpath\tgithub.com/cosmtrek/air",
+		"\tmod\tgithub.com/cosmtrek/air\tv1.51.0\th1:abc=",
+		"\tdep\tgithub.com/fatih/color\tv1.16.0\th1:def=",
+		"\tbuild\t-compiler=gc",
+		filepath.Join(bin, "dlv") + ": go1.22.1",
+		"\tpath\tgithub.com/go-delve/delve/cmd/dlv",
+		"\tmod\tgithub.com/go-delve/delve\tv1.22.1\th1:ghi=",
+		filepath.Join(bin, "golangci-lint") + ": go1.22.1",
+		"\tpath\tgithub.com/golangci/golangci-lint/cmd/golangci-lint",
+		"\tmod\tgithub.com/golangci/golangci-lint\tv1.57.2\th1:jkl=",
+		filepath.Join(bin, "gopls") + ": go1.22.1",
+		"\tpath\tgolang.org/x/tools/gopls",
+		"\tmod\tgolang.org/x/tools/gopls\tv0.15.2\th1:mno=",
+		filepath.Join(bin, "mytool") + ": go1.22.1",
+		"\tpath\texample.com/mytool",
+		"\tmod\texample.com/mytool\t(devel)\t",
+		"",
+	}, "\n")
+	r := &runnertest.Runner{Responses: map[string]runnertest.Response{
+		"go env GOBIN GOPATH": {Output: "\n" + gopath + "\n"},
+		"go version -m " + strings.Join(executables[1:], " "): {
+			Output: versionM,
+			Stderr: filepath.Join(bin, "sync.sh") + ": could not read Go build info from " + filepath.Join(bin, "sync.sh") + ": unrecognized file format\n",
+			Err:    errors.New("exit status 1"),
+		},
+	}}
+	env := &types.EnvironmentData{}
+
+	detectGoBinaries(context.Background(), env, r, path)
+
+	expected := map[string]map[string]string{"go": {
+		"github.com/cosmtrek/air":                             "v1.51.0",
+		"github.com/go-delve/delve/cmd/dlv":                   "v1.22.1",
+		"github.com/golangci/golangci-lint/cmd/golangci-lint": "v1.57.2",
+		"golang.org/x/tools/gopls":                            "v0.15.2",
+	}}
+	if !reflect.DeepEqual(env.GlobalPackages, expected) {
+		t.Errorf("expected global packages %v but got %v", expected, env.GlobalPackages)
+	}
+	if len(env.Warnings) != 0 {
+		t.Errorf("expected no warnings but got %q", env.Warnings)
+	}
+}
+
+func TestDetectGoBinariesCapsLargeDirectories(t *testing.T) {
+	gobin := t.TempDir()
+	path := runnertest.NewPath([]string{"/usr/bin"}, "/usr/bin/go")
+	for _, name := range []string{"a", "b", "c"} {
+		file := filepath.Join(gobin, name)
+		if err := os.WriteFile(file, nil, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		path.Executables[file] = true
+	}
+	defer func(max int) { maxGoBinaries = max }(maxGoBinaries)
+	maxGoBinaries = 2
+
+	r := &runnertest.Runner{Responses: map[string]runnertest.Response{
+		"go env GOBIN GOPATH": {Output: gobin + "\n/home/me/go\n"},
+		"go version -m " + filepath.Join(gobin, "a") + " " + filepath.Join(gobin, "b"): {
+			Output: filepath.Join(gobin, "a") + ": go1.22.1\n\tpath\texample.com/a\n\tmod\texample.com/a\tv1.0.0\t\n",
+		},
+	}}
+	env := &types.EnvironmentData{}
+
+	detectGoBinaries(context.Background(), env, r, path)
+
+	if expected := map[string]string{"example.com/a": "v1.0.0"}; !reflect.DeepEqual(env.GlobalPackages["go"], expected) {
+		t.Errorf("expected go packages %v but got %v", expected, env.GlobalPackages["go"])
+	}
+	if len(env.Warnings) != 1 || !strings.HasPrefix(env.Warnings[0], "read only the first 2 of the 3 executables in ") {
+		t.Errorf("expected a warning about the cap but got %q", env.Warnings)
+	}
+}
+
+func TestDetectGoBinariesWithoutGo(t *testing.T) {
+	r := &runnertest.Runner{}
+	env := &types.EnvironmentData{}
+
+	detectGoBinaries(context.Background(), env, r, runnertest.NewPath(nil))
+
+	if calls := r.Calls(); len(calls) != 0 {
+		t.Errorf("expected no commands without go but got %v", calls)
+	}
+	if env.GlobalPackages != nil || len(env.Warnings) != 0 {
+		t.Errorf("expected nothing recorded without go but got %v and %q", env.GlobalPackages, env.Warnings)
+	}
+}
@@ -0,0 +1,301 @@
+package scanner
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/runner"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+// gpuTimeout bounds each command asking about the GPUs, since nvidia-smi
+// hangs when the driver is stuck
+const gpuTimeout = 10 * time.Second
+
+// gpuLocations are where the GPU compute stack is looked for besides PATH
+type gpuLocations struct {
+	// nvcc are the nvcc executables tried when PATH has none, such as the
+	// one of /usr/local/cuda
+	nvcc []string
+	// cudnnHeaders are the directories searched for cudnn_version.h or,
+	// before cuDNN 8, cudnn.h
+	cudnnHeaders []string
+	// rocmVersion is the file holding the version of the ROCm installation
+	rocmVersion string
+}
+
+// defaultGPULocations returns the standard CUDA and ROCm install
+// locations, and those named by $CUDA_PATH and $ROCM_PATH
+func defaultGPULocations() gpuLocations {
+	locations := gpuLocations{
+		nvcc:         []string{"/usr/local/cuda/bin/nvcc"},
+		cudnnHeaders: []string{"/usr/include", "/usr/include/x86_64-linux-gnu", "/usr/include/aarch64-linux-gnu", "/usr/local/cuda/include"},
+		rocmVersion:  "/opt/rocm/.info/version",
+	}
+	if cuda := os.Getenv("CUDA_PATH"); cuda != "" {
+		locations.nvcc = append([]string{filepath.Join(cuda, "bin", "nvcc")}, locations.nvcc...)
+		locations.cudnnHeaders = append([]string{filepath.Join(cuda, "include")}, locations.cudnnHeaders...)
+	}
+	if rocm := os.Getenv("ROCM_PATH"); rocm != "" {
+		locations.rocmVersion = filepath.Join(rocm, ".info", "version")
+	}
+	return locations
+}
+
+// DetectGPU records the NVIDIA driver and the CUDA version it supports, the
+// CUDA toolkit, cuDNN, ROCm and the names of the GPUs. Machines with none
+// of them get no GPU section.
+func DetectGPU(ctx context.Context, envData *types.EnvironmentData) {
+	detectGPU(ctx, envData, runner.Default, runner.DefaultPath, defaultGPULocations())
+}
+
+func detectGPU(ctx context.Context, envData *types.EnvironmentData, r runner.Runner, path runner.PathIndex, locations gpuLocations) {
+	gpu := &types.GPU{}
+
+	if _, err := path.LookPath("nvidia-smi"); err == nil {
+		if stdout, err := gpuCommand(ctx, r, "nvidia-smi"); err != nil {
+			// Installed without a GPU, or with the driver not loaded
+			log.Printf("Warning: %v", err)
+		} else {
+			gpu.DriverVersion, gpu.CUDAVersion = ParseNvidiaSmiHeader(stdout)
+			if names, err := gpuCommand(ctx, r, "nvidia-smi", "--query-gpu=name", "--format=csv,noheader"); err == nil {
+				gpu.Devices = append(gpu.Devices, nonEmptyLines(names)...)
+			}
+		}
+	}
+
+	if nvcc := findExecutable(path, "nvcc", locations.nvcc); nvcc != "" {
+		if stdout, err := gpuCommand(ctx, r, nvcc, "--version"); err == nil {
+			gpu.CUDAToolkit = ParseNvccVersion(stdout)
+		}
+	}
+	gpu.CuDNN = cudnnVersion(locations.cudnnHeaders)
+
+	if _, err := path.LookPath("rocminfo"); err == nil {
+		if stdout, err := gpuCommand(ctx, r, "rocminfo"); err == nil {
+			gpu.Devices = append(gpu.Devices, ParseRocminfo(stdout)...)
+		} else {
+			log.Printf("Warning: %v", err)
+		}
+	}
+	if data, err := os.ReadFile(locations.rocmVersion); err == nil {
+		gpu.ROCmVersion = ParseROCmVersion(string(data))
+	}
+
+	if gpu.Empty() {
+		return
+	}
+	envData.GPU = gpu
+	log.Printf("Found GPU stack: driver %s, CUDA %s, toolkit %s, %d device(s)", orNone(gpu.DriverVersion), orNone(gpu.CUDAVersion), orNone(gpu.CUDAToolkit), len(gpu.Devices))
+}
+
+// gpuCommand runs name with args within gpuTimeout and returns its stdout
+func gpuCommand(ctx context.Context, r runner.Runner, name string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, gpuTimeout)
+	defer cancel()
+	stdout, stderr, err := r.Output(ctx, name, args...)
+	switch {
+	case err == nil:
+		return stdout, nil
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return "", fmt.Errorf("%s did not answer within %s", filepath.Base(name), gpuTimeout)
+	}
+	message := firstLine(stderr, stdout)
+	if message == "" {
+		message = err.Error()
+	}
+	return "", fmt.Errorf("%s failed: %s", filepath.Base(name), message)
+}
+
+// findExecutable returns name as PATH finds it, or the first of candidates
+// that is an executable, or "" when there is none
+func findExecutable(path runner.PathIndex, name string, candidates []string) string {
+	if _, err := path.LookPath(name); err == nil {
+		return name
+	}
+	for _, candidate := range candidates {
+		if path.IsExecutable(candidate) {
+			return candidate
+		}
+	}
+	return ""
+}
+
+var (
+	// nvidiaSmiDriver matches the driver version in the header nvidia-smi
+	// prints above its table, such as "Driver Version: 535.104.05"
+	nvidiaSmiDriver = regexp.MustCompile(`Driver Version:\s*([0-9][0-9.]*)`)
+	// nvidiaSmiCUDA matches the CUDA version in the same header, which
+	// drivers before 410 don't print and some print as "N/A"
+	nvidiaSmiCUDA = regexp.MustCompile(`CUDA Version:\s*([0-9][0-9.]*)`)
+)
+
+// ParseNvidiaSmiHeader returns the driver version and the newest CUDA
+// version the driver supports from the header of nvidia-smi's output. The
+// spacing and the fields around them change between driver releases, so
+// each is matched by its label alone. Either is "" when missing.
+func ParseNvidiaSmiHeader(output string) (driver, cuda string) {
+	for _, line := range strings.Split(output, "\n") {
+		if driver == "" {
+			if m := nvidiaSmiDriver.FindStringSubmatch(line); m != nil {
+				driver = m[1]
+			}
+		}
+		if cuda == "" {
+			if m := nvidiaSmiCUDA.FindStringSubmatch(line); m != nil {
+				cuda = m[1]
+			}
+		}
+		if driver != "" && cuda != "" {
+			break
+		}
+	}
+	return driver, cuda
+}
+
+var (
+	// nvccBuild matches the full toolkit version nvcc prints, such as
+	// "V12.2.140"
+	nvccBuild = regexp.MustCompile(`\bV([0-9]+\.[0-9]+(?:\.[0-9]+)*)`)
+	// nvccRelease matches the release nvcc prints, such as "release 12.2"
+	nvccRelease = regexp.MustCompile(`release ([0-9]+\.[0-9]+)`)
+)
+
+// ParseNvccVersion returns the CUDA toolkit version from the output of
+// 'nvcc --version': the full one, such as "12.2.140", or the release when
+// the full one is missing
+func ParseNvccVersion(output string) string {
+	if m := nvccBuild.FindStringSubmatch(output); m != nil {
+		return m[1]
+	}
+	if m := nvccRelease.FindStringSubmatch(output); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// cudnnDefine matches a version macro of the cuDNN headers, such as
+// "#define CUDNN_MAJOR 8"
+var cudnnDefine = regexp.MustCompile(`^#define\s+CUDNN_(MAJOR|MINOR|PATCHLEVEL)\s+([0-9]+)`)
+
+// cudnnVersion returns the version of the first cuDNN headers found in
+// dirs, "Installed" when their version can't be read, or "" when there are
+// none
+func cudnnVersion(dirs []string) string {
+	for _, dir := range dirs {
+		for _, name := range []string{"cudnn_version.h", "cudnn.h"} {
+			data, err := os.ReadFile(filepath.Join(dir, name))
+			if err != nil {
+				continue
+			}
+			if version := ParseCuDNNHeader(string(data)); version != "" {
+				return version
+			}
+			if name == "cudnn.h" {
+				return "Installed"
+			}
+		}
+	}
+	return ""
+}
+
+// ParseCuDNNHeader returns the version the CUDNN_MAJOR, CUDNN_MINOR and
+// CUDNN_PATCHLEVEL macros of a cuDNN header define, such as "8.9.7", or ""
+// when it defines no CUDNN_MAJOR
+func ParseCuDNNHeader(content string) string {
+	parts := make(map[string]string)
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		if m := cudnnDefine.FindStringSubmatch(strings.TrimSpace(scanner.Text())); m != nil {
+			parts[m[1]] = m[2]
+		}
+	}
+	if parts["MAJOR"] == "" {
+		return ""
+	}
+	version := parts["MAJOR"]
+	for _, part := range []string{"MINOR", "PATCHLEVEL"} {
+		if parts[part] == "" {
+			break
+		}
+		version += "." + parts[part]
+	}
+	return version
+}
+
+// ParseRocminfo returns the marketing names of the GPU agents rocminfo
+// lists, in order. CPU agents are skipped; agents without a marketing name
+// are listed by their name, such as "gfx1030".
+func ParseRocminfo(output string) []string {
+	var devices []string
+	var name, marketing, deviceType string
+	flush := func() {
+		if deviceType == "GPU" {
+			if marketing == "" {
+				marketing = name
+			}
+			if marketing != "" {
+				devices = append(devices, marketing)
+			}
+		}
+		name, marketing, deviceType = "", "", ""
+	}
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "Agent ") {
+			flush()
+			continue
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch strings.TrimSpace(key) {
+		case "Name":
+			if name == "" {
+				name = value
+			}
+		case "Marketing Name":
+			marketing = value
+		case "Device Type":
+			deviceType = value
+		}
+	}
+	flush()
+	return devices
+}
+
+// ParseROCmVersion returns the version in ROCm's .info/version file, such
+// as "6.0.2" for "6.0.2-115"
+func ParseROCmVersion(content string) string {
+	version, _, _ := strings.Cut(strings.TrimSpace(content), "-")
+	return version
+}
+
+// nonEmptyLines returns the trimmed lines of output that are not empty
+func nonEmptyLines(output string) []string {
+	var lines []string
+	for _, line := range strings.Split(output, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// orNone returns s, or "none" when it is empty
+func orNone(s string) string {
+	if s == "" {
+		return "none"
+	}
+	return s
+}
@@ -0,0 +1,162 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/runner/runnertest"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+func TestParseNvidiaSmiHeader(t *testing.T) {
+	testCases := []struct {
+		name   string
+		output string
+		driver string
+		cuda   string
+	}{
+		{name: "Driver 390, before CUDA was printed", output: readFixture(t, "gpu", "nvidia-smi-390.txt"), driver: "390.144"},
+		{name: "Driver 470", output: readFixture(t, "gpu", "nvidia-smi-470.txt"), driver: "470.82.01", cuda: "11.4"},
+		{name: "Driver 535, wider table", output: readFixture(t, "gpu", "nvidia-smi-535.txt"), driver: "535.104.05", cuda: "12.2"},
+		{name: "Driver 572 on Windows", output: readFixture(t, "gpu", "nvidia-smi-572-windows.txt"), driver: "572.16", cuda: "12.8"},
+		{name: "CUDA not available", output: "| NVIDIA-SMI 525.60.13    Driver Version: 525.60.13    CUDA Version: N/A      |\n", driver: "525.60.13"},
+		{name: "No header", output: "NVIDIA-SMI has failed because it couldn't communicate with the NVIDIA driver.\n"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			driver, cuda := ParseNvidiaSmiHeader(tc.output)
+			if driver != tc.driver || cuda != tc.cuda {
+				t.Errorf("expected driver %q and CUDA %q but got %q and %q", tc.driver, tc.cuda, driver, cuda)
+			}
+		})
+	}
+}
+
+func TestParseNvccVersion(t *testing.T) {
+	testCases := map[string]string{
+		readFixture(t, "gpu", "nvcc.txt"):                        "12.2.140",
+		"Cuda compilation tools, release 9.0, V9.0.176\n":        "9.0.176",
+		"Cuda compilation tools, release 11.8\n":                 "11.8",
+		"nvcc: NVIDIA (R) Cuda compiler driver\nno version here": "",
+	}
+	for output, expected := range testCases {
+		if actual := ParseNvccVersion(output); actual != expected {
+			t.Errorf("expected %q but got %q for:\n%s", expected, actual, output)
+		}
+	}
+}
+
+func TestParseCuDNNHeader(t *testing.T) {
+	testCases := map[string]string{
+		readFixture(t, "gpu", "cudnn_version.h"):               "8.9.7",
+		"#define CUDNN_MAJOR 7\n#define CUDNN_MINOR 6\n":       "7.6",
+		"#define CUDNN_VERSION_H_\n#include \"cudnn_ops.h\"\n": "",
+	}
+	for content, expected := range testCases {
+		if actual := ParseCuDNNHeader(content); actual != expected {
+			t.Errorf("expected %q but got %q for:\n%s", expected, actual, content)
+		}
+	}
+}
+
+func TestParseRocminfo(t *testing.T) {
+	expected := []string{"AMD Radeon RX 6800 XT", "gfx90a"}
+	if actual := ParseRocminfo(readFixture(t, "gpu", "rocminfo.txt")); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v but got %v", expected, actual)
+	}
+}
+
+func TestDetectGPU(t *testing.T) {
+	headers := t.TempDir()
+	if err := os.WriteFile(filepath.Join(headers, "cudnn_version.h"), []byte(readFixture(t, "gpu", "cudnn_version.h")), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	rocm := filepath.Join(t.TempDir(), "version")
+	if err := os.WriteFile(rocm, []byte("6.0.2-115\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	nvidia := map[string]runnertest.Response{
+		"nvidia-smi": {Output: readFixture(t, "gpu", "nvidia-smi-535.txt")},
+		"nvidia-smi --query-gpu=name --format=csv,noheader": {Output: "NVIDIA GeForce RTX 3090\nNVIDIA GeForce RTX 3090\n"},
+	}
+
+	testCases := []struct {
+		name      string
+		responses map[string]runnertest.Response
+		path      []string
+		locations gpuLocations
+		expected  *types.GPU
+	}{
+		{
+			name: "NVIDIA with the toolkit on PATH",
+			responses: mergeResponses(nvidia, map[string]runnertest.Response{
+				"nvcc --version": {Output: readFixture(t, "gpu", "nvcc.txt")},
+			}),
+			path:      []string{"/usr/bin/nvidia-smi", "/usr/bin/nvcc"},
+			locations: gpuLocations{cudnnHeaders: []string{t.TempDir(), headers}},
+			expected: &types.GPU{
+				DriverVersion: "535.104.05",
+				CUDAVersion:   "12.2",
+				CUDAToolkit:   "12.2.140",
+				CuDNN:         "8.9.7",
+				Devices:       []string{"NVIDIA GeForce RTX 3090", "NVIDIA GeForce RTX 3090"},
+			},
+		},
+		{
+			name: "Toolkit outside PATH",
+			responses: map[string]runnertest.Response{
+				"/usr/local/cuda/bin/nvcc --version": {Output: readFixture(t, "gpu", "nvcc.txt")},
+			},
+			path:      []string{"/usr/local/cuda/bin/nvcc"},
+			locations: gpuLocations{nvcc: []string{"/opt/cuda/bin/nvcc", "/usr/local/cuda/bin/nvcc"}},
+			expected:  &types.GPU{CUDAToolkit: "12.2.140"},
+		},
+		{
+			name: "Driver not loaded",
+			responses: map[string]runnertest.Response{
+				"nvidia-smi": {Output: "NVIDIA-SMI has failed because it couldn't communicate with the NVIDIA driver.\n", Err: errors.New("exit status 9")},
+			},
+			path: []string{"/usr/bin/nvidia-smi"},
+		},
+		{
+			name: "ROCm",
+			responses: map[string]runnertest.Response{
+				"rocminfo": {Output: readFixture(t, "gpu", "rocminfo.txt")},
+			},
+			path:      []string{"/usr/bin/rocminfo"},
+			locations: gpuLocations{rocmVersion: rocm},
+			expected:  &types.GPU{ROCmVersion: "6.0.2", Devices: []string{"AMD Radeon RX 6800 XT", "gfx90a"}},
+		},
+		{
+			name:      "No GPU",
+			locations: gpuLocations{nvcc: []string{"/usr/local/cuda/bin/nvcc"}, cudnnHeaders: []string{t.TempDir()}, rocmVersion: filepath.Join(t.TempDir(), "version")},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &runnertest.Runner{Responses: tc.responses}
+			env := &types.EnvironmentData{}
+			detectGPU(context.Background(), env, r, runnertest.NewPath([]string{"/usr/bin"}, tc.path...), tc.locations)
+			if !reflect.DeepEqual(env.GPU, tc.expected) {
+				t.Errorf("expected %+v but got %+v", tc.expected, env.GPU)
+			}
+		})
+	}
+}
+
+// mergeResponses returns the responses of both maps
+func mergeResponses(a, b map[string]runnertest.Response) map[string]runnertest.Response {
+	merged := make(map[string]runnertest.Response, len(a)+len(b))
+	for _, m := range []map[string]runnertest.Response{a, b} {
+		for line, response := range m {
+			merged[line] = response
+		}
+	}
+	return merged
+}
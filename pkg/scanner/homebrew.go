@@ -0,0 +1,22 @@
+package scanner
+
+import (
+	"context"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/installer/package_managers"
+	"github.com/MRQ67/stackmatch-cli/pkg/runner"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+// DetectHomebrew records every Homebrew installation and adds a warning when
+// more than one exists, since installs and checks may then target different prefixes.
+func DetectHomebrew(ctx context.Context, envData *types.EnvironmentData) {
+	detectHomebrew(ctx, envData, runner.Default, runner.DefaultPath)
+}
+
+func detectHomebrew(ctx context.Context, envData *types.EnvironmentData, r runner.Runner, path runner.PathIndex) {
+	envData.Homebrew = package_managers.DetectHomebrewInstalls(ctx, r, path)
+	if warning := package_managers.HomebrewWarning(envData.Homebrew); warning != "" {
+		envData.Warnings = append(envData.Warnings, warning)
+	}
+}
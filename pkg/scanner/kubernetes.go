@@ -0,0 +1,150 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+	"gopkg.in/yaml.v3"
+)
+
+// kubeconfig holds the parts of a kubeconfig file that are recorded. Users,
+// which hold the credentials, client certificates, tokens and exec plugins,
+// are deliberately left out so they are never even decoded.
+type kubeconfig struct {
+	CurrentContext string `yaml:"current-context"`
+	Clusters       []struct {
+		Name    string `yaml:"name"`
+		Cluster struct {
+			Server string `yaml:"server"`
+		} `yaml:"cluster"`
+	} `yaml:"clusters"`
+	Contexts []struct {
+		Name    string `yaml:"name"`
+		Context struct {
+			Cluster   string `yaml:"cluster"`
+			Namespace string `yaml:"namespace"`
+		} `yaml:"context"`
+	} `yaml:"contexts"`
+}
+
+// DetectKubernetes records the kubectl contexts, the hostnames of the API
+// servers of their clusters and the current context of the kubeconfig:
+// the files listed in $KUBECONFIG, merged the way kubectl merges them, or
+// ~/.kube/config
+func DetectKubernetes(ctx context.Context, envData *types.EnvironmentData) {
+	detectKubernetes(envData, kubeconfigPaths(os.Getenv("KUBECONFIG")))
+}
+
+// kubeconfigPaths returns the kubeconfig files kubectl reads: those of
+// list, a $KUBECONFIG value, without empty entries and repeats, or
+// ~/.kube/config when list is empty
+func kubeconfigPaths(list string) []string {
+	var paths []string
+	for _, path := range filepath.SplitList(list) {
+		if path != "" && !slices.Contains(paths, path) {
+			paths = append(paths, path)
+		}
+	}
+	if len(paths) == 0 {
+		home, _ := os.UserHomeDir()
+		paths = append(paths, filepath.Join(home, ".kube", "config"))
+	}
+	return paths
+}
+
+func detectKubernetes(envData *types.EnvironmentData, paths []string) {
+	var configs []kubeconfig
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			// kubectl skips missing files of $KUBECONFIG too
+			continue
+		}
+		if err != nil {
+			envData.Warnings = append(envData.Warnings, fmt.Sprintf("could not read kubeconfig: %v", err))
+			continue
+		}
+		var config kubeconfig
+		if err := yaml.Unmarshal(data, &config); err != nil {
+			envData.Warnings = append(envData.Warnings, fmt.Sprintf("could not parse kubeconfig %s: %v", path, err))
+			continue
+		}
+		configs = append(configs, config)
+	}
+
+	kubernetes := mergeKubeconfigs(configs)
+	if kubernetes == nil {
+		return
+	}
+	log.Printf("Found %d kubectl contexts", len(kubernetes.Contexts))
+	envData.Kubernetes = kubernetes
+}
+
+// mergeKubeconfigs merges kubeconfig files in the order kubectl reads them:
+// the first file to define a context or cluster, and the first to set the
+// current context, wins. Nil when they define no context.
+func mergeKubeconfigs(configs []kubeconfig) *types.Kubernetes {
+	kubernetes := &types.Kubernetes{}
+	servers := make(map[string]string)
+	seen := make(map[string]bool)
+	for _, config := range configs {
+		if kubernetes.CurrentContext == "" {
+			kubernetes.CurrentContext = config.CurrentContext
+		}
+		for _, cluster := range config.Clusters {
+			if _, ok := servers[cluster.Name]; !ok {
+				servers[cluster.Name] = ServerHostname(cluster.Cluster.Server)
+			}
+		}
+		for _, kubeContext := range config.Contexts {
+			if kubeContext.Name == "" || seen[kubeContext.Name] {
+				continue
+			}
+			seen[kubeContext.Name] = true
+			kubernetes.Contexts = append(kubernetes.Contexts, types.KubeContext{
+				Name:      kubeContext.Name,
+				Cluster:   kubeContext.Context.Cluster,
+				Namespace: kubeContext.Context.Namespace,
+			})
+		}
+	}
+	if len(kubernetes.Contexts) == 0 && kubernetes.CurrentContext == "" {
+		return nil
+	}
+
+	// Clusters may be defined in a later file than the contexts using them
+	for i, kubeContext := range kubernetes.Contexts {
+		kubernetes.Contexts[i].Server = servers[kubeContext.Cluster]
+	}
+	slices.SortFunc(kubernetes.Contexts, func(a, b types.KubeContext) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return kubernetes
+}
+
+// ServerHostname returns the hostname of server, the URL of a cluster's
+// API server such as "https://dev.k8s.example.com:6443/base", leaving out
+// the scheme, credentials, port and path. A server without a scheme, such
+// as "localhost:8080", is taken as host and port the way kubectl takes it.
+func ServerHostname(server string) string {
+	server = strings.TrimSpace(server)
+	if server == "" {
+		return ""
+	}
+	if !strings.Contains(server, "://") {
+		server = "https://" + server
+	}
+	u, err := url.Parse(server)
+	if err != nil {
+		return ""
+	}
+	return u.Hostname()
+}
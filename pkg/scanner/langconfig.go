@@ -0,0 +1,30 @@
+package scanner
+
+import (
+	"context"
+	"os"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/langconfig"
+	"github.com/MRQ67/stackmatch-cli/pkg/redact"
+	"github.com/MRQ67/stackmatch-cli/pkg/runner"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+// DetectLanguageConfig records the toolchain settings that decide where
+// packages are installed and fetched from: GOPATH and friends from go env,
+// the npm prefix and pip's config. Credentials in the values are redacted.
+func DetectLanguageConfig(ctx context.Context, envData *types.EnvironmentData) {
+	home, _ := os.UserHomeDir()
+	detectLanguageConfig(ctx, envData, runner.Default, runner.DefaultPath, home)
+}
+
+func detectLanguageConfig(ctx context.Context, envData *types.EnvironmentData, r runner.Runner, path runner.PathIndex, home string) {
+	config, warnings := langconfig.Read(ctx, r, path, home)
+	envData.Warnings = append(envData.Warnings, warnings...)
+	for _, values := range config {
+		for key, value := range values {
+			values[key] = redact.String(value)
+		}
+	}
+	envData.LanguageConfig = config
+}
@@ -0,0 +1,126 @@
+package scanner
+
+import (
+	"context"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/runner"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+// sideBySideLanguage is a language commonly installed in several versions at
+// once, each under its own versioned command such as python3.11
+type sideBySideLanguage struct {
+	// Name is the key recorded in LanguageVersions
+	Name string
+	// Entries are the ConfiguredLanguages entries whose version counts as
+	// found even when probing the commands fails
+	Entries []string
+	// Commands are the command names probed, generic ones first. Every
+	// match on PATH is run, not only the first.
+	Commands     []string
+	VersionRegex *regexp.Regexp
+}
+
+// sideBySideLanguages are the languages DetectLanguageVersions looks for
+var sideBySideLanguages = []sideBySideLanguage{
+	{
+		Name:    "Python",
+		Entries: []string{"Python", "Python 3"},
+		Commands: []string{
+			"python3", "python", "python2.7",
+			"python3.6", "python3.7", "python3.8", "python3.9", "python3.10",
+			"python3.11", "python3.12", "python3.13", "python3.14",
+		},
+		VersionRegex: regexp.MustCompile(`Python ([\d\.]+)`),
+	},
+	{
+		Name:         "Ruby",
+		Entries:      []string{"Ruby"},
+		Commands:     []string{"ruby", "ruby2.7", "ruby3.0", "ruby3.1", "ruby3.2", "ruby3.3", "ruby3.4"},
+		VersionRegex: regexp.MustCompile(`ruby ([\d\.]+)`),
+	},
+	{
+		Name:         "Node.js",
+		Entries:      []string{"Node.js"},
+		Commands:     []string{"node", "nodejs"},
+		VersionRegex: regexp.MustCompile(`v?([\d\.]+)`),
+	},
+}
+
+// DetectLanguageVersions records every version of Python, Ruby and Node.js
+// installed side by side, oldest first. Every copy of their commands on PATH
+// is run, along with versioned ones such as python3.11, and the Node.js
+// versions installed by nvm ($NVM_DIR or ~/.nvm) are added. Only languages
+// found in more than one version are recorded; ConfiguredLanguages keeps
+// the version that runs first.
+func DetectLanguageVersions(ctx context.Context, envData *types.EnvironmentData) {
+	nvmDir := os.Getenv("NVM_DIR")
+	if nvmDir == "" {
+		home, _ := os.UserHomeDir()
+		nvmDir = filepath.Join(home, ".nvm")
+	}
+	detectLanguageVersions(ctx, envData, runner.Default, runner.DefaultPath, nvmDir)
+}
+
+func detectLanguageVersions(ctx context.Context, envData *types.EnvironmentData, r runner.Runner, path runner.PathIndex, nvmDir string) {
+	for _, language := range sideBySideLanguages {
+		var versions []string
+		add := func(v string) {
+			if v != "" && v != "Installed" && !slices.Contains(versions, v) {
+				versions = append(versions, v)
+			}
+		}
+		for _, entry := range language.Entries {
+			add(envData.ConfiguredLanguages[entry])
+		}
+
+		probed := make(map[string]bool)
+		for _, command := range language.Commands {
+			for _, file := range path.LookPathAll(command) {
+				if ctx.Err() != nil {
+					return
+				}
+				// python3 is usually a link to one of the versioned commands
+				target := file
+				if resolved, err := filepath.EvalSymlinks(file); err == nil {
+					target = resolved
+				}
+				if probed[target] {
+					continue
+				}
+				probed[target] = true
+				// Python 2 prints its version to stderr
+				stdout, stderr, err := r.Output(ctx, file, "--version")
+				if err != nil {
+					continue
+				}
+				if match := language.VersionRegex.FindStringSubmatch(stdout + stderr); match != nil {
+					add(match[1])
+				}
+			}
+		}
+
+		// nvm installs node versions as versions/node/v20.11.0
+		if language.Name == "Node.js" {
+			names, _ := listDirs(filepath.Join(nvmDir, "versions", "node"))
+			for _, name := range names {
+				add(strings.TrimPrefix(name, "v"))
+			}
+		}
+
+		if len(versions) < 2 {
+			continue
+		}
+		log.Printf("Found %s installed side by side in versions %s", language.Name, strings.Join(versions, ", "))
+		if envData.LanguageVersions == nil {
+			envData.LanguageVersions = make(map[string][]string)
+		}
+		envData.LanguageVersions[language.Name] = sortVersions(versions)
+	}
+}
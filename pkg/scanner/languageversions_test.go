@@ -0,0 +1,80 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/runner/runnertest"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+func TestDetectLanguageVersions(t *testing.T) {
+	dir := t.TempDir()
+	bin := filepath.Join(dir, "bin")
+	local := filepath.Join(dir, "local", "bin")
+	shims := filepath.Join(dir, "pyenv", "shims")
+	nvmDir := filepath.Join(dir, "nvm")
+	for _, d := range []string{bin, local, shims, filepath.Join(nvmDir, "versions", "node", "v18.19.0"), filepath.Join(nvmDir, "versions", "node", "v20.11.0")} {
+		if err := os.MkdirAll(d, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(bin, "python3.11"), nil, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	// python3 links to python3.11, which is only run once
+	if err := os.Symlink("python3.11", filepath.Join(bin, "python3")); err != nil {
+		t.Fatal(err)
+	}
+
+	r := &runnertest.Runner{Responses: map[string]runnertest.Response{
+		filepath.Join(local, "python3") + " --version":    {Output: "Python 3.12.1\n"},
+		filepath.Join(bin, "python3") + " --version":      {Output: "Python 3.11.7\n"},
+		filepath.Join(bin, "python2.7") + " --version":    {Stderr: "Python 2.7.18\n"},
+		filepath.Join(shims, "python3.10") + " --version": {Stderr: "pyenv: python3.10: command not found\n", Err: errors.New("exit status 127")},
+		filepath.Join(bin, "ruby") + " --version":         {Output: "ruby 3.2.2 (2023-03-30 revision e51014f9c0) [x86_64-linux]\n"},
+		filepath.Join(bin, "node") + " --version":         {Output: "v20.11.0\n"},
+	}}
+	path := runnertest.NewPath([]string{local, bin, shims},
+		filepath.Join(local, "python3"),
+		filepath.Join(bin, "python3"),
+		filepath.Join(bin, "python3.11"),
+		filepath.Join(bin, "python2.7"),
+		filepath.Join(shims, "python3.10"),
+		filepath.Join(bin, "ruby"),
+		filepath.Join(bin, "node"),
+	)
+	env := &types.EnvironmentData{ConfiguredLanguages: map[string]string{"Python 3": "3.12.1", "Ruby": "3.2.2", "Node.js": "20.11.0"}}
+
+	detectLanguageVersions(context.Background(), env, r, path, nvmDir)
+
+	expected := map[string][]string{
+		"Python":  {"2.7.18", "3.11.7", "3.12.1"},
+		"Node.js": {"18.19.0", "20.11.0"},
+	}
+	if !reflect.DeepEqual(env.LanguageVersions, expected) {
+		t.Errorf("expected language versions %v but got %v", expected, env.LanguageVersions)
+	}
+	if calls := r.Calls(); slices.Contains(calls, filepath.Join(bin, "python3.11")+" --version") {
+		t.Errorf("expected python3.11 to be run once, through python3, but got %q", calls)
+	}
+}
+
+func TestDetectLanguageVersionsSingleVersion(t *testing.T) {
+	r := &runnertest.Runner{Responses: map[string]runnertest.Response{
+		"/usr/bin/python3 --version": {Output: "Python 3.12.1\n"},
+	}}
+	path := runnertest.NewPath([]string{"/usr/bin"}, "/usr/bin/python3")
+	env := &types.EnvironmentData{ConfiguredLanguages: map[string]string{"Python 3": "3.12.1"}}
+
+	detectLanguageVersions(context.Background(), env, r, path, filepath.Join(t.TempDir(), ".nvm"))
+
+	if env.LanguageVersions != nil {
+		t.Errorf("expected no side-by-side versions but got %v", env.LanguageVersions)
+	}
+}
@@ -0,0 +1,168 @@
+package scanner
+
+import (
+	"log"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+// genericVersion is the version regex of the generic probe, the one of
+// custom detectors without a regex
+var genericVersion = regexp.MustCompile(defaultCustomRegex)
+
+// probeCommand matches the IDs the generic probe runs as commands. IDs come
+// from the manifest's own tool_ids, so anything that could name a file
+// outside PATH, such as /tmp/x or ./build.sh, is left out.
+var probeCommand = regexp.MustCompile(`^[a-z0-9][a-z0-9._+-]*$`)
+
+// Manifest limits the languages, tools, package managers and editors a
+// scan looks for to the entries of an environment, such as a team's, so
+// that repeat scans checking for drift only run the version commands that
+// matter. Entries are matched by canonical ID.
+type Manifest struct {
+	// File is the environment file the manifest was read from
+	File string
+	// entries maps each category to the canonical IDs of its entries and
+	// their names
+	entries map[string]map[string]string
+}
+
+// NewManifest returns the manifest of the languages, tools, package
+// managers and editors of env, read from file
+func NewManifest(file string, env *types.EnvironmentData) *Manifest {
+	m := &Manifest{File: file, entries: make(map[string]map[string]string)}
+	for category, names := range map[string]map[string]string{
+		types.CategoryLanguages:       env.ConfiguredLanguages,
+		types.CategoryTools:           env.Tools,
+		types.CategoryPackageManagers: env.PackageManagers,
+		types.CategoryEditors:         env.CodeEditors,
+	} {
+		m.entries[category] = make(map[string]string, len(names))
+		for name := range names {
+			m.entries[category][env.ToolID(name)] = name
+		}
+	}
+	return m
+}
+
+// IDs returns the canonical IDs of the manifest's entries, sorted
+func (m *Manifest) IDs() []string {
+	var ids []string
+	for _, entries := range m.entries {
+		for id := range entries {
+			if !slices.Contains(ids, id) {
+				ids = append(ids, id)
+			}
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Scan returns the ManifestScan recorded by scans limited to m
+func (m *Manifest) Scan() *types.ManifestScan {
+	return &types.ManifestScan{Manifest: m.File, IDs: m.IDs()}
+}
+
+// manifest, when set, limits the executables every scan runs
+var manifest *Manifest
+
+// UseManifest limits subsequent scans to the entries of m. A nil manifest,
+// the default, looks for everything.
+func UseManifest(m *Manifest) {
+	manifest = m
+}
+
+// limit returns the executables of category a scan limited to m runs:
+// those of executables whose entry the manifest lists, under its own ID or
+// that of the canonical name of its aliases, followed by one for each entry
+// none of them detects. Those are the executables of the same ID known for
+// another OS or category, or else a generic probe running the ID with
+// --version, found on PATH; IDs that aren't plain command names are
+// skipped. Entries found by other detectors, such as docker plugins and
+// GUI apps, are left to them. A nil m returns executables unchanged.
+func (m *Manifest) limit(category string, executables []Executable, groups []types.AliasGroup) []Executable {
+	if m == nil {
+		return executables
+	}
+	entries := m.entries[category]
+	var limited []Executable
+	matched := make(map[string]bool)
+	for _, exe := range executables {
+		for _, id := range []string{exe.Info().ID, aliasID(exe.Name, groups)} {
+			if _, ok := entries[id]; ok && id != "" {
+				limited = append(limited, exe)
+				matched[id] = true
+				break
+			}
+		}
+	}
+
+	ids := make([]string, 0, len(entries))
+	for id := range entries {
+		if !matched[id] {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		if exe, ok := knownExecutable(id); ok {
+			if exe.Command != "" {
+				limited = append(limited, exe)
+			}
+			continue
+		}
+		if !probeCommand.MatchString(id) || strings.Contains(id, "..") {
+			log.Printf("Warning: not probing %q from %s: it is not a command name", id, m.File)
+			continue
+		}
+		limited = append(limited, Executable{Name: entries[id], Command: id, VersionArg: "--version", VersionRegex: genericVersion, ID: id})
+	}
+	return limited
+}
+
+// aliasID returns the canonical ID of the name the entry called name is
+// recorded under by scans, or "" when it has no aliases
+func aliasID(name string, groups []types.AliasGroup) string {
+	for _, g := range groups {
+		if g.CompareOnly {
+			continue
+		}
+		if strings.EqualFold(g.Canonical, name) || slices.ContainsFunc(g.Aliases, func(alias string) bool { return strings.EqualFold(alias, name) }) {
+			return types.CanonicalID(g.Canonical)
+		}
+	}
+	return ""
+}
+
+// knownExecutable returns the executable the scanner detects the entry
+// with canonical ID id with on any OS. Docker plugins and GUI apps are
+// returned without a command, since other detectors find them.
+func knownExecutable(id string) (Executable, bool) {
+	lists := [][]Executable{languageExecutables, toolExecutables, editorExecutables, appCommands, crossPlatformPackageManagers}
+	for _, goos := range []string{"darwin", "linux", "windows"} {
+		lists = append(lists, osPackageManagers[goos])
+	}
+	for _, list := range lists {
+		for _, exe := range list {
+			if exe.Info().ID == id {
+				return exe, true
+			}
+		}
+	}
+	for _, plugin := range dockerPlugins {
+		if plugin.Info().ID == id {
+			return Executable{Name: plugin.Name}, true
+		}
+	}
+	for _, app := range guiApps {
+		if app.ID == id {
+			return Executable{Name: app.Name}, true
+		}
+	}
+	return Executable{}, false
+}
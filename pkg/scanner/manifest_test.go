@@ -0,0 +1,108 @@
+package scanner
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/runner/runnertest"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+// TestManifestLimitsProbes scans a machine with every known tool and
+// language on PATH, plus Bun which StackMatch doesn't know, with and
+// without a manifest, and counts the version commands each scan runs
+func TestManifestLimitsProbes(t *testing.T) {
+	team := &types.EnvironmentData{
+		ConfiguredLanguages: map[string]string{"Python": "3.12.3", "Go": "1.22.4"},
+		Tools:               map[string]string{"Git": "2.43.0", "Kubernetes": "1.30.2", "Bun": "1.1.20", "Docker Compose Plugin": "2.27.1"},
+		ToolIDs:             map[string]string{"Kubernetes": "kubectl"},
+	}
+	m := NewManifest("team.json", team)
+
+	var paths []string
+	for _, list := range [][]Executable{toolExecutables, languageExecutables} {
+		for _, exe := range list {
+			paths = append(paths, "/usr/bin/"+exe.Command)
+		}
+	}
+	path := runnertest.NewPath([]string{"/usr/bin"}, append(paths, "/usr/bin/bun")...)
+	responses := map[string]runnertest.Response{
+		"git --version":            {Output: "git version 2.45.1\n"},
+		"kubectl version --client": {Output: "Client Version: v1.30.2\n"},
+		"bun --version":            {Output: "1.1.21\n"},
+		"python --version":         {Output: "Python 3.12.3\n"},
+		"python3 --version":        {Output: "Python 3.12.3\n"},
+		"go version":               {Output: "go version go1.22.4 linux/amd64\n"},
+	}
+
+	scan := func(manifest *Manifest) (*types.EnvironmentData, int) {
+		r := &runnertest.Runner{Responses: responses}
+		env := &types.EnvironmentData{Tools: map[string]string{}, ConfiguredLanguages: map[string]string{}}
+		for _, category := range []struct {
+			name        string
+			executables []Executable
+			entries     map[string]string
+		}{
+			{types.CategoryTools, toolExecutables, env.Tools},
+			{types.CategoryLanguages, languageExecutables, env.ConfiguredLanguages},
+		} {
+			executables := manifest.limit(category.name, category.executables, types.DefaultAliasGroups)
+			detectExecutablesWith(context.Background(), r, path, nil, nil, 1, env, executables, category.entries)
+		}
+		return env, len(r.Calls())
+	}
+
+	_, fullProbes := scan(nil)
+	if expected := len(toolExecutables) + len(languageExecutables); fullProbes != expected {
+		t.Errorf("expected a full scan to run %d version commands but got %d", expected, fullProbes)
+	}
+
+	// Python 3 is probed as an alias of Python, Bun by the generic probe,
+	// and the compose plugin is left to the docker-plugins detector
+	env, limitedProbes := scan(m)
+	if limitedProbes != 6 {
+		t.Errorf("expected a scan limited to the manifest to run 6 version commands but got %d", limitedProbes)
+	}
+	expectedTools := map[string]string{"Git": "2.45.1", "Kubernetes": "1.30.2", "Bun": "1.1.21"}
+	if !reflect.DeepEqual(env.Tools, expectedTools) {
+		t.Errorf("expected tools %v but got %v", expectedTools, env.Tools)
+	}
+	expectedLanguages := map[string]string{"Python": "3.12.3", "Python 3": "3.12.3", "Go": "1.22.4"}
+	if !reflect.DeepEqual(env.ConfiguredLanguages, expectedLanguages) {
+		t.Errorf("expected languages %v but got %v", expectedLanguages, env.ConfiguredLanguages)
+	}
+}
+
+// TestManifestSkipsPathIDs makes sure a shared environment file can't make
+// a scan run a file of its choosing through its tool_ids
+func TestManifestSkipsPathIDs(t *testing.T) {
+	shared := &types.EnvironmentData{
+		Tools: map[string]string{"Bun": "1.1.20", "Build": "1.0", "Tmp": "1.0", "Up": "1.0", "Windows": "1.0"},
+		ToolIDs: map[string]string{
+			"Build": "./build.sh", "Tmp": "/tmp/x", "Up": "..", "Windows": `c:\\tools\\x.exe`,
+		},
+	}
+	executables := NewManifest("shared.json", shared).limit(types.CategoryTools, nil, nil)
+	if len(executables) != 1 || executables[0].Command != "bun" {
+		t.Errorf("expected only bun to be probed but got %+v", executables)
+	}
+}
+
+func TestManifestScan(t *testing.T) {
+	m := NewManifest("team.json", &types.EnvironmentData{
+		ConfiguredLanguages: map[string]string{"Node.js": "20.11.1"},
+		Tools:               map[string]string{"npm": "10.2.4", "Git": "2.43.0"},
+		PackageManagers:     map[string]string{"npm": "10.2.4"},
+		CodeEditors:         map[string]string{"VS Code": "1.90.2"},
+		ToolIDs:             map[string]string{"VS Code": "vscode"},
+	})
+	expected := &types.ManifestScan{Manifest: "team.json", IDs: []string{"git", "nodejs", "npm", "vscode"}}
+	scan := m.Scan()
+	if !reflect.DeepEqual(scan, expected) {
+		t.Errorf("expected %+v but got %+v", expected, scan)
+	}
+	if !scan.Covers("vscode") || scan.Covers("terraform") {
+		t.Errorf("expected the scan to cover only the manifest's IDs")
+	}
+}
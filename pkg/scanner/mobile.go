@@ -0,0 +1,207 @@
+package scanner
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/runner"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+const (
+	// flutterTimeout bounds 'flutter --version', which takes a few seconds
+	// when Flutter checks for updates
+	flutterTimeout = 30 * time.Second
+	// flutterDoctorTimeout bounds 'flutter doctor', which starts every
+	// toolchain it checks and can take a minute
+	flutterDoctorTimeout = 90 * time.Second
+)
+
+// DetectAndroidSDK records the Android SDK named by $ANDROID_HOME or the
+// deprecated $ANDROID_SDK_ROOT, or else installed where Android Studio puts
+// it, with the platforms and build tools it holds
+func DetectAndroidSDK(ctx context.Context, envData *types.EnvironmentData) {
+	detectAndroidSDK(envData, androidSDKDirs(runtime.GOOS))
+}
+
+// androidSDKDirs returns the directories the Android SDK is looked for in,
+// in order
+func androidSDKDirs(goos string) []string {
+	var dirs []string
+	for _, name := range []string{"ANDROID_HOME", "ANDROID_SDK_ROOT"} {
+		if dir := os.Getenv(name); dir != "" {
+			dirs = append(dirs, dir)
+		}
+	}
+	home, _ := os.UserHomeDir()
+	switch goos {
+	case "darwin":
+		dirs = append(dirs, filepath.Join(home, "Library", "Android", "sdk"))
+	case "windows":
+		if local := os.Getenv("LOCALAPPDATA"); local != "" {
+			dirs = append(dirs, filepath.Join(local, "Android", "Sdk"))
+		}
+	default:
+		dirs = append(dirs, filepath.Join(home, "Android", "Sdk"))
+	}
+	return dirs
+}
+
+func detectAndroidSDK(envData *types.EnvironmentData, dirs []string) {
+	for _, dir := range dirs {
+		if !dirExists(dir) {
+			continue
+		}
+		sdk := &types.AndroidSDK{Path: dir}
+		sdk.Platforms, _ = listDirs(filepath.Join(dir, "platforms"))
+		slices.SortStableFunc(sdk.Platforms, func(a, b string) int { return apiLevel(a) - apiLevel(b) })
+		buildTools, _ := listDirs(filepath.Join(dir, "build-tools"))
+		sdk.BuildTools = sortVersions(buildTools)
+		log.Printf("Found Android SDK in %s with %d platform(s)", dir, len(sdk.Platforms))
+		mobileSection(envData).AndroidSDK = sdk
+		return
+	}
+}
+
+// apiLevel returns the API level of a platforms/ directory such as
+// "android-34", or 0 for preview platforms named by codename
+func apiLevel(platform string) int {
+	level, _ := strconv.Atoi(strings.TrimPrefix(platform, "android-"))
+	return level
+}
+
+// DetectFlutter records the version, channel and Dart version of the
+// Flutter SDK on PATH
+func DetectFlutter(ctx context.Context, envData *types.EnvironmentData) {
+	detectFlutter(ctx, envData, runner.Default, runner.DefaultPath)
+}
+
+func detectFlutter(ctx context.Context, envData *types.EnvironmentData, r runner.Runner, path runner.PathIndex) {
+	if _, err := path.LookPath("flutter"); err != nil {
+		return
+	}
+	output, err := mobileCommand(ctx, r, flutterTimeout, "flutter", "--version")
+	if err != nil {
+		envData.Warnings = append(envData.Warnings, fmt.Sprintf("could not get the Flutter version: %v", err))
+		return
+	}
+	flutter := ParseFlutterVersion(output)
+	log.Printf("Found Flutter version %s (%s channel)", orNone(flutter.Version), orNone(flutter.Channel))
+	mobileSection(envData).Flutter = flutter
+}
+
+// DetectFlutterDoctor records the checks of 'flutter doctor' for the
+// Flutter SDK found by DetectFlutter. It is a detector of its own so that
+// scans can skip it: the doctor starts every toolchain it checks.
+func DetectFlutterDoctor(ctx context.Context, envData *types.EnvironmentData) {
+	detectFlutterDoctor(ctx, envData, runner.Default, flutterDoctorTimeout)
+}
+
+func detectFlutterDoctor(ctx context.Context, envData *types.EnvironmentData, r runner.Runner, timeout time.Duration) {
+	if envData.Mobile == nil || envData.Mobile.Flutter == nil {
+		return
+	}
+	// The doctor exits non-zero when a check fails, yet prints its report
+	output, err := mobileCommand(ctx, r, timeout, "flutter", "doctor", "--machine")
+	checks, parseErr := ParseFlutterDoctor(output)
+	if parseErr != nil {
+		if ctx.Err() != nil {
+			// The scan was cancelled, which is no fault of the doctor
+			return
+		}
+		if err == nil {
+			err = parseErr
+		}
+		envData.Warnings = append(envData.Warnings, fmt.Sprintf("could not run flutter doctor: %v; use --skip flutter-doctor to leave it out", err))
+		return
+	}
+	log.Printf("Found %d flutter doctor checks", len(checks))
+	envData.Mobile.Flutter.Doctor = checks
+}
+
+// mobileSection returns envData's Mobile section, adding it when missing
+func mobileSection(envData *types.EnvironmentData) *types.Mobile {
+	if envData.Mobile == nil {
+		envData.Mobile = &types.Mobile{}
+	}
+	return envData.Mobile
+}
+
+var (
+	// flutterVersion matches the first line of 'flutter --version', such as
+	// "Flutter 3.22.2 • channel stable • https://github.com/flutter/flutter.git"
+	flutterVersion = regexp.MustCompile(`Flutter (\S+) \S+ channel (\S+)`)
+	// flutterDart matches the Dart version of 'flutter --version', such as
+	// "Tools • Dart 3.4.3 • DevTools 2.34.3"
+	flutterDart = regexp.MustCompile(`Dart (\d\S*)`)
+)
+
+// ParseFlutterVersion parses the output of 'flutter --version'
+func ParseFlutterVersion(output string) *types.Flutter {
+	flutter := &types.Flutter{}
+	if m := flutterVersion.FindStringSubmatch(output); m != nil {
+		flutter.Version, flutter.Channel = m[1], m[2]
+	}
+	if m := flutterDart.FindStringSubmatch(output); m != nil {
+		flutter.Dart = m[1]
+	}
+	return flutter
+}
+
+// flutterValidator is the part of a check of 'flutter doctor --machine'
+// that is recorded. Its messages, which name paths and devices, are not.
+type flutterValidator struct {
+	Title      string `json:"title"`
+	Status     string `json:"status"`
+	StatusInfo string `json:"statusInfo"`
+}
+
+// ParseFlutterDoctor parses the JSON array 'flutter doctor --machine'
+// prints, skipping anything Flutter prints before it such as a notice of a
+// new release
+func ParseFlutterDoctor(output string) ([]types.FlutterDoctorCheck, error) {
+	start := strings.Index(output, "[")
+	if start < 0 {
+		return nil, errors.New("no report in its output")
+	}
+	var validators []flutterValidator
+	if err := json.NewDecoder(strings.NewReader(output[start:])).Decode(&validators); err != nil {
+		return nil, fmt.Errorf("could not parse its report: %w", err)
+	}
+	checks := make([]types.FlutterDoctorCheck, 0, len(validators))
+	for _, v := range validators {
+		checks = append(checks, types.FlutterDoctorCheck{Name: v.Title, Status: v.Status, Summary: v.StatusInfo})
+	}
+	return checks, nil
+}
+
+// mobileCommand runs name with args within timeout and returns its stdout,
+// with an error saying why it failed. The stdout of a command that failed
+// is returned too.
+func mobileCommand(ctx context.Context, r runner.Runner, timeout time.Duration, name string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	stdout, stderr, err := r.Output(ctx, name, args...)
+	switch {
+	case err == nil:
+		return stdout, nil
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return stdout, fmt.Errorf("%s %s did not answer within %s", name, args[0], timeout)
+	}
+	message := firstLine(stderr, stdout)
+	if message == "" {
+		message = err.Error()
+	}
+	return stdout, fmt.Errorf("%s %s failed: %s", name, args[0], message)
+}
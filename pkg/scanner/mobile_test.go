@@ -0,0 +1,157 @@
+package scanner
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/runner/runnertest"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+func TestDetectAndroidSDK(t *testing.T) {
+	sdk := t.TempDir()
+	for _, dir := range []string{"platforms/android-34", "platforms/android-9", "platforms/android-VanillaIceCream", "build-tools/34.0.0", "build-tools/9.0.0", "build-tools/30.0.3"} {
+		if err := os.MkdirAll(filepath.Join(sdk, filepath.FromSlash(dir)), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	env := &types.EnvironmentData{}
+	detectAndroidSDK(env, []string{filepath.Join(t.TempDir(), "missing"), sdk})
+	expected := &types.AndroidSDK{
+		Path:       sdk,
+		Platforms:  []string{"android-VanillaIceCream", "android-9", "android-34"},
+		BuildTools: []string{"9.0.0", "30.0.3", "34.0.0"},
+	}
+	if env.Mobile == nil || !reflect.DeepEqual(env.Mobile.AndroidSDK, expected) {
+		t.Errorf("expected %+v but got %+v", expected, env.Mobile)
+	}
+
+	env = &types.EnvironmentData{}
+	detectAndroidSDK(env, []string{filepath.Join(t.TempDir(), "missing")})
+	if env.Mobile != nil {
+		t.Errorf("expected no mobile section without an SDK but got %+v", env.Mobile)
+	}
+}
+
+func TestParseFlutterVersion(t *testing.T) {
+	expected := &types.Flutter{Version: "3.22.2", Channel: "stable", Dart: "3.4.3"}
+	if actual := ParseFlutterVersion(readFixture(t, "mobile", "flutter-version.txt")); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %+v but got %+v", expected, actual)
+	}
+}
+
+func TestParseFlutterDoctor(t *testing.T) {
+	checks, err := ParseFlutterDoctor(readFixture(t, "mobile", "flutter-doctor.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []types.FlutterDoctorCheck{
+		{Name: "Flutter", Status: types.FlutterInstalled, Summary: "Channel stable, 3.22.2, on Ubuntu 24.04 LTS 6.8.0-35-generic, locale en_US.UTF-8"},
+		{Name: "Android toolchain - develop for Android devices", Status: types.FlutterPartial, Summary: "Android SDK version 34.0.0"},
+		{Name: "Chrome - develop for the web", Status: types.FlutterMissing},
+		{Name: "Connected device", Status: types.FlutterInstalled, Summary: "1 available"},
+	}
+	if !reflect.DeepEqual(checks, expected) {
+		t.Errorf("expected %+v but got %+v", expected, checks)
+	}
+
+	if _, err := ParseFlutterDoctor("Waiting for another flutter command to release the startup lock...\n"); err == nil {
+		t.Error("expected output without a report to be rejected")
+	}
+}
+
+func TestDetectFlutter(t *testing.T) {
+	version := runnertest.Response{Output: readFixture(t, "mobile", "flutter-version.txt")}
+	// The doctor exits with 1 when a check fails
+	doctor := runnertest.Response{Output: readFixture(t, "mobile", "flutter-doctor.json"), Err: errors.New("exit status 1")}
+
+	testCases := []struct {
+		name      string
+		path      []string
+		responses map[string]runnertest.Response
+		timeout   time.Duration
+		expected  *types.Flutter
+		checks    int
+		warning   string
+	}{
+		{
+			name:      "Flutter with a failing check",
+			path:      []string{"/usr/bin/flutter"},
+			responses: map[string]runnertest.Response{"flutter --version": version, "flutter doctor --machine": doctor},
+			expected:  &types.Flutter{Version: "3.22.2", Channel: "stable", Dart: "3.4.3"},
+			checks:    4,
+		},
+		{
+			name:      "Doctor too slow",
+			path:      []string{"/usr/bin/flutter"},
+			responses: map[string]runnertest.Response{"flutter --version": version, "flutter doctor --machine": doctor},
+			timeout:   -time.Second,
+			expected:  &types.Flutter{Version: "3.22.2", Channel: "stable", Dart: "3.4.3"},
+			warning:   "could not run flutter doctor: flutter doctor did not answer within -1s; use --skip flutter-doctor to leave it out",
+		},
+		{
+			name: "No Flutter",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &runnertest.Runner{Responses: tc.responses}
+			timeout := tc.timeout
+			if timeout == 0 {
+				timeout = flutterDoctorTimeout
+			}
+			env := &types.EnvironmentData{}
+			detectFlutter(context.Background(), env, r, runnertest.NewPath([]string{"/usr/bin"}, tc.path...))
+			detectFlutterDoctor(context.Background(), env, r, timeout)
+
+			if tc.expected == nil {
+				if env.Mobile != nil || len(r.Calls()) != 0 {
+					t.Errorf("expected nothing to run without flutter but got %+v after %q", env.Mobile, r.Calls())
+				}
+				return
+			}
+			flutter := env.Mobile.Flutter
+			if flutter.Version != tc.expected.Version || flutter.Channel != tc.expected.Channel || flutter.Dart != tc.expected.Dart {
+				t.Errorf("expected %+v but got %+v", tc.expected, flutter)
+			}
+			if len(flutter.Doctor) != tc.checks {
+				t.Errorf("expected %d doctor checks but got %+v", tc.checks, flutter.Doctor)
+			}
+			var warnings []string
+			if tc.warning != "" {
+				warnings = []string{tc.warning}
+			}
+			if !reflect.DeepEqual(env.Warnings, warnings) {
+				t.Errorf("expected warnings %q but got %q", warnings, env.Warnings)
+			}
+		})
+	}
+}
+
+// TestDetectFlutterDoctorLeavesOutMessages makes sure the doctor's
+// messages, which name home directories and devices, stay out of the scan
+func TestDetectFlutterDoctorLeavesOutMessages(t *testing.T) {
+	r := &runnertest.Runner{Responses: map[string]runnertest.Response{
+		"flutter doctor --machine": {Output: readFixture(t, "mobile", "flutter-doctor.json")},
+	}}
+	env := &types.EnvironmentData{Mobile: &types.Mobile{Flutter: &types.Flutter{Version: "3.22.2"}}}
+	detectFlutterDoctor(context.Background(), env, r, flutterDoctorTimeout)
+	data, err := json.Marshal(env)
+	if err != nil {
+		t.Fatalf("failed to marshal: %v", err)
+	}
+	for _, message := range []string{"/home/jane", "android-licenses", "google-chrome", "linux-x64"} {
+		if strings.Contains(string(data), message) {
+			t.Errorf("expected %q to be left out but got %s", message, data)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"log"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/runner"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+// nixStorePath matches a Nix store path, capturing the name after its hash
+// such as "hello-2.12.1"
+var nixStorePath = regexp.MustCompile(`^/nix/store/[0-9a-z]{32}-(.+)$`)
+
+// DetectNixPackages records the packages of the user's Nix profile under
+// GlobalPackages["nix"], keyed by package name such as "hello". They come
+// from 'nix profile list' when the profile is managed with 'nix profile',
+// and from 'nix-env -q' otherwise, such as when the nix-command feature is
+// not enabled or the profile was made with nix-env.
+func DetectNixPackages(ctx context.Context, envData *types.EnvironmentData) {
+	detectNixPackages(ctx, envData, runner.Default, runner.DefaultPath)
+}
+
+func detectNixPackages(ctx context.Context, envData *types.EnvironmentData, r runner.Runner, paths runner.PathIndex) {
+	packages, err := nixProfilePackages(ctx, r, paths)
+	if err != nil {
+		envData.Warnings = append(envData.Warnings, "could not list the Nix profile: "+err.Error())
+		return
+	}
+	if len(packages) == 0 {
+		return
+	}
+	log.Printf("Found %d packages in the Nix profile", len(packages))
+	if envData.GlobalPackages == nil {
+		envData.GlobalPackages = make(map[string]map[string]string)
+	}
+	envData.GlobalPackages["nix"] = packages
+}
+
+// nixProfilePackages lists the profile with 'nix profile list', or with
+// 'nix-env -q' when that fails or only nix-env is on PATH. It returns
+// nothing when neither is.
+func nixProfilePackages(ctx context.Context, r runner.Runner, paths runner.PathIndex) (map[string]string, error) {
+	var profileErr error
+	if _, err := paths.LookPath("nix"); err == nil {
+		stdout, stderr, err := r.Output(ctx, "nix", "profile", "list")
+		if err == nil {
+			return parseNixProfileList(stdout), nil
+		}
+		profileErr = nixFailure(stderr, err)
+	}
+	if _, err := paths.LookPath("nix-env"); err != nil {
+		return nil, profileErr
+	}
+	stdout, stderr, err := r.Output(ctx, "nix-env", "-q")
+	if err != nil {
+		return nil, nixFailure(stderr, err)
+	}
+	return parseNixEnvQuery(stdout), nil
+}
+
+// nixFailure returns the error nix reported on stderr, or err when it
+// reported none
+func nixFailure(stderr string, err error) error {
+	if message := firstLine(stderr); message != "" {
+		return errors.New(message)
+	}
+	return err
+}
+
+// parseNixProfileList returns the packages in the output of 'nix profile
+// list'. Nix 2.20 and later describe each package in a block of fields:
+//
+//	Name:               hello
+//	Flake attribute:    legacyPackages.x86_64-linux.hello
+//	Store paths:        /nix/store/...-hello-2.12.1
+//
+// while earlier releases print a line per package with its index, flake
+// attribute, locked flake and store paths. Versions are taken from the
+// store path.
+func parseNixProfileList(output string) map[string]string {
+	packages := make(map[string]string)
+	name := ""
+	for _, line := range strings.Split(strings.ReplaceAll(output, "\r\n", "\n"), "\n") {
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) >= 2 && fields[0] == "Name:":
+			name = fields[1]
+		case len(fields) >= 3 && fields[0] == "Store" && fields[1] == "paths:" && name != "":
+			packages[name] = nixVersion(fields[2])
+			name = ""
+		case len(fields) >= 4 && isIndex(fields[0]):
+			// 0 flake:nixpkgs#legacyPackages.x86_64-linux.hello github:NixOS/nixpkgs/... /nix/store/...-hello-2.12.1
+			pkg, _ := splitNixName(nixStoreName(fields[3]))
+			if _, attr, ok := strings.Cut(fields[1], "#"); ok {
+				pkg = attr[strings.LastIndex(attr, ".")+1:]
+			}
+			if pkg != "" {
+				packages[pkg] = nixVersion(fields[3])
+			}
+		}
+	}
+	return packages
+}
+
+// isIndex reports whether field is a number
+func isIndex(field string) bool {
+	_, err := strconv.Atoi(field)
+	return err == nil
+}
+
+// parseNixEnvQuery returns the packages in the output of 'nix-env -q', one
+// name-version per line such as "hello-2.12.1"
+func parseNixEnvQuery(output string) map[string]string {
+	packages := make(map[string]string)
+	for _, line := range strings.Split(strings.ReplaceAll(output, "\r\n", "\n"), "\n") {
+		name, version := splitNixName(strings.TrimSpace(line))
+		if name == "" {
+			continue
+		}
+		if version == "" {
+			version = "Installed"
+		}
+		packages[name] = version
+	}
+	return packages
+}
+
+// nixVersion returns the version of the package in storePath, or
+// "Installed" when its name has none
+func nixVersion(storePath string) string {
+	if _, version := splitNixName(nixStoreName(storePath)); version != "" {
+		return version
+	}
+	return "Installed"
+}
+
+// nixStoreName returns the name of a store path after its hash, or "" when
+// storePath is not one
+func nixStoreName(storePath string) string {
+	if match := nixStorePath.FindStringSubmatch(path.Clean(storePath)); match != nil {
+		return match[1]
+	}
+	return ""
+}
+
+// splitNixName splits a name such as "python3-3.11.6" into the package name
+// and version the way Nix does: the version starts after the first dash
+// followed by something other than a letter
+func splitNixName(name string) (string, string) {
+	for i := 0; i+1 < len(name); i++ {
+		if name[i] == '-' && !isLetter(name[i+1]) {
+			return name[:i], name[i+1:]
+		}
+	}
+	return name, ""
+}
+
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
@@ -0,0 +1,81 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/runner/runnertest"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+func TestDetectNixPackages(t *testing.T) {
+	const (
+		hello   = "/nix/store/63l345l7dgcfz789w1y93j1540czafqh-hello-2.12.1"
+		ripgrep = "/nix/store/1k2dwasmdz8qz2jdnpinbiil7pcsjzmk-ripgrep-14.1.0"
+		python  = "/nix/store/w7p7gbbv4qsx9dd4ssddaaqlk3kl0fxn-python3-3.11.9"
+	)
+	disabled := runnertest.Response{Stderr: "error: experimental Nix feature 'nix-command' is disabled; add '--extra-experimental-features nix-command' to enable it\n", Err: errors.New("exit status 1")}
+
+	testCases := []struct {
+		name      string
+		exes      []string
+		responses map[string]runnertest.Response
+		expected  map[string]map[string]string
+		warnings  int
+	}{
+		{
+			name: "nix 2.20 profile",
+			exes: []string{"/nix/var/nix/profiles/default/bin/nix", "/nix/var/nix/profiles/default/bin/nix-env"},
+			responses: map[string]runnertest.Response{"nix profile list": {Output: "Name:               hello\n" +
+				"Flake attribute:    legacyPackages.x86_64-linux.hello\n" +
+				"Original flake URL: flake:nixpkgs\n" +
+				"Locked flake URL:   github:NixOS/nixpkgs/b06025f1533a1e07b6db3e75151caa155d1c7eb3\n" +
+				"Store paths:        " + hello + "\n\n" +
+				"Name:               python3\n" +
+				"Flake attribute:    legacyPackages.x86_64-linux.python3\n" +
+				"Store paths:        " + python + "\n"}},
+			expected: map[string]map[string]string{"nix": {"hello": "2.12.1", "python3": "3.11.9"}},
+		},
+		{
+			name: "earlier nix profile",
+			exes: []string{"/nix/var/nix/profiles/default/bin/nix"},
+			responses: map[string]runnertest.Response{"nix profile list": {Output: "0 flake:nixpkgs#legacyPackages.x86_64-linux.ripgrep github:NixOS/nixpkgs/b06025f1533a1e07b6db3e75151caa155d1c7eb3#legacyPackages.x86_64-linux.ripgrep " + ripgrep + "\n" +
+				"1 - - " + hello + "\n"}},
+			expected: map[string]map[string]string{"nix": {"ripgrep": "14.1.0", "hello": "2.12.1"}},
+		},
+		{
+			name: "nix-command disabled",
+			exes: []string{"/nix/var/nix/profiles/default/bin/nix", "/nix/var/nix/profiles/default/bin/nix-env"},
+			responses: map[string]runnertest.Response{
+				"nix profile list": disabled,
+				"nix-env -q":       {Output: "hello-2.12.1\nripgrep-14.1.0\nnix-index-0.1.7\n"},
+			},
+			expected: map[string]map[string]string{"nix": {"hello": "2.12.1", "ripgrep": "14.1.0", "nix-index": "0.1.7"}},
+		},
+		{
+			name:      "nix-command disabled without nix-env",
+			exes:      []string{"/nix/var/nix/profiles/default/bin/nix"},
+			responses: map[string]runnertest.Response{"nix profile list": disabled},
+			warnings:  1,
+		},
+		{
+			name: "no nix",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &runnertest.Runner{Responses: tc.responses}
+			env := &types.EnvironmentData{}
+			detectNixPackages(context.Background(), env, r, runnertest.NewPath([]string{"/nix/var/nix/profiles/default/bin"}, tc.exes...))
+			if !reflect.DeepEqual(env.GlobalPackages, tc.expected) {
+				t.Errorf("expected global packages %v but got %v", tc.expected, env.GlobalPackages)
+			}
+			if len(env.Warnings) != tc.warnings {
+				t.Errorf("expected %d warnings but got %q", tc.warnings, env.Warnings)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package scanner
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/runner"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+// corepackManagers are the package managers corepack can put shims on PATH
+// for
+var corepackManagers = []string{"pnpm", "yarn"}
+
+// DetectNodeEnvironment records the corepack version and which of yarn and
+// pnpm on PATH are corepack shims, which 'corepack enable' installs. A
+// corepack without shims is installed but not enabled.
+func DetectNodeEnvironment(ctx context.Context, envData *types.EnvironmentData) {
+	detectNodeEnvironment(ctx, envData, runner.Default, runner.DefaultPath, isCorepackShim)
+}
+
+func detectNodeEnvironment(ctx context.Context, envData *types.EnvironmentData, r runner.Runner, path runner.PathIndex, isShim func(file string) bool) {
+	if _, err := path.LookPath("corepack"); err != nil {
+		return
+	}
+	corepack := &types.Corepack{}
+	if stdout, _, err := r.Output(ctx, "corepack", "--version"); err == nil {
+		corepack.Version = firstLine(stdout)
+	}
+	for _, name := range corepackManagers {
+		if file, err := path.LookPath(name); err == nil && isShim(file) {
+			corepack.Shims = append(corepack.Shims, name)
+		}
+	}
+	log.Printf("Found corepack %s (%s)", cmp.Or(corepack.Version, "unknown"), corepack.State())
+
+	if envData.Node == nil {
+		envData.Node = &types.NodeEnvironment{}
+	}
+	envData.Node.Corepack = corepack
+}
+
+// isCorepackShim reports whether file runs corepack: on Unix a symlink into
+// corepack's dist directory, on Windows a .cmd shim naming it
+func isCorepackShim(file string) bool {
+	if resolved, err := filepath.EvalSymlinks(file); err == nil && strings.Contains(filepath.ToSlash(resolved), "/corepack/") {
+		return true
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	// The shims are a few lines; a binary yarn or pnpm is not worth reading
+	head, err := io.ReadAll(io.LimitReader(f, 4096))
+	if err != nil {
+		return false
+	}
+	return bytes.Contains(head, []byte("corepack"))
+}
@@ -0,0 +1,90 @@
+package scanner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/runner/runnertest"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+func TestDetectNodeEnvironment(t *testing.T) {
+	testCases := []struct {
+		name     string
+		exes     []string
+		shims    map[string]bool
+		expected *types.NodeEnvironment
+	}{
+		{
+			name: "no corepack",
+			exes: []string{"/usr/local/bin/yarn"},
+		},
+		{
+			name: "corepack enabled for pnpm and yarn",
+			exes: []string{"/usr/local/bin/corepack", "/usr/local/bin/pnpm", "/usr/local/bin/yarn"},
+			shims: map[string]bool{
+				"/usr/local/bin/pnpm": true,
+				"/usr/local/bin/yarn": true,
+			},
+			expected: &types.NodeEnvironment{Corepack: &types.Corepack{Version: "0.29.4", Shims: []string{"pnpm", "yarn"}}},
+		},
+		{
+			// yarn was installed with 'npm install --global yarn'
+			name:     "corepack not enabled",
+			exes:     []string{"/usr/local/bin/corepack", "/usr/local/bin/yarn"},
+			expected: &types.NodeEnvironment{Corepack: &types.Corepack{Version: "0.29.4"}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var env types.EnvironmentData
+			r := &runnertest.Runner{Responses: map[string]runnertest.Response{
+				"corepack --version": {Output: "0.29.4\n"},
+			}}
+			path := runnertest.NewPath([]string{"/usr/local/bin"}, tc.exes...)
+			detectNodeEnvironment(context.Background(), &env, r, path, func(file string) bool { return tc.shims[file] })
+			if !reflect.DeepEqual(env.Node, tc.expected) {
+				t.Errorf("expected %+v but got %+v", tc.expected, env.Node)
+			}
+		})
+	}
+}
+
+func TestIsCorepackShim(t *testing.T) {
+	dir := t.TempDir()
+	dist := filepath.Join(dir, "lib", "node_modules", "corepack", "dist")
+	if err := os.MkdirAll(dist, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		filepath.Join(dist, "pnpm.js"): "#!/usr/bin/env node\n",
+		// The .cmd shims 'corepack enable' writes on Windows
+		filepath.Join(dir, "yarn.cmd"): "@SETLOCAL\r\n\"%~dp0\\node.exe\" \"%~dp0\\node_modules\\corepack\\dist\\yarn.js\" %*\r\n",
+		filepath.Join(dir, "npm-yarn"): "#!/bin/sh\nexec node /usr/lib/node_modules/yarn/bin/yarn.js \"$@\"\n",
+	}
+	for file, content := range files {
+		if err := os.WriteFile(file, []byte(content), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	link := filepath.Join(dir, "pnpm")
+	if err := os.Symlink(filepath.Join("lib", "node_modules", "corepack", "dist", "pnpm.js"), link); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+
+	testCases := map[string]bool{
+		link:                           true,
+		filepath.Join(dir, "yarn.cmd"): true,
+		filepath.Join(dir, "npm-yarn"): false,
+		filepath.Join(dir, "missing"):  false,
+	}
+	for file, expected := range testCases {
+		if actual := isCorepackShim(file); actual != expected {
+			t.Errorf("expected %s to be a shim: %t but got %t", file, expected, actual)
+		}
+	}
+}
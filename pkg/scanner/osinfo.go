@@ -0,0 +1,181 @@
+package scanner
+
+import (
+	"bufio"
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/runner"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+// osReleaseFiles are where Linux distributions describe themselves, in the
+// order the os-release specification says to read them
+var osReleaseFiles = []string{"/etc/os-release", "/usr/lib/os-release"}
+
+// windowsVersionKey is the registry key holding the Windows edition and release
+const windowsVersionKey = `HKLM\SOFTWARE\Microsoft\Windows NT\CurrentVersion`
+
+// verVersion matches the build number in the output of 'ver', such as
+// "Microsoft Windows [Version 10.0.22631.3296]"
+var verVersion = regexp.MustCompile(`\[Version ([\d.]+)\]`)
+
+// detectOSInfo fills in the distribution or edition of the OS, its version
+// and the kernel version, for goos, and whether Linux runs inside WSL.
+// Whatever can't be read is left empty.
+func detectOSInfo(ctx context.Context, sysInfo *types.SystemInfo, r runner.Runner, goos string, readFile func(string) ([]byte, error), getenv func(string) string) {
+	switch goos {
+	case "linux":
+		for _, file := range osReleaseFiles {
+			if content, err := readFile(file); err == nil {
+				sysInfo.OSName, sysInfo.OSVersion = ParseOSRelease(string(content))
+				break
+			}
+		}
+		if release, err := readFile("/proc/sys/kernel/osrelease"); err == nil {
+			sysInfo.KernelVersion = strings.TrimSpace(string(release))
+		}
+		// WSL kernels are named like 5.15.146.1-microsoft-standard-WSL2, and
+		// WSL sets WSL_DISTRO_NAME in every shell it starts
+		sysInfo.WSLDistro = getenv("WSL_DISTRO_NAME")
+		sysInfo.IsWSL = sysInfo.WSLDistro != "" || strings.Contains(strings.ToLower(sysInfo.KernelVersion), "microsoft")
+	case "darwin":
+		if stdout, _, err := r.Output(ctx, "sw_vers"); err == nil {
+			sysInfo.OSName, sysInfo.OSVersion = ParseSwVers(stdout)
+		}
+		if stdout, _, err := r.Output(ctx, "uname", "-r"); err == nil {
+			sysInfo.KernelVersion = strings.TrimSpace(stdout)
+		}
+	case "windows":
+		if stdout, _, err := r.Output(ctx, "reg", "query", windowsVersionKey); err == nil {
+			sysInfo.OSName, sysInfo.OSVersion = ParseWindowsVersion(stdout)
+		}
+		if stdout, _, err := r.Output(ctx, "cmd", "/c", "ver"); err == nil {
+			if m := verVersion.FindStringSubmatch(stdout); m != nil {
+				sysInfo.KernelVersion = m[1]
+			}
+		}
+	}
+}
+
+// muslLoaders and glibcLoaders match the dynamic loaders each C library
+// installs, for when ldd is missing, as on minimal container images
+var (
+	muslLoaders  = []string{"/lib/ld-musl-*.so.1"}
+	glibcLoaders = []string{"/lib/ld-linux*.so.*", "/lib64/ld-linux*.so.*", "/lib/*-linux-gnu*/libc.so.6"}
+)
+
+// detectLibc returns the C library of a Linux system, types.LibcGlibc or
+// types.LibcMusl, from 'ldd --version' or else from the dynamic loaders
+// glob finds. It returns "" when neither tells.
+func detectLibc(ctx context.Context, r runner.Runner, glob func(pattern string) ([]string, error)) string {
+	// musl's ldd prints its version to stderr and exits with status 1
+	stdout, stderr, _ := r.Output(ctx, "ldd", "--version")
+	if libc := ParseLddVersion(stdout + stderr); libc != "" {
+		return libc
+	}
+	for _, loaders := range []struct {
+		libc     string
+		patterns []string
+	}{{types.LibcMusl, muslLoaders}, {types.LibcGlibc, glibcLoaders}} {
+		for _, pattern := range loaders.patterns {
+			if matches, err := glob(pattern); err == nil && len(matches) > 0 {
+				return loaders.libc
+			}
+		}
+	}
+	return ""
+}
+
+// ParseLddVersion returns the C library named in the output of
+// 'ldd --version', such as "ldd (Ubuntu GLIBC 2.35-0ubuntu3.6) 2.35" or
+// "musl libc (x86_64)", or "" when it names neither
+func ParseLddVersion(output string) string {
+	lower := strings.ToLower(output)
+	switch {
+	case strings.Contains(lower, "musl"):
+		return types.LibcMusl
+	case strings.Contains(lower, "glibc"), strings.Contains(lower, "gnu libc"):
+		return types.LibcGlibc
+	}
+	return ""
+}
+
+// ParseOSRelease returns the distribution name and version in the content
+// of an os-release file, such as "Ubuntu" and "20.04". Rolling releases
+// such as Arch have no version.
+func ParseOSRelease(content string) (name, version string) {
+	fields := make(map[string]string)
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		key, value, found := strings.Cut(line, "=")
+		if !found || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			value = unquoted
+		} else {
+			value = strings.Trim(value, `'"`)
+		}
+		fields[key] = value
+	}
+
+	name = fields["NAME"]
+	if name == "" {
+		name = fields["ID"]
+	}
+	if name == "" {
+		// The specification's default
+		name = "Linux"
+	}
+	return name, fields["VERSION_ID"]
+}
+
+// ParseSwVers returns the product name and version in the output of
+// 'sw_vers', such as "macOS" and "14.4.1"
+func ParseSwVers(output string) (name, version string) {
+	for _, line := range strings.Split(output, "\n") {
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		switch strings.TrimSpace(key) {
+		case "ProductName":
+			name = strings.TrimSpace(value)
+		case "ProductVersion":
+			version = strings.TrimSpace(value)
+		}
+	}
+	return name, version
+}
+
+// ParseWindowsVersion returns the edition and release in the output of
+// 'reg query' for windowsVersionKey, such as "Windows 11 Pro" and "23H2".
+// Windows 11 still calls itself Windows 10 in the registry, so the build
+// number decides. Releases before DisplayVersion existed report ReleaseId,
+// and the build number is the last resort.
+func ParseWindowsVersion(output string) (name, version string) {
+	values := make(map[string]string)
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 || !strings.HasPrefix(fields[1], "REG_") {
+			continue
+		}
+		values[fields[0]] = strings.Join(fields[2:], " ")
+	}
+
+	name = values["ProductName"]
+	build, _ := strconv.Atoi(values["CurrentBuild"])
+	if build >= 22000 && strings.HasPrefix(name, "Windows 10") {
+		name = "Windows 11" + strings.TrimPrefix(name, "Windows 10")
+	}
+	for _, key := range []string{"DisplayVersion", "ReleaseId", "CurrentBuild"} {
+		if values[key] != "" {
+			return name, values[key]
+		}
+	}
+	return name, ""
+}
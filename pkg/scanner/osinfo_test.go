@@ -0,0 +1,197 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/runner/runnertest"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+func TestParseOSRelease(t *testing.T) {
+	testCases := []struct {
+		fixture string
+		name    string
+		version string
+	}{
+		{fixture: "ubuntu-os-release", name: "Ubuntu", version: "20.04"},
+		{fixture: "debian-os-release", name: "Debian GNU/Linux", version: "12"},
+		{fixture: "arch-os-release", name: "Arch Linux", version: ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.fixture, func(t *testing.T) {
+			name, version := ParseOSRelease(readFixture(t, "osinfo", tc.fixture))
+			if name != tc.name || version != tc.version {
+				t.Errorf("expected %q %q but got %q %q", tc.name, tc.version, name, version)
+			}
+		})
+	}
+
+	if name, version := ParseOSRelease("ID=alpine\nVERSION_ID=3.19.1\n"); name != "alpine" || version != "3.19.1" {
+		t.Errorf("expected the ID without NAME but got %q %q", name, version)
+	}
+	if name, _ := ParseOSRelease(""); name != "Linux" {
+		t.Errorf("expected Linux for an empty file but got %q", name)
+	}
+}
+
+func TestParseSwVers(t *testing.T) {
+	name, version := ParseSwVers(readFixture(t, "osinfo", "sw_vers.txt"))
+	if name != "macOS" || version != "14.4.1" {
+		t.Errorf("expected macOS 14.4.1 but got %q %q", name, version)
+	}
+}
+
+func TestParseWindowsVersion(t *testing.T) {
+	testCases := []struct {
+		fixture string
+		name    string
+		version string
+	}{
+		{fixture: "reg-windows11.txt", name: "Windows 11 Pro", version: "23H2"},
+		{fixture: "reg-server2019.txt", name: "Windows Server 2019 Standard", version: "1809"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.fixture, func(t *testing.T) {
+			name, version := ParseWindowsVersion(readFixture(t, "osinfo", tc.fixture))
+			if name != tc.name || version != tc.version {
+				t.Errorf("expected %q %q but got %q %q", tc.name, tc.version, name, version)
+			}
+		})
+	}
+}
+
+// noEnv is a getenv for an empty environment
+func noEnv(string) string { return "" }
+
+func TestDetectOSInfo(t *testing.T) {
+	files := map[string]string{
+		"/usr/lib/os-release":        readFixture(t, "osinfo", "debian-os-release"),
+		"/proc/sys/kernel/osrelease": "6.1.0-18-amd64\n",
+	}
+	readFile := func(name string) ([]byte, error) {
+		if content, ok := files[name]; ok {
+			return []byte(content), nil
+		}
+		return nil, os.ErrNotExist
+	}
+	r := &runnertest.Runner{Responses: map[string]runnertest.Response{
+		"sw_vers":                        {Output: readFixture(t, "osinfo", "sw_vers.txt")},
+		"uname -r":                       {Output: "23.4.0\n"},
+		"reg query " + windowsVersionKey: {Output: readFixture(t, "osinfo", "reg-windows11.txt")},
+		"cmd /c ver":                     {Output: "\r\nMicrosoft Windows [Version 10.0.22631.3296]\r\n"},
+	}}
+
+	testCases := []struct {
+		goos     string
+		expected types.SystemInfo
+	}{
+		{goos: "linux", expected: types.SystemInfo{OSName: "Debian GNU/Linux", OSVersion: "12", KernelVersion: "6.1.0-18-amd64"}},
+		{goos: "darwin", expected: types.SystemInfo{OSName: "macOS", OSVersion: "14.4.1", KernelVersion: "23.4.0"}},
+		{goos: "windows", expected: types.SystemInfo{OSName: "Windows 11 Pro", OSVersion: "23H2", KernelVersion: "10.0.22631.3296"}},
+		{goos: "freebsd", expected: types.SystemInfo{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.goos, func(t *testing.T) {
+			var actual types.SystemInfo
+			detectOSInfo(context.Background(), &actual, r, tc.goos, readFile, noEnv)
+			if actual != tc.expected {
+				t.Errorf("expected %+v but got %+v", tc.expected, actual)
+			}
+		})
+	}
+
+	// Nothing readable leaves the fields empty
+	failing := &runnertest.Runner{Responses: map[string]runnertest.Response{"sw_vers": {Err: errors.New("exit status 1")}}}
+	var actual types.SystemInfo
+	detectOSInfo(context.Background(), &actual, failing, "darwin", readFile, noEnv)
+	if actual != (types.SystemInfo{}) {
+		t.Errorf("expected no OS info but got %+v", actual)
+	}
+}
+
+func TestDetectWSL(t *testing.T) {
+	testCases := []struct {
+		name   string
+		kernel string
+		env    map[string]string
+		isWSL  bool
+		distro string
+	}{
+		{name: "native Linux", kernel: "6.1.0-18-amd64"},
+		{name: "WSL 2", kernel: "5.15.146.1-microsoft-standard-WSL2", env: map[string]string{"WSL_DISTRO_NAME": "Ubuntu-22.04"}, isWSL: true, distro: "Ubuntu-22.04"},
+		{name: "WSL 1 kernel without the variable", kernel: "4.4.0-19041-Microsoft", isWSL: true},
+		{name: "variable without a WSL kernel", kernel: "6.6.36.3-custom", env: map[string]string{"WSL_DISTRO_NAME": "Debian"}, isWSL: true, distro: "Debian"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			readFile := func(name string) ([]byte, error) {
+				if name == "/proc/sys/kernel/osrelease" {
+					return []byte(tc.kernel + "\n"), nil
+				}
+				return nil, os.ErrNotExist
+			}
+			getenv := func(name string) string { return tc.env[name] }
+			var actual types.SystemInfo
+			detectOSInfo(context.Background(), &actual, &runnertest.Runner{}, "linux", readFile, getenv)
+			if actual.IsWSL != tc.isWSL || actual.WSLDistro != tc.distro {
+				t.Errorf("expected WSL %v (%q) but got %v (%q)", tc.isWSL, tc.distro, actual.IsWSL, actual.WSLDistro)
+			}
+		})
+	}
+}
+
+func TestParseLddVersion(t *testing.T) {
+	testCases := []struct {
+		output   string
+		expected string
+	}{
+		{output: "ldd (Ubuntu GLIBC 2.35-0ubuntu3.6) 2.35\nCopyright (C) 2022 Free Software Foundation, Inc.\n", expected: types.LibcGlibc},
+		{output: "ldd (GNU libc) 2.39\n", expected: types.LibcGlibc},
+		{output: "musl libc (x86_64)\nVersion 1.2.4\nDynamic Program Loader\n", expected: types.LibcMusl},
+		{output: "", expected: ""},
+	}
+
+	for _, tc := range testCases {
+		if actual := ParseLddVersion(tc.output); actual != tc.expected {
+			t.Errorf("expected %q for %q but got %q", tc.expected, tc.output, actual)
+		}
+	}
+}
+
+func TestDetectLibc(t *testing.T) {
+	alpine := func(pattern string) ([]string, error) {
+		if pattern == "/lib/ld-musl-*.so.1" {
+			return []string{"/lib/ld-musl-x86_64.so.1"}, nil
+		}
+		return nil, nil
+	}
+	nothing := func(string) ([]string, error) { return nil, nil }
+	testCases := []struct {
+		name     string
+		ldd      runnertest.Response
+		glob     func(string) ([]string, error)
+		expected string
+	}{
+		// musl's ldd exits with status 1 after printing its version
+		{name: "musl ldd", ldd: runnertest.Response{Stderr: "musl libc (x86_64)\nVersion 1.2.4\n", Err: errors.New("exit status 1")}, glob: nothing, expected: types.LibcMusl},
+		{name: "glibc ldd", ldd: runnertest.Response{Output: "ldd (Debian GLIBC 2.36-9+deb12u4) 2.36\n"}, glob: alpine, expected: types.LibcGlibc},
+		{name: "no ldd on Alpine", ldd: runnertest.Response{Err: errors.New("executable file not found in $PATH")}, glob: alpine, expected: types.LibcMusl},
+		{name: "no ldd and no loader", ldd: runnertest.Response{Err: errors.New("executable file not found in $PATH")}, glob: nothing, expected: ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &runnertest.Runner{Responses: map[string]runnertest.Response{"ldd --version": tc.ldd}}
+			if actual := detectLibc(context.Background(), r, tc.glob); actual != tc.expected {
+				t.Errorf("expected %q but got %q", tc.expected, actual)
+			}
+		})
+	}
+}
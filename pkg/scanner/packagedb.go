@@ -0,0 +1,516 @@
+package scanner
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/installer"
+	"github.com/MRQ67/stackmatch-cli/pkg/runner"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+// dpkgStatusFile is where dpkg records the packages it installed
+const dpkgStatusFile = "/var/lib/dpkg/status"
+
+// packageDBCaveats describe how a fast scan can differ from a full scan of
+// the same machine, whatever databases it read
+var packageDBCaveats = []string{
+	"versions are those of the installed packages, which can differ from what the tools report, such as when another copy comes first on PATH",
+	"tools installed without a package manager, such as through version managers, installers or by hand, are left out",
+	"only packages with a package mapping are recorded, whether or not they put a command on PATH",
+}
+
+// packageDBPaths are the files and directories the package databases are
+// read from
+type packageDBPaths struct {
+	// DpkgStatus is dpkg's status file
+	DpkgStatus string
+	// ScoopApps lists the directories Scoop installs apps in, the user's
+	// first
+	ScoopApps []string
+	// TempDir is where 'winget export' writes its file
+	TempDir string
+}
+
+// packageDB is a package database a fast scan reads
+type packageDB struct {
+	// Name is recorded in FastScan.Databases and the sources of its
+	// entries
+	Name    string
+	Manager types.PackageManagerType
+	// ManagerName is the entry of the package manager itself in
+	// PackageManagers
+	ManagerName string
+	// ManagerPackage is the package that installs the package manager, for
+	// databases that list it
+	ManagerPackage string
+	// Caveat describes how its versions differ from those the tools report,
+	// if they do
+	Caveat string
+	// read returns the installed packages keyed by name, with the version
+	// of the package manager when the database doesn't list its package, or
+	// false when the database is not on this machine
+	read func(ctx context.Context, r runner.Runner, path runner.PathIndex, dirs packageDBPaths) (packages map[string]string, managerVersion string, found bool, err error)
+}
+
+// packageDBs are read in order; the first database with a package for an
+// entry sets its version
+var packageDBs = []packageDB{
+	{Name: "dpkg", Manager: types.TypeApt, ManagerName: "apt", ManagerPackage: "apt", Caveat: "dpkg versions are recorded without their epoch and Debian revision", read: readDpkg},
+	{Name: "homebrew", Manager: types.TypeHomebrew, ManagerName: "Homebrew", Caveat: "Homebrew versions are those of the linked keg, without the formula revision", read: readHomebrew},
+	{Name: "scoop", Manager: types.TypeScoop, ManagerName: "Scoop", read: readScoop},
+	{Name: "winget", Manager: types.TypeWinget, ManagerName: "Winget", Caveat: "winget only lists the packages it can match to one of its sources", read: readWinget},
+}
+
+// DetectFromPackageDatabases records the languages, tools, package managers
+// and editors installed through a package manager from its database,
+// without running any of them: dpkg's status file, 'brew info --installed',
+// Scoop's apps directory and 'winget export'. Packages are matched to
+// entries through the package mappings, and their source is recorded as
+// package-db:<database>:<package> (see types.PackageDBSource).
+func DetectFromPackageDatabases(ctx context.Context, envData *types.EnvironmentData) {
+	home, _ := os.UserHomeDir()
+	dirs := packageDBPaths{
+		DpkgStatus: dpkgStatusFile,
+		ScoopApps:  scoopAppDirs(home, os.Getenv),
+	}
+	tempDir, err := os.MkdirTemp("", "stackmatch-packagedb-")
+	if err != nil {
+		envData.Warnings = append(envData.Warnings, fmt.Sprintf("could not create a temporary directory for the winget export: %v", err))
+	} else {
+		defer os.RemoveAll(tempDir)
+		dirs.TempDir = tempDir
+	}
+	detectFromPackageDatabases(ctx, envData, runner.Default, runner.DefaultPath, dirs)
+}
+
+// scoopAppDirs returns the directories Scoop installs apps in for the user
+// and machine wide, moved by $SCOOP and $SCOOP_GLOBAL
+func scoopAppDirs(home string, getenv func(string) string) []string {
+	user := getenv("SCOOP")
+	if user == "" {
+		user = filepath.Join(home, "scoop")
+	}
+	dirs := []string{filepath.Join(user, "apps")}
+	global := getenv("SCOOP_GLOBAL")
+	if global == "" && getenv("ProgramData") != "" {
+		global = filepath.Join(getenv("ProgramData"), "scoop")
+	}
+	if global != "" {
+		dirs = append(dirs, filepath.Join(global, "apps"))
+	}
+	return dirs
+}
+
+func detectFromPackageDatabases(ctx context.Context, envData *types.EnvironmentData, r runner.Runner, path runner.PathIndex, dirs packageDBPaths) {
+	for _, entries := range []*map[string]string{&envData.ConfiguredLanguages, &envData.Tools, &envData.PackageManagers, &envData.CodeEditors} {
+		if *entries == nil {
+			*entries = make(map[string]string)
+		}
+	}
+	entries := packagedEntries()
+	fast := &types.FastScan{Databases: []string{}}
+	for _, db := range packageDBs {
+		if ctx.Err() != nil {
+			break
+		}
+		packages, managerVersion, found, err := db.read(ctx, r, path, dirs)
+		if err != nil {
+			envData.Warnings = append(envData.Warnings, fmt.Sprintf("could not read the %s package database: %v", db.Name, err))
+			continue
+		}
+		if !found {
+			continue
+		}
+		fast.Databases = append(fast.Databases, db.Name)
+		if db.Caveat != "" {
+			fast.Caveats = append(fast.Caveats, db.Caveat)
+		}
+
+		recorded := 0
+		managerSource := ""
+		if db.ManagerPackage != "" {
+			managerVersion = packages[db.ManagerPackage]
+			managerSource = types.PackageDBSource + ":" + db.Name + ":" + db.ManagerPackage
+		}
+		if managerVersion != "" && recordPackagedEntry(envData, envData.PackageManagers, db.ManagerName, types.CanonicalID(db.ManagerName), managerVersion, managerSource) {
+			recorded++
+		}
+		index := newPackageIndex(db.Manager)
+		for _, pkg := range slices.Sorted(maps.Keys(packages)) {
+			for _, id := range index.lookup(pkg) {
+				for _, entry := range entries[id] {
+					source := types.PackageDBSource + ":" + db.Name + ":" + pkg
+					if recordPackagedEntry(envData, entry.category(envData), entry.name, id, packages[pkg], source) {
+						recorded++
+					}
+				}
+			}
+		}
+		log.Printf("Found %d entries in the %s package database", recorded, db.Name)
+	}
+	fast.Caveats = append(slices.Clone(packageDBCaveats), fast.Caveats...)
+	envData.FastScan = fast
+}
+
+// recordPackagedEntry records version under name in entries unless an
+// earlier database did, and reports whether it did
+func recordPackagedEntry(envData *types.EnvironmentData, entries map[string]string, name, id, version, source string) bool {
+	if _, seen := entries[name]; seen {
+		return false
+	}
+	entries[name] = version
+	if envData.ToolIDs == nil {
+		envData.ToolIDs = make(map[string]string)
+	}
+	envData.ToolIDs[name] = id
+	if source != "" {
+		if envData.ToolSources == nil {
+			envData.ToolSources = make(map[string]string)
+		}
+		envData.ToolSources[name] = source
+	}
+	return true
+}
+
+// packagedEntry is where a full scan records a tool: the display name and
+// the category it is detected in
+type packagedEntry struct {
+	name     string
+	category func(envData *types.EnvironmentData) map[string]string
+}
+
+// packagedEntries returns the entries a full scan would record for each tool
+// ID, which may be several for tools detected in more than one category
+// such as npm
+func packagedEntries() map[string][]packagedEntry {
+	categories := []struct {
+		lists    [][]Executable
+		category func(envData *types.EnvironmentData) map[string]string
+	}{
+		{[][]Executable{languageExecutables}, func(e *types.EnvironmentData) map[string]string { return e.ConfiguredLanguages }},
+		{[][]Executable{toolExecutables}, func(e *types.EnvironmentData) map[string]string { return e.Tools }},
+		{[][]Executable{crossPlatformPackageManagers, osPackageManagers["darwin"], osPackageManagers["linux"], osPackageManagers["windows"]}, func(e *types.EnvironmentData) map[string]string { return e.PackageManagers }},
+		{[][]Executable{editorExecutables, appCommands}, func(e *types.EnvironmentData) map[string]string { return e.CodeEditors }},
+	}
+	for _, plugin := range dockerPlugins {
+		categories[1].lists = append(categories[1].lists, []Executable{plugin.Executable})
+	}
+	for _, emulator := range terminalEmulators {
+		categories[1].lists = append(categories[1].lists, []Executable{{Name: emulator.Name, ID: emulator.ID}})
+	}
+	for _, app := range guiApps {
+		categories[3].lists = append(categories[3].lists, []Executable{{Name: app.Name, ID: app.ID}})
+	}
+
+	type entryKey struct {
+		category int
+		name     string
+	}
+	entries := make(map[string][]packagedEntry)
+	seen := make(map[entryKey]bool)
+	for i, c := range categories {
+		for _, list := range c.lists {
+			for _, exe := range list {
+				info := exe.Info()
+				key := entryKey{i, info.Name}
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+				entries[info.ID] = append(entries[info.ID], packagedEntry{name: info.Name, category: c.category})
+			}
+		}
+	}
+	return entries
+}
+
+// packageIndex maps the packages of a package manager in the package
+// mappings to the IDs, and aliases, of the tools they install
+type packageIndex struct {
+	names map[string][]string
+	// versioned matches the packages of specific versions, such as
+	// Homebrew's python@3.12 or apt's python3.12
+	versioned []versionedPackage
+}
+
+type versionedPackage struct {
+	pattern *regexp.Regexp
+	ids     []string
+}
+
+// templateVersion matches the placeholders of a VersionFormat template
+var templateVersion = regexp.MustCompile(`\\\{(?:major|minor|version)\\\}`)
+
+// newPackageIndex indexes the packages of manager, including those of
+// specific majors or minor releases such as Homebrew's node@20 and those
+// named after a version template
+func newPackageIndex(manager types.PackageManagerType) packageIndex {
+	index := packageIndex{names: make(map[string][]string)}
+	for _, mapping := range installer.GetAllPackageMappings() {
+		ids := append([]string{mapping.ID}, mapping.Aliases...)
+		if pkg := mapping.Packages[manager]; pkg != "" {
+			index.names[pkg] = append(index.names[pkg], ids...)
+		}
+		format := mapping.Versions[manager]
+		for _, pkg := range format.Majors {
+			index.names[pkg] = append(index.names[pkg], ids...)
+		}
+		for _, pkg := range format.Minors {
+			index.names[pkg] = append(index.names[pkg], ids...)
+		}
+		if format.Package != "" {
+			pattern := templateVersion.ReplaceAllLiteralString(regexp.QuoteMeta(format.Package), `\d+(?:\.\d+)*`)
+			index.versioned = append(index.versioned, versionedPackage{regexp.MustCompile("^" + pattern + "$"), ids})
+		}
+	}
+	return index
+}
+
+// lookup returns the IDs of the tools pkg installs
+func (index packageIndex) lookup(pkg string) []string {
+	if ids, ok := index.names[pkg]; ok {
+		return ids
+	}
+	for _, v := range index.versioned {
+		if v.pattern.MatchString(pkg) {
+			return v.ids
+		}
+	}
+	return nil
+}
+
+// readDpkg reads dpkg's status file
+func readDpkg(_ context.Context, _ runner.Runner, _ runner.PathIndex, dirs packageDBPaths) (map[string]string, string, bool, error) {
+	f, err := os.Open(dirs.DpkgStatus)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, "", false, nil
+	}
+	if err != nil {
+		return nil, "", false, err
+	}
+	defer f.Close()
+	packages, err := parseDpkgStatus(f)
+	if err != nil {
+		return nil, "", false, err
+	}
+	return packages, "", true, nil
+}
+
+// parseDpkgStatus returns the installed packages of a dpkg status file, a
+// stanza of "Field: value" lines per package separated by blank lines.
+// Packages removed but not purged are skipped. Versions lose their epoch
+// and Debian revision, so "1:2.43.0-1ubuntu7" is recorded as "2.43.0".
+func parseDpkgStatus(status io.Reader) (map[string]string, error) {
+	packages := make(map[string]string)
+	var name, version, state string
+	flush := func() {
+		if name != "" && version != "" && strings.HasSuffix(state, " installed") {
+			packages[name] = debianUpstreamVersion(version)
+		}
+		name, version, state = "", "", ""
+	}
+
+	sc := bufio.NewScanner(status)
+	// Descriptions and conffile lists make for long stanzas, not long lines,
+	// but some fields run past bufio's default limit
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for sc.Scan() {
+		line := sc.Text()
+		if strings.TrimSpace(line) == "" {
+			flush()
+			continue
+		}
+		// Continuation lines of multi-line fields start with a space
+		if line[0] == ' ' || line[0] == '\t' {
+			continue
+		}
+		field, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		switch value = strings.TrimSpace(value); field {
+		case "Package":
+			name = value
+		case "Version":
+			version = value
+		case "Status":
+			state = value
+		}
+	}
+	flush()
+	return packages, sc.Err()
+}
+
+// debianUpstreamVersion strips the epoch and Debian revision from a
+// Debian package version
+func debianUpstreamVersion(v string) string {
+	if _, after, ok := strings.Cut(v, ":"); ok {
+		v = after
+	}
+	if i := strings.LastIndex(v, "-"); i > 0 {
+		v = v[:i]
+	}
+	return v
+}
+
+// readHomebrew lists the formulae and casks installed with one 'brew info
+// --json=v2 --installed'
+func readHomebrew(ctx context.Context, r runner.Runner, path runner.PathIndex, _ packageDBPaths) (map[string]string, string, bool, error) {
+	if _, err := path.LookPath("brew"); err != nil {
+		return nil, "", false, nil
+	}
+	stdout, stderr, err := r.Output(ctx, "brew", "info", "--json=v2", "--installed")
+	if err != nil {
+		return nil, "", false, errors.New(cmp.Or(firstLine(stderr), err.Error()))
+	}
+	packages, err := parseBrewInfo([]byte(stdout))
+	if err != nil {
+		return nil, "", false, err
+	}
+	return packages, "Installed", true, nil
+}
+
+// brewInfo is the part of 'brew info --json=v2' a fast scan reads
+type brewInfo struct {
+	Formulae []struct {
+		Name      string `json:"name"`
+		LinkedKeg string `json:"linked_keg"`
+		Installed []struct {
+			Version string `json:"version"`
+		} `json:"installed"`
+	} `json:"formulae"`
+	Casks []struct {
+		Token     string `json:"token"`
+		Installed string `json:"installed"`
+	} `json:"casks"`
+}
+
+// parseBrewInfo returns the installed formulae and casks of 'brew info
+// --json=v2 --installed'. A formula's version is that of its linked keg,
+// or the newest installed when none is linked, without the revision
+// Homebrew adds to rebuilt formulae ("3.12.1_1"). Casks installed as
+// "latest" are recorded as "Installed", and cask build numbers after a
+// comma ("4.26.1,131620") are dropped.
+func parseBrewInfo(data []byte) (map[string]string, error) {
+	var info brewInfo
+	if err := json.Unmarshal(data, &info); err != nil {
+		return nil, fmt.Errorf("invalid brew info output: %w", err)
+	}
+	packages := make(map[string]string)
+	for _, formula := range info.Formulae {
+		version := formula.LinkedKeg
+		if version == "" && len(formula.Installed) > 0 {
+			version = formula.Installed[len(formula.Installed)-1].Version
+		}
+		if version == "" {
+			continue
+		}
+		if i := strings.LastIndex(version, "_"); i > 0 {
+			version = version[:i]
+		}
+		packages[formula.Name] = version
+	}
+	for _, cask := range info.Casks {
+		version, _, _ := strings.Cut(cask.Installed, ",")
+		switch version {
+		case "":
+			continue
+		case "latest":
+			version = "Installed"
+		}
+		packages[cask.Token] = version
+	}
+	return packages, nil
+}
+
+// readScoop reads the manifest Scoop keeps of the current version of each
+// app it installed
+func readScoop(_ context.Context, _ runner.Runner, _ runner.PathIndex, dirs packageDBPaths) (map[string]string, string, bool, error) {
+	packages := make(map[string]string)
+	found := false
+	for _, dir := range dirs.ScoopApps {
+		apps, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		found = true
+		for _, app := range apps {
+			if _, seen := packages[app.Name()]; seen || !app.IsDir() || app.Name() == "scoop" {
+				continue
+			}
+			data, err := os.ReadFile(filepath.Join(dir, app.Name(), "current", "manifest.json"))
+			if err != nil {
+				continue
+			}
+			var manifest struct {
+				Version string `json:"version"`
+			}
+			if err := json.Unmarshal(data, &manifest); err != nil {
+				return nil, "", false, fmt.Errorf("invalid manifest of %s: %w", app.Name(), err)
+			}
+			packages[app.Name()] = cmp.Or(manifest.Version, "Installed")
+		}
+	}
+	if !found {
+		return nil, "", false, nil
+	}
+	return packages, "Installed", true, nil
+}
+
+// readWinget exports the packages winget knows to a file in dirs.TempDir
+// and reads it
+func readWinget(ctx context.Context, r runner.Runner, path runner.PathIndex, dirs packageDBPaths) (map[string]string, string, bool, error) {
+	if _, err := path.LookPath("winget"); err != nil || dirs.TempDir == "" {
+		return nil, "", false, nil
+	}
+	file := filepath.Join(dirs.TempDir, "winget-export.json")
+	_, stderr, err := r.Output(ctx, "winget", "export", "--output", file, "--include-versions", "--accept-source-agreements", "--disable-interactivity")
+	if err != nil {
+		return nil, "", false, errors.New(cmp.Or(firstLine(stderr), err.Error()))
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, "", false, err
+	}
+	return parseWingetExport(data)
+}
+
+// parseWingetExport returns the packages of a 'winget export' file, keyed
+// by package identifier, and the version of winget that wrote it. Packages
+// exported without a version are recorded as "Installed".
+func parseWingetExport(data []byte) (map[string]string, string, bool, error) {
+	var export struct {
+		Sources []struct {
+			Packages []struct {
+				PackageIdentifier string `json:"PackageIdentifier"`
+				Version           string `json:"Version"`
+			} `json:"Packages"`
+		} `json:"Sources"`
+		WinGetVersion string `json:"WinGetVersion"`
+	}
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, "", false, fmt.Errorf("invalid winget export: %w", err)
+	}
+	packages := make(map[string]string)
+	for _, source := range export.Sources {
+		for _, pkg := range source.Packages {
+			if pkg.PackageIdentifier != "" {
+				packages[pkg.PackageIdentifier] = cmp.Or(pkg.Version, "Installed")
+			}
+		}
+	}
+	return packages, cmp.Or(export.WinGetVersion, "Installed"), true, nil
+}
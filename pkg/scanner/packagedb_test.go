@@ -0,0 +1,252 @@
+package scanner
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/runner/runnertest"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+const (
+	brewInfoCommand     = "brew info --json=v2 --installed"
+	wingetExportCommand = "winget export --output %s --include-versions --accept-source-agreements --disable-interactivity"
+)
+
+func TestParseDpkgStatus(t *testing.T) {
+	expected := map[string]string{
+		"apt":        "2.7.14build2",
+		"git":        "2.43.0",
+		"libc6":      "2.39",
+		"nodejs":     "18.19.1+dfsg",
+		"python3.12": "3.12.3",
+	}
+
+	got, err := parseDpkgStatus(strings.NewReader(readFixture(t, "packagedb", "status")))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// tmux was removed but its config files were kept
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v but got %v", expected, got)
+	}
+}
+
+func TestDebianUpstreamVersion(t *testing.T) {
+	testCases := []struct {
+		version  string
+		expected string
+	}{
+		{"2.7.14build2", "2.7.14build2"},
+		{"1:2.43.0-1ubuntu7.1", "2.43.0"},
+		{"18.19.1+dfsg-6ubuntu5", "18.19.1+dfsg"},
+		{"1:9.1.0016-1ubuntu7-2", "9.1.0016-1ubuntu7"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.version, func(t *testing.T) {
+			if got := debianUpstreamVersion(tc.version); got != tc.expected {
+				t.Errorf("expected %q but got %q", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestParseBrewInfo(t *testing.T) {
+	expected := map[string]string{
+		"git":                "2.45.2",
+		"node@20":            "20.15.0",
+		"python@3.12":        "3.12.4",
+		"openssl@3":          "3.3.1",
+		"visual-studio-code": "1.90.2",
+		"iterm2":             "3.5.2",
+		"google-chrome":      "Installed",
+	}
+
+	got, err := parseBrewInfo([]byte(readFixture(t, "packagedb", "brew-info.json")))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v but got %v", expected, got)
+	}
+	if _, err := parseBrewInfo([]byte("Error: No available formula\n")); err == nil {
+		t.Error("expected an error for output that is not JSON")
+	}
+}
+
+// writeScoopApps creates a Scoop apps directory with a manifest for each
+// app, keyed by name
+func writeScoopApps(t *testing.T, apps map[string]string) string {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), "apps")
+	for name, manifest := range apps {
+		current := filepath.Join(dir, name, "current")
+		if err := os.MkdirAll(current, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if manifest == "" {
+			continue
+		}
+		if err := os.WriteFile(filepath.Join(current, "manifest.json"), []byte(manifest), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestDetectFromPackageDatabases(t *testing.T) {
+	dpkgStatus := filepath.Join("testdata", "packagedb", "status")
+	scoopApps := writeScoopApps(t, map[string]string{
+		"git":   `{"version": "2.45.2.windows.1", "url": "https://github.com/git-for-windows/git/releases/download/v2.45.2.windows.1/PortableGit-2.45.2-64-bit.7z.exe"}`,
+		"7zip":  `{"version": "24.07"}`,
+		"scoop": "",
+	})
+
+	testCases := []struct {
+		name            string
+		dpkgStatus      string
+		scoopApps       []string
+		exes            []string
+		responses       map[string]runnertest.Response
+		winget          bool
+		languages       map[string]string
+		tools           map[string]string
+		packageManagers map[string]string
+		editors         map[string]string
+		sources         map[string]string
+		databases       []string
+		warnings        int
+	}{
+		{
+			name:            "dpkg",
+			dpkgStatus:      dpkgStatus,
+			languages:       map[string]string{"Node.js": "18.19.1+dfsg", "Python": "3.12.3", "Python 3": "3.12.3"},
+			tools:           map[string]string{"Git": "2.43.0"},
+			packageManagers: map[string]string{"apt": "2.7.14build2"},
+			editors:         map[string]string{},
+			sources: map[string]string{
+				"Node.js":  "package-db:dpkg:nodejs",
+				"Python":   "package-db:dpkg:python3.12",
+				"Python 3": "package-db:dpkg:python3.12",
+				"Git":      "package-db:dpkg:git",
+				"apt":      "package-db:dpkg:apt",
+			},
+			databases: []string{"dpkg"},
+		},
+		{
+			name:            "Homebrew",
+			exes:            []string{"/opt/homebrew/bin/brew"},
+			responses:       map[string]runnertest.Response{brewInfoCommand: {Output: readFixture(t, "packagedb", "brew-info.json")}},
+			languages:       map[string]string{"Node.js": "20.15.0", "Python": "3.12.4", "Python 3": "3.12.4"},
+			tools:           map[string]string{"Git": "2.45.2", "iTerm2": "3.5.2"},
+			packageManagers: map[string]string{"Homebrew": "Installed"},
+			editors:         map[string]string{"VS Code": "1.90.2"},
+			databases:       []string{"homebrew"},
+		},
+		{
+			name:            "dpkg and Homebrew",
+			dpkgStatus:      dpkgStatus,
+			exes:            []string{"/opt/homebrew/bin/brew"},
+			responses:       map[string]runnertest.Response{brewInfoCommand: {Output: readFixture(t, "packagedb", "brew-info.json")}},
+			languages:       map[string]string{"Node.js": "18.19.1+dfsg", "Python": "3.12.3", "Python 3": "3.12.3"},
+			tools:           map[string]string{"Git": "2.43.0", "iTerm2": "3.5.2"},
+			packageManagers: map[string]string{"apt": "2.7.14build2", "Homebrew": "Installed"},
+			editors:         map[string]string{"VS Code": "1.90.2"},
+			databases:       []string{"dpkg", "homebrew"},
+		},
+		{
+			name:            "Scoop",
+			scoopApps:       []string{scoopApps, filepath.Join(t.TempDir(), "missing")},
+			languages:       map[string]string{},
+			tools:           map[string]string{"Git": "2.45.2.windows.1"},
+			packageManagers: map[string]string{"Scoop": "Installed"},
+			editors:         map[string]string{},
+			databases:       []string{"scoop"},
+		},
+		{
+			name:            "winget",
+			exes:            []string{`/windows/winget`},
+			winget:          true,
+			languages:       map[string]string{"Python": "3.12.4", "Python 3": "3.12.4"},
+			tools:           map[string]string{"Git": "2.45.2"},
+			packageManagers: map[string]string{"Winget": "1.8.1791"},
+			editors:         map[string]string{"VS Code": "1.91.0"},
+			databases:       []string{"winget"},
+		},
+		{
+			name:            "brew fails",
+			exes:            []string{"/opt/homebrew/bin/brew"},
+			responses:       map[string]runnertest.Response{brewInfoCommand: {Stderr: "Error: Cannot read the Homebrew prefix\n", Err: errors.New("exit status 1")}},
+			languages:       map[string]string{},
+			tools:           map[string]string{},
+			packageManagers: map[string]string{},
+			editors:         map[string]string{},
+			databases:       []string{},
+			warnings:        1,
+		},
+		{
+			name:            "No package database",
+			languages:       map[string]string{},
+			tools:           map[string]string{},
+			packageManagers: map[string]string{},
+			editors:         map[string]string{},
+			databases:       []string{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			responses := tc.responses
+			if tc.winget {
+				export := filepath.Join(tempDir, "winget-export.json")
+				if err := os.WriteFile(export, []byte(readFixture(t, "packagedb", "winget-export.json")), 0644); err != nil {
+					t.Fatal(err)
+				}
+				responses = map[string]runnertest.Response{fmt.Sprintf(wingetExportCommand, export): {}}
+			}
+			dirs := packageDBPaths{
+				DpkgStatus: cmp.Or(tc.dpkgStatus, filepath.Join(tempDir, "status")),
+				ScoopApps:  tc.scoopApps,
+				TempDir:    tempDir,
+			}
+			r := &runnertest.Runner{Responses: responses}
+			env := &types.EnvironmentData{}
+			detectFromPackageDatabases(context.Background(), env, r, runnertest.NewPath([]string{"/opt/homebrew/bin", "/windows"}, tc.exes...), dirs)
+
+			for _, category := range []struct {
+				name          string
+				expected, got map[string]string
+			}{
+				{"languages", tc.languages, env.ConfiguredLanguages},
+				{"tools", tc.tools, env.Tools},
+				{"package managers", tc.packageManagers, env.PackageManagers},
+				{"editors", tc.editors, env.CodeEditors},
+			} {
+				if !reflect.DeepEqual(category.got, category.expected) {
+					t.Errorf("expected %s %v but got %v", category.name, category.expected, category.got)
+				}
+			}
+			if tc.sources != nil && !reflect.DeepEqual(env.ToolSources, tc.sources) {
+				t.Errorf("expected sources %v but got %v", tc.sources, env.ToolSources)
+			}
+			if env.FastScan == nil || !reflect.DeepEqual(env.FastScan.Databases, tc.databases) {
+				t.Fatalf("expected the databases %v to be recorded but got %+v", tc.databases, env.FastScan)
+			}
+			if len(env.FastScan.Caveats) < len(packageDBCaveats) {
+				t.Errorf("expected the caveats of every fast scan but got %q", env.FastScan.Caveats)
+			}
+			if len(env.Warnings) != tc.warnings {
+				t.Errorf("expected %d warnings but got %q", tc.warnings, env.Warnings)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/runner/runnertest"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+// slowRunner answers version commands after a delay and records how many
+// ran at once
+type slowRunner struct {
+	mu       sync.Mutex
+	running  int
+	maxSeen  int
+	delay    time.Duration
+	failures map[string]bool
+}
+
+func (r *slowRunner) CombinedOutput(ctx context.Context, name string, args ...string) (string, error) {
+	stdout, stderr, err := r.Output(ctx, name, args...)
+	return stdout + stderr, err
+}
+
+func (r *slowRunner) Output(ctx context.Context, name string, args ...string) (string, string, error) {
+	r.mu.Lock()
+	r.running++
+	r.maxSeen = max(r.maxSeen, r.running)
+	r.mu.Unlock()
+
+	time.Sleep(r.delay)
+
+	r.mu.Lock()
+	r.running--
+	r.mu.Unlock()
+	if r.failures[name] {
+		return "", "error while loading shared libraries: libicui18n.so.72", fmt.Errorf("exit status 127")
+	}
+	return name + " version 1.0." + name[len("tool"):] + "\n", "", nil
+}
+
+func TestDetectExecutablesInParallel(t *testing.T) {
+	var executables []Executable
+	var paths []string
+	for i := 0; i < 24; i++ {
+		command := fmt.Sprintf("tool%d", i)
+		executables = append(executables, Executable{
+			Name:         fmt.Sprintf("Tool %02d", i),
+			Command:      command,
+			VersionArg:   "--version",
+			VersionRegex: regexp.MustCompile(`version ([\d.]+)`),
+		})
+		paths = append(paths, "/usr/bin/"+command)
+	}
+	path := runnertest.NewPath([]string{"/usr/bin"}, paths...)
+	failures := map[string]bool{"tool3": true, "tool17": true}
+
+	// A single worker runs everything in order and is the reference
+	scan := func(workers int) (*types.EnvironmentData, map[string]string, int) {
+		r := &slowRunner{delay: 5 * time.Millisecond, failures: failures}
+		env := &types.EnvironmentData{}
+		found := make(map[string]string)
+		detectExecutablesWith(context.Background(), r, path, nil, nil, workers, env, executables, found)
+		return env, found, r.maxSeen
+	}
+	wantEnv, wantFound, sequential := scan(1)
+	if sequential != 1 {
+		t.Fatalf("expected one worker to run one command at a time but saw %d", sequential)
+	}
+	if len(wantFound) != len(executables) || len(wantEnv.BrokenTools) != 2 {
+		t.Fatalf("expected every tool found and 2 broken but got %v and %v", wantFound, wantEnv.BrokenTools)
+	}
+
+	for _, workers := range []int{4, 8, 100} {
+		t.Run(fmt.Sprintf("%d workers", workers), func(t *testing.T) {
+			env, found, maxSeen := scan(workers)
+			if maxSeen > workers {
+				t.Errorf("expected at most %d commands at once but saw %d", workers, maxSeen)
+			}
+			if maxSeen < 2 {
+				t.Errorf("expected commands to run in parallel but saw %d at once", maxSeen)
+			}
+			if !reflect.DeepEqual(found, wantFound) {
+				t.Errorf("expected versions %v but got %v", wantFound, found)
+			}
+			if !reflect.DeepEqual(env, wantEnv) {
+				t.Errorf("expected the same environment as a sequential scan but got %+v", env)
+			}
+		})
+	}
+}
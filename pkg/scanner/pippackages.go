@@ -0,0 +1,66 @@
+package scanner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/runner"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+// DetectPipPackages records the packages installed with 'pip install
+// --user' under GlobalPackages["pip"], leaving out those only installed as
+// dependencies of others. The system site-packages are left out: their
+// packages mostly come from the OS package manager.
+func DetectPipPackages(ctx context.Context, envData *types.EnvironmentData) {
+	detectPipPackages(ctx, envData, runner.Default, runner.DefaultPath)
+}
+
+func detectPipPackages(ctx context.Context, envData *types.EnvironmentData, r runner.Runner, path runner.PathIndex) {
+	if _, err := path.LookPath("pip3"); err != nil {
+		return
+	}
+	stdout, stderr, err := r.Output(ctx, "pip3", "list", "--user", "--not-required", "--format=json", "--disable-pip-version-check")
+	if err != nil {
+		message := firstLine(stderr)
+		if message == "" {
+			message = err.Error()
+		}
+		envData.Warnings = append(envData.Warnings, "could not list the pip packages: "+message)
+		return
+	}
+	packages, err := parsePipList(stdout)
+	if err != nil {
+		envData.Warnings = append(envData.Warnings, fmt.Sprintf("could not read the pip packages: %v", err))
+		return
+	}
+	if len(packages) == 0 {
+		return
+	}
+	log.Printf("Found %d pip packages", len(packages))
+	if envData.GlobalPackages == nil {
+		envData.GlobalPackages = make(map[string]map[string]string)
+	}
+	envData.GlobalPackages["pip"] = packages
+}
+
+// parsePipList returns the packages and versions in the output of 'pip
+// list --format=json'
+func parsePipList(output string) (map[string]string, error) {
+	var list []struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	}
+	if err := json.Unmarshal([]byte(output), &list); err != nil {
+		return nil, err
+	}
+	packages := make(map[string]string, len(list))
+	for _, pkg := range list {
+		if pkg.Name != "" && pkg.Version != "" {
+			packages[pkg.Name] = pkg.Version
+		}
+	}
+	return packages, nil
+}
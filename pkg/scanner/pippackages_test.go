@@ -0,0 +1,72 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/runner/runnertest"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+func TestDetectPipPackages(t *testing.T) {
+	testCases := []struct {
+		name     string
+		response runnertest.Response
+		expected map[string]map[string]string
+		warning  string
+	}{
+		{
+			name:     "Packages",
+			response: runnertest.Response{Output: `[{"name": "httpie", "version": "3.2.2"}, {"name": "pre-commit", "version": "3.7.0"}]`},
+			expected: map[string]map[string]string{"pip": {"httpie": "3.2.2", "pre-commit": "3.7.0"}},
+		},
+		{
+			name:     "No packages",
+			response: runnertest.Response{Output: "[]\n"},
+		},
+		{
+			name: "Failed",
+			response: runnertest.Response{
+				Stderr: "ERROR: Exception:\nTraceback (most recent call last):\n",
+				Err:    errors.New("exit status 2"),
+			},
+			warning: "could not list the pip packages: ERROR: Exception:",
+		},
+		{
+			name:     "Invalid JSON",
+			response: runnertest.Response{Output: "Package    Version\n---------- -------\n"},
+			warning:  "could not read the pip packages:",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &runnertest.Runner{Responses: map[string]runnertest.Response{
+				"pip3 list --user --not-required --format=json --disable-pip-version-check": tc.response,
+			}}
+			env := &types.EnvironmentData{}
+
+			detectPipPackages(context.Background(), env, r, runnertest.NewPath([]string{"/usr/bin"}, "/usr/bin/pip3"))
+
+			if !reflect.DeepEqual(env.GlobalPackages, tc.expected) {
+				t.Errorf("expected global packages %v but got %v", tc.expected, env.GlobalPackages)
+			}
+			switch {
+			case tc.warning == "" && len(env.Warnings) != 0:
+				t.Errorf("expected no warnings but got %q", env.Warnings)
+			case tc.warning != "" && (len(env.Warnings) != 1 || !strings.HasPrefix(env.Warnings[0], tc.warning)):
+				t.Errorf("expected a warning starting with %q but got %q", tc.warning, env.Warnings)
+			}
+		})
+	}
+
+	r := &runnertest.Runner{}
+	env := &types.EnvironmentData{}
+	detectPipPackages(context.Background(), env, r, runnertest.NewPath(nil))
+	if calls := r.Calls(); len(calls) != 0 || env.GlobalPackages != nil {
+		t.Errorf("expected nothing run or recorded without pip3 but got %v and %v", calls, env.GlobalPackages)
+	}
+}
@@ -0,0 +1,234 @@
+package scanner
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/toolversions"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+// DefaultProjectDepth is how many directories below the project root are
+// searched for manifests, enough for monorepo packages like packages/web
+const DefaultProjectDepth = 3
+
+// projectManifests are the files that describe how a project is built and
+// run, recorded wherever they appear within DefaultProjectDepth. .env files
+// are left out: they hold values, not requirements.
+var projectManifests = map[string]bool{
+	"go.mod": true, "go.work": true,
+	"package.json": true, "package-lock.json": true, "yarn.lock": true, "pnpm-lock.yaml": true,
+	".nvmrc": true, ".node-version": true, ".python-version": true, ".ruby-version": true,
+	".go-version": true, ".java-version": true, ".tool-versions": true,
+	"Dockerfile": true, "docker-compose.yml": true, "docker-compose.yaml": true, "compose.yaml": true,
+	"requirements.txt": true, "pyproject.toml": true, "Pipfile": true, "poetry.lock": true,
+	"Cargo.toml": true, "Gemfile": true, "Gemfile.lock": true, "composer.json": true,
+	"pom.xml": true, "build.gradle": true, "build.gradle.kts": true, "Makefile": true,
+}
+
+// skippedProjectDirs hold dependencies, build output or version control
+// rather than the project's own manifests
+var skippedProjectDirs = map[string]bool{
+	".git": true, ".hg": true, ".svn": true, "node_modules": true, "vendor": true,
+	".venv": true, "venv": true, "__pycache__": true, "target": true, "dist": true, "build": true,
+}
+
+// packageJSONEngines maps the keys of package.json "engines" to the names
+// the scanner uses
+var packageJSONEngines = map[string]string{
+	"node": "Node.js",
+	"npm":  "npm",
+	"yarn": "yarn",
+	"pnpm": "pnpm",
+}
+
+// projectDir, when set, replaces the home directory in config file detection
+var projectDir string
+
+// UseProjectDir makes subsequent scans detect the manifests of the project
+// in dir instead of the config files of the home directory. An empty dir
+// restores home scanning.
+func UseProjectDir(dir string) {
+	projectDir = dir
+}
+
+// DetectProjectFiles records the manifests found in dir and its
+// subdirectories, up to DefaultProjectDepth deep, as config files and in
+// envData.Project, together with the tool versions the project asks for
+// and the package manager its package.json pins for corepack.
+// The walk shares DefaultGlobBudget; a truncated walk is reported in
+// envData.Warnings.
+func DetectProjectFiles(ctx context.Context, envData *types.EnvironmentData, dir string) {
+	if envData.Project == nil {
+		envData.Project = &types.ProjectInfo{Path: dir}
+	}
+
+	manifests, warning, err := walkProject(ctx, dir, DefaultProjectDepth, DefaultGlobBudget)
+	if err != nil && ctx.Err() == nil {
+		log.Printf("Warning: Could not scan project %s: %v", dir, err)
+	}
+	if warning != "" {
+		envData.Warnings = append(envData.Warnings, warning)
+	}
+	for _, rel := range manifests {
+		addConfigFile(envData, filepath.Join(dir, filepath.FromSlash(rel)))
+		envData.Project.Manifests = append(envData.Project.Manifests, rel)
+	}
+
+	requirements := projectRequirements(dir)
+	if len(requirements) > 0 {
+		envData.Project.Requirements = requirements
+	}
+
+	// Errors reading package.json were logged with its engines
+	if manifest, err := readPackageJSON(filepath.Join(dir, "package.json")); err == nil && manifest.PackageManager != "" {
+		if envData.Node == nil {
+			envData.Node = &types.NodeEnvironment{}
+		}
+		envData.Node.PackageManager = manifest.PackageManager
+	}
+}
+
+// walkProject returns the manifests under root as slash-separated paths
+// relative to root, in lexical order. Skipped directories and symlinks are
+// not entered. When the budget runs out the manifests found so far are
+// returned together with a warning describing the truncation.
+func walkProject(ctx context.Context, root string, depth int, budget GlobBudget) ([]string, string, error) {
+	var found []string
+	entries := 0
+	deadline := time.Now().Add(budget.MaxDuration)
+
+	err := filepath.WalkDir(root, func(p string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if p == root {
+				return err
+			}
+			// Unreadable directories are skipped like missing ones
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if p == root {
+			return nil
+		}
+		entries++
+		if budget.MaxFiles > 0 && entries > budget.MaxFiles {
+			return &errBudget{fmt.Sprintf("stopped after %d files", budget.MaxFiles)}
+		}
+		if budget.MaxDuration > 0 && time.Now().After(deadline) {
+			return &errBudget{fmt.Sprintf("stopped after %s", budget.MaxDuration)}
+		}
+
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		if entry.IsDir() {
+			if skippedProjectDirs[entry.Name()] || strings.Count(rel, "/") >= depth {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if entry.Type().IsRegular() && projectManifests[entry.Name()] {
+			found = append(found, rel)
+		}
+		return nil
+	})
+
+	var stopped *errBudget
+	switch {
+	case err == nil:
+		return found, "", nil
+	case errors.As(err, &stopped):
+		return found, fmt.Sprintf("project %s %s; manifests are incomplete", root, stopped.reason), nil
+	default:
+		return found, "", err
+	}
+}
+
+// projectRequirements infers the tool versions the project in dir asks for
+// from the manifests at its root. Version files such as .nvmrc and
+// .tool-versions name the version used for development, so they win over
+// the ranges in package.json "engines" and the minimum in go.mod.
+func projectRequirements(dir string) map[string]string {
+	requirements := make(map[string]string)
+
+	if manifest, err := readPackageJSON(filepath.Join(dir, "package.json")); err == nil {
+		for key, constraint := range manifest.Engines {
+			if name, ok := packageJSONEngines[key]; ok && constraint != "" {
+				requirements[name] = constraint
+			}
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		log.Printf("Warning: Could not read package.json engines: %v", err)
+	}
+
+	if goVersion, err := readGoDirective(filepath.Join(dir, "go.mod")); err == nil && goVersion != "" {
+		requirements["Go"] = ">=" + goVersion
+	} else if err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Printf("Warning: Could not read go.mod: %v", err)
+	}
+
+	// LoadDir fails when the project has no version files
+	if pins, err := toolversions.LoadDir(dir); err == nil {
+		for _, versions := range []map[string]string{pins.Languages, pins.Tools} {
+			for name, constraint := range versions {
+				if constraint != "" {
+					requirements[name] = constraint
+				}
+			}
+		}
+	}
+	return requirements
+}
+
+// packageJSON is what the scanner reads of a package.json file
+type packageJSON struct {
+	Engines map[string]string `json:"engines"`
+	// PackageManager pins the package manager corepack runs, such as
+	// "pnpm@9.1.0"
+	PackageManager string `json:"packageManager"`
+}
+
+// readPackageJSON reads a package.json file
+func readPackageJSON(path string) (*packageJSON, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var manifest packageJSON
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, err
+	}
+	return &manifest, nil
+}
+
+// readGoDirective returns the version of the go directive of a go.mod
+// file, the oldest Go release that can build the module
+func readGoDirective(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	lines := bufio.NewScanner(file)
+	for lines.Scan() {
+		fields := strings.Fields(lines.Text())
+		if len(fields) == 2 && fields[0] == "go" {
+			return fields[1], nil
+		}
+	}
+	return "", lines.Err()
+}
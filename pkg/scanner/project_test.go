@@ -0,0 +1,158 @@
+package scanner
+
+import (
+	"context"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+// writeProject creates files, keyed by slash-separated path, under a new
+// directory and returns it
+func writeProject(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestDetectProjectFiles(t *testing.T) {
+	testCases := []struct {
+		name         string
+		files        map[string]string
+		manifests    []string
+		requirements map[string]string
+		// packageManager is the packageManager field of the root package.json
+		packageManager string
+	}{
+		{
+			name: "Node monorepo",
+			files: map[string]string{
+				"package.json":                       `{"name": "web", "packageManager": "pnpm@9.1.0", "engines": {"node": ">=18", "npm": ">=9", "vscode": "^1.80.0"}}`,
+				"pnpm-lock.yaml":                     "lockfileVersion: '6.0'\n",
+				"packages/api/package.json":          `{"engines": {"node": ">=20"}}`,
+				"packages/api/Dockerfile":            "FROM node:20\n",
+				"packages/api/src/deep/go.mod":       "module too/deep\n",
+				"node_modules/left-pad/package.json": `{"engines": {"node": ">=0.10"}}`,
+				".env":                               "API_KEY=secret\n",
+				"README.md":                          "# web\n",
+			},
+			manifests: []string{"package.json", "packages/api/Dockerfile", "packages/api/package.json", "pnpm-lock.yaml"},
+			// Only the root package.json decides what the project asks for
+			requirements:   map[string]string{"Node.js": ">=18", "npm": ">=9"},
+			packageManager: "pnpm@9.1.0",
+		},
+		{
+			name: "Version files win over engines and go.mod",
+			files: map[string]string{
+				"package.json":   `{"engines": {"node": ">=18"}}`,
+				".nvmrc":         "v20.11.1\n",
+				"go.mod":         "module example.com/app\n\ngo 1.22\n\ntoolchain go1.22.3\n",
+				".tool-versions": "golang 1.23.1\nterraform 1.7\n",
+			},
+			manifests:    []string{".nvmrc", ".tool-versions", "go.mod", "package.json"},
+			requirements: map[string]string{"Node.js": "20.11.1", "Go": "1.23.1", "Terraform": "1.7.x"},
+		},
+		{
+			name: "Go module",
+			files: map[string]string{
+				"go.mod":                    "module example.com/app\n\ngo 1.22\n",
+				"go.sum":                    "",
+				"vendor/example.com/go.mod": "module vendored\n\ngo 1.16\n",
+				"deploy/compose.yaml":       "services: {}\n",
+			},
+			manifests:    []string{"deploy/compose.yaml", "go.mod"},
+			requirements: map[string]string{"Go": ">=1.22"},
+		},
+		{
+			name:  "Nothing to find",
+			files: map[string]string{"notes.txt": "hello\n"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := writeProject(t, tc.files)
+			var envData types.EnvironmentData
+			DetectProjectFiles(context.Background(), &envData, dir)
+
+			if envData.Project == nil || envData.Project.Path != dir {
+				t.Fatalf("expected the project to be recorded at %s but got %+v", dir, envData.Project)
+			}
+			if !slices.Equal(envData.Project.Manifests, tc.manifests) {
+				t.Errorf("expected manifests %v but got %v", tc.manifests, envData.Project.Manifests)
+			}
+			if len(envData.ConfigFiles) != len(tc.manifests) || len(envData.ConfigFileInfo) != len(tc.manifests) {
+				t.Errorf("expected every manifest as a config file but got %v", envData.ConfigFiles)
+			}
+			for _, path := range envData.ConfigFiles {
+				if !strings.HasPrefix(path, dir) {
+					t.Errorf("expected config files inside the project but got %s", path)
+				}
+			}
+			if !maps.Equal(envData.Project.Requirements, tc.requirements) {
+				t.Errorf("expected requirements %v but got %v", tc.requirements, envData.Project.Requirements)
+			}
+			var packageManager string
+			if envData.Node != nil {
+				packageManager = envData.Node.PackageManager
+			}
+			if packageManager != tc.packageManager {
+				t.Errorf("expected packageManager %q but got %q", tc.packageManager, packageManager)
+			}
+		})
+	}
+}
+
+func TestDetectConfigFilesUsesProjectDir(t *testing.T) {
+	home := writeProject(t, map[string]string{".gitconfig": "[user]\n"})
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	project := writeProject(t, map[string]string{"go.mod": "module example.com/app\n"})
+
+	UseProjectDir(project)
+	defer UseProjectDir("")
+	var envData types.EnvironmentData
+	DetectConfigFilesContext(context.Background(), &envData)
+	expected := []string{filepath.Join(project, "go.mod")}
+	if !slices.Equal(envData.ConfigFiles, expected) {
+		t.Errorf("expected config files %v but got %v", expected, envData.ConfigFiles)
+	}
+
+	UseProjectDir("")
+	envData = types.EnvironmentData{}
+	DetectConfigFilesContext(context.Background(), &envData)
+	expected = []string{filepath.Join(home, ".gitconfig")}
+	if !slices.Equal(envData.ConfigFiles, expected) || envData.Project != nil {
+		t.Errorf("expected the home directory to be scanned again but got %v", envData.ConfigFiles)
+	}
+}
+
+func TestWalkProjectBudget(t *testing.T) {
+	files := map[string]string{}
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		files[name+"/package.json"] = "{}"
+	}
+	dir := writeProject(t, files)
+
+	found, warning, err := walkProject(context.Background(), dir, DefaultProjectDepth, GlobBudget{MaxFiles: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) >= len(files) || !strings.Contains(warning, "stopped after 4 files") {
+		t.Errorf("expected the walk to stop early with a warning but got %v, %q", found, warning)
+	}
+}
@@ -0,0 +1,319 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/config"
+	"github.com/MRQ67/stackmatch-cli/pkg/installer"
+	"github.com/MRQ67/stackmatch-cli/pkg/runner"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+// ownerQuery asks a system package manager which package owns a file
+type ownerQuery struct {
+	Manager types.PackageManagerType
+	Command string
+	Args    []string
+	// unowned is part of the error printed for files no package owns
+	unowned string
+	// parse returns the owning package from the command's output
+	parse func(output string) string
+}
+
+// ownerQueries are the system package managers asked who owns an
+// executable. Only the first one on PATH is asked, since a file is only
+// registered with the system's own package manager.
+var ownerQueries = []ownerQuery{
+	{Manager: types.TypeApt, Command: "dpkg", Args: []string{"-S"}, unowned: "no path found matching pattern", parse: parseDpkgOwner},
+	{Manager: types.TypeDnf, Command: "rpm", Args: []string{"-qf", "--queryformat", "%{NAME}\n"}, unowned: "is not owned by any package", parse: parseFirstField},
+	{Manager: types.TypePacman, Command: "pacman", Args: []string{"-Qqo"}, unowned: "No package owns", parse: parseFirstField},
+}
+
+// owner is the package that owns an executable. An empty manager means the
+// system package manager doesn't know the file.
+type owner struct {
+	manager types.PackageManagerType
+	pkg     string
+}
+
+// importedPackage is a package a 'stackmatch import' recorded installing
+type importedPackage struct {
+	installation string
+	manager      types.PackageManagerType
+	pkg          string
+}
+
+// provenanceEvidence is what the scan, the installation records and the
+// package managers know about how one entry was installed. Nil pointers are
+// sources that know nothing about the entry.
+type provenanceEvidence struct {
+	scanSource string
+	imported   *importedPackage
+	owner      *owner
+}
+
+// DetectProvenance records how the languages, tools, package managers and
+// editors found were installed, by joining the source the scan recorded,
+// the installation records of 'stackmatch import' and the package owning
+// each executable. It runs after those categories were scanned.
+func DetectProvenance(ctx context.Context, envData *types.EnvironmentData) {
+	tracker, err := installer.NewInstallationTracker(config.TrackerFile())
+	var records []installer.InstallationRecord
+	if err != nil {
+		envData.Warnings = append(envData.Warnings, fmt.Sprintf("could not read the installation records to tell what stackmatch installed: %v", err))
+	} else {
+		records = tracker.ListInstallations()
+	}
+	detectProvenance(ctx, envData, runner.Default, runner.DefaultPath, concurrency, records)
+}
+
+func detectProvenance(ctx context.Context, envData *types.EnvironmentData, r runner.Runner, path runner.PathIndex, workers int, records []installer.InstallationRecord) {
+	commands := entryCommands()
+	var names []string
+	for _, entries := range []map[string]string{envData.ConfiguredLanguages, envData.Tools, envData.PackageManagers, envData.CodeEditors} {
+		for name := range entries {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	names = slices.Compact(names)
+
+	query, hasQuery := systemOwnerQuery(path)
+	owners := make([]*owner, len(names))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(max(workers, 1), len(names)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				command, known := commands[names[i]]
+				if !known || ctx.Err() != nil {
+					continue
+				}
+				file, err := path.LookPath(command)
+				if err != nil {
+					continue
+				}
+				owners[i] = queryOwner(ctx, r, query, hasQuery, file)
+			}
+		}()
+	}
+	for i := range names {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	// Newest first, so an entry is credited to the last import that
+	// installed it
+	completed := make([]installer.InstallationRecord, 0, len(records))
+	for _, record := range records {
+		if record.Status == installer.StatusCompleted {
+			completed = append(completed, record)
+		}
+	}
+	sort.SliceStable(completed, func(i, j int) bool { return completed[i].Timestamp.After(completed[j].Timestamp) })
+
+	for i, name := range names {
+		evidence := provenanceEvidence{
+			scanSource: envData.ToolSources[name],
+			imported:   findImport(completed, name, envData.ToolID(name)),
+			owner:      owners[i],
+		}
+		provenance, ok := resolveProvenance(evidence)
+		if !ok {
+			continue
+		}
+		if envData.Provenance == nil {
+			envData.Provenance = make(map[string]types.Provenance)
+		}
+		envData.Provenance[name] = provenance
+	}
+}
+
+// resolveProvenance joins the evidence about an entry, or returns false when
+// there is none. The sources are taken in a fixed order: an import that
+// recorded installing the entry, then the source the scan recorded, then
+// the package owning the executable, then ProvenanceManual when the system
+// package manager was asked and doesn't know the file. The owning package
+// is kept along the way, and disagreements with an import record are
+// reported as a conflict.
+func resolveProvenance(e provenanceEvidence) (types.Provenance, bool) {
+	var p types.Provenance
+	if e.owner != nil && e.owner.manager != "" {
+		p.Manager, p.Package = e.owner.manager, e.owner.pkg
+	}
+
+	switch {
+	case e.imported != nil:
+		p.Source = types.ProvenanceImport
+		p.Installation = e.imported.installation
+		if e.imported.manager != "" {
+			p.Manager = e.imported.manager
+		}
+		p.Package = e.imported.pkg
+		switch {
+		case e.owner == nil:
+		case e.owner.manager == "":
+			p.Conflict = fmt.Sprintf("import %s recorded installing it, but no package owns it", e.imported.installation)
+		case e.imported.manager != "" && e.owner.manager != e.imported.manager:
+			p.Conflict = fmt.Sprintf("import %s recorded installing it through %s, but it belongs to the %s package %s", e.imported.installation, e.imported.manager, e.owner.manager, e.owner.pkg)
+		}
+	case e.scanSource != "":
+		p.Source = e.scanSource
+	case e.owner != nil && e.owner.manager != "":
+		p.Source = string(e.owner.manager)
+	case e.owner != nil:
+		p.Source = types.ProvenanceManual
+	default:
+		return types.Provenance{}, false
+	}
+	return p, true
+}
+
+// findImport returns the newest of records that installed the entry called
+// name, whose canonical ID is id. Records written before packages were
+// recorded with their entry are matched by package name instead.
+func findImport(records []installer.InstallationRecord, name, id string) *importedPackage {
+	mapping, mapped := installer.LookupMapping(id)
+	for _, record := range records {
+		for _, key := range slices.Sorted(maps.Keys(record.Packages)) {
+			pkg := record.Packages[key]
+			matches := pkg.Tool == name
+			if pkg.Tool == "" {
+				matches = strings.EqualFold(pkg.Name, id)
+				for _, mappedName := range mapping.Packages {
+					matches = matches || (mapped && pkg.Name == mappedName)
+				}
+				for _, mappedName := range mapping.Musl {
+					matches = matches || (mapped && pkg.Name == mappedName)
+				}
+			}
+			if matches {
+				return &importedPackage{installation: record.ID, manager: types.PackageManagerType(pkg.ManagerType), pkg: pkg.Name}
+			}
+		}
+	}
+	return nil
+}
+
+// systemOwnerQuery returns the first of ownerQueries on PATH. rpm answers
+// for dnf, or for yum on systems without dnf.
+func systemOwnerQuery(path runner.PathIndex) (ownerQuery, bool) {
+	for _, query := range ownerQueries {
+		if _, err := path.LookPath(query.Command); err != nil {
+			continue
+		}
+		if query.Command == "rpm" {
+			if _, err := path.LookPath("dnf"); err != nil {
+				if _, err := path.LookPath("yum"); err == nil {
+					query.Manager = types.TypeYum
+				}
+			}
+		}
+		return query, true
+	}
+	return ownerQuery{}, false
+}
+
+// queryOwner returns the package owning file: a Homebrew formula or cask
+// when file links into the Cellar or Caskroom, otherwise what the system
+// package manager says. When the system package manager doesn't know file,
+// the file its symlinks lead to is asked about too. Nil means nobody could
+// tell.
+func queryOwner(ctx context.Context, r runner.Runner, query ownerQuery, hasQuery bool, file string) *owner {
+	resolved, err := filepath.EvalSymlinks(file)
+	if err != nil {
+		resolved = file
+	}
+	if formula := homebrewPackage(resolved); formula != "" {
+		return &owner{manager: types.TypeHomebrew, pkg: formula}
+	}
+	if !hasQuery {
+		return nil
+	}
+
+	files := []string{file}
+	if resolved != file {
+		files = append(files, resolved)
+	}
+	for _, f := range files {
+		stdout, stderr, err := r.Output(ctx, query.Command, append(append([]string(nil), query.Args...), f)...)
+		if err == nil {
+			if pkg := query.parse(stdout); pkg != "" {
+				return &owner{manager: query.Manager, pkg: pkg}
+			}
+			continue
+		}
+		if !strings.Contains(stdout+stderr, query.unowned) {
+			return nil
+		}
+	}
+	return &owner{}
+}
+
+// homebrewPackage returns the formula or cask that path belongs to, from
+// paths like /opt/homebrew/Cellar/git/2.43.0/bin/git, or ""
+func homebrewPackage(path string) string {
+	parts := strings.Split(filepath.ToSlash(path), "/")
+	for i, part := range parts[:max(len(parts)-1, 0)] {
+		if part == "Cellar" || part == "Caskroom" {
+			return parts[i+1]
+		}
+	}
+	return ""
+}
+
+// parseDpkgOwner returns the package in 'dpkg -S' output such as
+// "git: /usr/bin/git". Files shared by several packages are listed as
+// "pkg1, pkg2: path"; the first is returned. Diversions are skipped.
+func parseDpkgOwner(output string) string {
+	for _, line := range strings.Split(output, "\n") {
+		if strings.HasPrefix(line, "diversion by") {
+			continue
+		}
+		packages, _, found := strings.Cut(line, ": ")
+		if !found {
+			continue
+		}
+		pkg, _, _ := strings.Cut(packages, ",")
+		// Multi-arch packages are listed as git:amd64
+		pkg, _, _ = strings.Cut(strings.TrimSpace(pkg), ":")
+		return pkg
+	}
+	return ""
+}
+
+// parseFirstField returns the first word of output
+func parseFirstField(output string) string {
+	fields := strings.Fields(output)
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[0]
+}
+
+// entryCommands maps the display name of every entry the scanner detects on
+// this OS, including the user's custom detectors, to its command
+func entryCommands() map[string]string {
+	lists := [][]Executable{languageExecutables, toolExecutables, editorCommands(runtime.GOOS), crossPlatformPackageManagers, osPackageManagers[runtime.GOOS]}
+	for _, category := range []string{types.CategoryLanguages, types.CategoryTools, types.CategoryPackageManagers, types.CategoryEditors} {
+		lists = append(lists, detectorConfig.executables(category))
+	}
+	commands := make(map[string]string)
+	for _, list := range lists {
+		for _, exe := range list {
+			commands[exe.Name] = exe.Command
+		}
+	}
+	return commands
+}
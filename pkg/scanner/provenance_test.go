@@ -0,0 +1,164 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/installer"
+	"github.com/MRQ67/stackmatch-cli/pkg/runner/runnertest"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+func TestResolveProvenance(t *testing.T) {
+	imported := &importedPackage{installation: "inst_1", manager: types.TypeApt, pkg: "git"}
+	testCases := []struct {
+		name     string
+		evidence provenanceEvidence
+		expected *types.Provenance
+	}{
+		{
+			name:     "nothing known",
+			evidence: provenanceEvidence{},
+		},
+		{
+			name:     "owned by apt",
+			evidence: provenanceEvidence{owner: &owner{manager: types.TypeApt, pkg: "git"}},
+			expected: &types.Provenance{Source: "apt", Manager: types.TypeApt, Package: "git"},
+		},
+		{
+			name:     "no package owns it",
+			evidence: provenanceEvidence{owner: &owner{}},
+			expected: &types.Provenance{Source: types.ProvenanceManual},
+		},
+		{
+			name:     "scan source",
+			evidence: provenanceEvidence{scanSource: "login-shell:zsh"},
+			expected: &types.Provenance{Source: "login-shell:zsh"},
+		},
+		{
+			name:     "scan source wins over the owner",
+			evidence: provenanceEvidence{scanSource: "dnf-module:nodejs:18", owner: &owner{manager: types.TypeDnf, pkg: "nodejs"}},
+			expected: &types.Provenance{Source: "dnf-module:nodejs:18", Manager: types.TypeDnf, Package: "nodejs"},
+		},
+		{
+			name:     "import without owner query",
+			evidence: provenanceEvidence{imported: imported},
+			expected: &types.Provenance{Source: types.ProvenanceImport, Manager: types.TypeApt, Package: "git", Installation: "inst_1"},
+		},
+		{
+			name:     "import agreeing with the owner",
+			evidence: provenanceEvidence{imported: imported, owner: &owner{manager: types.TypeApt, pkg: "git"}},
+			expected: &types.Provenance{Source: types.ProvenanceImport, Manager: types.TypeApt, Package: "git", Installation: "inst_1"},
+		},
+		{
+			name:     "import wins over the scan source",
+			evidence: provenanceEvidence{scanSource: "login-shell:bash", imported: imported},
+			expected: &types.Provenance{Source: types.ProvenanceImport, Manager: types.TypeApt, Package: "git", Installation: "inst_1"},
+		},
+		{
+			name:     "import of a file no package owns",
+			evidence: provenanceEvidence{imported: imported, owner: &owner{}},
+			expected: &types.Provenance{
+				Source: types.ProvenanceImport, Manager: types.TypeApt, Package: "git", Installation: "inst_1",
+				Conflict: "import inst_1 recorded installing it, but no package owns it",
+			},
+		},
+		{
+			name:     "import through another manager",
+			evidence: provenanceEvidence{imported: imported, owner: &owner{manager: types.TypeHomebrew, pkg: "git"}},
+			expected: &types.Provenance{
+				Source: types.ProvenanceImport, Manager: types.TypeApt, Package: "git", Installation: "inst_1",
+				Conflict: "import inst_1 recorded installing it through apt, but it belongs to the homebrew package git",
+			},
+		},
+		{
+			name:     "old import record without manager",
+			evidence: provenanceEvidence{imported: &importedPackage{installation: "inst_0", pkg: "git"}, owner: &owner{manager: types.TypeApt, pkg: "git"}},
+			expected: &types.Provenance{Source: types.ProvenanceImport, Manager: types.TypeApt, Package: "git", Installation: "inst_0"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, ok := resolveProvenance(tc.evidence)
+			if tc.expected == nil {
+				if ok {
+					t.Errorf("expected no provenance but got %+v", actual)
+				}
+				return
+			}
+			if !ok || actual != *tc.expected {
+				t.Errorf("expected %+v but got %+v", *tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestDetectProvenance(t *testing.T) {
+	unowned := errors.New("exit status 1")
+	r := &runnertest.Runner{Responses: map[string]runnertest.Response{
+		"dpkg -S /usr/bin/git":        {Output: "git: /usr/bin/git\n"},
+		"dpkg -S /usr/bin/docker":     {Output: "diversion by foo from: /usr/bin/docker\ndocker.io:amd64: /usr/bin/docker\n"},
+		"dpkg -S /usr/local/bin/node": {Stderr: "dpkg-query: no path found matching pattern /usr/local/bin/node\n", Err: unowned},
+		"dpkg -S /usr/local/bin/go":   {Stderr: "dpkg-query: no path found matching pattern /usr/local/bin/go\n", Err: unowned},
+		"dpkg -S /usr/bin/make":       {Stderr: "dpkg-query: database is locked\n", Err: unowned},
+	}}
+	path := runnertest.NewPath([]string{"/usr/bin", "/usr/local/bin"},
+		"/usr/bin/dpkg", "/usr/bin/git", "/usr/bin/docker", "/usr/local/bin/node", "/usr/local/bin/go", "/usr/bin/make")
+	env := &types.EnvironmentData{
+		ConfiguredLanguages: map[string]string{"Node.js": "20.11.0", "Go": "1.22.0", "Python": "3.12.1"},
+		Tools:               map[string]string{"Git": "2.39.2", "Docker": "24.0.7", "Make": "4.3"},
+		ToolSources:         map[string]string{"Python": "login-shell:zsh"},
+	}
+	records := []installer.InstallationRecord{
+		{
+			ID: "inst_old", Timestamp: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), Status: installer.StatusCompleted,
+			Packages: map[string]installer.PackageInfo{"golang-go": {Name: "golang-go"}},
+		},
+		{
+			ID: "inst_new", Timestamp: time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC), Status: installer.StatusCompleted,
+			Packages: map[string]installer.PackageInfo{"git": {Name: "git", ManagerType: "apt", Tool: "Git"}},
+		},
+		{
+			ID: "inst_reverted", Timestamp: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), Status: installer.StatusRolledBack,
+			Packages: map[string]installer.PackageInfo{"docker.io": {Name: "docker.io", ManagerType: "apt", Tool: "Docker"}},
+		},
+	}
+
+	detectProvenance(context.Background(), env, r, path, 2, records)
+
+	expected := map[string]types.Provenance{
+		"Git":     {Source: types.ProvenanceImport, Manager: types.TypeApt, Package: "git", Installation: "inst_new"},
+		"Docker":  {Source: "apt", Manager: types.TypeApt, Package: "docker.io"},
+		"Node.js": {Source: types.ProvenanceManual},
+		"Go": {
+			Source: types.ProvenanceImport, Package: "golang-go", Installation: "inst_old",
+			Conflict: "import inst_old recorded installing it, but no package owns it",
+		},
+		"Python": {Source: "login-shell:zsh"},
+	}
+	if !reflect.DeepEqual(env.Provenance, expected) {
+		t.Errorf("expected provenance %+v but got %+v", expected, env.Provenance)
+	}
+}
+
+func TestHomebrewPackage(t *testing.T) {
+	testCases := []struct {
+		path     string
+		expected string
+	}{
+		{path: "/opt/homebrew/Cellar/git/2.43.0/bin/git", expected: "git"},
+		{path: "/usr/local/Caskroom/visual-studio-code/1.86.0/code", expected: "visual-studio-code"},
+		{path: "/usr/bin/git", expected: ""},
+		{path: "/opt/homebrew/Cellar", expected: ""},
+	}
+
+	for _, tc := range testCases {
+		if actual := homebrewPackage(tc.path); actual != tc.expected {
+			t.Errorf("expected %q to belong to %q but got %q", tc.path, tc.expected, actual)
+		}
+	}
+}
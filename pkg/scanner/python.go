@@ -0,0 +1,185 @@
+package scanner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/runner"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+// pythonProbe prints the version and sys.prefix of the interpreter running it
+const pythonProbe = "import platform, sys; print(platform.python_version()); print(sys.prefix)"
+
+// numericVersion matches versions pyenv can compare with an interpreter's,
+// unlike names such as miniconda3-latest or a pyenv-virtualenv environment
+var numericVersion = regexp.MustCompile(`^\d+(\.\d+)*$`)
+
+// DetectPythonEnvironment records, in order, what pyenv selects, the conda
+// environments and which one is active, whether uv and virtualenvwrapper
+// are installed, and which interpreter python3 resolves to with its prefix.
+// When the interpreter is not the one the manager in use configures, the
+// difference is recorded as a mismatch.
+func DetectPythonEnvironment(ctx context.Context, envData *types.EnvironmentData) {
+	detectPythonEnvironment(ctx, envData, runner.Default, runner.DefaultPath, os.Getenv)
+}
+
+func detectPythonEnvironment(ctx context.Context, envData *types.EnvironmentData, r runner.Runner, path runner.PathIndex, getenv func(string) string) {
+	python := &types.PythonEnvironment{}
+	found := false
+
+	if _, err := path.LookPath("pyenv"); err == nil {
+		found = true
+		python.Pyenv = &types.PyenvSelection{}
+		// 'pyenv global' lists every selected version, the first one wins
+		if stdout, _, err := r.Output(ctx, "pyenv", "global"); err == nil {
+			python.Pyenv.Global = firstLine(stdout)
+		}
+		// 'pyenv local' fails when no .python-version file applies
+		if stdout, _, err := r.Output(ctx, "pyenv", "local"); err == nil {
+			python.Pyenv.Local = firstLine(stdout)
+		}
+	}
+
+	if _, err := path.LookPath("conda"); err == nil {
+		found = true
+		stdout, stderr, err := r.Output(ctx, "conda", "env", "list", "--json")
+		if err == nil {
+			python.Conda, err = ParseCondaEnvList(stdout)
+		} else if message := firstLine(stderr); message != "" {
+			err = fmt.Errorf("%s", message)
+		}
+		if err != nil {
+			envData.Warnings = append(envData.Warnings, fmt.Sprintf("could not list the conda environments: %v", err))
+		} else {
+			python.Conda.Active = getenv("CONDA_PREFIX")
+		}
+	}
+
+	if _, err := path.LookPath("uv"); err == nil {
+		found = true
+		python.Uv = "Installed"
+		// uv 0.4.18 (7b55e9790 2024-10-01)
+		if stdout, _, err := r.Output(ctx, "uv", "--version"); err == nil {
+			if fields := strings.Fields(stdout); len(fields) >= 2 {
+				python.Uv = fields[1]
+			}
+		}
+	}
+
+	for _, script := range []string{"virtualenvwrapper.sh", "virtualenvwrapper_lazy.sh"} {
+		if _, err := path.LookPath(script); err == nil {
+			found = true
+			python.Virtualenvwrapper = true
+			break
+		}
+	}
+
+	for _, command := range []string{"python3", "python"} {
+		file, err := path.LookPath(command)
+		if err != nil {
+			continue
+		}
+		found = true
+		python.Interpreter = probeInterpreter(ctx, r, file)
+		break
+	}
+
+	if !found {
+		return
+	}
+	python.Mismatch = pythonMismatch(python)
+	envData.Python = python
+}
+
+// probeInterpreter runs the interpreter at file to learn its version and
+// prefix
+func probeInterpreter(ctx context.Context, r runner.Runner, file string) *types.PythonInterpreter {
+	interpreter := &types.PythonInterpreter{Path: file}
+	stdout, stderr, err := r.Output(ctx, file, "-c", pythonProbe)
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	if err != nil || len(lines) < 2 {
+		interpreter.Error = firstLine(stderr, stdout)
+		if interpreter.Error == "" && err != nil {
+			interpreter.Error = err.Error()
+		}
+		if interpreter.Error == "" {
+			interpreter.Error = "unexpected output"
+		}
+		return interpreter
+	}
+	interpreter.Version = strings.TrimSpace(lines[0])
+	interpreter.Prefix = strings.TrimSpace(lines[1])
+	return interpreter
+}
+
+// pythonMismatch explains how the interpreter python3 resolves to differs
+// from the one the manager in use configures, or returns ""
+func pythonMismatch(python *types.PythonEnvironment) string {
+	var configured string
+	switch python.Manager() {
+	case types.PythonManagerPyenv:
+		configured = "pyenv says " + python.Pyenv.Selected()
+	case types.PythonManagerConda:
+		configured = "conda environment " + python.Conda.Active + " is active"
+	default:
+		return ""
+	}
+
+	in := python.Interpreter
+	switch {
+	case in == nil:
+		return configured + " but python3 is not on PATH"
+	case in.Error != "":
+		return fmt.Sprintf("%s but %s fails: %s", configured, in.Path, in.Error)
+	}
+
+	resolved := fmt.Sprintf("%s but PATH resolves to %s %s", configured, in.Path, in.Version)
+	switch python.Manager() {
+	case types.PythonManagerPyenv:
+		selected := python.Pyenv.Selected()
+		if numericVersion.MatchString(selected) && in.Version != selected && !strings.HasPrefix(in.Version, selected+".") {
+			return resolved
+		}
+	case types.PythonManagerConda:
+		if !samePath(in.Prefix, python.Conda.Active) {
+			return resolved
+		}
+	}
+	return ""
+}
+
+// samePath reports whether two paths name the same directory, ignoring
+// trailing separators and the separator style
+func samePath(a, b string) bool {
+	clean := func(p string) string { return strings.TrimRight(strings.ReplaceAll(p, `\`, "/"), "/") }
+	return clean(a) == clean(b)
+}
+
+// condaEnvList is the output of 'conda env list --json'
+type condaEnvList struct {
+	Envs []string `json:"envs"`
+}
+
+// ParseCondaEnvList returns the environments in the output of 'conda env
+// list --json'. The base environment is the one not inside an envs
+// directory; named environments live in <base>/envs/<name>.
+func ParseCondaEnvList(output string) (*types.CondaEnvironments, error) {
+	var list condaEnvList
+	if err := json.Unmarshal([]byte(output), &list); err != nil {
+		return nil, fmt.Errorf("could not parse the conda environment list: %w", err)
+	}
+	envs := &types.CondaEnvironments{Envs: list.Envs}
+	for _, prefix := range list.Envs {
+		parts := strings.Split(strings.TrimRight(strings.ReplaceAll(prefix, `\`, "/"), "/"), "/")
+		if len(parts) < 2 || parts[len(parts)-2] != "envs" {
+			envs.Base = prefix
+			break
+		}
+	}
+	return envs, nil
+}
@@ -0,0 +1,165 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/runner/runnertest"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+func TestParseCondaEnvList(t *testing.T) {
+	testCases := []struct {
+		fixture string
+		base    string
+		envs    int
+	}{
+		{fixture: "conda-env-list.json", base: "/home/ada/miniconda3", envs: 3},
+		{fixture: "conda-env-list-windows.json", base: `C:\Users\ada\anaconda3`, envs: 2},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.fixture, func(t *testing.T) {
+			envs, err := ParseCondaEnvList(readFixture(t, "python", tc.fixture))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if envs.Base != tc.base || len(envs.Envs) != tc.envs {
+				t.Errorf("expected base %q with %d environments but got %q with %d", tc.base, tc.envs, envs.Base, len(envs.Envs))
+			}
+		})
+	}
+
+	if _, err := ParseCondaEnvList("conda: command not found"); err == nil {
+		t.Error("expected an error for output that is not JSON")
+	}
+}
+
+func TestDetectPythonEnvironment(t *testing.T) {
+	const (
+		systemPython = "/usr/bin/python3 -c " + pythonProbe
+		pyenvPython  = "/home/ada/.pyenv/shims/python3 -c " + pythonProbe
+		condaPython  = "/home/ada/miniconda3/envs/ml/bin/python3 -c " + pythonProbe
+	)
+	noLocal := runnertest.Response{Stderr: "pyenv: no local version configured for this directory\n", Err: errors.New("exit status 1")}
+
+	testCases := []struct {
+		name      string
+		exes      []string
+		responses map[string]runnertest.Response
+		getenv    map[string]string
+		expected  *types.PythonEnvironment
+	}{
+		{
+			name: "nothing installed",
+		},
+		{
+			name: "pyenv shadowed by the system python",
+			exes: []string{"/usr/bin/python3", "/home/ada/.pyenv/bin/pyenv", "/home/ada/.pyenv/shims/python3"},
+			responses: map[string]runnertest.Response{
+				"pyenv global": {Output: readFixture(t, "python", "pyenv-global.txt")},
+				"pyenv local":  noLocal,
+				systemPython:   {Output: "3.10.12\n/usr\n"},
+			},
+			expected: &types.PythonEnvironment{
+				Pyenv:       &types.PyenvSelection{Global: "3.12.1"},
+				Interpreter: &types.PythonInterpreter{Path: "/usr/bin/python3", Version: "3.10.12", Prefix: "/usr"},
+				Mismatch:    "pyenv says 3.12.1 but PATH resolves to /usr/bin/python3 3.10.12",
+			},
+		},
+		{
+			name: "pyenv local version through the shim",
+			exes: []string{"/home/ada/.pyenv/shims/python3", "/home/ada/.pyenv/bin/pyenv", "/usr/bin/python3"},
+			responses: map[string]runnertest.Response{
+				"pyenv global": {Output: readFixture(t, "python", "pyenv-global.txt")},
+				"pyenv local":  {Output: readFixture(t, "python", "pyenv-local.txt")},
+				pyenvPython:    {Output: "3.11.7\n/home/ada/.pyenv/versions/3.11.7\n"},
+			},
+			expected: &types.PythonEnvironment{
+				Pyenv:       &types.PyenvSelection{Global: "3.12.1", Local: "3.11.7"},
+				Interpreter: &types.PythonInterpreter{Path: "/home/ada/.pyenv/shims/python3", Version: "3.11.7", Prefix: "/home/ada/.pyenv/versions/3.11.7"},
+			},
+		},
+		{
+			name: "pyenv selecting a version that is not installed",
+			exes: []string{"/home/ada/.pyenv/shims/python3", "/home/ada/.pyenv/bin/pyenv"},
+			responses: map[string]runnertest.Response{
+				"pyenv global": {Output: "3.13.0\n"},
+				"pyenv local":  noLocal,
+				pyenvPython:    {Stderr: "pyenv: version `3.13.0' is not installed (set by /home/ada/.pyenv/version)\n", Err: errors.New("exit status 1")},
+			},
+			expected: &types.PythonEnvironment{
+				Pyenv: &types.PyenvSelection{Global: "3.13.0"},
+				Interpreter: &types.PythonInterpreter{
+					Path:  "/home/ada/.pyenv/shims/python3",
+					Error: "pyenv: version `3.13.0' is not installed (set by /home/ada/.pyenv/version)",
+				},
+				Mismatch: "pyenv says 3.13.0 but /home/ada/.pyenv/shims/python3 fails: pyenv: version `3.13.0' is not installed (set by /home/ada/.pyenv/version)",
+			},
+		},
+		{
+			name: "active conda environment with uv and virtualenvwrapper",
+			exes: []string{"/home/ada/miniconda3/envs/ml/bin/python3", "/home/ada/miniconda3/bin/conda", "/home/ada/.local/bin/uv", "/usr/bin/virtualenvwrapper.sh"},
+			responses: map[string]runnertest.Response{
+				"conda env list --json": {Output: readFixture(t, "python", "conda-env-list.json")},
+				"uv --version":          {Output: "uv 0.4.18 (7b55e9790 2024-10-01)\n"},
+				condaPython:             {Output: "3.11.9\n/home/ada/miniconda3/envs/ml\n"},
+			},
+			getenv: map[string]string{"CONDA_PREFIX": "/home/ada/miniconda3/envs/ml"},
+			expected: &types.PythonEnvironment{
+				Conda: &types.CondaEnvironments{
+					Base:   "/home/ada/miniconda3",
+					Active: "/home/ada/miniconda3/envs/ml",
+					Envs:   []string{"/home/ada/miniconda3", "/home/ada/miniconda3/envs/ml", "/home/ada/miniconda3/envs/py311"},
+				},
+				Uv:                "0.4.18",
+				Virtualenvwrapper: true,
+				Interpreter:       &types.PythonInterpreter{Path: "/home/ada/miniconda3/envs/ml/bin/python3", Version: "3.11.9", Prefix: "/home/ada/miniconda3/envs/ml"},
+			},
+		},
+		{
+			name: "active conda environment behind the system python",
+			exes: []string{"/usr/bin/python3", "/home/ada/miniconda3/bin/conda"},
+			responses: map[string]runnertest.Response{
+				"conda env list --json": {Output: readFixture(t, "python", "conda-env-list.json")},
+				systemPython:            {Output: "3.10.12\n/usr\n"},
+			},
+			getenv: map[string]string{"CONDA_PREFIX": "/home/ada/miniconda3/envs/ml"},
+			expected: &types.PythonEnvironment{
+				Conda: &types.CondaEnvironments{
+					Base:   "/home/ada/miniconda3",
+					Active: "/home/ada/miniconda3/envs/ml",
+					Envs:   []string{"/home/ada/miniconda3", "/home/ada/miniconda3/envs/ml", "/home/ada/miniconda3/envs/py311"},
+				},
+				Interpreter: &types.PythonInterpreter{Path: "/usr/bin/python3", Version: "3.10.12", Prefix: "/usr"},
+				Mismatch:    "conda environment /home/ada/miniconda3/envs/ml is active but PATH resolves to /usr/bin/python3 3.10.12",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var dirs []string
+			for _, exe := range tc.exes {
+				if dir := filepath.Dir(exe); !slices.Contains(dirs, dir) {
+					dirs = append(dirs, dir)
+				}
+			}
+			r := &runnertest.Runner{Responses: tc.responses}
+			getenv := func(name string) string { return tc.getenv[name] }
+			env := &types.EnvironmentData{}
+
+			detectPythonEnvironment(context.Background(), env, r, runnertest.NewPath(dirs, tc.exes...), getenv)
+			if !reflect.DeepEqual(env.Python, tc.expected) {
+				t.Errorf("expected %+v but got %+v", tc.expected, env.Python)
+			}
+			if len(env.Warnings) > 0 {
+				t.Errorf("expected no warnings but got %q", env.Warnings)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package scanner
+
+import (
+	"path"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/redact"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+// sensitiveFiles are the config files that store credentials, as paths
+// relative to the home directory. Environments never list them: the path
+// alone tells where to look, and the hash of a short file can be guessed.
+var sensitiveFiles = []string{".git-credentials", ".netrc", "_netrc", ".aws/credentials"}
+
+// isSensitiveFile reports whether p is one of sensitiveFiles or an .env
+// file, whose values are usually secrets. Paths pushed from Windows use
+// backslashes, so both separators are accepted.
+func isSensitiveFile(p string) bool {
+	p = strings.ReplaceAll(p, `\`, "/")
+	for _, name := range sensitiveFiles {
+		if p == name || strings.HasSuffix(p, "/"+name) {
+			return true
+		}
+	}
+	base := path.Base(p)
+	return base == ".env" || strings.HasPrefix(base, ".env.")
+}
+
+// RedactSensitive prepares env to leave the machine, as push and export do
+// unless --no-redact is given. It drops sensitive config files, masks the
+// environment variables that look secret (see redact.Variable) and masks the
+// secrets redact.String recognizes in every other value, such as a token
+// pasted into a scheduled job or reported by a custom detector.
+func RedactSensitive(env *types.EnvironmentData) {
+	env.ConfigFiles = slices.DeleteFunc(env.ConfigFiles, isSensitiveFile)
+	env.ConfigFileInfo = slices.DeleteFunc(env.ConfigFileInfo, func(info types.ConfigFileInfo) bool {
+		return isSensitiveFile(info.Path)
+	})
+	for name, value := range env.EnvVars {
+		env.EnvVars[name] = redact.Variable(name, value)
+	}
+	redactStrings(reflect.ValueOf(env).Elem())
+	if env.Summary != nil {
+		types.RefreshSummary(env)
+	}
+}
+
+// redactStrings masks secrets in every string reachable from v. Map keys
+// are names and are kept as they are.
+func redactStrings(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.String:
+		if v.CanSet() {
+			v.SetString(redact.String(v.String()))
+		}
+	case reflect.Pointer:
+		if !v.IsNil() {
+			redactStrings(v.Elem())
+		}
+	case reflect.Struct:
+		for i := range v.NumField() {
+			if v.Type().Field(i).IsExported() {
+				redactStrings(v.Field(i))
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := range v.Len() {
+			redactStrings(v.Index(i))
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			// Map values can't be set in place, so each is copied, redacted
+			// and stored back
+			value := reflect.New(v.Type().Elem()).Elem()
+			value.Set(iter.Value())
+			redactStrings(value)
+			v.SetMapIndex(iter.Key(), value)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+// Detector is one detection phase of a scan. Scans run the registered
+// detectors in the order they were registered (see Register), so a
+// detector can build on what the ones before it found.
+type Detector interface {
+	// Name identifies the detector in --only and --skip, such as
+	// "docker-plugins". Names are unique.
+	Name() string
+	// Category is the category the detector fills (see the Category
+	// constants), which --only and --skip also select it by. Categories
+	// StackMatch doesn't know are recorded under Extensions.
+	Category() string
+	// Detect adds what it finds to env. Problems worth telling the user
+	// about but not stopping for belong in env.Warnings; an error returned
+	// is recorded there too.
+	Detect(ctx context.Context, env *types.EnvironmentData) error
+}
+
+// Describer is implemented by detectors that say what they are doing in
+// the scan's progress messages, such as "Detecting docker CLI plugins".
+// Detectors without it are shown by name.
+type Describer interface {
+	Description() string
+}
+
+var (
+	registryMu sync.Mutex
+	registry   []Detector
+)
+
+// Register adds d to the detectors every scan runs, after those already
+// registered; call it from an init function. The built-in detectors are
+// registered first, so detectors added by other packages run after them,
+// including after provenance. Register panics if d is nil, has no name or
+// category, or has the name of a registered detector.
+func Register(d Detector) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if d == nil {
+		panic("scanner: Register detector is nil")
+	}
+	if d.Name() == "" || d.Category() == "" {
+		panic(fmt.Sprintf("scanner: Register detector %q needs a name and a category", d.Name()))
+	}
+	for _, registered := range registry {
+		if registered.Name() == d.Name() {
+			panic(fmt.Sprintf("scanner: Register called twice for detector %q", d.Name()))
+		}
+	}
+	registry = append(registry, d)
+}
+
+// Detectors returns the registered detectors in the order scans run them
+func Detectors() []Detector {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	return append([]Detector(nil), registry...)
+}
+
+// LookupDetector returns the registered detector called name
+func LookupDetector(name string) (Detector, bool) {
+	for _, d := range Detectors() {
+		if d.Name() == name {
+			return d, true
+		}
+	}
+	return nil, false
+}
+
+// fastDetectors name the registered detectors fast scans keep, besides
+// PackageDatabases: those that read files or run a single command
+var fastDetectors = []string{"system", "config-files", "git-config", "env-vars"}
+
+// FastDetectors returns the detectors a fast scan runs, in order: the
+// system, the package databases in place of the detectors that run a
+// command per entry, and the files read by the detectors of fastDetectors.
+// Detectors registered by other packages are not run by fast scans.
+func FastDetectors() []Detector {
+	var detectors []Detector
+	for _, name := range fastDetectors {
+		if d, ok := LookupDetector(name); ok {
+			detectors = append(detectors, d)
+		}
+		if name == "system" {
+			detectors = append(detectors, PackageDatabases)
+		}
+	}
+	return detectors
+}
+
+// detectorFunc is a Detector made of a function, as the built-in detectors
+// are
+type detectorFunc struct {
+	name        string
+	category    string
+	description string
+	detect      func(ctx context.Context, env *types.EnvironmentData)
+}
+
+func (d detectorFunc) Name() string        { return d.name }
+func (d detectorFunc) Category() string    { return d.category }
+func (d detectorFunc) Description() string { return d.description }
+
+func (d detectorFunc) Detect(ctx context.Context, env *types.EnvironmentData) error {
+	d.detect(ctx, env)
+	return nil
+}
+
+// ignoreContext adapts a detector that finishes quickly enough to only be
+// interrupted between detectors
+func ignoreContext(detect func(env *types.EnvironmentData)) func(context.Context, *types.EnvironmentData) {
+	return func(_ context.Context, env *types.EnvironmentData) { detect(env) }
+}
+
+// PackageDatabases reads the languages, tools, package managers and editors
+// from package databases (see DetectFromPackageDatabases). It replaces the
+// detectors of those categories in fast scans, so it is not registered.
+var PackageDatabases Detector = detectorFunc{"package-databases", types.CategoryTools, "Reading package databases", DetectFromPackageDatabases}
+
+// builtinDetectors are registered first, in the order they run
+var builtinDetectors = []detectorFunc{
+	{"system", types.CategorySystem, "Detecting system info", func(ctx context.Context, env *types.EnvironmentData) { DetectSystemInfo(ctx, &env.System) }},
+	{"languages", types.CategoryLanguages, "Detecting programming languages", DetectProgrammingLanguages},
+	{"python-environment", types.CategoryLanguages, "Resolving the Python interpreter", DetectPythonEnvironment},
+	{"corepack", types.CategoryLanguages, "Detecting corepack", DetectNodeEnvironment},
+	{"language-versions", types.CategoryLanguages, "Detecting side-by-side language versions", DetectLanguageVersions},
+	{"tools", types.CategoryTools, "Detecting development tools", DetectTools},
+	{"docker-plugins", types.CategoryTools, "Detecting docker CLI plugins", DetectDockerPlugins},
+	{"terminal-emulator", types.CategoryTools, "Detecting the terminal emulator", ignoreContext(DetectTerminalEmulator)},
+	{"package-managers", types.CategoryPackageManagers, "Detecting package managers", DetectPackageManagers},
+	{"homebrew", types.CategoryPackageManagers, "Detecting Homebrew installations", DetectHomebrew},
+	{"dnf-modules", types.CategoryPackageManagers, "Detecting DNF module streams", DetectDnfModules},
+	{"conda", types.CategoryPackageManagers, "Detecting conda environments", DetectConda},
+	{"editors", types.CategoryEditors, "Detecting code editors", DetectEditors},
+	// Apps found by their command above are not looked up again
+	{"apps", types.CategoryEditors, "Detecting installed applications", DetectApps},
+	{"config-files", types.CategoryConfigFiles, "Detecting config files", DetectConfigFilesContext},
+	{"shell-setup", types.CategoryConfigFiles, "Detecting shell frameworks and prompt tools", DetectShellSetup},
+	// Dotfile managers are matched against the config files found above
+	{"dotfile-managers", types.CategoryConfigFiles, "Detecting dotfile managers", DetectDotfileManagers},
+	{"git-config", types.CategoryGitConfig, "Detecting git config", DetectGitConfig},
+	{"language-config", types.CategoryLanguageConfig, "Detecting language config", DetectLanguageConfig},
+	{"env-vars", types.CategoryEnvVars, "Detecting environment variables", DetectEnvVars},
+	{"version-managers", types.CategoryVersionManagers, "Detecting version managers", DetectVersionManagers},
+	{"global-packages", types.CategoryGlobalPackages, "Detecting global packages", DetectGlobalPackages},
+	{"pip-packages", types.CategoryGlobalPackages, "Detecting packages installed with pip --user", DetectPipPackages},
+	{"go-binaries", types.CategoryGlobalPackages, "Detecting programs installed with go install", DetectGoBinaries},
+	{"flatpak", types.CategoryGlobalPackages, "Detecting flatpak apps", DetectFlatpakApps},
+	{"nix-profile", types.CategoryGlobalPackages, "Detecting Nix profile packages", DetectNixPackages},
+	{"services", types.CategoryServices, "Detecting developer services", DetectServices},
+	{"containers", types.CategoryContainers, "Detecting Docker images and containers", DetectContainers},
+	{"gpu", types.CategoryGPU, "Detecting the GPU compute stack", DetectGPU},
+	{"kubernetes", types.CategoryKubernetes, "Detecting kubectl contexts", DetectKubernetes},
+	{"cloud-profiles", types.CategoryCloud, "Detecting cloud CLI profiles", DetectCloudProfiles},
+	{"ssh-keys", types.CategorySSH, "Detecting SSH keys and the ssh-agent", DetectSSHKeys},
+	{"android-sdk", types.CategoryMobile, "Detecting the Android SDK", DetectAndroidSDK},
+	{"flutter", types.CategoryMobile, "Detecting Flutter", DetectFlutter},
+	// flutter doctor is slow, so it can be skipped on its own
+	{"flutter-doctor", types.CategoryMobile, "Running flutter doctor", DetectFlutterDoctor},
+	// Provenance joins what the detectors above found, so it runs last
+	{"provenance", types.CategoryProvenance, "Detecting how tools were installed", DetectProvenance},
+}
+
+func init() {
+	for _, d := range builtinDetectors {
+		Register(d)
+	}
+}
@@ -0,0 +1,82 @@
+package scanner
+
+import (
+	"context"
+	"testing"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+func TestRegisterRejectsInvalidDetectors(t *testing.T) {
+	testCases := []struct {
+		name     string
+		detector Detector
+	}{
+		{"Nil", nil},
+		{"No name", detectorFunc{category: types.CategoryTools}},
+		{"No category", detectorFunc{name: "kubectl-plugins"}},
+		{"Registered name", detectorFunc{name: "docker-plugins", category: types.CategoryTools}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("expected Register to panic")
+				}
+			}()
+			Register(tc.detector)
+		})
+	}
+	if n := len(Detectors()); n != len(builtinDetectors) {
+		t.Errorf("expected only the %d built-in detectors to be registered but got %d", len(builtinDetectors), n)
+	}
+}
+
+func TestDetectors(t *testing.T) {
+	detectors := Detectors()
+	if len(detectors) == 0 || detectors[0].Name() != "system" || detectors[len(detectors)-1].Name() != "provenance" {
+		t.Fatalf("expected the built-in detectors from system to provenance but got %d detectors", len(detectors))
+	}
+	// The slice returned is a copy
+	detectors[0] = nil
+	if d, ok := LookupDetector("system"); !ok || d.Category() != types.CategorySystem {
+		t.Errorf("expected the system detector to stay registered but got %v", d)
+	}
+	if _, ok := LookupDetector("package-databases"); ok {
+		t.Error("expected the package databases to only be read by fast scans")
+	}
+}
+
+func TestFastDetectors(t *testing.T) {
+	expected := []string{"system", "package-databases", "config-files", "git-config", "env-vars"}
+	var got []string
+	for _, d := range FastDetectors() {
+		got = append(got, d.Name())
+	}
+	if len(got) != len(expected) {
+		t.Fatalf("expected %v but got %v", expected, got)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("expected %v but got %v", expected, got)
+			break
+		}
+	}
+}
+
+func TestDetectorFunc(t *testing.T) {
+	d := detectorFunc{"terraform-workspaces", types.CategoryTools, "Detecting Terraform workspaces", func(_ context.Context, env *types.EnvironmentData) {
+		env.Tools = map[string]string{"Terraform": "1.9.2"}
+	}}
+	env := &types.EnvironmentData{}
+	if err := d.Detect(context.Background(), env); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if env.Tools["Terraform"] != "1.9.2" {
+		t.Errorf("expected the detector to run but got %v", env.Tools)
+	}
+	if d.Description() != "Detecting Terraform workspaces" {
+		t.Errorf("expected the description to be kept but got %q", d.Description())
+	}
+}
@@ -0,0 +1,177 @@
+package scanner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/runner"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+// DefaultServicePorts are the ports probed for locally running development
+// services, keyed by service. The service_ports entry of the detectors file
+// changes them.
+var DefaultServicePorts = map[string]int{
+	"postgresql":    5432,
+	"redis":         6379,
+	"mysql":         3306,
+	"mongodb":       27017,
+	"elasticsearch": 9200,
+}
+
+// RunningServiceUnknown is recorded for services that accept connections but
+// whose version could not be told
+const RunningServiceUnknown = "Running"
+
+const (
+	// serviceDialTimeout bounds the connection attempt to each port. Local
+	// ports answer at once or not at all.
+	serviceDialTimeout = 250 * time.Millisecond
+	// serviceVersionTimeout bounds asking a service for its version
+	serviceVersionTimeout = 3 * time.Second
+)
+
+// serviceVersion asks the service listening on port for its version,
+// returning "" when it can't be told
+type serviceVersion func(ctx context.Context, r runner.Runner, path runner.PathIndex, port int) string
+
+// serviceVersions are how the known services are asked for their version.
+// Only client commands that need no credentials are used, so nothing
+// prompts and no secrets are read.
+var serviceVersions = map[string]serviceVersion{
+	"postgresql": commandVersion("psql", regexp.MustCompile(`PostgreSQL (\d+(?:\.\d+)*)`), func(port string) []string {
+		return []string{"-h", "127.0.0.1", "-p", port, "-d", "postgres", "-w", "-A", "-t", "-c", "select version()"}
+	}),
+	"redis": commandVersion("redis-cli", regexp.MustCompile(`redis_version:(\d+(?:\.\d+)*)`), func(port string) []string {
+		return []string{"-h", "127.0.0.1", "-p", port, "INFO", "server"}
+	}),
+	"mysql": commandVersion("mysql", regexp.MustCompile(`^(\d+(?:\.\d+)*)`), func(port string) []string {
+		return []string{"-h", "127.0.0.1", "-P", port, "--connect-timeout=2", "-N", "-B", "-e", "select version()"}
+	}),
+	"mongodb": commandVersion("mongosh", regexp.MustCompile(`^(\d+(?:\.\d+)*)`), func(port string) []string {
+		return []string{"--quiet", "--host", "127.0.0.1", "--port", port, "--eval", "db.version()"}
+	}),
+	"elasticsearch": elasticsearchVersion,
+}
+
+// commandVersion asks a service for its version with its command line
+// client, run with the arguments args returns for the port
+func commandVersion(command string, re *regexp.Regexp, args func(port string) []string) serviceVersion {
+	return func(ctx context.Context, r runner.Runner, path runner.PathIndex, port int) string {
+		if _, err := path.LookPath(command); err != nil {
+			return ""
+		}
+		stdout, _, err := r.Output(ctx, command, args(strconv.Itoa(port))...)
+		if err != nil {
+			return ""
+		}
+		return parseVersion(strings.TrimSpace(stdout), re)
+	}
+}
+
+// elasticsearchHTTP fetches the Elasticsearch root endpoint
+var elasticsearchHTTP = &http.Client{Timeout: serviceVersionTimeout}
+
+// elasticsearchVersion reads the version Elasticsearch and OpenSearch give
+// on their root endpoint, which needs no credentials unless security is on
+func elasticsearchVersion(ctx context.Context, _ runner.Runner, _ runner.PathIndex, port int) string {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("http://127.0.0.1:%d/", port), nil)
+	if err != nil {
+		return ""
+	}
+	resp, err := elasticsearchHTTP.Do(req)
+	if err != nil {
+		return ""
+	}
+	defer resp.Body.Close()
+	var root struct {
+		Version struct {
+			Number string `json:"number"`
+		} `json:"version"`
+	}
+	if resp.StatusCode != http.StatusOK || json.NewDecoder(resp.Body).Decode(&root) != nil {
+		return ""
+	}
+	return root.Version.Number
+}
+
+// dialFunc connects to a TCP address
+type dialFunc func(ctx context.Context, network, address string) (net.Conn, error)
+
+// DetectRunningServices records the development services accepting
+// connections on this machine's loopback interface, from DefaultServicePorts
+// changed by ports, in RunningServices. Ports of 0 are not probed. Each
+// service found is asked for its version where that needs no credentials.
+func DetectRunningServices(ctx context.Context, envData *types.EnvironmentData, ports map[string]int) {
+	dialer := &net.Dialer{Timeout: serviceDialTimeout}
+	detectRunningServices(ctx, envData, runner.Default, runner.DefaultPath, dialer.DialContext, servicePorts(ports))
+}
+
+// servicePorts returns DefaultServicePorts changed by ports, less those set
+// to 0
+func servicePorts(ports map[string]int) map[string]int {
+	merged := make(map[string]int)
+	for name, port := range DefaultServicePorts {
+		merged[name] = port
+	}
+	for name, port := range ports {
+		merged[strings.ToLower(name)] = port
+	}
+	for name, port := range merged {
+		if port == 0 {
+			delete(merged, name)
+		}
+	}
+	return merged
+}
+
+func detectRunningServices(ctx context.Context, envData *types.EnvironmentData, r runner.Runner, path runner.PathIndex, dial dialFunc, ports map[string]int) {
+	names := make([]string, 0, len(ports))
+	for name := range ports {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	versions := make([]string, len(names))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			conn, err := dial(ctx, "tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(ports[name])))
+			if err != nil {
+				return
+			}
+			conn.Close()
+
+			versions[i] = RunningServiceUnknown
+			if identify, ok := serviceVersions[name]; ok {
+				ctx, cancel := context.WithTimeout(ctx, serviceVersionTimeout)
+				defer cancel()
+				if version := identify(ctx, r, path, ports[name]); version != "" {
+					versions[i] = version
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	for i, name := range names {
+		if versions[i] == "" {
+			continue
+		}
+		if envData.RunningServices == nil {
+			envData.RunningServices = make(map[string]string)
+		}
+		envData.RunningServices[name] = versions[i]
+	}
+}
@@ -0,0 +1,80 @@
+package scanner
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/runner/runnertest"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+// listen accepts connections on a free loopback port until the test ends
+// and returns the port
+func listen(t *testing.T) int {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	return listener.Addr().(*net.TCPAddr).Port
+}
+
+// closedPort returns a loopback port nothing listens on
+func closedPort(t *testing.T) int {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+	return port
+}
+
+func TestDetectRunningServices(t *testing.T) {
+	elasticsearch := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"name": "es01", "cluster_name": "docker-cluster", "version": {"number": "8.12.1", "build_flavor": "default"}}`))
+	}))
+	defer elasticsearch.Close()
+	esPort := elasticsearch.Listener.Addr().(*net.TCPAddr).Port
+
+	redisPort, mysqlPort, customPort := listen(t), listen(t), listen(t)
+	ports := map[string]int{
+		"redis":         redisPort,
+		"mysql":         mysqlPort,
+		"postgresql":    closedPort(t),
+		"elasticsearch": esPort,
+		"rabbitmq":      customPort,
+	}
+	r := &runnertest.Runner{Responses: map[string]runnertest.Response{
+		"redis-cli -h 127.0.0.1 -p " + strconv.Itoa(redisPort) + " INFO server": {Output: "# Server\r\nredis_version:7.2.4\r\nredis_git_sha1:00000000\r\n"},
+	}}
+	// mysql is running, but its client isn't installed
+	path := runnertest.NewPath([]string{"/usr/bin"}, "/usr/bin/redis-cli", "/usr/bin/psql")
+
+	env := &types.EnvironmentData{}
+	dialer := &net.Dialer{Timeout: serviceDialTimeout}
+	detectRunningServices(context.Background(), env, r, path, dialer.DialContext, ports)
+
+	expected := map[string]string{
+		"redis":         "7.2.4",
+		"mysql":         RunningServiceUnknown,
+		"elasticsearch": "8.12.1",
+		"rabbitmq":      RunningServiceUnknown,
+	}
+	if !reflect.DeepEqual(env.RunningServices, expected) {
+		t.Errorf("expected %v but got %v", expected, env.RunningServices)
+	}
+}
+
+func TestServicePorts(t *testing.T) {
+	actual := servicePorts(map[string]int{"PostgreSQL": 5433, "mongodb": 0, "rabbitmq": 5672})
+	expected := map[string]int{"postgresql": 5433, "redis": 6379, "mysql": 3306, "elasticsearch": 9200, "rabbitmq": 5672}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v but got %v", expected, actual)
+	}
+}
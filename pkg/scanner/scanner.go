@@ -1,22 +1,32 @@
 package scanner
 
 import (
-	"bytes"
+	"context"
+	"errors"
+	"fmt"
 	"log"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
 	"runtime"
+	"slices"
 	"strings"
+	"sync"
 
+	"github.com/MRQ67/stackmatch-cli/pkg/runner"
 	"github.com/MRQ67/stackmatch-cli/pkg/types"
 )
 
-// DetectSystemInfo gathers basic OS and architecture details.
-func DetectSystemInfo(sysInfo *types.SystemInfo) {
+// DetectSystemInfo gathers basic OS and architecture details. The commands
+// it runs to name the OS release and libc are stopped when ctx is done.
+func DetectSystemInfo(ctx context.Context, sysInfo *types.SystemInfo) {
 	sysInfo.OS = runtime.GOOS
 	sysInfo.Arch = runtime.GOARCH
+	detectOSInfo(ctx, sysInfo, runner.Default, runtime.GOOS, os.ReadFile, os.Getenv)
+	if runtime.GOOS == "linux" {
+		sysInfo.Libc = detectLibc(ctx, runner.Default, filepath.Glob)
+	}
 
 	// Shell detection
 	if runtime.GOOS == "windows" {
@@ -47,7 +57,21 @@ func DetectSystemInfo(sysInfo *types.SystemInfo) {
 }
 
 // DetectConfigFiles checks for the existence of common configuration files in the user's home directory.
+// Each file found is also described in ConfigFileInfo with a hash of its contents.
 func DetectConfigFiles(envData *types.EnvironmentData) {
+	DetectConfigFilesContext(context.Background(), envData)
+}
+
+// DetectConfigFilesContext is DetectConfigFiles honoring ctx. Glob patterns
+// are walked within DefaultGlobBudget; truncated patterns are reported in
+// envData.Warnings. After UseProjectDir, the project's manifests are
+// detected instead (see DetectProjectFiles).
+func DetectConfigFilesContext(ctx context.Context, envData *types.EnvironmentData) {
+	if projectDir != "" {
+		DetectProjectFiles(ctx, envData, projectDir)
+		return
+	}
+
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
 		log.Printf("Warning: Could not determine user home directory: %v", err)
@@ -61,6 +85,9 @@ func DetectConfigFiles(envData *types.EnvironmentData) {
 		// Shell configurations
 		".zshrc", ".bashrc", ".bash_profile", ".profile", ".zprofile", ".zshenv", ".bash_login",
 
+		// Terminal multiplexers
+		".tmux.conf", ".config/tmux/tmux.conf", ".screenrc", ".config/zellij/config.kdl",
+
 		// Package manager configs
 		".npmrc", ".yarnrc", ".pypirc", ".m2/settings.xml", ".gradle/gradle.properties",
 
@@ -80,13 +107,33 @@ func DetectConfigFiles(envData *types.EnvironmentData) {
 		"tsconfig.json", "webpack.config.js", "babel.config.js",
 		".eslintrc", ".eslintrc.js", ".eslintrc.json", ".prettierrc", ".prettierrc.js",
 		".babelrc", ".babelrc.js", ".babelrc.json", "jest.config.js", ".npmignore",
+
+		// XDG configs, matched with slash-separated globs
+		".config/git/*", ".config/nvim/init.*", ".config/fish/**/*.fish",
 	}
 
 	for _, file := range filesToScan {
+		if ctx.Err() != nil {
+			return
+		}
+		if isGlob(file) {
+			found, warning, err := globFiles(ctx, homeDir, file, DefaultGlobBudget)
+			if err != nil && ctx.Err() == nil {
+				log.Printf("Warning: Could not scan %s: %v", file, err)
+			}
+			if warning != "" {
+				envData.Warnings = append(envData.Warnings, warning)
+			}
+			for _, filePath := range found {
+				addConfigFile(envData, filePath)
+			}
+			continue
+		}
+
+		// Lstat, so a symlink whose target is gone is still recorded
 		filePath := filepath.Join(homeDir, file)
-		if _, err := os.Stat(filePath); err == nil {
-			log.Printf("Found config file: %s", filePath)
-			envData.ConfigFiles = append(envData.ConfigFiles, filePath)
+		if _, err := os.Lstat(filePath); err == nil {
+			addConfigFile(envData, filePath)
 		}
 	}
 }
@@ -97,49 +144,250 @@ type Executable struct {
 	Command      string
 	VersionArg   string
 	VersionRegex *regexp.Regexp
+	// ID is the canonical tool ID. When empty it is derived from Name.
+	ID string
+	// ExitsNonZero is set for tools whose version command exits with an
+	// error even when it works, such as 'screen -v'. Their output is
+	// parsed anyway.
+	ExitsNonZero bool
+}
+
+// Info returns the executable's canonical ID and display name
+func (e Executable) Info() types.ToolInfo {
+	id := e.ID
+	if id == "" {
+		id = types.CanonicalID(e.Name)
+	}
+	return types.ToolInfo{ID: id, Name: e.Name}
+}
+
+// detectorConfig holds the user's detector overrides, applied by every scan
+var detectorConfig *DetectorConfig
+
+// UseDetectorConfig sets the detector overrides used by subsequent scans.
+// A nil config restores the built-in detection.
+func UseDetectorConfig(cfg *DetectorConfig) {
+	detectorConfig = cfg
+}
+
+// shellProbe, when set, retries tools missing from PATH through the login shell
+var shellProbe *ShellProbe
+
+// UseShellProbe sets the login shell probe used by subsequent scans. A nil
+// probe, the default, only detects tools on PATH.
+func UseShellProbe(p *ShellProbe) {
+	shellProbe = p
+}
+
+// DefaultConcurrency is how many version commands a scan runs at once
+const DefaultConcurrency = 8
+
+// concurrency bounds the version commands run at once by every scan
+var concurrency = DefaultConcurrency
+
+// UseConcurrency sets how many version commands subsequent scans run at once.
+// Values below 1 restore DefaultConcurrency.
+func UseConcurrency(n int) {
+	if n < 1 {
+		n = DefaultConcurrency
+	}
+	concurrency = n
+}
+
+// detection is what was found out about one executable
+type detection struct {
+	// found is set when the executable is on PATH or the login shell has it
+	found      bool
+	version    string
+	overridden bool
+	failure    *types.ToolFailure
+	// source is the login shell source of tools missing from PATH
+	source string
 }
 
 // detectExecutables is a generic helper to find tools, package managers, etc.
-func detectExecutables(executables []Executable, dataMap map[string]string) {
-	for _, exe := range executables {
-		if _, err := exec.LookPath(exe.Command); err != nil {
-			continue // Command not found in PATH, skip
+// The canonical ID of everything found is recorded in envData.ToolIDs, and
+// tools whose version command fails in envData.BrokenTools.
+func detectExecutables(ctx context.Context, envData *types.EnvironmentData, executables []Executable, dataMap map[string]string) {
+	detectExecutablesWith(ctx, runner.Default, runner.DefaultPath, detectorConfig, shellProbe, concurrency, envData, executables, dataMap)
+}
+
+// detectExecutablesWith runs the version commands of executables on up to
+// workers goroutines. Each goroutine only fills in its executables' slot of
+// the results; envData and dataMap are updated afterwards in the order of
+// executables, so the outcome and the log don't depend on scheduling.
+func detectExecutablesWith(ctx context.Context, r runner.Runner, path runner.PathIndex, cfg *DetectorConfig, probe *ShellProbe, workers int, envData *types.EnvironmentData, executables []Executable, dataMap map[string]string) {
+	results := make([]detection, len(executables))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(max(workers, 1), len(executables)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = detectExecutable(ctx, r, path, cfg, probe, executables[i])
+			}
+		}()
+	}
+	for i := range executables {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	if envData.ToolIDs == nil {
+		envData.ToolIDs = make(map[string]string)
+	}
+	for i, exe := range executables {
+		recordDetection(envData, exe, results[i], dataMap)
+	}
+}
+
+// detectExecutable finds exe on PATH, or through the login shell probe, and
+// runs its version command
+func detectExecutable(ctx context.Context, r runner.Runner, path runner.PathIndex, cfg *DetectorConfig, probe *ShellProbe, exe Executable) detection {
+	if _, err := path.LookPath(exe.Command); err != nil {
+		if probe.probes(exe.Command) {
+			return detectThroughShell(ctx, r, probe, cfg, exe)
 		}
+		return detection{} // Command not found in PATH, skip
+	}
+	version, overridden, failure := getCommandVersion(ctx, r, exe, cfg.override(exe))
+	return detection{found: true, version: version, overridden: overridden, failure: failure}
+}
 
-		if version := getCommandVersion(exe.Command, exe.VersionArg, exe.VersionRegex); version != "" {
-			log.Printf("Found %s version %s", exe.Name, version)
-			dataMap[exe.Name] = version
-		} else {
-			// If version command fails but executable exists, record its presence.
-			dataMap[exe.Name] = "Installed"
+// recordDetection adds what was found about exe to envData and dataMap
+func recordDetection(envData *types.EnvironmentData, exe Executable, d detection, dataMap map[string]string) {
+	if !d.found {
+		return
+	}
+	envData.ToolIDs[exe.Name] = exe.Info().ID
+
+	switch {
+	case d.source != "":
+		log.Printf("Found %s version %s through %s", exe.Name, d.version, d.source)
+		dataMap[exe.Name] = d.version
+		if envData.ToolSources == nil {
+			envData.ToolSources = make(map[string]string)
+		}
+		envData.ToolSources[exe.Name] = d.source
+		return
+	case d.version != "" && d.overridden:
+		log.Printf("Found %s version %s (matched detectors override)", exe.Name, d.version)
+		dataMap[exe.Name] = d.version
+	case d.version != "":
+		log.Printf("Found %s version %s", exe.Name, d.version)
+		dataMap[exe.Name] = d.version
+	default:
+		// If version command fails but executable exists, record its presence.
+		dataMap[exe.Name] = "Installed"
+	}
+
+	if d.failure != nil {
+		if envData.BrokenTools == nil {
+			envData.BrokenTools = make(map[string]types.ToolFailure)
+		}
+		// Tools listed in several categories are warned about once
+		if _, seen := envData.BrokenTools[exe.Name]; !seen {
+			envData.Warnings = append(envData.Warnings, brokenWarning(exe, *d.failure))
 		}
+		envData.BrokenTools[exe.Name] = *d.failure
 	}
 }
 
-// getCommandVersion executes a command and parses its version.
-func getCommandVersion(command, versionArg string, versionRegex *regexp.Regexp) string {
-	// Most version commands are fast, but we set a timeout to avoid hangs.
-	cmd := exec.Command(command, versionArg)
-	var out bytes.Buffer
-	var stderr bytes.Buffer
-	cmd.Stdout = &out
-	cmd.Stderr = &stderr
+// detectThroughShell retries a tool missing from PATH through the login shell.
+// Tools the shell cannot run either are skipped, not reported as broken.
+func detectThroughShell(ctx context.Context, r runner.Runner, probe *ShellProbe, cfg *DetectorConfig, exe Executable) detection {
+	if !probe.finds(ctx, r, exe.Command) {
+		return detection{}
+	}
+	version, _, failure := getCommandVersion(ctx, probe.runner(r), exe, cfg.override(exe))
+	if failure != nil || ctx.Err() != nil {
+		return detection{}
+	}
+	if version == "" {
+		version = "Installed"
+	}
+	return detection{found: true, version: version, source: probe.Source()}
+}
+
+// brokenWarning describes a tool whose version command failed
+func brokenWarning(exe Executable, failure types.ToolFailure) string {
+	status := fmt.Sprintf("exited with status %d", failure.ExitStatus)
+	if failure.ExitStatus < 0 {
+		status = "could not be run"
+	}
+	warning := fmt.Sprintf("%s is installed but broken: '%s %s' %s", exe.Name, exe.Command, exe.VersionArg, status)
+	if failure.Output != "" {
+		warning += ": " + failure.Output
+	}
+	return warning
+}
 
-	err := cmd.Run()
+// getCommandVersion executes a command and parses its version. An override is
+// tried before the built-in regex; the returned bool reports whether it matched.
+// A failure is returned when the version command fails without printing a
+// version, which means the tool is on PATH but broken.
+func getCommandVersion(ctx context.Context, r runner.Runner, exe Executable, override *VersionOverride) (string, bool, *types.ToolFailure) {
+	if override != nil {
+		args := strings.Fields(exe.VersionArg)
+		if len(override.Args) > 0 {
+			args = override.Args
+		}
+		stdout, stderr, err := r.Output(ctx, exe.Command, args...)
+		if version := parseVersion(override.pick(stdout, stderr), override.regex); version != "" {
+			return version, true, nil
+		}
+		if err != nil {
+			log.Printf("Warning: Command '%s %s' failed: %v", exe.Command, strings.Join(args, " "), err)
+		}
+	}
 
-	output := out.String()
+	// Version arguments such as "version --client" are separate arguments
+	stdout, stderr, err := r.Output(ctx, exe.Command, strings.Fields(exe.VersionArg)...)
+	if err != nil && exe.ExitsNonZero && ctx.Err() == nil {
+		if version := parseVersion(stdout+stderr, exe.VersionRegex); version != "" {
+			return version, false, nil
+		}
+	}
 	if err != nil {
-		// Some tools print version to stderr (e.g., python --version)
-		// We'll use stderr as a fallback if stdout is empty.
-		if stderr.Len() > 0 {
-			output = stderr.String()
-		} else {
-			log.Printf("Warning: Command '%s %s' failed: %v", command, versionArg, err)
-			return ""
+		if ctx.Err() != nil {
+			return "", false, nil
 		}
+		// Error messages are not parsed: loose version regexes match
+		// things like the "72" in "libicui18n.so.72"
+		log.Printf("Warning: Command '%s %s' failed: %v", exe.Command, exe.VersionArg, err)
+		return "", false, &types.ToolFailure{ExitStatus: exitStatus(err), Output: firstLine(stderr, stdout)}
 	}
 
-	return parseVersion(output, versionRegex)
+	// Some tools print version to stderr (e.g., python2 --version, java -version)
+	if version := parseVersion(stdout, exe.VersionRegex); version != "" {
+		return version, false, nil
+	}
+	return parseVersion(stderr, exe.VersionRegex), false, nil
+}
+
+// exitStatus returns the exit status carried by err, or -1 when the command
+// did not run to completion
+func exitStatus(err error) int {
+	// Any runner's error that carries an exit code, not only *exec.ExitError
+	var exitErr interface{ ExitCode() int }
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
+
+// firstLine returns the first non-empty line of the first output that has one
+func firstLine(outputs ...string) string {
+	for _, output := range outputs {
+		for _, line := range strings.Split(output, "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				return line
+			}
+		}
+	}
+	return ""
 }
 
 // parseVersion extracts the version string using a regex.
@@ -155,183 +403,247 @@ func parseVersion(output string, regex *regexp.Regexp) string {
 	return ""
 }
 
+// crossPlatformPackageManagers are detected on every OS
+var crossPlatformPackageManagers = []Executable{
+	// Python
+	{Name: "pip", Command: "pip", VersionArg: "--version", VersionRegex: regexp.MustCompile(`pip ([\d\.]+)`)},
+	{Name: "pip3", Command: "pip3", VersionArg: "--version", VersionRegex: regexp.MustCompile(`pip ([\d\.]+)`)},
+	{Name: "pipx", Command: "pipx", VersionArg: "--version", VersionRegex: regexp.MustCompile(`([\d\.]+)`)},
+	{Name: "poetry", Command: "poetry", VersionArg: "--version", VersionRegex: regexp.MustCompile(`Poetry version ([\d\.]+)`)},
+
+	// JavaScript/Node.js
+	{Name: "npm", Command: "npm", VersionArg: "--version", VersionRegex: regexp.MustCompile(`([\d\.]+)`)},
+	{Name: "yarn", Command: "yarn", VersionArg: "--version", VersionRegex: regexp.MustCompile(`([\d\.]+)`)},
+	{Name: "pnpm", Command: "pnpm", VersionArg: "--version", VersionRegex: regexp.MustCompile(`([\d\.]+)`)},
+
+	// Container
+	{Name: "Docker", Command: "docker", VersionArg: "--version", VersionRegex: regexp.MustCompile(`Docker version ([\d\.]+)`)},
+	{Name: "Podman", Command: "podman", VersionArg: "--version", VersionRegex: regexp.MustCompile(`podman version ([\d\.]+)`)},
+}
+
+// osPackageManagers are the package managers detected on a specific OS
+var osPackageManagers = map[string][]Executable{
+	"darwin": {
+		{Name: "Homebrew", Command: "brew", VersionArg: "--version", VersionRegex: regexp.MustCompile(`Homebrew ([\d\.]+)`)},
+		{Name: "MacPorts", Command: "port", VersionArg: "version", VersionRegex: regexp.MustCompile(`version ([\d\.]+)`)},
+		{Name: "nix", Command: "nix", VersionArg: "--version", VersionRegex: regexp.MustCompile(`\([^)]*\) ([\d\.]+)`)},
+		{Name: "nix-env", Command: "nix-env", VersionArg: "--version", VersionRegex: regexp.MustCompile(`\([^)]*\) ([\d\.]+)`)},
+	},
+	"linux": {
+		{Name: "apt", Command: "apt", VersionArg: "--version", VersionRegex: regexp.MustCompile(`apt ([\d\.]+)`)},
+		{Name: "apt-get", Command: "apt-get", VersionArg: "--version", VersionRegex: regexp.MustCompile(`apt-get ([\d\.]+)`)},
+		{Name: "yum", Command: "yum", VersionArg: "--version", VersionRegex: regexp.MustCompile(`([\d\.]+)`)},
+		{Name: "dnf", Command: "dnf", VersionArg: "--version", VersionRegex: regexp.MustCompile(`([\d\.]+)`)},
+		{Name: "pacman", Command: "pacman", VersionArg: "--version", VersionRegex: regexp.MustCompile(`Pacman v([\d\.]+)`)},
+		{Name: "apk", Command: "apk", VersionArg: "--version", VersionRegex: regexp.MustCompile(`apk-tools ([\d\.]+)`)},
+		{Name: "zypper", Command: "zypper", VersionArg: "--version", VersionRegex: regexp.MustCompile(`zypper ([\d\.]+)`)},
+		{Name: "snap", Command: "snap", VersionArg: "--version", VersionRegex: regexp.MustCompile(`snap\\s+([\d\.]+)`)},
+		{Name: "flatpak", Command: "flatpak", VersionArg: "--version", VersionRegex: regexp.MustCompile(`Flatpak ([\d\.]+)`)},
+		{Name: "nix", Command: "nix", VersionArg: "--version", VersionRegex: regexp.MustCompile(`\([^)]*\) ([\d\.]+)`)},
+		{Name: "nix-env", Command: "nix-env", VersionArg: "--version", VersionRegex: regexp.MustCompile(`\([^)]*\) ([\d\.]+)`)},
+	},
+	"windows": {
+		{Name: "Chocolatey", Command: "choco", VersionArg: "--version", VersionRegex: regexp.MustCompile(`([\d\.]+)`)},
+		{Name: "Scoop", Command: "scoop", VersionArg: "--version", VersionRegex: regexp.MustCompile(`([\d\.]+)`)},
+		{Name: "Winget", Command: "winget", VersionArg: "--version", VersionRegex: regexp.MustCompile(`v([\d\.]+)`)},
+	},
+}
+
 // DetectPackageManagers finds common package managers based on the OS.
-func DetectPackageManagers(envData *types.EnvironmentData) {
+func DetectPackageManagers(ctx context.Context, envData *types.EnvironmentData) {
 	var executables []Executable
+	executables = append(executables, crossPlatformPackageManagers...)
+	executables = append(executables, osPackageManagers[runtime.GOOS]...)
+	executables = append(executables, detectorConfig.executables(types.CategoryPackageManagers)...)
+	detectExecutables(ctx, envData, manifest.limit(types.CategoryPackageManagers, executables, detectorConfig.AliasGroups()), envData.PackageManagers)
+}
+
+// languageExecutables are the programming languages the scanner detects
+var languageExecutables = []Executable{
+	// Compiled Languages
+	{Name: "Go", Command: "go", VersionArg: "version", VersionRegex: regexp.MustCompile(`go version go([\d\.]+)`)},
+	{Name: "Rust", Command: "rustc", VersionArg: "--version", VersionRegex: regexp.MustCompile(`rustc ([\d\.]+)`)},
+	{Name: "Java", Command: "java", VersionArg: "-version", VersionRegex: regexp.MustCompile(`version "([\d\._]+)"`)},
+	{Name: "Kotlin", Command: "kotlin", VersionArg: "-version", VersionRegex: regexp.MustCompile(`Kotlin version ([\d\.]+)`)},
+	{Name: "C#", Command: "dotnet", VersionArg: "--version", VersionRegex: regexp.MustCompile(`([\d\.]+)`), ID: "dotnet"},
+	{Name: "Scala", Command: "scala", VersionArg: "-version", VersionRegex: regexp.MustCompile(`version ([\d\.]+)`)},
+
+	// Scripting Languages
+	{Name: "Node.js", Command: "node", VersionArg: "--version", VersionRegex: regexp.MustCompile(`v?([\d\.]+)`)},
+	{Name: "Python", Command: "python", VersionArg: "--version", VersionRegex: regexp.MustCompile(`Python ([\d\.]+)`)},
+	{Name: "Python 3", Command: "python3", VersionArg: "--version", VersionRegex: regexp.MustCompile(`Python ([\d\.]+)`), ID: "python3"},
+	{Name: "Ruby", Command: "ruby", VersionArg: "--version", VersionRegex: regexp.MustCompile(`ruby ([\d\.]+)`)},
+	{Name: "PHP", Command: "php", VersionArg: "--version", VersionRegex: regexp.MustCompile(`PHP ([\d\.]+)`)},
+	{Name: "Perl", Command: "perl", VersionArg: "--version", VersionRegex: regexp.MustCompile(`v([\d\.]+)`)},
+	{Name: "Lua", Command: "lua", VersionArg: "-v", VersionRegex: regexp.MustCompile(`Lua ([\d\.]+)`)},
+
+	// JVM Languages
+	{Name: "Groovy", Command: "groovy", VersionArg: "--version", VersionRegex: regexp.MustCompile(`Groovy Version: ([\d\.]+)`)},
+
+	// Functional Languages
+	{Name: "Haskell", Command: "ghc", VersionArg: "--version", VersionRegex: regexp.MustCompile(`version ([\d\.]+)`)},
+	{Name: "Elixir", Command: "elixir", VersionArg: "--version", VersionRegex: regexp.MustCompile(`Elixir ([\d\.]+)`)},
+	{Name: "Clojure", Command: "clj", VersionArg: "--version", VersionRegex: regexp.MustCompile(`Clojure CLI version ([\d\.]+)`)},
+
+	// Web Technologies
+	{Name: "TypeScript", Command: "tsc", VersionArg: "--version", VersionRegex: regexp.MustCompile(`Version ([\d\.]+)`)},
+	{Name: "Dart", Command: "dart", VersionArg: "--version", VersionRegex: regexp.MustCompile(`Dart SDK version: ([\d\.]+)`)},
+
+	// Shells
+	{Name: "Bash", Command: "bash", VersionArg: "--version", VersionRegex: regexp.MustCompile(`version ([\d\.]+)`)},
+	{Name: "Zsh", Command: "zsh", VersionArg: "--version", VersionRegex: regexp.MustCompile(`zsh ([\d\.]+)`)},
+	{Name: "Fish", Command: "fish", VersionArg: "--version", VersionRegex: regexp.MustCompile(`fish, version ([\d\.]+)`)},
+
+	// Database and Query Languages
+	{Name: "SQLite", Command: "sqlite3", VersionArg: "--version", VersionRegex: regexp.MustCompile(`([\d\.]+)`)},
+	{Name: "PostgreSQL", Command: "psql", VersionArg: "--version", VersionRegex: regexp.MustCompile(`psql \(PostgreSQL\) ([\d\.]+)`)},
+	{Name: "MySQL", Command: "mysql", VersionArg: "--version", VersionRegex: regexp.MustCompile(`Ver ([\d\.]+)`)},
+}
+
+// DetectProgrammingLanguages finds common programming languages, and those
+// of the user's custom detectors.
+func DetectProgrammingLanguages(ctx context.Context, envData *types.EnvironmentData) {
+	executables := withCustom(detectorConfig, languageExecutables, types.CategoryLanguages)
+	detectExecutables(ctx, envData, manifest.limit(types.CategoryLanguages, executables, detectorConfig.AliasGroups()), envData.ConfiguredLanguages)
+}
+
+// tmuxVersion matches 'tmux -V' output such as "tmux 3.4", "tmux 3.3a" or
+// "tmux next-3.5" for builds from the development branch
+var tmuxVersion = regexp.MustCompile(`tmux (?:next-)?(\d+\.\d+[a-z]?)`)
+
+// toolExecutables are the development tools the scanner detects
+var toolExecutables = []Executable{
+	// Version Control
+	{Name: "Git", Command: "git", VersionArg: "--version", VersionRegex: regexp.MustCompile(`git version ([\d\.]+)`)},
+	{Name: "Mercurial", Command: "hg", VersionArg: "--version", VersionRegex: regexp.MustCompile(`version ([\d\.]+)`)},
+	{Name: "Subversion", Command: "svn", VersionArg: "--version --quiet", VersionRegex: regexp.MustCompile(`([\d\.]+)`)},
+
+	// Containerization
+	{Name: "Docker", Command: "docker", VersionArg: "--version", VersionRegex: regexp.MustCompile(`Docker version ([\d\.]+)`)},
+	{Name: "Docker Compose", Command: "docker-compose", VersionArg: "--version", VersionRegex: regexp.MustCompile(`docker-compose version ([\d\.]+)`)},
+	{Name: "Kubernetes", Command: "kubectl", VersionArg: "version --client", VersionRegex: regexp.MustCompile(`Client Version: v([\d\.]+)`), ID: "kubectl"},
+	{Name: "Helm", Command: "helm", VersionArg: "version --short", VersionRegex: regexp.MustCompile(`v([\d\.]+)`)},
+
+	// Build Tools
+	{Name: "Make", Command: "make", VersionArg: "--version", VersionRegex: regexp.MustCompile(`GNU Make ([\d\.]+)`)},
+	{Name: "CMake", Command: "cmake", VersionArg: "--version", VersionRegex: regexp.MustCompile(`cmake version ([\d\.]+)`)},
+	{Name: "Gradle", Command: "gradle", VersionArg: "--version", VersionRegex: regexp.MustCompile(`Gradle ([\d\.]+)`)},
+	{Name: "Maven", Command: "mvn", VersionArg: "--version", VersionRegex: regexp.MustCompile(`Apache Maven ([\d\.]+)`)},
+
+	// Package Managers (not in package managers to avoid duplication)
+	{Name: "npm", Command: "npm", VersionArg: "--version", VersionRegex: regexp.MustCompile(`([\d\.]+)`)},
+	{Name: "yarn", Command: "yarn", VersionArg: "--version", VersionRegex: regexp.MustCompile(`([\d\.]+)`)},
+	{Name: "pnpm", Command: "pnpm", VersionArg: "--version", VersionRegex: regexp.MustCompile(`([\d\.]+)`)},
+	{Name: "pip", Command: "pip", VersionArg: "--version", VersionRegex: regexp.MustCompile(`pip ([\d\.]+)`)},
+	{Name: "pip3", Command: "pip3", VersionArg: "--version", VersionRegex: regexp.MustCompile(`pip ([\d\.]+)`)},
+
+	// Cloud CLIs
+	{Name: "AWS CLI", Command: "aws", VersionArg: "--version", VersionRegex: regexp.MustCompile(`aws-cli/([\d\.]+)`)},
+	{Name: "Azure CLI", Command: "az", VersionArg: "--version", VersionRegex: regexp.MustCompile(`azure-cli\s+([\d\.]+)`)},
+	{Name: "Google Cloud SDK", Command: "gcloud", VersionArg: "--version", VersionRegex: regexp.MustCompile(`Google Cloud SDK ([\d\.]+)`)},
+
+	// Infrastructure as Code
+	{Name: "Terraform", Command: "terraform", VersionArg: "--version", VersionRegex: regexp.MustCompile(`Terraform v([\d\.]+)`)},
+	{Name: "Ansible", Command: "ansible", VersionArg: "--version", VersionRegex: regexp.MustCompile(`ansible \[core ([\d\.]+)\](?:\n|\r\n)?`)},
+	{Name: "Packer", Command: "packer", VersionArg: "--version", VersionRegex: regexp.MustCompile(`([\d\.]+)`)},
+
+	// Terminal multiplexers
+	{Name: "tmux", Command: "tmux", VersionArg: "-V", VersionRegex: tmuxVersion},
+	{Name: "GNU Screen", Command: "screen", VersionArg: "-v", VersionRegex: regexp.MustCompile(`Screen version ([\d\.]+)`), ExitsNonZero: true},
+	{Name: "Zellij", Command: "zellij", VersionArg: "--version", VersionRegex: regexp.MustCompile(`zellij ([\d\.]+)`)},
+
+	// Security
+	{Name: "OpenSSL", Command: "openssl", VersionArg: "version", VersionRegex: regexp.MustCompile(`OpenSSL ([\d\.]+[a-z]*)`)},
+
+	// Testing
+	{Name: "Jest", Command: "jest", VersionArg: "--version", VersionRegex: regexp.MustCompile(`([\d\.]+)`)},
+	{Name: "Pytest", Command: "pytest", VersionArg: "--version", VersionRegex: regexp.MustCompile(`pytest ([\d\.]+)`)},
+}
 
-	// Common, cross-platform package managers
-	crossPlatformExecutables := []Executable{
-		// Python
-		{Name: "pip", Command: "pip", VersionArg: "--version", VersionRegex: regexp.MustCompile(`pip ([\d\.]+)`)},
-		{Name: "pip3", Command: "pip3", VersionArg: "--version", VersionRegex: regexp.MustCompile(`pip ([\d\.]+)`)},
-		{Name: "pipx", Command: "pipx", VersionArg: "--version", VersionRegex: regexp.MustCompile(`([\d\.]+)`)},
-		{Name: "poetry", Command: "poetry", VersionArg: "--version", VersionRegex: regexp.MustCompile(`Poetry version ([\d\.]+)`)},
-
-		// JavaScript/Node.js
-		{Name: "npm", Command: "npm", VersionArg: "--version", VersionRegex: regexp.MustCompile(`([\d\.]+)`)},
-		{Name: "yarn", Command: "yarn", VersionArg: "--version", VersionRegex: regexp.MustCompile(`([\d\.]+)`)},
-		{Name: "pnpm", Command: "pnpm", VersionArg: "--version", VersionRegex: regexp.MustCompile(`([\d\.]+)`)},
-
-		// Container
-		{Name: "Docker", Command: "docker", VersionArg: "--version", VersionRegex: regexp.MustCompile(`Docker version ([\d\.]+)`)},
-		{Name: "Podman", Command: "podman", VersionArg: "--version", VersionRegex: regexp.MustCompile(`podman version ([\d\.]+)`)},
-	}
-	executables = append(executables, crossPlatformExecutables...)
-
-	// OS-specific package managers
-	switch runtime.GOOS {
-	case "darwin":
-		executables = append(executables,
-			Executable{Name: "Homebrew", Command: "brew", VersionArg: "--version", VersionRegex: regexp.MustCompile(`Homebrew ([\d\.]+)`)},
-			Executable{Name: "MacPorts", Command: "port", VersionArg: "version", VersionRegex: regexp.MustCompile(`version ([\d\.]+)`)},
-		)
-	case "linux":
-		// For Linux, we can check for common package managers
-		executables = append(executables,
-			Executable{Name: "apt", Command: "apt", VersionArg: "--version", VersionRegex: regexp.MustCompile(`apt ([\d\.]+)`)},
-			Executable{Name: "apt-get", Command: "apt-get", VersionArg: "--version", VersionRegex: regexp.MustCompile(`apt-get ([\d\.]+)`)},
-			Executable{Name: "yum", Command: "yum", VersionArg: "--version", VersionRegex: regexp.MustCompile(`([\d\.]+)`)},
-			Executable{Name: "dnf", Command: "dnf", VersionArg: "--version", VersionRegex: regexp.MustCompile(`([\d\.]+)`)},
-			Executable{Name: "pacman", Command: "pacman", VersionArg: "--version", VersionRegex: regexp.MustCompile(`Pacman v([\d\.]+)`)},
-			Executable{Name: "zypper", Command: "zypper", VersionArg: "--version", VersionRegex: regexp.MustCompile(`zypper ([\d\.]+)`)},
-			Executable{Name: "snap", Command: "snap", VersionArg: "--version", VersionRegex: regexp.MustCompile(`snap\\s+([\d\.]+)`)},
-		)
-	case "windows":
-		executables = append(executables,
-			Executable{Name: "Chocolatey", Command: "choco", VersionArg: "--version", VersionRegex: regexp.MustCompile(`([\d\.]+)`)},
-			Executable{Name: "Scoop", Command: "scoop", VersionArg: "--version", VersionRegex: regexp.MustCompile(`([\d\.]+)`)},
-			Executable{Name: "Winget", Command: "winget", VersionArg: "--version", VersionRegex: regexp.MustCompile(`v([\d\.]+)`)},
-		)
-	}
-
-	detectExecutables(executables, envData.PackageManagers)
+// DetectTools finds common development tools and their versions, and those
+// of the user's custom detectors.
+func DetectTools(ctx context.Context, envData *types.EnvironmentData) {
+	executables := withCustom(detectorConfig, toolExecutables, types.CategoryTools)
+	detectExecutables(ctx, envData, manifest.limit(types.CategoryTools, executables, detectorConfig.AliasGroups()), envData.Tools)
 }
 
-// DetectProgrammingLanguages finds common programming languages.
-func DetectProgrammingLanguages(envData *types.EnvironmentData) {
-	languages := []Executable{
-		// Compiled Languages
-		{Name: "Go", Command: "go", VersionArg: "version", VersionRegex: regexp.MustCompile(`go version go([\d\.]+)`)},
-		{Name: "Rust", Command: "rustc", VersionArg: "--version", VersionRegex: regexp.MustCompile(`rustc ([\d\.]+)`)},
-		{Name: "Java", Command: "java", VersionArg: "-version", VersionRegex: regexp.MustCompile(`version "([\d\._]+)"`)},
-		{Name: "Kotlin", Command: "kotlin", VersionArg: "-version", VersionRegex: regexp.MustCompile(`Kotlin version ([\d\.]+)`)},
-		{Name: "C#", Command: "dotnet", VersionArg: "--version", VersionRegex: regexp.MustCompile(`([\d\.]+)`)},
-		{Name: "Scala", Command: "scala", VersionArg: "-version", VersionRegex: regexp.MustCompile(`version ([\d\.]+)`)},
-
-		// Scripting Languages
-		{Name: "Node.js", Command: "node", VersionArg: "--version", VersionRegex: regexp.MustCompile(`v?([\d\.]+)`)},
-		{Name: "Python", Command: "python", VersionArg: "--version", VersionRegex: regexp.MustCompile(`Python ([\d\.]+)`)},
-		{Name: "Python 3", Command: "python3", VersionArg: "--version", VersionRegex: regexp.MustCompile(`Python ([\d\.]+)`)},
-		{Name: "Ruby", Command: "ruby", VersionArg: "--version", VersionRegex: regexp.MustCompile(`ruby ([\d\.]+)`)},
-		{Name: "PHP", Command: "php", VersionArg: "--version", VersionRegex: regexp.MustCompile(`PHP ([\d\.]+)`)},
-		{Name: "Perl", Command: "perl", VersionArg: "--version", VersionRegex: regexp.MustCompile(`v([\d\.]+)`)},
-		{Name: "Lua", Command: "lua", VersionArg: "-v", VersionRegex: regexp.MustCompile(`Lua ([\d\.]+)`)},
-
-		// JVM Languages
-		{Name: "Groovy", Command: "groovy", VersionArg: "--version", VersionRegex: regexp.MustCompile(`Groovy Version: ([\d\.]+)`)},
-
-		// Functional Languages
-		{Name: "Haskell", Command: "ghc", VersionArg: "--version", VersionRegex: regexp.MustCompile(`version ([\d\.]+)`)},
-		{Name: "Elixir", Command: "elixir", VersionArg: "--version", VersionRegex: regexp.MustCompile(`Elixir ([\d\.]+)`)},
-		{Name: "Clojure", Command: "clj", VersionArg: "--version", VersionRegex: regexp.MustCompile(`Clojure CLI version ([\d\.]+)`)},
-
-		// Web Technologies
-		{Name: "TypeScript", Command: "tsc", VersionArg: "--version", VersionRegex: regexp.MustCompile(`Version ([\d\.]+)`)},
-		{Name: "Dart", Command: "dart", VersionArg: "--version", VersionRegex: regexp.MustCompile(`Dart SDK version: ([\d\.]+)`)},
-
-		// Shells
-		{Name: "Bash", Command: "bash", VersionArg: "--version", VersionRegex: regexp.MustCompile(`version ([\d\.]+)`)},
-		{Name: "Zsh", Command: "zsh", VersionArg: "--version", VersionRegex: regexp.MustCompile(`zsh ([\d\.]+)`)},
-		{Name: "Fish", Command: "fish", VersionArg: "--version", VersionRegex: regexp.MustCompile(`fish, version ([\d\.]+)`)},
-
-		// Database and Query Languages
-		{Name: "SQLite", Command: "sqlite3", VersionArg: "--version", VersionRegex: regexp.MustCompile(`([\d\.]+)`)},
-		{Name: "PostgreSQL", Command: "psql", VersionArg: "--version", VersionRegex: regexp.MustCompile(`psql \(PostgreSQL\) ([\d\.]+)`)},
-		{Name: "MySQL", Command: "mysql", VersionArg: "--version", VersionRegex: regexp.MustCompile(`Ver ([\d\.]+)`)},
-	}
-	detectExecutables(languages, envData.ConfiguredLanguages)
+// editorExecutables are the code editors and IDEs the scanner detects
+var editorExecutables = []Executable{
+	// Lightweight Editors
+	{Name: "VS Code", Command: "code", VersionArg: "--version", VersionRegex: regexp.MustCompile(`([\d\.]+)`), ID: "vscode"},
+	{Name: "Sublime Text", Command: "subl", VersionArg: "--version", VersionRegex: regexp.MustCompile(`Sublime Text Build ([\d\.]+)`)},
+	{Name: "Atom", Command: "atom", VersionArg: "--version", VersionRegex: regexp.MustCompile(`Atom\s+:\s+([\d\.]+)`)},
+	{Name: "Vim", Command: "vim", VersionArg: "--version", VersionRegex: regexp.MustCompile(`VIM - Vi IMproved ([\d\.]+)`)},
+	{Name: "Neovim", Command: "nvim", VersionArg: "--version", VersionRegex: regexp.MustCompile(`NVIM v([\d\.]+)`)},
+	{Name: "Emacs", Command: "emacs", VersionArg: "--version", VersionRegex: regexp.MustCompile(`GNU Emacs ([\d\.]+)`)},
+	{Name: "Nano", Command: "nano", VersionArg: "--version", VersionRegex: regexp.MustCompile(`nano version ([\d\.]+)`)},
+
+	// Full IDEs
+	{Name: "IntelliJ IDEA", Command: "idea", VersionArg: "--version", VersionRegex: regexp.MustCompile(`(?:IntelliJ IDEA|IntelliJ IDEA Community Edition) ([\d\.]+)`)},
+	{Name: "PyCharm", Command: "pycharm", VersionArg: "--version", VersionRegex: regexp.MustCompile(`PyCharm ([\d\.]+)`)},
+	{Name: "WebStorm", Command: "webstorm", VersionArg: "--version", VersionRegex: regexp.MustCompile(`WebStorm ([\d\.]+)`)},
+	{Name: "GoLand", Command: "goland", VersionArg: "--version", VersionRegex: regexp.MustCompile(`GoLand ([\d\.]+)`)},
+	{Name: "Android Studio", Command: "studio", VersionArg: "--version", VersionRegex: regexp.MustCompile(`Android Studio ([\d\.]+)`)},
+	{Name: "Xcode", Command: "xcodebuild", VersionArg: "-version", VersionRegex: regexp.MustCompile(`Xcode ([\d\.]+)`)},
+	{Name: "Visual Studio", Command: "devenv", VersionArg: "/?", VersionRegex: regexp.MustCompile(`Microsoft Visual Studio ([\d\.]+)`)},
+
+	// Database tools and version control GUIs: see appCommands
+
+	// AI Code Editors
+	{Name: "Windsurf", Command: "windsurf", VersionArg: "--version", VersionRegex: regexp.MustCompile(`Windsurf ([\d\.]+)`)},
+	{Name: "Cursor", Command: "cursor", VersionArg: "--version", VersionRegex: regexp.MustCompile(`Cursor ([\d\.]+)`)},
 }
 
-// DetectTools finds common development tools and their versions.
-func DetectTools(envData *types.EnvironmentData) {
-	tools := []Executable{
-		// Version Control
-		{Name: "Git", Command: "git", VersionArg: "--version", VersionRegex: regexp.MustCompile(`git version ([\d\.]+)`)},
-		{Name: "Mercurial", Command: "hg", VersionArg: "--version", VersionRegex: regexp.MustCompile(`version ([\d\.]+)`)},
-		{Name: "Subversion", Command: "svn", VersionArg: "--version --quiet", VersionRegex: regexp.MustCompile(`([\d\.]+)`)},
-
-		// Containerization
-		{Name: "Docker", Command: "docker", VersionArg: "--version", VersionRegex: regexp.MustCompile(`Docker version ([\d\.]+)`)},
-		{Name: "Docker Compose", Command: "docker-compose", VersionArg: "--version", VersionRegex: regexp.MustCompile(`docker-compose version ([\d\.]+)`)},
-		{Name: "Kubernetes", Command: "kubectl", VersionArg: "version --client --short", VersionRegex: regexp.MustCompile(`Client Version: v([\d\.]+)`)},
-		{Name: "Helm", Command: "helm", VersionArg: "version --short", VersionRegex: regexp.MustCompile(`v([\d\.]+)`)},
-
-		// Build Tools
-		{Name: "Make", Command: "make", VersionArg: "--version", VersionRegex: regexp.MustCompile(`GNU Make ([\d\.]+)`)},
-		{Name: "CMake", Command: "cmake", VersionArg: "--version", VersionRegex: regexp.MustCompile(`cmake version ([\d\.]+)`)},
-		{Name: "Gradle", Command: "gradle", VersionArg: "--version", VersionRegex: regexp.MustCompile(`Gradle ([\d\.]+)`)},
-		{Name: "Maven", Command: "mvn", VersionArg: "--version", VersionRegex: regexp.MustCompile(`Apache Maven ([\d\.]+)`)},
-
-		// Package Managers (not in package managers to avoid duplication)
-		{Name: "npm", Command: "npm", VersionArg: "--version", VersionRegex: regexp.MustCompile(`([\d\.]+)`)},
-		{Name: "yarn", Command: "yarn", VersionArg: "--version", VersionRegex: regexp.MustCompile(`([\d\.]+)`)},
-		{Name: "pnpm", Command: "pnpm", VersionArg: "--version", VersionRegex: regexp.MustCompile(`([\d\.]+)`)},
-		{Name: "pip", Command: "pip", VersionArg: "--version", VersionRegex: regexp.MustCompile(`pip ([\d\.]+)`)},
-		{Name: "pip3", Command: "pip3", VersionArg: "--version", VersionRegex: regexp.MustCompile(`pip ([\d\.]+)`)},
-
-		// Cloud CLIs
-		{Name: "AWS CLI", Command: "aws", VersionArg: "--version", VersionRegex: regexp.MustCompile(`aws-cli/([\d\.]+)`)},
-		{Name: "Azure CLI", Command: "az", VersionArg: "--version", VersionRegex: regexp.MustCompile(`azure-cli\s+([\d\.]+)`)},
-		{Name: "Google Cloud SDK", Command: "gcloud", VersionArg: "--version", VersionRegex: regexp.MustCompile(`Google Cloud SDK ([\d\.]+)`)},
-
-		// Infrastructure as Code
-		{Name: "Terraform", Command: "terraform", VersionArg: "--version", VersionRegex: regexp.MustCompile(`Terraform v([\d\.]+)`)},
-		{Name: "Ansible", Command: "ansible", VersionArg: "--version", VersionRegex: regexp.MustCompile(`ansible \[core ([\d\.]+)\](?:\n|\r\n)?`)},
-		{Name: "Packer", Command: "packer", VersionArg: "--version", VersionRegex: regexp.MustCompile(`([\d\.]+)`)},
-
-		// Security
-		{Name: "OpenSSL", Command: "openssl", VersionArg: "version", VersionRegex: regexp.MustCompile(`OpenSSL ([\d\.]+[a-z]*)`)},
-
-		// Testing
-		{Name: "Jest", Command: "jest", VersionArg: "--version", VersionRegex: regexp.MustCompile(`([\d\.]+)`)},
-		{Name: "Pytest", Command: "pytest", VersionArg: "--version", VersionRegex: regexp.MustCompile(`pytest ([\d\.]+)`)},
-	}
-	detectExecutables(tools, envData.Tools)
+// appCommands are the commands of the database tools and version control
+// GUIs of guiApps. They are only looked for where DetectApps reads no
+// install records, such as on Linux, where the apps' packages put them on
+// PATH; elsewhere the app is found from its bundle or uninstall entry.
+var appCommands = []Executable{
+	{Name: "DBeaver", Command: "dbeaver", VersionArg: "--version", VersionRegex: regexp.MustCompile(`DBeaver ([\d\.]+)`)},
+	{Name: "TablePlus", Command: "tableplus", VersionArg: "--version", VersionRegex: regexp.MustCompile(`TablePlus ([\d\.]+)`)},
+	{Name: "GitHub Desktop", Command: "github", VersionArg: "--version", VersionRegex: regexp.MustCompile(`GitHub Desktop ([\d\.]+)`)},
+	{Name: "GitKraken", Command: "gitkraken", VersionArg: "--version", VersionRegex: regexp.MustCompile(`GitKraken ([\d\.]+)`)},
+	{Name: "Sourcetree", Command: "sourcetree", VersionArg: "--version", VersionRegex: regexp.MustCompile(`Sourcetree ([\d\.]+)`)},
+}
+
+// editorCommands returns the editors looked for by command on goos
+func editorCommands(goos string) []Executable {
+	if readsInstallRecords(goos) {
+		return editorExecutables
+	}
+	return append(slices.Clip(editorExecutables), appCommands...)
+}
+
+// KnownTools returns every tool, language, package manager and editor the
+// scanner can detect on any OS, without duplicates
+func KnownTools() []types.ToolInfo {
+	lists := [][]Executable{languageExecutables, toolExecutables, editorExecutables, appCommands, crossPlatformPackageManagers}
+	for _, plugin := range dockerPlugins {
+		lists = append(lists, []Executable{plugin.Executable})
+	}
+	for _, goos := range []string{"darwin", "linux", "windows"} {
+		lists = append(lists, osPackageManagers[goos])
+	}
+	for _, app := range guiApps {
+		lists = append(lists, []Executable{{Name: app.Name, ID: app.ID}})
+	}
+
+	var tools []types.ToolInfo
+	seen := make(map[string]bool)
+	for _, list := range lists {
+		for _, exe := range list {
+			if info := exe.Info(); !seen[info.ID] {
+				seen[info.ID] = true
+				tools = append(tools, info)
+			}
+		}
+	}
+	return tools
 }
 
-// DetectEditors finds common code editors and IDEs.
-func DetectEditors(envData *types.EnvironmentData) {
-	editors := []Executable{
-		// Lightweight Editors
-		{Name: "VS Code", Command: "code", VersionArg: "--version", VersionRegex: regexp.MustCompile(`([\d\.]+)`)},
-		{Name: "Sublime Text", Command: "subl", VersionArg: "--version", VersionRegex: regexp.MustCompile(`Sublime Text Build ([\d\.]+)`)},
-		{Name: "Atom", Command: "atom", VersionArg: "--version", VersionRegex: regexp.MustCompile(`Atom\s+:\s+([\d\.]+)`)},
-		{Name: "Vim", Command: "vim", VersionArg: "--version", VersionRegex: regexp.MustCompile(`VIM - Vi IMproved ([\d\.]+)`)},
-		{Name: "Neovim", Command: "nvim", VersionArg: "--version", VersionRegex: regexp.MustCompile(`NVIM v([\d\.]+)`)},
-		{Name: "Emacs", Command: "emacs", VersionArg: "--version", VersionRegex: regexp.MustCompile(`GNU Emacs ([\d\.]+)`)},
-		{Name: "Nano", Command: "nano", VersionArg: "--version", VersionRegex: regexp.MustCompile(`nano version ([\d\.]+)`)},
-
-		// Full IDEs
-		{Name: "IntelliJ IDEA", Command: "idea", VersionArg: "--version", VersionRegex: regexp.MustCompile(`(?:IntelliJ IDEA|IntelliJ IDEA Community Edition) ([\d\.]+)`)},
-		{Name: "PyCharm", Command: "pycharm", VersionArg: "--version", VersionRegex: regexp.MustCompile(`PyCharm ([\d\.]+)`)},
-		{Name: "WebStorm", Command: "webstorm", VersionArg: "--version", VersionRegex: regexp.MustCompile(`WebStorm ([\d\.]+)`)},
-		{Name: "GoLand", Command: "goland", VersionArg: "--version", VersionRegex: regexp.MustCompile(`GoLand ([\d\.]+)`)},
-		{Name: "Android Studio", Command: "studio", VersionArg: "--version", VersionRegex: regexp.MustCompile(`Android Studio ([\d\.]+)`)},
-		{Name: "Xcode", Command: "xcodebuild", VersionArg: "-version", VersionRegex: regexp.MustCompile(`Xcode ([\d\.]+)`)},
-		{Name: "Visual Studio", Command: "devenv", VersionArg: "/?", VersionRegex: regexp.MustCompile(`Microsoft Visual Studio ([\d\.]+)`)},
-
-		// Database Tools
-		{Name: "DBeaver", Command: "dbeaver", VersionArg: "--version", VersionRegex: regexp.MustCompile(`DBeaver ([\d\.]+)`)},
-		{Name: "TablePlus", Command: "tableplus", VersionArg: "--version", VersionRegex: regexp.MustCompile(`TablePlus ([\d\.]+)`)},
-
-		// Version Control GUIs
-		{Name: "GitHub Desktop", Command: "github", VersionArg: "--version", VersionRegex: regexp.MustCompile(`GitHub Desktop ([\d\.]+)`)},
-		{Name: "GitKraken", Command: "gitkraken", VersionArg: "--version", VersionRegex: regexp.MustCompile(`GitKraken ([\d\.]+)`)},
-		{Name: "Sourcetree", Command: "sourcetree", VersionArg: "--version", VersionRegex: regexp.MustCompile(`Sourcetree ([\d\.]+)`)},
-
-		// AI Code Editors
-		{Name: "Windsurf", Command: "windsurf", VersionArg: "--version", VersionRegex: regexp.MustCompile(`Windsurf ([\d\.]+)`)},
-		{Name: "Cursor", Command: "cursor", VersionArg: "--version", VersionRegex: regexp.MustCompile(`Cursor ([\d\.]+)`)},
-	}
-	detectExecutables(editors, envData.CodeEditors)
+// DetectEditors finds common code editors and IDEs, and those of the user's
+// custom detectors.
+func DetectEditors(ctx context.Context, envData *types.EnvironmentData) {
+	executables := withCustom(detectorConfig, editorCommands(runtime.GOOS), types.CategoryEditors)
+	detectExecutables(ctx, envData, manifest.limit(types.CategoryEditors, executables, detectorConfig.AliasGroups()), envData.CodeEditors)
 }
@@ -1,10 +1,22 @@
 package scanner
 
 import (
+	"os"
+	"path/filepath"
 	"regexp"
 	"testing"
 )
 
+// readFixture returns the contents of testdata/dir/name
+func readFixture(t *testing.T, dir, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", dir, name))
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+	return string(data)
+}
+
 func TestParseVersion(t *testing.T) {
 	testCases := []struct {
 		name     string
@@ -42,6 +54,36 @@ func TestParseVersion(t *testing.T) {
 			regex:    regexp.MustCompile(`Homebrew ([\d\.]+)`),
 			expected: "3.5.2",
 		},
+		{
+			name:     "tmux Version",
+			output:   "tmux 3.4\n",
+			regex:    tmuxVersion,
+			expected: "3.4",
+		},
+		{
+			name:     "tmux Patch Release",
+			output:   "tmux 3.3a\n",
+			regex:    tmuxVersion,
+			expected: "3.3a",
+		},
+		{
+			name:     "tmux Development Build",
+			output:   "tmux next-3.5\n",
+			regex:    tmuxVersion,
+			expected: "3.5",
+		},
+		{
+			name:     "Screen Version",
+			output:   "Screen version 4.00.03 (FAU) 23-Oct-06\n",
+			regex:    regexp.MustCompile(`Screen version ([\d\.]+)`),
+			expected: "4.00.03",
+		},
+		{
+			name:     "Zellij Version",
+			output:   "zellij 0.39.2\n",
+			regex:    regexp.MustCompile(`zellij ([\d\.]+)`),
+			expected: "0.39.2",
+		},
 		{
 			name:     "No Match",
 			output:   "Some random string",
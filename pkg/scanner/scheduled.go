@@ -0,0 +1,62 @@
+package scanner
+
+import (
+	"context"
+	"os"
+	"os/user"
+	"runtime"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/cron"
+	"github.com/MRQ67/stackmatch-cli/pkg/redact"
+	"github.com/MRQ67/stackmatch-cli/pkg/runner"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+// DetectScheduledJobs records the current user's crontab entries, or on
+// Windows the scheduled tasks that run as the current user. System crontabs
+// and tasks of other accounts are never read. Secrets in the commands are
+// redacted before they are stored.
+func DetectScheduledJobs(ctx context.Context, envData *types.EnvironmentData) {
+	detectScheduledJobs(ctx, envData, runner.Default, runner.DefaultPath, runtime.GOOS, currentUser())
+}
+
+func detectScheduledJobs(ctx context.Context, envData *types.EnvironmentData, r runner.Runner, path runner.PathIndex, goos, username string) {
+	var (
+		jobs []types.ScheduledJob
+		err  error
+	)
+	if goos == "windows" {
+		if _, lookErr := path.LookPath("schtasks"); lookErr != nil {
+			return
+		}
+		var output string
+		if output, err = cron.QueryTasks(ctx, r); err == nil {
+			jobs, err = cron.ParseSchtasks(output, username)
+		}
+	} else {
+		if _, lookErr := path.LookPath("crontab"); lookErr != nil {
+			return
+		}
+		var content string
+		if content, err = cron.ReadCrontab(ctx, r); err == nil {
+			jobs = cron.ParseCrontab(content)
+		}
+	}
+	if err != nil {
+		envData.Warnings = append(envData.Warnings, err.Error())
+		return
+	}
+
+	for i := range jobs {
+		jobs[i].Command = redact.String(jobs[i].Command)
+	}
+	envData.ScheduledJobs = jobs
+}
+
+// currentUser returns the name of the account running the scan
+func currentUser() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return os.Getenv("USERNAME")
+}
@@ -0,0 +1,83 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/runner/runnertest"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+func TestDetectScheduledJobs(t *testing.T) {
+	testCases := []struct {
+		name             string
+		goos             string
+		responses        map[string]runnertest.Response
+		expected         []types.ScheduledJob
+		expectedWarnings int
+	}{
+		{
+			name: "Crontab with secrets",
+			goos: "linux",
+			responses: map[string]runnertest.Response{
+				"crontab -l": {Output: "0 2 * * * PGPASSWORD=hunter2 pg_dump app > ~/backups/app.sql\n"},
+			},
+			expected: []types.ScheduledJob{
+				{Source: types.JobSourceCrontab, Schedule: "0 2 * * *", Command: "PGPASSWORD=[REDACTED] pg_dump app > ~/backups/app.sql"},
+			},
+		},
+		{
+			name: "No crontab",
+			goos: "linux",
+			responses: map[string]runnertest.Response{
+				"crontab -l": {Stderr: "no crontab for dev\n", Err: errors.New("exit status 1")},
+			},
+		},
+		{
+			name: "Crontab not allowed",
+			goos: "linux",
+			responses: map[string]runnertest.Response{
+				"crontab -l": {Stderr: "You (dev) are not allowed to use this program (crontab)\n", Err: errors.New("exit status 1")},
+			},
+			expectedWarnings: 1,
+		},
+		{
+			name: "Scheduled tasks",
+			goos: "windows",
+			responses: map[string]runnertest.Response{
+				"schtasks /query /fo CSV /v": {Output: "\"HostName\",\"TaskName\",\"Task To Run\",\"Run As User\",\"Schedule Type\",\"Start Time\",\"Days\"\r\n" +
+					"\"DEVBOX\",\"\\Sync\",\"sync.cmd --token=abc123\",\"DEVBOX\\dev\",\"Daily \",\"8:00:00 AM\",\"Every 1 day(s)\"\r\n"},
+			},
+			expected: []types.ScheduledJob{
+				{Source: types.JobSourceSchtasks, Name: `\Sync`, Schedule: "Daily at 8:00:00 AM", Command: "sync.cmd --token=[REDACTED]"},
+			},
+		},
+		{
+			name: "Scheduled tasks access denied",
+			goos: "windows",
+			responses: map[string]runnertest.Response{
+				"schtasks /query /fo CSV /v": {Output: "ERROR: Access is denied.\r\n", Err: errors.New("exit status 1")},
+			},
+			expectedWarnings: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &runnertest.Runner{Responses: tc.responses}
+			path := runnertest.NewPath([]string{"/usr/bin"}, "/usr/bin/crontab", "/usr/bin/schtasks")
+			env := &types.EnvironmentData{}
+
+			detectScheduledJobs(context.Background(), env, r, path, tc.goos, `DEVBOX\dev`)
+
+			if !reflect.DeepEqual(env.ScheduledJobs, tc.expected) {
+				t.Errorf("expected jobs %+v but got %+v", tc.expected, env.ScheduledJobs)
+			}
+			if len(env.Warnings) != tc.expectedWarnings {
+				t.Errorf("expected %d warnings but got %q", tc.expectedWarnings, env.Warnings)
+			}
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package scanner
+
+import (
+	"context"
+	"runtime"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/runner"
+	"github.com/MRQ67/stackmatch-cli/pkg/services"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+// DetectServices records the developer services, such as databases, set to
+// start at login or boot through brew services, systemd or the Windows
+// service manager. Services outside the allowlist of developer services are
+// not recorded.
+func DetectServices(ctx context.Context, envData *types.EnvironmentData) {
+	detectServices(ctx, envData, runner.Default, runner.DefaultPath, runtime.GOOS)
+}
+
+func detectServices(ctx context.Context, envData *types.EnvironmentData, r runner.Runner, path runner.PathIndex, goos string) {
+	installed, warnings := services.List(ctx, r, path, goos)
+	envData.Warnings = append(envData.Warnings, warnings...)
+	envData.Services = services.Enabled(installed)
+}
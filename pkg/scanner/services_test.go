@@ -0,0 +1,32 @@
+package scanner
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/runner/runnertest"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+func TestDetectServices(t *testing.T) {
+	r := &runnertest.Runner{Responses: map[string]runnertest.Response{
+		"brew services list --json": {Output: `[
+			{"name":"postgresql@16","status":"started","user":"dev","file":"/Users/dev/Library/LaunchAgents/homebrew.mxcl.postgresql@16.plist"},
+			{"name":"redis","status":"none","user":null,"file":"/opt/homebrew/opt/redis/homebrew.mxcl.redis.plist"},
+			{"name":"unbound","status":"started","user":"root","file":"/Library/LaunchDaemons/homebrew.mxcl.unbound.plist"}
+		]`},
+	}}
+	path := runnertest.NewPath([]string{"/opt/homebrew/bin"}, "/opt/homebrew/bin/brew")
+	env := &types.EnvironmentData{}
+
+	detectServices(context.Background(), env, r, path, "darwin")
+
+	expected := []types.Service{{Name: "postgresql", Unit: "postgresql@16", Manager: types.ServiceManagerBrew, Scope: types.ServiceScopeUser, State: "started"}}
+	if !reflect.DeepEqual(env.Services, expected) {
+		t.Errorf("expected services %+v but got %+v", expected, env.Services)
+	}
+	if len(env.Warnings) != 0 {
+		t.Errorf("expected no warnings but got %q", env.Warnings)
+	}
+}
@@ -0,0 +1,137 @@
+package scanner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/runner"
+)
+
+// DefaultShellProbeTimeout bounds each command run through a login shell.
+// Slow shell init files are common, hanging ones are not.
+const DefaultShellProbeTimeout = 5 * time.Second
+
+// DefaultLoginShellTools lists the commands version managers such as nvm,
+// sdkman, rbenv, pyenv and rustup usually put on PATH from shell init files
+var DefaultLoginShellTools = []string{
+	"node", "npm", "yarn", "pnpm", "deno", "bun",
+	"java", "kotlin", "scala", "gradle", "mvn",
+	"ruby", "gem", "python", "python3", "pip", "pip3", "poetry",
+	"go", "rustc", "cargo", "php",
+}
+
+// ShellProbe retries detection of tools missing from PATH through the user's
+// login shell, so tools that only exist once shell init files have run are
+// found. On Windows the PowerShell profile plays that role.
+type ShellProbe struct {
+	// Shell is the shell to run, $SHELL by default
+	Shell string
+	// Commands holds the commands worth probing
+	Commands map[string]bool
+	// Timeout bounds each probed command
+	Timeout time.Duration
+	// PowerShell runs commands through PowerShell instead of 'shell -lc'
+	PowerShell bool
+}
+
+// NewShellProbe returns a probe for commands, or for DefaultLoginShellTools
+// when commands is empty
+func NewShellProbe(commands []string) *ShellProbe {
+	if len(commands) == 0 {
+		commands = DefaultLoginShellTools
+	}
+	p := &ShellProbe{Commands: make(map[string]bool), Timeout: DefaultShellProbeTimeout}
+	for _, command := range commands {
+		p.Commands[command] = true
+	}
+	if runtime.GOOS == "windows" {
+		p.Shell = "powershell"
+		p.PowerShell = true
+	} else if p.Shell = os.Getenv("SHELL"); p.Shell == "" {
+		p.Shell = "/bin/sh"
+	}
+	return p
+}
+
+// probes reports whether command should be retried through the shell
+func (p *ShellProbe) probes(command string) bool {
+	return p != nil && p.Commands[command]
+}
+
+// finds reports whether the shell resolves command, so tools the shell lacks
+// as well are skipped without running, and logging, a failing version command
+func (p *ShellProbe) finds(ctx context.Context, r runner.Runner, command string) bool {
+	lookup := []string{"command", "-v"}
+	if p.PowerShell {
+		lookup = []string{"Get-Command"}
+	}
+	_, err := p.runner(r).CombinedOutput(ctx, lookup[0], append(lookup[1:], command)...)
+	return err == nil
+}
+
+// Source returns how tools found by the probe are recorded in ToolSources,
+// e.g. "login-shell:zsh" or "powershell-profile"
+func (p *ShellProbe) Source() string {
+	if p.PowerShell {
+		return "powershell-profile"
+	}
+	return "login-shell:" + filepath.Base(p.Shell)
+}
+
+// args returns the arguments that run name with args through the shell
+func (p *ShellProbe) args(name string, args []string) []string {
+	if p.PowerShell {
+		// PowerShell loads the profile unless told not to; '&' runs the
+		// quoted command name
+		words := []string{"&", powerShellQuote(name)}
+		for _, arg := range args {
+			words = append(words, powerShellQuote(arg))
+		}
+		return []string{"-NoLogo", "-NonInteractive", "-Command", strings.Join(words, " ")}
+	}
+	words := []string{shellQuote(name)}
+	for _, arg := range args {
+		words = append(words, shellQuote(arg))
+	}
+	return []string{"-lc", strings.Join(words, " ")}
+}
+
+// runner wraps r so every command runs through the shell
+func (p *ShellProbe) runner(r runner.Runner) runner.Runner {
+	return probeRunner{probe: p, next: r}
+}
+
+// probeRunner runs commands through a ShellProbe's shell, each under the
+// probe's timeout
+type probeRunner struct {
+	probe *ShellProbe
+	next  runner.Runner
+}
+
+// CombinedOutput implements runner.Runner
+func (r probeRunner) CombinedOutput(ctx context.Context, name string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.probe.Timeout)
+	defer cancel()
+	return r.next.CombinedOutput(ctx, r.probe.Shell, r.probe.args(name, args)...)
+}
+
+// Output implements runner.Runner
+func (r probeRunner) Output(ctx context.Context, name string, args ...string) (string, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.probe.Timeout)
+	defer cancel()
+	return r.next.Output(ctx, r.probe.Shell, r.probe.args(name, args)...)
+}
+
+// shellQuote quotes s for a POSIX shell
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// powerShellQuote quotes s as a PowerShell literal string
+func powerShellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
@@ -0,0 +1,118 @@
+package scanner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/runner"
+	"github.com/MRQ67/stackmatch-cli/pkg/runner/runnertest"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+func TestDetectThroughLoginShell(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake shell is a shell script")
+	}
+	// The fake shell only puts the version manager's directory on PATH in
+	// login mode, like nvm's lines in ~/.bash_profile
+	root := t.TempDir()
+	hidden := filepath.Join(root, "nvm", "bin")
+	shells := filepath.Join(root, "shells")
+	for _, dir := range []string{hidden, shells} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeStub(t, hidden, "node", `echo v20.11.0`)
+	writeStub(t, shells, "bash", `if [ "$1" = "-lc" ]; then PATH="`+hidden+`:$PATH"; export PATH; fi
+shift
+exec /bin/sh -c "$1"`)
+	writeStub(t, shells, "hang", `exec sleep 10`)
+
+	node := Executable{Name: "Node.js", Command: "node", VersionArg: "--version", VersionRegex: regexp.MustCompile(`v?([\d\.]+)`)}
+	ruby := Executable{Name: "Ruby", Command: "ruby", VersionArg: "--version", VersionRegex: regexp.MustCompile(`ruby ([\d\.]+)`)}
+	emptyPath := &runnertest.Path{Dirs: []string{t.TempDir()}}
+
+	testCases := []struct {
+		name            string
+		probe           *ShellProbe
+		expectedVersion string
+		expectedSource  string
+	}{
+		{
+			name:            "Found in login shell",
+			probe:           &ShellProbe{Shell: filepath.Join(shells, "bash"), Commands: map[string]bool{"node": true, "ruby": true}, Timeout: 5 * time.Second},
+			expectedVersion: "20.11.0",
+			expectedSource:  "login-shell:bash",
+		},
+		{
+			name: "Probe disabled",
+		},
+		{
+			name:  "Command not probed",
+			probe: &ShellProbe{Shell: filepath.Join(shells, "bash"), Commands: map[string]bool{"ruby": true}, Timeout: 5 * time.Second},
+		},
+		{
+			name:  "Shell hangs",
+			probe: &ShellProbe{Shell: filepath.Join(shells, "hang"), Commands: map[string]bool{"node": true}, Timeout: 100 * time.Millisecond},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			env := &types.EnvironmentData{ToolIDs: make(map[string]string)}
+			found := make(map[string]string)
+			start := time.Now()
+			detectExecutablesWith(context.Background(), runner.Default, emptyPath, nil, tc.probe, DefaultConcurrency, env, []Executable{node, ruby}, found)
+			if elapsed := time.Since(start); elapsed > 5*time.Second {
+				t.Errorf("expected the probe to give up after its timeout but it took %s", elapsed)
+			}
+
+			if found["Node.js"] != tc.expectedVersion {
+				t.Errorf("expected Node.js version %q but got %q", tc.expectedVersion, found["Node.js"])
+			}
+			if source := env.Tool("Node.js").Source; source != tc.expectedSource {
+				t.Errorf("expected source %q but got %q", tc.expectedSource, source)
+			}
+			if _, ok := found["Ruby"]; ok {
+				t.Errorf("expected Ruby, missing from the shell too, not to be recorded")
+			}
+			if len(env.BrokenTools) > 0 || len(env.Warnings) > 0 {
+				t.Errorf("expected tools missing from the shell not to be reported broken but got %v %q", env.BrokenTools, env.Warnings)
+			}
+		})
+	}
+}
+
+func TestShellProbeArgs(t *testing.T) {
+	testCases := []struct {
+		name     string
+		probe    ShellProbe
+		expected []string
+	}{
+		{
+			name:     "Login shell",
+			probe:    ShellProbe{Shell: "/bin/zsh"},
+			expected: []string{"-lc", `'kubectl' 'version' '--client' 'it'\''s'`},
+		},
+		{
+			name:     "PowerShell profile",
+			probe:    ShellProbe{Shell: "powershell", PowerShell: true},
+			expected: []string{"-NoLogo", "-NonInteractive", "-Command", `& 'kubectl' 'version' '--client' 'it''s'`},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := tc.probe.args("kubectl", []string{"version", "--client", "it's"})
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("expected %q but got %q", tc.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,292 @@
+package scanner
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/runner"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+// shellTools are the prompt and navigation tools recorded with the shell
+// setup, whose version they print with --version
+var shellTools = []string{"starship", "zoxide", "fzf"}
+
+// shellToolVersion matches the version printed by the shell tools, such as
+// "starship 1.17.1", "zoxide v0.9.4" or "0.46.1 (debian)"
+var shellToolVersion = regexp.MustCompile(`v?(\d+(?:\.\d+)+)`)
+
+// shellDirs are the directories shell frameworks are installed in and read
+// their config from, after the environment variables that move them
+type shellDirs struct {
+	// ZDotDir holds .zshrc, .zpreztorc and .zprezto: $ZDOTDIR or the home
+	// directory
+	ZDotDir string
+	// OhMyZsh is $ZSH or ~/.oh-my-zsh
+	OhMyZsh string
+	// Zinit lists where zinit may be installed, newest layout first
+	Zinit []string
+	// Config is $XDG_CONFIG_HOME or ~/.config
+	Config string
+	// Data is $XDG_DATA_HOME or ~/.local/share
+	Data string
+}
+
+// DetectShellSetup records the shell frameworks, plugin managers and prompt
+// tools found under ShellSetup: oh-my-zsh, prezto, zinit, fisher and
+// oh-my-fish from their install directories, with the plugins and theme
+// their config files list, and starship, zoxide and fzf with their version.
+// Nothing is sourced or run but the tools' --version.
+func DetectShellSetup(ctx context.Context, envData *types.EnvironmentData) {
+	home, _ := os.UserHomeDir()
+	detectShellSetup(ctx, envData, runner.Default, runner.DefaultPath, newShellDirs(home, os.Getenv))
+}
+
+// newShellDirs returns the shell directories of home, moved by the
+// variables getenv returns
+func newShellDirs(home string, getenv func(string) string) shellDirs {
+	or := func(name, fallback string) string {
+		if value := getenv(name); value != "" {
+			return value
+		}
+		return fallback
+	}
+	dirs := shellDirs{
+		ZDotDir: or("ZDOTDIR", home),
+		OhMyZsh: or("ZSH", filepath.Join(home, ".oh-my-zsh")),
+		Config:  or("XDG_CONFIG_HOME", filepath.Join(home, ".config")),
+		Data:    or("XDG_DATA_HOME", filepath.Join(home, ".local", "share")),
+	}
+	if zinitHome := getenv("ZINIT_HOME"); zinitHome != "" {
+		dirs.Zinit = append(dirs.Zinit, zinitHome)
+	}
+	dirs.Zinit = append(dirs.Zinit, filepath.Join(dirs.Data, "zinit", "zinit.git"), filepath.Join(home, ".zinit", "bin"))
+	return dirs
+}
+
+func detectShellSetup(ctx context.Context, envData *types.EnvironmentData, r runner.Runner, path runner.PathIndex, dirs shellDirs) {
+	setup := make(map[string]string)
+	zshrc := readShellFile(filepath.Join(dirs.ZDotDir, ".zshrc"))
+
+	if fileExists(filepath.Join(dirs.OhMyZsh, "oh-my-zsh.sh")) {
+		setup["oh-my-zsh"] = "Installed"
+		plugins, theme := parseOhMyZshConfig(zshrc)
+		recordShellList(setup, "oh-my-zsh:plugins", plugins)
+		if theme != "" {
+			setup["oh-my-zsh:theme"] = theme
+		}
+	}
+
+	if fileExists(filepath.Join(dirs.ZDotDir, ".zprezto", "init.zsh")) {
+		setup["prezto"] = "Installed"
+		modules, theme := parsePreztoConfig(readShellFile(filepath.Join(dirs.ZDotDir, ".zpreztorc")))
+		recordShellList(setup, "prezto:modules", modules)
+		if theme != "" {
+			setup["prezto:theme"] = theme
+		}
+	}
+
+	for _, dir := range dirs.Zinit {
+		if fileExists(filepath.Join(dir, "zinit.zsh")) {
+			setup["zinit"] = "Installed"
+			recordShellList(setup, "zinit:plugins", parseZinitPlugins(zshrc))
+			break
+		}
+	}
+
+	fish := filepath.Join(dirs.Config, "fish")
+	if fileExists(filepath.Join(fish, "functions", "fisher.fish")) {
+		setup["fisher"] = "Installed"
+		recordShellList(setup, "fisher:plugins", parseBareVersions(readShellFile(filepath.Join(fish, "fish_plugins"))))
+	}
+
+	if dirExists(filepath.Join(dirs.Data, "omf")) {
+		setup["oh-my-fish"] = "Installed"
+		packages, theme := parseOmfBundle(readShellFile(filepath.Join(dirs.Config, "omf", "bundle")))
+		recordShellList(setup, "oh-my-fish:packages", packages)
+		if theme != "" {
+			setup["oh-my-fish:theme"] = theme
+		}
+	}
+
+	for _, name := range shellTools {
+		if _, err := path.LookPath(name); err != nil || ctx.Err() != nil {
+			continue
+		}
+		setup[name] = "Installed"
+		stdout, stderr, err := r.Output(ctx, name, "--version")
+		if err != nil {
+			message := firstLine(stderr, stdout)
+			if message == "" {
+				message = err.Error()
+			}
+			envData.Warnings = append(envData.Warnings, fmt.Sprintf("could not get the version of %s: %s", name, message))
+			continue
+		}
+		if v := parseVersion(stdout, shellToolVersion); v != "" {
+			setup[name] = v
+		}
+	}
+
+	if len(setup) == 0 {
+		return
+	}
+	log.Printf("Found %d shell setup entries", len(setup))
+	envData.ShellSetup = setup
+}
+
+// recordShellList records a list of plugins or modules under key, space
+// separated the way the shell config lists them, when there are any
+func recordShellList(setup map[string]string, key string, names []string) {
+	if len(names) > 0 {
+		setup[key] = strings.Join(names, " ")
+	}
+}
+
+// readShellFile returns the contents of a shell config file, or "" when it
+// can't be read
+func readShellFile(file string) string {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return ""
+	}
+	return string(content)
+}
+
+func fileExists(file string) bool {
+	info, err := os.Stat(file)
+	return err == nil && !info.IsDir()
+}
+
+func dirExists(dir string) bool {
+	info, err := os.Stat(dir)
+	return err == nil && info.IsDir()
+}
+
+// shellLines returns the lines of a shell config file with comments
+// removed and lines ending in a backslash joined to the next
+func shellLines(content string) []string {
+	var lines []string
+	pending := ""
+	sc := bufio.NewScanner(strings.NewReader(content))
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if strings.HasPrefix(line, "#") {
+			line = ""
+		} else if i := strings.Index(line, " #"); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+		if strings.HasSuffix(line, `\`) {
+			pending += strings.TrimSuffix(line, `\`) + " "
+			continue
+		}
+		lines = append(lines, pending+line)
+		pending = ""
+	}
+	if pending != "" {
+		lines = append(lines, pending)
+	}
+	return lines
+}
+
+// unquote strips the quotes around a shell word
+func unquote(word string) string {
+	if len(word) >= 2 && (word[0] == '"' || word[0] == '\'') && word[len(word)-1] == word[0] {
+		return word[1 : len(word)-1]
+	}
+	return word
+}
+
+// parseOhMyZshConfig returns the plugins and theme .zshrc sets for
+// oh-my-zsh, from plugins=(git docker) and ZSH_THEME="robbyrussell". The
+// plugin list may span lines; the last assignment wins, as in zsh.
+func parseOhMyZshConfig(zshrc string) ([]string, string) {
+	var plugins []string
+	theme := ""
+	open := false
+	for _, line := range shellLines(zshrc) {
+		if open {
+			before, _, closed := strings.Cut(line, ")")
+			plugins = append(plugins, strings.Fields(before)...)
+			open = !closed
+			continue
+		}
+		if value, ok := strings.CutPrefix(line, "ZSH_THEME="); ok {
+			theme = unquote(strings.TrimSpace(value))
+			continue
+		}
+		if value, ok := strings.CutPrefix(line, "plugins=("); ok {
+			before, _, closed := strings.Cut(value, ")")
+			plugins = strings.Fields(before)
+			open = !closed
+		}
+	}
+	return plugins, theme
+}
+
+// parsePreztoConfig returns the modules and prompt theme .zpreztorc loads,
+// from "zstyle ':prezto:load' pmodule 'environment' 'git'" and
+// "zstyle ':prezto:module:prompt' theme 'sorin'"
+func parsePreztoConfig(zpreztorc string) ([]string, string) {
+	var modules []string
+	theme := ""
+	for _, line := range shellLines(zpreztorc) {
+		fields := strings.Fields(line)
+		if len(fields) < 4 || fields[0] != "zstyle" {
+			continue
+		}
+		switch zcontext, style := unquote(fields[1]), fields[2]; {
+		case zcontext == ":prezto:load" && style == "pmodule":
+			modules = nil
+			for _, field := range fields[3:] {
+				modules = append(modules, unquote(field))
+			}
+		case zcontext == ":prezto:module:prompt" && style == "theme":
+			theme = unquote(fields[3])
+		}
+	}
+	return modules, theme
+}
+
+// parseZinitPlugins returns the plugins .zshrc loads with 'zinit light' or
+// 'zinit load', such as "zsh-users/zsh-autosuggestions". Ice modifiers on
+// the same line, as in 'zinit ice wait; zinit light ...', are skipped.
+func parseZinitPlugins(zshrc string) []string {
+	var plugins []string
+	for _, line := range shellLines(zshrc) {
+		for _, command := range strings.Split(line, ";") {
+			fields := strings.Fields(command)
+			if len(fields) < 3 || fields[0] != "zinit" || (fields[1] != "light" && fields[1] != "load") {
+				continue
+			}
+			plugins = append(plugins, unquote(fields[len(fields)-1]))
+		}
+	}
+	return plugins
+}
+
+// parseOmfBundle returns the packages and theme listed in oh-my-fish's
+// bundle file, one "package name" or "theme name" per line
+func parseOmfBundle(bundle string) ([]string, string) {
+	var packages []string
+	theme := ""
+	for _, line := range shellLines(bundle) {
+		kind, name, ok := strings.Cut(line, " ")
+		if !ok {
+			continue
+		}
+		switch name = strings.TrimSpace(name); kind {
+		case "package":
+			packages = append(packages, name)
+		case "theme":
+			theme = name
+		}
+	}
+	return packages, theme
+}
@@ -0,0 +1,150 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/runner/runnertest"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+func TestDetectShellSetup(t *testing.T) {
+	home := t.TempDir()
+	files := map[string]string{
+		".oh-my-zsh/oh-my-zsh.sh": "",
+		".zshrc": "export ZSH=\"$HOME/.oh-my-zsh\"\n" +
+			"ZSH_THEME=\"agnoster\"\n" +
+			"# plugins=(git)\n" +
+			"plugins=(\n  git\n  docker # containers\n  fzf\n)\n" +
+			"source $ZSH/oh-my-zsh.sh\n" +
+			"zinit ice wait lucid; zinit light zsh-users/zsh-autosuggestions\n" +
+			"zinit load zdharma-continuum/history-search-multi-word\n",
+		".local/share/zinit/zinit.git/zinit.zsh": "",
+		".config/fish/functions/fisher.fish":     "",
+		".config/fish/fish_plugins":              "jorgebucaran/fisher\nIlanCosman/tide@v6\n",
+	}
+	for name, content := range files {
+		file := filepath.Join(home, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r := &runnertest.Runner{Responses: map[string]runnertest.Response{
+		"starship --version": {Output: "starship 1.17.1\nbranch:\ncommit_hash:\n"},
+		"zoxide --version":   {Output: "zoxide v0.9.4\n"},
+		"fzf --version":      {Stderr: "fzf: error while loading shared libraries\n", Err: errors.New("exit status 127")},
+	}}
+	path := runnertest.NewPath([]string{"/usr/bin"}, "/usr/bin/starship", "/usr/bin/zoxide", "/usr/bin/fzf")
+	var env types.EnvironmentData
+	detectShellSetup(context.Background(), &env, r, path, newShellDirs(home, func(string) string { return "" }))
+
+	expected := map[string]string{
+		"oh-my-zsh":         "Installed",
+		"oh-my-zsh:plugins": "git docker fzf",
+		"oh-my-zsh:theme":   "agnoster",
+		"zinit":             "Installed",
+		"zinit:plugins":     "zsh-users/zsh-autosuggestions zdharma-continuum/history-search-multi-word",
+		"fisher":            "Installed",
+		"fisher:plugins":    "jorgebucaran/fisher IlanCosman/tide@v6",
+		"starship":          "1.17.1",
+		"zoxide":            "0.9.4",
+		"fzf":               "Installed",
+	}
+	if !reflect.DeepEqual(env.ShellSetup, expected) {
+		t.Errorf("expected %v but got %v", expected, env.ShellSetup)
+	}
+	if len(env.Warnings) != 1 {
+		t.Errorf("expected a warning for fzf but got %q", env.Warnings)
+	}
+}
+
+func TestDetectShellSetupNothingFound(t *testing.T) {
+	var env types.EnvironmentData
+	detectShellSetup(context.Background(), &env, &runnertest.Runner{}, runnertest.NewPath(nil), newShellDirs(t.TempDir(), func(string) string { return "" }))
+	if env.ShellSetup != nil {
+		t.Errorf("expected no shell setup but got %v", env.ShellSetup)
+	}
+}
+
+func TestNewShellDirs(t *testing.T) {
+	vars := map[string]string{
+		"ZDOTDIR":       "/home/dev/.config/zsh",
+		"XDG_DATA_HOME": "/home/dev/data",
+	}
+	dirs := newShellDirs("/home/dev", func(name string) string { return vars[name] })
+	expected := shellDirs{
+		ZDotDir: "/home/dev/.config/zsh",
+		OhMyZsh: filepath.Join("/home/dev", ".oh-my-zsh"),
+		Zinit:   []string{filepath.Join("/home/dev/data", "zinit", "zinit.git"), filepath.Join("/home/dev", ".zinit", "bin")},
+		Config:  filepath.Join("/home/dev", ".config"),
+		Data:    "/home/dev/data",
+	}
+	if !reflect.DeepEqual(dirs, expected) {
+		t.Errorf("expected %+v but got %+v", expected, dirs)
+	}
+}
+
+func TestParseShellConfig(t *testing.T) {
+	testCases := []struct {
+		name          string
+		parse         func(string) ([]string, string)
+		content       string
+		expectedList  []string
+		expectedTheme string
+	}{
+		{
+			name:          "oh-my-zsh on one line",
+			parse:         parseOhMyZshConfig,
+			content:       "ZSH_THEME='robbyrussell'\nplugins=(git z)\n",
+			expectedList:  []string{"git", "z"},
+			expectedTheme: "robbyrussell",
+		},
+		{
+			// zsh keeps the last assignment
+			name:          "oh-my-zsh assigned twice",
+			parse:         parseOhMyZshConfig,
+			content:       "plugins=(git)\nplugins=(git kubectl)\nZSH_THEME=\n",
+			expectedList:  []string{"git", "kubectl"},
+			expectedTheme: "",
+		},
+		{
+			name:  "prezto",
+			parse: parsePreztoConfig,
+			content: "zstyle ':prezto:load' pmodule \\\n" +
+				"  'environment' \\\n" +
+				"  'git' \\\n" +
+				"  'prompt'\n" +
+				"zstyle ':prezto:module:prompt' theme 'sorin'\n" +
+				"# zstyle ':prezto:module:prompt' theme 'pure'\n",
+			expectedList:  []string{"environment", "git", "prompt"},
+			expectedTheme: "sorin",
+		},
+		{
+			name:          "oh-my-fish bundle",
+			parse:         parseOmfBundle,
+			content:       "package bass\npackage z\ntheme bobthefish\n",
+			expectedList:  []string{"bass", "z"},
+			expectedTheme: "bobthefish",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			list, theme := tc.parse(tc.content)
+			if !reflect.DeepEqual(list, tc.expectedList) {
+				t.Errorf("expected %q but got %q", tc.expectedList, list)
+			}
+			if theme != tc.expectedTheme {
+				t.Errorf("expected theme %q but got %q", tc.expectedTheme, theme)
+			}
+		})
+	}
+}
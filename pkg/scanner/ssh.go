@@ -0,0 +1,122 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/runner"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+// sshTimeout bounds each ssh-keygen and ssh-add command
+const sshTimeout = 5 * time.Second
+
+// DetectSSHKeys records the SSH public keys of ~/.ssh by the type, size and
+// fingerprint 'ssh-keygen -lf' reports, and whether an ssh-agent answers
+// 'ssh-add -l'. Only the .pub files are handed to ssh-keygen; private keys
+// are never opened, only looked for by name, and no key material or
+// comment is recorded.
+func DetectSSHKeys(ctx context.Context, envData *types.EnvironmentData) {
+	home, _ := os.UserHomeDir()
+	detectSSHKeys(ctx, envData, runner.Default, runner.DefaultPath, filepath.Join(home, ".ssh"))
+}
+
+func detectSSHKeys(ctx context.Context, envData *types.EnvironmentData, r runner.Runner, path runner.PathIndex, dir string) {
+	if _, err := path.LookPath("ssh-keygen"); err == nil {
+		envData.SSHKeys = sshPublicKeys(ctx, envData, r, dir)
+		if len(envData.SSHKeys) > 0 {
+			log.Printf("Found %d SSH keys", len(envData.SSHKeys))
+		}
+	}
+
+	if _, err := path.LookPath("ssh-add"); err == nil {
+		ctx, cancel := context.WithTimeout(ctx, sshTimeout)
+		defer cancel()
+		// ssh-add -l exits with 1 when the agent holds no keys and with 2
+		// when there is no agent to ask
+		output, err := r.CombinedOutput(ctx, "ssh-add", "-l")
+		if err == nil || strings.Contains(output, "has no identities") {
+			log.Printf("Found a running ssh-agent")
+			envData.SSHAgent = true
+		}
+	}
+}
+
+// sshPublicKeys describes the .pub files of dir, in name order
+func sshPublicKeys(ctx context.Context, envData *types.EnvironmentData, r runner.Runner, dir string) []types.SSHKeyInfo {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			envData.Warnings = append(envData.Warnings, fmt.Sprintf("could not list %s: %v", dir, err))
+		}
+		return nil
+	}
+
+	var keys []types.SSHKeyInfo
+	for _, entry := range entries {
+		if ctx.Err() != nil {
+			return keys
+		}
+		name := entry.Name()
+		file := filepath.Join(dir, name)
+		if !strings.HasSuffix(name, ".pub") || !fileExists(file) {
+			continue
+		}
+		output, err := sshCommand(ctx, r, "ssh-keygen", "-lf", file)
+		if err != nil {
+			log.Printf("Warning: could not fingerprint %s: %v", name, err)
+			continue
+		}
+		key, ok := ParseSSHKeygenFingerprint(output)
+		if !ok {
+			log.Printf("Warning: could not parse the fingerprint of %s", name)
+			continue
+		}
+		key.File = name
+		// Lstat, so the private key is neither opened nor followed
+		_, err = os.Lstat(strings.TrimSuffix(file, ".pub"))
+		key.PrivateKey = err == nil
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// ParseSSHKeygenFingerprint parses the first line printed by 'ssh-keygen
+// -lf', "<bits> <fingerprint> <comment> (<type>)", leaving out the comment
+func ParseSSHKeygenFingerprint(output string) (types.SSHKeyInfo, bool) {
+	fields := strings.Fields(firstLine(output))
+	if len(fields) < 3 {
+		return types.SSHKeyInfo{}, false
+	}
+	bits, err := strconv.Atoi(fields[0])
+	keyType := fields[len(fields)-1]
+	if err != nil || !strings.Contains(fields[1], ":") || !strings.HasPrefix(keyType, "(") || !strings.HasSuffix(keyType, ")") {
+		return types.SSHKeyInfo{}, false
+	}
+	return types.SSHKeyInfo{Type: strings.Trim(keyType, "()"), Bits: bits, Fingerprint: fields[1]}, true
+}
+
+// sshCommand runs an ssh command with sshTimeout and returns its stdout,
+// or an error saying why it failed
+func sshCommand(ctx context.Context, r runner.Runner, name string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, sshTimeout)
+	defer cancel()
+	stdout, stderr, err := r.Output(ctx, name, args...)
+	switch {
+	case err == nil:
+		return stdout, nil
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return "", fmt.Errorf("%s did not answer within %s", name, sshTimeout)
+	}
+	if message := firstLine(stderr, stdout); message != "" {
+		return "", fmt.Errorf("%s failed: %s", name, message)
+	}
+	return "", fmt.Errorf("%s failed: %w", name, err)
+}
@@ -0,0 +1,141 @@
+package scanner
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/runner/runnertest"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+// sshFixture is a ~/.ssh with an ED25519 key pair, an RSA public key
+// without its private key, a config and a known_hosts
+var sshFixture = filepath.Join("testdata", "ssh")
+
+func sshKeygenResponses() map[string]runnertest.Response {
+	return map[string]runnertest.Response{
+		"ssh-keygen -lf " + filepath.Join(sshFixture, "id_ed25519.pub"): {Output: "256 SHA256:pWb3RNOt2yKlYTLbUi6Ee8CrGm4c8Yy1mjPq0ZLbk2s jane@laptop (ED25519)\n"},
+		"ssh-keygen -lf " + filepath.Join(sshFixture, "id_rsa.pub"):     {Output: "3072 SHA256:Y0rDQ8ZkqPNh9mM5bFJ6pU1w2s7Xn8eTcVaLgHoR4iE ci@build (RSA)\n"},
+	}
+}
+
+func TestParseSSHKeygenFingerprint(t *testing.T) {
+	testCases := []struct {
+		output   string
+		expected types.SSHKeyInfo
+		ok       bool
+	}{
+		{
+			output:   "256 SHA256:pWb3RNOt2yKlYTLbUi6Ee8CrGm4c8Yy1mjPq0ZLbk2s jane@laptop (ED25519)\n",
+			expected: types.SSHKeyInfo{Type: "ED25519", Bits: 256, Fingerprint: "SHA256:pWb3RNOt2yKlYTLbUi6Ee8CrGm4c8Yy1mjPq0ZLbk2s"},
+			ok:       true,
+		},
+		{
+			output:   "256 SHA256:4aXh1cQwJm0n7bKpZr2sT9vYd3eLfGiU8oN5xC6A1Bw no comment (ECDSA-SK)\n",
+			expected: types.SSHKeyInfo{Type: "ECDSA-SK", Bits: 256, Fingerprint: "SHA256:4aXh1cQwJm0n7bKpZr2sT9vYd3eLfGiU8oN5xC6A1Bw"},
+			ok:       true,
+		},
+		{output: "testdata/ssh/config is not a public key file.\n"},
+		{output: ""},
+	}
+
+	for _, tc := range testCases {
+		key, ok := ParseSSHKeygenFingerprint(tc.output)
+		if ok != tc.ok || key != tc.expected {
+			t.Errorf("expected %+v, %v for %q but got %+v, %v", tc.expected, tc.ok, tc.output, key, ok)
+		}
+	}
+}
+
+func TestDetectSSHKeys(t *testing.T) {
+	noAgent := runnertest.Response{Output: "Could not open a connection to your authentication agent.\n", Err: errors.New("exit status 2")}
+	emptyAgent := runnertest.Response{Output: "The agent has no identities.\n", Err: errors.New("exit status 1")}
+
+	testCases := []struct {
+		name     string
+		path     []string
+		agent    *runnertest.Response
+		expected []types.SSHKeyInfo
+		running  bool
+	}{
+		{
+			name: "Keys and an agent with none loaded",
+			path: []string{"/usr/bin/ssh-keygen", "/usr/bin/ssh-add"},
+			expected: []types.SSHKeyInfo{
+				{File: "id_ed25519.pub", Type: "ED25519", Bits: 256, Fingerprint: "SHA256:pWb3RNOt2yKlYTLbUi6Ee8CrGm4c8Yy1mjPq0ZLbk2s", PrivateKey: true},
+				{File: "id_rsa.pub", Type: "RSA", Bits: 3072, Fingerprint: "SHA256:Y0rDQ8ZkqPNh9mM5bFJ6pU1w2s7Xn8eTcVaLgHoR4iE"},
+			},
+			agent:   &emptyAgent,
+			running: true,
+		},
+		{
+			name:  "No agent",
+			path:  []string{"/usr/bin/ssh-add"},
+			agent: &noAgent,
+		},
+		{
+			name: "No OpenSSH",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			responses := sshKeygenResponses()
+			if tc.agent != nil {
+				responses["ssh-add -l"] = *tc.agent
+			}
+			env := &types.EnvironmentData{}
+			detectSSHKeys(context.Background(), env, &runnertest.Runner{Responses: responses}, runnertest.NewPath([]string{"/usr/bin"}, tc.path...), sshFixture)
+			if !reflect.DeepEqual(env.SSHKeys, tc.expected) {
+				t.Errorf("expected %+v but got %+v", tc.expected, env.SSHKeys)
+			}
+			if env.SSHAgent != tc.running {
+				t.Errorf("expected ssh-agent running to be %v but got %v", tc.running, env.SSHAgent)
+			}
+			if len(env.Warnings) != 0 {
+				t.Errorf("expected no warnings but got %q", env.Warnings)
+			}
+		})
+	}
+
+	env := &types.EnvironmentData{}
+	detectSSHKeys(context.Background(), env, &runnertest.Runner{}, runnertest.NewPath([]string{"/usr/bin"}, "/usr/bin/ssh-keygen"), filepath.Join(t.TempDir(), "missing"))
+	if env.SSHKeys != nil || len(env.Warnings) != 0 {
+		t.Errorf("expected a missing ~/.ssh to be passed over but got %+v, %q", env.SSHKeys, env.Warnings)
+	}
+}
+
+// TestDetectSSHKeysLeavesOutKeyMaterial makes sure that only fingerprints
+// get into the scan: no key bodies, comments, private keys, config or
+// known hosts, and that the private key is never handed to a command
+func TestDetectSSHKeysLeavesOutKeyMaterial(t *testing.T) {
+	r := &runnertest.Runner{Responses: sshKeygenResponses()}
+	env := &types.EnvironmentData{}
+	detectSSHKeys(context.Background(), env, r, runnertest.NewPath([]string{"/usr/bin"}, "/usr/bin/ssh-keygen"), sshFixture)
+	if len(env.SSHKeys) != 2 {
+		t.Fatalf("expected both public keys but got %+v", env.SSHKeys)
+	}
+	data, err := json.Marshal(env)
+	if err != nil {
+		t.Fatalf("failed to marshal: %v", err)
+	}
+	for _, secret := range []string{
+		"AAAAC3NzaC1lZDI1NTE5", "AAAAB3NzaC1yc2E", "FakeEd25519KeyBody", "FakeRsaKeyBody",
+		"PRIVATE KEY", "b3BlbnNzaC1rZXktdjE", "FakePrivateKeyBody", "FakeKnownHostBody",
+		"jane@laptop", "ci@build", "github.com", "IdentityFile",
+	} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("expected %q to be left out but got %s", secret, data)
+		}
+	}
+	for _, call := range r.Calls() {
+		if !strings.HasSuffix(call, ".pub") {
+			t.Errorf("expected only public keys to be handed to ssh-keygen but ran %q", call)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package scanner
+
+import (
+	"log"
+	"os"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+// terminalEmulator is a terminal emulator told apart by the environment
+// variables it sets in the shells it runs
+type terminalEmulator struct {
+	Name string
+	ID   string
+	// Variable is set, to any value, in the emulator's shells
+	Variable string
+	// TermProgram is the value of $TERM_PROGRAM the emulator sets, which
+	// comes with its version in $TERM_PROGRAM_VERSION
+	TermProgram string
+}
+
+// terminalEmulators are tried in order. $TERM_PROGRAM comes first as it
+// names the innermost emulator; tmux sets it too, so inside tmux an
+// emulator is only found through the variables its sessions inherit.
+var terminalEmulators = []terminalEmulator{
+	{Name: "iTerm2", ID: "iterm2", TermProgram: "iTerm.app"},
+	{Name: "Terminal.app", ID: "apple-terminal", TermProgram: "Apple_Terminal"},
+	{Name: "WezTerm", ID: "wezterm", TermProgram: "WezTerm"},
+	{Name: "Ghostty", ID: "ghostty", TermProgram: "ghostty"},
+	{Name: "Warp", ID: "warp", TermProgram: "WarpTerminal"},
+	{Name: "Hyper", ID: "hyper", TermProgram: "Hyper"},
+	{Name: "Tabby", ID: "tabby", TermProgram: "Tabby"},
+	{Name: "Windows Terminal", ID: "windows-terminal", Variable: "WT_SESSION"},
+	{Name: "kitty", ID: "kitty", Variable: "KITTY_WINDOW_ID"},
+	{Name: "Alacritty", ID: "alacritty", Variable: "ALACRITTY_WINDOW_ID"},
+}
+
+// DetectTerminalEmulator records the terminal emulator the scan runs in
+// under Tools, as best it can be told from the environment: with the
+// version in $TERM_PROGRAM_VERSION when the emulator sets it, and as
+// "Installed" otherwise. Scans run outside a terminal, or in one not listed
+// in terminalEmulators, record none.
+func DetectTerminalEmulator(envData *types.EnvironmentData) {
+	detectTerminalEmulator(envData, os.Getenv)
+}
+
+func detectTerminalEmulator(envData *types.EnvironmentData, getenv func(string) string) {
+	emulator, ok := findTerminalEmulator(getenv)
+	if !ok {
+		return
+	}
+	version := "Installed"
+	if emulator.TermProgram != "" && getenv("TERM_PROGRAM_VERSION") != "" {
+		version = getenv("TERM_PROGRAM_VERSION")
+	}
+	log.Printf("Found terminal emulator %s version %s", emulator.Name, version)
+	if envData.Tools == nil {
+		envData.Tools = make(map[string]string)
+	}
+	envData.Tools[emulator.Name] = version
+	if envData.ToolIDs == nil {
+		envData.ToolIDs = make(map[string]string)
+	}
+	envData.ToolIDs[emulator.Name] = emulator.ID
+}
+
+// findTerminalEmulator returns the first of terminalEmulators the
+// environment names
+func findTerminalEmulator(getenv func(string) string) (terminalEmulator, bool) {
+	termProgram := getenv("TERM_PROGRAM")
+	for _, emulator := range terminalEmulators {
+		if emulator.TermProgram != "" && emulator.TermProgram == termProgram {
+			return emulator, true
+		}
+	}
+	for _, emulator := range terminalEmulators {
+		if emulator.Variable != "" && getenv(emulator.Variable) != "" {
+			return emulator, true
+		}
+	}
+	return terminalEmulator{}, false
+}
@@ -0,0 +1,59 @@
+package scanner
+
+import (
+	"testing"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+func TestDetectTerminalEmulator(t *testing.T) {
+	testCases := []struct {
+		name            string
+		vars            map[string]string
+		expectedName    string
+		expectedVersion string
+	}{
+		{
+			name:            "iTerm2",
+			vars:            map[string]string{"TERM_PROGRAM": "iTerm.app", "TERM_PROGRAM_VERSION": "3.4.23"},
+			expectedName:    "iTerm2",
+			expectedVersion: "3.4.23",
+		},
+		{
+			name:            "Windows Terminal",
+			vars:            map[string]string{"WT_SESSION": "3f6b8c2e-0d1c-4b5e-9a7f-2c8d1e4f6a9b"},
+			expectedName:    "Windows Terminal",
+			expectedVersion: "Installed",
+		},
+		{
+			// tmux replaces TERM_PROGRAM but keeps the variables kitty set
+			name:            "kitty running tmux",
+			vars:            map[string]string{"TERM_PROGRAM": "tmux", "TERM_PROGRAM_VERSION": "3.4", "KITTY_WINDOW_ID": "1"},
+			expectedName:    "kitty",
+			expectedVersion: "Installed",
+		},
+		{
+			name: "VS Code's terminal",
+			vars: map[string]string{"TERM_PROGRAM": "vscode", "TERM_PROGRAM_VERSION": "1.85.1"},
+		},
+		{
+			name: "no terminal",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var env types.EnvironmentData
+			detectTerminalEmulator(&env, func(name string) string { return tc.vars[name] })
+			if tc.expectedName == "" {
+				if len(env.Tools) != 0 {
+					t.Errorf("expected no terminal emulator but got %v", env.Tools)
+				}
+				return
+			}
+			if len(env.Tools) != 1 || env.Tools[tc.expectedName] != tc.expectedVersion {
+				t.Errorf("expected %s %s but got %v", tc.expectedName, tc.expectedVersion, env.Tools)
+			}
+		})
+	}
+}
@@ -0,0 +1,16 @@
+/*
+ * Copyright 2014-2023 NVIDIA Corporation.  All rights reserved.
+ */
+
+/* cudnn_version : cuDNN's version number */
+
+#ifndef CUDNN_VERSION_H_
+#define CUDNN_VERSION_H_
+
+#define CUDNN_MAJOR 8
+#define CUDNN_MINOR 9
+#define CUDNN_PATCHLEVEL 7
+
+#define CUDNN_VERSION (CUDNN_MAJOR * 1000 + CUDNN_MINOR * 100 + CUDNN_PATCHLEVEL)
+
+#endif /* CUDNN_VERSION_H */
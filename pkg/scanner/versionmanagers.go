@@ -0,0 +1,186 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/runner"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+	"github.com/MRQ67/stackmatch-cli/pkg/version"
+)
+
+// versionManagerCommand is a version manager that lists its installed
+// versions when run
+type versionManagerCommand struct {
+	Name string
+	Args []string
+	// parse turns the command's output into versions
+	parse func(output string) []string
+}
+
+// versionManagerCommands are the version managers run to list their versions
+var versionManagerCommands = []versionManagerCommand{
+	{Name: "pyenv", Args: []string{"versions", "--bare"}, parse: parsePyenvVersions},
+	{Name: "rbenv", Args: []string{"versions", "--bare"}, parse: parseBareVersions},
+	{Name: "asdf", Args: []string{"list"}, parse: parseAsdfVersions},
+}
+
+// DetectVersionManagers records the language version managers (nvm, pyenv,
+// rbenv, asdf and sdkman) and the versions each has installed. nvm and
+// sdkman are shell functions that only exist once shell init files have run,
+// so their install directories ($NVM_DIR or ~/.nvm, $SDKMAN_DIR or
+// ~/.sdkman) are listed instead of running them.
+func DetectVersionManagers(ctx context.Context, envData *types.EnvironmentData) {
+	home, _ := os.UserHomeDir()
+	nvmDir := os.Getenv("NVM_DIR")
+	if nvmDir == "" {
+		nvmDir = filepath.Join(home, ".nvm")
+	}
+	sdkmanDir := os.Getenv("SDKMAN_DIR")
+	if sdkmanDir == "" {
+		sdkmanDir = filepath.Join(home, ".sdkman")
+	}
+	detectVersionManagers(ctx, envData, runner.Default, runner.DefaultPath, nvmDir, sdkmanDir)
+}
+
+func detectVersionManagers(ctx context.Context, envData *types.EnvironmentData, r runner.Runner, path runner.PathIndex, nvmDir, sdkmanDir string) {
+	managers := make(map[string][]string)
+	record := func(name string, versions []string) {
+		log.Printf("Found %s with %d installed versions", name, len(versions))
+		if versions == nil {
+			versions = []string{}
+		}
+		managers[name] = versions
+	}
+
+	for _, manager := range versionManagerCommands {
+		if _, err := path.LookPath(manager.Name); err != nil || ctx.Err() != nil {
+			continue
+		}
+		stdout, stderr, err := r.Output(ctx, manager.Name, manager.Args...)
+		if err != nil {
+			message := firstLine(stderr, stdout)
+			if message == "" {
+				message = err.Error()
+			}
+			envData.Warnings = append(envData.Warnings, fmt.Sprintf("could not list the versions installed by %s: %s", manager.Name, message))
+			continue
+		}
+		record(manager.Name, manager.parse(stdout))
+	}
+
+	// nvm installs node versions as versions/node/v20.11.0
+	if names, ok := listDirs(filepath.Join(nvmDir, "versions", "node")); ok {
+		var versions []string
+		for _, name := range names {
+			versions = append(versions, strings.TrimPrefix(name, "v"))
+		}
+		record("nvm", sortVersions(versions))
+	}
+
+	// sdkman installs candidates as candidates/java/21.0.1-tem, next to a
+	// "current" link to the default one
+	if candidates, ok := listDirs(filepath.Join(sdkmanDir, "candidates")); ok {
+		var versions []string
+		for _, candidate := range candidates {
+			installed, _ := listDirs(filepath.Join(sdkmanDir, "candidates", candidate))
+			var own []string
+			for _, name := range installed {
+				if name != "current" {
+					own = append(own, name)
+				}
+			}
+			for _, v := range sortVersions(own) {
+				versions = append(versions, candidate+"@"+v)
+			}
+		}
+		record("sdkman", versions)
+	}
+
+	if len(managers) > 0 {
+		envData.VersionManagers = managers
+	}
+}
+
+// parseBareVersions returns the non-empty lines of output
+func parseBareVersions(output string) []string {
+	var versions []string
+	for _, line := range strings.Split(output, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			versions = append(versions, line)
+		}
+	}
+	return versions
+}
+
+// parsePyenvVersions returns the versions listed by 'pyenv versions --bare',
+// leaving out the virtualenvs pyenv-virtualenv lists as 3.12.1/envs/name
+func parsePyenvVersions(output string) []string {
+	var versions []string
+	for _, v := range parseBareVersions(output) {
+		if !strings.Contains(v, "/") {
+			versions = append(versions, v)
+		}
+	}
+	return versions
+}
+
+// parseAsdfVersions returns the versions listed by 'asdf list' as
+// plugin@version. Plugins are listed unindented with their versions indented
+// below, the current one marked with a *.
+func parseAsdfVersions(output string) []string {
+	var versions []string
+	plugin := ""
+	for _, line := range strings.Split(output, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "":
+		case trimmed == line:
+			plugin = trimmed
+		case plugin != "":
+			v := strings.TrimSpace(strings.TrimPrefix(trimmed, "*"))
+			// Plugins without versions list "No versions installed"
+			if v != "" && !strings.Contains(v, " ") {
+				versions = append(versions, plugin+"@"+v)
+			}
+		}
+	}
+	return versions
+}
+
+// listDirs returns the names of the directories in dir, following symlinks,
+// or false when dir can't be read
+func listDirs(dir string) ([]string, bool) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, false
+	}
+	var names []string
+	for _, entry := range entries {
+		if info, err := os.Stat(filepath.Join(dir, entry.Name())); err == nil && info.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+	return names, true
+}
+
+// sortVersions sorts versions oldest first, after any that don't parse
+func sortVersions(versions []string) []string {
+	sort.SliceStable(versions, func(i, j int) bool {
+		a, errA := version.Parse(versions[i])
+		b, errB := version.Parse(versions[j])
+		if errA != nil || errB != nil {
+			if (errA == nil) != (errB == nil) {
+				return errA != nil
+			}
+			return versions[i] < versions[j]
+		}
+		return a.Compare(b) < 0
+	})
+	return versions
+}
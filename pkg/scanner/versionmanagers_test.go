@@ -0,0 +1,116 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/runner/runnertest"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+func TestParseVersionManagerOutput(t *testing.T) {
+	testCases := []struct {
+		name     string
+		parse    func(string) []string
+		output   string
+		expected []string
+	}{
+		{
+			name:     "pyenv",
+			parse:    parsePyenvVersions,
+			output:   "3.11.7\n3.12.1\n3.12.1/envs/tools\n",
+			expected: []string{"3.11.7", "3.12.1"},
+		},
+		{
+			name:     "rbenv",
+			parse:    parseBareVersions,
+			output:   "3.2.2\n3.3.0\n\n",
+			expected: []string{"3.2.2", "3.3.0"},
+		},
+		{
+			name:     "asdf",
+			parse:    parseAsdfVersions,
+			output:   "golang\n  No versions installed\nnodejs\n  18.19.0\n *20.11.0\npython\n  3.12.1\n",
+			expected: []string{"nodejs@18.19.0", "nodejs@20.11.0", "python@3.12.1"},
+		},
+		{
+			name:     "asdf without plugins",
+			parse:    parseAsdfVersions,
+			output:   "",
+			expected: nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := tc.parse(tc.output)
+			if !reflect.DeepEqual(actual, tc.expected) {
+				t.Errorf("expected versions %q but got %q", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestDetectVersionManagers(t *testing.T) {
+	home := t.TempDir()
+	nvmDir := filepath.Join(home, ".nvm")
+	sdkmanDir := filepath.Join(home, ".sdkman")
+	for _, dir := range []string{
+		filepath.Join(nvmDir, "versions", "node", "v20.11.0"),
+		filepath.Join(nvmDir, "versions", "node", "v9.11.2"),
+		filepath.Join(sdkmanDir, "candidates", "java", "21.0.1-tem"),
+		filepath.Join(sdkmanDir, "candidates", "java", "17.0.9-tem"),
+		filepath.Join(sdkmanDir, "candidates", "java", "current"),
+		filepath.Join(sdkmanDir, "candidates", "gradle"),
+	} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r := &runnertest.Runner{Responses: map[string]runnertest.Response{
+		"pyenv versions --bare": {Output: "3.12.1\n3.12.1/envs/tools\n"},
+		"rbenv versions --bare": {Output: ""},
+		"asdf list": {
+			Stderr: "asdf: plugin directory is not readable\n",
+			Err:    errors.New("exit status 1"),
+		},
+	}}
+	path := runnertest.NewPath([]string{"/usr/bin"}, "/usr/bin/pyenv", "/usr/bin/rbenv", "/usr/bin/asdf")
+	env := &types.EnvironmentData{}
+
+	detectVersionManagers(context.Background(), env, r, path, nvmDir, sdkmanDir)
+
+	expected := map[string][]string{
+		"pyenv":  {"3.12.1"},
+		"rbenv":  {},
+		"nvm":    {"9.11.2", "20.11.0"},
+		"sdkman": {"java@17.0.9-tem", "java@21.0.1-tem"},
+	}
+	if !reflect.DeepEqual(env.VersionManagers, expected) {
+		t.Errorf("expected version managers %v but got %v", expected, env.VersionManagers)
+	}
+	warning := "could not list the versions installed by asdf: asdf: plugin directory is not readable"
+	if !reflect.DeepEqual(env.Warnings, []string{warning}) {
+		t.Errorf("expected warning %q but got %q", warning, env.Warnings)
+	}
+}
+
+func TestDetectVersionManagersWithoutManagers(t *testing.T) {
+	r := &runnertest.Runner{}
+	env := &types.EnvironmentData{}
+	home := t.TempDir()
+
+	detectVersionManagers(context.Background(), env, r, runnertest.NewPath(nil), filepath.Join(home, ".nvm"), filepath.Join(home, ".sdkman"))
+
+	if calls := r.Calls(); len(calls) != 0 {
+		t.Errorf("expected no commands without version managers but got %v", calls)
+	}
+	if env.VersionManagers != nil {
+		t.Errorf("expected no version managers but got %v", env.VersionManagers)
+	}
+}
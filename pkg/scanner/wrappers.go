@@ -0,0 +1,137 @@
+package scanner
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+// buildWrapper describes a build tool wrapper checked into a project
+type buildWrapper struct {
+	// Tool is the display name used for the tool in EnvironmentData.Tools
+	Tool string
+	// Path is the wrapper properties file relative to the project root
+	Path string
+	// DistributionRegex extracts the version from the distributionUrl
+	DistributionRegex *regexp.Regexp
+}
+
+var buildWrappers = []buildWrapper{
+	{
+		Tool:              "Gradle",
+		Path:              filepath.Join("gradle", "wrapper", "gradle-wrapper.properties"),
+		DistributionRegex: regexp.MustCompile(`gradle-([0-9][^/]*?)-(?:bin|all)\.zip$`),
+	},
+	{
+		Tool:              "Maven",
+		Path:              filepath.Join(".mvn", "wrapper", "maven-wrapper.properties"),
+		DistributionRegex: regexp.MustCompile(`apache-maven-([0-9][^/]*?)-bin\.(?:zip|tar\.gz)$`),
+	},
+}
+
+// DetectBuildWrappers records the Gradle and Maven versions pinned by wrapper
+// files in projectDir. Missing wrapper directories are not an error.
+func DetectBuildWrappers(envData *types.EnvironmentData, projectDir string) {
+	if envData.Project == nil {
+		envData.Project = &types.ProjectInfo{Path: projectDir}
+	}
+
+	for _, w := range buildWrappers {
+		file, err := os.Open(filepath.Join(projectDir, w.Path))
+		if err != nil {
+			continue // Project doesn't use this wrapper
+		}
+		version, err := parseWrapperVersion(file, w.DistributionRegex)
+		file.Close()
+		if err != nil {
+			log.Printf("Warning: Could not read %s wrapper: %v", w.Tool, err)
+			continue
+		}
+
+		log.Printf("Found %s wrapper pinned to %s", w.Tool, version)
+		if envData.Project.BuildWrappers == nil {
+			envData.Project.BuildWrappers = make(map[string]string)
+		}
+		envData.Project.BuildWrappers[w.Tool] = version
+	}
+}
+
+// parseWrapperVersion extracts the pinned distribution version from a wrapper
+// properties file
+func parseWrapperVersion(r io.Reader, distributionRegex *regexp.Regexp) (string, error) {
+	props, err := parseProperties(r)
+	if err != nil {
+		return "", err
+	}
+
+	url, ok := props["distributionUrl"]
+	if !ok || url == "" {
+		return "", fmt.Errorf("no distributionUrl found")
+	}
+
+	matches := distributionRegex.FindStringSubmatch(url)
+	if len(matches) < 2 {
+		return "", fmt.Errorf("unrecognized distributionUrl: %s", url)
+	}
+	return matches[1], nil
+}
+
+// parseProperties reads a Java .properties file into a map. It supports '=' and
+// ':' separators, '#' and '!' comments and backslash escapes such as "https\://".
+func parseProperties(r io.Reader) (map[string]string, error) {
+	props := make(map[string]string)
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "!") {
+			continue
+		}
+
+		sep := strings.IndexAny(line, "=:")
+		for sep > 0 && line[sep-1] == '\\' {
+			next := strings.IndexAny(line[sep+1:], "=:")
+			if next < 0 {
+				sep = -1
+				break
+			}
+			sep += next + 1
+		}
+		if sep < 0 {
+			props[unescapeProperty(line)] = ""
+			continue
+		}
+
+		key := unescapeProperty(strings.TrimSpace(line[:sep]))
+		props[key] = unescapeProperty(strings.TrimSpace(line[sep+1:]))
+	}
+	return props, sc.Err()
+}
+
+// unescapeProperty removes the backslash escapes used in .properties values
+func unescapeProperty(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	escaped := false
+	for _, r := range s {
+		if escaped {
+			b.WriteRune(r)
+			escaped = false
+			continue
+		}
+		if r == '\\' {
+			escaped = true
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
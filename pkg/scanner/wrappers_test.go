@@ -0,0 +1,85 @@
+package scanner
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+func TestParseWrapperVersion(t *testing.T) {
+	gradle := buildWrappers[0].DistributionRegex
+	maven := buildWrappers[1].DistributionRegex
+
+	testCases := []struct {
+		fixture  string
+		tool     string
+		expected string
+		hasError bool
+	}{
+		{fixture: "gradle-8.5-bin.properties", tool: "gradle", expected: "8.5"},
+		{fixture: "gradle-7.6.1-all.properties", tool: "gradle", expected: "7.6.1"},
+		{fixture: "gradle-mirror-rc.properties", tool: "gradle", expected: "8.6-rc-1"},
+		{fixture: "maven-3.9.6.properties", tool: "maven", expected: "3.9.6"},
+		{fixture: "maven-takari.properties", tool: "maven", expected: "3.6.3"},
+		{fixture: "no-distribution.properties", tool: "gradle", hasError: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.fixture, func(t *testing.T) {
+			file, err := os.Open(filepath.Join("testdata", "wrappers", tc.fixture))
+			if err != nil {
+				t.Fatalf("failed to open fixture: %v", err)
+			}
+			defer file.Close()
+
+			regex := gradle
+			if tc.tool == "maven" {
+				regex = maven
+			}
+
+			actual, err := parseWrapperVersion(file, regex)
+			if tc.hasError {
+				if err == nil {
+					t.Fatalf("expected error, got version %q", actual)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if actual != tc.expected {
+				t.Errorf("expected version '%s', but got '%s'", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestDetectBuildWrappers(t *testing.T) {
+	projectDir := t.TempDir()
+	wrapperDir := filepath.Join(projectDir, "gradle", "wrapper")
+	if err := os.MkdirAll(wrapperDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	fixture, err := os.ReadFile(filepath.Join("testdata", "wrappers", "gradle-8.5-bin.properties"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(wrapperDir, "gradle-wrapper.properties"), fixture, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var envData types.EnvironmentData
+	DetectBuildWrappers(&envData, projectDir)
+
+	if envData.Project == nil {
+		t.Fatal("expected project info to be populated")
+	}
+	if got := envData.Project.BuildWrappers["Gradle"]; got != "8.5" {
+		t.Errorf("expected Gradle wrapper 8.5, got %q", got)
+	}
+	if _, ok := envData.Project.BuildWrappers["Maven"]; ok {
+		t.Error("expected no Maven wrapper for a project without .mvn")
+	}
+}
@@ -0,0 +1,293 @@
+// Package server implements the local HTTP API started by 'stackmatch serve'.
+//
+// Every endpoint answers JSON. All but /healthz require the bearer token
+// created by LoadOrCreateToken:
+//
+//	GET  /healthz             liveness, no token needed
+//	GET  /scan                the local environment, from cache when fresh
+//	GET  /scan?stream=1       the same as JSON lines, one per category scanned
+//	POST /check               check the local environment against the posted one
+//	GET  /diff?against=<ref>  changes from the local environment to another one
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/diff"
+	"github.com/MRQ67/stackmatch-cli/pkg/envfile"
+	"github.com/MRQ67/stackmatch-cli/pkg/stackmatch"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+// Defaults for Options
+const (
+	DefaultCacheTTL    = 5 * time.Minute
+	DefaultScanTimeout = 2 * time.Minute
+)
+
+// maxBodySize bounds the environments accepted by POST /check
+const maxBodySize = 4 << 20
+
+// Options configures the API
+type Options struct {
+	// Token is the bearer token requests must present. Required.
+	Token string
+	// CacheTTL is how long a scan is served before the machine is scanned
+	// again (default DefaultCacheTTL). Pass ?refresh=1 to /scan to rescan.
+	CacheTTL time.Duration
+	// ScanTimeout bounds each scan (default DefaultScanTimeout)
+	ScanTimeout time.Duration
+	// Scan scans the local environment, sending what it found so far on
+	// sections as categories complete when sections is not nil (see
+	// stackmatch.ScanOptions.Sections); stackmatch.Scan when nil
+	Scan func(ctx context.Context, sections chan<- stackmatch.ScanSection) (types.EnvironmentData, error)
+	// Resolve loads the environment an env-ref names for /diff. /diff
+	// answers 501 when it is nil.
+	Resolve func(ctx context.Context, ref string) (*types.EnvironmentData, error)
+	// Rules suppress differences in /check and /diff answers. May be nil.
+	Rules *diff.Rules
+}
+
+// Server serves the API. Scans are serialized: concurrent requests wait for
+// the scan in progress and share its result.
+type Server struct {
+	opts Options
+	mux  *http.ServeMux
+	now  func() time.Time
+
+	mu        sync.Mutex
+	cached    *types.EnvironmentData
+	scannedAt time.Time
+}
+
+// New returns a Server for opts
+func New(opts Options) (*Server, error) {
+	if opts.Token == "" {
+		return nil, errors.New("a token is required")
+	}
+	if opts.CacheTTL <= 0 {
+		opts.CacheTTL = DefaultCacheTTL
+	}
+	if opts.ScanTimeout <= 0 {
+		opts.ScanTimeout = DefaultScanTimeout
+	}
+	if opts.Scan == nil {
+		opts.Scan = func(ctx context.Context, sections chan<- stackmatch.ScanSection) (types.EnvironmentData, error) {
+			return stackmatch.Scan(ctx, stackmatch.ScanOptions{Sections: sections})
+		}
+	}
+
+	s := &Server{opts: opts, mux: http.NewServeMux(), now: time.Now}
+	s.mux.HandleFunc("/healthz", s.handleHealthz)
+	s.mux.Handle("/scan", s.authorized(http.MethodGet, s.handleScan))
+	s.mux.Handle("/check", s.authorized(http.MethodPost, s.handleCheck))
+	s.mux.Handle("/diff", s.authorized(http.MethodGet, s.handleDiff))
+	return s, nil
+}
+
+// ServeHTTP implements http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// authorized wraps an endpoint answering method with the token check
+func (s *Server) authorized(method string, next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.opts.Token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="stackmatch"`)
+			writeError(w, http.StatusUnauthorized, errors.New("missing or invalid bearer token"))
+			return
+		}
+		if r.Method != method {
+			w.Header().Set("Allow", method)
+			writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("use %s", method))
+			return
+		}
+		next(w, r)
+	})
+}
+
+func (s *Server) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok", "version": stackmatch.Version})
+}
+
+func (s *Server) handleScan(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Get("stream") != "" {
+		s.handleScanStream(w, r)
+		return
+	}
+	env, age, err := s.scan(r.Context(), r.URL.Query().Get("refresh") != "", nil)
+	if err != nil {
+		writeScanError(w, err)
+		return
+	}
+	w.Header().Set("Age", fmt.Sprint(int(age.Seconds())))
+	writeJSON(w, http.StatusOK, env)
+}
+
+// checkResponse is the body answered by POST /check
+type checkResponse struct {
+	Passed bool `json:"passed"`
+	stackmatch.CheckResult
+}
+
+func (s *Server) handleCheck(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err != nil {
+		writeError(w, http.StatusRequestEntityTooLarge, err)
+		return
+	}
+	wanted, err := envfile.Parse(body, envfile.Options{})
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid environment: %w", err))
+		return
+	}
+
+	installed, _, err := s.scan(r.Context(), false, nil)
+	if err != nil {
+		writeScanError(w, err)
+		return
+	}
+	result := stackmatch.Check(*installed, *wanted)
+	s.opts.Rules.ApplyCheck(&result)
+	writeJSON(w, http.StatusOK, checkResponse{Passed: result.Passed(), CheckResult: result})
+}
+
+func (s *Server) handleDiff(w http.ResponseWriter, r *http.Request) {
+	ref := r.URL.Query().Get("against")
+	if ref == "" {
+		writeError(w, http.StatusBadRequest, errors.New("the against parameter is required"))
+		return
+	}
+	if s.opts.Resolve == nil {
+		writeError(w, http.StatusNotImplemented, errors.New("environment references are not supported"))
+		return
+	}
+	against, err := s.opts.Resolve(r.Context(), ref)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+
+	local, _, err := s.scan(r.Context(), false, nil)
+	if err != nil {
+		writeScanError(w, err)
+		return
+	}
+	result := stackmatch.Diff(*local, *against)
+	s.opts.Rules.ApplyDiff(&result)
+	writeJSON(w, http.StatusOK, result)
+}
+
+// scanLine is a line of the answer to /scan?stream=1
+type scanLine struct {
+	// Category is the category the line completes; empty on the last line
+	Category string `json:"category,omitempty"`
+	// Complete is set on the last line when the scan finished
+	Complete bool `json:"complete"`
+	// Error says why the scan stopped, on the last line of a failed scan
+	Error       string                 `json:"error,omitempty"`
+	Environment *types.EnvironmentData `json:"environment"`
+}
+
+// handleScanStream answers /scan?stream=1 with a JSON line holding what the
+// scan found so far each time a category completes, so clients can show
+// the system before the languages are done. The last line holds the whole
+// environment and is marked complete, or holds what was found before the
+// scan failed, such as by timing out, and the error. A fresh cached scan
+// is answered in a single line.
+func (s *Server) handleScanStream(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	enc := json.NewEncoder(w)
+	write := func(line scanLine) {
+		_ = enc.Encode(line)
+		if flusher, ok := w.(http.Flusher); ok {
+			flusher.Flush()
+		}
+	}
+
+	sections := make(chan stackmatch.ScanSection)
+	written := make(chan struct{})
+	go func() {
+		defer close(written)
+		for section := range sections {
+			write(scanLine{Category: section.Category, Environment: &section.Environment})
+		}
+	}()
+	env, _, err := s.scan(r.Context(), r.URL.Query().Get("refresh") != "", sections)
+	close(sections)
+	<-written
+
+	if err != nil {
+		write(scanLine{Error: scanErrorMessage(err), Environment: env})
+		return
+	}
+	write(scanLine{Complete: true, Environment: env})
+}
+
+// scan returns the cached scan and its age, scanning again when the cache
+// is stale or refresh is set. Scans send their progress on sections when it
+// is not nil. A failed scan returns what it found before failing, if
+// anything, which is not cached.
+func (s *Server) scan(ctx context.Context, refresh bool, sections chan<- stackmatch.ScanSection) (*types.EnvironmentData, time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !refresh && s.cached != nil {
+		if age := s.now().Sub(s.scannedAt); age < s.opts.CacheTTL {
+			return s.cached, age, nil
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.opts.ScanTimeout)
+	defer cancel()
+	env, err := s.opts.Scan(ctx, sections)
+	if err != nil {
+		if env.Incomplete {
+			return &env, 0, err
+		}
+		return nil, 0, err
+	}
+	s.cached, s.scannedAt = &env, s.now()
+	return s.cached, 0, nil
+}
+
+// writeScanError answers a failed scan, telling timeouts apart
+func writeScanError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if errors.Is(err, context.DeadlineExceeded) {
+		status = http.StatusGatewayTimeout
+	}
+	writeError(w, status, errors.New(scanErrorMessage(err)))
+}
+
+// scanErrorMessage describes a failed scan, telling timeouts apart
+func scanErrorMessage(err error) string {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Sprintf("scan did not finish in time: %v", err)
+	}
+	return fmt.Sprintf("scan failed: %v", err)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(v)
+}
@@ -0,0 +1,325 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/stackmatch"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+const testToken = "secret-token"
+
+// newTestServer returns a Server whose scans return a fixed environment and
+// count how often they ran
+func newTestServer(t *testing.T, opts Options) (*Server, *int) {
+	t.Helper()
+	scans := 0
+	if opts.Scan == nil {
+		opts.Scan = func(ctx context.Context, sections chan<- stackmatch.ScanSection) (types.EnvironmentData, error) {
+			scans++
+			return types.EnvironmentData{
+				StackmatchVersion:   "0.3.0",
+				System:              types.SystemInfo{OS: "linux", Arch: "amd64"},
+				ConfiguredLanguages: map[string]string{"Go": "1.22.3"},
+				Tools:               map[string]string{"Git": "2.45.0"},
+			}, nil
+		}
+	}
+	opts.Token = testToken
+	s, err := New(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, &scans
+}
+
+func request(t *testing.T, s *Server, method, target, token, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAuthorization(t *testing.T) {
+	s, scans := newTestServer(t, Options{})
+
+	testCases := []struct {
+		name     string
+		method   string
+		target   string
+		token    string
+		expected int
+	}{
+		{name: "Health without token", method: http.MethodGet, target: "/healthz", expected: http.StatusOK},
+		{name: "Scan without token", method: http.MethodGet, target: "/scan", expected: http.StatusUnauthorized},
+		{name: "Scan with wrong token", method: http.MethodGet, target: "/scan", token: "guess", expected: http.StatusUnauthorized},
+		{name: "Diff without token", method: http.MethodGet, target: "/diff?against=env.json", expected: http.StatusUnauthorized},
+		{name: "Check with wrong method", method: http.MethodGet, target: "/check", token: testToken, expected: http.StatusMethodNotAllowed},
+		{name: "Unknown path", method: http.MethodGet, target: "/install", token: testToken, expected: http.StatusNotFound},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := request(t, s, tc.method, tc.target, tc.token, "")
+			if rec.Code != tc.expected {
+				t.Errorf("expected status %d but got %d: %s", tc.expected, rec.Code, rec.Body)
+			}
+		})
+	}
+	if *scans != 0 {
+		t.Errorf("expected rejected requests not to scan but got %d scans", *scans)
+	}
+}
+
+func TestScanCache(t *testing.T) {
+	s, scans := newTestServer(t, Options{CacheTTL: time.Minute})
+	now := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+
+	steps := []struct {
+		name      string
+		advance   time.Duration
+		target    string
+		wantScans int
+		wantAge   string
+	}{
+		{name: "First request scans", target: "/scan", wantScans: 1, wantAge: "0"},
+		{name: "Fresh scan is reused", advance: 30 * time.Second, target: "/scan", wantScans: 1, wantAge: "30"},
+		{name: "Refresh rescans", target: "/scan?refresh=1", wantScans: 2, wantAge: "0"},
+		{name: "Stale scan is replaced", advance: 2 * time.Minute, target: "/scan", wantScans: 3, wantAge: "0"},
+	}
+
+	for _, step := range steps {
+		now = now.Add(step.advance)
+		rec := request(t, s, http.MethodGet, step.target, testToken, "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: expected status 200 but got %d: %s", step.name, rec.Code, rec.Body)
+		}
+		if *scans != step.wantScans {
+			t.Errorf("%s: expected %d scans but got %d", step.name, step.wantScans, *scans)
+		}
+		if age := rec.Header().Get("Age"); age != step.wantAge {
+			t.Errorf("%s: expected Age %s but got %s", step.name, step.wantAge, age)
+		}
+		var env types.EnvironmentData
+		if err := json.Unmarshal(rec.Body.Bytes(), &env); err != nil || env.Tools["Git"] != "2.45.0" {
+			t.Errorf("%s: expected the scanned environment but got %s (%v)", step.name, rec.Body, err)
+		}
+	}
+}
+
+func TestScanTimeout(t *testing.T) {
+	s, _ := newTestServer(t, Options{
+		ScanTimeout: 10 * time.Millisecond,
+		Scan: func(ctx context.Context, sections chan<- stackmatch.ScanSection) (types.EnvironmentData, error) {
+			<-ctx.Done()
+			return types.EnvironmentData{}, ctx.Err()
+		},
+	})
+
+	rec := request(t, s, http.MethodGet, "/scan", testToken, "")
+	if rec.Code != http.StatusGatewayTimeout {
+		t.Errorf("expected status 504 but got %d: %s", rec.Code, rec.Body)
+	}
+}
+
+// readScanLines decodes the answer to /scan?stream=1
+func readScanLines(t *testing.T, rec *httptest.ResponseRecorder) []scanLine {
+	t.Helper()
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200 but got %d: %s", rec.Code, rec.Body)
+	}
+	if contentType := rec.Header().Get("Content-Type"); contentType != "application/x-ndjson" {
+		t.Errorf("expected JSON lines but got %s", contentType)
+	}
+	var lines []scanLine
+	dec := json.NewDecoder(rec.Body)
+	for dec.More() {
+		var line scanLine
+		if err := dec.Decode(&line); err != nil {
+			t.Fatalf("invalid line: %v", err)
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func TestScanStream(t *testing.T) {
+	scans := 0
+	s, _ := newTestServer(t, Options{
+		Scan: func(ctx context.Context, sections chan<- stackmatch.ScanSection) (types.EnvironmentData, error) {
+			scans++
+			env := types.EnvironmentData{System: types.SystemInfo{OS: "linux", Arch: "amd64"}, Incomplete: true}
+			sections <- stackmatch.ScanSection{Category: types.CategorySystem, Environment: env}
+			env.ConfiguredLanguages = map[string]string{"Go": "1.22.3"}
+			sections <- stackmatch.ScanSection{Category: types.CategoryLanguages, Environment: env}
+			env.Incomplete = false
+			return env, nil
+		},
+	})
+
+	lines := readScanLines(t, request(t, s, http.MethodGet, "/scan?stream=1", testToken, ""))
+	if len(lines) != 3 {
+		t.Fatalf("expected a line per category and the result but got %+v", lines)
+	}
+	if lines[0].Category != types.CategorySystem || lines[0].Complete || lines[0].Environment.System.OS != "linux" || lines[0].Environment.ConfiguredLanguages != nil {
+		t.Errorf("expected the system alone first but got %+v", lines[0])
+	}
+	if lines[1].Category != types.CategoryLanguages || lines[1].Complete || lines[1].Environment.ConfiguredLanguages["Go"] != "1.22.3" {
+		t.Errorf("expected the languages second but got %+v", lines[1])
+	}
+	if last := lines[2]; !last.Complete || last.Category != "" || last.Environment.Incomplete || last.Environment.ConfiguredLanguages["Go"] != "1.22.3" {
+		t.Errorf("expected the whole environment last, marked complete, but got %+v", last)
+	}
+
+	// The scan is cached like any other
+	lines = readScanLines(t, request(t, s, http.MethodGet, "/scan?stream=1", testToken, ""))
+	if scans != 1 || len(lines) != 1 || !lines[0].Complete {
+		t.Errorf("expected the cached scan in a single line but got %+v", lines)
+	}
+	rec := request(t, s, http.MethodGet, "/scan", testToken, "")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"Go": "1.22.3"`) {
+		t.Errorf("expected /scan to answer the streamed scan but got %d: %s", rec.Code, rec.Body)
+	}
+}
+
+func TestScanStreamTimeout(t *testing.T) {
+	s, _ := newTestServer(t, Options{
+		ScanTimeout: 10 * time.Millisecond,
+		Scan: func(ctx context.Context, sections chan<- stackmatch.ScanSection) (types.EnvironmentData, error) {
+			env := types.EnvironmentData{System: types.SystemInfo{OS: "linux", Arch: "amd64"}, Incomplete: true}
+			if sections != nil {
+				sections <- stackmatch.ScanSection{Category: types.CategorySystem, Environment: env}
+			}
+			<-ctx.Done()
+			return env, ctx.Err()
+		},
+	})
+
+	lines := readScanLines(t, request(t, s, http.MethodGet, "/scan?stream=1", testToken, ""))
+	if len(lines) != 2 {
+		t.Fatalf("expected the system and the error but got %+v", lines)
+	}
+	last := lines[1]
+	if last.Complete || !strings.Contains(last.Error, "did not finish in time") {
+		t.Errorf("expected the last line to report the timeout but got %+v", last)
+	}
+	if last.Environment == nil || !last.Environment.Incomplete || last.Environment.System.OS != "linux" {
+		t.Errorf("expected the partial environment, marked incomplete, but got %+v", last.Environment)
+	}
+
+	// Partial scans are not cached
+	rec := request(t, s, http.MethodGet, "/scan", testToken, "")
+	if rec.Code != http.StatusGatewayTimeout {
+		t.Errorf("expected the scan to be run again and time out, got %d: %s", rec.Code, rec.Body)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	s, _ := newTestServer(t, Options{})
+
+	testCases := []struct {
+		name       string
+		body       string
+		expected   int
+		wantPassed bool
+	}{
+		{
+			name:       "Satisfied",
+			body:       `{"stackmatch_version": "0.3.0", "tools": {"Git": "2.45.0"}}`,
+			expected:   http.StatusOK,
+			wantPassed: true,
+		},
+		{
+			name:     "Missing tool",
+			body:     `{"stackmatch_version": "0.3.0", "tools": {"Git": "2.45.0", "Docker": "26.1.0"}}`,
+			expected: http.StatusOK,
+		},
+		{name: "Not JSON", body: `scan output`, expected: http.StatusBadRequest},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := request(t, s, http.MethodPost, "/check", testToken, tc.body)
+			if rec.Code != tc.expected {
+				t.Fatalf("expected status %d but got %d: %s", tc.expected, rec.Code, rec.Body)
+			}
+			if tc.expected != http.StatusOK {
+				return
+			}
+			var result struct {
+				Passed bool              `json:"passed"`
+				Items  []json.RawMessage `json:"items"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+				t.Fatal(err)
+			}
+			if result.Passed != tc.wantPassed || len(result.Items) == 0 {
+				t.Errorf("expected passed %v with items but got %s", tc.wantPassed, rec.Body)
+			}
+		})
+	}
+}
+
+func TestDiff(t *testing.T) {
+	s, _ := newTestServer(t, Options{
+		Resolve: func(ctx context.Context, ref string) (*types.EnvironmentData, error) {
+			if ref != "team" {
+				return nil, errors.New("environment not found")
+			}
+			return &types.EnvironmentData{Tools: map[string]string{"Git": "2.46.0"}}, nil
+		},
+	})
+
+	rec := request(t, s, http.MethodGet, "/diff?against=team", testToken, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200 but got %d: %s", rec.Code, rec.Body)
+	}
+	if !strings.Contains(rec.Body.String(), `"2.46.0"`) {
+		t.Errorf("expected the changed Git version in %s", rec.Body)
+	}
+
+	if rec := request(t, s, http.MethodGet, "/diff?against=other", testToken, ""); rec.Code != http.StatusNotFound {
+		t.Errorf("expected status 404 for an unknown reference but got %d", rec.Code)
+	}
+	if rec := request(t, s, http.MethodGet, "/diff", testToken, ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 without against but got %d", rec.Code)
+	}
+}
+
+func TestLoadOrCreateToken(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "serve-token")
+
+	token, err := LoadOrCreateToken(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(token) != 64 {
+		t.Errorf("expected a 64 character token but got %q", token)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm&0077 != 0 && os.PathSeparator == '/' {
+		t.Errorf("expected the token to be private but got mode %v", perm)
+	}
+
+	again, err := LoadOrCreateToken(path)
+	if err != nil || again != token {
+		t.Errorf("expected the stored token %q but got %q, %v", token, again, err)
+	}
+}
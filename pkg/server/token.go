@@ -0,0 +1,37 @@
+package server
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/config"
+)
+
+// LoadOrCreateToken returns the token stored at path, first generating a
+// random one readable only by the current user when there is none
+func LoadOrCreateToken(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err == nil {
+		if token := strings.TrimSpace(string(data)); token != "" {
+			return token, nil
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return "", fmt.Errorf("could not read token: %w", err)
+	}
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("could not generate token: %w", err)
+	}
+	token := hex.EncodeToString(buf)
+
+	if err := config.WritePrivateFile(path, []byte(token+"\n")); err != nil {
+		return "", fmt.Errorf("could not write token: %w", err)
+	}
+	return token, nil
+}
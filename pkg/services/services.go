@@ -0,0 +1,348 @@
+// Package services lists the developer services, such as databases, that
+// brew services, systemd and the Windows service manager know about, and
+// enables them. Only services on an allowlist of developer services are
+// considered; everything else the service managers run is left alone.
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/runner"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+// developerServices maps the names service managers give developer services,
+// once normalized (see Name), to the service's name across managers
+var developerServices = map[string]string{
+	"postgresql":            "postgresql",
+	"postgres":              "postgresql",
+	"mysql":                 "mysql",
+	"mysqld":                "mysql",
+	"mariadb":               "mariadb",
+	"redis":                 "redis",
+	"redis-server":          "redis",
+	"valkey":                "valkey",
+	"mongodb":               "mongodb",
+	"mongodb-community":     "mongodb",
+	"mongod":                "mongodb",
+	"memcached":             "memcached",
+	"rabbitmq":              "rabbitmq",
+	"rabbitmq-server":       "rabbitmq",
+	"elasticsearch":         "elasticsearch",
+	"elasticsearch-service": "elasticsearch",
+	"opensearch":            "opensearch",
+	"kafka":                 "kafka",
+	"zookeeper":             "zookeeper",
+	"minio":                 "minio",
+	"nginx":                 "nginx",
+	"caddy":                 "caddy",
+	"dnsmasq":               "dnsmasq",
+	"consul":                "consul",
+	"vault":                 "vault",
+	"etcd":                  "etcd",
+	"docker":                "docker",
+	"com.docker":            "docker",
+	"containerd":            "containerd",
+	"podman":                "podman",
+	"colima":                "colima",
+	"ollama":                "ollama",
+	"mssqlserver":           "sqlserver",
+	"mssql-server":          "sqlserver",
+}
+
+// versionSuffix matches the versions and architectures managers append to
+// service names, as in postgresql-x64-16, MySQL80 or postgresql-14
+var versionSuffix = regexp.MustCompile(`([-_.]?(x64|x86|[0-9][0-9.]*))+$`)
+
+// Name returns the name across managers of the service a manager calls
+// unit, or "" when unit is not a developer service. Versions, systemd
+// instances and the .service suffix are ignored, so postgresql@16,
+// postgresql@14-main.service and postgresql-x64-16 are all "postgresql".
+func Name(unit string) string {
+	name := strings.ToLower(strings.TrimSuffix(unit, ".service"))
+	name, _, _ = strings.Cut(name, "@")
+	if service, ok := developerServices[name]; ok {
+		return service
+	}
+	return developerServices[versionSuffix.ReplaceAllString(name, "")]
+}
+
+// Commands used to list and change services
+const (
+	brewServices   = "brew"
+	systemctl      = "systemctl"
+	powershell     = "powershell"
+	getServiceJSON = "Get-Service | Select-Object Name, @{Name='Status'; Expression={[string]$_.Status}}, @{Name='StartType'; Expression={[string]$_.StartType}} | ConvertTo-Json -Compress"
+)
+
+// Installed is a developer service known to a service manager on this
+// machine, whether or not it is set to start on its own
+type Installed struct {
+	types.Service
+	// Enabled is whether the service starts at login or boot
+	Enabled bool
+}
+
+// List returns the developer services that the service managers on PATH
+// know about, for goos. Managers that fail are reported as warnings and
+// skipped; a user systemd that can't be reached, as in containers, is
+// skipped silently.
+func List(ctx context.Context, r runner.Runner, path runner.PathIndex, goos string) ([]Installed, []string) {
+	var (
+		installed []Installed
+		warnings  []string
+	)
+	add := func(services []Installed, err error) {
+		if err != nil {
+			warnings = append(warnings, err.Error())
+			return
+		}
+		installed = append(installed, services...)
+	}
+
+	if goos == "windows" {
+		if _, err := path.LookPath(powershell); err == nil {
+			add(listWindows(ctx, r))
+		}
+		return installed, warnings
+	}
+	if _, err := path.LookPath(brewServices); err == nil {
+		add(listBrew(ctx, r))
+	}
+	if _, err := path.LookPath(systemctl); err == nil && goos == "linux" {
+		add(listSystemd(ctx, r, types.ServiceScopeUser))
+		add(listSystemd(ctx, r, types.ServiceScopeSystem))
+	}
+	return installed, warnings
+}
+
+// Enabled returns the services of installed that start on their own
+func Enabled(installed []Installed) []types.Service {
+	var enabled []types.Service
+	for _, service := range installed {
+		if service.Enabled {
+			enabled = append(enabled, service.Service)
+		}
+	}
+	return enabled
+}
+
+func listBrew(ctx context.Context, r runner.Runner) ([]Installed, error) {
+	stdout, stderr, err := r.Output(ctx, brewServices, "services", "list", "--json")
+	if err != nil {
+		return nil, commandError("Homebrew services", stderr, err)
+	}
+	return ParseBrew(stdout)
+}
+
+func listSystemd(ctx context.Context, r runner.Runner, scope string) ([]Installed, error) {
+	args := []string{"list-unit-files", "--type=service", "--no-legend", "--no-pager"}
+	if scope == types.ServiceScopeUser {
+		args = append([]string{"--user"}, args...)
+	}
+	stdout, stderr, err := r.Output(ctx, systemctl, args...)
+	if err != nil {
+		// There is no user manager outside a login session
+		if scope == types.ServiceScopeUser && strings.Contains(stderr, "Failed to connect to bus") {
+			return nil, nil
+		}
+		return nil, commandError(scope+" systemd services", stderr, err)
+	}
+	return ParseSystemd(stdout, scope), nil
+}
+
+func listWindows(ctx context.Context, r runner.Runner) ([]Installed, error) {
+	stdout, stderr, err := r.Output(ctx, powershell, "-NoProfile", "-NonInteractive", "-Command", getServiceJSON)
+	if err != nil {
+		return nil, commandError("Windows services", stderr, err)
+	}
+	return ParseWindows(stdout)
+}
+
+// commandError describes a service manager that could not be listed
+func commandError(what, stderr string, err error) error {
+	message := strings.TrimSpace(stderr)
+	if i := strings.IndexByte(message, '\n'); i >= 0 {
+		message = message[:i]
+	}
+	if message == "" {
+		message = err.Error()
+	}
+	return fmt.Errorf("could not list %s: %s", what, message)
+}
+
+// brewService is an entry of 'brew services list --json'
+type brewService struct {
+	Name   string  `json:"name"`
+	Status string  `json:"status"`
+	User   *string `json:"user"`
+	File   string  `json:"file"`
+}
+
+// ParseBrew returns the developer services in the output of 'brew services
+// list --json'. Services run by root, whose launchd plist is in
+// /Library/LaunchDaemons, are system services. Started, scheduled and
+// failing services are enabled.
+func ParseBrew(output string) ([]Installed, error) {
+	if strings.TrimSpace(output) == "" {
+		return nil, nil
+	}
+	var entries []brewService
+	if err := json.Unmarshal([]byte(output), &entries); err != nil {
+		return nil, fmt.Errorf("could not parse the Homebrew service list: %w", err)
+	}
+	var services []Installed
+	for _, entry := range entries {
+		name := Name(entry.Name)
+		if name == "" {
+			continue
+		}
+		scope := types.ServiceScopeUser
+		if (entry.User != nil && *entry.User == "root") || strings.Contains(entry.File, "/LaunchDaemons/") {
+			scope = types.ServiceScopeSystem
+		}
+		services = append(services, Installed{
+			Service: types.Service{Name: name, Unit: entry.Name, Manager: types.ServiceManagerBrew, Scope: scope, State: entry.Status},
+			Enabled: entry.Status == "started" || entry.Status == "scheduled" || entry.Status == "error",
+		})
+	}
+	return services, nil
+}
+
+// ParseSystemd returns the developer services in the output of 'systemctl
+// list-unit-files --type=service --no-legend', for services of scope.
+// Templates such as postgresql@.service are skipped, since only their
+// instances run.
+func ParseSystemd(output, scope string) []Installed {
+	var services []Installed
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || strings.HasSuffix(fields[0], "@.service") {
+			continue
+		}
+		name := Name(fields[0])
+		if name == "" {
+			continue
+		}
+		services = append(services, Installed{
+			Service: types.Service{Name: name, Unit: fields[0], Manager: types.ServiceManagerSystemd, Scope: scope, State: fields[1]},
+			Enabled: fields[1] == "enabled",
+		})
+	}
+	return services
+}
+
+// windowsService is an entry of the Get-Service listing
+type windowsService struct {
+	Name      string `json:"Name"`
+	Status    string `json:"Status"`
+	StartType string `json:"StartType"`
+}
+
+// ParseWindows returns the developer services in the JSON listing of
+// Get-Service, which is a single object when there is one service. Every
+// Windows service is a system service; those starting automatically are
+// enabled.
+func ParseWindows(output string) ([]Installed, error) {
+	output = strings.TrimSpace(output)
+	if output == "" {
+		return nil, nil
+	}
+	var entries []windowsService
+	if strings.HasPrefix(output, "{") {
+		output = "[" + output + "]"
+	}
+	if err := json.Unmarshal([]byte(output), &entries); err != nil {
+		return nil, fmt.Errorf("could not parse the Windows service list: %w", err)
+	}
+	var services []Installed
+	for _, entry := range entries {
+		name := Name(entry.Name)
+		if name == "" {
+			continue
+		}
+		services = append(services, Installed{
+			Service: types.Service{Name: name, Unit: entry.Name, Manager: types.ServiceManagerWindows, Scope: types.ServiceScopeSystem, State: entry.StartType},
+			Enabled: strings.HasPrefix(entry.StartType, "Automatic"),
+		})
+	}
+	return services, nil
+}
+
+// managerOrder ranks the managers whose services Match prefers
+var managerOrder = map[string]int{
+	types.ServiceManagerBrew:    0,
+	types.ServiceManagerSystemd: 1,
+	types.ServiceManagerWindows: 2,
+}
+
+// Match returns the equivalent among installed of every service of wanted,
+// keyed by service name. Services whose package is not installed here are
+// left out. An equivalent already enabled is preferred, then user services
+// over system services, then brew services over systemd.
+func Match(wanted []types.Service, installed []Installed) map[string]Installed {
+	candidates := candidatesByName(installed)
+	matches := make(map[string]Installed)
+	for _, service := range wanted {
+		if found := candidates[service.Name]; len(found) > 0 {
+			matches[service.Name] = found[0]
+		}
+	}
+	return matches
+}
+
+// candidatesByName groups installed by service name, best candidate first
+func candidatesByName(installed []Installed) map[string][]Installed {
+	byName := make(map[string][]Installed)
+	for _, service := range installed {
+		byName[service.Name] = append(byName[service.Name], service)
+	}
+	for _, found := range byName {
+		sort.SliceStable(found, func(i, j int) bool {
+			a, b := found[i], found[j]
+			if a.Enabled != b.Enabled {
+				return a.Enabled
+			}
+			if a.Scope != b.Scope {
+				return a.Scope == types.ServiceScopeUser
+			}
+			return managerOrder[a.Manager] < managerOrder[b.Manager]
+		})
+	}
+	return byName
+}
+
+// EnableCommand returns the command that makes service start on its own
+// and starts it now
+func EnableCommand(service types.Service) []string {
+	switch service.Manager {
+	case types.ServiceManagerBrew:
+		return []string{brewServices, "services", "start", service.Unit}
+	case types.ServiceManagerSystemd:
+		if service.Scope == types.ServiceScopeUser {
+			return []string{systemctl, "--user", "enable", "--now", service.Unit}
+		}
+		return []string{systemctl, "enable", "--now", service.Unit}
+	case types.ServiceManagerWindows:
+		script := fmt.Sprintf("Set-Service -Name '%[1]s' -StartupType Automatic; Start-Service -Name '%[1]s'", strings.ReplaceAll(service.Unit, "'", "''"))
+		return []string{powershell, "-NoProfile", "-NonInteractive", "-Command", script}
+	}
+	return nil
+}
+
+// Enable makes service start on its own and starts it now
+func Enable(ctx context.Context, r runner.Runner, service types.Service) error {
+	command := EnableCommand(service)
+	if command == nil {
+		return fmt.Errorf("can't enable %s: unknown service manager %q", service.Unit, service.Manager)
+	}
+	if output, err := r.CombinedOutput(ctx, command[0], command[1:]...); err != nil {
+		return fmt.Errorf("failed to enable %s: %w\n%s", service.Unit, err, strings.TrimSpace(output))
+	}
+	return nil
+}
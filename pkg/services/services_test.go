@@ -0,0 +1,236 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/runner/runnertest"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+const (
+	brewCommand          = "brew services list --json"
+	systemdUserCommand   = "systemctl --user list-unit-files --type=service --no-legend --no-pager"
+	systemdSystemCommand = "systemctl list-unit-files --type=service --no-legend --no-pager"
+)
+
+// readFixture returns the content of a file in testdata
+func readFixture(t *testing.T, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestName(t *testing.T) {
+	testCases := []struct {
+		unit     string
+		expected string
+	}{
+		{unit: "postgresql", expected: "postgresql"},
+		{unit: "postgresql@16", expected: "postgresql"},
+		{unit: "postgresql@14-main.service", expected: "postgresql"},
+		{unit: "postgresql-x64-16", expected: "postgresql"},
+		{unit: "MySQL80", expected: "mysql"},
+		{unit: "redis-server.service", expected: "redis"},
+		{unit: "mongodb-community@7.0", expected: "mongodb"},
+		{unit: "com.docker.service", expected: "docker"},
+		{unit: "elasticsearch-service-x64", expected: "elasticsearch"},
+		{unit: "ssh.service", expected: ""},
+		{unit: "Spooler", expected: ""},
+	}
+
+	for _, tc := range testCases {
+		if actual := Name(tc.unit); actual != tc.expected {
+			t.Errorf("expected %q to be the service %q but got %q", tc.unit, tc.expected, actual)
+		}
+	}
+}
+
+func TestParseBrew(t *testing.T) {
+	expected := []Installed{
+		{Service: types.Service{Name: "colima", Unit: "colima", Manager: types.ServiceManagerBrew, Scope: types.ServiceScopeUser, State: "none"}},
+		{Service: types.Service{Name: "dnsmasq", Unit: "dnsmasq", Manager: types.ServiceManagerBrew, Scope: types.ServiceScopeSystem, State: "started"}, Enabled: true},
+		{Service: types.Service{Name: "postgresql", Unit: "postgresql@16", Manager: types.ServiceManagerBrew, Scope: types.ServiceScopeUser, State: "started"}, Enabled: true},
+		{Service: types.Service{Name: "redis", Unit: "redis", Manager: types.ServiceManagerBrew, Scope: types.ServiceScopeUser, State: "scheduled"}, Enabled: true},
+		{Service: types.Service{Name: "mongodb", Unit: "mongodb-community@7.0", Manager: types.ServiceManagerBrew, Scope: types.ServiceScopeUser, State: "error"}, Enabled: true},
+	}
+
+	actual, err := ParseBrew(readFixture(t, "brew-services.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %+v but got %+v", expected, actual)
+	}
+	if _, err := ParseBrew("Error: Unknown command: services"); err == nil {
+		t.Error("expected an error for output that is not JSON")
+	}
+}
+
+func TestParseSystemd(t *testing.T) {
+	testCases := []struct {
+		fixture  string
+		scope    string
+		expected []Installed
+	}{
+		{
+			fixture: "systemctl-user.txt",
+			scope:   types.ServiceScopeUser,
+			expected: []Installed{
+				{Service: types.Service{Name: "ollama", Unit: "ollama.service", Manager: types.ServiceManagerSystemd, Scope: types.ServiceScopeUser, State: "disabled"}},
+				{Service: types.Service{Name: "podman", Unit: "podman.service", Manager: types.ServiceManagerSystemd, Scope: types.ServiceScopeUser, State: "disabled"}},
+				{Service: types.Service{Name: "redis", Unit: "redis.service", Manager: types.ServiceManagerSystemd, Scope: types.ServiceScopeUser, State: "enabled"}, Enabled: true},
+			},
+		},
+		{
+			fixture: "systemctl-system.txt",
+			scope:   types.ServiceScopeSystem,
+			expected: []Installed{
+				{Service: types.Service{Name: "docker", Unit: "docker.service", Manager: types.ServiceManagerSystemd, Scope: types.ServiceScopeSystem, State: "enabled"}, Enabled: true},
+				{Service: types.Service{Name: "mysql", Unit: "mysql.service", Manager: types.ServiceManagerSystemd, Scope: types.ServiceScopeSystem, State: "disabled"}},
+				{Service: types.Service{Name: "nginx", Unit: "nginx.service", Manager: types.ServiceManagerSystemd, Scope: types.ServiceScopeSystem, State: "masked"}},
+				{Service: types.Service{Name: "postgresql", Unit: "postgresql.service", Manager: types.ServiceManagerSystemd, Scope: types.ServiceScopeSystem, State: "enabled"}, Enabled: true},
+				{Service: types.Service{Name: "redis", Unit: "redis-server.service", Manager: types.ServiceManagerSystemd, Scope: types.ServiceScopeSystem, State: "enabled"}, Enabled: true},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.fixture, func(t *testing.T) {
+			actual := ParseSystemd(readFixture(t, tc.fixture), tc.scope)
+			if !reflect.DeepEqual(actual, tc.expected) {
+				t.Errorf("expected %+v but got %+v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestParseWindows(t *testing.T) {
+	expected := []Installed{
+		{Service: types.Service{Name: "docker", Unit: "com.docker.service", Manager: types.ServiceManagerWindows, Scope: types.ServiceScopeSystem, State: "Manual"}},
+		{Service: types.Service{Name: "mongodb", Unit: "MongoDB", Manager: types.ServiceManagerWindows, Scope: types.ServiceScopeSystem, State: "Automatic"}, Enabled: true},
+		{Service: types.Service{Name: "mysql", Unit: "MySQL80", Manager: types.ServiceManagerWindows, Scope: types.ServiceScopeSystem, State: "Disabled"}},
+		{Service: types.Service{Name: "postgresql", Unit: "postgresql-x64-16", Manager: types.ServiceManagerWindows, Scope: types.ServiceScopeSystem, State: "AutomaticDelayedStart"}, Enabled: true},
+	}
+
+	actual, err := ParseWindows(readFixture(t, "get-service.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %+v but got %+v", expected, actual)
+	}
+
+	// ConvertTo-Json writes a single service as an object
+	single, err := ParseWindows(`{"Name":"Redis","Status":"Running","StartType":"Automatic"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(single) != 1 || single[0].Unit != "Redis" || !single[0].Enabled {
+		t.Errorf("expected the single Redis service but got %+v", single)
+	}
+}
+
+func TestList(t *testing.T) {
+	r := &runnertest.Runner{Responses: map[string]runnertest.Response{
+		systemdUserCommand:   {Stderr: "Failed to connect to bus: No medium found\n", Err: errors.New("exit status 1")},
+		systemdSystemCommand: {Output: readFixture(t, "systemctl-system.txt")},
+		brewCommand:          {Stderr: "Error: Unknown command: services\n", Err: errors.New("exit status 1")},
+	}}
+	path := runnertest.NewPath([]string{"/usr/bin", "/home/linuxbrew/.linuxbrew/bin"}, "/usr/bin/systemctl", "/home/linuxbrew/.linuxbrew/bin/brew")
+
+	installed, warnings := List(context.Background(), r, path, "linux")
+
+	expectedWarnings := []string{"could not list Homebrew services: Error: Unknown command: services"}
+	if !reflect.DeepEqual(warnings, expectedWarnings) {
+		t.Errorf("expected warnings %q but got %q", expectedWarnings, warnings)
+	}
+	expected := []types.Service{
+		{Name: "docker", Unit: "docker.service", Manager: types.ServiceManagerSystemd, Scope: types.ServiceScopeSystem, State: "enabled"},
+		{Name: "postgresql", Unit: "postgresql.service", Manager: types.ServiceManagerSystemd, Scope: types.ServiceScopeSystem, State: "enabled"},
+		{Name: "redis", Unit: "redis-server.service", Manager: types.ServiceManagerSystemd, Scope: types.ServiceScopeSystem, State: "enabled"},
+	}
+	if actual := Enabled(installed); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected enabled services %+v but got %+v", expected, actual)
+	}
+
+	// Windows is only asked through PowerShell
+	r = &runnertest.Runner{}
+	if installed, warnings := List(context.Background(), r, path, "windows"); installed != nil || warnings != nil || len(r.Calls()) != 0 {
+		t.Errorf("expected nothing without PowerShell but got %+v, %q and calls %q", installed, warnings, r.Calls())
+	}
+}
+
+func TestMatch(t *testing.T) {
+	userRedis := Installed{Service: types.Service{Name: "redis", Unit: "redis.service", Manager: types.ServiceManagerSystemd, Scope: types.ServiceScopeUser, State: "disabled"}}
+	systemRedis := Installed{Service: types.Service{Name: "redis", Unit: "redis-server.service", Manager: types.ServiceManagerSystemd, Scope: types.ServiceScopeSystem, State: "disabled"}}
+	brewRedis := Installed{Service: types.Service{Name: "redis", Unit: "redis", Manager: types.ServiceManagerBrew, Scope: types.ServiceScopeUser, State: "none"}}
+	enabledRedis := systemRedis
+	enabledRedis.State, enabledRedis.Enabled = "enabled", true
+	wanted := []types.Service{{Name: "redis", Unit: "redis", Manager: types.ServiceManagerBrew, Scope: types.ServiceScopeUser, State: "started"}, {Name: "postgresql", Unit: "postgresql@16", Manager: types.ServiceManagerBrew, Scope: types.ServiceScopeUser, State: "started"}}
+
+	testCases := []struct {
+		name      string
+		installed []Installed
+		expected  map[string]Installed
+	}{
+		{name: "not installed", installed: nil, expected: map[string]Installed{}},
+		{name: "user over system", installed: []Installed{systemRedis, userRedis}, expected: map[string]Installed{"redis": userRedis}},
+		{name: "brew over systemd", installed: []Installed{userRedis, brewRedis}, expected: map[string]Installed{"redis": brewRedis}},
+		{name: "already enabled", installed: []Installed{brewRedis, enabledRedis}, expected: map[string]Installed{"redis": enabledRedis}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := Match(wanted, tc.installed); !reflect.DeepEqual(actual, tc.expected) {
+				t.Errorf("expected %+v but got %+v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestEnable(t *testing.T) {
+	testCases := []struct {
+		service  types.Service
+		expected string
+	}{
+		{
+			service:  types.Service{Unit: "postgresql@16", Manager: types.ServiceManagerBrew, Scope: types.ServiceScopeUser},
+			expected: "brew services start postgresql@16",
+		},
+		{
+			service:  types.Service{Unit: "redis.service", Manager: types.ServiceManagerSystemd, Scope: types.ServiceScopeUser},
+			expected: "systemctl --user enable --now redis.service",
+		},
+		{
+			service:  types.Service{Unit: "postgresql.service", Manager: types.ServiceManagerSystemd, Scope: types.ServiceScopeSystem},
+			expected: "systemctl enable --now postgresql.service",
+		},
+		{
+			service:  types.Service{Unit: "MySQL80", Manager: types.ServiceManagerWindows, Scope: types.ServiceScopeSystem},
+			expected: "powershell -NoProfile -NonInteractive -Command Set-Service -Name 'MySQL80' -StartupType Automatic; Start-Service -Name 'MySQL80'",
+		},
+	}
+
+	for _, tc := range testCases {
+		r := &runnertest.Runner{Responses: map[string]runnertest.Response{tc.expected: {}}}
+		if err := Enable(context.Background(), r, tc.service); err != nil {
+			t.Errorf("expected %s to be enabled with %q but got: %v", tc.service.Unit, tc.expected, err)
+		}
+	}
+
+	r := &runnertest.Runner{Responses: map[string]runnertest.Response{
+		"systemctl --user enable --now redis.service": {Output: "Failed to enable unit: Unit file redis.service does not exist.\n", Err: errors.New("exit status 1")},
+	}}
+	err := Enable(context.Background(), r, types.Service{Unit: "redis.service", Manager: types.ServiceManagerSystemd, Scope: types.ServiceScopeUser})
+	if err == nil || err.Error() != "failed to enable redis.service: exit status 1\nFailed to enable unit: Unit file redis.service does not exist." {
+		t.Errorf("expected the systemctl error but got %v", err)
+	}
+}
@@ -0,0 +1,237 @@
+// Package shellinit writes the lines language version managers such as nvm
+// and pyenv need in the user's shell rc file before they work in new shells.
+// Only the current user's rc files for bash, zsh and fish are touched.
+package shellinit
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+)
+
+// BackupSuffix is added to the name of an rc file for the copy Apply keeps
+// before changing it
+const BackupSuffix = ".stackmatch-backup"
+
+// manager describes how to load a version manager in each shell
+type manager struct {
+	// snippets are the init lines per shell. A shell without one can't
+	// load the manager without a plugin.
+	snippets map[string]string
+	// existing matches init lines written by hand or by the manager's
+	// installer, so they aren't added twice
+	existing *regexp.Regexp
+}
+
+// The init lines bash and zsh share, as the managers document them
+const (
+	posixNvm = `export NVM_DIR="$HOME/.nvm"
+[ -s "$NVM_DIR/nvm.sh" ] && \. "$NVM_DIR/nvm.sh"
+[ -s "$NVM_DIR/bash_completion" ] && \. "$NVM_DIR/bash_completion"`
+	posixPyenv = `export PYENV_ROOT="$HOME/.pyenv"
+[[ -d $PYENV_ROOT/bin ]] && export PATH="$PYENV_ROOT/bin:$PATH"
+command -v pyenv >/dev/null && eval "$(pyenv init -)"`
+	posixSdkman = `export SDKMAN_DIR="$HOME/.sdkman"
+[[ -s "$SDKMAN_DIR/bin/sdkman-init.sh" ]] && source "$SDKMAN_DIR/bin/sdkman-init.sh"`
+	posixAsdf = `export PATH="${ASDF_DATA_DIR:-$HOME/.asdf}/shims:$PATH"`
+)
+
+// managers are the version managers with init lines, keyed by the names
+// recorded in EnvironmentData.VersionManagers and used by the installer.
+// uv needs none.
+var managers = map[string]manager{
+	"nvm": {
+		// nvm only supports POSIX shells; fish needs a plugin such as nvm.fish
+		snippets: map[string]string{"bash": posixNvm, "zsh": posixNvm},
+		existing: regexp.MustCompile(`nvm\.sh`),
+	},
+	"pyenv": {
+		snippets: map[string]string{
+			"bash": posixPyenv,
+			"zsh":  posixPyenv,
+			"fish": `set -gx PYENV_ROOT $HOME/.pyenv
+test -d $PYENV_ROOT/bin; and fish_add_path $PYENV_ROOT/bin
+type -q pyenv; and pyenv init - | source`,
+		},
+		existing: regexp.MustCompile(`pyenv init`),
+	},
+	"rbenv": {
+		snippets: map[string]string{
+			"bash": `command -v rbenv >/dev/null && eval "$(rbenv init - bash)"`,
+			"zsh":  `command -v rbenv >/dev/null && eval "$(rbenv init - zsh)"`,
+			"fish": `type -q rbenv; and rbenv init - fish | source`,
+		},
+		existing: regexp.MustCompile(`rbenv init`),
+	},
+	"sdkman": {
+		// sdkman only supports POSIX shells; fish needs sdkman-for-fish
+		snippets: map[string]string{"bash": posixSdkman, "zsh": posixSdkman},
+		existing: regexp.MustCompile(`sdkman-init\.sh`),
+	},
+	"mise": {
+		snippets: map[string]string{
+			"bash": `command -v mise >/dev/null && eval "$(mise activate bash)"`,
+			"zsh":  `command -v mise >/dev/null && eval "$(mise activate zsh)"`,
+			"fish": `type -q mise; and mise activate fish | source`,
+		},
+		existing: regexp.MustCompile(`mise activate`),
+	},
+	"asdf": {
+		// asdf 0.16 and later only need the shims on PATH, which also
+		// runs the tools of older releases
+		snippets: map[string]string{
+			"bash": posixAsdf,
+			"zsh":  posixAsdf,
+			"fish": `fish_add_path (set -q ASDF_DATA_DIR; and echo $ASDF_DATA_DIR; or echo $HOME/.asdf)/shims`,
+		},
+		existing: regexp.MustCompile(`asdf\.(sh|fish)|\.asdf/shims|ASDF_DATA_DIR`),
+	},
+}
+
+// Init is what loading a version manager in a shell takes
+type Init struct {
+	// Manager is the version manager, such as "nvm"
+	Manager string `json:"manager"`
+	// Shell is the shell's name, "bash", "zsh" or "fish"
+	Shell string `json:"shell"`
+	// File is the rc file the lines go in, relative to the home directory
+	// as in "~/.zshrc"
+	File string `json:"file"`
+	// Snippet is the init lines, or "" when the manager can't be loaded in
+	// the shell without a plugin
+	Snippet string `json:"snippet,omitempty"`
+}
+
+// For returns the init of manager in shell, given as a name or path such as
+// "/bin/zsh". It reports false for managers that need no init and shells
+// other than bash, zsh and fish.
+func For(managerName, shell string) (Init, bool) {
+	return forOS(managerName, shell, runtime.GOOS)
+}
+
+func forOS(managerName, shell, goos string) (Init, bool) {
+	m, ok := managers[managerName]
+	if !ok {
+		return Init{}, false
+	}
+	shell = strings.TrimSuffix(filepath.Base(shell), ".exe")
+	var file string
+	switch shell {
+	case "bash":
+		// Terminals on macOS start login shells, which don't read ~/.bashrc
+		file = "~/.bashrc"
+		if goos == "darwin" {
+			file = "~/.bash_profile"
+		}
+	case "zsh":
+		file = "~/.zshrc"
+	case "fish":
+		file = "~/.config/fish/config.fish"
+	default:
+		return Init{}, false
+	}
+	return Init{Manager: managerName, Shell: shell, File: file, Snippet: m.snippets[shell]}, true
+}
+
+// Path returns File under home
+func (i Init) Path(home string) string {
+	return filepath.Join(home, filepath.FromSlash(strings.TrimPrefix(i.File, "~/")))
+}
+
+// markers return the comments around the lines Apply adds
+func (i Init) markers() (begin, end string) {
+	return fmt.Sprintf("# >>> %s init (added by stackmatch) >>>", i.Manager), fmt.Sprintf("# <<< %s init (added by stackmatch) <<<", i.Manager)
+}
+
+// Configured reports whether content, an rc file, already loads the manager:
+// either Apply added its lines, or an uncommented line, such as one its
+// installer wrote, does
+func (i Init) Configured(content string) bool {
+	begin, _ := i.markers()
+	if strings.Contains(content, begin) {
+		return true
+	}
+	existing := managers[i.Manager].existing
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#") && existing != nil && existing.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply appends the init lines, between marker comments, to the rc file
+// under home unless it already loads the manager, copying the file to
+// BackupSuffix first unless an earlier Apply did. It reports whether it
+// changed the file.
+func Apply(home string, i Init) (bool, error) {
+	if i.Snippet == "" {
+		return false, fmt.Errorf("%s can't be loaded in %s without a plugin", i.Manager, i.Shell)
+	}
+	path := i.Path(home)
+	content, err := os.ReadFile(path)
+	exists := err == nil
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return false, fmt.Errorf("failed to read %s: %w", i.File, err)
+	}
+	if i.Configured(string(content)) {
+		return false, nil
+	}
+
+	mode := fs.FileMode(0o644)
+	if exists {
+		if info, err := os.Stat(path); err == nil {
+			mode = info.Mode().Perm()
+		}
+		if err := backUp(path+BackupSuffix, content, mode); err != nil {
+			return false, fmt.Errorf("failed to back up %s: %w", i.File, err)
+		}
+	} else if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return false, fmt.Errorf("failed to create %s: %w", i.File, err)
+	}
+
+	begin, end := i.markers()
+	block := fmt.Sprintf("%s\n%s\n%s\n", begin, i.Snippet, end)
+	switch {
+	case len(content) == 0:
+	case strings.HasSuffix(string(content), "\n"):
+		block = "\n" + block
+	default:
+		block = "\n\n" + block
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, mode)
+	if err != nil {
+		return false, fmt.Errorf("failed to open %s: %w", i.File, err)
+	}
+	if _, err := f.WriteString(block); err != nil {
+		f.Close()
+		return false, fmt.Errorf("failed to write %s: %w", i.File, err)
+	}
+	if err := f.Close(); err != nil {
+		return false, fmt.Errorf("failed to write %s: %w", i.File, err)
+	}
+	return true, nil
+}
+
+// backUp writes content to path unless it exists, so the backup keeps the
+// file as it was before the first lines were added
+func backUp(path string, content []byte, mode fs.FileMode) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, mode)
+	if errors.Is(err, fs.ErrExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(content); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
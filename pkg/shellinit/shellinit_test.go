@@ -0,0 +1,159 @@
+package shellinit
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFor(t *testing.T) {
+	testCases := []struct {
+		manager string
+		shell   string
+		goos    string
+		file    string
+		snippet string
+		ok      bool
+	}{
+		{manager: "nvm", shell: "/bin/bash", goos: "linux", file: "~/.bashrc", snippet: `\. "$NVM_DIR/nvm.sh"`, ok: true},
+		{manager: "nvm", shell: "/bin/bash", goos: "darwin", file: "~/.bash_profile", snippet: `\. "$NVM_DIR/nvm.sh"`, ok: true},
+		{manager: "pyenv", shell: "/usr/bin/zsh", goos: "linux", file: "~/.zshrc", snippet: `eval "$(pyenv init -)"`, ok: true},
+		{manager: "pyenv", shell: "/opt/homebrew/bin/fish", goos: "darwin", file: "~/.config/fish/config.fish", snippet: "pyenv init - | source", ok: true},
+		{manager: "rbenv", shell: "fish", goos: "linux", file: "~/.config/fish/config.fish", snippet: "rbenv init - fish | source", ok: true},
+		// sdkman has no fish support, so the step says to use a plugin
+		{manager: "sdkman", shell: "/usr/bin/fish", goos: "linux", file: "~/.config/fish/config.fish", ok: true},
+		{manager: "uv", shell: "/bin/zsh", goos: "linux"},
+		{manager: "nvm", shell: "powershell.exe", goos: "windows"},
+		{manager: "nvm", shell: "/bin/sh", goos: "linux"},
+	}
+
+	for _, tc := range testCases {
+		init, ok := forOS(tc.manager, tc.shell, tc.goos)
+		if ok != tc.ok {
+			t.Errorf("%s in %s: expected ok %v but got %v", tc.manager, tc.shell, tc.ok, ok)
+			continue
+		}
+		if init.File != tc.file {
+			t.Errorf("%s in %s: expected the file %q but got %q", tc.manager, tc.shell, tc.file, init.File)
+		}
+		if (tc.snippet == "") != (init.Snippet == "") || !strings.Contains(init.Snippet, tc.snippet) {
+			t.Errorf("%s in %s: expected a snippet containing %q but got %q", tc.manager, tc.shell, tc.snippet, init.Snippet)
+		}
+	}
+}
+
+func TestApplyIsIdempotent(t *testing.T) {
+	for _, shell := range []string{"bash", "zsh", "fish"} {
+		t.Run(shell, func(t *testing.T) {
+			home := t.TempDir()
+			init, _ := forOS("pyenv", shell, "linux")
+			path := init.Path(home)
+			// fish's config directory doesn't exist yet
+			original := ""
+			if shell != "fish" {
+				original = "alias ll='ls -l'"
+				if err := os.WriteFile(path, []byte(original), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			for run := range 2 {
+				added, err := Apply(home, init)
+				if err != nil {
+					t.Fatalf("run %d: expected the init to apply but got %v", run+1, err)
+				}
+				if added != (run == 0) {
+					t.Errorf("run %d: expected added to be %v but got %v", run+1, run == 0, added)
+				}
+			}
+
+			content, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if count := strings.Count(string(content), init.Snippet); count != 1 {
+				t.Errorf("expected the init once but found it %d times in:\n%s", count, content)
+			}
+			if !strings.HasPrefix(string(content), original) || !strings.Contains(string(content), "# >>> pyenv init (added by stackmatch) >>>\n") {
+				t.Errorf("expected the init appended between markers but got:\n%s", content)
+			}
+			backup, err := os.ReadFile(path + BackupSuffix)
+			switch {
+			case shell == "fish" && err == nil:
+				t.Errorf("expected no backup of a new file but got %q", backup)
+			case shell != "fish" && string(backup) != original:
+				t.Errorf("expected a backup of the original file but got %q (%v)", backup, err)
+			}
+			if info, err := os.Stat(path); shell != "fish" && (err != nil || info.Mode().Perm() != 0o600) {
+				t.Errorf("expected the file to keep its permissions but got %v (%v)", info.Mode(), err)
+			}
+		})
+	}
+}
+
+func TestApplyKeepsFirstBackup(t *testing.T) {
+	home := t.TempDir()
+	pyenv, _ := forOS("pyenv", "bash", "linux")
+	nvm, _ := forOS("nvm", "bash", "linux")
+	original := "alias ll='ls -l'\n"
+	if err := os.WriteFile(pyenv.Path(home), []byte(original), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, init := range []Init{pyenv, nvm} {
+		if added, err := Apply(home, init); err != nil || !added {
+			t.Fatalf("expected the %s init to be added but got %v, %v", init.Manager, added, err)
+		}
+	}
+	backup, err := os.ReadFile(pyenv.Path(home) + BackupSuffix)
+	if err != nil || string(backup) != original {
+		t.Errorf("expected the backup to keep the original file but got %q (%v)", backup, err)
+	}
+}
+
+func TestApplyFindsExistingInit(t *testing.T) {
+	testCases := []struct {
+		name     string
+		content  string
+		expected bool
+	}{
+		{
+			name:    "installer lines",
+			content: "export NVM_DIR=\"$HOME/.nvm\"\n[ -s \"$NVM_DIR/nvm.sh\" ] && \\. \"$NVM_DIR/nvm.sh\"  # This loads nvm\n",
+		},
+		{
+			name:     "commented out",
+			content:  "# source ~/.nvm/nvm.sh\n",
+			expected: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			home := t.TempDir()
+			init, _ := forOS("nvm", "zsh", "linux")
+			if err := os.WriteFile(init.Path(home), []byte(tc.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			added, err := Apply(home, init)
+			if err != nil || added != tc.expected {
+				t.Errorf("expected added to be %v but got %v (%v)", tc.expected, added, err)
+			}
+			if _, err := os.Stat(filepath.Join(home, ".zshrc"+BackupSuffix)); tc.expected == os.IsNotExist(err) {
+				t.Errorf("expected a backup only when the file changed, got %v", err)
+			}
+		})
+	}
+}
+
+func TestApplyWithoutSnippet(t *testing.T) {
+	home := t.TempDir()
+	init, _ := forOS("nvm", "fish", "linux")
+	if _, err := Apply(home, init); err == nil || !strings.Contains(err.Error(), "without a plugin") {
+		t.Errorf("expected nvm in fish to need a plugin but got %v", err)
+	}
+	if _, err := os.Stat(init.Path(home)); !os.IsNotExist(err) {
+		t.Errorf("expected no file to be written but got %v", err)
+	}
+}
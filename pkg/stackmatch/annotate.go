@@ -0,0 +1,117 @@
+package stackmatch
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+// classifiable returns the entry maps whose entries can be classified
+func classifiable(env *types.EnvironmentData) []map[string]string {
+	return []map[string]string{env.ConfiguredLanguages, env.Tools, env.PackageManagers, env.CodeEditors}
+}
+
+// Annotate classifies the entries called names as r, or removes their
+// classification when r is types.Unclassified. A name matches an entry's
+// display name ignoring case or its canonical tool ID, so "git", "Git" and
+// "vscode" all work. Unknown names are reported together and leave env
+// unchanged.
+func Annotate(env *types.EnvironmentData, names []string, r types.Requirement) error {
+	var matched []string
+	var unknown []string
+	for _, name := range names {
+		found := matchEntries(env, name)
+		if len(found) == 0 {
+			unknown = append(unknown, name)
+		}
+		matched = append(matched, found...)
+	}
+	if len(unknown) > 0 {
+		return fmt.Errorf("no entry is named %s", strings.Join(unknown, ", "))
+	}
+	for _, name := range matched {
+		env.SetRequirement(name, r)
+	}
+	return nil
+}
+
+// matchEntries returns the display names of the entries name refers to
+func matchEntries(env *types.EnvironmentData, name string) []string {
+	id := types.CanonicalID(name)
+	var found []string
+	for _, entries := range classifiable(env) {
+		for entry := range entries {
+			if strings.EqualFold(entry, name) || env.ToolID(entry) == id {
+				found = append(found, entry)
+			}
+		}
+	}
+	return found
+}
+
+// RequiredOnly returns a copy of env holding only its required entries, for
+// installing just the essentials. Config files and categories this release
+// cannot install are left out.
+func RequiredOnly(env types.EnvironmentData) types.EnvironmentData {
+	filter := func(entries map[string]string) map[string]string {
+		required := make(map[string]string)
+		for name, version := range entries {
+			if env.Requirements[name] == types.Required {
+				required[name] = version
+			}
+		}
+		return required
+	}
+	env.ConfiguredLanguages = filter(env.ConfiguredLanguages)
+	env.Tools = filter(env.Tools)
+	env.PackageManagers = filter(env.PackageManagers)
+	env.CodeEditors = filter(env.CodeEditors)
+	if _, ok := env.ConfiguredLanguages["Python"]; !ok {
+		env.Python = nil
+	}
+	env.LanguageVersions = keptLanguageVersions(&env)
+	env.ConfigFiles = nil
+	env.ConfigFileInfo = nil
+	env.ShellSetup = nil
+	env.Conda = nil
+	env.ScheduledJobs = nil
+	env.GitConfig = nil
+	env.LanguageConfig = nil
+	env.EnvVars = nil
+	env.VersionManagers = nil
+	env.GlobalPackages = nil
+	env.Services = nil
+	env.RunningServices = nil
+	env.Containers = nil
+	env.GPU = nil
+	env.Kubernetes = nil
+	env.CloudProfiles = nil
+	env.SSHKeys = nil
+	env.SSHAgent = false
+	env.Mobile = nil
+	env.Extensions = nil
+	env.Summary = types.BuildSummary(&env)
+	return env
+}
+
+// keptLanguageVersions returns the side-by-side versions of the languages env
+// still lists, or nil when there are none
+func keptLanguageVersions(env *types.EnvironmentData) map[string][]string {
+	var kept map[string][]string
+	for language, versions := range env.LanguageVersions {
+		_, ok := env.ConfiguredLanguages[language]
+		if language == "Python" {
+			_, python3 := env.ConfiguredLanguages["Python 3"]
+			ok = ok || python3
+		}
+		if !ok {
+			continue
+		}
+		if kept == nil {
+			kept = make(map[string][]string)
+		}
+		kept[language] = versions
+	}
+	return kept
+}
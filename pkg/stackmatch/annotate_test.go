@@ -0,0 +1,88 @@
+package stackmatch
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+func annotatedEnvironment() types.EnvironmentData {
+	return types.EnvironmentData{
+		ConfiguredLanguages: map[string]string{"Go": "1.22.3", "Node.js": "20.11.0"},
+		Tools:               map[string]string{"Git": "2.45.0", "Docker": "26.1.0"},
+		CodeEditors:         map[string]string{"VS Code": "1.89.1", "Neovim": "0.10.0"},
+		ToolIDs:             map[string]string{"VS Code": "vscode"},
+		ConfigFiles:         []string{"/home/dev/.gitconfig"},
+	}
+}
+
+func TestAnnotate(t *testing.T) {
+	testCases := []struct {
+		name        string
+		names       []string
+		requirement types.Requirement
+		expected    map[string]types.Requirement
+		wantErr     string
+	}{
+		{
+			name:        "Names, case and IDs",
+			names:       []string{"git", "GO", "vscode", "nodejs"},
+			requirement: types.Required,
+			expected:    map[string]types.Requirement{"Git": types.Required, "Go": types.Required, "VS Code": types.Required, "Node.js": types.Required, "Neovim": types.Optional},
+		},
+		{
+			name:        "Unclassify",
+			names:       []string{"neovim"},
+			requirement: types.Unclassified,
+			expected:    map[string]types.Requirement{},
+		},
+		{
+			name:        "Unknown names change nothing",
+			names:       []string{"git", "emacs", "rust"},
+			requirement: types.Required,
+			expected:    map[string]types.Requirement{"Neovim": types.Optional},
+			wantErr:     "no entry is named emacs, rust",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			env := annotatedEnvironment()
+			env.Requirements = map[string]types.Requirement{"Neovim": types.Optional}
+
+			err := Annotate(&env, tc.names, tc.requirement)
+			if tc.wantErr == "" && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tc.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tc.wantErr)) {
+				t.Errorf("expected error %q but got %v", tc.wantErr, err)
+			}
+			if !reflect.DeepEqual(env.Requirements, tc.expected) {
+				t.Errorf("expected %v but got %v", tc.expected, env.Requirements)
+			}
+		})
+	}
+}
+
+func TestRequiredOnly(t *testing.T) {
+	env := annotatedEnvironment()
+	env.Requirements = map[string]types.Requirement{"Go": types.Required, "Git": types.Required, "Neovim": types.Optional}
+	env.ShellSetup = map[string]string{"oh-my-zsh": "Installed"}
+	env.Conda = &types.CondaInstallation{Executable: "conda"}
+
+	required := RequiredOnly(env)
+	if !reflect.DeepEqual(required.ConfiguredLanguages, map[string]string{"Go": "1.22.3"}) ||
+		!reflect.DeepEqual(required.Tools, map[string]string{"Git": "2.45.0"}) ||
+		len(required.CodeEditors) != 0 || len(required.ConfigFiles) != 0 ||
+		required.ShellSetup != nil || required.Conda != nil {
+		t.Errorf("expected only Go and Git but got %+v", required)
+	}
+	if required.Summary == nil || required.Summary.Counts[types.CategoryTools] != 1 {
+		t.Errorf("expected the summary to count the required entries but got %+v", required.Summary)
+	}
+	if len(env.Tools) != 2 {
+		t.Errorf("expected the original environment to be unchanged but got %v", env.Tools)
+	}
+}
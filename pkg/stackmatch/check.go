@@ -0,0 +1,17 @@
+package stackmatch
+
+import (
+	"github.com/MRQ67/stackmatch-cli/pkg/diff"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+// CheckResult reports which entries of a wanted environment are satisfied
+type CheckResult = diff.CheckResult
+
+// Check reports whether the installed environment satisfies wanted. Both are
+// reconciled first, like in Diff.
+func Check(installed, wanted types.EnvironmentData) CheckResult {
+	groups := aliasGroups()
+	installed, wanted = types.Reconcile(installed, groups), types.Reconcile(wanted, groups)
+	return *diff.Check(&installed, &wanted)
+}
@@ -0,0 +1,59 @@
+package stackmatch
+
+import (
+	"fmt"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+	"github.com/MRQ67/stackmatch-cli/pkg/version"
+)
+
+// NewerMajorError is returned by CheckCompatibility for environments written
+// by a newer major release, which may change the meaning of existing fields
+type NewerMajorError struct {
+	// FileVersion is the release that wrote the environment
+	FileVersion string
+	// Version is the running release
+	Version string
+}
+
+func (e *NewerMajorError) Error() string {
+	return fmt.Sprintf("environment was written by stackmatch %s, a newer major release than this one (%s); upgrade stackmatch to use it",
+		e.FileVersion, e.Version)
+}
+
+// CheckCompatibility compares the release that wrote env with this one. It
+// returns a warning when env comes from a newer minor release or uses a
+// newer schema_version, whose additions this release keeps but cannot
+// install, and a *NewerMajorError when env comes from a newer major release.
+// Older files need nothing: reading them already migrates them to
+// types.CurrentSchemaVersion.
+func CheckCompatibility(env *types.EnvironmentData) (warning string, err error) {
+	return checkCompatibility(env, Version)
+}
+
+func checkCompatibility(env *types.EnvironmentData, current string) (string, error) {
+	const kept = "fields and categories it added are kept but not installed"
+	newerSchema := env.SchemaVersion > types.CurrentSchemaVersion
+
+	fileVersion, fileErr := version.Parse(env.StackmatchVersion)
+	runningVersion, runningErr := version.Parse(current)
+	if fileErr == nil && runningErr == nil {
+		switch {
+		case fileVersion.Major > runningVersion.Major:
+			return "", &NewerMajorError{FileVersion: env.StackmatchVersion, Version: current}
+		case fileVersion.Major == runningVersion.Major && fileVersion.Minor > runningVersion.Minor:
+			if newerSchema {
+				return fmt.Sprintf("environment was written by stackmatch %s (schema version %d), newer than this release (%s, schema version %d); %s",
+					env.StackmatchVersion, env.SchemaVersion, current, types.CurrentSchemaVersion, kept), nil
+			}
+			return fmt.Sprintf("environment was written by stackmatch %s, newer than this release (%s); %s",
+				env.StackmatchVersion, current, kept), nil
+		}
+	}
+
+	if newerSchema {
+		return fmt.Sprintf("environment uses schema version %d, newer than stackmatch %s supports (%d); %s",
+			env.SchemaVersion, current, types.CurrentSchemaVersion, kept), nil
+	}
+	return "", nil
+}
@@ -0,0 +1,67 @@
+package stackmatch
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+func TestCheckCompatibility(t *testing.T) {
+	testCases := []struct {
+		name          string
+		fileVersion   string
+		schemaVersion int
+		wantWarning   string
+		wantMajor     bool
+	}{
+		{name: "Same release", fileVersion: "1.4.2", schemaVersion: types.CurrentSchemaVersion},
+		{name: "Older release without schema version", fileVersion: "0.1.0"},
+		{name: "Older minor", fileVersion: "1.2.0", schemaVersion: 1},
+		{name: "Newer patch", fileVersion: "1.4.9", schemaVersion: types.CurrentSchemaVersion},
+		{
+			name:          "Newer minor",
+			fileVersion:   "1.5.0",
+			schemaVersion: types.CurrentSchemaVersion,
+			wantWarning:   "environment was written by stackmatch 1.5.0, newer than this release (1.4.2); fields and categories it added are kept but not installed",
+		},
+		{
+			name:          "Newer minor with a newer schema",
+			fileVersion:   "1.5.0",
+			schemaVersion: types.CurrentSchemaVersion + 1,
+			wantWarning:   "environment was written by stackmatch 1.5.0 (schema version 3), newer than this release (1.4.2, schema version 2); fields and categories it added are kept but not installed",
+		},
+		{
+			name:          "Newer schema from an unversioned build",
+			fileVersion:   "dev",
+			schemaVersion: types.CurrentSchemaVersion + 1,
+			wantWarning:   "environment uses schema version 3, newer than stackmatch 1.4.2 supports (2); fields and categories it added are kept but not installed",
+		},
+		{name: "Newer major", fileVersion: "2.0.0", schemaVersion: types.CurrentSchemaVersion, wantMajor: true},
+		{name: "Newer major with a v prefix", fileVersion: "v2.1", wantMajor: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			env := &types.EnvironmentData{StackmatchVersion: tc.fileVersion, SchemaVersion: tc.schemaVersion}
+			warning, err := checkCompatibility(env, "1.4.2")
+
+			var majorErr *NewerMajorError
+			if tc.wantMajor {
+				if !errors.As(err, &majorErr) {
+					t.Fatalf("expected a NewerMajorError but got %v", err)
+				}
+				if majorErr.FileVersion != tc.fileVersion || majorErr.Version != "1.4.2" {
+					t.Errorf("expected the error to name %s and 1.4.2 but got %q", tc.fileVersion, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if warning != tc.wantWarning {
+				t.Errorf("expected warning %q but got %q", tc.wantWarning, warning)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package stackmatch
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PlanCoverage counts how much of an environment a plan reproduces. Every
+// language, tool, package manager, editor and global package of the
+// environment, and every entry of a category this release doesn't know, is
+// counted once as installable, cross-platform, manual or unsupported.
+type PlanCoverage struct {
+	// Entries counts the entries of the environment
+	Entries int `json:"entries"`
+	// Installable counts the entries the plan installs
+	Installable int `json:"installable"`
+	// Unverifiable counts the installable entries with a recorded version
+	// the package manager can't be asked for, so whichever version it
+	// ships is installed and not checked against the recorded one
+	Unverifiable int `json:"unverifiable"`
+	// CrossPlatform counts the entries the package manager has no package
+	// for, typically because the environment was scanned on another
+	// platform
+	CrossPlatform int `json:"cross_platform"`
+	// Manual counts the languages left as manual steps, with no version
+	// manager or package mapping to install them, and the entries left
+	// out because their package conflicts with another of the plan
+	Manual int `json:"manual"`
+	// Unsupported counts the entries this release can't install, such as
+	// global packages of an unknown package manager and entries of unknown
+	// categories
+	Unsupported int `json:"unsupported"`
+}
+
+// Percent returns the share of the entries the plan installs, rounded down
+// so a threshold is only met when it is reached. An empty environment is
+// fully covered.
+func (c PlanCoverage) Percent() int {
+	if c.Entries == 0 {
+		return 100
+	}
+	return c.Installable * 100 / c.Entries
+}
+
+// NeedsAction counts the entries the plan leaves to the user
+func (c PlanCoverage) NeedsAction() int {
+	return c.Entries - c.Installable
+}
+
+// Summary describes the coverage in one line, such as "plan covers 78% of
+// the environment; 6 items need manual action"
+func (c PlanCoverage) Summary() string {
+	return fmt.Sprintf("plan covers %d%% of the environment; %d items need manual action", c.Percent(), c.NeedsAction())
+}
+
+// Breakdown describes each count, naming manager as the package manager
+// without packages for the cross-platform entries
+func (c PlanCoverage) Breakdown(manager string) string {
+	parts := []string{fmt.Sprintf("%d of %d entries installable", c.Installable, c.Entries)}
+	if c.Unverifiable > 0 {
+		parts = append(parts, fmt.Sprintf("%d at a version that can't be verified", c.Unverifiable))
+	}
+	if c.CrossPlatform > 0 {
+		parts = append(parts, fmt.Sprintf("%d with no package for %s", c.CrossPlatform, manager))
+	}
+	if c.Manual > 0 {
+		parts = append(parts, fmt.Sprintf("%d left as manual steps", c.Manual))
+	}
+	if c.Unsupported > 0 {
+		parts = append(parts, fmt.Sprintf("%d not installable by this release", c.Unsupported))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// recordedVersion reports whether v is a version rather than a marker of
+// presence such as "Installed"
+func recordedVersion(v string) bool {
+	return v != "" && v != "Installed"
+}
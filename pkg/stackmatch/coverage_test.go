@@ -0,0 +1,83 @@
+package stackmatch
+
+import (
+	"context"
+	"testing"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+func TestPlanCoverage(t *testing.T) {
+	testCases := []struct {
+		name     string
+		fixture  string
+		manager  types.PackageManagerType
+		expected PlanCoverage
+		summary  string
+	}{
+		{
+			// Go has no version manager or versioned package, Homebrew,
+			// Xcode and VS Code no apt package, and this release can't
+			// install cargo packages or databases
+			name:     "macOS workstation on apt",
+			fixture:  "macos-workstation.json",
+			manager:  types.TypeApt,
+			expected: PlanCoverage{Entries: 13, Installable: 7, Unverifiable: 5, CrossPlatform: 3, Manual: 1, Unsupported: 2},
+			summary:  "plan covers 53% of the environment; 6 items need manual action",
+		},
+		{
+			name:     "Linux server on apt",
+			fixture:  "ubuntu-server.json",
+			manager:  types.TypeApt,
+			expected: PlanCoverage{Entries: 3, Installable: 3},
+			summary:  "plan covers 100% of the environment; 0 items need manual action",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			env := loadFixture(t, "coverage", tc.fixture)
+			plan, err := Plan(context.Background(), env, PlanOptions{
+				Manager:          &fakeManager{pmType: tc.manager},
+				VersionManager:   &fakeVersionManager{supported: map[string]bool{"Node.js": true}},
+				GlobalInstallers: map[string]types.GlobalPackageInstaller{"npm": &fakeGlobalInstaller{available: true}},
+			})
+			if err != nil {
+				t.Fatalf("plan failed: %v", err)
+			}
+			if plan.Coverage != tc.expected {
+				t.Errorf("expected coverage %+v but got %+v", tc.expected, plan.Coverage)
+			}
+			if summary := plan.Coverage.Summary(); summary != tc.summary {
+				t.Errorf("expected summary %q but got %q", tc.summary, summary)
+			}
+
+			result, err := Install(context.Background(), plan, InstallOptions{})
+			if err != nil {
+				t.Fatalf("install failed: %v", err)
+			}
+			if result.Coverage != plan.Coverage {
+				t.Errorf("expected the report to carry the plan's coverage %+v but got %+v", plan.Coverage, result.Coverage)
+			}
+		})
+	}
+}
+
+func TestPlanCoveragePercent(t *testing.T) {
+	testCases := []struct {
+		coverage PlanCoverage
+		expected int
+	}{
+		{coverage: PlanCoverage{}, expected: 100},
+		{coverage: PlanCoverage{Entries: 10, Installable: 9}, expected: 90},
+		// 89.9% doesn't meet a 90% threshold
+		{coverage: PlanCoverage{Entries: 1000, Installable: 899}, expected: 89},
+		{coverage: PlanCoverage{Entries: 4, CrossPlatform: 4}, expected: 0},
+	}
+
+	for _, tc := range testCases {
+		if actual := tc.coverage.Percent(); actual != tc.expected {
+			t.Errorf("expected %+v to be %d%% but got %d%%", tc.coverage, tc.expected, actual)
+		}
+	}
+}
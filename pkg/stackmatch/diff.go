@@ -0,0 +1,27 @@
+package stackmatch
+
+import (
+	"github.com/MRQ67/stackmatch-cli/pkg/config"
+	"github.com/MRQ67/stackmatch-cli/pkg/diff"
+	"github.com/MRQ67/stackmatch-cli/pkg/scanner"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+// DiffResult lists the differences between two environments
+type DiffResult = diff.Result
+
+// Diff returns the changes needed to go from environment a to environment b.
+// Both are reconciled first, so a tool recorded as "Python 3" in one and
+// "Python" in the other is not reported as removed and added.
+func Diff(a, b types.EnvironmentData) DiffResult {
+	groups := aliasGroups()
+	a, b = types.Reconcile(a, groups), types.Reconcile(b, groups)
+	return *diff.Compare(&a, &b)
+}
+
+// aliasGroups returns the alias groups of the user's detectors file. An
+// invalid file falls back to the defaults; scans report it.
+func aliasGroups() []types.AliasGroup {
+	detectors, _ := scanner.LoadDetectorConfig(config.DetectorsFile())
+	return detectors.AliasGroups()
+}
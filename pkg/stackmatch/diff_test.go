@@ -0,0 +1,123 @@
+package stackmatch
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/diff"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+// loadFixture reads an environment from a directory of testdata
+func loadFixture(t *testing.T, dir, name string) types.EnvironmentData {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", dir, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var env types.EnvironmentData
+	if err := json.Unmarshal(data, &env); err != nil {
+		t.Fatal(err)
+	}
+	return env
+}
+
+// The fixture pairs are scans of one machine months apart, taken by releases
+// that named Python, pip and Node.js differently
+func TestDiffReconcilesAliases(t *testing.T) {
+	// Only the default alias groups apply
+	t.Setenv("HOME", t.TempDir())
+
+	testCases := []struct {
+		name     string
+		before   string
+		after    string
+		noisy    int
+		expected []diff.Change
+	}{
+		{
+			name:   "Ubuntu",
+			before: "ubuntu-before.json",
+			after:  "ubuntu-after.json",
+			noisy:  6,
+			expected: []diff.Change{
+				{Category: types.CategoryTools, Name: "Git", Kind: diff.Changed, From: "2.34.1", To: "2.43.0"},
+			},
+		},
+		{
+			name:   "macOS",
+			before: "macos-before.json",
+			after:  "macos-after.json",
+			noisy:  6,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			before, after := loadFixture(t, "aliases", tc.before), loadFixture(t, "aliases", tc.after)
+
+			if raw := diff.Compare(&before, &after); len(raw.Changes) != tc.noisy {
+				t.Errorf("expected the fixtures to differ in %d raw changes but got %+v", tc.noisy, raw.Changes)
+			}
+			var changes []diff.Change
+			for _, c := range Diff(before, after).Changes {
+				if c.Category != types.CategorySystem {
+					changes = append(changes, c)
+				}
+			}
+			if !reflect.DeepEqual(changes, tc.expected) {
+				t.Errorf("expected changes %+v but got %+v", tc.expected, changes)
+			}
+
+			// Each scan satisfies the other after reconciliation, apart from
+			// the real upgrade
+			result := Check(after, before)
+			if failed := len(result.Items) - countOK(result); failed != len(tc.expected) {
+				t.Errorf("expected %d failing check items but got %+v", len(tc.expected), result.Items)
+			}
+		})
+	}
+}
+
+// countOK counts the satisfied items of a check
+func countOK(result CheckResult) int {
+	ok := 0
+	for _, item := range result.Items {
+		if item.Status == diff.StatusOK {
+			ok++
+		}
+	}
+	return ok
+}
+
+// The compose plugin and the legacy docker-compose binary satisfy the same
+// requirement, but scans record them separately
+func TestDiffDockerCompose(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	legacy := types.EnvironmentData{Tools: map[string]string{"Docker": "24.0.7", "Docker Compose": "1.29.2"}}
+	plugin := types.EnvironmentData{
+		Tools:   map[string]string{"Docker": "24.0.7", "Docker Compose Plugin": "2.24.5"},
+		ToolIDs: map[string]string{"Docker Compose Plugin": "docker-compose-plugin"},
+	}
+
+	expected := []diff.Change{
+		{Category: types.CategoryTools, Name: "Docker Compose", Kind: diff.Changed, From: "1.29.2", To: "2.24.5"},
+	}
+	if changes := Diff(legacy, plugin).Changes; !reflect.DeepEqual(changes, expected) {
+		t.Errorf("expected changes %+v but got %+v", expected, changes)
+	}
+
+	wanted := types.EnvironmentData{Tools: map[string]string{"Docker Compose": ">=1.29"}}
+	if result := Check(plugin, wanted); countOK(result) != 1 {
+		t.Errorf("expected the compose plugin to satisfy docker-compose but got %+v", result.Items)
+	}
+
+	both := types.EnvironmentData{Tools: map[string]string{"Docker Compose": "1.29.2", "Docker Compose Plugin": "2.24.5"}}
+	if groups := scanAliasGroups(aliasGroups()); !reflect.DeepEqual(types.Reconcile(both, groups).Tools, both.Tools) {
+		t.Errorf("expected scans to keep both compose entries but got %v", types.Reconcile(both, groups).Tools)
+	}
+}
@@ -0,0 +1,21 @@
+//go:build !windows
+
+package stackmatch
+
+import "golang.org/x/sys/unix"
+
+// defaultInstallVolume is where packages go when the package manager
+// doesn't say
+func defaultInstallVolume() string {
+	return "/"
+}
+
+// volumeFreeSpace returns the bytes available to unprivileged users on the
+// file system holding path
+func volumeFreeSpace(path string) (uint64, error) {
+	var stat unix.Statfs_t
+	if err := unix.Statfs(path, &stat); err != nil {
+		return 0, err
+	}
+	return uint64(stat.Bavail) * uint64(stat.Bsize), nil
+}
@@ -0,0 +1,32 @@
+//go:build windows
+
+package stackmatch
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// defaultInstallVolume is where packages go when the package manager
+// doesn't say
+func defaultInstallVolume() string {
+	if drive := os.Getenv("SystemDrive"); drive != "" {
+		return drive + `\`
+	}
+	return `C:\`
+}
+
+// volumeFreeSpace returns the bytes available to the current user on the
+// volume holding path, which may be limited by quotas
+func volumeFreeSpace(path string) (uint64, error) {
+	name, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return 0, err
+	}
+	var available uint64
+	if err := windows.GetDiskFreeSpaceEx(name, &available, nil, nil); err != nil {
+		return 0, err
+	}
+	return available, nil
+}
@@ -0,0 +1,57 @@
+package stackmatch
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+// DryRun asks the plan's package manager what installing the items of plan
+// would do, without installing anything, and records the answer in each
+// item's Change and the packages they pull in in plan.Dependencies. Items
+// marked for reinstallation are reinstalled whatever the answer. Package
+// managers that can't tell (those that are not a types.DryRunner), and
+// items their answer doesn't mention, get the Change Install makes of the
+// plan, marked Estimated. An error means the package manager failed to
+// answer, which leaves every item estimated.
+func DryRun(ctx context.Context, plan *InstallPlan) error {
+	var packages []types.PackageInfo
+	for _, item := range plan.Items {
+		if !item.Reinstall {
+			packages = append(packages, types.PackageInfo{Name: item.Package, Version: item.PackageVersion})
+		}
+	}
+
+	var changes []types.PackageChange
+	var err error
+	if dryRunner, ok := plan.Manager.(types.DryRunner); ok && len(packages) > 0 {
+		if changes, err = dryRunner.DryRunInstall(ctx, packages); err != nil {
+			changes = nil
+			err = fmt.Errorf("%s could not tell what the install would do: %w", plan.Manager.Name(), err)
+		}
+	}
+
+	reported := make(map[string]types.PackageChange)
+	plan.Dependencies = nil
+	for _, change := range changes {
+		if change.Dependency {
+			plan.Dependencies = append(plan.Dependencies, change)
+		} else {
+			reported[change.Package] = change
+		}
+	}
+	for i := range plan.Items {
+		item := &plan.Items[i]
+		change, ok := reported[item.Package]
+		switch {
+		case item.Reinstall:
+			change, ok = types.PackageChange{Package: item.Package, Action: types.ActionReinstall, To: item.PackageVersion}, true
+		case !ok:
+			change = types.PackageChange{Package: item.Package, Action: types.ActionInstall, To: item.PackageVersion}
+		}
+		item.Change = &change
+		item.Estimated = !ok
+	}
+	return err
+}
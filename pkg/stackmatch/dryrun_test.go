@@ -0,0 +1,102 @@
+package stackmatch
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+// fakeDryRunner is a fakeManager with a canned dry run
+type fakeDryRunner struct {
+	fakeManager
+	changes []types.PackageChange
+	err     error
+	asked   []types.PackageInfo
+}
+
+func (m *fakeDryRunner) DryRunInstall(ctx context.Context, packages []types.PackageInfo) ([]types.PackageChange, error) {
+	m.asked = packages
+	return m.changes, m.err
+}
+
+func TestDryRun(t *testing.T) {
+	items := []PlanItem{
+		{Name: "Git", Package: "git"},
+		{Name: "Node.js", Package: "nodejs", PackageVersion: "20.*"},
+		{Name: "Python", Package: "python3", Reinstall: true},
+		{Name: "Make", Package: "make"},
+	}
+	reported := []types.PackageChange{
+		{Package: "git", Action: types.ActionInstall, To: "1:2.43.0-1"},
+		{Package: "git-man", Action: types.ActionInstall, To: "1:2.43.0-1", Dependency: true},
+		{Package: "nodejs", Action: types.ActionUpgrade, From: "18.19.0-1", To: "20.11.1-1"},
+	}
+	estimated := []types.PackageChange{
+		{Package: "git", Action: types.ActionInstall},
+		{Package: "nodejs", Action: types.ActionInstall, To: "20.*"},
+		{Package: "python3", Action: types.ActionReinstall},
+		{Package: "make", Action: types.ActionInstall},
+	}
+
+	testCases := []struct {
+		name         string
+		manager      types.Installer
+		changes      []types.PackageChange
+		estimated    []bool
+		dependencies []types.PackageChange
+		expectErr    bool
+	}{
+		{
+			name:         "Reported",
+			manager:      &fakeDryRunner{changes: reported},
+			changes:      []types.PackageChange{reported[0], reported[2], estimated[2], estimated[3]},
+			estimated:    []bool{false, false, false, true},
+			dependencies: []types.PackageChange{reported[1]},
+		},
+		{
+			name:      "No dry run",
+			manager:   &fakeManager{pmType: types.TypeScoop},
+			changes:   estimated,
+			estimated: []bool{true, true, false, true},
+		},
+		{
+			name:      "Dry run fails",
+			manager:   &fakeDryRunner{err: errors.New("exit status 100")},
+			changes:   estimated,
+			estimated: []bool{true, true, false, true},
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			plan := &InstallPlan{Manager: tc.manager, Items: append([]PlanItem(nil), items...)}
+			err := DryRun(context.Background(), plan)
+			if (err != nil) != tc.expectErr {
+				t.Fatalf("expected an error %v but got %v", tc.expectErr, err)
+			}
+			for i, item := range plan.Items {
+				if item.Change == nil || !reflect.DeepEqual(*item.Change, tc.changes[i]) {
+					t.Errorf("expected %s to change as %+v but got %+v", item.Name, tc.changes[i], item.Change)
+				}
+				if item.Estimated != tc.estimated[i] {
+					t.Errorf("expected %s to be estimated %v but got %v", item.Name, tc.estimated[i], item.Estimated)
+				}
+			}
+			if !reflect.DeepEqual(plan.Dependencies, tc.dependencies) {
+				t.Errorf("expected the dependencies %+v but got %+v", tc.dependencies, plan.Dependencies)
+			}
+		})
+	}
+
+	// Broken packages are reinstalled, so they are not asked about
+	manager := &fakeDryRunner{changes: reported}
+	DryRun(context.Background(), &InstallPlan{Manager: manager, Items: append([]PlanItem(nil), items...)})
+	expected := []types.PackageInfo{{Name: "git"}, {Name: "nodejs", Version: "20.*"}, {Name: "make"}}
+	if !reflect.DeepEqual(manager.asked, expected) {
+		t.Errorf("expected %+v to be asked about but got %+v", expected, manager.asked)
+	}
+}
@@ -0,0 +1,39 @@
+package stackmatch_test
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/stackmatch"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+func ExampleScan() {
+	env, err := stackmatch.Scan(context.Background(), stackmatch.ScanOptions{
+		Progress: stackmatch.ProgressFunc(func(msg string) {
+			// Render progress however the embedding program likes
+		}),
+	})
+	if err != nil {
+		return
+	}
+	fmt.Println(env.System.OS != "")
+}
+
+func ExampleDiff() {
+	before := types.EnvironmentData{
+		Tools: map[string]string{"Git": "2.39.0", "Make": "4.3"},
+	}
+	after := types.EnvironmentData{
+		Tools: map[string]string{"Git": "2.40.1", "Terraform": "1.6.0"},
+	}
+
+	result := stackmatch.Diff(before, after)
+	for _, c := range result.Changes {
+		fmt.Printf("%s %s/%s\n", c.Kind, c.Category, c.Name)
+	}
+	// Output:
+	// changed tools/Git
+	// removed tools/Make
+	// added tools/Terraform
+}
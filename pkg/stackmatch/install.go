@@ -0,0 +1,1138 @@
+package stackmatch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/audit"
+	"github.com/MRQ67/stackmatch-cli/pkg/gitconfig"
+	"github.com/MRQ67/stackmatch-cli/pkg/installer"
+	"github.com/MRQ67/stackmatch-cli/pkg/langconfig"
+	"github.com/MRQ67/stackmatch-cli/pkg/redact"
+	"github.com/MRQ67/stackmatch-cli/pkg/shellinit"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+// PlanOptions controls how an installation plan is built
+type PlanOptions struct {
+	// Manager overrides package manager detection when set
+	Manager types.Installer
+	// VersionManager overrides version manager detection when set. Languages
+	// are installed through it when it supports them.
+	VersionManager types.VersionManager
+	// Installed is this machine's environment, typically from Scan. Entries
+	// that are broken in it are marked for reinstallation, and language
+	// settings it already has get no manual step. May be nil.
+	Installed *types.EnvironmentData
+	// GlobalInstallers overrides the installers of global packages, keyed by
+	// package manager such as "npm", when set
+	GlobalInstallers map[string]types.GlobalPackageInstaller
+	// PythonManagers overrides the version managers installing Python the
+	// way the environment did, keyed by manager such as "pyenv", when set
+	PythonManagers map[string]types.VersionManager
+	// DnfModules overrides the version manager installing the languages the
+	// environment got from a DNF module stream, when set
+	DnfModules types.VersionManager
+	// Shell is this machine's shell, as in SystemInfo.Shell. The version
+	// managers of the environment and of the plan get a manual step with
+	// the lines that load them in it.
+	Shell string
+	// ConflictRules are the user's rules for packages that can't be
+	// installed together, checked before the built-in ones
+	ConflictRules []installer.ConflictRule
+	// ResolveConflict picks the package to keep of a conflict whose rule
+	// prefers none of them, such as by asking the user, and returns one of
+	// its packages, or "" to keep them all. When nil, such conflicts are
+	// left unresolved.
+	ResolveConflict func(conflict installer.Conflict) (string, error)
+	// Scope is where packages are installed, such as types.ScopeUser for
+	// the user's own prefix on a shared machine. When empty, each package
+	// manager installs where it does by default.
+	Scope types.InstallScope
+}
+
+// PlanItem is a single package the plan will install
+type PlanItem struct {
+	// Name is the entry as recorded in the environment (e.g. "Git")
+	Name string `json:"name"`
+	// ID is the entry's canonical tool ID (e.g. "git")
+	ID string `json:"id,omitempty"`
+	// Category is the environment category the entry came from
+	Category string `json:"category"`
+	// Version is the version recorded in the environment, if any
+	Version string `json:"version,omitempty"`
+	// Package is the package name passed to the package manager
+	Package string `json:"package"`
+	// PackageVersion is the version Package is installed at, in the
+	// package manager's format (such as "18.*" for apt), when the mapping
+	// of the entry gives one
+	PackageVersion string `json:"package_version,omitempty"`
+	// Reinstall is set when the entry is installed but broken, so it is
+	// reinstalled even when its version already matches
+	Reinstall bool `json:"reinstall,omitempty"`
+	// Manager is the language package manager that installs a global
+	// package, such as "npm", or the version manager that installs a
+	// runtime instead of the plan's, such as "pyenv"
+	Manager string `json:"manager,omitempty"`
+	// Change is what installing the item would do, once DryRun has asked
+	// the package manager
+	Change *types.PackageChange `json:"change,omitempty"`
+	// Estimated is set by DryRun when the package manager couldn't tell
+	// what installing the item would do, so Change assumes it is installed
+	Estimated bool `json:"estimated,omitempty"`
+	// Scope is where the item is installed: system-wide, or into the
+	// user's prefix
+	Scope types.InstallScope `json:"scope,omitempty"`
+}
+
+// InstallPlan describes what Install will do for an environment
+type InstallPlan struct {
+	// Manager is the package manager the plan targets
+	Manager types.Installer `json:"-"`
+	// VersionManager installs Runtimes. Nil when none is available.
+	VersionManager types.VersionManager `json:"-"`
+	// Items are the packages to install, in installation order
+	Items []PlanItem `json:"items"`
+	// Runtimes are the languages to install through the version manager
+	Runtimes []PlanItem `json:"runtimes,omitempty"`
+	// RuntimeManagers install the Runtimes that name their Manager, keyed
+	// by name
+	RuntimeManagers map[string]types.VersionManager `json:"-"`
+	// GlobalPackages are the packages to install through their language's
+	// package manager, after the runtimes
+	GlobalPackages []PlanItem `json:"global_packages,omitempty"`
+	// GlobalInstallers install GlobalPackages, keyed by package manager
+	GlobalInstallers map[string]types.GlobalPackageInstaller `json:"-"`
+	// ManualSteps are actions the plan cannot automate, such as entries with
+	// no package for this manager or config files that must be copied by hand
+	ManualSteps []types.ManualStep `json:"manual_steps,omitempty"`
+	// Unsupported are entries in categories this release cannot install,
+	// such as those added by newer releases or custom detectors
+	Unsupported []PlanItem `json:"unsupported,omitempty"`
+	// ShellInits are the lines the version managers need in the shell's rc
+	// file, each also given as a manual step (see ShellInitStep)
+	ShellInits []shellinit.Init `json:"shell_init,omitempty"`
+	// EnableCorepack is set when the environment had corepack enabled and
+	// this machine, as far as the installed scan tells, doesn't. It is also
+	// given as a manual step (see CorepackStep).
+	EnableCorepack bool `json:"enable_corepack,omitempty"`
+	// Coverage counts how much of the environment the plan installs
+	Coverage PlanCoverage `json:"coverage"`
+	// Dependencies are the packages installing the items pulls in, as
+	// reported by the package manager to DryRun
+	Dependencies []types.PackageChange `json:"dependencies,omitempty"`
+	// Conflicts are the packages of the environment that can't be
+	// installed together, and which of each the plan kept
+	Conflicts []installer.ConflictDecision `json:"conflicts,omitempty"`
+}
+
+// Reinstalls returns the items and runtimes marked for reinstallation
+func (p *InstallPlan) Reinstalls() []PlanItem {
+	var items []PlanItem
+	for _, item := range append(slices.Clone(p.Items), p.Runtimes...) {
+		if item.Reinstall {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// DeclineReinstalls unmarks every item for reinstallation, leaving broken
+// tools as they are
+func (p *InstallPlan) DeclineReinstalls() {
+	for i := range p.Items {
+		p.Items[i].Reinstall = false
+	}
+	for i := range p.Runtimes {
+		p.Runtimes[i].Reinstall = false
+	}
+}
+
+// Packages returns the package names of every item in the plan
+func (p *InstallPlan) Packages() []string {
+	packages := make([]string, 0, len(p.Items))
+	for _, item := range p.Items {
+		packages = append(packages, item.Package)
+	}
+	return packages
+}
+
+// InstallOptions controls how a plan is executed
+type InstallOptions struct {
+	// Progress is notified before installation starts. May be nil.
+	Progress Progress
+	// Pin holds every package installed for an entry with a recorded
+	// version at that version once installation succeeds, so system
+	// upgrades leave it alone
+	Pin bool
+	// Verify checks every package of the plan against its version once
+	// everything is installed, asking the package manager about them all at
+	// once when it can
+	Verify bool
+	// FailFast installs the plan's packages one at a time and stops at the
+	// first one that fails to install or, with Verify, to verify right
+	// after it is installed
+	FailFast bool
+}
+
+// InstallResult summarizes an installation
+type InstallResult struct {
+	Packages []string      `json:"packages"`
+	Duration time.Duration `json:"duration"`
+	// ManualSteps holds the plan's steps followed by any reported by the
+	// package manager while installing
+	ManualSteps []types.ManualStep `json:"manual_steps,omitempty"`
+	// Pinned lists the packages held at their installed version
+	Pinned []string `json:"pinned,omitempty"`
+	// Warnings describes packages that could not be pinned
+	Warnings []string `json:"warnings,omitempty"`
+	// Verification holds the outcome of checking each package of the plan
+	// once installed; empty when installation was not verified
+	Verification []installer.PackageVerification `json:"verification,omitempty"`
+	// Coverage is the plan's, for reports that don't keep the plan
+	Coverage PlanCoverage `json:"coverage"`
+}
+
+// postInstallSteps are follow-up actions needed after installing a package on
+// some package managers, keyed by canonical tool ID
+var postInstallSteps = map[string]struct {
+	managers []types.PackageManagerType
+	step     types.ManualStep
+}{
+	"docker": {
+		managers: []types.PackageManagerType{types.TypeApt, types.TypeDnf, types.TypeYum, types.TypePacman, types.TypeApk},
+		step: types.ManualStep{
+			Category:    types.CategoryTools,
+			Description: "Add your user to the docker group and log in again to run docker without sudo",
+			DocURL:      "https://docs.docker.com/engine/install/linux-postinstall/",
+		},
+	},
+}
+
+// Plan builds the list of packages needed to reproduce env on this machine
+func Plan(ctx context.Context, env types.EnvironmentData, opts PlanOptions) (*InstallPlan, error) {
+	manager := opts.Manager
+	if manager == nil {
+		var err error
+		manager, err = installer.DetectPackageManagerContext(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("could not detect a supported package manager: %w", err)
+		}
+	}
+
+	versionManager := opts.VersionManager
+	if versionManager == nil {
+		versionManager = installer.DetectVersionManager()
+	}
+
+	// A manager that can't install into the scope only fails the plan when
+	// it has packages to install
+	managerScope, scopeErr := applyScope(manager, manager.Name(), opts.Scope)
+
+	plan := &InstallPlan{Manager: manager, VersionManager: versionManager, Items: []PlanItem{}}
+	seen := make(map[string]bool)
+	// Entries sharing a package are all installed by it, so each is
+	// counted, and all are taken back out if a conflict drops the package
+	entries := make(map[string]packageEntries)
+	countEntry := func(pkg string, unverifiable bool) {
+		counts := entries[pkg]
+		counts.installable++
+		plan.Coverage.Installable++
+		if unverifiable {
+			counts.unverifiable++
+			plan.Coverage.Unverifiable++
+		}
+		entries[pkg] = counts
+	}
+
+	// Languages go through a version manager rather than the system package
+	// manager, so projects get the exact runtime they pin. Without one, the
+	// package manager installs those whose mapping says how to install a
+	// given version, such as node@18 on Homebrew.
+	for _, name := range sortedKeys(env.ConfiguredLanguages) {
+		version := env.ConfiguredLanguages[name]
+		vm, scope := pythonManager(env, name, opts), types.ScopeUser
+		if vm == nil {
+			vm, scope = dnfModuleManager(env, name, opts), types.ScopeSystem
+		}
+		if vm != nil {
+			// A DNF module has one stream enabled at a time
+			if scope == types.ScopeUser {
+				plan.Runtimes = append(plan.Runtimes, sideBySideRuntimes(env, name, version, vm.Name())...)
+			}
+			plan.Runtimes = append(plan.Runtimes, PlanItem{
+				Name:      name,
+				ID:        env.ToolID(name),
+				Category:  types.CategoryLanguages,
+				Version:   version,
+				Package:   name,
+				Reinstall: isBroken(opts.Installed, name),
+				Manager:   vm.Name(),
+				Scope:     scope,
+			})
+			if plan.RuntimeManagers == nil {
+				plan.RuntimeManagers = make(map[string]types.VersionManager)
+			}
+			plan.RuntimeManagers[vm.Name()] = vm
+			plan.Coverage.Installable++
+			continue
+		}
+		if name == "Python" && env.Python != nil && env.Python.Manager() == types.PythonManagerConda {
+			plan.ManualSteps = append(plan.ManualSteps, condaStep(env.Python.Conda.Active, version))
+		}
+		if versionManager != nil && versionManager.Supports(name) {
+			plan.Runtimes = append(plan.Runtimes, sideBySideRuntimes(env, name, version, "")...)
+			plan.Runtimes = append(plan.Runtimes, PlanItem{
+				Name:      name,
+				ID:        env.ToolID(name),
+				Category:  types.CategoryLanguages,
+				Version:   version,
+				Package:   name,
+				Reinstall: isBroken(opts.Installed, name),
+				Scope:     types.ScopeUser,
+			})
+			plan.Coverage.Installable++
+			continue
+		}
+		if others := sideBySideRuntimes(env, name, version, ""); len(others) > 0 {
+			versions := make([]string, len(others))
+			for i, item := range others {
+				versions[i] = item.Version
+			}
+			plan.ManualSteps = append(plan.ManualSteps, types.ManualStep{
+				Category:    types.CategoryLanguages,
+				Description: fmt.Sprintf("Install %s %s, found installed side by side, with a version manager such as mise", name, strings.Join(versions, " and ")),
+			})
+		}
+		if id := env.ToolID(name); installer.MapsVersions(id, manager.Type()) {
+			pkg, pkgVersion, err := installer.ResolvePackage(id, manager.Type(), version)
+			var unresolvable *installer.VersionResolutionError
+			if errors.As(err, &unresolvable) {
+				// Installing some other version would not reproduce the
+				// environment, so it is left to the user
+				plan.ManualSteps = append(plan.ManualSteps, types.ManualStep{
+					Category:    types.CategoryLanguages,
+					Description: fmt.Sprintf("Install %s manually; %v", withVersion(name, version), err),
+				})
+				plan.Coverage.Manual++
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			countEntry(pkg, recordedVersion(version) && pkgVersion.Version == "")
+			if !seen[pkg] {
+				seen[pkg] = true
+				plan.Items = append(plan.Items, PlanItem{
+					Name:           name,
+					ID:             id,
+					Category:       types.CategoryLanguages,
+					Version:        version,
+					Package:        pkg,
+					PackageVersion: pkgVersion.Version,
+					Reinstall:      isBroken(opts.Installed, name),
+					Scope:          managerScope,
+				})
+			}
+			continue
+		}
+		plan.ManualSteps = append(plan.ManualSteps, types.ManualStep{
+			Category:    types.CategoryLanguages,
+			Description: "Install " + withVersion(name, version),
+		})
+		plan.Coverage.Manual++
+	}
+
+	categories := []struct {
+		name    string
+		entries map[string]string
+	}{
+		{types.CategoryTools, env.Tools},
+		{types.CategoryPackageManagers, env.PackageManagers},
+		{types.CategoryEditors, env.CodeEditors},
+	}
+
+	for _, category := range categories {
+		for _, name := range sortedKeys(category.entries) {
+			// Resolve by canonical ID; display names like "VS Code" are not
+			// package names
+			id := env.ToolID(name)
+			pkg, pkgVersion, err := installer.ResolvePackage(id, manager.Type(), category.entries[name])
+			var unresolvable *installer.VersionResolutionError
+			if errors.As(err, &unresolvable) {
+				// A constraint the manager can't express installs the
+				// unversioned package, whose version is then unverifiable
+				pkg, err = installer.GetPackageName(id, manager.Type())
+				pkgVersion = installer.VersionConstraint{}
+			}
+			if err != nil {
+				// Known package that this manager does not ship, typically
+				// an environment captured on another platform
+				plan.ManualSteps = append(plan.ManualSteps, types.ManualStep{
+					Category:    category.name,
+					Description: fmt.Sprintf("Install %s manually; %s has no package for it", name, manager.Name()),
+				})
+				plan.Coverage.CrossPlatform++
+				continue
+			}
+			if pkg == "" {
+				pkg = id
+			}
+			countEntry(pkg, recordedVersion(category.entries[name]) && (!installer.MapsVersions(id, manager.Type()) || pkgVersion.Version == ""))
+			if seen[pkg] {
+				continue
+			}
+			seen[pkg] = true
+
+			if post, ok := postInstallSteps[id]; ok && slices.Contains(post.managers, manager.Type()) {
+				plan.ManualSteps = append(plan.ManualSteps, post.step)
+			}
+
+			item := PlanItem{
+				Name:      name,
+				ID:        id,
+				Category:  category.name,
+				Version:   category.entries[name],
+				Package:   pkg,
+				Reinstall: isBroken(opts.Installed, name),
+				Scope:     managerScope,
+			}
+			if installer.MapsVersions(id, manager.Type()) {
+				item.PackageVersion = pkgVersion.Version
+			}
+			plan.Items = append(plan.Items, item)
+		}
+	}
+
+	if scopeErr != nil && len(plan.Items) > 0 {
+		return nil, fmt.Errorf("%w, and the plan installs %d packages with it: %s", scopeErr, len(plan.Items), strings.Join(plan.Packages(), ", "))
+	}
+
+	if err := resolveConflicts(plan, opts, entries); err != nil {
+		return nil, err
+	}
+
+	// Global packages go through their language's package manager, which
+	// this plan may be what installs, so whether it is available is only
+	// checked when installing
+	for _, manager := range sortedKeys(env.GlobalPackages) {
+		global := opts.GlobalInstallers[manager]
+		if global == nil {
+			global = installer.GlobalPackageInstaller(manager)
+		}
+		packages := env.GlobalPackages[manager]
+		var globalScope types.InstallScope
+		if global != nil {
+			var err error
+			if globalScope, err = applyScope(global, manager, opts.Scope); err != nil {
+				for _, name := range sortedKeys(packages) {
+					plan.ManualSteps = append(plan.ManualSteps, types.ManualStep{
+						Category:    types.CategoryGlobalPackages,
+						Description: fmt.Sprintf("Install the %s package %s manually; %v", manager, withVersion(name, packages[name]), err),
+					})
+					plan.Coverage.Manual++
+				}
+				continue
+			}
+		}
+		for _, name := range sortedKeys(packages) {
+			if global == nil {
+				plan.ManualSteps = append(plan.ManualSteps, types.ManualStep{
+					Category:    types.CategoryGlobalPackages,
+					Description: fmt.Sprintf("Install the %s package %s manually; this release can't install %s packages", manager, withVersion(name, packages[name]), manager),
+				})
+				plan.Coverage.Unsupported++
+				continue
+			}
+			plan.Coverage.Installable++
+			plan.GlobalPackages = append(plan.GlobalPackages, PlanItem{
+				Name:     name,
+				Category: types.CategoryGlobalPackages,
+				Version:  packages[name],
+				Package:  globalPackage(name, packages[name]),
+				Manager:  manager,
+				Scope:    globalScope,
+			})
+		}
+		if global != nil {
+			if plan.GlobalInstallers == nil {
+				plan.GlobalInstallers = make(map[string]types.GlobalPackageInstaller)
+			}
+			plan.GlobalInstallers[manager] = global
+		}
+	}
+
+	for _, file := range env.ConfigFiles {
+		description := fmt.Sprintf("Copy %s from the source machine", file)
+		// Copying over a managed file would be undone by its manager
+		if info, ok := env.ConfigFile(file); ok && info.ManagedBy != "" {
+			description = fmt.Sprintf("Apply %s with %s, which manages it on the source machine, rather than copying it", file, info.ManagedBy)
+		}
+		plan.ManualSteps = append(plan.ManualSteps, types.ManualStep{
+			Category:    types.CategoryConfigFiles,
+			Description: description,
+		})
+	}
+
+	for _, job := range env.ScheduledJobs {
+		plan.ManualSteps = append(plan.ManualSteps, ScheduledJobStep(job))
+	}
+
+	for _, service := range env.Services {
+		plan.ManualSteps = append(plan.ManualSteps, ServiceStep(service))
+	}
+
+	if env.Node != nil && env.Node.Corepack.Enabled() && !(opts.Installed != nil && opts.Installed.Node != nil && opts.Installed.Node.Corepack.Enabled()) {
+		plan.EnableCorepack = true
+		plan.ManualSteps = append(plan.ManualSteps, CorepackStep())
+	}
+
+	plan.ShellInits = shellInits(env, plan, opts.Shell)
+	for _, init := range plan.ShellInits {
+		plan.ManualSteps = append(plan.ManualSteps, ShellInitStep(init))
+	}
+
+	plan.ManualSteps = append(plan.ManualSteps, gitConfigSteps(env.GitConfig)...)
+	plan.ManualSteps = append(plan.ManualSteps, gitSettingSteps(env.GitConfig, opts.Installed)...)
+	plan.ManualSteps = append(plan.ManualSteps, languageConfigSteps(env.LanguageConfig, opts.Installed)...)
+
+	for _, category := range types.ExtensionCategories(&env) {
+		for _, name := range sortedKeys(env.Extensions[category]) {
+			plan.Unsupported = append(plan.Unsupported, PlanItem{
+				Name:     name,
+				Category: category,
+				Version:  env.Extensions[category][name],
+			})
+			plan.Coverage.Unsupported++
+		}
+	}
+
+	plan.Coverage.Entries = plan.Coverage.Installable + plan.Coverage.CrossPlatform + plan.Coverage.Manual + plan.Coverage.Unsupported
+	return plan, nil
+}
+
+// applyScope makes installer, the package manager called name, install
+// into scope unless scope is empty, and returns the scope it installs
+// into. Installers that aren't a types.Scoper only install system-wide.
+func applyScope(installer any, name string, scope types.InstallScope) (types.InstallScope, error) {
+	scoper, ok := installer.(types.Scoper)
+	if !ok {
+		if scope != "" && scope != types.ScopeSystem {
+			return "", &types.UnsupportedScopeError{Manager: name, Scope: scope}
+		}
+		return types.ScopeSystem, nil
+	}
+	if scope != "" {
+		if err := scoper.SetScope(scope); err != nil {
+			return "", err
+		}
+	}
+	return scoper.Scope(), nil
+}
+
+// packageEntries counts the entries of the environment a package of a plan
+// installs
+type packageEntries struct {
+	installable int
+	// unverifiable counts those with a recorded version the package
+	// manager can't be asked for
+	unverifiable int
+}
+
+// resolveConflicts finds the packages of the plan's items that can't be
+// installed together and keeps one of each conflict: the one its rule
+// prefers, or the one opts.ResolveConflict picks. The others are left out of
+// the plan with a manual step each, and the entries they install, counted
+// in entries, move from installable to manual in the coverage. A conflict
+// opts.ResolveConflict fails to pick for is a *types.UnresolvedConflictError.
+func resolveConflicts(plan *InstallPlan, opts PlanOptions, entries map[string]packageEntries) error {
+	for _, conflict := range installer.FindConflicts(opts.ConflictRules, plan.Manager.Type(), plan.Packages()) {
+		decision := installer.ConflictDecision{Packages: conflict.Packages, Reason: conflict.Rule.Reason, Decision: installer.DecisionUnresolved}
+		if kept := conflict.Preferred(); kept != "" {
+			decision.Kept, decision.Decision = kept, installer.DecisionPreferred
+		} else if opts.ResolveConflict != nil {
+			kept, err := opts.ResolveConflict(conflict)
+			if err != nil {
+				return &types.UnresolvedConflictError{Packages: conflict.Packages, Err: err}
+			}
+			if kept != "" && !slices.Contains(conflict.Packages, kept) {
+				return fmt.Errorf("%s is not one of the conflicting packages %s", kept, strings.Join(conflict.Packages, ", "))
+			}
+			if kept != "" {
+				decision.Kept, decision.Decision = kept, installer.DecisionChosen
+			}
+		}
+		plan.Conflicts = append(plan.Conflicts, decision)
+
+		for _, pkg := range decision.Dropped() {
+			i := slices.IndexFunc(plan.Items, func(item PlanItem) bool { return item.Package == pkg })
+			item := plan.Items[i]
+			plan.Items = slices.Delete(plan.Items, i, i+1)
+			plan.ManualSteps = append(plan.ManualSteps, ConflictStep(item, decision.Kept))
+			counts := entries[pkg]
+			plan.Coverage.Installable -= counts.installable
+			plan.Coverage.Unverifiable -= counts.unverifiable
+			plan.Coverage.Manual += counts.installable
+		}
+	}
+	return nil
+}
+
+// ConflictStep is the manual step for item, left out of the plan because
+// its package conflicts with kept
+func ConflictStep(item PlanItem, kept string) types.ManualStep {
+	return types.ManualStep{
+		Category:    item.Category,
+		Description: fmt.Sprintf("Install %s (%s) by hand if you need it; it conflicts with %s, which was installed instead", item.Name, item.Package, kept),
+	}
+}
+
+// ScheduledJobStep is the manual step that recreates job on this machine
+func ScheduledJobStep(job types.ScheduledJob) types.ManualStep {
+	description := "Add to your crontab: " + job.Line()
+	if job.Source == types.JobSourceSchtasks {
+		description = fmt.Sprintf("Create the scheduled task %s (%s) running: %s", job.Name, job.Schedule, job.Command)
+	}
+	if redact.Redacted(job.Command) {
+		description += " (fill in the redacted secrets)"
+	}
+	return types.ManualStep{Category: types.CategoryScheduledJobs, Description: description}
+}
+
+// shellInits returns the init of every version manager of env, and of those
+// plan installs runtimes with, in shell
+func shellInits(env types.EnvironmentData, plan *InstallPlan, shell string) []shellinit.Init {
+	names := make(map[string]bool)
+	for name := range env.VersionManagers {
+		names[name] = true
+	}
+	for _, item := range plan.Runtimes {
+		if item.Manager != "" {
+			names[item.Manager] = true
+		} else if plan.VersionManager != nil {
+			names[plan.VersionManager.Name()] = true
+		}
+	}
+	var inits []shellinit.Init
+	for _, name := range sortedKeys(names) {
+		if init, ok := shellinit.For(name, shell); ok {
+			inits = append(inits, init)
+		}
+	}
+	return inits
+}
+
+// ShellInitStep is the manual step that loads a version manager in new
+// shells
+func ShellInitStep(init shellinit.Init) types.ManualStep {
+	if init.Snippet == "" {
+		return types.ManualStep{
+			Category:    types.CategoryVersionManagers,
+			Description: fmt.Sprintf("Set up %s in %s with a plugin; it only supports bash and zsh", init.Manager, init.Shell),
+		}
+	}
+	return types.ManualStep{
+		Category:    types.CategoryVersionManagers,
+		Description: fmt.Sprintf("Add these lines to %s so new %s shells load %s", init.File, init.Shell, init.Manager),
+		Snippet:     init.Snippet,
+	}
+}
+
+// ServiceStep is the manual step that sets up service to start on its own on
+// this machine
+func ServiceStep(service types.Service) types.ManualStep {
+	return types.ManualStep{
+		Category:    types.CategoryServices,
+		Description: fmt.Sprintf("Enable the %s service (%s on the source machine through %s as %s)", service.Name, service.State, service.Manager, service.Unit),
+	}
+}
+
+// CorepackStep is the manual step that enables corepack, so yarn and pnpm
+// run the version each project's packageManager field asks for
+func CorepackStep() types.ManualStep {
+	return types.ManualStep{
+		Category:    types.CategoryLanguageConfig,
+		Description: "Enable corepack so yarn and pnpm follow each project's packageManager field: corepack enable",
+	}
+}
+
+// URLRewriteStep is the manual step that adds rule to the global git config
+func URLRewriteStep(rule types.URLRewrite) types.ManualStep {
+	description := fmt.Sprintf("Add the git URL rewrite: git config --global --add %s %s", rule.Key(), rule.InsteadOf)
+	if redact.Redacted(rule.Base) || redact.Redacted(rule.InsteadOf) {
+		description += " (fill in the redacted secrets)"
+	}
+	return types.ManualStep{Category: types.CategoryGitConfig, Description: description}
+}
+
+// statFile is os.Stat, replaced in tests
+var statFile = os.Stat
+
+// gitConfigSteps lists a step per URL rewrite of config, and one per
+// credential helper whose program is given by an absolute path missing on
+// this machine. Helpers git finds by name are left to the user.
+func gitConfigSteps(config *types.GitConfig) []types.ManualStep {
+	if config == nil {
+		return nil
+	}
+	var steps []types.ManualStep
+	for _, rule := range config.URLRewrites {
+		steps = append(steps, URLRewriteStep(rule))
+	}
+	for _, helper := range config.CredentialHelpers {
+		path := gitconfig.HelperPath(helper.Helper)
+		if path == "" {
+			continue
+		}
+		if _, err := statFile(path); err == nil {
+			continue
+		}
+		steps = append(steps, types.ManualStep{
+			Category:    types.CategoryGitConfig,
+			Description: fmt.Sprintf("Install the git credential helper %s, which %s uses but is missing here", path, helper.Key()),
+		})
+	}
+	return steps
+}
+
+// gitSettingStep is the manual step that sets a git setting on this
+// machine, noting the value it has here when one was scanned
+func gitSettingStep(change gitconfig.SettingChange, scanned bool) types.ManualStep {
+	description := fmt.Sprintf("Set git %s: %s", change.Key, gitconfig.SetCommand(change.Key, change.Value))
+	switch {
+	case redact.Redacted(change.Value):
+		description += " (fill in the redacted secrets)"
+	case !scanned:
+	case change.Current == "":
+		description += " (not set here)"
+	default:
+		description += fmt.Sprintf(" (here: %s)", change.Current)
+	}
+	return types.ManualStep{Category: types.CategoryGitConfig, Description: description}
+}
+
+// gitSettingSteps lists a step per setting of config that installed does not
+// have with the same value, or every setting when installed is nil
+func gitSettingSteps(config *types.GitConfig, installed *types.EnvironmentData) []types.ManualStep {
+	if config == nil {
+		return nil
+	}
+	var have map[string]string
+	if installed != nil && installed.GitConfig != nil {
+		have = installed.GitConfig.Settings
+	}
+	var steps []types.ManualStep
+	for _, change := range gitconfig.Compare(config.Settings, have) {
+		steps = append(steps, gitSettingStep(change, installed != nil))
+	}
+	return steps
+}
+
+// LanguageSettingStep is the manual step that writes setting on this machine
+func LanguageSettingStep(setting types.LanguageSetting) types.ManualStep {
+	description := fmt.Sprintf("Set %s: %s", setting.Name(), strings.Join(langconfig.Command(setting, ""), " "))
+	if redact.Redacted(setting.Value) {
+		description += " (fill in the redacted secrets)"
+	}
+	return types.ManualStep{Category: types.CategoryLanguageConfig, Description: description}
+}
+
+// languageConfigSteps lists a step per setting of config that installed does
+// not have, or every setting when installed is nil. Settings that move where
+// commands are installed get a second step to put that directory on PATH,
+// since shell init files are left to the user.
+func languageConfigSteps(config types.LanguageConfig, installed *types.EnvironmentData) []types.ManualStep {
+	var have types.LanguageConfig
+	if installed != nil {
+		have = installed.LanguageConfig
+	}
+	var steps []types.ManualStep
+	for _, change := range langconfig.Compare(config, have) {
+		steps = append(steps, LanguageSettingStep(change.LanguageSetting))
+		var dir string
+		switch change.Name() {
+		case "go.GOBIN":
+			dir = change.Value
+		case "go.GOPATH":
+			if _, ok := config.Get(langconfig.Go, "GOBIN"); !ok {
+				dir = change.Value + "/bin"
+			}
+		case "npm.prefix":
+			dir = "the bin directory of " + change.Value
+		}
+		if dir != "" {
+			steps = append(steps, types.ManualStep{
+				Category:    types.CategoryLanguageConfig,
+				Description: fmt.Sprintf("Add %s to PATH in your shell init file", dir),
+			})
+		}
+	}
+	return steps
+}
+
+// isBroken reports whether the entry called name is broken in installed
+func isBroken(installed *types.EnvironmentData, name string) bool {
+	return installed != nil && installed.Tool(name).Broken
+}
+
+// pythonManager returns the version manager that installs the language name
+// the way env did, when it is Python from a manager available here, such as
+// pyenv. It is preferred over the plan's version manager.
+func pythonManager(env types.EnvironmentData, name string, opts PlanOptions) types.VersionManager {
+	if name != "Python" || env.Python == nil {
+		return nil
+	}
+	manager := env.Python.Manager()
+	vm, ok := opts.PythonManagers[manager]
+	if !ok {
+		vm = installer.PythonVersionManager(manager)
+	}
+	if vm == nil || !vm.IsAvailable() {
+		return nil
+	}
+	return vm
+}
+
+// sideBySideRuntimes returns the runtimes installing, through manager, the
+// versions of the language entry called name found next to the one that ran
+// first (see EnvironmentData.LanguageVersions). They go before it, so the
+// version managers that select what they install leave it the default.
+func sideBySideRuntimes(env types.EnvironmentData, name, version, manager string) []PlanItem {
+	var items []PlanItem
+	for _, v := range env.SideBySideVersions(name) {
+		if v == version {
+			continue
+		}
+		items = append(items, PlanItem{
+			Name:     name,
+			ID:       env.ToolID(name),
+			Category: types.CategoryLanguages,
+			Version:  v,
+			Package:  name,
+			Manager:  manager,
+			Scope:    types.ScopeUser,
+		})
+	}
+	return items
+}
+
+// dnfModuleManager returns the version manager installing the language called
+// name from a DNF module stream, when the environment got it from one, or nil.
+// Module streams are system-wide, so user-scoped imports don't use them.
+func dnfModuleManager(env types.EnvironmentData, name string, opts PlanOptions) types.VersionManager {
+	if !strings.HasPrefix(env.ToolSources[name], "dnf-module:") || opts.Scope == types.ScopeUser {
+		return nil
+	}
+	vm := opts.DnfModules
+	if vm == nil {
+		vm = installer.DnfModuleManager()
+	}
+	if !vm.IsAvailable() || !vm.Supports(name) {
+		return nil
+	}
+	return vm
+}
+
+// condaStep is the manual step recreating the conda environment that
+// provided Python, given its prefix
+func condaStep(prefix, version string) types.ManualStep {
+	name := path.Base(strings.ReplaceAll(prefix, `\`, "/"))
+	python := "python"
+	if version != "" && version != "Installed" {
+		python += "=" + version
+	}
+	return types.ManualStep{
+		Category:    types.CategoryLanguages,
+		Description: fmt.Sprintf("Python came from the conda environment %s; recreate it with 'conda create -n %s %s'", name, name, python),
+		DocURL:      "https://docs.conda.io/projects/conda/en/latest/user-guide/tasks/manage-environments.html",
+	}
+}
+
+// withVersion formats a name followed by its version, if any
+func withVersion(name, version string) string {
+	if version == "" {
+		return name
+	}
+	return name + " " + version
+}
+
+// globalPackage returns the package argument that installs a global package
+// at version, such as "eslint@8.57.0"
+func globalPackage(name, version string) string {
+	if version == "" || version == "Installed" {
+		return name
+	}
+	return name + "@" + version
+}
+
+// sortedKeys returns the keys of m in lexical order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Install executes plan using the plan's package manager. The returned result
+// is non-nil whenever plan is valid, even if installation fails, so callers can
+// still report the manual steps collected so far.
+func Install(ctx context.Context, plan *InstallPlan, opts InstallOptions) (*InstallResult, error) {
+	if plan == nil || plan.Manager == nil {
+		return nil, fmt.Errorf("install requires a plan with a package manager")
+	}
+
+	packages := plan.Packages()
+	step(opts.Progress, fmt.Sprintf("Installing %d packages", len(packages)))
+
+	// Broken tools are reinstalled separately when the manager can, since
+	// installing an installed package does nothing. Packages with a version
+	// in the manager's format are installed one by one at that version.
+	var install, reinstall []string
+	var versioned []PlanItem
+	reinstaller, canReinstall := plan.Manager.(types.Reinstaller)
+	for _, item := range plan.Items {
+		switch {
+		case item.PackageVersion != "":
+			versioned = append(versioned, item)
+		case canReinstall && item.Reinstall:
+			reinstall = append(reinstall, item.Package)
+		default:
+			install = append(install, item.Package)
+		}
+	}
+
+	collector := &types.StepCollector{}
+	for _, s := range plan.ManualSteps {
+		collector.Add(s)
+	}
+
+	ctx = types.WithStepCollector(ctx, collector)
+	result := &InstallResult{Packages: packages, Coverage: plan.Coverage}
+	start := time.Now()
+	var err error
+	if opts.FailFast {
+		err = installEach(ctx, plan, opts, result)
+	} else {
+		if len(install) > 0 {
+			err = audit.Do(ctx, managerOperation(plan, audit.ActionInstall, install...), func(ctx context.Context) error {
+				return plan.Manager.InstallMultiple(ctx, install)
+			})
+		}
+		if err == nil && len(reinstall) > 0 {
+			step(opts.Progress, fmt.Sprintf("Reinstalling %d broken packages", len(reinstall)))
+			err = audit.Do(ctx, managerOperation(plan, audit.ActionReinstall, reinstall...), func(ctx context.Context) error {
+				return reinstaller.ReinstallPackages(ctx, reinstall)
+			})
+		}
+		for _, item := range versioned {
+			if err != nil {
+				break
+			}
+			step(opts.Progress, fmt.Sprintf("Installing %s %s", item.Package, item.PackageVersion))
+			err = installVersion(ctx, plan, item)
+		}
+	}
+	if err == nil {
+		err = installRuntimes(ctx, plan, opts)
+	}
+	if err == nil {
+		err = installGlobalPackages(ctx, plan, opts)
+	}
+	if err == nil && opts.Verify && !opts.FailFast && len(plan.Items) > 0 {
+		step(opts.Progress, fmt.Sprintf("Verifying %d packages", len(plan.Items)))
+		result.Verification = installer.VerifyPackages(ctx, plan.Manager, packages, packageConstraints(plan.Items))
+	}
+	result.Duration = time.Since(start)
+	result.ManualSteps = collector.Steps()
+	if bootstrapsPackageManager(plan) {
+		// Even a failed run may have installed some of them, which changes
+		// which package manager detection finds
+		_ = installer.InvalidateMetadata()
+	}
+	if err != nil {
+		return result, fmt.Errorf("failed to install packages: %w", err)
+	}
+
+	if opts.Pin {
+		pinPackages(ctx, plan, opts, result)
+	}
+	return result, nil
+}
+
+// installEach installs the plan's packages one at a time, verifying each
+// right after it is installed when opts.Verify is set, and stops at the
+// first package that fails either
+func installEach(ctx context.Context, plan *InstallPlan, opts InstallOptions, result *InstallResult) error {
+	reinstaller, canReinstall := plan.Manager.(types.Reinstaller)
+	constraints := packageConstraints(plan.Items)
+	for _, item := range plan.Items {
+		var err error
+		switch {
+		case item.PackageVersion != "":
+			step(opts.Progress, fmt.Sprintf("Installing %s %s", item.Package, item.PackageVersion))
+			err = installVersion(ctx, plan, item)
+		case canReinstall && item.Reinstall:
+			step(opts.Progress, fmt.Sprintf("Reinstalling %s", item.Package))
+			err = audit.Do(ctx, managerOperation(plan, audit.ActionReinstall, item.Package), func(ctx context.Context) error {
+				return reinstaller.ReinstallPackages(ctx, []string{item.Package})
+			})
+		default:
+			step(opts.Progress, fmt.Sprintf("Installing %s", item.Package))
+			err = audit.Do(ctx, managerOperation(plan, audit.ActionInstall, item.Package), func(ctx context.Context) error {
+				return plan.Manager.InstallPackage(ctx, item.Package)
+			})
+			var installed *types.PackageAlreadyInstalledError
+			if errors.As(err, &installed) {
+				err = nil
+			}
+		}
+		if err != nil {
+			return err
+		}
+		if !opts.Verify {
+			continue
+		}
+
+		verification := installer.VerifyPackages(ctx, plan.Manager, []string{item.Package}, constraints)[0]
+		result.Verification = append(result.Verification, verification)
+		if verification.State == installer.VerificationUnsatisfied {
+			return fmt.Errorf("%s did not verify: %s", item.Package, verification.Reason)
+		}
+	}
+	return nil
+}
+
+// bootstrapsPackageManager reports whether plan installs a package manager,
+// such as snapd through APT
+func bootstrapsPackageManager(plan *InstallPlan) bool {
+	for _, item := range plan.Items {
+		if item.Category == types.CategoryPackageManagers {
+			return true
+		}
+	}
+	return false
+}
+
+// managerOperation describes the change the plan's package manager makes
+// to packages, for the audit log
+func managerOperation(plan *InstallPlan, action string, packages ...string) audit.Operation {
+	return audit.Operation{Action: action, Package: strings.Join(packages, " "), Manager: plan.Manager.Name()}
+}
+
+// installVersion installs the package of item at its PackageVersion
+func installVersion(ctx context.Context, plan *InstallPlan, item PlanItem) error {
+	op := managerOperation(plan, audit.ActionInstall, item.Package)
+	op.Version = item.PackageVersion
+	return audit.Do(ctx, op, func(ctx context.Context) error {
+		return plan.Manager.InstallVersion(ctx, item.Package, types.VersionConstraint{Version: item.PackageVersion})
+	})
+}
+
+// packageConstraints returns the version each item's package must be
+// installed at, keyed by package, for the items that have one
+func packageConstraints(items []PlanItem) map[string]string {
+	constraints := make(map[string]string)
+	for _, item := range items {
+		if item.PackageVersion != "" {
+			constraints[item.Package] = item.PackageVersion
+		}
+	}
+	return constraints
+}
+
+// pinPackages holds the plan's versioned packages and records the outcome
+// in result. A package that cannot be pinned does not fail the installation.
+func pinPackages(ctx context.Context, plan *InstallPlan, opts InstallOptions, result *InstallResult) {
+	var versioned []string
+	for _, item := range plan.Items {
+		if item.Version != "" && item.Version != "Installed" {
+			versioned = append(versioned, item.Package)
+		}
+	}
+	if len(versioned) == 0 {
+		return
+	}
+
+	pinner, ok := plan.Manager.(types.Pinner)
+	if !ok {
+		result.Warnings = append(result.Warnings, fmt.Sprintf(
+			"%s cannot pin packages, so upgrades may change the versions of %s",
+			plan.Manager.Name(), strings.Join(versioned, ", ")))
+		return
+	}
+	step(opts.Progress, fmt.Sprintf("Pinning %d packages", len(versioned)))
+	for _, pkg := range versioned {
+		err := audit.Do(ctx, managerOperation(plan, audit.ActionPin, pkg), func(ctx context.Context) error {
+			return pinner.PinPackage(ctx, pkg)
+		})
+		if err != nil {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("could not pin %s: %v", pkg, err))
+			continue
+		}
+		result.Pinned = append(result.Pinned, pkg)
+	}
+}
+
+// installRuntimes installs the plan's languages through its version manager
+func installRuntimes(ctx context.Context, plan *InstallPlan, opts InstallOptions) error {
+	for _, item := range plan.Runtimes {
+		vm := plan.VersionManager
+		if item.Manager != "" {
+			vm = plan.RuntimeManagers[item.Manager]
+		}
+		step(opts.Progress, fmt.Sprintf("Installing %s with %s", withVersion(item.Name, item.Version), vm.Name()))
+		op := audit.Operation{Action: audit.ActionInstall, Package: item.Name, Version: item.Version, Manager: vm.Name()}
+		err := audit.Do(ctx, op, func(ctx context.Context) error {
+			return vm.InstallRuntime(ctx, item.Name, item.Version)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// installGlobalPackages installs the plan's global packages through their
+// language's package manager. Packages of a manager that is still not
+// available once the runtimes are installed are left as manual steps.
+func installGlobalPackages(ctx context.Context, plan *InstallPlan, opts InstallOptions) error {
+	var managers []string
+	packages := make(map[string][]string)
+	for _, item := range plan.GlobalPackages {
+		if _, ok := packages[item.Manager]; !ok {
+			managers = append(managers, item.Manager)
+		}
+		packages[item.Manager] = append(packages[item.Manager], item.Package)
+	}
+
+	for _, manager := range managers {
+		global := plan.GlobalInstallers[manager]
+		if global == nil || !global.IsAvailable() {
+			for _, pkg := range packages[manager] {
+				types.AddManualStep(ctx, types.ManualStep{
+					Category:    types.CategoryGlobalPackages,
+					Description: fmt.Sprintf("Install the %s package %s once %s is installed", manager, pkg, manager),
+				})
+			}
+			continue
+		}
+		step(opts.Progress, fmt.Sprintf("Installing %d global %s packages", len(packages[manager]), manager))
+		op := audit.Operation{Action: audit.ActionInstall, Package: strings.Join(packages[manager], " "), Manager: manager}
+		err := audit.Do(ctx, op, func(ctx context.Context) error {
+			return global.InstallGlobal(ctx, packages[manager])
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
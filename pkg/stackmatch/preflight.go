@@ -0,0 +1,66 @@
+package stackmatch
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+	"github.com/MRQ67/stackmatch-cli/pkg/ui"
+)
+
+// Rough disk space needed per package and per language runtime. Package
+// managers rarely say how much an install takes without downloading
+// metadata first, so this errs on the generous side.
+const (
+	bytesPerPackage = 100 << 20
+	bytesPerRuntime = 500 << 20
+)
+
+// freeSpace returns the bytes available to the current user on the volume
+// holding path; replaced in tests
+var freeSpace = volumeFreeSpace
+
+// Preflight runs the checks that can tell before installing whether plan is
+// likely to fail halfway: enough disk space for a rough estimate of its
+// size, and, for package managers that support it, their own health and
+// whether their repositories can be reached. Every check is returned; those
+// that found a problem have Passed false.
+func Preflight(ctx context.Context, plan *InstallPlan) []types.PreflightResult {
+	var results []types.PreflightResult
+	checker, _ := plan.Manager.(types.PreflightChecker)
+
+	volume := defaultInstallVolume()
+	if checker != nil {
+		volume = checker.InstallVolume()
+	}
+	if result, ok := checkDiskSpace(plan, volume); ok {
+		results = append(results, result)
+	}
+
+	if checker != nil && ctx.Err() == nil {
+		results = append(results, checker.PreflightChecks(ctx)...)
+	}
+	return results
+}
+
+// checkDiskSpace compares the free space on volume with the estimated size
+// of plan. It reports false when there is nothing to install or the free
+// space cannot be measured.
+func checkDiskSpace(plan *InstallPlan, volume string) (types.PreflightResult, bool) {
+	needed := uint64(len(plan.Items))*bytesPerPackage + uint64(len(plan.Runtimes))*bytesPerRuntime
+	if needed == 0 {
+		return types.PreflightResult{}, false
+	}
+	free, err := freeSpace(volume)
+	if err != nil {
+		return types.PreflightResult{}, false
+	}
+
+	result := types.PreflightResult{Check: "disk space"}
+	if free < needed {
+		result.Problem = fmt.Sprintf("only %s free on %s, but installing %d packages and %d runtimes may take about %s",
+			ui.HumanSize(int64(free)), volume, len(plan.Items), len(plan.Runtimes), ui.HumanSize(int64(needed)))
+		result.Fix = fmt.Sprintf("Free up space on %s (for example by clearing the package manager's download cache), or import fewer entries with --required-only", volume)
+	}
+	return result, true
+}
@@ -0,0 +1,92 @@
+package stackmatch
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+// fakeChecker is a fakeManager with canned preflight results
+type fakeChecker struct {
+	fakeManager
+	volume  string
+	results []types.PreflightResult
+}
+
+func (m *fakeChecker) InstallVolume() string { return m.volume }
+
+func (m *fakeChecker) PreflightChecks(ctx context.Context) []types.PreflightResult {
+	return m.results
+}
+
+func TestPreflight(t *testing.T) {
+	unreachable := types.PreflightResult{Check: "repositories reachable", Problem: "could not reach deb.debian.org", Fix: "Check your network"}
+	healthy := types.PreflightResult{Check: "dpkg state"}
+	items := []PlanItem{{Name: "Git", Package: "git"}, {Name: "Make", Package: "make"}}
+
+	testCases := []struct {
+		name           string
+		manager        types.Installer
+		items          []PlanItem
+		runtimes       []PlanItem
+		free           uint64
+		freeErr        error
+		expectedVolume string
+		expected       []types.PreflightResult
+	}{
+		{
+			name:           "Manager checks after disk space",
+			manager:        &fakeChecker{volume: "/opt/homebrew", results: []types.PreflightResult{healthy, unreachable}},
+			items:          items,
+			free:           10 << 30,
+			expectedVolume: "/opt/homebrew",
+			expected:       []types.PreflightResult{{Check: "disk space"}, healthy, unreachable},
+		},
+		{
+			name:     "Not enough disk space",
+			manager:  &fakeManager{pmType: types.TypeApt},
+			items:    items,
+			runtimes: []PlanItem{{Name: "Node.js", Package: "node"}},
+			free:     512 << 20,
+			expected: []types.PreflightResult{{
+				Check:   "disk space",
+				Problem: "only 512.0 MiB free on " + defaultInstallVolume() + ", but installing 2 packages and 1 runtimes may take about 700.0 MiB",
+				Fix:     "Free up space on " + defaultInstallVolume() + " (for example by clearing the package manager's download cache), or import fewer entries with --required-only",
+			}},
+		},
+		{
+			name:     "Free space unknown",
+			manager:  &fakeChecker{volume: "/", results: []types.PreflightResult{healthy}},
+			items:    items,
+			freeErr:  errors.New("statfs: permission denied"),
+			expected: []types.PreflightResult{healthy},
+		},
+		{
+			name:    "Nothing to install",
+			manager: &fakeManager{pmType: types.TypeApt},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var volume string
+			freeSpace = func(path string) (uint64, error) {
+				volume = path
+				return tc.free, tc.freeErr
+			}
+			defer func() { freeSpace = volumeFreeSpace }()
+
+			plan := &InstallPlan{Manager: tc.manager, Items: tc.items, Runtimes: tc.runtimes}
+			results := Preflight(context.Background(), plan)
+			if !reflect.DeepEqual(results, tc.expected) {
+				t.Errorf("expected %+v but got %+v", tc.expected, results)
+			}
+			if tc.expectedVolume != "" && volume != tc.expectedVolume {
+				t.Errorf("expected free space on %s to be checked but got %s", tc.expectedVolume, volume)
+			}
+		})
+	}
+}
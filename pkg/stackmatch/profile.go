@@ -0,0 +1,313 @@
+package stackmatch
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"syscall"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+	"gopkg.in/yaml.v3"
+)
+
+// Profile selects the part of an environment to export, such as a minimal
+// bootstrap set for onboarding instead of everything a machine has
+type Profile struct {
+	Name        string `yaml:"-" json:"name"`
+	Description string `yaml:"description,omitempty" json:"description,omitempty"`
+	// Categories are included whole (see the Category constants)
+	Categories []string `yaml:"categories,omitempty" json:"categories,omitempty"`
+	// Tools are included from whichever category holds them. A name matches
+	// an entry's display name ignoring case or its canonical tool ID.
+	Tools []string `yaml:"tools,omitempty" json:"tools,omitempty"`
+	// BuiltIn marks the profiles of DefaultProfiles
+	BuiltIn bool `yaml:"-" json:"built_in,omitempty"`
+}
+
+// DefaultProfiles are available without a profiles file. A profile of the
+// same name in the file replaces them.
+var DefaultProfiles = []Profile{
+	{
+		Name:        "bootstrap",
+		Description: "Git, Docker, language runtimes and code editors",
+		Categories:  []string{types.CategoryLanguages, types.CategoryEditors},
+		Tools:       []string{"git", "docker"},
+		BuiltIn:     true,
+	},
+}
+
+// profileCategories are the categories a profile can include whole.
+// Categories from newer releases or custom detectors are accepted too.
+var profileCategories = []string{
+	types.CategoryLanguages,
+	types.CategoryTools,
+	types.CategoryPackageManagers,
+	types.CategoryEditors,
+	types.CategoryConfigFiles,
+	types.CategoryScheduledJobs,
+	types.CategoryGitConfig,
+	types.CategoryLanguageConfig,
+	types.CategoryEnvVars,
+	types.CategoryVersionManagers,
+	types.CategoryGlobalPackages,
+	types.CategoryServices,
+	types.CategoryContainers,
+	types.CategoryGPU,
+	types.CategoryKubernetes,
+	types.CategoryCloud,
+	types.CategorySSH,
+	types.CategoryMobile,
+}
+
+// LoadProfiles returns DefaultProfiles merged with the profiles file at
+// path, ordered by name. A missing file yields only the defaults.
+func LoadProfiles(path string) ([]Profile, error) {
+	var profiles []Profile
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist), errors.Is(err, syscall.ENOTDIR):
+	case err != nil:
+		return nil, fmt.Errorf("failed to read profiles: %w", err)
+	default:
+		profiles, err = ParseProfiles(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	for _, profile := range DefaultProfiles {
+		if !slices.ContainsFunc(profiles, func(p Profile) bool { return p.Name == profile.Name }) {
+			profiles = append(profiles, profile)
+		}
+	}
+	sort.Slice(profiles, func(i, j int) bool { return profiles[i].Name < profiles[j].Name })
+	return profiles, nil
+}
+
+// ParseProfiles parses a profiles file in YAML or JSON, a profiles mapping
+// from profile name to its description, categories and tools. Errors give
+// the line of the offending profile.
+func ParseProfiles(data []byte) ([]Profile, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid profiles: %w", err)
+	}
+	if len(doc.Content) == 0 {
+		return nil, nil
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("line %d: profiles must be a mapping with a profiles key", root.Line)
+	}
+
+	var profiles []Profile
+	var errs []error
+	for i := 0; i < len(root.Content); i += 2 {
+		key, value := root.Content[i], root.Content[i+1]
+		if key.Value != "profiles" {
+			errs = append(errs, fmt.Errorf("line %d: unknown key %q (want profiles)", key.Line, key.Value))
+			continue
+		}
+		if value.Kind != yaml.MappingNode {
+			errs = append(errs, fmt.Errorf("line %d: profiles must map profile names to profiles", value.Line))
+			continue
+		}
+		for j := 0; j < len(value.Content); j += 2 {
+			name, node := value.Content[j], value.Content[j+1]
+			profile, err := parseProfile(name.Value, node)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("profile %s (line %d): %w", name.Value, name.Line, err))
+				continue
+			}
+			profiles = append(profiles, profile)
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return profiles, nil
+}
+
+// parseProfile decodes and validates the profile called name
+func parseProfile(name string, node *yaml.Node) (Profile, error) {
+	profile := Profile{Name: name}
+	if node.Kind != yaml.MappingNode {
+		return profile, errors.New("must be a mapping with description, categories and tools keys")
+	}
+	for i := 0; i < len(node.Content); i += 2 {
+		switch key := node.Content[i].Value; key {
+		case "description", "categories", "tools":
+		default:
+			return profile, fmt.Errorf("unknown key %q (want description, categories or tools)", key)
+		}
+	}
+	if err := node.Decode(&profile); err != nil {
+		return profile, err
+	}
+	if len(profile.Categories) == 0 && len(profile.Tools) == 0 {
+		return profile, errors.New("needs categories, tools or both")
+	}
+	for _, category := range profile.Categories {
+		if category == types.CategorySystem || category == types.CategoryRequirements {
+			return profile, fmt.Errorf("%s is always exported and cannot be listed", category)
+		}
+	}
+	return profile, nil
+}
+
+// FindProfile returns the profile called name
+func FindProfile(profiles []Profile, name string) (Profile, error) {
+	names := make([]string, 0, len(profiles))
+	for _, profile := range profiles {
+		if profile.Name == name {
+			return profile, nil
+		}
+		names = append(names, profile.Name)
+	}
+	return Profile{}, fmt.Errorf("no profile is named %s (have %s)", name, strings.Join(names, ", "))
+}
+
+// ApplyProfile returns a copy of env holding only what profile includes,
+// stamped with the profile's name, and the profile's tools and categories
+// env doesn't have. System information, Homebrew installations and the
+// project are kept; entry metadata such as tool IDs and requirements is
+// kept for the entries that remain.
+func ApplyProfile(env types.EnvironmentData, profile Profile) (types.EnvironmentData, []string) {
+	// Tools are matched against the entries before filtering
+	original := env
+	include := make(map[string]bool)
+	for _, category := range profile.Categories {
+		include[category] = true
+	}
+
+	var missing []string
+	for _, category := range profile.Categories {
+		if slices.Contains(profileCategories, category) {
+			continue
+		}
+		if _, ok := env.Extensions[category]; !ok {
+			missing = append(missing, "category "+category)
+		}
+	}
+
+	entries := []struct {
+		category string
+		from     map[string]string
+		to       *map[string]string
+	}{
+		{types.CategoryLanguages, env.ConfiguredLanguages, &env.ConfiguredLanguages},
+		{types.CategoryTools, env.Tools, &env.Tools},
+		{types.CategoryPackageManagers, env.PackageManagers, &env.PackageManagers},
+		{types.CategoryEditors, env.CodeEditors, &env.CodeEditors},
+	}
+	kept := make(map[string]bool)
+	for _, e := range entries {
+		filtered := make(map[string]string)
+		for name, version := range e.from {
+			if include[e.category] {
+				filtered[name] = version
+				kept[name] = true
+			}
+		}
+		*e.to = filtered
+	}
+	for _, name := range profile.Tools {
+		found := matchEntries(&original, name)
+		if len(found) == 0 {
+			missing = append(missing, name)
+		}
+		for _, entry := range found {
+			for _, e := range entries {
+				if version, ok := e.from[entry]; ok {
+					(*e.to)[entry] = version
+					kept[entry] = true
+				}
+			}
+		}
+	}
+
+	if !kept["Python"] {
+		env.Python = nil
+	}
+	env.LanguageVersions = keptLanguageVersions(&env)
+	// conda is scanned with the package managers and the shell setup with
+	// the config files
+	if !include[types.CategoryPackageManagers] {
+		env.Conda = nil
+	}
+	if !include[types.CategoryConfigFiles] {
+		env.ConfigFiles = nil
+		env.ConfigFileInfo = nil
+		env.ShellSetup = nil
+	}
+	if !include[types.CategoryScheduledJobs] {
+		env.ScheduledJobs = nil
+	}
+	if !include[types.CategoryGitConfig] {
+		env.GitConfig = nil
+	}
+	if !include[types.CategoryLanguageConfig] {
+		env.LanguageConfig = nil
+	}
+	if !include[types.CategoryEnvVars] {
+		env.EnvVars = nil
+	}
+	if !include[types.CategoryVersionManagers] {
+		env.VersionManagers = nil
+	}
+	if !include[types.CategoryGlobalPackages] {
+		env.GlobalPackages = nil
+	}
+	if !include[types.CategoryServices] {
+		env.Services = nil
+		env.RunningServices = nil
+	}
+	if !include[types.CategoryContainers] {
+		env.Containers = nil
+	}
+	if !include[types.CategoryGPU] {
+		env.GPU = nil
+	}
+	if !include[types.CategoryKubernetes] {
+		env.Kubernetes = nil
+	}
+	if !include[types.CategoryCloud] {
+		env.CloudProfiles = nil
+	}
+	if !include[types.CategorySSH] {
+		env.SSHKeys = nil
+		env.SSHAgent = false
+	}
+	if !include[types.CategoryMobile] {
+		env.Mobile = nil
+	}
+	env.Extensions = keepNames(env.Extensions, include)
+
+	env.ToolIDs = keepNames(env.ToolIDs, kept)
+	env.ToolSources = keepNames(env.ToolSources, kept)
+	env.Provenance = keepNames(env.Provenance, kept)
+	env.BrokenTools = keepNames(env.BrokenTools, kept)
+	env.Requirements = keepNames(env.Requirements, kept)
+	env.Aliases = keepNames(env.Aliases, kept)
+
+	env.Profile = profile.Name
+	env.Summary = types.BuildSummary(&env)
+	return env, missing
+}
+
+// keepNames returns the values of m whose names are kept, or nil when none are
+func keepNames[V any](m map[string]V, kept map[string]bool) map[string]V {
+	var filtered map[string]V
+	for name, value := range m {
+		if kept[name] {
+			if filtered == nil {
+				filtered = make(map[string]V)
+			}
+			filtered[name] = value
+		}
+	}
+	return filtered
+}
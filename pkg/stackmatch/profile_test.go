@@ -0,0 +1,214 @@
+package stackmatch
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+func fullEnvironment() types.EnvironmentData {
+	env := types.EnvironmentData{
+		System:              types.SystemInfo{OS: "linux", Arch: "amd64"},
+		ConfiguredLanguages: map[string]string{"Go": "1.22.3", "Node.js": "20.11.0"},
+		Tools:               map[string]string{"Git": "2.45.0", "Docker": "26.1.0", "kubectl": "1.30.0", "jq": "1.7.1"},
+		PackageManagers:     map[string]string{"npm": "10.2.4"},
+		CodeEditors:         map[string]string{"VS Code": "1.89.1"},
+		ToolIDs:             map[string]string{"VS Code": "vscode", "jq": "jq"},
+		Requirements:        map[string]types.Requirement{"Git": types.Required, "jq": types.Optional},
+		ConfigFiles:         []string{"/home/dev/.gitconfig"},
+		ShellSetup:          map[string]string{"starship": "1.19.0"},
+		Conda:               &types.CondaInstallation{Executable: "conda", Version: "24.1.2"},
+		GitConfig:           &types.GitConfig{URLRewrites: []types.URLRewrite{{Base: "git@github.com:", InsteadOf: "https://github.com/"}}},
+		Extensions:          map[string]map[string]string{"databases": {"Redis": "7.2.4"}},
+	}
+	env.Summary = types.BuildSummary(&env)
+	return env
+}
+
+func TestApplyProfile(t *testing.T) {
+	testCases := []struct {
+		name            string
+		profile         Profile
+		expectedLangs   map[string]string
+		expectedTools   map[string]string
+		expectedEditors map[string]string
+		expectedMissing []string
+		gitConfig       bool
+	}{
+		{
+			name:            "Built-in bootstrap",
+			profile:         DefaultProfiles[0],
+			expectedLangs:   map[string]string{"Go": "1.22.3", "Node.js": "20.11.0"},
+			expectedTools:   map[string]string{"Git": "2.45.0", "Docker": "26.1.0"},
+			expectedEditors: map[string]string{"VS Code": "1.89.1"},
+		},
+		{
+			name:            "Tools by ID and categories beyond entries",
+			profile:         Profile{Name: "ops", Categories: []string{types.CategoryGitConfig}, Tools: []string{"KUBECTL", "vscode"}},
+			expectedLangs:   map[string]string{},
+			expectedTools:   map[string]string{"kubectl": "1.30.0"},
+			expectedEditors: map[string]string{"VS Code": "1.89.1"},
+			gitConfig:       true,
+		},
+		{
+			name:            "Absent tools and categories are reported",
+			profile:         Profile{Name: "data", Categories: []string{"queues"}, Tools: []string{"git", "terraform"}},
+			expectedLangs:   map[string]string{},
+			expectedTools:   map[string]string{"Git": "2.45.0"},
+			expectedEditors: map[string]string{},
+			expectedMissing: []string{"category queues", "terraform"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			env := fullEnvironment()
+
+			got, missing := ApplyProfile(env, tc.profile)
+
+			if !reflect.DeepEqual(got.ConfiguredLanguages, tc.expectedLangs) {
+				t.Errorf("expected languages %v but got %v", tc.expectedLangs, got.ConfiguredLanguages)
+			}
+			if !reflect.DeepEqual(got.Tools, tc.expectedTools) {
+				t.Errorf("expected tools %v but got %v", tc.expectedTools, got.Tools)
+			}
+			if !reflect.DeepEqual(got.CodeEditors, tc.expectedEditors) {
+				t.Errorf("expected editors %v but got %v", tc.expectedEditors, got.CodeEditors)
+			}
+			if len(got.PackageManagers) != 0 || got.ConfigFiles != nil || got.ShellSetup != nil || got.Conda != nil || got.Extensions != nil {
+				t.Errorf("expected categories outside the profile to be dropped but got %+v", got)
+			}
+			if (got.GitConfig != nil) != tc.gitConfig {
+				t.Errorf("expected git config kept %t but got %+v", tc.gitConfig, got.GitConfig)
+			}
+			if !reflect.DeepEqual(missing, tc.expectedMissing) {
+				t.Errorf("expected missing %q but got %q", tc.expectedMissing, missing)
+			}
+			if got.Profile != tc.profile.Name {
+				t.Errorf("expected the environment stamped with profile %s but got %q", tc.profile.Name, got.Profile)
+			}
+			if got.System != env.System {
+				t.Errorf("expected system info to be kept but got %+v", got.System)
+			}
+			if got.Summary.Counts[types.CategoryTools] != len(tc.expectedTools) {
+				t.Errorf("expected the summary to count %d tools but got %v", len(tc.expectedTools), got.Summary.Counts)
+			}
+			if _, ok := got.Requirements["jq"]; ok {
+				t.Errorf("expected requirements of dropped entries to go but got %v", got.Requirements)
+			}
+			if len(env.Tools) != 4 || env.Profile != "" {
+				t.Errorf("expected the original environment to be left unchanged")
+			}
+		})
+	}
+}
+
+func TestParseProfiles(t *testing.T) {
+	testCases := []struct {
+		name          string
+		data          string
+		expected      []Profile
+		expectedError []string
+	}{
+		{
+			name: "Profiles",
+			data: `profiles:
+  onboarding:
+    description: Day one
+    categories: [languages]
+    tools: [git, docker]
+  ops:
+    tools: [kubectl]
+`,
+			expected: []Profile{
+				{Name: "onboarding", Description: "Day one", Categories: []string{"languages"}, Tools: []string{"git", "docker"}},
+				{Name: "ops", Tools: []string{"kubectl"}},
+			},
+		},
+		{
+			name:     "JSON",
+			data:     `{"profiles": {"ops": {"tools": ["kubectl"]}}}`,
+			expected: []Profile{{Name: "ops", Tools: []string{"kubectl"}}},
+		},
+		{
+			name: "Invalid profiles",
+			data: `profiles:
+  empty:
+    description: Nothing
+  typo:
+    tool: [git]
+  system:
+    categories: [system]
+`,
+			expectedError: []string{
+				"profile empty (line 2): needs categories, tools or both",
+				`profile typo (line 4): unknown key "tool"`,
+				"profile system (line 6): system is always exported",
+			},
+		},
+		{
+			name:          "Unknown top-level key",
+			data:          "profile: {}\n",
+			expectedError: []string{`line 1: unknown key "profile" (want profiles)`},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			profiles, err := ParseProfiles([]byte(tc.data))
+			if len(tc.expectedError) > 0 {
+				if err == nil {
+					t.Fatalf("expected errors %q but got profiles %+v", tc.expectedError, profiles)
+				}
+				for _, expected := range tc.expectedError {
+					if !strings.Contains(err.Error(), expected) {
+						t.Errorf("expected the error to mention %q but got %q", expected, err)
+					}
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(profiles, tc.expected) {
+				t.Errorf("expected profiles %+v but got %+v", tc.expected, profiles)
+			}
+		})
+	}
+}
+
+func TestLoadProfiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "profiles.yaml")
+
+	profiles, err := LoadProfiles(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(profiles, DefaultProfiles) {
+		t.Errorf("expected only the built-in profiles without a file but got %+v", profiles)
+	}
+
+	data := "profiles:\n  bootstrap:\n    tools: [git]\n  ops:\n    tools: [kubectl]\n"
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	profiles, err = LoadProfiles(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []Profile{
+		{Name: "bootstrap", Tools: []string{"git"}},
+		{Name: "ops", Tools: []string{"kubectl"}},
+	}
+	if !reflect.DeepEqual(profiles, expected) {
+		t.Errorf("expected the file to replace the built-in bootstrap profile but got %+v", profiles)
+	}
+
+	if _, err := FindProfile(profiles, "onboarding"); err == nil || !strings.Contains(err.Error(), "have bootstrap, ops") {
+		t.Errorf("expected an unknown profile to list the available ones but got %v", err)
+	}
+}
@@ -0,0 +1,334 @@
+package stackmatch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/config"
+	"github.com/MRQ67/stackmatch-cli/pkg/runner"
+	"github.com/MRQ67/stackmatch-cli/pkg/scanner"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+// ScanOptions controls how the local environment is scanned
+type ScanOptions struct {
+	// Progress is notified before each detection phase. May be nil.
+	Progress Progress
+	// ProjectPath, when set, scans that project directory: its manifests
+	// and the tool versions they ask for replace the home directory's config
+	// files, and build tool wrapper pins are recorded.
+	ProjectPath string
+	// DetectorsFile overrides the location of the detector overrides file
+	// (default ~/.stackmatch/detectors.yaml)
+	DetectorsFile string
+	// LoginShellProbe retries tools missing from PATH through the login
+	// shell, for tools that version managers like nvm only put on PATH from
+	// shell init files. Off by default: it runs the user's shell init files.
+	LoginShellProbe bool
+	// ScheduledJobs captures the user's crontab, or on Windows the user's
+	// scheduled tasks, with secrets in their commands redacted. Off by
+	// default: the commands may reveal more than the user wants to share.
+	ScheduledJobs bool
+	// ProbeServices tries well-known local ports for running development
+	// services such as PostgreSQL and Redis and asks those found for their
+	// version. Off by default: it opens network connections.
+	ProbeServices bool
+	// Deep lists the packages of every conda environment found, recording
+	// the versions of key packages such as numpy. Off by default: it runs
+	// one slow 'conda list' per environment.
+	Deep bool
+	// Concurrency is how many version commands run at once (default
+	// scanner.DefaultConcurrency)
+	Concurrency int
+	// Detectors limits the scan to the registered detectors with these
+	// names (see SelectDetectors). Nil runs every detector. The sections of
+	// categories not scanned are left empty, so they are omitted from the
+	// JSON.
+	Detectors []string
+	// Categories limits the scan to the detectors of these categories (see
+	// ScanCategories) when Detectors is nil. Unknown categories are ignored.
+	//
+	// Deprecated: use Detectors with SelectDetectors, which also selects
+	// single detectors.
+	Categories []string
+	// Fast reads the languages, tools, package managers and editors from
+	// the package databases of dpkg, Homebrew, Scoop and winget instead of
+	// running each of them, and skips the categories that need a command
+	// per entry (see scanner.FastDetectors). The result records what it
+	// read in FastScan. Detectors and LoginShellProbe don't apply to it.
+	Fast bool
+	// Manifest, when set, limits the languages, tools, package managers and
+	// editors looked for to its entries, and records that in ManifestScan so
+	// the entries not looked for aren't taken for removed. Fast scans run
+	// no version commands and ignore it.
+	Manifest *scanner.Manifest
+	// Sections, when set, receives what the scan found so far each time
+	// the last phase of a category finishes, so callers can show results
+	// before the whole scan is done. Scan waits for each section to be
+	// received, or drops it when ctx is done, and never closes the channel.
+	Sections chan<- ScanSection
+}
+
+// ScanSection is sent on ScanOptions.Sections when every phase of a
+// category has run
+type ScanSection struct {
+	// Category is the category just completed, such as "languages"
+	Category string `json:"category"`
+	// Environment is what the scan found so far, marked Incomplete. It is a
+	// copy the scan no longer changes.
+	Environment types.EnvironmentData `json:"environment"`
+}
+
+// ScanCategories are the categories a scan can be limited to: those of the
+// built-in detectors, in the order they are first scanned. SelectDetectors
+// also accepts the categories of detectors registered later.
+var ScanCategories = detectorCategories()
+
+// detectorCategories returns the categories of the registered detectors, in
+// the order they are first scanned
+func detectorCategories() []string {
+	var categories []string
+	for _, d := range scanner.Detectors() {
+		if !slices.Contains(categories, d.Category()) {
+			categories = append(categories, d.Category())
+		}
+	}
+	return categories
+}
+
+// DetectorNames returns the names of the registered detectors in the order
+// they run
+func DetectorNames() []string {
+	var names []string
+	for _, d := range scanner.Detectors() {
+		names = append(names, d.Name())
+	}
+	return names
+}
+
+// SelectDetectors returns the names of the registered detectors selected by
+// only, less those selected by skip, for ScanOptions.Detectors. Both list
+// categories or detector names, and a category selects all of its
+// detectors. It returns nil, to run every detector, when both are empty.
+func SelectDetectors(only, skip []string) ([]string, error) {
+	detectors := scanner.Detectors()
+	selects := func(names []string, d scanner.Detector) bool {
+		return slices.Contains(names, d.Name()) || slices.Contains(names, d.Category())
+	}
+	for _, name := range append(slices.Clone(only), skip...) {
+		if !slices.ContainsFunc(detectors, func(d scanner.Detector) bool { return selects([]string{name}, d) }) {
+			return nil, fmt.Errorf("unknown category or detector %q (valid categories: %s; detectors: %s)",
+				name, strings.Join(detectorCategories(), ", "), strings.Join(DetectorNames(), ", "))
+		}
+	}
+	if len(only) == 0 && len(skip) == 0 {
+		return nil, nil
+	}
+	names := []string{}
+	for _, d := range detectors {
+		if (len(only) == 0 || selects(only, d)) && !selects(skip, d) {
+			names = append(names, d.Name())
+		}
+	}
+	return names, nil
+}
+
+// selectedDetectors returns opts.Detectors or, when only the deprecated
+// Categories is set, the names of the detectors of those categories
+func (opts ScanOptions) selectedDetectors() []string {
+	if opts.Detectors != nil || opts.Categories == nil {
+		return opts.Detectors
+	}
+	known := detectorCategories()
+	categories := slices.DeleteFunc(slices.Clone(opts.Categories), func(category string) bool {
+		return !slices.Contains(known, category)
+	})
+	if len(categories) == 0 {
+		return []string{}
+	}
+	// Only known categories are left, which SelectDetectors accepts
+	names, _ := SelectDetectors(categories, nil)
+	return names
+}
+
+// runDetector runs d, recording an error it returns as a warning
+func runDetector(ctx context.Context, progress Progress, d scanner.Detector, env *types.EnvironmentData) {
+	message := "Running the " + d.Name() + " detector"
+	if describer, ok := d.(scanner.Describer); ok {
+		message = describer.Description()
+	}
+	step(progress, message)
+	if err := d.Detect(ctx, env); err != nil {
+		env.Warnings = append(env.Warnings, fmt.Sprintf("the %s detector failed: %v", d.Name(), err))
+	}
+}
+
+// scanAliasGroups drops the groups that are only collapsed when comparing,
+// so scans record those tools under each of their names
+func scanAliasGroups(groups []types.AliasGroup) []types.AliasGroup {
+	return slices.DeleteFunc(slices.Clone(groups), func(g types.AliasGroup) bool { return g.CompareOnly })
+}
+
+// NewEnvironment returns an empty EnvironmentData stamped with the current
+// StackMatch version and scan time
+func NewEnvironment() types.EnvironmentData {
+	return types.EnvironmentData{
+		SchemaVersion:       types.CurrentSchemaVersion,
+		StackmatchVersion:   Version,
+		ScanDate:            time.Now().UTC(),
+		Tools:               make(map[string]string),
+		PackageManagers:     make(map[string]string),
+		CodeEditors:         make(map[string]string),
+		ConfiguredLanguages: make(map[string]string),
+		ToolIDs:             make(map[string]string),
+		ToolSources:         make(map[string]string),
+		ConfigFiles:         []string{},
+	}
+}
+
+// scanPhase is one step of a scan: a detector, or a step asked for by
+// ScanOptions
+type scanPhase struct {
+	category string
+	run      func(ctx context.Context, env *types.EnvironmentData)
+}
+
+// scanPhases returns the phases of a scan with opts in the order they run
+func scanPhases(opts ScanOptions, detectors *scanner.DetectorConfig) []scanPhase {
+	run := scanner.Detectors()
+	if opts.Fast {
+		run = scanner.FastDetectors()
+	}
+	selected := opts.selectedDetectors()
+	var phases []scanPhase
+	for _, d := range run {
+		if selected != nil && !opts.Fast && !slices.Contains(selected, d.Name()) {
+			continue
+		}
+		phases = append(phases, scanPhase{d.Category(), func(ctx context.Context, env *types.EnvironmentData) {
+			runDetector(ctx, opts.Progress, d, env)
+		}})
+	}
+
+	if opts.ProjectPath != "" {
+		phases = append(phases, scanPhase{types.CategoryConfigFiles, func(_ context.Context, env *types.EnvironmentData) {
+			step(opts.Progress, "Detecting project build wrappers")
+			scanner.DetectBuildWrappers(env, opts.ProjectPath)
+		}})
+	}
+	if opts.ScheduledJobs {
+		phases = append(phases, scanPhase{types.CategoryScheduledJobs, func(ctx context.Context, env *types.EnvironmentData) {
+			step(opts.Progress, "Detecting scheduled jobs")
+			scanner.DetectScheduledJobs(ctx, env)
+		}})
+	}
+	if opts.Deep {
+		phases = append(phases, scanPhase{types.CategoryPackageManagers, func(ctx context.Context, env *types.EnvironmentData) {
+			if env.Conda != nil {
+				step(opts.Progress, "Listing the packages of each conda environment")
+				scanner.DetectCondaPackages(ctx, env)
+			}
+		}})
+	}
+	if opts.ProbeServices {
+		phases = append(phases, scanPhase{types.CategoryServices, func(ctx context.Context, env *types.EnvironmentData) {
+			step(opts.Progress, "Probing local development services")
+			scanner.DetectRunningServices(ctx, env, detectors.ServicePorts)
+		}})
+	}
+	return phases
+}
+
+// Scan detects the development environment of the current machine.
+// If ctx is cancelled between phases, the environment found so far is
+// returned marked Incomplete, together with the context error.
+func Scan(ctx context.Context, opts ScanOptions) (types.EnvironmentData, error) {
+	env := NewEnvironment()
+	start := time.Now()
+
+	detectorsFile := opts.DetectorsFile
+	if detectorsFile == "" {
+		detectorsFile = config.DetectorsFile()
+	}
+	detectors, err := scanner.LoadDetectorConfig(detectorsFile)
+	if err != nil {
+		env.Warnings = append(env.Warnings, fmt.Sprintf("ignoring invalid entries of the detectors file: %v", err))
+	}
+	scanner.UseDetectorConfig(detectors)
+	defer scanner.UseDetectorConfig(nil)
+	scanner.UseConcurrency(opts.Concurrency)
+	defer scanner.UseConcurrency(0)
+	if opts.LoginShellProbe && !opts.Fast {
+		scanner.UseShellProbe(scanner.NewShellProbe(detectors.LoginShellTools))
+		defer scanner.UseShellProbe(nil)
+	}
+	if opts.Manifest != nil && !opts.Fast {
+		scanner.UseManifest(opts.Manifest)
+		defer scanner.UseManifest(nil)
+		env.ManifestScan = opts.Manifest.Scan()
+	}
+	if opts.ProjectPath != "" {
+		scanner.UseProjectDir(opts.ProjectPath)
+		defer scanner.UseProjectDir("")
+	}
+
+	// Every lookup during the scan goes through one listing of PATH, so a
+	// network mount on PATH costs the listing budget once instead of a
+	// stall per tool
+	path := runner.IndexPath(ctx, runner.IndexOptions{Exclude: detectors.ExcludePath})
+	for _, dir := range path.Slow {
+		env.Warnings = append(env.Warnings, fmt.Sprintf(
+			"skipped PATH entry %s: listing it took longer than %s, so tools installed there were not detected; add it to exclude_path in %s to skip it without waiting",
+			dir, runner.DefaultListBudget, detectorsFile))
+	}
+	defaultPath := runner.DefaultPath
+	runner.DefaultPath = path
+	defer func() { runner.DefaultPath = defaultPath }()
+
+	// Collapse names like "Python 3" into "Python" so scans compare clean
+	// however the tools were named
+	finish := func(env types.EnvironmentData) types.EnvironmentData {
+		env = types.Reconcile(env, scanAliasGroups(detectors.AliasGroups()))
+		env.Summary = types.BuildSummary(&env)
+		env.Summary.ScanDurationMS = time.Since(start).Milliseconds()
+		return env
+	}
+
+	phases := scanPhases(opts, detectors)
+	for i, phase := range phases {
+		if err := ctx.Err(); err != nil {
+			env.Incomplete = true
+			return finish(env), err
+		}
+		phase.run(ctx, &env)
+
+		lastOfCategory := !slices.ContainsFunc(phases[i+1:], func(p scanPhase) bool { return p.category == phase.category })
+		if opts.Sections != nil && lastOfCategory && ctx.Err() == nil {
+			select {
+			case opts.Sections <- ScanSection{Category: phase.category, Environment: finish(snapshot(env))}:
+			case <-ctx.Done():
+				// Nobody is left to receive it
+			}
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		env.Incomplete = true
+		return finish(env), err
+	}
+	return finish(env), nil
+}
+
+// snapshot returns a deep copy of env marked Incomplete, which the scan
+// can go on filling in without changing it
+func snapshot(env types.EnvironmentData) types.EnvironmentData {
+	var copied types.EnvironmentData
+	if data, err := json.Marshal(env); err == nil && json.Unmarshal(data, &copied) == nil {
+		env = copied
+	}
+	env.Incomplete = true
+	return env
+}
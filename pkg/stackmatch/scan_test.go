@@ -0,0 +1,238 @@
+package stackmatch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+func TestSelectDetectors(t *testing.T) {
+	testCases := []struct {
+		name     string
+		only     []string
+		skip     []string
+		expected []string
+		err      string
+	}{
+		{name: "Neither", expected: nil},
+		{name: "Category", only: []string{types.CategoryLanguages}, expected: []string{"languages", "python-environment", "corepack", "language-versions"}},
+		{name: "Category less a detector", only: []string{types.CategoryLanguages}, skip: []string{"corepack"}, expected: []string{"languages", "python-environment", "language-versions"}},
+		{name: "Detector", only: []string{"docker-plugins", "git-config"}, expected: []string{"docker-plugins", "git-config"}},
+		{name: "Skip everything", skip: ScanCategories, expected: []string{}},
+		{name: "Unknown", only: []string{"databases"}, err: `unknown category or detector "databases"`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := SelectDetectors(tc.only, tc.skip)
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("expected an error containing %q but got %v", tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("expected %#v but got %#v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestScanOptionsCategories(t *testing.T) {
+	testCases := []struct {
+		name     string
+		opts     ScanOptions
+		expected []string
+	}{
+		{name: "Neither", opts: ScanOptions{}, expected: nil},
+		{name: "Category", opts: ScanOptions{Categories: []string{types.CategoryLanguages, "queues"}}, expected: []string{"languages", "python-environment", "corepack", "language-versions"}},
+		{name: "Unknown category", opts: ScanOptions{Categories: []string{"queues"}}, expected: []string{}},
+		{name: "Detectors first", opts: ScanOptions{Categories: []string{types.CategoryLanguages}, Detectors: []string{"git-config"}}, expected: []string{"git-config"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.opts.selectedDetectors(); !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("expected %#v but got %#v", tc.expected, got)
+			}
+		})
+	}
+}
+
+// failingDetector is a detector from another package, without a
+// description
+type failingDetector struct{}
+
+func (failingDetector) Name() string     { return "kubectl-contexts" }
+func (failingDetector) Category() string { return "kubernetes" }
+func (failingDetector) Detect(context.Context, *types.EnvironmentData) error {
+	return errors.New("no kubeconfig")
+}
+
+func TestRunDetector(t *testing.T) {
+	var messages []string
+	env := &types.EnvironmentData{}
+	runDetector(context.Background(), ProgressFunc(func(msg string) { messages = append(messages, msg) }), failingDetector{}, env)
+
+	if !reflect.DeepEqual(messages, []string{"Running the kubectl-contexts detector"}) {
+		t.Errorf("expected the detector to be shown by name but got %q", messages)
+	}
+	if !reflect.DeepEqual(env.Warnings, []string{"the kubectl-contexts detector failed: no kubeconfig"}) {
+		t.Errorf("expected the error to be recorded as a warning but got %q", env.Warnings)
+	}
+}
+
+// sectionScanOptions scans the system, environment variables and a
+// kubeconfig, which give the same result every time
+func sectionScanOptions(t *testing.T) ScanOptions {
+	t.Helper()
+	dir := t.TempDir()
+	kubeconfig := filepath.Join(dir, "kubeconfig")
+	if err := os.WriteFile(kubeconfig, []byte("current-context: dev\ncontexts:\n- name: dev\n  context: {cluster: dev}\nclusters:\n- name: dev\n  cluster: {server: https://dev.example.com:6443}\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("KUBECONFIG", kubeconfig)
+	return ScanOptions{
+		DetectorsFile: filepath.Join(dir, "detectors.yaml"),
+		Detectors:     []string{"system", "env-vars", "kubernetes"},
+	}
+}
+
+// collectSections scans with opts, returning the sections sent while it ran
+func collectSections(ctx context.Context, opts ScanOptions) (types.EnvironmentData, []ScanSection, error) {
+	sections := make(chan ScanSection)
+	done := make(chan []ScanSection)
+	go func() {
+		var received []ScanSection
+		for section := range sections {
+			received = append(received, section)
+		}
+		done <- received
+	}()
+	opts.Sections = sections
+	env, err := Scan(ctx, opts)
+	close(sections)
+	return env, <-done, err
+}
+
+// withoutTiming clears what differs between two scans of the same machine
+func withoutTiming(env types.EnvironmentData) types.EnvironmentData {
+	env.ScanDate = time.Time{}
+	if env.Summary != nil {
+		summary := *env.Summary
+		summary.ScanDurationMS = 0
+		env.Summary = &summary
+	}
+	return env
+}
+
+func TestScanSections(t *testing.T) {
+	opts := sectionScanOptions(t)
+	batch, err := Scan(context.Background(), opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	streamed, sections, err := collectSections(context.Background(), opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(withoutTiming(streamed), withoutTiming(batch)) {
+		t.Errorf("expected the streamed scan to match the batch scan\nstreamed: %+v\nbatch:    %+v", streamed, batch)
+	}
+	if streamed.Incomplete {
+		t.Error("expected the finished scan not to be marked incomplete")
+	}
+
+	var categories []string
+	for _, section := range sections {
+		categories = append(categories, section.Category)
+		if !section.Environment.Incomplete || section.Environment.Summary == nil {
+			t.Errorf("expected the %s section to be a summarized document marked incomplete", section.Category)
+		}
+	}
+	if expected := []string{types.CategorySystem, types.CategoryEnvVars, types.CategoryKubernetes}; !reflect.DeepEqual(categories, expected) {
+		t.Fatalf("expected sections %q but got %q", expected, categories)
+	}
+	if system := sections[0].Environment; system.System.OS == "" || system.Kubernetes != nil {
+		t.Errorf("expected the system section to hold the system and nothing scanned after it, got %+v", system)
+	}
+
+	// The last section holds everything, so it encodes as the finished
+	// scan but for the flag
+	last := sections[len(sections)-1].Environment
+	last.Incomplete = false
+	lastJSON, _ := json.Marshal(withoutTiming(last))
+	finalJSON, _ := json.Marshal(withoutTiming(streamed))
+	if string(lastJSON) != string(finalJSON) {
+		t.Errorf("expected the last section to hold the whole scan\nlast:  %s\nfinal: %s", lastJSON, finalJSON)
+	}
+}
+
+func TestScanCancelledMidway(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	opts := sectionScanOptions(t)
+	opts.Progress = ProgressFunc(func(msg string) {
+		if msg == "Detecting environment variables" {
+			cancel()
+		}
+	})
+
+	env, sections, err := collectSections(ctx, opts)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the scan to be cancelled but got %v", err)
+	}
+	if len(sections) != 1 || sections[0].Category != types.CategorySystem {
+		t.Errorf("expected only the system section before cancelling, got %d sections", len(sections))
+	}
+	if !env.Incomplete || env.System.OS == "" || env.Kubernetes != nil {
+		t.Errorf("expected the system found before cancelling, marked incomplete, but got %+v", env)
+	}
+	if env.Summary == nil {
+		t.Fatal("expected the partial document to be summarized")
+	}
+	if issues := env.Validate(); len(issues) != 0 {
+		t.Errorf("expected the partial document to be valid but got %v", issues)
+	}
+}
+
+func TestScanCancelledWhileSectionUnreceived(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	opts := sectionScanOptions(t)
+	// Nothing receives the sections, as when a client has gone away
+	opts.Sections = make(chan ScanSection)
+	var once sync.Once
+	opts.Progress = ProgressFunc(func(string) {
+		once.Do(func() {
+			time.AfterFunc(10*time.Millisecond, cancel)
+		})
+	})
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := Scan(ctx, opts)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected the scan to be cancelled but got %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("expected the scan to stop waiting for its section once cancelled")
+	}
+}
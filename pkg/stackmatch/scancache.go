@@ -0,0 +1,168 @@
+package stackmatch
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/config"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+// DefaultScanCacheTTL is how long a scan is reused before the machine is
+// scanned again
+const DefaultScanCacheTTL = 10 * time.Minute
+
+// ScanCache keeps the last scan on disk, so that commands run in quick
+// succession, such as 'scan' then 'push', don't each run every detector
+type ScanCache struct {
+	// Path is the cache file, normally config.ScanCacheFile
+	Path string
+	// TTL is how long a scan is fresh (default DefaultScanCacheTTL)
+	TTL time.Duration
+	// Refresh ignores the cached scan, scanning again and replacing it, as
+	// --no-cache does
+	Refresh bool
+
+	now func() time.Time
+}
+
+// cachedScan is the content of the cache file
+type cachedScan struct {
+	ScannedAt time.Time `json:"scanned_at"`
+	// Fingerprint is ScanFingerprint of the options the scan was run with
+	Fingerprint string                `json:"fingerprint"`
+	Environment types.EnvironmentData `json:"environment"`
+}
+
+// NewScanCache returns a cache kept in path with the given TTL
+func NewScanCache(path string, ttl time.Duration) *ScanCache {
+	return &ScanCache{Path: path, TTL: ttl}
+}
+
+// ScanFingerprint identifies what a scan with opts would see: the OS,
+// PATH, the StackMatch version, the options that change what is detected
+// and the content of the detectors file. A cached scan is only reused for
+// the same fingerprint, so editing the detectors file, scanning other
+// categories or limiting the scan to another manifest scans again.
+func ScanFingerprint(opts ScanOptions) string {
+	detectorsFile := opts.DetectorsFile
+	if detectorsFile == "" {
+		detectorsFile = config.DetectorsFile()
+	}
+	// A missing detectors file hashes like an empty one
+	detectors, _ := os.ReadFile(detectorsFile)
+	detectorsHash := sha256.Sum256(detectors)
+	projectPath := opts.ProjectPath
+	if projectPath != "" {
+		if abs, err := filepath.Abs(projectPath); err == nil {
+			projectPath = abs
+		}
+	}
+	selected := opts.selectedDetectors()
+	var manifestIDs []string
+	if opts.Manifest != nil {
+		manifestIDs = opts.Manifest.IDs()
+	}
+
+	hash := sha256.New()
+	for _, part := range []string{
+		runtime.GOOS, runtime.GOARCH, Version, os.Getenv("PATH"),
+		detectorsFile, hex.EncodeToString(detectorsHash[:]), projectPath,
+		fmt.Sprint(opts.LoginShellProbe, opts.ScheduledJobs, opts.ProbeServices, opts.Deep, opts.Fast),
+		strings.Join(selected, ","), fmt.Sprint(selected == nil),
+		strings.Join(manifestIDs, ","), fmt.Sprint(opts.Manifest == nil),
+	} {
+		// NUL can't appear in any part, so parts can't run into each other
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// Load returns the cached scan and when it was taken while it is fresh and
+// has fingerprint, or nil when it is missing, stale, from other options,
+// unreadable or Refresh is set
+func (c *ScanCache) Load(fingerprint string) (*types.EnvironmentData, time.Time) {
+	if c.Refresh {
+		return nil, time.Time{}
+	}
+	data, err := os.ReadFile(c.Path)
+	if err != nil {
+		return nil, time.Time{}
+	}
+	var cached cachedScan
+	if err := json.Unmarshal(data, &cached); err != nil || cached.Fingerprint != fingerprint {
+		return nil, time.Time{}
+	}
+	ttl := c.TTL
+	if ttl <= 0 {
+		ttl = DefaultScanCacheTTL
+	}
+	if age := c.clock().Sub(cached.ScannedAt); age < 0 || age >= ttl {
+		return nil, time.Time{}
+	}
+	return &cached.Environment, cached.ScannedAt
+}
+
+// Store replaces the cached scan with env, scanned with fingerprint
+func (c *ScanCache) Store(fingerprint string, env types.EnvironmentData) error {
+	data, err := json.MarshalIndent(cachedScan{
+		ScannedAt:   c.clock().UTC(),
+		Fingerprint: fingerprint,
+		Environment: env,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := config.WritePrivateFile(c.Path, append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write scan cache: %w", err)
+	}
+	return nil
+}
+
+// Invalidate deletes the cached scan, so that the next command scans again
+func (c *ScanCache) Invalidate() error {
+	if err := os.Remove(c.Path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to invalidate scan cache: %w", err)
+	}
+	return nil
+}
+
+// clock returns the current time, which tests replace
+func (c *ScanCache) clock() time.Time {
+	if c.now != nil {
+		return c.now()
+	}
+	return time.Now()
+}
+
+// Scan returns the cached scan when it is fresh and was run with the same
+// options, and otherwise scans and caches the result. The time returned is
+// when the cached scan was taken, and zero for a new scan. A nil cache
+// always scans.
+func (c *ScanCache) Scan(ctx context.Context, opts ScanOptions) (types.EnvironmentData, time.Time, error) {
+	if c == nil {
+		env, err := Scan(ctx, opts)
+		return env, time.Time{}, err
+	}
+	fingerprint := ScanFingerprint(opts)
+	if env, scannedAt := c.Load(fingerprint); env != nil {
+		return *env, scannedAt, nil
+	}
+	env, err := Scan(ctx, opts)
+	if err != nil {
+		return env, time.Time{}, err
+	}
+	// A cache that can't be written costs the next command a scan
+	_ = c.Store(fingerprint, env)
+	return env, time.Time{}, nil
+}
@@ -0,0 +1,138 @@
+package stackmatch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/scanner"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+func TestScanCache(t *testing.T) {
+	scannedAt := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name string
+		// change runs between storing and loading the scan
+		change   func(t *testing.T, cache *ScanCache, opts *ScanOptions)
+		expected bool
+	}{
+		{name: "Fresh", expected: true},
+		{
+			name: "Just before the TTL",
+			change: func(t *testing.T, cache *ScanCache, opts *ScanOptions) {
+				cache.now = func() time.Time { return scannedAt.Add(9*time.Minute + 59*time.Second) }
+			},
+			expected: true,
+		},
+		{
+			name: "Expired",
+			change: func(t *testing.T, cache *ScanCache, opts *ScanOptions) {
+				cache.now = func() time.Time { return scannedAt.Add(10 * time.Minute) }
+			},
+		},
+		{
+			name: "Configured TTL",
+			change: func(t *testing.T, cache *ScanCache, opts *ScanOptions) {
+				cache.TTL = 30 * time.Second
+				cache.now = func() time.Time { return scannedAt.Add(time.Minute) }
+			},
+		},
+		{
+			name: "Clock went back",
+			change: func(t *testing.T, cache *ScanCache, opts *ScanOptions) {
+				cache.now = func() time.Time { return scannedAt.Add(-time.Hour) }
+			},
+		},
+		{
+			name: "Refresh",
+			change: func(t *testing.T, cache *ScanCache, opts *ScanOptions) {
+				cache.Refresh = true
+			},
+		},
+		{
+			name: "PATH changed",
+			change: func(t *testing.T, cache *ScanCache, opts *ScanOptions) {
+				t.Setenv("PATH", "/opt/new/bin:"+os.Getenv("PATH"))
+			},
+		},
+		{
+			name: "Detectors file edited",
+			change: func(t *testing.T, cache *ScanCache, opts *ScanOptions) {
+				if err := os.WriteFile(opts.DetectorsFile, []byte("exclude_path: [/mnt]\n"), 0600); err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+		{
+			name: "Other categories",
+			change: func(t *testing.T, cache *ScanCache, opts *ScanOptions) {
+				opts.Detectors = []string{"languages"}
+			},
+		},
+		{
+			name: "Other project",
+			change: func(t *testing.T, cache *ScanCache, opts *ScanOptions) {
+				opts.ProjectPath = t.TempDir()
+			},
+		},
+		{
+			name: "Limited to a manifest",
+			change: func(t *testing.T, cache *ScanCache, opts *ScanOptions) {
+				opts.Manifest = scanner.NewManifest("team.json", &types.EnvironmentData{Tools: map[string]string{"Git": "2.43.0"}})
+			},
+		},
+		{
+			name: "Invalidated",
+			change: func(t *testing.T, cache *ScanCache, opts *ScanOptions) {
+				if err := cache.Invalidate(); err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			cache := NewScanCache(filepath.Join(dir, "scan-cache.json"), DefaultScanCacheTTL)
+			cache.now = func() time.Time { return scannedAt }
+			opts := ScanOptions{DetectorsFile: filepath.Join(dir, "detectors.yaml")}
+			env := types.EnvironmentData{Tools: map[string]string{"Git": "2.43.0"}}
+			if err := cache.Store(ScanFingerprint(opts), env); err != nil {
+				t.Fatal(err)
+			}
+
+			if tc.change != nil {
+				tc.change(t, cache, &opts)
+			}
+			cached, at := cache.Load(ScanFingerprint(opts))
+			if (cached != nil) != tc.expected {
+				t.Fatalf("expected the cached scan to be used %v but got %v", tc.expected, cached != nil)
+			}
+			if cached != nil && (cached.Tools["Git"] != "2.43.0" || !at.Equal(scannedAt)) {
+				t.Errorf("expected the scan stored at %s but got %v from %s", scannedAt, cached.Tools, at)
+			}
+		})
+	}
+}
+
+func TestScanCacheScanReusesScan(t *testing.T) {
+	dir := t.TempDir()
+	cache := NewScanCache(filepath.Join(dir, "scan-cache.json"), time.Hour)
+	opts := ScanOptions{DetectorsFile: filepath.Join(dir, "detectors.yaml")}
+	if err := cache.Store(ScanFingerprint(opts), types.EnvironmentData{Tools: map[string]string{"Git": "2.43.0"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	env, scannedAt, err := cache.Scan(context.Background(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if env.Tools["Git"] != "2.43.0" || scannedAt.IsZero() {
+		t.Errorf("expected the cached scan but got %v from %s", env.Tools, scannedAt)
+	}
+}
@@ -0,0 +1,32 @@
+// Package stackmatch is the stable Go API for embedding StackMatch.
+//
+// It exposes scanning, diffing, planning and installation without writing to
+// stdout or prompting the user. Long-running operations report progress through
+// the Progress interface so callers decide how (or whether) to render it. The
+// stackmatch CLI is built on this package.
+package stackmatch
+
+// Version is the StackMatch release implemented by this package. It is recorded
+// in every scanned environment as stackmatch_version.
+const Version = "0.3.0"
+
+// Progress receives progress notifications from long-running operations
+type Progress interface {
+	// Step is called when an operation starts a new phase
+	Step(message string)
+}
+
+// ProgressFunc adapts an ordinary function to the Progress interface
+type ProgressFunc func(message string)
+
+// Step calls f(message)
+func (f ProgressFunc) Step(message string) {
+	f(message)
+}
+
+// step reports a message to p if it is set
+func step(p Progress, message string) {
+	if p != nil {
+		p.Step(message)
+	}
+}
@@ -0,0 +1,73 @@
+package stackmatch
+
+import (
+	"strings"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+// TargetCategories lists the scan categories whose entries a platform
+// target changes, for scanning just what AddTarget needs
+var TargetCategories = []string{types.CategorySystem, types.CategoryLanguages, types.CategoryTools, types.CategoryPackageManagers, types.CategoryEditors}
+
+// AddTarget records how the scanned environment differs from the entries of
+// env as the target for the scanned platform (see types.PlatformTarget):
+// entries scanned that env lacks are added and entries of env not found are
+// removed. The versions of entries both have stay those of env, and renames
+// are left to the user. It returns the key of the target and whether it
+// replaced one already there.
+func AddTarget(env *types.EnvironmentData, scanned types.EnvironmentData) (string, bool) {
+	base := *env
+	target := &types.PlatformTarget{}
+	categories := []struct {
+		key           string
+		base, scanned map[string]string
+	}{
+		{"configured_languages", base.ConfiguredLanguages, scanned.ConfiguredLanguages},
+		{"tools", base.Tools, scanned.Tools},
+		{"package_managers", base.PackageManagers, scanned.PackageManagers},
+		{"code_editors", base.CodeEditors, scanned.CodeEditors},
+	}
+	for _, category := range categories {
+		for _, name := range sortedKeys(category.scanned) {
+			if hasEntry(&base, name, scanned.ToolID(name)) {
+				continue
+			}
+			if target.Add == nil {
+				target.Add = make(map[string]map[string]string)
+			}
+			if target.Add[category.key] == nil {
+				target.Add[category.key] = make(map[string]string)
+			}
+			target.Add[category.key][name] = category.scanned[name]
+		}
+	}
+	for _, category := range categories {
+		for _, name := range sortedKeys(category.base) {
+			if !hasEntry(&scanned, name, base.ToolID(name)) {
+				target.Remove = append(target.Remove, name)
+			}
+		}
+	}
+
+	key := types.TargetKey(scanned.System.OS, scanned.System.Arch)
+	_, replaced := env.Targets[key]
+	if env.Targets == nil {
+		env.Targets = make(map[string]*types.PlatformTarget)
+	}
+	env.Targets[key] = target
+	return key, replaced
+}
+
+// hasEntry reports whether env has an entry called name, ignoring case, or
+// with the tool ID id
+func hasEntry(env *types.EnvironmentData, name, id string) bool {
+	for _, entries := range classifiable(env) {
+		for entry := range entries {
+			if strings.EqualFold(entry, name) || env.ToolID(entry) == id {
+				return true
+			}
+		}
+	}
+	return false
+}
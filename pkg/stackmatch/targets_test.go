@@ -0,0 +1,55 @@
+package stackmatch
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+func TestAddTarget(t *testing.T) {
+	env := &types.EnvironmentData{
+		System:          types.SystemInfo{OS: "darwin", Arch: "arm64"},
+		Tools:           map[string]string{"Git": "2.45.0", "Docker Desktop": "4.30.0"},
+		PackageManagers: map[string]string{"Homebrew": "4.3.1"},
+		CodeEditors:     map[string]string{"VS Code": "1.89.1"},
+		ToolIDs:         map[string]string{"Docker Desktop": "docker", "VS Code": "vscode"},
+	}
+	scanned := types.EnvironmentData{
+		System:          types.SystemInfo{OS: "linux", Arch: "amd64"},
+		Tools:           map[string]string{"git": "2.43.0", "Docker": "24.0.7", "Make": "4.3"},
+		PackageManagers: map[string]string{"APT": "2.4.11"},
+		CodeEditors:     map[string]string{"Visual Studio Code": "1.89.0"},
+		ToolIDs:         map[string]string{"Visual Studio Code": "vscode"},
+	}
+
+	key, replaced := AddTarget(env, scanned)
+	if key != "linux/amd64" || replaced {
+		t.Fatalf("expected a new linux/amd64 target but got %q (replaced: %v)", key, replaced)
+	}
+	expected := &types.PlatformTarget{
+		Add:    map[string]map[string]string{"tools": {"Make": "4.3"}, "package_managers": {"APT": "2.4.11"}},
+		Remove: []string{"Homebrew"},
+	}
+	if !reflect.DeepEqual(env.Targets[key], expected) {
+		t.Errorf("expected %+v but got %+v", expected, env.Targets[key])
+	}
+
+	// Merging the target back gives the scanned entries at the base versions
+	merged, _ := types.ForPlatform(*env, "linux", "amd64")
+	tools := map[string]string{"Git": "2.45.0", "Docker Desktop": "4.30.0", "Make": "4.3"}
+	if !reflect.DeepEqual(merged.Tools, tools) {
+		t.Errorf("expected tools %v but got %v", tools, merged.Tools)
+	}
+	if managers := map[string]string{"APT": "2.4.11"}; !reflect.DeepEqual(merged.PackageManagers, managers) {
+		t.Errorf("expected package managers %v but got %v", managers, merged.PackageManagers)
+	}
+
+	scanned.Tools["Make"] = "4.4"
+	if _, replaced := AddTarget(env, scanned); !replaced {
+		t.Errorf("expected the linux/amd64 target to be replaced")
+	}
+	if version := env.Targets[key].Add["tools"]["Make"]; version != "4.4" {
+		t.Errorf("expected the new scan to be recorded but got Make %q", version)
+	}
+}
@@ -0,0 +1,106 @@
+package stats
+
+import (
+	"sort"
+	"time"
+)
+
+// CommandUsage counts the invocations of one command
+type CommandUsage struct {
+	Command  string
+	Count    int
+	Failures int
+}
+
+// ManagerFailures is the failure rate of installs through one package manager
+type ManagerFailures struct {
+	Manager  string
+	Runs     int
+	Failures int
+}
+
+// Rate returns the fraction of runs that failed
+func (m ManagerFailures) Rate() float64 {
+	if m.Runs == 0 {
+		return 0
+	}
+	return float64(m.Failures) / float64(m.Runs)
+}
+
+// Aggregates summarizes a set of records
+type Aggregates struct {
+	Total int
+	// Commands is sorted by count, most used first
+	Commands []CommandUsage
+	// AverageImport is the mean duration of import invocations
+	AverageImport time.Duration
+	// Managers is sorted by manager name
+	Managers []ManagerFailures
+	// Counts sums the counts recorded across all invocations
+	Counts map[string]int
+}
+
+// Aggregate computes usage aggregates from records
+func Aggregate(records []Record) Aggregates {
+	agg := Aggregates{Total: len(records), Counts: make(map[string]int)}
+	commands := make(map[string]*CommandUsage)
+	managers := make(map[string]*ManagerFailures)
+	var importTotal time.Duration
+	imports := 0
+
+	for _, rec := range records {
+		usage, ok := commands[rec.Command]
+		if !ok {
+			usage = &CommandUsage{Command: rec.Command}
+			commands[rec.Command] = usage
+		}
+		usage.Count++
+		failed := rec.Result != ResultOK
+		if failed {
+			usage.Failures++
+		}
+
+		if rec.Command == "import" {
+			importTotal += time.Duration(rec.DurationMS) * time.Millisecond
+			imports++
+		}
+
+		if rec.Manager != "" {
+			m, ok := managers[rec.Manager]
+			if !ok {
+				m = &ManagerFailures{Manager: rec.Manager}
+				managers[rec.Manager] = m
+			}
+			m.Runs++
+			if failed {
+				m.Failures++
+			}
+		}
+
+		for name, n := range rec.Counts {
+			agg.Counts[name] += n
+		}
+	}
+
+	for _, usage := range commands {
+		agg.Commands = append(agg.Commands, *usage)
+	}
+	sort.Slice(agg.Commands, func(i, j int) bool {
+		if agg.Commands[i].Count != agg.Commands[j].Count {
+			return agg.Commands[i].Count > agg.Commands[j].Count
+		}
+		return agg.Commands[i].Command < agg.Commands[j].Command
+	})
+
+	for _, m := range managers {
+		agg.Managers = append(agg.Managers, *m)
+	}
+	sort.Slice(agg.Managers, func(i, j int) bool {
+		return agg.Managers[i].Manager < agg.Managers[j].Manager
+	})
+
+	if imports > 0 {
+		agg.AverageImport = importTotal / time.Duration(imports)
+	}
+	return agg
+}
@@ -0,0 +1,142 @@
+// Package stats keeps opt-in, local-only usage statistics. Records hold the
+// command name, timing, outcome and counts; never arguments, flag values or
+// anything else typed by the user. Nothing here touches the network.
+package stats
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/config"
+)
+
+// DefaultMaxSize is the size at which the stats file is rotated
+const DefaultMaxSize int64 = 1 << 20
+
+// Result classes recorded for each invocation
+const (
+	ResultOK       = "ok"
+	ResultCanceled = "canceled"
+	ResultNetwork  = "network"
+	ResultCommand  = "command"
+	ResultError    = "error"
+)
+
+// Record is a single command invocation
+type Record struct {
+	Time       time.Time `json:"time"`
+	Command    string    `json:"command"`
+	DurationMS int64     `json:"duration_ms"`
+	Result     string    `json:"result"`
+	// Manager is the package manager used, for commands that install
+	Manager string         `json:"manager,omitempty"`
+	Counts  map[string]int `json:"counts,omitempty"`
+}
+
+// Classify maps an error to a result class without recording its message
+func Classify(err error) string {
+	if err == nil {
+		return ResultOK
+	}
+	var netErr net.Error
+	var exitErr *exec.ExitError
+	switch {
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return ResultCanceled
+	case errors.As(err, &netErr):
+		return ResultNetwork
+	case errors.As(err, &exitErr):
+		return ResultCommand
+	default:
+		return ResultError
+	}
+}
+
+// Store appends records to a JSON lines file, rotating it to <path>.1 once it
+// grows past MaxSize
+type Store struct {
+	path    string
+	MaxSize int64
+}
+
+// NewStore returns a store writing to path
+func NewStore(path string) *Store {
+	return &Store{path: path, MaxSize: DefaultMaxSize}
+}
+
+// Path returns the current stats file
+func (s *Store) Path() string {
+	return s.path
+}
+
+func (s *Store) rotatedPath() string {
+	return s.path + ".1"
+}
+
+// Append writes rec as one line
+func (s *Store) Append(rec Record) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("failed to encode stats record: %w", err)
+	}
+	data = append(data, '\n')
+
+	if info, err := os.Stat(s.path); err == nil && info.Size()+int64(len(data)) > s.MaxSize {
+		if err := os.Rename(s.path, s.rotatedPath()); err != nil {
+			return fmt.Errorf("failed to rotate stats file: %w", err)
+		}
+	}
+
+	f, err := config.AppendPrivateFile(s.path)
+	if err != nil {
+		return fmt.Errorf("failed to open stats file: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(data); err != nil {
+		return fmt.Errorf("failed to write stats record: %w", err)
+	}
+	return nil
+}
+
+// Read returns every record, oldest first, including the rotated file.
+// Lines that fail to parse are skipped.
+func (s *Store) Read() ([]Record, error) {
+	var records []Record
+	for _, path := range []string{s.rotatedPath(), s.path} {
+		data, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read stats file: %w", err)
+		}
+
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		for scanner.Scan() {
+			var rec Record
+			if err := json.Unmarshal(scanner.Bytes(), &rec); err == nil {
+				records = append(records, rec)
+			}
+		}
+	}
+	return records, nil
+}
+
+// Clear deletes all recorded statistics
+func (s *Store) Clear() error {
+	for _, path := range []string{s.path, s.rotatedPath()} {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove %s: %w", path, err)
+		}
+	}
+	return nil
+}
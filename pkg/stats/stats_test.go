@@ -0,0 +1,91 @@
+package stats
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStoreRotatesAtSizeCap(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "stats.jsonl"))
+	store.MaxSize = 512
+
+	for i := 0; i < 20; i++ {
+		if err := store.Append(Record{Command: "scan", Result: ResultOK, DurationMS: int64(i)}); err != nil {
+			t.Fatalf("append failed: %v", err)
+		}
+	}
+
+	info, err := os.Stat(store.Path())
+	if err != nil {
+		t.Fatalf("stats file missing: %v", err)
+	}
+	if info.Size() > store.MaxSize {
+		t.Errorf("expected stats file to stay under %d bytes, got %d", store.MaxSize, info.Size())
+	}
+	if _, err := os.Stat(store.Path() + ".1"); err != nil {
+		t.Errorf("expected a rotated file: %v", err)
+	}
+
+	records, err := store.Read()
+	if err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	if len(records) == 0 || records[len(records)-1].DurationMS != 19 {
+		t.Errorf("expected the latest record last, got %+v", records)
+	}
+
+	if err := store.Clear(); err != nil {
+		t.Fatalf("clear failed: %v", err)
+	}
+	if records, _ := store.Read(); len(records) != 0 {
+		t.Errorf("expected no records after clear, got %d", len(records))
+	}
+}
+
+func TestAggregate(t *testing.T) {
+	records := []Record{
+		{Command: "scan", Result: ResultOK},
+		{Command: "scan", Result: ResultOK},
+		{Command: "import", Result: ResultOK, DurationMS: 30000, Manager: "apt", Counts: map[string]int{"packages_installed": 4}},
+		{Command: "import", Result: ResultCommand, DurationMS: 10000, Manager: "apt"},
+		{Command: "import", Result: ResultOK, DurationMS: 20000, Manager: "Homebrew", Counts: map[string]int{"packages_installed": 2}},
+		{Command: "check", Result: ResultError},
+	}
+
+	agg := Aggregate(records)
+
+	if agg.Commands[0].Command != "import" || agg.Commands[0].Count != 3 || agg.Commands[0].Failures != 1 {
+		t.Errorf("expected import to be most used with 1 failure, got %+v", agg.Commands[0])
+	}
+	if agg.AverageImport != 20*time.Second {
+		t.Errorf("expected average import of 20s, got %s", agg.AverageImport)
+	}
+	expectedManagers := []ManagerFailures{{"Homebrew", 1, 0}, {"apt", 2, 1}}
+	if fmt.Sprint(agg.Managers) != fmt.Sprint(expectedManagers) {
+		t.Errorf("expected managers %v but got %v", expectedManagers, agg.Managers)
+	}
+	if agg.Counts["packages_installed"] != 6 {
+		t.Errorf("expected 6 packages installed, got %d", agg.Counts["packages_installed"])
+	}
+}
+
+func TestClassify(t *testing.T) {
+	testCases := []struct {
+		err      error
+		expected string
+	}{
+		{nil, ResultOK},
+		{fmt.Errorf("scan: %w", context.Canceled), ResultCanceled},
+		{errors.New("boom"), ResultError},
+	}
+	for _, tc := range testCases {
+		if got := Classify(tc.err); got != tc.expected {
+			t.Errorf("Classify(%v): expected %q but got %q", tc.err, tc.expected, got)
+		}
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/MRQ67/stackmatch-cli/pkg/auth"
+	"github.com/MRQ67/stackmatch-cli/pkg/envfile"
 	"github.com/MRQ67/stackmatch-cli/pkg/types"
 	supabase "github.com/supabase-community/supabase-go"
 )
@@ -65,6 +66,7 @@ func (c *Client) SaveEnvironment(ctx context.Context, env *types.EnvironmentData
 	if env.ScanDate.IsZero() {
 		env.ScanDate = time.Now()
 	}
+	types.RefreshSummary(env)
 
 	// Convert environment data to JSON
 	envJSON, err := json.Marshal(env)
@@ -72,6 +74,12 @@ func (c *Client) SaveEnvironment(ctx context.Context, env *types.EnvironmentData
 		return "", fmt.Errorf("failed to marshal environment data: %w", err)
 	}
 
+	// Pull verifies the data it downloads against this checksum
+	checksum, err := envfile.Checksum(envJSON)
+	if err != nil {
+		return "", fmt.Errorf("failed to checksum environment data: %w", err)
+	}
+
 	// Log the data being saved (truncated for brevity)
 	log.Printf("Saving environment data (truncated): %s", string(envJSON)[:min(100, len(envJSON))])
 
@@ -86,13 +94,30 @@ func (c *Client) SaveEnvironment(ctx context.Context, env *types.EnvironmentData
 		"data":      json.RawMessage(envJSON),
 		"is_public": isPublic,
 		"user_id":   userID,
+		// Row-level copy so listings and search can show counts without
+		// fetching the data blob
+		"summary":     env.Summary,
+		"size":        len(envJSON),
+		"data_sha256": checksum,
 	}
 
-	// Insert the data using the authenticated client
+	// Insert the data using the authenticated client. Optional columns the
+	// project's table lacks are left out and the insert tried again.
+	if err := Writes.Wait(ctx); err != nil {
+		return "", err
+	}
 	var result []map[string]interface{}
-	_, err = c.Client.From("environments").
-		Insert(insertData, false, "", "", "").
-		ExecuteTo(&result)
+	for {
+		_, err = c.Client.From("environments").
+			Insert(insertData, false, "", "", "").
+			ExecuteTo(&result)
+		column, missing := missingColumn(err)
+		if _, sent := insertData[column]; !missing || !sent {
+			break
+		}
+		log.Printf("Warning: the environments table has no %s column; see the README to add it", column)
+		delete(insertData, column)
+	}
 
 	if err != nil {
 		return "", fmt.Errorf("failed to save environment: %w", err)
@@ -113,10 +138,11 @@ func (c *Client) SaveEnvironment(ctx context.Context, env *types.EnvironmentData
 
 // envRow represents a row in the environments table
 type envRow struct {
-	ID        string          `json:"id"`
-	CreatedAt string          `json:"created_at"`
-	UpdatedAt string          `json:"updated_at"`
-	Data      json.RawMessage `json:"data"`
+	ID         string          `json:"id"`
+	CreatedAt  string          `json:"created_at"`
+	UpdatedAt  string          `json:"updated_at"`
+	Data       json.RawMessage `json:"data"`
+	DataSHA256 string          `json:"data_sha256"`
 }
 
 // GetEnvironment retrieves an environment from Supabase by ID
@@ -128,11 +154,14 @@ func (c *Client) GetEnvironment(ctx context.Context, id string) (*types.Environm
 		id = id[3:]
 	}
 
-	// Select only the data column and filter by ID
-	_, err := c.From("environments").
-		Select("data", "", false).
-		Eq("id", id).
-		ExecuteTo(&rows)
+	// Select only the data column and its checksum, and filter by ID
+	err := selectColumns([]string{"data", "data_sha256"}, func(columns string) error {
+		_, err := c.From("environments").
+			Select(columns, "", false).
+			Eq("id", id).
+			ExecuteTo(&rows)
+		return err
+	})
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to get environment from Supabase: %w", err)
@@ -142,13 +171,12 @@ func (c *Client) GetEnvironment(ctx context.Context, id string) (*types.Environm
 		return nil, fmt.Errorf("environment not found with id: %s", id)
 	}
 
-	// Unmarshal the JSON data into EnvironmentData
-	var envData types.EnvironmentData
-	if err := json.Unmarshal(rows[0].Data, &envData); err != nil {
+	envData, err := ParseStored(rows[0].Data, rows[0].DataSHA256, "environment "+id, envfile.Options{})
+	if err != nil {
 		return nil, fmt.Errorf("failed to unmarshal environment data: %w", err)
 	}
 
-	return &envData, nil
+	return envData, nil
 }
 
 // ListEnvironments retrieves a list of all environments for a given user
@@ -179,6 +207,7 @@ func (c *Client) ListEnvironments(ctx context.Context, userID string) ([]types.E
 		if err := json.Unmarshal(row.Data, &envData); err != nil {
 			return nil, fmt.Errorf("failed to unmarshal environment data: %w", err)
 		}
+		types.RefreshSummary(&envData)
 		envs = append(envs, types.Environment{
 			Name:     row.Name,
 			Username: username,
@@ -230,6 +259,9 @@ type envWithData struct {
 	Data      json.RawMessage `json:"data"`
 	CreatedAt string          `json:"created_at"`
 	UpdatedAt string          `json:"updated_at"`
+	// DataSHA256 is the checksum of Data recorded on push, empty for rows
+	// pushed before checksums were recorded
+	DataSHA256 string `json:"data_sha256"`
 }
 
 // FindEnvironmentByUserAndName finds an environment by username and environment name
@@ -270,17 +302,20 @@ func (c *Client) FindEnvironmentByUserAndName(ctx context.Context, username, env
 		return nil, fmt.Errorf("environment '%s' not found for user '%s'", envName, username)
 	}
 
-	// Unmarshal the JSON data into EnvironmentData
-	var envData types.EnvironmentData
-	if err := json.Unmarshal(envRows[0].Data, &envData); err != nil {
+	envData, err := ParseStored(envRows[0].Data, envRows[0].DataSHA256, fmt.Sprintf("environment %s/%s", username, envName), envfile.Options{})
+	if err != nil {
 		return nil, fmt.Errorf("failed to unmarshal environment data: %w", err)
 	}
 
-	return &envData, nil
+	return envData, nil
 }
 
 // DeleteEnvironment deletes an environment from Supabase by name
 func (c *Client) DeleteEnvironment(ctx context.Context, name string, userID string) error {
+	if err := Writes.Wait(ctx); err != nil {
+		return err
+	}
+
 	// Delete the environment by name and user ID
 	_, _, err := c.From("environments").Delete("", "").Eq("name", name).Eq("user_id", userID).Execute() 
 	if err != nil {
@@ -318,6 +353,7 @@ func (c *Client) SearchEnvironments(ctx context.Context, query string) ([]types.
 		if err := json.Unmarshal(row.Data, &envData); err != nil {
 			return nil, fmt.Errorf("failed to unmarshal environment data: %w", err)
 		}
+		types.RefreshSummary(&envData)
 		envs = append(envs, types.Environment{
 			Name:     row.Name,
 			Username: username,
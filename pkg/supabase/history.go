@@ -0,0 +1,76 @@
+package supabase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/envfile"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+// Snapshot is one pushed version of an environment, as recorded in the
+// environment_history table
+type Snapshot struct {
+	Version   int
+	CreatedAt time.Time
+	UpdatedBy string
+	Data      *types.EnvironmentData
+}
+
+// EnvironmentVersions returns the version numbers recorded for the
+// environment with ID envID, oldest first. Only the version column is
+// fetched.
+func (c *Client) EnvironmentVersions(ctx context.Context, envID string) ([]int, error) {
+	var rows []struct {
+		Version int `json:"version"`
+	}
+	_, err := c.From("environment_history").Select("version", "", false).Eq("environment_id", envID).ExecuteTo(&rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get environment history: %w", err)
+	}
+	versions := make([]int, 0, len(rows))
+	for _, row := range rows {
+		versions = append(versions, row.Version)
+	}
+	sort.Ints(versions)
+	return versions, nil
+}
+
+// EnvironmentSnapshot fetches a version of the environment with ID envID. It
+// returns nil when that version was not recorded.
+func (c *Client) EnvironmentSnapshot(ctx context.Context, envID string, version int) (*Snapshot, error) {
+	var rows []struct {
+		Version   int             `json:"version"`
+		CreatedAt time.Time       `json:"created_at"`
+		UpdatedBy string          `json:"updated_by"`
+		Data      json.RawMessage `json:"data"`
+	}
+	_, err := c.From("environment_history").
+		Select("version,created_at,updated_by,data", "", false).
+		Eq("environment_id", envID).
+		Eq("version", strconv.Itoa(version)).
+		ExecuteTo(&rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get version %d: %w", version, err)
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	row := rows[0]
+	// Older rows hold the data as a JSON string rather than an object
+	data := []byte(row.Data)
+	var text string
+	if json.Unmarshal(row.Data, &text) == nil {
+		data = []byte(text)
+	}
+	env, err := envfile.Parse(data, envfile.Options{Repair: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal version %d: %w", version, err)
+	}
+	return &Snapshot{Version: row.Version, CreatedAt: row.CreatedAt, UpdatedBy: row.UpdatedBy, Data: env}, nil
+}
@@ -0,0 +1,58 @@
+package supabase
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestEnvironmentHistory(t *testing.T) {
+	rows := &fakeRows{history: []map[string]any{
+		{"environment_id": "id-1", "version": 3, "created_at": "2026-10-01T09:00:00Z", "updated_by": "user-1",
+			"data": map[string]any{"stackmatch_version": "0.3.0", "tools": map[string]any{"Git": "2.43.0"}}},
+		{"environment_id": "id-1", "version": 1, "created_at": "2026-09-01T09:00:00Z", "updated_by": "user-1",
+			"data": `{"stackmatch_version":"0.3.0","tools":{"Git":"2.40.1"}}`},
+		{"environment_id": "id-2", "version": 2, "created_at": "2026-09-02T09:00:00Z", "data": `{}`},
+	}}
+	client := newFakeClient(t, rows)
+	ctx := context.Background()
+
+	versions, err := client.EnvironmentVersions(ctx, "id-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(versions, []int{1, 3}) {
+		t.Errorf("expected versions [1 3] but got %v", versions)
+	}
+
+	testCases := []struct {
+		name    string
+		version int
+		git     string
+	}{
+		{name: "data stored as an object", version: 3, git: "2.43.0"},
+		{name: "data stored as a string", version: 1, git: "2.40.1"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			snapshot, err := client.EnvironmentSnapshot(ctx, "id-1", tc.version)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if snapshot == nil {
+				t.Fatalf("expected version %d to be found", tc.version)
+			}
+			if snapshot.Version != tc.version || snapshot.CreatedAt.IsZero() {
+				t.Errorf("expected version %d with its date but got %+v", tc.version, snapshot)
+			}
+			if snapshot.Data.Tools["Git"] != tc.git {
+				t.Errorf("expected Git %s but got %q", tc.git, snapshot.Data.Tools["Git"])
+			}
+		})
+	}
+
+	snapshot, err := client.EnvironmentSnapshot(ctx, "id-1", 2)
+	if err != nil || snapshot != nil {
+		t.Errorf("expected no snapshot of a missing version but got %+v, %v", snapshot, err)
+	}
+}
@@ -0,0 +1,33 @@
+package supabase
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/envfile"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+// Notice, when set, is told about environments downloaded without being
+// verified, because they were pushed before checksums were recorded
+var Notice func(message string)
+
+// ParseStored parses the data column of a stored environment, described by
+// what in notices, after verifying it against checksum, the row's
+// data_sha256 column. Stored data can't be fixed by hand, so stray text
+// around it is skipped, but only in rows without a checksum: those are
+// parsed unverified and Notice is told.
+func ParseStored(data []byte, checksum, what string, opts envfile.Options) (*types.EnvironmentData, error) {
+	if checksum == "" {
+		if Notice != nil {
+			Notice(fmt.Sprintf("%s was pushed before checksums were recorded, so it could not be checked for corruption", what))
+		}
+		opts.Repair = true
+	}
+	opts.Checksum = checksum
+	env, err := envfile.Parse(data, opts)
+	if errors.Is(err, envfile.ErrCorrupted) {
+		return nil, fmt.Errorf("%w; try again, and if it keeps failing, push the environment again", err)
+	}
+	return env, err
+}
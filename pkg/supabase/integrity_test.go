@@ -0,0 +1,56 @@
+package supabase
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/envfile"
+)
+
+func TestGetEnvironmentVerifiesChecksum(t *testing.T) {
+	data := `{"stackmatch_version":"0.3.0","system":{"os":"linux","arch":"amd64"},"tools":{"Git":"2.43.0"}}`
+	checksum, err := envfile.Checksum([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name      string
+		row       map[string]any
+		corrupted bool
+		notice    bool
+	}{
+		{name: "Matching", row: map[string]any{"data": data, "data_sha256": checksum}},
+		{name: "Mismatching", row: map[string]any{"data": strings.Replace(data, "2.43.0", "2.43.1", 1), "data_sha256": checksum}, corrupted: true},
+		{name: "Pushed before checksums", row: map[string]any{"data": data}, notice: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var notices []string
+			Notice = func(message string) { notices = append(notices, message) }
+			defer func() { Notice = nil }()
+			tc.row["id"] = "id-1"
+			client := newFakeClient(t, &fakeRows{environments: []map[string]any{tc.row}})
+
+			env, err := client.GetEnvironment(context.Background(), "id-1")
+			if tc.corrupted {
+				if !errors.Is(err, envfile.ErrCorrupted) || !strings.Contains(err.Error(), "try again") {
+					t.Fatalf("expected a corruption error suggesting a retry but got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if env.Tools["Git"] != "2.43.0" {
+				t.Errorf("expected Git 2.43.0 but got %q", env.Tools["Git"])
+			}
+			if tc.notice != (len(notices) == 1) {
+				t.Errorf("expected a notice %v but got %v", tc.notice, notices)
+			}
+		})
+	}
+}
@@ -0,0 +1,179 @@
+package supabase
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+	"github.com/supabase-community/postgrest-go"
+)
+
+// DefaultPageLimit is the number of rows listed when no limit is given
+const DefaultPageLimit = 50
+
+// Page selects a window of rows. A Limit of zero or less returns every row
+// from Offset on.
+type Page struct {
+	Limit  int
+	Offset int
+}
+
+// EnvironmentInfo is the metadata of a stored environment, without its data
+type EnvironmentInfo struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	UserID    string    `json:"user_id"`
+	IsPublic  bool      `json:"is_public"`
+	CreatedAt time.Time `json:"created_at"`
+	// Size is the length of the stored data in bytes. Zero for rows pushed
+	// before it was recorded.
+	Size     int            `json:"size"`
+	Summary  *types.Summary `json:"summary,omitempty"`
+	Username string         `json:"-"`
+}
+
+// infoColumns are the columns selected for metadata listings; the data blob is
+// left out so listing stays cheap with many environments
+const infoColumns = "id,name,user_id,is_public,created_at,size,summary"
+
+// baseInfoColumns are infoColumns without the optional columns, for projects
+// whose environments table predates them
+const baseInfoColumns = "id,name,user_id,is_public,created_at"
+
+// optionalColumns are the columns of the environments table added after it
+// was first published. Projects that haven't added them still work, without
+// sizes and summaries in listings and without checking downloaded data.
+var optionalColumns = []string{"size", "summary", "data_sha256"}
+
+// missingColumn returns the optional column err reports the environments
+// table doesn't have, if any. PostgREST answers 42703 when a selected
+// column doesn't exist and PGRST204 when an inserted one doesn't.
+func missingColumn(err error) (string, bool) {
+	if err == nil {
+		return "", false
+	}
+	message := err.Error()
+	if !strings.Contains(message, "42703") && !strings.Contains(message, "PGRST204") {
+		return "", false
+	}
+	for _, column := range optionalColumns {
+		if strings.Contains(message, "environments."+column) || strings.Contains(message, "'"+column+"'") {
+			return column, true
+		}
+	}
+	return "", false
+}
+
+// selectInfo runs the query build returns with infoColumns, or with
+// baseInfoColumns when the table lacks an optional column
+func selectInfo(build func(columns string) *postgrest.FilterBuilder, infos *[]EnvironmentInfo) (int64, error) {
+	total, err := build(infoColumns).ExecuteTo(infos)
+	if _, missing := missingColumn(err); missing {
+		total, err = build(baseInfoColumns).ExecuteTo(infos)
+	}
+	return total, err
+}
+
+// selectColumns runs the query run makes with columns, comma separated,
+// leaving out each optional column the table lacks and trying again
+func selectColumns(columns []string, run func(columns string) error) error {
+	for {
+		err := run(strings.Join(columns, ","))
+		column, missing := missingColumn(err)
+		if !missing || !slices.Contains(columns, column) {
+			return err
+		}
+		columns = slices.DeleteFunc(slices.Clone(columns), func(c string) bool { return c == column })
+	}
+}
+
+// ListEnvironmentInfo returns one page of the user's environments, newest
+// first, and the total number of environments the user has
+func (c *Client) ListEnvironmentInfo(ctx context.Context, userID string, page Page) ([]EnvironmentInfo, int, error) {
+	query := func(columns string) *postgrest.FilterBuilder {
+		return withPage(c.From("environments").
+			Select(columns, "exact", false).
+			Eq("user_id", userID).
+			Order("created_at", nil), page)
+	}
+
+	var infos []EnvironmentInfo
+	total, err := selectInfo(query, &infos)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list environments: %w", err)
+	}
+	return infos, int(total), nil
+}
+
+// SearchEnvironmentInfo returns one page of the public environments whose
+// name matches query, with their owners' usernames, and the total number of matches
+func (c *Client) SearchEnvironmentInfo(ctx context.Context, query string, page Page) ([]EnvironmentInfo, int, error) {
+	filter := func(columns string) *postgrest.FilterBuilder {
+		filter := c.From("environments").
+			Select(columns, "exact", false).
+			Eq("is_public", "true")
+		if query != "" {
+			filter = filter.TextSearch("name", query, "", "")
+		}
+		return withPage(filter.Order("created_at", nil), page)
+	}
+
+	var infos []EnvironmentInfo
+	total, err := selectInfo(filter, &infos)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to search environments: %w", err)
+	}
+
+	if err := c.fillUsernames(infos); err != nil {
+		return nil, 0, err
+	}
+	return infos, int(total), nil
+}
+
+// maxRow bounds open-ended ranges, which PostgREST expresses as offset+limit
+const maxRow = 1<<31 - 2
+
+// withPage restricts query to the rows selected by page
+func withPage(query *postgrest.FilterBuilder, page Page) *postgrest.FilterBuilder {
+	switch {
+	case page.Limit > 0:
+		return query.Range(page.Offset, page.Offset+page.Limit-1, "")
+	case page.Offset > 0:
+		return query.Range(page.Offset, maxRow, "")
+	}
+	return query
+}
+
+// fillUsernames looks up the owners of infos with a single profiles query
+func (c *Client) fillUsernames(infos []EnvironmentInfo) error {
+	if len(infos) == 0 {
+		return nil
+	}
+	seen := make(map[string]bool)
+	var ids []string
+	for _, info := range infos {
+		if !seen[info.UserID] {
+			seen[info.UserID] = true
+			ids = append(ids, info.UserID)
+		}
+	}
+
+	var profiles []struct {
+		ID       string `json:"id"`
+		Username string `json:"username"`
+	}
+	if _, err := c.From("profiles").Select("id,username", "", false).In("id", ids).ExecuteTo(&profiles); err != nil {
+		return fmt.Errorf("failed to get usernames: %w", err)
+	}
+	usernames := make(map[string]string, len(profiles))
+	for _, p := range profiles {
+		usernames[p.ID] = p.Username
+	}
+	for i := range infos {
+		infos[i].Username = usernames[infos[i].UserID]
+	}
+	return nil
+}
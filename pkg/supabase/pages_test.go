@@ -0,0 +1,311 @@
+package supabase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/auth"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+// fakeRows serves the environments, environment_history and profiles tables
+// like PostgREST, honoring offset and limit and reporting the exact count in
+// Content-Range
+type fakeRows struct {
+	environments []map[string]any
+	history      []map[string]any
+	profiles     []map[string]any
+	// missing are the columns the environments table lacks, as in projects
+	// created before they were added
+	missing []string
+
+	mu       sync.Mutex
+	requests []string
+}
+
+func (f *fakeRows) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	f.requests = append(f.requests, r.Method+" "+r.URL.Path+"?"+r.URL.RawQuery)
+	f.mu.Unlock()
+
+	if strings.HasSuffix(r.URL.Path, "/environments") && r.Method == http.MethodPost {
+		f.insert(w, r)
+		return
+	}
+	if strings.HasSuffix(r.URL.Path, "/environments") {
+		for _, column := range strings.Split(r.URL.Query().Get("select"), ",") {
+			if slices.Contains(f.missing, column) {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(map[string]string{"code": "42703", "message": "column environments." + column + " does not exist"})
+				return
+			}
+		}
+	}
+
+	var table []map[string]any
+	switch {
+	case strings.HasSuffix(r.URL.Path, "/environments"):
+		table = f.environments
+	case strings.HasSuffix(r.URL.Path, "/environment_history"):
+		table = f.history
+	case strings.HasSuffix(r.URL.Path, "/profiles"):
+		table = f.profiles
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	rows := selectRows(table, r.URL.Query())
+
+	total := len(rows)
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil {
+		limit = total
+	}
+	start := min(offset, total)
+	end := min(start+limit, total)
+	page := rows[start:end]
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Range", fmt.Sprintf("%d-%d/%d", start, max(end-1, start), total))
+	json.NewEncoder(w).Encode(page)
+}
+
+// insert adds the posted row to the environments table and answers it with
+// an ID, or rejects it like PostgREST when it has a missing column
+func (f *fakeRows) insert(w http.ResponseWriter, r *http.Request) {
+	var row map[string]any
+	if err := json.NewDecoder(r.Body).Decode(&row); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	for _, column := range f.missing {
+		if _, ok := row[column]; ok {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"code": "PGRST204", "message": "Could not find the '" + column + "' column of 'environments' in the schema cache"})
+			return
+		}
+	}
+	f.mu.Lock()
+	row["id"] = fmt.Sprintf("id-%d", len(f.environments))
+	f.environments = append(f.environments, row)
+	f.mu.Unlock()
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode([]map[string]any{row})
+}
+
+// selectRows applies eq filters and the select column list to table
+func selectRows(table []map[string]any, params url.Values) []map[string]any {
+	var columns []string
+	if sel := params.Get("select"); sel != "" && sel != "*" {
+		columns = strings.Split(sel, ",")
+	}
+
+	var rows []map[string]any
+	for _, row := range table {
+		matches := true
+		for key, values := range params {
+			if value, ok := strings.CutPrefix(values[0], "eq."); ok && fmt.Sprint(row[key]) != value {
+				matches = false
+			}
+		}
+		if !matches {
+			continue
+		}
+		if columns == nil {
+			rows = append(rows, row)
+			continue
+		}
+		selected := make(map[string]any, len(columns))
+		for _, column := range columns {
+			if value, ok := row[column]; ok {
+				selected[column] = value
+			}
+		}
+		rows = append(rows, selected)
+	}
+	return rows
+}
+
+func newFakeClient(t *testing.T, rows *fakeRows) *Client {
+	t.Helper()
+	server := httptest.NewServer(rows)
+	t.Cleanup(server.Close)
+	client, err := NewClient(server.URL, "test-key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func environments(n int) []map[string]any {
+	rows := make([]map[string]any, n)
+	for i := range rows {
+		rows[i] = map[string]any{
+			"id":         fmt.Sprintf("id-%d", i),
+			"name":       fmt.Sprintf("env-%d", i),
+			"user_id":    fmt.Sprintf("user-%d", i%2),
+			"is_public":  true,
+			"created_at": "2026-10-16T09:00:00Z",
+			"size":       100 + i,
+		}
+	}
+	return rows
+}
+
+func TestListEnvironmentInfo(t *testing.T) {
+	testCases := []struct {
+		name     string
+		page     Page
+		expected []string
+	}{
+		{name: "first page", page: Page{Limit: 2}, expected: []string{"env-0", "env-1"}},
+		{name: "second page", page: Page{Limit: 2, Offset: 2}, expected: []string{"env-2", "env-3"}},
+		{name: "last partial page", page: Page{Limit: 2, Offset: 4}, expected: []string{"env-4"}},
+		{name: "past the end", page: Page{Limit: 2, Offset: 10}, expected: nil},
+		{name: "no limit", page: Page{}, expected: []string{"env-0", "env-1", "env-2", "env-3", "env-4"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Five environments of user-0 and one of someone else
+			envs := environments(6)
+			for i := range envs[:5] {
+				envs[i]["user_id"] = "user-0"
+			}
+			envs[5]["user_id"] = "user-9"
+			rows := &fakeRows{environments: envs}
+			client := newFakeClient(t, rows)
+
+			infos, total, err := client.ListEnvironmentInfo(context.Background(), "user-0", tc.page)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if total != 5 {
+				t.Errorf("expected a total of 5 but got %d", total)
+			}
+			var names []string
+			for _, info := range infos {
+				names = append(names, info.Name)
+			}
+			if strings.Join(names, ",") != strings.Join(tc.expected, ",") {
+				t.Errorf("expected %v but got %v", tc.expected, names)
+			}
+
+			query := rows.requests[0]
+			if strings.Contains(query, "data") {
+				t.Errorf("expected the data column not to be selected, but got %s", query)
+			}
+		})
+	}
+}
+
+func TestSearchEnvironmentInfoFillsUsernames(t *testing.T) {
+	rows := &fakeRows{
+		environments: environments(3),
+		profiles: []map[string]any{
+			{"id": "user-0", "username": "ada"},
+			{"id": "user-1", "username": "linus"},
+		},
+	}
+	client := newFakeClient(t, rows)
+
+	infos, total, err := client.SearchEnvironmentInfo(context.Background(), "env", Page{Limit: 50})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 3 || len(infos) != 3 {
+		t.Fatalf("expected 3 of 3 results but got %d of %d", len(infos), total)
+	}
+	expected := []string{"ada", "linus", "ada"}
+	for i, info := range infos {
+		if info.Username != expected[i] {
+			t.Errorf("expected %s to belong to %s but got %q", info.Name, expected[i], info.Username)
+		}
+		if info.Size != 100+i {
+			t.Errorf("expected %s to have size %d but got %d", info.Name, 100+i, info.Size)
+		}
+	}
+	if len(rows.requests) != 2 {
+		t.Errorf("expected one environments and one profiles request but got %v", rows.requests)
+	}
+}
+
+func TestOptionalColumnsMissing(t *testing.T) {
+	rows := &fakeRows{environments: environments(2), missing: []string{"size", "summary"}}
+	for _, env := range rows.environments {
+		delete(env, "size")
+	}
+	client := newFakeClient(t, rows)
+
+	infos, total, err := client.ListEnvironmentInfo(context.Background(), "user-0", Page{Limit: 50})
+	if err != nil {
+		t.Fatalf("expected listing to fall back to the base columns but got %v", err)
+	}
+	if total != 1 || len(infos) != 1 || infos[0].Name != "env-0" || infos[0].Size != 0 {
+		t.Errorf("expected env-0 without a size but got %+v of %d", infos, total)
+	}
+
+	// Retrying without the missing columns is still one write
+	defer func(writes *RateLimiter) { Writes = writes }(Writes)
+	clock := &fakeClock{now: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)}
+	Writes = clock.attach(NewRateLimiter(1, 1, ""))
+
+	ctx := context.WithValue(context.Background(), "user", &auth.User{ID: "user-0"})
+	id, err := client.SaveEnvironment(ctx, &types.EnvironmentData{StackmatchVersion: "1.0.0"}, "laptop", false)
+	if err != nil {
+		t.Fatalf("expected the push to leave out the missing columns but got %v", err)
+	}
+	if len(clock.sleeps) != 0 {
+		t.Errorf("expected the retries not to wait for the rate limit but they waited %v", clock.sleeps)
+	}
+	saved := rows.environments[len(rows.environments)-1]
+	if id != saved["id"] || saved["data_sha256"] == nil {
+		t.Errorf("expected %s to be saved with its checksum but got %+v", id, saved)
+	}
+	for _, column := range rows.missing {
+		if _, ok := saved[column]; ok {
+			t.Errorf("expected %s to be left out but got %+v", column, saved)
+		}
+	}
+}
+
+func TestChecksumColumnMissing(t *testing.T) {
+	rows := &fakeRows{environments: environments(1), missing: []string{"data_sha256"}}
+	rows.environments[0]["data"] = map[string]any{"stackmatch_version": "1.0.0", "tools": map[string]any{"Git": "2.43.0"}}
+	client := newFakeClient(t, rows)
+
+	env, err := client.GetEnvironment(context.Background(), "id-0")
+	if err != nil {
+		t.Fatalf("expected the pull to go without the checksum but got %v", err)
+	}
+	if env.Tools["Git"] != "2.43.0" {
+		t.Errorf("expected the stored data but got %+v", env)
+	}
+
+	details, err := client.ShowEnvironment(context.Background(), EnvironmentRef{ID: "id-0", Username: "jane"}, true)
+	if err != nil {
+		t.Fatalf("expected show to go without the checksum but got %v", err)
+	}
+	if details.Data == nil || details.Data.Tools["Git"] != "2.43.0" {
+		t.Errorf("expected the stored data but got %+v", details)
+	}
+
+	ctx := context.WithValue(context.Background(), "user", &auth.User{ID: "user-0"})
+	if _, err := client.SaveEnvironment(ctx, &types.EnvironmentData{StackmatchVersion: "1.0.0"}, "laptop", false); err != nil {
+		t.Fatalf("expected the push to leave out the checksum but got %v", err)
+	}
+	if saved := rows.environments[len(rows.environments)-1]; saved["data_sha256"] != nil {
+		t.Errorf("expected the checksum to be left out but got %+v", saved)
+	}
+}
@@ -0,0 +1,163 @@
+package supabase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/config"
+)
+
+// Writes to Supabase are limited to DefaultWritesPerMinute, with bursts of
+// DefaultWriteBurst, unless the configuration sets other values
+const (
+	DefaultWritesPerMinute = 30
+	DefaultWriteBurst      = 10
+)
+
+// Writes limits the writes of every Client, such as pushes and deletes. The
+// CLI replaces it with a limiter configured from config.json; nil lets every
+// write through.
+var Writes = NewRateLimiter(DefaultWritesPerMinute, DefaultWriteBurst, "")
+
+// RateLimiter spaces out writes with a token bucket: Burst writes go through
+// at once, after which one more is allowed every minute divided by
+// PerMinute. A limiter with no PerMinute lets every write through.
+type RateLimiter struct {
+	PerMinute int
+	Burst     int
+	// Path, when set, keeps the bucket in a file, so that separate runs such
+	// as scheduled pushes share it. Runs that write at the same moment may
+	// each read it before the other saves it, letting one extra write
+	// through.
+	Path string
+
+	mu     sync.Mutex
+	bucket *rateBucket
+	now    func() time.Time
+	sleep  func(ctx context.Context, d time.Duration) error
+}
+
+// rateBucket is the state of a RateLimiter. Tokens is negative while writes
+// are waiting for the tokens they have taken.
+type rateBucket struct {
+	Tokens    float64   `json:"tokens"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// NewRateLimiter returns a limiter of perMinute writes with bursts of burst,
+// kept in path unless it is ""
+func NewRateLimiter(perMinute, burst int, path string) *RateLimiter {
+	return &RateLimiter{PerMinute: perMinute, Burst: burst, Path: path}
+}
+
+// Wait blocks until a write may go through, telling Notice when it has to
+// wait so a delayed command doesn't look hung, and fails if ctx ends first
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	if l == nil || l.PerMinute <= 0 {
+		return nil
+	}
+	l.mu.Lock()
+	delay := l.reserve()
+	l.mu.Unlock()
+	if delay <= 0 {
+		return nil
+	}
+
+	if Notice != nil {
+		Notice(fmt.Sprintf("waiting %s to respect the rate limit of %d writes a minute", delay.Round(time.Second), l.PerMinute))
+	}
+	sleep := l.sleep
+	if sleep == nil {
+		sleep = sleepContext
+	}
+	if err := sleep(ctx, delay); err != nil {
+		// The write won't happen, so the next one needn't wait for it
+		l.mu.Lock()
+		l.refund()
+		l.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// reserve takes a token from the bucket, refilled for the time since it was
+// last used, and returns how long the write has to wait for it
+func (l *RateLimiter) reserve() time.Duration {
+	now := time.Now()
+	if l.now != nil {
+		now = l.now()
+	}
+	burst := float64(max(l.Burst, 1))
+	interval := time.Minute / time.Duration(l.PerMinute)
+
+	bucket := l.load(now, burst)
+	if elapsed := now.Sub(bucket.UpdatedAt); elapsed > 0 {
+		bucket.Tokens = min(burst, bucket.Tokens+float64(elapsed)/float64(interval))
+	}
+	bucket.UpdatedAt = now
+	bucket.Tokens--
+	l.save(bucket)
+
+	if bucket.Tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-bucket.Tokens * float64(interval))
+}
+
+// refund gives back the token reserve took for a write that gave up waiting
+func (l *RateLimiter) refund() {
+	now := time.Now()
+	if l.now != nil {
+		now = l.now()
+	}
+	burst := float64(max(l.Burst, 1))
+	bucket := l.load(now, burst)
+	bucket.Tokens = min(burst, bucket.Tokens+1)
+	l.save(bucket)
+}
+
+// load returns the bucket, full when it was never used or its file can't be
+// read
+func (l *RateLimiter) load(now time.Time, burst float64) *rateBucket {
+	if l.Path == "" {
+		if l.bucket == nil {
+			l.bucket = &rateBucket{Tokens: burst, UpdatedAt: now}
+		}
+		return l.bucket
+	}
+	bucket := &rateBucket{Tokens: burst, UpdatedAt: now}
+	if data, err := os.ReadFile(l.Path); err == nil {
+		if err := json.Unmarshal(data, bucket); err != nil {
+			bucket = &rateBucket{Tokens: burst, UpdatedAt: now}
+		}
+	}
+	return bucket
+}
+
+// save keeps bucket for the next write. Failing to write the file only
+// means the next run starts with a full bucket.
+func (l *RateLimiter) save(bucket *rateBucket) {
+	if l.Path == "" {
+		l.bucket = bucket
+		return
+	}
+	if data, err := json.Marshal(bucket); err == nil {
+		_ = config.WritePrivateFile(l.Path, data)
+	}
+}
+
+// sleepContext waits for d or until ctx ends
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,127 @@
+package supabase
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeClock stands in for time in a RateLimiter: sleeping advances it
+type fakeClock struct {
+	now    time.Time
+	sleeps []time.Duration
+}
+
+func (c *fakeClock) attach(l *RateLimiter) *RateLimiter {
+	l.now = func() time.Time { return c.now }
+	l.sleep = func(_ context.Context, d time.Duration) error {
+		c.sleeps = append(c.sleeps, d)
+		c.now = c.now.Add(d)
+		return nil
+	}
+	return l
+}
+
+func TestRateLimiter(t *testing.T) {
+	var notices []string
+	defer func(notice func(string)) { Notice = notice }(Notice)
+	Notice = func(message string) { notices = append(notices, message) }
+
+	clock := &fakeClock{now: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)}
+	limiter := clock.attach(NewRateLimiter(6, 2, ""))
+
+	// The burst goes through, then one write every 10s
+	for range 4 {
+		if err := limiter.Wait(context.Background()); err != nil {
+			t.Fatalf("expected the write to wait but got %v", err)
+		}
+	}
+	expected := []time.Duration{10 * time.Second, 10 * time.Second}
+	if !reflect.DeepEqual(clock.sleeps, expected) {
+		t.Errorf("expected waits %v but got %v", expected, clock.sleeps)
+	}
+	if len(notices) != 2 || notices[0] != "waiting 10s to respect the rate limit of 6 writes a minute" {
+		t.Errorf("expected a note for each wait but got %q", notices)
+	}
+
+	// A quiet minute refills the bucket up to the burst
+	clock.now = clock.now.Add(time.Minute)
+	clock.sleeps = nil
+	for range 2 {
+		if err := limiter.Wait(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(clock.sleeps) != 0 {
+		t.Errorf("expected a refilled burst to go through at once but it waited %v", clock.sleeps)
+	}
+}
+
+func TestRateLimiterSharedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rate-limit.json")
+	clock := &fakeClock{now: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)}
+
+	// Each run has its own limiter, as separate pushes do
+	for range 3 {
+		if err := clock.attach(NewRateLimiter(30, 3, path)).Wait(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(clock.sleeps) != 0 {
+		t.Fatalf("expected the burst to go through at once but it waited %v", clock.sleeps)
+	}
+	if err := clock.attach(NewRateLimiter(30, 3, path)).Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if expected := []time.Duration{2 * time.Second}; !reflect.DeepEqual(clock.sleeps, expected) {
+		t.Errorf("expected a run after the burst to wait %v but got %v", expected, clock.sleeps)
+	}
+}
+
+func TestRateLimiterCancelled(t *testing.T) {
+	limiter := NewRateLimiter(1, 1, "")
+	limiter.now = func() time.Time { return time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC) }
+	if err := limiter.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := limiter.Wait(ctx); err == nil || !strings.Contains(err.Error(), "canceled") {
+		t.Errorf("expected a cancelled wait to fail but got %v", err)
+	}
+
+	// The cancelled write gave its token back, so the next waits a minute
+	// rather than two
+	var waited time.Duration
+	limiter.sleep = func(_ context.Context, d time.Duration) error {
+		waited = d
+		return nil
+	}
+	if err := limiter.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if waited != time.Minute {
+		t.Errorf("expected the next write to wait 1m0s but it waited %v", waited)
+	}
+}
+
+func TestRateLimiterOff(t *testing.T) {
+	var limiter *RateLimiter
+	if err := limiter.Wait(context.Background()); err != nil {
+		t.Errorf("expected no limit from a nil limiter but got %v", err)
+	}
+	clock := &fakeClock{now: time.Now()}
+	off := clock.attach(NewRateLimiter(-1, 1, ""))
+	for range 5 {
+		if err := off.Wait(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(clock.sleeps) != 0 {
+		t.Errorf("expected a negative rate to turn the limit off but it waited %v", clock.sleeps)
+	}
+}
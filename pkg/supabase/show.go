@@ -0,0 +1,143 @@
+package supabase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/envfile"
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+// EnvironmentRef identifies a stored environment by ID, or by owner and name
+type EnvironmentRef struct {
+	ID string
+	// UserID and Username identify the owner when looking up by Name. One is
+	// enough; Username is resolved to a user ID.
+	UserID   string
+	Username string
+	Name     string
+}
+
+// String formats the reference the way users type it
+func (r EnvironmentRef) String() string {
+	switch {
+	case r.ID != "":
+		return "id " + r.ID
+	case r.Username != "":
+		return r.Username + "/" + r.Name
+	default:
+		return r.Name
+	}
+}
+
+// EnvironmentDetails is a stored environment's metadata and, when fetched,
+// its data
+type EnvironmentDetails struct {
+	EnvironmentInfo
+	// Data is nil unless the full environment was requested or the row has
+	// no stored summary
+	Data *types.EnvironmentData
+}
+
+// ShowEnvironment fetches the metadata of the environment ref points to. The
+// data column is only downloaded when full is set or the row predates stored
+// summaries, in which case the summary is computed from the data. Private
+// environments are only visible to their owner.
+func (c *Client) ShowEnvironment(ctx context.Context, ref EnvironmentRef, full bool) (*EnvironmentDetails, error) {
+	if ref.ID == "" && ref.UserID == "" && ref.Username != "" {
+		userID, err := c.userID(ref.Username)
+		if err != nil {
+			return nil, err
+		}
+		ref.UserID = userID
+	}
+
+	rows, err := c.selectEnvironment(ref, full)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 1 && !full && rows[0].Summary == nil {
+		rows, err = c.selectEnvironment(ref, true)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("environment %s not found or not visible to you", ref)
+	}
+
+	row := rows[0]
+	details := &EnvironmentDetails{EnvironmentInfo: row.EnvironmentInfo}
+	if len(row.Data) > 0 && string(row.Data) != "null" {
+		env, err := ParseStored(row.Data, row.DataSHA256, "environment "+ref.String(), envfile.Options{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal environment data: %w", err)
+		}
+		details.Data = env
+		details.Summary = env.Summary
+		if details.Size == 0 {
+			details.Size = len(row.Data)
+		}
+	}
+
+	infos := []EnvironmentInfo{details.EnvironmentInfo}
+	if ref.Username != "" {
+		infos[0].Username = ref.Username
+	} else if err := c.fillUsernames(infos); err != nil {
+		return nil, err
+	}
+	details.EnvironmentInfo = infos[0]
+	return details, nil
+}
+
+// environmentRow is a metadata row with an optional data column
+type environmentRow struct {
+	EnvironmentInfo
+	Data       json.RawMessage `json:"data"`
+	DataSHA256 string          `json:"data_sha256"`
+}
+
+// selectEnvironment selects the rows ref points to. Tables without the
+// optional columns are queried again without them; ShowEnvironment then
+// finds no summary and fetches the data to compute one.
+func (c *Client) selectEnvironment(ref EnvironmentRef, withData bool) ([]environmentRow, error) {
+	if ref.ID == "" && (ref.UserID == "" || ref.Name == "") {
+		return nil, fmt.Errorf("an environment ID, or an owner and a name, is required")
+	}
+	columns := strings.Split(infoColumns, ",")
+	if withData {
+		columns = append(columns, "data", "data_sha256")
+	}
+
+	var rows []environmentRow
+	err := selectColumns(columns, func(columns string) error {
+		query := c.From("environments").Select(columns, "", false)
+		if ref.ID != "" {
+			query = query.Eq("id", ref.ID)
+		} else {
+			query = query.Eq("user_id", ref.UserID).Eq("name", ref.Name)
+		}
+		_, err := query.ExecuteTo(&rows)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get environment: %w", err)
+	}
+	return rows, nil
+}
+
+// userID resolves a username to the owner's user ID
+func (c *Client) userID(username string) (string, error) {
+	var users []struct {
+		ID string `json:"id"`
+	}
+	if _, err := c.From("profiles").Select("id", "", false).Eq("username", username).ExecuteTo(&users); err != nil {
+		return "", fmt.Errorf("failed to find user: %w", err)
+	}
+	if len(users) == 0 {
+		return "", fmt.Errorf("user '%s' not found", username)
+	}
+	return users[0].ID, nil
+}
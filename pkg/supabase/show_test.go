@@ -0,0 +1,81 @@
+package supabase
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestShowEnvironment(t *testing.T) {
+	data := `{"stackmatch_version":"0.3.0","system":{"os":"linux","arch":"amd64"},"tools":{"Git":"2.43.0","Make":"4.3"}}`
+	summary := map[string]any{"counts": map[string]int{"tools": 2}, "os": "linux", "fingerprint": "abc"}
+	newRows := func() *fakeRows {
+		return &fakeRows{
+			environments: []map[string]any{
+				{"id": "id-1", "name": "laptop", "user_id": "user-1", "is_public": true, "created_at": "2026-10-16T09:00:00Z", "size": len(data), "summary": summary, "data": data},
+				{"id": "id-2", "name": "legacy", "user_id": "user-1", "is_public": true, "created_at": "2025-01-02T09:00:00Z", "data": data},
+				{"id": "id-3", "name": "laptop", "user_id": "user-2", "is_public": true, "created_at": "2026-10-10T09:00:00Z", "summary": summary, "data": data},
+			},
+			profiles: []map[string]any{
+				{"id": "user-1", "username": "ada"},
+				{"id": "user-2", "username": "linus"},
+			},
+		}
+	}
+
+	testCases := []struct {
+		name     string
+		ref      EnvironmentRef
+		full     bool
+		expectID string
+		owner    string
+		withData bool
+		errText  string
+	}{
+		{name: "by ID", ref: EnvironmentRef{ID: "id-3"}, expectID: "id-3", owner: "linus"},
+		{name: "by username and name", ref: EnvironmentRef{Username: "ada", Name: "laptop"}, expectID: "id-1", owner: "ada"},
+		{name: "own by name", ref: EnvironmentRef{UserID: "user-2", Name: "laptop"}, expectID: "id-3", owner: "linus"},
+		{name: "full", ref: EnvironmentRef{ID: "id-1"}, full: true, expectID: "id-1", owner: "ada", withData: true},
+		{name: "no stored summary", ref: EnvironmentRef{ID: "id-2"}, expectID: "id-2", owner: "ada", withData: true},
+		{name: "unknown user", ref: EnvironmentRef{Username: "nobody", Name: "laptop"}, errText: "user 'nobody' not found"},
+		{name: "unknown name", ref: EnvironmentRef{Username: "ada", Name: "desktop"}, errText: "ada/desktop not found"},
+		{name: "empty ref", ref: EnvironmentRef{Name: "laptop"}, errText: "is required"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rows := newRows()
+			client := newFakeClient(t, rows)
+
+			details, err := client.ShowEnvironment(context.Background(), tc.ref, tc.full)
+			if tc.errText != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.errText) {
+					t.Fatalf("expected an error containing %q but got %v", tc.errText, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if details.ID != tc.expectID {
+				t.Errorf("expected environment %s but got %s", tc.expectID, details.ID)
+			}
+			if details.Username != tc.owner {
+				t.Errorf("expected owner %q but got %q", tc.owner, details.Username)
+			}
+			if (details.Data != nil) != tc.withData {
+				t.Errorf("expected data to be fetched: %v, but got %v", tc.withData, details.Data != nil)
+			}
+			if details.Summary == nil || details.Summary.Counts["tools"] != 2 {
+				t.Errorf("expected a summary with 2 tools but got %+v", details.Summary)
+			}
+			if !tc.withData {
+				for _, req := range rows.requests {
+					if strings.Contains(req, "data") {
+						t.Errorf("expected the data column not to be selected, but got %s", req)
+					}
+				}
+			}
+		})
+	}
+}
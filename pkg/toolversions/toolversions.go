@@ -0,0 +1,326 @@
+// Package toolversions reads the per-project version files used by asdf, mise,
+// nvm, pyenv and friends (.tool-versions, .nvmrc, .python-version, ...) and
+// turns them into a minimal environment StackMatch can check or install.
+package toolversions
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/types"
+)
+
+// ToolVersionsFile is the asdf/mise file listing one "plugin version" pair per line
+const ToolVersionsFile = ".tool-versions"
+
+// singleVersionFiles are files holding a single version for one language,
+// in the order they are read from a project directory
+var singleVersionFiles = []struct {
+	file string
+	name string
+}{
+	{".nvmrc", "nodejs"},
+	{".node-version", "nodejs"},
+	{".python-version", "python"},
+	{".ruby-version", "ruby"},
+	{".go-version", "golang"},
+	{".java-version", "java"},
+}
+
+// pluginLanguages maps asdf/mise plugin names to the language names used by
+// the scanner. Plugins not listed here are treated as tools.
+var pluginLanguages = map[string]string{
+	"nodejs":  "Node.js",
+	"node":    "Node.js",
+	"python":  "Python",
+	"ruby":    "Ruby",
+	"golang":  "Go",
+	"go":      "Go",
+	"java":    "Java",
+	"kotlin":  "Kotlin",
+	"scala":   "Scala",
+	"rust":    "Rust",
+	"php":     "PHP",
+	"perl":    "Perl",
+	"lua":     "Lua",
+	"elixir":  "Elixir",
+	"haskell": "Haskell",
+	"dart":    "Dart",
+	"groovy":  "Groovy",
+	"dotnet":  "C#",
+}
+
+// pluginTools maps asdf/mise plugin names to the tool names used by the
+// scanner. Other plugins keep their plugin name.
+var pluginTools = map[string]string{
+	"terraform": "Terraform",
+	"packer":    "Packer",
+	"kubectl":   "Kubernetes",
+	"helm":      "Helm",
+	"gradle":    "Gradle",
+	"maven":     "Maven",
+	"cmake":     "CMake",
+	"awscli":    "AWS CLI",
+	"gcloud":    "Google Cloud SDK",
+	"yarn":      "yarn",
+	"pnpm":      "pnpm",
+}
+
+// partialVersionRegex matches versions with fewer than three numeric parts
+var partialVersionRegex = regexp.MustCompile(`^\d+(\.\d+)?$`)
+
+// Entry is a single name/version pair read from a version file
+type Entry struct {
+	// Name is the plugin name as written in the file (e.g. "nodejs")
+	Name string
+	// Version is the first version listed for the plugin
+	Version string
+}
+
+// IsVersionFile reports whether path names a file this package can read
+func IsVersionFile(path string) bool {
+	base := filepath.Base(path)
+	if base == ToolVersionsFile {
+		return true
+	}
+	for _, f := range singleVersionFiles {
+		if f.file == base {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseToolVersions reads a .tool-versions file. Each line is a plugin name
+// followed by one or more versions; only the first version is kept, as that
+// is the one asdf activates. Comments start with '#'.
+func ParseToolVersions(r io.Reader) ([]Entry, error) {
+	var entries []Entry
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := stripComment(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("line %d: expected \"<plugin> <version>\", got %q", lineNo, line)
+		}
+		entries = append(entries, Entry{Name: fields[0], Version: fields[1]})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// ParseVersionFile reads a single-version file such as .nvmrc and returns the
+// first non-comment, non-blank line
+func ParseVersionFile(r io.Reader) (string, error) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if line := stripComment(scanner.Text()); line != "" {
+			return strings.Fields(line)[0], nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", fmt.Errorf("no version found")
+}
+
+// stripComment removes a trailing '#' comment and surrounding whitespace
+func stripComment(line string) string {
+	if i := strings.IndexByte(line, '#'); i >= 0 {
+		line = line[:i]
+	}
+	return strings.TrimSpace(line)
+}
+
+// Constraint converts a version as written in a version file into a
+// constraint understood by pkg/version. Partial versions ("18", "3.11")
+// become wildcards and aliases such as "lts/*", "latest" or "system" match
+// any version.
+func Constraint(raw string) string {
+	v := strings.TrimPrefix(raw, "v")
+	switch {
+	case partialVersionRegex.MatchString(v):
+		return v + ".x"
+	case len(v) > 0 && v[0] >= '0' && v[0] <= '9':
+		return v
+	default:
+		return ""
+	}
+}
+
+// Source records which file declared a version
+type Source struct {
+	File    string `json:"file"`
+	Version string `json:"version"`
+}
+
+// Conflict is a name declared with different versions by different files
+type Conflict struct {
+	Name    string   `json:"name"`
+	Sources []Source `json:"sources"`
+}
+
+func (c Conflict) String() string {
+	parts := make([]string, 0, len(c.Sources))
+	for _, s := range c.Sources {
+		parts = append(parts, fmt.Sprintf("%s in %s", s.Version, filepath.Base(s.File)))
+	}
+	return fmt.Sprintf("%s: %s (using %s)", c.Name, strings.Join(parts, ", "), c.Sources[0].Version)
+}
+
+// Result is the merged content of one or more version files
+type Result struct {
+	// Languages maps scanner language names to version constraints
+	Languages map[string]string
+	// Tools maps plugins that are not languages to version constraints
+	Tools map[string]string
+	// Files lists the files that were read, in order
+	Files []string
+	// Conflicts lists names declared with different versions. The first
+	// file read wins.
+	Conflicts []Conflict
+
+	sources map[string][]Source
+	order   []string
+}
+
+func newResult() *Result {
+	return &Result{
+		Languages: make(map[string]string),
+		Tools:     make(map[string]string),
+		sources:   make(map[string][]Source),
+	}
+}
+
+// add merges an entry read from file
+func (r *Result) add(file string, entry Entry) {
+	plugin := strings.ToLower(entry.Name)
+	name, isLanguage := pluginLanguages[plugin]
+	if !isLanguage {
+		name = entry.Name
+		if tool, ok := pluginTools[plugin]; ok {
+			name = tool
+		}
+	}
+	constraint := Constraint(entry.Version)
+
+	r.sources[name] = append(r.sources[name], Source{File: file, Version: entry.Version})
+	if len(r.sources[name]) > 1 {
+		return
+	}
+	r.order = append(r.order, name)
+	if isLanguage {
+		r.Languages[name] = constraint
+	} else {
+		r.Tools[name] = constraint
+	}
+}
+
+// finish computes conflicts once every file has been added
+func (r *Result) finish() {
+	for _, name := range r.order {
+		sources := r.sources[name]
+		for _, s := range sources[1:] {
+			if Constraint(s.Version) != Constraint(sources[0].Version) {
+				r.Conflicts = append(r.Conflicts, Conflict{Name: name, Sources: sources})
+				break
+			}
+		}
+	}
+}
+
+// Load reads and merges the given version files in order
+func Load(paths ...string) (*Result, error) {
+	result := newResult()
+	for _, path := range paths {
+		if err := result.load(path); err != nil {
+			return nil, err
+		}
+	}
+	result.finish()
+	return result, nil
+}
+
+// LoadDir reads every known version file in dir. .tool-versions is read
+// first, so it wins over single-version files when they disagree.
+func LoadDir(dir string) (*Result, error) {
+	var paths []string
+	candidates := []string{ToolVersionsFile}
+	for _, f := range singleVersionFiles {
+		candidates = append(candidates, f.file)
+	}
+	for _, name := range candidates {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			paths = append(paths, path)
+		}
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no version files found in %s", dir)
+	}
+	return Load(paths...)
+}
+
+// load reads a single file into r
+func (r *Result) load(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("could not read file %s: %w", path, err)
+	}
+	defer f.Close()
+
+	r.Files = append(r.Files, path)
+	base := filepath.Base(path)
+
+	if base == ToolVersionsFile {
+		entries, err := ParseToolVersions(f)
+		if err != nil {
+			return fmt.Errorf("could not parse %s: %w", path, err)
+		}
+		for _, entry := range entries {
+			r.add(path, entry)
+		}
+		return nil
+	}
+
+	for _, sf := range singleVersionFiles {
+		if sf.file != base {
+			continue
+		}
+		v, err := ParseVersionFile(f)
+		if err != nil {
+			return fmt.Errorf("could not parse %s: %w", path, err)
+		}
+		r.add(path, Entry{Name: sf.name, Version: v})
+		return nil
+	}
+
+	return fmt.Errorf("%s is not a supported version file", path)
+}
+
+// Environment synthesizes a minimal environment declaring the versions in r
+func (r *Result) Environment() types.EnvironmentData {
+	return types.EnvironmentData{
+		SchemaVersion:       types.CurrentSchemaVersion,
+		ScanDate:            time.Now().UTC(),
+		ConfiguredLanguages: r.Languages,
+		Tools:               r.Tools,
+		PackageManagers:     map[string]string{},
+		CodeEditors:         map[string]string{},
+		ConfigFiles:         []string{},
+	}
+}
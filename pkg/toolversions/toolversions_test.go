@@ -0,0 +1,149 @@
+package toolversions
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseToolVersions(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected []Entry
+		wantErr  bool
+	}{
+		{
+			name:     "Plugin version pairs",
+			input:    "nodejs 20.11.0\npython 3.12.1\n",
+			expected: []Entry{{"nodejs", "20.11.0"}, {"python", "3.12.1"}},
+		},
+		{
+			name: "Comments and blank lines",
+			input: `# Toolchain for the API service
+
+golang 1.22.0   # keep in sync with go.mod
+   
+  terraform   1.6.6
+`,
+			expected: []Entry{{"golang", "1.22.0"}, {"terraform", "1.6.6"}},
+		},
+		{
+			name:     "Fallback versions keep the first",
+			input:    "python 3.12.1 3.11.7 system\n",
+			expected: []Entry{{"python", "3.12.1"}},
+		},
+		{
+			name:     "CRLF line endings",
+			input:    "ruby 3.3.0\r\nnodejs lts\r\n",
+			expected: []Entry{{"ruby", "3.3.0"}, {"nodejs", "lts"}},
+		},
+		{
+			name:    "Missing version",
+			input:   "nodejs\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			entries, err := ParseToolVersions(strings.NewReader(tc.input))
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("expected an error but got %v", entries)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(entries, tc.expected) {
+				t.Errorf("expected %v but got %v", tc.expected, entries)
+			}
+		})
+	}
+}
+
+func TestParseVersionFile(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected string
+		wantErr  bool
+	}{
+		{name: "Plain version", input: "20.11.0\n", expected: "20.11.0"},
+		{name: "Leading comment and blank line", input: "# node for CI\n\nv18\n", expected: "v18"},
+		{name: "Alias", input: "lts/hydrogen", expected: "lts/hydrogen"},
+		{name: "Empty file", input: "\n# nothing\n", wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			v, err := ParseVersionFile(strings.NewReader(tc.input))
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("expected an error but got %q", v)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if v != tc.expected {
+				t.Errorf("expected %q but got %q", tc.expected, v)
+			}
+		})
+	}
+}
+
+func TestConstraint(t *testing.T) {
+	testCases := map[string]string{
+		"20.11.0":      "20.11.0",
+		"v18":          "18.x",
+		"3.11":         "3.11.x",
+		"lts/hydrogen": "",
+		"system":       "",
+		"latest":       "",
+	}
+	for raw, expected := range testCases {
+		if got := Constraint(raw); got != expected {
+			t.Errorf("Constraint(%q): expected %q but got %q", raw, expected, got)
+		}
+	}
+}
+
+func TestLoadDirMergesAndReportsConflicts(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		".tool-versions":  "nodejs 20.11.0\nterraform 1.6.6\n",
+		".nvmrc":          "18\n",
+		".python-version": "3.12\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	result, err := LoadDir(dir)
+	if err != nil {
+		t.Fatalf("LoadDir failed: %v", err)
+	}
+
+	expectedLanguages := map[string]string{"Node.js": "20.11.0", "Python": "3.12.x"}
+	if !reflect.DeepEqual(result.Languages, expectedLanguages) {
+		t.Errorf("expected languages %v but got %v", expectedLanguages, result.Languages)
+	}
+	if result.Tools["Terraform"] != "1.6.6" {
+		t.Errorf("expected Terraform 1.6.6 in tools but got %v", result.Tools)
+	}
+
+	if len(result.Conflicts) != 1 || result.Conflicts[0].Name != "Node.js" {
+		t.Fatalf("expected a single Node.js conflict but got %v", result.Conflicts)
+	}
+	if got := result.Conflicts[0].String(); got != "Node.js: 20.11.0 in .tool-versions, 18 in .nvmrc (using 20.11.0)" {
+		t.Errorf("unexpected conflict description %q", got)
+	}
+}
@@ -0,0 +1,209 @@
+package types
+
+import (
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/version"
+)
+
+// AliasGroup lists names under which scans report the same tool, such as
+// "Python" on one machine and "Python 3" on another
+type AliasGroup struct {
+	// Canonical is the name every alias collapses into
+	Canonical string
+	// Aliases are the other names of the tool
+	Aliases []string
+	// CompareOnly groups are collapsed when comparing environments but not
+	// by scans, which record each name with its own version. The names are
+	// different tools that satisfy the same requirement, such as the legacy
+	// docker-compose binary and the compose plugin.
+	CompareOnly bool
+}
+
+// DefaultAliasGroups are the aliases collapsed without a detectors file
+var DefaultAliasGroups = []AliasGroup{
+	{Canonical: "Python", Aliases: []string{"Python 3", "python3"}},
+	{Canonical: "pip", Aliases: []string{"pip3"}},
+	{Canonical: "Node.js", Aliases: []string{"node", "nodejs"}},
+	{Canonical: "Docker Compose", Aliases: []string{"Docker Compose Plugin"}, CompareOnly: true},
+}
+
+// MergeAliasGroups returns groups extended by extra, which maps a canonical
+// name to more aliases. Aliases of a canonical name already in groups are
+// added to that group.
+func MergeAliasGroups(groups []AliasGroup, extra map[string][]string) []AliasGroup {
+	merged := make([]AliasGroup, 0, len(groups)+len(extra))
+	index := make(map[string]int)
+	for _, g := range groups {
+		index[strings.ToLower(g.Canonical)] = len(merged)
+		merged = append(merged, AliasGroup{Canonical: g.Canonical, Aliases: slices.Clone(g.Aliases), CompareOnly: g.CompareOnly})
+	}
+	canonicals := make([]string, 0, len(extra))
+	for canonical := range extra {
+		canonicals = append(canonicals, canonical)
+	}
+	sort.Strings(canonicals)
+	for _, canonical := range canonicals {
+		if i, ok := index[strings.ToLower(canonical)]; ok {
+			merged[i].Aliases = append(merged[i].Aliases, extra[canonical]...)
+			continue
+		}
+		index[strings.ToLower(canonical)] = len(merged)
+		merged = append(merged, AliasGroup{Canonical: canonical, Aliases: slices.Clone(extra[canonical])})
+	}
+	return merged
+}
+
+// matches reports whether name is the group's canonical name or one of its
+// aliases, ignoring case
+func (g AliasGroup) matches(name string) bool {
+	if strings.EqualFold(name, g.Canonical) {
+		return true
+	}
+	for _, alias := range g.Aliases {
+		if strings.EqualFold(name, alias) {
+			return true
+		}
+	}
+	return false
+}
+
+// Reconcile returns a copy of env in which the entries of each alias group
+// are collapsed into a single entry under the group's canonical name, so the
+// same machine compares clean against itself however a scan named its tools.
+// The collapsed entry keeps the highest version together with that entry's
+// tool ID, source, provenance and failure, and the strongest requirement of
+// the group. The names collapsed into it are recorded in Aliases. env is not
+// modified.
+func Reconcile(env EnvironmentData, groups []AliasGroup) EnvironmentData {
+	env.ConfiguredLanguages = maps.Clone(env.ConfiguredLanguages)
+	env.Tools = maps.Clone(env.Tools)
+	env.PackageManagers = maps.Clone(env.PackageManagers)
+	env.CodeEditors = maps.Clone(env.CodeEditors)
+	env.ToolIDs = maps.Clone(env.ToolIDs)
+	env.ToolSources = maps.Clone(env.ToolSources)
+	env.Provenance = maps.Clone(env.Provenance)
+	env.BrokenTools = maps.Clone(env.BrokenTools)
+	env.Requirements = maps.Clone(env.Requirements)
+	env.Aliases = maps.Clone(env.Aliases)
+
+	for _, entries := range []map[string]string{env.ConfiguredLanguages, env.Tools, env.PackageManagers, env.CodeEditors} {
+		for _, g := range groups {
+			collapse(&env, entries, g)
+		}
+	}
+	return env
+}
+
+// collapse merges the entries of one category that belong to g
+func collapse(env *EnvironmentData, entries map[string]string, g AliasGroup) {
+	var names []string
+	for name := range entries {
+		if g.matches(name) {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 || (len(names) == 1 && names[0] == g.Canonical) {
+		return
+	}
+	// The canonical spelling wins ties, then names in order
+	sort.Slice(names, func(i, j int) bool {
+		if (names[i] == g.Canonical) != (names[j] == g.Canonical) {
+			return names[i] == g.Canonical
+		}
+		return names[i] < names[j]
+	})
+
+	winner := names[0]
+	requirement := Unclassified
+	for _, name := range names {
+		if newerVersion(entries[name], entries[winner]) {
+			winner = name
+		}
+		if rank(env.Requirements[name]) > rank(requirement) {
+			requirement = env.Requirements[name]
+		}
+	}
+
+	kept := entries[winner]
+	id, source := env.ToolID(winner), env.ToolSources[winner]
+	provenance, traced := env.Provenance[winner]
+	failure, broken := env.BrokenTools[winner]
+	var aliases []string
+	for _, name := range names {
+		delete(entries, name)
+		delete(env.ToolIDs, name)
+		delete(env.ToolSources, name)
+		delete(env.Provenance, name)
+		delete(env.BrokenTools, name)
+		delete(env.Requirements, name)
+		if name != g.Canonical {
+			aliases = append(aliases, name)
+		}
+	}
+
+	entries[g.Canonical] = kept
+	if env.ToolIDs != nil || id != CanonicalID(g.Canonical) {
+		if env.ToolIDs == nil {
+			env.ToolIDs = make(map[string]string)
+		}
+		env.ToolIDs[g.Canonical] = id
+	}
+	if source != "" {
+		if env.ToolSources == nil {
+			env.ToolSources = make(map[string]string)
+		}
+		env.ToolSources[g.Canonical] = source
+	}
+	if traced {
+		env.Provenance[g.Canonical] = provenance
+	}
+	if broken {
+		if env.BrokenTools == nil {
+			env.BrokenTools = make(map[string]ToolFailure)
+		}
+		env.BrokenTools[g.Canonical] = failure
+	}
+	env.SetRequirement(g.Canonical, requirement)
+	if len(aliases) > 0 {
+		if env.Aliases == nil {
+			env.Aliases = make(map[string][]string)
+		}
+		merged := append(slices.Clone(env.Aliases[g.Canonical]), aliases...)
+		sort.Strings(merged)
+		env.Aliases[g.Canonical] = slices.Compact(merged)
+	}
+}
+
+// newerVersion reports whether version a ranks above b. Parseable versions
+// rank above other values, and markers such as "Installed" rank last.
+func newerVersion(a, b string) bool {
+	va, errA := version.Parse(a)
+	vb, errB := version.Parse(b)
+	switch {
+	case errA == nil && errB == nil:
+		return va.Compare(vb) > 0
+	case errA == nil || errB == nil:
+		return errA == nil
+	}
+	return isMarker(b) && !isMarker(a)
+}
+
+// isMarker reports whether v records presence rather than a version
+func isMarker(v string) bool {
+	return strings.TrimSpace(v) == "" || strings.EqualFold(v, "Installed")
+}
+
+// rank orders requirements from unclassified to required
+func rank(r Requirement) int {
+	switch r {
+	case Required:
+		return 2
+	case Optional:
+		return 1
+	}
+	return 0
+}
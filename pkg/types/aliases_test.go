@@ -0,0 +1,103 @@
+package types
+
+import (
+	"maps"
+	"reflect"
+	"testing"
+)
+
+func TestReconcile(t *testing.T) {
+	testCases := []struct {
+		name             string
+		env              EnvironmentData
+		groups           []AliasGroup
+		expectedTools    map[string]string
+		expectedAliases  map[string][]string
+		expectedIDs      map[string]string
+		expectedRequired map[string]Requirement
+	}{
+		{
+			name:            "Alias collapses into the canonical name",
+			env:             EnvironmentData{Tools: map[string]string{"Python 3": "3.11.4", "Git": "2.45.0"}},
+			expectedTools:   map[string]string{"Python": "3.11.4", "Git": "2.45.0"},
+			expectedAliases: map[string][]string{"Python": {"Python 3"}},
+		},
+		{
+			name: "Highest version wins with its tool ID",
+			env: EnvironmentData{
+				Tools:   map[string]string{"Python": "2.7.18", "Python 3": "3.11.4"},
+				ToolIDs: map[string]string{"Python": "python", "Python 3": "python3"},
+			},
+			expectedTools:   map[string]string{"Python": "3.11.4"},
+			expectedAliases: map[string][]string{"Python": {"Python 3"}},
+			expectedIDs:     map[string]string{"Python": "python3"},
+		},
+		{
+			name:            "Versions beat Installed",
+			env:             EnvironmentData{PackageManagers: map[string]string{"pip": "Installed", "pip3": "24.0"}},
+			expectedTools:   map[string]string{"pip": "24.0"},
+			expectedAliases: map[string][]string{"pip": {"pip3"}},
+		},
+		{
+			name:            "Case drift is reconciled",
+			env:             EnvironmentData{Tools: map[string]string{"node": "20.11.0", "NODE.JS": "18.19.0"}},
+			expectedTools:   map[string]string{"Node.js": "20.11.0"},
+			expectedAliases: map[string][]string{"Node.js": {"NODE.JS", "node"}},
+		},
+		{
+			name: "Strongest requirement is kept",
+			env: EnvironmentData{
+				Tools:        map[string]string{"Python": "3.12.1", "python3": "3.11.4"},
+				Requirements: map[string]Requirement{"Python": Optional, "python3": Required},
+			},
+			expectedTools:    map[string]string{"Python": "3.12.1"},
+			expectedAliases:  map[string][]string{"Python": {"python3"}},
+			expectedRequired: map[string]Requirement{"Python": Required},
+		},
+		{
+			name:          "Canonical name alone is unchanged",
+			env:           EnvironmentData{Tools: map[string]string{"Python": "3.12.1"}},
+			expectedTools: map[string]string{"Python": "3.12.1"},
+		},
+		{
+			name:            "User groups extend the defaults",
+			env:             EnvironmentData{Tools: map[string]string{"python3.12": "3.12.1", "kubectl": "1.30.0", "k": "1.29.0"}},
+			groups:          MergeAliasGroups(DefaultAliasGroups, map[string][]string{"python": {"python3.12"}, "kubectl": {"k"}}),
+			expectedTools:   map[string]string{"Python": "3.12.1", "kubectl": "1.30.0"},
+			expectedAliases: map[string][]string{"Python": {"python3.12"}, "kubectl": {"k"}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			groups := tc.groups
+			if groups == nil {
+				groups = DefaultAliasGroups
+			}
+			before := maps.Clone(tc.env.Tools)
+			got := Reconcile(tc.env, groups)
+
+			tools := got.Tools
+			if tools == nil {
+				tools = got.PackageManagers
+			}
+			if !reflect.DeepEqual(tools, tc.expectedTools) {
+				t.Errorf("expected entries %v but got %v", tc.expectedTools, tools)
+			}
+			if len(got.Aliases)+len(tc.expectedAliases) > 0 && !reflect.DeepEqual(got.Aliases, tc.expectedAliases) {
+				t.Errorf("expected aliases %v but got %v", tc.expectedAliases, got.Aliases)
+			}
+			for name, id := range tc.expectedIDs {
+				if got.ToolID(name) != id {
+					t.Errorf("expected %s to have ID %s but got %s", name, id, got.ToolID(name))
+				}
+			}
+			if tc.expectedRequired != nil && !reflect.DeepEqual(got.Requirements, tc.expectedRequired) {
+				t.Errorf("expected requirements %v but got %v", tc.expectedRequired, got.Requirements)
+			}
+			if !reflect.DeepEqual(tc.env.Tools, before) {
+				t.Errorf("expected the original environment to be left unchanged")
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package types
+
+// Category names used to group environment entries across scan, diff and import.
+const (
+	CategorySystem          = "system"
+	CategoryLanguages       = "languages"
+	CategoryTools           = "tools"
+	CategoryPackageManagers = "package-managers"
+	CategoryEditors         = "editors"
+	CategoryConfigFiles     = "config-files"
+	// CategoryScheduledJobs holds crontab entries and scheduled tasks (see ScheduledJob)
+	CategoryScheduledJobs = "scheduled-jobs"
+	// CategoryGitConfig holds git URL rewrites and credential helpers (see GitConfig)
+	CategoryGitConfig = "git-config"
+	// CategoryLanguageConfig holds toolchain settings such as GOPATH (see LanguageConfig)
+	CategoryLanguageConfig = "language-config"
+	// CategoryVersionManagers holds the versions installed by version managers (see VersionManagers)
+	CategoryVersionManagers = "version-managers"
+	// CategoryGlobalPackages holds packages installed with 'npm install -g' and the like (see GlobalPackages)
+	CategoryGlobalPackages = "global-packages"
+	// CategoryServices holds developer services set to start on their own (see Service)
+	CategoryServices = "services"
+	// CategoryEnvVars holds toolchain environment variables such as JAVA_HOME (see EnvVars)
+	CategoryEnvVars = "env-vars"
+	// CategoryContainers holds Docker images and running containers (see Containers)
+	CategoryContainers = "containers"
+	// CategoryGPU holds the GPU compute stack: driver, CUDA, cuDNN and ROCm (see GPU)
+	CategoryGPU = "gpu"
+	// CategoryKubernetes holds the kubectl contexts and their clusters (see Kubernetes)
+	CategoryKubernetes = "kubernetes"
+	// CategoryCloud holds the profile names of the cloud CLIs (see CloudProfiles)
+	CategoryCloud = "cloud"
+	// CategorySSH holds the fingerprints of the SSH keys and whether an ssh-agent runs (see SSHKeys)
+	CategorySSH = "ssh"
+	// CategoryMobile holds the Android SDK and Flutter (see Mobile)
+	CategoryMobile = "mobile"
+	// CategoryProvenance holds how the entries found were installed (see Provenance)
+	CategoryProvenance = "provenance"
+	// CategoryRequirements holds changes to how entries are classified (see Requirement)
+	CategoryRequirements = "requirements"
+)
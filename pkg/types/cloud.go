@@ -0,0 +1,11 @@
+package types
+
+// Cloud CLIs whose profiles are recorded in EnvironmentData.CloudProfiles
+const (
+	// CloudAWS holds the profiles of ~/.aws/config, such as "default" and "dev"
+	CloudAWS = "aws"
+	// CloudGcloud holds the gcloud configurations, such as "work"
+	CloudGcloud = "gcloud"
+	// CloudAzure holds the names of the Azure subscriptions az is logged in to
+	CloudAzure = "azure"
+)
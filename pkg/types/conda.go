@@ -0,0 +1,32 @@
+package types
+
+// CondaInstallation is a conda installation and its environments. Miniforge
+// and Mambaforge installations are recorded the same way, with the front end
+// found on PATH.
+type CondaInstallation struct {
+	// Executable is the front end that listed the environments: "conda",
+	// "mamba" or "micromamba"
+	Executable string `json:"executable"`
+	// Version is what the front end reports, such as "24.1.2"
+	Version string `json:"version,omitempty"`
+	// Distribution is the installer the base environment came from, such
+	// as "miniforge3", "mambaforge", "miniconda3" or "anaconda3", when its
+	// directory tells
+	Distribution string `json:"distribution,omitempty"`
+	// Environments lists every environment, base first
+	Environments []CondaEnvironment `json:"environments,omitempty"`
+}
+
+// CondaEnvironment is one environment of a conda installation
+type CondaEnvironment struct {
+	// Name is "base", the name of an environment under envs, or "" for one
+	// created elsewhere with --prefix
+	Name string `json:"name,omitempty"`
+	// Prefix is the environment's directory
+	Prefix string `json:"prefix"`
+	// Packages maps the key packages installed in the environment, such as
+	// python and numpy, to their version. Only deep scans list them.
+	Packages map[string]string `json:"packages,omitempty"`
+	// Error is why the packages of the environment could not be listed
+	Error string `json:"error,omitempty"`
+}
@@ -0,0 +1,46 @@
+package types
+
+import "time"
+
+// ConfigFileInfo describes a config file found by a scan, so that two
+// machines that both have a file such as ~/.npmrc can be told apart by what
+// it contains
+type ConfigFileInfo struct {
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+	// SHA256 is the hex SHA-256 of the contents. It is empty for directories
+	// and for files that could not be read.
+	SHA256  string    `json:"sha256,omitempty"`
+	ModTime time.Time `json:"mtime"`
+	// Dir is set for directories, such as ~/.vim, which are not hashed
+	Dir bool `json:"dir,omitempty"`
+	// Symlink is set when the entry itself is a symbolic link; its size,
+	// hash and modification time are those of the file it points to
+	Symlink bool `json:"symlink,omitempty"`
+	// Target is the path a symlink resolves to
+	Target string `json:"target,omitempty"`
+	// ManagedBy is the dotfile manager the file belongs to, such as
+	// "chezmoi", so imports can point at it rather than copying the file
+	ManagedBy string `json:"managed_by,omitempty"`
+	// Error says why the file could not be read
+	Error string `json:"error,omitempty"`
+}
+
+// Dotfile managers recorded in ConfigFileInfo.ManagedBy
+const (
+	DotfileManagerChezmoi = "chezmoi"
+	DotfileManagerStow    = "stow"
+	DotfileManagerYadm    = "yadm"
+	DotfileManagerDotbot  = "dotbot"
+)
+
+// ConfigFile returns the description of the config file at path, recorded
+// in ConfigFileInfo, or false for files written before they were described
+func (e *EnvironmentData) ConfigFile(path string) (ConfigFileInfo, bool) {
+	for _, info := range e.ConfigFileInfo {
+		if info.Path == path {
+			return info, true
+		}
+	}
+	return ConfigFileInfo{}, false
+}
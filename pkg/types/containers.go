@@ -0,0 +1,36 @@
+package types
+
+// ContainersDaemonNotRunning is the Containers error recorded when the
+// docker CLI is installed but its daemon can't be reached
+const ContainersDaemonNotRunning = "daemon not running"
+
+// Containers records the Docker images present and the containers running
+// when the environment was scanned, which tell what services the
+// environment depends on beyond the docker version
+type Containers struct {
+	// Images are the local images that have a name, in name order
+	Images []ContainerImage `json:"images,omitempty"`
+	// Running are the images the running containers were started from, as
+	// docker reports them (such as "postgres:16"), in order
+	Running []string `json:"running,omitempty"`
+	// Error explains why images and containers could not be listed, such
+	// as ContainersDaemonNotRunning
+	Error string `json:"error,omitempty"`
+}
+
+// ContainerImage is a local Docker image
+type ContainerImage struct {
+	// Name is the image's repository, such as "postgres" or
+	// "ghcr.io/org/app"
+	Name string `json:"name"`
+	// Tag is the image's tag, such as "16-alpine"
+	Tag string `json:"tag,omitempty"`
+}
+
+// String returns the image as name:tag
+func (i ContainerImage) String() string {
+	if i.Tag == "" {
+		return i.Name
+	}
+	return i.Name + ":" + i.Tag
+}
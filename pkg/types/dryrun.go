@@ -0,0 +1,48 @@
+package types
+
+import "context"
+
+// PackageAction is what installing a package would do to it
+type PackageAction string
+
+const (
+	// ActionInstall installs a package that is not installed
+	ActionInstall PackageAction = "install"
+	// ActionUpgrade replaces the installed version with a newer one
+	ActionUpgrade PackageAction = "upgrade"
+	// ActionDowngrade replaces the installed version with an older one
+	ActionDowngrade PackageAction = "downgrade"
+	// ActionHeld leaves the package alone although the install asks for a
+	// change, because it is held, pinned or excluded, or the package
+	// manager refuses the change
+	ActionHeld PackageAction = "held"
+	// ActionNone leaves the package alone because it is already installed
+	// as asked
+	ActionNone PackageAction = "none"
+	// ActionReinstall installs a package again because it is broken
+	ActionReinstall PackageAction = "reinstall"
+)
+
+// PackageChange is what a package manager reports installing a package
+// would do, without changing anything
+type PackageChange struct {
+	Package string        `json:"package"`
+	Action  PackageAction `json:"action"`
+	// From is the version installed now, when the package manager says
+	From string `json:"from,omitempty"`
+	// To is the version that would be installed, when the package manager
+	// says
+	To string `json:"to,omitempty"`
+	// Dependency is set for packages pulled in by those asked for
+	Dependency bool `json:"dependency,omitempty"`
+}
+
+// DryRunner is implemented by installers whose package manager can tell
+// what an install would do without doing it, such as 'apt-get install
+// --dry-run'
+type DryRunner interface {
+	// DryRunInstall returns what installing packages would do to each of
+	// them and to the dependencies they pull in. A package's Version, in
+	// the package manager's format, asks for that version.
+	DryRunInstall(ctx context.Context, packages []PackageInfo) ([]PackageChange, error)
+}
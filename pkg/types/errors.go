@@ -1,6 +1,9 @@
 package types
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 // PackageAlreadyInstalledError is returned when a package is already installed
 type PackageAlreadyInstalledError struct {
@@ -19,3 +22,86 @@ type PackageNotFoundError struct {
 func (e *PackageNotFoundError) Error() string {
 	return fmt.Sprintf("package %s not found in repository", e.Package)
 }
+
+// UnsupportedScopeError is returned when a package manager can't install
+// into the scope asked for, such as apt into the user's prefix
+type UnsupportedScopeError struct {
+	// Manager is the package manager, such as "apt"
+	Manager string
+	Scope   InstallScope
+	// Reason says why, such as what is missing, when there is more to it
+	// than the package manager only installing system-wide
+	Reason string
+}
+
+func (e *UnsupportedScopeError) Error() string {
+	if e.Reason != "" {
+		return fmt.Sprintf("%s can't install into the %s scope: %s", e.Manager, e.Scope, e.Reason)
+	}
+	return fmt.Sprintf("%s can't install into the %s scope; it only installs system-wide", e.Manager, e.Scope)
+}
+
+// NoPackageManagerError is returned when none of the package managers
+// considered for the OS was found, or none of those found can be used.
+// Report says what was found of each.
+type NoPackageManagerError struct {
+	Report ProbeReport
+}
+
+func (e *NoPackageManagerError) Error() string {
+	var problems, considered []string
+	for _, probe := range e.Report.Managers {
+		considered = append(considered, probe.Name)
+		if probe.Found {
+			problems = append(problems, fmt.Sprintf("%s found but %s", probe.Name, probe.Problem))
+		}
+	}
+	if len(problems) > 0 {
+		return "no supported package manager found: " + strings.Join(problems, "; ")
+	}
+	if len(considered) == 0 {
+		return "no supported package manager found"
+	}
+	return fmt.Sprintf("no supported package manager found (looked for %s)", strings.Join(considered, ", "))
+}
+
+// PackageConflictError is returned when the package manager refuses to
+// install packages because they conflict with each other or with packages
+// already installed
+type PackageConflictError struct {
+	// Manager is the package manager that reported the conflict
+	Manager string
+	// Packages are the conflicting packages its output names, when it does
+	Packages []string
+	// Err is the error of the command that failed
+	Err error
+}
+
+func (e *PackageConflictError) Error() string {
+	if len(e.Packages) == 0 {
+		return fmt.Sprintf("%s reported conflicting packages: %v", e.Manager, e.Err)
+	}
+	return fmt.Sprintf("%s reported conflicting packages (%s): %v", e.Manager, strings.Join(e.Packages, ", "), e.Err)
+}
+
+func (e *PackageConflictError) Unwrap() error {
+	return e.Err
+}
+
+// UnresolvedConflictError is returned when packages of an import plan can't
+// be installed together, no rule prefers one of them and no one was picked,
+// such as when nobody answered the prompt. The package manager never ran.
+type UnresolvedConflictError struct {
+	// Packages are the conflicting packages
+	Packages []string
+	// Err says why none was picked
+	Err error
+}
+
+func (e *UnresolvedConflictError) Error() string {
+	return fmt.Sprintf("%s can't be installed together: %v", strings.Join(e.Packages, ", "), e.Err)
+}
+
+func (e *UnresolvedConflictError) Unwrap() error {
+	return e.Err
+}
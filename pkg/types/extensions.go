@@ -0,0 +1,94 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// environmentFields is the set of top-level JSON keys EnvironmentData knows
+var environmentFields = func() map[string]bool {
+	fields := make(map[string]bool)
+	t := reflect.TypeOf(EnvironmentData{})
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			fields[name] = true
+		}
+	}
+	return fields
+}()
+
+// environmentJSON has EnvironmentData's fields without its JSON methods
+type environmentJSON EnvironmentData
+
+// UnmarshalJSON decodes an environment, keeping top-level categories this
+// release does not know in Extensions. Unknown keys whose value is not a
+// name → version object are not categories and are ignored.
+func (e *EnvironmentData) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, (*environmentJSON)(e)); err != nil {
+		return err
+	}
+
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	e.Extensions = nil
+	for key, value := range raw {
+		if environmentFields[key] {
+			continue
+		}
+		var entries map[string]string
+		if err := json.Unmarshal(value, &entries); err != nil || entries == nil {
+			continue
+		}
+		if e.Extensions == nil {
+			e.Extensions = make(map[string]map[string]string)
+		}
+		e.Extensions[key] = entries
+	}
+	return nil
+}
+
+// MarshalJSON encodes an environment with its Extensions as top-level keys
+// after the known fields, in name order
+func (e EnvironmentData) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(environmentJSON(e))
+	if err != nil || len(e.Extensions) == 0 {
+		return data, err
+	}
+
+	var buf bytes.Buffer
+	buf.Write(bytes.TrimSuffix(data, []byte("}")))
+	for _, category := range ExtensionCategories(&e) {
+		key, _ := json.Marshal(category)
+		value, err := json.Marshal(e.Extensions[category])
+		if err != nil {
+			return nil, err
+		}
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// ExtensionCategories returns the names of env's extension categories in
+// order, leaving out any that would collide with a known field
+func ExtensionCategories(env *EnvironmentData) []string {
+	categories := make([]string, 0, len(env.Extensions))
+	for category := range env.Extensions {
+		if !environmentFields[category] {
+			categories = append(categories, category)
+		}
+	}
+	sort.Strings(categories)
+	return categories
+}
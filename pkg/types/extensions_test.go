@@ -0,0 +1,90 @@
+package types
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestExtensionsRoundTrip(t *testing.T) {
+	env := loadFixture(t, "env_extensions.json")
+
+	expected := map[string]map[string]string{
+		"databases": {"PostgreSQL server": "16.2", "Redis": "7.2.4"},
+		"browsers":  {},
+	}
+	if !reflect.DeepEqual(env.Extensions, expected) {
+		t.Fatalf("expected extensions %v but got %v", expected, env.Extensions)
+	}
+	if env.Tools["Git"] != "2.45.0" {
+		t.Errorf("expected known categories to decode as before but got %v", env.Tools)
+	}
+
+	data, err := json.Marshal(env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		t.Fatalf("expected valid JSON but got %v: %s", err, data)
+	}
+	if string(raw["databases"]) != `{"PostgreSQL server":"16.2","Redis":"7.2.4"}` {
+		t.Errorf("expected databases at the top level but got %s", data)
+	}
+	if _, ok := raw["extensions"]; ok {
+		t.Errorf("expected no extensions key but got %s", data)
+	}
+
+	var again EnvironmentData
+	if err := json.Unmarshal(data, &again); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(again.Extensions, env.Extensions) {
+		t.Errorf("expected extensions to survive a round trip, got %v", again.Extensions)
+	}
+}
+
+func TestExtensionsInSummary(t *testing.T) {
+	env := loadFixture(t, "env_extensions.json")
+	summary := BuildSummary(env)
+	if summary.Counts["databases"] != 2 {
+		t.Errorf("expected 2 databases counted but got %v", summary.Counts)
+	}
+
+	without := *env
+	without.Extensions = nil
+	if BuildSummary(&without).Fingerprint == summary.Fingerprint {
+		t.Error("expected extension entries to change the fingerprint")
+	}
+}
+
+func TestVersionManagersRoundTrip(t *testing.T) {
+	data := []byte(`{"tools": {"Git": "2.45.0"}, "version_managers": {"pyenv": ["3.11.7", "3.12.1"], "rbenv": []}}`)
+	var env EnvironmentData
+	if err := json.Unmarshal(data, &env); err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string][]string{"pyenv": {"3.11.7", "3.12.1"}, "rbenv": {}}
+	if !reflect.DeepEqual(env.VersionManagers, expected) {
+		t.Fatalf("expected version managers %v but got %v", expected, env.VersionManagers)
+	}
+	if len(env.Extensions) != 0 {
+		t.Errorf("expected version managers not to be read as an extension but got %v", env.Extensions)
+	}
+
+	data, err := json.Marshal(&env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var again EnvironmentData
+	if err := json.Unmarshal(data, &again); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(again.VersionManagers, expected) {
+		t.Errorf("expected version managers to survive a round trip, got %v", again.VersionManagers)
+	}
+
+	if older := loadFixture(t, "env_v2.json"); older.VersionManagers != nil {
+		t.Errorf("expected no version managers in an older file but got %v", older.VersionManagers)
+	}
+}
@@ -0,0 +1,26 @@
+package types
+
+import "strings"
+
+// PackageDBSource starts the ToolSources value of entries whose version was
+// read from a package database by a fast scan, followed by the database and
+// the package, such as "package-db:dpkg:git"
+const PackageDBSource = "package-db"
+
+// FastScan describes a scan that read the languages, tools, package managers
+// and editors from package databases instead of running each of them. Their
+// versions are those of the packages, so they can differ from a full scan
+// of the same machine without anything having changed.
+type FastScan struct {
+	// Databases lists the package databases read, such as "dpkg" or
+	// "homebrew"
+	Databases []string `json:"databases"`
+	// Caveats describe how the entries can differ from those of a full scan
+	Caveats []string `json:"caveats,omitempty"`
+}
+
+// PackageDBVersion reports whether the version of the entry called name was
+// read from a package database rather than reported by the tool
+func (e *EnvironmentData) PackageDBVersion(name string) bool {
+	return strings.HasPrefix(e.ToolSources[name], PackageDBSource+":")
+}
@@ -0,0 +1,52 @@
+package types
+
+// GitConfig holds the global git settings that change how a machine reaches
+// remotes, and a few that shape everyday use. Stored credentials are never
+// captured, only the helpers that provide them.
+type GitConfig struct {
+	// URLRewrites are the url.<base>.insteadOf and pushInsteadOf rules, in
+	// the order git lists them
+	URLRewrites []URLRewrite `json:"url_rewrites,omitempty"`
+	// CredentialHelpers are the credential.helper settings, in the order git
+	// lists them
+	CredentialHelpers []CredentialHelper `json:"credential_helpers,omitempty"`
+	// Settings holds user.name, init.defaultBranch, core.editor,
+	// pull.rebase and every alias.<name>, keyed by their git config key
+	Settings map[string]string `json:"settings,omitempty"`
+}
+
+// URLRewrite is a url.<base>.insteadOf rule: remote URLs starting with
+// InsteadOf are fetched from Base instead, for example to force SSH for
+// github.com or go through an internal mirror
+type URLRewrite struct {
+	Base      string `json:"base"`
+	InsteadOf string `json:"instead_of"`
+	// Push marks a pushInsteadOf rule, which only applies to pushes
+	Push bool `json:"push,omitempty"`
+}
+
+// Key returns the git config key that sets the rule
+func (r URLRewrite) Key() string {
+	if r.Push {
+		return "url." + r.Base + ".pushInsteadOf"
+	}
+	return "url." + r.Base + ".insteadOf"
+}
+
+// CredentialHelper is a credential.helper setting
+type CredentialHelper struct {
+	// URL limits the helper to credentials for that URL
+	// (credential.<url>.helper); empty for the global helper
+	URL string `json:"url,omitempty"`
+	// Helper is the helper's name or command line, such as "osxkeychain",
+	// "cache --timeout=3600" or "/usr/local/bin/git-credential-manager"
+	Helper string `json:"helper"`
+}
+
+// Key returns the git config key that sets the helper
+func (h CredentialHelper) Key() string {
+	if h.URL != "" {
+		return "credential." + h.URL + ".helper"
+	}
+	return "credential.helper"
+}
@@ -0,0 +1,30 @@
+package types
+
+// GPU records the GPU compute stack found: the NVIDIA driver with the CUDA
+// toolkit and cuDNN, and ROCm. Machines where none of it is found have no
+// GPU section.
+type GPU struct {
+	// DriverVersion is the version of the NVIDIA driver, as nvidia-smi
+	// reports it, such as "535.104.05"
+	DriverVersion string `json:"driver_version,omitempty"`
+	// CUDAVersion is the newest CUDA runtime the NVIDIA driver supports, as
+	// nvidia-smi reports it, such as "12.2". The toolkit installed may be
+	// older.
+	CUDAVersion string `json:"cuda_version,omitempty"`
+	// CUDAToolkit is the version of the CUDA toolkit nvcc belongs to, such
+	// as "12.2.140"
+	CUDAToolkit string `json:"cuda_toolkit,omitempty"`
+	// CuDNN is the version of the cuDNN headers found, such as "8.9.7", or
+	// "Installed" when it could not be told
+	CuDNN string `json:"cudnn,omitempty"`
+	// ROCmVersion is the version of the ROCm installation, such as "6.0.2"
+	ROCmVersion string `json:"rocm_version,omitempty"`
+	// Devices are the names of the GPUs, NVIDIA ones first, such as
+	// "NVIDIA GeForce RTX 3090" or "AMD Radeon RX 6800 XT"
+	Devices []string `json:"devices,omitempty"`
+}
+
+// Empty reports whether nothing of the GPU compute stack was found
+func (g *GPU) Empty() bool {
+	return g == nil || (g.DriverVersion == "" && g.CUDAVersion == "" && g.CUDAToolkit == "" && g.CuDNN == "" && g.ROCmVersion == "" && len(g.Devices) == 0)
+}
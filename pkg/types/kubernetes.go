@@ -0,0 +1,25 @@
+package types
+
+// Kubernetes records the kubectl contexts configured locally and the
+// clusters they point at. Credentials, client certificates, tokens and exec
+// plugins of the kubeconfig are never recorded.
+type Kubernetes struct {
+	// CurrentContext is the context kubectl uses by default, if set
+	CurrentContext string `json:"current_context,omitempty"`
+	// Contexts are the contexts of the merged kubeconfig, in name order
+	Contexts []KubeContext `json:"contexts,omitempty"`
+}
+
+// KubeContext is a kubectl context and the cluster it targets
+type KubeContext struct {
+	// Name is the context's name, such as "kind-dev"
+	Name string `json:"name"`
+	// Cluster is the name of the context's cluster
+	Cluster string `json:"cluster,omitempty"`
+	// Server is the hostname of the cluster's API server, such as
+	// "dev.k8s.example.com" or "127.0.0.1", without scheme, port or path.
+	// Empty when the cluster isn't in the kubeconfig.
+	Server string `json:"server,omitempty"`
+	// Namespace is the namespace the context defaults to, if set
+	Namespace string `json:"namespace,omitempty"`
+}
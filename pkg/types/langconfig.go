@@ -0,0 +1,44 @@
+package types
+
+import "sort"
+
+// LanguageConfig holds the settings of language toolchains that decide where
+// packages are installed and fetched from, keyed by toolchain ("go", "npm",
+// "pip") and then by setting name, such as "GOPATH" or "prefix". Paths inside
+// the home directory are recorded relative to it, as "~/go".
+type LanguageConfig map[string]map[string]string
+
+// LanguageSetting is one setting of a LanguageConfig
+type LanguageSetting struct {
+	Toolchain string `json:"toolchain"`
+	Key       string `json:"key"`
+	Value     string `json:"value"`
+}
+
+// Name identifies the setting across toolchains, as "go.GOPATH"
+func (s LanguageSetting) Name() string {
+	return s.Toolchain + "." + s.Key
+}
+
+// Settings returns every setting of c, ordered by toolchain and then by key
+func (c LanguageConfig) Settings() []LanguageSetting {
+	var settings []LanguageSetting
+	for toolchain, values := range c {
+		for key, value := range values {
+			settings = append(settings, LanguageSetting{Toolchain: toolchain, Key: key, Value: value})
+		}
+	}
+	sort.Slice(settings, func(i, j int) bool {
+		if settings[i].Toolchain != settings[j].Toolchain {
+			return settings[i].Toolchain < settings[j].Toolchain
+		}
+		return settings[i].Key < settings[j].Key
+	})
+	return settings
+}
+
+// Get returns the value of a setting and whether it is recorded
+func (c LanguageConfig) Get(toolchain, key string) (string, bool) {
+	value, ok := c[toolchain][key]
+	return value, ok
+}
@@ -0,0 +1,24 @@
+package types
+
+import "slices"
+
+// ManifestScan describes a scan that only looked for the languages, tools,
+// package managers and editors of another environment, its manifest (see
+// 'scan --manifest'). Entries the manifest doesn't list were not looked
+// for, so their absence says nothing about the machine.
+type ManifestScan struct {
+	// Manifest is the environment file the scan was limited to
+	Manifest string `json:"manifest"`
+	// IDs are the canonical IDs of the entries looked for, sorted
+	IDs []string `json:"ids"`
+}
+
+// Covers reports whether a scan described by m looked for the entry with
+// canonical ID id. Every entry is covered when m is nil, as on full scans.
+func (m *ManifestScan) Covers(id string) bool {
+	if m == nil {
+		return true
+	}
+	_, found := slices.BinarySearch(m.IDs, id)
+	return found
+}
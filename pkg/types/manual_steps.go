@@ -0,0 +1,53 @@
+package types
+
+import (
+	"context"
+	"sync"
+)
+
+// ManualStep is a follow-up action StackMatch cannot automate, such as copying
+// SSH keys or rebooting after an installer asks for it
+type ManualStep struct {
+	Category    string `json:"category"`
+	Description string `json:"description"`
+	DocURL      string `json:"doc_url,omitempty"`
+	Done        bool   `json:"done"`
+	// Snippet is text to paste, such as lines for a shell rc file
+	Snippet string `json:"snippet,omitempty"`
+}
+
+// StepCollector gathers manual steps emitted while planning and installing.
+// It is safe for concurrent use.
+type StepCollector struct {
+	mu    sync.Mutex
+	steps []ManualStep
+}
+
+// Add records a manual step
+func (c *StepCollector) Add(step ManualStep) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.steps = append(c.steps, step)
+}
+
+// Steps returns a copy of the collected steps in the order they were added
+func (c *StepCollector) Steps() []ManualStep {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]ManualStep(nil), c.steps...)
+}
+
+type stepCollectorKey struct{}
+
+// WithStepCollector returns a context that carries c, letting package manager
+// backends report manual steps without changing the Installer interface
+func WithStepCollector(ctx context.Context, c *StepCollector) context.Context {
+	return context.WithValue(ctx, stepCollectorKey{}, c)
+}
+
+// AddManualStep records step on the collector carried by ctx, if any
+func AddManualStep(ctx context.Context, step ManualStep) {
+	if c, ok := ctx.Value(stepCollectorKey{}).(*StepCollector); ok && c != nil {
+		c.Add(step)
+	}
+}
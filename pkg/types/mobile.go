@@ -0,0 +1,58 @@
+package types
+
+// Mobile records the mobile development stack: the Android SDK and
+// Flutter. Nil on machines with neither.
+type Mobile struct {
+	// AndroidSDK is the Android SDK found, if any
+	AndroidSDK *AndroidSDK `json:"android_sdk,omitempty"`
+	// Flutter is the Flutter SDK on PATH, if any
+	Flutter *Flutter `json:"flutter,omitempty"`
+}
+
+// AndroidSDK describes an Android SDK installation by what its directories
+// hold
+type AndroidSDK struct {
+	// Path is the SDK's directory, from $ANDROID_HOME, $ANDROID_SDK_ROOT or
+	// where Android Studio installs it
+	Path string `json:"path"`
+	// Platforms are the directory names of platforms/, such as
+	// "android-34", by API level
+	Platforms []string `json:"platforms,omitempty"`
+	// BuildTools are the directory names of build-tools/, such as
+	// "34.0.0", oldest first
+	BuildTools []string `json:"build_tools,omitempty"`
+}
+
+// Flutter describes the Flutter SDK and what 'flutter doctor' says of the
+// toolchains it needs
+type Flutter struct {
+	// Version is the Flutter version, such as "3.22.2"
+	Version string `json:"version,omitempty"`
+	// Channel is the release channel, such as "stable" or "beta"
+	Channel string `json:"channel,omitempty"`
+	// Dart is the version of the Dart SDK bundled with it
+	Dart string `json:"dart,omitempty"`
+	// Doctor lists the checks of 'flutter doctor', in the order it runs
+	// them. Empty when the doctor was skipped or did not answer in time.
+	Doctor []FlutterDoctorCheck `json:"doctor,omitempty"`
+}
+
+// FlutterDoctorCheck is one check of 'flutter doctor --machine', such as
+// the Android toolchain or Xcode
+type FlutterDoctorCheck struct {
+	// Name is the check's title, such as "Android toolchain - develop for
+	// Android devices"
+	Name string `json:"name"`
+	// Status is FlutterInstalled, FlutterPartial or FlutterMissing
+	Status string `json:"status"`
+	// Summary is the one line summary of the check, such as "Android SDK
+	// version 34.0.0"
+	Summary string `json:"summary,omitempty"`
+}
+
+// Statuses of FlutterDoctorCheck, as 'flutter doctor --machine' reports them
+const (
+	FlutterInstalled = "installed"
+	FlutterPartial   = "partial"
+	FlutterMissing   = "notAvailable"
+)
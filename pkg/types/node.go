@@ -0,0 +1,35 @@
+package types
+
+// NodeEnvironment is how Node.js package managers are set up beyond their
+// versions: whether corepack provides yarn and pnpm, and which package
+// manager the scanned project pins
+type NodeEnvironment struct {
+	// Corepack is the state of corepack, when it is installed
+	Corepack *Corepack `json:"corepack,omitempty"`
+	// PackageManager is the packageManager field of the scanned project's
+	// package.json, such as "pnpm@9.1.0". Only project scans record it.
+	PackageManager string `json:"package_manager,omitempty"`
+}
+
+// Corepack is the state of corepack, which runs the yarn or pnpm version a
+// project's packageManager field asks for through shims on PATH
+type Corepack struct {
+	// Version is what 'corepack --version' reports
+	Version string `json:"version,omitempty"`
+	// Shims lists the package managers whose command on PATH is a corepack
+	// shim, such as "pnpm" and "yarn"
+	Shims []string `json:"shims,omitempty"`
+}
+
+// Enabled reports whether 'corepack enable' has installed shims on PATH
+func (c *Corepack) Enabled() bool {
+	return c != nil && len(c.Shims) > 0
+}
+
+// State returns "enabled" or "disabled", as diff and check report it
+func (c *Corepack) State() string {
+	if c.Enabled() {
+		return "enabled"
+	}
+	return "disabled"
+}
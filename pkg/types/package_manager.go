@@ -1,6 +1,10 @@
 package types
 
-import "context"
+import (
+	"context"
+	"fmt"
+	"time"
+)
 
 // PackageManagerType represents the type of package manager
 type PackageManagerType string
@@ -11,6 +15,7 @@ const (
 	TypeDnf        PackageManagerType = "dnf"
 	TypeYum        PackageManagerType = "yum"
 	TypePacman     PackageManagerType = "pacman"
+	TypeApk        PackageManagerType = "apk"
 	TypeSnap       PackageManagerType = "snap"
 	TypeHomebrew   PackageManagerType = "homebrew"
 	TypeChocolatey PackageManagerType = "chocolatey"
@@ -68,10 +73,148 @@ type Installer interface {
 	UninstallPackage(ctx context.Context, pkg string) error
 }
 
+// PackageManagerMetadata describes the package manager found on this
+// machine, as probed by MetadataDescriber. It is cached between commands.
+type PackageManagerMetadata struct {
+	Type PackageManagerType `json:"type"`
+	// Executable is the path of the package manager's executable
+	Executable string `json:"executable"`
+	Version    string `json:"version,omitempty"`
+	// VerifiedAt is when the package manager was last probed
+	VerifiedAt time.Time `json:"verified_at"`
+}
+
+// MetadataDescriber is implemented by installers that can describe
+// themselves beyond their type
+type MetadataDescriber interface {
+	// Describe probes the package manager's executable and version. What
+	// can't be probed is left empty.
+	Describe(ctx context.Context) PackageManagerMetadata
+}
+
+// UninstallOptions controls how a package is removed
+type UninstallOptions struct {
+	// RemoveDependencies also removes dependencies that were installed automatically
+	RemoveDependencies bool
+}
+
+// UninstallResult reports what an uninstall actually removed
+type UninstallResult struct {
+	// Removed lists every package the package manager reported as removed,
+	// including automatically installed dependencies
+	Removed []string
+}
+
+// UninstallReporter is implemented by installers that can report which
+// packages an uninstall removed
+type UninstallReporter interface {
+	// UninstallPackageWithReport uninstalls a package and returns the packages removed
+	UninstallPackageWithReport(ctx context.Context, pkg string, opts UninstallOptions) (*UninstallResult, error)
+}
+
+// Reinstaller is implemented by installers that can reinstall packages that
+// are already installed, which repairs tools broken by a missing library or
+// a partial upgrade
+type Reinstaller interface {
+	// ReinstallPackages reinstalls packages, installing any that are missing
+	ReinstallPackages(ctx context.Context, packages []string) error
+}
+
+// Pinner is implemented by installers that can hold a package at its
+// installed version, so upgrading the whole system (e.g. 'apt upgrade' or
+// 'brew upgrade') leaves it alone
+type Pinner interface {
+	// PinPackage holds pkg at its installed version
+	PinPackage(ctx context.Context, pkg string) error
+	// UnpinPackage releases a hold placed by PinPackage
+	UnpinPackage(ctx context.Context, pkg string) error
+}
+
+// BulkVersionChecker is implemented by installers that can report the
+// installed versions of many packages with a single query, rather than one
+// query per package
+type BulkVersionChecker interface {
+	// InstalledVersions returns the installed version of every package of
+	// packages that is installed, keyed by package. Packages that are not
+	// installed are left out; those installed at a version the manager
+	// cannot tell map to "".
+	InstalledVersions(ctx context.Context, packages []string) (map[string]string, error)
+}
+
+// InstallScope is where an installer puts packages: system-wide, or into
+// the user's own prefix, as on shared machines without root
+type InstallScope string
+
+const (
+	// ScopeSystem installs where the package manager installs by default,
+	// usually system-wide
+	ScopeSystem InstallScope = "system"
+	// ScopeUser installs into the user's home, such as ~/homebrew for
+	// Homebrew, ~/.local for npm and pip --user
+	ScopeUser InstallScope = "user"
+)
+
+// ParseInstallScope returns the scope named s, "system" or "user"
+func ParseInstallScope(s string) (InstallScope, error) {
+	switch scope := InstallScope(s); scope {
+	case ScopeSystem, ScopeUser:
+		return scope, nil
+	}
+	return "", fmt.Errorf("unknown install scope %q; use system or user", s)
+}
+
+// Scoper is implemented by installers that can install into the user's
+// prefix as well as system-wide. Installers without it, such as apt, only
+// install system-wide.
+type Scoper interface {
+	// Scope returns the scope the installer installs into
+	Scope() InstallScope
+	// SetScope makes the installer install into scope, and returns an
+	// *UnsupportedScopeError when it can't
+	SetScope(scope InstallScope) error
+}
+
+// GlobalPackageInstaller installs packages globally through a language's own
+// package manager, such as 'npm install -g', rather than the system one
+type GlobalPackageInstaller interface {
+	// Name returns the package manager's key in EnvironmentData.GlobalPackages,
+	// such as "npm"
+	Name() string
+
+	// IsAvailable checks if the package manager is available on the system
+	IsAvailable() bool
+
+	// InstallGlobal installs packages given as name@version, or just the
+	// name for the latest version
+	InstallGlobal(ctx context.Context, packages []string) error
+}
+
+// VersionManager installs language runtimes at specific versions, such as
+// mise or asdf. It is preferred over the system package manager for languages.
+type VersionManager interface {
+	// Name returns the name of the version manager
+	Name() string
+
+	// IsAvailable checks if the version manager is available on the system
+	IsAvailable() bool
+
+	// Supports reports whether the version manager can install the language,
+	// given its scanner name (e.g. "Node.js")
+	Supports(language string) bool
+
+	// InstallRuntime installs the language at a version satisfying constraint.
+	// An empty constraint installs the latest version.
+	InstallRuntime(ctx context.Context, language, constraint string) error
+}
+
 // PackageInfo contains information about a package that can be installed
 type PackageInfo struct {
 	Name    string
 	Version string // Optional version constraint
+	// Tool is the environment entry the package installs, such as "Git"
+	Tool string
+	// Manager is the package manager that installs the package
+	Manager PackageManagerType
 }
 
 // InstallOptions contains options for package installation
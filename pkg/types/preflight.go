@@ -0,0 +1,29 @@
+package types
+
+import "context"
+
+// PreflightResult is the outcome of a check run before installing anything,
+// so imports don't stop halfway for reasons that could be seen up front
+type PreflightResult struct {
+	// Check names what was checked, such as "repositories reachable"
+	Check string `json:"check"`
+	// Problem describes what is wrong; empty when the check passed
+	Problem string `json:"problem,omitempty"`
+	// Fix tells the user how to resolve the problem
+	Fix string `json:"fix,omitempty"`
+}
+
+// Passed reports whether the check found no problem
+func (r PreflightResult) Passed() bool {
+	return r.Problem == ""
+}
+
+// PreflightChecker is implemented by installers that can check their own
+// health and reach their repositories before installing
+type PreflightChecker interface {
+	// InstallVolume returns a path on the volume packages are installed to
+	InstallVolume() string
+	// PreflightChecks runs the installer's checks. Each finishes within a
+	// short timeout.
+	PreflightChecks(ctx context.Context) []PreflightResult
+}
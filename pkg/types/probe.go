@@ -0,0 +1,72 @@
+package types
+
+import "context"
+
+// ManagerProbe is what detecting the package manager found out about one of
+// those considered
+type ManagerProbe struct {
+	// Name is the package manager's display name, such as "APT"
+	Name string             `json:"name"`
+	Type PackageManagerType `json:"type"`
+	// Found is set when its executable is on PATH
+	Found bool `json:"found"`
+	// Executable is where it was found
+	Executable string `json:"executable,omitempty"`
+	// Problem says why a package manager that was found can't be used, such
+	// as "/var/lib/dpkg is locked: unattended-upgrade (pid 812) is using it"
+	Problem string `json:"problem,omitempty"`
+	// Fix tells the user how to resolve the problem
+	Fix string `json:"fix,omitempty"`
+	// Selected is set on the package manager detection chose
+	Selected bool `json:"selected,omitempty"`
+}
+
+// Usable reports whether the package manager was found and can be used
+func (p ManagerProbe) Usable() bool {
+	return p.Found && p.Problem == ""
+}
+
+// ProbeReport lists every package manager considered for an OS, in the
+// order they are preferred
+type ProbeReport struct {
+	OS       string         `json:"os"`
+	Managers []ManagerProbe `json:"managers"`
+}
+
+// Selected returns the package manager detection chose, if any
+func (r ProbeReport) Selected() (ManagerProbe, bool) {
+	for _, probe := range r.Managers {
+		if probe.Selected {
+			return probe, true
+		}
+	}
+	return ManagerProbe{}, false
+}
+
+// PassedOver counts the package managers found but unusable ahead of the
+// selected one, which detection only passes over when told to fall back
+func (r ProbeReport) PassedOver() int {
+	passed := 0
+	for _, probe := range r.Managers {
+		if probe.Selected {
+			return passed
+		}
+		if probe.Found {
+			passed++
+		}
+	}
+	return 0
+}
+
+// ManagerProber is implemented by installers that can say where their
+// executable is and whether it can be used at all, such as APT with the
+// dpkg database locked. Package manager detection passes over those that
+// report a problem.
+type ManagerProber interface {
+	// Executable returns the path of the executable on PATH, or "" when it
+	// isn't there
+	Executable() string
+	// CheckUsable runs quick local checks. Checks that can't tell, such as a
+	// probe command that fails, pass.
+	CheckUsable(ctx context.Context) PreflightResult
+}
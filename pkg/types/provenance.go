@@ -0,0 +1,46 @@
+package types
+
+import "fmt"
+
+// Provenance sources that are not package managers or scan sources
+const (
+	// ProvenanceManual is the source of files no package manager owns
+	ProvenanceManual = "manual"
+	// ProvenanceImport is the source of entries a 'stackmatch import'
+	// installed (see Provenance.Installation)
+	ProvenanceImport = "stackmatch-import"
+)
+
+// Provenance records how an entry found on the machine was installed. It
+// joins the source the scanner recorded (see ToolInfo.Source), the records
+// of 'stackmatch import' and which package owns the executable.
+type Provenance struct {
+	// Source is ProvenanceImport, a package manager such as "apt", a scan
+	// source such as "login-shell:zsh", or ProvenanceManual
+	Source string `json:"source"`
+	// Manager is the package manager that installed or owns the entry
+	Manager PackageManagerType `json:"manager,omitempty"`
+	// Package is the package that installed or owns the entry
+	Package string `json:"package,omitempty"`
+	// Installation is the ID of the import that installed the entry
+	Installation string `json:"installation,omitempty"`
+	// Conflict explains how the sources disagreed, such as an import that
+	// recorded installing a file no package owns
+	Conflict string `json:"conflict,omitempty"`
+}
+
+// String describes p, such as "apt (git)" or "stackmatch import inst_1 via apt"
+func (p Provenance) String() string {
+	switch {
+	case p.Source == ProvenanceImport && p.Manager != "":
+		return fmt.Sprintf("stackmatch import %s via %s", p.Installation, p.Manager)
+	case p.Source == ProvenanceImport:
+		return "stackmatch import " + p.Installation
+	case p.Package != "" && string(p.Manager) == p.Source:
+		return fmt.Sprintf("%s (%s)", p.Source, p.Package)
+	case p.Manager != "" && string(p.Manager) != p.Source:
+		return fmt.Sprintf("%s, owned by %s", p.Source, p.Manager)
+	default:
+		return p.Source
+	}
+}
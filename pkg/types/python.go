@@ -0,0 +1,94 @@
+package types
+
+import "strings"
+
+// Python environment managers, as named in PythonEnvironment.Manager
+const (
+	PythonManagerPyenv = "pyenv"
+	PythonManagerConda = "conda"
+	PythonManagerUv    = "uv"
+)
+
+// PythonEnvironment is how Python is set up: what the Python environment
+// managers found select, and which interpreter python3 actually resolves to.
+// Python is detected by running python3, which on many machines is a broken
+// shim or a system interpreter nobody uses, so this tells the two apart.
+type PythonEnvironment struct {
+	// Pyenv is what pyenv selects, when pyenv is installed
+	Pyenv *PyenvSelection `json:"pyenv,omitempty"`
+	// Conda lists the conda environments, when conda is installed
+	Conda *CondaEnvironments `json:"conda,omitempty"`
+	// Uv is the version of uv, when it is installed
+	Uv string `json:"uv,omitempty"`
+	// Virtualenvwrapper is set when virtualenvwrapper is installed
+	Virtualenvwrapper bool `json:"virtualenvwrapper,omitempty"`
+	// Interpreter is what python3 on PATH resolves to, when it is on PATH
+	Interpreter *PythonInterpreter `json:"interpreter,omitempty"`
+	// Mismatch explains how the interpreter differs from the one the
+	// manager configures, such as "pyenv says 3.12.1 but PATH resolves to
+	// /usr/bin/python3 3.10.12". Empty when they agree or nothing is
+	// configured.
+	Mismatch string `json:"mismatch,omitempty"`
+}
+
+// PyenvSelection is the Python versions pyenv is configured with. "system"
+// selects the interpreter pyenv didn't install.
+type PyenvSelection struct {
+	// Global is the version from 'pyenv global'
+	Global string `json:"global,omitempty"`
+	// Local is the version from 'pyenv local', set by a .python-version file
+	// in the scanned directory or its parents
+	Local string `json:"local,omitempty"`
+}
+
+// Selected returns the version pyenv selects, local before global, or ""
+// when it selects the system interpreter
+func (p *PyenvSelection) Selected() string {
+	selected := p.Local
+	if selected == "" {
+		selected = p.Global
+	}
+	if selected == "system" {
+		return ""
+	}
+	return selected
+}
+
+// CondaEnvironments is what conda knows about its environments
+type CondaEnvironments struct {
+	// Base is the prefix of the base environment
+	Base string `json:"base,omitempty"`
+	// Active is the prefix of the activated environment, if any
+	Active string `json:"active,omitempty"`
+	// Envs lists the prefixes of every environment, base included
+	Envs []string `json:"envs,omitempty"`
+}
+
+// PythonInterpreter is an interpreter found on PATH
+type PythonInterpreter struct {
+	// Path is the python3 executable PATH resolves to
+	Path string `json:"path"`
+	// Version is what the interpreter reports; empty when it failed to run
+	Version string `json:"version,omitempty"`
+	// Prefix is the interpreter's sys.prefix, which tells virtual and conda
+	// environments apart from the installation they were made from
+	Prefix string `json:"prefix,omitempty"`
+	// Error is why the interpreter failed to run, as broken shims do
+	Error string `json:"error,omitempty"`
+}
+
+// Manager returns the manager that provides the interpreter in use:
+// pyenv when it selects a version, conda when an environment is active and
+// uv when the interpreter is one uv installed. Empty when Python comes from
+// elsewhere, such as the system package manager.
+func (p *PythonEnvironment) Manager() string {
+	switch {
+	case p.Pyenv != nil && p.Pyenv.Selected() != "":
+		return PythonManagerPyenv
+	case p.Conda != nil && p.Conda.Active != "":
+		return PythonManagerConda
+	case p.Uv != "" && p.Interpreter != nil && strings.Contains(strings.ReplaceAll(p.Interpreter.Prefix, `\`, "/"), "/uv/python/"):
+		return PythonManagerUv
+	}
+	return ""
+}
@@ -0,0 +1,38 @@
+package types
+
+// Requirement classifies how much an entry matters to an environment a team
+// shares: required entries must be present, optional ones are personal
+// preference. Entries never annotated, including every entry of files
+// written before classification existed, are unclassified.
+type Requirement string
+
+const (
+	// Unclassified is the zero value, for entries that were never annotated
+	Unclassified Requirement = ""
+	// Required entries fail checks when missing and are installed by
+	// 'import --required-only'
+	Required Requirement = "required"
+	// Optional entries only produce warnings when missing
+	Optional Requirement = "optional"
+)
+
+// String returns the name shown to users, "unclassified" for the zero value
+func (r Requirement) String() string {
+	if r == Unclassified {
+		return "unclassified"
+	}
+	return string(r)
+}
+
+// SetRequirement classifies the entry called name, removing the
+// classification when r is Unclassified
+func (e *EnvironmentData) SetRequirement(name string, r Requirement) {
+	if r == Unclassified {
+		delete(e.Requirements, name)
+		return
+	}
+	if e.Requirements == nil {
+		e.Requirements = make(map[string]Requirement)
+	}
+	e.Requirements[name] = r
+}
@@ -0,0 +1,26 @@
+package types
+
+// Sources of scheduled jobs
+const (
+	JobSourceCrontab  = "crontab"
+	JobSourceSchtasks = "schtasks"
+)
+
+// ScheduledJob is a job the user scheduled with cron or the Windows Task
+// Scheduler, such as a nightly backup or cache cleanup
+type ScheduledJob struct {
+	// Source is JobSourceCrontab or JobSourceSchtasks
+	Source string `json:"source"`
+	// Name is the task's path in the Task Scheduler; crontab entries have none
+	Name string `json:"name,omitempty"`
+	// Schedule is the cron expression (e.g. "0 3 * * *" or "@daily") or a
+	// description of the task's trigger (e.g. "Daily at 3:00:00 AM")
+	Schedule string `json:"schedule"`
+	// Command is the command the job runs, with secrets redacted
+	Command string `json:"command"`
+}
+
+// Line returns the job as a crontab line
+func (j ScheduledJob) Line() string {
+	return j.Schedule + " " + j.Command
+}
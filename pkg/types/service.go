@@ -0,0 +1,35 @@
+package types
+
+// Service managers a developer service can be registered with
+const (
+	ServiceManagerBrew    = "brew-services"
+	ServiceManagerSystemd = "systemd"
+	ServiceManagerWindows = "windows"
+)
+
+// Scopes of a service
+const (
+	// ServiceScopeUser services run as the user and start at login
+	ServiceScopeUser = "user"
+	// ServiceScopeSystem services run as the system and start at boot;
+	// changing them needs administrator rights
+	ServiceScopeSystem = "system"
+)
+
+// Service is a developer service, such as a database, set to start on its
+// own through a service manager
+type Service struct {
+	// Name is the service's name across managers, such as "postgresql"
+	Name string `json:"name"`
+	// Unit is the manager's name for it, such as "postgresql@16",
+	// "redis-server.service" or "MySQL80"
+	Unit string `json:"unit"`
+	// Manager is ServiceManagerBrew, ServiceManagerSystemd or
+	// ServiceManagerWindows
+	Manager string `json:"manager"`
+	// Scope is ServiceScopeUser or ServiceScopeSystem
+	Scope string `json:"scope"`
+	// State is the state the manager reports, such as "started", "enabled"
+	// or "Automatic"
+	State string `json:"state"`
+}
@@ -0,0 +1,18 @@
+package types
+
+// SSHKeyInfo describes an SSH public key of ~/.ssh by what 'ssh-keygen -lf'
+// reports of it. Neither the key nor its comment, which is often an email
+// address, is recorded, and the private key is never read.
+type SSHKeyInfo struct {
+	// File is the public key's file name, such as "id_ed25519.pub"
+	File string `json:"file"`
+	// Type is the key type, such as "ED25519", "RSA" or "ECDSA-SK"
+	Type string `json:"type"`
+	// Bits is the key size, such as 256 or 4096
+	Bits int `json:"bits"`
+	// Fingerprint is the SHA-256 fingerprint, such as "SHA256:uNiVz..."
+	Fingerprint string `json:"fingerprint"`
+	// PrivateKey is set when the private key file next to it, the same name
+	// without .pub, exists. Only its presence is checked.
+	PrivateKey bool `json:"private_key,omitempty"`
+}
@@ -0,0 +1,108 @@
+package types
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// CurrentSchemaVersion is the schema version written by this release.
+// Files without a schema_version predate it and carry no summary.
+const CurrentSchemaVersion = 2
+
+// Summary is a small digest of an environment, cheap to list and search
+// without parsing the full data
+type Summary struct {
+	// Counts maps a category (see the Category constants) to its number of entries
+	Counts map[string]int `json:"counts"`
+	OS     string         `json:"os"`
+	// Fingerprint identifies the environment's contents independently of
+	// when or where it was scanned
+	Fingerprint string `json:"fingerprint"`
+	// ScanDurationMS is how long the scan took, when known
+	ScanDurationMS int64 `json:"scan_duration_ms,omitempty"`
+}
+
+// BuildSummary computes the summary of env. The scan duration is carried
+// over from env's current summary since it cannot be derived from the data.
+func BuildSummary(env *EnvironmentData) *Summary {
+	summary := &Summary{
+		Counts: map[string]int{
+			CategoryLanguages:       len(env.ConfiguredLanguages),
+			CategoryTools:           len(env.Tools),
+			CategoryPackageManagers: len(env.PackageManagers),
+			CategoryEditors:         len(env.CodeEditors),
+			CategoryConfigFiles:     len(env.ConfigFiles),
+		},
+		OS:          env.System.OS,
+		Fingerprint: fingerprint(env),
+	}
+	for _, category := range ExtensionCategories(env) {
+		if _, ok := summary.Counts[category]; !ok {
+			summary.Counts[category] = len(env.Extensions[category])
+		}
+	}
+	if env.Summary != nil {
+		summary.ScanDurationMS = env.Summary.ScanDurationMS
+	}
+	return summary
+}
+
+// RefreshSummary recomputes env's summary if it is missing or no longer
+// matches the data, as with files written by older releases or edited by
+// hand. It reports whether the summary changed.
+func RefreshSummary(env *EnvironmentData) bool {
+	fresh := BuildSummary(env)
+	if env.Summary != nil && env.Summary.Fingerprint == fresh.Fingerprint && env.Summary.OS == fresh.OS && sameCounts(env.Summary.Counts, fresh.Counts) {
+		return false
+	}
+	env.Summary = fresh
+	return true
+}
+
+func sameCounts(a, b map[string]int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if b[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// fingerprint hashes the system platform and every category entry in a
+// stable order. Scan date, hostname and shell are left out so that two
+// scans of the same setup share a fingerprint.
+func fingerprint(env *EnvironmentData) string {
+	lines := []string{"os=" + env.System.OS, "arch=" + env.System.Arch}
+	sections := []struct {
+		category string
+		entries  map[string]string
+	}{
+		{CategoryLanguages, env.ConfiguredLanguages},
+		{CategoryTools, env.Tools},
+		{CategoryPackageManagers, env.PackageManagers},
+		{CategoryEditors, env.CodeEditors},
+	}
+	for _, section := range sections {
+		for name, version := range section.entries {
+			lines = append(lines, fmt.Sprintf("%s/%s=%s", section.category, name, version))
+		}
+	}
+	for _, file := range env.ConfigFiles {
+		lines = append(lines, CategoryConfigFiles+"/"+file)
+	}
+	for _, category := range ExtensionCategories(env) {
+		for name, version := range env.Extensions[category] {
+			lines = append(lines, fmt.Sprintf("%s/%s=%s", category, name, version))
+		}
+	}
+	sort.Strings(lines[2:])
+
+	sum := sha256.Sum256([]byte(strings.Join(lines, "\n")))
+	return hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,90 @@
+package types
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func loadFixture(t *testing.T, name string) *EnvironmentData {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+	var env EnvironmentData
+	if err := json.Unmarshal(data, &env); err != nil {
+		t.Fatalf("failed to parse fixture: %v", err)
+	}
+	return &env
+}
+
+func TestRefreshSummary(t *testing.T) {
+	testCases := []struct {
+		name          string
+		fixture       string
+		schemaVersion int
+	}{
+		// Written before summaries existed
+		{name: "Schema v1 without summary", fixture: "env_v1.json", schemaVersion: 0},
+		// Summary is stale: a tool was added by hand after the scan
+		{name: "Schema v2 with stale summary", fixture: "env_v2.json", schemaVersion: 2},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			env := loadFixture(t, tc.fixture)
+			if env.SchemaVersion != tc.schemaVersion {
+				t.Fatalf("expected schema version %d but got %d", tc.schemaVersion, env.SchemaVersion)
+			}
+
+			if !RefreshSummary(env) {
+				t.Fatal("expected the summary to be recomputed")
+			}
+
+			expected := map[string]int{
+				CategoryLanguages:       len(env.ConfiguredLanguages),
+				CategoryTools:           len(env.Tools),
+				CategoryPackageManagers: len(env.PackageManagers),
+				CategoryEditors:         len(env.CodeEditors),
+				CategoryConfigFiles:     len(env.ConfigFiles),
+			}
+			if !sameCounts(env.Summary.Counts, expected) {
+				t.Errorf("expected counts %v but got %v", expected, env.Summary.Counts)
+			}
+			if env.Summary.OS != env.System.OS {
+				t.Errorf("expected OS %q but got %q", env.System.OS, env.Summary.OS)
+			}
+
+			// A fresh summary is left alone
+			if RefreshSummary(env) {
+				t.Error("expected an up to date summary not to change")
+			}
+		})
+	}
+}
+
+func TestRefreshSummaryKeepsScanDuration(t *testing.T) {
+	env := loadFixture(t, "env_v2.json")
+	RefreshSummary(env)
+	if env.Summary.ScanDurationMS != 1840 {
+		t.Errorf("expected scan duration to be kept, got %d", env.Summary.ScanDurationMS)
+	}
+}
+
+func TestFingerprintIgnoresScanDetails(t *testing.T) {
+	a := loadFixture(t, "env_v1.json")
+	b := loadFixture(t, "env_v1.json")
+	b.System.Hostname = "other-host"
+	b.ScanDate = b.ScanDate.AddDate(0, 1, 0)
+
+	if BuildSummary(a).Fingerprint != BuildSummary(b).Fingerprint {
+		t.Error("expected hostname and scan date not to affect the fingerprint")
+	}
+
+	b.Tools["Git"] = "2.45.0"
+	if BuildSummary(a).Fingerprint == BuildSummary(b).Fingerprint {
+		t.Error("expected a version change to change the fingerprint")
+	}
+}
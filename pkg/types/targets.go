@@ -0,0 +1,142 @@
+package types
+
+import (
+	"maps"
+	"slices"
+	"strings"
+)
+
+// TargetCategories are the JSON names of the categories a platform target
+// can add entries to
+var TargetCategories = []string{"configured_languages", "tools", "package_managers", "code_editors"}
+
+// PlatformTarget adjusts the entries of an environment for one platform, so
+// a single file can describe an environment meant for macOS and Linux alike.
+// Removals apply first, then renames, then additions.
+type PlatformTarget struct {
+	// Add adds entries, or changes the version of base entries, keyed by
+	// category (see TargetCategories) and then by name
+	Add map[string]map[string]string `json:"add,omitempty"`
+	// Remove names the base entries this platform goes without
+	Remove []string `json:"remove,omitempty"`
+	// Rename maps base entry names to the name the entry goes by on this
+	// platform, such as "Docker Desktop" to "Docker". The entry keeps its
+	// version and requirement and takes the tool ID of its new name.
+	Rename map[string]string `json:"rename,omitempty"`
+}
+
+// TargetKey returns the key of the target for os and arch, such as
+// "darwin/arm64"
+func TargetKey(os, arch string) string {
+	return os + "/" + arch
+}
+
+// Target returns the key and the target of e matching os and arch: the one
+// keyed by os/arch, or else the one keyed by os alone. The key is "" when no
+// target matches.
+func (e *EnvironmentData) Target(os, arch string) (string, *PlatformTarget) {
+	for _, key := range []string{TargetKey(os, arch), os} {
+		if target, ok := e.Targets[key]; ok && target != nil {
+			return key, target
+		}
+	}
+	return "", nil
+}
+
+// ForPlatform returns a copy of env with the target matching os and arch
+// merged into its entries (see PlatformTarget) and its targets dropped, and
+// the key of the target merged, or "" when none matches. Names are matched
+// exactly; use validate to find the ones naming no entry, which are skipped.
+// env is not modified.
+func ForPlatform(env EnvironmentData, os, arch string) (EnvironmentData, string) {
+	key, target := env.Target(os, arch)
+	env.Targets = nil
+	if target == nil {
+		return env, ""
+	}
+
+	env.ConfiguredLanguages = maps.Clone(env.ConfiguredLanguages)
+	env.Tools = maps.Clone(env.Tools)
+	env.PackageManagers = maps.Clone(env.PackageManagers)
+	env.CodeEditors = maps.Clone(env.CodeEditors)
+	env.ToolIDs = maps.Clone(env.ToolIDs)
+	env.ToolSources = maps.Clone(env.ToolSources)
+	env.Provenance = maps.Clone(env.Provenance)
+	env.BrokenTools = maps.Clone(env.BrokenTools)
+	env.Requirements = maps.Clone(env.Requirements)
+	env.Aliases = maps.Clone(env.Aliases)
+
+	for _, name := range target.Remove {
+		if entries := env.targetEntries(name); entries != nil {
+			delete(entries, name)
+			env.forget(name)
+		}
+	}
+	for _, from := range slices.Sorted(maps.Keys(target.Rename)) {
+		to := target.Rename[from]
+		entries := env.targetEntries(from)
+		if entries == nil || to == "" || to == from {
+			continue
+		}
+		version, requirement := entries[from], env.Requirements[from]
+		delete(entries, from)
+		env.forget(from)
+		entries[to] = version
+		env.SetRequirement(to, requirement)
+	}
+	for _, category := range TargetCategories {
+		for name, version := range target.Add[category] {
+			entries := env.targetCategory(category)
+			if *entries == nil {
+				*entries = make(map[string]string)
+			}
+			(*entries)[name] = version
+		}
+	}
+	return env, key
+}
+
+// targetEntries returns the entry map holding name, or nil
+func (e *EnvironmentData) targetEntries(name string) map[string]string {
+	for _, category := range TargetCategories {
+		if entries := *e.targetCategory(category); entries != nil {
+			if _, ok := entries[name]; ok {
+				return entries
+			}
+		}
+	}
+	return nil
+}
+
+// targetCategory returns the entry map of a category in TargetCategories
+func (e *EnvironmentData) targetCategory(category string) *map[string]string {
+	switch category {
+	case "configured_languages":
+		return &e.ConfiguredLanguages
+	case "tools":
+		return &e.Tools
+	case "package_managers":
+		return &e.PackageManagers
+	case "code_editors":
+		return &e.CodeEditors
+	}
+	return nil
+}
+
+// forget drops what is recorded about the entry called name besides its
+// version: how this machine got it no longer applies once it is moved
+func (e *EnvironmentData) forget(name string) {
+	delete(e.ToolIDs, name)
+	delete(e.ToolSources, name)
+	delete(e.Provenance, name)
+	delete(e.BrokenTools, name)
+	delete(e.Requirements, name)
+	delete(e.Aliases, name)
+}
+
+// validTargetKey reports whether key is "os" or "os/arch" for a known
+// operating system
+func validTargetKey(key string) bool {
+	os, arch, hasArch := strings.Cut(key, "/")
+	return knownOS[os] && (!hasArch || (arch != "" && !strings.Contains(arch, "/")))
+}
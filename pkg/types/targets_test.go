@@ -0,0 +1,118 @@
+package types
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestForPlatform(t *testing.T) {
+	base := func() EnvironmentData {
+		return EnvironmentData{
+			System:          SystemInfo{OS: "darwin", Arch: "arm64"},
+			Tools:           map[string]string{"Git": "2.45.0", "Docker Desktop": "4.30.0", "coreutils": "9.5"},
+			PackageManagers: map[string]string{"Homebrew": "4.3.1"},
+			CodeEditors:     map[string]string{"VS Code": "1.89.1"},
+			ToolIDs:         map[string]string{"VS Code": "vscode", "Docker Desktop": "docker"},
+			ToolSources:     map[string]string{"coreutils": "brew"},
+			Requirements:    map[string]Requirement{"Docker Desktop": Required, "Homebrew": Required},
+			Targets: map[string]*PlatformTarget{
+				"linux/amd64": {
+					Add:    map[string]map[string]string{"package_managers": {"APT": "2.4.11"}, "tools": {"Git": "2.43.0"}},
+					Remove: []string{"Homebrew"},
+					Rename: map[string]string{"Docker Desktop": "Docker", "coreutils": "GNU coreutils"},
+				},
+				"linux": {Remove: []string{"VS Code"}},
+				"darwin": {
+					Add:    map[string]map[string]string{"code_editors": {"Xcode": "Installed"}},
+					Remove: []string{"Missing"},
+				},
+			},
+		}
+	}
+
+	testCases := []struct {
+		name         string
+		os, arch     string
+		key          string
+		tools        map[string]string
+		managers     map[string]string
+		editors      map[string]string
+		toolIDs      map[string]string
+		requirements map[string]Requirement
+	}{
+		{
+			name:         "Linux target adds, removes and renames",
+			os:           "linux",
+			arch:         "amd64",
+			key:          "linux/amd64",
+			tools:        map[string]string{"Git": "2.43.0", "Docker": "4.30.0", "GNU coreutils": "9.5"},
+			managers:     map[string]string{"APT": "2.4.11"},
+			editors:      map[string]string{"VS Code": "1.89.1"},
+			toolIDs:      map[string]string{"VS Code": "vscode"},
+			requirements: map[string]Requirement{"Docker": Required},
+		},
+		{
+			name:         "OS-only target matches any architecture",
+			os:           "linux",
+			arch:         "arm64",
+			key:          "linux",
+			tools:        map[string]string{"Git": "2.45.0", "Docker Desktop": "4.30.0", "coreutils": "9.5"},
+			managers:     map[string]string{"Homebrew": "4.3.1"},
+			editors:      map[string]string{},
+			toolIDs:      map[string]string{"Docker Desktop": "docker"},
+			requirements: map[string]Requirement{"Docker Desktop": Required, "Homebrew": Required},
+		},
+		{
+			name:         "macOS target skips names the base lacks",
+			os:           "darwin",
+			arch:         "arm64",
+			key:          "darwin",
+			tools:        map[string]string{"Git": "2.45.0", "Docker Desktop": "4.30.0", "coreutils": "9.5"},
+			managers:     map[string]string{"Homebrew": "4.3.1"},
+			editors:      map[string]string{"VS Code": "1.89.1", "Xcode": "Installed"},
+			toolIDs:      map[string]string{"VS Code": "vscode", "Docker Desktop": "docker"},
+			requirements: map[string]Requirement{"Docker Desktop": Required, "Homebrew": Required},
+		},
+		{
+			name:         "No target for the platform",
+			os:           "windows",
+			arch:         "amd64",
+			tools:        map[string]string{"Git": "2.45.0", "Docker Desktop": "4.30.0", "coreutils": "9.5"},
+			managers:     map[string]string{"Homebrew": "4.3.1"},
+			editors:      map[string]string{"VS Code": "1.89.1"},
+			toolIDs:      map[string]string{"VS Code": "vscode", "Docker Desktop": "docker"},
+			requirements: map[string]Requirement{"Docker Desktop": Required, "Homebrew": Required},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			env := base()
+			merged, key := ForPlatform(env, tc.os, tc.arch)
+			if key != tc.key {
+				t.Errorf("expected target %q but got %q", tc.key, key)
+			}
+			if merged.Targets != nil {
+				t.Errorf("expected the targets to be dropped but got %v", merged.Targets)
+			}
+			if !reflect.DeepEqual(merged.Tools, tc.tools) {
+				t.Errorf("expected tools %v but got %v", tc.tools, merged.Tools)
+			}
+			if !reflect.DeepEqual(merged.PackageManagers, tc.managers) {
+				t.Errorf("expected package managers %v but got %v", tc.managers, merged.PackageManagers)
+			}
+			if !reflect.DeepEqual(merged.CodeEditors, tc.editors) {
+				t.Errorf("expected editors %v but got %v", tc.editors, merged.CodeEditors)
+			}
+			if !reflect.DeepEqual(merged.ToolIDs, tc.toolIDs) {
+				t.Errorf("expected tool IDs %v but got %v", tc.toolIDs, merged.ToolIDs)
+			}
+			if !reflect.DeepEqual(merged.Requirements, tc.requirements) {
+				t.Errorf("expected requirements %v but got %v", tc.requirements, merged.Requirements)
+			}
+			if !reflect.DeepEqual(env, base()) {
+				t.Errorf("expected the base environment to be left unchanged")
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package types
+
+import "strings"
+
+// ToolInfo identifies a tool the scanner knows about
+type ToolInfo struct {
+	// ID is the canonical identifier package mappings are keyed by (e.g. "nodejs")
+	ID string `json:"id"`
+	// Name is the display name recorded in environments (e.g. "Node.js")
+	Name string `json:"name"`
+	// Source records how the tool was installed when known, such as
+	// "dnf-module:nodejs:18" for a DNF module stream
+	Source string `json:"source,omitempty"`
+	// ExitStatus is the exit status of a failed version command
+	ExitStatus int `json:"exit_status,omitempty"`
+	// Broken is set for tools found on PATH whose version command fails,
+	// such as a node linked against a missing library
+	Broken bool `json:"broken,omitempty"`
+	// Requirement is how the environment classifies the tool
+	Requirement Requirement `json:"requirement,omitempty"`
+	// Aliases are the other names the tool was reported under, such as
+	// "Python 3" for Python
+	Aliases []string `json:"aliases,omitempty"`
+}
+
+// ToolFailure records how the version command of a broken tool failed
+type ToolFailure struct {
+	// ExitStatus is the command's exit status, or -1 when it could not be run
+	ExitStatus int `json:"exit_status"`
+	// Output is the first line the command printed, usually the error
+	Output string `json:"output,omitempty"`
+}
+
+// CanonicalID derives a tool identifier from a display name: lower case,
+// with dots removed and spaces replaced by hyphens, so "Node.js" becomes
+// "nodejs" and "Docker Compose" becomes "docker-compose"
+func CanonicalID(name string) string {
+	id := strings.ToLower(strings.TrimSpace(name))
+	id = strings.ReplaceAll(id, ".", "")
+	return strings.Join(strings.Fields(id), "-")
+}
+
+// ToolID returns the canonical ID of the entry called name: the one the
+// scanner recorded, or one derived from the name for files written before
+// IDs were recorded
+func (e *EnvironmentData) ToolID(name string) string {
+	if id, ok := e.ToolIDs[name]; ok && id != "" {
+		return id
+	}
+	return CanonicalID(name)
+}
+
+// Tool returns what is known about the entry called name
+func (e *EnvironmentData) Tool(name string) ToolInfo {
+	info := ToolInfo{ID: e.ToolID(name), Name: name, Source: e.ToolSources[name], Requirement: e.Requirements[name], Aliases: e.Aliases[name]}
+	if failure, ok := e.BrokenTools[name]; ok {
+		info.ExitStatus = failure.ExitStatus
+		info.Broken = true
+	}
+	return info
+}
+
+// SideBySideVersions returns the versions of the language entry called name
+// found installed side by side (see LanguageVersions), oldest first. The
+// "Python 3" entry gets the 3.x versions recorded for Python.
+func (e *EnvironmentData) SideBySideVersions(name string) []string {
+	if name != "Python 3" {
+		return e.LanguageVersions[name]
+	}
+	var versions []string
+	for _, v := range e.LanguageVersions["Python"] {
+		if strings.HasPrefix(v, "3.") {
+			versions = append(versions, v)
+		}
+	}
+	return versions
+}
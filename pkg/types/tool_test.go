@@ -0,0 +1,71 @@
+package types
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCanonicalID(t *testing.T) {
+	testCases := []struct {
+		name     string
+		expected string
+	}{
+		{"Node.js", "nodejs"},
+		{"VS Code", "vs-code"},
+		{"Docker Compose", "docker-compose"},
+		{"  Google  Cloud SDK ", "google-cloud-sdk"},
+		{"git", "git"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := CanonicalID(tc.name); got != tc.expected {
+				t.Errorf("expected %q but got %q", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestToolID(t *testing.T) {
+	env := EnvironmentData{ToolIDs: map[string]string{"VS Code": "vscode"}}
+	if got := env.ToolID("VS Code"); got != "vscode" {
+		t.Errorf("expected the recorded ID vscode but got %q", got)
+	}
+	if got := env.ToolID("Node.js"); got != "nodejs" {
+		t.Errorf("expected the derived ID nodejs but got %q", got)
+	}
+}
+
+func TestTool(t *testing.T) {
+	env := EnvironmentData{
+		ToolSources:  map[string]string{"Node.js": "dnf-module:nodejs:18"},
+		Requirements: map[string]Requirement{"Node.js": Required},
+		Aliases:      map[string][]string{"Node.js": {"node"}},
+	}
+	expected := ToolInfo{ID: "nodejs", Name: "Node.js", Source: "dnf-module:nodejs:18", Requirement: Required, Aliases: []string{"node"}}
+	if got := env.Tool("Node.js"); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %+v but got %+v", expected, got)
+	}
+	if got := env.Tool("Git"); got.Source != "" || got.Requirement != Unclassified {
+		t.Errorf("expected no source or requirement for Git but got %+v", got)
+	}
+}
+
+func TestProvenanceString(t *testing.T) {
+	testCases := []struct {
+		provenance Provenance
+		expected   string
+	}{
+		{Provenance{Source: "apt", Manager: TypeApt, Package: "git"}, "apt (git)"},
+		{Provenance{Source: ProvenanceManual}, "manual"},
+		{Provenance{Source: ProvenanceImport, Manager: TypeApt, Package: "git", Installation: "inst_1"}, "stackmatch import inst_1 via apt"},
+		{Provenance{Source: ProvenanceImport, Installation: "inst_1"}, "stackmatch import inst_1"},
+		{Provenance{Source: "dnf-module:nodejs:18", Manager: TypeDnf, Package: "nodejs"}, "dnf-module:nodejs:18, owned by dnf"},
+	}
+
+	for _, tc := range testCases {
+		if actual := tc.provenance.String(); actual != tc.expected {
+			t.Errorf("expected %q but got %q", tc.expected, actual)
+		}
+	}
+}
@@ -1,19 +1,170 @@
 package types
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
 // EnvironmentData represents the top-level structure for the scanned environment.
 // This is the structure that will be serialized to/from JSON.
 type EnvironmentData struct {
+	// SchemaVersion is 0 for files written before schema versioning was added.
+	SchemaVersion     int               `json:"schema_version,omitempty"`
 	StackmatchVersion string            `json:"stackmatch_version"`
 	ScanDate          time.Time         `json:"scan_date"`
-	System            SystemInfo        `json:"system"`
+	System            SystemInfo        `json:"system,omitzero"`
 	Tools             map[string]string `json:"tools,omitempty"`
 	PackageManagers   map[string]string `json:"package_managers,omitempty"`
 	CodeEditors       map[string]string `json:"code_editors,omitempty"`
 	// ConfiguredLanguages stores detected programming languages and their primary versions.
 	ConfiguredLanguages map[string]string `json:"configured_languages,omitempty"`
-	ConfigFiles         []string          `json:"config_files,omitempty"`
+	// LanguageVersions lists, oldest first, every version of a language
+	// found installed side by side, such as python3.11 next to python3.12,
+	// keyed by language. Only languages found in more than one version are
+	// listed; ConfiguredLanguages keeps the version that runs first.
+	LanguageVersions map[string][]string `json:"language_versions,omitempty"`
+	ConfigFiles      []string            `json:"config_files,omitempty"`
+	// ConfigFileInfo describes each file of ConfigFiles, with a hash of its
+	// contents. ConfigFiles is still written for older releases.
+	ConfigFileInfo []ConfigFileInfo `json:"config_file_info,omitempty"`
+	// ToolIDs maps the display names above to canonical tool IDs (see ToolInfo)
+	ToolIDs map[string]string `json:"tool_ids,omitempty"`
+	// ToolSources records how entries were installed when the scanner can
+	// tell, keyed by display name (see ToolInfo.Source)
+	ToolSources map[string]string `json:"tool_sources,omitempty"`
+	// Provenance records how the entries found on this machine got there,
+	// keyed by display name (see Provenance)
+	Provenance map[string]Provenance `json:"provenance,omitempty"`
+	// BrokenTools lists entries found on PATH whose version command failed,
+	// keyed by display name. Their versions are recorded as "Installed".
+	BrokenTools map[string]ToolFailure `json:"broken_tools,omitempty"`
+	// Requirements classifies entries as required or optional, keyed by
+	// display name. Entries not listed are unclassified.
+	Requirements map[string]Requirement `json:"requirements,omitempty"`
+	// Aliases lists, keyed by display name, the other names an entry was
+	// reported under before Reconcile collapsed them (see AliasGroup)
+	Aliases map[string][]string `json:"aliases,omitempty"`
+	// ScheduledJobs lists the user's crontab entries or scheduled tasks. Only
+	// scans run with the scheduled job scanner enabled fill it in.
+	ScheduledJobs []ScheduledJob `json:"scheduled_jobs,omitempty"`
+	// GitConfig holds the URL rewrites and credential helpers of the user's
+	// global git config
+	GitConfig *GitConfig `json:"git_config,omitempty"`
+	// LanguageConfig holds the toolchain settings that decide where packages
+	// are installed and fetched from, such as GOPATH and the npm prefix
+	LanguageConfig LanguageConfig `json:"language_config,omitempty"`
+	// VersionManagers lists the versions installed by each language version
+	// manager found, such as pyenv or nvm, keyed by manager. asdf and sdkman
+	// versions are recorded as tool@version. Import ignores them.
+	VersionManagers map[string][]string `json:"version_managers,omitempty"`
+	// Python records the Python environment managers found and which
+	// interpreter python3 resolves to
+	Python *PythonEnvironment `json:"python,omitempty"`
+	// Node records whether corepack provides yarn and pnpm and, in project
+	// scans, the package manager the project pins
+	Node *NodeEnvironment `json:"node,omitempty"`
+	// Conda records the conda installation found, with its environments
+	Conda *CondaInstallation `json:"conda,omitempty"`
+	// ShellSetup records the shell frameworks, plugin managers and prompt
+	// tools found, such as "oh-my-zsh" or "starship", with their version or
+	// "Installed". The plugins and theme a framework's config file lists are
+	// recorded next to it, space separated, such as "oh-my-zsh:plugins" =
+	// "git docker" and "oh-my-zsh:theme" = "robbyrussell" (prezto lists
+	// "prezto:modules" and oh-my-fish "oh-my-fish:packages").
+	ShellSetup map[string]string `json:"shell_setup,omitempty"`
+	// GlobalPackages holds the packages installed globally through a
+	// language's package manager, keyed by manager (such as "npm") and then
+	// by package name
+	GlobalPackages map[string]map[string]string `json:"global_packages,omitempty"`
+	// Services lists the developer services, such as databases, set to start
+	// at login or boot through brew services, systemd or the Windows service
+	// manager
+	Services []Service `json:"services,omitempty"`
+	// RunningServices maps the development services found accepting
+	// connections on well-known local ports, such as "postgresql", to their
+	// version, or "Running" when it could not be told. Only scans run with
+	// the service probe enabled fill it in.
+	RunningServices map[string]string `json:"running_services,omitempty"`
+	// EnvVars holds the environment variables that decide where toolchains
+	// and SDKs are found, such as JAVA_HOME, keyed by name. Values that
+	// look secret are masked with "[REDACTED]".
+	EnvVars map[string]string `json:"env_vars,omitempty"`
+	// Containers records the Docker images present and the containers
+	// running, when docker is installed
+	Containers *Containers `json:"containers,omitempty"`
+	// GPU records the NVIDIA driver, CUDA toolkit, cuDNN and ROCm found,
+	// with the GPUs. Nil on machines with none of them.
+	GPU *GPU `json:"gpu,omitempty"`
+	// Kubernetes records the kubectl contexts of the kubeconfig and the
+	// servers of their clusters. Nil when there is no kubeconfig.
+	Kubernetes *Kubernetes `json:"kubernetes,omitempty"`
+	// CloudProfiles lists the profiles configured for each cloud CLI, keyed
+	// by CLI (see CloudAWS), in name order. Only names are recorded, never
+	// keys, tokens or account IDs.
+	CloudProfiles map[string][]string `json:"cloud_profiles,omitempty"`
+	// SSHKeys lists the SSH public keys of ~/.ssh by type, size and
+	// fingerprint, in file name order. Key material is never recorded.
+	SSHKeys []SSHKeyInfo `json:"ssh_keys,omitempty"`
+	// SSHAgent is set when an ssh-agent answered during the scan
+	SSHAgent bool `json:"ssh_agent,omitempty"`
+	// Mobile records the Android SDK and Flutter found. Nil on machines
+	// with neither.
+	Mobile *Mobile `json:"mobile,omitempty"`
+	// Targets adjusts the entries above for other platforms, keyed by
+	// "os/arch" such as "darwin/arm64" or by "os" alone. Import merges the
+	// target matching the local platform before planning (see ForPlatform).
+	Targets map[string]*PlatformTarget `json:"targets,omitempty"`
+	// Profile names the export profile the environment was filtered with,
+	// if any (see 'stackmatch config profiles')
+	Profile string `json:"profile,omitempty"`
+	// Project is set when the scan was run against a specific project directory.
+	Project *ProjectInfo `json:"project,omitempty"`
+	// FastScan is set on scans that read versions from package databases
+	// instead of running each tool (see 'scan --fast')
+	FastScan *FastScan `json:"fast_scan,omitempty"`
+	// ManifestScan is set on scans limited to the entries of another
+	// environment (see 'scan --manifest')
+	ManifestScan *ManifestScan `json:"manifest_scan,omitempty"`
+	// Homebrew lists every Homebrew installation found, primary first.
+	Homebrew []HomebrewInstall `json:"homebrew,omitempty"`
+	// Warnings are problems found while scanning that did not stop the scan.
+	Warnings []string `json:"warnings,omitempty"`
+	// Incomplete is set on the documents of a scan that was interrupted,
+	// and on those sent while a scan is in progress: categories not yet
+	// scanned are missing from them.
+	Incomplete bool `json:"incomplete,omitempty"`
+	// Summary holds per-category counts and a fingerprint. Use BuildSummary or
+	// RefreshSummary rather than filling it in by hand.
+	Summary *Summary `json:"summary,omitempty"`
+	// Extensions holds categories this release does not know, such as those
+	// added by newer releases or custom detectors, keyed by their JSON name.
+	// They are written back at the top level unchanged; see extensions.go.
+	Extensions map[string]map[string]string `json:"-"`
+}
+
+// ProjectInfo describes the project directory a scan was run against.
+type ProjectInfo struct {
+	Path string `json:"path"`
+	// BuildWrappers maps a build tool (e.g. "Gradle") to the version pinned by the
+	// project's wrapper, which is the version builds actually use.
+	BuildWrappers map[string]string `json:"build_wrappers,omitempty"`
+	// Manifests lists the project files found, such as go.mod, package.json
+	// or Dockerfile, as slash-separated paths relative to Path.
+	Manifests []string `json:"manifests,omitempty"`
+	// Requirements maps a language or tool (e.g. "Node.js") to the version
+	// constraint the project asks for in .nvmrc, package.json engines, go.mod
+	// and the like.
+	Requirements map[string]string `json:"requirements,omitempty"`
+}
+
+// HomebrewInstall describes one Homebrew installation. Macs migrated from
+// Intel often have both /usr/local and /opt/homebrew.
+type HomebrewInstall struct {
+	Prefix string `json:"prefix"`
+	// Arch is the architecture the installation targets (e.g. "arm64", "x86_64")
+	Arch string `json:"arch,omitempty"`
+	// Primary is set on the installation whose brew comes first on PATH
+	Primary bool `json:"primary,omitempty"`
 }
 
 // SystemInfo holds basic information about the operating system and architecture.
@@ -22,6 +173,30 @@ type SystemInfo struct {
 	Arch        string `json:"arch"`
 	Shell       string `json:"shell,omitempty"`
 	Hostname    string `json:"hostname,omitempty"` // Added Hostname as it's often useful
+	// OSName is the Linux distribution or the macOS or Windows edition, such
+	// as "Ubuntu" or "Windows 11 Pro"
+	OSName        string `json:"os_name,omitempty"`
+	OSVersion     string `json:"os_version,omitempty"`
+	KernelVersion string `json:"kernel_version,omitempty"`
+	// IsWSL is set when the scan ran inside the Windows Subsystem for Linux
+	IsWSL bool `json:"is_wsl,omitempty"`
+	// WSLDistro is the WSL distribution's name, such as "Ubuntu-22.04"
+	WSLDistro string `json:"wsl_distro,omitempty"`
+	// Libc is the C library Linux programs are built against, LibcGlibc or
+	// LibcMusl (as on Alpine), or "" when it could not be told
+	Libc string `json:"libc,omitempty"`
+}
+
+// C libraries recorded in SystemInfo.Libc
+const (
+	LibcGlibc = "glibc"
+	LibcMusl  = "musl"
+)
+
+// Release returns the OS name and version, such as "Ubuntu 20.04", or "" for
+// files written before they were recorded
+func (s SystemInfo) Release() string {
+	return strings.TrimSpace(s.OSName + " " + s.OSVersion)
 }
 
 // EnvironmentHistory represents a version history entry for an environment
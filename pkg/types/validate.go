@@ -0,0 +1,209 @@
+package types
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/MRQ67/stackmatch-cli/pkg/version"
+)
+
+// ValidationIssue is a problem found in an environment file
+type ValidationIssue struct {
+	// Path is a JSON pointer (RFC 6901) to the offending value; empty for
+	// the whole document
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+func (i ValidationIssue) String() string {
+	path := i.Path
+	if path == "" {
+		path = "(document)"
+	}
+	return path + ": " + i.Message
+}
+
+// JSONPointer builds a JSON pointer from unescaped reference tokens
+func JSONPointer(tokens ...string) string {
+	var b strings.Builder
+	for _, token := range tokens {
+		token = strings.ReplaceAll(token, "~", "~0")
+		token = strings.ReplaceAll(token, "/", "~1")
+		b.WriteString("/" + token)
+	}
+	return b.String()
+}
+
+// knownOS are the operating systems the scanner reports
+var knownOS = map[string]bool{"darwin": true, "linux": true, "windows": true, "freebsd": true}
+
+// Validate checks rules the schema cannot express, such as a scan date in
+// the future or a summary that no longer matches the data. None of them
+// stop the file from being imported.
+func (e *EnvironmentData) Validate() []ValidationIssue {
+	var issues []ValidationIssue
+	add := func(path, format string, args ...interface{}) {
+		issues = append(issues, ValidationIssue{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if e.SchemaVersion > CurrentSchemaVersion {
+		add("/schema_version", "schema version %d is newer than this release supports (%d)", e.SchemaVersion, CurrentSchemaVersion)
+	}
+	if _, err := version.Parse(e.StackmatchVersion); err != nil {
+		add("/stackmatch_version", "%q is not a version number", e.StackmatchVersion)
+	}
+
+	switch {
+	case e.ScanDate.IsZero():
+		add("/scan_date", "scan date is missing")
+	case e.ScanDate.After(time.Now().Add(24 * time.Hour)):
+		add("/scan_date", "scan date %s is in the future", e.ScanDate.Format(time.RFC3339))
+	}
+
+	switch {
+	case e.System.OS == "":
+		add("/system/os", "operating system is missing")
+	case !knownOS[e.System.OS]:
+		add("/system/os", "unknown operating system %q", e.System.OS)
+	}
+
+	categories := []struct {
+		key     string
+		entries map[string]string
+	}{
+		{"configured_languages", e.ConfiguredLanguages},
+		{"tools", e.Tools},
+		{"package_managers", e.PackageManagers},
+		{"code_editors", e.CodeEditors},
+	}
+	names := make(map[string]bool)
+	for _, category := range categories {
+		for _, name := range sortedNames(category.entries) {
+			names[name] = true
+			if strings.TrimSpace(category.entries[name]) == "" {
+				add(JSONPointer(category.key, name), "version is empty; use \"Installed\" when it is unknown")
+			}
+		}
+	}
+	for _, category := range ExtensionCategories(e) {
+		for name := range e.Extensions[category] {
+			names[name] = true
+		}
+	}
+
+	for _, name := range sortedNames(e.ToolIDs) {
+		if !names[name] {
+			add(JSONPointer("tool_ids", name), "no entry is named %q", name)
+		}
+	}
+	for _, name := range sortedNames(e.ToolSources) {
+		if !names[name] {
+			add(JSONPointer("tool_sources", name), "no entry is named %q", name)
+		}
+	}
+	for _, name := range sortedNames(e.BrokenTools) {
+		if !names[name] {
+			add(JSONPointer("broken_tools", name), "no entry is named %q", name)
+		}
+	}
+	for _, name := range sortedNames(e.Requirements) {
+		if !names[name] {
+			add(JSONPointer("requirements", name), "no entry is named %q", name)
+		}
+	}
+	for _, name := range sortedNames(e.Aliases) {
+		if !names[name] {
+			add(JSONPointer("aliases", name), "no entry is named %q", name)
+		}
+	}
+
+	seen := make(map[string]int)
+	for i, file := range e.ConfigFiles {
+		if first, ok := seen[file]; ok {
+			add(fmt.Sprintf("/config_files/%d", i), "duplicate of /config_files/%d", first)
+			continue
+		}
+		seen[file] = i
+	}
+	for i, info := range e.ConfigFileInfo {
+		if _, ok := seen[info.Path]; !ok {
+			add(fmt.Sprintf("/config_file_info/%d/path", i), "%s is not in config_files", info.Path)
+		}
+	}
+
+	primary := -1
+	for i, install := range e.Homebrew {
+		if !install.Primary {
+			continue
+		}
+		if primary >= 0 {
+			add(fmt.Sprintf("/homebrew/%d/primary", i), "/homebrew/%d is already marked primary", primary)
+			continue
+		}
+		primary = i
+	}
+
+	for _, key := range sortedNames(e.Targets) {
+		e.validateTarget(key, add)
+	}
+
+	if e.Summary != nil {
+		fresh := BuildSummary(e)
+		if e.Summary.Fingerprint != fresh.Fingerprint || e.Summary.OS != fresh.OS || !sameCounts(e.Summary.Counts, fresh.Counts) {
+			add("/summary", "summary does not match the data; it is recomputed when the file is read")
+		}
+	}
+
+	return issues
+}
+
+func sortedNames[V any](m map[string]V) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// validateTarget checks that the target keyed by key names a platform and
+// only changes entries the base environment has
+func (e *EnvironmentData) validateTarget(key string, add func(path, format string, args ...interface{})) {
+	if !validTargetKey(key) {
+		add(JSONPointer("targets", key), "%q is not an os/arch platform such as \"darwin/arm64\" or \"linux\"", key)
+	}
+	target := e.Targets[key]
+	if target == nil {
+		return
+	}
+	removed := make(map[string]bool)
+	for i, name := range target.Remove {
+		if e.targetEntries(name) == nil {
+			add(JSONPointer("targets", key, "remove", fmt.Sprint(i)), "no entry is named %q", name)
+		}
+		removed[name] = true
+	}
+	for _, from := range sortedNames(target.Rename) {
+		to := target.Rename[from]
+		switch {
+		case e.targetEntries(from) == nil:
+			add(JSONPointer("targets", key, "rename", from), "no entry is named %q", from)
+		case removed[from]:
+			add(JSONPointer("targets", key, "rename", from), "%q is also removed", from)
+		case strings.TrimSpace(to) == "":
+			add(JSONPointer("targets", key, "rename", from), "new name is empty")
+		case to != from && e.targetEntries(to) != nil && !removed[to]:
+			add(JSONPointer("targets", key, "rename", from), "%q would replace the entry already named %q", from, to)
+		}
+	}
+	// The schema rejects categories not in TargetCategories
+	for _, category := range sortedNames(target.Add) {
+		for _, name := range sortedNames(target.Add[category]) {
+			if strings.TrimSpace(target.Add[category][name]) == "" {
+				add(JSONPointer("targets", key, "add", category, name), "version is empty; use \"Installed\" when it is unknown")
+			}
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package types
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestValidate(t *testing.T) {
+	valid := func() EnvironmentData {
+		env := EnvironmentData{
+			SchemaVersion:     CurrentSchemaVersion,
+			StackmatchVersion: "0.3.0",
+			ScanDate:          time.Date(2026, 10, 16, 9, 12, 44, 0, time.UTC),
+			System:            SystemInfo{OS: "linux", Arch: "amd64"},
+			Tools:             map[string]string{"Git": "2.45.0", "Make": "Installed"},
+			CodeEditors:       map[string]string{"VS Code": "1.89.1"},
+			ToolIDs:           map[string]string{"VS Code": "vscode"},
+			ConfigFiles:       []string{"/home/dev/.gitconfig"},
+		}
+		RefreshSummary(&env)
+		return env
+	}
+
+	testCases := []struct {
+		name     string
+		modify   func(env *EnvironmentData)
+		expected []ValidationIssue
+	}{
+		{name: "Valid environment", modify: func(env *EnvironmentData) {}},
+		{
+			name: "Newer schema and bad version",
+			modify: func(env *EnvironmentData) {
+				env.SchemaVersion = CurrentSchemaVersion + 1
+				env.StackmatchVersion = "latest"
+			},
+			expected: []ValidationIssue{
+				{Path: "/schema_version", Message: "schema version 3 is newer than this release supports (2)"},
+				{Path: "/stackmatch_version", Message: `"latest" is not a version number`},
+			},
+		},
+		{
+			name: "Scan date and OS",
+			modify: func(env *EnvironmentData) {
+				env.ScanDate = time.Date(2999, 1, 1, 0, 0, 0, 0, time.UTC)
+				env.System.OS = "plan9"
+				RefreshSummary(env)
+			},
+			expected: []ValidationIssue{
+				{Path: "/scan_date", Message: "scan date 2999-01-01T00:00:00Z is in the future"},
+				{Path: "/system/os", Message: `unknown operating system "plan9"`},
+			},
+		},
+		{
+			name: "Entries",
+			modify: func(env *EnvironmentData) {
+				env.Tools["Docker"] = " "
+				env.ToolIDs["Atom"] = "atom"
+				env.ToolSources = map[string]string{"Node.js": "dnf-module:nodejs:18"}
+				env.Requirements = map[string]Requirement{"Git": Required, "Vim": Optional}
+				env.Aliases = map[string][]string{"Git": {"git"}, "Python": {"Python 3"}}
+				env.ConfigFiles = append(env.ConfigFiles, "/home/dev/.npmrc", "/home/dev/.gitconfig")
+				env.ConfigFileInfo = []ConfigFileInfo{{Path: "/home/dev/.npmrc"}, {Path: "/home/dev/.yarnrc"}}
+				env.Homebrew = []HomebrewInstall{{Prefix: "/opt/homebrew", Primary: true}, {Prefix: "/usr/local", Primary: true}}
+				RefreshSummary(env)
+			},
+			expected: []ValidationIssue{
+				{Path: "/tools/Docker", Message: `version is empty; use "Installed" when it is unknown`},
+				{Path: "/tool_ids/Atom", Message: `no entry is named "Atom"`},
+				{Path: "/tool_sources/Node.js", Message: `no entry is named "Node.js"`},
+				{Path: "/requirements/Vim", Message: `no entry is named "Vim"`},
+				{Path: "/aliases/Python", Message: `no entry is named "Python"`},
+				{Path: "/config_files/2", Message: "duplicate of /config_files/0"},
+				{Path: "/config_file_info/1/path", Message: "/home/dev/.yarnrc is not in config_files"},
+				{Path: "/homebrew/1/primary", Message: "/homebrew/0 is already marked primary"},
+			},
+		},
+		{
+			name: "Platform targets",
+			modify: func(env *EnvironmentData) {
+				env.Targets = map[string]*PlatformTarget{
+					"darwin/arm64": {
+						Add:    map[string]map[string]string{"package_managers": {"Homebrew": "4.2.0", "MacPorts": " "}},
+						Remove: []string{"Make", "Vim"},
+						Rename: map[string]string{"Make": "GNU Make", "Atom": "Pulsar", "Git": "VS Code"},
+					},
+					"linux":  {Rename: map[string]string{"VS Code": "Code - OSS"}},
+					"amiga/": {},
+				}
+			},
+			expected: []ValidationIssue{
+				{Path: "/targets/amiga~1", Message: `"amiga/" is not an os/arch platform such as "darwin/arm64" or "linux"`},
+				{Path: "/targets/darwin~1arm64/remove/1", Message: `no entry is named "Vim"`},
+				{Path: "/targets/darwin~1arm64/rename/Atom", Message: `no entry is named "Atom"`},
+				{Path: "/targets/darwin~1arm64/rename/Git", Message: `"Git" would replace the entry already named "VS Code"`},
+				{Path: "/targets/darwin~1arm64/rename/Make", Message: `"Make" is also removed`},
+				{Path: "/targets/darwin~1arm64/add/package_managers/MacPorts", Message: `version is empty; use "Installed" when it is unknown`},
+			},
+		},
+		{
+			name:     "Stale summary",
+			modify:   func(env *EnvironmentData) { env.Tools["Git"] = "2.46.0" },
+			expected: []ValidationIssue{{Path: "/summary", Message: "summary does not match the data; it is recomputed when the file is read"}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			env := valid()
+			tc.modify(&env)
+			if issues := env.Validate(); !reflect.DeepEqual(issues, tc.expected) {
+				t.Errorf("expected %v but got %v", tc.expected, issues)
+			}
+		})
+	}
+}
+
+func TestJSONPointer(t *testing.T) {
+	if got := JSONPointer("tools", "a/b~c"); got != "/tools/a~1b~0c" {
+		t.Errorf("expected /tools/a~1b~0c but got %q", got)
+	}
+	if got := JSONPointer(); got != "" {
+		t.Errorf("expected the empty root pointer but got %q", got)
+	}
+	if got := (ValidationIssue{Message: "expected object but got array"}).String(); got != "(document): expected object but got array" {
+		t.Errorf("expected the root to be shown as (document) but got %q", got)
+	}
+}
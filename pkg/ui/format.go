@@ -0,0 +1,110 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// sizeUnits are the binary units HumanSize steps through
+var sizeUnits = []string{"B", "KiB", "MiB", "GiB", "TiB"}
+
+// HumanSize formats a byte count with binary units, such as "1023 B" or
+// "1.5 MiB". The output does not depend on the locale.
+func HumanSize(bytes int64) string {
+	if bytes < 0 {
+		return "-" + HumanSize(-bytes)
+	}
+	if bytes < 1024 {
+		return strconv.FormatInt(bytes, 10) + " B"
+	}
+	value := float64(bytes)
+	unit := 0
+	// Step up while the value would print as 1024.0 or more
+	for value >= 1024-0.05 && unit < len(sizeUnits)-1 {
+		value /= 1024
+		unit++
+	}
+	return strconv.FormatFloat(value, 'f', 1, 64) + " " + sizeUnits[unit]
+}
+
+// HumanDuration formats d with its two largest units, such as "2h 5m",
+// "3d 4h" or "42s". Durations under a second are shown in milliseconds, and
+// negative durations, such as the time left on an expired session, get a
+// leading "-".
+func HumanDuration(d time.Duration) string {
+	if d < 0 {
+		return "-" + HumanDuration(-d)
+	}
+	const day = 24 * time.Hour
+	if d < time.Second {
+		return strconv.FormatInt(d.Milliseconds(), 10) + "ms"
+	}
+	// Units are picked after rounding, so 59.6s is "1m" rather than "60s"
+	if r := d.Round(time.Second); r < time.Minute {
+		return twoUnits(r, time.Second, "s", 0, "")
+	} else if r < time.Hour {
+		return twoUnits(r, time.Minute, "m", time.Second, "s")
+	}
+	if r := d.Round(time.Minute); r < day {
+		return twoUnits(r, time.Hour, "h", time.Minute, "m")
+	}
+	return twoUnits(d.Round(time.Hour), day, "d", time.Hour, "h")
+}
+
+// twoUnits formats d as a count of major units followed by the remainder in
+// minor units, leaving the remainder out when it is zero
+func twoUnits(d, major time.Duration, majorSuffix string, minor time.Duration, minorSuffix string) string {
+	s := strconv.FormatInt(int64(d/major), 10) + majorSuffix
+	if minor == 0 {
+		return s
+	}
+	if rest := (d % major) / minor; rest > 0 {
+		s += " " + strconv.FormatInt(int64(rest), 10) + minorSuffix
+	}
+	return s
+}
+
+// RelativeTime describes t relative to now, such as "3 days ago" or
+// "in 5 minutes"
+func RelativeTime(t time.Time) string {
+	return relativeTime(t, time.Now())
+}
+
+func relativeTime(t, now time.Time) string {
+	d := now.Sub(t)
+	future := d < 0
+	if future {
+		d = -d
+	}
+	if d < time.Minute {
+		return "just now"
+	}
+
+	const day = 24 * time.Hour
+	var amount string
+	switch {
+	case d < time.Hour:
+		amount = plural(int64(d/time.Minute), "minute")
+	case d < day:
+		amount = plural(int64(d/time.Hour), "hour")
+	case d < 30*day:
+		amount = plural(int64(d/day), "day")
+	case d < 365*day:
+		amount = plural(int64(d/(30*day)), "month")
+	default:
+		amount = plural(int64(d/(365*day)), "year")
+	}
+	if future {
+		return "in " + amount
+	}
+	return amount + " ago"
+}
+
+// plural formats n units, such as "1 day" or "3 days"
+func plural(n int64, unit string) string {
+	if n == 1 {
+		return fmt.Sprintf("1 %s", unit)
+	}
+	return fmt.Sprintf("%d %ss", n, unit)
+}
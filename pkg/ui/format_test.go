@@ -0,0 +1,76 @@
+package ui
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHumanSize(t *testing.T) {
+	testCases := []struct {
+		bytes    int64
+		expected string
+	}{
+		{0, "0 B"},
+		{1023, "1023 B"},
+		{1024, "1.0 KiB"},
+		{1536, "1.5 KiB"},
+		{1024*1024 - 1, "1.0 MiB"},
+		{1024 * 1024, "1.0 MiB"},
+		{5*1024*1024 + 300*1024, "5.3 MiB"},
+		{3 * 1024 * 1024 * 1024, "3.0 GiB"},
+		{-2048, "-2.0 KiB"},
+	}
+
+	for _, tc := range testCases {
+		if got := HumanSize(tc.bytes); got != tc.expected {
+			t.Errorf("HumanSize(%d): expected %q but got %q", tc.bytes, tc.expected, got)
+		}
+	}
+}
+
+func TestHumanDuration(t *testing.T) {
+	testCases := []struct {
+		duration time.Duration
+		expected string
+	}{
+		{0, "0ms"},
+		{850 * time.Millisecond, "850ms"},
+		{42 * time.Second, "42s"},
+		{59*time.Second + 600*time.Millisecond, "1m"},
+		{5*time.Minute + 30*time.Second, "5m 30s"},
+		{2*time.Hour + 5*time.Minute, "2h 5m"},
+		{2*time.Hour + 59*time.Minute + 50*time.Second, "3h"},
+		{76 * time.Hour, "3d 4h"},
+		{-5 * time.Minute, "-5m"},
+		{-(26*time.Hour + 10*time.Minute), "-1d 2h"},
+	}
+
+	for _, tc := range testCases {
+		if got := HumanDuration(tc.duration); got != tc.expected {
+			t.Errorf("HumanDuration(%s): expected %q but got %q", tc.duration, tc.expected, got)
+		}
+	}
+}
+
+func TestRelativeTime(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		t        time.Time
+		expected string
+	}{
+		{now.Add(-30 * time.Second), "just now"},
+		{now.Add(-time.Minute), "1 minute ago"},
+		{now.Add(-59 * time.Minute), "59 minutes ago"},
+		{now.Add(-3 * time.Hour), "3 hours ago"},
+		{now.Add(-3 * 24 * time.Hour), "3 days ago"},
+		{now.Add(-45 * 24 * time.Hour), "1 month ago"},
+		{now.Add(-800 * 24 * time.Hour), "2 years ago"},
+		{now.Add(5 * time.Minute), "in 5 minutes"},
+	}
+
+	for _, tc := range testCases {
+		if got := relativeTime(tc.t, now); got != tc.expected {
+			t.Errorf("relativeTime(%s): expected %q but got %q", tc.t, tc.expected, got)
+		}
+	}
+}
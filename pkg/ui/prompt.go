@@ -0,0 +1,171 @@
+package ui
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// maxAttempts bounds how often a prompt is repeated on unrecognized input
+const maxAttempts = 3
+
+// ErrNoAnswer is returned when a prompt gets no usable answer, either because
+// input ended with no default to fall back on or because every attempt was
+// rejected
+var ErrNoAnswer = errors.New("no valid answer given")
+
+// Prompter asks questions and reads whole-line answers. Answers are read
+// through one buffer, so every prompt of a command must share a Prompter or
+// input typed ahead (or piped in) is lost.
+type Prompter struct {
+	in  *bufio.Reader
+	out io.Writer
+	// fd is the terminal to read passwords from without echo, or -1 when
+	// input is not a terminal
+	fd int
+}
+
+// NewPrompter returns a Prompter reading from in and printing prompts to out
+func NewPrompter(in io.Reader, out io.Writer) *Prompter {
+	p := &Prompter{in: bufio.NewReader(in), out: out, fd: -1}
+	if f, ok := in.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+		p.fd = int(f.Fd())
+	}
+	return p
+}
+
+// stdin is the Prompter shared by every interactive command
+var stdin = NewPrompter(os.Stdin, os.Stdout)
+
+// Stdin returns the Prompter reading standard input, for flows that pass
+// it around rather than calling Ask and Confirm
+func Stdin() *Prompter {
+	return stdin
+}
+
+// Interactive reports whether standard input and standard output are both
+// terminals, so that questions can be asked and are seen
+func Interactive() bool {
+	return term.IsTerminal(int(os.Stdin.Fd())) && term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+// StdoutTerminal reports whether standard output is a terminal, so that
+// output meant for people can be shown instead of output meant for programs
+func StdoutTerminal() bool {
+	return term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+// Confirm asks the user for confirmation on standard input
+func Confirm(prompt string, defaultYes bool) (bool, error) {
+	return stdin.Confirm(prompt, defaultYes)
+}
+
+// Ask asks the user for a line of text on standard input
+func Ask(prompt, def string, validate func(string) error) (string, error) {
+	return stdin.Ask(prompt, def, validate)
+}
+
+// AskPassword asks the user for a password on standard input
+func AskPassword(prompt string, validate func(string) error) (string, error) {
+	return stdin.AskPassword(prompt, validate)
+}
+
+// Confirm asks a yes/no question. An empty answer or the end of input picks
+// the default. Only the first word counts, so "yes please" is yes.
+// Unrecognized answers are asked again a few times before giving up.
+func (p *Prompter) Confirm(prompt string, defaultYes bool) (bool, error) {
+	options := " [y/N] "
+	if defaultYes {
+		options = " [Y/n] "
+	}
+
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		fmt.Fprint(p.out, Info("%s ", symbols.Question)+prompt+options)
+		line, err := p.readLine()
+		if errors.Is(err, io.EOF) {
+			fmt.Fprintln(p.out)
+			return defaultYes, nil
+		}
+		if err != nil {
+			return false, err
+		}
+
+		fields := strings.Fields(strings.ToLower(line))
+		if len(fields) == 0 {
+			return defaultYes, nil
+		}
+		switch fields[0] {
+		case "y", "yes":
+			return true, nil
+		case "n", "no":
+			return false, nil
+		}
+		fmt.Fprintln(p.out, "Please answer yes or no.")
+	}
+	return false, fmt.Errorf("%w after %d attempts", ErrNoAnswer, maxAttempts)
+}
+
+// Ask prints prompt and returns the answer, trimmed. An empty answer or the
+// end of input picks def when it is set. Answers rejected by validate, which
+// may be nil, are reported and asked again a few times before giving up.
+func (p *Prompter) Ask(prompt, def string, validate func(string) error) (string, error) {
+	return p.ask(prompt, def, validate, p.readLine)
+}
+
+// AskPassword is like Ask without a default, and does not echo the answer
+// when reading from a terminal
+func (p *Prompter) AskPassword(prompt string, validate func(string) error) (string, error) {
+	read := p.readLine
+	if p.fd >= 0 {
+		read = func() (string, error) {
+			password, err := term.ReadPassword(p.fd)
+			fmt.Fprintln(p.out)
+			return string(password), err
+		}
+	}
+	return p.ask(prompt, "", validate, read)
+}
+
+func (p *Prompter) ask(prompt, def string, validate func(string) error, read func() (string, error)) (string, error) {
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		fmt.Fprint(p.out, prompt)
+		answer, err := read()
+		if errors.Is(err, io.EOF) {
+			fmt.Fprintln(p.out)
+			if def != "" {
+				return def, nil
+			}
+			return "", fmt.Errorf("%w: input ended", ErrNoAnswer)
+		}
+		if err != nil {
+			return "", err
+		}
+
+		if answer == "" && def != "" {
+			return def, nil
+		}
+		if validate != nil {
+			if err := validate(answer); err != nil {
+				fmt.Fprintln(p.out, err)
+				continue
+			}
+		}
+		return answer, nil
+	}
+	return "", fmt.Errorf("%w after %d attempts", ErrNoAnswer, maxAttempts)
+}
+
+// readLine returns the next line of input, trimmed. A last line without a
+// newline is returned as is; io.EOF is returned only when nothing was left.
+func (p *Prompter) readLine() (string, error) {
+	line, err := p.in.ReadString('\n')
+	if errors.Is(err, io.EOF) && line != "" {
+		err = nil
+	}
+	return strings.TrimSpace(line), err
+}
@@ -0,0 +1,120 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestConfirm(t *testing.T) {
+	testCases := []struct {
+		name       string
+		input      string
+		defaultYes bool
+		expected   bool
+		wantErr    bool
+		prompts    int
+	}{
+		{name: "Yes", input: "y\n", expected: true, prompts: 1},
+		{name: "No with default yes", input: "NO\n", defaultYes: true, expected: false, prompts: 1},
+		{name: "Multi-word answer", input: "yes please\n", expected: true, prompts: 1},
+		{name: "Empty answer picks default", input: "\n", defaultYes: true, expected: true, prompts: 1},
+		{name: "EOF picks default", input: "", defaultYes: true, expected: true, prompts: 1},
+		{name: "Last line without newline", input: "y", expected: true, prompts: 1},
+		{name: "Garbage then yes", input: "maybe\ny\n", expected: true, prompts: 2},
+		{name: "Garbage then EOF", input: "maybe\n", defaultYes: true, expected: true, prompts: 2},
+		{name: "Too much garbage", input: "a\nb\nc\ny\n", wantErr: true, prompts: maxAttempts},
+		{name: "Windows line endings", input: "n\r\n", defaultYes: true, expected: false, prompts: 1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var out strings.Builder
+			p := NewPrompter(strings.NewReader(tc.input), &out)
+			got, err := p.Confirm("Continue?", tc.defaultYes)
+			if tc.wantErr {
+				if !errors.Is(err, ErrNoAnswer) {
+					t.Fatalf("expected ErrNoAnswer but got %v", err)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			} else if got != tc.expected {
+				t.Errorf("expected %v but got %v", tc.expected, got)
+			}
+			if prompts := strings.Count(out.String(), "Continue?"); prompts != tc.prompts {
+				t.Errorf("expected %d prompts but got %d: %q", tc.prompts, prompts, out.String())
+			}
+		})
+	}
+}
+
+// A multi-word answer must not leave words behind for the next prompt
+func TestPromptsShareInput(t *testing.T) {
+	p := NewPrompter(strings.NewReader("yes please\nmy env\nn\n"), io.Discard)
+
+	if ok, err := p.Confirm("Continue?", false); err != nil || !ok {
+		t.Fatalf("expected yes but got %v, %v", ok, err)
+	}
+	if name, err := p.Ask("Name: ", "", nil); err != nil || name != "my env" {
+		t.Fatalf("expected %q but got %q, %v", "my env", name, err)
+	}
+	if ok, err := p.Confirm("Public?", true); err != nil || ok {
+		t.Fatalf("expected no but got %v, %v", ok, err)
+	}
+}
+
+func TestAsk(t *testing.T) {
+	notEmpty := func(answer string) error {
+		if answer == "" {
+			return fmt.Errorf("answer must not be empty")
+		}
+		return nil
+	}
+
+	testCases := []struct {
+		name     string
+		input    string
+		def      string
+		validate func(string) error
+		expected string
+		wantErr  bool
+	}{
+		{name: "Answer is trimmed", input: "  dev laptop \n", expected: "dev laptop"},
+		{name: "Empty answer picks default", input: "\n", def: "Environment", expected: "Environment"},
+		{name: "EOF picks default", input: "", def: "Environment", expected: "Environment"},
+		{name: "EOF without default", input: "", wantErr: true},
+		{name: "Rejected then accepted", input: "\nok\n", validate: notEmpty, expected: "ok"},
+		{name: "Rejected too often", input: "\n\n\nok\n", validate: notEmpty, wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var out strings.Builder
+			p := NewPrompter(strings.NewReader(tc.input), &out)
+			got, err := p.Ask("Name: ", tc.def, tc.validate)
+			if tc.wantErr {
+				if !errors.Is(err, ErrNoAnswer) {
+					t.Fatalf("expected ErrNoAnswer but got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tc.expected {
+				t.Errorf("expected %q but got %q", tc.expected, got)
+			}
+		})
+	}
+}
+
+// Passwords piped in rather than typed are read as lines
+func TestAskPasswordFromPipe(t *testing.T) {
+	p := NewPrompter(strings.NewReader("secret123\n"), io.Discard)
+	got, err := p.AskPassword("Password: ", nil)
+	if err != nil || got != "secret123" {
+		t.Errorf("expected secret123 but got %q, %v", got, err)
+	}
+}
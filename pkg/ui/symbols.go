@@ -0,0 +1,88 @@
+package ui
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+// SymbolSet is the symbols that mark messages, prompts and progress
+type SymbolSet struct {
+	Success  string
+	Failure  string
+	Warning  string
+	Info     string
+	Wait     string
+	Question string
+	Bullet   string
+	// Arrow joins the old and new value of a change
+	Arrow string
+}
+
+// unicodeSymbols are printed on terminals that can show UTF-8
+var unicodeSymbols = SymbolSet{
+	Success:  "✓",
+	Failure:  "✗",
+	Warning:  "!",
+	Info:     "ℹ",
+	Wait:     "⌛",
+	Question: "❔",
+	Bullet:   "•",
+	Arrow:    "→",
+}
+
+// asciiSymbols stand in for unicodeSymbols on terminals without UTF-8, and
+// for screen readers, which read them out more usefully than the glyphs
+var asciiSymbols = SymbolSet{
+	Success:  "[OK]",
+	Failure:  "[FAIL]",
+	Warning:  "[!]",
+	Info:     "[i]",
+	Wait:     "[WAIT]",
+	Question: "[?]",
+	Bullet:   "-",
+	Arrow:    "->",
+}
+
+// symbols is the set in use
+var symbols = unicodeSymbols
+
+func init() {
+	SetASCII(DetectASCII(os.Getenv))
+}
+
+// Symbols returns the symbols in use, so that messages printed outside this
+// package match the ones printed through it
+func Symbols() SymbolSet {
+	return symbols
+}
+
+// SetASCII switches between the ASCII and the Unicode symbols
+func SetASCII(ascii bool) {
+	symbols = unicodeSymbols
+	if ascii {
+		symbols = asciiSymbols
+	}
+}
+
+// DetectASCII reports whether the environment read through getenv asks for
+// ASCII symbols. STACKMATCH_ASCII=1 or =0 decides; otherwise ASCII is used
+// when the locale names a character set other than UTF-8, as the C and
+// POSIX locales do. An unset locale, as on Windows, keeps Unicode.
+func DetectASCII(getenv func(string) string) bool {
+	if value := getenv("STACKMATCH_ASCII"); value != "" {
+		if ascii, err := strconv.ParseBool(value); err == nil {
+			return ascii
+		}
+	}
+	// LC_ALL overrides LC_CTYPE, which overrides LANG
+	for _, name := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		locale := getenv(name)
+		if locale == "" {
+			continue
+		}
+		charset := strings.ToLower(locale)
+		return !strings.Contains(charset, "utf-8") && !strings.Contains(charset, "utf8")
+	}
+	return false
+}
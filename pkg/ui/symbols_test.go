@@ -0,0 +1,102 @@
+package ui
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var updateGolden = flag.Bool("update", false, "Rewrite the golden files in testdata")
+
+// Run 'go test ./pkg/ui -run TestSymbolsGolden -update' to rewrite the
+// golden files after a deliberate change, and review the diff.
+func TestSymbolsGolden(t *testing.T) {
+	for _, mode := range []struct {
+		name  string
+		ascii bool
+	}{
+		{name: "unicode", ascii: false},
+		{name: "ascii", ascii: true},
+	} {
+		t.Run(mode.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			saved, savedOut, savedErr := symbols, stdout, stderr
+			defer func() { symbols, stdout, stderr = saved, savedOut, savedErr }()
+			SetASCII(mode.ascii)
+			stdout, stderr = &buf, &buf
+
+			PrintSuccess("Installed %s", "git")
+			PrintError(errors.New("exit status 100"), "Failed to install %s", "docker")
+			PrintWarning("Skipping %s", "nvm")
+			PrintInfo("%d package(s) to install", 3)
+			NewSpinner("Scanning")
+			fmt.Fprintln(&buf)
+			bar := NewProgressBar(4, "Installing")
+			bar.current = 2
+			fmt.Fprintln(&buf, bar.line())
+			fmt.Fprintf(&buf, "%s Detecting tools...\n", Symbols().Bullet)
+			if _, err := NewPrompter(strings.NewReader("y\n"), &buf).Confirm("Proceed?", false); err != nil {
+				t.Fatal(err)
+			}
+			fmt.Fprintln(&buf)
+
+			golden := filepath.Join("testdata", "symbols", mode.name+".golden")
+			if *updateGolden {
+				if err := os.WriteFile(golden, buf.Bytes(), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			expected, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("could not read %s (run with -update to create it): %v", golden, err)
+			}
+			if buf.String() != string(expected) {
+				t.Errorf("expected:\n%s\nbut got:\n%s", expected, buf.String())
+			}
+		})
+	}
+}
+
+func TestSymbolsASCIIOnly(t *testing.T) {
+	for _, symbol := range []string{asciiSymbols.Success, asciiSymbols.Failure, asciiSymbols.Warning, asciiSymbols.Info, asciiSymbols.Wait, asciiSymbols.Question, asciiSymbols.Bullet, asciiSymbols.Arrow} {
+		for _, r := range symbol {
+			if r > 127 {
+				t.Errorf("expected ASCII symbols but got %q", symbol)
+			}
+		}
+	}
+}
+
+func TestDetectASCII(t *testing.T) {
+	testCases := []struct {
+		name     string
+		env      map[string]string
+		expected bool
+	}{
+		{name: "nothing set", env: map[string]string{}, expected: false},
+		{name: "UTF-8 locale", env: map[string]string{"LANG": "en_US.UTF-8"}, expected: false},
+		{name: "utf8 spelling", env: map[string]string{"LANG": "de_DE.utf8"}, expected: false},
+		{name: "C locale", env: map[string]string{"LANG": "C"}, expected: true},
+		{name: "POSIX locale", env: map[string]string{"LC_ALL": "POSIX"}, expected: true},
+		{name: "Latin-1 locale", env: map[string]string{"LANG": "en_US.ISO-8859-1"}, expected: true},
+		{name: "LC_ALL overrides LANG", env: map[string]string{"LC_ALL": "C", "LANG": "en_US.UTF-8"}, expected: true},
+		{name: "LC_CTYPE overrides LANG", env: map[string]string{"LC_CTYPE": "en_US.UTF-8", "LANG": "C"}, expected: false},
+		{name: "forced on", env: map[string]string{"STACKMATCH_ASCII": "1", "LANG": "en_US.UTF-8"}, expected: true},
+		{name: "forced off", env: map[string]string{"STACKMATCH_ASCII": "false", "LANG": "C"}, expected: false},
+		{name: "unrecognized value", env: map[string]string{"STACKMATCH_ASCII": "maybe", "LANG": "C"}, expected: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			getenv := func(name string) string { return tc.env[name] }
+			if actual := DetectASCII(getenv); actual != tc.expected {
+				t.Errorf("expected %v but got %v", tc.expected, actual)
+			}
+		})
+	}
+}
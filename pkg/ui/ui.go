@@ -2,10 +2,17 @@ package ui
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"strings"
 )
 
+// stdout and stderr receive everything printed by this package
+var (
+	stdout io.Writer = os.Stdout
+	stderr io.Writer = os.Stderr
+)
+
 // ANSI color codes
 const (
 	colorReset  = "\033[0m"
@@ -52,31 +59,6 @@ func Info(format string, a ...interface{}) string {
 	return colorize(fmt.Sprintf(format, a...), colorBlue)
 }
 
-// Confirm asks the user for confirmation
-func Confirm(prompt string, defaultYes bool) (bool, error) {
-	var options string
-	if defaultYes {
-		options = " [Y/n] "
-	} else {
-		options = " [y/N] "
-	}
-
-	fmt.Print(Info("❔ ") + prompt + options)
-
-	var response string
-	_, err := fmt.Scanln(&response)
-	if err != nil && err.Error() != "unexpected newline" {
-		return false, err
-	}
-
-	response = strings.TrimSpace(strings.ToLower(response))
-	if response == "" {
-		return defaultYes, nil
-	}
-
-	return response == "y" || response == "yes", nil
-}
-
 // ProgressBar is a simple progress bar implementation
 type ProgressBar struct {
 	total   int
@@ -106,6 +88,11 @@ func (p *ProgressBar) Render() {
 		return
 	}
 
+	fmt.Fprint(stderr, "\r"+p.line())
+}
+
+// line returns the progress bar as rendered
+func (p *ProgressBar) line() string {
 	width := 30
 	percent := float64(p.current) / float64(p.total)
 	filled := int(float64(width) * percent)
@@ -114,19 +101,19 @@ func (p *ProgressBar) Render() {
 		strings.Repeat(" ", width-filled) + "] " +
 		fmt.Sprintf("%d/%d", p.current, p.total)
 
-	fmt.Fprintf(os.Stderr, "\r%s %s %s", "⌛", p.desc, bar)
+	return fmt.Sprintf("%s %s %s", symbols.Wait, p.desc, bar)
 }
 
 // Close finishes the progress bar
 func (p *ProgressBar) Close() {
 	if isTerminal() {
-		fmt.Fprintln(os.Stderr)
+		fmt.Fprintln(stderr)
 	}
 }
 
 // PrintSuccess prints a success message
 func PrintSuccess(format string, a ...interface{}) {
-	fmt.Fprintln(os.Stdout, Success("✓")+" "+fmt.Sprintf(format, a...))
+	fmt.Fprintln(stdout, Success("%s", symbols.Success)+" "+fmt.Sprintf(format, a...))
 }
 
 // PrintError prints an error message
@@ -135,17 +122,17 @@ func PrintError(err error, format string, a ...interface{}) {
 	if err != nil {
 		msg = fmt.Sprintf("%s: %v", msg, err)
 	}
-	fmt.Fprintln(os.Stderr, Error("✗")+" "+msg)
+	fmt.Fprintln(stderr, Error("%s", symbols.Failure)+" "+msg)
 }
 
 // PrintWarning prints a warning message
 func PrintWarning(format string, a ...interface{}) {
-	fmt.Fprintln(os.Stdout, Warning("!")+" "+fmt.Sprintf(format, a...))
+	fmt.Fprintln(stdout, Warning("%s", symbols.Warning)+" "+fmt.Sprintf(format, a...))
 }
 
 // PrintInfo prints an info message
 func PrintInfo(format string, a ...interface{}) {
-	fmt.Fprintln(os.Stdout, Info("ℹ")+" "+fmt.Sprintf(format, a...))
+	fmt.Fprintln(stdout, Info("%s", symbols.Info)+" "+fmt.Sprintf(format, a...))
 }
 
 // Spinner is a simple spinner implementation
@@ -155,13 +142,13 @@ type Spinner struct {
 
 // NewSpinner creates a new spinner
 func NewSpinner(msg string) *Spinner {
-	fmt.Fprintf(os.Stderr, "⌛ %s... ", msg)
+	fmt.Fprintf(stderr, "%s %s... ", symbols.Wait, msg)
 	return &Spinner{msg: msg}
 }
 
 // Close finishes the spinner
 func (s *Spinner) Close() {
 	if isTerminal() {
-		fmt.Fprint(os.Stderr, "\r"+strings.Repeat(" ", len(s.msg)+5)+"\r")
+		fmt.Fprint(stderr, "\r"+strings.Repeat(" ", len(symbols.Wait)+len(s.msg)+5)+"\r")
 	}
 }
@@ -0,0 +1,122 @@
+package version
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Bounds returns the range of versions constraint allows: at least lower and
+// below upper, with nil for an open bound. Partial versions stand for every
+// version they start, so "18", "18.x" and "=18" are all >=18.0.0 <19.0.0.
+// Exclusions ("!=1.2.3") don't narrow the range, and pre-releases are
+// ignored. Constraints no version satisfies are an error.
+func Bounds(constraint string) (lower, upper *Version, err error) {
+	constraint = strings.TrimSpace(constraint)
+	if constraint == "" || strings.EqualFold(constraint, "Installed") {
+		return nil, nil, nil
+	}
+
+	for _, text := range splitClauses(constraint) {
+		low, high, err := clauseBounds(text)
+		if err != nil {
+			return nil, nil, err
+		}
+		if low != nil && (lower == nil || low.Compare(lower) > 0) {
+			lower = low
+		}
+		if high != nil && (upper == nil || high.Compare(upper) < 0) {
+			upper = high
+		}
+	}
+	if lower != nil && upper != nil && lower.Compare(upper) >= 0 {
+		return nil, nil, fmt.Errorf("no version satisfies %q", constraint)
+	}
+	return lower, upper, nil
+}
+
+// clauseBounds returns the range of versions one clause of a constraint allows
+func clauseBounds(text string) (lower, upper *Version, err error) {
+	switch {
+	case text == "*" || text == "x" || text == "X":
+		return nil, nil, nil
+	case strings.Contains(text, " - "):
+		parts := strings.SplitN(text, " - ", 2)
+		if lower, _, err = partialVersion(parts[0]); err != nil {
+			return nil, nil, err
+		}
+		v, components, err := partialVersion(parts[1])
+		if err != nil {
+			return nil, nil, err
+		}
+		return lower, nextVersion(v, components), nil
+	case strings.HasPrefix(text, "^") || strings.HasPrefix(text, "~"):
+		low, high, err := expandRange(text, parseNormalized)
+		if err != nil {
+			return nil, nil, err
+		}
+		lower, _ = Parse(low)
+		upper, _ = Parse(high)
+		return lower, upper, nil
+	case strings.ContainsAny(text, "xX*"):
+		// Only the components before the first wildcard count, so 1.2.x is 1.2
+		prefix := strings.FieldsFunc(strings.TrimLeft(text, "="), func(r rune) bool { return r == 'x' || r == 'X' || r == '*' })
+		if len(prefix) == 0 {
+			return nil, nil, nil
+		}
+		v, components, err := partialVersion(strings.TrimSuffix(prefix[0], "."))
+		if err != nil {
+			return nil, nil, err
+		}
+		return v, nextVersion(v, components), nil
+	}
+
+	op, target := "=", text
+	for _, candidate := range operators[:6] {
+		if strings.HasPrefix(text, candidate) {
+			op, target = candidate, strings.TrimSpace(text[len(candidate):])
+			break
+		}
+	}
+	v, components, err := partialVersion(target)
+	if err != nil {
+		return nil, nil, err
+	}
+	switch op {
+	case ">=":
+		return v, nil, nil
+	case ">":
+		return nextVersion(v, components), nil, nil
+	case "<":
+		return nil, v, nil
+	case "<=":
+		return nil, nextVersion(v, components), nil
+	case "!=":
+		return nil, nil, nil
+	}
+	return v, nextVersion(v, components), nil
+}
+
+// partialVersion parses a possibly partial version such as "18" or "3.12",
+// returning how many components it has
+func partialVersion(s string) (*Version, int, error) {
+	normalized, _ := Normalize(s)
+	v, err := Parse(normalized)
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid version %q in constraint: %s", s, parseProblem(normalized))
+	}
+	components := len(strings.Split(partialRegex.FindString(strings.TrimPrefix(normalized, "v")), "."))
+	return &Version{Major: v.Major, Minor: v.Minor, Patch: v.Patch}, components, nil
+}
+
+// nextVersion returns the first version after every version v stands for
+// with that many components: 19.0.0 for 18, 3.13.0 for 3.12 and 1.2.4 for
+// 1.2.3
+func nextVersion(v *Version, components int) *Version {
+	switch components {
+	case 1:
+		return &Version{Major: v.Major + 1}
+	case 2:
+		return &Version{Major: v.Major, Minor: v.Minor + 1}
+	}
+	return &Version{Major: v.Major, Minor: v.Minor, Patch: v.Patch + 1}
+}
@@ -0,0 +1,95 @@
+package version
+
+import "testing"
+
+func TestBounds(t *testing.T) {
+	testCases := []struct {
+		constraint    string
+		lower         string
+		upper         string
+		expectedError string
+	}{
+		{constraint: "18.19.0", lower: "18.19.0", upper: "18.19.1"},
+		{constraint: "18", lower: "18.0.0", upper: "19.0.0"},
+		{constraint: "=18", lower: "18.0.0", upper: "19.0.0"},
+		{constraint: "3.12", lower: "3.12.0", upper: "3.13.0"},
+		{constraint: "=3.12", lower: "3.12.0", upper: "3.13.0"},
+		{constraint: ">=18 <19", lower: "18.0.0", upper: "19.0.0"},
+		{constraint: ">= 18, < 20", lower: "18.0.0", upper: "20.0.0"},
+		{constraint: ">3.11 <=3.12", lower: "3.12.0", upper: "3.13.0"},
+		{constraint: "^3.11", lower: "3.11.0", upper: "4.0.0"},
+		{constraint: "~3.11.4", lower: "3.11.4", upper: "3.12.0"},
+		{constraint: "18.x", lower: "18.0.0", upper: "19.0.0"},
+		{constraint: "3.12.*", lower: "3.12.0", upper: "3.13.0"},
+		{constraint: "1.2 - 1.4", lower: "1.2.0", upper: "1.5.0"},
+		{constraint: ">=20 !=20.1.0", lower: "20.0.0"},
+		{constraint: "<19", upper: "19.0.0"},
+		{constraint: "v20.11.0", lower: "20.11.0", upper: "20.11.1"},
+		{constraint: "Installed"},
+		{constraint: "*"},
+		{constraint: ">=19 <18", expectedError: `no version satisfies ">=19 <18"`},
+		{constraint: ">=latest", expectedError: `invalid version "latest" in constraint: "latest" does not start with a number`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.constraint, func(t *testing.T) {
+			lower, upper, err := Bounds(tc.constraint)
+			if tc.expectedError != "" {
+				if err == nil || err.Error() != tc.expectedError {
+					t.Errorf("expected error %q but got %v", tc.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error but got %v", err)
+			}
+			if s := versionString(lower); s != tc.lower {
+				t.Errorf("expected lower bound %q but got %q", tc.lower, s)
+			}
+			if s := versionString(upper); s != tc.upper {
+				t.Errorf("expected upper bound %q but got %q", tc.upper, s)
+			}
+		})
+	}
+}
+
+// versionString returns v as a string, or "" for an open bound
+func versionString(v *Version) string {
+	if v == nil {
+		return ""
+	}
+	return v.String()
+}
+
+// TestBoundsAgreesWithExplain makes sure a version import resolves a package
+// for by its bounds is one validation and check, which call Explain, accept
+func TestBoundsAgreesWithExplain(t *testing.T) {
+	testCases := []struct {
+		installed  string
+		constraint string
+	}{
+		{"18.20.0", "18"},
+		{"19.0.0", "18"},
+		{"18.0.0", "=18"},
+		{"17.9.1", "=18"},
+		{"3.12.4", "3.12"},
+		{"3.13.0", "3.12"},
+		{"18.19.0", "18.19.0"},
+		{"18.19.1", "18.19.0"},
+	}
+
+	for _, tc := range testCases {
+		lower, upper, err := Bounds(tc.constraint)
+		if err != nil {
+			t.Fatalf("unexpected error for %q: %v", tc.constraint, err)
+		}
+		v, err := Parse(tc.installed)
+		if err != nil {
+			t.Fatalf("failed to parse version %q: %v", tc.installed, err)
+		}
+		within := (lower == nil || v.Compare(lower) >= 0) && (upper == nil || v.Compare(upper) < 0)
+		if explained := Explain(tc.installed, tc.constraint); explained.Satisfied != within {
+			t.Errorf("%s against %q: Bounds gives [%v, %v) but Explain says %v", tc.installed, tc.constraint, lower, upper, explained.Satisfied)
+		}
+	}
+}
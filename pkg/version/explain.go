@@ -0,0 +1,388 @@
+package version
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Explanation traces how an installed version was checked against a wanted
+// constraint, so users can see exactly what was compared
+type Explanation struct {
+	// Raw is the installed version as recorded
+	Raw string `json:"raw"`
+	// Normalized is Raw after Normalizations were applied
+	Normalized string `json:"normalized"`
+	// Normalizations describes each change made to Raw
+	Normalizations []string `json:"normalizations,omitempty"`
+	// Parsed holds the components of Normalized; nil when it is not a version
+	Parsed *Version `json:"parsed,omitempty"`
+	// ParseError explains why Normalized is not a version
+	ParseError string `json:"parse_error,omitempty"`
+	// Constraint is the wanted version or constraint
+	Constraint string `json:"constraint"`
+	// Clauses are the parts of Constraint, all of which must hold
+	Clauses []Clause `json:"clauses,omitempty"`
+	// Note explains results decided without comparing versions
+	Note      string `json:"note,omitempty"`
+	Satisfied bool   `json:"satisfied"`
+}
+
+// Clause is one part of a constraint, such as ">=1.22" in ">=1.22 <1.23"
+type Clause struct {
+	// Text is the clause as written
+	Text string `json:"text"`
+	// Comparisons are what the clause stands for; "^2.38" is ">=2.38.0" and
+	// "<3.0.0". Wildcard patterns have none.
+	Comparisons []Comparison `json:"comparisons,omitempty"`
+	// Pattern is the wildcard pattern the version was matched against
+	Pattern   string `json:"pattern,omitempty"`
+	Error     string `json:"error,omitempty"`
+	Satisfied bool   `json:"satisfied"`
+}
+
+// Comparison is a single comparison of the installed version with a target
+type Comparison struct {
+	Op        string `json:"op"`
+	Target    string `json:"target"`
+	Satisfied bool   `json:"satisfied"`
+}
+
+var (
+	epochRegex    = regexp.MustCompile(`^(\d+):`)
+	prefixRegex   = regexp.MustCompile(`^(v|go)(\d)`)
+	updateRegex   = regexp.MustCompile(`^(\d+(?:\.\d+)*)_(\d+)`)
+	extraRegex    = regexp.MustCompile(`^(\d+\.\d+\.\d+)\.([0-9A-Za-z.]+)`)
+	revisionRegex = regexp.MustCompile(`^(\d+(?:\.\d+){0,2})-(\d+[A-Za-z.~][0-9A-Za-z.~]*)$`)
+	partialRegex  = regexp.MustCompile(`^v?\d+(?:\.\d+){0,2}`)
+)
+
+// Normalize rewrites vendor version strings into the form Parse accepts and
+// describes each change. Epochs ("1:2.38.1") are dropped, "go" and "v"
+// prefixes removed, and Java updates ("1.8.0_362"), components beyond
+// major.minor.patch ("2.43.0.windows.1") and distribution package revisions
+// ("2.38.1-1ubuntu1") become build metadata. Comparisons only look at build
+// metadata when the wanted version has some, so "2.38.1-1ubuntu1" satisfies
+// "2.38.1" but "1.8.0_362" does not satisfy "1.8.0_400".
+func Normalize(raw string) (string, []string) {
+	v := strings.TrimSpace(raw)
+	var steps []string
+
+	if m := epochRegex.FindStringSubmatch(v); m != nil {
+		v = v[len(m[0]):]
+		steps = append(steps, fmt.Sprintf("dropped epoch %q", m[0]))
+	}
+	if m := prefixRegex.FindStringSubmatch(v); m != nil {
+		v = v[len(m[1]):]
+		steps = append(steps, fmt.Sprintf("dropped prefix %q", m[1]))
+	}
+	if m := updateRegex.FindStringSubmatch(v); m != nil {
+		v = m[1] + "+" + m[2] + v[len(m[0]):]
+		steps = append(steps, fmt.Sprintf("treated update %q as build metadata", "_"+m[2]))
+	}
+	if m := extraRegex.FindStringSubmatch(v); m != nil {
+		rest := v[len(m[0]):]
+		v = m[1] + appendBuild(rest, m[2])
+		steps = append(steps, fmt.Sprintf("treated %q beyond major.minor.patch as build metadata", "."+m[2]))
+	}
+	if m := revisionRegex.FindStringSubmatch(v); m != nil {
+		v = m[1] + "+" + m[2]
+		steps = append(steps, fmt.Sprintf("treated package revision %q as build metadata, not a pre-release", "-"+m[2]))
+	}
+	return v, steps
+}
+
+// appendBuild adds build to the build metadata at the start of rest, if any
+func appendBuild(rest, build string) string {
+	if strings.HasPrefix(rest, "+") {
+		return "+" + build + "." + rest[1:]
+	}
+	return "+" + build + rest
+}
+
+// parseProblem explains why s is not a version
+func parseProblem(s string) string {
+	switch {
+	case s == "":
+		return "the version is empty"
+	case strings.EqualFold(s, "Installed"):
+		return `"Installed" records that the tool is present, not which version it is`
+	}
+	prefix := partialRegex.FindString(s)
+	if prefix == "" {
+		return fmt.Sprintf("%q does not start with a number", s)
+	}
+	return fmt.Sprintf("unexpected %q after %q", s[len(prefix):], prefix)
+}
+
+// Explain checks the installed version against constraint and records how.
+// Constraint may be an exact version, a partial one standing for every
+// version it starts ("18" is >=18.0.0 <19.0.0), a list of clauses that must
+// all hold (">=1.22 <1.23" or ">=1.22, <1.23"), caret and tilde ranges
+// ("^2.38", "~1.4"), hyphen ranges ("1.2 - 1.4"), wildcards ("1.2.x") or a
+// marker such as "Installed", which any installed version satisfies.
+func Explain(installed, constraint string) *Explanation {
+	e := &Explanation{Raw: installed, Constraint: constraint}
+	e.Normalized, e.Normalizations = Normalize(installed)
+
+	switch {
+	case constraint == "" || strings.EqualFold(constraint, "Installed"):
+		e.Note = "any installed version satisfies " + quoteOrEmpty(constraint)
+		e.Satisfied = true
+		return e
+	case installed == constraint:
+		e.Note = "the installed version is exactly the wanted one"
+		e.Satisfied = true
+		return e
+	}
+
+	v, err := Parse(e.Normalized)
+	if err != nil {
+		e.ParseError = parseProblem(e.Normalized)
+		e.Note = "the installed version could not be parsed, so it cannot satisfy a constraint"
+		return e
+	}
+	e.Parsed = v
+
+	e.Satisfied = true
+	for _, text := range splitClauses(constraint) {
+		clause := evaluateClause(v, text, parseNormalized)
+		e.Clauses = append(e.Clauses, clause)
+		e.Satisfied = e.Satisfied && clause.Satisfied
+	}
+	return e
+}
+
+// quoteOrEmpty quotes s, or names the empty constraint
+func quoteOrEmpty(s string) string {
+	if s == "" {
+		return "an empty constraint"
+	}
+	return fmt.Sprintf("%q", s)
+}
+
+// operators recognized at the start of a clause, longest first
+var operators = []string{">=", "<=", "!=", ">", "<", "=", "^", "~"}
+
+// splitClauses splits a constraint into clauses separated by commas or
+// spaces. Operators written apart from their version ("> = 1.2" aside) are
+// joined with it, and hyphen ranges are kept whole.
+func splitClauses(constraint string) []string {
+	constraint = strings.TrimSpace(constraint)
+	if strings.Contains(constraint, " - ") {
+		return []string{constraint}
+	}
+	var clauses []string
+	pending := ""
+	for _, field := range strings.Fields(strings.ReplaceAll(constraint, ",", " ")) {
+		if isOperator(field) {
+			pending += field
+			continue
+		}
+		clauses = append(clauses, pending+field)
+		pending = ""
+	}
+	if pending != "" {
+		clauses = append(clauses, pending)
+	}
+	return clauses
+}
+
+func isOperator(s string) bool {
+	for _, op := range operators {
+		if s == op {
+			return true
+		}
+	}
+	return false
+}
+
+// targetParser parses a version written in a constraint, returning the
+// version and, when it cannot be parsed, why not
+type targetParser func(s string) (*Version, string)
+
+// parseNormalized parses s after Normalize, as check does for wanted versions
+func parseNormalized(s string) (*Version, string) {
+	normalized, _ := Normalize(s)
+	v, err := Parse(normalized)
+	if err != nil {
+		return nil, parseProblem(normalized)
+	}
+	return v, ""
+}
+
+// parseExact parses s as written, as installers do for pinned versions, so a
+// pin such as "1.2.3.4" is rejected rather than folded into "1.2.3+4"
+func parseExact(s string) (*Version, string) {
+	v, err := Parse(s)
+	if err != nil {
+		return nil, parseProblem(s)
+	}
+	return v, ""
+}
+
+// evaluateClause checks v against one clause, parsing the versions it names
+// with parse
+func evaluateClause(v *Version, text string, parse targetParser) Clause {
+	clause := Clause{Text: text}
+
+	if text == "*" || text == "x" || text == "X" {
+		clause.Pattern = text
+		clause.Satisfied = true
+		return clause
+	}
+
+	var comparisons [][2]string
+	switch {
+	case strings.Contains(text, " - "):
+		parts := strings.SplitN(text, " - ", 2)
+		comparisons = [][2]string{{">=", parts[0]}, {"<=", parts[1]}}
+	case strings.HasPrefix(text, "^") || strings.HasPrefix(text, "~"):
+		lower, upper, err := expandRange(text, parse)
+		if err != nil {
+			clause.Error = err.Error()
+			return clause
+		}
+		comparisons = [][2]string{{">=", lower}, {"<", upper}}
+	case strings.ContainsAny(text, "xX*"):
+		clause.Pattern = text
+		ok, err := checkWildcardConstraint(v, text)
+		if err != nil {
+			clause.Error = err.Error()
+		}
+		clause.Satisfied = ok
+		return clause
+	default:
+		op, target := "=", text
+		for _, candidate := range operators[:6] {
+			if strings.HasPrefix(text, candidate) {
+				op, target = candidate, strings.TrimSpace(text[len(candidate):])
+				break
+			}
+		}
+		comparisons = [][2]string{{op, target}}
+		if op == "=" {
+			// A partial version stands for every version it starts, as in
+			// Bounds, so "18" is >=18.0.0 <19.0.0
+			if lower, upper, ok := partialRange(target); ok {
+				comparisons = [][2]string{{">=", lower}, {"<", upper}}
+			}
+		}
+	}
+
+	clause.Satisfied = true
+	for _, c := range comparisons {
+		target, problem := parse(c[1])
+		if target == nil {
+			clause.Error = fmt.Sprintf("invalid version %q in constraint: %s", c[1], problem)
+			clause.Satisfied = false
+			return clause
+		}
+		ok := compareWith(v, c[0], target)
+		clause.Comparisons = append(clause.Comparisons, Comparison{Op: c[0], Target: target.String(), Satisfied: ok})
+		clause.Satisfied = clause.Satisfied && ok
+	}
+	return clause
+}
+
+// partialRange returns the bounds of a version with fewer than three
+// components, such as "18" or "3.12", and false for any other version
+func partialRange(target string) (lower, upper string, ok bool) {
+	trimmed := strings.TrimPrefix(target, "v")
+	prefix := partialRegex.FindString(trimmed)
+	if prefix == "" || prefix != trimmed {
+		return "", "", false
+	}
+	v, components, err := partialVersion(trimmed)
+	if err != nil || components == 3 {
+		return "", "", false
+	}
+	return v.String(), nextVersion(v, components).String(), true
+}
+
+// compareWith applies op to v and target. Versions that differ only in build
+// metadata are ordered by it when target has some, so an update or vendor
+// component the user asked for is not ignored.
+func compareWith(v *Version, op string, target *Version) bool {
+	cmp := v.Compare(target)
+	if cmp == 0 && target.Build != "" {
+		cmp = compareBuild(v.Build, target.Build)
+	}
+	switch op {
+	case ">=":
+		return cmp >= 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case "<":
+		return cmp < 0
+	case "!=":
+		return cmp != 0
+	}
+	return cmp == 0
+}
+
+// compareBuild orders build metadata by its dot-separated identifiers,
+// numerically where both are numbers. Missing metadata sorts first.
+func compareBuild(a, b string) int {
+	if a == b {
+		return 0
+	}
+	if a == "" {
+		return -1
+	}
+	if b == "" {
+		return 1
+	}
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if as[i] == bs[i] {
+			continue
+		}
+		an, aErr := strconv.Atoi(as[i])
+		bn, bErr := strconv.Atoi(bs[i])
+		if aErr == nil && bErr == nil {
+			return compareInts(an, bn)
+		}
+		if as[i] < bs[i] {
+			return -1
+		}
+		return 1
+	}
+	return compareInts(len(as), len(bs))
+}
+
+// expandRange returns the bounds of a caret or tilde range. "^1.2.3" allows
+// changes that keep the leftmost non-zero component, "~1.2.3" allows patch
+// changes, and "~1" minor changes.
+func expandRange(text string, parse targetParser) (lower, upper string, err error) {
+	v, problem := parse(text[1:])
+	if v == nil {
+		return "", "", fmt.Errorf("invalid version %q in constraint: %s", text[1:], problem)
+	}
+	normalized, _ := Normalize(text[1:])
+	components := len(strings.Split(partialRegex.FindString(strings.TrimPrefix(normalized, "v")), "."))
+
+	next := Version{}
+	if text[0] == '^' {
+		switch {
+		case v.Major > 0 || components == 1:
+			next.Major = v.Major + 1
+		case v.Minor > 0 || components == 2:
+			next.Minor = v.Minor + 1
+		default:
+			next.Patch = v.Patch + 1
+		}
+	} else {
+		next.Major = v.Major
+		if components == 1 {
+			next.Major++
+		} else {
+			next.Minor = v.Minor + 1
+		}
+	}
+	base := Version{Major: v.Major, Minor: v.Minor, Patch: v.Patch, PreRelease: v.PreRelease}
+	return base.String(), next.String(), nil
+}
@@ -0,0 +1,137 @@
+package version
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var updateGolden = flag.Bool("update", false, "Rewrite the golden files in testdata")
+
+func TestExplain(t *testing.T) {
+	testCases := []struct {
+		name       string
+		installed  string
+		constraint string
+		satisfied  bool
+	}{
+		{name: "debian-epoch-caret", installed: "1:2.38.1-1ubuntu1", constraint: "^2.38", satisfied: true},
+		{name: "java-update", installed: "1.8.0_362", constraint: ">=11", satisfied: false},
+		{name: "go-prefix-range", installed: "go1.21.3", constraint: ">=1.22 <1.23", satisfied: false},
+		{name: "java-update-exact", installed: "1.8.0_362", constraint: "1.8.0_400", satisfied: false},
+		{name: "vendor-components-tilde", installed: "2.43.0.windows.1", constraint: "~2.43", satisfied: true},
+		{name: "vendor-components-exact", installed: "2.43.0.windows.1", constraint: "2.43.0.windows.2", satisfied: false},
+		{name: "fourth-component-exact", installed: "23.1.0.0", constraint: "23.1.0.5", satisfied: false},
+		{name: "fourth-component-minimum", installed: "23.1.0.5", constraint: ">=23.1.0.4", satisfied: true},
+		{name: "caret-zero-major", installed: "0.3.9", constraint: "^0.2.1", satisfied: false},
+		{name: "comma-separated", installed: "18.19.0", constraint: ">= 18, < 20", satisfied: true},
+		{name: "trailing-text", installed: "1.2.3beta", constraint: ">=1.2", satisfied: false},
+		{name: "marker-installed", installed: "Installed", constraint: ">=1.0", satisfied: false},
+		{name: "marker-wanted", installed: "3.12.1", constraint: "Installed", satisfied: true},
+		{name: "invalid-constraint", installed: "1.2.3", constraint: ">=latest", satisfied: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := Explain(tc.installed, tc.constraint)
+			if e.Satisfied != tc.satisfied {
+				t.Errorf("expected satisfied to be %v but got %v", tc.satisfied, e.Satisfied)
+			}
+
+			var buf bytes.Buffer
+			encoder := json.NewEncoder(&buf)
+			encoder.SetEscapeHTML(false)
+			encoder.SetIndent("", "  ")
+			if err := encoder.Encode(e); err != nil {
+				t.Fatal(err)
+			}
+			got := buf.Bytes()
+			golden := filepath.Join("testdata", "explain", tc.name+".json")
+			if *updateGolden {
+				if err := os.WriteFile(golden, got, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			expected, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("could not read %s (run with -update to create it): %v", golden, err)
+			}
+			if string(got) != string(expected) {
+				t.Errorf("explanation differs from %s:\n%s", golden, got)
+			}
+		})
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	testCases := []struct {
+		raw      string
+		expected string
+	}{
+		{"1:2.38.1-1ubuntu1", "2.38.1+1ubuntu1"},
+		{"1.8.0_362", "1.8.0+362"},
+		{"go1.22.3", "1.22.3"},
+		{"2.43.0.windows.1", "2.43.0+windows.1"},
+		{"5.14.0-362.el9", "5.14.0+362.el9"},
+		{"1.0.0-rc.1", "1.0.0-rc.1"},
+		{"1.0.0-1", "1.0.0-1"},
+		{" 3.12.1 ", "3.12.1"},
+	}
+
+	for _, tc := range testCases {
+		if got, _ := Normalize(tc.raw); got != tc.expected {
+			t.Errorf("Normalize(%q): expected %q but got %q", tc.raw, tc.expected, got)
+		}
+	}
+}
+
+// TestSatisfiesAgreesWithExplain makes sure the installers, which call
+// Satisfies, and check, which calls Explain, agree on every constraint
+func TestSatisfiesAgreesWithExplain(t *testing.T) {
+	testCases := []struct {
+		installed  string
+		constraint string
+	}{
+		{"2.38.1", "^2.38"},
+		{"2.39.0", "^2.38"},
+		{"3.0.0", "^2.38"},
+		{"0.2.5", "^0.2.1"},
+		{"0.3.0", "^0.2.1"},
+		{"1.2.0", "~1.2"},
+		{"1.2.9", "~1.2"},
+		{"1.3.0", "~1.2"},
+		{"1.4.2", "~1"},
+		{"1.22.0", ">=1.22 <1.23"},
+		{"1.22.7", ">=1.22, <1.23"},
+		{"1.23.0", ">=1.22 <1.23"},
+		{"1.21.9", ">= 1.22"},
+		{"18.19.0", "1.2 - 20"},
+		{"1.2.3", "1.2.x"},
+		{"1.3.0", "1.2.x"},
+		{"1.2.3", "!=1.2.3"},
+		{"3.12.1", "3.12.1"},
+		{"18.20.0", "18"},
+		{"19.0.0", "18"},
+		{"18.20.0", "=18"},
+		{"17.9.1", "=18"},
+		{"3.12.4", "3.12"},
+		{"3.13.0", "3.12"},
+		{"1.2.3", ">=latest"},
+		{"1.2.3", "^abc"},
+	}
+
+	for _, tc := range testCases {
+		v, err := Parse(tc.installed)
+		if err != nil {
+			t.Fatalf("failed to parse version %q: %v", tc.installed, err)
+		}
+		satisfied, err := v.Satisfies(tc.constraint)
+		explained := Explain(tc.installed, tc.constraint)
+		if satisfied != explained.Satisfied {
+			t.Errorf("%s against %q: Satisfies says %v (%v) but Explain says %v", tc.installed, tc.constraint, satisfied, err, explained.Satisfied)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package version
 
 import (
+	"errors"
 	"fmt"
 	"regexp"
 	"strconv"
@@ -9,11 +10,11 @@ import (
 
 // Version represents a semantic version (SemVer)
 type Version struct {
-	Major      int
-	Minor      int
-	Patch      int
-	PreRelease string
-	Build      string
+	Major      int    `json:"major"`
+	Minor      int    `json:"minor"`
+	Patch      int    `json:"patch"`
+	PreRelease string `json:"pre_release,omitempty"`
+	Build      string `json:"build,omitempty"`
 }
 
 var (
@@ -96,71 +97,27 @@ func (v *Version) Compare(other *Version) int {
 	return 0
 }
 
-// Satisfies checks if this version satisfies the given constraint
+// Satisfies checks if this version satisfies the given constraint. It
+// evaluates the constraint clause by clause, as Explain does, and returns
+// an error for a clause that cannot be evaluated. Unlike Explain, versions in
+// the constraint are taken as written, not normalized, so a pin Parse does not
+// accept is an error.
 func (v *Version) Satisfies(constraint string) (bool, error) {
 	// Handle empty constraint as "any version"
+	constraint = strings.TrimSpace(constraint)
 	if constraint == "" || constraint == "*" {
 		return true, nil
 	}
 
-	// Handle basic operators: =, >, <, >=, <=
-	for _, op := range []string{">=", "<=", ">", "<", "=", "!="} {
-		if strings.HasPrefix(constraint, op) {
-			verStr := strings.TrimSpace(constraint[len(op):])
-			other, err := Parse(verStr)
-			if err != nil {
-				return false, fmt.Errorf("invalid version in constraint: %w", err)
-			}
-
-			cmp := v.Compare(other)
-			switch op {
-			case ">=":
-				return cmp >= 0, nil
-			case "<=":
-				return cmp <= 0, nil
-			case ">":
-				return cmp > 0, nil
-			case "<":
-				return cmp < 0, nil
-			case "=":
-				return cmp == 0, nil
-			case "!=":
-				return cmp != 0, nil
-			}
-		}
-	}
-
-	// Handle version range (e.g., "1.2.3 - 2.3.4")
-	if strings.Contains(constraint, " - ") {
-		parts := strings.SplitN(constraint, " - ", 2)
-		if len(parts) != 2 {
-			return false, fmt.Errorf("invalid version range: %s", constraint)
-		}
-
-		lower, err := Parse(strings.TrimSpace(parts[0]))
-		if err != nil {
-			return false, fmt.Errorf("invalid lower bound in range: %w", err)
-		}
-
-		upper, err := Parse(strings.TrimSpace(parts[1]))
-		if err != nil {
-			return false, fmt.Errorf("invalid upper bound in range: %w", err)
+	satisfied := true
+	for _, text := range splitClauses(constraint) {
+		clause := evaluateClause(v, text, parseExact)
+		if clause.Error != "" {
+			return false, errors.New(clause.Error)
 		}
-
-		return v.Compare(lower) >= 0 && v.Compare(upper) <= 0, nil
-	}
-
-	// Handle wildcards (e.g., "1.2.x" or "1.*")
-	if strings.ContainsAny(constraint, "xX*^") {
-		return checkWildcardConstraint(v, constraint)
-	}
-
-	// Handle exact match
-	target, err := Parse(constraint)
-	if err != nil {
-		return false, fmt.Errorf("invalid version constraint: %w", err)
+		satisfied = satisfied && clause.Satisfied
 	}
-	return v.Compare(target) == 0, nil
+	return satisfied, nil
 }
 
 // checkWildcardConstraint handles version constraints with wildcards
@@ -115,9 +115,23 @@ func TestSatisfies(t *testing.T) {
 		{"1.2.3", "1.2.3-*", true, false},
 		{"1.2.3", "1.2.4-*", false, false},
 
+		// Caret and tilde ranges, and clauses that must all hold
+		{"2.39.1", "^2.38", true, false},
+		{"3.0.0", "^2.38", false, false},
+		{"1.2.9", "~1.2", true, false},
+		{"1.3.0", "~1.2", false, false},
+		{"1.22.5", ">=1.22 <1.23", true, false},
+		{"1.23.0", ">=1.22, <1.23", false, false},
+
+		// Partial versions stand for every version they start
+		{"18.20.0", "18", true, false},
+		{"19.0.0", "=18", false, false},
+		{"3.12.4", "3.12", true, false},
+
 		// Invalid constraints
 		{"1.2.3", "invalid", false, true},
 		{"1.2.3", "1.2.3.4", false, true},
+		{"1.2.3", ">=1.2 <abc", false, true},
 	}
 
 	for _, tc := range tests {